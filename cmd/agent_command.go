@@ -27,6 +27,7 @@ var (
 	agentLastSession           bool
 	agentPersona               string
 	agentDryRun                bool
+	agentNoCache               bool
 	maxIterations              int
 	agentNoStreaming           bool
 	agentShowReasoningTerminal bool
@@ -41,6 +42,9 @@ var (
 	agentNoConnectionCheck     bool
 	agentTraceDatasetDir       string
 	agentPromptStdin           bool
+	agentOutputFormat          string
+	agentRecordRun             bool
+	agentNoWatch               bool
 )
 
 // runStartupPermissionCheck performs a security check on config file permissions
@@ -70,6 +74,10 @@ func createChatAgent() (*agent.Agent, error) {
 	var chatAgent *agent.Agent
 	var err error
 
+	if err := checkOfflineProvider(agentProvider); err != nil {
+		return nil, err
+	}
+
 	if agentProvider != "" && agentModel != "" {
 		modelWithProvider := fmt.Sprintf("%s:%s", agentProvider, agentModel)
 		chatAgent, err = agent.NewAgentWithModel(modelWithProvider)
@@ -126,8 +134,9 @@ func init() {
 	agentCmd.Flags().StringVarP(&agentProvider, "provider", "p", "", "Provider to use (openai, chutes, openrouter, deepinfra, deepseek, zai, mistral, ollama, ollama-local, ollama-turbo, lmstudio, or custom providers)")
 	agentCmd.Flags().StringVar(&agentSessionID, "session-id", "", "Resume a specific session ID in the current working directory scope")
 	agentCmd.Flags().BoolVar(&agentLastSession, "last-session", false, "Resume the most recent session from the current working directory scope")
-	agentCmd.Flags().StringVar(&agentPersona, "persona", "", "Persona to activate at startup (e.g., general, coder, refactor, debugger, tester, code_reviewer, researcher, web_scraper)")
-	agentCmd.Flags().BoolVar(&agentDryRun, "dry-run", false, "Run tools in simulation mode (enhanced safety)")
+	agentCmd.Flags().StringVar(&agentPersona, "persona", "", "Persona to activate at startup (e.g., general, coder, refactor, debugger, tester, code_reviewer, security_reviewer, researcher, web_scraper)")
+	agentCmd.Flags().BoolVar(&agentDryRun, "dry-run", false, "Preview file writes, edits, git and non-read-only shell commands instead of applying them")
+	agentCmd.Flags().BoolVar(&agentNoCache, "no-cache", false, "Send every LLM request even when the response cache has an answer (or set LEDIT_NO_CACHE=1)")
 	agentCmd.Flags().IntVar(&maxIterations, "max-iterations", 0, "Maximum iterations per prompt before stopping (default: 0 = unlimited)")
 	agentCmd.Flags().BoolVar(&agentNoStreaming, "no-stream", false, "Disable streaming mode (useful for scripts and pipelines) (or set LEDIT_NO_STREAM=1)")
 	agentCmd.Flags().BoolVar(&agentShowReasoningTerminal, "show-reasoning-terminal", false, "Render reasoning stream chunks in terminal output (default: hidden; WebUI still receives reasoning)")
@@ -141,6 +150,9 @@ func init() {
 	agentCmd.Flags().StringVar(&agentWorkflowConfig, "workflow-config", "", "JSON file that defines agent workflow steps for non-interactive runs")
 	agentCmd.Flags().StringVar(&agentTraceDatasetDir, "trace-dataset-dir", "", "Enable dataset trace mode and write to directory (also settable via LEDIT_TRACE_DATASET_DIR env var)")
 	agentCmd.Flags().BoolVar(&agentPromptStdin, "prompt-stdin", false, "Read the prompt from stdin (avoids OS ARG_MAX limits for large prompts)")
+	agentCmd.Flags().BoolVar(&agentRecordRun, "record", false, "Record LLM exchanges, tool calls and file mutations to a replayable run archive (or set LEDIT_RECORD_RUN=1)")
+	agentCmd.Flags().BoolVar(&agentNoWatch, "no-watch", false, "Disable the workspace file watcher that refreshes context when files change on disk (interactive mode)")
+	agentCmd.Flags().StringVar(&agentOutputFormat, "output", agentOutputText, "Output format: text (default) or json (headless newline-delimited JSON events ending in a result record; exit code 0=success, 1=failure, 2=partial)")
 	_ = agentCmd.RegisterFlagCompletionFunc("persona", completePersonaFlag)
 
 	// Initialize environment-based defaults
@@ -157,6 +169,10 @@ func init() {
 		if os.Getenv("LEDIT_NO_SUBAGENTS") == "1" || os.Getenv("LEDIT_NO_SUBAGENTS") == "true" {
 			agentNoSubagents = true
 		}
+		// Check for LEDIT_RECORD_RUN environment variable
+		if os.Getenv("LEDIT_RECORD_RUN") == "1" || os.Getenv("LEDIT_RECORD_RUN") == "true" {
+			agentRecordRun = true
+		}
 		// Check for LEDIT_NO_CONNECTION_CHECK environment variable
 		if os.Getenv("LEDIT_NO_CONNECTION_CHECK") == "1" || os.Getenv("LEDIT_NO_CONNECTION_CHECK") == "true" {
 			agentNoConnectionCheck = true
@@ -260,9 +276,26 @@ Examples:
   ledit agent --last-session
 
   # Disable web UI
-  ledit agent --no-web-ui "Analyze this code"`,
+  ledit agent --no-web-ui "Analyze this code"
+
+  # Record a replayable run archive (replay with 'ledit replay <run-id>')
+  ledit agent --record "Fix the flaky test"
+
+  # Headless JSON event stream for CI (exit code 0=success, 1=failure, 2=partial)
+  ledit agent --output json "Fix the failing tests" > events.jsonl`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		outputFormat, err := validateAgentOutputFormat(agentOutputFormat)
+		if err != nil {
+			return err
+		}
+		jsonOut := os.Stdout
+		if outputFormat == agentOutputJSON {
+			// Reserve stdout for the JSON stream; human-readable output goes to stderr.
+			os.Stdout = os.Stderr
+			defer func() { os.Stdout = jsonOut }()
+		}
+
 		chatAgent, err := createChatAgent()
 		if err != nil {
 			return fmt.Errorf("failed to create chat agent: %w", err)
@@ -281,6 +314,15 @@ Examples:
 			fmt.Printf("Dataset tracing enabled: %s\n", traceSession.GetRunID())
 		}
 
+		if agentRecordRun {
+			recorder, err := agent.NewRunRecorder("", chatAgent.GetProvider(), chatAgent.GetModel())
+			if err != nil {
+				return fmt.Errorf("failed to initialize run recorder: %w", err)
+			}
+			chatAgent.EnableRunRecording(recorder)
+			defer saveRunRecording(chatAgent)
+		}
+
 		// Set unsafe mode if flag is provided
 		chatAgent.SetUnsafeMode(agentUnsafe)
 
@@ -312,6 +354,9 @@ Examples:
 		if agentDryRun {
 			_ = os.Setenv("LEDIT_DRY_RUN", "1")
 		}
+		if agentNoCache {
+			_ = os.Setenv("LEDIT_NO_CACHE", "1")
+		}
 		if agentNoConnectionCheck {
 			os.Setenv("LEDIT_SKIP_CONNECTION_CHECK", "1")
 		}
@@ -371,6 +416,16 @@ Examples:
 			stdinIsTerminal = false
 		}
 
+		if outputFormat == agentOutputJSON {
+			query := strings.Join(args, " ")
+			if code := runHeadlessJSON(chatAgent, query, jsonOut); code != exitCodeSuccess {
+				// Deferred calls do not run on os.Exit.
+				saveRunRecording(chatAgent)
+				os.Exit(code)
+			}
+			return nil
+		}
+
 		// We're interactive only if we have a terminal, no args, and not in CI
 		isInteractive := len(args) == 0 && !isCI && stdinIsTerminal
 
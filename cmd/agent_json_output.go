@@ -0,0 +1,170 @@
+// Headless JSON output mode for the agent command (ledit agent --output json)
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/alantheprice/ledit/pkg/agent"
+	"github.com/alantheprice/ledit/pkg/configuration"
+	"github.com/alantheprice/ledit/pkg/events"
+)
+
+const (
+	agentOutputText = "text"
+	agentOutputJSON = "json"
+
+	jsonStreamSubscriber = "json-output"
+	jsonResultEventType  = "result"
+)
+
+// Exit codes for headless runs.
+const (
+	exitCodeSuccess = 0
+	exitCodeFailure = 1
+	exitCodePartial = 2
+)
+
+// jsonStreamRecord is a single line of the headless JSON stream.
+type jsonStreamRecord struct {
+	Type      string    `json:"type"`
+	Timestamp time.Time `json:"timestamp"`
+	Data      any       `json:"data"`
+}
+
+// jsonEventStream writes event bus events to a writer as newline-delimited JSON.
+// It also remembers the last completed response so the final result can carry it.
+type jsonEventStream struct {
+	mu           sync.Mutex
+	encoder      *json.Encoder
+	done         chan struct{}
+	lastResponse string
+}
+
+func newJSONEventStream(w io.Writer) *jsonEventStream {
+	return &jsonEventStream{
+		encoder: json.NewEncoder(w),
+		done:    make(chan struct{}),
+	}
+}
+
+// start subscribes to the event bus and forwards every event until the
+// subscription is closed by stop.
+func (s *jsonEventStream) start(eventBus *events.EventBus) {
+	ch := eventBus.Subscribe(jsonStreamSubscriber)
+	go func() {
+		defer close(s.done)
+		for event := range ch {
+			s.observe(event)
+			s.write(jsonStreamRecord{Type: event.Type, Timestamp: event.Timestamp, Data: event.Data})
+		}
+	}()
+}
+
+// stop unsubscribes from the event bus and waits for buffered events to flush.
+func (s *jsonEventStream) stop(eventBus *events.EventBus) {
+	eventBus.Unsubscribe(jsonStreamSubscriber)
+	<-s.done
+}
+
+func (s *jsonEventStream) observe(event events.UIEvent) {
+	if event.Type != events.EventTypeQueryCompleted {
+		return
+	}
+	data, ok := event.Data.(map[string]interface{})
+	if !ok {
+		return
+	}
+	if response, ok := data["response"].(string); ok {
+		s.mu.Lock()
+		s.lastResponse = response
+		s.mu.Unlock()
+	}
+}
+
+func (s *jsonEventStream) response() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastResponse
+}
+
+func (s *jsonEventStream) write(record jsonStreamRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.encoder.Encode(record); err != nil {
+		fmt.Fprintf(os.Stderr, "[WARN] Failed to write JSON event %q: %v\n", record.Type, err)
+	}
+}
+
+// writeResult emits the terminal result record.
+func (s *jsonEventStream) writeResult(result agent.AgentResult) {
+	s.write(jsonStreamRecord{Type: jsonResultEventType, Timestamp: time.Now(), Data: result})
+}
+
+// exitCodeForResult maps a result status to the process exit code.
+func exitCodeForResult(result agent.AgentResult) int {
+	switch result.Status {
+	case agent.ResultStatusSuccess:
+		return exitCodeSuccess
+	case agent.ResultStatusPartial:
+		return exitCodePartial
+	default:
+		return exitCodeFailure
+	}
+}
+
+// validateAgentOutputFormat normalizes and validates the --output flag value.
+func validateAgentOutputFormat(format string) (string, error) {
+	normalized := strings.ToLower(strings.TrimSpace(format))
+	switch normalized {
+	case "", agentOutputText:
+		return agentOutputText, nil
+	case agentOutputJSON:
+		return agentOutputJSON, nil
+	default:
+		return "", fmt.Errorf("unsupported --output format %q (expected %q or %q)", format, agentOutputText, agentOutputJSON)
+	}
+}
+
+// runHeadlessJSON runs a single query without any interactive affordances and
+// writes a structured JSON event stream to jsonOut, ending with an AgentResult
+// record. Callers are expected to have redirected human-readable output away
+// from jsonOut. It returns the process exit code.
+func runHeadlessJSON(chatAgent *agent.Agent, query string, jsonOut io.Writer) int {
+	eventBus := events.NewEventBus()
+	stream := newJSONEventStream(jsonOut)
+	stream.start(eventBus)
+
+	ensureContinuationSessionID(chatAgent)
+	chatAgent.SetEventBus(eventBus)
+	SetupAgentEvents(chatAgent, eventBus)
+
+	startTime := time.Now()
+	runErr := chatAgent.GetConfigManager().UpdateConfigNoSave(func(cfg *configuration.Config) error {
+		cfg.SkipPrompt = true
+		return nil
+	})
+	if runErr == nil {
+		if strings.TrimSpace(query) == "" {
+			runErr = errors.New("--output json requires a prompt argument or --prompt-stdin")
+		} else {
+			ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+			runErr = ProcessQuery(ctx, chatAgent, eventBus, query)
+			stop()
+		}
+	}
+
+	stream.stop(eventBus)
+	result := chatAgent.BuildResult(stream.response(), runErr, time.Since(startTime))
+	stream.writeResult(result)
+	return exitCodeForResult(result)
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/alantheprice/ledit/pkg/agent"
+	"github.com/alantheprice/ledit/pkg/events"
+)
+
+func TestValidateAgentOutputFormat(t *testing.T) {
+	cases := map[string]string{
+		"":       agentOutputText,
+		"text":   agentOutputText,
+		" JSON ": agentOutputJSON,
+	}
+	for input, want := range cases {
+		got, err := validateAgentOutputFormat(input)
+		if err != nil {
+			t.Fatalf("validateAgentOutputFormat(%q) returned error: %v", input, err)
+		}
+		if got != want {
+			t.Fatalf("validateAgentOutputFormat(%q) = %q, want %q", input, got, want)
+		}
+	}
+
+	if _, err := validateAgentOutputFormat("yaml"); err == nil {
+		t.Fatal("expected error for unsupported output format")
+	}
+}
+
+func TestExitCodeForResult(t *testing.T) {
+	cases := map[string]int{
+		agent.ResultStatusSuccess: exitCodeSuccess,
+		agent.ResultStatusPartial: exitCodePartial,
+		agent.ResultStatusFailure: exitCodeFailure,
+		"unknown":                 exitCodeFailure,
+	}
+	for status, want := range cases {
+		if got := exitCodeForResult(agent.AgentResult{Status: status}); got != want {
+			t.Fatalf("exitCodeForResult(%q) = %d, want %d", status, got, want)
+		}
+	}
+}
+
+func TestJSONEventStreamWritesEventsAndResult(t *testing.T) {
+	var buf bytes.Buffer
+	eventBus := events.NewEventBus()
+	stream := newJSONEventStream(&buf)
+	stream.start(eventBus)
+
+	eventBus.Publish(events.EventTypeToolStart, map[string]interface{}{"tool_name": "read_file"})
+	eventBus.Publish(events.EventTypeQueryCompleted, map[string]interface{}{"response": "all done"})
+	stream.stop(eventBus)
+
+	if got := stream.response(); got != "all done" {
+		t.Fatalf("expected captured response %q, got %q", "all done", got)
+	}
+
+	stream.writeResult(agent.AgentResult{Status: agent.ResultStatusSuccess, Response: stream.response()})
+
+	var types []string
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var record map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("stream line is not valid JSON: %v (%s)", err, scanner.Text())
+		}
+		types = append(types, record["type"].(string))
+	}
+
+	want := []string{events.EventTypeToolStart, events.EventTypeQueryCompleted, jsonResultEventType}
+	if len(types) != len(want) {
+		t.Fatalf("expected %d records, got %d (%v)", len(want), len(types), types)
+	}
+	for i := range want {
+		if types[i] != want[i] {
+			t.Fatalf("record %d: expected type %q, got %q", i, want[i], types[i])
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strings"
 	"sync/atomic"
 	"syscall"
@@ -17,6 +18,7 @@ import (
 	"github.com/alantheprice/ledit/pkg/configuration"
 	"github.com/alantheprice/ledit/pkg/console"
 	"github.com/alantheprice/ledit/pkg/events"
+	"github.com/alantheprice/ledit/pkg/jobs"
 	"github.com/alantheprice/ledit/pkg/webui"
 	"golang.org/x/term"
 )
@@ -274,6 +276,13 @@ func RunAgent(chatAgent *agent.Agent, isInteractive bool, args []string) (err er
 		// Check if we should prompt for GitHub MCP setup (interactive, non-SkipPrompt)
 		promptGitHubMCPSetupIfNeeded(&AgentAdapter{agent: chatAgent})
 
+		// Keep workspace context fresh as files change in the editor or on disk
+		if !agentNoWatch {
+			if err := chatAgent.StartWorkspaceWatcher(ctx, chatAgent.GetWorkspaceRoot()); err != nil {
+				fmt.Fprintf(os.Stderr, "[WARN] Workspace watcher disabled: %v\n", err)
+			}
+		}
+
 		err = runInteractiveMode(ctx, chatAgent, eventBus)
 	} else {
 		if err := chatAgent.GetConfigManager().UpdateConfigNoSave(func(cfg *configuration.Config) error {
@@ -311,7 +320,13 @@ func RunAgent(chatAgent *agent.Agent, isInteractive bool, args []string) (err er
 		if query == "" && (workflowConfig == nil || len(workflowConfig.Steps) == 0) {
 			// No query provided - check if we should keep running (daemon mode)
 			if daemonMode && webServer != nil && webServer.IsRunning() {
-				// Daemon mode: keep web UI running
+				// Daemon mode: keep web UI running. The watcher feeds on-disk
+				// edits to the file tree and diff views.
+				if !agentNoWatch {
+					if err := chatAgent.StartWorkspaceWatcher(ctx, chatAgent.GetWorkspaceRoot()); err != nil {
+						fmt.Fprintf(os.Stderr, "[WARN] Workspace watcher disabled: %v\n", err)
+					}
+				}
 				fmt.Printf("\n[web] Web UI running at http://localhost:%d\n", webServer.GetPort())
 				if !isServiceMode() {
 					fmt.Println("Press Ctrl+C to stop the server.")
@@ -475,12 +490,26 @@ func runInteractiveMode(ctx context.Context, chatAgent *agent.Agent, eventBus *e
 
 	// Initialize with existing history from agent
 	inputReader.SetHistory(chatAgent.GetHistory())
+	if configDir, err := configuration.GetConfigDir(); err == nil {
+		keymap, err := console.LoadKeymap(filepath.Join(configDir, "keymap.json"))
+		if err != nil {
+			fmt.Printf("[WARN] %v; using default key bindings\n", err)
+		}
+		inputReader.SetKeymap(keymap)
+	}
+	if configManager := chatAgent.GetConfigManager(); configManager != nil && configManager.GetConfig() != nil {
+		config := configManager.GetConfig()
+		inputReader.SetMouse(config.GetMouseEnabled())
+		console.SetHyperlinks(config.GetHyperlinksEnabled())
+		console.SetEditorCommand(config.EditorCommand)
+	}
 
 	for {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
 		default:
+			inputReader.SetPrompt(interactivePrompt(chatAgent))
 			query, err := inputReader.ReadLine()
 
 			if err != nil {
@@ -506,6 +535,9 @@ func runInteractiveMode(ctx context.Context, chatAgent *agent.Agent, eventBus *e
 				fmt.Println("=====================================")
 				chatAgent.PrintConversationSummary(true)
 				printContinuationHint(chatAgent)
+				if running := jobs.Default().Running(); running > 0 {
+					fmt.Printf("[bg] %d background job(s) still running; they finish on their own and log to .ledit/jobs\n", running)
+				}
 				return nil
 			}
 
@@ -536,10 +568,23 @@ func runInteractiveMode(ctx context.Context, chatAgent *agent.Agent, eventBus *e
 	}
 }
 
+// interactivePrompt leads the input prompt with a footer segment for
+// background activity (indexing, jobs, rate limits) when there is any.
+func interactivePrompt(chatAgent *agent.Agent) string {
+	if segment := agent_commands.BackgroundStatusSegment(chatAgent); segment != "" {
+		return console.Colorize("["+segment+"]", console.ActivePalette().Muted) + " ledit> "
+	}
+	return "ledit> "
+}
+
 // runDirectMode handles single query execution
 func runDirectMode(ctx context.Context, chatAgent *agent.Agent, eventBus *events.EventBus, query string) error {
 	if os.Getenv("LEDIT_SUBAGENT") != "1" {
 		fmt.Printf("[>>] Processing: %s\n", query)
+	} else {
+		// Report usage, with the cost tree of any nested subagents, to the
+		// parent agent, which adds it to its own totals.
+		defer chatAgent.PrintConciseSummary()
 	}
 
 	// Slash/bang commands should bypass command-detection fast paths.
@@ -45,26 +45,26 @@ func TryZshCommandExecution(ctx context.Context, chatAgent *agent.Agent, query s
 	// Build the display message with colors
 	var displayMsg strings.Builder
 	displayMsg.WriteString(fmt.Sprintf("\n%s[Detected %s command: %s%s]%s",
-		console.ColorCyan,
+		console.ActivePalette().Accent,
 		cmdInfo.Type,
 		cmdInfo.Name,
-		console.ColorReset,
-		console.ColorReset,
+		console.Code(console.ColorReset),
+		console.Code(console.ColorReset),
 	))
 	switch cmdInfo.Type {
 	case zsh.CommandTypeExternal:
 		displayMsg.WriteString(fmt.Sprintf(" %s[%s%s]%s",
-			console.ColorGray,
+			console.ActivePalette().Muted,
 			cmdInfo.Path,
-			console.ColorReset,
-			console.ColorReset,
+			console.Code(console.ColorReset),
+			console.Code(console.ColorReset),
 		))
 	case zsh.CommandTypeAlias:
 		displayMsg.WriteString(fmt.Sprintf(" %s[%s%s]%s",
-			console.ColorGray,
+			console.ActivePalette().Muted,
 			cmdInfo.Value,
-			console.ColorReset,
-			console.ColorReset,
+			console.Code(console.ColorReset),
+			console.Code(console.ColorReset),
 		))
 	}
 	displayMsg.WriteString("\n")
@@ -96,13 +96,13 @@ func TryZshCommandExecution(ctx context.Context, chatAgent *agent.Agent, query s
 			fmt.Printf("%s%s[Auto-executing with !]%s\n",
 				displayMsg.String(),
 				console.ColorizeBold("Auto-executing with !", console.ColorYellow),
-				console.ColorReset,
+				console.Code(console.ColorReset),
 			)
 		} else {
 			fmt.Printf("%s%s[Auto-executing]%s\n",
 				displayMsg.String(),
 				console.ColorizeBold("Auto-executing", console.ColorGreen),
-				console.ColorReset,
+				console.Code(console.ColorReset),
 			)
 		}
 	}
@@ -110,7 +110,7 @@ func TryZshCommandExecution(ctx context.Context, chatAgent *agent.Agent, query s
 	// Execute the command with color indicator
 	fmt.Printf("%s▶ Executing:%s %s\n",
 		console.ColorizeBold("▶ Executing:", console.ColorBlue),
-		console.ColorReset,
+		console.Code(console.ColorReset),
 		query,
 	)
 
@@ -118,24 +118,24 @@ func TryZshCommandExecution(ctx context.Context, chatAgent *agent.Agent, query s
 	separatorWidth := GetTerminalWidth()
 	separator := strings.Repeat("─", separatorWidth)
 	fmt.Printf("%s%s%s\n",
-		console.ColorGray,
+		console.ActivePalette().Muted,
 		separator,
-		console.ColorReset,
+		console.Code(console.ColorReset),
 	)
 
 	_, err = ExecuteCommand(query)
 
 	// Print separator after output
 	fmt.Printf("%s%s%s\n",
-		console.ColorGray,
+		console.ActivePalette().Muted,
 		separator,
-		console.ColorReset,
+		console.Code(console.ColorReset),
 	)
 
 	if err != nil {
 		fmt.Printf("%s[FAIL] Error:%s %v\n",
-			console.ColorRed,
-			console.ColorReset,
+			console.Code(console.ColorRed),
+			console.Code(console.ColorReset),
 			err,
 		)
 		// Command execution failed - ask user if they want to send to LLM instead
@@ -249,7 +249,7 @@ func TryDirectExecution(ctx context.Context, chatAgent *agent.Agent, query strin
 func executeDirectCommand(command string) (bool, error) {
 	fmt.Printf("%s[!] Fast path:%s %s\n",
 		console.ColorizeBold("[!] Fast path:", console.ColorYellow),
-		console.ColorReset,
+		console.Code(console.ColorReset),
 		command,
 	)
 
@@ -257,9 +257,9 @@ func executeDirectCommand(command string) (bool, error) {
 	separatorWidth := GetTerminalWidth()
 	separator := strings.Repeat("─", separatorWidth)
 	fmt.Printf("%s%s%s\n",
-		console.ColorGray,
+		console.ActivePalette().Muted,
 		separator,
-		console.ColorReset,
+		console.Code(console.ColorReset),
 	)
 
 	// Execute the command directly (output streams in real-time)
@@ -267,15 +267,15 @@ func executeDirectCommand(command string) (bool, error) {
 
 	// Print separator after output
 	fmt.Printf("%s%s%s\n",
-		console.ColorGray,
+		console.ActivePalette().Muted,
 		separator,
-		console.ColorReset,
+		console.Code(console.ColorReset),
 	)
 
 	if err != nil {
 		fmt.Printf("%s[FAIL] Error:%s %v\n",
-			console.ColorRed,
-			console.ColorReset,
+			console.Code(console.ColorRed),
+			console.Code(console.ColorReset),
 			err,
 		)
 	}
@@ -0,0 +1,231 @@
+// Ask command for ledit
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/alantheprice/ledit/pkg/agent"
+	"github.com/alantheprice/ledit/pkg/configuration"
+	"github.com/alantheprice/ledit/pkg/tokenizer"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// askInputMaxBytes caps the piped input sent to the model; the rest is cut
+// with a note saying how much was left out.
+const askInputMaxBytes = 256 << 10
+
+// Kinds of piped input, each framed differently in the prompt.
+const (
+	askInputDiff = "diff"
+	askInputLog  = "log"
+	askInputCode = "code"
+	askInputText = "text"
+)
+
+var (
+	askAs       string
+	askModel    string
+	askProvider string
+)
+
+var (
+	// diffHunkPattern matches a unified diff hunk header.
+	diffHunkPattern = regexp.MustCompile(`(?m)^@@ -\d+(,\d+)? \+\d+(,\d+)? @@`)
+	// gitLogPattern matches the commit header of git log output.
+	gitLogPattern = regexp.MustCompile(`(?m)^commit [0-9a-f]{7,64}\b`)
+	// onelineLogPattern matches a line of git log --oneline.
+	onelineLogPattern = regexp.MustCompile(`^[0-9a-f]{7,40} \S`)
+	// logLinePattern matches a line starting with a timestamp, a log level,
+	// or a Go or Python failure.
+	logLinePattern = regexp.MustCompile(`^\W{0,2}(\d{4}[-/]\d{2}[-/]\d{2}[ T]\d{2}:\d{2}|\d{2}:\d{2}:\d{2}|[A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}|(TRACE|DEBUG|INFO|WARN|WARNING|ERROR|FATAL|CRITICAL)\b|panic: |goroutine \d+ \[|Traceback \(most recent call last\))`)
+)
+
+var askCmd = &cobra.Command{
+	Use:   "ask [question]",
+	Short: "Answer a one-shot question about piped input",
+	Long: `Answer a one-shot question, with anything piped to stdin attached as context.
+
+The piped input is detected as a diff, a log or code (or plain text) and framed
+for the model accordingly; --as overrides the detection. Without a question,
+ask uses a default for the kind of input, such as reviewing a diff. Only the
+answer is printed to stdout, so ask composes in shell pipelines; progress goes
+to stderr.
+
+Examples:
+  git diff | ledit ask "review this"
+  git log -20 | ledit ask "write release notes"
+  journalctl -u app --since today | ledit ask "why does it keep restarting?"
+  ledit ask "what does this do?" < script.sh
+  git diff --staged | ledit ask > review.md`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runAsk(args); err != nil {
+			// Exit non-zero so the rest of a pipeline sees the failure
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitCodeFailure)
+		}
+	},
+}
+
+func runAsk(args []string) error {
+	question := strings.TrimSpace(strings.Join(args, " "))
+	input, err := readPipedInput(os.Stdin, term.IsTerminal(int(os.Stdin.Fd())))
+	if err != nil {
+		return fmt.Errorf("failed to read stdin: %w", err)
+	}
+	if question == "" && strings.TrimSpace(input) == "" {
+		return errors.New("nothing to ask: pass a question, pipe input to stdin, or both")
+	}
+	kind := askAs
+	if kind == "" {
+		kind = detectInputKind(input)
+	}
+	prompt, err := buildAskPrompt(question, input, kind)
+	if err != nil {
+		return err
+	}
+
+	// Reserve stdout for the answer; everything else goes to stderr.
+	answerOut := os.Stdout
+	os.Stdout = os.Stderr
+	defer func() { os.Stdout = answerOut }()
+
+	agentModel = askModel
+	agentProvider = askProvider
+	chatAgent, err := createChatAgent()
+	if err != nil {
+		return fmt.Errorf("failed to create chat agent: %w", err)
+	}
+	defer chatAgent.Shutdown()
+	chatAgent.DisableStreaming()
+	// Stdin holds the input, so nothing can answer a prompt.
+	if err := chatAgent.GetConfigManager().UpdateConfigNoSave(func(cfg *configuration.Config) error {
+		cfg.SkipPrompt = true
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	answer, err := chatAgent.ProcessQuery(prompt)
+	if err != nil {
+		return err
+	}
+	// A run that failed or stopped early is not an answer to pipe on
+	if reason := chatAgent.GetLastRunTerminationReason(); reason != agent.RunTerminationCompleted {
+		fmt.Fprintln(os.Stderr, strings.TrimSpace(answer))
+		if reason == "" {
+			reason = "request failed"
+		}
+		return fmt.Errorf("no answer: %s", reason)
+	}
+	_, err = fmt.Fprintln(answerOut, strings.TrimSpace(answer))
+	return err
+}
+
+// readPipedInput reads stdin unless it is a terminal, which has nothing
+// piped to it.
+func readPipedInput(r io.Reader, isTerminal bool) (string, error) {
+	if isTerminal {
+		return "", nil
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// detectInputKind guesses whether input is a diff, a log, code or plain
+// text.
+func detectInputKind(input string) string {
+	if strings.HasPrefix(input, "diff --git ") || strings.Contains(input, "\ndiff --git ") ||
+		(strings.Contains(input, "\n+++ ") && diffHunkPattern.MatchString(input)) {
+		return askInputDiff
+	}
+	if gitLogPattern.MatchString(input) {
+		return askInputLog
+	}
+
+	lines, logLines, onelineLines := 0, 0, 0
+	for _, line := range strings.Split(input, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		lines++
+		if logLinePattern.MatchString(line) {
+			logLines++
+		}
+		if onelineLogPattern.MatchString(line) {
+			onelineLines++
+		}
+	}
+	if lines > 0 && (logLines*3 >= lines || onelineLines*2 > lines) {
+		return askInputLog
+	}
+	if tokenizer.LooksLikeCode(input) {
+		return askInputCode
+	}
+	return askInputText
+}
+
+// askFraming describes each kind of input to the model, with the question
+// asked when none is given.
+var askFraming = map[string]struct{ about, question string }{
+	askInputDiff: {
+		"a unified diff. Read the changed files in the workspace when the hunks don't show enough context.",
+		"Review this diff: point out bugs, risky changes and missing tests, most important first.",
+	},
+	askInputLog: {
+		"log output. Quote the lines you refer to.",
+		"Summarize this log and explain any errors or warnings, with their likely cause.",
+	},
+	askInputCode: {
+		"source code.",
+		"Explain what this code does and point out any problems.",
+	},
+	askInputText: {
+		"text.",
+		"Summarize this.",
+	},
+}
+
+// buildAskPrompt frames the question and the piped input of the given kind
+// as one query.
+func buildAskPrompt(question, input, kind string) (string, error) {
+	framing, ok := askFraming[kind]
+	if !ok {
+		return "", fmt.Errorf("unknown input kind %q (diff, log, code or text)", kind)
+	}
+	var sb strings.Builder
+	if strings.TrimSpace(input) == "" {
+		sb.WriteString(question)
+	} else {
+		if question == "" {
+			question = framing.question
+		}
+		sb.WriteString(question)
+		fmt.Fprintf(&sb, "\n\nThe input below was piped to ledit from the shell and is %s", framing.about)
+		sb.WriteString(" Only change files if the question asks you to.")
+		if len(input) > askInputMaxBytes {
+			fmt.Fprintf(&sb, " It was cut to its first %d KB of %d KB.", askInputMaxBytes>>10, len(input)>>10)
+			input = input[:askInputMaxBytes]
+			if i := strings.LastIndexByte(input, '\n'); i > 0 {
+				input = input[:i]
+			}
+		}
+		fmt.Fprintf(&sb, "\n\n<piped_input kind=%q>\n%s\n</piped_input>", kind, strings.TrimRight(input, "\n"))
+	}
+	sb.WriteString("\n\nYour reply is printed to stdout as the whole output of the command, so give just the answer, without a preamble.")
+	return sb.String(), nil
+}
+
+func init() {
+	askCmd.Flags().StringVar(&askAs, "as", "", "Treat piped input as diff, log, code or text instead of detecting it")
+	askCmd.Flags().StringVarP(&askModel, "model", "m", "", "Model name for the agent")
+	askCmd.Flags().StringVarP(&askProvider, "provider", "p", "", "Provider to use")
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDetectInputKind(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"git diff", "diff --git a/main.go b/main.go\nindex 1..2 100644\n--- a/main.go\n+++ b/main.go\n@@ -1,3 +1,3 @@\n-old\n+new\n", askInputDiff},
+		{"plain unified diff", "--- a.txt\n+++ b.txt\n@@ -1 +1 @@\n-a\n+b\n", askInputDiff},
+		{"git log", "commit 3f2a9c1d8e\nAuthor: Dev <dev@example.com>\nDate:   Mon Oct 12\n\n    Fix parser\n", askInputLog},
+		{"git log oneline", "3f2a9c1 Fix parser\nb81e0d2 Add tests\n9c0ffee Bump deps\n", askInputLog},
+		{"app log", "2026-10-16 14:02:11 INFO started\n2026-10-16 14:02:12 ERROR connection refused\nretrying\n", askInputLog},
+		{"go panic", "panic: runtime error: index out of range\n\ngoroutine 1 [running]:\nmain.main()\n\t/src/main.go:5 +0x1d\n", askInputLog},
+		{"code", "package main\n\nfunc main() {\n\tprintln(\"hi\")\n}\n", askInputCode},
+		{"text", "Meeting notes: ship the release on Friday.\n", askInputText},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectInputKind(tt.input); got != tt.want {
+				t.Errorf("detectInputKind() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildAskPrompt(t *testing.T) {
+	prompt, err := buildAskPrompt("", "--- a\n+++ b\n", askInputDiff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(prompt, "Review this diff") || !strings.Contains(prompt, "is a unified diff") ||
+		!strings.Contains(prompt, "<piped_input kind=\"diff\">\n--- a\n+++ b\n</piped_input>") {
+		t.Fatalf("expected the default diff question and framing, got %q", prompt)
+	}
+
+	prompt, err = buildAskPrompt("what time is it in Tokyo?", "", askInputText)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(prompt, "piped_input") || !strings.HasPrefix(prompt, "what time is it in Tokyo?") {
+		t.Fatalf("expected a question without input to be asked as is, got %q", prompt)
+	}
+
+	long := strings.Repeat("0123456789abcdef\n", askInputMaxBytes/16)
+	prompt, err = buildAskPrompt("count the lines", long, askInputText)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(prompt, "It was cut to its first 256 KB") || len(prompt) > askInputMaxBytes+1024 ||
+		!strings.Contains(prompt, "0123456789abcdef\n</piped_input>") {
+		t.Fatalf("expected the input to be cut at a line under the limit, got %d bytes", len(prompt))
+	}
+
+	if _, err := buildAskPrompt("q", "x", "yaml"); err == nil {
+		t.Fatal("expected an unknown --as kind to be rejected")
+	}
+}
+
+func TestReadPipedInput(t *testing.T) {
+	if input, err := readPipedInput(strings.NewReader("piped"), true); err != nil || input != "" {
+		t.Fatalf("expected a terminal not to be read, got %q, %v", input, err)
+	}
+	if input, err := readPipedInput(strings.NewReader("piped"), false); err != nil || input != "piped" {
+		t.Fatalf("expected piped input to be read, got %q, %v", input, err)
+	}
+}
@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/alantheprice/ledit/pkg/configuration"
+	"github.com/alantheprice/ledit/pkg/credentials"
+	"github.com/spf13/cobra"
+)
+
+var (
+	authWithToken bool
+	authNoVerify  bool
+)
+
+var authCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "Sign in to providers and manage their API keys",
+	Long: `Store provider API keys in the OS keyring (macOS Keychain, Windows Credential
+Manager, or the Secret Service via libsecret on Linux), or in the encrypted key
+file where no keyring is available. A provider's environment variable, such as
+OPENAI_API_KEY, still takes precedence over the stored key.
+
+Commands:
+  login  - Store a provider's API key after checking it works
+  logout - Remove a provider's stored API key
+  status - Show where each provider's API key comes from`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return authStatus()
+	},
+}
+
+var authLoginCmd = &cobra.Command{
+	Use:   "login <provider>",
+	Short: "Store a provider's API key after checking it works",
+	Long: `Prompt for a provider's API key, check it by listing the provider's models,
+and store it in the OS keyring or the encrypted key file. With --with-token the
+key is read from standard input instead, for scripts:
+
+  echo "$KEY" | ledit auth login openrouter --with-token`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		provider, err := authProvider(args[0])
+		if err != nil {
+			return err
+		}
+		metadata, err := configuration.GetProviderAuthMetadata(provider)
+		if err != nil {
+			return err
+		}
+		if !metadata.RequiresAPIKey {
+			fmt.Printf("[ok] %s needs no API key\n", metadata.DisplayName)
+			return nil
+		}
+
+		var key string
+		if authWithToken {
+			key, err = readToken(os.Stdin)
+		} else {
+			key, err = configuration.PromptForAPIKey(provider)
+		}
+		if err != nil {
+			return err
+		}
+
+		if authNoVerify {
+			if err := credentials.SetToActiveBackend(provider, key); err != nil {
+				return err
+			}
+		} else {
+			fmt.Printf("Checking the key with %s...\n", metadata.DisplayName)
+			count, err := configuration.ValidateAndSaveAPIKey(provider, key)
+			if err != nil {
+				return fmt.Errorf("%s rejected the key, nothing was stored: %w", metadata.DisplayName, err)
+			}
+			fmt.Printf("[ok] %d models available\n", count)
+		}
+		backend, err := credentials.GetStorageBackend()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("[ok] Stored the %s API key in %s\n", metadata.DisplayName, credentials.DescribeBackend(backend))
+		if metadata.EnvVar != "" && strings.TrimSpace(os.Getenv(metadata.EnvVar)) != "" {
+			fmt.Printf("Note: %s is set and takes precedence over the stored key\n", metadata.EnvVar)
+		}
+		return nil
+	},
+}
+
+var authLogoutCmd = &cobra.Command{
+	Use:   "logout <provider>",
+	Short: "Remove a provider's stored API key",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		provider, err := authProvider(args[0])
+		if err != nil {
+			return err
+		}
+		if value, _, err := credentials.GetFromActiveBackend(provider); err == nil && strings.TrimSpace(value) == "" {
+			fmt.Printf("No stored API key for %s\n", provider)
+			return nil
+		}
+		if err := credentials.DeleteFromActiveBackend(provider); err != nil {
+			return err
+		}
+		fmt.Printf("[ok] Removed the stored API key for %s\n", provider)
+		if envVar := credentials.ProviderEnvVar(provider); envVar != "" && strings.TrimSpace(os.Getenv(envVar)) != "" {
+			fmt.Printf("Note: %s is still set and will be used\n", envVar)
+		}
+		return nil
+	},
+}
+
+var authStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show where each provider's API key comes from",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return authStatus()
+	},
+}
+
+// authProvider returns the normalized name of a provider ledit knows.
+func authProvider(name string) (string, error) {
+	provider := strings.ToLower(strings.TrimSpace(name))
+	cfg, _ := configuration.Load()
+	if _, err := configuration.MapProviderStringToClientType(cfg, provider); err != nil {
+		return "", fmt.Errorf("unknown provider %q (available: %s)", name, strings.Join(configuration.GetAvailableProviders(), ", "))
+	}
+	return provider, nil
+}
+
+// readToken reads an API key piped to standard input.
+func readToken(r io.Reader) (string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, 64*1024))
+	if err != nil {
+		return "", fmt.Errorf("failed to read the API key: %w", err)
+	}
+	key := strings.TrimSpace(string(data))
+	if key == "" {
+		return "", errors.New("no API key on standard input")
+	}
+	if strings.ContainsAny(key, "\r\n") {
+		return "", errors.New("expected a single API key on standard input")
+	}
+	return key, nil
+}
+
+func authStatus() error {
+	backend, err := credentials.GetStorageBackend()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Keys are stored in %s\n\n", credentials.DescribeBackend(backend))
+	for _, provider := range configuration.GetAvailableProviders() {
+		metadata, err := configuration.GetProviderAuthMetadata(provider)
+		if err != nil || !metadata.RequiresAPIKey {
+			continue
+		}
+		resolved, err := credentials.ResolveProvider(provider)
+		switch {
+		case err != nil:
+			fmt.Printf("  %-14s error: %v\n", provider, err)
+		case resolved.Value == "" && metadata.EnvVar != "":
+			fmt.Printf("  %-14s not set (ledit auth login %s, or set %s)\n", provider, provider, metadata.EnvVar)
+		case resolved.Value == "":
+			fmt.Printf("  %-14s not set (ledit auth login %s)\n", provider, provider)
+		case resolved.Source == "environment":
+			fmt.Printf("  %-14s %s from %s\n", provider, credentials.MaskValue(resolved.Value), resolved.EnvVar)
+		default:
+			fmt.Printf("  %-14s %s from %s\n", provider, credentials.MaskValue(resolved.Value), credentials.DescribeBackend(backend))
+		}
+	}
+	return nil
+}
+
+func init() {
+	authLoginCmd.Flags().BoolVar(&authWithToken, "with-token", false, "Read the API key from standard input")
+	authLoginCmd.Flags().BoolVar(&authNoVerify, "no-verify", false, "Store the key without checking it with the provider")
+	rootCmd.AddCommand(authCmd)
+	authCmd.AddCommand(authLoginCmd, authLogoutCmd, authStatusCmd)
+}
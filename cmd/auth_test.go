@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadToken(t *testing.T) {
+	key, err := readToken(strings.NewReader("  sk-or-abcdef123456\n"))
+	if err != nil || key != "sk-or-abcdef123456" {
+		t.Fatalf("readToken = %q, %v", key, err)
+	}
+	for _, input := range []string{"", "\n", "sk-one\nsk-two\n"} {
+		if _, err := readToken(strings.NewReader(input)); err == nil {
+			t.Errorf("expected %q to be rejected", input)
+		}
+	}
+}
+
+func TestAuthProviderRejectsUnknownProviders(t *testing.T) {
+	t.Setenv("LEDIT_CONFIG", t.TempDir())
+	if provider, err := authProvider(" OpenAI "); err != nil || provider != "openai" {
+		t.Fatalf("authProvider = %q, %v", provider, err)
+	}
+	if _, err := authProvider("no-such-provider"); err == nil || !strings.Contains(err.Error(), "unknown provider") {
+		t.Fatalf("expected an unknown provider error, got %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+// Capabilities handshake command for ledit
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/alantheprice/ledit/pkg/capabilities"
+	"github.com/spf13/cobra"
+)
+
+var capabilitiesJSON bool
+
+var capabilitiesCmd = &cobra.Command{
+	Use:   "capabilities",
+	Short: "Describe the tools, providers, commands and protocols this build supports",
+	Long: `Describe what this ledit build supports so integrations can feature-detect.
+
+With --json a stable, versioned document is printed containing the built-in
+tools with their parameter schemas, providers, slash commands, config file
+keys and supported protocol versions. The same document is served by the
+web UI at GET /api/capabilities.
+
+Examples:
+  # Human-readable summary
+  ledit capabilities
+
+  # Machine-readable handshake for editor plugins and scripts
+  ledit capabilities --json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		caps := capabilities.Collect()
+		if capabilitiesJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(caps)
+		}
+		printCapabilities(os.Stdout, caps)
+		return nil
+	},
+}
+
+func printCapabilities(w io.Writer, caps *capabilities.Capabilities) {
+	fmt.Fprintf(w, "ledit %s (%s), capabilities schema v%d\n\n", caps.Version, caps.Platform, caps.SchemaVersion)
+
+	fmt.Fprintf(w, "Protocols:\n")
+	for _, p := range caps.Protocols {
+		fmt.Fprintf(w, "  %-18s %-12s %s\n", p.Name, p.Version, p.Description)
+	}
+
+	fmt.Fprintf(w, "\nTools (%d):\n", len(caps.Tools))
+	for _, t := range caps.Tools {
+		fmt.Fprintf(w, "  %s\n", t.Name)
+	}
+
+	fmt.Fprintf(w, "\nSlash commands (%d):\n", len(caps.Commands))
+	for _, c := range caps.Commands {
+		fmt.Fprintf(w, "  /%-20s %s\n", c.Name, c.Description)
+	}
+
+	fmt.Fprintf(w, "\nProviders (%d):\n", len(caps.Providers))
+	for _, p := range caps.Providers {
+		fmt.Fprintf(w, "  %s\n", p)
+	}
+
+	fmt.Fprintf(w, "\nConfig keys: %d (see --json for the full schema)\n", len(caps.ConfigSchema))
+}
+
+func init() {
+	capabilitiesCmd.Flags().BoolVar(&capabilitiesJSON, "json", false, "Print the capabilities document as JSON")
+	capabilities.Version = version
+}
@@ -0,0 +1,145 @@
+// Changelog command for ledit
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	api "github.com/alantheprice/ledit/pkg/agent_api"
+	"github.com/alantheprice/ledit/pkg/changelog"
+	"github.com/alantheprice/ledit/pkg/configuration"
+	"github.com/alantheprice/ledit/pkg/factory"
+	"github.com/alantheprice/ledit/pkg/git"
+	"github.com/alantheprice/ledit/pkg/history"
+	"github.com/spf13/cobra"
+)
+
+var (
+	changelogModel    string
+	changelogProvider string
+	changelogSince    string
+	changelogRelease  string
+	changelogFile     string
+	changelogDryRun   bool
+	changelogNoLLM    bool
+)
+
+var changelogCmd = &cobra.Command{
+	Use:   "changelog",
+	Short: "Update CHANGELOG.md from recent commits and session changes",
+	Long: `Collect the commits since the last tag and the agent's session changes that
+are not committed yet, group them into Added, Changed, Fixed and the other
+Keep a Changelog sections, and merge them into CHANGELOG.md.
+
+Entries go into the "## [Unreleased]" section; with --release they and the
+Unreleased section become "## [<version>] - <date>". Commits whose hash the
+changelog already mentions are skipped, so running it again only adds what
+is new. The model set in commit_provider and commit_model writes the entries;
+with --no-llm, or when the model fails, they are sorted by their wording.
+
+Examples:
+  ledit changelog --dry-run
+  ledit changelog --since v0.15.0
+  ledit changelog --release v0.16.0`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runChangelog()
+	},
+}
+
+func init() {
+	changelogCmd.Flags().StringVarP(&changelogModel, "model", "m", "", "Model name")
+	changelogCmd.Flags().StringVarP(&changelogProvider, "provider", "p", "", "Provider to use")
+	changelogCmd.Flags().StringVar(&changelogSince, "since", "", "Tag or commit to start after (default: the latest tag)")
+	changelogCmd.Flags().StringVar(&changelogRelease, "release", "", "Write the entries as this release instead of Unreleased")
+	changelogCmd.Flags().StringVar(&changelogFile, "file", "CHANGELOG.md", "Changelog to update, relative to the repository root")
+	changelogCmd.Flags().BoolVar(&changelogDryRun, "dry-run", false, "Show the updated section without writing it")
+	changelogCmd.Flags().BoolVar(&changelogNoLLM, "no-llm", false, "Sort changes by their wording instead of asking the model")
+}
+
+func runChangelog() error {
+	root, err := git.GetGitRootDir()
+	if err != nil {
+		return err
+	}
+	path := changelogFile
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read %s: %w", changelogFile, err)
+	}
+
+	r, err := changelog.ResolveRange(root, changelogSince)
+	if err != nil {
+		return err
+	}
+	commits, err := changelog.CollectCommits(root, r)
+	if err != nil {
+		return err
+	}
+	history.InitializeHistoryPaths(nil)
+	session, err := changelog.CollectSessionChanges(root, r.Since)
+	if err != nil {
+		fmt.Printf("[WARN] Skipping session changes: %v\n", err)
+	}
+	changes := changelog.Unrecorded(append(commits, session...), string(content))
+	fmt.Printf("[changelog] %d new commit(s) and %d uncommitted session change(s) %s\n",
+		len(changelog.Unrecorded(commits, string(content))), len(session), r)
+	if len(changes) == 0 && changelogRelease == "" {
+		fmt.Printf("[OK] %s is up to date\n", changelogFile)
+		return nil
+	}
+
+	var entries []changelog.Entry
+	if len(changes) > 0 {
+		entries = groupChangelogEntries(changes)
+	}
+	updated, section := changelog.Update(string(content), entries, changelogRelease, time.Now())
+	if changelogDryRun {
+		fmt.Printf("\n%s\n[dry-run] %s was not changed\n", section, changelogFile)
+		return nil
+	}
+	if err := os.WriteFile(path, []byte(updated), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", changelogFile, err)
+	}
+	fmt.Printf("[ok] Updated %s with %d item(s)\n", changelogFile, len(entries))
+	return nil
+}
+
+// groupChangelogEntries has the model write the entries, falling back to
+// sorting the changes by their wording.
+func groupChangelogEntries(changes []changelog.Change) []changelog.Entry {
+	if !changelogNoLLM {
+		client, err := createChangelogClient()
+		if err == nil {
+			var entries []changelog.Entry
+			if entries, err = changelog.Group(client, changes); err == nil {
+				return entries
+			}
+		}
+		fmt.Printf("[WARN] Could not group changes with the model (%v); sorting them by their wording\n", err)
+	}
+	return changelog.Classify(changes)
+}
+
+// createChangelogClient returns the client for -p/-m, or for the commit
+// provider and model.
+func createChangelogClient() (api.ClientInterface, error) {
+	cfg, err := configuration.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	if changelogProvider == "" && changelogModel == "" {
+		return factory.CreateProviderClient(api.ClientType(cfg.GetCommitProvider()), cfg.GetCommitModel())
+	}
+	clientType, model, err := configuration.ResolveProviderModel(cfg, changelogProvider, changelogModel)
+	if err != nil {
+		return nil, err
+	}
+	return factory.CreateProviderClient(clientType, model)
+}
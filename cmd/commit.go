@@ -16,6 +16,8 @@ var (
 	commitModel        string
 	commitAllowSecrets bool
 	commitDryRun       bool
+	commitConventional bool
+	commitPRDesc       bool
 )
 
 var commitCmd = &cobra.Command{
@@ -58,6 +60,12 @@ and then allows you to confirm, edit, or retry the commit before finalizing it.`
 		if commitAllowSecrets {
 			cmdArgs = append(cmdArgs, "--allow-secrets")
 		}
+		if commitConventional {
+			cmdArgs = append(cmdArgs, "--conventional")
+		}
+		if commitPRDesc {
+			cmdArgs = append(cmdArgs, "--pr-description")
+		}
 
 		err = commitCmd.Execute(cmdArgs, chatAgent)
 		if err != nil {
@@ -71,4 +79,6 @@ func init() {
 	commitCmd.Flags().StringVar(&commitModel, "model", "", "Specify LLM model to use for commit message generation (e.g., 'ollama:llama3')")
 	commitCmd.Flags().BoolVar(&commitAllowSecrets, "allow-secrets", false, "Allow committing files flagged as potentially containing secrets")
 	commitCmd.Flags().BoolVar(&commitDryRun, "dry-run", false, "Generate and display commit message without executing commit")
+	commitCmd.Flags().BoolVar(&commitConventional, "conventional", false, "Write a Conventional Commits message (overrides the commit_style config)")
+	commitCmd.Flags().BoolVar(&commitPRDesc, "pr-description", false, "Also generate a pull request description and save it to .ledit/pr_description.md")
 }
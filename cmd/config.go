@@ -1,17 +1,33 @@
 package cmd
 
 import (
+	"bufio"
 	"encoding/json"
 	"fmt"
+	"os"
+	"strconv"
+	"strings"
 
 	"github.com/alantheprice/ledit/pkg/configuration"
+	"github.com/alantheprice/ledit/pkg/console"
+	"github.com/alantheprice/ledit/pkg/hooks"
+	"github.com/alantheprice/ledit/pkg/toolpolicy"
 	"github.com/spf13/cobra"
 )
 
 var configCmd = &cobra.Command{
 	Use:   "config",
 	Short: "Show or manage configuration",
-	Long:  `Display and manage ledit configuration. Output is always credential-redacted.`,
+	Long: `Display and manage ledit configuration. Output is always credential-redacted.
+
+Settings are layered, each layer overriding the ones before it:
+  built-in defaults < config.json < .ledit/config.yaml in the project
+  < LEDIT_CONFIG_* environment variables < --set key=value flags
+
+Commands:
+  show   - Display the effective configuration
+  edit   - Change common settings interactively
+  doctor - Check the configuration for invalid settings and combinations`,
 }
 
 var configShowCmd = &cobra.Command{
@@ -38,7 +54,223 @@ are redacted before output.`,
 	},
 }
 
+var configEditCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "Change common settings interactively",
+	Long: `Show the common settings (models, budgets and approvals) with their effective
+values and the layer each comes from, and change them in config.json or in the
+project's .ledit/config.yaml.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runConfigEdit(bufio.NewReader(os.Stdin))
+	},
+}
+
+var configDoctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the configuration for invalid settings and combinations",
+	Long:  `Check the effective configuration, exiting with status 1 if any setting is invalid.`,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		root, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to get current directory: %v\n", err)
+			os.Exit(1)
+		}
+		config, err := configuration.Load()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to load config: %v\n", err)
+			os.Exit(1)
+		}
+		if path, err := configuration.GetConfigPath(); err == nil {
+			fmt.Printf("User config:    %s\n", path)
+		}
+		projectFile := configuration.ProjectConfigPath(root)
+		if _, err := os.Stat(projectFile); err != nil {
+			projectFile += " (none)"
+		}
+		fmt.Printf("Project config: %s\n\n", projectFile)
+
+		issues := configuration.Diagnose(config)
+		if config.ColorPalette != "" {
+			if _, ok := console.LookupPalette(config.ColorPalette); !ok {
+				issues = append(issues, configuration.Issue{
+					Severity: configuration.IssueError,
+					Key:      "color_palette",
+					Message:  fmt.Sprintf("unknown palette %q (allowed: %s)", config.ColorPalette, strings.Join(console.PaletteNames(), ", ")),
+				})
+			}
+		}
+		if _, err := toolpolicy.Load(root); err != nil {
+			issues = append(issues, configuration.Issue{Severity: configuration.IssueError, Key: toolpolicy.FileName, Message: err.Error()})
+		}
+		if _, err := hooks.Load(root); err != nil {
+			issues = append(issues, configuration.Issue{Severity: configuration.IssueError, Key: hooks.FileName, Message: err.Error()})
+		}
+
+		failed := false
+		for _, issue := range issues {
+			source := ""
+			if !strings.Contains(issue.Key, "/") && issue.Key != "layers" {
+				source = fmt.Sprintf(" [%s]", config.SettingSource(issue.Key))
+			}
+			fmt.Printf("[%s] %s%s: %s\n", issue.Severity, issue.Key, source, issue.Message)
+			failed = failed || issue.Severity == configuration.IssueError
+		}
+		if len(issues) == 0 {
+			fmt.Println("[ok] No problems found")
+		}
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+// editableSetting is a setting offered by ledit config edit.
+type editableSetting struct {
+	group string
+	key   string
+	help  string
+}
+
+// editableSettings returns the settings ledit config edit offers for config.
+// The model setting is the one of the current provider.
+func editableSettings(config *configuration.Config) []editableSetting {
+	settings := []editableSetting{
+		{"Models", "last_used_provider", "provider used by default"},
+	}
+	if config.LastUsedProvider != "" {
+		settings = append(settings, editableSetting{"Models", "provider_models." + config.LastUsedProvider, "model used with " + config.LastUsedProvider})
+	}
+	return append(settings, []editableSetting{
+		{"Models", "subagent_provider", "provider for subagents (default: last_used_provider)"},
+		{"Models", "subagent_model", "model for subagents"},
+		{"Models", "commit_provider", "provider for commit messages"},
+		{"Models", "commit_model", "model for commit messages"},
+		{"Models", "review_provider", "provider for reviews"},
+		{"Models", "review_model", "model for reviews"},
+		{"Models", "escalation_provider", "provider of the escalation model (default: current provider)"},
+		{"Models", "escalation_model", "stronger model for a todo the current model failed twice"},
+		{"Models", "reasoning_effort", "low, medium or high (empty: automatic)"},
+		{"Budgets", "subagent_max_parallel", "subagents run at once"},
+		{"Budgets", "subagent_max_depth", "how deeply subagents may nest"},
+		{"Budgets", "api_timeouts.overall_timeout_sec", "longest an LLM request may take"},
+		{"Budgets", "file_read.max_kb", "largest file read in full"},
+		{"Budgets", "response_cache.enabled", "reuse responses to identical requests"},
+		{"Approvals", "self_review_gate_mode", "off, code or always"},
+		{"Approvals", "allow_orchestrator_git_write", "let the orchestrator commit and push via shell commands"},
+		{"Approvals", "auto_execute_detected_commands", "run detected shell commands without asking"},
+		{"Approvals", "dependency_install_proposals", "propose installing missing dependencies"},
+		{"Approvals", "secret_redaction_enabled", "redact secrets before they are sent to providers"},
+	}...)
+}
+
+func runConfigEdit(reader *bufio.Reader) error {
+	root, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory: %w", err)
+	}
+	for {
+		config, err := configuration.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		settings := editableSettings(config)
+		group := ""
+		for i, setting := range settings {
+			if setting.group != group {
+				group = setting.group
+				fmt.Printf("\n%s\n", group)
+			}
+			value, ok := config.Setting(setting.key)
+			if !ok {
+				value = "-"
+			}
+			fmt.Printf("  %2d. %-34s %-24s [%s]\n", i+1, setting.key, value, config.SettingSource(setting.key))
+		}
+		fmt.Println()
+
+		answer, err := promptLine(reader, fmt.Sprintf("Setting to change (1-%d, q to quit): ", len(settings)))
+		if err != nil {
+			return err
+		}
+		if answer == "" || strings.EqualFold(answer, "q") {
+			return nil
+		}
+		choice, err := strconv.Atoi(answer)
+		if err != nil || choice < 1 || choice > len(settings) {
+			fmt.Printf("Please enter a number between 1 and %d\n", len(settings))
+			continue
+		}
+		setting := settings[choice-1]
+
+		raw, err := promptLine(reader, fmt.Sprintf("New value for %s (%s, empty keeps it): ", setting.key, setting.help))
+		if err != nil {
+			return err
+		}
+		if raw == "" {
+			continue
+		}
+		if _, err := configuration.ParseSetting(setting.key, raw); err != nil {
+			fmt.Printf("[WARN] %v\n", err)
+			continue
+		}
+		scope, err := promptLine(reader, "Save to (u)ser config.json or (p)roject .ledit/config.yaml? [u]: ")
+		if err != nil {
+			return err
+		}
+		if err := saveEditedSetting(root, setting.key, raw, strings.HasPrefix(strings.ToLower(scope), "p")); err != nil {
+			fmt.Printf("[WARN] %v\n", err)
+			continue
+		}
+
+		updated, err := configuration.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		if value, _ := updated.Setting(setting.key); value != raw {
+			fmt.Printf("[WARN] Saved, but the %s layer overrides %s\n", updated.SettingSource(setting.key), setting.key)
+		} else {
+			fmt.Printf("[ok] %s = %s\n", setting.key, raw)
+		}
+		for _, issue := range configuration.Diagnose(updated) {
+			if strings.HasPrefix(issue.Key, setting.key) || issue.Key == strings.SplitN(setting.key, ".", 2)[0] {
+				fmt.Printf("[%s] %s: %s\n", issue.Severity, issue.Key, issue.Message)
+			}
+		}
+	}
+}
+
+// saveEditedSetting writes key to the project config, or to config.json.
+func saveEditedSetting(root, key, raw string, project bool) error {
+	if project {
+		return configuration.SetProjectSetting(root, key, raw)
+	}
+	config, err := configuration.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := config.ApplySetting(key, raw); err != nil {
+		return err
+	}
+	if err := config.Save(); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	return nil
+}
+
+// applyConfigOverrides sets the --set flag layer of the configuration.
+func applyConfigOverrides(assignments []string) error {
+	if len(assignments) == 0 {
+		return nil
+	}
+	if err := configuration.SetFlagOverrides(assignments); err != nil {
+		return fmt.Errorf("invalid --set: %w", err)
+	}
+	return nil
+}
+
 func init() {
-	configCmd.AddCommand(configShowCmd)
+	configCmd.AddCommand(configShowCmd, configEditCmd, configDoctorCmd)
 	rootCmd.AddCommand(configCmd)
 }
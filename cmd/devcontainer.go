@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/alantheprice/ledit/pkg/configuration"
+	"github.com/alantheprice/ledit/pkg/devcontainer"
+	"github.com/spf13/cobra"
+)
+
+var devcontainerRebuild bool
+
+var devcontainerCmd = &cobra.Command{
+	Use:   "devcontainer",
+	Short: "Run shell commands in the project's devcontainer",
+	Long: `Manage the container described by .devcontainer/devcontainer.json.
+
+With "devcontainer": {"enabled": true} in config.json, the agent's shell
+commands, builds and tests run inside it, with the workspace mounted, so they
+see the same toolchain as the rest of the team. The container is built and
+started on first use; "up" does that ahead of time.
+
+Commands:
+  status - Show the devcontainer config and container state
+  up     - Build and start the container
+  down   - Stop and remove the container
+  exec   - Run a command in the container the way the agent does`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return devcontainerStatus(cmd.Context())
+	},
+}
+
+var devcontainerStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the devcontainer config and container state",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return devcontainerStatus(cmd.Context())
+	},
+}
+
+var devcontainerUpCmd = &cobra.Command{
+	Use:   "up",
+	Short: "Build and start the container",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		container, err := workspaceDevcontainer()
+		if err != nil {
+			return err
+		}
+		if devcontainerRebuild {
+			if _, err := container.Down(cmd.Context()); err != nil {
+				return err
+			}
+		}
+		id, err := container.Up(cmd.Context())
+		if err != nil {
+			return err
+		}
+		fmt.Printf("[ok] %s is running (%s)\n", container.Describe(), shortID(id))
+		return nil
+	},
+}
+
+var devcontainerDownCmd = &cobra.Command{
+	Use:   "down",
+	Short: "Stop and remove the container",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		container, err := workspaceDevcontainer()
+		if err != nil {
+			return err
+		}
+		state, err := container.Down(cmd.Context())
+		if err != nil {
+			return err
+		}
+		if state.ID == "" {
+			fmt.Printf("No container for %s\n", container.Describe())
+			return nil
+		}
+		fmt.Printf("[ok] Removed %s (%s)\n", container.Describe(), shortID(state.ID))
+		return nil
+	},
+}
+
+var devcontainerExecCmd = &cobra.Command{
+	Use:   "exec <command>",
+	Short: "Run a command in the container the way the agent does",
+	Long: `Run a shell command in the container, in the directory the current directory
+is mounted at, starting the container first if needed.
+
+Example:
+  ledit devcontainer exec go test ./...`,
+	Args:               cobra.MinimumNArgs(1),
+	DisableFlagParsing: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		container, err := workspaceDevcontainer()
+		if err != nil {
+			return err
+		}
+		dir, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer stop()
+
+		run, finish, err := container.Command(ctx, dir, strings.Join(args, " "))
+		if err != nil {
+			return err
+		}
+		var output strings.Builder
+		run.Stdout = &output
+		run.Stderr = &output
+		runErr := run.Run()
+		result, _ := finish(output.String())
+		fmt.Print(result)
+		var exitErr *exec.ExitError
+		if errors.As(runErr, &exitErr) {
+			os.Exit(exitErr.ExitCode())
+		}
+		return runErr
+	},
+}
+
+// workspaceDevcontainer returns the devcontainer of the current directory.
+// It works whether or not the agent is set to use it.
+func workspaceDevcontainer() (*devcontainer.Container, error) {
+	root, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current directory: %w", err)
+	}
+	var runtime string
+	if manager, err := configuration.NewManagerSilent(); err == nil {
+		if cfg := manager.GetConfig(); cfg.Devcontainer != nil {
+			runtime = cfg.Devcontainer.Runtime
+		}
+	}
+	return devcontainer.New(root, devcontainer.Options{
+		Runtime: runtime,
+		Progress: func(message string) {
+			fmt.Printf("[devcontainer] %s\n", message)
+		},
+	})
+}
+
+func devcontainerStatus(ctx context.Context) error {
+	container, err := workspaceDevcontainer()
+	if err != nil {
+		return err
+	}
+	cfg := container.Config()
+	fmt.Printf("Config:    %s\n", cfg.File)
+	if cfg.Build != nil {
+		fmt.Printf("Build:     %s\n", cfg.Build.Dockerfile)
+	} else {
+		fmt.Printf("Image:     %s\n", cfg.Image)
+	}
+	fmt.Printf("Workspace: %s\n", cfg.WorkspaceFolder)
+
+	state, err := container.Status(ctx)
+	if err != nil {
+		return err
+	}
+	switch {
+	case state.ID == "":
+		fmt.Println("Container: none (created on first use, or with ledit devcontainer up)")
+	case state.Outdated:
+		fmt.Printf("Container: %s, outdated (recreated on next use)\n", shortID(state.ID))
+	case state.Running:
+		fmt.Printf("Container: %s, running\n", shortID(state.ID))
+	default:
+		fmt.Printf("Container: %s, stopped\n", shortID(state.ID))
+	}
+
+	enabled := false
+	if manager, err := configuration.NewManagerSilent(); err == nil {
+		cfg := manager.GetConfig()
+		enabled = cfg.Devcontainer != nil && cfg.Devcontainer.Enabled
+	}
+	if !enabled {
+		fmt.Println(`The agent runs commands locally; set "devcontainer": {"enabled": true} in config.json to use the container.`)
+	}
+	return nil
+}
+
+func shortID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
+
+func init() {
+	devcontainerUpCmd.Flags().BoolVar(&devcontainerRebuild, "rebuild", false, "Remove the existing container first")
+	rootCmd.AddCommand(devcontainerCmd)
+	devcontainerCmd.AddCommand(devcontainerStatusCmd, devcontainerUpCmd, devcontainerDownCmd, devcontainerExecCmd)
+}
@@ -0,0 +1,161 @@
+// Docs commands for ledit
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	tools "github.com/alantheprice/ledit/pkg/agent_tools"
+	"github.com/alantheprice/ledit/pkg/configuration"
+	"github.com/alantheprice/ledit/pkg/docsync"
+	"github.com/alantheprice/ledit/pkg/git"
+	"github.com/spf13/cobra"
+)
+
+var (
+	docsCheckTodos bool
+	docsCheckJSON  bool
+)
+
+var docsCmd = &cobra.Command{
+	Use:   "docs",
+	Short: "Keep the documentation in sync with the code",
+}
+
+var docsCheckCmd = &cobra.Command{
+	Use:   "check [files...]",
+	Short: "Report documentation that has drifted from the code",
+	Long: `Cross-reference README.md and docs/*.md against the code and report what
+they describe that no longer exists:
+
+  - ledit commands and flags in shell snippets, inline code and command headings
+  - config.json settings in setting headings, JSON examples and --set overrides
+  - repository files and relative links
+  - exported identifiers that Go snippets use from the project's packages
+
+Each finding is printed as file:line. The command exits with status 1 when
+there is drift, so it can run in CI. With --todos, a fix todo is added for
+each finding to the project's todo list for the agent to work through.
+
+Examples:
+  ledit docs check
+  ledit docs check README.md docs/CLI_REFERENCE.md
+  ledit docs check --todos`,
+	Run: func(cmd *cobra.Command, args []string) {
+		failed, err := runDocsCheck(args)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	docsCheckCmd.Flags().BoolVar(&docsCheckTodos, "todos", false, "Add a todo to fix each finding")
+	docsCheckCmd.Flags().BoolVar(&docsCheckJSON, "json", false, "Print the findings as JSON")
+	docsCmd.AddCommand(docsCheckCmd)
+}
+
+// runDocsCheck checks the files, or the default documentation, and reports
+// whether any drift was found.
+func runDocsCheck(files []string) (bool, error) {
+	root, err := git.GetGitRootDir()
+	if err != nil {
+		if root, err = os.Getwd(); err != nil {
+			return false, fmt.Errorf("failed to get current directory: %w", err)
+		}
+	}
+	if len(files) == 0 {
+		if files, err = docsync.DefaultFiles(root); err != nil {
+			return false, fmt.Errorf("failed to list documentation: %w", err)
+		}
+	}
+
+	checker := &docsync.Checker{Root: root, CLI: rootCmd, IsSetting: configuration.IsSetting}
+	findings, err := checker.Check(files)
+	if err != nil {
+		return false, err
+	}
+
+	if docsCheckJSON {
+		data, err := json.MarshalIndent(findings, "", "  ")
+		if err != nil {
+			return false, err
+		}
+		fmt.Println(string(data))
+	} else {
+		for _, finding := range findings {
+			fmt.Println(finding)
+		}
+		if len(findings) == 0 {
+			fmt.Printf("[OK] %d file(s) match the code\n", len(files))
+		} else {
+			fmt.Printf("\n[WARN] %d finding(s) in %d file(s)\n", len(findings), len(files))
+		}
+	}
+
+	if docsCheckTodos && len(findings) > 0 {
+		added, err := addDocsTodos(root, findings)
+		if err != nil {
+			return true, fmt.Errorf("failed to add todos: %w", err)
+		}
+		if !docsCheckJSON {
+			fmt.Printf("[ok] Added %d todo(s)\n", added)
+		}
+	}
+	return len(findings) > 0, nil
+}
+
+// addDocsTodos adds a pending todo for each finding not already on the
+// project's todo list.
+func addDocsTodos(root string, findings []docsync.Finding) (int, error) {
+	if err := tools.LoadTodos(root); err != nil {
+		return 0, err
+	}
+	todos := tools.TodoRead()
+	existing := make(map[string]bool, len(todos))
+	for _, todo := range todos {
+		existing[todo.ID] = true
+	}
+	added := 0
+	for _, finding := range findings {
+		id := docsTodoID(finding)
+		if existing[id] {
+			continue
+		}
+		existing[id] = true
+		todos = append(todos, tools.TodoItem{
+			ID:       id,
+			Content:  fmt.Sprintf("Update %s:%d (%s)", finding.File, finding.Line, finding.Message),
+			Status:   "pending",
+			Priority: "medium",
+			Source:   "docs check",
+		})
+		added++
+	}
+	if added == 0 {
+		return 0, nil
+	}
+	return added, tools.UpdateTodos(todos)
+}
+
+// docsTodoID identifies a finding by what drifted rather than its line, so
+// edits elsewhere in the file don't duplicate its todo.
+func docsTodoID(finding docsync.Finding) string {
+	id := strings.ToLower(finding.File + "-" + finding.Message)
+	id = strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '-'
+	}, id)
+	for strings.Contains(id, "--") {
+		id = strings.ReplaceAll(id, "--", "-")
+	}
+	return "docs-drift-" + strings.Trim(id, "-")
+}
@@ -0,0 +1,126 @@
+// Export session transcript command for ledit
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alantheprice/ledit/pkg/transcript"
+	"github.com/spf13/cobra"
+)
+
+var (
+	transcriptOutput      string
+	transcriptCondense    bool
+	transcriptOnlyChanges bool
+	transcriptFull        bool
+	transcriptFormat      string
+)
+
+var exportTranscriptCmd = &cobra.Command{
+	Use:   "export-transcript [session-id]",
+	Short: "Export a session transcript as Markdown or HTML",
+	Long: `Export a readable transcript of a saved agent session.
+
+Without a session ID, the most recent session for the current working
+directory is exported. Transcripts are built from the structured session
+store, so tool calls and file changes are rendered from their arguments
+rather than from console output.
+
+Modes (choose one, default --full):
+  --full          Every message, tool call and tool result
+  --condense      Prompts, responses and diffs; tool noise collapsed to one line
+  --only-changes  Just the prompts and the diffs they produced
+
+Formats (--format, default markdown, or html when -o ends in .html):
+  markdown        Markdown with fenced code blocks
+  html            Standalone page using a colorblind-safe palette; success,
+                  failure and diff lines are also marked with symbols
+
+Examples:
+  # Condensed transcript of the latest session in this directory
+  ledit export-transcript --condense
+
+  # Only prompts and resulting diffs for a specific session, written to a file
+  ledit export-transcript session_1234567890 --only-changes -o changes.md
+
+  # Shareable HTML transcript
+  ledit export-transcript -o transcript.html`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mode, err := resolveTranscriptMode(transcriptFull, transcriptCondense, transcriptOnlyChanges)
+		if err != nil {
+			return err
+		}
+
+		sessionID := ""
+		if len(args) > 0 {
+			sessionID = args[0]
+		}
+		workingDir, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to resolve current working directory: %w", err)
+		}
+
+		state, err := transcript.LoadSession(sessionID, workingDir)
+		if err != nil {
+			return fmt.Errorf("failed to load session: %w", err)
+		}
+		t, err := transcript.Build(*state, mode)
+		if err != nil {
+			return fmt.Errorf("failed to build transcript: %w", err)
+		}
+		format, err := transcript.ResolveFormat(transcriptFormat, transcriptOutput)
+		if err != nil {
+			return err
+		}
+		rendered := transcript.Render(t, format)
+
+		if strings.TrimSpace(transcriptOutput) == "" {
+			fmt.Fprint(os.Stdout, rendered)
+			return nil
+		}
+		if dir := filepath.Dir(transcriptOutput); dir != "." {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return fmt.Errorf("failed to create output directory: %w", err)
+			}
+		}
+		if err := os.WriteFile(transcriptOutput, []byte(rendered), 0644); err != nil {
+			return fmt.Errorf("failed to write transcript: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "Transcript (%s, %d entries) written to %s\n", mode, len(t.Entries), transcriptOutput)
+		return nil
+	},
+}
+
+// resolveTranscriptMode maps the mutually exclusive mode flags to a transcript mode.
+func resolveTranscriptMode(full, condense, onlyChanges bool) (transcript.Mode, error) {
+	selected := 0
+	mode := transcript.ModeFull
+	if full {
+		selected++
+	}
+	if condense {
+		selected++
+		mode = transcript.ModeCondense
+	}
+	if onlyChanges {
+		selected++
+		mode = transcript.ModeOnlyChanges
+	}
+	if selected > 1 {
+		return "", errors.New("flags --full, --condense and --only-changes are mutually exclusive")
+	}
+	return mode, nil
+}
+
+func init() {
+	exportTranscriptCmd.Flags().StringVarP(&transcriptOutput, "output", "o", "", "Output file path (default: stdout)")
+	exportTranscriptCmd.Flags().StringVar(&transcriptFormat, "format", "", "Output format: markdown or html (default: from output extension, else markdown)")
+	exportTranscriptCmd.Flags().BoolVar(&transcriptFull, "full", false, "Include every message, tool call and tool result (default)")
+	exportTranscriptCmd.Flags().BoolVar(&transcriptCondense, "condense", false, "Keep prompts, responses and diffs; summarize tool noise")
+	exportTranscriptCmd.Flags().BoolVar(&transcriptOnlyChanges, "only-changes", false, "Keep only prompts and the resulting diffs")
+}
@@ -0,0 +1,118 @@
+// Test generation command for ledit
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/alantheprice/ledit/pkg/agent"
+	"github.com/alantheprice/ledit/pkg/gentests"
+	"github.com/spf13/cobra"
+)
+
+var (
+	genTestsModel       string
+	genTestsProvider    string
+	genTestsCoverage    bool
+	genTestsMaxAttempts int
+)
+
+var genTestsCmd = &cobra.Command{
+	Use:   "gen-tests <path>",
+	Short: "Generate tests for the untested exported functions of a Go file",
+	Long: `Analyze a Go source file, find its exported functions that have no tests,
+and have the agent write table-driven tests for them.
+
+A function counts as untested when no test file in its package mentions it,
+or, with --coverage, when the package's tests cover none of its statements.
+The generated tests go in <file>_test.go and are compiled and run; failures
+are fed back to the agent. Tests that still fail after the last attempt are
+discarded and the test file is restored. The source file is never changed.
+
+Examples:
+  ledit gen-tests pkg/parser/tokens.go
+  ledit gen-tests --coverage --max-attempts 5 internal/store/cache.go`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		return runGenTests(ctx, args[0])
+	},
+}
+
+func init() {
+	genTestsCmd.Flags().StringVarP(&genTestsModel, "model", "m", "", "Model name")
+	genTestsCmd.Flags().StringVarP(&genTestsProvider, "provider", "p", "", "Provider to use")
+	genTestsCmd.Flags().BoolVar(&genTestsCoverage, "coverage", false, "Find untested functions from a coverage run of the package's tests")
+	genTestsCmd.Flags().IntVar(&genTestsMaxAttempts, "max-attempts", gentests.DefaultMaxAttempts, "Generate-and-verify rounds before giving up")
+}
+
+func runGenTests(ctx context.Context, path string) error {
+	if genTestsCoverage {
+		fmt.Printf("[test] Measuring coverage of %s\n", filepath.Dir(path))
+	}
+	analysis, err := gentests.Analyze(ctx, path, genTestsCoverage)
+	if err != nil {
+		return err
+	}
+	if genTestsCoverage && !analysis.UsedCoverage {
+		fmt.Println("[WARN] The package's tests produced no coverage profile; using test references instead")
+	}
+	if len(analysis.Untested) == 0 {
+		fmt.Printf("[OK] All %d exported function(s) in %s have tests\n", len(analysis.Exported), path)
+		return nil
+	}
+	fmt.Printf("[test] %d of %d exported function(s) untested:\n", len(analysis.Untested), len(analysis.Exported))
+	for _, fn := range analysis.Untested {
+		fmt.Printf("  %s\n", fn)
+	}
+
+	chatAgent, err := createGenTestsAgent()
+	if err != nil {
+		return err
+	}
+	defer chatAgent.Shutdown()
+
+	generator := &gentests.Generator{
+		Generate: func(prompt string) error {
+			_, err := chatAgent.ProcessQuery(prompt)
+			return err
+		},
+		Verify:      gentests.VerifyGoTests,
+		MaxAttempts: genTestsMaxAttempts,
+		OnAttempt: func(round gentests.Attempt) {
+			fmt.Printf("\n[test] %s\n", gentests.FormatAttempt(round))
+		},
+	}
+	report, err := generator.Run(ctx, analysis)
+	if err != nil {
+		return fmt.Errorf("gen-tests failed: %w", err)
+	}
+	if report.Kept {
+		fmt.Printf("[OK] Kept %d test(s) in %s\n", len(report.Tests), report.TestFile)
+		return nil
+	}
+	fmt.Printf("[FAIL] Generated tests did not pass after %d attempt(s); %s was restored\n", len(report.Attempts), report.TestFile)
+	return nil
+}
+
+// createGenTestsAgent creates the agent that writes the tests.
+func createGenTestsAgent() (*agent.Agent, error) {
+	var chatAgent *agent.Agent
+	var err error
+
+	if genTestsProvider != "" && genTestsModel != "" {
+		chatAgent, err = agent.NewAgentWithModel(fmt.Sprintf("%s:%s", genTestsProvider, genTestsModel))
+	} else if genTestsModel != "" {
+		chatAgent, err = agent.NewAgentWithModel(genTestsModel)
+	} else {
+		chatAgent, err = agent.NewAgent()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize agent: %w", err)
+	}
+	return chatAgent, nil
+}
@@ -0,0 +1,154 @@
+// Log triage command for ledit
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/alantheprice/ledit/pkg/agent"
+	"github.com/alantheprice/ledit/pkg/logchat"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var (
+	logsFile     string
+	logsCommand  string
+	logsWindow   int
+	logsModel    string
+	logsProvider string
+)
+
+var logsCmd = &cobra.Command{
+	Use:   "logs",
+	Short: "Work with application logs",
+}
+
+var logsChatCmd = &cobra.Command{
+	Use:   "chat [question]",
+	Short: "Ask questions about a large log file",
+	Long: `Index a log file (or the output of a command) and chat with the agent about it.
+
+The log is never loaded into the model's context. Ingestion records line
+offsets and timestamps and summarizes fixed-size windows of lines; the agent
+then answers questions with targeted retrieval tools (overview, time-bounded
+search, histograms, line context and slices).
+
+Recognized timestamps: RFC 3339/ISO-8601, Apache/Nginx common log format,
+syslog prefixes and epoch seconds in JSON "ts"/"time" fields.
+
+Examples:
+  ledit logs chat --file app.log
+  ledit logs chat --file app.log "when did the 500s start?"
+  ledit logs chat --cmd "kubectl logs deploy/api --since=2h" "show the context of the first OOM"`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if (logsFile == "") == (logsCommand == "") {
+			return errors.New("specify exactly one of --file or --cmd")
+		}
+
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		var (
+			idx *logchat.Index
+			err error
+		)
+		if logsFile != "" {
+			fmt.Fprintf(os.Stderr, "Indexing %s...\n", logsFile)
+			idx, err = logchat.IndexFile(logsFile, logsWindow)
+		} else {
+			fmt.Fprintf(os.Stderr, "Running %q and indexing its output...\n", logsCommand)
+			idx, err = logchat.IndexCommand(ctx, logsCommand, logsWindow)
+		}
+		if err != nil {
+			return err
+		}
+		defer idx.Close()
+		fmt.Fprintf(os.Stderr, "Indexed %d lines in %d windows.\n", idx.Lines(), len(idx.Windows))
+
+		chatAgent, err := createLogsAgent(idx)
+		if err != nil {
+			return err
+		}
+		defer chatAgent.Shutdown()
+
+		question := ""
+		if len(args) > 0 {
+			question = strings.TrimSpace(args[0])
+		}
+		return runLogsChat(chatAgent, question)
+	},
+}
+
+func init() {
+	logsChatCmd.Flags().StringVarP(&logsFile, "file", "f", "", "Log file to index")
+	logsChatCmd.Flags().StringVar(&logsCommand, "cmd", "", "Shell command whose output is indexed (e.g. \"kubectl logs pod/api\")")
+	logsChatCmd.Flags().IntVar(&logsWindow, "window", logchat.DefaultWindowLines, "Lines per summarized window")
+	logsChatCmd.Flags().StringVarP(&logsModel, "model", "m", "", "Model name")
+	logsChatCmd.Flags().StringVarP(&logsProvider, "provider", "p", "", "Provider to use")
+	logsCmd.AddCommand(logsChatCmd)
+}
+
+// createLogsAgent creates an agent with the log retrieval tools registered.
+func createLogsAgent(idx *logchat.Index) (*agent.Agent, error) {
+	var chatAgent *agent.Agent
+	var err error
+
+	if logsProvider != "" && logsModel != "" {
+		chatAgent, err = agent.NewAgentWithModel(fmt.Sprintf("%s:%s", logsProvider, logsModel))
+	} else if logsModel != "" {
+		chatAgent, err = agent.NewAgentWithModel(logsModel)
+	} else {
+		chatAgent, err = agent.NewAgent()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize agent: %w", err)
+	}
+
+	if err := logchat.Register(chatAgent, idx); err != nil {
+		return nil, fmt.Errorf("failed to register log tools: %w", err)
+	}
+	chatAgent.SetSystemPrompt(chatAgent.GetSystemPrompt() + "\n\n" + logchat.SystemPrompt(idx))
+	return chatAgent, nil
+}
+
+// runLogsChat answers question (if given) and, on a terminal, keeps reading
+// follow-up questions until exit.
+func runLogsChat(chatAgent *agent.Agent, question string) error {
+	interactive := term.IsTerminal(int(os.Stdin.Fd()))
+	if question == "" && !interactive {
+		return errors.New("a question is required when stdin is not a terminal")
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		if question == "" {
+			fmt.Print("\n[logs] Ask about the log (exit to quit): ")
+			input, err := reader.ReadString('\n')
+			if err != nil {
+				return nil
+			}
+			question = strings.TrimSpace(input)
+			switch strings.ToLower(question) {
+			case "":
+				continue
+			case "exit", "quit", "q":
+				return nil
+			}
+		}
+
+		if _, err := chatAgent.ProcessQueryWithContinuity(question); err != nil {
+			fmt.Printf("\n[WARN] Agent error: %v\n", err)
+		}
+		if !interactive {
+			return nil
+		}
+		question = ""
+	}
+}
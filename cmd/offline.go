@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/alantheprice/ledit/pkg/configuration"
+)
+
+var offlineMode bool
+
+// applyOfflineMode switches every LLM call to the configured local provider
+// when --offline or LEDIT_OFFLINE is set. The switch is made through the
+// flag layer, so it is never saved to config.json.
+func applyOfflineMode() error {
+	if offlineMode {
+		if err := os.Setenv(configuration.OfflineEnv, "1"); err != nil {
+			return fmt.Errorf("failed to enable offline mode: %w", err)
+		}
+	}
+	if !configuration.IsOffline() {
+		return nil
+	}
+	config, err := configuration.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	overrides, err := configuration.OfflineOverrides(config)
+	if err != nil {
+		return fmt.Errorf("--offline: %w", err)
+	}
+	return applyConfigOverrides(append(append([]string(nil), configOverrides...), overrides...))
+}
+
+// checkOfflineProvider rejects a --provider that needs the network.
+func checkOfflineProvider(provider string) error {
+	if provider == "" || !configuration.IsOffline() {
+		return nil
+	}
+	config, _ := configuration.Load()
+	if !configuration.IsLocalProvider(config, provider) {
+		return fmt.Errorf("--provider %s needs the network, which --offline turns off; use a local provider such as ollama-local or lmstudio", provider)
+	}
+	return nil
+}
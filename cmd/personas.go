@@ -0,0 +1,363 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/alantheprice/ledit/pkg/configuration"
+	"github.com/spf13/cobra"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+)
+
+var personasCmd = &cobra.Command{
+	Use:   "personas",
+	Short: "Manage subagent personas",
+	Long: `Manage subagent personas - named subagent configurations with their own
+system prompt, model, token budget and allowed tools.
+
+Project personas are stored in .ledit/personas/<id>.json, are loaded whenever
+ledit runs in the project, and replace a built-in or global persona with the
+same ID there. The orchestrating model sees them through the list_personas tool.
+
+Commands:
+  list      - List all personas
+  show      - Show one persona in full
+  create    - Create a project persona
+  edit      - Change a project persona
+  remove    - Delete a project persona
+  validate  - Check the project's persona definitions`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return listPersonas()
+	},
+}
+
+var personasListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all personas",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return listPersonas()
+	},
+}
+
+var personasShowCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "Show one persona in full",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, err := configuration.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		id, persona, ok := findPersona(config, args[0])
+		if !ok {
+			return fmt.Errorf("persona not found: %s", args[0])
+		}
+		printPersona(id, persona)
+		return nil
+	},
+}
+
+var personasCreateCmd = &cobra.Command{
+	Use:   "create <id>",
+	Short: "Create a project persona",
+	Long: `Create a persona in the project's .ledit/personas/ directory.
+
+Without --prompt or --prompt-file, a system prompt file is created next to the
+definition for you to edit.
+
+Example:
+  ledit personas create security-auditor --description "Audits changes for security issues" \
+    --model gpt-5 --max-tokens 300000 --tools read_file,search_files,read_symbol`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		root, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+		id := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(args[0])), "-", "_")
+		path := configuration.ProjectPersonaPath(root, id)
+		if _, err := os.Stat(path); err == nil {
+			return fmt.Errorf("persona %q already exists at %s; use 'ledit personas edit'", id, path)
+		}
+
+		persona := configuration.SubagentType{
+			ID:      id,
+			Name:    cases.Title(language.English).String(strings.ReplaceAll(id, "_", " ")),
+			Enabled: true,
+		}
+		if err := applyPersonaFlags(cmd, &persona); err != nil {
+			return err
+		}
+
+		scaffold := ""
+		if persona.SystemPrompt == "" && persona.SystemPromptText == "" {
+			scaffold = filepath.Join(configuration.ProjectPersonasDir(root), id+".md")
+			persona.SystemPrompt = filepath.ToSlash(filepath.Join(configuration.ConfigDirName, "personas", id+".md"))
+			if err := writePersonaPromptScaffold(scaffold, persona); err != nil {
+				return err
+			}
+		}
+		if err := configuration.SaveProjectPersona(root, persona); err != nil {
+			if scaffold != "" {
+				os.Remove(scaffold)
+			}
+			return err
+		}
+
+		fmt.Printf("[ok] Created persona '%s' at %s\n", id, path)
+		if scaffold != "" {
+			fmt.Printf("\nEdit %s to write its system prompt.\n", scaffold)
+		}
+		fmt.Printf("Use it with run_subagent's persona parameter: \"persona\": \"%s\"\n", id)
+		return nil
+	},
+}
+
+var personasEditCmd = &cobra.Command{
+	Use:   "edit <id>",
+	Short: "Change a project persona",
+	Long: `Change the fields of a project persona given as flags; other fields keep
+their values.
+
+Example:
+  ledit personas edit security-auditor --max-tokens 500000 --enabled=false`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		root, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+		id := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(args[0])), "-", "_")
+		persona, err := configuration.ReadProjectPersona(root, id)
+		if os.IsNotExist(err) {
+			return fmt.Errorf("persona %q is not defined in this project; create it with 'ledit personas create'", id)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read persona %q: %w", id, err)
+		}
+
+		if err := applyPersonaFlags(cmd, &persona); err != nil {
+			return err
+		}
+		if cmd.Flags().Changed("enabled") {
+			persona.Enabled, _ = cmd.Flags().GetBool("enabled")
+		}
+		if err := configuration.SaveProjectPersona(root, persona); err != nil {
+			return err
+		}
+		fmt.Printf("[ok] Updated persona '%s'\n", id)
+		return nil
+	},
+}
+
+var personasRemoveCmd = &cobra.Command{
+	Use:   "remove <id>",
+	Short: "Delete a project persona",
+	Long:  `Delete a project persona's definition. Its system prompt file is kept.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		root, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+		path := configuration.ProjectPersonaPath(root, args[0])
+		if err := os.Remove(path); err != nil {
+			if os.IsNotExist(err) {
+				return fmt.Errorf("persona %q is not defined in this project", args[0])
+			}
+			return fmt.Errorf("failed to remove persona: %w", err)
+		}
+		fmt.Printf("[ok] Removed %s\n", path)
+		return nil
+	},
+}
+
+var personasValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check the project's persona definitions",
+	Long:  `Check the project's persona definitions, exiting with status 1 if any is invalid.`,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		root, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to get current directory: %v\n", err)
+			os.Exit(1)
+		}
+		personas, err := configuration.LoadProjectPersonas(root)
+		for _, id := range sortedPersonaIDs(personas) {
+			fmt.Printf("[ok] %s\n", id)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if len(personas) == 0 {
+			fmt.Printf("No personas defined in %s\n", configuration.ProjectPersonasDir(root))
+		}
+	},
+}
+
+// applyPersonaFlags sets the persona fields given as flags.
+func applyPersonaFlags(cmd *cobra.Command, persona *configuration.SubagentType) error {
+	flags := cmd.Flags()
+	if flags.Changed("name") {
+		persona.Name, _ = flags.GetString("name")
+	}
+	if flags.Changed("description") {
+		persona.Description, _ = flags.GetString("description")
+	}
+	if flags.Changed("provider") {
+		persona.Provider, _ = flags.GetString("provider")
+	}
+	if flags.Changed("model") {
+		persona.Model, _ = flags.GetString("model")
+	}
+	if flags.Changed("max-tokens") {
+		persona.MaxTokens, _ = flags.GetInt("max-tokens")
+	}
+	if flags.Changed("tools") {
+		persona.AllowedTools, _ = flags.GetStringSlice("tools")
+	}
+	if flags.Changed("aliases") {
+		persona.Aliases, _ = flags.GetStringSlice("aliases")
+	}
+	if flags.Changed("prompt") && flags.Changed("prompt-file") {
+		return fmt.Errorf("use --prompt or --prompt-file, not both")
+	}
+	if flags.Changed("prompt") {
+		persona.SystemPromptText, _ = flags.GetString("prompt")
+		persona.SystemPrompt = ""
+	}
+	if flags.Changed("prompt-file") {
+		persona.SystemPrompt, _ = flags.GetString("prompt-file")
+		persona.SystemPromptText = ""
+	}
+	return nil
+}
+
+func writePersonaPromptScaffold(path string, persona configuration.SubagentType) error {
+	content := fmt.Sprintf(`You are the %s subagent. %s
+
+<!-- Edit this file to describe how the persona works -->
+
+## Focus
+
+<!-- What the persona is responsible for, and what it must not change -->
+
+## Workflow
+
+<!-- The steps it follows and how it verifies its work -->
+
+## Output
+
+<!-- What it reports back to the orchestrator when done -->
+`, persona.Name, persona.Description)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create personas directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write system prompt file: %w", err)
+	}
+	return nil
+}
+
+func listPersonas() error {
+	config, err := configuration.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	for _, id := range sortedPersonaIDs(config.SubagentTypes) {
+		persona := config.SubagentTypes[id]
+		status := "enabled"
+		if !persona.Enabled {
+			status = "disabled"
+		}
+		source := "global"
+		if persona.Source == configuration.PersonaSourceProject {
+			source = "project"
+		}
+		fmt.Printf("  %-22s %-8s %-8s %s\n", id, source, status, persona.Description)
+	}
+	fmt.Println()
+	fmt.Println("Use 'ledit personas show <id>' for details, 'ledit personas create <id>' to add a project persona.")
+	return nil
+}
+
+func findPersona(config *configuration.Config, name string) (string, configuration.SubagentType, bool) {
+	normalized := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), "-", "_")
+	for id, persona := range config.SubagentTypes {
+		if strings.EqualFold(id, normalized) || strings.EqualFold(persona.Name, name) {
+			return id, persona, true
+		}
+	}
+	return "", configuration.SubagentType{}, false
+}
+
+func printPersona(id string, persona configuration.SubagentType) {
+	value := func(s, fallback string) string {
+		if strings.TrimSpace(s) == "" {
+			return fallback
+		}
+		return s
+	}
+	source := "global"
+	if persona.Source == configuration.PersonaSourceProject {
+		source = "project"
+	}
+	fmt.Printf("%s (%s)\n", persona.Name, id)
+	fmt.Printf("  Description: %s\n", persona.Description)
+	fmt.Printf("  Source:      %s\n", source)
+	fmt.Printf("  Enabled:     %t\n", persona.Enabled)
+	fmt.Printf("  Provider:    %s\n", value(persona.Provider, "<default>"))
+	fmt.Printf("  Model:       %s\n", value(persona.Model, "<default>"))
+	if persona.MaxTokens > 0 {
+		fmt.Printf("  Budget:      %d tokens\n", persona.MaxTokens)
+	} else {
+		fmt.Printf("  Budget:      <default>\n")
+	}
+	if len(persona.Aliases) > 0 {
+		fmt.Printf("  Aliases:     %s\n", strings.Join(persona.Aliases, ", "))
+	}
+	if len(persona.AllowedTools) > 0 {
+		fmt.Printf("  Tools:       %s\n", strings.Join(persona.AllowedTools, ", "))
+	} else {
+		fmt.Printf("  Tools:       <all>\n")
+	}
+	if persona.SystemPromptText != "" {
+		fmt.Printf("  System prompt:\n%s\n", persona.SystemPromptText)
+	} else {
+		fmt.Printf("  System prompt: %s\n", value(persona.SystemPrompt, "<default>"))
+	}
+}
+
+func sortedPersonaIDs(personas map[string]configuration.SubagentType) []string {
+	ids := make([]string, 0, len(personas))
+	for id := range personas {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func init() {
+	rootCmd.AddCommand(personasCmd)
+	personasCmd.AddCommand(personasListCmd, personasShowCmd, personasCreateCmd, personasEditCmd, personasRemoveCmd, personasValidateCmd)
+
+	for _, c := range []*cobra.Command{personasCreateCmd, personasEditCmd} {
+		c.Flags().String("name", "", "Display name")
+		c.Flags().String("description", "", "What the persona is for; shown to the orchestrating model")
+		c.Flags().String("provider", "", "Provider for the persona's subagents")
+		c.Flags().String("model", "", "Model for the persona's subagents")
+		c.Flags().Int("max-tokens", 0, "Token budget per subagent run (0 uses the default)")
+		c.Flags().StringSlice("tools", nil, "Comma-separated tool allowlist (empty allows all tools)")
+		c.Flags().StringSlice("aliases", nil, "Comma-separated alternative IDs")
+		c.Flags().String("prompt", "", "Inline system prompt")
+		c.Flags().String("prompt-file", "", "System prompt file, relative to the project root")
+	}
+	personasEditCmd.Flags().Bool("enabled", true, "Whether the persona can be used")
+}
@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alantheprice/ledit/pkg/configuration"
+	modelsettings "github.com/alantheprice/ledit/pkg/model_settings"
+	"github.com/spf13/cobra"
+)
+
+var pricingCmd = &cobra.Command{
+	Use:   "pricing [provider/model...]",
+	Short: "Show the model prices used for cost reporting",
+	Long: `Show the per-million-token prices ledit uses to estimate request costs when
+a provider doesn't report them, and where each price comes from.
+
+Prices are taken, in order of precedence, from the pricing.models entries
+in config.json, from prices refreshed from the configured source (by default
+OpenRouter's model list, cached in the config directory and refreshed daily),
+and from the built-in model registry.
+
+Without arguments, the configured provider's model is shown.
+
+Commands:
+  refresh - Fetch prices from the source now`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		manager, err := configuration.NewManagerSilent()
+		if err != nil {
+			return err
+		}
+		cfg := manager.GetConfig()
+		if len(args) == 0 {
+			provider := cfg.LastUsedProvider
+			args = []string{provider + "/" + cfg.GetModelForProvider(provider)}
+		}
+
+		if table := modelsettings.CurrentPriceTable(); table != nil {
+			fmt.Printf("Refreshed prices: %d models from %s, fetched %s\n\n", len(table.Models), table.Source, table.FetchedAt.Format("2006-01-02 15:04"))
+		} else {
+			fmt.Println("Refreshed prices: none yet; run 'ledit pricing refresh'")
+			fmt.Println()
+		}
+		for _, arg := range args {
+			provider, model, ok := strings.Cut(arg, "/")
+			if !ok {
+				provider, model = cfg.LastUsedProvider, arg
+			}
+			caps := cfg.ResolveModelCapabilities(provider, model)
+			if caps.PriceSource == "" {
+				fmt.Printf("  %s/%s: no price known; add one under pricing.models in config.json\n", provider, model)
+				continue
+			}
+			fmt.Printf("  %s/%s: $%.3f/M input, $%.3f/M output (%s)\n", provider, model, caps.InputCostPerMTok, caps.OutputCostPerMTok, caps.PriceSource)
+		}
+		return nil
+	},
+}
+
+var pricingRefreshCmd = &cobra.Command{
+	Use:   "refresh",
+	Short: "Fetch model prices from the configured source now",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		manager, err := configuration.NewManagerSilent()
+		if err != nil {
+			return err
+		}
+		path, err := pricingCachePath()
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		table, err := modelsettings.RefreshPriceTable(ctx, path, manager.GetConfig().GetPricing().SourceURL)
+		if err != nil {
+			return err
+		}
+		modelsettings.SetPriceTable(table)
+		fmt.Printf("[ok] %d model prices from %s\n", len(table.Models), table.Source)
+
+		overrides := manager.GetConfig().GetPricing().Models
+		if len(overrides) > 0 {
+			names := make([]string, 0, len(overrides))
+			for name := range overrides {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			fmt.Printf("pricing.models in config.json still overrides: %s\n", strings.Join(names, ", "))
+		}
+		return nil
+	},
+}
+
+func pricingCachePath() (string, error) {
+	configDir, err := configuration.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "cache", "pricing.json"), nil
+}
+
+// loadModelPrices applies the cached refreshed prices and, when they are
+// older than pricing.refresh_hours and refresh is set, fetches new ones in
+// the background. Subagents use their parent's cache without refreshing.
+func loadModelPrices(cfg *configuration.Config, refresh bool) {
+	if cfg == nil {
+		return
+	}
+	pricing := cfg.GetPricing()
+	path, err := pricingCachePath()
+	if err != nil {
+		return
+	}
+	table, err := modelsettings.LoadPriceTable(path)
+	if err != nil || (table != nil && table.Source != pricing.SourceURL) {
+		table = nil
+	}
+	modelsettings.SetPriceTable(table)
+
+	if !refresh || !*pricing.AutoRefresh || os.Getenv("LEDIT_SUBAGENT") == "1" || configuration.IsOffline() ||
+		!table.Stale(time.Duration(pricing.RefreshHours)*time.Hour) {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+		if refreshed, err := modelsettings.RefreshPriceTable(ctx, path, pricing.SourceURL); err == nil {
+			modelsettings.SetPriceTable(refreshed)
+		}
+	}()
+}
+
+func init() {
+	rootCmd.AddCommand(pricingCmd)
+	pricingCmd.AddCommand(pricingRefreshCmd)
+}
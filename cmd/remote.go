@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/alantheprice/ledit/pkg/agent"
+	"github.com/alantheprice/ledit/pkg/configuration"
+	"github.com/alantheprice/ledit/pkg/remote"
+	"github.com/spf13/cobra"
+)
+
+var remoteCmd = &cobra.Command{
+	Use:   "remote",
+	Short: "Run shell commands on a remote host over SSH",
+	Long: `Check and use the remote host configured under "remote" in config.json.
+
+When it is set, the agent's shell commands, builds and tests run on the host
+in the directory the workspace maps to, while the console and the file tools
+stay local. Files changed locally are sent with each command, and files the
+command changes on the host are brought back after it exits.
+
+Commands:
+  check - Connect and show the directory mapping and round-trip time
+  push  - Send the whole workspace to the host
+  run   - Run a command on the host the way the agent does`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return checkRemote(cmd.Context())
+	},
+}
+
+var remoteCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Connect and show the directory mapping and round-trip time",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return checkRemote(cmd.Context())
+	},
+}
+
+var remotePushCmd = &cobra.Command{
+	Use:   "push",
+	Short: "Send the whole workspace to the host",
+	Long: `Send every file in the workspace, outside the excluded directories, to the
+directory it maps to on the host. The agent does this with its first command
+in a session; pushing ahead of time saves that wait.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		host, err := configuredRemoteHost()
+		if err != nil {
+			return err
+		}
+		defer host.Close()
+		stats, err := host.Push(cmd.Context())
+		if err != nil {
+			return err
+		}
+		fmt.Printf("[ok] Sent %d files to %s\n", stats.Sent, host.Describe())
+		return nil
+	},
+}
+
+var remoteRunCmd = &cobra.Command{
+	Use:   "run <command>",
+	Short: "Run a command on the host the way the agent does",
+	Long: `Run a shell command on the host in the directory the current directory maps
+to, syncing files both ways as the agent's shell commands do.
+
+Example:
+  ledit remote run go test ./...`,
+	Args:               cobra.MinimumNArgs(1),
+	DisableFlagParsing: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		host, err := configuredRemoteHost()
+		if err != nil {
+			return err
+		}
+		dir, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer stop()
+
+		run, finish, err := host.Command(ctx, dir, strings.Join(args, " "))
+		if err != nil {
+			return err
+		}
+		var output strings.Builder
+		run.Stdout = &output
+		run.Stderr = &output
+		runErr := run.Run()
+		result, err := finish(output.String())
+		fmt.Println(strings.TrimRight(result, "\n"))
+		if err != nil {
+			return err
+		}
+		var exitErr *exec.ExitError
+		if errors.As(runErr, &exitErr) {
+			os.Exit(exitErr.ExitCode())
+		}
+		return runErr
+	},
+}
+
+// configuredRemoteHost returns the host for the current directory.
+func configuredRemoteHost() (*remote.Host, error) {
+	manager, err := configuration.NewManagerSilent()
+	if err != nil {
+		return nil, err
+	}
+	cfg := manager.GetConfig()
+	if cfg.Remote == nil || strings.TrimSpace(cfg.Remote.Host) == "" {
+		return nil, errors.New(`no remote host configured; add a "remote" section to config.json, e.g. {"remote": {"host": "dev@buildbox"}}`)
+	}
+	root, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current directory: %w", err)
+	}
+	return remote.New(agent.RemoteConfig(cfg.Remote), root)
+}
+
+func checkRemote(ctx context.Context) error {
+	host, err := configuredRemoteHost()
+	if err != nil {
+		return err
+	}
+	root, _ := os.Getwd()
+	fmt.Printf("Workspace: %s\n", root)
+	rtt, err := host.Connect(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Host:      %s\n", host.Describe())
+	fmt.Printf("Latency:   %s per command round trip", rtt.Round(time.Millisecond))
+	if host.Compressed() {
+		fmt.Print(" (transfers are compressed)")
+	}
+	fmt.Println()
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(remoteCmd)
+	remoteCmd.AddCommand(remoteCheckCmd, remotePushCmd, remoteRunCmd)
+}
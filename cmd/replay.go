@@ -0,0 +1,147 @@
+// Replay command for ledit: re-runs a recorded agent run offline
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/alantheprice/ledit/pkg/agent"
+	"github.com/alantheprice/ledit/pkg/configuration"
+	"github.com/alantheprice/ledit/pkg/events"
+	"github.com/spf13/cobra"
+)
+
+var replayCmd = &cobra.Command{
+	Use:   "replay <run-id|archive-path>",
+	Short: "Replay a recorded agent run with mocked LLM responses",
+	Long: `Replay a run recorded with 'ledit agent --record'.
+
+The recorded LLM responses are served in order instead of calling a provider,
+so the agent re-executes the same tool calls offline. After the replay, the
+executed tool sequence is compared with the recorded one and any divergence
+is reported. The command exits non-zero when the sequences differ.
+
+Tools run for real against the current working directory, so replay from a
+checkout that matches the state the run was recorded in.
+
+Examples:
+  # Replay a recorded run by ID
+  ledit replay run_20260101_120000_123456
+
+  # Replay an archive file directly
+  ledit replay ./runs/run_20260101_120000_123456.json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := agent.ResolveRunArchivePath(args[0])
+		if err != nil {
+			return err
+		}
+		archive, err := agent.LoadRunArchive(path)
+		if err != nil {
+			return err
+		}
+
+		diverged, err := replayRun(archive)
+		if err != nil {
+			return err
+		}
+		if diverged {
+			os.Exit(exitCodeFailure)
+		}
+		return nil
+	},
+}
+
+// replayRun replays archive and prints a report. It reports whether the
+// replayed tool sequence diverged from the recorded one.
+func replayRun(archive *agent.RunArchive) (bool, error) {
+	if len(archive.Queries) == 0 {
+		return false, fmt.Errorf("run %s has no recorded queries", archive.RunID)
+	}
+	if cwd, err := os.Getwd(); err == nil && archive.WorkingDirectory != "" && cwd != archive.WorkingDirectory {
+		fmt.Fprintf(os.Stderr, "[WARN] Run was recorded in %s; replaying in %s\n", archive.WorkingDirectory, cwd)
+	}
+
+	chatAgent, replayClient, err := agent.NewReplayAgent(archive)
+	if err != nil {
+		return false, fmt.Errorf("failed to create replay agent: %w", err)
+	}
+	defer chatAgent.Shutdown()
+
+	// Record the replay in memory so its tool sequence can be compared; the
+	// archive is never saved.
+	recorder, err := agent.NewRunRecorder(os.TempDir(), archive.Provider, archive.Model)
+	if err != nil {
+		return false, err
+	}
+	chatAgent.EnableRunRecording(recorder)
+
+	if err := chatAgent.GetConfigManager().UpdateConfigNoSave(func(cfg *configuration.Config) error {
+		cfg.SkipPrompt = true
+		return nil
+	}); err != nil {
+		return false, err
+	}
+
+	eventBus := events.NewEventBus()
+	chatAgent.SetEventBus(eventBus)
+	SetupAgentEvents(chatAgent, eventBus)
+
+	fmt.Printf("Replaying run %s (%s/%s): %d queries, %d LLM exchanges, %d tool calls\n",
+		archive.RunID, archive.Provider, archive.Model,
+		len(archive.Queries), len(archive.Exchanges), len(archive.ToolCalls))
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	for i, query := range archive.Queries {
+		if err := ProcessQuery(ctx, chatAgent, eventBus, query); err != nil {
+			fmt.Fprintf(os.Stderr, "[WARN] Replay of query %d stopped: %v\n", i+1, err)
+			break
+		}
+	}
+
+	replayed := recorder.Archive()
+	divergences := agent.CompareToolSequences(archive.ToolCalls, replayed.ToolCalls)
+	fmt.Println()
+	fmt.Printf("Tool calls: recorded %d, replayed %d\n", len(archive.ToolCalls), len(replayed.ToolCalls))
+	if remaining := replayClient.Remaining(); remaining > 0 {
+		fmt.Printf("Unused recorded LLM responses: %d\n", remaining)
+	}
+	if divergent := replayClient.DivergentRequests(); len(divergent) > 0 {
+		fmt.Printf("LLM requests with a different conversation length: %v\n", divergent)
+	}
+	if len(divergences) == 0 {
+		fmt.Println("✅ Replay matched the recorded tool sequence")
+		return false, nil
+	}
+
+	fmt.Printf("❌ Replay diverged at %d tool call(s):\n", len(divergences))
+	for _, d := range divergences {
+		fmt.Printf("  #%d %s: expected %s, got %s\n", d.Index, d.Reason, describeToolCall(d.Expected), describeToolCall(d.Actual))
+	}
+	return true, nil
+}
+
+func describeToolCall(call *agent.RecordedToolCall) string {
+	if call == nil {
+		return "(none)"
+	}
+	return fmt.Sprintf("%s(%s)", call.ToolName, call.Arguments)
+}
+
+// saveRunRecording writes the agent's run archive, if recording is enabled.
+func saveRunRecording(chatAgent *agent.Agent) {
+	recorder := chatAgent.GetRunRecorder()
+	if recorder == nil {
+		return
+	}
+	path, err := recorder.Save()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[WARN] Failed to save run recording: %v\n", err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Run recorded: %s (%s)\nReplay with: ledit replay %s\n", recorder.RunID(), path, recorder.RunID())
+}
@@ -0,0 +1,253 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/alantheprice/ledit/pkg/codereview"
+	"github.com/alantheprice/ledit/pkg/configuration"
+	"github.com/alantheprice/ledit/pkg/forge"
+	"github.com/alantheprice/ledit/pkg/git"
+)
+
+// defaultReviewOutput is where the review JSON is written, relative to the
+// repository root.
+const defaultReviewOutput = ".ledit/review.json"
+
+// pullRequestTargetPattern matches targets that name a pull request rather
+// than a revision range: a URL, or a number such as 12 or #12.
+var pullRequestTargetPattern = regexp.MustCompile(`^(?:#?[0-9]+|https?://.+)$`)
+
+// reviewTarget is the diff a review covers.
+type reviewTarget struct {
+	name  string // e.g. "main..HEAD" or "acme/app#12"
+	about string // what the change is, for the reviewer
+	head  string // revision the new versions of the files are at
+	diff  string
+
+	// Set when the target is a pull request
+	client forge.Forge
+	pr     *forge.PullRequest
+}
+
+// runDiffReview reviews a revision range or pull request with the reviewer
+// persona, chunk by chunk, and reports the ranked findings.
+func runDiffReview(ctx context.Context, target string) error {
+	root, err := git.GetGitRootDir()
+	if err != nil {
+		return err
+	}
+	var resolved *reviewTarget
+	if pullRequestTargetPattern.MatchString(target) {
+		resolved, err = resolvePullRequestTarget(ctx, root, target)
+	} else {
+		resolved, err = resolveRangeTarget(root, target)
+	}
+	if err != nil {
+		return err
+	}
+	if reviewStagedPost && resolved.pr == nil {
+		return fmt.Errorf("--post needs a pull request to post to, not a revision range")
+	}
+
+	chunks := codereview.ChunkDiff(resolved.diff, codereview.DefaultDiffChunkBytes, shouldSkipFileForContext)
+	if len(chunks) == 0 {
+		fmt.Printf("[OK] %s has no changes to review\n", resolved.name)
+		return nil
+	}
+	files := 0
+	for _, chunk := range chunks {
+		files += len(chunk.Files)
+	}
+	fmt.Printf("[review] Reviewing %s: %d file(s) in %d part(s)\n", resolved.name, files, len(chunks))
+
+	chatAgent, err := createReviewAgent(reviewStagedPersona)
+	if err != nil {
+		return err
+	}
+	defer chatAgent.Shutdown()
+
+	var findings []codereview.ReviewFinding
+	for i, chunk := range chunks {
+		fmt.Printf("[review] Part %d/%d: %s\n", i+1, len(chunks), strings.Join(chunk.Files, ", "))
+		chatAgent.ClearConversationHistory()
+		reply, err := chatAgent.ProcessQuery(codereview.DiffReviewPrompt(chunk, resolved.about, resolved.head, i+1, len(chunks)))
+		if err != nil {
+			return fmt.Errorf("review of part %d failed: %w", i+1, err)
+		}
+		found, err := codereview.ParseReviewFindings(reply)
+		if err != nil {
+			fmt.Printf("[WARN] Skipping part %d: %v\n", i+1, err)
+			continue
+		}
+		findings = append(findings, found...)
+	}
+
+	result := codereview.BuildDiffReviewResult(resolved.name, codereview.RankReviewFindings(findings), codereview.DiffLines(resolved.diff))
+	printDiffReviewSummary(result)
+	if err := writeDiffReviewResult(root, result); err != nil {
+		return err
+	}
+
+	if reviewStagedPost {
+		review := forge.Review{Body: result.Summary, CommitSHA: resolved.head}
+		for _, c := range result.Comments {
+			review.Comments = append(review.Comments, forge.ReviewComment{Path: c.Path, Line: c.Line, Body: c.Body})
+		}
+		if err := resolved.client.PostReview(ctx, resolved.pr.Number, review); err != nil {
+			return fmt.Errorf("failed to post the review: %w", err)
+		}
+		fmt.Printf("[ok] Posted %d inline comment(s) to %s\n", len(review.Comments), resolved.pr.URL)
+	}
+	return nil
+}
+
+// resolveRangeTarget diffs a revision range. A single revision means the
+// changes on HEAD since it (rev...HEAD).
+func resolveRangeTarget(root, target string) (*reviewTarget, error) {
+	spec := target
+	if !strings.Contains(target, "..") {
+		spec = target + "...HEAD"
+	}
+	i := strings.Index(spec, "..")
+	base, head := spec[:i], strings.TrimLeft(spec[i:], ".")
+	if head == "" {
+		head = "HEAD"
+	}
+	if base != "" {
+		if _, err := reviewGit(root, "rev-parse", "--verify", "--quiet", base+"^{commit}"); err != nil {
+			return nil, fmt.Errorf("unknown revision %q", base)
+		}
+	}
+	headSHA, err := reviewGit(root, "rev-parse", "--verify", "--quiet", head+"^{commit}")
+	if err != nil {
+		return nil, fmt.Errorf("unknown revision %q", head)
+	}
+	diff, err := reviewGit(root, "diff", "--no-color", "--no-ext-diff", spec, "--")
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff %s: %w", spec, err)
+	}
+	log, _ := reviewGit(root, "log", "--no-merges", "--format=- %s", spec, "--")
+	about := "Revision range " + spec
+	if log != "" {
+		about += "\n\nCommits:\n" + log
+	}
+	return &reviewTarget{name: target, about: about, head: headSHA, diff: diff}, nil
+}
+
+// resolvePullRequestTarget fetches a pull request's head and base and diffs
+// them the way the forge shows the change (base...head).
+func resolvePullRequestTarget(ctx context.Context, root, target string) (*reviewTarget, error) {
+	cfg, err := configuration.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	ws, _ := forge.Detect(root, cfg.ForgeHosts)
+	var origin *forge.Repo
+	if ws != nil {
+		origin = &ws.Repo
+	}
+	repo, number, err := forge.ParsePullRequestRef(target, origin, cfg.ForgeHosts)
+	if err != nil {
+		return nil, err
+	}
+	token, err := forge.ResolveToken(repo.Kind)
+	if err != nil {
+		return nil, err
+	}
+	client, err := forge.New(repo, token)
+	if err != nil {
+		return nil, err
+	}
+	pr, err := client.GetPullRequest(ctx, number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pull request #%d: %w", number, err)
+	}
+
+	remote := "origin"
+	if origin == nil || *origin != repo {
+		remote = fmt.Sprintf("https://%s/%s.git", repo.Host, repo.FullName())
+	}
+	if _, err := reviewGit(root, "fetch", "--quiet", "--no-tags", remote, forge.PullRequestRef(repo.Kind, number)); err != nil {
+		return nil, fmt.Errorf("failed to fetch pull request #%d: %w", number, err)
+	}
+	head, err := reviewGit(root, "rev-parse", "FETCH_HEAD")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := reviewGit(root, "fetch", "--quiet", "--no-tags", remote, pr.BaseBranch); err != nil {
+		return nil, fmt.Errorf("failed to fetch base branch %s: %w", pr.BaseBranch, err)
+	}
+	base, err := reviewGit(root, "rev-parse", "FETCH_HEAD")
+	if err != nil {
+		return nil, err
+	}
+	diff, err := reviewGit(root, "diff", "--no-color", "--no-ext-diff", base+"..."+head)
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff pull request #%d: %w", number, err)
+	}
+
+	name := fmt.Sprintf("%s#%d", repo.FullName(), number)
+	about := fmt.Sprintf("Pull request %s: %s", name, pr.Title)
+	if body := strings.TrimSpace(pr.Body); body != "" {
+		about += "\n\n" + body
+	}
+	return &reviewTarget{name: name, about: about, head: head, diff: diff, client: client, pr: pr}, nil
+}
+
+// reviewGit runs git in root and returns its trimmed output.
+func reviewGit(root string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = root
+	output, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("%s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", err
+	}
+	return strings.TrimRight(string(output), "\n"), nil
+}
+
+func printDiffReviewSummary(result codereview.DiffReviewResult) {
+	fmt.Println("\n--- Code Review ---")
+	if len(result.Findings) == 0 {
+		fmt.Println("[OK] No issues found")
+		return
+	}
+	for _, finding := range result.Findings {
+		fmt.Printf("[%s] %s: %s\n", strings.ToUpper(finding.Severity), finding.Location(), finding.Title)
+	}
+	fmt.Printf("\n%d finding(s), %d as inline comment(s)\n", len(result.Findings), len(result.Comments))
+}
+
+// writeDiffReviewResult writes the review as JSON to --output, or to stdout
+// for "-".
+func writeDiffReviewResult(root string, result codereview.DiffReviewResult) error {
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return err
+	}
+	output := reviewStagedOutput
+	if output == "-" {
+		fmt.Println(string(data))
+		return nil
+	}
+	if output == "" {
+		output = filepath.Join(root, defaultReviewOutput)
+		if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+			return err
+		}
+	}
+	if err := os.WriteFile(output, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", output, err)
+	}
+	fmt.Printf("[ok] Wrote the review to %s\n", output)
+	return nil
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alantheprice/ledit/pkg/agent"
+	"github.com/alantheprice/ledit/pkg/codereview"
+	"github.com/alantheprice/ledit/pkg/git"
+)
+
+// maxSecurityReviewFiles caps the files listed in the security review
+// prompt; the persona can still search the rest of the repository.
+const maxSecurityReviewFiles = 200
+
+// runSecurityReview has the security persona review the changed files, or
+// every tracked file with all, and records and prints its findings.
+func runSecurityReview(all bool, report string) error {
+	root, err := git.GetGitRootDir()
+	if err != nil {
+		return err
+	}
+	files, err := securityReviewFiles(root, all)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		fmt.Println("[OK] No changed files to review; use --all to review the whole repository")
+		return nil
+	}
+	scope := "changed file(s)"
+	if all {
+		scope = "tracked file(s)"
+	}
+	fmt.Printf("[security] Reviewing %d %s\n", len(files), scope)
+
+	chatAgent, err := createReviewAgent(codereview.SecurityPersona)
+	if err != nil {
+		return err
+	}
+	defer chatAgent.Shutdown()
+
+	listed := files
+	if len(listed) > maxSecurityReviewFiles {
+		listed = append(listed[:maxSecurityReviewFiles:maxSecurityReviewFiles], fmt.Sprintf("... and %d more", len(files)-maxSecurityReviewFiles))
+	}
+	reply, err := chatAgent.ProcessQuery(codereview.SecurityReviewPrompt(listed, all))
+	if err != nil {
+		return fmt.Errorf("security review failed: %w", err)
+	}
+	findings, err := codereview.ParseSecurityFindings(reply)
+	if err != nil {
+		return err
+	}
+	if _, err := codereview.RecordSecurityFindings(root, files, findings, time.Now()); err != nil {
+		return err
+	}
+
+	rendered := codereview.RenderSecurityReport(findings)
+	fmt.Printf("\n%s\n", rendered)
+	if report != "" {
+		if err := os.WriteFile(report, []byte(rendered), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", report, err)
+		}
+		fmt.Printf("[ok] Wrote the report to %s\n", report)
+	}
+	fmt.Printf("[ok] Recorded %d finding(s) in %s\n", len(findings), codereview.SecurityFindingsFile)
+	return nil
+}
+
+// securityReviewFiles lists the files to review: the tracked files with
+// all, otherwise those with staged or unstaged changes and new untracked
+// files. Deleted, generated and binary files are left out.
+func securityReviewFiles(root string, all bool) ([]string, error) {
+	var commands [][]string
+	if all {
+		commands = [][]string{{"ls-files"}}
+	} else {
+		commands = [][]string{
+			{"diff", "--name-only", "--cached"},
+			{"diff", "--name-only"},
+			{"ls-files", "--others", "--exclude-standard"},
+		}
+	}
+	seen := make(map[string]bool)
+	var files []string
+	for _, args := range commands {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = root
+		output, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list files with git %s: %w", strings.Join(args, " "), err)
+		}
+		for _, file := range strings.Split(string(output), "\n") {
+			file = strings.TrimSpace(file)
+			if file == "" || seen[file] || shouldSkipFileForContext(file) {
+				continue
+			}
+			seen[file] = true
+			if info, err := os.Stat(filepath.Join(root, file)); err != nil || info.IsDir() {
+				continue
+			}
+			files = append(files, file)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// createReviewAgent creates an agent running persona, with the model from
+// --model when set.
+func createReviewAgent(persona string) (*agent.Agent, error) {
+	var chatAgent *agent.Agent
+	var err error
+	if reviewStagedModel != "" {
+		chatAgent, err = agent.NewAgentWithModel(reviewStagedModel)
+	} else {
+		chatAgent, err = agent.NewAgent()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize agent: %w", err)
+	}
+	if err := chatAgent.ApplyPersona(persona); err != nil {
+		chatAgent.Shutdown()
+		return nil, err
+	}
+	return chatAgent, nil
+}
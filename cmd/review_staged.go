@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
@@ -19,14 +20,64 @@ import (
 var (
 	reviewStagedModel      string
 	reviewStagedSkipPrompt bool // Not strictly necessary for review, but consistent with other commands
+	reviewStagedSecurity   bool
+	reviewStagedAll        bool
+	reviewStagedReport     string
+	reviewStagedPersona    string
+	reviewStagedOutput     string
+	reviewStagedPost       bool
 )
 
 var reviewStagedCmd = &cobra.Command{
-	Use:   "review",
-	Short: "Perform an AI-powered code review on staged Git changes",
+	Use:   "review [range|PR]",
+	Short: "Perform an AI-powered code review on staged Git changes, a revision range or a PR",
 	Long: `This command uses an LLM to review your currently staged Git changes.
-It provides feedback on code quality, potential issues, and suggestions for improvement.`,
+It provides feedback on code quality, potential issues, and suggestions for improvement.
+
+Given a revision range (main..HEAD, or a single revision meaning rev...HEAD) or
+a pull request (its URL, or its number in the origin repository), the reviewer
+persona reviews that diff instead, in chunks of whole files or hunks. Findings
+are de-duplicated and ranked by severity, printed as a summary, and written as
+inline-comment-ready JSON to .ledit/review.json (or --output). With --post they
+are posted to the pull request as a review.
+
+With --security, the security_reviewer persona reviews the changed files (staged,
+unstaged and untracked), or every tracked file with --all. It traces untrusted
+input to dangerous sinks and reports findings with severity, CWE, file, line and
+remediation. Findings are kept in .ledit/security_findings.json: findings for the
+reviewed files are replaced by the new review, others are kept.
+
+Examples:
+  ledit review
+  ledit review main..HEAD
+  ledit review https://github.com/acme/app/pull/12 --post
+  ledit review --security
+  ledit review --security --all --report security-report.md`,
+	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
+		if reviewStagedSecurity && len(args) > 0 {
+			fmt.Fprintln(os.Stderr, "Error: --security reviews the changed or tracked files and takes no range")
+			os.Exit(1)
+		}
+		if len(args) == 1 {
+			ctx := cmd.Context()
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			if err := runDiffReview(ctx, args[0]); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+		if reviewStagedSecurity {
+			if err := runSecurityReview(reviewStagedAll, reviewStagedReport); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+
 		logger := utils.GetLogger(reviewStagedSkipPrompt)
 
 		cfg, err := configuration.LoadOrInitConfig(reviewStagedSkipPrompt)
@@ -178,6 +229,12 @@ It provides feedback on code quality, potential issues, and suggestions for impr
 func init() {
 	reviewStagedCmd.Flags().StringVarP(&reviewStagedModel, "model", "m", "", "Specify the LLM model to use for the code review (e.g., 'ollama:llama3')")
 	reviewStagedCmd.Flags().BoolVar(&reviewStagedSkipPrompt, "skip-prompt", false, "Skip any interactive prompts (e.g., for confirmation, though less relevant for review)")
+	reviewStagedCmd.Flags().BoolVar(&reviewStagedSecurity, "security", false, "Run a security review of the changed files with the security_reviewer persona")
+	reviewStagedCmd.Flags().BoolVar(&reviewStagedAll, "all", false, "With --security, review every tracked file instead of the changed ones")
+	reviewStagedCmd.Flags().StringVar(&reviewStagedReport, "report", "", "With --security, also write the Markdown report to this file")
+	reviewStagedCmd.Flags().StringVar(&reviewStagedPersona, "persona", codereview.ReviewPersona, "Persona that reviews a range or pull request")
+	reviewStagedCmd.Flags().StringVar(&reviewStagedOutput, "output", "", "File to write the review JSON of a range or pull request to, or - for stdout (default .ledit/review.json)")
+	reviewStagedCmd.Flags().BoolVar(&reviewStagedPost, "post", false, "Post the findings to the pull request as a review")
 }
 
 // detectProjectType detects the type of project based on files in the current directory
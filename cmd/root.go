@@ -8,12 +8,15 @@ import (
 
 	tools "github.com/alantheprice/ledit/pkg/agent_tools"
 	"github.com/alantheprice/ledit/pkg/configuration"
+	"github.com/alantheprice/ledit/pkg/console"
 	"github.com/alantheprice/ledit/pkg/pythonruntime"
 	"github.com/spf13/cobra"
 )
 
 var startupChecksOnce sync.Once
 var isolatedConfig bool
+var rootDryRun bool
+var configOverrides []string
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
@@ -37,6 +40,15 @@ For autonomous operation, try: ledit agent "your intent here"
 
 Running just 'ledit' without arguments starts enhanced agent mode with automatic web UI.`,
 	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		console.EnableVirtualTerminal()
+		if err := applyConfigOverrides(configOverrides); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := applyOfflineMode(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
 		if isolatedConfig {
 			cwd, err := os.Getwd()
 			if err != nil {
@@ -58,8 +70,18 @@ Running just 'ledit' without arguments starts enhanced agent mode with automatic
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Default to interactive mode when no arguments provided
-		useInteractive := len(args) == 0 && cmd.Flags().NFlag() == 0
+		flagCount := cmd.Flags().NFlag()
+		if rootDryRun {
+			flagCount--
+		}
+		if offlineMode {
+			flagCount--
+		}
+		useInteractive := len(args) == 0 && flagCount == 0
 		if useInteractive {
+			if rootDryRun {
+				_ = os.Setenv("LEDIT_DRY_RUN", "1")
+			}
 			chatAgent, err := createChatAgent()
 			if err != nil {
 				return fmt.Errorf("failed to initialize agent: %w", err)
@@ -89,12 +111,16 @@ func initializeSystem() {
 		if err != nil && os.Getenv("LEDIT_DEBUG") != "" {
 			println("API key initialization warning:", err.Error())
 		}
+		if cfg, err := configuration.Load(); err == nil {
+			loadModelPrices(cfg, false)
+			loadTokenizers(cfg)
+		}
 		return
 	}
 
 	// WebUI-first bootstrap: initialize silently without terminal prompts.
 	// First-run setup is completed through the WebUI onboarding flow.
-	_, err := configuration.NewManagerSilent()
+	manager, err := configuration.NewManagerSilent()
 	if err != nil {
 		// If initialization fails, print helpful error and exit
 		fmt.Fprintf(os.Stderr, "Failed to initialize ledit: %v\n", err)
@@ -103,6 +129,8 @@ func initializeSystem() {
 		os.Exit(1)
 	}
 
+	loadModelPrices(manager.GetConfig(), true)
+	loadTokenizers(manager.GetConfig())
 	runStartupChecks()
 }
 
@@ -125,18 +153,32 @@ func init() {
 
 	// rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.ledit.yaml)")
 	rootCmd.PersistentFlags().BoolVar(&isolatedConfig, "isolated-config", false, "Use per-working-directory config at ./.ledit (clone from main config on first run)")
+	rootCmd.PersistentFlags().StringArrayVar(&configOverrides, "set", nil, "Override a setting for this run, e.g. --set subagent_max_parallel=4 (repeatable)")
+	rootCmd.PersistentFlags().BoolVar(&offlineMode, "offline", false, "Work without the network: use the local model from offline.provider and disable web tools (or set LEDIT_OFFLINE=1)")
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
 	rootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+	rootCmd.Flags().BoolVar(&rootDryRun, "dry-run", false, "Start interactive mode with changes previewed instead of applied (toggle with /dryrun)")
 
 	rootCmd.AddCommand(agentCmd)
+	rootCmd.AddCommand(askCmd)
 	rootCmd.AddCommand(exportTrainingCmd)
+	rootCmd.AddCommand(exportTranscriptCmd)
+	rootCmd.AddCommand(replayCmd)
+	rootCmd.AddCommand(capabilitiesCmd)
 	rootCmd.AddCommand(commitCmd)
+	rootCmd.AddCommand(changelogCmd)
+	rootCmd.AddCommand(docsCmd)
+	rootCmd.AddCommand(genTestsCmd)
 	rootCmd.AddCommand(logCmd)
+	rootCmd.AddCommand(logsCmd)
+	rootCmd.AddCommand(serveCmd)
 	rootCmd.AddCommand(mcpCmd)
 	rootCmd.AddCommand(customModelCmd)
 	rootCmd.AddCommand(reviewStagedCmd)
 	rootCmd.AddCommand(shellCmd)
 	rootCmd.AddCommand(planCmd)
+	rootCmd.AddCommand(runTemplateCmd)
+	rootCmd.AddCommand(workOnCmd)
 }
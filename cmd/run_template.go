@@ -0,0 +1,226 @@
+// Run-template command for ledit: expands a declarative task template into an
+// execution plan and runs it
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"strings"
+	"syscall"
+
+	"github.com/alantheprice/ledit/pkg/agent"
+	"github.com/alantheprice/ledit/pkg/events"
+	"github.com/alantheprice/ledit/pkg/execplan"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var (
+	runTemplateModel    string
+	runTemplateProvider string
+	runTemplateParams   []string
+	runTemplateYes      bool
+	runTemplateDryRun   bool
+)
+
+func init() {
+	runTemplateCmd.Flags().StringVarP(&runTemplateModel, "model", "m", "", "Model name to use")
+	runTemplateCmd.Flags().StringVarP(&runTemplateProvider, "provider", "p", "", "Provider to use")
+	runTemplateCmd.Flags().StringArrayVarP(&runTemplateParams, "param", "P", nil, "Template parameter as key=value (repeatable)")
+	runTemplateCmd.Flags().BoolVarP(&runTemplateYes, "yes", "y", false, "Execute the drafted plan without asking for approval")
+	runTemplateCmd.Flags().BoolVar(&runTemplateDryRun, "dry-run", false, "Draft and print the plan without executing it")
+}
+
+var runTemplateCmd = &cobra.Command{
+	Use:   "run-template <template> [key=value ...]",
+	Short: "Run a declarative task template",
+	Long: `Run a reusable task template. A template is a YAML file describing a goal,
+parameters, constraints, target paths, a validation command and a budget.
+The agent expands it into an execution plan, which runs one step at a time
+once approved. The validation command runs after the last step.
+
+<template> is a file path or the name of a file in .ledit/templates/.
+
+Template format:
+  name: add-endpoint
+  goal: Add a {{method}} {{route}} endpoint backed by {{handler}}
+  parameters:
+    - name: method
+      default: GET
+    - name: route
+      required: true
+    - name: handler
+      required: true
+  constraints:
+    - Register the route next to the existing ones in internal/api/routes.go
+  paths: [internal/api/]
+  validation: go test ./internal/api/...
+  budget:
+    max_steps: 6        # reject drafted plans with more steps
+    max_iterations: 40  # agent iterations per step
+    max_cost: 0.50      # USD; remaining steps are cancelled once exceeded
+
+Examples:
+  # Run .ledit/templates/add-endpoint.yaml
+  ledit run-template add-endpoint route=/users handler=ListUsers
+
+  # Same, with flags, without the approval prompt
+  ledit run-template add-endpoint -P route=/users -P handler=ListUsers --yes
+
+  # Only show the plan the template expands to
+  ledit run-template ./refactor.yaml --dry-run`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ok, err := runTemplate(args[0], append(args[1:], runTemplateParams...))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			os.Exit(exitCodeFailure)
+		}
+		return nil
+	},
+}
+
+// runTemplate loads, expands, plans and executes a template. It reports
+// whether every step completed and validation passed.
+func runTemplate(nameOrPath string, paramArgs []string) (bool, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return false, err
+	}
+	path, err := execplan.ResolveTemplatePath(cwd, nameOrPath)
+	if err != nil {
+		return false, err
+	}
+	tmpl, err := execplan.LoadTemplate(path)
+	if err != nil {
+		return false, err
+	}
+	values, err := execplan.ParseParameterArgs(paramArgs)
+	if err != nil {
+		return false, err
+	}
+	expanded, err := tmpl.Expand(values)
+	if err != nil {
+		return false, fmt.Errorf("template %s: %w", tmpl.Name, err)
+	}
+	tmpl = expanded
+
+	chatAgent, err := createTemplateAgent()
+	if err != nil {
+		return false, err
+	}
+	defer chatAgent.Shutdown()
+	if tmpl.Budget.MaxIterations > 0 {
+		chatAgent.SetMaxIterations(tmpl.Budget.MaxIterations)
+	}
+
+	runner, err := execplan.NewAgentRunner(chatAgent)
+	if err != nil {
+		return false, err
+	}
+
+	fmt.Printf("[*] Drafting plan for template %s (%s/%s)...\n", tmpl.Name, chatAgent.GetProvider(), chatAgent.GetModel())
+	plan, err := runner.CreateTemplatePlan(tmpl)
+	if err != nil {
+		return false, err
+	}
+	fmt.Printf("\n%s\n", execplan.Render(plan))
+	if runTemplateDryRun {
+		return true, nil
+	}
+	if !runTemplateYes && term.IsTerminal(int(os.Stdin.Fd())) {
+		answer, err := promptLine(bufio.NewReader(os.Stdin), "Execute this plan? [y/N]: ")
+		if err != nil {
+			return false, err
+		}
+		if !isYes(answer) {
+			fmt.Println("Plan discarded.")
+			return true, nil
+		}
+	}
+
+	eventBus := events.NewEventBus()
+	chatAgent.SetEventBus(eventBus)
+	SetupAgentEvents(chatAgent, eventBus)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	runner.Execute = func(prompt string) error {
+		return ProcessQuery(ctx, chatAgent, eventBus, prompt)
+	}
+	runner.OnProgress = func(p execplan.Progress) {
+		if p.Step.Status == execplan.StepInProgress {
+			fmt.Printf("\n[>] Step %d/%d: %s\n", p.Index, p.Total, p.Step.Title)
+		}
+	}
+	if max := tmpl.Budget.MaxCost; max > 0 {
+		startCost := chatAgent.GetTotalCost()
+		runner.Budget = func() error {
+			if spent := chatAgent.GetTotalCost() - startCost; spent >= max {
+				return fmt.Errorf("cost budget of $%.2f reached ($%.4f spent)", max, spent)
+			}
+			return nil
+		}
+	}
+
+	if err := runner.ExecutePlan(ctx, plan); err != nil {
+		return false, err
+	}
+	completed, failed, cancelled := plan.Counts()
+	fmt.Printf("\n%s\n", execplan.Render(plan))
+	fmt.Printf("Steps: %d completed, %d failed, %d cancelled\n", completed, failed, cancelled)
+	ok := failed == 0 && cancelled == 0
+
+	if tmpl.Validation != "" {
+		fmt.Printf("\n[*] Validating: %s\n", tmpl.Validation)
+		if err := runValidationCommand(ctx, tmpl.Validation); err != nil {
+			fmt.Printf("[FAIL] Validation failed: %v\n", err)
+			ok = false
+		} else {
+			fmt.Println("[OK] Validation passed")
+		}
+	}
+	return ok, nil
+}
+
+// createTemplateAgent creates an agent for the --model and --provider flags.
+func createTemplateAgent() (*agent.Agent, error) {
+	var chatAgent *agent.Agent
+	var err error
+
+	if runTemplateProvider != "" && runTemplateModel != "" {
+		chatAgent, err = agent.NewAgentWithModel(fmt.Sprintf("%s:%s", runTemplateProvider, runTemplateModel))
+	} else if runTemplateModel != "" {
+		chatAgent, err = agent.NewAgentWithModel(runTemplateModel)
+	} else {
+		chatAgent, err = agent.NewAgent()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize agent: %w", err)
+	}
+	return chatAgent, nil
+}
+
+// runValidationCommand runs command in the current directory, streaming its
+// output.
+func runValidationCommand(ctx context.Context, command string) error {
+	var c *exec.Cmd
+	if runtime.GOOS == "windows" {
+		c = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		c = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	if err := c.Run(); err != nil {
+		return fmt.Errorf("%s: %w", strings.TrimSpace(command), err)
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/alantheprice/ledit/pkg/jobs"
+	"github.com/alantheprice/ledit/pkg/schedule"
+	"github.com/spf13/cobra"
+)
+
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "Run agent tasks on a schedule",
+	Long: `Run agent tasks on cron schedules, such as a weekly changelog update.
+
+Tasks are stored in the project's .ledit/schedule.json. 'ledit schedule run'
+checks them every minute and starts each due task as a headless background
+agent in the project, the same way /bg does. Runs missed while the daemon is
+not running are skipped.
+
+Commands:
+  add     - Schedule a task
+  list    - List scheduled tasks with their next and last runs
+  remove  - Delete a scheduled task
+  run     - Run the scheduler in the foreground, logging JSON lines`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return listScheduledTasks()
+	},
+}
+
+var scheduleAddCmd = &cobra.Command{
+	Use:   "add <prompt>",
+	Short: "Schedule a task",
+	Long: `Schedule an agent task. The cron expression has five fields: minute, hour,
+day of month, month and day of week, in local time. @hourly, @daily, @weekly,
+@monthly and @yearly are accepted too.
+
+Example:
+  ledit schedule add "update CHANGELOG from merged PRs" --cron "0 9 * * 1"`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		root, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+		cronExpr, _ := cmd.Flags().GetString("cron")
+		provider, _ := cmd.Flags().GetString("provider")
+		model, _ := cmd.Flags().GetString("model")
+		task, err := schedule.Add(root, schedule.Task{
+			Prompt:   strings.Join(args, " "),
+			Cron:     cronExpr,
+			Provider: provider,
+			Model:    model,
+		})
+		if err != nil {
+			return err
+		}
+		cron, _ := schedule.ParseCron(task.Cron)
+		fmt.Printf("[ok] Scheduled task %s (%s), next run %s\n", task.ID, task.Cron, cron.Next(time.Now()).Format("Mon 2006-01-02 15:04"))
+		fmt.Println("Tasks run while 'ledit schedule run' is running in this project.")
+		return nil
+	},
+}
+
+var scheduleListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List scheduled tasks",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return listScheduledTasks()
+	},
+}
+
+var scheduleRemoveCmd = &cobra.Command{
+	Use:   "remove <id>",
+	Short: "Delete a scheduled task",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		root, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+		if err := schedule.Remove(root, args[0]); err != nil {
+			return err
+		}
+		fmt.Printf("[ok] Removed scheduled task %s\n", args[0])
+		return nil
+	},
+}
+
+var scheduleRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Run the scheduler in the foreground",
+	Long: `Run the scheduler until interrupted. Every minute, due tasks are started as
+background jobs with the agent's JSON output, and their full output goes to
+.ledit/jobs/. The scheduler logs one JSON object per line to stdout: when
+tasks start, are skipped because their previous run is still going, and
+finish with their status, changed files, tokens and cost.
+
+With --now <id>, the task runs once immediately and the command exits when
+it finishes, with status 1 if it failed.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		root, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer stop()
+
+		daemon := schedule.NewDaemon(root, os.Stdout)
+		now, _ := cmd.Flags().GetString("now")
+		if now == "" {
+			return daemon.Run(ctx)
+		}
+		job, err := daemon.RunNow(ctx, now)
+		if err != nil {
+			return err
+		}
+		if job.Status == jobs.StatusFailed {
+			os.Exit(1)
+		}
+		return nil
+	},
+}
+
+func listScheduledTasks() error {
+	root, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory: %w", err)
+	}
+	tasks, err := schedule.Load(root)
+	if err != nil {
+		return err
+	}
+	if len(tasks) == 0 {
+		fmt.Println("No scheduled tasks. Add one with: ledit schedule add \"<prompt>\" --cron \"0 9 * * 1\"")
+		return nil
+	}
+
+	next := make(map[string]time.Time, len(tasks))
+	for _, run := range schedule.NextRuns(tasks, time.Now()) {
+		next[run.Task.ID] = run.At
+	}
+	for _, task := range tasks {
+		nextRun := "disabled"
+		if at, ok := next[task.ID]; ok {
+			nextRun = "next " + at.Format("Mon 2006-01-02 15:04")
+		}
+		fmt.Printf("  %-4s %-16s %-26s %s\n", task.ID, task.Cron, nextRun, truncateScheduledPrompt(task.Prompt, 60))
+		if !task.LastRun.IsZero() {
+			fmt.Printf("       last run %s: %s", task.LastRun.Format("Mon 2006-01-02 15:04"), task.LastStatus)
+			if task.LastLog != "" {
+				fmt.Printf(" (%s)", task.LastLog)
+			}
+			fmt.Println()
+		}
+	}
+	return nil
+}
+
+func truncateScheduledPrompt(prompt string, limit int) string {
+	prompt = strings.Join(strings.Fields(prompt), " ")
+	if len([]rune(prompt)) <= limit {
+		return prompt
+	}
+	return string([]rune(prompt)[:limit-3]) + "..."
+}
+
+func init() {
+	rootCmd.AddCommand(scheduleCmd)
+	scheduleCmd.AddCommand(scheduleAddCmd, scheduleListCmd, scheduleRemoveCmd, scheduleRunCmd)
+
+	scheduleAddCmd.Flags().String("cron", "", "Cron expression, e.g. \"0 9 * * 1\" for Mondays at 9:00 (required)")
+	scheduleAddCmd.Flags().String("provider", "", "Provider for the task (default: the configured provider)")
+	scheduleAddCmd.Flags().String("model", "", "Model for the task (default: the provider's model)")
+	scheduleAddCmd.MarkFlagRequired("cron")
+	scheduleRunCmd.Flags().String("now", "", "Run task <id> once immediately and exit")
+}
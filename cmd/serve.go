@@ -0,0 +1,137 @@
+// Serve command for ledit
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/alantheprice/ledit/pkg/configuration"
+	"github.com/alantheprice/ledit/pkg/editorbridge"
+	"github.com/alantheprice/ledit/pkg/events"
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveWeb      bool
+	serveEditor   bool
+	serveListen   string
+	servePort     int
+	serveModel    string
+	serveProvider string
+	serveNoWatch  bool
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve the web UI or an editor bridge without an interactive terminal prompt",
+	Long: `With --web, start the embedded web server bound to an agent and keep it
+running.
+
+The web UI provides a chat panel bound to the agent, a live file tree, a diff
+viewer updated from file_changed events (agent edits and, through the
+workspace watcher, edits made on disk), git status, and session stats.
+
+This is equivalent to 'ledit agent --daemon' without a query. Without
+--port the server uses the shared daemon port (54000), reusing an already
+running instance if one holds it.
+
+With --editor, ledit instead serves the agent to editor plugins (VS Code,
+Neovim) over JSON-RPC 2.0 with Language Server Protocol framing, on stdio or,
+with --listen, a loopback TCP address. Plugins can explain or refactor a
+selection, streaming the answer, and apply workspace edits through the
+agent's change tracking.
+
+Examples:
+  ledit serve --web
+  ledit serve --web --port 8080
+  ledit serve --web -p openrouter -m "qwen/qwen3-coder-30b"
+  ledit serve --editor
+  ledit serve --editor --listen 127.0.0.1:7777`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		switch {
+		case serveWeb && serveEditor:
+			return errors.New("--web and --editor serve different clients; pick one")
+		case serveEditor:
+			return runEditorBridge()
+		case !serveWeb:
+			return errors.New("nothing to serve: use --web or --editor")
+		}
+		if IsCI() {
+			return errors.New("ledit serve is not available in CI environments")
+		}
+
+		daemonMode = true
+		disableWebUI = false
+		webPort = servePort
+		agentModel = serveModel
+		agentProvider = serveProvider
+		agentNoWatch = serveNoWatch
+
+		chatAgent, err := createChatAgent()
+		if err != nil {
+			return fmt.Errorf("failed to create chat agent: %w", err)
+		}
+		return RunAgent(chatAgent, false, nil)
+	},
+}
+
+// runEditorBridge serves the agent to editor plugins until stdin closes or,
+// with --listen, until interrupted.
+func runEditorBridge() error {
+	protocolOut := os.Stdout
+	if serveListen == "" {
+		// Reserve stdout for the protocol; human-readable output goes to stderr.
+		os.Stdout = os.Stderr
+		defer func() { os.Stdout = protocolOut }()
+	}
+
+	agentModel = serveModel
+	agentProvider = serveProvider
+	chatAgent, err := createChatAgent()
+	if err != nil {
+		return fmt.Errorf("failed to create chat agent: %w", err)
+	}
+	defer chatAgent.Shutdown()
+
+	// The editor owns the terminal's stdin, so nothing can answer a prompt.
+	if err := chatAgent.GetConfigManager().UpdateConfigNoSave(func(cfg *configuration.Config) error {
+		cfg.SkipPrompt = true
+		return nil
+	}); err != nil {
+		return err
+	}
+	eventBus := events.NewEventBus()
+	chatAgent.SetEventBus(eventBus)
+
+	root := chatAgent.GetWorkspaceRoot()
+	if root == "" {
+		if root, err = os.Getwd(); err != nil {
+			return err
+		}
+	}
+	server := editorbridge.NewServer(chatAgent, root, eventBus)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	if serveListen == "" {
+		return server.Serve(ctx, os.Stdin, protocolOut)
+	}
+	return server.ListenAndServe(ctx, serveListen, func(addr net.Addr, tokenPath string) {
+		fmt.Fprintf(os.Stderr, "Editor bridge listening on %s (connection token in %s)\n", addr, tokenPath)
+	})
+}
+
+func init() {
+	serveCmd.Flags().BoolVar(&serveWeb, "web", false, "Serve the web UI (chat, file tree, diff viewer, session stats)")
+	serveCmd.Flags().BoolVar(&serveEditor, "editor", false, "Serve the agent to editor plugins over JSON-RPC")
+	serveCmd.Flags().StringVar(&serveListen, "listen", "", "Loopback address for --editor, such as 127.0.0.1:7777 (default: stdio)")
+	serveCmd.Flags().IntVar(&servePort, "port", 0, "Port for the web UI (default: 54000)")
+	serveCmd.Flags().StringVarP(&serveModel, "model", "m", "", "Model name for the agent")
+	serveCmd.Flags().StringVarP(&serveProvider, "provider", "p", "", "Provider to use")
+	serveCmd.Flags().BoolVar(&serveNoWatch, "no-watch", false, "Disable the workspace file watcher")
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/alantheprice/ledit/pkg/snapshot"
+	"github.com/spf13/cobra"
+)
+
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Snapshot and restore the whole workspace",
+	Long: `Take whole-workspace snapshots independent of git, covering untracked and
+ignored files, before letting the agent make large changes.
+
+Snapshots are stored in the project's .ledit/snapshots/. Files unchanged since
+the previous snapshot are hardlinked to its copy, and the rest are cloned
+copy-on-write on filesystems that support it (Btrfs, XFS, APFS) or copied.
+.git, .ledit, node_modules, .venv, __pycache__ and .cache directories are
+left out.
+
+Commands:
+  create  - Snapshot the workspace
+  list    - List snapshots
+  restore - Return the workspace to a snapshot
+  delete  - Delete a snapshot`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return listSnapshots()
+	},
+}
+
+var snapshotCreateCmd = &cobra.Command{
+	Use:   "create [name]",
+	Short: "Snapshot the workspace",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		root, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+		snap, err := snapshot.Create(root, strings.Join(args, " "))
+		if err != nil {
+			return err
+		}
+		fmt.Printf("[ok] Created snapshot %s: %s\n", snap.Label(), snap.Summary())
+		return nil
+	},
+}
+
+var snapshotListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List snapshots",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return listSnapshots()
+	},
+}
+
+var snapshotRestoreCmd = &cobra.Command{
+	Use:   "restore <id|name>",
+	Short: "Return the workspace to a snapshot",
+	Long: `Return the workspace to a snapshot: changed and missing files are restored
+and files created since the snapshot are deleted. The current state is
+snapshotted first, so a restore can itself be undone, unless --no-backup is
+given.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		root, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		noBackup, _ := cmd.Flags().GetBool("no-backup")
+		result, err := snapshot.Restore(root, args[0], snapshot.RestoreOptions{DryRun: dryRun, NoBackup: noBackup})
+		if err != nil {
+			return err
+		}
+		if dryRun {
+			for _, path := range result.Restored {
+				fmt.Printf("  restore %s\n", path)
+			}
+			for _, path := range result.Deleted {
+				fmt.Printf("  delete  %s\n", path)
+			}
+			fmt.Printf("Would restore %d and delete %d files; %d unchanged\n", len(result.Restored), len(result.Deleted), result.Unchanged)
+			return nil
+		}
+		fmt.Printf("[ok] Restored snapshot %s: %d files restored, %d deleted, %d unchanged\n",
+			args[0], len(result.Restored), len(result.Deleted), result.Unchanged)
+		if result.Backup.ID != "" {
+			fmt.Printf("The previous state was saved as snapshot %s; undo with: ledit snapshot restore %s\n", result.Backup.ID, result.Backup.ID)
+		}
+		return nil
+	},
+}
+
+var snapshotDeleteCmd = &cobra.Command{
+	Use:   "delete <id|name>",
+	Short: "Delete a snapshot",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		root, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+		snap, err := snapshot.Delete(root, args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("[ok] Deleted snapshot %s\n", snap.Label())
+		return nil
+	},
+}
+
+func listSnapshots() error {
+	root, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory: %w", err)
+	}
+	snapshots, err := snapshot.List(root)
+	if err != nil {
+		return err
+	}
+	if len(snapshots) == 0 {
+		fmt.Println("No snapshots. Create one with: ledit snapshot create [name]")
+		return nil
+	}
+	for _, snap := range snapshots {
+		fmt.Printf("  %-4s %-24s %s  %s\n", snap.ID, snap.Name, snap.Created.Format("Mon 2006-01-02 15:04"), snap.Summary())
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(snapshotCmd)
+	snapshotCmd.AddCommand(snapshotCreateCmd, snapshotListCmd, snapshotRestoreCmd, snapshotDeleteCmd)
+
+	snapshotRestoreCmd.Flags().Bool("dry-run", false, "Show what would be restored and deleted without changing anything")
+	snapshotRestoreCmd.Flags().Bool("no-backup", false, "Don't snapshot the current state first")
+}
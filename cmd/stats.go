@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/alantheprice/ledit/pkg/configuration"
+	"github.com/alantheprice/ledit/pkg/runstats"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show local usage analytics for agent runs",
+	Long: `Show tokens, cost, success rate and run time of past agent runs per model
+and per project, with a chart of spend over time.
+
+Every agent run is recorded locally in stats/runs.jsonl in the ledit config
+directory; nothing is sent anywhere. Set LEDIT_NO_STATS=1 to stop recording.
+
+Examples:
+  ledit stats
+  ledit stats --days 90 --interval week --by project
+  ledit stats --html stats.html`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		days, _ := cmd.Flags().GetInt("days")
+		intervalFlag, _ := cmd.Flags().GetString("interval")
+		by, _ := cmd.Flags().GetString("by")
+		htmlPath, _ := cmd.Flags().GetString("html")
+		asJSON, _ := cmd.Flags().GetBool("json")
+		if days <= 0 {
+			return fmt.Errorf("--days must be positive, got %d", days)
+		}
+		interval, err := runstats.ParseInterval(intervalFlag)
+		if err != nil {
+			return err
+		}
+
+		configDir, err := configuration.GetConfigDir()
+		if err != nil {
+			return err
+		}
+		to := time.Now()
+		today := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, to.Location())
+		from := today.AddDate(0, 0, 1-days)
+		runs, err := runstats.Load(configDir, from)
+		if err != nil {
+			return err
+		}
+		report, err := runstats.Build(runs, from, to, interval, by)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case asJSON:
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(report)
+		case htmlPath != "":
+			if err := os.WriteFile(htmlPath, []byte(runstats.RenderHTML(report)), 0o644); err != nil {
+				return fmt.Errorf("failed to write %s: %w", htmlPath, err)
+			}
+			abs, _ := filepath.Abs(htmlPath)
+			fmt.Printf("[ok] Wrote %d runs to %s\n", report.Total.Runs, abs)
+			return nil
+		}
+		fmt.Print(runstats.RenderText(report, statsChartWidth()))
+		if report.Total.Runs == 0 {
+			fmt.Printf("Runs are recorded in %s as the agent finishes them.\n", runstats.Path(configDir))
+		}
+		return nil
+	},
+}
+
+// statsChartWidth fits the text chart to the terminal, leaving room for the
+// bucket label and the cost.
+func statsChartWidth() int {
+	width := 80
+	if w, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil {
+		width = w
+	}
+	return max(10, min(60, width-24))
+}
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+	statsCmd.Flags().Int("days", 30, "Number of days to include, ending today")
+	statsCmd.Flags().String("interval", "day", "Timeline bucket: day, week or month")
+	statsCmd.Flags().String("by", "model", "Split the spend chart by model or project")
+	statsCmd.Flags().String("html", "", "Write a standalone HTML report to this path instead of printing")
+	statsCmd.Flags().Bool("json", false, "Print the aggregated report as JSON")
+}
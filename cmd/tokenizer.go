@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/alantheprice/ledit/pkg/configuration"
+	"github.com/alantheprice/ledit/pkg/tokenizer"
+	"github.com/spf13/cobra"
+)
+
+// tiktokenBaseURL is where OpenAI publishes its encodings' rank files.
+const tiktokenBaseURL = "https://openaipublic.blob.core.windows.net/encodings/"
+
+var tokenizerCountModel string
+
+var tokenizerCmd = &cobra.Command{
+	Use:   "tokenizer",
+	Short: "Manage the tokenizers used to count tokens",
+	Long: `Manage the tokenizer files ledit counts tokens with for context limits,
+pruning and cost estimates.
+
+OpenAI models use tiktoken rank files (cl100k_base, o200k_base) from the
+tokenizers directory in the config directory; other models can be mapped to
+a tiktoken or sentencepiece (.model) file with the tokenizers option in
+config.json. Models without a tokenizer file use a heuristic estimate.
+
+Commands:
+  download - Download OpenAI's tiktoken rank files
+  count    - Count the tokens in a file or stdin`,
+}
+
+var tokenizerDownloadCmd = &cobra.Command{
+	Use:       "download [encoding...]",
+	Short:     "Download tiktoken rank files (default: cl100k_base and o200k_base)",
+	ValidArgs: tokenizer.Encodings,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, err := tokenizerDir()
+		if err != nil {
+			return err
+		}
+		if len(args) == 0 {
+			args = tokenizer.Encodings
+		}
+		for _, name := range args {
+			if !slices.Contains(tokenizer.Encodings, name) {
+				return fmt.Errorf("unknown encoding %q (available: %s)", name, strings.Join(tokenizer.Encodings, ", "))
+			}
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		for _, name := range args {
+			path := filepath.Join(dir, name+".tiktoken")
+			if err := downloadTiktoken(name, path); err != nil {
+				return err
+			}
+			fmt.Printf("[ok] %s -> %s\n", name, path)
+		}
+		return nil
+	},
+}
+
+var tokenizerCountCmd = &cobra.Command{
+	Use:   "count [file|-]",
+	Short: "Count the tokens in a file or stdin with a model's tokenizer",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		manager, err := configuration.NewManagerSilent()
+		if err != nil {
+			return err
+		}
+		cfg := manager.GetConfig()
+		loadTokenizers(cfg)
+
+		var data []byte
+		if len(args) == 0 || args[0] == "-" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(args[0])
+		}
+		if err != nil {
+			return err
+		}
+
+		model := tokenizerCountModel
+		if model == "" {
+			model = cfg.GetModelForProvider(cfg.LastUsedProvider)
+		}
+		t := tokenizer.ForModel(model)
+		text := string(data)
+		fmt.Printf("%s: %d tokens (%s)\n", model, t.Count(text), t.Name())
+		if _, heuristic := t.(tokenizer.Heuristic); heuristic {
+			fmt.Println("No tokenizer file for this model; run 'ledit tokenizer download' or set tokenizers in config.json")
+		} else {
+			fmt.Printf("heuristic estimate: %d tokens\n", tokenizer.Heuristic{}.Count(text))
+		}
+		return nil
+	},
+}
+
+func tokenizerDir() (string, error) {
+	configDir, err := configuration.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "tokenizers"), nil
+}
+
+// loadTokenizers points the tokenizer package at the tokenizer directory and
+// the tokenizers option in config.json.
+func loadTokenizers(cfg *configuration.Config) {
+	if cfg == nil {
+		return
+	}
+	dir, err := tokenizerDir()
+	if err != nil {
+		return
+	}
+	tokenizer.Configure(dir, cfg.Tokenizers)
+}
+
+// downloadTiktoken fetches an encoding's rank file and only replaces path
+// once the download parses.
+func downloadTiktoken(name, path string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tiktokenBaseURL+name+".tiktoken", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download %s: %s", name, resp.Status)
+	}
+
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, resp.Body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		_, err = tokenizer.LoadTiktoken(tmp)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to download %s: %w", name, err)
+	}
+	return os.Rename(tmp, path)
+}
+
+func init() {
+	rootCmd.AddCommand(tokenizerCmd)
+	tokenizerCmd.AddCommand(tokenizerDownloadCmd)
+	tokenizerCmd.AddCommand(tokenizerCountCmd)
+	tokenizerCountCmd.Flags().StringVar(&tokenizerCountModel, "model", "", "Model whose tokenizer to use (default: the configured model)")
+}
@@ -0,0 +1,235 @@
+// Issue-driven task command for ledit
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/alantheprice/ledit/pkg/agent"
+	"github.com/alantheprice/ledit/pkg/configuration"
+	"github.com/alantheprice/ledit/pkg/forge"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// workOnSummaryLimit caps the agent summary quoted in the completion comment.
+const workOnSummaryLimit = 4000
+
+var (
+	workOnModel     string
+	workOnProvider  string
+	workOnComment   bool
+	workOnNoComment bool
+	workOnPrint     bool
+)
+
+var workOnCmd = &cobra.Command{
+	Use:   "work-on <issue-url|ID>",
+	Short: "Work on a GitHub, GitLab or Jira issue",
+	Long: `Fetch an issue with its description and comments, turn it into the
+agent's task and todo list, and run the agent on it. Open checklist items
+("- [ ] ...") in the description become separate todos.
+
+The issue can be given as:
+  https://github.com/acme/app/issues/12      GitHub issue
+  https://gitlab.com/acme/app/-/issues/12    GitLab issue
+  https://acme.atlassian.net/browse/PROJ-12  Jira issue
+  12 or #12                                  issue of the origin remote's repository
+  PROJ-12                                    Jira issue on the site in jira.url
+
+When the agent is done, ledit offers to comment on the issue with its
+summary and a link to the open pull request of the current branch (see /pr
+create). --comment posts without asking, --no-comment never posts.
+
+Tokens come from GITHUB_TOKEN (or GH_TOKEN), GITLAB_TOKEN and JIRA_API_TOKEN,
+or from /pr token <github|gitlab|jira>. Jira Cloud tokens also need the
+account's email in jira.email.
+
+Examples:
+  ledit work-on 42
+  ledit work-on https://github.com/acme/app/issues/42 --comment
+  ledit work-on PROJ-7 --print`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if workOnComment && workOnNoComment {
+			return errors.New("--comment and --no-comment cannot be used together")
+		}
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		return runWorkOn(ctx, args[0])
+	},
+}
+
+func init() {
+	workOnCmd.Flags().StringVarP(&workOnModel, "model", "m", "", "Model name")
+	workOnCmd.Flags().StringVarP(&workOnProvider, "provider", "p", "", "Provider to use")
+	workOnCmd.Flags().BoolVar(&workOnComment, "comment", false, "Comment on the issue when done without asking")
+	workOnCmd.Flags().BoolVar(&workOnNoComment, "no-comment", false, "Never comment on the issue")
+	workOnCmd.Flags().BoolVar(&workOnPrint, "print", false, "Print the task derived from the issue and exit")
+}
+
+func runWorkOn(ctx context.Context, ref string) error {
+	if configuration.IsOffline() {
+		return errors.New("work-on reads the issue over the network, which --offline turns off")
+	}
+	cfg, err := configuration.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	var jiraURL, jiraEmail string
+	if cfg.Jira != nil {
+		jiraURL, jiraEmail = cfg.Jira.URL, cfg.Jira.Email
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory: %w", err)
+	}
+
+	// The workspace is optional: it resolves bare issue numbers and finds
+	// the pull request to link at the end
+	ws, _ := forge.Detect(dir, cfg.ForgeHosts)
+	var origin *forge.Repo
+	if ws != nil {
+		origin = &ws.Repo
+	}
+	issueRef, err := forge.ParseIssueRef(ref, origin, cfg.ForgeHosts, jiraURL)
+	if err != nil {
+		return err
+	}
+	token, err := forge.ResolveToken(issueRef.Kind)
+	if err != nil {
+		return err
+	}
+	tracker, err := forge.NewIssueTracker(issueRef, token, jiraEmail)
+	if err != nil {
+		return err
+	}
+	issue, err := tracker.GetIssue(ctx, issueRef.Key)
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s: %w", issueRef, err)
+	}
+	name := issueRef.String()
+	fmt.Printf("[issue] %s: %s (%s, %d comment(s))\n", name, issue.Title, issue.State, len(issue.Comments))
+	if strings.EqualFold(issue.State, "closed") || strings.EqualFold(issue.State, "done") {
+		fmt.Printf("[WARN] %s is %s\n", name, issue.State)
+	}
+
+	prompt := agent.IssueTaskPrompt(name, issue)
+	if workOnPrint {
+		fmt.Println(prompt)
+		return nil
+	}
+
+	chatAgent, err := createWorkOnAgent()
+	if err != nil {
+		return err
+	}
+	defer chatAgent.Shutdown()
+
+	added, err := agent.AddIssueTodos(name, issue)
+	if err != nil {
+		return fmt.Errorf("failed to add todos: %w", err)
+	}
+	fmt.Printf("[issue] Added %d todo(s)\n", added)
+
+	summary, err := chatAgent.ProcessQuery(prompt)
+	if err != nil {
+		return fmt.Errorf("work-on failed: %w", err)
+	}
+	if workOnNoComment {
+		return nil
+	}
+
+	pr := findBranchPullRequest(ctx, ws, issueRef, token)
+	if !workOnComment {
+		if !term.IsTerminal(int(os.Stdin.Fd())) {
+			fmt.Printf("[issue] Not commenting on %s; pass --comment to post the summary\n", name)
+			return nil
+		}
+		answer, err := promptLine(bufio.NewReader(os.Stdin), fmt.Sprintf("\nComment on %s with the summary%s? [y/N]: ", name, pullRequestSuffix(pr)))
+		if err != nil || !isYes(answer) {
+			return nil
+		}
+	}
+	if err := tracker.CommentOnIssue(ctx, issueRef.Key, workOnCompletionComment(summary, pr, ws)); err != nil {
+		return fmt.Errorf("failed to comment on %s: %w", name, err)
+	}
+	fmt.Printf("[ok] Commented on %s\n", name)
+	return nil
+}
+
+// createWorkOnAgent creates the agent that works on the issue.
+func createWorkOnAgent() (*agent.Agent, error) {
+	var chatAgent *agent.Agent
+	var err error
+
+	if workOnProvider != "" && workOnModel != "" {
+		chatAgent, err = agent.NewAgentWithModel(fmt.Sprintf("%s:%s", workOnProvider, workOnModel))
+	} else if workOnModel != "" {
+		chatAgent, err = agent.NewAgentWithModel(workOnModel)
+	} else {
+		chatAgent, err = agent.NewAgent()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize agent: %w", err)
+	}
+	return chatAgent, nil
+}
+
+// findBranchPullRequest returns the open pull request of the current branch,
+// or nil when there is none or it cannot be looked up. The issue's token is
+// reused when the issue lives on the same forge.
+func findBranchPullRequest(ctx context.Context, ws *forge.Workspace, issueRef forge.IssueRef, issueToken string) *forge.PullRequest {
+	if ws == nil {
+		return nil
+	}
+	token := issueToken
+	if issueRef.Kind != ws.Repo.Kind || issueRef.Repo.Host != ws.Repo.Host {
+		var err error
+		if token, err = forge.ResolveToken(ws.Repo.Kind); err != nil {
+			return nil
+		}
+	}
+	client, err := forge.New(ws.Repo, token)
+	if err != nil {
+		return nil
+	}
+	pr, err := client.FindPullRequest(ctx, ws.Branch)
+	if err != nil {
+		return nil
+	}
+	return pr
+}
+
+func pullRequestSuffix(pr *forge.PullRequest) string {
+	if pr == nil {
+		return ""
+	}
+	return fmt.Sprintf(" and a link to #%d", pr.Number)
+}
+
+// workOnCompletionComment builds the comment posted on the issue when the
+// agent is done.
+func workOnCompletionComment(summary string, pr *forge.PullRequest, ws *forge.Workspace) string {
+	var sb strings.Builder
+	sb.WriteString("Worked on this issue with ledit.\n\n")
+	if runes := []rune(strings.TrimSpace(summary)); len(runes) > workOnSummaryLimit {
+		summary = string(runes[:workOnSummaryLimit]) + "..."
+	}
+	if summary = strings.TrimSpace(summary); summary != "" {
+		sb.WriteString(summary + "\n\n")
+	}
+	switch {
+	case pr != nil:
+		fmt.Fprintf(&sb, "Pull request: %s", pr.URL)
+	case ws != nil:
+		fmt.Fprintf(&sb, "The changes are on branch `%s`; no pull request is open yet.", ws.Branch)
+	}
+	return strings.TrimSpace(sb.String())
+}
@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	api "github.com/alantheprice/ledit/pkg/agent_api"
@@ -18,6 +19,7 @@ import (
 	"github.com/alantheprice/ledit/pkg/noninteractive"
 	"github.com/alantheprice/ledit/pkg/prompts"
 	"github.com/alantheprice/ledit/pkg/security"
+	"github.com/alantheprice/ledit/pkg/toolpolicy"
 	"github.com/alantheprice/ledit/pkg/utils"
 	"github.com/alantheprice/ledit/pkg/validation"
 )
@@ -40,7 +42,11 @@ type Agent struct {
 	client                  api.ClientInterface
 	messages                []api.Message
 	systemPrompt            string
-	baseSystemPrompt        string // Base prompt restored when persona is cleared
+	baseSystemPrompt        string                     // Base prompt restored when persona is cleared
+	promptSections          []PromptLayer              // Layers the embedded system prompt was assembled from
+	skillPrompt             string                     // Skill instructions appended to systemPrompt
+	promptOverrides         map[string]*promptOverride // Session overrides of system prompt layers, by layer name
+	promptOverridesMu       sync.Mutex                 // Protects promptOverrides
 	maxIterations           int
 	currentIteration        int
 	totalCost               float64
@@ -58,6 +64,25 @@ type Agent struct {
 	sessionID               string                         // Unique session identifier
 	turnCheckpoints         []TurnCheckpoint               // Completed-turn summaries used when context gets tight
 	checkpointMu            sync.RWMutex                   // Protects background checkpoint compaction
+	pinnedFacts             []string                       // User-pinned facts kept in the system message across compaction
+	pinnedFiles             []string                       // User-pinned file paths kept across compaction
+	pinsMu                  sync.RWMutex                   // Protects pinnedFacts and pinnedFiles
+	branches                *branchStore                   // Conversation checkpoints and branches (/checkpoint, /branch)
+	branchesOnce            sync.Once                      // Lazily initializes branches
+	readCache               *readCache                     // read_file results and speculative import prefetches
+	readCacheOnce           sync.Once                      // Lazily initializes readCache
+	toolDedup               *toolDedup                     // Recent read-only tool results reused for exact repeats
+	toolDedupOnce           sync.Once                      // Lazily initializes toolDedup
+	toolOutputs             *toolOutputStore               // Full text of truncated tool results for fetch_more
+	toolOutputsOnce         sync.Once                      // Lazily initializes toolOutputs
+	remoteExec              *remoteExec                    // Remote host running shell commands, when configured
+	remoteExecOnce          sync.Once                      // Lazily initializes remoteExec
+	devcontainer            *devcontainerExec              // Workspace devcontainer running shell commands, when enabled
+	devcontainerOnce        sync.Once                      // Lazily initializes devcontainer
+	imageAttachments        []ImageAttachment              // Images queued with /attach for the next query
+	imageAttachmentsMu      sync.Mutex                     // Protects imageAttachments
+	usageLedger             *usageLedger                   // Token and cost attribution per tool, file and subagent
+	usageLedgerOnce         sync.Once                      // Lazily initializes usageLedger
 	optimizer               *ConversationOptimizer         // Conversation optimization
 	configManager           *configuration.Manager         // Configuration management
 	currentContextTokens    int                            // Current context size being sent to model
@@ -70,21 +95,36 @@ type Agent struct {
 	mcpInitialized          bool                           // Track whether MCP has been initialized
 	mcpInitErr              error                          // Store initialization error
 	mcpInitMu               sync.Mutex                     // Protect concurrent initialization
+	customTools             map[string]customTool          // Tools registered by embedding programs
+	customToolsMu           sync.RWMutex                   // Protects customTools
+	toolPolicy              *toolpolicy.Policy             // Cached .ledit/policy.yaml, nil when absent
+	responseCacheStats      ResponseCacheStats             // Requests answered from the response cache
+	toolPolicyErr           error                          // Load error for the cached policy file
+	toolPolicyModTime       time.Time                      // Modification time of the cached policy file
+	toolPolicyMu            sync.Mutex                     // Protects the tool policy cache
 	circuitBreaker          *CircuitBreakerState           // Track repetitive actions
 	conversationPruner      *ConversationPruner            // Automatic conversation pruning
 	toolCallGuidanceAdded   bool                           // Prevent repeating tool call guidance
 	activeSkills            []string                       // Currently activated skills (by ID)
 	activePersona           string                         // Currently active persona ID (direct agent or subagent env)
 	workspaceRoot           string                         // Explicit workspace root for this agent instance
+	workspaceWatcher        *workspaceWatcher              // Filesystem watcher keeping workspace context fresh
+	workspaceWatcherMu      sync.Mutex                     // Protects workspaceWatcher
+	rateLimitedUntil        atomic.Int64                   // UnixNano until which requests wait on a rate limit
 
 	// Session-scoped provider/model overrides (webui sessions)
 	// When set, these take precedence over config values and don't persist
 	sessionProvider api.ClientType // Session-scoped provider override
 	sessionModel    string         // Session-scoped model override
 
+	// Per-todo escalation to a stronger model after repeated failures
+	escalation escalationState
+
 	// Input injection handling
 	inputInjectionChan  chan string        // Channel for injecting new user input
 	inputInjectionMutex sync.Mutex         // Mutex for input injection operations
+	pendingSteers       []string           // Corrections waiting to interrupt the tool chain
+	steerMu             sync.Mutex         // Protects pendingSteers
 	interruptCtx        context.Context    // Context for interrupt handling
 	interruptCancel     context.CancelFunc // Cancel function for interrupt context
 	outputMutex         *sync.Mutex        // Mutex for synchronized output
@@ -93,8 +133,11 @@ type Agent struct {
 	reasoningCallback   func(string)       // Custom reasoning/thinking callback
 	streamingBuffer     strings.Builder    // Buffer for streaming content
 	reasoningBuffer     strings.Builder    // Buffer for reasoning content
-	flushCallback       func()             // Callback to flush buffered output
-	asyncOutput         chan string        // Buffered channel for async PrintLine calls
+	streamStats         StreamDispatchStats
+	streamStatsMu       sync.Mutex
+	capabilityWarned    string      // provider/model last checked by warnModelCapabilityGaps
+	flushCallback       func()      // Callback to flush buffered output
+	asyncOutput         chan string // Buffered channel for async PrintLine calls
 
 	// Command history for interactive mode
 	historyMu       sync.Mutex // Protects commandHistory and historyIndex
@@ -110,6 +153,9 @@ type Agent struct {
 	// Trace session for dataset collection
 	traceSession interface{} // Using interface{} to avoid circular dependency
 
+	// Run recorder for offline replay (nil when recording is disabled)
+	runRecorder *RunRecorder
+
 	// Feature flags
 	falseStopDetectionEnabled bool
 	statsUpdateCallback       func(int, float64) // Callback for token/cost updates
@@ -209,6 +255,9 @@ func (a *Agent) Shutdown() {
 		cancel()
 	}
 
+	// Stop the workspace watcher
+	a.StopWorkspaceWatcher()
+
 	// Cancel interrupt context
 	if a.interruptCancel != nil {
 		a.interruptCancel()
@@ -263,71 +312,7 @@ func NewAgentWithModel(model string) (*Agent, error) {
 			return nil, fmt.Errorf("failed to create API client for tests: %w", err)
 		}
 
-		// Load system prompt for test agent
-		providerName := api.GetProviderName(clientType)
-		systemPrompt, err := GetEmbeddedSystemPromptWithProvider(providerName)
-		if err != nil {
-			return nil, fmt.Errorf("failed to load system prompt: %w", err)
-		}
-		systemPrompt = resolveConfiguredSystemPrompt(configManager.GetConfig(), systemPrompt)
-
-		// Create agent with minimal initialization using test client
-		agent := &Agent{
-			client:                    client,
-			messages:                  []api.Message{},
-			systemPrompt:              systemPrompt,
-			baseSystemPrompt:          systemPrompt,
-			maxIterations:             0, // 0 means unlimited
-			totalCost:                 0.0,
-			clientType:                clientType,
-			debug:                     isDebugEnvEnabled(),
-			optimizer:                 NewConversationOptimizer(true, false),
-			configManager:             configManager,
-			shellCommandHistory:       make(map[string]*ShellCommandResult),
-			inputInjectionChan:        make(chan string, inputInjectionBufferSize),
-			interruptCtx:              context.Background(),
-			interruptCancel:           func() { /* no-op */ },
-			falseStopDetectionEnabled: true,
-			conversationPruner:        NewConversationPruner(false),
-			activePersona:             "orchestrator",
-			workspaceRoot:             workspaceRoot,
-			securityApprovalMgr:       NewSecurityApprovalManager(),
-			outputRouter:              NewOutputRouter(nil, nil),
-			ignoredSecurityConcerns:   make(map[string]map[string]bool),
-			outputRedactor:            security.NewOutputRedactor(),
-			elevationGate:             security.NewElevationGate(nil),
-		}
-
-		agent.optimizer.SetLLMClient(agent.client, agent.GetProvider(), func(line string) {
-			agent.PrintLineAsync(line)
-		})
-
-		// Wire output router with the agent reference now that agent exists
-		if agent.outputRouter != nil {
-			agent.outputRouter.agent = agent
-		}
-
-		// Load command history from configuration
-		agent.loadHistoryFromConfig()
-		// Initialize debug log file if debug enabled
-		if agent.debug {
-			if err := agent.initDebugLogger(); err != nil {
-				fmt.Fprintf(os.Stderr, "WARNING: Failed to initialize debug logger: %v\n", err)
-			}
-		}
-
-		// Initialize MCP manager
-		agent.mcpManager = mcp.NewMCPManager(nil)
-
-		if persona := strings.TrimSpace(os.Getenv("LEDIT_PERSONA")); persona != "" {
-			agent.activePersona = strings.ReplaceAll(strings.ToLower(persona), "-", "_")
-		}
-
-		// Initialize change tracker
-		agent.changeTracker = NewChangeTracker(agent, "")
-		agent.changeTracker.Enable() // Start enabled by default
-
-		return agent, nil
+		return newOfflineAgent(configManager, workspaceRoot, client, clientType)
 	}
 
 	// Non-interactive fast-fail: check provider availability before entering
@@ -471,16 +456,21 @@ func NewAgentWithModel(model string) (*Agent, error) {
 	// Check if debug mode is enabled
 	debug := isDebugEnvEnabled()
 
-	// Use embedded system prompt with provider-specific enhancements
-	providerName := api.GetProviderName(clientType)
-	systemPrompt, err := GetEmbeddedSystemPromptWithProvider(providerName)
+	// Use the embedded system prompt unless the config replaces it
+	promptSections, err := embeddedPromptLayers()
 	if err != nil {
 		return nil, fmt.Errorf("failed to load system prompt: %w", err)
 	}
-	systemPrompt = resolveConfiguredSystemPrompt(configManager.GetConfig(), systemPrompt)
+	systemPrompt := resolveConfiguredSystemPrompt(configManager.GetConfig(), joinPromptLayers(promptSections))
+	applyConfiguredPalette(configManager.GetConfig())
 
-	// Clear old todos at session start
-	tools.TodoWrite([]tools.TodoItem{})
+	// Reload the workspace's todos from earlier sessions. Subagents keep a
+	// private in-memory list so they never overwrite the parent's todos.
+	if os.Getenv("LEDIT_SUBAGENT") == "1" {
+		tools.TodoWrite([]tools.TodoItem{})
+	} else if err := tools.LoadTodos(workspaceRoot); err != nil && debug {
+		fmt.Fprintf(os.Stderr, "WARNING: Failed to load todos: %v\n", err)
+	}
 
 	// Clean up old sessions (keep only most recent 20 for this working directory scope).
 	if err := cleanupMemorySessions(); err != nil && debug {
@@ -499,6 +489,7 @@ func NewAgentWithModel(model string) (*Agent, error) {
 		messages:                  []api.Message{},
 		systemPrompt:              systemPrompt,
 		baseSystemPrompt:          systemPrompt,
+		promptSections:            promptSections,
 		maxIterations:             0, // 0 means unlimited
 		totalCost:                 0.0,
 		clientType:                clientType,
@@ -561,6 +552,9 @@ func NewAgentWithModel(model string) (*Agent, error) {
 	agent.mcpManager = mcp.NewMCPManager(nil)
 	// MCP servers will be initialized on first use to improve startup performance
 
+	// Register external programs declared in .ledit/tools as tools
+	agent.registerExecTools()
+
 	// Initialize circuit breaker
 	agent.circuitBreaker = &CircuitBreakerState{
 		Actions: make(map[string]*CircuitBreakerAction),
@@ -576,6 +570,99 @@ func NewAgentWithModel(model string) (*Agent, error) {
 	return agent, nil
 }
 
+// newOfflineAgent builds an agent around an already constructed client without
+// resolving a provider, checking API keys or probing the connection. It backs
+// the test client and run replay.
+func newOfflineAgent(configManager *configuration.Manager, workspaceRoot string, client api.ClientInterface, clientType api.ClientType) (*Agent, error) {
+	// Load system prompt for the offline agent
+	promptSections, err := embeddedPromptLayers()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load system prompt: %w", err)
+	}
+	systemPrompt := resolveConfiguredSystemPrompt(configManager.GetConfig(), joinPromptLayers(promptSections))
+
+	// Create agent with minimal initialization using the provided client
+	agent := &Agent{
+		client:                    client,
+		messages:                  []api.Message{},
+		systemPrompt:              systemPrompt,
+		baseSystemPrompt:          systemPrompt,
+		promptSections:            promptSections,
+		maxIterations:             0, // 0 means unlimited
+		totalCost:                 0.0,
+		clientType:                clientType,
+		debug:                     isDebugEnvEnabled(),
+		optimizer:                 NewConversationOptimizer(true, false),
+		configManager:             configManager,
+		shellCommandHistory:       make(map[string]*ShellCommandResult),
+		inputInjectionChan:        make(chan string, inputInjectionBufferSize),
+		interruptCtx:              context.Background(),
+		interruptCancel:           func() { /* no-op */ },
+		falseStopDetectionEnabled: true,
+		conversationPruner:        NewConversationPruner(false),
+		activePersona:             "orchestrator",
+		workspaceRoot:             workspaceRoot,
+		securityApprovalMgr:       NewSecurityApprovalManager(),
+		outputRouter:              NewOutputRouter(nil, nil),
+		ignoredSecurityConcerns:   make(map[string]map[string]bool),
+		outputRedactor:            security.NewOutputRedactor(),
+		elevationGate:             security.NewElevationGate(nil),
+	}
+
+	agent.optimizer.SetLLMClient(agent.client, agent.GetProvider(), func(line string) {
+		agent.PrintLineAsync(line)
+	})
+
+	// Wire output router with the agent reference now that agent exists
+	if agent.outputRouter != nil {
+		agent.outputRouter.agent = agent
+	}
+
+	// Load command history from configuration
+	agent.loadHistoryFromConfig()
+	// Initialize debug log file if debug enabled
+	if agent.debug {
+		if err := agent.initDebugLogger(); err != nil {
+			fmt.Fprintf(os.Stderr, "WARNING: Failed to initialize debug logger: %v\n", err)
+		}
+	}
+
+	// Initialize MCP manager
+	agent.mcpManager = mcp.NewMCPManager(nil)
+
+	if persona := strings.TrimSpace(os.Getenv("LEDIT_PERSONA")); persona != "" {
+		agent.activePersona = strings.ReplaceAll(strings.ToLower(persona), "-", "_")
+	}
+
+	// Initialize change tracker
+	agent.changeTracker = NewChangeTracker(agent, "")
+	agent.changeTracker.Enable() // Start enabled by default
+
+	return agent, nil
+}
+
+// NewAgentWithClient creates an agent that sends every request through client
+// instead of a configured provider. No API key is required and the provider
+// selection in the user's config is left untouched. The workspace root is the
+// current directory.
+func NewAgentWithClient(client api.ClientInterface) (*Agent, error) {
+	if client == nil {
+		return nil, fmt.Errorf("client is required")
+	}
+	configManager, err := configuration.NewManagerSilent()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize configuration: %w", err)
+	}
+	workspaceRoot, err := os.Getwd()
+	if err != nil {
+		workspaceRoot = "."
+	}
+	if absWorkspaceRoot, absErr := filepath.Abs(workspaceRoot); absErr == nil {
+		workspaceRoot = absWorkspaceRoot
+	}
+	return newOfflineAgent(configManager, workspaceRoot, client, api.ClientType(client.GetProvider()))
+}
+
 // GetDebugLogPath returns the path to the current debug log file (if any)
 func (a *Agent) GetDebugLogPath() string { return a.debugLogPath }
 
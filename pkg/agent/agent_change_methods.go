@@ -85,7 +85,12 @@ func (a *Agent) CommitChanges(llmResponse string) error {
 	if a.changeTracker != nil && a.changeTracker.IsEnabled() {
 		// Get the full conversation from the agent
 		conversation := a.messages
-		return a.changeTracker.Commit(llmResponse, conversation)
+		if err := a.changeTracker.Commit(llmResponse, conversation); err != nil {
+			return err
+		}
+		if a.changeTracker.baseRevisionRecorded {
+			a.noteBranchRevision(a.changeTracker.GetRevisionID())
+		}
 	}
 	return nil
 }
@@ -99,6 +104,11 @@ func (a *Agent) ClearTrackedChanges() {
 
 // TrackFileWrite is called by the WriteFile tool to track file writes
 func (a *Agent) TrackFileWrite(filePath string, content string) error {
+	a.noteBranchBaseline(filePath)
+	a.invalidateReadCache(filePath)
+	if a.runRecorder != nil {
+		a.runRecorder.recordFileMutation(filePath, "write", "", content)
+	}
 	if a.changeTracker != nil && a.changeTracker.IsEnabled() {
 		return a.changeTracker.TrackFileWrite(filePath, content)
 	}
@@ -111,6 +121,11 @@ func (a *Agent) TrackFileWrite(filePath string, content string) error {
 
 // TrackFileEdit is called by the EditFile tool to track file edits
 func (a *Agent) TrackFileEdit(filePath string, originalContent string, newContent string) error {
+	a.noteBranchBaseline(filePath)
+	a.invalidateReadCache(filePath)
+	if a.runRecorder != nil {
+		a.runRecorder.recordFileMutation(filePath, "edit", originalContent, newContent)
+	}
 	if a.changeTracker != nil && a.changeTracker.IsEnabled() {
 		return a.changeTracker.TrackFileEdit(filePath, originalContent, newContent)
 	}
@@ -75,7 +75,7 @@ func (a *Agent) SelectProvider() error {
 		return fmt.Errorf("failed to create client for %s: %w", newProvider, err)
 	}
 
-	a.client = client
+	a.client = a.wrapRecordingClient(client)
 	a.client.SetDebug(a.debug)
 
 	return nil
@@ -104,6 +104,12 @@ func recoverProviderStartup(configManager *configuration.Manager, failedProvider
 	}
 
 	failedProviderName := api.GetProviderName(failedProvider)
+
+	// Offline there is nothing to fall back to, so say what to start
+	// rather than offering providers that need the network
+	if configuration.IsOffline() {
+		return "", "", fmt.Errorf("offline mode: the local provider %s is not reachable (%v). Start it (for Ollama: ollama serve) or set offline.provider to another local provider", failedProviderName, startupErr)
+	}
 	fmt.Fprintf(os.Stderr, "[WARN] Failed to initialize provider '%s': %v\n", failedProviderName, startupErr)
 
 	// Non-interactive mode cannot recover via prompt.
@@ -16,6 +16,7 @@ import (
 	api "github.com/alantheprice/ledit/pkg/agent_api"
 	"github.com/alantheprice/ledit/pkg/credentials"
 	"github.com/alantheprice/ledit/pkg/logging"
+	"github.com/alantheprice/ledit/pkg/tokenizer"
 	"github.com/alantheprice/ledit/pkg/utils"
 )
 
@@ -224,11 +225,34 @@ func (ac *APIClient) SendWithRetry(messages []api.Message, tools []api.Tool, rea
 	ac.agent.streamingBuffer.Reset()
 	ac.agent.reasoningBuffer.Reset()
 
+	// Count tokens with the tokenizer of the model this request goes to.
+	tokenizer.SetModel(ac.agent.GetModel())
+
+	// Fail fast (or compact first) instead of uploading a request the provider will reject.
+	messages, err = ac.ensureRequestFits(messages, tools)
+	if err != nil {
+		return nil, err
+	}
+
+	// Serve identical requests from the opt-in response cache
+	cache := ac.agent.responseCache()
+	if cache != nil {
+		if cached, ok := ac.cachedResponse(cache, ac.responseCacheKey(messages, tools, reasoning, disableThinking)); ok {
+			return cached, nil
+		}
+	}
+
 	for retry := 0; retry <= ac.maxRetries; retry++ {
 		if ac.agent.debug {
 			ac.agent.debugLog("DEBUG: APIClient attempt %d/%d\n", retry, ac.maxRetries)
 		}
 
+		// Queue behind the provider's rate limits, shared with subagents
+		reservation, waitErr := ac.waitForRateLimit(ac.estimateRequestTokens(messages, tools))
+		if waitErr != nil {
+			return nil, waitErr
+		}
+
 		// Send request with diagnostic timing
 		if ac.agent.debug {
 			ac.agent.debugLog("DEBUG: APIClient starting sendRequest at %s\n", time.Now().Format("15:04:05.000"))
@@ -253,6 +277,13 @@ func (ac *APIClient) SendWithRetry(messages []api.Message, tools []api.Tool, rea
 					estimatedCost,
 					cachedTokens,
 				)
+				ac.agent.recordRequestUsage(messages, resp, promptTokens, completionTokens, estimatedCost)
+				if settleErr := reservation.Settle(totalTokens); settleErr != nil {
+					ac.agent.debugLog("DEBUG: failed to record rate limit usage: %v\n", settleErr)
+				}
+				if cache != nil {
+					ac.storeResponse(cache, ac.responseCacheKey(messages, tools, reasoning, disableThinking), resp)
+				}
 				if estimatedUsage {
 					ac.agent.MarkEstimatedTokenUsageResponse()
 				}
@@ -359,7 +390,8 @@ func (ac *APIClient) sendRequest(messages []api.Message, tools []api.Tool, reaso
 		ac.printContextBreakdown(messages, tools)
 	}
 
-	if ac.agent.streamingEnabled {
+	// Some models stream tool-call deltas unreliably; request those turns whole.
+	if ac.agent.streamingEnabled && (len(tools) == 0 || ac.agent.GetModelCapabilities().StreamingToolCalls) {
 		return ac.sendStreamingRequest(messages, tools, reasoning, disableThinking)
 	}
 	return ac.sendRegularRequest(messages, tools, reasoning, disableThinking)
@@ -428,6 +460,22 @@ func (ac *APIClient) sendStreamingRequest(messages []api.Message, tools []api.To
 	// Track streaming activity for timeout detection
 	chunkReceived := make(chan bool, 10) // Buffer to prevent blocking
 
+	// Output is written by a single goroutine fed through a bounded queue, so a
+	// fast provider applies backpressure instead of piling up pending writes.
+	dispatcher := newStreamDispatcher(ac.agent.interruptCtx, streamQueueCapacity, ac.agent.PublishStreamChunk)
+	defer func() {
+		dispatcher.close()
+		if ac.agent.outputRouter != nil {
+			ac.agent.outputRouter.FlushStream()
+		}
+		stats := dispatcher.Stats()
+		ac.agent.recordStreamDispatchStats(stats)
+		if ac.agent.debug && (stats.Coalesced > 0 || stats.Dropped > 0 || stats.Blocked > 0) {
+			ac.agent.debugLog("DEBUG: stream dispatch: enqueued=%d written=%d coalesced=%d dropped=%d blocked=%d max_depth=%d\n",
+				stats.Enqueued, stats.Written, stats.Coalesced, stats.Dropped, stats.Blocked, stats.MaxDepth)
+		}
+	}()
+
 	// Enhanced callback with timeout tracking and content type
 	streamCallback := func(content string, contentType string) {
 		// Notify that we received a chunk
@@ -456,8 +504,8 @@ func (ac *APIClient) sendStreamingRequest(messages []api.Message, tools []api.To
 		}
 
 		// Route through OutputRouter (single source: publishes event + writes terminal)
-		// This replaces the old dual-write pattern of PublishStreamChunk + streamingCallback
-		ac.agent.PublishStreamChunk(sanitizedContent, contentType)
+		// via the dispatcher's writer goroutine.
+		dispatcher.enqueue(sanitizedContent, contentType)
 	}
 
 	// Start the API call in a goroutine
@@ -517,6 +565,12 @@ func (ac *APIClient) sendStreamingRequest(messages []api.Message, tools []api.To
 			chunkTimer.Reset(ac.chunkTimeout)
 
 		case result := <-resultChan:
+			// Write any queued chunks before flushing the terminal.
+			dispatcher.close()
+			if ac.agent.outputRouter != nil {
+				ac.agent.outputRouter.FlushStream()
+			}
+
 			// Ensure streaming output is flushed
 			if ac.agent.outputMutex != nil {
 				ac.agent.outputMutex.Lock()
@@ -676,6 +730,8 @@ func (ac *APIClient) handleRateLimit(err error, attempt int) bool {
 
 	// Calculate and wait for backoff
 	backoffDelay := ac.rateLimiter.CalculateBackoffDelay(nil, attempt)
+	ac.pauseRateLimit(backoffDelay)
+	ac.agent.noteRateLimited(backoffDelay)
 
 	// Show progress to user
 	ac.rateLimiter.WaitWithProgress(backoffDelay, ac.agent.GetProvider())
@@ -870,6 +926,9 @@ func (ac *APIClient) deriveUsageMetrics(resp *api.ChatResponse, messages []api.M
 		if totalTokens == 0 {
 			totalTokens = promptTokens + completionTokens
 		}
+		if estimatedCost == 0 {
+			estimatedCost = ac.agent.GetModelCapabilities().EstimateCost(promptTokens, completionTokens)
+		}
 		return promptTokens, completionTokens, totalTokens, estimatedCost, cachedTokens, false
 	}
 
@@ -877,6 +936,9 @@ func (ac *APIClient) deriveUsageMetrics(resp *api.ChatResponse, messages []api.M
 	promptTokens = ac.estimateRequestTokens(messages, tools)
 	completionTokens = estimateCompletionTokensFromResponse(resp)
 	totalTokens = promptTokens + completionTokens
+	if estimatedCost == 0 {
+		estimatedCost = ac.agent.GetModelCapabilities().EstimateCost(promptTokens, completionTokens)
+	}
 	return promptTokens, completionTokens, totalTokens, estimatedCost, cachedTokens, true
 }
 
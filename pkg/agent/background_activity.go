@@ -0,0 +1,56 @@
+package agent
+
+import (
+	"time"
+
+	"github.com/alantheprice/ledit/pkg/index"
+)
+
+// BackgroundActivity describes work going on outside the current request:
+// the workspace watcher, symbol index builds and rate limit waits.
+type BackgroundActivity struct {
+	Watching         bool   // the workspace watcher is running
+	WatchRoot        string // directory the watcher covers
+	PendingChanges   int    // file changes waiting out the watcher's debounce
+	Indexing         bool   // a symbol index build is running
+	IndexedFiles     int
+	IndexTotal       int
+	RateLimitedUntil time.Time // zero unless requests are waiting on a rate limit
+}
+
+// IndexPercent returns how far the running symbol index build has got.
+func (b BackgroundActivity) IndexPercent() int {
+	if b.IndexTotal == 0 {
+		return 0
+	}
+	return b.IndexedFiles * 100 / b.IndexTotal
+}
+
+// BackgroundActivity reports the agent's background work.
+func (a *Agent) BackgroundActivity() BackgroundActivity {
+	var activity BackgroundActivity
+
+	a.workspaceWatcherMu.Lock()
+	ww := a.workspaceWatcher
+	a.workspaceWatcherMu.Unlock()
+	if ww != nil {
+		activity.Watching, activity.WatchRoot = true, ww.root
+		ww.mu.Lock()
+		activity.PendingChanges = len(ww.pending)
+		ww.mu.Unlock()
+	}
+
+	activity.IndexedFiles, activity.IndexTotal, activity.Indexing = index.BuildProgress()
+
+	if until := a.rateLimitedUntil.Load(); until > 0 {
+		if deadline := time.Unix(0, until); time.Now().Before(deadline) {
+			activity.RateLimitedUntil = deadline
+		}
+	}
+	return activity
+}
+
+// noteRateLimited records that requests wait delay for a rate limit.
+func (a *Agent) noteRateLimited(delay time.Duration) {
+	a.rateLimitedUntil.Store(time.Now().Add(delay).UnixNano())
+}
@@ -0,0 +1,24 @@
+package agent
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackgroundActivityRateLimit(t *testing.T) {
+	a := &Agent{}
+	if activity := a.BackgroundActivity(); activity.Watching || !activity.RateLimitedUntil.IsZero() {
+		t.Fatalf("expected no background activity, got %+v", activity)
+	}
+
+	a.noteRateLimited(time.Minute)
+	if activity := a.BackgroundActivity(); time.Until(activity.RateLimitedUntil) <= 0 {
+		t.Fatalf("expected a pending rate limit wait, got %+v", activity)
+	}
+
+	// A wait that has passed is no longer reported
+	a.noteRateLimited(-time.Second)
+	if activity := a.BackgroundActivity(); !activity.RateLimitedUntil.IsZero() {
+		t.Fatalf("expected the expired wait to be dropped, got %+v", activity)
+	}
+}
@@ -0,0 +1,466 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	api "github.com/alantheprice/ledit/pkg/agent_api"
+	tools "github.com/alantheprice/ledit/pkg/agent_tools"
+)
+
+// DefaultBranchName is the branch a session starts on.
+const DefaultBranchName = "main"
+
+// FileSnapshot is the content of one file at a checkpoint.
+type FileSnapshot struct {
+	Exists  bool   `json:"exists"`
+	Content string `json:"content,omitempty"`
+}
+
+// ConversationCheckpoint is a snapshot of the conversation, todo list,
+// committed revision IDs and agent-modified files that a branch can be
+// started from or switched back to.
+type ConversationCheckpoint struct {
+	ID              string                  `json:"id"`
+	Name            string                  `json:"name,omitempty"`
+	Branch          string                  `json:"branch"`
+	ParentID        string                  `json:"parent_id,omitempty"`
+	CreatedAt       time.Time               `json:"created_at"`
+	Auto            bool                    `json:"auto,omitempty"` // saved when switching away from a branch
+	Messages        []api.Message           `json:"messages"`
+	TurnCheckpoints []TurnCheckpoint        `json:"turn_checkpoints,omitempty"`
+	PinnedFacts     []string                `json:"pinned_facts,omitempty"`
+	PinnedFiles     []string                `json:"pinned_files,omitempty"`
+	TaskActions     []TaskAction            `json:"task_actions,omitempty"`
+	Todos           []tools.TodoItem        `json:"todos,omitempty"`
+	RevisionIDs     []string                `json:"revision_ids,omitempty"`
+	Files           map[string]FileSnapshot `json:"files,omitempty"` // absolute path → content
+}
+
+// Label returns the checkpoint name, or its ID when unnamed.
+func (c ConversationCheckpoint) Label() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	return c.ID
+}
+
+// ConversationBranch is a named line of checkpoints.
+type ConversationBranch struct {
+	Name       string `json:"name"`
+	Head       string `json:"head,omitempty"`        // latest checkpoint ID on the branch
+	ForkedFrom string `json:"forked_from,omitempty"` // checkpoint the branch started from
+}
+
+// CheckpointDiff compares two checkpoints, or a checkpoint and the live state.
+type CheckpointDiff struct {
+	From, To         string
+	MessagesFrom     int
+	MessagesTo       int
+	TodosFrom        []tools.TodoItem
+	TodosTo          []tools.TodoItem
+	RevisionsAdded   []string
+	RevisionsRemoved []string
+	Files            []FileDiff
+}
+
+// FileDiff is one file whose content differs between two checkpoints.
+type FileDiff struct {
+	Path   string
+	Before FileSnapshot
+	After  FileSnapshot
+}
+
+// branchStore holds the checkpoints and branches of one agent session.
+type branchStore struct {
+	mu          sync.Mutex
+	current     string
+	branches    []ConversationBranch
+	checkpoints []ConversationCheckpoint
+	nextID      int
+	// baseline holds each agent-modified file as it was before the agent
+	// first touched it, so checkpoints taken before that touch can restore it.
+	baseline  map[string]FileSnapshot
+	revisions []string // revision IDs committed on the current branch
+}
+
+func (a *Agent) branchState() *branchStore {
+	a.branchesOnce.Do(func() {
+		a.branches = &branchStore{
+			current:  DefaultBranchName,
+			branches: []ConversationBranch{{Name: DefaultBranchName}},
+			baseline: make(map[string]FileSnapshot),
+		}
+	})
+	return a.branches
+}
+
+// noteBranchBaseline records path's content before the agent's first change
+// to it. Called before every tracked write or edit.
+func (a *Agent) noteBranchBaseline(path string) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return
+	}
+	store := a.branchState()
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if _, ok := store.baseline[abs]; ok {
+		return
+	}
+	store.baseline[abs] = readFileSnapshot(abs)
+}
+
+// noteBranchRevision records a revision ID committed on the current branch.
+func (a *Agent) noteBranchRevision(revisionID string) {
+	if revisionID == "" {
+		return
+	}
+	store := a.branchState()
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	for _, id := range store.revisions {
+		if id == revisionID {
+			return
+		}
+	}
+	store.revisions = append(store.revisions, revisionID)
+}
+
+// CreateCheckpoint snapshots the current conversation, todos, revision IDs and
+// agent-modified files on the current branch.
+func (a *Agent) CreateCheckpoint(name string) (ConversationCheckpoint, error) {
+	name = strings.TrimSpace(name)
+	a.commitForCheckpoint()
+	store := a.branchState()
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if name != "" {
+		if _, ok := store.find(name); ok {
+			return ConversationCheckpoint{}, fmt.Errorf("checkpoint %q already exists", name)
+		}
+	}
+	cp := a.snapshotLocked(store, name, false)
+	return cp, nil
+}
+
+// ListCheckpoints returns all checkpoints, oldest first.
+func (a *Agent) ListCheckpoints() []ConversationCheckpoint {
+	store := a.branchState()
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	return append([]ConversationCheckpoint(nil), store.checkpoints...)
+}
+
+// ListBranches returns the current branch name and all branches sorted by name.
+func (a *Agent) ListBranches() (string, []ConversationBranch) {
+	store := a.branchState()
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	branches := append([]ConversationBranch(nil), store.branches...)
+	sort.Slice(branches, func(i, j int) bool { return branches[i].Name < branches[j].Name })
+	return store.current, branches
+}
+
+// CreateBranch starts a new branch from a checkpoint (by ID or name; the
+// current branch's latest checkpoint when empty) and switches to it. The
+// state being left is saved as a checkpoint so it can be switched back to.
+func (a *Agent) CreateBranch(name, from string) (ConversationCheckpoint, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return ConversationCheckpoint{}, errors.New("branch name is required")
+	}
+	a.commitForCheckpoint()
+	store := a.branchState()
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if store.branch(name) != nil {
+		return ConversationCheckpoint{}, fmt.Errorf("branch %q already exists", name)
+	}
+	var base ConversationCheckpoint
+	if strings.TrimSpace(from) == "" {
+		head := store.branch(store.current).Head
+		if head == "" {
+			return ConversationCheckpoint{}, errors.New("no checkpoint to branch from: create one with /checkpoint first")
+		}
+		base, _ = store.find(head)
+	} else {
+		cp, ok := store.find(from)
+		if !ok {
+			return ConversationCheckpoint{}, fmt.Errorf("checkpoint %q not found", from)
+		}
+		base = cp
+	}
+
+	a.snapshotLocked(store, "", true)
+	if err := a.restoreLocked(store, base); err != nil {
+		return ConversationCheckpoint{}, err
+	}
+	store.branches = append(store.branches, ConversationBranch{Name: name, Head: base.ID, ForkedFrom: base.ID})
+	store.current = name
+	return base, nil
+}
+
+// SwitchBranch saves the current state on the current branch and restores the
+// latest checkpoint of the named branch.
+func (a *Agent) SwitchBranch(name string) (ConversationCheckpoint, error) {
+	a.commitForCheckpoint()
+	store := a.branchState()
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	target := store.branch(strings.TrimSpace(name))
+	if target == nil {
+		return ConversationCheckpoint{}, fmt.Errorf("branch %q not found", name)
+	}
+	if target.Name == store.current {
+		return ConversationCheckpoint{}, fmt.Errorf("already on branch %q", name)
+	}
+	head, ok := store.find(target.Head)
+	if !ok {
+		return ConversationCheckpoint{}, fmt.Errorf("branch %q has no checkpoint to switch to", name)
+	}
+
+	a.snapshotLocked(store, "", true)
+	if err := a.restoreLocked(store, head); err != nil {
+		return ConversationCheckpoint{}, err
+	}
+	store.current = target.Name
+	return head, nil
+}
+
+// DiffCheckpoints compares checkpoint from with checkpoint to, or with the
+// live state when to is empty.
+func (a *Agent) DiffCheckpoints(from, to string) (CheckpointDiff, error) {
+	store := a.branchState()
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	before, ok := store.find(from)
+	if !ok {
+		return CheckpointDiff{}, fmt.Errorf("checkpoint %q not found", from)
+	}
+	var after ConversationCheckpoint
+	if strings.TrimSpace(to) == "" {
+		after = a.captureLocked(store)
+		after.ID = "current"
+	} else if after, ok = store.find(to); !ok {
+		return CheckpointDiff{}, fmt.Errorf("checkpoint %q not found", to)
+	}
+
+	diff := CheckpointDiff{
+		From:         before.Label(),
+		To:           after.Label(),
+		MessagesFrom: len(before.Messages),
+		MessagesTo:   len(after.Messages),
+		TodosFrom:    before.Todos,
+		TodosTo:      after.Todos,
+	}
+	diff.RevisionsAdded = subtractStrings(after.RevisionIDs, before.RevisionIDs)
+	diff.RevisionsRemoved = subtractStrings(before.RevisionIDs, after.RevisionIDs)
+	for _, path := range store.knownFiles(before, after) {
+		b, af := store.fileAt(before, path), store.fileAt(after, path)
+		if b != af {
+			diff.Files = append(diff.Files, FileDiff{Path: path, Before: b, After: af})
+		}
+	}
+	return diff, nil
+}
+
+// SessionDiff returns the files the agent changed this session whose content
+// differs from before its first change to them, sorted by path.
+func (a *Agent) SessionDiff() []FileDiff {
+	store := a.branchState()
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	var files []FileDiff
+	for _, path := range store.knownFiles() {
+		before, after := store.baseline[path], readFileSnapshot(path)
+		if before != after {
+			files = append(files, FileDiff{Path: path, Before: before, After: after})
+		}
+	}
+	return files
+}
+
+// commitForCheckpoint commits pending tracked changes so their revision ID is
+// part of the next checkpoint. Must be called without the branch store lock.
+func (a *Agent) commitForCheckpoint() {
+	if err := a.CommitChanges("checkpoint"); err != nil {
+		a.debugLog("failed to commit changes for checkpoint: %v\n", err)
+	}
+}
+
+// snapshotLocked captures the live state as a new checkpoint on the current
+// branch and advances the branch head.
+func (a *Agent) snapshotLocked(store *branchStore, name string, auto bool) ConversationCheckpoint {
+	cp := a.captureLocked(store)
+	store.nextID++
+	cp.ID = fmt.Sprintf("cp%d", store.nextID)
+	cp.Name = name
+	cp.Auto = auto
+	branch := store.branch(store.current)
+	cp.ParentID = branch.Head
+	branch.Head = cp.ID
+	store.checkpoints = append(store.checkpoints, cp)
+	return cp
+}
+
+// captureLocked reads the live conversation, todos and files into a
+// checkpoint without an ID.
+func (a *Agent) captureLocked(store *branchStore) ConversationCheckpoint {
+	facts, files := a.GetPins()
+	cp := ConversationCheckpoint{
+		Branch:          store.current,
+		CreatedAt:       time.Now(),
+		Messages:        append([]api.Message(nil), a.messages...),
+		TurnCheckpoints: a.copyTurnCheckpoints(),
+		PinnedFacts:     facts,
+		PinnedFiles:     files,
+		TaskActions:     a.GetTaskActions(),
+		Todos:           tools.TodoRead(),
+		RevisionIDs:     append([]string(nil), store.revisions...),
+		Files:           make(map[string]FileSnapshot, len(store.baseline)),
+	}
+	for path := range store.baseline {
+		cp.Files[path] = readFileSnapshot(path)
+	}
+	return cp
+}
+
+// restoreLocked replaces the live conversation, todos and files with cp.
+// Files the agent touched after cp was taken revert to their baseline.
+func (a *Agent) restoreLocked(store *branchStore, cp ConversationCheckpoint) error {
+	for _, path := range store.knownFiles(cp) {
+		want := store.fileAt(cp, path)
+		if readFileSnapshot(path) == want {
+			continue
+		}
+		a.noteAgentFileWrite(path)
+		if !want.Exists {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to remove %s: %w", path, err)
+			}
+		} else {
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return fmt.Errorf("failed to restore %s: %w", path, err)
+			}
+			if err := os.WriteFile(path, []byte(want.Content), 0644); err != nil {
+				return fmt.Errorf("failed to restore %s: %w", path, err)
+			}
+		}
+		if a.optimizer != nil {
+			a.optimizer.InvalidateFile(path)
+			if rel, err := filepath.Rel(a.GetWorkspaceRoot(), path); err == nil {
+				a.optimizer.InvalidateFile(rel)
+			}
+		}
+	}
+
+	// Keep running totals: switching branches does not refund spent tokens.
+	a.ApplyState(&ConversationState{
+		Messages:                append([]api.Message(nil), cp.Messages...),
+		TurnCheckpoints:         cp.TurnCheckpoints,
+		PinnedFacts:             cp.PinnedFacts,
+		PinnedFiles:             cp.PinnedFiles,
+		TaskActions:             cp.TaskActions,
+		TotalCost:               a.totalCost,
+		TotalTokens:             a.totalTokens,
+		PromptTokens:            a.promptTokens,
+		CompletionTokens:        a.completionTokens,
+		EstimatedTokenResponses: a.estimatedTokenResponses,
+		CachedTokens:            a.cachedTokens,
+		CachedCostSavings:       a.cachedCostSavings,
+	})
+	tools.TodoWrite(append([]tools.TodoItem(nil), cp.Todos...))
+	store.revisions = append([]string(nil), cp.RevisionIDs...)
+	return nil
+}
+
+func (s *branchStore) branch(name string) *ConversationBranch {
+	for i := range s.branches {
+		if s.branches[i].Name == name {
+			return &s.branches[i]
+		}
+	}
+	return nil
+}
+
+// find looks a checkpoint up by ID, then by name (latest wins).
+func (s *branchStore) find(ref string) (ConversationCheckpoint, bool) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return ConversationCheckpoint{}, false
+	}
+	for _, cp := range s.checkpoints {
+		if cp.ID == ref {
+			return cp, true
+		}
+	}
+	for i := len(s.checkpoints) - 1; i >= 0; i-- {
+		if s.checkpoints[i].Name == ref {
+			return s.checkpoints[i], true
+		}
+	}
+	return ConversationCheckpoint{}, false
+}
+
+// fileAt returns path's content as of cp. Files first touched after cp was
+// taken are not in its snapshot and had their baseline content then.
+func (s *branchStore) fileAt(cp ConversationCheckpoint, path string) FileSnapshot {
+	if snap, ok := cp.Files[path]; ok {
+		return snap
+	}
+	return s.baseline[path]
+}
+
+// knownFiles lists every file tracked by the baseline or the given checkpoints.
+func (s *branchStore) knownFiles(cps ...ConversationCheckpoint) []string {
+	seen := make(map[string]bool, len(s.baseline))
+	for path := range s.baseline {
+		seen[path] = true
+	}
+	for _, cp := range cps {
+		for path := range cp.Files {
+			seen[path] = true
+		}
+	}
+	paths := make([]string, 0, len(seen))
+	for path := range seen {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func readFileSnapshot(path string) FileSnapshot {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return FileSnapshot{}
+	}
+	return FileSnapshot{Exists: true, Content: string(data)}
+}
+
+// subtractStrings returns the values in a that are not in b, in order.
+func subtractStrings(a, b []string) []string {
+	exclude := make(map[string]bool, len(b))
+	for _, v := range b {
+		exclude[v] = true
+	}
+	var out []string
+	for _, v := range a {
+		if !exclude[v] {
+			out = append(out, v)
+		}
+	}
+	return out
+}
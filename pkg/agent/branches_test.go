@@ -0,0 +1,89 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	api "github.com/alantheprice/ledit/pkg/agent_api"
+	tools "github.com/alantheprice/ledit/pkg/agent_tools"
+)
+
+func TestCheckpointBranchRestoresConversationTodosAndFiles(t *testing.T) {
+	defer tools.TodoWrite(nil)
+
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "main.go")
+	if err := os.WriteFile(existing, []byte("v1"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	a := &Agent{messages: []api.Message{{Role: "user", Content: "refactor main"}}}
+	tools.TodoWrite([]tools.TodoItem{{ID: "1", Content: "refactor", Status: "pending"}})
+
+	base, err := a.CreateCheckpoint("before-refactor")
+	if err != nil {
+		t.Fatalf("CreateCheckpoint: %v", err)
+	}
+	if base.ID != "cp1" || base.Branch != DefaultBranchName {
+		t.Fatalf("unexpected checkpoint: %+v", base)
+	}
+
+	// First approach on main: edit one file, create another.
+	created := filepath.Join(dir, "helper.go")
+	a.noteBranchBaseline(existing)
+	a.noteBranchBaseline(created)
+	if err := os.WriteFile(existing, []byte("v2"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(created, []byte("helper"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	a.messages = append(a.messages, api.Message{Role: "assistant", Content: "approach A"})
+	tools.TodoWrite([]tools.TodoItem{{ID: "1", Content: "refactor", Status: "completed"}})
+
+	if _, err := a.CreateBranch("alt", "before-refactor"); err != nil {
+		t.Fatalf("CreateBranch: %v", err)
+	}
+	if len(a.messages) != 1 {
+		t.Errorf("expected conversation restored to 1 message, got %d", len(a.messages))
+	}
+	if todos := tools.TodoRead(); len(todos) != 1 || todos[0].Status != "pending" {
+		t.Errorf("expected todos restored, got %+v", todos)
+	}
+	if data, _ := os.ReadFile(existing); string(data) != "v1" {
+		t.Errorf("expected main.go restored to v1, got %q", data)
+	}
+	if _, err := os.Stat(created); !os.IsNotExist(err) {
+		t.Errorf("expected helper.go removed on branch, stat err = %v", err)
+	}
+
+	diff, err := a.DiffCheckpoints("before-refactor", "cp2")
+	if err != nil {
+		t.Fatalf("DiffCheckpoints: %v", err)
+	}
+	if diff.MessagesFrom != 1 || diff.MessagesTo != 2 || len(diff.Files) != 2 {
+		t.Errorf("unexpected diff: %+v", diff)
+	}
+
+	if _, err := a.SwitchBranch(DefaultBranchName); err != nil {
+		t.Fatalf("SwitchBranch: %v", err)
+	}
+	if data, _ := os.ReadFile(existing); string(data) != "v2" {
+		t.Errorf("expected main.go back at v2 on main, got %q", data)
+	}
+	if data, _ := os.ReadFile(created); string(data) != "helper" {
+		t.Errorf("expected helper.go back on main, got %q", data)
+	}
+	if len(a.messages) != 2 {
+		t.Errorf("expected main conversation with 2 messages, got %d", len(a.messages))
+	}
+
+	current, branches := a.ListBranches()
+	if current != DefaultBranchName || len(branches) != 2 || branches[0].Name != "alt" || branches[0].ForkedFrom != "cp1" {
+		t.Errorf("unexpected branches: current=%s %+v", current, branches)
+	}
+	if _, err := a.CreateBranch("alt", ""); err == nil {
+		t.Error("expected error creating a duplicate branch")
+	}
+}
@@ -0,0 +1,165 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	api "github.com/alantheprice/ledit/pkg/agent_api"
+)
+
+// The clarification pre-flight scores a request for ambiguity with one
+// request to the model, without tools, before the agent starts on it. When
+// the score reaches the configured threshold the questions the model
+// suggests are asked through ask_user and the answers are added to the
+// request, so work starts from the interpretation the user meant.
+
+const clarificationSystemPrompt = `You review a request to a coding agent before the agent starts working on it. Decide how ambiguous the request is: whether a capable engineer with the conversation so far and access to the repository would have to guess at what the user wants.
+
+Score 0 when the request is clear or the agent can settle the open points by reading the code; score 1 when plausible interpretations lead to substantially different work. Do not count details the agent can decide sensibly on its own.
+
+When the score is 0.5 or higher, write at most %d short questions whose answers would remove the ambiguity, most important first. Give each question 2 to 4 likely answers as options when they are known.
+
+Respond with JSON only, no prose:
+{"score": 0.0, "reason": "one sentence", "questions": [{"question": "...", "options": ["...", "..."]}]}`
+
+const (
+	// clarificationContextMessages is how many earlier messages are shown
+	// with the request, so follow-ups are judged in context.
+	clarificationContextMessages = 6
+	// clarificationContextChars caps each earlier message.
+	clarificationContextChars = 500
+)
+
+// clarificationAssessment is the model's verdict on a request.
+type clarificationAssessment struct {
+	Score     float64                 `json:"score"`
+	Reason    string                  `json:"reason"`
+	Questions []clarificationQuestion `json:"questions"`
+}
+
+type clarificationQuestion struct {
+	Question string   `json:"question"`
+	Options  []string `json:"options"`
+}
+
+// clarifyRequest runs the clarification pre-flight on query and returns it
+// with the user's answers appended. The query is returned unchanged when the
+// pre-flight is disabled, nobody can answer, the request is short or clear,
+// or the assessment fails.
+func (a *Agent) clarifyRequest(query string) string {
+	cfg := a.GetConfig()
+	if cfg == nil || a.client == nil {
+		return query
+	}
+	settings := cfg.GetClarification()
+	trimmed := strings.TrimSpace(query)
+	if !settings.Enabled || strings.HasPrefix(trimmed, "/") || len(strings.Fields(trimmed)) < settings.MinWords || !a.canAskUser() {
+		return query
+	}
+
+	assessment, err := a.assessAmbiguity(query, settings.MaxQuestions)
+	if err != nil {
+		a.debugLog("[clarify] Skipping clarification: %v\n", err)
+		return query
+	}
+	a.debugLog("[clarify] Ambiguity score %.2f (threshold %.2f): %s\n", assessment.Score, settings.Threshold, assessment.Reason)
+	if assessment.Score < settings.Threshold || len(assessment.Questions) == 0 {
+		return query
+	}
+
+	questions := assessment.Questions
+	if len(questions) > settings.MaxQuestions {
+		questions = questions[:settings.MaxQuestions]
+	}
+	a.PrintLine(fmt.Sprintf("[?] The request is open to more than one reading (ambiguity %.2f): %s", assessment.Score, strings.TrimSpace(assessment.Reason)))
+
+	var answers []string
+	for _, q := range questions {
+		question := strings.TrimSpace(q.Question)
+		if question == "" {
+			continue
+		}
+		answer, err := a.askUser(question, q.Options, true)
+		if err != nil {
+			if !errors.Is(err, ErrCancelled) {
+				a.debugLog("[clarify] Stopped asking: %v\n", err)
+			}
+			break
+		}
+		if answer = strings.TrimSpace(answer); answer != "" {
+			answers = append(answers, fmt.Sprintf("- %s\n  Answer: %s", question, answer))
+		}
+	}
+	if len(answers) == 0 {
+		return query
+	}
+	return query + "\n\n## Clarifications\n\nThe user answered these questions about the request:\n" + strings.Join(answers, "\n")
+}
+
+// assessAmbiguity asks the model to score query for ambiguity and suggest up
+// to maxQuestions clarifying questions.
+func (a *Agent) assessAmbiguity(query string, maxQuestions int) (*clarificationAssessment, error) {
+	var prompt strings.Builder
+	if history := a.clarificationContext(); history != "" {
+		prompt.WriteString("Conversation so far:\n")
+		prompt.WriteString(history)
+		prompt.WriteString("\n\n")
+	}
+	prompt.WriteString("Request:\n")
+	prompt.WriteString(query)
+
+	messages := []api.Message{
+		{Role: "system", Content: fmt.Sprintf(clarificationSystemPrompt, maxQuestions)},
+		{Role: "user", Content: prompt.String()},
+	}
+	resp, err := a.client.SendChatRequest(messages, nil, "", true)
+	if err != nil {
+		return nil, fmt.Errorf("ambiguity check failed: %w", err)
+	}
+	if resp == nil || len(resp.Choices) == 0 {
+		return nil, errors.New("ambiguity check returned no response")
+	}
+	usage := resp.Usage
+	a.TrackMetricsFromResponse(usage.PromptTokens, usage.CompletionTokens, usage.TotalTokens, usage.EstimatedCost, usage.PromptTokensDetails.CachedTokens)
+	a.recordRequestUsage(messages, resp, usage.PromptTokens, usage.CompletionTokens, usage.EstimatedCost)
+
+	return parseClarificationAssessment(resp.Choices[0].Message.Content)
+}
+
+// clarificationContext renders the last user and assistant messages of the
+// conversation, shortened.
+func (a *Agent) clarificationContext() string {
+	var lines []string
+	for i := len(a.messages) - 1; i >= 0 && len(lines) < clarificationContextMessages; i-- {
+		msg := a.messages[i]
+		content := strings.TrimSpace(msg.Content)
+		if (msg.Role != "user" && msg.Role != "assistant") || content == "" {
+			continue
+		}
+		if len(content) > clarificationContextChars {
+			content = strings.ToValidUTF8(content[:clarificationContextChars], "") + "..."
+		}
+		lines = append(lines, msg.Role+": "+content)
+	}
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// parseClarificationAssessment reads the JSON object in a model response,
+// ignoring any code fence or text around it.
+func parseClarificationAssessment(content string) (*clarificationAssessment, error) {
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("ambiguity check returned no JSON: %q", content)
+	}
+	var assessment clarificationAssessment
+	if err := json.Unmarshal([]byte(content[start:end+1]), &assessment); err != nil {
+		return nil, fmt.Errorf("ambiguity check returned invalid JSON: %w", err)
+	}
+	return &assessment, nil
+}
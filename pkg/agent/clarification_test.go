@@ -0,0 +1,116 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/alantheprice/ledit/pkg/configuration"
+)
+
+// pickFirstUI answers every quick prompt with its first option.
+type pickFirstUI struct {
+	prompts []string
+}
+
+func (u *pickFirstUI) IsInteractive() bool { return true }
+
+func (u *pickFirstUI) ShowDropdown(ctx context.Context, items interface{}, options DropdownOptions) (interface{}, error) {
+	return nil, ErrUINotAvailable
+}
+
+func (u *pickFirstUI) ShowQuickPrompt(ctx context.Context, prompt string, options []QuickOption, horizontal bool) (QuickOption, error) {
+	u.prompts = append(u.prompts, prompt)
+	return options[0], nil
+}
+
+func newClarificationTestAgent(t *testing.T, enabled bool, responses ...*ScriptedResponse) (*Agent, *ScriptedClient, *pickFirstUI) {
+	t.Helper()
+	for _, key := range []string{"LEDIT_FROM_AGENT", "LEDIT_SUBAGENT", "LEDIT_CI_MODE", "CI", "GITHUB_ACTIONS"} {
+		t.Setenv(key, "")
+	}
+	cfg := configuration.NewConfig()
+	cfg.Clarification = &configuration.ClarificationConfig{Enabled: enabled}
+	client := NewScriptedClient(responses...)
+	a := makeAgentWithScriptedClient(1, client)
+	a.configManager = configuration.NewManagerWithConfig(cfg, nil)
+	ui := &pickFirstUI{}
+	a.ui = ui
+	return a, client, ui
+}
+
+const ambiguousRequest = "Make the export faster for the big customers please"
+
+func TestClarifyRequestAsksAboutAmbiguousRequests(t *testing.T) {
+	assessment := "```json\n" + `{"score": 0.8, "reason": "Which export is meant is unclear.", "questions": [
+		{"question": "Which export?", "options": ["CSV export", "PDF export"]},
+		{"question": "What counts as big?", "options": ["Over 10k rows", "Over 1M rows"]},
+		{"question": "Is caching allowed?", "options": ["Yes", "No"]},
+		{"question": "A fourth question", "options": ["a", "b"]}]}` + "\n```"
+	a, client, ui := newClarificationTestAgent(t, true,
+		NewScriptedResponseBuilder().Content(assessment).Usage(120, 40, 160, 0.002).Build())
+
+	query := a.clarifyRequest(ambiguousRequest)
+
+	if !strings.HasPrefix(query, ambiguousRequest) {
+		t.Fatalf("expected the request to be kept, got %q", query)
+	}
+	for _, want := range []string{"## Clarifications", "Which export?\n  Answer: CSV export", "What counts as big?\n  Answer: Over 10k rows"} {
+		if !strings.Contains(query, want) {
+			t.Fatalf("expected %q in the clarified request, got %q", want, query)
+		}
+	}
+	if len(ui.prompts) != configuration.MaxClarificationQuestions {
+		t.Fatalf("expected %d questions, got %v", configuration.MaxClarificationQuestions, ui.prompts)
+	}
+	if sent := client.GetSentRequests(); len(sent) != 1 || !strings.Contains(sent[0][1].Content, ambiguousRequest) {
+		t.Fatalf("expected one scoring request for the query, got %v", sent)
+	}
+	if a.totalCost != 0.002 || a.totalTokens != 160 {
+		t.Fatalf("expected the scoring request to be tracked, got %d tokens, $%f", a.totalTokens, a.totalCost)
+	}
+}
+
+func TestClarifyRequestLeavesClearRequestsAlone(t *testing.T) {
+	a, client, ui := newClarificationTestAgent(t, true,
+		NewScriptedResponseBuilder().Content(`{"score": 0.2, "reason": "Clear.", "questions": []}`).Build())
+	if query := a.clarifyRequest(ambiguousRequest); query != ambiguousRequest {
+		t.Fatalf("expected a clear request to be unchanged, got %q", query)
+	}
+	if len(ui.prompts) != 0 || len(client.GetSentRequests()) != 1 {
+		t.Fatalf("expected one scoring request and no questions, got %v", ui.prompts)
+	}
+
+	// Disabled, short requests and slash commands are not scored at all.
+	disabled, disabledClient, _ := newClarificationTestAgent(t, false)
+	short, shortClient, _ := newClarificationTestAgent(t, true)
+	for _, tc := range []struct {
+		agent  *Agent
+		client *ScriptedClient
+		query  string
+	}{
+		{disabled, disabledClient, ambiguousRequest},
+		{short, shortClient, "fix the tests"},
+		{short, shortClient, "/commit with a message about the export change"},
+	} {
+		if query := tc.agent.clarifyRequest(tc.query); query != tc.query {
+			t.Fatalf("expected %q to be unchanged, got %q", tc.query, query)
+		}
+		if sent := tc.client.GetSentRequests(); len(sent) != 0 {
+			t.Fatalf("expected %q not to be scored, got %d requests", tc.query, len(sent))
+		}
+	}
+}
+
+func TestParseClarificationAssessment(t *testing.T) {
+	got, err := parseClarificationAssessment(`Here you go: {"score": 0.7, "reason": "r", "questions": [{"question": "q?"}]} done`)
+	if err != nil {
+		t.Fatalf("parseClarificationAssessment: %v", err)
+	}
+	if got.Score != 0.7 || len(got.Questions) != 1 || got.Questions[0].Question != "q?" {
+		t.Fatalf("unexpected assessment %+v", got)
+	}
+	if _, err := parseClarificationAssessment("no json here"); err == nil {
+		t.Fatal("expected an error for a response without JSON")
+	}
+}
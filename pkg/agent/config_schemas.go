@@ -0,0 +1,231 @@
+package agent
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Schemas for config files the agent commonly edits. They are subsets of the
+// upstream JSON schemas limited to what validateDataAgainstSchema understands;
+// "$ref": "#/$defs/..." references are inlined when loaded.
+//
+//go:embed config_schemas/*.json
+var configSchemaFS embed.FS
+
+type knownConfigSchemas struct {
+	packageJSON    map[string]interface{}
+	compose        map[string]interface{}
+	workflow       map[string]interface{}
+	kubernetes     map[string]interface{}
+	kubernetesKind map[string]map[string]interface{} // kind → extra top-level properties
+}
+
+var (
+	configSchemasOnce sync.Once
+	configSchemas     knownConfigSchemas
+	configSchemasErr  error
+)
+
+func loadKnownConfigSchemas() (knownConfigSchemas, error) {
+	configSchemasOnce.Do(func() {
+		load := func(name string) map[string]interface{} {
+			if configSchemasErr != nil {
+				return nil
+			}
+			data, err := configSchemaFS.ReadFile("config_schemas/" + name)
+			if err != nil {
+				configSchemasErr = err
+				return nil
+			}
+			var schema map[string]interface{}
+			if err := json.Unmarshal(data, &schema); err != nil {
+				configSchemasErr = fmt.Errorf("invalid embedded schema %s: %w", name, err)
+				return nil
+			}
+			defs, _ := schema["$defs"].(map[string]interface{})
+			resolved, _ := inlineSchemaRefs(schema, defs).(map[string]interface{})
+			delete(resolved, "$defs")
+			return resolved
+		}
+
+		configSchemas.packageJSON = load("package-json.json")
+		configSchemas.compose = load("docker-compose.json")
+		configSchemas.workflow = load("github-workflow.json")
+		configSchemas.kubernetes = load("kubernetes.json")
+		if configSchemasErr != nil {
+			return
+		}
+		kinds, _ := configSchemas.kubernetes["kinds"].(map[string]interface{})
+		delete(configSchemas.kubernetes, "kinds")
+		configSchemas.kubernetesKind = make(map[string]map[string]interface{}, len(kinds))
+		for kind, raw := range kinds {
+			if props, ok := raw.(map[string]interface{}); ok {
+				configSchemas.kubernetesKind[kind] = props
+			}
+		}
+	})
+	return configSchemas, configSchemasErr
+}
+
+// inlineSchemaRefs replaces {"$ref": "#/$defs/<name>"} nodes with the named
+// definition. The embedded schemas are not recursive.
+func inlineSchemaRefs(node interface{}, defs map[string]interface{}) interface{} {
+	switch typed := node.(type) {
+	case map[string]interface{}:
+		if ref, ok := typed["$ref"].(string); ok {
+			if def, ok := defs[strings.TrimPrefix(ref, "#/$defs/")]; ok {
+				return inlineSchemaRefs(def, defs)
+			}
+		}
+		out := make(map[string]interface{}, len(typed))
+		for key, value := range typed {
+			out[key] = inlineSchemaRefs(value, defs)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(typed))
+		for i := range typed {
+			out[i] = inlineSchemaRefs(typed[i], defs)
+		}
+		return out
+	default:
+		return typed
+	}
+}
+
+// knownConfigSchemaFor returns the schema name and schema for a config
+// document at path, or "" when the file is not a recognized config type.
+func knownConfigSchemaFor(path string, doc interface{}) (string, map[string]interface{}) {
+	schemas, err := loadKnownConfigSchemas()
+	if err != nil {
+		return "", nil
+	}
+
+	base := strings.ToLower(filepath.Base(path))
+	ext := filepath.Ext(base)
+	isYAML := ext == ".yaml" || ext == ".yml"
+	switch {
+	case base == "package.json":
+		return "package.json", schemas.packageJSON
+	case isYAML && (strings.HasPrefix(base, "docker-compose") || strings.HasPrefix(base, "compose.")):
+		return "docker-compose", schemas.compose
+	case isYAML && strings.Contains(filepath.ToSlash(filepath.Clean(path)), ".github/workflows/"):
+		return "github-workflow", schemas.workflow
+	}
+
+	if !isYAML && ext != ".json" {
+		return "", nil
+	}
+	obj, ok := doc.(map[string]interface{})
+	if !ok {
+		return "", nil
+	}
+	apiVersion, hasAPIVersion := obj["apiVersion"].(string)
+	kind, hasKind := obj["kind"].(string)
+	if !hasAPIVersion || !hasKind || apiVersion == "" || kind == "" {
+		return "", nil
+	}
+
+	extra := schemas.kubernetesKind[kind]
+	if len(extra) == 0 {
+		return "kubernetes", schemas.kubernetes
+	}
+	props := make(map[string]interface{})
+	for key, value := range schemas.kubernetes["properties"].(map[string]interface{}) {
+		props[key] = value
+	}
+	for key, value := range extra {
+		props[key] = value
+	}
+	schema := make(map[string]interface{}, len(schemas.kubernetes))
+	for key, value := range schemas.kubernetes {
+		schema[key] = value
+	}
+	schema["properties"] = props
+	return "kubernetes/" + kind, schema
+}
+
+// validateKnownConfigContent checks content against the schema of a
+// recognized config file type (package.json, Docker Compose, GitHub
+// workflows, Kubernetes manifests). It returns "" and no errors for other
+// files and for content that does not parse; syntax is reported elsewhere.
+func validateKnownConfigContent(path, content string) (string, []string) {
+	format := inferStructuredFormat(path, "")
+	if format == "" || strings.TrimSpace(content) == "" {
+		return "", nil
+	}
+
+	var docs []interface{}
+	if format == "json" {
+		var doc interface{}
+		if err := json.Unmarshal([]byte(content), &doc); err != nil {
+			return "", nil
+		}
+		docs = append(docs, doc)
+	} else {
+		// Kubernetes manifests often hold several "---" separated documents.
+		dec := yaml.NewDecoder(strings.NewReader(content))
+		for {
+			var doc interface{}
+			err := dec.Decode(&doc)
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return "", nil
+			}
+			if doc != nil {
+				docs = append(docs, normalizeYAMLValue(doc))
+			}
+		}
+	}
+
+	var names []string
+	var errs []string
+	for i, doc := range docs {
+		name, schema := knownConfigSchemaFor(path, doc)
+		if schema == nil {
+			continue
+		}
+		root := "$"
+		if len(docs) > 1 {
+			root = fmt.Sprintf("$[doc%d]", i+1)
+		}
+		if docErrs := validateDataAgainstSchema(doc, schema, root); len(docErrs) > 0 {
+			errs = append(errs, docErrs...)
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	return strings.Join(names, ","), errs
+}
+
+// checkKnownConfigSchema returns a validation error describing schema
+// violations that content introduces over previous (the file before the
+// change). It is returned as the tool result so the model can fix the config
+// in the same turn instead of landing an invalid file. Violations already
+// present in previous are not reported, so existing files stay editable.
+func checkKnownConfigSchema(toolName, path, previous, content string) error {
+	name, errs := validateKnownConfigContent(path, content)
+	if len(errs) == 0 {
+		return nil
+	}
+	if _, existing := validateKnownConfigContent(path, previous); len(existing) > 0 {
+		errs = slices.DeleteFunc(errs, func(e string) bool { return slices.Contains(existing, e) })
+		if len(errs) == 0 {
+			return nil
+		}
+	}
+	return formatStructuredValidationError(toolName, errs,
+		fmt.Sprintf("schema=%s file=%s next_step=fix the listed fields and retry; the change was not applied", name, path))
+}
@@ -0,0 +1,142 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateKnownConfigContentDetectsSchemaViolations(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		content    string
+		wantSchema string
+		wantErrs   []string
+	}{
+		{
+			name:       "package.json dependency version must be a string",
+			path:       "package.json",
+			content:    `{"name": "app", "dependencies": {"react": 18}, "scripts": {"test": "jest"}}`,
+			wantSchema: "package.json",
+			wantErrs:   []string{"$.dependencies.react: expected string"},
+		},
+		{
+			name: "compose service typo",
+			path: "deploy/docker-compose.yml",
+			content: `services:
+  web:
+    image: nginx
+    port: ["80:80"]
+x-common: {}
+`,
+			wantSchema: "docker-compose",
+			wantErrs:   []string{"$.services.web.port: additional property not allowed"},
+		},
+		{
+			name: "workflow missing on and bad step key",
+			path: ".github/workflows/ci.yml",
+			content: `name: CI
+jobs:
+  test:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+      - runs: go test ./...
+`,
+			wantSchema: "github-workflow",
+			wantErrs:   []string{"$.on: required field missing", "$.jobs.test.steps[1].runs: additional property not allowed"},
+		},
+		{
+			name: "kubernetes multi-document manifest",
+			path: "k8s/app.yaml",
+			content: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+  labels:
+    version: 2
+spec:
+  selector:
+    matchLabels:
+      app: api
+  template:
+    spec:
+      containers:
+        - name: api
+          image: api:latest
+          env:
+            - name: PORT
+              value: 8080
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: api
+spec:
+  ports:
+    - port: "80"
+`,
+			wantSchema: "kubernetes/Deployment,kubernetes/Service",
+			wantErrs: []string{
+				"$[doc1].metadata.labels.version: expected string",
+				"$[doc1].spec.template.spec.containers[0].env[0].value: expected string",
+				"$[doc2].spec.ports[0].port: expected integer",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, errs := validateKnownConfigContent(tt.path, tt.content)
+			if schema != tt.wantSchema {
+				t.Errorf("schema = %q, want %q", schema, tt.wantSchema)
+			}
+			if len(errs) != len(tt.wantErrs) {
+				t.Fatalf("got errors %v, want %v", errs, tt.wantErrs)
+			}
+			for _, want := range tt.wantErrs {
+				found := false
+				for _, got := range errs {
+					found = found || got == want
+				}
+				if !found {
+					t.Errorf("missing error %q in %v", want, errs)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateKnownConfigContentIgnoresOtherFiles(t *testing.T) {
+	for path, content := range map[string]string{
+		"config.yaml":           "apiVersion: 1\nport: abc\n",
+		"tsconfig.json":         `{"compilerOptions": {"strict": "yes"}}`,
+		"chart/templates/a.yml": "apiVersion: v1\nkind: {{ .Values.kind }}\n",
+		"docker-compose.yml":    "services: [",
+	} {
+		if schema, errs := validateKnownConfigContent(path, content); schema != "" || len(errs) != 0 {
+			t.Errorf("%s: expected no schema check, got %q %v", path, schema, errs)
+		}
+	}
+}
+
+func TestCheckKnownConfigSchemaReportsOnlyNewViolations(t *testing.T) {
+	previous := `{"name": "app", "version": 1}`
+
+	if err := checkKnownConfigSchema("edit_file", "package.json", previous, `{"name": "app", "version": 1, "private": true}`); err != nil {
+		t.Fatalf("pre-existing violation should not block the edit: %v", err)
+	}
+
+	err := checkKnownConfigSchema("edit_file", "package.json", previous, `{"name": "app", "version": 1, "private": "yes"}`)
+	if err == nil {
+		t.Fatal("expected schema violation for private")
+	}
+	for _, want := range []string{"tool=edit_file", "schema=package.json", "failed_paths=$.private", "the change was not applied"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q missing %q", err, want)
+		}
+	}
+	if strings.Contains(err.Error(), "$.version") {
+		t.Errorf("pre-existing $.version violation should not be reported: %v", err)
+	}
+}
@@ -0,0 +1,167 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CompactionResult describes a compaction pass over the conversation history.
+type CompactionResult struct {
+	Method         string // "checkpoint" or "structural"
+	BeforeMessages int
+	AfterMessages  int
+	BeforeTokens   int
+	AfterTokens    int
+}
+
+// CompactHistory rewrites older turns into summaries without dropping recent
+// context: completed-turn checkpoints are applied first, then structural
+// compaction summarizes the middle of the conversation. It returns false when
+// nothing could be compacted. Unlike TriggerCompaction it never truncates.
+func (a *Agent) CompactHistory() (CompactionResult, bool) {
+	result := CompactionResult{
+		BeforeMessages: len(a.messages),
+		BeforeTokens:   estimateMessageTokens(a.messages),
+	}
+
+	if a.HasTurnCheckpoints() {
+		checkpointed, remaining := a.BuildCheckpointCompactedMessages(a.messages)
+		if len(checkpointed) < len(a.messages) {
+			a.messages = checkpointed
+			a.ReplaceTurnCheckpoints(remaining)
+			result.Method = "checkpoint"
+		}
+	}
+
+	if result.Method == "" && a.optimizer != nil && a.optimizer.IsEnabled() {
+		compacted := a.optimizer.CompactConversation(a.messages)
+		if len(compacted) < len(a.messages) {
+			a.messages = compacted
+			a.clearTurnCheckpoints()
+			result.Method = "structural"
+		}
+	}
+
+	result.AfterMessages = len(a.messages)
+	result.AfterTokens = estimateMessageTokens(a.messages)
+	return result, result.Method != ""
+}
+
+// PinFact keeps fact in the system message so it survives compaction.
+func (a *Agent) PinFact(fact string) error {
+	fact = strings.TrimSpace(fact)
+	if fact == "" {
+		return fmt.Errorf("fact is empty")
+	}
+	a.pinsMu.Lock()
+	defer a.pinsMu.Unlock()
+	for _, existing := range a.pinnedFacts {
+		if existing == fact {
+			return nil
+		}
+	}
+	a.pinnedFacts = append(a.pinnedFacts, fact)
+	return nil
+}
+
+// PinFile marks path as a key file: it is listed in the system message and its
+// latest read_file result is retained when older turns are compacted.
+func (a *Agent) PinFile(path string) error {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return fmt.Errorf("file path is empty")
+	}
+	a.pinsMu.Lock()
+	for _, existing := range a.pinnedFiles {
+		if existing == path {
+			a.pinsMu.Unlock()
+			return nil
+		}
+	}
+	a.pinnedFiles = append(a.pinnedFiles, path)
+	files := append([]string(nil), a.pinnedFiles...)
+	a.pinsMu.Unlock()
+
+	if a.optimizer != nil {
+		a.optimizer.SetPinnedFiles(files)
+	}
+	return nil
+}
+
+// Unpin removes a pinned fact or file by its exact text.
+func (a *Agent) Unpin(item string) bool {
+	item = strings.TrimSpace(item)
+	a.pinsMu.Lock()
+	removed := false
+	a.pinnedFacts, removed = removeString(a.pinnedFacts, item)
+	var removedFile bool
+	a.pinnedFiles, removedFile = removeString(a.pinnedFiles, item)
+	files := append([]string(nil), a.pinnedFiles...)
+	a.pinsMu.Unlock()
+
+	if removedFile && a.optimizer != nil {
+		a.optimizer.SetPinnedFiles(files)
+	}
+	return removed || removedFile
+}
+
+// ClearPins removes all pinned facts and files.
+func (a *Agent) ClearPins() {
+	a.replacePins(nil, nil)
+}
+
+// GetPins returns copies of the pinned facts and files.
+func (a *Agent) GetPins() (facts []string, files []string) {
+	a.pinsMu.RLock()
+	defer a.pinsMu.RUnlock()
+	return append([]string(nil), a.pinnedFacts...), append([]string(nil), a.pinnedFiles...)
+}
+
+func (a *Agent) replacePins(facts, files []string) {
+	a.pinsMu.Lock()
+	a.pinnedFacts = append([]string(nil), facts...)
+	a.pinnedFiles = append([]string(nil), files...)
+	a.pinsMu.Unlock()
+
+	if a.optimizer != nil {
+		a.optimizer.SetPinnedFiles(files)
+	}
+}
+
+// pinnedContextSupplement renders the pins as a system prompt section, or ""
+// when nothing is pinned.
+func (a *Agent) pinnedContextSupplement() string {
+	facts, files := a.GetPins()
+	if len(facts) == 0 && len(files) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("## Pinned Context\n\nThe user pinned the following; treat it as current even if earlier turns were summarized.\n")
+	if len(facts) > 0 {
+		b.WriteString("\nKey facts:\n")
+		for _, fact := range facts {
+			b.WriteString("- ")
+			b.WriteString(fact)
+			b.WriteString("\n")
+		}
+	}
+	if len(files) > 0 {
+		b.WriteString("\nKey files (re-read them before editing if their contents are not in recent messages):\n")
+		for _, file := range files {
+			b.WriteString("- ")
+			b.WriteString(file)
+			b.WriteString("\n")
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func removeString(values []string, target string) ([]string, bool) {
+	for i, value := range values {
+		if value == target {
+			return append(values[:i:i], values[i+1:]...), true
+		}
+	}
+	return values, false
+}
@@ -0,0 +1,132 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	api "github.com/alantheprice/ledit/pkg/agent_api"
+)
+
+// longHistoryWithFileReads builds a conversation long enough for structural
+// compaction whose middle reads three files and whose recent messages mention
+// only pkg/foo.go.
+func longHistoryWithFileReads() []api.Message {
+	messages := []api.Message{
+		{Role: "user", Content: "Refactor the foo package"},
+		{Role: "assistant", Content: "I will read the relevant files first."},
+	}
+	for i, path := range []string{"pkg/foo.go", "pkg/bar.go", "pkg/baz.go"} {
+		id := "call-read-" + string(rune('a'+i))
+		messages = append(messages,
+			api.Message{Role: "assistant", ToolCalls: []api.ToolCall{{ID: id}}},
+			api.Message{Role: "tool", ToolCallId: id, Content: "Tool call result for read_file: " + path + "\npackage " + strings.TrimSuffix(strings.TrimPrefix(path, "pkg/"), ".go") + "\n\nfunc Example() {}\n"},
+			api.Message{Role: "assistant", Content: "Reviewed " + path + " and found nothing unusual."},
+		)
+	}
+	for i := 0; i < 6; i++ {
+		messages = append(messages, api.Message{Role: "assistant", Content: "Updated tests and verified the package builds cleanly."})
+	}
+	for i := 0; i < 12; i++ {
+		messages = append(messages, api.Message{Role: "assistant", Content: "Still working on the refactor step by step."})
+	}
+	messages[len(messages)-1].Content = "Next I will change Example in pkg/foo.go to return an error."
+	return messages
+}
+
+func TestCompactConversationRetainsReferencedFileReads(t *testing.T) {
+	optimizer := NewConversationOptimizer(true, false)
+	messages := longHistoryWithFileReads()
+
+	compacted := optimizer.CompactConversation(messages)
+	if len(compacted) >= len(messages) {
+		t.Fatalf("expected compaction, got %d -> %d messages", len(messages), len(compacted))
+	}
+	summary := compacted[2].Content
+	if !strings.Contains(summary, "Retained read of pkg/foo.go:\npackage foo") {
+		t.Fatalf("expected referenced read of pkg/foo.go to be retained, got:\n%s", summary)
+	}
+	if strings.Contains(summary, "Retained read of pkg/bar.go") {
+		t.Fatalf("did not expect unreferenced pkg/bar.go to be retained, got:\n%s", summary)
+	}
+
+	optimizer.SetPinnedFiles([]string{"pkg/bar.go"})
+	summary = optimizer.CompactConversation(messages)[2].Content
+	if !strings.Contains(summary, "Retained read of pkg/bar.go") {
+		t.Fatalf("expected pinned pkg/bar.go to be retained, got:\n%s", summary)
+	}
+	if strings.Contains(summary, "Retained read of pkg/baz.go") {
+		t.Fatalf("did not expect pkg/baz.go to be retained, got:\n%s", summary)
+	}
+}
+
+func TestCompactHistory(t *testing.T) {
+	agent := makeAgentWithScriptedClient(1, NewScriptedClient())
+	agent.optimizer = NewConversationOptimizer(true, false)
+
+	if _, ok := agent.CompactHistory(); ok {
+		t.Fatal("expected an empty history not to compact")
+	}
+
+	agent.messages = longHistoryWithFileReads()
+	result, ok := agent.CompactHistory()
+	if !ok {
+		t.Fatal("expected long history to compact")
+	}
+	if result.Method != "structural" {
+		t.Fatalf("expected structural compaction, got %q", result.Method)
+	}
+	if result.AfterMessages != len(agent.messages) || result.AfterMessages >= result.BeforeMessages {
+		t.Fatalf("unexpected result %+v for %d messages", result, len(agent.messages))
+	}
+	if result.BeforeTokens == 0 || result.AfterTokens == 0 {
+		t.Fatalf("expected token estimates, got %+v", result)
+	}
+}
+
+func TestPinsAreSentInSystemMessage(t *testing.T) {
+	client := NewScriptedClient(stopResponse())
+	agent := makeAgentWithScriptedClient(3, client)
+
+	if err := agent.PinFact("The API must stay backwards compatible"); err != nil {
+		t.Fatal(err)
+	}
+	if err := agent.PinFact("  "); err == nil {
+		t.Fatal("expected empty fact to be rejected")
+	}
+	if err := agent.PinFile("pkg/api/server.go"); err != nil {
+		t.Fatal(err)
+	}
+	if err := agent.PinFile("pkg/api/server.go"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := agent.ProcessQuery("Add an endpoint"); err != nil {
+		t.Fatalf("ProcessQuery: %v", err)
+	}
+	system := client.GetSentRequest(0)[0]
+	if system.Role != "system" {
+		t.Fatalf("expected a system message first, got %q", system.Role)
+	}
+	for _, want := range []string{"## Pinned Context", "- The API must stay backwards compatible", "- pkg/api/server.go"} {
+		if !strings.Contains(system.Content, want) {
+			t.Fatalf("expected system message to contain %q, got:\n%s", want, system.Content)
+		}
+	}
+	if strings.Count(system.Content, "pkg/api/server.go") != 1 {
+		t.Fatal("expected a repeated pin to be stored once")
+	}
+
+	if !agent.Unpin("pkg/api/server.go") || agent.Unpin("pkg/api/server.go") {
+		t.Fatal("expected Unpin to remove the file exactly once")
+	}
+	facts, files := agent.GetPins()
+	if len(facts) != 1 || len(files) != 0 {
+		t.Fatalf("unexpected pins after unpin: %v %v", facts, files)
+	}
+
+	agent.ApplyState(&ConversationState{PinnedFiles: []string{"go.mod"}})
+	facts, files = agent.GetPins()
+	if len(facts) != 0 || len(files) != 1 || files[0] != "go.mod" {
+		t.Fatalf("expected ApplyState to restore pins, got %v %v", facts, files)
+	}
+}
@@ -16,7 +16,8 @@ type ContextFileInfo struct {
 }
 
 // DiscoverContextFiles looks for context files in the current directory and parent directories
-// Returns the first matching file based on priority order
+// Returns the first matching file based on priority order. Instruction files
+// (LEDIT.md, AGENTS.md, CLAUDE.md) are found by DiscoverInstructionFiles; these are the fallback.
 func DiscoverContextFiles() (*ContextFileInfo, error) {
 	// Priority order for context files
 	contextFiles := []struct {
@@ -25,8 +26,6 @@ func DiscoverContextFiles() (*ContextFileInfo, error) {
 		priority     int
 		searchUpward bool // whether to search parent directories
 	}{
-		{"AGENTS.md", "Agent configuration and context", 1, true},
-		{"Claude.md", "Claude AI assistant context", 2, true},
 		{"cursor.md", "Cursor editor context", 3, true},
 		{"cursor-context.md", "Cursor editor context (alternative)", 4, true},
 		{"github-copilot.md", "GitHub Copilot context", 5, true},
@@ -96,8 +95,17 @@ func DiscoverContextFiles() (*ContextFileInfo, error) {
 	return nil, nil // No context files found
 }
 
-// LoadContextFiles loads and formats context files for inclusion in system prompt
+// LoadContextFiles loads and formats context files for inclusion in system prompt.
+// Instruction files are preferred; other context files are used only when there are none.
 func LoadContextFiles() (string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get current working directory: %w", err)
+	}
+	if instructions := DiscoverInstructionFiles(cwd); len(instructions) > 0 {
+		return FormatInstructionFiles(instructions), nil
+	}
+
 	contextFile, err := DiscoverContextFiles()
 	if err != nil {
 		return "", fmt.Errorf("failed to discover context files: %w", err)
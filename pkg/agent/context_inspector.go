@@ -0,0 +1,247 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	api "github.com/alantheprice/ledit/pkg/agent_api"
+)
+
+// contextPreviewChars is how much of a message InspectContext previews.
+const contextPreviewChars = 60
+
+// droppedToolResult replaces a tool result the user dropped on its own, so
+// the tool call that produced it still has a result.
+const droppedToolResult = "[Tool result removed from context by the user]"
+
+// ContextEntry describes one message in the conversation.
+type ContextEntry struct {
+	Index   int // position in the conversation
+	Role    string
+	Tokens  int
+	Age     int    // user turns since the message; 0 for the current turn
+	Pinned  bool   // kept across compaction as a pinned file or fact
+	File    string // file the message reads or writes, if any
+	Read    bool   // the message is a read_file result for File
+	Preview string
+}
+
+// FileContextUsage totals the tokens one file's contents take up in the
+// conversation.
+type FileContextUsage struct {
+	Path     string
+	Tokens   int
+	Messages int
+}
+
+// InspectContext lists the messages in the conversation and the files whose
+// contents take up the most of it, largest first.
+func (a *Agent) InspectContext() ([]ContextEntry, []FileContextUsage) {
+	facts, files := a.GetPins()
+	pinnedFacts := make(map[string]bool, len(facts))
+	for _, fact := range facts {
+		pinnedFacts[fact] = true
+	}
+	pinnedFiles := make(map[string]bool, len(files))
+	for _, file := range files {
+		pinnedFiles[file] = true
+	}
+
+	callFiles := make(map[string]string)
+	fileReads := make(map[string]bool)
+	for _, msg := range a.messages {
+		for _, tc := range msg.ToolCalls {
+			if path := toolCallFile(tc); path != "" {
+				callFiles[tc.ID] = path
+				fileReads[tc.ID] = tc.Function.Name == "read_file"
+			}
+		}
+	}
+
+	entries := make([]ContextEntry, len(a.messages))
+	usage := make(map[string]*FileContextUsage)
+	addUsage := func(path string, tokens int) {
+		if usage[path] == nil {
+			usage[path] = &FileContextUsage{Path: path}
+		}
+		usage[path].Tokens += tokens
+		usage[path].Messages++
+	}
+
+	turns := 0
+	for i := len(a.messages) - 1; i >= 0; i-- {
+		msg := a.messages[i]
+		entry := ContextEntry{
+			Index:   i,
+			Role:    msg.Role,
+			Tokens:  estimateMessageTokens([]api.Message{msg}),
+			Age:     turns,
+			Preview: contextPreview(msg),
+		}
+		for _, tc := range msg.ToolCalls {
+			argTokens := EstimateTokens(tc.Function.Arguments)
+			entry.Tokens += argTokens
+			if path := callFiles[tc.ID]; path != "" {
+				entry.File = path
+				addUsage(path, argTokens)
+			}
+		}
+		if msg.Role == "tool" {
+			if path := callFiles[msg.ToolCallId]; path != "" {
+				entry.File = path
+				entry.Read = fileReads[msg.ToolCallId]
+				// Compaction retains only the reads of a pinned file
+				entry.Pinned = entry.Read && pinnedFiles[path]
+				addUsage(path, entry.Tokens)
+			}
+		} else {
+			entry.Pinned = pinnedFacts[strings.TrimSpace(msg.Content)]
+		}
+		entries[i] = entry
+		if msg.Role == "user" {
+			turns++
+		}
+	}
+
+	byFile := make([]FileContextUsage, 0, len(usage))
+	for _, u := range usage {
+		byFile = append(byFile, *u)
+	}
+	sort.Slice(byFile, func(i, j int) bool {
+		if byFile[i].Tokens != byFile[j].Tokens {
+			return byFile[i].Tokens > byFile[j].Tokens
+		}
+		return byFile[i].Path < byFile[j].Path
+	})
+	return entries, byFile
+}
+
+// DropContextMessages removes the messages at indexes from the conversation.
+// Dropping a tool call also drops its results; a tool result dropped on its
+// own is replaced with a placeholder so its call stays answered. Pinned
+// messages must be unpinned first.
+func (a *Agent) DropContextMessages(indexes []int) (removed, cleared int, err error) {
+	entries, _ := a.InspectContext()
+	drop := make(map[int]bool, len(indexes))
+	droppedCalls := make(map[string]bool)
+	for _, index := range indexes {
+		if index < 0 || index >= len(entries) {
+			return 0, 0, fmt.Errorf("no message %d in the conversation", index+1)
+		}
+		drop[index] = true
+		for _, tc := range a.messages[index].ToolCalls {
+			droppedCalls[tc.ID] = true
+		}
+	}
+	for i, msg := range a.messages {
+		if entries[i].Pinned && (drop[i] || (msg.Role == "tool" && droppedCalls[msg.ToolCallId])) {
+			return 0, 0, fmt.Errorf("message %d is pinned; unpin it first", i+1)
+		}
+	}
+
+	messages := make([]api.Message, 0, len(a.messages))
+	for i, msg := range a.messages {
+		switch {
+		case msg.Role == "tool" && droppedCalls[msg.ToolCallId]:
+			removed++
+		case drop[i] && msg.Role == "tool":
+			msg.Content = droppedToolResult
+			msg.Images = nil
+			messages = append(messages, msg)
+			cleared++
+		case drop[i]:
+			removed++
+		default:
+			messages = append(messages, msg)
+		}
+	}
+	a.messages = messages
+	if removed > 0 {
+		// Checkpoints refer to message positions, which just shifted
+		a.clearTurnCheckpoints()
+	}
+	return removed, cleared, nil
+}
+
+// PinContextMessage keeps the message at index across compaction: a tool
+// result pins the file it read, any other message pins its text as a fact.
+// It returns a description of what was pinned.
+func (a *Agent) PinContextMessage(index int) (string, error) {
+	key, isFile, err := a.contextPinKey(index)
+	if err != nil {
+		return "", err
+	}
+	if isFile {
+		return "file " + key, a.PinFile(key)
+	}
+	return "message", a.PinFact(key)
+}
+
+// UnpinContextMessage removes the pin PinContextMessage added for index.
+func (a *Agent) UnpinContextMessage(index int) error {
+	key, _, err := a.contextPinKey(index)
+	if err != nil {
+		return err
+	}
+	if !a.Unpin(key) {
+		return fmt.Errorf("message %d is not pinned", index+1)
+	}
+	return nil
+}
+
+// contextPinKey returns the pin that covers the message at index.
+func (a *Agent) contextPinKey(index int) (key string, isFile bool, err error) {
+	entries, _ := a.InspectContext()
+	if index < 0 || index >= len(entries) {
+		return "", false, fmt.Errorf("no message %d in the conversation", index+1)
+	}
+	if entries[index].Role == "tool" {
+		if !entries[index].Read {
+			return "", false, fmt.Errorf("message %d is not a file read; only read_file results can be pinned", index+1)
+		}
+		return entries[index].File, true, nil
+	}
+	content := strings.TrimSpace(a.messages[index].Content)
+	if content == "" {
+		return "", false, fmt.Errorf("message %d has no text to pin", index+1)
+	}
+	return content, false, nil
+}
+
+// toolCallFile returns the file a tool call reads or writes.
+func toolCallFile(tc api.ToolCall) string {
+	var args map[string]interface{}
+	if err := json.Unmarshal([]byte(tc.Function.Arguments), &args); err != nil {
+		return ""
+	}
+	for _, key := range []string{"file_path", "path"} {
+		if path, ok := args[key].(string); ok && strings.TrimSpace(path) != "" {
+			return strings.TrimSpace(path)
+		}
+	}
+	return ""
+}
+
+// contextPreview returns the first line of a message, or the tools it calls.
+func contextPreview(msg api.Message) string {
+	preview := ""
+	for _, line := range strings.Split(msg.Content, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			preview = line
+			break
+		}
+	}
+	if preview == "" && len(msg.ToolCalls) > 0 {
+		names := make([]string, 0, len(msg.ToolCalls))
+		for _, tc := range msg.ToolCalls {
+			names = append(names, tc.Function.Name)
+		}
+		preview = "calls " + strings.Join(names, ", ")
+	}
+	if runes := []rune(preview); len(runes) > contextPreviewChars {
+		preview = string(runes[:contextPreviewChars-3]) + "..."
+	}
+	return preview
+}
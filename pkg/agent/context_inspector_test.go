@@ -0,0 +1,119 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	api "github.com/alantheprice/ledit/pkg/agent_api"
+)
+
+func contextToolCall(id, name, args string) api.ToolCall {
+	call := api.ToolCall{ID: id, Type: "function"}
+	call.Function.Name = name
+	call.Function.Arguments = args
+	return call
+}
+
+// contextHistory is two turns: the first reads a large and a small file,
+// the second edits the small one.
+func contextHistory() []api.Message {
+	return []api.Message{
+		{Role: "user", Content: "Look at the parser"},
+		{Role: "assistant", ToolCalls: []api.ToolCall{
+			contextToolCall("r1", "read_file", `{"file_path":"parser.go"}`),
+			contextToolCall("r2", "read_file", `{"file_path":"lexer.go"}`),
+		}},
+		{Role: "tool", ToolCallId: "r1", Content: "Tool call result for read_file: parser.go\n" + strings.Repeat("func parse() {}\n", 200)},
+		{Role: "tool", ToolCallId: "r2", Content: "Tool call result for read_file: lexer.go\npackage lexer\n"},
+		{Role: "assistant", Content: "The parser is large.\nDetails follow."},
+		{Role: "user", Content: "Fix the lexer"},
+		{Role: "assistant", ToolCalls: []api.ToolCall{
+			contextToolCall("e1", "edit_file", `{"file_path":"lexer.go","old_string":"a","new_string":"b"}`),
+		}},
+		{Role: "tool", ToolCallId: "e1", Content: "Edited lexer.go"},
+	}
+}
+
+func TestInspectContext(t *testing.T) {
+	a := &Agent{messages: contextHistory()}
+	if err := a.PinFile("lexer.go"); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, files := a.InspectContext()
+	if len(entries) != 8 {
+		t.Fatalf("expected 8 entries, got %d", len(entries))
+	}
+	if entries[0].Age != 1 || entries[5].Age != 0 || entries[7].Age != 0 {
+		t.Fatalf("unexpected ages %d, %d, %d", entries[0].Age, entries[5].Age, entries[7].Age)
+	}
+	if entries[1].Preview != "calls read_file, read_file" || entries[4].Preview != "The parser is large." {
+		t.Fatalf("unexpected previews %q, %q", entries[1].Preview, entries[4].Preview)
+	}
+	// Only reads of a pinned file are retained by compaction
+	if !entries[3].Pinned || entries[2].Pinned || entries[7].Pinned || entries[7].File != "lexer.go" {
+		t.Fatalf("unexpected pins %+v", entries)
+	}
+
+	if len(files) != 2 || files[0].Path != "parser.go" || files[1].Path != "lexer.go" || files[1].Messages != 4 {
+		t.Fatalf("unexpected file usage %+v", files)
+	}
+	// A file's share covers its reads and the arguments of calls on it
+	if want := entries[2].Tokens + EstimateTokens(`{"file_path":"parser.go"}`); files[0].Tokens != want {
+		t.Fatalf("expected parser.go to count its read and call, got %d want %d", files[0].Tokens, want)
+	}
+}
+
+func TestDropContextMessages(t *testing.T) {
+	a := &Agent{messages: contextHistory()}
+	a.turnCheckpoints = []TurnCheckpoint{{StartIndex: 0, EndIndex: 4, Summary: "read files"}}
+
+	// A tool result on its own keeps a placeholder; a tool call takes its result along
+	removed, cleared, err := a.DropContextMessages([]int{2, 6})
+	if err != nil {
+		t.Fatalf("DropContextMessages: %v", err)
+	}
+	if removed != 2 || cleared != 1 {
+		t.Fatalf("expected 2 removed and 1 cleared, got %d and %d", removed, cleared)
+	}
+	if len(a.messages) != 6 || a.messages[2].Content != droppedToolResult || a.messages[5].Content != "Fix the lexer" {
+		t.Fatalf("unexpected messages %+v", a.messages)
+	}
+	if len(a.turnCheckpoints) != 0 {
+		t.Fatal("expected turn checkpoints to be cleared")
+	}
+
+	if _, _, err := a.DropContextMessages([]int{6}); err == nil {
+		t.Fatal("expected an out of range message to be rejected")
+	}
+}
+
+func TestPinContextMessage(t *testing.T) {
+	a := &Agent{messages: contextHistory()}
+
+	if pinned, err := a.PinContextMessage(2); err != nil || pinned != "file parser.go" {
+		t.Fatalf("expected parser.go to be pinned, got %q, %v", pinned, err)
+	}
+	if _, err := a.PinContextMessage(7); err == nil {
+		t.Fatal("expected an edit result to be rejected")
+	}
+	if _, err := a.PinContextMessage(4); err != nil {
+		t.Fatalf("PinContextMessage: %v", err)
+	}
+	facts, files := a.GetPins()
+	if len(facts) != 1 || facts[0] != "The parser is large.\nDetails follow." || len(files) != 1 {
+		t.Fatalf("unexpected pins %v, %v", facts, files)
+	}
+
+	// Pinned messages and the calls that produced them can't be dropped
+	if _, _, err := a.DropContextMessages([]int{1}); err == nil || !strings.Contains(err.Error(), "message 3 is pinned") {
+		t.Fatalf("expected the pinned read to block the drop, got %v", err)
+	}
+
+	if err := a.UnpinContextMessage(4); err != nil {
+		t.Fatalf("UnpinContextMessage: %v", err)
+	}
+	if err := a.UnpinContextMessage(4); err == nil {
+		t.Fatal("expected unpinning twice to fail")
+	}
+}
@@ -13,12 +13,20 @@ import (
 	tools "github.com/alantheprice/ledit/pkg/agent_tools"
 	"github.com/alantheprice/ledit/pkg/configuration"
 	"github.com/alantheprice/ledit/pkg/console"
+	"github.com/alantheprice/ledit/pkg/hooks"
+	"github.com/alantheprice/ledit/pkg/tokenizer"
 )
 
 // ProcessQuery handles the main conversation loop with the LLM
 func (a *Agent) ProcessQuery(userQuery string) (string, error) {
+	stats := a.startRunStats()
+	a.runTaskHooks(hooks.TaskStart, userQuery, "", nil, stats.started)
+	tokenizer.SetModel(a.GetModel())
 	handler := NewConversationHandler(a)
-	return handler.ProcessQuery(userQuery)
+	response, err := handler.ProcessQuery(userQuery)
+	a.recordRunStats(stats, err)
+	a.runTaskHooks(taskEndEvent(a.GetLastRunTerminationReason(), err), userQuery, response, err, stats.started)
+	return response, err
 }
 
 // ProcessQueryWithContinuity processes a query with continuity from previous actions
@@ -59,13 +67,12 @@ func (a *Agent) ProcessQueryWithContinuity(userQuery string) (string, error) {
 // getOptimizedToolDefinitions returns tool definitions optimized based on conversation context
 func (a *Agent) getOptimizedToolDefinitions(messages []api.Message) []api.Tool {
 	// Start with standard tools
-	tools := api.GetToolDefinitions()
+	tools := a.describeLocalShell(api.GetToolDefinitions())
 
 	// Filter out run_subagent and run_parallel_subagents when:
-	// 1. Running as a subagent (prevents nested subagents)
+	// 1. Running at the subagent depth limit (prevents runaway nesting)
 	// 2. User explicitly disabled subagents via --no-subagents flag or LEDIT_NO_SUBAGENTS env
-	noSubagents := os.Getenv("LEDIT_SUBAGENT") == "1" || os.Getenv("LEDIT_NO_SUBAGENTS") == "1"
-	if noSubagents {
+	if !a.subagentsAllowed() {
 		filtered := make([]api.Tool, 0, len(tools))
 		for _, tool := range tools {
 			// Skip run_subagent and run_parallel_subagents
@@ -77,12 +84,20 @@ func (a *Agent) getOptimizedToolDefinitions(messages []api.Message) []api.Tool {
 		tools = filtered
 	}
 
+	// Tools that need the network are of no use offline
+	if a.Offline() {
+		tools = withoutNetworkTools(tools)
+	}
+
 	// Add MCP tools if available
 	mcpTools := a.getMCPTools()
 	if mcpTools != nil {
 		tools = append(tools, mcpTools...)
 	}
 
+	// Add tools registered by embedding programs
+	tools = append(tools, a.customToolDefinitions()...)
+
 	// For custom providers, apply tool filtering only when tool_calls is explicitly configured.
 	if customProvider, ok := a.getCurrentCustomProvider(); ok {
 		if len(customProvider.ToolCalls) > 0 {
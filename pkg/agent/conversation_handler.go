@@ -30,6 +30,8 @@ type ConversationHandler struct {
 	turnHistory                []TurnEvaluation
 	ocrEnforcementAttempts     int
 	tentativeRejectionCount    int
+	editsSinceValidation       int               // File modifications since the validation gate last ran
+	filesSinceValidation       []string          // Paths modified since the validation gate last ran
 	traceSession               interface{}       // Using interface{} to avoid circular import
 	currentTurnRecord          *trace.TurnRecord // Temporary storage for current turn, updated with response data later
 }
@@ -63,6 +65,12 @@ func (ch *ConversationHandler) ProcessQuery(userQuery string) (string, error) {
 		ch.agent.debugLog("DEBUG: ProcessQuery called with: %s\n", userQuery)
 	}
 	ch.agent.lastRunTerminationReason = ""
+	defer ch.agent.endTodoEscalation()
+	if ch.agent.runRecorder != nil {
+		ch.agent.runRecorder.recordQuery(userQuery)
+	}
+
+	ch.agent.warnModelCapabilityGaps()
 
 	// Publish query started event
 	ch.agent.publishEvent(events.EventTypeQueryStarted, events.QueryStartedEvent(userQuery, ch.agent.GetProvider(), ch.agent.GetModel()))
@@ -91,19 +99,24 @@ func (ch *ConversationHandler) ProcessQuery(userQuery string) (string, error) {
 			ch.agent.debugLog("DEBUG: Reset circuit breaker for new query\n")
 		}
 	}
+	ch.agent.resetToolDedup()
 
-	// Process images if present
-	images, processedQuery, err := ch.processImagesInQuery(userQuery)
+	// Process images if present, including any queued with /attach
+	images, processedQuery, err := ch.processImagesInQuery(ch.agent.takeImageAttachments(userQuery))
 	if err != nil {
 		ch.agent.publishEvent(events.EventTypeError, events.ErrorEvent("Image processing failed", err))
 		return "", fmt.Errorf("failed to process images in query: %w", err)
 	}
 
-	// Add user message with optional multimodal images
+	// Ask about an ambiguous request before spending tokens on a guess
+	processedQuery = ch.agent.clarifyRequest(processedQuery)
+
+	// Add user message with optional multimodal images, plus any relevant
+	// memories that did not fit in the system prompt
 	ch.queryStartIndex = len(ch.agent.messages)
 	userMessage := api.Message{
 		Role:    "user",
-		Content: ch.prepareUserInputForModel(processedQuery),
+		Content: ch.prepareUserInputForModel(processedQuery) + relevantMemoriesForQuery(ch.agent.currentWorkspaceRoot(), processedQuery),
 		Images:  images,
 	}
 	ch.agent.messages = append(ch.agent.messages, userMessage)
@@ -143,6 +156,9 @@ func (ch *ConversationHandler) ProcessQuery(userQuery string) (string, error) {
 			break
 		}
 
+		// Return to the regular model once an escalated todo is done
+		ch.agent.updateTodoEscalation()
+
 		// Track latest user message for this iteration
 		if userMsg, ok := ch.lastUserMessage(); ok {
 			ch.pendingUserMessage = userMsg
@@ -197,7 +213,10 @@ func (ch *ConversationHandler) ProcessQuery(userQuery string) (string, error) {
 		ch.lastActivityTime = time.Now()
 
 		// Process response
-		if shouldStop := ch.processResponse(response); shouldStop {
+		if shouldStop := ch.processResponse(response); shouldStop && ch.agent.hasPendingSteer() {
+			// A correction arrived while the model was finishing; let it revise
+			ch.agent.debugLog("[steer] Continuing to apply a correction\n")
+		} else if shouldStop {
 			ch.agent.debugLog("[OK] Conversation complete\n")
 			completed = true
 			ch.agent.lastRunTerminationReason = RunTerminationCompleted
@@ -233,6 +252,7 @@ func (ch *ConversationHandler) checkForInterrupt() bool {
 		})
 		return false // Continue processing with new input
 	default:
+		ch.applyPendingSteers()
 		return false
 	}
 }
@@ -266,7 +286,7 @@ func (ch *ConversationHandler) recordTurnStart(originalQuery, processedQuery str
 	ch.currentTurnRecord = &trace.TurnRecord{
 		RunID:              traceSession.GetRunID(),
 		TurnIndex:          ch.agent.currentIteration,
-		SystemPrompt:       ch.agent.systemPrompt,
+		SystemPrompt:       ch.agent.requestSystemPrompt(),
 		UserPrompt:         processedQuery,    // What model sees (after truncation)
 		UserPromptOriginal: originalQuery,     // What user typed (before truncation)
 		MessagesSent:       ch.agent.messages, // Messages array as sent to provider
@@ -467,11 +487,15 @@ func (ch *ConversationHandler) processResponse(resp *api.ChatResponse) bool {
 		// Add tool results immediately after the assistant message with tool calls
 		ch.agent.messages = append(ch.agent.messages, toolResults...)
 		ch.agent.debugLog("[ok] Added %d tool results to conversation\n", len(toolResults))
+		ch.agent.recordToolFailures(choice.Message.ToolCalls, toolResults)
 
 		// The model made concrete progress by executing tools, so reset
 		// the tentative rejection counter — prior rejections are now stale.
 		ch.tentativeRejectionCount = 0
 
+		// Build, lint and test once enough files have changed
+		ch.runValidationGate(choice.Message.ToolCalls, ch.toolExecutor.succeededCalls())
+
 		// Additional debugging for DeepSeek tool call format
 		if strings.EqualFold(ch.agent.GetProvider(), "deepseek") {
 			ch.agent.debugLog("[search] DeepSeek conversation flow check:\n")
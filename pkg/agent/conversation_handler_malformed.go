@@ -13,6 +13,7 @@ func (ch *ConversationHandler) handleMalformedToolCalls(content string, turn Tur
 	if ch.fallbackParser == nil {
 		ch.agent.debugLog("[WARN] Fallback parser is nil, cannot parse malformed tool calls\n")
 		turn.GuardrailTrigger = "fallback parser unavailable"
+		ch.agent.recordTodoFailure("malformed tool call")
 
 		// Update turn record without fallback usage
 		ch.updateTurnRecord(content, nil, append(parserErrors, "fallback parser unavailable"), false, "")
@@ -23,6 +24,7 @@ func (ch *ConversationHandler) handleMalformedToolCalls(content string, turn Tur
 	if fallbackResult == nil || len(fallbackResult.ToolCalls) == 0 {
 		ch.agent.debugLog("[WARN] Fallback parser could not extract valid tool calls\n")
 		turn.GuardrailTrigger = "fallback parser failed"
+		ch.agent.recordTodoFailure("malformed tool call")
 
 		// Update turn record without fallback success
 		ch.updateTurnRecord(content, nil, append(parserErrors, "fallback parser failed"), false, "")
@@ -48,6 +50,7 @@ func (ch *ConversationHandler) handleMalformedToolCalls(content string, turn Tur
 	toolResults := ch.toolExecutor.ExecuteTools(fallbackResult.ToolCalls)
 	ch.agent.messages = append(ch.agent.messages, toolResults...)
 	ch.agent.debugLog("[ok] Executed %d fallback-parsed tool calls\n", len(toolResults))
+	ch.agent.recordToolFailures(fallbackResult.ToolCalls, toolResults)
 
 	// The model made concrete progress by executing tools via fallback, so reset
 	// the tentative rejection counter — prior rejections are now stale.
@@ -25,6 +25,9 @@ func (ch *ConversationHandler) prepareMessages(tools []api.Tool) []api.Message {
 		return append(messages, pendingTransientMessages...)
 	}
 
+	// Drop optimizer records for files changed on disk since the last request
+	ch.agent.applyWorkspaceInvalidations()
+
 	// Use conversation optimizer if enabled
 	if ch.agent.optimizer != nil && ch.agent.optimizer.IsEnabled() {
 		optimizedMessages = ch.agent.optimizer.OptimizeConversation(ch.agent.messages)
@@ -55,10 +58,14 @@ func (ch *ConversationHandler) prepareMessages(tools []api.Tool) []api.Message {
 	optimizedMessages = ch.stripImagesForNonVisionModels(optimizedMessages)
 
 	// Build the system message, consuming any one-shot supplement (e.g. continuity context).
-	systemContent := ch.agent.systemPrompt
+	systemContent := ch.agent.requestSystemPrompt()
 	if supplement := ch.agent.consumePendingSystemSupplement(); supplement != "" {
 		systemContent = systemContent + "\n\n---\n\n" + supplement
 	}
+	// Pinned facts and files live in the system message so compaction never drops them.
+	if pinned := ch.agent.pinnedContextSupplement(); pinned != "" {
+		systemContent = systemContent + "\n\n---\n\n" + pinned
+	}
 
 	// Always include system prompt at the beginning
 	allMessages := []api.Message{{Role: "system", Content: systemContent}}
@@ -82,12 +89,12 @@ func (ch *ConversationHandler) prepareMessages(tools []api.Tool) []api.Message {
 				// Persist adjusted remaining checkpoints so indices stay valid against the compacted array.
 				ch.agent.ReplaceTurnCheckpoints(remainingCheckpoints)
 
-				checkpointHistory := []api.Message{{Role: "system", Content: ch.agent.systemPrompt}}
+				checkpointHistory := []api.Message{{Role: "system", Content: systemContent}}
 				checkpointHistory = append(checkpointHistory, checkpointedMessages...)
 				checkpointHistory = collapseSystemMessagesToFront(checkpointHistory)
 				optimizedMessages = checkpointedMessages
 
-				allMessages = []api.Message{{Role: "system", Content: ch.agent.systemPrompt}}
+				allMessages = []api.Message{{Role: "system", Content: systemContent}}
 				allMessages = append(allMessages, optimizedMessages...)
 				allMessages = appendPendingTransient(allMessages)
 				allMessages = collapseSystemMessagesToFront(allMessages)
@@ -105,7 +112,7 @@ func (ch *ConversationHandler) prepareMessages(tools []api.Tool) []api.Message {
 		if currentTokens > compactionThreshold && ch.agent.optimizer != nil && ch.agent.optimizer.IsEnabled() {
 			llmCompacted := ch.agent.optimizer.CompactConversation(optimizedMessages)
 			if len(llmCompacted) < len(optimizedMessages) {
-				llmHistory := []api.Message{{Role: "system", Content: ch.agent.systemPrompt}}
+				llmHistory := []api.Message{{Role: "system", Content: systemContent}}
 				llmHistory = append(llmHistory, llmCompacted...)
 				llmHistory = collapseSystemMessagesToFront(llmHistory)
 				llmTokens := ch.apiClient.estimateRequestTokens(llmHistory, tools)
@@ -115,7 +122,7 @@ func (ch *ConversationHandler) prepareMessages(tools []api.Tool) []api.Message {
 					ch.agent.clearTurnCheckpoints()
 					optimizedMessages = llmCompacted
 
-					allMessages = []api.Message{{Role: "system", Content: ch.agent.systemPrompt}}
+					allMessages = []api.Message{{Role: "system", Content: systemContent}}
 					allMessages = append(allMessages, optimizedMessages...)
 					allMessages = appendPendingTransient(allMessages)
 					allMessages = collapseSystemMessagesToFront(allMessages)
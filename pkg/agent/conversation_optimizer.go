@@ -43,6 +43,7 @@ type ConversationOptimizer struct {
 	client       api.ClientInterface // LLM client for generating summaries (nil = use Go fallback)
 	providerName string              // Provider name for summary logging
 	printLine    func(string)        // Console output callback (nil = silent)
+	pinnedFiles  map[string]bool     // File reads always retained by structural compaction
 }
 
 // NewConversationOptimizer creates a new conversation optimizer
@@ -121,6 +122,10 @@ func (co *ConversationOptimizer) CompactConversation(messages []api.Message) []a
 		return messages
 	}
 
+	if retained := co.retainedFileReads(middle, messages[recentStart:]); retained != "" {
+		summary += "\n\n" + retained
+	}
+
 	compacted := make([]api.Message, 0, anchorEnd+1+len(messages)-recentStart)
 	compacted = append(compacted, messages[:anchorEnd]...)
 	compacted = append(compacted, api.Message{
@@ -131,6 +136,64 @@ func (co *ConversationOptimizer) CompactConversation(messages []api.Message) []a
 	return compacted
 }
 
+// retainedFileReads carries the latest read_file results from the compacted
+// middle into the summary when recent messages still reference the file or the
+// user pinned it, so the model does not have to re-read them.
+func (co *ConversationOptimizer) retainedFileReads(middle, recent []api.Message) string {
+	limit := PruningConfig.Structural.MaxRetainedResults
+	if limit <= 0 {
+		return ""
+	}
+
+	var recentText strings.Builder
+	readRecently := make(map[string]bool)
+	for _, msg := range recent {
+		recentText.WriteString(msg.Content)
+		for _, tc := range msg.ToolCalls {
+			recentText.WriteString(tc.Function.Arguments)
+		}
+		if path := co.extractFilePath(msg.Content); msg.Role == "tool" && path != "" {
+			readRecently[path] = true
+		}
+	}
+	referenced := recentText.String()
+
+	seen := make(map[string]bool)
+	var retained []string
+	for i := len(middle) - 1; i >= 0 && len(retained) < limit; i-- {
+		msg := middle[i]
+		if msg.Role != "tool" {
+			continue
+		}
+		path := co.extractFilePath(msg.Content)
+		if path == "" || seen[path] || readRecently[path] {
+			continue
+		}
+		seen[path] = true
+		if !co.pinnedFiles[path] && !strings.Contains(referenced, path) {
+			continue
+		}
+		content := co.extractFileContent(msg.Content)
+		if maxChars := PruningConfig.Structural.MaxRetainedChars; maxChars > 0 && len(content) > maxChars {
+			content = content[:maxChars] + "\n[... truncated]"
+		}
+		retained = append(retained, fmt.Sprintf("Retained read of %s:\n%s", path, content))
+	}
+	if len(retained) == 0 {
+		return ""
+	}
+	return strings.Join(retained, "\n\n")
+}
+
+// SetPinnedFiles sets the file paths whose reads structural compaction retains.
+func (co *ConversationOptimizer) SetPinnedFiles(paths []string) {
+	pinned := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		pinned[path] = true
+	}
+	co.pinnedFiles = pinned
+}
+
 // isRedundantFileRead checks if this message is a redundant file read
 func (co *ConversationOptimizer) isRedundantFileRead(msg api.Message, index int) bool {
 	if msg.Role != "tool" {
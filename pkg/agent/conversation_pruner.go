@@ -42,6 +42,8 @@ var PruningConfig = struct {
 		MinMiddleMessages    int // Minimum middle-segment size before rewriting into a summary
 		MaxSummaryEntries    int // Maximum summary bullets retained in the compacted message
 		MaxEntryChars        int // Maximum chars per summarized entry
+		MaxRetainedResults   int // Maximum referenced/pinned file reads carried into the summary
+		MaxRetainedChars     int // Maximum chars per retained file read
 	}
 
 	// Target token percentages when pruning
@@ -67,12 +69,16 @@ var PruningConfig = struct {
 		MinMiddleMessages    int
 		MaxSummaryEntries    int
 		MaxEntryChars        int
+		MaxRetainedResults   int
+		MaxRetainedChars     int
 	}{
 		RecentMessagesToKeep: 12,
 		MinMessagesToCompact: 18,
 		MinMiddleMessages:    6,
 		MaxSummaryEntries:    10,
 		MaxEntryChars:        180,
+		MaxRetainedResults:   3,
+		MaxRetainedChars:     4000,
 	},
 
 	// Target percentages for pruning
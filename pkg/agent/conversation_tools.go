@@ -2,7 +2,6 @@ package agent
 
 import (
 	"fmt"
-	"os"
 	"strings"
 
 	api "github.com/alantheprice/ledit/pkg/agent_api"
@@ -14,6 +13,13 @@ func (ch *ConversationHandler) sendMessage() (*api.ChatResponse, error) {
 	messages := ch.prepareMessages(tools)
 	reasoning := ch.determineReasoningEffort()
 
+	// Models without native tool calling get the tools in the prompt instead
+	// and reply in text, which the fallback parser turns into tool calls.
+	if len(tools) > 0 && !ch.agent.GetModelCapabilities().NativeTools {
+		messages = withTextToolInstructions(messages, tools)
+		tools = nil
+	}
+
 	return ch.apiClient.SendWithRetry(messages, tools, reasoning)
 }
 
@@ -30,8 +36,7 @@ func (ch *ConversationHandler) prepareTools() []api.Tool {
 	}
 
 	fallback := api.GetToolDefinitions()
-	noSubagents := os.Getenv("LEDIT_SUBAGENT") == "1" || os.Getenv("LEDIT_NO_SUBAGENTS") == "1"
-	if noSubagents {
+	if !ch.agent.subagentsAllowed() {
 		filtered := make([]api.Tool, 0, len(fallback))
 		for _, tool := range fallback {
 			if tool.Function.Name == "run_subagent" || tool.Function.Name == "run_parallel_subagents" {
@@ -0,0 +1,159 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// subagentCostTreePrefix starts the line a subagent prints with the cost
+// tree of the subagents it ran, next to its SUBAGENT_METRICS line.
+const subagentCostTreePrefix = "SUBAGENT_COST_TREE:"
+
+// CostNode is one agent in the cost tree: the primary agent at the root and
+// every subagent run below the agent that started it. Tokens and cost
+// include the node's descendants, as each agent adds its subagents' usage to
+// its own totals.
+type CostNode struct {
+	Name             string     `json:"name"`
+	PromptTokens     int        `json:"prompt_tokens"`
+	CompletionTokens int        `json:"completion_tokens"`
+	Cost             float64    `json:"cost_usd"`
+	Children         []CostNode `json:"children,omitempty"`
+}
+
+// CostLevel is the usage of the agents at one depth of the cost tree, not
+// counting what their subagents used.
+type CostLevel struct {
+	Depth            int
+	Agents           int
+	PromptTokens     int
+	CompletionTokens int
+	Cost             float64
+}
+
+// TotalTokens returns the node's prompt and completion tokens.
+func (n CostNode) TotalTokens() int {
+	return n.PromptTokens + n.CompletionTokens
+}
+
+// Own returns the usage of the node's agent itself: its totals minus its
+// children's.
+func (n CostNode) Own() (promptTokens, completionTokens int, cost float64) {
+	promptTokens, completionTokens, cost = n.PromptTokens, n.CompletionTokens, n.Cost
+	for _, child := range n.Children {
+		promptTokens -= child.PromptTokens
+		completionTokens -= child.CompletionTokens
+		cost -= child.Cost
+	}
+	return max(promptTokens, 0), max(completionTokens, 0), max(cost, 0)
+}
+
+// Levels returns the own usage of the tree's agents per depth, the root
+// being depth 0.
+func (n CostNode) Levels() []CostLevel {
+	var levels []CostLevel
+	var walk func(node CostNode, depth int)
+	walk = func(node CostNode, depth int) {
+		if depth == len(levels) {
+			levels = append(levels, CostLevel{Depth: depth})
+		}
+		prompt, completion, cost := node.Own()
+		levels[depth].Agents++
+		levels[depth].PromptTokens += prompt
+		levels[depth].CompletionTokens += completion
+		levels[depth].Cost += cost
+		for _, child := range node.Children {
+			walk(child, depth+1)
+		}
+	}
+	walk(n, 0)
+	return levels
+}
+
+// CostTree returns the session's usage as a tree of the subagents this
+// agent ran, with this agent at the root.
+func (a *Agent) CostTree() CostNode {
+	root := CostNode{
+		Name:             "primary agent",
+		PromptTokens:     a.promptTokens,
+		CompletionTokens: a.completionTokens,
+		Cost:             a.totalCost,
+	}
+	if depth, _ := a.subagentDepth(); depth > 0 {
+		root.Name = fmt.Sprintf("subagent (depth %d)", depth)
+	}
+	ledger := a.usageLedgerState()
+	ledger.mu.Lock()
+	root.Children = append([]CostNode(nil), ledger.subagentRuns...)
+	ledger.mu.Unlock()
+	return root
+}
+
+// CostTreeReport renders the cost tree and its per-depth totals, or returns
+// "" when no subagent ran.
+func (a *Agent) CostTreeReport() string {
+	tree := a.CostTree()
+	if len(tree.Children) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	var writeNode func(node CostNode, prefix, branch, indent string)
+	writeNode = func(node CostNode, prefix, branch, indent string) {
+		fmt.Fprintf(&sb, "%s%s%s: %s tokens, $%.6f", prefix, branch, node.Name, a.formatTokenCount(node.TotalTokens()), node.Cost)
+		if len(node.Children) > 0 {
+			prompt, completion, cost := node.Own()
+			fmt.Fprintf(&sb, " (own %s tokens, $%.6f)", a.formatTokenCount(prompt+completion), cost)
+		}
+		sb.WriteString("\n")
+		for i, child := range node.Children {
+			if i == len(node.Children)-1 {
+				writeNode(child, prefix+indent, "└─ ", "   ")
+			} else {
+				writeNode(child, prefix+indent, "├─ ", "│  ")
+			}
+		}
+	}
+	writeNode(tree, "", "", "")
+
+	sb.WriteString("\nBy depth (own usage):\n")
+	for _, level := range tree.Levels() {
+		noun := "agents"
+		if level.Agents == 1 {
+			noun = "agent"
+		}
+		fmt.Fprintf(&sb, "  depth %d: %d %s, %s tokens (%d prompt + %d completion), $%.6f\n", level.Depth, level.Agents, noun,
+			a.formatTokenCount(level.PromptTokens+level.CompletionTokens), level.PromptTokens, level.CompletionTokens, level.Cost)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// subagentCostTreeLine returns the line a subagent prints for its parent
+// with the subagents it ran, or "" when it ran none.
+func (a *Agent) subagentCostTreeLine() string {
+	tree := a.CostTree()
+	if len(tree.Children) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(tree.Children)
+	if err != nil {
+		return ""
+	}
+	return subagentCostTreePrefix + " " + string(data)
+}
+
+// takeSubagentCostTree removes the cost tree a subagent reported from its
+// extracted summary and returns it.
+func takeSubagentCostTree(summary map[string]string) []CostNode {
+	raw, ok := summary["subagent_cost_tree"]
+	if !ok {
+		return nil
+	}
+	delete(summary, "subagent_cost_tree")
+	var children []CostNode
+	if err := json.Unmarshal([]byte(raw), &children); err != nil {
+		return nil
+	}
+	return children
+}
@@ -0,0 +1,89 @@
+package agent
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestCostTreeAggregatesNestedSubagents(t *testing.T) {
+	t.Setenv("LEDIT_SUBAGENT_DEPTH", "")
+	t.Setenv("LEDIT_SUBAGENT", "")
+
+	// The coder subagent ran a tester of its own and reported it.
+	child := &Agent{promptTokens: 800, completionTokens: 200, totalCost: 0.01}
+	child.recordSubagentUsage("tester", 300, 100, 0.004, nil)
+	line := child.subagentCostTreeLine()
+	if !strings.HasPrefix(line, subagentCostTreePrefix) {
+		t.Fatalf("expected a cost tree line, got %q", line)
+	}
+
+	summary := extractSubagentSummary("Modified: a.go\nSUBAGENT_METRICS: total_tokens=1000 prompt_tokens=800 completion_tokens=200 total_cost=0.010000\n" + line + "\n")
+	children := takeSubagentCostTree(summary)
+	if _, ok := summary["subagent_cost_tree"]; ok {
+		t.Fatal("expected the cost tree to be removed from the summary")
+	}
+	if len(children) != 1 || children[0].Name != "tester" {
+		t.Fatalf("unexpected reported subagents: %+v", children)
+	}
+
+	parent := &Agent{promptTokens: 1500, completionTokens: 300, totalCost: 0.02}
+	parent.recordSubagentUsage("coder", 800, 200, 0.01, children)
+	parent.recordSubagentUsage("reviewer", 100, 50, 0.001, nil)
+
+	tree := parent.CostTree()
+	if tree.Name != "primary agent" || len(tree.Children) != 2 || len(tree.Children[0].Children) != 1 {
+		t.Fatalf("unexpected tree: %+v", tree)
+	}
+	levels := tree.Levels()
+	if len(levels) != 3 {
+		t.Fatalf("expected three levels, got %+v", levels)
+	}
+	want := []CostLevel{
+		{Depth: 0, Agents: 1, PromptTokens: 600, CompletionTokens: 50, Cost: 0.009},
+		{Depth: 1, Agents: 2, PromptTokens: 600, CompletionTokens: 150, Cost: 0.007},
+		{Depth: 2, Agents: 1, PromptTokens: 300, CompletionTokens: 100, Cost: 0.004},
+	}
+	for i, level := range levels {
+		if level.Depth != want[i].Depth || level.Agents != want[i].Agents || level.PromptTokens != want[i].PromptTokens ||
+			level.CompletionTokens != want[i].CompletionTokens || math.Abs(level.Cost-want[i].Cost) > 1e-9 {
+			t.Errorf("level %d: got %+v, want %+v", i, level, want[i])
+		}
+	}
+
+	report := parent.CostTreeReport()
+	for _, want := range []string{"primary agent: 1.8K tokens", "├─ coder: 1.0K tokens", "│  └─ tester: 400 tokens", "└─ reviewer: 150 tokens", "depth 1: 2 agents"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("expected %q in the report:\n%s", want, report)
+		}
+	}
+	if (&Agent{}).CostTreeReport() != "" {
+		t.Error("expected no report without subagents")
+	}
+}
+
+func TestSubagentDepthLimit(t *testing.T) {
+	t.Setenv("LEDIT_NO_SUBAGENTS", "")
+	t.Setenv("LEDIT_SUBAGENT_MAX_DEPTH", "")
+	t.Setenv("LEDIT_SUBAGENT", "1")
+	t.Setenv("LEDIT_SUBAGENT_DEPTH", "1")
+
+	registry := newDefaultToolRegistry()
+	_, _, err := registry.ExecuteTool(context.Background(), "run_subagent", map[string]interface{}{"prompt": "x"}, nil)
+	if err == nil || !strings.Contains(err.Error(), "depth 1") {
+		t.Fatalf("expected subagents at the default limit to be refused, got %v", err)
+	}
+	if (&Agent{}).subagentsAllowed() {
+		t.Fatal("expected the subagent tools to be withheld at the depth limit")
+	}
+
+	t.Setenv("LEDIT_SUBAGENT_MAX_DEPTH", "2")
+	if !(&Agent{}).subagentsAllowed() {
+		t.Fatal("expected a depth 1 subagent to spawn subagents when two levels are allowed")
+	}
+	t.Setenv("LEDIT_SUBAGENT_DEPTH", "2")
+	if err := (&Agent{}).subagentDepthError(); err == nil || !strings.Contains(err.Error(), "at most 2") {
+		t.Fatalf("expected depth 2 to be at the limit, got %v", err)
+	}
+}
@@ -0,0 +1,119 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/alantheprice/ledit/pkg/coverage"
+	"github.com/alantheprice/ledit/pkg/filesystem"
+)
+
+// coverageGapLimit caps the functions listed by test_coverage.
+const coverageGapLimit = 25
+
+// handleTestCoverage reports the stored per-function coverage of a file or
+// directory, measuring it first when asked to or when nothing is stored.
+func handleTestCoverage(ctx context.Context, a *Agent, args map[string]interface{}) (string, error) {
+	root := filesystem.WorkspaceRootFromContext(ctx)
+	if root == "" {
+		root = a.currentWorkspaceRoot()
+	}
+	scope := "."
+	if path := getOptionalString(args, "path"); path != "" {
+		absPath, err := filesystem.SafeResolvePathWithBypass(ctx, path)
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve path: %w", err)
+		}
+		rel, err := filepath.Rel(root, absPath)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return "", fmt.Errorf("path is outside the workspace: %s", path)
+		}
+		scope = filepath.ToSlash(rel)
+	}
+	refresh, _ := args["refresh"].(bool)
+
+	report, err := coverage.Load(root)
+	if err != nil {
+		return "", err
+	}
+	var testsFailed bool
+	if refresh || report == nil || len(report.Scope(scope)) == 0 {
+		measured, output, err := coverage.Measure(ctx, root, []string{coveragePackages(root, scope)})
+		if err != nil {
+			return "", fmt.Errorf("failed to measure coverage: %w", err)
+		}
+		testsFailed = strings.Contains(output, "FAIL")
+		if report == nil {
+			report = measured
+		} else {
+			report.Merge(measured)
+		}
+		if err := coverage.Save(root, report); err != nil {
+			return "", err
+		}
+		a.AddTaskAction("command_executed", fmt.Sprintf("Executed: %s", measured.Command), measured.Command)
+	}
+
+	result := formatCoverage(root, scope, report)
+	if testsFailed {
+		result += "\nSome tests failed; coverage only reflects the tests that passed or ran.\n"
+	}
+	return result, nil
+}
+
+// coveragePackages is the go test package pattern measuring scope: the
+// file's package, or every package under a directory.
+func coveragePackages(root, scope string) string {
+	if scope == "." {
+		return "./..."
+	}
+	if info, err := os.Stat(filepath.Join(root, filepath.FromSlash(scope))); err == nil && !info.IsDir() {
+		return "./" + filepath.ToSlash(filepath.Dir(scope))
+	}
+	return "./" + scope + "/..."
+}
+
+// formatCoverage summarizes the scope's coverage and lists its least covered
+// functions. Files changed since they were measured are flagged as stale.
+func formatCoverage(root, scope string, report *coverage.Report) string {
+	files := report.Scope(scope)
+	if len(files) == 0 {
+		return fmt.Sprintf("No coverage data for %s; it may have no Go tests.\n", scope)
+	}
+	statements, covered := coverage.Totals(files)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Coverage of %s: %s (%d of %d statements)\n", scope, coverage.FormatPercent(covered, statements), covered, statements)
+	fmt.Fprintf(&sb, "Last measured %s by `%s`\n", report.UpdatedAt.Format(time.RFC3339), report.Command)
+
+	var stale []string
+	for _, f := range files {
+		if info, err := os.Stat(filepath.Join(root, filepath.FromSlash(f.Path))); err == nil && info.ModTime().After(f.MeasuredAt) {
+			stale = append(stale, f.Path)
+		}
+	}
+	if len(stale) > 0 {
+		fmt.Fprintf(&sb, "Changed since measured (use refresh=true): %s\n", strings.Join(stale, ", "))
+	}
+
+	gaps := coverage.Gaps(files)
+	if len(gaps) == 0 {
+		sb.WriteString("\nEvery function is fully covered.\n")
+		return sb.String()
+	}
+	fmt.Fprintf(&sb, "\nFunctions with uncovered statements (%d):\n", len(gaps))
+	for i, gap := range gaps {
+		if i == coverageGapLimit {
+			fmt.Fprintf(&sb, "  ... and %d more\n", len(gaps)-coverageGapLimit)
+			break
+		}
+		fn := gap.Function
+		fmt.Fprintf(&sb, "  %s:%d %s %s (%d of %d statements uncovered)\n",
+			gap.File, fn.StartLine, fn.Name, coverage.FormatPercent(fn.Covered, fn.Statements), gap.Uncovered(), fn.Statements)
+	}
+	return sb.String()
+}
@@ -0,0 +1,104 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	api "github.com/alantheprice/ledit/pkg/agent_api"
+	"github.com/alantheprice/ledit/pkg/filesystem"
+)
+
+// customTool is a tool registered on one agent instance by an embedding
+// program, alongside the built-in tools.
+type customTool struct {
+	definition api.Tool
+	handler    ToolHandler
+}
+
+// RegisterCustomTool adds a tool to this agent only. The definition is sent to
+// the model with the built-in tools, and handler runs when the model calls it.
+// Names may not shadow built-in or MCP tools.
+func (a *Agent) RegisterCustomTool(definition api.Tool, handler ToolHandler) error {
+	name := strings.TrimSpace(definition.Function.Name)
+	if name == "" {
+		return fmt.Errorf("custom tool name is required")
+	}
+	if handler == nil {
+		return fmt.Errorf("custom tool %q has no handler", name)
+	}
+	if strings.HasPrefix(name, "mcp_") {
+		return fmt.Errorf("custom tool %q uses the reserved mcp_ prefix", name)
+	}
+	for _, builtin := range GetToolRegistry().GetAvailableTools() {
+		if builtin == name {
+			return fmt.Errorf("custom tool %q conflicts with a built-in tool", name)
+		}
+	}
+
+	if definition.Type == "" {
+		definition.Type = "function"
+	}
+	definition.Function.Name = name
+
+	a.customToolsMu.Lock()
+	defer a.customToolsMu.Unlock()
+	if a.customTools == nil {
+		a.customTools = make(map[string]customTool)
+	}
+	a.customTools[name] = customTool{definition: definition, handler: handler}
+	return nil
+}
+
+// UnregisterCustomTool removes a tool added with RegisterCustomTool.
+func (a *Agent) UnregisterCustomTool(name string) {
+	a.customToolsMu.Lock()
+	defer a.customToolsMu.Unlock()
+	delete(a.customTools, name)
+}
+
+// customToolDefinitions returns the custom tool definitions sorted by name so
+// the prompt is stable across runs.
+func (a *Agent) customToolDefinitions() []api.Tool {
+	a.customToolsMu.RLock()
+	defer a.customToolsMu.RUnlock()
+	if len(a.customTools) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(a.customTools))
+	for name := range a.customTools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	defs := make([]api.Tool, 0, len(names))
+	for _, name := range names {
+		defs = append(defs, a.customTools[name].definition)
+	}
+	return defs
+}
+
+// customToolHandler returns the handler for a custom tool name. In dry-run
+// mode the handler only describes the call, since ledit cannot tell whether
+// a custom tool changes anything.
+func (a *Agent) customToolHandler(name string) (ToolHandler, bool) {
+	a.customToolsMu.RLock()
+	defer a.customToolsMu.RUnlock()
+	tool, ok := a.customTools[name]
+	if !ok {
+		return nil, false
+	}
+	if a.DryRun() {
+		return func(_ context.Context, a *Agent, args map[string]interface{}) (string, error) {
+			return a.previewExternalTool(name, args), nil
+		}, true
+	}
+	return func(ctx context.Context, a *Agent, args map[string]interface{}) (string, error) {
+		// Files the tool writes are this process's own changes, not edits
+		// made outside ledit, so later writes must not conflict with them
+		locks := filesystem.Locks()
+		snapshot := locks.Snapshot()
+		defer locks.Adopt(snapshot)
+		return tool.handler(ctx, a, args)
+	}, true
+}
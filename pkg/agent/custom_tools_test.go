@@ -0,0 +1,68 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	api "github.com/alantheprice/ledit/pkg/agent_api"
+)
+
+func customToolDefinition(name string) api.Tool {
+	var tool api.Tool
+	tool.Function.Name = name
+	tool.Function.Description = "custom tool"
+	tool.Function.Parameters = map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+	return tool
+}
+
+func TestRegisterCustomToolValidation(t *testing.T) {
+	a := makeAgentWithScriptedClient(1, NewScriptedClient())
+	handler := func(ctx context.Context, a *Agent, args map[string]interface{}) (string, error) { return "ok", nil }
+
+	if err := a.RegisterCustomTool(customToolDefinition(""), handler); err == nil {
+		t.Fatal("expected empty name to be rejected")
+	}
+	if err := a.RegisterCustomTool(customToolDefinition("shell_command"), handler); err == nil {
+		t.Fatal("expected built-in name to be rejected")
+	}
+	if err := a.RegisterCustomTool(customToolDefinition("mcp_thing"), handler); err == nil {
+		t.Fatal("expected mcp_ prefix to be rejected")
+	}
+	if err := a.RegisterCustomTool(customToolDefinition("deploy"), nil); err == nil {
+		t.Fatal("expected nil handler to be rejected")
+	}
+	if err := a.RegisterCustomTool(customToolDefinition("deploy"), handler); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	defs := a.customToolDefinitions()
+	if len(defs) != 1 || defs[0].Function.Name != "deploy" || defs[0].Type != "function" {
+		t.Fatalf("unexpected definitions: %+v", defs)
+	}
+
+	a.UnregisterCustomTool("deploy")
+	if _, ok := a.customToolHandler("deploy"); ok {
+		t.Fatal("expected deploy to be unregistered")
+	}
+}
+
+func TestCustomToolsIncludedInToolDefinitions(t *testing.T) {
+	a := makeAgentWithScriptedClient(1, NewScriptedClient())
+	handler := func(ctx context.Context, a *Agent, args map[string]interface{}) (string, error) { return "ok", nil }
+	if err := a.RegisterCustomTool(customToolDefinition("zeta_tool"), handler); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.RegisterCustomTool(customToolDefinition("alpha_tool"), handler); err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, tool := range a.getOptimizedToolDefinitions(nil) {
+		if tool.Function.Name == "alpha_tool" || tool.Function.Name == "zeta_tool" {
+			names = append(names, tool.Function.Name)
+		}
+	}
+	if len(names) != 2 || names[0] != "alpha_tool" || names[1] != "zeta_tool" {
+		t.Fatalf("expected custom tools in sorted order, got %v", names)
+	}
+}
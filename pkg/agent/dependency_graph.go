@@ -0,0 +1,272 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/alantheprice/ledit/pkg/filesystem"
+)
+
+// Dependency graph limits. Files past them are left out of the graph, so
+// impact results for very large repositories are a lower bound.
+const (
+	dependencyGraphMaxFiles     = 20000
+	dependencyGraphMaxFileBytes = 1 << 20
+	impactListLimit             = 100 // files listed per section by impact_of_change
+)
+
+// dependencyGraphSkippedDirs are never scanned for source files.
+var dependencyGraphSkippedDirs = map[string]bool{
+	"node_modules": true, "vendor": true, "dist": true, "build": true, "target": true,
+	"__pycache__": true, "venv": true,
+}
+
+// DependencyGraph is a workspace's intra-repo import graph. Go imports link a
+// file to every file of the imported package; JS/TS, Python, C and Rust
+// imports link it to the file they resolve to.
+type DependencyGraph struct {
+	root       string
+	imports    map[string][]string // file -> workspace files it imports
+	importedBy map[string][]string // file -> workspace files importing it
+}
+
+// BuildDependencyGraph scans root's source files and resolves their local
+// imports.
+func BuildDependencyGraph(ctx context.Context, root string) (*DependencyGraph, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve workspace root: %w", err)
+	}
+	// Tool paths are resolved through symlinks, so the root must be too.
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+	g := &DependencyGraph{
+		root:       root,
+		imports:    make(map[string][]string),
+		importedBy: make(map[string][]string),
+	}
+
+	scanned := 0
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != root && (strings.HasPrefix(name, ".") || dependencyGraphSkippedDirs[name]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !hasImportResolver(path) {
+			return nil
+		}
+		if scanned >= dependencyGraphMaxFiles {
+			return filepath.SkipAll
+		}
+		info, err := d.Info()
+		if err != nil || info.Size() > dependencyGraphMaxFileBytes {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		scanned++
+		imports := resolveLocalImports(path, string(content), root, 0)
+		g.imports[path] = imports
+		for _, imported := range imports {
+			g.importedBy[imported] = append(g.importedBy[imported], path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// hasImportResolver reports whether resolveLocalImports understands path's
+// language.
+func hasImportResolver(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".go", ".ts", ".tsx", ".js", ".jsx", ".mjs", ".cjs", ".vue", ".svelte", ".py",
+		".c", ".h", ".cc", ".cpp", ".cxx", ".hpp", ".hh", ".m", ".mm", ".rs":
+		return true
+	}
+	return false
+}
+
+// ImpactedFile is a file that depends on a changed file, Depth imports away.
+type ImpactedFile struct {
+	Path  string
+	Depth int
+}
+
+// ChangeImpact lists what editing File is likely to affect. Paths are
+// relative to the workspace root.
+type ChangeImpact struct {
+	File       string
+	Dependents []ImpactedFile
+	// Tests are the test files among the dependents, plus the Go tests of
+	// every affected package.
+	Tests []string
+	// GoPackages are the affected Go packages as ./relative/dir patterns.
+	GoPackages []string
+}
+
+// Impact returns the files that import absPath directly or transitively and
+// the tests likely to exercise it.
+func (g *DependencyGraph) Impact(absPath string) ChangeImpact {
+	impact := ChangeImpact{File: g.rel(absPath)}
+	depth := map[string]int{absPath: 0}
+	queue := []string{absPath}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, dependent := range g.importedBy[current] {
+			if _, seen := depth[dependent]; seen {
+				continue
+			}
+			depth[dependent] = depth[current] + 1
+			queue = append(queue, dependent)
+		}
+	}
+
+	tests := map[string]bool{}
+	goDirs := map[string]bool{}
+	for path, d := range depth {
+		if d > 0 {
+			impact.Dependents = append(impact.Dependents, ImpactedFile{Path: g.rel(path), Depth: d})
+		}
+		if isTestFile(path) {
+			tests[path] = true
+		}
+		if strings.HasSuffix(path, ".go") {
+			goDirs[filepath.Dir(path)] = true
+		}
+	}
+	for dir := range goDirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() && strings.HasSuffix(entry.Name(), "_test.go") {
+				tests[filepath.Join(dir, entry.Name())] = true
+			}
+		}
+		pkg := "."
+		if rel := g.rel(dir); rel != "." {
+			pkg = "./" + filepath.ToSlash(rel)
+		}
+		impact.GoPackages = append(impact.GoPackages, pkg)
+	}
+	for path := range tests {
+		impact.Tests = append(impact.Tests, g.rel(path))
+	}
+
+	sort.Slice(impact.Dependents, func(i, j int) bool {
+		a, b := impact.Dependents[i], impact.Dependents[j]
+		if a.Depth != b.Depth {
+			return a.Depth < b.Depth
+		}
+		return a.Path < b.Path
+	})
+	sort.Strings(impact.Tests)
+	sort.Strings(impact.GoPackages)
+	return impact
+}
+
+func (g *DependencyGraph) rel(path string) string {
+	if rel, err := filepath.Rel(g.root, path); err == nil {
+		return rel
+	}
+	return path
+}
+
+// isTestFile recognizes test files by the usual naming conventions.
+func isTestFile(path string) bool {
+	base := strings.ToLower(filepath.Base(path))
+	stem := strings.TrimSuffix(base, filepath.Ext(base))
+	switch {
+	case strings.HasSuffix(base, "_test.go"),
+		strings.HasSuffix(stem, ".test"), strings.HasSuffix(stem, ".spec"),
+		strings.HasSuffix(base, ".py") && (strings.HasPrefix(base, "test_") || strings.HasSuffix(stem, "_test")):
+		return true
+	}
+	for _, part := range strings.Split(filepath.ToSlash(filepath.Dir(path)), "/") {
+		if part == "__tests__" {
+			return true
+		}
+	}
+	return false
+}
+
+// String formats the impact for the model.
+func (c ChangeImpact) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Impact of changing %s:\n", c.File)
+	if len(c.Dependents) == 0 {
+		b.WriteString("\nNo workspace files import it.\n")
+	} else {
+		fmt.Fprintf(&b, "\nDependents (%d):\n", len(c.Dependents))
+		for i, dependent := range c.Dependents {
+			if i == impactListLimit {
+				fmt.Fprintf(&b, "  ... and %d more\n", len(c.Dependents)-impactListLimit)
+				break
+			}
+			kind := "direct"
+			if dependent.Depth > 1 {
+				kind = fmt.Sprintf("via %d imports", dependent.Depth)
+			}
+			fmt.Fprintf(&b, "  %s (%s)\n", dependent.Path, kind)
+		}
+	}
+	if len(c.Tests) > 0 {
+		fmt.Fprintf(&b, "\nTests (%d):\n", len(c.Tests))
+		for i, test := range c.Tests {
+			if i == impactListLimit {
+				fmt.Fprintf(&b, "  ... and %d more\n", len(c.Tests)-impactListLimit)
+				break
+			}
+			fmt.Fprintf(&b, "  %s\n", test)
+		}
+	}
+	if len(c.GoPackages) > 0 {
+		fmt.Fprintf(&b, "\nGo packages to test: %s\n", strings.Join(c.GoPackages, " "))
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func handleImpactOfChange(ctx context.Context, a *Agent, args map[string]interface{}) (string, error) {
+	path, err := getFilePath(args)
+	if err != nil {
+		return "", fmt.Errorf("failed to get file path: %w", err)
+	}
+	absPath, err := filesystem.SafeResolvePathWithBypass(ctx, path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve file path: %w", err)
+	}
+	if !isRegularFile(absPath) {
+		return "", fmt.Errorf("file does not exist: %s", absPath)
+	}
+	root := filesystem.WorkspaceRootFromContext(ctx)
+	if root == "" {
+		root = a.currentWorkspaceRoot()
+	}
+	graph, err := BuildDependencyGraph(ctx, root)
+	if err != nil {
+		return "", fmt.Errorf("failed to build dependency graph: %w", err)
+	}
+	return graph.Impact(absPath).String(), nil
+}
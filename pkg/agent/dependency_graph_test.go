@@ -0,0 +1,97 @@
+package agent
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	api "github.com/alantheprice/ledit/pkg/agent_api"
+)
+
+func writeDependencyFixture(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	writePrefetchFixture(t, root, map[string]string{
+		"go.mod":                   "module example.com/app\n\ngo 1.22\n",
+		"main.go":                  "package main\n\nimport \"example.com/app/internal/api\"\n\nfunc main() {}\n",
+		"internal/api/api.go":      "package api\n\nimport \"example.com/app/internal/store\"\n",
+		"internal/api/api_test.go": "package api\n",
+		"internal/store/store.go":  "package store\n",
+		"internal/store/db.go":     "package store\n",
+		"internal/other/other.go":  "package other\n",
+		"web/util.ts":              "export const x = 1\n",
+		"web/app.ts":               "import { x } from './util'\n",
+		"web/app.test.ts":          "import { app } from './app'\n",
+	})
+	return root
+}
+
+func TestDependencyGraphImpact(t *testing.T) {
+	root := writeDependencyFixture(t)
+	graph, err := BuildDependencyGraph(t.Context(), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	impact := graph.Impact(filepath.Join(graph.root, "internal", "store", "db.go"))
+	wantDependents := []ImpactedFile{{Path: "internal/api/api.go", Depth: 1}, {Path: "main.go", Depth: 2}}
+	if !reflect.DeepEqual(impact.Dependents, wantDependents) {
+		t.Errorf("dependents = %+v, want %+v", impact.Dependents, wantDependents)
+	}
+	if want := []string{"internal/api/api_test.go"}; !reflect.DeepEqual(impact.Tests, want) {
+		t.Errorf("tests = %v, want %v", impact.Tests, want)
+	}
+	if want := []string{".", "./internal/api", "./internal/store"}; !reflect.DeepEqual(impact.GoPackages, want) {
+		t.Errorf("packages = %v, want %v", impact.GoPackages, want)
+	}
+
+	impact = graph.Impact(filepath.Join(graph.root, "web", "util.ts"))
+	if want := []string{"web/app.test.ts"}; !reflect.DeepEqual(impact.Tests, want) {
+		t.Errorf("tests = %v, want %v", impact.Tests, want)
+	}
+	if out := impact.String(); !strings.Contains(out, "web/app.ts (direct)") || !strings.Contains(out, "web/app.test.ts (via 2 imports)") {
+		t.Errorf("unexpected report:\n%s", out)
+	}
+
+	impact = graph.Impact(filepath.Join(graph.root, "internal", "other", "other.go"))
+	if len(impact.Dependents) != 0 || !strings.Contains(impact.String(), "No workspace files import it") {
+		t.Errorf("expected no dependents, got %+v", impact)
+	}
+}
+
+func TestValidationGateScopeTests(t *testing.T) {
+	root := writeDependencyFixture(t)
+
+	gate := &ValidationGate{Build: "go build ./...", Test: "go test {packages}"}
+	scoped, err := gate.ScopeTests(t.Context(), root, []string{"internal/api/api.go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if scoped.Test != "go test . ./internal/api" || gate.Test != "go test {packages}" {
+		t.Errorf("scoped test = %q (gate %q)", scoped.Test, gate.Test)
+	}
+
+	scoped, err = gate.ScopeTests(t.Context(), root, []string{"web/util.ts"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if steps := scoped.Steps(); len(steps) != 1 || steps[0].Name != "build" {
+		t.Errorf("expected only the build step without affected packages, got %+v", steps)
+	}
+
+	unscoped := &ValidationGate{Test: "go test ./..."}
+	if scoped, err := unscoped.ScopeTests(t.Context(), root, nil); err != nil || scoped.Test != "go test ./..." {
+		t.Errorf("commands without placeholders should be unchanged, got %q, %v", scoped.Test, err)
+	}
+}
+
+func TestModifiedFilePaths(t *testing.T) {
+	calls := []api.ToolCall{writeToolCall("1", "write_file"), writeToolCall("2", "edit_file")}
+	calls[0].Function.Arguments = `{"path": "a.go", "content": "package a"}`
+	calls[1].Function.Arguments = `{"file_path": "b.go"}`
+	succeeded := map[string]bool{"1": true, "2": true}
+	if got := modifiedFilePaths(calls, succeeded); !reflect.DeepEqual(got, []string{"a.go", "b.go"}) {
+		t.Errorf("modifiedFilePaths = %v", got)
+	}
+}
@@ -0,0 +1,96 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/alantheprice/ledit/pkg/depinstall"
+)
+
+// checkMissingDependencies looks for imports in a freshly written file that
+// its manifest does not declare. With the user's approval it installs them
+// and re-runs validation. The returned note is appended to the tool result so
+// the model knows the build state; it is empty when nothing is missing.
+func (a *Agent) checkMissingDependencies(ctx context.Context, path, content string) string {
+	if a.configManager == nil || !a.configManager.GetConfig().GetDependencyInstallProposalsEnabled() {
+		return ""
+	}
+
+	root := a.currentWorkspaceRoot()
+	proposal := depinstall.Propose(root, depinstall.FindMissing(root, path, content))
+	if proposal == nil {
+		return ""
+	}
+	missing := proposal.Missing
+	command := proposal.String()
+	a.debugLog("Missing dependencies in %s: %v (proposed: %s)\n", path, missing.Packages, command)
+
+	prompt := fmt.Sprintf("%s imports %s, not declared in %s. Run `%s`?",
+		path, strings.Join(missing.Packages, ", "), manifestName(missing.Ecosystem), command)
+	choices := []ChoiceOption{
+		{Label: "Install", Value: "install"},
+		{Label: "Skip", Value: "skip"},
+	}
+	choice, err := a.PromptChoice(prompt, choices)
+	if err != nil {
+		// No interactive UI (headless or subagent): leave the decision to the model.
+		return fmt.Sprintf("\n\nMissing dependencies: %s not declared in %s. The build will fail until they are installed with: %s",
+			strings.Join(missing.Packages, ", "), manifestName(missing.Ecosystem), command)
+	}
+	if choice != "install" {
+		return fmt.Sprintf("\n\nMissing dependencies: the user declined `%s`; %s remain undeclared.",
+			command, strings.Join(missing.Packages, ", "))
+	}
+
+	a.PrintLine(fmt.Sprintf("[deps] Running %s", command))
+	installCtx, cancel := context.WithTimeout(ctx, depinstall.DefaultInstallTimeout)
+	defer cancel()
+	output, err := proposal.Run(installCtx)
+	if err != nil {
+		a.PrintLine(fmt.Sprintf("[deps] Install failed: %v", err))
+		return fmt.Sprintf("\n\nDependency install failed (%v):\n%s", err, truncateDependencyOutput(output))
+	}
+
+	return "\n\n" + a.revalidateAfterInstall(ctx, root, path, content, command)
+}
+
+// revalidateAfterInstall re-runs import detection and syntax validation after
+// an install and summarizes the result.
+func (a *Agent) revalidateAfterInstall(ctx context.Context, root, path, content, command string) string {
+	if still := depinstall.FindMissing(root, path, content); still != nil {
+		a.PrintLine(fmt.Sprintf("[deps] Still undeclared after install: %s", strings.Join(still.Packages, ", ")))
+		return fmt.Sprintf("Ran `%s`, but %s are still not declared in %s.",
+			command, strings.Join(still.Packages, ", "), manifestName(still.Ecosystem))
+	}
+
+	if a.validator != nil && strings.HasSuffix(path, ".go") {
+		result := a.validator.RunValidation(ctx, path, content)
+		if !result.Valid {
+			messages := make([]string, 0, len(result.Errors))
+			for _, d := range result.Errors {
+				messages = append(messages, d.Message)
+			}
+			return fmt.Sprintf("Installed dependencies with `%s`; validation still reports: %s", command, strings.Join(messages, "; "))
+		}
+	}
+
+	a.PrintLine(fmt.Sprintf("[deps] Installed dependencies with %s", command))
+	return fmt.Sprintf("Installed missing dependencies with `%s`; validation passed.", command)
+}
+
+func manifestName(ecosystem depinstall.Ecosystem) string {
+	if ecosystem == depinstall.EcosystemGo {
+		return "go.mod"
+	}
+	return "package.json"
+}
+
+func truncateDependencyOutput(output string) string {
+	const limit = 2000
+	output = strings.TrimSpace(output)
+	if len(output) > limit {
+		return "..." + output[len(output)-limit:]
+	}
+	return output
+}
@@ -0,0 +1,59 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alantheprice/ledit/pkg/configuration"
+)
+
+func newDependencyTestAgent(t *testing.T, enabled *bool) (*Agent, string) {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/app\n\ngo 1.22\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := configuration.NewConfig()
+	cfg.DependencyInstallProposals = enabled
+	agent := makeAgentWithScriptedClient(1, NewScriptedClient())
+	agent.configManager = configuration.NewManagerWithConfig(cfg, nil)
+	agent.SetWorkspaceRoot(dir)
+	return agent, dir
+}
+
+func TestCheckMissingDependencies_ProposesCommandWithoutUI(t *testing.T) {
+	agent, dir := newDependencyTestAgent(t, nil)
+
+	content := "package app\n\nimport (\n\t\"fmt\"\n\n\t\"github.com/google/uuid\"\n)\n"
+	note := agent.checkMissingDependencies(context.Background(), filepath.Join(dir, "app.go"), content)
+
+	if !strings.Contains(note, "github.com/google/uuid not declared in go.mod") {
+		t.Fatalf("expected missing dependency note, got: %q", note)
+	}
+	if !strings.Contains(note, "go get github.com/google/uuid") {
+		t.Fatalf("expected proposed install command, got: %q", note)
+	}
+}
+
+func TestCheckMissingDependencies_NothingMissing(t *testing.T) {
+	agent, dir := newDependencyTestAgent(t, nil)
+
+	content := "package app\n\nimport (\n\t\"fmt\"\n\n\t\"example.com/app/internal/store\"\n)\n"
+	if note := agent.checkMissingDependencies(context.Background(), filepath.Join(dir, "app.go"), content); note != "" {
+		t.Fatalf("expected no note, got: %q", note)
+	}
+}
+
+func TestCheckMissingDependencies_Disabled(t *testing.T) {
+	disabled := false
+	agent, dir := newDependencyTestAgent(t, &disabled)
+
+	content := "package app\n\nimport \"github.com/google/uuid\"\n"
+	if note := agent.checkMissingDependencies(context.Background(), filepath.Join(dir, "app.go"), content); note != "" {
+		t.Fatalf("expected no note when disabled, got: %q", note)
+	}
+}
@@ -0,0 +1,79 @@
+// Devcontainer execution: shell commands, builds and tests run in the
+// workspace's devcontainer, which has the workspace mounted.
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	tools "github.com/alantheprice/ledit/pkg/agent_tools"
+	"github.com/alantheprice/ledit/pkg/devcontainer"
+)
+
+// devcontainerExec holds the container for the workspace it was set up for.
+type devcontainerExec struct {
+	mu        sync.Mutex
+	root      string
+	container *devcontainer.Container
+	err       error
+	ready     bool
+}
+
+func (a *Agent) devcontainerState() *devcontainerExec {
+	a.devcontainerOnce.Do(func() {
+		a.devcontainer = &devcontainerExec{}
+	})
+	return a.devcontainer
+}
+
+// Devcontainer returns the container that runs the workspace's shell
+// commands, or nil when they run locally: devcontainers are not enabled or
+// the workspace has no devcontainer.json.
+func (a *Agent) Devcontainer() (*devcontainer.Container, error) {
+	config := a.GetConfig()
+	if config == nil || config.Devcontainer == nil || !config.Devcontainer.Enabled {
+		return nil, nil
+	}
+	root := a.currentWorkspaceRoot()
+	s := a.devcontainerState()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.ready || s.root != root {
+		s.root, s.ready = root, true
+		s.container, s.err = devcontainer.New(root, devcontainer.Options{
+			Runtime: config.Devcontainer.Runtime,
+			Progress: func(message string) {
+				a.PrintLineAsync("[devcontainer] " + message)
+			},
+		})
+		if errors.Is(s.err, devcontainer.ErrNotFound) {
+			a.debugLog("[devcontainer] %v; running commands locally\n", s.err)
+			s.container, s.err = nil, nil
+		}
+	}
+	return s.container, s.err
+}
+
+// execBackendContext returns a context whose shell commands run on the
+// remote host or in the devcontainer, if either is set up.
+func (a *Agent) execBackendContext(ctx context.Context) (context.Context, tools.ExecBackend, error) {
+	host, err := a.RemoteHost()
+	if err != nil {
+		return ctx, nil, fmt.Errorf("remote execution is configured but unavailable: %w", err)
+	}
+	container, err := a.Devcontainer()
+	if err != nil {
+		return ctx, nil, fmt.Errorf("devcontainer execution is enabled but unavailable: %w", err)
+	}
+	switch {
+	case host != nil && container != nil:
+		return ctx, nil, errors.New("both remote and devcontainer execution are enabled; disable one of them")
+	case host != nil:
+		return tools.WithExecBackend(ctx, host), host, nil
+	case container != nil:
+		return tools.WithExecBackend(ctx, container), container, nil
+	}
+	return ctx, nil, nil
+}
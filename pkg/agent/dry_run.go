@@ -0,0 +1,180 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	tools "github.com/alantheprice/ledit/pkg/agent_tools"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// dryRunEnv enables dry-run mode. It is set by --dry-run and /dryrun, and
+// subagent processes inherit it.
+const dryRunEnv = "LEDIT_DRY_RUN"
+
+// dryRunNote tells the model that nothing was changed and how to go on.
+const dryRunNote = "DRY RUN: nothing was changed. Continue planning as if this step succeeded, and do not retry it."
+
+// DryRun reports whether tools that change files, the repository or
+// anything outside the process only show what they would do.
+func (a *Agent) DryRun() bool {
+	enabled, err := strconv.ParseBool(strings.TrimSpace(os.Getenv(dryRunEnv)))
+	return err == nil && enabled
+}
+
+// SetDryRun turns dry-run mode on or off for this process and the subagents
+// it starts.
+func (a *Agent) SetDryRun(enabled bool) {
+	if enabled {
+		_ = os.Setenv(dryRunEnv, "1")
+	} else {
+		_ = os.Unsetenv(dryRunEnv)
+	}
+}
+
+// changesState reports whether a tool call would change files, the
+// repository or anything outside the process. Shell commands are judged by
+// tools.IsReadOnlyShellCommand, so reads still run in dry-run mode.
+func changesState(toolName string, args map[string]interface{}) bool {
+	switch toolName {
+	case "write_file", "edit_file", "write_structured_file", "patch_structured_file", "rename_symbol",
+		"edit_transaction", "git", "commit", "add_memory", "delete_memory":
+		return true
+	case "shell_command":
+		command, _ := args["command"].(string)
+		return !tools.IsReadOnlyShellCommand(command)
+	case "pr":
+		action, _ := args["action"].(string)
+		action = strings.ToLower(strings.TrimSpace(action))
+		return action == "create" || action == "reply"
+	case "rollback_changes":
+		confirm, _ := args["confirm"].(bool)
+		return confirm
+	}
+	return false
+}
+
+// previewToolCall describes what a state-changing tool call would do
+// instead of running it. File writes other than edit_file are previewed by
+// writeFileContent once the handler has built the new content, so they
+// return handled=false here.
+func (a *Agent) previewToolCall(ctx context.Context, toolName string, args map[string]interface{}) (string, bool, error) {
+	var action string
+	switch toolName {
+	case "edit_file":
+		path, err := getFilePath(args)
+		if err != nil {
+			return "", true, fmt.Errorf("failed to get file path: %w", err)
+		}
+		oldStr, _ := args["old_str"].(string)
+		newStr, _ := args["new_str"].(string)
+		before, after, err := tools.PreviewEdit(ctx, path, oldStr, newStr)
+		if err != nil {
+			return "", true, fmt.Errorf("failed to edit file %s: %w", path, err)
+		}
+		return a.previewFileWrite(path, before, after), true, nil
+	case "edit_transaction":
+		tx, err := stageTransaction(ctx, args)
+		if err != nil {
+			return "", true, err
+		}
+		var previews []string
+		for _, f := range tx.Files() {
+			previews = append(previews, a.previewFileWrite(f.Path, f.Before, f.After))
+		}
+		return strings.Join(previews, "\n\n"), true, nil
+	case "shell_command":
+		command, _ := args["command"].(string)
+		action = "run: " + command
+	case "git":
+		operation, _ := args["operation"].(string)
+		gitArgs, _ := args["args"].(string)
+		action = strings.TrimSpace("run: git " + strings.ReplaceAll(operation, "_", "-") + " " + gitArgs)
+	case "commit":
+		action = "commit the staged changes"
+		if message, _ := args["message"].(string); message != "" {
+			action += fmt.Sprintf(" with message %q", message)
+		}
+	case "pr":
+		if verb, _ := args["action"].(string); strings.EqualFold(verb, "reply") {
+			action = fmt.Sprintf("reply to pull request comment %v", args["comment_id"])
+		} else {
+			action = fmt.Sprintf("open a pull request titled %q", args["title"])
+		}
+	case "rollback_changes":
+		action = fmt.Sprintf("roll back revision %v", args["revision_id"])
+	case "rename_symbol":
+		action = fmt.Sprintf("rename %v in %v to %v and update its references", args["symbol"], args["path"], args["new_name"])
+	case "add_memory":
+		action = fmt.Sprintf("save memory %q", args["name"])
+	case "delete_memory":
+		action = fmt.Sprintf("delete memory %q", args["name"])
+	default:
+		return "", false, nil
+	}
+	a.PrintLine(fmt.Sprintf("[dry-run] Would %s", action))
+	return fmt.Sprintf("%s\nWould %s", dryRunNote, action), true, nil
+}
+
+// previewExternalTool describes an MCP or custom tool call instead of
+// running it; ledit cannot tell whether such tools change anything.
+func (a *Agent) previewExternalTool(toolName string, args map[string]interface{}) string {
+	a.PrintLine(fmt.Sprintf("[dry-run] Would call %s %v", toolName, args))
+	return fmt.Sprintf("%s\nWould call %s with %v", dryRunNote, toolName, args)
+}
+
+// previewFileWrite shows the diff a write would make and returns the tool
+// result for the model.
+func (a *Agent) previewFileWrite(path, before, after string) string {
+	if before == after {
+		a.PrintLine(fmt.Sprintf("[dry-run] %s would be unchanged", path))
+		return fmt.Sprintf("%s\n%s would be unchanged", dryRunNote, path)
+	}
+	verb := "write"
+	if before == "" {
+		verb = "create"
+	}
+	a.PrintLine(fmt.Sprintf("[dry-run] Would %s %s:", verb, path))
+	a.ShowColoredDiff(before, after, 50)
+	return fmt.Sprintf("%s\nWould %s %s with these line changes:\n%s", dryRunNote, verb, path, dryRunDiff(before, after, 200))
+}
+
+// dryRunDiff renders the line changes from before to after as -/+ lines,
+// showing at most maxLines of them.
+func dryRunDiff(before, after string, maxLines int) string {
+	dmp := diffmatchpatch.New()
+	a, b, lines := dmp.DiffLinesToChars(before, after)
+	diffs := dmp.DiffCharsToLines(dmp.DiffMain(a, b, false), lines)
+
+	var sb strings.Builder
+	shown, omitted := 0, 0
+	for _, d := range diffs {
+		prefix := ""
+		switch d.Type {
+		case diffmatchpatch.DiffInsert:
+			prefix = "+"
+		case diffmatchpatch.DiffDelete:
+			prefix = "-"
+		default:
+			continue
+		}
+		for _, line := range strings.SplitAfter(d.Text, "\n") {
+			if line == "" {
+				continue
+			}
+			if shown >= maxLines {
+				omitted++
+				continue
+			}
+			sb.WriteString(prefix + strings.TrimSuffix(line, "\n") + "\n")
+			shown++
+		}
+	}
+	if omitted > 0 {
+		sb.WriteString(fmt.Sprintf("... %d more changed lines\n", omitted))
+	}
+	return sb.String()
+}
@@ -0,0 +1,96 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDryRunPreviewsWritesWithoutTouchingDisk(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	t.Setenv(dryRunEnv, "1")
+	writeTestFile(t, dir, "main.go", "package main\n\nfunc main() {}\n")
+
+	agent := newTestAgent(t)
+	reg := GetToolRegistry()
+	ctx := context.Background()
+
+	_, out, err := reg.ExecuteTool(ctx, "write_file", map[string]interface{}{"path": "new.txt", "content": "hello\n"}, agent)
+	if err != nil {
+		t.Fatalf("write_file returned error: %v", err)
+	}
+	if !strings.Contains(out, "DRY RUN") || !strings.Contains(out, "+hello") {
+		t.Fatalf("expected a create preview, got: %s", out)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "new.txt")); !os.IsNotExist(err) {
+		t.Fatalf("write_file created the file in dry-run mode")
+	}
+
+	_, out, err = reg.ExecuteTool(ctx, "edit_file", map[string]interface{}{
+		"path":    "main.go",
+		"old_str": "func main() {}",
+		"new_str": "func main() { println(1) }",
+	}, agent)
+	if err != nil {
+		t.Fatalf("edit_file returned error: %v", err)
+	}
+	if !strings.Contains(out, "-func main() {}") || !strings.Contains(out, "+func main() { println(1) }") {
+		t.Fatalf("expected an edit diff, got: %s", out)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "main.go"))
+	if string(data) != "package main\n\nfunc main() {}\n" {
+		t.Fatalf("edit_file changed the file in dry-run mode: %q", data)
+	}
+
+	_, out, err = reg.ExecuteTool(ctx, "shell_command", map[string]interface{}{"command": "touch made.txt"}, agent)
+	if err != nil {
+		t.Fatalf("shell_command returned error: %v", err)
+	}
+	if !strings.Contains(out, "Would run: touch made.txt") {
+		t.Fatalf("expected a command preview, got: %s", out)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "made.txt")); !os.IsNotExist(err) {
+		t.Fatalf("shell_command ran in dry-run mode")
+	}
+}
+
+func TestDryRunToggle(t *testing.T) {
+	t.Setenv(dryRunEnv, "")
+	a := &Agent{}
+	if a.DryRun() {
+		t.Fatal("dry run should be off by default")
+	}
+	a.SetDryRun(true)
+	if !a.DryRun() || os.Getenv(dryRunEnv) != "1" {
+		t.Fatal("SetDryRun(true) should enable dry run for this process and subagents")
+	}
+	a.SetDryRun(false)
+	if a.DryRun() {
+		t.Fatal("SetDryRun(false) should disable dry run")
+	}
+}
+
+func TestChangesState(t *testing.T) {
+	cases := []struct {
+		tool string
+		args map[string]interface{}
+		want bool
+	}{
+		{"write_file", nil, true},
+		{"read_file", nil, false},
+		{"shell_command", map[string]interface{}{"command": "git status"}, false},
+		{"shell_command", map[string]interface{}{"command": "rm -rf build"}, true},
+		{"pr", map[string]interface{}{"action": "status"}, false},
+		{"pr", map[string]interface{}{"action": "create"}, true},
+		{"rollback_changes", map[string]interface{}{"confirm": false}, false},
+		{"rollback_changes", map[string]interface{}{"confirm": true}, true},
+	}
+	for _, tc := range cases {
+		if got := changesState(tc.tool, tc.args); got != tc.want {
+			t.Errorf("changesState(%s, %v) = %v, want %v", tc.tool, tc.args, got, tc.want)
+		}
+	}
+}
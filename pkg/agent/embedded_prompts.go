@@ -24,11 +24,22 @@ var embeddedPromptFiles embed.FS
 
 // GetEmbeddedSystemPrompt returns the embedded system prompt
 func GetEmbeddedSystemPrompt() (string, error) {
+	layers, err := embeddedPromptLayers()
+	if err != nil {
+		return "", err
+	}
+	return joinPromptLayers(layers), nil
+}
+
+// embeddedPromptLayers returns the layers of the embedded system prompt: the
+// prompt itself, the date and time, discovered context files and memories.
+func embeddedPromptLayers() ([]PromptLayer, error) {
 	// Extract the prompt content from the markdown
 	promptContent, err := extractSystemPrompt()
 	if err != nil {
-		return "", fmt.Errorf("failed to extract system prompt: %w", err)
+		return nil, fmt.Errorf("failed to extract system prompt: %w", err)
 	}
+	layers := []PromptLayer{{Name: PromptLayerBase, Source: "embedded system prompt", Content: promptContent}}
 
 	// Add current date and time for temporal context
 	currentTime := time.Now()
@@ -36,21 +47,21 @@ func GetEmbeddedSystemPrompt() (string, error) {
 		currentTime.Format("2006-01-02"),
 		currentTime.Format("15:04:05"),
 		currentTime.Location().String())
-	promptContent = promptContent + dateTimeString
+	layers = append(layers, PromptLayer{Name: PromptLayerEnvironment, Source: "date and time at session start", Content: dateTimeString})
 
 	// Add discovered context files (AGENTS.md, Claude.md, etc.)
 	contextFiles, err := LoadContextFiles()
 	if err == nil && contextFiles != "" {
-		promptContent = promptContent + contextFiles
+		layers = append(layers, PromptLayer{Name: PromptLayerInstructions, Source: "project instruction files", Content: contextFiles})
 	}
 
 	// Add memories (user preferences and learned patterns)
 	memories := LoadMemoriesForPrompt()
 	if memories != "" {
-		promptContent = promptContent + memories
+		layers = append(layers, PromptLayer{Name: PromptLayerMemories, Source: "saved memories", Content: memories})
 	}
 
-	return promptContent, nil
+	return layers, nil
 }
 
 // GetEmbeddedSystemPromptWithProvider returns the embedded system prompt
@@ -58,6 +69,12 @@ func GetEmbeddedSystemPromptWithProvider(provider string) (string, error) {
 	return GetEmbeddedSystemPrompt()
 }
 
+// BaseSystemPrompt returns the embedded system prompt without the date, context
+// files and memories that GetEmbeddedSystemPrompt appends at runtime.
+func BaseSystemPrompt() (string, error) {
+	return extractSystemPrompt()
+}
+
 // extractSystemPrompt extracts the prompt content from the system_prompt markdown
 func extractSystemPrompt() (string, error) {
 	// The system_prompt.md has the prompt content in a code block
@@ -1,6 +1,7 @@
 package agent
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -133,6 +134,16 @@ func (eh *ErrorHandler) buildRateLimitMessage(attempts int, lastErr error) strin
 
 // classifyError returns user-friendly error explanation
 func (eh *ErrorHandler) classifyError(apiErr error) string {
+	var tooLarge *RequestTooLargeError
+	if errors.As(apiErr, &tooLarge) {
+		return "The request was not sent because it is too large for the model, even after compacting history:\n\n" +
+			tooLarge.Error() + "\n\n" +
+			"Try one of these:\n" +
+			"- Shorten or split the largest message listed above\n" +
+			"- Ask me to read smaller parts of large files\n" +
+			"- Switch to a model/provider with a larger context window\n\n"
+	}
+
 	errorMsg := apiErr.Error()
 	if strings.Contains(errorMsg, "timeout") || strings.Contains(errorMsg, "deadline exceeded") {
 		return "The API request timed out, likely due to high server load or a complex request.\n\n"
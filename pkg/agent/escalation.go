@@ -0,0 +1,177 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	api "github.com/alantheprice/ledit/pkg/agent_api"
+	tools "github.com/alantheprice/ledit/pkg/agent_tools"
+	"github.com/alantheprice/ledit/pkg/configuration"
+	"github.com/alantheprice/ledit/pkg/factory"
+)
+
+// escalationFailureThreshold is how many times the current model may fail a
+// todo before the todo is handed to the escalation model.
+const escalationFailureThreshold = 2
+
+// createEscalationClient creates the client for the escalation model;
+// replaced in tests.
+var createEscalationClient = factory.CreateProviderClient
+
+// ModelEscalation records a todo that was handed to a stronger model.
+type ModelEscalation struct {
+	Todo   string    `json:"todo"`
+	From   string    `json:"from"`
+	To     string    `json:"to"`
+	Reason string    `json:"reason"`
+	At     time.Time `json:"at"`
+}
+
+// escalationState tracks failures per todo and the model an escalated todo
+// returns to.
+type escalationState struct {
+	failures    map[string]int
+	todo        string // key of the escalated todo, "" when not escalated
+	client      api.ClientInterface
+	clientType  api.ClientType
+	provider    api.ClientType
+	model       string
+	escalations []ModelEscalation
+}
+
+// GetModelEscalations returns the todos handed to the escalation model this
+// session.
+func (a *Agent) GetModelEscalations() []ModelEscalation {
+	return append([]ModelEscalation(nil), a.escalation.escalations...)
+}
+
+// activeTodo returns the todo in progress, keyed by its ID or content.
+func activeTodo() (key, content string, ok bool) {
+	for _, todo := range tools.TodoRead() {
+		if todo.Status != "in_progress" {
+			continue
+		}
+		key = todo.ID
+		if key == "" {
+			key = todo.Content
+		}
+		return key, todo.Content, true
+	}
+	return "", "", false
+}
+
+// recordToolFailures counts a batch of tool calls as one failure of the
+// todo in progress when it had unparseable arguments or a failed edit.
+func (a *Agent) recordToolFailures(toolCalls []api.ToolCall, results []api.Message) {
+	names := make(map[string]string, len(toolCalls))
+	for _, tc := range toolCalls {
+		names[tc.ID] = tc.Function.Name
+	}
+	for _, result := range results {
+		switch name := names[result.ToolCallId]; {
+		case strings.HasPrefix(result.Content, "Error parsing arguments"):
+			a.recordTodoFailure("unparseable arguments for " + name)
+			return
+		case fileModifyingTools[name] && strings.HasPrefix(result.Content, "Error"):
+			a.recordTodoFailure("failed " + name)
+			return
+		}
+	}
+}
+
+// recordTodoFailure counts a failure of the todo in progress and escalates
+// the todo once the current model has failed it twice.
+func (a *Agent) recordTodoFailure(reason string) {
+	key, content, ok := activeTodo()
+	if !ok || key == a.escalation.todo {
+		return
+	}
+	if a.escalation.failures == nil {
+		a.escalation.failures = make(map[string]int)
+	}
+	a.escalation.failures[key]++
+	a.debugLog("[escalate] Todo %q failed (%s), %d/%d\n", content, reason, a.escalation.failures[key], escalationFailureThreshold)
+	if a.escalation.failures[key] >= escalationFailureThreshold {
+		a.escalateTodo(key, content, reason)
+	}
+}
+
+// escalateTodo switches to the configured escalation model until the todo
+// is no longer in progress.
+func (a *Agent) escalateTodo(key, content, reason string) {
+	config := a.GetConfig()
+	if config == nil || a.escalation.todo != "" {
+		return
+	}
+	providerName, model := config.GetEscalation()
+	if model == "" {
+		return
+	}
+	provider := a.clientType
+	if providerName != "" {
+		var err error
+		if provider, err = configuration.MapProviderStringToClientType(config, providerName); err != nil {
+			a.debugLog("[escalate] %v\n", err)
+			return
+		}
+	}
+	from := a.GetProvider() + ":" + a.GetModel()
+	to := string(provider) + ":" + model
+	if from == to {
+		return
+	}
+	client, err := createEscalationClient(provider, model)
+	if err != nil {
+		a.PrintLineAsync(fmt.Sprintf("[WARN] Could not escalate to %s: %v", to, err))
+		return
+	}
+	client.SetDebug(a.debug)
+
+	a.escalation.todo = key
+	a.escalation.client = a.client
+	a.escalation.clientType = a.clientType
+	a.escalation.provider = a.sessionProvider
+	a.escalation.model = a.sessionModel
+	a.escalation.escalations = append(a.escalation.escalations, ModelEscalation{
+		Todo: content, From: from, To: to, Reason: reason, At: time.Now(),
+	})
+	a.switchClient(client, provider, provider, model)
+	a.PrintLineAsync(fmt.Sprintf("[escalate] %s failed %q twice (last: %s); switching to %s for this todo", from, content, reason, to))
+}
+
+// updateTodoEscalation returns to the previous model once the escalated
+// todo is no longer the one in progress.
+func (a *Agent) updateTodoEscalation() {
+	if a.escalation.todo == "" {
+		return
+	}
+	if key, _, ok := activeTodo(); ok && key == a.escalation.todo {
+		return
+	}
+	a.endTodoEscalation()
+}
+
+// endTodoEscalation returns to the model in use before the escalation.
+func (a *Agent) endTodoEscalation() {
+	if a.escalation.todo == "" {
+		return
+	}
+	delete(a.escalation.failures, a.escalation.todo)
+	a.switchClient(a.escalation.client, a.escalation.clientType, a.escalation.provider, a.escalation.model)
+	a.escalation.todo = ""
+	a.escalation.client = nil
+	a.PrintLineAsync(fmt.Sprintf("[escalate] Back to %s:%s", a.GetProvider(), a.GetModel()))
+}
+
+// switchClient puts client in place for the session without touching the
+// configuration.
+func (a *Agent) switchClient(client api.ClientInterface, clientType, sessionProvider api.ClientType, sessionModel string) {
+	prevProvider, prevModel := a.GetProvider(), a.GetModel()
+	a.client = a.wrapRecordingClient(client)
+	a.clientType = clientType
+	a.sessionProvider = sessionProvider
+	a.sessionModel = sessionModel
+	a.maxContextTokens = a.getModelContextLimit()
+	a.normalizeConversationForCurrentModelSyntax(prevProvider, prevModel)
+}
@@ -0,0 +1,100 @@
+package agent
+
+import (
+	"errors"
+	"testing"
+
+	api "github.com/alantheprice/ledit/pkg/agent_api"
+	tools "github.com/alantheprice/ledit/pkg/agent_tools"
+	"github.com/alantheprice/ledit/pkg/configuration"
+)
+
+func failedEdit(id string) ([]api.ToolCall, []api.Message) {
+	call := api.ToolCall{ID: id}
+	call.Function.Name = "edit_file"
+	return []api.ToolCall{call}, []api.Message{{Role: "tool", ToolCallId: id, Content: "Error: old_string not found"}}
+}
+
+func TestTodoEscalationSwitchesModelForThatTodoOnly(t *testing.T) {
+	defer tools.TodoWrite(nil)
+	original := createEscalationClient
+	defer func() { createEscalationClient = original }()
+
+	var requested string
+	createEscalationClient = func(provider api.ClientType, model string) (api.ClientInterface, error) {
+		requested = string(provider) + ":" + model
+		client := NewScriptedClient()
+		if err := client.SetModel(model); err != nil {
+			return nil, err
+		}
+		return client, nil
+	}
+
+	base := NewScriptedClient()
+	a := makeAgentWithScriptedClient(10, base)
+	a.clientType = api.TestClientType
+	a.configManager = configuration.NewManagerWithConfig(&configuration.Config{EscalationModel: "strong"}, nil)
+	tools.TodoWrite([]tools.TodoItem{
+		{ID: "1", Content: "Fix the parser", Status: "in_progress"},
+		{ID: "2", Content: "Update docs", Status: "pending"},
+	})
+
+	a.recordToolFailures(failedEdit("c1"))
+	if a.client != base {
+		t.Fatal("expected no escalation after one failure")
+	}
+	// Successful calls are not failures
+	a.recordToolFailures([]api.ToolCall{{ID: "c2"}}, []api.Message{{ToolCallId: "c2", Content: "ok"}})
+	if a.client != base {
+		t.Fatal("expected no escalation after a success")
+	}
+
+	a.recordToolFailures(failedEdit("c3"))
+	if a.client == base || a.GetModel() != "strong" || requested != "test:strong" {
+		t.Fatalf("expected escalation to test:strong, got %s:%s (requested %q)", a.GetProvider(), a.GetModel(), requested)
+	}
+	escalations := a.GetModelEscalations()
+	if len(escalations) != 1 || escalations[0].Todo != "Fix the parser" || escalations[0].To != "test:strong" || escalations[0].Reason != "failed edit_file" {
+		t.Fatalf("unexpected escalations %+v", escalations)
+	}
+
+	// Further failures of the escalated todo do not escalate again
+	a.recordToolFailures(failedEdit("c4"))
+	a.updateTodoEscalation()
+	if len(a.GetModelEscalations()) != 1 || a.GetModel() != "strong" {
+		t.Fatal("expected the escalated model to stay for the same todo")
+	}
+
+	tools.TodoWrite([]tools.TodoItem{
+		{ID: "1", Content: "Fix the parser", Status: "completed"},
+		{ID: "2", Content: "Update docs", Status: "in_progress"},
+	})
+	a.updateTodoEscalation()
+	if a.client != base || a.sessionModel != "" {
+		t.Fatalf("expected the original model back, got %s", a.GetModel())
+	}
+	a.recordToolFailures(failedEdit("c5"))
+	if a.client != base {
+		t.Fatal("expected the next todo to start with a fresh failure count")
+	}
+}
+
+func TestTodoEscalationNeedsConfiguredModel(t *testing.T) {
+	defer tools.TodoWrite(nil)
+	original := createEscalationClient
+	defer func() { createEscalationClient = original }()
+	createEscalationClient = func(api.ClientType, string) (api.ClientInterface, error) {
+		return nil, errors.New("should not be called")
+	}
+
+	base := NewScriptedClient()
+	a := makeAgentWithScriptedClient(10, base)
+	a.configManager = configuration.NewManagerWithConfig(&configuration.Config{}, nil)
+	tools.TodoWrite([]tools.TodoItem{{ID: "1", Content: "Fix the parser", Status: "in_progress"}})
+
+	a.recordTodoFailure("malformed tool call")
+	a.recordTodoFailure("malformed tool call")
+	if a.client != base || len(a.GetModelEscalations()) != 0 {
+		t.Fatal("expected no escalation without escalation_model")
+	}
+}
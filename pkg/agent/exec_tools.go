@@ -0,0 +1,237 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	api "github.com/alantheprice/ledit/pkg/agent_api"
+	"github.com/alantheprice/ledit/pkg/configuration"
+	"gopkg.in/yaml.v3"
+)
+
+// ExecToolsDir holds a workspace's exec tool manifests, relative to the
+// workspace root. User-wide manifests live in "tools" under the ledit config
+// directory.
+var ExecToolsDir = filepath.Join(".ledit", "tools")
+
+// execToolNamePattern is the tool name format the providers accept.
+var execToolNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+const (
+	defaultExecToolTimeout   = 30 * time.Second
+	defaultExecToolMaxOutput = 64 * 1024
+	execToolStderrLimit      = 2000
+)
+
+// ExecToolManifest declares an external program as an agent tool. The
+// program gets the call's arguments as a JSON object on stdin, and its
+// stdout is returned to the model.
+type ExecToolManifest struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	// Parameters is the JSON schema of the arguments; an empty object schema
+	// is used when it is omitted.
+	Parameters map[string]interface{} `yaml:"parameters"`
+	// Command is run with sh -c in the workspace root.
+	Command string `yaml:"command"`
+	// Timeout bounds each call; 0 means 30 seconds.
+	Timeout time.Duration `yaml:"timeout"`
+	// MaxOutputBytes caps the stdout returned to the model; 0 means 64KB.
+	MaxOutputBytes int `yaml:"max_output_bytes"`
+
+	// Path is the manifest file the tool was loaded from.
+	Path string `yaml:"-"`
+}
+
+// LoadExecToolManifests reads the *.yaml, *.yml and *.json manifests in
+// dirs. A manifest in a later directory replaces one with the same tool name
+// in an earlier one. Missing directories are skipped; invalid manifests are
+// returned as errors alongside the valid ones.
+func LoadExecToolManifests(dirs ...string) ([]ExecToolManifest, []error) {
+	byName := make(map[string]ExecToolManifest)
+	var errs []error
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			switch strings.ToLower(filepath.Ext(entry.Name())) {
+			case ".yaml", ".yml", ".json":
+			default:
+				continue
+			}
+			if entry.IsDir() {
+				continue
+			}
+			manifest, err := loadExecToolManifest(filepath.Join(dir, entry.Name()))
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			byName[manifest.Name] = manifest
+		}
+	}
+
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	manifests := make([]ExecToolManifest, 0, len(names))
+	for _, name := range names {
+		manifests = append(manifests, byName[name])
+	}
+	return manifests, errs
+}
+
+// loadExecToolManifest reads and validates one manifest. JSON manifests are
+// parsed as YAML, which is a superset.
+func loadExecToolManifest(path string) (ExecToolManifest, error) {
+	var manifest ExecToolManifest
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return manifest, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		return manifest, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	manifest.Path = path
+	manifest.Name = strings.TrimSpace(manifest.Name)
+	switch {
+	case manifest.Name == "":
+		return manifest, fmt.Errorf("invalid %s: name is required", path)
+	case !execToolNamePattern.MatchString(manifest.Name):
+		return manifest, fmt.Errorf("invalid %s: name %q may only contain letters, digits, _ and -", path, manifest.Name)
+	case strings.TrimSpace(manifest.Command) == "":
+		return manifest, fmt.Errorf("invalid %s: command is required", path)
+	case manifest.Timeout < 0 || manifest.MaxOutputBytes < 0:
+		return manifest, fmt.Errorf("invalid %s: timeout and max_output_bytes must not be negative", path)
+	}
+	if manifest.Parameters == nil {
+		manifest.Parameters = map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+	}
+	return manifest, nil
+}
+
+// Definition returns the tool definition sent to the model.
+func (m ExecToolManifest) Definition() api.Tool {
+	var tool api.Tool
+	tool.Type = "function"
+	tool.Function.Name = m.Name
+	tool.Function.Description = m.Description
+	if tool.Function.Description == "" {
+		tool.Function.Description = "External tool defined in " + m.Path
+	}
+	tool.Function.Parameters = m.Parameters
+	return tool
+}
+
+// Run runs the tool's command in dir with args as JSON on stdin. It returns
+// stdout, truncated to the output limit. A failed or timed-out command is
+// an error that includes the tail of stderr.
+func (m ExecToolManifest) Run(ctx context.Context, dir string, args map[string]interface{}) (string, error) {
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	input, err := json.Marshal(args)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode arguments for %s: %w", m.Name, err)
+	}
+	timeout := m.Timeout
+	if timeout <= 0 {
+		timeout = defaultExecToolTimeout
+	}
+	maxOutput := m.MaxOutputBytes
+	if maxOutput <= 0 {
+		maxOutput = defaultExecToolMaxOutput
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	cmd := exec.CommandContext(runCtx, "sh", "-c", m.Command)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "LEDIT_TOOL_NAME="+m.Name, "LEDIT_TOOL_DIR="+filepath.Dir(m.Path))
+	cmd.Stdin = bytes.NewReader(input)
+	stdout := &cappedBuffer{limit: maxOutput}
+	stderr := &cappedBuffer{limit: execToolStderrLimit}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	err = cmd.Run()
+	if errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+		return "", fmt.Errorf("tool %s timed out after %s", m.Name, timeout)
+	}
+	if err != nil {
+		message := fmt.Sprintf("tool %s failed: %v", m.Name, err)
+		if text := strings.TrimSpace(stderr.buf.String()); text != "" {
+			message += "\n" + text
+		}
+		return "", errors.New(message)
+	}
+
+	output := stdout.buf.String()
+	if stdout.dropped > 0 {
+		output += fmt.Sprintf("\n... output truncated: %d more bytes over the %d byte limit", stdout.dropped, maxOutput)
+	}
+	return output, nil
+}
+
+// cappedBuffer keeps the first limit bytes written to it and counts the
+// rest, so a noisy tool cannot exhaust memory.
+type cappedBuffer struct {
+	buf     bytes.Buffer
+	limit   int
+	dropped int
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.buf.Len(); room < len(p) {
+		if room > 0 {
+			b.buf.Write(p[:room])
+		}
+		b.dropped += len(p) - max(room, 0)
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+// execToolDirs returns the user and workspace manifest directories, in
+// increasing precedence.
+func execToolDirs(workspaceRoot string) []string {
+	var dirs []string
+	if configDir, err := configuration.GetConfigDir(); err == nil {
+		dirs = append(dirs, filepath.Join(configDir, "tools"))
+	}
+	return append(dirs, filepath.Join(workspaceRoot, ExecToolsDir))
+}
+
+// registerExecTools registers the user's and the workspace's exec tools as
+// custom tools. Problems are reported as warnings so one broken manifest
+// does not stop the session.
+func (a *Agent) registerExecTools() {
+	root := a.currentWorkspaceRoot()
+	manifests, errs := LoadExecToolManifests(execToolDirs(root)...)
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "WARNING: exec tool skipped: %v\n", err)
+	}
+	for _, manifest := range manifests {
+		manifest := manifest
+		handler := func(ctx context.Context, a *Agent, args map[string]interface{}) (string, error) {
+			return manifest.Run(ctx, a.currentWorkspaceRoot(), args)
+		}
+		if err := a.RegisterCustomTool(manifest.Definition(), handler); err != nil {
+			fmt.Fprintf(os.Stderr, "WARNING: exec tool from %s skipped: %v\n", manifest.Path, err)
+		}
+	}
+}
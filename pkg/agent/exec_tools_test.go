@@ -0,0 +1,139 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tools "github.com/alantheprice/ledit/pkg/agent_tools"
+	"github.com/alantheprice/ledit/pkg/filesystem"
+)
+
+func writeExecToolManifest(t *testing.T, dir, file, content string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, file), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadExecToolManifests(t *testing.T) {
+	userDir := t.TempDir()
+	projectDir := t.TempDir()
+	writeExecToolManifest(t, userDir, "echo.yaml", "name: echo_args\ncommand: cat\n")
+	writeExecToolManifest(t, userDir, "stamp.json", `{"name": "stamp", "description": "Print a stamp", "command": "echo stamp",
+		"parameters": {"type": "object", "properties": {"label": {"type": "string"}}}}`)
+	writeExecToolManifest(t, projectDir, "echo.yml", "name: echo_args\ndescription: Project echo\ncommand: cat\ntimeout: 2s\n")
+	writeExecToolManifest(t, projectDir, "broken.yaml", "name: bad name\ncommand: true\n")
+	writeExecToolManifest(t, projectDir, "nocommand.yaml", "name: empty\n")
+	writeExecToolManifest(t, projectDir, "README.md", "not a manifest")
+
+	manifests, errs := LoadExecToolManifests(userDir, projectDir, filepath.Join(projectDir, "missing"))
+	if len(errs) != 2 {
+		t.Errorf("expected 2 errors, got %v", errs)
+	}
+	if len(manifests) != 2 {
+		t.Fatalf("expected 2 manifests, got %d", len(manifests))
+	}
+	echo := manifests[0]
+	if echo.Name != "echo_args" || echo.Description != "Project echo" || echo.Timeout != 2*time.Second {
+		t.Errorf("expected the project echo_args manifest to win, got %+v", echo)
+	}
+	if params, _ := echo.Definition().Function.Parameters.(map[string]interface{}); params["type"] != "object" {
+		t.Errorf("expected a default object schema, got %v", echo.Definition().Function.Parameters)
+	}
+	stamp := manifests[1].Definition()
+	if stamp.Type != "function" || stamp.Function.Name != "stamp" || stamp.Function.Description != "Print a stamp" {
+		t.Errorf("unexpected definition %+v", stamp)
+	}
+}
+
+func TestExecToolManifestRun(t *testing.T) {
+	dir := t.TempDir()
+	manifest := ExecToolManifest{Name: "echo_args", Command: "cat; echo; pwd", Path: filepath.Join(dir, "echo.yaml")}
+	output, err := manifest.Run(context.Background(), dir, map[string]interface{}{"id": "OPS-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(output, `{"id":"OPS-1"}`) || !strings.Contains(output, dir) {
+		t.Errorf("expected the JSON arguments and the working directory, got %q", output)
+	}
+
+	manifest.Command = "echo oops >&2; exit 3"
+	if _, err := manifest.Run(context.Background(), dir, nil); err == nil || !strings.Contains(err.Error(), "oops") {
+		t.Errorf("expected a failure including stderr, got %v", err)
+	}
+
+	manifest.Command = "sleep 5"
+	manifest.Timeout = 100 * time.Millisecond
+	if _, err := manifest.Run(context.Background(), dir, nil); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected a timeout, got %v", err)
+	}
+
+	manifest.Command = "printf 0123456789"
+	manifest.Timeout = 0
+	manifest.MaxOutputBytes = 4
+	output, err = manifest.Run(context.Background(), dir, nil)
+	if err != nil || !strings.HasPrefix(output, "0123\n") || !strings.Contains(output, "6 more bytes") {
+		t.Errorf("expected truncated output, got %q (%v)", output, err)
+	}
+}
+
+func TestRegisterExecTools(t *testing.T) {
+	t.Setenv("LEDIT_CONFIG", t.TempDir())
+	root := t.TempDir()
+	writeExecToolManifest(t, filepath.Join(root, ExecToolsDir), "greet.yaml", "name: greet\ncommand: \"echo hello\"\n")
+	writeExecToolManifest(t, filepath.Join(root, ExecToolsDir), "shadow.yaml", "name: shell_command\ncommand: \"true\"\n")
+
+	a := makeAgentWithScriptedClient(1, NewScriptedClient())
+	a.workspaceRoot = root
+	a.registerExecTools()
+
+	defs := a.customToolDefinitions()
+	if len(defs) != 1 || defs[0].Function.Name != "greet" {
+		t.Fatalf("expected only greet to be registered, got %+v", defs)
+	}
+	handler, ok := a.customToolHandler("greet")
+	if !ok {
+		t.Fatal("expected a handler for greet")
+	}
+	output, err := handler(context.Background(), a, nil)
+	if err != nil || strings.TrimSpace(output) != "hello" {
+		t.Errorf("handler() = %q, %v", output, err)
+	}
+}
+
+func TestExecToolWritesAreNotConflicts(t *testing.T) {
+	t.Setenv("LEDIT_CONFIG", t.TempDir())
+	root := t.TempDir()
+	t.Chdir(root)
+	path := filepath.Join(root, "notes.txt")
+	if err := os.WriteFile(path, []byte("draft\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	filesystem.Locks().ObserveFile(path)
+	writeExecToolManifest(t, filepath.Join(root, ExecToolsDir), "format.yaml", "name: format_notes\ncommand: \"printf 'formatted notes\\\\n' > notes.txt\"\n")
+
+	a := makeAgentWithScriptedClient(1, NewScriptedClient())
+	a.workspaceRoot = root
+	a.registerExecTools()
+	handler, ok := a.customToolHandler("format_notes")
+	if !ok {
+		t.Fatal("expected a handler for format_notes")
+	}
+	if _, err := handler(context.Background(), a, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tools.EditFile(context.Background(), path, "formatted", "final"); err != nil {
+		t.Fatalf("expected the tool's own write not to conflict, got %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "final notes\n" {
+		t.Errorf("unexpected content %q", data)
+	}
+}
@@ -0,0 +1,113 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/alantheprice/ledit/pkg/filesystem"
+	"github.com/alantheprice/ledit/pkg/git"
+)
+
+// File history limits: commits listed by default, and blame segments shown
+// before the rest are summarized.
+const (
+	fileHistoryDefaultCommits = 10
+	fileHistoryMaxSegments    = 80
+)
+
+// recentChangeAge is how recent a change must be for file_history to warn
+// against undoing it.
+const recentChangeAge = 30 * 24 * time.Hour
+
+// handleFileHistory reports the recent commits of a file and who last
+// changed each part of it or of a line range.
+func handleFileHistory(ctx context.Context, a *Agent, args map[string]interface{}) (string, error) {
+	path, err := getFilePath(args)
+	if err != nil {
+		return "", fmt.Errorf("failed to get file path: %w", err)
+	}
+	absPath, err := filesystem.SafeResolvePathWithBypass(ctx, path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve file path: %w", err)
+	}
+	if !isRegularFile(absPath) {
+		return "", fmt.Errorf("file does not exist: %s", absPath)
+	}
+	startLine := normalizePositiveInt(args["start_line"])
+	endLine := normalizePositiveInt(args["end_line"])
+	if endLine > 0 && startLine > endLine {
+		return "", fmt.Errorf("start_line %d is after end_line %d", startLine, endLine)
+	}
+	limit := fileHistoryDefaultCommits
+	if n := normalizePositiveInt(args["max_commits"]); n > 0 {
+		limit = n
+	}
+
+	history, err := git.GetFileHistory(filepath.Dir(absPath), filepath.Base(absPath), startLine, endLine, limit)
+	if err != nil {
+		return "", err
+	}
+	display := path
+	root := filesystem.WorkspaceRootFromContext(ctx)
+	if root == "" {
+		root = a.currentWorkspaceRoot()
+	}
+	if rel, err := filepath.Rel(root, absPath); err == nil && !strings.HasPrefix(rel, "..") {
+		display = filepath.ToSlash(rel)
+	}
+	return formatFileHistory(display, history, startLine, endLine, time.Now()), nil
+}
+
+// formatFileHistory lists the commits and blame segments of a file history,
+// flagging changes made in the last recentChangeAge.
+func formatFileHistory(path string, history *git.FileHistory, startLine, endLine int, now time.Time) string {
+	var b strings.Builder
+	recent := func(c git.HistoryCommit) string {
+		if !c.Date.IsZero() && now.Sub(c.Date) < recentChangeAge {
+			return " [recent]"
+		}
+		return ""
+	}
+
+	fmt.Fprintf(&b, "History of %s\n\nRecent commits (newest first):\n", path)
+	if len(history.Commits) == 0 {
+		b.WriteString("  (none: the file has not been committed)\n")
+	}
+	for _, c := range history.Commits {
+		fmt.Fprintf(&b, "  %s %s %s: %s%s\n", c.Hash, c.Date.Format("2006-01-02"), c.Author, c.Subject, recent(c))
+	}
+
+	scope := "the whole file"
+	if startLine > 0 || endLine > 0 {
+		scope = fmt.Sprintf("lines %d-%d", max(startLine, 1), endLine)
+		if endLine == 0 {
+			scope = fmt.Sprintf("lines %d-end", startLine)
+		}
+	}
+	fmt.Fprintf(&b, "\nLast change to each line of %s:\n", scope)
+	hasRecent := false
+	for i, seg := range history.Blame {
+		if i == fileHistoryMaxSegments {
+			fmt.Fprintf(&b, "  ... %d more segments; pass start_line and end_line to narrow the range\n", len(history.Blame)-i)
+			break
+		}
+		lines := fmt.Sprintf("%d-%d", seg.StartLine, seg.EndLine)
+		if seg.StartLine == seg.EndLine {
+			lines = fmt.Sprintf("%d", seg.StartLine)
+		}
+		if seg.Uncommitted {
+			fmt.Fprintf(&b, "  %-9s uncommitted changes\n", lines)
+			continue
+		}
+		mark := recent(seg.Commit)
+		hasRecent = hasRecent || mark != ""
+		fmt.Fprintf(&b, "  %-9s %s %s %s: %s%s\n", lines, seg.Commit.Hash, seg.Commit.Date.Format("2006-01-02"), seg.Commit.Author, seg.Commit.Subject, mark)
+	}
+	if hasRecent {
+		b.WriteString("\nLines marked [recent] were changed deliberately in the last 30 days; check the commit (git show <hash>) before reverting them.\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,123 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tools "github.com/alantheprice/ledit/pkg/agent_tools"
+	"github.com/alantheprice/ledit/pkg/console"
+)
+
+// maxAttachedImageSourceBytes caps the files /attach will read. Larger
+// sources than console.MaxPastedImageSize are downscaled before saving.
+const maxAttachedImageSourceBytes = 50 * 1024 * 1024
+
+// ImageAttachment is an image queued with /attach for the next query.
+type ImageAttachment struct {
+	Source     string // Path the user attached
+	SavedPath  string // Copy under .ledit/pasted-images, relative to the workspace
+	Bytes      int    // Size of the saved copy
+	Downscaled bool   // Whether the source was downscaled to fit the size cap
+}
+
+// AttachImage copies the image at path into the workspace's pasted-images
+// directory and queues it for the next query. Images over the per-image cap
+// are downscaled and recompressed first.
+func (a *Agent) AttachImage(path string) (ImageAttachment, error) {
+	root := a.currentWorkspaceRoot()
+	source := strings.TrimSpace(path)
+	if pasted, ok := console.PastedImagePath(source); ok {
+		source = pasted
+	} else if source = strings.Trim(source, `'"`); !filepath.IsAbs(source) {
+		source = filepath.Join(root, source)
+	}
+
+	info, err := os.Stat(source)
+	if err != nil {
+		return ImageAttachment{}, err
+	}
+	if !info.Mode().IsRegular() {
+		return ImageAttachment{}, fmt.Errorf("%s is not a file", path)
+	}
+	if info.Size() > maxAttachedImageSourceBytes {
+		return ImageAttachment{}, fmt.Errorf("%s is %d bytes, over the %d byte limit", path, info.Size(), maxAttachedImageSourceBytes)
+	}
+	data, err := os.ReadFile(source)
+	if err != nil {
+		return ImageAttachment{}, err
+	}
+	if ext, _ := console.DetectImageMagic(data); ext == "" {
+		return ImageAttachment{}, errors.New("unrecognised image format")
+	}
+
+	attachment := ImageAttachment{Source: source}
+	if len(data) > console.MaxPastedImageSize {
+		optimized, _, err := tools.OptimizeImageData(source, data)
+		if err != nil {
+			return ImageAttachment{}, fmt.Errorf("failed to downscale image: %w", err)
+		}
+		if len(optimized) > console.MaxPastedImageSize {
+			return ImageAttachment{}, fmt.Errorf("image is still %d bytes after downscaling, over the %d byte limit", len(optimized), console.MaxPastedImageSize)
+		}
+		data = optimized
+		attachment.Downscaled = true
+	}
+
+	saved, err := console.SavePastedImage(data, root)
+	if err != nil {
+		return ImageAttachment{}, err
+	}
+	attachment.SavedPath = saved
+	attachment.Bytes = len(data)
+
+	a.imageAttachmentsMu.Lock()
+	a.imageAttachments = append(a.imageAttachments, attachment)
+	a.imageAttachmentsMu.Unlock()
+	return attachment, nil
+}
+
+// PendingImageAttachments returns the images queued for the next query.
+func (a *Agent) PendingImageAttachments() []ImageAttachment {
+	a.imageAttachmentsMu.Lock()
+	defer a.imageAttachmentsMu.Unlock()
+	return append([]ImageAttachment(nil), a.imageAttachments...)
+}
+
+// ClearImageAttachments drops the queued images and returns how many there were.
+func (a *Agent) ClearImageAttachments() int {
+	a.imageAttachmentsMu.Lock()
+	defer a.imageAttachmentsMu.Unlock()
+	n := len(a.imageAttachments)
+	a.imageAttachments = nil
+	return n
+}
+
+// SupportsImageInput reports whether images are sent to the current model
+// directly. Otherwise the model gets the image paths and can use the image
+// analysis tools.
+func (a *Agent) SupportsImageInput() bool {
+	return a.client != nil && a.client.SupportsVision()
+}
+
+// takeImageAttachments appends the pasted-image placeholders for queued
+// attachments to query and clears the queue, so processImagesInQuery embeds
+// them exactly like pasted images.
+func (a *Agent) takeImageAttachments(query string) string {
+	a.imageAttachmentsMu.Lock()
+	attachments := a.imageAttachments
+	a.imageAttachments = nil
+	a.imageAttachmentsMu.Unlock()
+
+	if len(attachments) == 0 {
+		return query
+	}
+	var b strings.Builder
+	b.WriteString(query)
+	for _, attachment := range attachments {
+		fmt.Fprintf(&b, "\nPasted image saved to disk: %s", attachment.SavedPath)
+	}
+	return b.String()
+}
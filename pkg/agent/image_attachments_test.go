@@ -0,0 +1,63 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAttachImageQueuesPlaceholderForNextQuery(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "shot.png"), pngMagic, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	a := &Agent{workspaceRoot: root, client: &visionSupportingClient{supportsVision: true}}
+
+	attachment, err := a.AttachImage("shot.png")
+	if err != nil {
+		t.Fatalf("AttachImage: %v", err)
+	}
+	if attachment.Downscaled || attachment.Bytes != len(pngMagic) {
+		t.Errorf("unexpected attachment: %+v", attachment)
+	}
+	if _, err := a.AttachImage("missing.png"); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+	if _, err := a.AttachImage(filepath.Join(root, "shot.png")); err != nil {
+		t.Fatalf("AttachImage with absolute path: %v", err)
+	}
+	if got := len(a.PendingImageAttachments()); got != 2 {
+		t.Fatalf("expected 2 pending attachments, got %d", got)
+	}
+
+	query := a.takeImageAttachments("what is wrong with this layout?")
+	if !strings.Contains(query, "Pasted image saved to disk: "+attachment.SavedPath) {
+		t.Fatalf("query missing placeholder: %q", query)
+	}
+	if len(a.PendingImageAttachments()) != 0 {
+		t.Error("attachments should be consumed by the query")
+	}
+
+	images, cleaned, err := a.processImagesInQuery(query)
+	if err != nil {
+		t.Fatalf("processImagesInQuery: %v", err)
+	}
+	if len(images) != 2 || strings.Contains(cleaned, "Pasted image saved to disk") {
+		t.Errorf("expected 2 embedded images and a cleaned query, got %d images, %q", len(images), cleaned)
+	}
+}
+
+func TestAttachImageRejectsNonImages(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "notes.png"), []byte("not an image"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	a := &Agent{workspaceRoot: root}
+	if _, err := a.AttachImage("notes.png"); err == nil {
+		t.Fatal("expected an error for a file that is not an image")
+	}
+	if n := a.ClearImageAttachments(); n != 0 {
+		t.Errorf("expected nothing queued, got %d", n)
+	}
+}
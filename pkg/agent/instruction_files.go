@@ -0,0 +1,150 @@
+package agent
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alantheprice/ledit/pkg/configuration"
+)
+
+// InstructionFileNames are the per-directory instruction files, highest
+// precedence first. Only the first one present in a directory is loaded, so
+// a CLAUDE.md kept next to AGENTS.md for other tools is not included twice.
+var InstructionFileNames = []string{"LEDIT.md", "AGENTS.md", "CLAUDE.md", "Claude.md"}
+
+// Instruction file scopes, in increasing precedence.
+const (
+	InstructionScopeUser      = "user"
+	InstructionScopeProject   = "project"
+	InstructionScopeDirectory = "directory"
+)
+
+const (
+	maxNestedInstructionDepth = 4
+	maxInstructionPromptBytes = 50_000
+)
+
+// InstructionFile is an instruction file that applies to the session.
+type InstructionFile struct {
+	Path  string
+	Scope string
+	// Dir is the directory a directory-scoped file applies to, relative to
+	// the working directory.
+	Dir     string
+	Content string
+}
+
+// DiscoverInstructionFiles returns the instruction files for cwd in
+// increasing precedence: the user's file in the ledit config directory, one
+// per directory from the repository root down to cwd, then files in
+// subdirectories of cwd, which apply only to work under them. Outside a git
+// repository every parent directory is searched.
+func DiscoverInstructionFiles(cwd string) []InstructionFile {
+	var files []InstructionFile
+	if configDir, err := configuration.GetConfigDir(); err == nil {
+		if file, ok := readInstructionFile(configDir); ok {
+			file.Scope = InstructionScopeUser
+			files = append(files, file)
+		}
+	}
+
+	dirs := []string{cwd}
+	for dir := cwd; ; {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+		dirs = append(dirs, dir)
+	}
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if file, ok := readInstructionFile(dirs[i]); ok {
+			file.Scope = InstructionScopeProject
+			files = append(files, file)
+		}
+	}
+
+	return append(files, nestedInstructionFiles(cwd)...)
+}
+
+// nestedInstructionFiles finds instruction files in subdirectories of root,
+// shallowest first, skipping hidden, vendored and build directories.
+func nestedInstructionFiles(root string) []InstructionFile {
+	var files []InstructionFile
+	_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() || path == root {
+			return nil
+		}
+		rel, relErr := filepath.Rel(root, path)
+		if relErr != nil {
+			return filepath.SkipDir
+		}
+		name := d.Name()
+		if strings.HasPrefix(name, ".") || name == "node_modules" || name == "vendor" ||
+			name == "dist" || name == "build" || strings.Count(rel, string(filepath.Separator)) >= maxNestedInstructionDepth {
+			return filepath.SkipDir
+		}
+		if file, ok := readInstructionFile(path); ok {
+			file.Scope = InstructionScopeDirectory
+			file.Dir = filepath.ToSlash(rel)
+			files = append(files, file)
+		}
+		return nil
+	})
+	return files
+}
+
+// readInstructionFile reads the highest-precedence instruction file in dir.
+func readInstructionFile(dir string) (InstructionFile, bool) {
+	for _, name := range InstructionFileNames {
+		path := filepath.Join(dir, name)
+		content, err := os.ReadFile(path)
+		if err != nil || strings.TrimSpace(string(content)) == "" {
+			continue
+		}
+		return InstructionFile{Path: path, Content: strings.TrimSpace(string(content))}, true
+	}
+	return InstructionFile{}, false
+}
+
+// Describe summarizes where a file applies, e.g. "applies under pkg/api/".
+func (f InstructionFile) Describe() string {
+	switch f.Scope {
+	case InstructionScopeUser:
+		return "user instructions, all projects"
+	case InstructionScopeDirectory:
+		return "applies under " + f.Dir + "/"
+	}
+	return "project instructions"
+}
+
+// FormatInstructionFiles renders instruction files for the system prompt.
+// Files past the size budget are listed but not included.
+func FormatInstructionFiles(files []InstructionFile) string {
+	var sb strings.Builder
+	sb.WriteString("\n\n---\n\n## Project Instructions\n\n")
+	sb.WriteString("Follow the instructions below. They are ordered from most general to most specific; " +
+		"when two conflict, the later one wins. Instructions for a subdirectory apply only to work under that directory.\n\n")
+
+	written := 0
+	for i, file := range files {
+		entry := fmt.Sprintf("### `%s` (%s)\n\n%s\n\n", file.Path, file.Describe(), file.Content)
+		if written+len(entry) > maxInstructionPromptBytes {
+			sb.WriteString(fmt.Sprintf("*[%d instruction file(s) omitted — total size exceeded %d bytes:", len(files)-i, maxInstructionPromptBytes))
+			for _, omitted := range files[i:] {
+				sb.WriteString(" " + omitted.Path)
+			}
+			sb.WriteString("]*\n\n")
+			break
+		}
+		sb.WriteString(entry)
+		written += len(entry)
+	}
+	return sb.String()
+}
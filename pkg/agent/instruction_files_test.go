@@ -0,0 +1,53 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiscoverInstructionFilesPrecedence(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv("LEDIT_CONFIG", configDir)
+	writeTestFile(t, configDir, "LEDIT.md", "user rule")
+
+	repo := t.TempDir()
+	if err := os.Mkdir(filepath.Join(repo, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, repo, "AGENTS.md", "repo rule")
+	writeTestFile(t, repo, "CLAUDE.md", "duplicate repo rule")
+	writeTestFile(t, repo, "svc/LEDIT.md", "service rule")
+	writeTestFile(t, repo, "svc/api/AGENTS.md", "api rule")
+	writeTestFile(t, repo, "svc/node_modules/pkg/AGENTS.md", "ignored")
+
+	files := DiscoverInstructionFiles(filepath.Join(repo, "svc"))
+	var got []string
+	for _, f := range files {
+		got = append(got, f.Scope+":"+f.Content)
+	}
+	want := []string{"user:user rule", "project:repo rule", "project:service rule", "directory:api rule"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("instruction files = %v, want %v", got, want)
+	}
+	if files[3].Dir != "api" || files[3].Describe() != "applies under api/" {
+		t.Fatalf("unexpected nested file: %+v", files[3])
+	}
+
+	prompt := FormatInstructionFiles(files)
+	if !strings.Contains(prompt, "## Project Instructions") || strings.Index(prompt, "repo rule") > strings.Index(prompt, "api rule") {
+		t.Fatalf("unexpected prompt section: %s", prompt)
+	}
+}
+
+func TestFormatInstructionFilesOmitsPastBudget(t *testing.T) {
+	files := []InstructionFile{
+		{Path: "/repo/AGENTS.md", Scope: InstructionScopeProject, Content: strings.Repeat("a", maxInstructionPromptBytes)},
+		{Path: "/repo/sub/AGENTS.md", Scope: InstructionScopeDirectory, Dir: "sub", Content: "small"},
+	}
+	prompt := FormatInstructionFiles(files)
+	if !strings.Contains(prompt, "2 instruction file(s) omitted") || !strings.Contains(prompt, "/repo/sub/AGENTS.md") {
+		t.Fatalf("expected omitted files to be listed, got %q", prompt[:200])
+	}
+}
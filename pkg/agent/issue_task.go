@@ -0,0 +1,82 @@
+package agent
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	tools "github.com/alantheprice/ledit/pkg/agent_tools"
+	"github.com/alantheprice/ledit/pkg/forge"
+)
+
+// Turning tracker issues into agent tasks (ledit work-on)
+
+// issueChecklistItem matches an open Markdown task list item, "- [ ] step".
+var issueChecklistItem = regexp.MustCompile(`^\s*[-*+]\s+\[ \]\s+(.+)$`)
+
+// IssueTaskPrompt turns an issue and its discussion into the task for the
+// agent. name is how the issue is referred to, e.g. acme/app#12 or PROJ-12.
+func IssueTaskPrompt(name string, issue *forge.Issue) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Resolve issue %s: %s\n", name, issue.Title)
+	if issue.URL != "" {
+		fmt.Fprintf(&sb, "URL: %s\n", issue.URL)
+	}
+	if len(issue.Labels) > 0 {
+		fmt.Fprintf(&sb, "Labels: %s\n", strings.Join(issue.Labels, ", "))
+	}
+	body := strings.TrimSpace(issue.Body)
+	if body == "" {
+		body = "(no description)"
+	}
+	fmt.Fprintf(&sb, "\nDescription (by %s):\n%s\n", issue.Author, body)
+	if len(issue.Comments) > 0 {
+		sb.WriteString("\nDiscussion, oldest first. Later comments may refine or override the description:\n")
+		sb.WriteString(FormatPullRequestComments(issue.Comments))
+	}
+	sb.WriteString("\nThe todo list holds the steps taken from the issue. Work through them, keep the change focused on what the issue asks for, and verify it with the project's build and tests. If the issue is unclear or already resolved, stop and explain instead of guessing. Finish with a short summary of what changed.")
+	return sb.String()
+}
+
+// AddIssueTodos adds a pending todo for every open checklist item in the
+// issue description, or a single todo for the issue when it has none. Items
+// already on the todo list are skipped. It returns how many were added.
+func AddIssueTodos(name string, issue *forge.Issue) (int, error) {
+	var steps []string
+	for _, line := range strings.Split(issue.Body, "\n") {
+		if m := issueChecklistItem.FindStringSubmatch(line); m != nil {
+			steps = append(steps, strings.TrimSpace(m[1]))
+		}
+	}
+	if len(steps) == 0 {
+		steps = []string{"Resolve " + name + ": " + summarizeComment(issue.Title, 160)}
+	}
+
+	todos := tools.TodoRead()
+	existing := make(map[string]bool, len(todos))
+	for _, todo := range todos {
+		existing[todo.ID] = true
+	}
+	added := 0
+	for i, step := range steps {
+		id := fmt.Sprintf("issue-%s-%d", issue.Key, i+1)
+		if existing[id] {
+			continue
+		}
+		todos = append(todos, tools.TodoItem{
+			ID:       id,
+			Content:  summarizeComment(step, 200),
+			Status:   "pending",
+			Priority: "medium",
+			Source:   name,
+		})
+		added++
+	}
+	if added == 0 {
+		return 0, nil
+	}
+	if err := tools.UpdateTodos(todos); err != nil {
+		return 0, err
+	}
+	return added, nil
+}
@@ -281,6 +281,10 @@ func (a *Agent) executeMCPTool(toolName string, args map[string]interface{}) (st
 	serverName := parts[0]
 	actualToolName := parts[1]
 
+	if a.DryRun() {
+		return a.previewExternalTool(serverName+"/"+actualToolName, args), nil
+	}
+
 	ctx := context.Background()
 	result, err := a.mcpManager.CallTool(ctx, serverName, actualToolName, args)
 	if err != nil {
@@ -1,7 +1,6 @@
 package agent
 
 import (
-	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -14,22 +13,63 @@ import (
 
 const memoryDirName = "memories"
 
+// MemoryScope says where a memory is stored and which sessions see it.
+type MemoryScope string
+
+const (
+	// MemoryScopeUser memories live in ~/.ledit/memories/ and apply to every project
+	MemoryScopeUser MemoryScope = "user"
+	// MemoryScopeProject memories live in <workspace>/.ledit/memories/ and apply to that project only
+	MemoryScopeProject MemoryScope = "project"
+)
+
+// ParseMemoryScope parses a scope name. An empty name yields MemoryScopeProject.
+func ParseMemoryScope(name string) (MemoryScope, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "project", "workspace", "repo":
+		return MemoryScopeProject, nil
+	case "user", "global":
+		return MemoryScopeUser, nil
+	}
+	return "", fmt.Errorf("unknown memory scope %q: use project or user", name)
+}
+
 // MemoryInfo represents information about a memory file
 type MemoryInfo struct {
-	Name    string // Memory name, derived from filename (without .md extension)
-	Path    string // Full file path
-	Content string // File content string
+	Name    string      // Memory name, derived from filename (without .md extension)
+	Path    string      // Full file path
+	Content string      // File content string
+	Scope   MemoryScope // Where the memory is stored
 }
 
-// getMemoryDir returns the path to ~/.ledit/memories/
-// Creates the directory if it doesn't exist
-func getMemoryDir() string {
-	configDir, err := configuration.GetConfigDir()
-	if err != nil {
-		return ""
+// maxMemoryBytes caps a single memory. Memories are meant to be short facts
+// ("tests run with make check"), and the cap keeps one from crowding out the rest.
+const maxMemoryBytes = 8_000
+
+// getMemoryDir returns the directory for a scope: ~/.ledit/memories/ for user
+// memories and <projectRoot>/.ledit/memories/ for project memories.
+// Creates the user directory if it doesn't exist
+func getMemoryDir(scope MemoryScope, projectRoot string) string {
+	var memoryDir string
+	switch scope {
+	case MemoryScopeProject:
+		if strings.TrimSpace(projectRoot) == "" {
+			return ""
+		}
+		memoryDir = filepath.Join(projectRoot, ".ledit", memoryDirName)
+	default:
+		configDir, err := configuration.GetConfigDir()
+		if err != nil {
+			return ""
+		}
+		memoryDir = filepath.Join(configDir, memoryDirName)
 	}
 
-	memoryDir := filepath.Join(configDir, memoryDirName)
+	// Project memory directories are created on first save so reading
+	// memories does not add .ledit/ to every directory ledit runs in
+	if scope == MemoryScopeProject {
+		return memoryDir
+	}
 
 	// Check if directory exists
 	if _, err := os.Stat(memoryDir); os.IsNotExist(err) {
@@ -42,11 +82,19 @@ func getMemoryDir() string {
 	return memoryDir
 }
 
-// LoadAllMemories reads all .md files from the memories directory
-// Returns a slice of MemoryInfo sorted by filename
-// Returns empty slice (not error) if no memories exist
-func LoadAllMemories() ([]MemoryInfo, error) {
-	memoryDir := getMemoryDir()
+// memoryProjectRoot is the project used by callers without an agent: the
+// current working directory.
+func memoryProjectRoot() string {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	return cwd
+}
+
+// loadScopeMemories reads all .md files from one scope's memories directory
+func loadScopeMemories(scope MemoryScope, projectRoot string) ([]MemoryInfo, error) {
+	memoryDir := getMemoryDir(scope, projectRoot)
 	if memoryDir == "" {
 		return []MemoryInfo{}, nil
 	}
@@ -74,65 +122,108 @@ func LoadAllMemories() ([]MemoryInfo, error) {
 			continue // Skip files that can't be read
 		}
 
-		// Remove .md extension for the name
-		name := strings.TrimSuffix(entry.Name(), ".md")
-
 		memories = append(memories, MemoryInfo{
-			Name:    name,
+			Name:    strings.TrimSuffix(entry.Name(), ".md"),
 			Path:    filePath,
 			Content: string(content),
+			Scope:   scope,
 		})
 	}
+	return memories, nil
+}
+
+// LoadAllMemories reads the project's memories and the user's memories.
+// A project memory hides a user memory with the same name.
+// Returns project memories first, each scope sorted by name, and an empty
+// slice (not error) if no memories exist
+func LoadAllMemories(projectRoot string) ([]MemoryInfo, error) {
+	project, err := loadScopeMemories(MemoryScopeProject, projectRoot)
+	if err != nil {
+		return nil, err
+	}
+	user, err := loadScopeMemories(MemoryScopeUser, projectRoot)
+	if err != nil {
+		return nil, err
+	}
 
-	// Sort by filename (Name field)
-	sort.Slice(memories, func(i, j int) bool {
+	shadowed := make(map[string]bool, len(project))
+	for _, m := range project {
+		shadowed[m.Name] = true
+	}
+	memories := project
+	for _, m := range user {
+		if !shadowed[m.Name] {
+			memories = append(memories, m)
+		}
+	}
+
+	sort.SliceStable(memories, func(i, j int) bool {
+		if memories[i].Scope != memories[j].Scope {
+			return memories[i].Scope == MemoryScopeProject
+		}
 		return memories[i].Name < memories[j].Name
 	})
 
 	return memories, nil
 }
 
-// LoadMemoryContent reads a single memory file by name
-// The name should be without the .md extension (e.g., "git-safety" reads git-safety.md)
-func LoadMemoryContent(name string) (string, error) {
-	memoryDir := getMemoryDir()
-	if memoryDir == "" {
-		return "", errors.New("failed to get memory directory: unable to locate config directory")
+// FindMemory looks a memory up by name. An empty scope checks the project
+// first, then the user's memories.
+func FindMemory(projectRoot string, scope MemoryScope, name string) (MemoryInfo, error) {
+	name = sanitizeMemoryName(name)
+	scopes := []MemoryScope{MemoryScopeProject, MemoryScopeUser}
+	if scope != "" {
+		scopes = []MemoryScope{scope}
 	}
-
-	filePath := filepath.Join(memoryDir, name+".md")
-
-	content, err := os.ReadFile(filePath)
-	if err != nil {
-		return "", fmt.Errorf("failed to read memory file %q: %w", name, err)
+	for _, s := range scopes {
+		memoryDir := getMemoryDir(s, projectRoot)
+		if memoryDir == "" {
+			continue
+		}
+		filePath := filepath.Join(memoryDir, name+".md")
+		content, err := os.ReadFile(filePath)
+		if err == nil {
+			return MemoryInfo{Name: name, Path: filePath, Content: string(content), Scope: s}, nil
+		}
+		if !os.IsNotExist(err) {
+			return MemoryInfo{}, fmt.Errorf("failed to read memory file %q: %w", name, err)
+		}
 	}
-
-	return string(content), nil
+	return MemoryInfo{}, fmt.Errorf("memory %q does not exist", name)
 }
 
-// SaveMemory writes a memory file
+// SaveMemory writes a memory file in the given scope and returns its path.
 // Sanitizes the name: lowercase, replace spaces with hyphens, strip special chars
 // Keeps only alphanumeric, hyphens, and underscores
-func SaveMemory(name string, content string) error {
+func SaveMemory(projectRoot string, scope MemoryScope, name string, content string) (string, error) {
+	if len(content) > maxMemoryBytes {
+		return "", fmt.Errorf("memory is %d bytes; keep memories under %d bytes by saving one fact per memory", len(content), maxMemoryBytes)
+	}
+
 	// Sanitize the name
 	sanitized := sanitizeMemoryName(name)
 
-	memoryDir := getMemoryDir()
+	memoryDir := getMemoryDir(scope, projectRoot)
 	if memoryDir == "" {
-		return errors.New("failed to get memory directory: unable to locate config directory")
+		return "", fmt.Errorf("failed to get %s memory directory", scope)
 	}
 
+	if err := os.MkdirAll(memoryDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create memory directory: %w", err)
+	}
 	filePath := filepath.Join(memoryDir, sanitized+".md")
 
 	// Write the file
 	err := os.WriteFile(filePath, []byte(content), 0644)
 	if err != nil {
-		return fmt.Errorf("failed to write memory file %q: %w", sanitized, err)
+		return "", fmt.Errorf("failed to write memory file %q: %w", sanitized, err)
 	}
 
-	return nil
+	return filePath, nil
 }
 
+var memoryNameInvalidChars = regexp.MustCompile(`[^a-z0-9\-_]+`)
+
 // sanitizeMemoryName sanitizes a memory name
 // - Converts to lowercase
 // - Replaces spaces with hyphens
@@ -140,13 +231,13 @@ func SaveMemory(name string, content string) error {
 // - Ensures .md extension is not part of the name
 func sanitizeMemoryName(name string) string {
 	// Convert to lowercase
-	name = strings.ToLower(name)
+	name = strings.ToLower(strings.TrimSuffix(name, ".md"))
 
 	// Replace spaces with hyphens
 	name = strings.ReplaceAll(name, " ", "-")
 
 	// Keep only alphanumeric, hyphens, and underscores
-	matched := regexp.MustCompile(`[^a-z0-9\-_]+`).ReplaceAllString(name, "")
+	matched := memoryNameInvalidChars.ReplaceAllString(name, "")
 
 	// Remove leading/trailing hyphens and underscores
 	matched = strings.Trim(matched, "-_")
@@ -159,113 +250,195 @@ func sanitizeMemoryName(name string) string {
 	return matched
 }
 
-// DeleteMemory deletes a memory file by name (with .md extension)
-func DeleteMemory(name string) error {
-	memoryDir := getMemoryDir()
-	if memoryDir == "" {
-		return errors.New("failed to get memory directory: unable to locate config directory")
-	}
-
-	// Ensure .md extension
-	if !strings.HasSuffix(name, ".md") {
-		name = name + ".md"
-	}
-
-	filePath := filepath.Join(memoryDir, name)
-
-	// Check if file exists
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		return fmt.Errorf("memory file %q does not exist", name)
+// DeleteMemory deletes a memory file by name and returns the deleted memory.
+// An empty scope deletes the project memory if there is one, otherwise the user's.
+func DeleteMemory(projectRoot string, scope MemoryScope, name string) (MemoryInfo, error) {
+	memory, err := FindMemory(projectRoot, scope, name)
+	if err != nil {
+		return MemoryInfo{}, err
 	}
 
 	// Delete the file
-	err := os.Remove(filePath)
-	if err != nil {
-		return fmt.Errorf("failed to delete memory file %q: %w", name, err)
+	if err := os.Remove(memory.Path); err != nil {
+		return MemoryInfo{}, fmt.Errorf("failed to delete memory file %q: %w", memory.Name, err)
 	}
 
-	return nil
+	return memory, nil
 }
 
 // ListMemories returns list of all memories with their name, path, and first line (title/heading)
-// Sorts alphabetically by name
-func ListMemories() ([]MemoryInfo, error) {
-	memories, err := LoadAllMemories()
+func ListMemories(projectRoot string) ([]MemoryInfo, error) {
+	memories, err := LoadAllMemories(projectRoot)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load memories: %w", err)
 	}
 
 	// Extract first line (title) for each memory
 	for i := range memories {
-		lines := strings.Split(memories[i].Content, "\n")
-		if len(lines) > 0 {
-			// Get the first non-empty line
-			for _, line := range lines {
-				if strings.TrimSpace(line) != "" {
-					memories[i].Content = strings.TrimSpace(line)
-					break
-				}
-			}
-		}
+		memories[i].Content = memoryTitle(memories[i].Content)
 	}
 
-	// Sort alphabetically by name
-	sort.Slice(memories, func(i, j int) bool {
-		return memories[i].Name < memories[j].Name
-	})
-
 	return memories, nil
 }
 
+// memoryTitle returns the first non-empty line of a memory without heading markers
+func memoryTitle(content string) string {
+	for _, line := range strings.Split(content, "\n") {
+		if strings.TrimSpace(line) != "" {
+			return strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "# "))
+		}
+	}
+	return ""
+}
+
 // maxMemoryPromptBytes is the maximum number of bytes of memories included in the system prompt.
 // ~50 KB keeps memory useful while preventing individual oversized files from consuming the
 // entire context window before any conversation messages are added (~12 500 tokens at 4 chars/tok).
 const maxMemoryPromptBytes = 50_000
 
-// LoadMemoriesForPrompt loads all memories and formats them for inclusion in the system prompt
+// maxQueryMemoryBytes is the budget for memories retrieved for a single query
+// when they did not fit in the system prompt.
+const maxQueryMemoryBytes = 8_000
+
+// LoadMemoriesForPrompt loads the project's and user's memories and formats them for inclusion in the system prompt
 // Returns empty string if no memories exist
 func LoadMemoriesForPrompt() string {
-	memories, err := LoadAllMemories()
+	memories, err := LoadAllMemories(memoryProjectRoot())
 	if err != nil || len(memories) == 0 {
 		return ""
 	}
 
+	included, omitted := selectMemoriesWithinBudget(memories, maxMemoryPromptBytes)
+
 	var sb strings.Builder
 
 	sb.WriteString("\n\n---\n\n")
 	sb.WriteString("## Memories\n\n")
-	sb.WriteString("The following memories capture user preferences and learned patterns from previous sessions. Use them to guide your behavior.\n\n")
+	sb.WriteString("The following memories capture user preferences and learned patterns from previous sessions. Use them to guide your behavior. Project memories take precedence over user memories.\n\n")
 
+	for _, memory := range included {
+		sb.WriteString(formatMemoryEntry(memory))
+	}
+	if omitted > 0 {
+		sb.WriteString(fmt.Sprintf("*[%d additional memory file(s) omitted — total size exceeded %d bytes. Memories relevant to each request are added to it; use list_memories and read_memory for the rest]*\n\n",
+			omitted, maxMemoryPromptBytes))
+	}
+
+	return sb.String()
+}
+
+// selectMemoriesWithinBudget returns the leading memories whose entries fit in
+// budget bytes, and how many were left out.
+func selectMemoriesWithinBudget(memories []MemoryInfo, budget int) ([]MemoryInfo, int) {
 	bytesWritten := 0
 	for i, memory := range memories {
-		header := fmt.Sprintf("### %s\n", memory.Name)
+		size := len(formatMemoryEntry(memory))
+		if bytesWritten+size > budget {
+			return memories[:i], len(memories) - i
+		}
+		bytesWritten += size
+	}
+	return memories, 0
+}
 
-		// Process content: skip leading H1 title if present
-		content := memory.Content
-		lines := strings.Split(content, "\n")
+// formatMemoryEntry renders a memory as a prompt section, dropping a leading H1
+// title since the section heading already names the memory.
+func formatMemoryEntry(memory MemoryInfo) string {
+	lines := strings.Split(memory.Content, "\n")
+	if len(lines) > 0 && strings.HasPrefix(strings.TrimSpace(lines[0]), "# ") {
+		lines = lines[1:]
+	}
+	return fmt.Sprintf("### %s (%s)\n%s\n\n", memory.Name, memory.Scope, strings.Join(lines, "\n"))
+}
 
-		startIdx := 0
-		if len(lines) > 0 {
-			firstLine := strings.TrimSpace(lines[0])
-			if strings.HasPrefix(firstLine, "# ") {
-				startIdx = 1
-			}
-		}
+// memoryStopWords are ignored when matching a query against memories.
+var memoryStopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "this": true, "that": true, "from": true,
+	"are": true, "was": true, "you": true, "can": true, "please": true, "into": true, "use": true,
+	"how": true, "what": true, "why": true, "when": true, "make": true, "add": true, "all": true,
+}
+
+var memoryTermPattern = regexp.MustCompile(`[a-z0-9_]+`)
 
-		body := ""
-		if startIdx < len(lines) {
-			body = strings.Join(lines[startIdx:], "\n")
+// memoryTerms splits text into lowercase words of three or more characters.
+func memoryTerms(text string) map[string]bool {
+	terms := make(map[string]bool)
+	for _, term := range memoryTermPattern.FindAllString(strings.ToLower(text), -1) {
+		if len(term) >= 3 && !memoryStopWords[term] {
+			terms[term] = true
 		}
+	}
+	return terms
+}
 
-		entry := header + body + "\n\n"
-		if bytesWritten+len(entry) > maxMemoryPromptBytes {
-			sb.WriteString(fmt.Sprintf("*[%d additional memory file(s) omitted — total size exceeded %d bytes]*\n\n",
-				len(memories)-i, maxMemoryPromptBytes))
-			break
+// RankMemories orders memories by relevance to query: each query word found
+// in a memory's name scores 2 and each found in its content scores 1, with
+// ties going to project memories. Memories that share no words with the query
+// are dropped.
+func RankMemories(memories []MemoryInfo, query string) []MemoryInfo {
+	queryTerms := memoryTerms(query)
+	if len(queryTerms) == 0 {
+		return nil
+	}
+
+	type scored struct {
+		memory MemoryInfo
+		score  int
+	}
+	var ranked []scored
+	for _, memory := range memories {
+		nameTerms := memoryTerms(strings.ReplaceAll(memory.Name, "-", " "))
+		contentTerms := memoryTerms(memory.Content)
+		score := 0
+		for term := range queryTerms {
+			if nameTerms[term] {
+				score += 2
+			}
+			if contentTerms[term] {
+				score++
+			}
+		}
+		if score > 0 {
+			ranked = append(ranked, scored{memory, score})
 		}
-		sb.WriteString(entry)
-		bytesWritten += len(entry)
 	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].score != ranked[j].score {
+			return ranked[i].score > ranked[j].score
+		}
+		return ranked[i].memory.Scope == MemoryScopeProject && ranked[j].memory.Scope != MemoryScopeProject
+	})
+
+	result := make([]MemoryInfo, len(ranked))
+	for i, r := range ranked {
+		result[i] = r.memory
+	}
+	return result
+}
 
+// relevantMemoriesForQuery returns a block of the memories most relevant to
+// query among those left out of the system prompt, or "" when every memory
+// is already there or none match.
+func relevantMemoriesForQuery(projectRoot, query string) string {
+	memories, err := LoadAllMemories(projectRoot)
+	if err != nil {
+		return ""
+	}
+	_, omitted := selectMemoriesWithinBudget(memories, maxMemoryPromptBytes)
+	if omitted == 0 {
+		return ""
+	}
+
+	relevant, _ := selectMemoriesWithinBudget(RankMemories(memories[len(memories)-omitted:], query), maxQueryMemoryBytes)
+	if len(relevant) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\n\n<relevant_memories>\n")
+	for _, memory := range relevant {
+		sb.WriteString(formatMemoryEntry(memory))
+	}
+	sb.WriteString("</relevant_memories>")
 	return sb.String()
 }
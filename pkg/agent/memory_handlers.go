@@ -18,12 +18,22 @@ func handleAddMemory(ctx context.Context, a *Agent, args map[string]interface{})
 		return "", fmt.Errorf("content is required: %w", err)
 	}
 
-	if err := SaveMemory(name, content); err != nil {
+	rawScope, _ := args["scope"].(string)
+	scope, err := ParseMemoryScope(rawScope)
+	if err != nil {
+		return "", err
+	}
+
+	path, err := SaveMemory(a.currentWorkspaceRoot(), scope, name, content)
+	if err != nil {
 		return "", fmt.Errorf("failed to save memory: %w", err)
 	}
 
 	sanitized := sanitizeMemoryName(name)
-	return fmt.Sprintf("Memory '%s' saved to ~/.ledit/memories/%s.md. This memory will be loaded in all future conversations.", sanitized, sanitized), nil
+	if scope == MemoryScopeUser {
+		return fmt.Sprintf("User memory '%s' saved to %s. This memory will be loaded in all future conversations.", sanitized, path), nil
+	}
+	return fmt.Sprintf("Project memory '%s' saved to %s. This memory will be loaded in future conversations in this project.", sanitized, path), nil
 }
 
 // handleReadMemory reads and returns the content of a specific memory
@@ -33,17 +43,24 @@ func handleReadMemory(ctx context.Context, a *Agent, args map[string]interface{}
 		return "", fmt.Errorf("name is required: %w", err)
 	}
 
-	content, err := LoadMemoryContent(name)
+	var scope MemoryScope
+	if raw, _ := args["scope"].(string); raw != "" {
+		if scope, err = ParseMemoryScope(raw); err != nil {
+			return "", err
+		}
+	}
+
+	memory, err := FindMemory(a.currentWorkspaceRoot(), scope, name)
 	if err != nil {
 		return "", fmt.Errorf("failed to read memory '%s': %w", name, err)
 	}
 
-	return fmt.Sprintf("## Memory: %s\n\n%s", name, content), nil
+	return fmt.Sprintf("## Memory: %s (%s)\n\n%s", memory.Name, memory.Scope, memory.Content), nil
 }
 
 // handleListMemories returns a formatted list of all saved memories
 func handleListMemories(ctx context.Context, a *Agent, args map[string]interface{}) (string, error) {
-	memories, err := ListMemories()
+	memories, err := ListMemories(a.currentWorkspaceRoot())
 	if err != nil {
 		return "", fmt.Errorf("failed to list memories: %w", err)
 	}
@@ -61,10 +78,7 @@ func handleListMemories(ctx context.Context, a *Agent, args map[string]interface
 		if len(title) > 120 {
 			title = title[:117] + "..."
 		}
-		// Strip leading markdown heading markers for cleaner display
-		title = strings.TrimLeft(title, "# ")
-		title = strings.TrimSpace(title)
-		sb.WriteString(fmt.Sprintf("- **%s** — %s\n", m.Name, title))
+		sb.WriteString(fmt.Sprintf("- **%s** (%s) — %s\n", m.Name, m.Scope, title))
 	}
 
 	sb.WriteString("\nUse `read_memory` to view full content, or `add_memory`/`delete_memory` to manage memories.")
@@ -78,12 +92,17 @@ func handleDeleteMemory(ctx context.Context, a *Agent, args map[string]interface
 		return "", fmt.Errorf("name is required: %w", err)
 	}
 
-	// Strip .md extension if provided
-	name = strings.TrimSuffix(name, ".md")
+	var scope MemoryScope
+	if raw, _ := args["scope"].(string); raw != "" {
+		if scope, err = ParseMemoryScope(raw); err != nil {
+			return "", err
+		}
+	}
 
-	if err := DeleteMemory(name); err != nil {
+	memory, err := DeleteMemory(a.currentWorkspaceRoot(), scope, name)
+	if err != nil {
 		return "", fmt.Errorf("failed to delete memory '%s': %w", name, err)
 	}
 
-	return fmt.Sprintf("Memory '%s' deleted.", name), nil
+	return fmt.Sprintf("Memory '%s' (%s) deleted.", memory.Name, memory.Scope), nil
 }
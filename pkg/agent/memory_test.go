@@ -0,0 +1,100 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMemoryScopes(t *testing.T) {
+	t.Setenv("LEDIT_CONFIG", t.TempDir())
+	project := t.TempDir()
+
+	if _, err := SaveMemory(project, MemoryScopeUser, "Logging", "# Logging\nWe use zap for logging\n"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SaveMemory(project, MemoryScopeUser, "editor", "Prefers tabs\n"); err != nil {
+		t.Fatal(err)
+	}
+	path, err := SaveMemory(project, MemoryScopeProject, "logging", "This repo uses slog\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != filepath.Join(project, ".ledit", "memories", "logging.md") {
+		t.Fatalf("project memory saved to %s", path)
+	}
+
+	memories, err := LoadAllMemories(project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, m := range memories {
+		got = append(got, string(m.Scope)+":"+m.Name)
+	}
+	if strings.Join(got, ",") != "project:logging,user:editor" {
+		t.Fatalf("memories = %v, want the project memory to hide the user one", got)
+	}
+
+	deleted, err := DeleteMemory(project, "", "logging")
+	if err != nil || deleted.Scope != MemoryScopeProject {
+		t.Fatalf("DeleteMemory = %+v, %v; want the project memory deleted first", deleted, err)
+	}
+	memory, err := FindMemory(project, "", "logging")
+	if err != nil || memory.Scope != MemoryScopeUser {
+		t.Fatalf("FindMemory = %+v, %v; want the user memory once the project one is gone", memory, err)
+	}
+
+	if _, err := SaveMemory(project, MemoryScopeProject, "huge", strings.Repeat("x", maxMemoryBytes+1)); err == nil {
+		t.Fatal("expected oversized memory to be rejected")
+	}
+}
+
+func TestMemoriesDoNotCreateProjectDirOnRead(t *testing.T) {
+	t.Setenv("LEDIT_CONFIG", t.TempDir())
+	project := t.TempDir()
+	if _, err := LoadAllMemories(project); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(project, ".ledit")); !os.IsNotExist(err) {
+		t.Fatal("reading memories should not create .ledit in the project")
+	}
+}
+
+func TestRankMemories(t *testing.T) {
+	memories := []MemoryInfo{
+		{Name: "editor", Content: "Prefers tabs", Scope: MemoryScopeUser},
+		{Name: "test-command", Content: "Tests are run with make check", Scope: MemoryScopeProject},
+		{Name: "logging", Content: "We use zap; tests assert on log output", Scope: MemoryScopeUser},
+	}
+	ranked := RankMemories(memories, "Why are the tests failing?")
+	if len(ranked) != 2 || ranked[0].Name != "test-command" || ranked[1].Name != "logging" {
+		t.Fatalf("unexpected ranking: %+v", ranked)
+	}
+	if RankMemories(memories, "the and for") != nil {
+		t.Fatal("stop words alone should match nothing")
+	}
+}
+
+func TestRelevantMemoriesForQueryOnlyAddsOverflow(t *testing.T) {
+	t.Setenv("LEDIT_CONFIG", t.TempDir())
+	project := t.TempDir()
+	if _, err := SaveMemory(project, MemoryScopeProject, "test-command", "Tests are run with make check\n"); err != nil {
+		t.Fatal(err)
+	}
+	if got := relevantMemoriesForQuery(project, "run the tests"); got != "" {
+		t.Fatalf("memories already in the system prompt should not be repeated, got %q", got)
+	}
+
+	filler := strings.Repeat("padding ", maxMemoryBytes/9)
+	for i := 0; i < maxMemoryPromptBytes/len(filler)+1; i++ {
+		if _, err := SaveMemory(project, MemoryScopeProject, "aaa-filler-"+string(rune('a'+i)), filler); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got := relevantMemoriesForQuery(project, "run the tests")
+	if !strings.Contains(got, "<relevant_memories>") || !strings.Contains(got, "make check") {
+		t.Fatalf("expected the overflowed test-command memory, got %q", got)
+	}
+}
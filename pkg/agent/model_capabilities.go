@@ -0,0 +1,80 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	api "github.com/alantheprice/ledit/pkg/agent_api"
+	modelsettings "github.com/alantheprice/ledit/pkg/model_settings"
+)
+
+// smallContextWindowTokens is the context size below which agentic sessions
+// compact so often that a warning is worthwhile.
+const smallContextWindowTokens = 32000
+
+// GetModelCapabilities resolves the capability registry entry for the current
+// provider and model, applying model_capabilities overrides and pricing.models
+// prices from config.
+func (a *Agent) GetModelCapabilities() modelsettings.ModelCapabilities {
+	var caps modelsettings.ModelCapabilities
+	if cfg := a.GetConfig(); cfg != nil {
+		caps = cfg.ResolveModelCapabilities(a.GetProvider(), a.GetModel())
+	} else {
+		caps = modelsettings.ResolveModelCapabilities(a.GetProvider(), a.GetModel())
+	}
+	// The provider's own vision detection is authoritative when it says yes.
+	if a.client != nil && a.client.SupportsVision() {
+		caps.Vision = true
+	}
+	return caps
+}
+
+// warnModelCapabilityGaps tells the user once per model when it lacks a
+// capability the agent relies on.
+func (a *Agent) warnModelCapabilityGaps() {
+	model := a.GetModel()
+	key := a.GetProvider() + "/" + model
+	if a.capabilityWarned == key {
+		return
+	}
+	a.capabilityWarned = key
+
+	caps := a.GetModelCapabilities()
+	if !caps.NativeTools {
+		a.PrintLine(fmt.Sprintf("[WARN] Model %s does not support native tool calls; tools are described in the prompt and parsed from its replies, which is less reliable.", model))
+	}
+	if caps.ContextWindow > 0 && caps.ContextWindow < smallContextWindowTokens {
+		a.PrintLine(fmt.Sprintf("[WARN] Model %s has a %d-token context window; long sessions will be compacted frequently.", model, caps.ContextWindow))
+	}
+}
+
+// withTextToolInstructions describes tools in the system prompt for models
+// without native tool calling. Their replies are turned into tool calls by
+// the fallback parser.
+func withTextToolInstructions(messages []api.Message, tools []api.Tool) []api.Message {
+	instructions := textToolInstructions(tools)
+	out := append([]api.Message(nil), messages...)
+	if len(out) > 0 && out[0].Role == "system" {
+		out[0].Content = out[0].Content + "\n\n---\n\n" + instructions
+		return out
+	}
+	return append([]api.Message{{Role: "system", Content: instructions}}, out...)
+}
+
+// textToolInstructions describes tools and the reply format for calling them.
+func textToolInstructions(tools []api.Tool) string {
+	var sb strings.Builder
+	sb.WriteString("## Tool Calling\n\n")
+	sb.WriteString("Native tool calling is unavailable. To call a tool, reply with one JSON object per call wrapped in <tool_call> tags, then stop and wait for the result:\n\n")
+	sb.WriteString("<tool_call>\n{\"name\": \"read_file\", \"arguments\": {\"path\": \"main.go\"}}\n</tool_call>\n\n")
+	sb.WriteString("Available tools:\n")
+	for _, tool := range tools {
+		params, err := json.Marshal(tool.Function.Parameters)
+		if err != nil {
+			params = []byte("{}")
+		}
+		fmt.Fprintf(&sb, "- %s: %s\n  parameters: %s\n", tool.Function.Name, tool.Function.Description, params)
+	}
+	return sb.String()
+}
@@ -0,0 +1,85 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	api "github.com/alantheprice/ledit/pkg/agent_api"
+	"github.com/alantheprice/ledit/pkg/configuration"
+	modelsettings "github.com/alantheprice/ledit/pkg/model_settings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithTextToolInstructions_AppendsToSystemPrompt(t *testing.T) {
+	messages := []api.Message{
+		{Role: "system", Content: "You are a coding agent."},
+		{Role: "user", Content: "hi"},
+	}
+	var tool api.Tool
+	tool.Type = "function"
+	tool.Function.Name = "read_file"
+	tool.Function.Description = "Read a file"
+	tool.Function.Parameters = map[string]interface{}{"type": "object"}
+
+	out := withTextToolInstructions(messages, []api.Tool{tool})
+
+	require.Len(t, out, 2)
+	assert.Contains(t, out[0].Content, "You are a coding agent.")
+	assert.Contains(t, out[0].Content, "## Tool Calling")
+	assert.Contains(t, out[0].Content, "- read_file: Read a file")
+	assert.Equal(t, "You are a coding agent.", messages[0].Content, "input slice must not be mutated")
+}
+
+func TestGetModelCapabilities_ConfigOverride(t *testing.T) {
+	native := false
+	cfg := configuration.NewConfig()
+	cfg.ModelCapabilities = []modelsettings.CapabilityProfile{
+		{ID: "local", MatchExact: []string{"my-local-model"}, NativeTools: &native, ContextWindow: 8192},
+	}
+
+	client := NewScriptedClient()
+	require.NoError(t, client.SetModel("my-local-model"))
+	agent := makeAgentWithScriptedClient(1, client)
+	agent.configManager = configuration.NewManagerWithConfig(cfg, nil)
+
+	caps := agent.GetModelCapabilities()
+	assert.True(t, caps.Known)
+	assert.False(t, caps.NativeTools)
+	assert.Equal(t, 8192, caps.ContextWindow)
+}
+
+// TestE2E_TextToolCallingForModelsWithoutNativeTools verifies that a model
+// without native tool support gets tools described in the prompt and that
+// its text tool calls are still executed.
+func TestE2E_TextToolCallingForModelsWithoutNativeTools(t *testing.T) {
+	tempFile := filepath.Join(t.TempDir(), "notes.txt")
+	require.NoError(t, os.WriteFile(tempFile, []byte("remember the milk"), 0o644))
+
+	toolReply := NewScriptedResponseBuilder().
+		Content(fmt.Sprintf("<tool_call>\n{\"name\": \"read_file\", \"arguments\": {\"path\": %q}}\n</tool_call>", tempFile)).
+		FinishReason("stop").
+		Build()
+
+	agent, _, client := buildE2EAgentWithClient(t, 10, toolReply, stopResponse())
+	require.NoError(t, client.SetModel("gemma-3-12b"))
+
+	_, err := agent.ProcessQuery("What is in notes.txt?")
+	require.NoError(t, err)
+
+	sent := client.GetSentRequests()
+	require.NotEmpty(t, sent)
+	require.Equal(t, "system", sent[0][0].Role)
+	assert.Contains(t, sent[0][0].Content, "## Tool Calling")
+
+	found := false
+	for _, msg := range findToolMessages(agent.messages) {
+		if strings.Contains(msg.Content, "remember the milk") {
+			found = true
+		}
+	}
+	assert.True(t, found, "text tool call should have been executed")
+}
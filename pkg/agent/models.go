@@ -192,7 +192,7 @@ func (a *Agent) SetProvider(provider api.ClientType) error {
 	}
 
 	// Switch to the new client
-	a.client = newClient
+	a.client = a.wrapRecordingClient(newClient)
 	a.clientType = provider
 
 	// Get the actual model being used (might be different due to fallback)
@@ -280,7 +280,7 @@ func (a *Agent) SetProviderPersisted(provider api.ClientType) error {
 	}
 
 	// Switch to the new client
-	a.client = newClient
+	a.client = a.wrapRecordingClient(newClient)
 	a.clientType = provider
 
 	// Get the actual model being used (might be different due to fallback)
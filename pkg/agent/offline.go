@@ -0,0 +1,40 @@
+package agent
+
+import (
+	"fmt"
+
+	api "github.com/alantheprice/ledit/pkg/agent_api"
+	"github.com/alantheprice/ledit/pkg/configuration"
+)
+
+// networkTools are the tools that need the network. In offline mode they are
+// not offered to the model and calls to them fail at once.
+var networkTools = map[string]bool{
+	"web_search": true,
+	"fetch_url":  true,
+	"browse_url": true,
+	"pr":         true,
+}
+
+// Offline reports whether the agent runs without the network (--offline).
+func (a *Agent) Offline() bool {
+	return configuration.IsOffline()
+}
+
+// offlineToolError is returned for calls to network tools in offline mode.
+// It tells the model to carry on with what is available locally.
+func offlineToolError(toolName string) error {
+	return fmt.Errorf("%s needs the network, and ledit is running offline (--offline). Continue with the files in the workspace and local tools, and tell the user what could not be checked", toolName)
+}
+
+// withoutNetworkTools drops the tools that need the network.
+func withoutNetworkTools(tools []api.Tool) []api.Tool {
+	filtered := make([]api.Tool, 0, len(tools))
+	for _, tool := range tools {
+		if networkTools[tool.Function.Name] {
+			continue
+		}
+		filtered = append(filtered, tool)
+	}
+	return filtered
+}
@@ -0,0 +1,26 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/alantheprice/ledit/pkg/configuration"
+)
+
+func TestOfflineBlocksNetworkTools(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv(configuration.OfflineEnv, "1")
+
+	agent := newTestAgent(t)
+	for _, tool := range agent.getOptimizedToolDefinitions(nil) {
+		if networkTools[tool.Function.Name] {
+			t.Errorf("%s offered to the model offline", tool.Function.Name)
+		}
+	}
+
+	_, _, err := GetToolRegistry().ExecuteTool(context.Background(), "web_search", map[string]interface{}{"query": "go generics"}, agent)
+	if err == nil || !strings.Contains(err.Error(), "running offline") {
+		t.Fatalf("expected an offline error, got %v", err)
+	}
+}
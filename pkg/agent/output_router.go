@@ -6,7 +6,9 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/alantheprice/ledit/pkg/console"
 	"github.com/alantheprice/ledit/pkg/events"
+	"golang.org/x/term"
 )
 
 // OutputMode determines how output is routed
@@ -34,6 +36,8 @@ type OutputRouter struct {
 	eventBus                 *events.EventBus
 	agent                    *Agent
 	reasoningTerminalEnabled bool
+	markdown                 *console.StreamingFormatter // renders streamed text on a terminal; nil for plain output
+	markdownChecked          bool
 }
 
 // NewOutputRouter creates an output router.
@@ -150,16 +154,60 @@ func (r *OutputRouter) RouteStreamChunk(chunk string, contentType string) {
 			mu.Lock()
 			defer mu.Unlock()
 		}
+		console.RecordOutput(chunk)
 		callback(chunk)
 		return
 	}
 
 	// Non-streaming terminal fallback: only write assistant text
 	if contentType != "reasoning" {
+		console.RecordOutput(chunk)
+		if markdown := r.markdownFormatter(); markdown != nil {
+			markdown.Write(chunk)
+			return
+		}
 		fmt.Print(chunk)
 	}
 }
 
+// markdownFormatter returns the formatter for rendering streamed text, or nil
+// when it should be printed as is: when stdout is not a terminal, in CI, or
+// when plain_output is configured.
+func (r *OutputRouter) markdownFormatter() *console.StreamingFormatter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.markdownChecked {
+		return r.markdown
+	}
+	r.markdownChecked = true
+
+	if isCIOutput() || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return nil
+	}
+	if agent := r.agent; agent != nil && agent.configManager != nil {
+		if cfg := agent.configManager.GetConfig(); cfg != nil && cfg.PlainOutput {
+			return nil
+		}
+	}
+	r.markdown = console.NewStreamingFormatter(os.Stdout)
+	return r.markdown
+}
+
+// FlushStream writes out any streamed text the markdown renderer is still
+// holding, such as an unfinished line or table. Call it when a response ends.
+func (r *OutputRouter) FlushStream() {
+	r.mu.RLock()
+	markdown := r.markdown
+	r.mu.RUnlock()
+	if markdown != nil {
+		markdown.Flush()
+	}
+}
+
+func isCIOutput() bool {
+	return os.Getenv("LEDIT_CI_MODE") == "1" || os.Getenv("CI") != "" || os.Getenv("GITHUB_ACTIONS") != ""
+}
+
 // RouteAgentMessage routes an agent system message.
 // category: "info", "warning", "error", "tool_log", "thought"
 // RouteAgentMessage routes a message for display in both the WebUI and terminal.
@@ -187,6 +235,9 @@ func (r *OutputRouter) writeTerminalMessage(message string) {
 		return
 	}
 
+	// Finish any partially rendered response line before writing over it
+	r.FlushStream()
+
 	// Ensure newline
 	if !strings.HasSuffix(message, "\n") {
 		message += "\n"
@@ -202,6 +253,7 @@ func (r *OutputRouter) writeTerminalMessage(message string) {
 		mu.Lock()
 		defer mu.Unlock()
 	}
+	console.RecordOutput(message)
 
 	// Route through streamingCallback if available (still under mutex for ordering)
 	if agent != nil && agent.streamingEnabled && agent.streamingCallback != nil {
@@ -210,13 +262,13 @@ func (r *OutputRouter) writeTerminalMessage(message string) {
 	}
 
 	// Direct terminal output
-	if os.Getenv("LEDIT_CI_MODE") == "1" || os.Getenv("CI") != "" || os.Getenv("GITHUB_ACTIONS") != "" {
+	if isCIOutput() {
 		fmt.Print(message)
 		return
 	}
 
 	fmt.Print("\r\033[K")
-	fmt.Print(message)
+	fmt.Print(console.LinkFileRefs(message))
 }
 
 // RouteToolLog routes a tool execution log message with iteration and context info.
@@ -248,10 +300,10 @@ func (r *OutputRouter) RouteToolLog(action string, target string) {
 		r.publish(events.EventTypeAgentMessage, events.AgentMessageEvent("tool_log", fmt.Sprintf("%s %s", iterInfo, action), extra))
 	}
 
-	// Terminal output: format with ANSI colors
-	const darkGray = "\033[90m"
-	const slightlyLighterGray = "\033[38;5;246m"
-	const reset = "\033[0m"
+	// Terminal output: muted palette colors (omitted under NO_COLOR)
+	darkGray := console.ActivePalette().Muted
+	slightlyLighterGray := console.Code(console.ColorDim)
+	reset := console.Code(console.ColorReset)
 
 	var message string
 	if target != "" {
@@ -30,6 +30,8 @@ func init() {
 type ConversationState struct {
 	Messages                []api.Message    `json:"messages"`
 	TurnCheckpoints         []TurnCheckpoint `json:"turn_checkpoints,omitempty"`
+	PinnedFacts             []string         `json:"pinned_facts,omitempty"`
+	PinnedFiles             []string         `json:"pinned_files,omitempty"`
 	TaskActions             []TaskAction     `json:"task_actions"`
 	TotalCost               float64          `json:"total_cost"`
 	TotalTokens             int              `json:"total_tokens"`
@@ -210,12 +212,27 @@ func (a *Agent) SaveStateScoped(sessionID, workingDir string) error {
 		return fmt.Errorf("failed to create scoped session directory: %w", err)
 	}
 
-	// Generate session name from first user message
-	sessionName := a.generateSessionName()
+	state := a.SnapshotState()
+	state.SessionID = cleanSessionID
+	state.WorkingDirectory = cleanWorkingDir
 
-	state := ConversationState{
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal state: %w", err)
+	}
+
+	return os.WriteFile(stateFile, data, 0600)
+}
+
+// SnapshotState returns the live conversation in the form it is persisted,
+// for exporting the current session without saving it first.
+func (a *Agent) SnapshotState() ConversationState {
+	pinnedFacts, pinnedFiles := a.GetPins()
+	return ConversationState{
 		Messages:                a.messages,
 		TurnCheckpoints:         a.copyTurnCheckpoints(),
+		PinnedFacts:             pinnedFacts,
+		PinnedFiles:             pinnedFiles,
 		TaskActions:             a.GetTaskActions(),
 		TotalCost:               a.totalCost,
 		TotalTokens:             a.totalTokens,
@@ -225,17 +242,10 @@ func (a *Agent) SaveStateScoped(sessionID, workingDir string) error {
 		CachedTokens:            a.cachedTokens,
 		CachedCostSavings:       a.cachedCostSavings,
 		LastUpdated:             time.Now(),
-		SessionID:               cleanSessionID,
-		Name:                    sessionName,
-		WorkingDirectory:        cleanWorkingDir,
+		SessionID:               a.sessionID,
+		Name:                    a.generateSessionName(),
+		WorkingDirectory:        a.workspaceRoot,
 	}
-
-	data, err := json.MarshalIndent(state, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal state: %w", err)
-	}
-
-	return os.WriteFile(stateFile, data, 0600)
 }
 
 // LoadStateWithoutAgent loads a conversation state by session ID without an Agent instance
@@ -658,6 +668,7 @@ func (a *Agent) ApplyState(state *ConversationState) {
 	// Apply saved state
 	a.messages = state.Messages
 	a.ReplaceTurnCheckpoints(state.TurnCheckpoints)
+	a.replacePins(state.PinnedFacts, state.PinnedFiles)
 	a.replaceTaskActions(state.TaskActions)
 	a.totalCost = state.TotalCost
 	a.totalTokens = state.TotalTokens
@@ -1,12 +1,15 @@
 package agent
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"sort"
 	"strings"
 
 	api "github.com/alantheprice/ledit/pkg/agent_api"
+	tools "github.com/alantheprice/ledit/pkg/agent_tools"
+	"github.com/alantheprice/ledit/pkg/configuration"
 )
 
 // GetActivePersona returns the currently active persona ID.
@@ -206,3 +209,55 @@ func (a *Agent) isOrchestratorGitWriteAllowed() bool {
 	}
 	return config.AllowOrchestratorGitWrite
 }
+
+// handleListPersonas describes the enabled personas run_subagent accepts:
+// what each is for, its model, token budget and tools.
+func handleListPersonas(ctx context.Context, a *Agent, args map[string]interface{}) (string, error) {
+	ids := a.GetAvailablePersonaIDs()
+	if len(ids) == 0 {
+		return "No personas available.", nil
+	}
+	config := a.configManager.GetConfig()
+
+	var sb strings.Builder
+	sb.WriteString("## Available Personas\n\n")
+	for _, id := range ids {
+		persona := config.GetSubagentType(id)
+		if persona == nil {
+			continue
+		}
+		source := ""
+		if persona.Source == configuration.PersonaSourceProject {
+			source = " [project]"
+		}
+		fmt.Fprintf(&sb, "- **%s** (`%s`)%s: %s\n", persona.Name, id, source, persona.Description)
+		if len(persona.Aliases) > 0 {
+			fmt.Fprintf(&sb, "  - Aliases: %s\n", strings.Join(persona.Aliases, ", "))
+		}
+
+		model := strings.TrimSpace(persona.Model)
+		if provider := strings.TrimSpace(persona.Provider); provider != "" {
+			model = strings.TrimPrefix(provider+"/"+model, "/")
+		}
+		if model == "" {
+			model = "default subagent model"
+		}
+		budget := tools.GetSubagentMaxTokens()
+		if persona.MaxTokens > 0 {
+			budget = persona.MaxTokens
+		}
+		budgetText := "unlimited"
+		if budget > 0 {
+			budgetText = fmt.Sprintf("%d tokens", budget)
+		}
+		fmt.Fprintf(&sb, "  - Model: %s | Budget: %s\n", model, budgetText)
+
+		toolNames := "all tools"
+		if len(persona.AllowedTools) > 0 {
+			toolNames = strings.Join(persona.AllowedTools, ", ")
+		}
+		fmt.Fprintf(&sb, "  - Tools: %s\n", toolNames)
+	}
+	sb.WriteString("\nPass the ID as the `persona` parameter of `run_subagent`.")
+	return sb.String(), nil
+}
@@ -1,6 +1,7 @@
 package agent
 
 import (
+	"context"
 	"strings"
 	"testing"
 
@@ -93,3 +94,40 @@ func TestApplyPersonaNotFoundIncludesAvailablePersonas(t *testing.T) {
 		t.Fatalf("expected orchestrator in available persona list, got: %s", msg)
 	}
 }
+
+func TestHandleListPersonasDescribesBudgetsAndTools(t *testing.T) {
+	agent, err := NewAgent()
+	if err != nil {
+		t.Fatalf("failed to create agent: %v", err)
+	}
+
+	if err := agent.GetConfigManager().UpdateConfigNoSave(func(cfg *configuration.Config) error {
+		cfg.SubagentTypes["tmp_auditor"] = configuration.SubagentType{
+			ID:           "tmp_auditor",
+			Name:         "Temp Auditor",
+			Description:  "Audits things",
+			Model:        "audit-model",
+			AllowedTools: []string{"read_file", "search_files"},
+			MaxTokens:    1234,
+			Enabled:      true,
+			Source:       configuration.PersonaSourceProject,
+		}
+		cfg.SubagentTypes["tmp_disabled"] = configuration.SubagentType{ID: "tmp_disabled", Name: "Temp Disabled"}
+		return nil
+	}); err != nil {
+		t.Fatalf("failed to seed persona config: %v", err)
+	}
+
+	out, err := handleListPersonas(context.Background(), agent, nil)
+	if err != nil {
+		t.Fatalf("handleListPersonas: %v", err)
+	}
+	for _, want := range []string{"**Temp Auditor** (`tmp_auditor`) [project]: Audits things", "Model: audit-model | Budget: 1234 tokens", "Tools: read_file, search_files"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "tmp_disabled") {
+		t.Fatalf("disabled persona listed:\n%s", out)
+	}
+}
@@ -0,0 +1,246 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Names of the system prompt layers, in the order they are sent.
+const (
+	PromptLayerBase         = "base"
+	PromptLayerPersona      = "persona"
+	PromptLayerEnvironment  = "environment"
+	PromptLayerInstructions = "instructions"
+	PromptLayerMemories     = "memories"
+	PromptLayerSkills       = "skills"
+	PromptLayerPinned       = "pinned"
+	PromptLayerTools        = "tools"
+)
+
+// promptLayerSeparator goes before each layer after the first.
+const promptLayerSeparator = "\n\n---\n\n"
+
+// PromptLayer is one part of the system prompt sent with each request.
+type PromptLayer struct {
+	Name     string
+	Source   string // where the content comes from
+	Content  string // the layer's text as sent, including any leading separator
+	Override string // file replacing the layer for this session, if any
+}
+
+// promptOverride replaces a prompt layer with the contents of a file, read
+// again whenever the file changes.
+type promptOverride struct {
+	path    string
+	content string
+	modTime time.Time
+	size    int64
+}
+
+// load reads the override file.
+func (o *promptOverride) load() error {
+	info, err := os.Stat(o.path)
+	if err != nil {
+		return fmt.Errorf("failed to read prompt override: %w", err)
+	}
+	data, err := os.ReadFile(o.path)
+	if err != nil {
+		return fmt.Errorf("failed to read prompt override: %w", err)
+	}
+	content := strings.TrimSpace(string(data))
+	if content == "" {
+		return fmt.Errorf("prompt override %q is empty", o.path)
+	}
+	o.content, o.modTime, o.size = content, info.ModTime(), info.Size()
+	return nil
+}
+
+// refresh reloads the override file if it changed since it was last read. A
+// file that can no longer be read, or is now empty, keeps its last contents.
+func (o *promptOverride) refresh() {
+	info, err := os.Stat(o.path)
+	if err != nil || (info.ModTime().Equal(o.modTime) && info.Size() == o.size) {
+		return
+	}
+	_ = o.load()
+}
+
+// joinPromptLayers concatenates layers into the prompt they make up.
+func joinPromptLayers(layers []PromptLayer) string {
+	var sb strings.Builder
+	for _, layer := range layers {
+		sb.WriteString(layer.Content)
+	}
+	return sb.String()
+}
+
+// PromptLayers returns the layers of the system prompt: the base or persona
+// prompt, the date and time, project instructions, memories and skills, then
+// the pinned context and tool documentation added to each request. Session
+// overrides are applied.
+func (a *Agent) PromptLayers() []PromptLayer {
+	layers := a.systemPromptLayers()
+	if pinned := a.pinnedContextSupplement(); pinned != "" {
+		layers = append(layers, PromptLayer{Name: PromptLayerPinned, Source: "/pin", Content: promptLayerSeparator + pinned})
+	}
+	return append(layers, a.toolsPromptLayer())
+}
+
+// OverridePromptLayer replaces a layer of the system prompt with the contents
+// of a file for the rest of the session. The file is read again whenever it
+// changes, so edits apply from the next request.
+func (a *Agent) OverridePromptLayer(name, path string) error {
+	name = strings.ToLower(strings.TrimSpace(name))
+	switch name {
+	case PromptLayerPinned:
+		return errors.New("the pinned layer lists pinned facts and files; use /pin and /unpin to change it")
+	case PromptLayerTools:
+		return errors.New("the tools layer is generated from the tool definitions and can't be overridden")
+	}
+	found := false
+	for _, layer := range a.systemPromptLayers() {
+		found = found || layer.Name == name
+	}
+	if !found {
+		return fmt.Errorf("no %q layer in the current system prompt", name)
+	}
+
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return errors.New("prompt override file path is empty")
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve prompt override: %w", err)
+	}
+	override := &promptOverride{path: absPath}
+	if err := override.load(); err != nil {
+		return err
+	}
+
+	a.promptOverridesMu.Lock()
+	defer a.promptOverridesMu.Unlock()
+	if a.promptOverrides == nil {
+		a.promptOverrides = make(map[string]*promptOverride)
+	}
+	a.promptOverrides[name] = override
+	return nil
+}
+
+// ResetPromptOverrides removes the overrides of the named layers, or of all
+// layers when none are named, and returns how many were removed.
+func (a *Agent) ResetPromptOverrides(names ...string) int {
+	a.promptOverridesMu.Lock()
+	defer a.promptOverridesMu.Unlock()
+	if len(names) == 0 {
+		removed := len(a.promptOverrides)
+		a.promptOverrides = nil
+		return removed
+	}
+	removed := 0
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := a.promptOverrides[name]; ok {
+			delete(a.promptOverrides, name)
+			removed++
+		}
+	}
+	return removed
+}
+
+// requestSystemPrompt returns the system prompt with the session's layer
+// overrides applied.
+func (a *Agent) requestSystemPrompt() string {
+	a.promptOverridesMu.Lock()
+	overridden := len(a.promptOverrides) > 0
+	a.promptOverridesMu.Unlock()
+	if !overridden {
+		return a.systemPrompt
+	}
+	return joinPromptLayers(a.systemPromptLayers())
+}
+
+// systemPromptLayers splits the system prompt into its layers and applies
+// the session's overrides.
+func (a *Agent) systemPromptLayers() []PromptLayer {
+	prompt, skills := a.systemPrompt, ""
+	if a.skillPrompt != "" && strings.HasSuffix(prompt, a.skillPrompt) {
+		prompt, skills = strings.TrimSuffix(prompt, a.skillPrompt), a.skillPrompt
+	}
+
+	var layers []PromptLayer
+	if len(a.promptSections) > 0 && prompt == joinPromptLayers(a.promptSections) {
+		layers = append(layers, a.promptSections...)
+	} else {
+		// A persona, system_prompt_text or --system-prompt replaced the
+		// embedded prompt along with everything appended to it
+		layers = append(layers, a.customPromptLayer(prompt))
+	}
+	if skills != "" {
+		layers = append(layers, PromptLayer{Name: PromptLayerSkills, Source: strings.Join(a.activeSkills, ", "), Content: skills})
+	}
+
+	a.promptOverridesMu.Lock()
+	defer a.promptOverridesMu.Unlock()
+	for i := range layers {
+		override := a.promptOverrides[layers[i].Name]
+		if override == nil {
+			continue
+		}
+		override.refresh()
+		layers[i].Content = override.content
+		if i > 0 {
+			layers[i].Content = promptLayerSeparator + layers[i].Content
+		}
+		layers[i].Override = override.path
+	}
+	return layers
+}
+
+// customPromptLayer describes a system prompt that replaced the embedded one.
+func (a *Agent) customPromptLayer(prompt string) PromptLayer {
+	layer := PromptLayer{Name: PromptLayerBase, Source: "custom system prompt", Content: prompt}
+	personaID := a.GetActivePersona()
+	if personaID == "" || a.configManager == nil || a.configManager.GetConfig() == nil {
+		return layer
+	}
+	persona := a.configManager.GetConfig().GetSubagentType(personaID)
+	if persona == nil {
+		return layer
+	}
+	switch {
+	case strings.TrimSpace(persona.SystemPromptText) != "":
+		layer.Name, layer.Source = PromptLayerPersona, "persona "+personaID
+	case strings.TrimSpace(persona.SystemPrompt) != "":
+		layer.Name, layer.Source = PromptLayerPersona, fmt.Sprintf("persona %s (%s)", personaID, strings.TrimSpace(persona.SystemPrompt))
+	}
+	return layer
+}
+
+// toolsPromptLayer documents the tools offered to the model: described in
+// the prompt for models without native tool calls, and sent as tool
+// definitions otherwise.
+func (a *Agent) toolsPromptLayer() PromptLayer {
+	tools := a.getOptimizedToolDefinitions(a.messages)
+	if a.client != nil && !a.GetModelCapabilities().NativeTools {
+		return PromptLayer{
+			Name:    PromptLayerTools,
+			Source:  fmt.Sprintf("%d tools described in the prompt", len(tools)),
+			Content: promptLayerSeparator + textToolInstructions(tools),
+		}
+	}
+
+	var sb strings.Builder
+	for _, tool := range tools {
+		fmt.Fprintf(&sb, "- %s: %s\n", tool.Function.Name, tool.Function.Description)
+	}
+	return PromptLayer{
+		Name:    PromptLayerTools,
+		Source:  fmt.Sprintf("%d native tool definitions", len(tools)),
+		Content: sb.String(),
+	}
+}
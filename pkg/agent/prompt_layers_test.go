@@ -0,0 +1,103 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func promptLayerAgent() *Agent {
+	sections := []PromptLayer{
+		{Name: PromptLayerBase, Content: "You are ledit."},
+		{Name: PromptLayerEnvironment, Content: "\n\n## Current Date and Time\n\n---\n"},
+		{Name: PromptLayerInstructions, Content: "\n\n---\n\n## Project Instructions\n\nUse tabs."},
+	}
+	skill := "\n\n---\n\n[Skill Activated: Go]\n\nRun gofmt."
+	return &Agent{
+		systemPrompt:   joinPromptLayers(sections) + skill,
+		promptSections: sections,
+		skillPrompt:    skill,
+		activeSkills:   []string{"go"},
+	}
+}
+
+func promptLayerNames(layers []PromptLayer) []string {
+	names := make([]string, 0, len(layers))
+	for _, layer := range layers {
+		names = append(names, layer.Name)
+	}
+	return names
+}
+
+func TestPromptLayers(t *testing.T) {
+	a := promptLayerAgent()
+	if err := a.PinFact("The API is frozen"); err != nil {
+		t.Fatal(err)
+	}
+
+	layers := a.PromptLayers()
+	if got := strings.Join(promptLayerNames(layers), ","); got != "base,environment,instructions,skills,pinned,tools" {
+		t.Fatalf("unexpected layers %s", got)
+	}
+	if joinPromptLayers(layers[:4]) != a.systemPrompt {
+		t.Fatal("expected the prompt layers to join into the system prompt")
+	}
+	if layers[3].Source != "go" || !strings.Contains(layers[4].Content, "The API is frozen") {
+		t.Fatalf("unexpected skills and pinned layers %+v", layers[3:5])
+	}
+
+	// A prompt that replaced the embedded one is a single layer
+	a.SetSystemPrompt("You review code.")
+	if got := strings.Join(promptLayerNames(a.systemPromptLayers()), ","); got != "base" {
+		t.Fatalf("unexpected layers for a replaced prompt %s", got)
+	}
+}
+
+func TestOverridePromptLayerReloadsFile(t *testing.T) {
+	a := promptLayerAgent()
+	path := filepath.Join(t.TempDir(), "instructions.md")
+	if err := os.WriteFile(path, []byte("Use spaces.\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.OverridePromptLayer("Instructions", path); err != nil {
+		t.Fatalf("OverridePromptLayer: %v", err)
+	}
+	prompt := a.requestSystemPrompt()
+	if !strings.Contains(prompt, "\n\n---\n\nUse spaces.") || strings.Contains(prompt, "Use tabs.") {
+		t.Fatalf("expected the override to replace the instructions, got %q", prompt)
+	}
+	if a.GetSystemPrompt() == prompt {
+		t.Fatal("expected the override to leave the session's system prompt unchanged")
+	}
+
+	// Edits to the file apply on the next request
+	if err := os.WriteFile(path, []byte("Use two spaces."), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Second)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if prompt := a.requestSystemPrompt(); !strings.Contains(prompt, "Use two spaces.") {
+		t.Fatalf("expected the edited override to be reloaded, got %q", prompt)
+	}
+	if layers := a.PromptLayers(); layers[2].Override != path {
+		t.Fatalf("expected the instructions layer to name its override, got %+v", layers[2])
+	}
+
+	if a.ResetPromptOverrides() != 1 || a.requestSystemPrompt() != a.systemPrompt {
+		t.Fatal("expected resetting to restore the system prompt")
+	}
+
+	for _, name := range []string{"tools", "pinned", "memories"} {
+		if err := a.OverridePromptLayer(name, path); err == nil {
+			t.Errorf("expected overriding the %s layer to fail", name)
+		}
+	}
+	if err := a.OverridePromptLayer("base", filepath.Join(t.TempDir(), "missing.md")); err == nil {
+		t.Fatal("expected a missing override file to be rejected")
+	}
+}
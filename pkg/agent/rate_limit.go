@@ -0,0 +1,69 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/alantheprice/ledit/pkg/configuration"
+	"github.com/alantheprice/ledit/pkg/ratelimit"
+)
+
+// providerRateLimiter returns the limiter shared by every ledit process for
+// the current provider, or nil when config.json sets no rate_limits for it.
+func (a *Agent) providerRateLimiter() *ratelimit.Limiter {
+	config := a.GetConfig()
+	if config == nil {
+		return nil
+	}
+	provider := a.GetProvider()
+	limit := config.RateLimits[provider]
+	limits := ratelimit.Limits{RequestsPerMinute: limit.RequestsPerMinute, TokensPerMinute: limit.TokensPerMinute}
+	if !limits.Enabled() {
+		return nil
+	}
+	configDir, err := configuration.GetConfigDir()
+	if err != nil {
+		return nil
+	}
+	return ratelimit.New(filepath.Join(configDir, "ratelimit"), provider, limits)
+}
+
+// waitForRateLimit queues the next request until it fits in the provider's
+// limits, showing the wait in the terminal and the web UI's progress line.
+// Limiter failures other than an interrupt let the request through.
+func (ac *APIClient) waitForRateLimit(estimatedTokens int) (*ratelimit.Reservation, error) {
+	limiter := ac.agent.providerRateLimiter()
+	if limiter == nil {
+		return nil, nil
+	}
+	ctx := ac.agent.interruptCtx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	reservation, err := limiter.Wait(ctx, estimatedTokens, func(delay time.Duration, reason string) {
+		ac.agent.noteRateLimited(delay)
+		message := fmt.Sprintf("[rate] Waiting %s for the %s rate limit (%s)", delay.Round(time.Second), ac.agent.GetProvider(), reason)
+		ac.agent.PrintLineAsync(message)
+		ac.agent.PublishQueryProgress(message, ac.agent.currentIteration, ac.agent.totalTokens)
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		ac.agent.debugLog("DEBUG: rate limiter unavailable, sending without it: %v\n", err)
+		return nil, nil
+	}
+	return reservation, nil
+}
+
+// pauseRateLimit makes every process using the provider wait out a rate
+// limit the provider reported, not just this one.
+func (ac *APIClient) pauseRateLimit(delay time.Duration) {
+	if limiter := ac.agent.providerRateLimiter(); limiter != nil {
+		if err := limiter.Pause(delay); err != nil {
+			ac.agent.debugLog("DEBUG: failed to share rate limit pause: %v\n", err)
+		}
+	}
+}
@@ -0,0 +1,465 @@
+package agent
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	tools "github.com/alantheprice/ledit/pkg/agent_tools"
+	"github.com/alantheprice/ledit/pkg/filesystem"
+)
+
+// Read-ahead limits. Prefetching only ever loads local source files into the
+// read cache; nothing is added to the conversation until read_file asks.
+const (
+	readCacheMaxBytes       = 8 << 20   // total cached content
+	prefetchMaxFileBytes    = 256 << 10 // skip larger files
+	prefetchMaxFilesPerRead = 12        // imports followed per read_file
+	prefetchTimeout         = 5 * time.Second
+	// Files modified this recently are not cached: a same-size rewrite within
+	// the filesystem's timestamp granularity would otherwise go unnoticed.
+	readCacheRacyWindow = 2 * time.Second
+)
+
+// readCacheEntry is one file's read_file output. Entries are only served
+// while the file's size and modification time are unchanged.
+type readCacheEntry struct {
+	content    string
+	modTime    time.Time
+	size       int64
+	prefetched bool // loaded speculatively and not yet read by the model
+}
+
+// ReadCacheStats summarizes read cache effectiveness for the session summary.
+type ReadCacheStats struct {
+	Reads        int // full-file read_file calls
+	Hits         int // reads served from the cache
+	PrefetchHits int // hits on files loaded by the prefetcher
+	Prefetched   int // files loaded by the prefetcher
+	CachedFiles  int
+	CachedBytes  int64
+}
+
+type readCache struct {
+	mu       sync.Mutex
+	entries  map[string]*readCacheEntry
+	order    []string // insertion order, oldest first, for eviction
+	bytes    int64
+	inflight map[string]bool
+	stats    ReadCacheStats
+}
+
+func (a *Agent) readCacheState() *readCache {
+	a.readCacheOnce.Do(func() {
+		a.readCache = &readCache{
+			entries:  make(map[string]*readCacheEntry),
+			inflight: make(map[string]bool),
+		}
+	})
+	return a.readCache
+}
+
+// ReadCacheStats returns read cache and prefetch counters for this session.
+func (a *Agent) ReadCacheStats() ReadCacheStats {
+	c := a.readCacheState()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.CachedFiles = len(c.entries)
+	stats.CachedBytes = c.bytes
+	return stats
+}
+
+// cachedRead returns the cached read_file output for absPath if the file is
+// unchanged since it was cached.
+func (a *Agent) cachedRead(absPath string) (string, bool) {
+	info, err := os.Stat(absPath)
+	c := a.readCacheState()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stats.Reads++
+	entry, ok := c.entries[absPath]
+	if !ok {
+		return "", false
+	}
+	if err != nil || info.Size() != entry.size || !info.ModTime().Equal(entry.modTime) {
+		c.removeLocked(absPath)
+		return "", false
+	}
+	c.stats.Hits++
+	if entry.prefetched {
+		c.stats.PrefetchHits++
+		entry.prefetched = false
+	}
+	return entry.content, true
+}
+
+// storeRead caches read_file output for absPath, stamped with info from
+// before the read so a concurrent write invalidates it.
+func (a *Agent) storeRead(absPath, content string, info os.FileInfo, prefetched bool) {
+	if info == nil || int64(len(content)) > readCacheMaxBytes/4 || time.Since(info.ModTime()) < readCacheRacyWindow {
+		return
+	}
+	c := a.readCacheState()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, exists := c.entries[absPath]; exists {
+		if prefetched {
+			return
+		}
+		c.removeLocked(absPath)
+	}
+	c.entries[absPath] = &readCacheEntry{
+		content:    content,
+		modTime:    info.ModTime(),
+		size:       info.Size(),
+		prefetched: prefetched,
+	}
+	c.order = append(c.order, absPath)
+	c.bytes += int64(len(content))
+	if prefetched {
+		c.stats.Prefetched++
+	}
+	for c.bytes > readCacheMaxBytes && len(c.order) > 0 {
+		c.removeLocked(c.order[0])
+	}
+}
+
+// invalidateReadCache drops path from the read cache. Called before the agent
+// writes a file.
+func (a *Agent) invalidateReadCache(path string) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return
+	}
+	c := a.readCacheState()
+	c.mu.Lock()
+	c.removeLocked(abs)
+	c.mu.Unlock()
+}
+
+func (c *readCache) removeLocked(absPath string) {
+	entry, ok := c.entries[absPath]
+	if !ok {
+		return
+	}
+	c.bytes -= int64(len(entry.content))
+	delete(c.entries, absPath)
+	for i, p := range c.order {
+		if p == absPath {
+			c.order = append(c.order[:i], c.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// prefetchImports loads the local files imported by absPath into the read
+// cache in the background.
+func (a *Agent) prefetchImports(ctx context.Context, absPath, content string) {
+	root := filesystem.WorkspaceRootFromContext(ctx)
+	if root == "" {
+		root = a.currentWorkspaceRoot()
+	}
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return
+	}
+
+	candidates := localImportPaths(absPath, content, root)
+	if len(candidates) == 0 {
+		return
+	}
+
+	c := a.readCacheState()
+	c.mu.Lock()
+	var todo []string
+	for _, path := range candidates {
+		if len(todo) >= prefetchMaxFilesPerRead {
+			break
+		}
+		if _, cached := c.entries[path]; cached || c.inflight[path] {
+			continue
+		}
+		c.inflight[path] = true
+		todo = append(todo, path)
+	}
+	c.mu.Unlock()
+	if len(todo) == 0 {
+		return
+	}
+
+	go func() {
+		// No security bypass: prefetching never reads outside the workspace.
+		prefetchCtx, cancel := context.WithTimeout(filesystem.WithWorkspaceRoot(context.Background(), root), prefetchTimeout)
+		defer cancel()
+		defer func() {
+			c.mu.Lock()
+			for _, path := range todo {
+				delete(c.inflight, path)
+			}
+			c.mu.Unlock()
+		}()
+
+		for _, path := range todo {
+			if prefetchCtx.Err() != nil {
+				return
+			}
+			info, err := os.Stat(path)
+			if err != nil || info.IsDir() || info.Size() > prefetchMaxFileBytes {
+				continue
+			}
+			fileContent, err := tools.ReadFile(prefetchCtx, path)
+			if err != nil {
+				continue
+			}
+			a.storeRead(path, fileContent, info, true)
+		}
+		a.debugLog("[prefetch] loaded imports of %s (%d candidates)\n", absPath, len(todo))
+	}()
+}
+
+const (
+	maxImportScanLines = 400 // imports are read from the top of a file only
+	maxGoPackageFiles  = 6   // files prefetched per imported Go package
+)
+
+var (
+	goImportBlockPattern = regexp.MustCompile(`(?s)import\s*\((.*?)\)`)
+	goImportLinePattern  = regexp.MustCompile(`^\s*(?:import\s+)?(?:[\w.]+\s+)?"([^"]+)"`)
+	goModulePathPattern  = regexp.MustCompile(`(?m)^module\s+(\S+)`)
+	jsImportPattern      = regexp.MustCompile(`(?:import|export)\s[^'"]*?from\s*['"]([^'"]+)['"]|import\s*\(?\s*['"]([^'"]+)['"]|require\(\s*['"]([^'"]+)['"]\s*\)`)
+	pyFromImportPattern  = regexp.MustCompile(`^\s*from\s+(\.*[\w.]*)\s+import\s+(.+)$`)
+	pyImportPattern      = regexp.MustCompile(`^\s*import\s+([\w.]+(?:\s*,\s*[\w.]+)*)`)
+	cIncludePattern      = regexp.MustCompile(`^\s*#\s*include\s*"([^"]+)"`)
+	rustModPattern       = regexp.MustCompile(`^\s*(?:pub(?:\([^)]*\))?\s+)?mod\s+(\w+)\s*;`)
+
+	jsResolveSuffixes = []string{"", ".ts", ".tsx", ".js", ".jsx", ".mjs", ".cjs", "/index.ts", "/index.tsx", "/index.js"}
+	goModulePathCache sync.Map // workspace root → module path ("" when not a Go module)
+)
+
+// localImportPaths returns existing workspace files imported by the file at
+// absPath, in source order. Third-party and standard library imports are
+// skipped.
+func localImportPaths(absPath, content, root string) []string {
+	return resolveLocalImports(absPath, content, root, maxGoPackageFiles)
+}
+
+// resolveLocalImports is localImportPaths with the number of files listed
+// per imported Go package as a parameter; 0 lists the whole package.
+func resolveLocalImports(absPath, content, root string, goFilesPerPackage int) []string {
+	dir := filepath.Dir(absPath)
+	var out []string
+	seen := map[string]bool{absPath: true}
+	add := func(path string) {
+		path = filepath.Clean(path)
+		if seen[path] || !withinRoot(path, root) {
+			return
+		}
+		seen[path] = true
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			out = append(out, path)
+		}
+	}
+
+	switch strings.ToLower(filepath.Ext(absPath)) {
+	case ".go":
+		module := goModulePath(root)
+		if module == "" {
+			return nil
+		}
+		for _, imp := range goImports(content) {
+			if imp != module && !strings.HasPrefix(imp, module+"/") {
+				continue
+			}
+			pkgDir := filepath.Join(root, filepath.FromSlash(strings.TrimPrefix(imp, module)))
+			for _, file := range goPackageFiles(pkgDir, goFilesPerPackage) {
+				add(file)
+			}
+		}
+	case ".ts", ".tsx", ".js", ".jsx", ".mjs", ".cjs", ".vue", ".svelte":
+		for _, m := range jsImportPattern.FindAllStringSubmatch(content, -1) {
+			spec := firstNonEmpty(m[1:]...)
+			if !strings.HasPrefix(spec, ".") {
+				continue
+			}
+			base := filepath.Join(dir, filepath.FromSlash(spec))
+			for _, suffix := range jsResolveSuffixes {
+				if isRegularFile(base + filepath.FromSlash(suffix)) {
+					add(base + filepath.FromSlash(suffix))
+					break
+				}
+			}
+		}
+	case ".py":
+		forEachLine(content, func(line string) {
+			if m := pyFromImportPattern.FindStringSubmatch(line); m != nil {
+				module := m[1]
+				if resolved := pythonModuleFile(module, dir, root); resolved != "" {
+					add(resolved)
+				}
+				// "from . import a" and "from pkg import sub" may name submodules.
+				prefix := module
+				if strings.Trim(module, ".") != "" {
+					prefix += "."
+				}
+				for _, name := range strings.Split(strings.Trim(m[2], "() "), ",") {
+					fields := strings.Fields(name)
+					if len(fields) == 0 || fields[0] == "*" {
+						continue
+					}
+					if sub := pythonModuleFile(prefix+fields[0], dir, root); sub != "" {
+						add(sub)
+					}
+				}
+				return
+			}
+			if m := pyImportPattern.FindStringSubmatch(line); m != nil {
+				for _, module := range strings.Split(m[1], ",") {
+					if resolved := pythonModuleFile(strings.TrimSpace(module), dir, root); resolved != "" {
+						add(resolved)
+					}
+				}
+			}
+		})
+	case ".c", ".h", ".cc", ".cpp", ".cxx", ".hpp", ".hh", ".m", ".mm":
+		forEachLine(content, func(line string) {
+			if m := cIncludePattern.FindStringSubmatch(line); m != nil {
+				for _, base := range []string{dir, root, filepath.Join(root, "include")} {
+					if candidate := filepath.Join(base, filepath.FromSlash(m[1])); isRegularFile(candidate) {
+						add(candidate)
+						break
+					}
+				}
+			}
+		})
+	case ".rs":
+		forEachLine(content, func(line string) {
+			if m := rustModPattern.FindStringSubmatch(line); m != nil {
+				modDir := dir
+				if stem := strings.TrimSuffix(filepath.Base(absPath), ".rs"); stem != "mod" && stem != "lib" && stem != "main" {
+					modDir = filepath.Join(dir, stem)
+				}
+				for _, candidate := range []string{filepath.Join(modDir, m[1]+".rs"), filepath.Join(modDir, m[1], "mod.rs")} {
+					if isRegularFile(candidate) {
+						add(candidate)
+						break
+					}
+				}
+			}
+		})
+	}
+	return out
+}
+
+func goImports(content string) []string {
+	var imports []string
+	header := content
+	if idx := strings.Index(header, "\nfunc "); idx > 0 {
+		header = header[:idx]
+	}
+	for _, block := range goImportBlockPattern.FindAllStringSubmatch(header, -1) {
+		forEachLine(block[1], func(line string) {
+			if m := goImportLinePattern.FindStringSubmatch(line); m != nil {
+				imports = append(imports, m[1])
+			}
+		})
+	}
+	forEachLine(header, func(line string) {
+		if strings.HasPrefix(strings.TrimSpace(line), "import ") && !strings.Contains(line, "(") {
+			if m := goImportLinePattern.FindStringSubmatch(line); m != nil {
+				imports = append(imports, m[1])
+			}
+		}
+	})
+	return imports
+}
+
+// goPackageFiles lists up to limit non-test Go files of a package directory;
+// a limit of 0 lists them all.
+func goPackageFiles(dir string, limit int) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		files = append(files, filepath.Join(dir, name))
+		if limit > 0 && len(files) >= limit {
+			break
+		}
+	}
+	return files
+}
+
+func goModulePath(root string) string {
+	if cached, ok := goModulePathCache.Load(root); ok {
+		return cached.(string)
+	}
+	module := ""
+	if data, err := os.ReadFile(filepath.Join(root, "go.mod")); err == nil {
+		if m := goModulePathPattern.FindSubmatch(data); m != nil {
+			module = string(m[1])
+		}
+	}
+	goModulePathCache.Store(root, module)
+	return module
+}
+
+// pythonModuleFile resolves a dotted module name ("pkg.mod", ".sibling",
+// "..parent.mod") to a .py file or package __init__.py.
+func pythonModuleFile(module, dir, root string) string {
+	base := root
+	trimmed := strings.TrimLeft(module, ".")
+	if dots := len(module) - len(trimmed); dots > 0 {
+		base = dir
+		for i := 1; i < dots; i++ {
+			base = filepath.Dir(base)
+		}
+	}
+	if trimmed == "" {
+		return ""
+	}
+	rel := filepath.Join(strings.Split(trimmed, ".")...)
+	for _, candidate := range []string{filepath.Join(base, rel+".py"), filepath.Join(base, rel, "__init__.py")} {
+		if isRegularFile(candidate) {
+			return candidate
+		}
+	}
+	return ""
+}
+
+func forEachLine(content string, fn func(string)) {
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for n := 0; scanner.Scan() && n < maxImportScanLines; n++ {
+		fn(scanner.Text())
+	}
+}
+
+func isRegularFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
+
+func withinRoot(path, root string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,101 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func writePrefetchFixture(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	old := time.Now().Add(-time.Hour)
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLocalImportPaths(t *testing.T) {
+	root := t.TempDir()
+	writePrefetchFixture(t, root, map[string]string{
+		"go.mod":                    "module example.com/app\n\ngo 1.22\n",
+		"main.go":                   "package main\n\nimport (\n\t\"fmt\"\n\tcfg \"example.com/app/internal/config\"\n)\n\nfunc main() {}\n",
+		"internal/config/a.go":      "package config\n",
+		"internal/config/a_test.go": "package config\n",
+		"web/app.ts":                "import { api } from './api'\nimport React from 'react'\nconst u = require('../shared/util')\n",
+		"web/api/index.ts":          "export const api = 1\n",
+		"shared/util.js":            "module.exports = {}\n",
+		"svc/handler.py":            "import os\nfrom .models import User\nfrom . import views\nimport svc.db\n",
+		"svc/models.py":             "",
+		"svc/views.py":              "",
+		"svc/db/__init__.py":        "",
+		"src/main.c":                "#include <stdio.h>\n#include \"util.h\"\n",
+		"src/util.h":                "",
+	})
+	abs := func(name string) string { return filepath.Join(root, filepath.FromSlash(name)) }
+	read := func(name string) string {
+		data, err := os.ReadFile(abs(name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	tests := map[string][]string{
+		"main.go":        {abs("internal/config/a.go")},
+		"web/app.ts":     {abs("web/api/index.ts"), abs("shared/util.js")},
+		"svc/handler.py": {abs("svc/models.py"), abs("svc/views.py"), abs("svc/db/__init__.py")},
+		"src/main.c":     {abs("src/util.h")},
+	}
+	for name, want := range tests {
+		if got := localImportPaths(abs(name), read(name), root); !reflect.DeepEqual(got, want) {
+			t.Errorf("localImportPaths(%s) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestReadCacheServesUnchangedFilesOnly(t *testing.T) {
+	root := t.TempDir()
+	writePrefetchFixture(t, root, map[string]string{"a.go": "package a\n"})
+	path := filepath.Join(root, "a.go")
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := &Agent{}
+	a.storeRead(path, "package a\n", info, true)
+	if got, ok := a.cachedRead(path); !ok || got != "package a\n" {
+		t.Fatalf("expected prefetched content to be served, got %q %v", got, ok)
+	}
+
+	// A change on disk (new size and mtime) invalidates the entry.
+	if err := os.WriteFile(path, []byte("package a // changed\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := a.cachedRead(path); ok {
+		t.Fatal("expected stale entry to be dropped")
+	}
+
+	// Recently modified files are not cached at all.
+	info, _ = os.Stat(path)
+	a.storeRead(path, "package a // changed\n", info, false)
+	if _, ok := a.cachedRead(path); ok {
+		t.Fatal("expected recently modified file not to be cached")
+	}
+
+	stats := a.ReadCacheStats()
+	if stats.Reads != 3 || stats.Hits != 1 || stats.PrefetchHits != 1 || stats.Prefetched != 1 || stats.CachedFiles != 0 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
@@ -0,0 +1,68 @@
+// Remote execution: shell commands, builds and tests run on the host set in
+// the remote config while the console and the file tools stay local.
+package agent
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/alantheprice/ledit/pkg/configuration"
+	"github.com/alantheprice/ledit/pkg/remote"
+)
+
+// remoteExec holds the host for the workspace it was set up for.
+type remoteExec struct {
+	mu    sync.Mutex
+	root  string
+	host  *remote.Host
+	err   error
+	ready bool
+}
+
+func (a *Agent) remoteExecState() *remoteExec {
+	a.remoteExecOnce.Do(func() {
+		a.remoteExec = &remoteExec{}
+	})
+	return a.remoteExec
+}
+
+// RemoteHost returns the host that runs the workspace's shell commands, or
+// nil when they run locally: no remote is configured, it is disabled, or the
+// workspace is outside its mapped paths.
+func (a *Agent) RemoteHost() (*remote.Host, error) {
+	config := a.GetConfig()
+	if config == nil || config.Remote == nil || config.Remote.Disabled || strings.TrimSpace(config.Remote.Host) == "" {
+		return nil, nil
+	}
+	root := a.currentWorkspaceRoot()
+	s := a.remoteExecState()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.ready || s.root != root {
+		s.root, s.ready = root, true
+		s.host, s.err = remote.New(RemoteConfig(config.Remote), root)
+		if errors.Is(s.err, remote.ErrNotMapped) {
+			a.debugLog("[remote] %v; running commands locally\n", s.err)
+			s.host, s.err = nil, nil
+		}
+	}
+	return s.host, s.err
+}
+
+// RemoteConfig converts the remote config section to a host config. Shared
+// SSH connections keep their sockets in ssh/ in the config directory.
+func RemoteConfig(c *configuration.RemoteConfig) remote.Config {
+	cfg := remote.Config{
+		Host:         c.Host,
+		Port:         c.Port,
+		IdentityFile: c.IdentityFile,
+		Paths:        c.Paths,
+		Exclude:      c.Exclude,
+	}
+	if configDir, err := configuration.GetConfigDir(); err == nil {
+		cfg.ControlDir = filepath.Join(configDir, "ssh")
+	}
+	return cfg
+}
@@ -0,0 +1,163 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	api "github.com/alantheprice/ledit/pkg/agent_api"
+)
+
+// maxPreflightCompactions bounds how many times an oversized request is
+// compacted and re-prepared before giving up.
+const maxPreflightCompactions = 2
+
+// RequestBudget is the pre-flight size estimate for one chat request.
+type RequestBudget struct {
+	Provider           string
+	Model              string
+	MessageTokens      int
+	ToolTokens         int
+	ToolCount          int
+	ReservedCompletion int
+	TotalTokens        int // input tokens plus the reserved completion
+	ContextWindow      int // 0 when unknown
+	RequestBytes       int
+	MaxRequestBytes    int // 0 when the provider has no limit
+	Largest            []MessageSize
+}
+
+// MessageSize is the estimated size of one message in a request.
+type MessageSize struct {
+	Index   int
+	Role    string
+	Tokens  int
+	Preview string
+}
+
+// OverContext reports whether the request does not fit the context window.
+func (b RequestBudget) OverContext() bool {
+	return b.ContextWindow > 0 && b.TotalTokens > b.ContextWindow
+}
+
+// OverRequestSize reports whether the request body exceeds the provider limit.
+func (b RequestBudget) OverRequestSize() bool {
+	return b.MaxRequestBytes > 0 && b.RequestBytes > b.MaxRequestBytes
+}
+
+// Exceeded reports whether the request would be rejected for its size.
+func (b RequestBudget) Exceeded() bool {
+	return b.OverContext() || b.OverRequestSize()
+}
+
+// Breakdown describes where the request's size comes from.
+func (b RequestBudget) Breakdown() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "~%d tokens: messages %d, tools %d (%d definitions), reserved completion %d",
+		b.TotalTokens, b.MessageTokens, b.ToolTokens, b.ToolCount, b.ReservedCompletion)
+	if b.ContextWindow > 0 {
+		fmt.Fprintf(&sb, "; context window %d", b.ContextWindow)
+	}
+	fmt.Fprintf(&sb, "\nrequest body: %s", formatByteSize(b.RequestBytes))
+	if b.MaxRequestBytes > 0 {
+		fmt.Fprintf(&sb, " (provider limit %s)", formatByteSize(b.MaxRequestBytes))
+	}
+	if len(b.Largest) > 0 {
+		sb.WriteString("\nlargest messages:")
+		for _, msg := range b.Largest {
+			fmt.Fprintf(&sb, "\n  #%d %s ~%d tokens: %s", msg.Index, msg.Role, msg.Tokens, msg.Preview)
+		}
+	}
+	return sb.String()
+}
+
+// RequestTooLargeError is returned instead of sending a request that the
+// provider would reject for its size.
+type RequestTooLargeError struct {
+	Budget RequestBudget
+}
+
+func (e *RequestTooLargeError) Error() string {
+	var reasons []string
+	if e.Budget.OverContext() {
+		reasons = append(reasons, fmt.Sprintf("~%d tokens exceeds the %d-token context window", e.Budget.TotalTokens, e.Budget.ContextWindow))
+	}
+	if e.Budget.OverRequestSize() {
+		reasons = append(reasons, fmt.Sprintf("%s body exceeds the provider's %s request limit",
+			formatByteSize(e.Budget.RequestBytes), formatByteSize(e.Budget.MaxRequestBytes)))
+	}
+	return fmt.Sprintf("request too large for %s/%s: %s even after compaction\n%s",
+		e.Budget.Provider, e.Budget.Model, strings.Join(reasons, " and "), e.Budget.Breakdown())
+}
+
+// measureRequest estimates the size of a request before it is sent.
+func (ac *APIClient) measureRequest(messages []api.Message, tools []api.Tool) RequestBudget {
+	budget := RequestBudget{
+		Provider:           ac.agent.GetProvider(),
+		Model:              ac.agent.GetModel(),
+		ToolCount:          len(tools),
+		ToolTokens:         len(tools) * api.ToolTokenEstimate,
+		ReservedCompletion: api.MinOutputTokens,
+		ContextWindow:      ac.agent.maxContextTokens,
+	}
+	inputTokens := ac.estimateRequestTokens(messages, tools)
+	budget.MessageTokens = inputTokens - budget.ToolTokens
+	budget.TotalTokens = inputTokens + budget.ReservedCompletion
+
+	if body, err := json.Marshal(api.ChatRequest{Model: budget.Model, Messages: messages, Tools: tools}); err == nil {
+		budget.RequestBytes = len(body)
+	}
+	if limited, ok := ac.agent.client.(interface{ GetMaxRequestBytes() int }); ok {
+		budget.MaxRequestBytes = limited.GetMaxRequestBytes()
+	}
+
+	sizes := make([]MessageSize, 0, len(messages))
+	for i, msg := range messages {
+		tokens := api.EstimateInputTokens([]api.Message{msg}, nil) - api.SystemInstructionBuffer
+		preview := strings.Join(strings.Fields(msg.Content), " ")
+		if len(preview) > 80 {
+			preview = preview[:80] + "…"
+		}
+		sizes = append(sizes, MessageSize{Index: i, Role: msg.Role, Tokens: tokens, Preview: preview})
+	}
+	sort.SliceStable(sizes, func(i, j int) bool { return sizes[i].Tokens > sizes[j].Tokens })
+	if len(sizes) > 3 {
+		sizes = sizes[:3]
+	}
+	budget.Largest = sizes
+	return budget
+}
+
+// ensureRequestFits checks the request against the context window and the
+// provider's request size limit. Oversized requests are compacted and
+// re-prepared; when that is not enough a RequestTooLargeError is returned
+// rather than letting the provider reject the upload.
+func (ac *APIClient) ensureRequestFits(messages []api.Message, tools []api.Tool) ([]api.Message, error) {
+	for attempt := 0; ; attempt++ {
+		budget := ac.measureRequest(messages, tools)
+		if !budget.Exceeded() {
+			return messages, nil
+		}
+		if ac.agent.debug {
+			ac.agent.debugLog("DEBUG: pre-flight size check failed (attempt %d):\n%s\n", attempt, budget.Breakdown())
+		}
+		if attempt >= maxPreflightCompactions || ac.prepareMessagesCallback == nil || !ac.agent.TriggerCompaction() {
+			return nil, &RequestTooLargeError{Budget: budget}
+		}
+		ac.agent.PrintLineAsync(fmt.Sprintf("[~] Request too large to send (~%d tokens, %s). Compacting conversation first...",
+			budget.TotalTokens, formatByteSize(budget.RequestBytes)))
+		messages = ac.prepareMessagesCallback(tools)
+	}
+}
+
+func formatByteSize(n int) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
@@ -0,0 +1,92 @@
+package agent
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	api "github.com/alantheprice/ledit/pkg/agent_api"
+)
+
+// requestLimitedClient is a scripted client whose provider caps request bodies.
+type requestLimitedClient struct {
+	*ScriptedClient
+	maxBytes int
+}
+
+func (c *requestLimitedClient) GetMaxRequestBytes() int { return c.maxBytes }
+
+func TestMeasureRequestBreakdown(t *testing.T) {
+	agent := makeAgentWithScriptedClient(1, NewScriptedClient())
+	agent.client = &requestLimitedClient{ScriptedClient: NewScriptedClient(), maxBytes: 1000}
+	agent.maxContextTokens = 2000
+	ac := NewAPIClient(agent)
+
+	messages := []api.Message{
+		{Role: "system", Content: "system"},
+		{Role: "user", Content: "summarize this"},
+		{Role: "tool", Content: "Tool call result for read_file: big.log\n" + strings.Repeat("line of log output\n", 500)},
+	}
+	tools := []api.Tool{{Type: "function"}, {Type: "function"}}
+	budget := ac.measureRequest(messages, tools)
+
+	if budget.ToolTokens != 2*api.ToolTokenEstimate || budget.ToolCount != 2 {
+		t.Fatalf("unexpected tool accounting: %+v", budget)
+	}
+	if budget.TotalTokens != budget.MessageTokens+budget.ToolTokens+api.MinOutputTokens {
+		t.Fatalf("expected total to include the reserved completion: %+v", budget)
+	}
+	if !budget.OverContext() || !budget.OverRequestSize() {
+		t.Fatalf("expected both limits to be exceeded: %+v", budget)
+	}
+	if budget.Largest[0].Index != 2 || budget.Largest[0].Role != "tool" {
+		t.Fatalf("expected the tool result to be the largest message, got %+v", budget.Largest[0])
+	}
+	breakdown := budget.Breakdown()
+	for _, want := range []string{"reserved completion 512", "context window 2000", "provider limit 1000 B", "#2 tool"} {
+		if !strings.Contains(breakdown, want) {
+			t.Fatalf("expected breakdown to contain %q, got:\n%s", want, breakdown)
+		}
+	}
+}
+
+func TestOversizedRequestFailsFastWithoutSending(t *testing.T) {
+	client := NewScriptedClient(stopResponse())
+	agent := makeAgentWithScriptedClient(3, client)
+	agent.maxContextTokens = 3000
+	document := "Review this:\n" + strings.Repeat("a very long pasted document line\n", 2000)
+
+	_, err := NewAPIClient(agent).SendWithRetry([]api.Message{{Role: "user", Content: document}}, nil, "")
+	var tooLarge *RequestTooLargeError
+	if !errors.As(err, &tooLarge) {
+		t.Fatalf("expected RequestTooLargeError, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "exceeds the 3000-token context window") {
+		t.Fatalf("expected the context window in the error, got: %v", err)
+	}
+
+	output, err := agent.ProcessQuery(document)
+	if err != nil {
+		t.Fatalf("ProcessQuery: %v", err)
+	}
+	if !strings.Contains(output, "request too large for test/test-model") || !strings.Contains(output, "largest messages:") {
+		t.Fatalf("expected the size breakdown in the output, got:\n%s", output)
+	}
+	if sent := len(client.GetSentRequests()); sent != 0 {
+		t.Fatalf("expected no request to reach the provider, got %d", sent)
+	}
+}
+
+func TestRequestWithinLimitsIsSent(t *testing.T) {
+	client := NewScriptedClient(stopResponse())
+	agent := makeAgentWithScriptedClient(3, client)
+	agent.client = &requestLimitedClient{ScriptedClient: client, maxBytes: 1 << 20}
+	agent.maxContextTokens = 100000
+
+	if _, err := agent.ProcessQuery("hello"); err != nil {
+		t.Fatalf("ProcessQuery: %v", err)
+	}
+	if sent := len(client.GetSentRequests()); sent != 1 {
+		t.Fatalf("expected one request, got %d", sent)
+	}
+}
@@ -0,0 +1,121 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	api "github.com/alantheprice/ledit/pkg/agent_api"
+	"github.com/alantheprice/ledit/pkg/configuration"
+	"github.com/alantheprice/ledit/pkg/responsecache"
+)
+
+// noResponseCacheEnv bypasses the response cache. It is set by --no-cache,
+// and subagent processes inherit it.
+const noResponseCacheEnv = "LEDIT_NO_CACHE"
+
+const (
+	defaultResponseCacheTTL   = 7 * 24 * time.Hour
+	defaultResponseCacheBytes = 100 << 20
+)
+
+// ResponseCacheStats counts the requests answered from the response cache.
+type ResponseCacheStats struct {
+	Hits        int
+	SavedTokens int
+	SavedCost   float64
+}
+
+// ResponseCacheStats returns the response cache counters for this session.
+func (a *Agent) ResponseCacheStats() ResponseCacheStats {
+	return a.responseCacheStats
+}
+
+// responseCache returns the response cache when config.json enables it and
+// it is not bypassed for this run.
+func (a *Agent) responseCache() *responsecache.Cache {
+	if disabled, err := strconv.ParseBool(strings.TrimSpace(os.Getenv(noResponseCacheEnv))); err == nil && disabled {
+		return nil
+	}
+	config := a.GetConfig()
+	if config == nil || config.ResponseCache == nil || !config.ResponseCache.Enabled {
+		return nil
+	}
+	configDir, err := configuration.GetConfigDir()
+	if err != nil {
+		return nil
+	}
+	ttl := defaultResponseCacheTTL
+	if config.ResponseCache.TTLHours > 0 {
+		ttl = time.Duration(config.ResponseCache.TTLHours) * time.Hour
+	}
+	maxBytes := int64(defaultResponseCacheBytes)
+	if config.ResponseCache.MaxSizeMB > 0 {
+		maxBytes = int64(config.ResponseCache.MaxSizeMB) << 20
+	}
+	return responsecache.New(filepath.Join(configDir, "cache", "responses"), ttl, maxBytes)
+}
+
+// responseCacheKey returns the cache key for a request, or "" if it cannot
+// be computed.
+func (ac *APIClient) responseCacheKey(messages []api.Message, tools []api.Tool, reasoning string, disableThinking bool) string {
+	key, err := responsecache.Key(responsecache.Request{
+		Provider:        ac.agent.GetProvider(),
+		Model:           ac.agent.GetModel(),
+		Messages:        messages,
+		Tools:           tools,
+		Reasoning:       reasoning,
+		DisableThinking: disableThinking,
+	})
+	if err != nil {
+		ac.agent.debugLog("DEBUG: %v\n", err)
+		return ""
+	}
+	return key
+}
+
+// cachedResponse returns the cached response for a request. When streaming,
+// its text is replayed through the stream so it displays like a live one.
+func (ac *APIClient) cachedResponse(cache *responsecache.Cache, key string) (*api.ChatResponse, bool) {
+	if key == "" {
+		return nil, false
+	}
+	resp, ok := cache.Get(key)
+	if !ok || len(resp.Choices) == 0 {
+		return nil, false
+	}
+
+	ac.agent.responseCacheStats.Hits++
+	ac.agent.responseCacheStats.SavedTokens += resp.Usage.TotalTokens
+	ac.agent.responseCacheStats.SavedCost += resp.Usage.EstimatedCost
+	ac.agent.PrintLineAsync("[cache] Reusing a cached response (run with --no-cache to send the request)")
+
+	if ac.agent.streamingEnabled {
+		message := resp.Choices[0].Message
+		if message.ReasoningContent != "" {
+			ac.agent.reasoningBuffer.WriteString(message.ReasoningContent)
+			ac.agent.PublishStreamChunk(message.ReasoningContent, "reasoning")
+		}
+		if message.Content != "" {
+			ac.agent.streamingBuffer.WriteString(message.Content)
+			ac.agent.PublishStreamChunk(message.Content, "assistant_text")
+		}
+		if ac.agent.outputRouter != nil {
+			ac.agent.outputRouter.FlushStream()
+		}
+	}
+	return resp, true
+}
+
+// storeResponse caches a successful response. Failures only cost a future
+// cache hit, so they are logged and ignored.
+func (ac *APIClient) storeResponse(cache *responsecache.Cache, key string, resp *api.ChatResponse) {
+	if key == "" || resp == nil || len(resp.Choices) == 0 {
+		return
+	}
+	if err := cache.Put(key, ac.agent.GetProvider(), ac.agent.GetModel(), resp); err != nil {
+		ac.agent.debugLog("DEBUG: failed to cache response: %v\n", err)
+	}
+}
@@ -0,0 +1,86 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Agent run result statuses reported to automation consumers.
+const (
+	ResultStatusSuccess = "success"
+	ResultStatusPartial = "partial"
+	ResultStatusFailure = "failure"
+)
+
+// AgentResult is the machine-readable summary of a single agent run. It is the
+// final record emitted by headless output modes (e.g. `ledit agent --output json`).
+type AgentResult struct {
+	Status            string            `json:"status"`
+	TerminationReason string            `json:"termination_reason,omitempty"`
+	Response          string            `json:"response"`
+	Error             string            `json:"error,omitempty"`
+	Provider          string            `json:"provider"`
+	Model             string            `json:"model"`
+	SessionID         string            `json:"session_id,omitempty"`
+	Iterations        int               `json:"iterations"`
+	FilesChanged      []string          `json:"files_changed"`
+	Tokens            AgentResultTokens `json:"tokens"`
+	Cost              float64           `json:"cost"`
+	DurationMs        int64             `json:"duration_ms"`
+}
+
+// AgentResultTokens breaks down token usage for an AgentResult.
+type AgentResultTokens struct {
+	Prompt     int `json:"prompt"`
+	Completion int `json:"completion"`
+	Cached     int `json:"cached"`
+	Total      int `json:"total"`
+}
+
+// BuildResult assembles an AgentResult from the agent's current metrics and the
+// outcome of the most recent run.
+func (a *Agent) BuildResult(response string, runErr error, duration time.Duration) AgentResult {
+	result := AgentResult{
+		Status:            resultStatusFor(a.GetLastRunTerminationReason(), runErr),
+		TerminationReason: a.GetLastRunTerminationReason(),
+		Response:          response,
+		Provider:          a.GetProvider(),
+		Model:             a.GetModel(),
+		SessionID:         a.GetSessionID(),
+		Iterations:        a.GetCurrentIteration(),
+		FilesChanged:      a.GetTrackedFiles(),
+		Tokens: AgentResultTokens{
+			Prompt:     a.GetPromptTokens(),
+			Completion: a.GetCompletionTokens(),
+			Cached:     a.GetCachedTokens(),
+			Total:      a.GetTotalTokens(),
+		},
+		Cost:       a.GetTotalCost(),
+		DurationMs: duration.Milliseconds(),
+	}
+	if runErr != nil {
+		result.Error = runErr.Error()
+	}
+	if result.FilesChanged == nil {
+		result.FilesChanged = []string{}
+	}
+	return result
+}
+
+// resultStatusFor maps a run termination reason and error to a result status.
+// Runs that stopped early (iteration cap, interrupt) are reported as partial.
+func resultStatusFor(terminationReason string, runErr error) string {
+	if errors.Is(runErr, context.Canceled) {
+		return ResultStatusPartial
+	}
+	if runErr != nil {
+		return ResultStatusFailure
+	}
+	switch terminationReason {
+	case RunTerminationMaxIterations, RunTerminationInterrupted:
+		return ResultStatusPartial
+	default:
+		return ResultStatusSuccess
+	}
+}
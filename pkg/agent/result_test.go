@@ -0,0 +1,31 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestResultStatusFor(t *testing.T) {
+	tests := []struct {
+		name   string
+		reason string
+		err    error
+		want   string
+	}{
+		{"completed", RunTerminationCompleted, nil, ResultStatusSuccess},
+		{"no reason", "", nil, ResultStatusSuccess},
+		{"max iterations", RunTerminationMaxIterations, nil, ResultStatusPartial},
+		{"interrupted", RunTerminationInterrupted, nil, ResultStatusPartial},
+		{"cancelled", "", fmt.Errorf("query interrupted: %w", context.Canceled), ResultStatusPartial},
+		{"error", RunTerminationCompleted, errors.New("boom"), ResultStatusFailure},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resultStatusFor(tt.reason, tt.err); got != tt.want {
+				t.Fatalf("resultStatusFor(%q, %v) = %q, want %q", tt.reason, tt.err, got, tt.want)
+			}
+		})
+	}
+}
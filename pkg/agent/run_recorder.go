@@ -0,0 +1,272 @@
+// Run recording: captures every LLM exchange, tool call and file mutation of a
+// run into a single archive so it can be replayed offline.
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	api "github.com/alantheprice/ledit/pkg/agent_api"
+	"github.com/alantheprice/ledit/pkg/configuration"
+)
+
+// RunArchiveVersion is the current run archive format version.
+const RunArchiveVersion = 1
+
+const (
+	runArchiveDirName = "runs"
+	runArchiveExt     = ".json"
+)
+
+// RunArchive is the on-disk record of a single agent run.
+type RunArchive struct {
+	Version          int                    `json:"version"`
+	RunID            string                 `json:"run_id"`
+	CreatedAt        time.Time              `json:"created_at"`
+	Provider         string                 `json:"provider"`
+	Model            string                 `json:"model"`
+	WorkingDirectory string                 `json:"working_directory"`
+	Queries          []string               `json:"queries"`
+	Exchanges        []RecordedExchange     `json:"exchanges"`
+	ToolCalls        []RecordedToolCall     `json:"tool_calls"`
+	FileMutations    []RecordedFileMutation `json:"file_mutations"`
+}
+
+// RecordedExchange is one LLM request and the response (or error) it produced.
+type RecordedExchange struct {
+	Index    int               `json:"index"`
+	Kind     string            `json:"kind"` // chat, stream, vision
+	Messages []api.Message     `json:"messages"`
+	Response *api.ChatResponse `json:"response,omitempty"`
+	Error    string            `json:"error,omitempty"`
+}
+
+// RecordedToolCall is one tool execution.
+type RecordedToolCall struct {
+	Index     int    `json:"index"`
+	Iteration int    `json:"iteration"`
+	ToolName  string `json:"tool_name"`
+	Arguments string `json:"arguments"`
+	Result    string `json:"result,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// RecordedFileMutation is one file write or edit performed by a tool.
+type RecordedFileMutation struct {
+	Index     int    `json:"index"`
+	Path      string `json:"path"`
+	Operation string `json:"operation"` // write, edit
+	Before    string `json:"before,omitempty"`
+	After     string `json:"after"`
+}
+
+// RunRecorder accumulates a RunArchive while a run is in progress.
+type RunRecorder struct {
+	mu      sync.Mutex
+	dir     string
+	archive RunArchive
+}
+
+// NewRunRecorder creates a recorder that saves archives into dir. An empty dir
+// uses the default runs directory under the ledit config directory.
+func NewRunRecorder(dir, provider, model string) (*RunRecorder, error) {
+	if strings.TrimSpace(dir) == "" {
+		defaultDir, err := DefaultRunsDir()
+		if err != nil {
+			return nil, err
+		}
+		dir = defaultDir
+	}
+	workingDir, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve working directory: %w", err)
+	}
+	now := time.Now()
+	return &RunRecorder{
+		dir: dir,
+		archive: RunArchive{
+			Version:          RunArchiveVersion,
+			RunID:            fmt.Sprintf("run_%s_%d", now.Format("20060102_150405"), now.UnixNano()%1_000_000),
+			CreatedAt:        now.UTC(),
+			Provider:         provider,
+			Model:            model,
+			WorkingDirectory: workingDir,
+		},
+	}, nil
+}
+
+// DefaultRunsDir returns the directory where run archives are stored.
+func DefaultRunsDir() (string, error) {
+	configDir, err := configuration.GetConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get config directory: %w", err)
+	}
+	return filepath.Join(configDir, runArchiveDirName), nil
+}
+
+// ResolveRunArchivePath maps a run ID or archive path to an archive file path.
+func ResolveRunArchivePath(runIDOrPath string) (string, error) {
+	ref := strings.TrimSpace(runIDOrPath)
+	if ref == "" {
+		return "", fmt.Errorf("run ID cannot be empty")
+	}
+	if strings.HasSuffix(ref, runArchiveExt) || strings.ContainsRune(ref, os.PathSeparator) {
+		return ref, nil
+	}
+	dir, err := DefaultRunsDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, ref+runArchiveExt), nil
+}
+
+// LoadRunArchive reads a run archive from disk.
+func LoadRunArchive(path string) (*RunArchive, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read run archive: %w", err)
+	}
+	var archive RunArchive
+	if err := json.Unmarshal(data, &archive); err != nil {
+		return nil, fmt.Errorf("failed to parse run archive %s: %w", path, err)
+	}
+	if archive.Version > RunArchiveVersion {
+		return nil, fmt.Errorf("run archive version %d is newer than supported version %d", archive.Version, RunArchiveVersion)
+	}
+	return &archive, nil
+}
+
+// RunID returns the ID of the run being recorded.
+func (r *RunRecorder) RunID() string {
+	return r.archive.RunID
+}
+
+// Archive returns a snapshot of the recorded data.
+func (r *RunRecorder) Archive() RunArchive {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	snapshot := r.archive
+	snapshot.Queries = append([]string(nil), r.archive.Queries...)
+	snapshot.Exchanges = append([]RecordedExchange(nil), r.archive.Exchanges...)
+	snapshot.ToolCalls = append([]RecordedToolCall(nil), r.archive.ToolCalls...)
+	snapshot.FileMutations = append([]RecordedFileMutation(nil), r.archive.FileMutations...)
+	return snapshot
+}
+
+// Save writes the archive to disk and returns its path.
+func (r *RunRecorder) Save() (string, error) {
+	archive := r.Archive()
+	if err := os.MkdirAll(r.dir, 0o700); err != nil {
+		return "", fmt.Errorf("failed to create runs directory: %w", err)
+	}
+	data, err := json.MarshalIndent(archive, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal run archive: %w", err)
+	}
+	path := filepath.Join(r.dir, archive.RunID+runArchiveExt)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return "", fmt.Errorf("failed to write run archive: %w", err)
+	}
+	return path, nil
+}
+
+func (r *RunRecorder) recordQuery(query string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.archive.Queries = append(r.archive.Queries, query)
+}
+
+func (r *RunRecorder) recordExchange(kind string, messages []api.Message, resp *api.ChatResponse, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	exchange := RecordedExchange{
+		Index:    len(r.archive.Exchanges),
+		Kind:     kind,
+		Messages: append([]api.Message(nil), messages...),
+		Response: resp,
+	}
+	if err != nil {
+		exchange.Error = err.Error()
+	}
+	r.archive.Exchanges = append(r.archive.Exchanges, exchange)
+}
+
+func (r *RunRecorder) recordToolCall(iteration int, toolName, arguments, result string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	call := RecordedToolCall{
+		Index:     len(r.archive.ToolCalls),
+		Iteration: iteration,
+		ToolName:  toolName,
+		Arguments: arguments,
+		Result:    result,
+	}
+	if err != nil {
+		call.Error = err.Error()
+	}
+	r.archive.ToolCalls = append(r.archive.ToolCalls, call)
+}
+
+func (r *RunRecorder) recordFileMutation(path, operation, before, after string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.archive.FileMutations = append(r.archive.FileMutations, RecordedFileMutation{
+		Index:     len(r.archive.FileMutations),
+		Path:      path,
+		Operation: operation,
+		Before:    before,
+		After:     after,
+	})
+}
+
+// recordingClient wraps a provider client and records every exchange.
+type recordingClient struct {
+	api.ClientInterface
+	recorder *RunRecorder
+}
+
+func (c *recordingClient) SendChatRequest(messages []api.Message, tools []api.Tool, reasoning string, disableThinking bool) (*api.ChatResponse, error) {
+	resp, err := c.ClientInterface.SendChatRequest(messages, tools, reasoning, disableThinking)
+	c.recorder.recordExchange("chat", messages, resp, err)
+	return resp, err
+}
+
+func (c *recordingClient) SendChatRequestStream(messages []api.Message, tools []api.Tool, reasoning string, disableThinking bool, callback api.StreamCallback) (*api.ChatResponse, error) {
+	resp, err := c.ClientInterface.SendChatRequestStream(messages, tools, reasoning, disableThinking, callback)
+	c.recorder.recordExchange("stream", messages, resp, err)
+	return resp, err
+}
+
+func (c *recordingClient) SendVisionRequest(messages []api.Message, tools []api.Tool, reasoning string, disableThinking bool) (*api.ChatResponse, error) {
+	resp, err := c.ClientInterface.SendVisionRequest(messages, tools, reasoning, disableThinking)
+	c.recorder.recordExchange("vision", messages, resp, err)
+	return resp, err
+}
+
+// EnableRunRecording starts recording the agent's LLM exchanges, tool calls and
+// file mutations into recorder.
+func (a *Agent) EnableRunRecording(recorder *RunRecorder) {
+	a.runRecorder = recorder
+	a.client = a.wrapRecordingClient(a.client)
+}
+
+// GetRunRecorder returns the active run recorder (can be nil).
+func (a *Agent) GetRunRecorder() *RunRecorder {
+	return a.runRecorder
+}
+
+// wrapRecordingClient wraps client for recording when a recorder is active.
+func (a *Agent) wrapRecordingClient(client api.ClientInterface) api.ClientInterface {
+	if a.runRecorder == nil || client == nil {
+		return client
+	}
+	if existing, ok := client.(*recordingClient); ok {
+		client = existing.ClientInterface
+	}
+	return &recordingClient{ClientInterface: client, recorder: a.runRecorder}
+}
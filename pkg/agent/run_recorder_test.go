@@ -0,0 +1,110 @@
+package agent
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunRecorderRecordAndReplay(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(target, []byte("hello\n"), 0o644); err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+	args := `{"path":"` + target + `"}`
+
+	recorder, err := NewRunRecorder(dir, "test", "scripted")
+	if err != nil {
+		t.Fatalf("NewRunRecorder: %v", err)
+	}
+	recorded := makeAgentWithScriptedClient(5, NewScriptedClient(
+		NewToolCallResponse("read_file", args),
+		NewStopResponse("The file says hello."),
+	))
+	recorded.EnableRunRecording(recorder)
+	if _, err := recorded.ProcessQuery("What does notes.txt say?"); err != nil {
+		t.Fatalf("recorded ProcessQuery: %v", err)
+	}
+
+	path, err := recorder.Save()
+	if err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	archive, err := LoadRunArchive(path)
+	if err != nil {
+		t.Fatalf("LoadRunArchive: %v", err)
+	}
+	if len(archive.Queries) != 1 || archive.Queries[0] != "What does notes.txt say?" {
+		t.Fatalf("unexpected recorded queries: %v", archive.Queries)
+	}
+	if len(archive.Exchanges) < 2 {
+		t.Fatalf("expected at least 2 recorded exchanges, got %d", len(archive.Exchanges))
+	}
+	if len(archive.ToolCalls) != 1 || archive.ToolCalls[0].ToolName != "read_file" {
+		t.Fatalf("unexpected recorded tool calls: %+v", archive.ToolCalls)
+	}
+
+	replayRecorder, err := NewRunRecorder(dir, archive.Provider, archive.Model)
+	if err != nil {
+		t.Fatalf("NewRunRecorder: %v", err)
+	}
+	replayClient := NewReplayClient(archive)
+	replayed := makeAgentWithScriptedClient(5, NewScriptedClient())
+	replayed.EnableRunRecording(replayRecorder)
+	replayed.UseReplayClient(replayClient)
+	if _, err := replayed.ProcessQuery(archive.Queries[0]); err != nil {
+		t.Fatalf("replayed ProcessQuery: %v", err)
+	}
+
+	if divergences := CompareToolSequences(archive.ToolCalls, replayRecorder.Archive().ToolCalls); len(divergences) != 0 {
+		t.Fatalf("expected replay to match recorded tool sequence, got %+v", divergences)
+	}
+	if remaining := replayClient.Remaining(); remaining != 0 {
+		t.Fatalf("expected all recorded responses to be replayed, %d left", remaining)
+	}
+	if divergent := replayClient.DivergentRequests(); len(divergent) != 0 {
+		t.Fatalf("expected identical requests on replay, got divergent exchanges %v", divergent)
+	}
+}
+
+func TestReplayClientExhausted(t *testing.T) {
+	client := NewReplayClient(&RunArchive{})
+	if _, err := client.SendChatRequest(nil, nil, "", false); !errors.Is(err, ErrReplayExhausted) {
+		t.Fatalf("expected ErrReplayExhausted, got %v", err)
+	}
+}
+
+func TestCompareToolSequences(t *testing.T) {
+	read := RecordedToolCall{ToolName: "read_file", Arguments: `{"path":"a"}`}
+	readOther := RecordedToolCall{ToolName: "read_file", Arguments: `{"path":"b"}`}
+	write := RecordedToolCall{ToolName: "write_file", Arguments: `{"path":"a"}`}
+
+	tests := []struct {
+		name     string
+		expected []RecordedToolCall
+		actual   []RecordedToolCall
+		reasons  []string
+	}{
+		{name: "identical", expected: []RecordedToolCall{read, write}, actual: []RecordedToolCall{read, write}},
+		{name: "different tool", expected: []RecordedToolCall{read}, actual: []RecordedToolCall{write}, reasons: []string{"tool name differs"}},
+		{name: "different arguments", expected: []RecordedToolCall{read}, actual: []RecordedToolCall{readOther}, reasons: []string{"tool arguments differ"}},
+		{name: "missing call", expected: []RecordedToolCall{read, write}, actual: []RecordedToolCall{read}, reasons: []string{"tool call missing from replay"}},
+		{name: "extra call", expected: []RecordedToolCall{read}, actual: []RecordedToolCall{read, write}, reasons: []string{"unexpected extra tool call"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CompareToolSequences(tt.expected, tt.actual)
+			if len(got) != len(tt.reasons) {
+				t.Fatalf("expected %d divergences, got %+v", len(tt.reasons), got)
+			}
+			for i, reason := range tt.reasons {
+				if got[i].Reason != reason {
+					t.Fatalf("divergence %d: expected %q, got %q", i, reason, got[i].Reason)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+// Run replay: serves recorded LLM responses in order so a recorded run can be
+// reproduced offline and its tool execution sequence compared.
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	api "github.com/alantheprice/ledit/pkg/agent_api"
+	"github.com/alantheprice/ledit/pkg/configuration"
+)
+
+// ErrReplayExhausted is returned when a replay requests more LLM responses
+// than the archive recorded, which means the run diverged.
+var ErrReplayExhausted = errors.New("replay exhausted: no more recorded LLM responses")
+
+// Compile-time check that ReplayClient implements api.ClientInterface
+var _ api.ClientInterface = (*ReplayClient)(nil)
+
+// ReplayClient is a mock provider client that returns the responses recorded in
+// a RunArchive, in order.
+type ReplayClient struct {
+	mu        sync.Mutex
+	archive   *RunArchive
+	index     int
+	model     string
+	debug     bool
+	divergent []int
+}
+
+// NewReplayClient creates a client that replays archive's LLM exchanges.
+func NewReplayClient(archive *RunArchive) *ReplayClient {
+	return &ReplayClient{archive: archive, model: archive.Model}
+}
+
+// Remaining returns the number of recorded exchanges not yet replayed.
+func (c *ReplayClient) Remaining() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.archive.Exchanges) - c.index
+}
+
+// DivergentRequests returns the indexes of exchanges whose replayed request had
+// a different message count than the recorded one.
+func (c *ReplayClient) DivergentRequests() []int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]int(nil), c.divergent...)
+}
+
+func (c *ReplayClient) next(messages []api.Message) (*api.ChatResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.index >= len(c.archive.Exchanges) {
+		return nil, ErrReplayExhausted
+	}
+	exchange := c.archive.Exchanges[c.index]
+	c.index++
+	if len(exchange.Messages) != len(messages) {
+		c.divergent = append(c.divergent, exchange.Index)
+	}
+	if exchange.Error != "" {
+		return nil, fmt.Errorf("recorded provider error: %s", exchange.Error)
+	}
+	if exchange.Response == nil {
+		return nil, fmt.Errorf("recorded exchange %d has no response", exchange.Index)
+	}
+	resp := *exchange.Response
+	return &resp, nil
+}
+
+func (c *ReplayClient) SendChatRequest(messages []api.Message, tools []api.Tool, reasoning string, disableThinking bool) (*api.ChatResponse, error) {
+	return c.next(messages)
+}
+
+func (c *ReplayClient) SendChatRequestStream(messages []api.Message, tools []api.Tool, reasoning string, disableThinking bool, callback api.StreamCallback) (*api.ChatResponse, error) {
+	resp, err := c.next(messages)
+	if err != nil || callback == nil || len(resp.Choices) == 0 {
+		return resp, err
+	}
+	if reasoning := resp.Choices[0].Message.ReasoningContent; reasoning != "" {
+		callback(reasoning, "reasoning")
+	}
+	if content := resp.Choices[0].Message.Content; content != "" {
+		callback(content, "assistant_text")
+	}
+	return resp, nil
+}
+
+func (c *ReplayClient) SendVisionRequest(messages []api.Message, tools []api.Tool, reasoning string, disableThinking bool) (*api.ChatResponse, error) {
+	return c.next(messages)
+}
+
+func (c *ReplayClient) CheckConnection() error { return nil }
+
+func (c *ReplayClient) SetDebug(debug bool) { c.debug = debug }
+
+func (c *ReplayClient) SetModel(model string) error {
+	c.model = model
+	return nil
+}
+
+func (c *ReplayClient) GetModel() string { return c.model }
+
+func (c *ReplayClient) GetProvider() string { return c.archive.Provider }
+
+func (c *ReplayClient) GetModelContextLimit() (int, error) { return 128000, nil }
+
+func (c *ReplayClient) ListModels(ctx context.Context) ([]api.ModelInfo, error) {
+	return []api.ModelInfo{{ID: c.model, Name: c.model}}, nil
+}
+
+func (c *ReplayClient) SupportsVision() bool { return true }
+
+func (c *ReplayClient) GetVisionModel() string { return c.model }
+
+func (c *ReplayClient) GetLastTPS() float64 { return 0 }
+
+func (c *ReplayClient) GetAverageTPS() float64 { return 0 }
+
+func (c *ReplayClient) GetTPSStats() map[string]float64 { return map[string]float64{} }
+
+func (c *ReplayClient) ResetTPSStats() {}
+
+// NewReplayAgent creates an agent that serves archive's recorded LLM responses
+// instead of calling a provider. No provider configuration or API key is
+// required, and the provider selection in the user's config is left untouched.
+func NewReplayAgent(archive *RunArchive) (*Agent, *ReplayClient, error) {
+	configManager, err := configuration.NewManagerSilent()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize configuration: %w", err)
+	}
+	workspaceRoot, err := os.Getwd()
+	if err != nil {
+		workspaceRoot = "."
+	}
+	if absWorkspaceRoot, absErr := filepath.Abs(workspaceRoot); absErr == nil {
+		workspaceRoot = absWorkspaceRoot
+	}
+
+	client := NewReplayClient(archive)
+	agent, err := newOfflineAgent(configManager, workspaceRoot, client, api.TestClientType)
+	if err != nil {
+		return nil, nil, err
+	}
+	return agent, client, nil
+}
+
+// UseReplayClient replaces the agent's provider client with a replay client.
+func (a *Agent) UseReplayClient(client *ReplayClient) {
+	a.client = a.wrapRecordingClient(client)
+}
+
+// ToolSequenceDivergence describes where a replayed tool sequence differs from
+// the recorded one.
+type ToolSequenceDivergence struct {
+	Index    int               `json:"index"`
+	Expected *RecordedToolCall `json:"expected,omitempty"`
+	Actual   *RecordedToolCall `json:"actual,omitempty"`
+	Reason   string            `json:"reason"`
+}
+
+// CompareToolSequences compares recorded and replayed tool calls by name and
+// arguments, in order.
+func CompareToolSequences(expected, actual []RecordedToolCall) []ToolSequenceDivergence {
+	var divergences []ToolSequenceDivergence
+	count := len(expected)
+	if len(actual) > count {
+		count = len(actual)
+	}
+	for i := 0; i < count; i++ {
+		switch {
+		case i >= len(actual):
+			exp := expected[i]
+			divergences = append(divergences, ToolSequenceDivergence{Index: i, Expected: &exp, Reason: "tool call missing from replay"})
+		case i >= len(expected):
+			act := actual[i]
+			divergences = append(divergences, ToolSequenceDivergence{Index: i, Actual: &act, Reason: "unexpected extra tool call"})
+		case expected[i].ToolName != actual[i].ToolName:
+			exp, act := expected[i], actual[i]
+			divergences = append(divergences, ToolSequenceDivergence{Index: i, Expected: &exp, Actual: &act, Reason: "tool name differs"})
+		case expected[i].Arguments != actual[i].Arguments:
+			exp, act := expected[i], actual[i]
+			divergences = append(divergences, ToolSequenceDivergence{Index: i, Expected: &exp, Actual: &act, Reason: "tool arguments differ"})
+		}
+	}
+	return divergences
+}
@@ -0,0 +1,65 @@
+package agent
+
+import (
+	"os"
+	"time"
+
+	"github.com/alantheprice/ledit/pkg/configuration"
+	"github.com/alantheprice/ledit/pkg/runstats"
+)
+
+// noRunStatsEnv turns off recording runs for `ledit stats`.
+const noRunStatsEnv = "LEDIT_NO_STATS"
+
+// runStatsBaseline is the agent's cumulative usage when a run starts, so the
+// run's own share can be recorded when it ends.
+type runStatsBaseline struct {
+	started                                           time.Time
+	promptTokens, completionTokens, cachedTokens, all int
+	cost                                              float64
+}
+
+func (a *Agent) startRunStats() runStatsBaseline {
+	return runStatsBaseline{
+		started:          time.Now(),
+		promptTokens:     a.GetPromptTokens(),
+		completionTokens: a.GetCompletionTokens(),
+		cachedTokens:     a.GetCachedTokens(),
+		all:              a.GetTotalTokens(),
+		cost:             a.GetTotalCost(),
+	}
+}
+
+// recordRunStats appends the finished run to the local run log read by
+// `ledit stats`. Subagent runs are left out because their usage is already
+// part of the parent's run, as are runs that sent no request.
+func (a *Agent) recordRunStats(base runStatsBaseline, runErr error) {
+	if a.configManager == nil || os.Getenv(noRunStatsEnv) == "1" || os.Getenv("LEDIT_SUBAGENT") == "1" {
+		return
+	}
+	configDir, err := configuration.GetConfigDir()
+	if err != nil {
+		return
+	}
+	run := runstats.Run{
+		Time:             time.Now(),
+		Project:          a.currentWorkspaceRoot(),
+		Provider:         a.GetProvider(),
+		Model:            a.GetModel(),
+		Status:           resultStatusFor(a.GetLastRunTerminationReason(), runErr),
+		DurationMs:       time.Since(base.started).Milliseconds(),
+		PromptTokens:     a.GetPromptTokens() - base.promptTokens,
+		CompletionTokens: a.GetCompletionTokens() - base.completionTokens,
+		CachedTokens:     a.GetCachedTokens() - base.cachedTokens,
+		TotalTokens:      a.GetTotalTokens() - base.all,
+		Cost:             a.GetTotalCost() - base.cost,
+		Iterations:       a.GetCurrentIteration(),
+		FilesChanged:     len(a.GetTrackedFiles()),
+	}
+	if run.TotalTokens == 0 && run.Cost == 0 && runErr == nil {
+		return
+	}
+	if err := runstats.Record(configDir, run); err != nil {
+		a.debugLog("Failed to record run stats: %v\n", err)
+	}
+}
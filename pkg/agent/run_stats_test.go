@@ -0,0 +1,44 @@
+package agent
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/alantheprice/ledit/pkg/configuration"
+	"github.com/alantheprice/ledit/pkg/runstats"
+)
+
+func TestRecordRunStatsStoresTheRunsOwnUsage(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv("LEDIT_CONFIG", configDir)
+	t.Setenv("LEDIT_SUBAGENT", "")
+	t.Setenv(noRunStatsEnv, "")
+
+	a := makeAgentWithScriptedClient(5, NewScriptedClient())
+	a.configManager = configuration.NewManagerWithConfig(configuration.NewConfig(), nil)
+	a.SetWorkspaceRoot("/src/project")
+	a.TrackMetricsFromResponse(1000, 200, 1200, 0.05, 0) // an earlier query
+
+	base := a.startRunStats()
+	a.recordRunStats(base, nil) // nothing was sent
+	a.TrackMetricsFromResponse(300, 100, 400, 0.01, 50)
+	a.recordRunStats(base, errors.New("provider down"))
+
+	runs, err := runstats.Load(configDir, time.Time{})
+	if err != nil || len(runs) != 1 {
+		t.Fatalf("expected one recorded run, got %+v, %v", runs, err)
+	}
+	run := runs[0]
+	if run.Project != "/src/project" || run.Status != ResultStatusFailure || run.PromptTokens != 300 ||
+		run.CompletionTokens != 100 || run.CachedTokens != 50 || run.TotalTokens != 400 || math.Abs(run.Cost-0.01) > 1e-9 {
+		t.Fatalf("unexpected run %+v", run)
+	}
+
+	t.Setenv("LEDIT_SUBAGENT", "1")
+	a.recordRunStats(a.startRunStats(), errors.New("subagent failed"))
+	if runs, _ := runstats.Load(configDir, time.Time{}); len(runs) != 1 {
+		t.Fatalf("expected subagent runs not to be recorded, got %d runs", len(runs))
+	}
+}
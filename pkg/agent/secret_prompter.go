@@ -50,14 +50,18 @@ func (a *secretPrompterAdapter) PromptSecretAction(secrets []security.DetectedSe
 	}
 }
 
-// isSecretSensitiveTool returns true for tools that can return secrets in their output.
-func isSecretSensitiveTool(toolName string) bool {
-	switch toolName {
-	case "shell_command", "read_file", "search_files",
-		"write_file", "edit_file", "write_structured_file", "patch_structured_file":
-		return true
+// secretRedactionEnabled reports whether tool output should be scanned and
+// redacted before it is sent to the provider (config: secret_redaction_enabled).
+func (a *Agent) secretRedactionEnabled() bool {
+	if a.outputRedactor == nil {
+		return false
 	}
-	return false
+	if a.configManager != nil {
+		if cfg := a.configManager.GetConfig(); cfg != nil {
+			return cfg.GetSecretRedactionEnabled()
+		}
+	}
+	return true
 }
 
 // SetElevationGatePrompter wires the agent's interactive UI into the elevation gate.
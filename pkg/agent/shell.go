@@ -88,7 +88,8 @@ func (a *Agent) executeShellCommandWithTruncation(ctx context.Context, command s
 			fullOutputPath = outputPath
 		}
 
-		truncationNotice := buildTruncationNotice(topTokens, bottomTokens, truncatedTokens, truncatedLines, fullOutputPath, saveErr)
+		continuation := a.storeToolOutput(fullResult, topEndIndex)
+		truncationNotice := buildTruncationNotice(topTokens, bottomTokens, truncatedTokens, truncatedLines, fullOutputPath, saveErr, continuation)
 
 		var builder strings.Builder
 		builder.WriteString(topSegment)
@@ -140,18 +141,21 @@ func countLinesInSegment(segment string) int {
 	return lines
 }
 
-func buildTruncationNotice(headTokens, tailTokens, truncatedTokens, truncatedLines int, outputPath string, saveErr error) string {
+func buildTruncationNotice(headTokens, tailTokens, truncatedTokens, truncatedLines int, outputPath string, saveErr error, continuation string) string {
+	notice := fmt.Sprintf("Output truncated: omitted %d middle token(s) across ~%d line(s). Showing first %d tokens and last %d tokens.",
+		truncatedTokens, truncatedLines, headTokens, tailTokens)
+	if continuation != "" {
+		notice += " " + continuationHint(continuation)
+	}
+
 	if outputPath == "" {
 		if saveErr != nil {
-			return fmt.Sprintf("[Output truncated: omitted %d middle token(s) across ~%d line(s). Showing first %d tokens and last %d tokens. Failed to save full output: %v]",
-				truncatedTokens, truncatedLines, headTokens, tailTokens, saveErr)
+			return fmt.Sprintf("[%s Failed to save full output: %v]", notice, saveErr)
 		}
-		return fmt.Sprintf("[Output truncated: omitted %d middle token(s) across ~%d line(s). Showing first %d tokens and last %d tokens. Full output path unavailable]",
-			truncatedTokens, truncatedLines, headTokens, tailTokens)
+		return fmt.Sprintf("[%s Full output path unavailable]", notice)
 	}
 
-	return fmt.Sprintf("[Output truncated: omitted %d middle token(s) across ~%d line(s). Showing first %d tokens and last %d tokens. Full output saved to %s]",
-		truncatedTokens, truncatedLines, headTokens, tailTokens, outputPath)
+	return fmt.Sprintf("[%s Full output saved to %s]", notice, outputPath)
 }
 
 func (a *Agent) saveShellOutputToFile(output string) (string, error) {
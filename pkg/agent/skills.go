@@ -183,10 +183,12 @@ func handleActivateSkill(ctx context.Context, a *Agent, args map[string]interfac
 	// all subsequent turns without relying on history system-message injection.
 	skillMessage := fmt.Sprintf("[Skill Activated: %s]\n\n%s", skillInfo.Name, skillInfo.Content)
 	if strings.TrimSpace(a.systemPrompt) != "" {
-		a.systemPrompt = a.systemPrompt + "\n\n---\n\n" + skillMessage
+		skillMessage = "\n\n---\n\n" + skillMessage
+		a.systemPrompt = a.systemPrompt + skillMessage
 	} else {
 		a.systemPrompt = skillMessage
 	}
+	a.skillPrompt += skillMessage
 
 	return fmt.Sprintf("Activated skill '%s' (%s).\n\nDescription: %s\n\nInstructions loaded into context.", skillInfo.Name, skillID, skillInfo.Description), nil
 }
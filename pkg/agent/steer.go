@@ -0,0 +1,94 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	api "github.com/alantheprice/ledit/pkg/agent_api"
+)
+
+// Steer interrupts the running query with a correction from the user. Unlike
+// InjectInputContext, which queues a message for the next iteration, a steer
+// also pauses the current tool chain: the tool call in progress finishes, the
+// calls after it are skipped, and the model sees the correction as a
+// high-priority system instruction before it continues.
+func (a *Agent) Steer(correction string) error {
+	correction = strings.TrimSpace(correction)
+	if correction == "" {
+		return errors.New("steer correction is empty")
+	}
+	a.steerMu.Lock()
+	defer a.steerMu.Unlock()
+	if len(a.pendingSteers) >= inputInjectionBufferSize {
+		return errors.New("failed to steer: too many corrections are waiting")
+	}
+	a.pendingSteers = append(a.pendingSteers, correction)
+	return nil
+}
+
+// hasPendingSteer reports whether a correction is waiting to be applied.
+func (a *Agent) hasPendingSteer() bool {
+	a.steerMu.Lock()
+	defer a.steerMu.Unlock()
+	return len(a.pendingSteers) > 0
+}
+
+// takePendingSteers returns and clears the waiting corrections.
+func (a *Agent) takePendingSteers() []string {
+	a.steerMu.Lock()
+	defer a.steerMu.Unlock()
+	steers := a.pendingSteers
+	a.pendingSteers = nil
+	return steers
+}
+
+// steeredToolResults gives each tool call that was skipped because of a
+// steer a result, so the conversation stays valid.
+func (te *ToolExecutor) steeredToolResults(toolCalls []api.ToolCall) []api.Message {
+	results := make([]api.Message, 0, len(toolCalls))
+	for _, tc := range toolCalls {
+		toolCallID := tc.ID
+		if toolCallID == "" {
+			toolCallID = te.GenerateToolCallID(tc.Function.Name)
+		}
+		results = append(results, api.Message{
+			Role:       "tool",
+			Content:    fmt.Sprintf("Skipped: the user sent a correction before this call ran. Call %s again only if it still fits the corrected plan.", tc.Function.Name),
+			ToolCallId: toolCallID,
+		})
+	}
+	return results
+}
+
+// applyPendingSteers adds waiting corrections to the conversation: as a user
+// message, so they stay in the history, and as a one-shot system supplement
+// telling the model to revise its plan before doing anything else.
+func (ch *ConversationHandler) applyPendingSteers() bool {
+	steers := ch.agent.takePendingSteers()
+	if len(steers) == 0 {
+		return false
+	}
+	correction := strings.Join(steers, "\n\n")
+	ch.agent.debugLog("[steer] Applying correction: %s\n", correction)
+	ch.agent.PrintLineAsync("[steer] Pausing to apply your correction")
+
+	ch.agent.messages = append(ch.agent.messages, api.Message{
+		Role:    "user",
+		Content: ch.prepareUserInputForModel("Correction: " + correction),
+	})
+	supplement := steerSupplement(correction)
+	if existing := ch.agent.consumePendingSystemSupplement(); existing != "" {
+		supplement = existing + "\n\n---\n\n" + supplement
+	}
+	ch.agent.setPendingSystemSupplement(supplement)
+	return true
+}
+
+// steerSupplement is the system instruction that accompanies a correction.
+func steerSupplement(correction string) string {
+	return "## User Correction (highest priority)\n\n" +
+		"The user interrupted the task to correct you:\n\n" + correction + "\n\n" +
+		"This overrides any earlier instruction or plan it conflicts with. Tool calls you made after the correction arrived were skipped. " +
+		"Before calling any more tools, briefly restate your revised plan, then continue with it."
+}
@@ -0,0 +1,110 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	api "github.com/alantheprice/ledit/pkg/agent_api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readFileCall(id, path string) api.ToolCall {
+	return api.ToolCall{
+		ID:   id,
+		Type: "function",
+		Function: struct {
+			Name      string `json:"name"`
+			Arguments string `json:"arguments"`
+		}{Name: "read_file", Arguments: fmt.Sprintf(`{"file_path":"%s"}`, path)},
+	}
+}
+
+// runWithSteer runs query and steers with correction while the first
+// response is in flight.
+func runWithSteer(t *testing.T, agent *Agent, query, correction string) (string, error) {
+	t.Helper()
+	var (
+		result string
+		err    error
+		wg     sync.WaitGroup
+	)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		result, err = agent.ProcessQuery(query)
+	}()
+	time.Sleep(100 * time.Millisecond)
+	require.NoError(t, agent.Steer(correction))
+	wg.Wait()
+	return result, err
+}
+
+func TestSteerSkipsToolCallsAndRevisesPlan(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	fileA, fileB := filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt")
+	require.NoError(t, os.WriteFile(fileA, []byte("alpha"), 0o644))
+	require.NoError(t, os.WriteFile(fileB, []byte("bravo"), 0o644))
+
+	first := NewScriptedResponseBuilder().
+		Content("Reading both files.").
+		ToolCalls([]api.ToolCall{readFileCall("call_1", fileA), readFileCall("call_2", fileB)}).
+		Delay(300 * time.Millisecond).
+		Build()
+	agent, _, client := buildE2EAgentWithClient(t, 10, first, stopResponse())
+
+	result, err := runWithSteer(t, agent, "Summarize the files", "Only look at b.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "Done.", result)
+
+	toolMsgs := findToolMessages(agent.messages)
+	require.Len(t, toolMsgs, 2)
+	for _, msg := range toolMsgs {
+		assert.Contains(t, msg.Content, "Skipped: the user sent a correction")
+	}
+	assertMessageOrdering(t, agent.messages, []string{"user", "assistant", "tool", "tool", "user", "assistant"})
+	assert.Contains(t, agent.messages[4].Content, "Only look at b.txt")
+
+	sent := client.GetSentRequests()
+	require.Len(t, sent, 2)
+	require.Equal(t, "system", sent[1][0].Role)
+	assert.Contains(t, sent[1][0].Content, "User Correction")
+	assert.Contains(t, sent[1][0].Content, "Only look at b.txt")
+	assert.NotContains(t, agent.messages[0].Content, "User Correction", "the supplement is one-shot")
+}
+
+func TestSteerDuringFinalResponseContinues(t *testing.T) {
+	t.Parallel()
+
+	final := NewScriptedResponseBuilder().
+		Content("All finished.").
+		FinishReason("stop").
+		Delay(300 * time.Millisecond).
+		Build()
+	agent, _, client := buildE2EAgentWithClient(t, 10, final, stopResponse())
+
+	result, err := runWithSteer(t, agent, "Do the task", "Also update the README")
+	require.NoError(t, err)
+	assert.Equal(t, "Done.", result)
+	assert.Len(t, client.GetSentRequests(), 2, "the correction should get a second turn")
+	assert.False(t, agent.hasPendingSteer())
+}
+
+func TestSteerRejectsEmptyAndOverflow(t *testing.T) {
+	agent := &Agent{}
+	assert.Error(t, agent.Steer("  "))
+	for i := 0; i < inputInjectionBufferSize; i++ {
+		require.NoError(t, agent.Steer(fmt.Sprintf("correction %d", i)))
+	}
+	assert.Error(t, agent.Steer("one too many"))
+	assert.Len(t, agent.takePendingSteers(), inputInjectionBufferSize)
+	assert.False(t, agent.hasPendingSteer())
+	assert.True(t, strings.HasPrefix(steerSupplement("x"), "## User Correction"))
+}
@@ -0,0 +1,222 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+const (
+	// streamQueueCapacity bounds the number of chunks waiting to be written.
+	// When the queue is full the provider's reader blocks (backpressure)
+	// instead of buffering without limit.
+	streamQueueCapacity = 256
+	// streamCoalesceMaxBytes caps how much queued text a single write may
+	// merge, so one slow write does not turn into one huge one.
+	streamCoalesceMaxBytes = 16 * 1024
+)
+
+// StreamDispatchStats reports how streamed chunks were delivered to output.
+type StreamDispatchStats struct {
+	Enqueued  int64 `json:"enqueued"`  // chunks received from the provider
+	Written   int64 `json:"written"`   // writes made to the output router
+	Coalesced int64 `json:"coalesced"` // chunks merged into a preceding write
+	Dropped   int64 `json:"dropped"`   // chunks discarded after interrupt or close
+	Blocked   int64 `json:"blocked"`   // enqueues that waited on a full queue
+	MaxDepth  int   `json:"max_depth"` // deepest observed queue length
+}
+
+func (s *StreamDispatchStats) add(other StreamDispatchStats) {
+	s.Enqueued += other.Enqueued
+	s.Written += other.Written
+	s.Coalesced += other.Coalesced
+	s.Dropped += other.Dropped
+	s.Blocked += other.Blocked
+	if other.MaxDepth > s.MaxDepth {
+		s.MaxDepth = other.MaxDepth
+	}
+}
+
+type streamChunk struct {
+	content     string
+	contentType string
+}
+
+// streamDispatcher decouples the provider's stream reader from output. Chunks
+// go into a bounded queue drained by a single writer goroutine. While the queue
+// is at least half full the writer merges consecutive chunks of the same
+// content type, so it catches up without changing chunk boundaries in the
+// common case.
+type streamDispatcher struct {
+	queue             chan streamChunk
+	coalesceThreshold int
+	done              chan struct{}
+	cancel            <-chan struct{}
+	write             func(content, contentType string)
+
+	mu       sync.RWMutex // held for reading while enqueueing, for writing while closing
+	closed   bool
+	closeMu  sync.Once
+	finished chan struct{}
+
+	statsMu sync.Mutex
+	stats   StreamDispatchStats
+}
+
+// newStreamDispatcher starts a dispatcher that delivers chunks to write.
+// Enqueues blocked on a full queue give up once ctx is done.
+func newStreamDispatcher(ctx context.Context, capacity int, write func(content, contentType string)) *streamDispatcher {
+	if capacity <= 0 {
+		capacity = streamQueueCapacity
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	d := &streamDispatcher{
+		queue:             make(chan streamChunk, capacity),
+		coalesceThreshold: (capacity + 1) / 2,
+		done:              make(chan struct{}),
+		cancel:            ctx.Done(),
+		write:             write,
+		finished:          make(chan struct{}),
+	}
+	go d.run()
+	return d
+}
+
+// enqueue hands a chunk to the writer, blocking while the queue is full.
+func (d *streamDispatcher) enqueue(content, contentType string) {
+	if content == "" {
+		return
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	d.statsMu.Lock()
+	d.stats.Enqueued++
+	d.statsMu.Unlock()
+
+	if d.closed {
+		d.countDropped()
+		return
+	}
+
+	chunk := streamChunk{content: content, contentType: contentType}
+	select {
+	case d.queue <- chunk:
+		d.observeDepth()
+		return
+	default:
+	}
+
+	d.statsMu.Lock()
+	d.stats.Blocked++
+	d.statsMu.Unlock()
+
+	select {
+	case d.queue <- chunk:
+		d.observeDepth()
+	case <-d.done:
+		d.countDropped()
+	case <-d.cancel:
+		d.countDropped()
+	}
+}
+
+// close stops accepting chunks and waits for queued chunks to be written.
+func (d *streamDispatcher) close() {
+	d.closeMu.Do(func() {
+		// Release enqueuers blocked on a full queue before taking the lock.
+		close(d.done)
+		d.mu.Lock()
+		d.closed = true
+		close(d.queue)
+		d.mu.Unlock()
+	})
+	<-d.finished
+}
+
+// Stats returns a snapshot of the dispatcher's counters.
+func (d *streamDispatcher) Stats() StreamDispatchStats {
+	d.statsMu.Lock()
+	defer d.statsMu.Unlock()
+	return d.stats
+}
+
+func (d *streamDispatcher) run() {
+	defer close(d.finished)
+
+	var carry *streamChunk
+	for {
+		var chunk streamChunk
+		if carry != nil {
+			chunk, carry = *carry, nil
+		} else {
+			next, ok := <-d.queue
+			if !ok {
+				return
+			}
+			chunk = next
+		}
+
+		// Under pressure, coalesce whatever else is already queued with the
+		// same content type.
+		var merged strings.Builder
+		merged.WriteString(chunk.content)
+		coalesced := int64(0)
+		overflowing := len(d.queue) >= d.coalesceThreshold
+	drain:
+		for overflowing && merged.Len() < streamCoalesceMaxBytes {
+			select {
+			case next, ok := <-d.queue:
+				if !ok {
+					break drain
+				}
+				if next.contentType != chunk.contentType {
+					carry = &next
+					break drain
+				}
+				merged.WriteString(next.content)
+				coalesced++
+			default:
+				break drain
+			}
+		}
+
+		d.write(merged.String(), chunk.contentType)
+
+		d.statsMu.Lock()
+		d.stats.Written++
+		d.stats.Coalesced += coalesced
+		d.statsMu.Unlock()
+	}
+}
+
+func (d *streamDispatcher) observeDepth() {
+	depth := len(d.queue)
+	d.statsMu.Lock()
+	if depth > d.stats.MaxDepth {
+		d.stats.MaxDepth = depth
+	}
+	d.statsMu.Unlock()
+}
+
+func (d *streamDispatcher) countDropped() {
+	d.statsMu.Lock()
+	d.stats.Dropped++
+	d.statsMu.Unlock()
+}
+
+// GetStreamDispatchStats returns cumulative streaming delivery metrics for
+// this agent.
+func (a *Agent) GetStreamDispatchStats() StreamDispatchStats {
+	a.streamStatsMu.Lock()
+	defer a.streamStatsMu.Unlock()
+	return a.streamStats
+}
+
+func (a *Agent) recordStreamDispatchStats(stats StreamDispatchStats) {
+	a.streamStatsMu.Lock()
+	defer a.streamStatsMu.Unlock()
+	a.streamStats.add(stats)
+}
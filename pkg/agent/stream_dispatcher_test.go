@@ -0,0 +1,169 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordedWrite struct {
+	content     string
+	contentType string
+}
+
+type writeRecorder struct {
+	mu      sync.Mutex
+	writes  []recordedWrite
+	gate    chan struct{} // when set, writes block until it is closed
+	entered chan struct{} // receives once per write that reaches the gate
+}
+
+func newGatedWriteRecorder() *writeRecorder {
+	return &writeRecorder{gate: make(chan struct{}), entered: make(chan struct{}, 64)}
+}
+
+func (w *writeRecorder) write(content, contentType string) {
+	if w.gate != nil {
+		w.entered <- struct{}{}
+		<-w.gate
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.writes = append(w.writes, recordedWrite{content, contentType})
+}
+
+func (w *writeRecorder) joined(contentType string) string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var sb strings.Builder
+	for _, wr := range w.writes {
+		if wr.contentType == contentType {
+			sb.WriteString(wr.content)
+		}
+	}
+	return sb.String()
+}
+
+func TestStreamDispatcherPreservesOrder(t *testing.T) {
+	rec := &writeRecorder{}
+	d := newStreamDispatcher(context.Background(), 4, rec.write)
+
+	var want strings.Builder
+	for i := 0; i < 200; i++ {
+		chunk := string(rune('a' + i%26))
+		want.WriteString(chunk)
+		d.enqueue(chunk, "assistant_text")
+	}
+	d.close()
+
+	if got := rec.joined("assistant_text"); got != want.String() {
+		t.Fatalf("chunks out of order or lost:\nwant %q\ngot  %q", want.String(), got)
+	}
+	stats := d.Stats()
+	if stats.Enqueued != 200 || stats.Dropped != 0 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	if stats.Written+stats.Coalesced != stats.Enqueued {
+		t.Fatalf("every chunk should be written or coalesced: %+v", stats)
+	}
+}
+
+func TestStreamDispatcherCoalescesWhenWriterIsSlow(t *testing.T) {
+	rec := newGatedWriteRecorder()
+	d := newStreamDispatcher(context.Background(), 16, rec.write)
+
+	// The writer takes the first chunk and blocks on the gate; the rest queue up.
+	d.enqueue("first ", "assistant_text")
+	<-rec.entered
+	for i := 0; i < 10; i++ {
+		d.enqueue("x", "assistant_text")
+	}
+	d.enqueue("thinking", "reasoning")
+	d.enqueue("y", "assistant_text")
+	close(rec.gate)
+	d.close()
+
+	rec.mu.Lock()
+	writes := append([]recordedWrite(nil), rec.writes...)
+	rec.mu.Unlock()
+
+	want := []recordedWrite{
+		{"first ", "assistant_text"},
+		{"xxxxxxxxxx", "assistant_text"},
+		{"thinking", "reasoning"},
+		{"y", "assistant_text"},
+	}
+	if len(writes) != len(want) {
+		t.Fatalf("expected %d writes, got %+v", len(want), writes)
+	}
+	for i := range want {
+		if writes[i] != want[i] {
+			t.Fatalf("write %d: expected %+v, got %+v", i, want[i], writes[i])
+		}
+	}
+	if stats := d.Stats(); stats.Coalesced != 9 {
+		t.Fatalf("expected 9 coalesced chunks, got %+v", stats)
+	}
+}
+
+func TestStreamDispatcherAppliesBackpressure(t *testing.T) {
+	rec := newGatedWriteRecorder()
+	d := newStreamDispatcher(context.Background(), 2, rec.write)
+
+	d.enqueue("a", "assistant_text") // taken by the blocked writer
+	<-rec.entered
+	d.enqueue("b", "assistant_text")
+	d.enqueue("c", "assistant_text") // queue now full
+
+	enqueued := make(chan struct{})
+	go func() {
+		d.enqueue("d", "assistant_text")
+		close(enqueued)
+	}()
+
+	select {
+	case <-enqueued:
+		t.Fatal("enqueue should block while the queue is full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(rec.gate)
+	select {
+	case <-enqueued:
+	case <-time.After(time.Second):
+		t.Fatal("enqueue did not resume after the writer drained the queue")
+	}
+	d.close()
+
+	if got := rec.joined("assistant_text"); got != "abcd" {
+		t.Fatalf("expected abcd, got %q", got)
+	}
+	if stats := d.Stats(); stats.Blocked != 1 || stats.MaxDepth > 2 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestStreamDispatcherDropsAfterCancelAndClose(t *testing.T) {
+	rec := newGatedWriteRecorder()
+	ctx, cancel := context.WithCancel(context.Background())
+	d := newStreamDispatcher(ctx, 1, rec.write)
+
+	d.enqueue("a", "assistant_text") // taken by the blocked writer
+	<-rec.entered
+	d.enqueue("b", "assistant_text") // queue now full
+	cancel()
+	d.enqueue("c", "assistant_text") // would block; dropped on cancel
+
+	close(rec.gate)
+	d.close()
+	d.enqueue("d", "assistant_text") // after close
+
+	if got := rec.joined("assistant_text"); got != "ab" {
+		t.Fatalf("expected ab, got %q", got)
+	}
+	if stats := d.Stats(); stats.Dropped != 2 {
+		t.Fatalf("expected 2 dropped chunks, got %+v", stats)
+	}
+}
@@ -55,6 +55,15 @@ func (a *Agent) PrintConversationSummary(forceFull bool) {
 	fmt.Printf("[cfg] Tool calls:      %d\n", metrics.toolCalls)
 	fmt.Printf("[tools] Tool results:    %d\n", metrics.toolMessages)
 	fmt.Printf("[msg] Total messages:   %d\n", len(a.messages))
+	if line := a.readCacheSummaryLine(); line != "" {
+		fmt.Printf("[read] Read cache:     %s\n", line)
+	}
+	if line := a.responseCacheSummaryLine(); line != "" {
+		fmt.Printf("[cache] Response cache: %s\n", line)
+	}
+	if line := a.toolDedupSummaryLine(); line != "" {
+		fmt.Printf("[dedup] Repeated calls: %s\n", line)
+	}
 	fmt.Println()
 
 	// Calculate processed tokens (excluding cached ones)
@@ -132,6 +141,22 @@ func (a *Agent) PrintConversationSummary(forceFull bool) {
 		fmt.Printf("[list] Cost per iteration: $%.6f\n", costPerIteration)
 	}
 
+	if escalations := a.GetModelEscalations(); len(escalations) > 0 {
+		fmt.Println()
+		fmt.Println("[up] Model Escalations")
+		fmt.Println("──────────────────────────────")
+		for _, e := range escalations {
+			fmt.Printf("%s -> %s: %s (%s)\n", e.From, e.To, e.Todo, e.Reason)
+		}
+	}
+
+	if tree := a.CostTreeReport(); tree != "" {
+		fmt.Println()
+		fmt.Println("[tree] Subagent Cost Tree")
+		fmt.Println("──────────────────────────────")
+		fmt.Println(tree)
+	}
+
 	fmt.Println("══════════════════════════════")
 	fmt.Println()
 }
@@ -157,6 +182,13 @@ func (a *Agent) PrintConciseSummary() {
 		a.formatTokenCount(a.cachedTokens),
 		costStr)
 
+	if line := a.responseCacheSummaryLine(); line != "" {
+		fmt.Printf("[cache] Response cache: %s\n", line)
+	}
+	if line := a.toolDedupSummaryLine(); line != "" {
+		fmt.Printf("[dedup] Repeated calls: %s\n", line)
+	}
+
 	// Output machine-parseable metrics for parent agent extraction
 	fmt.Printf("SUBAGENT_METRICS: total_tokens=%d prompt_tokens=%d completion_tokens=%d total_cost=%.6f cached_tokens=%d processed_prompt_tokens=%d processed_tokens=%d\n",
 		a.totalTokens,
@@ -166,6 +198,9 @@ func (a *Agent) PrintConciseSummary() {
 		a.cachedTokens,
 		processedPromptTokens,
 		processedTokens)
+	if line := a.subagentCostTreeLine(); line != "" {
+		fmt.Println(line)
+	}
 }
 
 // PrintCompactProgress prints a minimal progress indicator for non-interactive mode
@@ -199,6 +234,42 @@ func (a *Agent) PrintCompactProgress() {
 		formatCostCompact(a.totalCost))
 }
 
+// readCacheSummaryLine describes read cache hits, or "" before any file read.
+func (a *Agent) readCacheSummaryLine() string {
+	stats := a.ReadCacheStats()
+	if stats.Reads == 0 && stats.Prefetched == 0 {
+		return ""
+	}
+	hitRate := 0.0
+	if stats.Reads > 0 {
+		hitRate = float64(stats.Hits) / float64(stats.Reads) * 100
+	}
+	return fmt.Sprintf("%d/%d reads hit (%.0f%%), %d files prefetched, %d read after prefetch",
+		stats.Hits, stats.Reads, hitRate, stats.Prefetched, stats.PrefetchHits)
+}
+
+// responseCacheSummaryLine describes response cache hits, or "" without any.
+func (a *Agent) responseCacheSummaryLine() string {
+	stats := a.ResponseCacheStats()
+	if stats.Hits == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d requests reused, %s tokens and $%.6f saved",
+		stats.Hits, a.formatTokenCount(stats.SavedTokens), stats.SavedCost)
+}
+
+// toolDedupSummaryLine describes deduplicated tool calls, or "" without any.
+func (a *Agent) toolDedupSummaryLine() string {
+	hits := a.ToolDedupStats().Hits
+	if hits == 0 {
+		return ""
+	}
+	if hits == 1 {
+		return "1 tool call answered from an identical earlier call"
+	}
+	return fmt.Sprintf("%d tool calls answered from identical earlier calls", hits)
+}
+
 // calculateCachedCost calculates the cost savings from cached tokens
 func (a *Agent) calculateCachedCost(cachedTokens int) float64 {
 	if cachedTokens == 0 {
@@ -332,6 +403,9 @@ func (a *Agent) GenerateConversationSummary() string {
 		efficiency := float64(a.cachedTokens) / float64(a.totalTokens) * 100
 		summary.WriteString(fmt.Sprintf("• Efficiency: %.1f%% tokens cached\n", efficiency))
 	}
+	if line := a.readCacheSummaryLine(); line != "" {
+		summary.WriteString(fmt.Sprintf("• Read cache: %s\n", line))
+	}
 
 	summary.WriteString("══════════════════════════════\n")
 
@@ -0,0 +1,57 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/alantheprice/ledit/pkg/hooks"
+)
+
+// noHooksEnv turns off the task hooks in .ledit/hooks.yaml.
+const noHooksEnv = "LEDIT_NO_HOOKS"
+
+// runTaskHooks runs the workspace's hooks for a task lifecycle event. On
+// task_start response and runErr are unused. Subagent runs are left out, as
+// they are part of the parent's task. Failing hooks are reported on stderr
+// and never fail the task.
+func (a *Agent) runTaskHooks(event hooks.Event, task, response string, runErr error, started time.Time) {
+	if os.Getenv(noHooksEnv) == "1" || os.Getenv("LEDIT_SUBAGENT") == "1" {
+		return
+	}
+	root := a.currentWorkspaceRoot()
+	config, err := hooks.Load(root)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[WARN] Task hooks skipped: %v\n", err)
+		return
+	}
+	if config == nil {
+		return
+	}
+
+	payload := hooks.Payload{
+		Event:     event,
+		Task:      strings.TrimSpace(task),
+		Workspace: root,
+		Status:    "running",
+		Time:      time.Now(),
+	}
+	if event != hooks.TaskStart {
+		result := a.BuildResult(response, runErr, time.Since(started))
+		payload.Status, payload.Error, payload.Result = result.Status, result.Error, result
+	}
+	for _, err := range config.Run(context.Background(), payload) {
+		fmt.Fprintf(os.Stderr, "[WARN] Hook failed on %s: %v\n", event, err)
+	}
+}
+
+// taskEndEvent is the hook event for a finished run: task_error when it
+// failed, task_finish otherwise, including runs that stopped early.
+func taskEndEvent(terminationReason string, runErr error) hooks.Event {
+	if resultStatusFor(terminationReason, runErr) == ResultStatusFailure {
+		return hooks.TaskError
+	}
+	return hooks.TaskFinish
+}
@@ -0,0 +1,76 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alantheprice/ledit/pkg/hooks"
+)
+
+func TestRunTaskHooksPostsTheResult(t *testing.T) {
+	t.Setenv("LEDIT_SUBAGENT", "")
+	t.Setenv(noHooksEnv, "")
+	bodies := make(chan []byte, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies <- body
+	}))
+	defer server.Close()
+
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, ".ledit"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	config := fmt.Sprintf("hooks:\n  - type: webhook\n    on: [task_error]\n    url: %s\n", server.URL)
+	if err := os.WriteFile(filepath.Join(root, hooks.FileName), []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	a := makeAgentWithScriptedClient(5, NewScriptedClient())
+	a.SetWorkspaceRoot(root)
+	a.runTaskHooks(hooks.TaskStart, "fix the build", "", nil, time.Now())
+	a.runTaskHooks(hooks.TaskError, " fix the build\n", "", errors.New("provider down"), time.Now())
+
+	var payload struct {
+		Event  string      `json:"event"`
+		Task   string      `json:"task"`
+		Status string      `json:"status"`
+		Result AgentResult `json:"result"`
+	}
+	if err := json.Unmarshal(<-bodies, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Event != "task_error" || payload.Task != "fix the build" || payload.Status != ResultStatusFailure || payload.Result.Error != "provider down" {
+		t.Fatalf("unexpected payload %+v", payload)
+	}
+	if len(bodies) != 0 {
+		t.Fatal("expected only the task_error hook to run")
+	}
+
+	t.Setenv("LEDIT_SUBAGENT", "1")
+	a.runTaskHooks(hooks.TaskError, "subtask", "", errors.New("boom"), time.Now())
+	if len(bodies) != 0 {
+		t.Fatal("expected no hooks for subagent runs")
+	}
+}
+
+func TestTaskEndEvent(t *testing.T) {
+	if got := taskEndEvent(RunTerminationCompleted, nil); got != hooks.TaskFinish {
+		t.Errorf("completed run: got %s", got)
+	}
+	if got := taskEndEvent("", fmt.Errorf("stopped: %w", context.Canceled)); got != hooks.TaskFinish {
+		t.Errorf("interrupted run: got %s", got)
+	}
+	if got := taskEndEvent(RunTerminationCompleted, errors.New("boom")); got != hooks.TaskError {
+		t.Errorf("failed run: got %s", got)
+	}
+}
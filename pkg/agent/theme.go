@@ -4,12 +4,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+
+	"github.com/alantheprice/ledit/pkg/configuration"
+	"github.com/alantheprice/ledit/pkg/console"
 )
 
 // Theme represents a color theme configuration
 type Theme struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
+	Palette     string `json:"palette,omitempty"` // console palette, one of console.PaletteNames()
 	Colors      struct {
 		Success   string `json:"success"`
 		Warning   string `json:"warning"`
@@ -58,6 +62,19 @@ func (tm *ThemeManager) LoadDefaultTheme() {
 	}
 }
 
+// LoadColorblindTheme loads a theme that avoids red/green pairs and switches
+// the console to the colorblind-safe palette.
+func (tm *ThemeManager) LoadColorblindTheme() {
+	tm.LoadDefaultTheme()
+	tm.theme.Name = console.PaletteColorblind
+	tm.theme.Description = "Colorblind-safe theme (blue/orange instead of green/red)"
+	tm.theme.Palette = console.PaletteColorblind
+	tm.theme.Colors.Success = "blue"
+	tm.theme.Colors.Error = "orange"
+	tm.theme.Colors.Info = "white"
+	console.SetPalette(console.PaletteColorblind)
+}
+
 // LoadThemeFromFile loads a theme from a JSON file
 func (tm *ThemeManager) LoadThemeFromFile(themePath string) error {
 	// Check if file exists
@@ -78,6 +95,9 @@ func (tm *ThemeManager) LoadThemeFromFile(themePath string) error {
 	if err := json.Unmarshal(data, &theme); err != nil {
 		return fmt.Errorf("error parsing theme JSON: %w", err)
 	}
+	if theme.Palette != "" && !console.SetPalette(theme.Palette) {
+		return fmt.Errorf("unknown palette %q in theme: must be one of %v", theme.Palette, console.PaletteNames())
+	}
 
 	tm.theme = theme
 	return nil
@@ -109,3 +129,14 @@ func (tm *ThemeManager) GetColor(name string) string {
 func (tm *ThemeManager) GetTheme() Theme {
 	return tm.theme
 }
+
+// applyConfiguredPalette activates the console palette named in the config.
+// Unknown names keep the default palette.
+func applyConfiguredPalette(cfg *configuration.Config) {
+	if cfg == nil || cfg.ColorPalette == "" {
+		return
+	}
+	if !console.SetPalette(cfg.ColorPalette) {
+		fmt.Fprintf(os.Stderr, "[WARN] Unknown color_palette %q; using default\n", cfg.ColorPalette)
+	}
+}
@@ -0,0 +1,353 @@
+package agent
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	api "github.com/alantheprice/ledit/pkg/agent_api"
+)
+
+// Token accounting attributes each request's usage to what consumed it.
+// Providers only report per-request totals, so usage is split by estimated
+// token counts:
+//   - prompt tokens: tool call arguments and tool results in the request are
+//     charged to that tool call and the files it names; the rest (system
+//     prompt, user messages, assistant text, tool schemas) to the conversation.
+//   - completion tokens: tool calls in the response are charged to their tool,
+//     response text and reasoning to the conversation.
+//
+// Cost is split in proportion to tokens. Subagents report their own totals.
+// Files are a second view of the tool usage, not an additional charge.
+
+// Usage categories in a UsageReport.
+const (
+	UsageCategoryConversation = "conversation"
+	UsageCategoryTool         = "tool"
+	UsageCategoryFile         = "file"
+	UsageCategorySubagent     = "subagent"
+)
+
+// UsageEntry is the usage attributed to one tool, file, subagent or the
+// conversation itself.
+type UsageEntry struct {
+	Category         string  `json:"category"`
+	Name             string  `json:"name"`
+	Calls            int     `json:"calls"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	TotalTokens      int     `json:"total_tokens"`
+	Cost             float64 `json:"cost_usd"`
+}
+
+// UsageReport is the session's token and cost attribution.
+type UsageReport struct {
+	GeneratedAt      time.Time    `json:"generated_at"`
+	Provider         string       `json:"provider"`
+	Model            string       `json:"model"`
+	PromptTokens     int          `json:"prompt_tokens"`
+	CompletionTokens int          `json:"completion_tokens"`
+	Cost             float64      `json:"cost_usd"`
+	Conversation     UsageEntry   `json:"conversation"`
+	Tools            []UsageEntry `json:"tools"`
+	Files            []UsageEntry `json:"files"`
+	Subagents        []UsageEntry `json:"subagents"`
+}
+
+// usageBucket accumulates fractional shares so rounding happens once, when
+// the report is built.
+type usageBucket struct {
+	calls      int
+	prompt     float64
+	completion float64
+	cost       float64
+}
+
+type usageKey struct {
+	category string
+	name     string
+}
+
+type usageLedger struct {
+	mu      sync.Mutex
+	buckets map[usageKey]*usageBucket
+	// subagentRuns are the cost tree nodes of the subagents run, in order.
+	subagentRuns []CostNode
+}
+
+// toolUse is a tool call found in the conversation.
+type toolUse struct {
+	name  string
+	files []string
+}
+
+func (a *Agent) usageLedgerState() *usageLedger {
+	a.usageLedgerOnce.Do(func() {
+		a.usageLedger = &usageLedger{buckets: make(map[usageKey]*usageBucket)}
+	})
+	return a.usageLedger
+}
+
+func (l *usageLedger) bucket(category, name string) *usageBucket {
+	key := usageKey{category: category, name: name}
+	b := l.buckets[key]
+	if b == nil {
+		b = &usageBucket{}
+		l.buckets[key] = b
+	}
+	return b
+}
+
+// recordRequestUsage attributes one request's reported usage.
+func (a *Agent) recordRequestUsage(messages []api.Message, resp *api.ChatResponse, promptTokens, completionTokens int, cost float64) {
+	if promptTokens <= 0 && completionTokens <= 0 {
+		return
+	}
+	costPerToken := 0.0
+	if total := promptTokens + completionTokens; total > 0 {
+		costPerToken = cost / float64(total)
+	}
+	root := a.currentWorkspaceRoot()
+
+	// Prompt side: shares of the tool calls and results in the request.
+	uses := make(map[string]toolUse)
+	promptShares := make(map[string]float64) // tool call ID -> estimated tokens
+	var orphanShares []float64
+	for _, msg := range messages {
+		for _, tc := range msg.ToolCalls {
+			uses[tc.ID] = toolUse{name: usageToolName(tc.Function.Name), files: usageFiles(tc.Function.Arguments, root)}
+			promptShares[tc.ID] += float64(EstimateTokens(tc.Function.Arguments))
+		}
+		if msg.Role == "tool" {
+			tokens := float64(EstimateTokens(msg.Content))
+			if _, ok := uses[msg.ToolCallId]; ok {
+				promptShares[msg.ToolCallId] += tokens
+			} else {
+				orphanShares = append(orphanShares, tokens)
+			}
+		}
+	}
+	attributed := 0.0
+	for _, tokens := range promptShares {
+		attributed += tokens
+	}
+	for _, tokens := range orphanShares {
+		attributed += tokens
+	}
+	promptScale := 1.0
+	if attributed > float64(promptTokens) && attributed > 0 {
+		promptScale = float64(promptTokens) / attributed
+	}
+
+	// Completion side: the tool calls the response makes versus its text.
+	var calls []api.ToolCall
+	textTokens := 0.0
+	if resp != nil && len(resp.Choices) > 0 {
+		msg := resp.Choices[0].Message
+		calls = msg.ToolCalls
+		textTokens = float64(EstimateTokens(msg.Content) + EstimateTokens(msg.ReasoningContent))
+	}
+	completionShares := make([]float64, len(calls))
+	completionEstimate := textTokens
+	for i, tc := range calls {
+		completionShares[i] = float64(EstimateTokens(tc.Function.Name) + EstimateTokens(tc.Function.Arguments))
+		completionEstimate += completionShares[i]
+	}
+	completionScale := 0.0
+	if completionEstimate > 0 {
+		completionScale = float64(completionTokens) / completionEstimate
+	}
+
+	ledger := a.usageLedgerState()
+	ledger.mu.Lock()
+	defer ledger.mu.Unlock()
+
+	charge := func(use toolUse, prompt, completion float64, call bool) {
+		targets := []*usageBucket{ledger.bucket(UsageCategoryTool, use.name)}
+		for _, file := range use.files {
+			targets = append(targets, ledger.bucket(UsageCategoryFile, file))
+		}
+		for _, b := range targets {
+			b.prompt += prompt
+			b.completion += completion
+			b.cost += (prompt + completion) * costPerToken
+			if call {
+				b.calls++
+			}
+		}
+	}
+
+	promptCharged := 0.0
+	for id, tokens := range promptShares {
+		charge(uses[id], tokens*promptScale, 0, false)
+		promptCharged += tokens * promptScale
+	}
+	for _, tokens := range orphanShares {
+		charge(toolUse{name: "unknown"}, tokens*promptScale, 0, false)
+		promptCharged += tokens * promptScale
+	}
+
+	completionCharged := 0.0
+	for i, tc := range calls {
+		use := toolUse{name: usageToolName(tc.Function.Name), files: usageFiles(tc.Function.Arguments, root)}
+		charge(use, 0, completionShares[i]*completionScale, true)
+		completionCharged += completionShares[i] * completionScale
+	}
+
+	conversation := ledger.bucket(UsageCategoryConversation, UsageCategoryConversation)
+	rest := (float64(promptTokens) - promptCharged) + (float64(completionTokens) - completionCharged)
+	conversation.prompt += float64(promptTokens) - promptCharged
+	conversation.completion += float64(completionTokens) - completionCharged
+	conversation.cost += rest * costPerToken
+	conversation.calls++
+}
+
+// recordSubagentUsage charges a subagent run's reported totals to name and
+// adds the run, with the subagents it ran, to the cost tree.
+func (a *Agent) recordSubagentUsage(name string, promptTokens, completionTokens int, cost float64, children []CostNode) {
+	if strings.TrimSpace(name) == "" {
+		name = "subagent"
+	}
+	ledger := a.usageLedgerState()
+	ledger.mu.Lock()
+	defer ledger.mu.Unlock()
+	b := ledger.bucket(UsageCategorySubagent, name)
+	b.calls++
+	b.prompt += float64(promptTokens)
+	b.completion += float64(completionTokens)
+	b.cost += cost
+	ledger.subagentRuns = append(ledger.subagentRuns, CostNode{
+		Name:             name,
+		PromptTokens:     promptTokens,
+		CompletionTokens: completionTokens,
+		Cost:             cost,
+		Children:         children,
+	})
+}
+
+// TokenUsageReport returns the session's usage broken down by tool, file and
+// subagent, largest first.
+func (a *Agent) TokenUsageReport() UsageReport {
+	report := UsageReport{
+		GeneratedAt:      time.Now(),
+		Provider:         a.GetProvider(),
+		Model:            a.GetModel(),
+		PromptTokens:     a.promptTokens,
+		CompletionTokens: a.completionTokens,
+		Cost:             a.totalCost,
+		Conversation:     UsageEntry{Category: UsageCategoryConversation, Name: UsageCategoryConversation},
+	}
+
+	ledger := a.usageLedgerState()
+	ledger.mu.Lock()
+	for key, b := range ledger.buckets {
+		entry := UsageEntry{
+			Category:         key.category,
+			Name:             key.name,
+			Calls:            b.calls,
+			PromptTokens:     int(b.prompt + 0.5),
+			CompletionTokens: int(b.completion + 0.5),
+			Cost:             b.cost,
+		}
+		entry.TotalTokens = entry.PromptTokens + entry.CompletionTokens
+		switch key.category {
+		case UsageCategoryConversation:
+			report.Conversation = entry
+		case UsageCategoryTool:
+			report.Tools = append(report.Tools, entry)
+		case UsageCategoryFile:
+			report.Files = append(report.Files, entry)
+		case UsageCategorySubagent:
+			report.Subagents = append(report.Subagents, entry)
+		}
+	}
+	ledger.mu.Unlock()
+
+	for _, entries := range [][]UsageEntry{report.Tools, report.Files, report.Subagents} {
+		sort.Slice(entries, func(i, j int) bool {
+			if entries[i].TotalTokens != entries[j].TotalTokens {
+				return entries[i].TotalTokens > entries[j].TotalTokens
+			}
+			return entries[i].Name < entries[j].Name
+		})
+	}
+	return report
+}
+
+// Entries returns every entry in the report, conversation first.
+func (r UsageReport) Entries() []UsageEntry {
+	entries := []UsageEntry{r.Conversation}
+	entries = append(entries, r.Tools...)
+	entries = append(entries, r.Subagents...)
+	return append(entries, r.Files...)
+}
+
+// WriteCSV writes one row per entry.
+func (r UsageReport) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"category", "name", "calls", "prompt_tokens", "completion_tokens", "total_tokens", "cost_usd"}); err != nil {
+		return err
+	}
+	for _, e := range r.Entries() {
+		row := []string{
+			e.Category,
+			e.Name,
+			strconv.Itoa(e.Calls),
+			strconv.Itoa(e.PromptTokens),
+			strconv.Itoa(e.CompletionTokens),
+			strconv.Itoa(e.TotalTokens),
+			strconv.FormatFloat(e.Cost, 'f', 6, 64),
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r UsageReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// usageToolName strips channel suffixes some models append to tool names.
+func usageToolName(name string) string {
+	name = strings.TrimSpace(strings.Split(name, "<|channel|>")[0])
+	if name == "" {
+		return "unknown"
+	}
+	return name
+}
+
+// usageFiles returns the workspace-relative files a tool call names.
+func usageFiles(arguments, root string) []string {
+	var args map[string]interface{}
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return nil
+	}
+	var files []string
+	for _, key := range []string{"path", "file_path", "image_path"} {
+		path, ok := args[key].(string)
+		if !ok || strings.TrimSpace(path) == "" || strings.Contains(path, "://") {
+			continue
+		}
+		path = filepath.Clean(strings.TrimSpace(path))
+		if filepath.IsAbs(path) && root != "" {
+			if rel, err := filepath.Rel(root, path); err == nil && !strings.HasPrefix(rel, "..") {
+				path = rel
+			}
+		}
+		files = append(files, filepath.ToSlash(path))
+		break
+	}
+	return files
+}
@@ -0,0 +1,101 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"math"
+	"testing"
+
+	api "github.com/alantheprice/ledit/pkg/agent_api"
+)
+
+func usageToolCall(id, name, args string) api.ToolCall {
+	tc := api.ToolCall{ID: id, Type: "function"}
+	tc.Function.Name = name
+	tc.Function.Arguments = args
+	return tc
+}
+
+func TestRecordRequestUsageAttributesToolsFilesAndConversation(t *testing.T) {
+	root := t.TempDir()
+	a := &Agent{workspaceRoot: root, promptTokens: 1000, completionTokens: 200, totalCost: 0.012}
+
+	readCall := usageToolCall("call_1", "read_file", `{"path": "`+root+`/pkg/big.go"}`)
+	messages := []api.Message{
+		{Role: "system", Content: "You are a coding agent."},
+		{Role: "user", Content: "Explain big.go"},
+		{Role: "assistant", ToolCalls: []api.ToolCall{readCall}},
+		{Role: "tool", ToolCallId: "call_1", Content: string(bytes.Repeat([]byte("func f() {}\n"), 50))},
+	}
+	resp := &api.ChatResponse{Choices: []api.Choice{{}}}
+	resp.Choices[0].Message.Content = "Let me also check the tests."
+	resp.Choices[0].Message.ToolCalls = []api.ToolCall{usageToolCall("call_2", "search_files", `{"search_pattern": "TestBig"}`)}
+
+	a.recordRequestUsage(messages, resp, 1000, 200, 0.012)
+	a.recordSubagentUsage("coder", 300, 50, 0.004, nil)
+
+	report := a.TokenUsageReport()
+	byName := map[string]UsageEntry{}
+	for _, e := range report.Entries() {
+		byName[e.Category+":"+e.Name] = e
+	}
+
+	read := byName["tool:read_file"]
+	if read.PromptTokens == 0 || read.CompletionTokens != 0 || read.Calls != 0 {
+		t.Errorf("read_file should be charged prompt tokens for its call and result only: %+v", read)
+	}
+	if file := byName["file:pkg/big.go"]; file.PromptTokens != read.PromptTokens {
+		t.Errorf("file entry should mirror its tool call: %+v vs %+v", file, read)
+	}
+	search := byName["tool:search_files"]
+	if search.Calls != 1 || search.CompletionTokens == 0 || search.PromptTokens != 0 {
+		t.Errorf("search_files should be charged output tokens for the new call: %+v", search)
+	}
+	if sub := byName["subagent:coder"]; sub.Calls != 1 || sub.TotalTokens != 350 || sub.Cost != 0.004 {
+		t.Errorf("unexpected subagent entry: %+v", sub)
+	}
+
+	conv := report.Conversation
+	if conv.Calls != 1 {
+		t.Errorf("conversation should count one request, got %d", conv.Calls)
+	}
+	if got := conv.PromptTokens + read.PromptTokens; got < 999 || got > 1001 {
+		t.Errorf("prompt tokens should add up to 1000, got %d", got)
+	}
+	if got := conv.CompletionTokens + search.CompletionTokens; got < 199 || got > 201 {
+		t.Errorf("completion tokens should add up to 200, got %d", got)
+	}
+	if cost := conv.Cost + read.Cost + search.Cost; math.Abs(cost-0.012) > 1e-9 {
+		t.Errorf("request cost should be fully attributed, got %f", cost)
+	}
+}
+
+func TestUsageReportExports(t *testing.T) {
+	a := &Agent{}
+	a.recordRequestUsage([]api.Message{{Role: "user", Content: "hi"}}, nil, 10, 5, 0.001)
+
+	var csvOut bytes.Buffer
+	if err := a.TokenUsageReport().WriteCSV(&csvOut); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&csvOut).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0][0] != "category" || rows[1][0] != "conversation" || rows[1][5] != "15" {
+		t.Errorf("unexpected CSV rows: %v", rows)
+	}
+
+	var jsonOut bytes.Buffer
+	if err := a.TokenUsageReport().WriteJSON(&jsonOut); err != nil {
+		t.Fatal(err)
+	}
+	var decoded UsageReport
+	if err := json.Unmarshal(jsonOut.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Conversation.TotalTokens != 15 {
+		t.Errorf("unexpected JSON report: %s", jsonOut.String())
+	}
+}
@@ -104,6 +104,24 @@ func newDefaultToolRegistry() *ToolRegistry {
 		Handler: handleCommitTool,
 	})
 
+	// Register pr tool - pull requests (merge requests) on the origin's GitHub or GitLab
+	registry.RegisterTool(ToolConfig{
+		Name:        "pr",
+		Description: "Work with the pull request (GitLab: merge request) of the current branch on the origin remote's GitHub or GitLab. Actions: status, create, comments, reply.",
+		Parameters: []ParameterConfig{
+			{"action", "string", true, []string{"op", "operation"}, "One of: status (branch and open pull request), create (open a pull request), comments (list review comments), reply (answer a comment)"},
+			{"title", "string", false, []string{}, "Pull request title (create)"},
+			{"body", "string", false, []string{"description", "message"}, "Pull request description (create) or reply text (reply)"},
+			{"base", "string", false, []string{"target"}, "Base branch (create, defaults to the remote's default branch)"},
+			{"draft", "bool", false, []string{}, "Open the pull request as a draft (create)"},
+			{"push", "bool", false, []string{}, "Push the branch first if the remote does not have it (create)"},
+			{"number", "int", false, []string{"pr", "id"}, "Pull request number (comments, reply; defaults to the current branch's pull request)"},
+			{"add_todos", "bool", false, []string{"todos"}, "Add a todo for every review thread (comments)"},
+			{"comment_id", "string", false, []string{"comment"}, "ID of the comment to reply to (reply)"},
+		},
+		Handler: handlePullRequest,
+	})
+
 	// Register read_file tool
 	registry.RegisterTool(ToolConfig{
 		Name:        "read_file",
@@ -116,6 +134,63 @@ func newDefaultToolRegistry() *ToolRegistry {
 		HandlerImages: handleReadFileWithImages,
 	})
 
+	// Register read_symbol tool
+	registry.RegisterTool(ToolConfig{
+		Name:        "read_symbol",
+		Description: "Read one function, method, type or variable from a file, with its doc comment",
+		Parameters: []ParameterConfig{
+			{"path", "string", true, []string{"file_path"}, "Path to the file containing the symbol"},
+			{"symbol", "string", true, []string{"name"}, "Symbol name; use Type.Method for methods"},
+		},
+		Handler: handleReadSymbol,
+	})
+
+	// Register impact_of_change tool
+	registry.RegisterTool(ToolConfig{
+		Name:        "impact_of_change",
+		Description: "List the workspace files and tests that import a file directly or transitively",
+		Parameters: []ParameterConfig{
+			{"path", "string", true, []string{"file_path"}, "Path to the file being changed"},
+		},
+		Handler: handleImpactOfChange,
+	})
+
+	// Register file_history tool
+	registry.RegisterTool(ToolConfig{
+		Name:        "file_history",
+		Description: "Show a file's recent commits and who last changed each line, for a line range or the whole file",
+		Parameters: []ParameterConfig{
+			{"path", "string", true, []string{"file_path"}, "Path to the file"},
+			{"start_line", "int", false, []string{}, "First line to blame (1-based, default: 1)"},
+			{"end_line", "int", false, []string{}, "Last line to blame (default: the last line)"},
+			{"max_commits", "int", false, []string{}, "Recent commits to list (default: 10)"},
+		},
+		Handler: handleFileHistory,
+	})
+
+	// Register test_coverage tool
+	registry.RegisterTool(ToolConfig{
+		Name:        "test_coverage",
+		Description: "Report Go test coverage per function and the least covered functions",
+		Parameters: []ParameterConfig{
+			{"path", "string", false, []string{"file_path"}, "File or directory to report on (default: the whole workspace)"},
+			{"refresh", "boolean", false, []string{}, "Run the tests again instead of using the stored coverage"},
+		},
+		Handler: handleTestCoverage,
+	})
+
+	// Register run_linters tool
+	registry.RegisterTool(ToolConfig{
+		Name:        "run_linters",
+		Description: "Run the project's linters and return deduplicated diagnostics",
+		Parameters: []ParameterConfig{
+			{"path", "string", false, []string{"file_path"}, "File or directory to lint (default: the whole workspace)"},
+			{"linters", "array", false, []string{}, "Linters to run: golangci-lint, eslint, ruff (default: detected from the project)"},
+			{"max_results", "int", false, []string{}, "Maximum diagnostics to list (default: 50)"},
+		},
+		Handler: handleRunLinters,
+	})
+
 	// Register write_file tool
 	registry.RegisterTool(ToolConfig{
 		Name:        "write_file",
@@ -139,6 +214,30 @@ func newDefaultToolRegistry() *ToolRegistry {
 		Handler: handleEditFile,
 	})
 
+	// Register rename_symbol tool
+	registry.RegisterTool(ToolConfig{
+		Name:        "rename_symbol",
+		Description: "Rename a Go symbol and all its references using gopls",
+		Parameters: []ParameterConfig{
+			{"path", "string", true, []string{"file_path"}, "Go file declaring or using the symbol"},
+			{"symbol", "string", true, []string{"name"}, "Current name; use Type.Method or Type.Field for members"},
+			{"new_name", "string", true, []string{"new_symbol"}, "New name"},
+			{"line", "int", false, []string{}, "Line of the identifier in path, required for local variables and parameters"},
+		},
+		Handler: handleRenameSymbol,
+	})
+
+	// Register edit_transaction tool
+	registry.RegisterTool(ToolConfig{
+		Name:        "edit_transaction",
+		Description: "Apply edits to several files as one all-or-nothing transaction",
+		Parameters: []ParameterConfig{
+			{"edits", "array", true, []string{}, "Edits in order: each has path and either old_str and new_str, or content"},
+			{"validate", "bool", false, []string{}, "Run the build before keeping the edits and roll them back if it fails"},
+		},
+		Handler: handleEditTransaction,
+	})
+
 	// Register write_structured_file tool
 	registry.RegisterTool(ToolConfig{
 		Name:        "write_structured_file",
@@ -211,12 +310,14 @@ func newDefaultToolRegistry() *ToolRegistry {
 	// Register search_files tool (cross-platform file content search)
 	registry.RegisterTool(ToolConfig{
 		Name:        "search_files",
-		Description: "Search text pattern in files (cross-platform, ignores .git, node_modules, .ledit by default)",
+		Description: "Search text pattern in files (uses ripgrep when installed and respects .gitignore; ignores .git, node_modules, .ledit by default)",
 		Parameters: []ParameterConfig{
 			{"search_pattern", "string", true, []string{"pattern"}, "Text pattern or regex to search for"},
 			{"directory", "string", false, []string{"root"}, "Directory to search (default: .)"},
 			{"file_glob", "string", false, []string{"file_pattern", "glob"}, "Glob to limit files (e.g., *.go)"},
 			{"case_sensitive", "bool", false, []string{}, "Case sensitive search (default: false)"},
+			{"fixed_strings", "bool", false, []string{}, "Treat search_pattern as a literal string, not a regex (default: false)"},
+			{"multiline", "bool", false, []string{}, "Allow regex matches to span lines; '.' also matches newlines (default: false)"},
 			{"max_results", "int", false, []string{}, "Maximum results to return (default: 50)"},
 			{"max_bytes", "int", false, []string{}, "Maximum total bytes of matches to return (default: 102400)"},
 		},
@@ -244,6 +345,17 @@ func newDefaultToolRegistry() *ToolRegistry {
 		HandlerImages: handleFetchURLWithImages,
 	})
 
+	// Register fetch_more tool
+	registry.RegisterTool(ToolConfig{
+		Name:        "fetch_more",
+		Description: "Read the next chunk of a truncated tool result by its continuation token",
+		Parameters: []ParameterConfig{
+			{"continuation_token", "string", true, []string{"token"}, "Continuation token from a truncated result or the previous chunk"},
+			{"max_chars", "int", false, []string{}, "Maximum characters to return (default: 12000, at most 50000)"},
+		},
+		Handler: handleFetchMore,
+	})
+
 	// Register browse_url tool
 	registry.RegisterTool(ToolConfig{
 		Name:        "browse_url",
@@ -346,6 +458,26 @@ func newDefaultToolRegistry() *ToolRegistry {
 		Handler:     handleListSkills,
 	})
 
+	// Register ask_user tool
+	registry.RegisterTool(ToolConfig{
+		Name:        "ask_user",
+		Description: "Ask the user a question and wait for the answer, instead of guessing when a requirement is ambiguous or a decision is theirs to make. Offer options when the likely answers are known.",
+		Parameters: []ParameterConfig{
+			{"question", "string", true, []string{"prompt"}, "The question to ask, with the context the user needs to answer it"},
+			{"options", "array", false, []string{"choices"}, "Possible answers for the user to choose from, most likely first"},
+			{"allow_other", "bool", false, []string{}, "Let the user type an answer that is not among the options (default: true)"},
+		},
+		Handler: handleAskUser,
+	})
+
+	// Register list_personas tool
+	registry.RegisterTool(ToolConfig{
+		Name:        "list_personas",
+		Description: "List the subagent personas available to run_subagent, including project-defined ones, with what each is for, its model, token budget and allowed tools.",
+		Parameters:  []ParameterConfig{},
+		Handler:     handleListPersonas,
+	})
+
 	// Register activate_skill tool
 	registry.RegisterTool(ToolConfig{
 		Name:        "activate_skill",
@@ -359,10 +491,11 @@ func newDefaultToolRegistry() *ToolRegistry {
 	// Register memory tools
 	registry.RegisterTool(ToolConfig{
 		Name:        "add_memory",
-		Description: "Save a memory to persist across all future conversations. Use this to remember user preferences, learned patterns, project-specific conventions, or anything useful for future sessions. Memories are stored as markdown files in ~/.ledit/memories/ and loaded into your system prompt automatically.",
+		Description: "Save a memory to persist across future conversations. Use this to remember facts you learned (e.g., 'tests are run with make check', 'we use zap for logging'), user preferences, or conventions. Save one short fact per memory. Project memories are stored in .ledit/memories/ in the workspace and apply only to this project; user memories are stored in ~/.ledit/memories/ and apply everywhere. Memories are loaded into your context automatically.",
 		Parameters: []ParameterConfig{
 			{"name", "string", true, []string{"title"}, "Short descriptive name for the memory (e.g., 'git-safety', 'test-conventions')"},
-			{"content", "string", true, []string{}, "Markdown content to store in the memory file"},
+			{"content", "string", true, []string{}, "Markdown content to store in the memory file (under 8000 bytes)"},
+			{"scope", "string", false, []string{}, "'project' (default) for facts about this codebase, or 'user' for personal preferences that apply to every project"},
 		},
 		Handler: handleAddMemory,
 	})
@@ -372,22 +505,24 @@ func newDefaultToolRegistry() *ToolRegistry {
 		Description: "Read a specific memory by name. Returns the full markdown content of the memory file.",
 		Parameters: []ParameterConfig{
 			{"name", "string", true, []string{}, "Name of the memory to read (without .md extension, e.g., 'git-safety')"},
+			{"scope", "string", false, []string{}, "'project' or 'user'; by default the project memory is read if there is one"},
 		},
 		Handler: handleReadMemory,
 	})
 
 	registry.RegisterTool(ToolConfig{
 		Name:        "list_memories",
-		Description: "List all saved memories. Returns memory names and their first lines (titles). Memories persist across all conversations.",
+		Description: "List all saved project and user memories. Returns memory names, scopes and their first lines (titles). Memories persist across conversations.",
 		Parameters:  []ParameterConfig{},
 		Handler:     handleListMemories,
 	})
 
 	registry.RegisterTool(ToolConfig{
 		Name:        "delete_memory",
-		Description: "Delete a memory by name. Permanently removes the memory file from ~/.ledit/memories/.",
+		Description: "Delete a memory by name. Permanently removes the memory file from the project's .ledit/memories/ or ~/.ledit/memories/.",
 		Parameters: []ParameterConfig{
 			{"name", "string", true, []string{}, "Name of the memory to delete (e.g., 'git-safety')"},
+			{"scope", "string", false, []string{}, "'project' or 'user'; by default the project memory is deleted if there is one"},
 		},
 		Handler: handleDeleteMemory,
 	})
@@ -416,88 +551,32 @@ func (r *ToolRegistry) ExecuteTool(ctx context.Context, toolName string, args ma
 		return nil, "", fmt.Errorf("unknown tool '%s'", toolName)
 	}
 
-	// CRITICAL: Prevent subagents from creating nested subagents
-	// This check ensures that subagents (identified by LEDIT_SUBAGENT env var)
-		// cannot spawn further subagents, preventing runaway agent chains
-	if os.Getenv("LEDIT_SUBAGENT") == "1" {
-		if toolName == "run_subagent" || toolName == "run_parallel_subagents" {
-			const errMsg = "SUBAGENT_RESTRICTION: Subagents are not allowed to spawn nested subagents. " +
-				"This restriction prevents runaway agent chains and ensures proper task delegation. " +
-				"If you need additional work done, please complete your current task and return " +
-				"your results to the primary agent for further delegation."
+	// CRITICAL: Prevent subagents nested at the depth limit (tracked through
+	// LEDIT_SUBAGENT_DEPTH) from spawning further subagents, preventing
+	// runaway agent chains
+	if toolName == "run_subagent" || toolName == "run_parallel_subagents" {
+		if err := agent.subagentDepthError(); err != nil {
 			if agent != nil && agent.debug {
-				agent.debugLog("[NO] Blocked subagent tool '%s' - nested subagents are not allowed\n", toolName)
+				agent.debugLog("[NO] Blocked subagent tool '%s' - subagent depth limit reached\n", toolName)
 			}
-			return nil, "", errors.New(errMsg)
+			return nil, "", err
 		}
 	}
 
-	// Security validation — classify and block/prompt dangerous operations
-	if secResult := tools.ClassifyToolCall(toolName, args); secResult.ShouldBlock || secResult.ShouldPrompt {
-		if agent != nil && agent.GetUnsafeMode() {
-			// Unsafe mode: bypass all security checks
-			if agent.debug {
-				agent.debugLog("[UNLOCK] Unsafe mode: bypassing security validation for %s (risk: %s)\n", toolName, secResult.Risk)
-			}
-		} else if agent != nil {
-			// Check if we're running as a subagent — subagents cannot prompt
-			isSubagent := os.Getenv("LEDIT_FROM_AGENT") == "1" || os.Getenv("LEDIT_SUBAGENT") == "1"
-
-			// Prefer webui approval path when a browser tab is connected.
-			// When the process has an active webui client, the query likely
-			// originated from the browser. Sending the approval request through
-			// the event bus ensures the dialog appears in the webui. The CLI
-			// interactive prompt is unreliable in this case because stdin may
-			// belong to the terminal that launched the server — the user is
-			// interacting via the browser, not the terminal.
-			if mgr := agent.GetSecurityApprovalMgr(); mgr != nil && agent.GetEventBus() != nil && !isSubagent && agent.HasActiveWebUIClients() {
-				// WEBUI: request approval via event bus for the browser dialog
-				if agent.debug {
-					agent.debugLog("[APPROVAL] Requesting security approval via webui for %s (risk: %s)\n", toolName, secResult.Risk)
-				}
-				// Build extras with context the webui dialog needs (command, target, risk type)
-				extras := map[string]string{}
-				if secResult.RiskType != "" {
-					extras["risk_type"] = formatRiskType(secResult.RiskType)
-				}
-				switch toolName {
-				case "shell_command":
-					if cmd, ok := args["command"].(string); ok && cmd != "" {
-						extras["command"] = cmd
-					}
-				case "write_file", "edit_file", "write_structured_file", "patch_structured_file":
-					if path, ok := args["path"].(string); ok && path != "" {
-						extras["target"] = path
-					}
-				case "git":
-					if op, ok := args["operation"].(string); ok && op != "" {
-						extras["target"] = fmt.Sprintf("git %s", op)
-					}
-				}
-				if !mgr.RequestApproval(agent.GetEventBus(), agent.GetEventClientID(), toolName, secResult.Risk.String(), secResult.Reasoning, extras) {
-					return nil, "", fmt.Errorf("security rejected: user rejected %s — %s", toolName, secResult.Reasoning)
-				}
-			} else {
-				// CLI: prompt user interactively via terminal stdin
-				agentConfig := agent.GetConfig()
-				logger := utils.GetLogger(agentConfig != nil && agentConfig.SkipPrompt)
-				canPrompt := logger != nil && logger.IsInteractive() && !isSubagent
-
-				if canPrompt {
-					prompt := buildSecurityPrompt(toolName, args, secResult)
-					if !logger.AskForConfirmation(prompt, false, false) {
-						return nil, "", fmt.Errorf("security rejected: user rejected %s — %s", toolName, secResult.Reasoning)
-					}
-				} else if secResult.ShouldBlock {
-					// NON-INTERACTIVE + DANGEROUS, no approval mechanism: always block
-					return nil, "", fmt.Errorf("security block: %s — %s", toolName, secResult.Reasoning)
-				} else if secResult.ShouldPrompt && !isSubagent {
-					// NON-INTERACTIVE + CAUTION, needs prompt but no approval mechanism:
-					// Return a special error that tells the LLM to re-assert safety before proceeding
-					return nil, "", fmt.Errorf("security caution: %s — %s (requires LLM verification: confirm this action is safe, expected, and aligned with user goals before proceeding)", toolName, secResult.Reasoning)
-				}
-				// NON-INTERACTIVE + CAUTION, no approval mechanism, not a subagent: auto-allow (safe operations)
-			}
+	// Offline, network tools fail at once instead of timing out
+	if networkTools[toolName] && agent != nil && agent.Offline() {
+		return nil, "", offlineToolError(toolName)
+	}
+
+	// In dry-run mode state-changing calls only show a preview, so they
+	// need no approval
+	dryRun := agent != nil && agent.DryRun() && changesState(toolName, args)
+
+	// Security validation — classify and block/prompt dangerous operations,
+	// unless the tool policy already cleared the call
+	if secResult := tools.ClassifyToolCall(toolName, args); !dryRun && (secResult.ShouldBlock || secResult.ShouldPrompt) && !policySkipsApproval(ctx, secResult) {
+		if err := approveToolCall(agent, toolName, args, secResult); err != nil {
+			return nil, "", err
 		}
 	}
 
@@ -507,6 +586,23 @@ func (r *ToolRegistry) ExecuteTool(ctx context.Context, toolName string, args ma
 		return nil, "", fmt.Errorf("parameter validation failed for tool '%s': %w", toolName, err)
 	}
 
+	if dryRun {
+		if preview, handled, err := agent.previewToolCall(ctx, toolName, validatedArgs); handled {
+			return nil, preview, err
+		}
+	}
+
+	// Files this call changes without recording the write (shell commands,
+	// git, gopls) are its own changes, not edits made outside ledit, so they
+	// must not be reported as conflicts by later writes. Shell commands
+	// classified read-only are included: formatters and generators run that
+	// way can still write files
+	if changesState(toolName, validatedArgs) || toolName == "shell_command" {
+		locks := filesystem.Locks()
+		snapshot := locks.Snapshot()
+		defer locks.Adopt(snapshot)
+	}
+
 	// Execute the tool handler — prefer the image-capable handler when set
 	if tool.HandlerImages != nil {
 		return tool.HandlerImages(ctx, agent, validatedArgs)
@@ -518,6 +614,78 @@ func (r *ToolRegistry) ExecuteTool(ctx context.Context, toolName string, args ma
 	return nil, result, nil
 }
 
+// approveToolCall asks the user to approve a tool call flagged by secResult,
+// via the webui when a browser is connected and the terminal otherwise. It
+// returns an error when the call is rejected or cannot be approved.
+func approveToolCall(agent *Agent, toolName string, args map[string]interface{}, secResult tools.SecurityResult) error {
+	if agent != nil && agent.GetUnsafeMode() {
+		// Unsafe mode: bypass all security checks
+		if agent.debug {
+			agent.debugLog("[UNLOCK] Unsafe mode: bypassing security validation for %s (risk: %s)\n", toolName, secResult.Risk)
+		}
+	} else if agent != nil {
+		// Check if we're running as a subagent — subagents cannot prompt
+		isSubagent := os.Getenv("LEDIT_FROM_AGENT") == "1" || os.Getenv("LEDIT_SUBAGENT") == "1"
+
+		// Prefer webui approval path when a browser tab is connected.
+		// When the process has an active webui client, the query likely
+		// originated from the browser. Sending the approval request through
+		// the event bus ensures the dialog appears in the webui. The CLI
+		// interactive prompt is unreliable in this case because stdin may
+		// belong to the terminal that launched the server — the user is
+		// interacting via the browser, not the terminal.
+		if mgr := agent.GetSecurityApprovalMgr(); mgr != nil && agent.GetEventBus() != nil && !isSubagent && agent.HasActiveWebUIClients() {
+			// WEBUI: request approval via event bus for the browser dialog
+			if agent.debug {
+				agent.debugLog("[APPROVAL] Requesting security approval via webui for %s (risk: %s)\n", toolName, secResult.Risk)
+			}
+			// Build extras with context the webui dialog needs (command, target, risk type)
+			extras := map[string]string{}
+			if secResult.RiskType != "" {
+				extras["risk_type"] = formatRiskType(secResult.RiskType)
+			}
+			switch toolName {
+			case "shell_command":
+				if cmd, ok := args["command"].(string); ok && cmd != "" {
+					extras["command"] = cmd
+				}
+			case "write_file", "edit_file", "write_structured_file", "patch_structured_file":
+				if path, ok := args["path"].(string); ok && path != "" {
+					extras["target"] = path
+				}
+			case "git":
+				if op, ok := args["operation"].(string); ok && op != "" {
+					extras["target"] = fmt.Sprintf("git %s", op)
+				}
+			}
+			if !mgr.RequestApproval(agent.GetEventBus(), agent.GetEventClientID(), toolName, secResult.Risk.String(), secResult.Reasoning, extras) {
+				return fmt.Errorf("security rejected: user rejected %s — %s", toolName, secResult.Reasoning)
+			}
+		} else {
+			// CLI: prompt user interactively via terminal stdin
+			agentConfig := agent.GetConfig()
+			logger := utils.GetLogger(agentConfig != nil && agentConfig.SkipPrompt)
+			canPrompt := logger != nil && logger.IsInteractive() && !isSubagent
+
+			if canPrompt {
+				prompt := buildSecurityPrompt(toolName, args, secResult)
+				if !logger.AskForConfirmation(prompt, false, false) {
+					return fmt.Errorf("security rejected: user rejected %s — %s", toolName, secResult.Reasoning)
+				}
+			} else if secResult.ShouldBlock {
+				// NON-INTERACTIVE + DANGEROUS, no approval mechanism: always block
+				return fmt.Errorf("security block: %s — %s", toolName, secResult.Reasoning)
+			} else if secResult.ShouldPrompt && !isSubagent {
+				// NON-INTERACTIVE + CAUTION, needs prompt but no approval mechanism:
+				// Return a special error that tells the LLM to re-assert safety before proceeding
+				return fmt.Errorf("security caution: %s — %s (requires LLM verification: confirm this action is safe, expected, and aligned with user goals before proceeding)", toolName, secResult.Reasoning)
+			}
+			// NON-INTERACTIVE + CAUTION, no approval mechanism, not a subagent: auto-allow (safe operations)
+		}
+	}
+	return nil
+}
+
 // buildSecurityPrompt constructs a detailed security approval prompt for the user
 func buildSecurityPrompt(toolName string, args map[string]interface{}, secResult tools.SecurityResult) string {
 	var sb strings.Builder
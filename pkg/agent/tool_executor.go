@@ -24,6 +24,9 @@ type ToolExecutor struct {
 	toolIndex   int   // Counter for tool execution order within each turn
 	idCounter   int64 // Atomic counter for unique tool call ID generation
 	idCounterMu sync.Mutex
+
+	succeededMu sync.Mutex
+	succeeded   map[string]bool // IDs of the calls in the current batch that succeeded
 }
 
 // NewToolExecutor creates a new tool executor
@@ -37,6 +40,9 @@ func NewToolExecutor(agent *Agent) *ToolExecutor {
 func (te *ToolExecutor) ExecuteTools(toolCalls []api.ToolCall) []api.Message {
 	// Reset tool index counter at the start of each tool execution batch
 	te.toolIndex = 0
+	te.succeededMu.Lock()
+	te.succeeded = make(map[string]bool, len(toolCalls))
+	te.succeededMu.Unlock()
 
 	// Log tool calls at the beginning of the process
 	if te.agent != nil {
@@ -86,6 +92,11 @@ func (te *ToolExecutor) ExecuteTools(toolCalls []api.ToolCall) []api.Message {
 		// Context not cancelled
 	}
 
+	// A correction from the user voids the calls chosen before it
+	if te.agent.hasPendingSteer() {
+		return te.steeredToolResults(toolCalls)
+	}
+
 	// Optimize parallel execution for independent, side-effect-free batched tools.
 	if te.canExecuteInParallel(toolCalls) {
 		return te.executeParallel(toolCalls)
@@ -94,3 +105,26 @@ func (te *ToolExecutor) ExecuteTools(toolCalls []api.ToolCall) []api.Message {
 	// Sequential execution for other tools
 	return te.executeSequential(toolCalls)
 }
+
+// markSucceeded records that a call in the current batch ran without error.
+func (te *ToolExecutor) markSucceeded(toolCallID string) {
+	te.succeededMu.Lock()
+	defer te.succeededMu.Unlock()
+	if te.succeeded == nil {
+		te.succeeded = make(map[string]bool)
+	}
+	te.succeeded[toolCallID] = true
+}
+
+// succeededCalls returns the IDs of the calls in the last batch that ran
+// without error. Calls that were blocked, skipped, interrupted or only
+// previewed in dry-run mode are not included.
+func (te *ToolExecutor) succeededCalls() map[string]bool {
+	te.succeededMu.Lock()
+	defer te.succeededMu.Unlock()
+	succeeded := make(map[string]bool, len(te.succeeded))
+	for id := range te.succeeded {
+		succeeded[id] = true
+	}
+	return succeeded
+}
@@ -0,0 +1,107 @@
+// Tool executor: deduplication of repeated read-only tool calls.
+package agent
+
+import (
+	"fmt"
+	"sync"
+)
+
+// toolDedupTurns is how many model turns an idempotent call's result can be
+// reused for. A repeat after that runs again, so a model stuck re-issuing the
+// same call still reaches the circuit breaker.
+const toolDedupTurns = 3
+
+// dedupableTools return the same result for the same arguments as long as
+// nothing changes the workspace in between.
+var dedupableTools = map[string]bool{
+	"read_file": true, "read_symbol": true, "impact_of_change": true, "search_files": true,
+	"web_search": true, "fetch_url": true, "fetch_more": true, "view_history": true, "TodoRead": true,
+	"list_skills": true, "list_personas": true, "read_memory": true, "list_memories": true,
+}
+
+// ToolDedupStats counts tool calls answered with an earlier call's result.
+type ToolDedupStats struct {
+	Hits int
+}
+
+type toolDedupEntry struct {
+	result    string
+	iteration int
+}
+
+// toolDedup remembers the results of recent idempotent calls in the current
+// query, keyed by tool name and arguments. Any other tool call, or a file
+// changing on disk, may change what they return and clears it.
+type toolDedup struct {
+	mu      sync.Mutex
+	entries map[string]toolDedupEntry
+	stats   ToolDedupStats
+}
+
+func (a *Agent) toolDedupState() *toolDedup {
+	a.toolDedupOnce.Do(func() {
+		a.toolDedup = &toolDedup{entries: make(map[string]toolDedupEntry)}
+	})
+	return a.toolDedup
+}
+
+// ToolDedupStats returns how many repeated tool calls were deduplicated this
+// session.
+func (a *Agent) ToolDedupStats() ToolDedupStats {
+	d := a.toolDedupState()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.stats
+}
+
+// resetToolDedup forgets remembered results. Called at the start of each
+// query and when the workspace changes underneath the agent.
+func (a *Agent) resetToolDedup() {
+	d := a.toolDedupState()
+	d.mu.Lock()
+	clear(d.entries)
+	d.mu.Unlock()
+}
+
+// dedupedResult returns the result of an identical call made within the last
+// toolDedupTurns turns. Calls to tools that are not idempotent clear the
+// remembered results instead.
+func (te *ToolExecutor) dedupedResult(toolName string, args map[string]interface{}) (string, bool) {
+	d := te.agent.toolDedupState()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !dedupableTools[toolName] {
+		clear(d.entries)
+		return "", false
+	}
+	entry, ok := d.entries[te.generateActionKey(toolName, args)]
+	turns := te.agent.currentIteration - entry.iteration
+	if !ok || turns > toolDedupTurns {
+		return "", false
+	}
+	d.stats.Hits++
+	return fmt.Sprintf("[Repeated call: the same %s call ran %s and nothing has changed since, so it was not run again. Its result follows.]\n\n%s",
+		toolName, turnsAgo(turns), entry.result), true
+}
+
+// rememberResult records a successful idempotent call's result.
+func (te *ToolExecutor) rememberResult(toolName string, args map[string]interface{}, result string) {
+	if !dedupableTools[toolName] {
+		return
+	}
+	d := te.agent.toolDedupState()
+	d.mu.Lock()
+	d.entries[te.generateActionKey(toolName, args)] = toolDedupEntry{result: result, iteration: te.agent.currentIteration}
+	d.mu.Unlock()
+}
+
+func turnsAgo(turns int) string {
+	switch turns {
+	case 0:
+		return "earlier this turn"
+	case 1:
+		return "1 turn ago"
+	default:
+		return fmt.Sprintf("%d turns ago", turns)
+	}
+}
@@ -0,0 +1,62 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	api "github.com/alantheprice/ledit/pkg/agent_api"
+	"github.com/alantheprice/ledit/pkg/factory"
+)
+
+func TestToolExecutorDeduplicatesRepeatedReads(t *testing.T) {
+	agent := &Agent{
+		client:       &providerOverrideClient{TestClient: &factory.TestClient{}, provider: "openrouter"},
+		interruptCtx: context.Background(),
+		outputMutex:  &sync.Mutex{},
+	}
+	executor := NewToolExecutor(agent)
+
+	filePath := filepath.Join(t.TempDir(), "dedup.txt")
+	if err := os.WriteFile(filePath, []byte("first version"), 0o644); err != nil {
+		t.Fatalf("failed to write temp file: %v", err)
+	}
+	read := api.ToolCall{ID: "call_read", Type: "function"}
+	read.Function.Name = "read_file"
+	read.Function.Arguments = `{"path":"` + filePath + `"}`
+
+	if msg := executor.executeSingleTool(read); strings.HasPrefix(msg.Content, "[Repeated call") {
+		t.Fatalf("expected the first read to run, got: %q", msg.Content)
+	}
+	agent.currentIteration++
+	msg := executor.executeSingleTool(read)
+	if !strings.HasPrefix(msg.Content, "[Repeated call: the same read_file call ran 1 turn ago") || !strings.Contains(msg.Content, "first version") {
+		t.Fatalf("expected the repeat to reuse the first result, got: %q", msg.Content)
+	}
+	if hits := agent.ToolDedupStats().Hits; hits != 1 {
+		t.Fatalf("expected 1 deduplicated call, got %d", hits)
+	}
+
+	// Any other tool call may change what a read returns
+	todo := api.ToolCall{ID: "call_todo", Type: "function"}
+	todo.Function.Name = "TodoWrite"
+	todo.Function.Arguments = `{"todos":[]}`
+	executor.executeSingleTool(todo)
+	if err := os.WriteFile(filePath, []byte("second version"), 0o644); err != nil {
+		t.Fatalf("failed to write temp file: %v", err)
+	}
+	if msg := executor.executeSingleTool(read); strings.HasPrefix(msg.Content, "[Repeated call") || !strings.Contains(msg.Content, "second version") {
+		t.Fatalf("expected a read after another tool call to run again, got: %q", msg.Content)
+	}
+
+	agent.currentIteration += toolDedupTurns + 1
+	if msg := executor.executeSingleTool(read); strings.HasPrefix(msg.Content, "[Repeated call") {
+		t.Fatalf("expected a repeat outside the turn window to run again, got: %q", msg.Content)
+	}
+	if line := agent.toolDedupSummaryLine(); line != "1 tool call answered from an identical earlier call" {
+		t.Fatalf("unexpected summary line %q", line)
+	}
+}
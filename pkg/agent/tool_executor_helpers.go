@@ -32,7 +32,7 @@ func (te *ToolExecutor) tryExecuteMCPTool(toolName string, args map[string]inter
 
 // shouldStopExecution checks if execution should stop after a tool
 func (te *ToolExecutor) shouldStopExecution(toolName, result string) bool {
-	// Stop on ask_user to wait for response
+	// Stop on ask_user: calls made with it were chosen before the answer
 	if toolName == "ask_user" {
 		return true
 	}
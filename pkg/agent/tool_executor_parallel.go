@@ -50,6 +50,9 @@ func (te *ToolExecutor) parallelBatchToolName(toolCalls []api.ToolCall) string {
 
 func (te *ToolExecutor) normalizeToolNameForScheduling(toolName string) string {
 	name := strings.Split(toolName, "<|channel|>")[0]
+	if _, ok := te.agent.customToolHandler(name); ok {
+		return name
+	}
 	if alias := te.agent.suggestCorrectToolName(name); alias != "" {
 		return alias
 	}
@@ -58,7 +61,7 @@ func (te *ToolExecutor) normalizeToolNameForScheduling(toolName string) string {
 
 func isParallelSafeBatchTool(toolName string) bool {
 	switch toolName {
-	case "read_file", "fetch_url", "search_files":
+	case "read_file", "read_symbol", "impact_of_change", "file_history", "fetch_url", "search_files":
 		return true
 	default:
 		return false
@@ -51,8 +51,26 @@ func (te *ToolExecutor) executeSequential(toolCalls []api.ToolCall) []api.Messag
 		result := te.executeSingleTool(tc)
 		toolResults = append(toolResults, result)
 
-		// Check if execution should stop
+		// Check if execution should stop; the calls left still need a
+		// result so the conversation stays valid
 		if te.shouldStopExecution(tc.Function.Name, result.Content) {
+			for _, skipped := range toolCalls[i+1:] {
+				toolCallID := skipped.ID
+				if toolCallID == "" {
+					toolCallID = te.GenerateToolCallID(skipped.Function.Name)
+				}
+				toolResults = append(toolResults, api.Message{
+					Role:       "tool",
+					Content:    fmt.Sprintf("Skipped: %s stopped this batch of tool calls. Call %s again if it is still needed.", tc.Function.Name, skipped.Function.Name),
+					ToolCallId: toolCallID,
+				})
+			}
+			break
+		}
+
+		// Pause the chain after this call when the user sent a correction
+		if te.agent.hasPendingSteer() {
+			toolResults = append(toolResults, te.steeredToolResults(toolCalls[i+1:])...)
 			break
 		}
 	}
@@ -119,6 +137,19 @@ func (te *ToolExecutor) executeSingleToolWithIndex(toolCall api.ToolCall, toolIn
 		}
 	}
 
+	// Answer an exact repeat of a recent read-only call with its result
+	if cached, ok := te.dedupedResult(normalizedToolName, args); ok {
+		te.agent.debugLog("[tool] Deduplicated repeated %s call\n", normalizedToolName)
+		te.updateCircuitBreaker(normalizedToolName, args)
+		te.agent.PublishToolEnd(toolCallID, normalizedToolName, "completed", cached, "", time.Since(startTime))
+		te.markSucceeded(toolCallID)
+		return api.Message{
+			Role:       "tool",
+			Content:    cached,
+			ToolCallId: toolCallID,
+		}
+	}
+
 	// Create a context with a timeout for the tool execution
 	// Subagents get 30 minutes (for large file operations), other tools get 5 minutes
 	// Can be overridden via LEDIT_TOOL_TIMEOUT environment variable
@@ -135,6 +166,24 @@ func (te *ToolExecutor) executeSingleToolWithIndex(toolCall api.ToolCall, toolIn
 
 	// Execute the tool in a goroutine
 	go func() {
+		ctx, err := te.agent.authorizeToolCall(ctx, normalizedToolName, args)
+		if err != nil {
+			resultChan <- struct {
+				images []api.ImageData
+				result string
+				err    error
+			}{nil, "", err}
+			return
+		}
+		if err := te.agent.runBeforeToolHooks(ctx, normalizedToolName, args); err != nil {
+			resultChan <- struct {
+				images []api.ImageData
+				result string
+				err    error
+			}{nil, "", err}
+			return
+		}
+
 		if normalizedToolName == "mcp_tools" {
 			result, err := te.agent.handleMCPToolsCommand(args)
 			resultChan <- struct {
@@ -145,8 +194,18 @@ func (te *ToolExecutor) executeSingleToolWithIndex(toolCall api.ToolCall, toolIn
 			return
 		}
 
-		registry := GetToolRegistry()
 		execCtx := withToolExecutionMetadata(ctx, toolCallID, normalizedToolName, te.agent.GetWorkspaceRoot())
+		if handler, ok := te.agent.customToolHandler(normalizedToolName); ok {
+			result, err := handler(execCtx, te.agent, args)
+			resultChan <- struct {
+				images []api.ImageData
+				result string
+				err    error
+			}{nil, result, err}
+			return
+		}
+
+		registry := GetToolRegistry()
 		images, result, err := registry.ExecuteTool(execCtx, normalizedToolName, args, te.agent)
 
 		if err != nil && strings.Contains(err.Error(), "unknown tool") {
@@ -209,7 +268,7 @@ func (te *ToolExecutor) executeSingleToolWithIndex(toolCall api.ToolCall, toolIn
 			// The LLM will see this and can decide to re-assert safety and retry, or abort
 			return api.Message{
 				Role:       "tool",
-				Content:    fmt.Sprintf("SECURITY_CAUTION_REQUIRED: %s", safeErr),
+				Content:    te.redactErrorForModel(fmt.Sprintf("SECURITY_CAUTION_REQUIRED: %s", safeErr), normalizedToolName, args),
 				ToolCallId: toolCallID,
 			}
 		}
@@ -230,16 +289,21 @@ func (te *ToolExecutor) executeSingleToolWithIndex(toolCall api.ToolCall, toolIn
 	// modelResult is what gets sent to the model (may be truncated)
 	modelResult := fullResult
 	if err == nil {
-		modelResult = constrainToolResultForModel(normalizedToolName, args, fullResult)
+		modelResult = te.agent.constrainToolResultForModel(normalizedToolName, args, fullResult)
+		// After hooks see the whole result; what they report goes to the model
+		if !te.agent.DryRun() {
+			modelResult += te.agent.runAfterToolHooks(te.agent.interruptCtx, normalizedToolName, args, fullResult)
+		}
 	}
 
 	// Apply secret redaction to tool output before sending to LLM.
-	if err == nil && modelResult != "" && te.agent.outputRedactor != nil &&
-		isSecretSensitiveTool(normalizedToolName) {
+	if err == nil && modelResult != "" && te.agent.secretRedactionEnabled() {
 		redactResult := te.agent.outputRedactor.RedactToolOutput(modelResult, normalizedToolName, args)
 		if len(redactResult.Secrets) > 0 {
 			modelResult = te.applySecretElevation(modelResult, redactResult, normalizedToolName, args, toolCallID)
 		}
+	} else if err != nil {
+		modelResult = te.redactErrorForModel(modelResult, normalizedToolName, args)
 	}
 
 	// Also redact fullResult for trace recording to avoid storing secrets.
@@ -262,6 +326,12 @@ func (te *ToolExecutor) executeSingleToolWithIndex(toolCall api.ToolCall, toolIn
 
 	// Update circuit breaker
 	te.updateCircuitBreaker(normalizedToolName, args)
+	if err == nil {
+		te.rememberResult(normalizedToolName, args, modelResult)
+		if !te.agent.DryRun() || !changesState(normalizedToolName, args) {
+			te.markSucceeded(toolCallID)
+		}
+	}
 
 	// Publish rich tool end event for real-time UI updates
 	if te.agent != nil {
@@ -284,6 +354,16 @@ func (te *ToolExecutor) executeSingleToolWithIndex(toolCall api.ToolCall, toolIn
 	}
 }
 
+// redactErrorForModel redacts secrets from a failed call's message, which
+// often echoes the command or request that failed. Unlike output, an error
+// is redacted without asking the user: there is nothing to allow through.
+func (te *ToolExecutor) redactErrorForModel(content, toolName string, args map[string]interface{}) string {
+	if content == "" || !te.agent.secretRedactionEnabled() {
+		return content
+	}
+	return te.agent.outputRedactor.RedactToolOutput(content, toolName, args).Content
+}
+
 // applySecretElevation evaluates detected secrets through the elevation gate
 // and returns the appropriate content to send to the LLM (redacted, allowed, or blocked).
 func (te *ToolExecutor) applySecretElevation(originalResult string, redactResult security.RedactionResult, toolName string, args map[string]interface{}, toolCallID string) string {
@@ -315,7 +395,8 @@ func (te *ToolExecutor) applySecretElevation(originalResult string, redactResult
 		te.agent.PrintLine(fmt.Sprintf("[security] Blocked %s: %d secret(s) detected, user chose to block", source, len(redactResult.Secrets)))
 		return fmt.Sprintf("BLOCKED: detected secrets in output. Operation blocked. Found %d secret(s) — user chose to block.", len(redactResult.Secrets))
 	default:
-		te.agent.debugLog("[security] redacted %d secret(s) from %s\n", len(redactResult.Secrets), toolName)
+		te.agent.PrintLine(fmt.Sprintf("[security] Redacted %d secret(s) from %s before sending to the model: %s",
+			len(redactResult.Secrets), source, security.DescribeSecrets(redactResult.Secrets)))
 		return redactResult.Content
 	}
 }
@@ -3,6 +3,7 @@ package agent
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
@@ -13,6 +14,7 @@ import (
 	tools "github.com/alantheprice/ledit/pkg/agent_tools"
 	"github.com/alantheprice/ledit/pkg/factory"
 	"github.com/alantheprice/ledit/pkg/mcp"
+	"github.com/alantheprice/ledit/pkg/security"
 )
 
 type fakeMCPManager struct {
@@ -190,6 +192,30 @@ func TestSanitizeToolFailureMessage_RedactsAndTruncates(t *testing.T) {
 	}
 }
 
+func TestExecuteSingleTool_RedactsSecretsInToolErrors(t *testing.T) {
+	agent := makeAgentWithScriptedClient(1, NewScriptedClient())
+	agent.outputRedactor = security.NewOutputRedactor()
+	const key = "sk-ant-REDACTED"
+	deploy := func(ctx context.Context, a *Agent, args map[string]interface{}) (string, error) {
+		return "", errors.New(`curl -H "Authorization: Bearer ` + key + `" failed: 401 Unauthorized`)
+	}
+	if err := agent.RegisterCustomTool(customToolDefinition("deploy"), deploy); err != nil {
+		t.Fatal(err)
+	}
+
+	tc := api.ToolCall{ID: "call-1", Type: "function"}
+	tc.Function.Name = "deploy"
+	tc.Function.Arguments = `{}`
+
+	msg := NewToolExecutor(agent).executeSingleTool(tc)
+	if strings.Contains(msg.Content, key) {
+		t.Fatalf("expected the key in the tool error to be redacted, got: %s", msg.Content)
+	}
+	if !strings.Contains(msg.Content, "[REDACTED") || !strings.Contains(msg.Content, "401 Unauthorized") {
+		t.Fatalf("expected the rest of the error to reach the model, got: %s", msg.Content)
+	}
+}
+
 func TestParseToolArgumentsWithRepair_MissingClosingBrace(t *testing.T) {
 	args, repaired, err := parseToolArgumentsWithRepair(`{"path":"README.md"`)
 	if err != nil {
@@ -424,7 +450,7 @@ func TestCanExecuteInParallelSearchFilesProviderRestrictions(t *testing.T) {
 
 func TestConstrainToolResultForModel_NonFetchURLUnchanged(t *testing.T) {
 	input := strings.Repeat("a", defaultFetchURLResultMaxChars+1000)
-	got := constrainToolResultForModel("read_file", nil, input)
+	got := (&Agent{}).constrainToolResultForModel("read_file", nil, input)
 	if got != input {
 		t.Fatalf("expected non-fetch tool output to remain unchanged")
 	}
@@ -434,7 +460,7 @@ func TestConstrainToolResultForModel_FetchURLTruncatesLargeOutput(t *testing.T)
 	t.Setenv("LEDIT_FETCH_URL_MAX_CHARS", "100")
 	input := strings.Repeat("x", 220)
 
-	got := constrainToolResultForModel("fetch_url", map[string]interface{}{"url": "https://example.com"}, input)
+	got := (&Agent{}).constrainToolResultForModel("fetch_url", map[string]interface{}{"url": "https://example.com"}, input)
 	if !strings.Contains(got, "FETCH_URL OUTPUT TRUNCATED FOR MODEL CONTEXT") {
 		t.Fatalf("expected truncation marker in output")
 	}
@@ -453,7 +479,7 @@ func TestConstrainToolResultForModel_FetchURLRespectsSmallOutput(t *testing.T) {
 	t.Setenv("LEDIT_FETCH_URL_MAX_CHARS", "500")
 	input := strings.Repeat("b", 120)
 
-	got := constrainToolResultForModel("fetch_url", nil, input)
+	got := (&Agent{}).constrainToolResultForModel("fetch_url", nil, input)
 	if got != input {
 		t.Fatalf("expected output below limit to remain unchanged")
 	}
@@ -465,7 +491,7 @@ func TestConstrainToolResultForModel_FetchURLSavesFullOutputToArchive(t *testing
 	t.Setenv("LEDIT_FETCH_URL_ARCHIVE_DIR", archiveDir)
 
 	input := strings.Repeat("z", 220)
-	got := constrainToolResultForModel("fetch_url", map[string]interface{}{"url": "https://example.com/long"}, input)
+	got := (&Agent{}).constrainToolResultForModel("fetch_url", map[string]interface{}{"url": "https://example.com/long"}, input)
 
 	if !strings.Contains(got, "Full output saved to ") {
 		t.Fatalf("expected full output path in truncation notice")
@@ -516,7 +542,7 @@ func TestConstrainToolResultForModel_AnalyzeImageContentCompactsJSONResult(t *te
 		t.Fatalf("marshal OCR result: %v", err)
 	}
 
-	got := constrainToolResultForModel("analyze_image_content", nil, string(raw))
+	got := (&Agent{}).constrainToolResultForModel("analyze_image_content", nil, string(raw))
 
 	if got == string(raw) {
 		t.Fatalf("expected analyze_image_content result to be compacted")
@@ -537,7 +563,7 @@ func TestConstrainToolResultForModel_AnalyzeImageContentCompactsJSONResult(t *te
 
 func TestConstrainToolResultForModel_AnalyzeImageContentLeavesInvalidJSONUntouched(t *testing.T) {
 	input := "not-json"
-	got := constrainToolResultForModel("analyze_image_content", nil, input)
+	got := (&Agent{}).constrainToolResultForModel("analyze_image_content", nil, input)
 	if got != input {
 		t.Fatalf("expected invalid OCR payload to remain unchanged")
 	}
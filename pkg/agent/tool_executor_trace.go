@@ -12,6 +12,9 @@ import (
 
 // recordToolExecutionWithIndex records tool execution data to the trace session
 func (te *ToolExecutor) recordToolExecutionWithIndex(toolName string, rawArgs string, args map[string]interface{}, fullResult, modelResult string, err error, toolIndex int) {
+	if te.agent != nil && te.agent.runRecorder != nil {
+		te.agent.runRecorder.recordToolCall(te.agent.currentIteration, toolName, rawArgs, fullResult, err)
+	}
 	if te.agent == nil || te.agent.traceSession == nil {
 		return // Trace session not enabled
 	}
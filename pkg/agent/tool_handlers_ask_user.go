@@ -0,0 +1,97 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	tools "github.com/alantheprice/ledit/pkg/agent_tools"
+)
+
+// Tool handler implementation for asking the user a question
+
+// askUserOtherValue is the dropdown value of the entry that lets the user
+// type an answer that is not among the options.
+const askUserOtherValue = "\x00other"
+
+func handleAskUser(_ context.Context, a *Agent, args map[string]interface{}) (string, error) {
+	question, err := convertToString(args["question"], "question")
+	if err != nil {
+		return "", fmt.Errorf("failed to convert question parameter: %w", err)
+	}
+	question = strings.TrimSpace(question)
+	if question == "" {
+		return "", errors.New("question must not be empty")
+	}
+	var options []string
+	if raw, ok := args["options"].([]interface{}); ok {
+		for _, item := range raw {
+			if option, ok := item.(string); ok && strings.TrimSpace(option) != "" {
+				options = append(options, strings.TrimSpace(option))
+			}
+		}
+	}
+	allowOther := true
+	if v, ok := args["allow_other"].(bool); ok {
+		allowOther = v
+	}
+	if len(options) == 0 {
+		allowOther = true
+	}
+
+	if !a.canAskUser() {
+		return "The user cannot be asked: ledit is not running interactively, so nobody can answer. " +
+			"Make the most reasonable assumption, continue, and state the assumption in your final response.", nil
+	}
+
+	answer, err := a.askUser(question, options, allowOther)
+	if err != nil {
+		if errors.Is(err, ErrCancelled) {
+			answer = ""
+		} else {
+			return "", fmt.Errorf("failed to ask the user: %w", err)
+		}
+	}
+	if answer == "" {
+		return "The user did not answer. Make the most reasonable assumption, continue, and state the assumption in your final response.", nil
+	}
+	return "The user answered: " + answer, nil
+}
+
+// canAskUser reports whether someone is at the terminal to answer a
+// question: not a subagent, automation or a run with piped input.
+func (a *Agent) canAskUser() bool {
+	if os.Getenv("LEDIT_FROM_AGENT") == "1" || os.Getenv("LEDIT_SUBAGENT") == "1" || isCIOutput() {
+		return false
+	}
+	return (a.ui != nil && a.ui.IsInteractive()) || !isNonInteractive()
+}
+
+// askUser asks question with the dropdown when a UI is available and on
+// stdin otherwise. An empty answer means the user skipped the question.
+func (a *Agent) askUser(question string, options []string, allowOther bool) (string, error) {
+	if len(options) == 0 {
+		return tools.AskUser(question)
+	}
+
+	choices := make([]ChoiceOption, 0, len(options)+1)
+	for _, option := range options {
+		choices = append(choices, ChoiceOption{Label: option, Value: option})
+	}
+	if allowOther {
+		choices = append(choices, ChoiceOption{Label: "Other (type an answer)", Value: askUserOtherValue})
+	}
+	choice, err := a.PromptChoice(question, choices)
+	if errors.Is(err, ErrUINotAvailable) {
+		return tools.AskUserChoice(question, options, allowOther)
+	}
+	if err != nil {
+		return "", err
+	}
+	if choice == askUserOtherValue {
+		return tools.AskUser("Your answer")
+	}
+	return choice, nil
+}
@@ -0,0 +1,43 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	api "github.com/alantheprice/ledit/pkg/agent_api"
+)
+
+func TestAskUserWithoutAnInteractiveUser(t *testing.T) {
+	t.Setenv("LEDIT_SUBAGENT", "1")
+	a := &Agent{}
+	result, err := handleAskUser(context.Background(), a, map[string]interface{}{
+		"question": "Which database?",
+		"options":  []interface{}{"PostgreSQL", "SQLite"},
+	})
+	if err != nil {
+		t.Fatalf("handleAskUser: %v", err)
+	}
+	if !strings.Contains(result, "cannot be asked") || !strings.Contains(result, "assumption") {
+		t.Fatalf("expected the model to be told to proceed on an assumption, got %q", result)
+	}
+
+	if _, err := handleAskUser(context.Background(), a, map[string]interface{}{"question": "  "}); err == nil {
+		t.Fatal("expected an empty question to be rejected")
+	}
+}
+
+func TestAskUserStopsTheRestOfTheBatch(t *testing.T) {
+	t.Setenv("LEDIT_SUBAGENT", "1")
+	a := makeAgentWithScriptedClient(1, NewScriptedClient())
+	results := NewToolExecutor(a).ExecuteTools([]api.ToolCall{
+		policyToolCall("call-1", "ask_user", `{"question": "Which database?"}`),
+		policyToolCall("call-2", "read_file", `{"path": "a.txt"}`),
+	})
+	if len(results) != 2 {
+		t.Fatalf("expected a result for every call, got %+v", results)
+	}
+	if results[1].ToolCallId != "call-2" || !strings.Contains(results[1].Content, "Skipped") {
+		t.Fatalf("expected the call after ask_user to be skipped, got %+v", results[1])
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/alantheprice/ledit/pkg/console"
 	"github.com/alantheprice/ledit/pkg/events"
 	"github.com/alantheprice/ledit/pkg/filesystem"
+	"github.com/alantheprice/ledit/pkg/security"
 )
 
 // Tool handler implementations for file operations
@@ -26,6 +27,8 @@ func handleReadFile(ctx context.Context, a *Agent, args map[string]interface{})
 		return "", fmt.Errorf("failed to get file path: %w", err)
 	}
 
+	ctx = tools.WithReadLimits(ctx, a.readLimits())
+
 	// Parse view_range (Claude Code style: [start, end])
 	var startLine, endLine int
 	var hasRange bool
@@ -52,11 +55,17 @@ func handleReadFile(ctx context.Context, a *Agent, args map[string]interface{})
 				result, err = tools.ReadFileWithRange(ctx2, path, startLine, endLine)
 			}
 		}
+		if errors.Is(err, tools.ErrNonTextFile) {
+			result, err = tools.DescribeBinaryFile(ctx, path)
+		}
 
 		a.debugLog("Read file result: %s, error: %v\n", result, err)
 
 		if err == nil {
 			a.AddTaskAction("file_read", fmt.Sprintf("Read file: %s (lines %d-%d)", path, startLine, endLine), path)
+			if absPath, resolveErr := filesystem.SafeResolvePathWithBypass(ctx, path); resolveErr == nil {
+				filesystem.Locks().ObserveFile(absPath)
+			}
 		}
 
 		if err != nil {
@@ -66,6 +75,21 @@ func handleReadFile(ctx context.Context, a *Agent, args map[string]interface{})
 	}
 
 	a.debugLog("Reading file: %s\n", path)
+
+	// Serve unchanged files from the read cache (filled by earlier reads and
+	// the import prefetcher), then prefetch this file's imports.
+	absPath, resolveErr := filesystem.SafeResolvePathWithBypass(ctx, path)
+	if resolveErr == nil {
+		if cached, ok := a.cachedRead(absPath); ok {
+			a.debugLog("Read file served from cache: %s\n", absPath)
+			filesystem.Locks().ObserveFile(absPath)
+			a.AddTaskAction("file_read", fmt.Sprintf("Read file: %s", path), path)
+			a.prefetchImports(ctx, absPath, cached)
+			return cached, nil
+		}
+	}
+	info, _ := os.Stat(absPath)
+
 	result, err := tools.ReadFile(ctx, path)
 
 	if err != nil {
@@ -74,6 +98,15 @@ func handleReadFile(ctx context.Context, a *Agent, args map[string]interface{})
 			result, err = tools.ReadFile(ctx2, path)
 		}
 	}
+	if errors.Is(err, tools.ErrNonTextFile) {
+		result, err = tools.DescribeBinaryFile(ctx, path)
+	}
+
+	if err == nil && resolveErr == nil {
+		filesystem.Locks().ObserveFile(absPath)
+		a.storeRead(absPath, result, info, false)
+		a.prefetchImports(ctx, absPath, result)
+	}
 
 	a.debugLog("Read file result: %s, error: %v\n", result, err)
 
@@ -87,6 +120,48 @@ func handleReadFile(ctx context.Context, a *Agent, args map[string]interface{})
 	return result, nil
 }
 
+func handleReadSymbol(ctx context.Context, a *Agent, args map[string]interface{}) (string, error) {
+	path, err := getFilePath(args)
+	if err != nil {
+		return "", fmt.Errorf("failed to get file path: %w", err)
+	}
+	symbol, _ := args["symbol"].(string)
+	if strings.TrimSpace(symbol) == "" {
+		return "", fmt.Errorf("symbol is required")
+	}
+
+	a.debugLog("Reading symbol %s from file: %s\n", symbol, path)
+	result, err := tools.ReadSymbol(ctx, path, symbol)
+	if err != nil {
+		ctx2 := handleFileSecurityError(ctx, a, "read_symbol", path, err)
+		if ctx2 != ctx {
+			result, err = tools.ReadSymbol(ctx2, path, symbol)
+		}
+	}
+	if err != nil {
+		return "", fmt.Errorf("read symbol %q from %s: %w", symbol, path, err)
+	}
+
+	a.AddTaskAction("file_read", fmt.Sprintf("Read symbol %s from %s", symbol, path), path)
+	if absPath, resolveErr := filesystem.SafeResolvePathWithBypass(ctx, path); resolveErr == nil {
+		filesystem.Locks().ObserveFile(absPath)
+	}
+	return result, nil
+}
+
+// readLimits returns the read_file limits set in config.json.
+func (a *Agent) readLimits() tools.ReadLimits {
+	config := a.GetConfig()
+	if config == nil || config.FileRead == nil {
+		return tools.ReadLimits{}
+	}
+	return tools.ReadLimits{
+		MaxBytes:           config.FileRead.MaxKB * 1024,
+		RangeMaxBytes:      config.FileRead.RangeMaxKB * 1024,
+		BinaryPreviewBytes: config.FileRead.BinaryPreviewBytes,
+	}
+}
+
 // isImageExtension returns true for common image file extensions
 func isImageExtension(filePath string) bool {
 	ext := strings.ToLower(filepath.Ext(filePath))
@@ -354,6 +429,18 @@ func writeFileContent(ctx context.Context, a *Agent, path, content, toolName str
 		a.debugLog("%s\n", warning)
 	}
 
+	previousContent, _ := tools.ReadFile(ctx, path)
+	if err := checkRedactionPlaceholders(toolName, path, previousContent, content); err != nil {
+		return "", err
+	}
+	if err := checkKnownConfigSchema(toolName, path, previousContent, content); err != nil {
+		return "", err
+	}
+
+	if a.DryRun() {
+		return a.previewFileWrite(path, previousContent, content), nil
+	}
+
 	a.debugLog("Writing file: %s\n", path)
 
 	if trackErr := a.TrackFileWrite(path, content); trackErr != nil {
@@ -388,6 +475,7 @@ func writeFileContent(ctx context.Context, a *Agent, path, content, toolName str
 
 	// Publish file change event for web UI auto-sync
 	if err == nil {
+		a.noteAgentFileWrite(path)
 		a.publishEvent(events.EventTypeFileChanged, events.FileChangedEvent(path, "write", content))
 		a.debugLog("Published file_changed event: %s (write)\n", path)
 
@@ -403,7 +491,7 @@ func writeFileContent(ctx context.Context, a *Agent, path, content, toolName str
 	if err != nil {
 		return "", fmt.Errorf("failed to write file %s: %w", path, err)
 	}
-	return result, nil
+	return result + a.checkMissingDependencies(ctx, path, content), nil
 }
 
 func handleEditFile(ctx context.Context, a *Agent, args map[string]interface{}) (string, error) {
@@ -421,6 +509,9 @@ func handleEditFile(ctx context.Context, a *Agent, args map[string]interface{})
 	if err != nil {
 		return "", fmt.Errorf("failed to get new_str parameter: %w", err)
 	}
+	if err := checkRedactionPlaceholders("edit_file", path, oldStr, newStr); err != nil {
+		return "", err
+	}
 
 	if warning := validateJSONContent(newStr, path); warning != "" {
 		a.debugLog("%s\n", warning)
@@ -460,6 +551,19 @@ func handleEditFile(ctx context.Context, a *Agent, args map[string]interface{})
 		a.CheckFileContentSecurity(path, newStr)
 	}
 
+	// Known config files (package.json, compose files, workflows, Kubernetes
+	// manifests) are checked against their schema; violations undo the edit.
+	if err == nil {
+		if editedContent, readErr := tools.ReadFile(ctx, path); readErr == nil {
+			if schemaErr := checkKnownConfigSchema("edit_file", path, originalContent, editedContent); schemaErr != nil {
+				if _, werr := tools.WriteFile(ctx, path, originalContent); werr != nil {
+					return "", fmt.Errorf("edit violates schema in %s and restore failed: %w (schema error: %v)", path, werr, schemaErr)
+				}
+				return "", schemaErr
+			}
+		}
+	}
+
 	// JSON edits are transparently validated and normalized through structured writes.
 	if err == nil && strings.EqualFold(filepath.Ext(path), ".json") {
 		editedContent, readErr := tools.ReadFile(ctx, path)
@@ -495,6 +599,7 @@ func handleEditFile(ctx context.Context, a *Agent, args map[string]interface{})
 
 	// Publish file change event for web UI auto-sync
 	if err == nil {
+		a.noteAgentFileWrite(path)
 		var eventContent string
 		if eventContent, err = tools.ReadFile(ctx, path); err == nil {
 			a.publishEvent(events.EventTypeFileChanged, events.FileChangedEvent(path, "edit", eventContent))
@@ -513,17 +618,19 @@ func handleEditFile(ctx context.Context, a *Agent, args map[string]interface{})
 	}
 
 	// Display diff if successful
+	var dependencyNote string
 	if err == nil {
 		newContent, readErr := tools.ReadFile(ctx, path)
 		if readErr == nil {
 			a.ShowColoredDiff(originalContent, newContent, 50)
+			dependencyNote = a.checkMissingDependencies(ctx, path, newContent)
 		}
 	}
 
 	if err != nil {
 		return "", fmt.Errorf("failed to edit file %s: %w", path, err)
 	}
-	return result, nil
+	return result + dependencyNote, nil
 }
 
 // Helper functions for file handlers
@@ -576,6 +683,16 @@ func validateJSONContent(content, path string) string {
 	return ""
 }
 
+// checkRedactionPlaceholders rejects content that adds secret redaction
+// placeholders over previous. The model only saw the placeholder, so writing
+// it back would replace the real value in the file.
+func checkRedactionPlaceholders(toolName, path, previous, content string) error {
+	if security.CountRedactionPlaceholders(content) <= security.CountRedactionPlaceholders(previous) {
+		return nil
+	}
+	return fmt.Errorf("%s: content for %s contains [REDACTED] placeholders, which stand in for secrets hidden from you; the change was not applied. Leave the redacted lines as they are and edit around them, or ask the user to fill in the values", toolName, path)
+}
+
 func disallowRawStructuredWrite(path, toolName string) error {
 	ext := strings.ToLower(filepath.Ext(path))
 	switch ext {
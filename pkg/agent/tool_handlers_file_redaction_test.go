@@ -0,0 +1,46 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	tools "github.com/alantheprice/ledit/pkg/agent_tools"
+)
+
+func TestFileToolsRejectRedactionPlaceholders(t *testing.T) {
+	t.Chdir(t.TempDir())
+	original := "API_URL=https://api.example.com\nAPI_TOKEN=[REDACTED:High Entropy String]\n"
+
+	_, err := handleWriteFile(context.Background(), nil, map[string]interface{}{
+		"path":    "settings.env",
+		"content": original,
+	})
+	if err == nil || !strings.Contains(err.Error(), "the change was not applied") {
+		t.Fatalf("expected write_file to refuse a placeholder, got %v", err)
+	}
+	if _, statErr := os.Stat("settings.env"); !os.IsNotExist(statErr) {
+		t.Fatalf("expected nothing to be written, got %v", statErr)
+	}
+
+	_, err = handleEditFile(context.Background(), nil, map[string]interface{}{
+		"path":    "settings.env",
+		"old_str": "API_TOKEN=abc",
+		"new_str": "API_TOKEN=[REDACTED]",
+	})
+	if err == nil || !strings.Contains(err.Error(), "edit_file") {
+		t.Fatalf("expected edit_file to refuse a placeholder, got %v", err)
+	}
+
+	// Placeholders already in the file, such as in test fixtures, stay editable
+	if err := os.WriteFile("settings.env", []byte(original), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stageEdits(context.Background(), []tools.TransactionEdit{{Path: "settings.env", OldStr: "api.example.com", NewStr: "api.example.org"}}); err != nil {
+		t.Fatalf("expected an edit keeping the placeholder to be staged, got %v", err)
+	}
+	if _, err := stageEdits(context.Background(), []tools.TransactionEdit{{Path: "settings.env", OldStr: "https://api.example.com", NewStr: "[REDACTED:Slack Webhook]"}}); err == nil {
+		t.Fatal("expected edit_transaction to refuse an added placeholder")
+	}
+}
@@ -0,0 +1,49 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tools "github.com/alantheprice/ledit/pkg/agent_tools"
+	"github.com/alantheprice/ledit/pkg/filesystem"
+)
+
+// Tool handler implementations for lint operations
+
+func handleRunLinters(ctx context.Context, a *Agent, args map[string]interface{}) (string, error) {
+	root := filesystem.WorkspaceRootFromContext(ctx)
+	if root == "" {
+		root = a.currentWorkspaceRoot()
+	}
+	path := getOptionalString(args, "path")
+	if path != "" {
+		absPath, err := filesystem.SafeResolvePathWithBypass(ctx, path)
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve path: %w", err)
+		}
+		path = absPath
+	}
+	var linters []string
+	if raw, ok := args["linters"].([]interface{}); ok {
+		for _, item := range raw {
+			if name, ok := item.(string); ok && strings.TrimSpace(name) != "" {
+				linters = append(linters, name)
+			}
+		}
+	}
+	limit := tools.DefaultLintLimit
+	if v, ok := args["max_results"]; ok {
+		if normalized := normalizePositiveInt(v); normalized > 0 {
+			limit = normalized
+		}
+	}
+
+	a.debugLog("Running linters in %s (path=%q, linters=%v)\n", root, path, linters)
+	report, err := tools.RunLinters(ctx, root, path, linters)
+	if err != nil {
+		return "", fmt.Errorf("run linters: %w", err)
+	}
+	a.AddTaskAction("command_executed", fmt.Sprintf("Executed: %s", strings.Join(report.Ran, ", ")), strings.Join(report.Ran, ", "))
+	return report.Summary(limit), nil
+}
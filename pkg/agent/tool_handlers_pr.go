@@ -0,0 +1,251 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	tools "github.com/alantheprice/ledit/pkg/agent_tools"
+	"github.com/alantheprice/ledit/pkg/forge"
+)
+
+// Tool handler implementations for the pull request (forge) integration
+
+// OpenForge detects the forge behind the workspace's origin remote and
+// returns the current branch state with an authenticated client.
+func (a *Agent) OpenForge() (*forge.Workspace, forge.Forge, error) {
+	var hosts map[string]string
+	if cfg := a.GetConfig(); cfg != nil {
+		hosts = cfg.ForgeHosts
+	}
+	dir := a.GetWorkspaceRoot()
+	ws, err := forge.Detect(dir, hosts)
+	if err != nil {
+		return nil, nil, err
+	}
+	token, err := forge.ResolveToken(ws.Repo.Kind)
+	if err != nil {
+		return nil, nil, err
+	}
+	client, err := forge.New(ws.Repo, token)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ws, client, nil
+}
+
+// ResolvePullRequest returns pull request number, or the open pull request of
+// the current branch when number is 0.
+func ResolvePullRequest(ctx context.Context, ws *forge.Workspace, client forge.Forge, number int) (int, error) {
+	if number > 0 {
+		return number, nil
+	}
+	pr, err := client.FindPullRequest(ctx, ws.Branch)
+	if err != nil {
+		return 0, err
+	}
+	if pr == nil {
+		return 0, fmt.Errorf("no open pull request for branch %s", ws.Branch)
+	}
+	return pr.Number, nil
+}
+
+// AddPullRequestTodos adds a pending todo for every comment that starts a
+// review thread and is not on the todo list yet. It returns how many were
+// added.
+func AddPullRequestTodos(number int, comments []forge.Comment) (int, error) {
+	todos := tools.TodoRead()
+	existing := make(map[string]bool, len(todos))
+	for _, todo := range todos {
+		existing[todo.ID] = true
+	}
+
+	added := 0
+	for _, comment := range comments {
+		id := fmt.Sprintf("pr-%d-comment-%s", number, comment.ID)
+		if comment.Reply || existing[id] {
+			continue
+		}
+		content := fmt.Sprintf("Address review comment %s by @%s", comment.ID, comment.Author)
+		if loc := comment.Location(); loc != "" {
+			content += " on " + loc
+		}
+		content += ": " + summarizeComment(comment.Body, 160)
+		todos = append(todos, tools.TodoItem{
+			ID:       id,
+			Content:  content,
+			Status:   "pending",
+			Priority: "medium",
+			Source:   fmt.Sprintf("PR #%d", number),
+		})
+		added++
+	}
+	if added == 0 {
+		return 0, nil
+	}
+	if err := tools.UpdateTodos(todos); err != nil {
+		return 0, err
+	}
+	return added, nil
+}
+
+// FormatPullRequestComments renders comments for display, replies indented
+// under the comment they answer.
+func FormatPullRequestComments(comments []forge.Comment) string {
+	var sb strings.Builder
+	for _, comment := range comments {
+		indent := ""
+		if comment.Reply {
+			indent = "    "
+		}
+		fmt.Fprintf(&sb, "%s[%s] @%s", indent, comment.ID, comment.Author)
+		if loc := comment.Location(); loc != "" {
+			fmt.Fprintf(&sb, " on %s", loc)
+		}
+		fmt.Fprintf(&sb, " (%s)\n", comment.CreatedAt.Format("2006-01-02 15:04"))
+		for _, line := range strings.Split(strings.TrimSpace(comment.Body), "\n") {
+			fmt.Fprintf(&sb, "%s  %s\n", indent, line)
+		}
+	}
+	return sb.String()
+}
+
+// summarizeComment returns the first line of body, shortened to max runes.
+func summarizeComment(body string, max int) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(body), "\n")
+	runes := []rune(strings.TrimSpace(line))
+	if len(runes) > max {
+		return string(runes[:max]) + "..."
+	}
+	return string(runes)
+}
+
+func handlePullRequest(ctx context.Context, a *Agent, args map[string]interface{}) (string, error) {
+	action, err := convertToString(args["action"], "action")
+	if err != nil {
+		return "", fmt.Errorf("failed to convert action parameter: %w", err)
+	}
+	ws, client, err := a.OpenForge()
+	if err != nil {
+		return "", err
+	}
+	number, _ := args["number"].(int)
+
+	switch strings.ToLower(strings.TrimSpace(action)) {
+	case "status":
+		return pullRequestStatus(ctx, a, ws, client)
+	case "create":
+		return createPullRequest(ctx, a, ws, client, args)
+	case "comments":
+		number, err := ResolvePullRequest(ctx, ws, client, number)
+		if err != nil {
+			return "", err
+		}
+		comments, err := client.ListComments(ctx, number)
+		if err != nil {
+			return "", err
+		}
+		if len(comments) == 0 {
+			return fmt.Sprintf("Pull request #%d has no comments", number), nil
+		}
+		result := fmt.Sprintf("Pull request #%d has %d comments:\n%s", number, len(comments), FormatPullRequestComments(comments))
+		if addTodos, _ := args["add_todos"].(bool); addTodos {
+			added, err := AddPullRequestTodos(number, comments)
+			if err != nil {
+				return "", err
+			}
+			result += fmt.Sprintf("\nAdded %d todos for unaddressed review threads", added)
+		}
+		return result, nil
+	case "reply":
+		commentID, _ := args["comment_id"].(string)
+		body, _ := args["body"].(string)
+		if strings.TrimSpace(commentID) == "" || strings.TrimSpace(body) == "" {
+			return "", errors.New("reply requires comment_id and body")
+		}
+		number, err := ResolvePullRequest(ctx, ws, client, number)
+		if err != nil {
+			return "", err
+		}
+		comments, err := client.ListComments(ctx, number)
+		if err != nil {
+			return "", err
+		}
+		for _, comment := range comments {
+			if comment.ID == strings.TrimSpace(commentID) {
+				reply, err := client.Reply(ctx, number, comment, body)
+				if err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("Replied to comment %s on pull request #%d (reply %s)", comment.ID, number, reply.ID), nil
+			}
+		}
+		return "", fmt.Errorf("comment %s not found on pull request #%d", commentID, number)
+	default:
+		return "", fmt.Errorf("unknown pr action %q: use create, status, comments or reply", action)
+	}
+}
+
+func pullRequestStatus(ctx context.Context, a *Agent, ws *forge.Workspace, client forge.Forge) (string, error) {
+	pushed := "not pushed"
+	if ws.IsPushed(a.GetWorkspaceRoot()) {
+		pushed = "pushed"
+	}
+	status := fmt.Sprintf("Repository %s on %s, branch %s (%s), base %s", ws.Repo.FullName(), ws.Repo.Host, ws.Branch, pushed, ws.Base)
+	pr, err := client.FindPullRequest(ctx, ws.Branch)
+	if err != nil {
+		return "", err
+	}
+	if pr == nil {
+		return status + "\nNo open pull request for this branch", nil
+	}
+	draft := ""
+	if pr.Draft {
+		draft = " (draft)"
+	}
+	return fmt.Sprintf("%s\nPull request #%d%s: %s\n%s", status, pr.Number, draft, pr.Title, pr.URL), nil
+}
+
+func createPullRequest(ctx context.Context, a *Agent, ws *forge.Workspace, client forge.Forge, args map[string]interface{}) (string, error) {
+	title, _ := args["title"].(string)
+	if strings.TrimSpace(title) == "" {
+		return "", errors.New("create requires a title")
+	}
+	body, _ := args["body"].(string)
+	base, _ := args["base"].(string)
+	if base == "" {
+		base = ws.Base
+	}
+	if ws.Branch == base {
+		return "", fmt.Errorf("current branch %s is the base branch; create a feature branch first", ws.Branch)
+	}
+	if existing, err := client.FindPullRequest(ctx, ws.Branch); err != nil {
+		return "", err
+	} else if existing != nil {
+		return fmt.Sprintf("Pull request #%d already exists for %s: %s", existing.Number, ws.Branch, existing.URL), nil
+	}
+
+	dir := a.GetWorkspaceRoot()
+	if !ws.IsPushed(dir) {
+		if push, _ := args["push"].(bool); !push {
+			return "", fmt.Errorf("branch %s is not pushed to %s; push it first or set push=true", ws.Branch, ws.Remote)
+		}
+		if err := ws.Push(ctx, dir); err != nil {
+			return "", err
+		}
+	}
+
+	draft, _ := args["draft"].(bool)
+	pr, err := client.CreatePullRequest(ctx, forge.CreateRequest{
+		Title: strings.TrimSpace(title),
+		Body:  body,
+		Head:  ws.Branch,
+		Base:  base,
+		Draft: draft,
+	})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Created pull request #%d (%s → %s): %s", pr.Number, ws.Branch, base, pr.URL), nil
+}
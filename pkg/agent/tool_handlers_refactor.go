@@ -0,0 +1,66 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	tools "github.com/alantheprice/ledit/pkg/agent_tools"
+	"github.com/alantheprice/ledit/pkg/events"
+	"github.com/alantheprice/ledit/pkg/filesystem"
+)
+
+// Tool handler implementations for refactoring operations
+
+// renameDiffMaxLines caps the changed lines shown per file by rename_symbol.
+const renameDiffMaxLines = 20
+
+func handleRenameSymbol(ctx context.Context, a *Agent, args map[string]interface{}) (string, error) {
+	path, err := getFilePath(args)
+	if err != nil {
+		return "", fmt.Errorf("failed to get file path: %w", err)
+	}
+	symbol, err := getRequiredString(args, "symbol")
+	if err != nil {
+		return "", fmt.Errorf("failed to get symbol parameter: %w", err)
+	}
+	newName, err := getRequiredString(args, "new_name")
+	if err != nil {
+		return "", fmt.Errorf("failed to get new_name parameter: %w", err)
+	}
+	line := 0
+	if v, ok := args["line"]; ok {
+		line = normalizePositiveInt(v)
+	}
+	root := filesystem.WorkspaceRootFromContext(ctx)
+	if root == "" {
+		root = a.currentWorkspaceRoot()
+	}
+
+	a.debugLog("Renaming %s in %s to %s\n", symbol, path, newName)
+	files, err := tools.RenameSymbol(ctx, root, path, symbol, newName, line)
+	if err != nil {
+		return "", fmt.Errorf("rename %s to %s: %w", symbol, newName, err)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Renamed %s to %s in %d file(s):\n", symbol, newName, len(files))
+	for _, f := range files {
+		if trackErr := a.TrackFileEdit(f.Path, f.Before, f.After); trackErr != nil {
+			a.debugLog("Warning: Failed to track file edit: %v\n", trackErr)
+		}
+		if a.optimizer != nil {
+			a.optimizer.InvalidateFile(f.Path)
+		}
+		a.noteAgentFileWrite(f.Path)
+		a.publishEvent(events.EventTypeFileChanged, events.FileChangedEvent(f.Path, "edit", f.After))
+
+		display := f.Path
+		if rel, relErr := filepath.Rel(root, f.Path); relErr == nil && !strings.HasPrefix(rel, "..") {
+			display = rel
+		}
+		fmt.Fprintf(&sb, "\n%s:\n%s", display, dryRunDiff(f.Before, f.After, renameDiffMaxLines))
+	}
+	return sb.String(), nil
+}
@@ -36,7 +36,20 @@ func getSearchMaxBytes() int {
 
 // Tool handler implementations for search operations
 
+// searchRequest holds the normalized search_files arguments.
+type searchRequest struct {
+	pattern       string
+	root          string
+	glob          string
+	caseSensitive bool
+	fixedStrings  bool // treat pattern as a literal string
+	multiline     bool // allow matches to span lines
+	maxResults    int
+	maxBytes      int
+}
 
+// searchExcludedDirs are skipped by both search backends.
+var searchExcludedDirs = []string{".git", "node_modules", ".ledit", ".venv", "dist", "build", ".cache"}
 
 func handleSearchFiles(ctx context.Context, a *Agent, args map[string]interface{}) (string, error) {
 	var pattern string
@@ -67,56 +80,114 @@ func handleSearchFiles(ctx context.Context, a *Agent, args map[string]interface{
 		glob = v
 	}
 
-	caseSensitive := false
+	req := searchRequest{
+		pattern:    pattern,
+		root:       root,
+		glob:       glob,
+		maxResults: defaultSearchMaxResults,
+		maxBytes:   getSearchMaxBytes(),
+	}
 	if v, ok := args["case_sensitive"].(bool); ok {
-		caseSensitive = v
+		req.caseSensitive = v
+	}
+	if v, ok := args["fixed_strings"].(bool); ok {
+		req.fixedStrings = v
+	}
+	if v, ok := args["multiline"].(bool); ok {
+		req.multiline = v
 	}
-
-	maxResults := defaultSearchMaxResults
 	if v, ok := args["max_results"]; ok {
 		if normalized := normalizePositiveInt(v); normalized > 0 {
-			maxResults = normalized
+			req.maxResults = normalized
 		}
 	}
-
-	maxBytes := getSearchMaxBytes()
 	if v, ok := args["max_bytes"]; ok {
 		if normalized := normalizePositiveInt(v); normalized > 0 {
-			maxBytes = normalized
+			req.maxBytes = normalized
+		}
+	}
+
+	a.debugLog("Searching files: pattern=%q, root=%s, max_results=%d\n", pattern, root, req.maxResults)
+
+	var (
+		out     string
+		matched int
+		capped  bool
+		err     error
+	)
+	rg := ripgrepPath()
+	if rg != "" {
+		out, matched, capped, err = searchFilesWithRipgrep(ctx, rg, req)
+		if err != nil {
+			a.debugLog("ripgrep search failed, falling back to built-in search: %v\n", err)
+		}
+	}
+	if rg == "" || err != nil {
+		out, matched, capped, err = searchFilesWithWalker(req)
+		if err != nil {
+			return "", err
 		}
 	}
 
-	a.debugLog("Searching files: pattern=%q, root=%s, max_results=%d\n", pattern, root, maxResults)
+	if matched == 0 {
+		return fmt.Sprintf("No matches found for pattern '%s' in %s", pattern, root), nil
+	}
+
+	// Add truncation warning if search was capped by max_bytes limit
+	if capped {
+		return fmt.Sprintf("%s\n\n[Search results truncated due to max_bytes limit (%d bytes). Consider increasing max_bytes parameter or using LEDIT_SEARCH_MAX_BYTES env var.]", out, req.maxBytes), nil
+	}
+	return out, nil
+}
+
+// searchFilesWithWalker is the built-in search used when ripgrep is not
+// available. It returns the formatted matches, the match count and whether
+// the output was capped.
+func searchFilesWithWalker(req searchRequest) (string, int, bool, error) {
+	pattern := req.pattern
+	caseSensitive := req.caseSensitive
 
 	// Prepare matcher: try regex first, then fallback to substring
 	var re *regexp.Regexp
 	var err error
-	if caseSensitive {
-		re, err = regexp.Compile(pattern)
+	if req.fixedStrings {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	flags := ""
+	if !caseSensitive {
+		flags += "i"
+	}
+	if req.multiline {
+		flags += "s"
+	}
+	if flags != "" {
+		re, err = regexp.Compile("(?" + flags + ")" + pattern)
 	} else {
-		re, err = regexp.Compile("(?i)" + pattern)
+		re, err = regexp.Compile(pattern)
 	}
 	useRegex := err == nil
+	pattern = req.pattern
 
 	// Default excluded directories
-	excluded := map[string]bool{
-		".git":         true,
-		"node_modules": true,
-		".ledit":       true,
-		".venv":        true,
-		"dist":         true,
-		"build":        true,
-		".cache":       true,
+	excluded := make(map[string]bool, len(searchExcludedDirs))
+	for _, dir := range searchExcludedDirs {
+		excluded[dir] = true
 	}
 
 	matched := 0
 	var b strings.Builder
 	searchCapped := false
+	search := func(path, content string) bool {
+		if req.multiline && useRegex {
+			return searchBufferMultiline(&b, path, content, re, &matched, req.maxResults, req.maxBytes)
+		}
+		return searchBufferLines(&b, path, content, re, pattern, caseSensitive, useRegex, &matched, req.maxResults, req.maxBytes)
+	}
 
 	// Limit per-file read to avoid huge files (in bytes)
 	const maxFileSize = 2 * 1024 * 1024 // 2MB
 
-	walkErr := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+	walkErr := filepath.WalkDir(req.root, func(path string, d os.DirEntry, err error) error {
 		if searchCapped {
 			return io.EOF
 		}
@@ -138,9 +209,9 @@ func handleSearchFiles(ctx context.Context, a *Agent, args map[string]interface{
 		}
 
 		// Glob filter
-		if glob != "" {
+		if req.glob != "" {
 			// Use base name for typical patterns
-			if ok, _ := filepath.Match(glob, name); !ok {
+			if ok, _ := filepath.Match(req.glob, name); !ok {
 				return nil
 			}
 		}
@@ -176,7 +247,7 @@ func handleSearchFiles(ctx context.Context, a *Agent, args map[string]interface{
 				return nil
 			}
 			// search within this chunk by lines
-			if searchBufferLines(&b, path, string(buf), re, pattern, caseSensitive, useRegex, &matched, maxResults, maxBytes) {
+			if search(path, string(buf)) {
 				searchCapped = true
 				return io.EOF // stop walking by returning non-nil? better: track and stop later
 			}
@@ -191,7 +262,7 @@ func handleSearchFiles(ctx context.Context, a *Agent, args map[string]interface{
 		if bytesIndexByte(content, 0) >= 0 {
 			return nil
 		}
-		if searchBufferLines(&b, path, string(content), re, pattern, caseSensitive, useRegex, &matched, maxResults, maxBytes) {
+		if search(path, string(content)) {
 			searchCapped = true
 			return io.EOF
 		}
@@ -199,18 +270,9 @@ func handleSearchFiles(ctx context.Context, a *Agent, args map[string]interface{
 	})
 
 	if walkErr != nil && walkErr != io.EOF {
-		return "", fmt.Errorf("search failed: %w", walkErr)
+		return "", 0, false, fmt.Errorf("search failed: %w", walkErr)
 	}
-
-	if matched == 0 {
-		return fmt.Sprintf("No matches found for pattern '%s' in %s", pattern, root), nil
-	}
-
-	// Add truncation warning if search was capped by max_bytes limit
-	if searchCapped {
-		return fmt.Sprintf("%s\n\n[Search results truncated due to max_bytes limit (%d bytes). Consider increasing max_bytes parameter or using LEDIT_SEARCH_MAX_BYTES env var.]", b.String(), maxBytes), nil
-	}
-	return b.String(), nil
+	return b.String(), matched, searchCapped, nil
 }
 
 func handleWebSearch(ctx context.Context, a *Agent, args map[string]interface{}) (string, error) {
@@ -334,6 +396,40 @@ func bytesIndexByte(b []byte, c byte) int {
 	return -1
 }
 
+// searchBufferMultiline appends regex matches that may span lines, printing
+// each matched line with its number; returns true if max reached
+func searchBufferMultiline(b *strings.Builder, path, content string, re *regexp.Regexp, matched *int, max int, maxBytes int) bool {
+	norm := filepath.ToSlash(path)
+	for _, loc := range re.FindAllStringIndex(content, -1) {
+		if *matched >= max || (maxBytes > 0 && b.Len() >= maxBytes) {
+			return true
+		}
+		lineStart := strings.LastIndexByte(content[:loc[0]], '\n') + 1
+		lineEnd := len(content)
+		if idx := strings.IndexByte(content[loc[1]:], '\n'); idx >= 0 {
+			lineEnd = loc[1] + idx
+		}
+		writeSearchMatch(b, norm, strings.Count(content[:lineStart], "\n")+1, content[lineStart:lineEnd])
+		*matched++
+		if maxBytes > 0 && b.Len() >= maxBytes {
+			return true
+		}
+	}
+	return false
+}
+
+// writeSearchMatch appends a match in grep format (path:line:content), one
+// output line per matched source line.
+func writeSearchMatch(b *strings.Builder, path string, lineNumber int, text string) {
+	for i, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if defaultSearchLineLength > 0 && len(line) > defaultSearchLineLength {
+			line = truncateString(line, defaultSearchLineLength)
+		}
+		fmt.Fprintf(b, "%s:%d:%s\n", path, lineNumber+i, line)
+	}
+}
+
 // searchBufferLines scans lines of content and appends matches; returns true if max reached
 func searchBufferLines(b *strings.Builder, path, content string, re *regexp.Regexp, pattern string, caseSensitive, useRegex bool, matched *int, max int, maxBytes int) bool {
 	// Normalize to forward slashes for readability
@@ -357,12 +453,8 @@ func searchBufferLines(b *strings.Builder, path, content string, re *regexp.Rege
 			}
 		}
 		if ok {
-			lineOut := line
-			if defaultSearchLineLength > 0 && len(lineOut) > defaultSearchLineLength {
-				lineOut = truncateString(lineOut, defaultSearchLineLength)
-			}
 			// Format similar to grep: path:line:content
-			fmt.Fprintf(b, "%s:%d:%s\n", norm, i+1, lineOut)
+			writeSearchMatch(b, norm, i+1, line)
 			*matched++
 			if maxBytes > 0 && b.Len() >= maxBytes {
 				return true
@@ -0,0 +1,151 @@
+package agent
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+var (
+	ripgrepOnce sync.Once
+	ripgrepBin  string
+)
+
+// ripgrepPath returns the rg binary used by search_files, or "" when it is
+// not installed or LEDIT_SEARCH_BACKEND=go selects the built-in search.
+func ripgrepPath() string {
+	if strings.EqualFold(strings.TrimSpace(os.Getenv("LEDIT_SEARCH_BACKEND")), "go") {
+		return ""
+	}
+	ripgrepOnce.Do(func() {
+		if path, err := exec.LookPath("rg"); err == nil {
+			ripgrepBin = path
+		}
+	})
+	return ripgrepBin
+}
+
+// ripgrepArgs builds the rg command line for req. rg respects .gitignore,
+// skips hidden and binary files, and reports matches as JSON lines.
+func ripgrepArgs(req searchRequest) []string {
+	args := []string{"--json", "--no-config", "--max-filesize", "2M"}
+	if req.caseSensitive {
+		args = append(args, "--case-sensitive")
+	} else {
+		args = append(args, "--ignore-case")
+	}
+	// Mirror the built-in search: patterns that are not valid regular
+	// expressions are searched literally.
+	if req.fixedStrings || !validSearchRegex(req.pattern) {
+		args = append(args, "--fixed-strings")
+	}
+	if req.multiline {
+		args = append(args, "--multiline", "--multiline-dotall")
+	}
+	for _, dir := range searchExcludedDirs {
+		args = append(args, "--glob", "!"+dir+"/")
+	}
+	if req.glob != "" {
+		// filepath.Match on base names in the built-in search; rg globs
+		// without a slash also match base names.
+		args = append(args, "--glob", req.glob)
+	}
+	return append(args, "--regexp", req.pattern, "--", filepath.Clean(req.root))
+}
+
+func validSearchRegex(pattern string) bool {
+	_, err := regexp.Compile(pattern)
+	return err == nil
+}
+
+// rgMessage is the subset of rg's --json output used by search_files.
+type rgMessage struct {
+	Type string `json:"type"`
+	Data struct {
+		Path       rgText `json:"path"`
+		Lines      rgText `json:"lines"`
+		LineNumber int    `json:"line_number"`
+	} `json:"data"`
+}
+
+// rgText holds rg's text-or-base64 values; non-UTF-8 content is base64.
+type rgText struct {
+	Text  string `json:"text"`
+	Bytes string `json:"bytes"`
+}
+
+// searchFilesWithRipgrep runs rg and formats its matches like the built-in
+// search (path:line:content). An error means the caller should fall back.
+func searchFilesWithRipgrep(ctx context.Context, rg string, req searchRequest) (string, int, bool, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, rg, ripgrepArgs(req)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return "", 0, false, err
+	}
+	if err := cmd.Start(); err != nil {
+		return "", 0, false, err
+	}
+
+	var b strings.Builder
+	matched := 0
+	capped := false
+	stopped := false
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 0, 64*1024), 8*1024*1024)
+	for scanner.Scan() {
+		var msg rgMessage
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil || msg.Type != "match" {
+			continue
+		}
+		if msg.Data.Path.Text == "" || msg.Data.Lines.Bytes != "" {
+			continue // non-UTF-8 path or content
+		}
+		if matched >= req.maxResults || (req.maxBytes > 0 && b.Len() >= req.maxBytes) {
+			capped, stopped = true, true
+			break
+		}
+		writeSearchMatch(&b, filepath.ToSlash(msg.Data.Path.Text), msg.Data.LineNumber, msg.Data.Lines.Text)
+		matched++
+		if req.maxBytes > 0 && b.Len() >= req.maxBytes {
+			capped, stopped = true, true
+			break
+		}
+	}
+	if stopped {
+		cancel()
+	}
+	waitErr := cmd.Wait()
+
+	if stopped {
+		return b.String(), matched, capped, nil
+	}
+	if scanErr := scanner.Err(); scanErr != nil {
+		return "", 0, false, fmt.Errorf("reading rg output: %w", scanErr)
+	}
+	if waitErr != nil {
+		var exitErr *exec.ExitError
+		// Exit status 1 means no matches; 2 with matches means some files
+		// could not be read, which the built-in search also skips.
+		if !errors.As(waitErr, &exitErr) || (exitErr.ExitCode() != 1 && matched == 0) {
+			return "", 0, false, fmt.Errorf("rg failed: %v: %s", waitErr, strings.TrimSpace(stderr.String()))
+		}
+	}
+	return b.String(), matched, capped, nil
+}
@@ -0,0 +1,91 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func writeSearchFixture(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	files := map[string]string{
+		"main.go":             "package main\n\nfunc main() {\n\tfmt.Println(\"a.b\")\n}\n",
+		"util.go":             "package main\n\n// aXb is not a literal match\nfunc helper() {}\n",
+		"node_modules/dep.js": "fmt.Println(\"a.b\")\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestSearchFilesFixedStringsAndMultiline(t *testing.T) {
+	backends := []string{"go"}
+	if ripgrepPath() != "" {
+		backends = append(backends, "rg")
+	}
+	for _, backend := range backends {
+		t.Run(backend, func(t *testing.T) {
+			if backend == "go" {
+				t.Setenv("LEDIT_SEARCH_BACKEND", "go")
+			}
+			root := writeSearchFixture(t)
+			a := &Agent{}
+
+			out, err := handleSearchFiles(context.Background(), a, map[string]interface{}{
+				"search_pattern": "a.b",
+				"directory":      root,
+				"fixed_strings":  true,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(out, "main.go:4:") || strings.Contains(out, "util.go") || strings.Contains(out, "node_modules") {
+				t.Errorf("unexpected fixed-string results:\n%s", out)
+			}
+
+			out, err = handleSearchFiles(context.Background(), a, map[string]interface{}{
+				"search_pattern": `func main\(\) \{.*?Println`,
+				"directory":      root,
+				"multiline":      true,
+				"file_glob":      "*.go",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines := strings.Split(strings.TrimSpace(out), "\n")
+			want := []string{"main.go:3:func main() {", "main.go:4:\tfmt.Println(\"a.b\")"}
+			for i := range lines {
+				lines[i] = strings.TrimPrefix(lines[i], filepath.ToSlash(root)+"/")
+			}
+			if !slices.Equal(lines, want) {
+				t.Errorf("multiline results = %q, want %q", lines, want)
+			}
+		})
+	}
+}
+
+func TestRipgrepArgs(t *testing.T) {
+	args := ripgrepArgs(searchRequest{pattern: "foo(", root: "./src/", glob: "*.go", multiline: true, maxResults: 10})
+	joined := strings.Join(args, " ")
+	for _, want := range []string{"--json", "--ignore-case", "--fixed-strings", "--multiline --multiline-dotall", "--glob !node_modules/", "--glob *.go", "--regexp foo( -- src"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("args %q missing %q", joined, want)
+		}
+	}
+
+	args = ripgrepArgs(searchRequest{pattern: `foo\(`, root: ".", caseSensitive: true})
+	if slices.Contains(args, "--fixed-strings") || !slices.Contains(args, "--case-sensitive") {
+		t.Errorf("unexpected args for a valid case-sensitive regex: %q", args)
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/alantheprice/ledit/pkg/factory"
 	"github.com/alantheprice/ledit/pkg/git"
 	"github.com/alantheprice/ledit/pkg/security"
+	"github.com/alantheprice/ledit/pkg/toolpolicy"
 )
 
 // configManagerInterface defines the interface for accessing config
@@ -78,7 +79,75 @@ func handleShellCommand(ctx context.Context, a *Agent, args map[string]interface
 		}
 	}
 
-	return a.executeShellCommandWithTruncation(ctx, command)
+	ctx, executor, err := a.shellSandboxContext(ctx, command)
+	if err != nil {
+		return "", err
+	}
+	ctx, backend, err := a.execBackendContext(ctx)
+	if err != nil {
+		return "", err
+	}
+	if backend != nil && executor != nil {
+		return "", fmt.Errorf("%s requires a sandbox for this command, which can't be applied to commands running on %s", toolpolicy.FileName, backend.Describe())
+	}
+
+	result, err := a.executeShellCommandWithTruncation(ctx, command)
+	if executor != nil {
+		result += fmt.Sprintf("\n[sandbox] Ran in %s; only the workspace is writable.", executor.Describe())
+	}
+	if isConflictProneGitCommand(command) {
+		result += a.conflictNotice()
+	}
+	return result, err
+}
+
+// describeLocalShell tells the model which shell runs shell_command when it
+// is PowerShell or cmd.exe, as on Windows without Git Bash, so it doesn't
+// write bash. Commands on a remote host or in a devcontainer run in sh.
+func (a *Agent) describeLocalShell(defs []api.Tool) []api.Tool {
+	shell := tools.LocalShell()
+	if shell.Kind == tools.ShellPOSIX {
+		return defs
+	}
+	if _, backend, err := a.execBackendContext(context.Background()); err == nil && backend != nil {
+		return defs
+	}
+	syntax := "PowerShell"
+	if shell.Kind == tools.ShellCmd {
+		syntax = "cmd.exe batch"
+	}
+	for i := range defs {
+		if defs[i].Function.Name == "shell_command" {
+			defs[i].Function.Description += fmt.Sprintf(" on Windows, in %s. Use %s syntax, not bash", shell.Name(), syntax)
+		}
+	}
+	return defs
+}
+
+// isConflictProneGitCommand reports whether command is a git operation that
+// can stop with merge conflicts.
+func isConflictProneGitCommand(command string) bool {
+	switch extractGitSubcommand(command) {
+	case "merge", "rebase", "pull", "cherry-pick", "revert", "am", "apply", "stash":
+		return true
+	}
+	return false
+}
+
+// conflictNotice describes unresolved merge conflicts in the workspace, or
+// returns "" when there are none.
+func (a *Agent) conflictNotice() string {
+	files, err := git.ConflictedFiles(a.GetWorkspaceRoot())
+	if err != nil || len(files) == 0 {
+		return ""
+	}
+	notice := fmt.Sprintf("\n\n[conflicts] %d file(s) have merge conflicts: %s\n"+
+		"Ask the user to run /resolve, which proposes a resolution for each conflict and applies it after their approval. "+
+		"Do not edit conflict markers away without the user's review.", len(files), strings.Join(files, ", "))
+	if op := git.InProgressOperation(a.GetWorkspaceRoot()); op != git.OperationNone {
+		notice += fmt.Sprintf(" Once resolved and staged, the %s continues with: %s", op, op.ContinueCommand())
+	}
+	return notice
 }
 
 // handleGitOperation handles git operations with approval for write operations
@@ -132,10 +201,13 @@ func handleGitOperation(ctx context.Context, a *Agent, args map[string]interface
 	}, "", nil, approvalPrompter)
 
 	if err != nil {
+		if notice := a.conflictNotice(); notice != "" {
+			return fmt.Sprintf("git %s stopped with conflicts: %v%s", operation, err, notice), nil
+		}
 		return "", fmt.Errorf("failed to execute git operation %s: %w", operation, err)
 	}
 
-	return result, nil
+	return result + a.conflictNotice(), nil
 }
 
 // isValidGitOperation checks if a git operation type is valid
@@ -9,6 +9,7 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"regexp"
 	"slices"
 	"strconv"
 	"strings"
@@ -109,6 +110,9 @@ func handlePatchStructuredFile(ctx context.Context, a *Agent, args map[string]in
 	if err != nil {
 		return "", fmt.Errorf("failed to read structured file: %w", err)
 	}
+	// The patch is based on this read; a write by another agent before the
+	// patched content is written back is a conflict.
+	filesystem.Locks().Observe(resolvedPath, contentBytes)
 
 	doc, err := deserializeStructuredContent(format, string(contentBytes))
 	if err != nil {
@@ -257,6 +261,12 @@ func validateDataAgainstSchema(data interface{}, schema map[string]interface{},
 	var errs []string
 	if typeRaw, ok := schema["type"]; ok {
 		typeName, _ := typeRaw.(string)
+		if options, ok := typeRaw.([]interface{}); ok {
+			typeName = matchSchemaType(data, options)
+			if typeName == "" {
+				return []string{fmt.Sprintf("%s: expected %s", path, joinSchemaTypes(options))}
+			}
+		}
 		switch typeName {
 		case "object":
 			obj, ok := data.(map[string]interface{})
@@ -278,8 +288,17 @@ func validateDataAgainstSchema(data interface{}, schema map[string]interface{},
 			for key, value := range obj {
 				propRaw, exists := props[key]
 				if !exists {
-					if additional, ok := schema["additionalProperties"].(bool); ok && !additional {
-						errs = append(errs, fmt.Sprintf("%s.%s: additional property not allowed", path, key))
+					if patternSchema, ok := matchPatternProperty(schema, key); ok {
+						errs = append(errs, validateDataAgainstSchema(value, patternSchema, path+"."+key)...)
+						continue
+					}
+					switch additional := schema["additionalProperties"].(type) {
+					case bool:
+						if !additional {
+							errs = append(errs, fmt.Sprintf("%s.%s: additional property not allowed", path, key))
+						}
+					case map[string]interface{}:
+						errs = append(errs, validateDataAgainstSchema(value, additional, path+"."+key)...)
 					}
 					continue
 				}
@@ -342,6 +361,57 @@ func validateDataAgainstSchema(data interface{}, schema map[string]interface{},
 	return errs
 }
 
+// matchSchemaType returns the first type in a "type" list that data satisfies.
+func matchSchemaType(data interface{}, options []interface{}) string {
+	for _, option := range options {
+		typeName, _ := option.(string)
+		var ok bool
+		switch typeName {
+		case "object":
+			_, ok = data.(map[string]interface{})
+		case "array":
+			_, ok = data.([]interface{})
+		case "string":
+			_, ok = data.(string)
+		case "number":
+			ok = isNumberValue(data)
+		case "integer":
+			ok = isIntegerValue(data)
+		case "boolean":
+			_, ok = data.(bool)
+		case "null":
+			ok = data == nil
+		}
+		if ok {
+			return typeName
+		}
+	}
+	return ""
+}
+
+func joinSchemaTypes(options []interface{}) string {
+	names := make([]string, 0, len(options))
+	for _, option := range options {
+		names = append(names, fmt.Sprint(option))
+	}
+	return strings.Join(names, " or ")
+}
+
+// matchPatternProperty returns the patternProperties schema whose pattern
+// matches key.
+func matchPatternProperty(schema map[string]interface{}, key string) (map[string]interface{}, bool) {
+	patterns, _ := schema["patternProperties"].(map[string]interface{})
+	for pattern, raw := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil || !re.MatchString(key) {
+			continue
+		}
+		propSchema, _ := raw.(map[string]interface{})
+		return propSchema, true
+	}
+	return nil, false
+}
+
 func formatStructuredValidationError(toolName string, errs []string, context string) error {
 	if len(errs) == 0 {
 		return errors.New("schema validation failed: no error details provided")
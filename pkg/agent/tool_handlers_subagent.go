@@ -21,6 +21,16 @@ const (
 	BATCH_SIZE                = 50               // Number of lines to batch before publishing
 )
 
+const (
+	// subagentContextFileShare is the percentage of the context window the
+	// files in a subagent prompt may use; the rest is left for its system
+	// prompt, tool definitions and work.
+	subagentContextFileShare = 50
+	// subagentContextBytesPerToken converts MAX_SUBAGENT_CONTEXT_SIZE to a
+	// token cap.
+	subagentContextBytesPerToken = 4
+)
+
 // MILESTONE_PHASES defines phases that trigger immediate publish without batching
 var MILESTONE_PHASES = []string{"spawn", "complete", "step"}
 
@@ -297,6 +307,10 @@ func extractSubagentSummary(stdout string) map[string]string {
 					errors = append(errors, trimmedLine)
 				}
 			case 'S', 's':
+				if strings.HasPrefix(trimmedLine, subagentCostTreePrefix) {
+					summary["subagent_cost_tree"] = strings.TrimSpace(trimmedLine[len(subagentCostTreePrefix):])
+					continue
+				}
 				if strings.HasPrefix(trimmedLine, "SUBAGENT_METRICS:") {
 					// Parse the metrics using pre-compiled regex
 					if matches := totalTokensRe.FindStringSubmatch(trimmedLine); len(matches) > 1 {
@@ -399,6 +413,51 @@ func extractSubagentSummary(stdout string) map[string]string {
 	return summary
 }
 
+// subagentFileBudget returns the tokens the files of a subagent prompt may
+// use: the share of the model's context window left after the rest of the
+// prompt, which takes promptTokens, capped by MAX_SUBAGENT_CONTEXT_SIZE.
+func (a *Agent) subagentFileBudget(promptTokens int) int {
+	window := 0
+	if a.client != nil {
+		window = a.GetMaxContextTokens()
+	}
+	if window <= 0 {
+		window = 32000
+	}
+	budget := window*subagentContextFileShare/100 - promptTokens
+	return max(min(budget, MAX_SUBAGENT_CONTEXT_SIZE/subagentContextBytesPerToken-promptTokens), 0)
+}
+
+// subagentDepth returns how deeply this agent is nested below the primary
+// agent and how deeply subagents may nest.
+func (a *Agent) subagentDepth() (depth, maxDepth int) {
+	configured := 0
+	if a != nil {
+		if cfg := a.GetConfig(); cfg != nil {
+			configured = cfg.SubagentMaxDepth
+		}
+	}
+	return tools.SubagentDepth(), tools.GetSubagentMaxDepth(configured)
+}
+
+// subagentDepthError returns why this agent may not spawn subagents, or nil
+// when it is above the depth limit.
+func (a *Agent) subagentDepthError() error {
+	depth, maxDepth := a.subagentDepth()
+	if depth < maxDepth {
+		return nil
+	}
+	return fmt.Errorf("SUBAGENT_RESTRICTION: this agent runs at subagent depth %d and subagents may nest at most %d level(s) deep, "+
+		"so it cannot spawn further subagents. Complete your current task and return your results "+
+		"to the agent that started you for further delegation", depth, maxDepth)
+}
+
+// subagentsAllowed reports whether the subagent tools are offered to the
+// model: subagents are enabled and this agent is above the depth limit.
+func (a *Agent) subagentsAllowed() bool {
+	return os.Getenv("LEDIT_NO_SUBAGENTS") != "1" && a.subagentDepthError() == nil
+}
+
 func handleRunSubagent(ctx context.Context, a *Agent, args map[string]interface{}) (string, error) {
 	prompt, err := convertToString(args["prompt"], "prompt")
 	if err != nil {
@@ -542,19 +601,28 @@ func handleRunSubagent(ctx context.Context, a *Agent, args map[string]interface{
 		enhancedPrompt.WriteString("\n---\n\n")
 	}
 
-	// Add relevant files section if provided
+	// Add relevant files section if provided, packed to fit the context
+	// window the subagent will have
 	if len(files) > 0 {
+		budget := a.subagentFileBudget(EstimateTokens(enhancedPrompt.String()) + EstimateTokens(prompt))
 		enhancedPrompt.WriteString("# Relevant Files\n\n")
-		for _, filePath := range files {
-			enhancedPrompt.WriteString(fmt.Sprintf("## File: %s\n\n", filePath))
-			content, err := tools.ReadFile(ctx, filePath)
-			if err != nil {
-				enhancedPrompt.WriteString(fmt.Sprintf("[Error reading file: %v]\n\n", err))
-				a.debugLog("Failed to read file %s for subagent context: %v\n", filePath, err)
-			} else {
-				enhancedPrompt.WriteString(content)
-				enhancedPrompt.WriteString("\n\n")
+		for _, file := range tools.PackFiles(ctx, files, context+"\n"+prompt, budget, EstimateTokens) {
+			if file.Err != nil {
+				enhancedPrompt.WriteString(fmt.Sprintf("## File: %s\n\n[Error reading file: %v]\n\n", file.Path, file.Err))
+				a.debugLog("Failed to read file %s for subagent context: %v\n", file.Path, file.Err)
+				continue
 			}
+			a.debugLog("Subagent context: %s included as %s (~%d tokens)\n", file.Path, file.Mode, file.Tokens)
+			switch file.Mode {
+			case tools.PackSymbols:
+				enhancedPrompt.WriteString(fmt.Sprintf("## File: %s (excerpts: %s; read_file for the rest)\n\n", file.Path, strings.Join(file.Symbols, ", ")))
+			case tools.PackOutline:
+				enhancedPrompt.WriteString(fmt.Sprintf("## File: %s (outline; read_file for the content)\n\n", file.Path))
+			default:
+				enhancedPrompt.WriteString(fmt.Sprintf("## File: %s\n\n", file.Path))
+			}
+			enhancedPrompt.WriteString(file.Text)
+			enhancedPrompt.WriteString("\n\n")
 		}
 		enhancedPrompt.WriteString("---\n\n")
 	}
@@ -575,6 +643,8 @@ func handleRunSubagent(ctx context.Context, a *Agent, args map[string]interface{
 	var provider string
 	var model string
 	explicitSubagentConfig := false
+	// The persona's token budget replaces LEDIT_SUBAGENT_MAX_TOKENS when set
+	maxTokens := tools.GetSubagentMaxTokens()
 
 	if a.configManager != nil {
 		config := a.configManager.GetConfig()
@@ -587,12 +657,15 @@ func handleRunSubagent(ctx context.Context, a *Agent, args map[string]interface{
 				model = config.GetSubagentTypeModel(persona)
 				systemPromptPath = subagentType.SystemPrompt
 				systemPromptText = subagentType.SystemPromptText
+				if subagentType.MaxTokens > 0 {
+					maxTokens = subagentType.MaxTokens
+				}
 				// Track if persona had explicit provider/model (not from global fallback)
 				if subagentType.Provider != "" || subagentType.Model != "" {
 					explicitSubagentConfig = true
 				}
-				a.debugLog("Using persona '%s': provider=%s model=%s system_prompt=%s\n",
-					persona, provider, model, systemPromptPath)
+				a.debugLog("Using persona '%s': provider=%s model=%s system_prompt=%s max_tokens=%d\n",
+					persona, provider, model, systemPromptPath, maxTokens)
 				a.warnSubagentFallback(fmt.Sprintf("persona '%s'", persona), strings.TrimSpace(subagentType.Provider), strings.TrimSpace(subagentType.Model), provider, model)
 			} else {
 				a.debugLog("Warning: Persona '%s' not found or disabled, using default subagent config\n", persona)
@@ -678,7 +751,7 @@ func handleRunSubagent(ctx context.Context, a *Agent, args map[string]interface{
 	})
 	fmt.Fprintf(os.Stderr, "[~] Spawning subagent [%s]: provider=%s, model=%s\n", persona, displayProvider, displayModel)
 
-	resultMap, err := tools.RunSubagent(a.currentWorkspaceRoot(), enhancedPrompt.String(), model, provider, streamCallback, systemPromptPath, systemPromptText, persona)
+	resultMap, err := tools.RunSubagent(a.currentWorkspaceRoot(), enhancedPrompt.String(), model, provider, streamCallback, systemPromptPath, systemPromptText, persona, maxTokens)
 	if err != nil {
 		a.debugLog("Subagent spawn error: %v\n", err)
 		return "", fmt.Errorf("failed to spawn subagent: %w", err)
@@ -699,6 +772,7 @@ func handleRunSubagent(ctx context.Context, a *Agent, args map[string]interface{
 	// Extract summary from stdout
 	if stdout, ok := resultMap["stdout"]; ok {
 		summary := extractSubagentSummary(stdout)
+		costChildren := takeSubagentCostTree(summary)
 		summaryJSON, err := json.MarshalIndent(summary, "", "  ")
 		if err != nil {
 			a.debugLog("Failed to marshal summary: %v\n", err)
@@ -727,6 +801,7 @@ func handleRunSubagent(ctx context.Context, a *Agent, args map[string]interface{
 
 				// Add to parent agent's totals using TrackMetricsFromResponse
 				a.TrackMetricsFromResponse(promptTokens, completionTokens, totalTokens, totalCost, cachedTokens)
+				a.recordSubagentUsage(persona, promptTokens, completionTokens, totalCost, costChildren)
 				a.debugLog("Tracked subagent costs: %d tokens, $%.6f\n", totalTokens, totalCost)
 			}
 		}
@@ -832,7 +907,7 @@ func handleRunSubagent(ctx context.Context, a *Agent, args map[string]interface{
 			"2. Can you complete the remaining work yourself?\n"+
 			"3. Should you ask the user for guidance on how to proceed?\n\n"+
 			"Partial subagent output:\n%s",
-			tokensUsed, tools.GetSubagentMaxTokens(), stdout)
+			tokensUsed, maxTokens, stdout)
 
 		a.debugLog("Subagent exceeded token budget, returning partial output to primary agent\n")
 		return errorMsg, nil
@@ -1058,6 +1133,7 @@ func handleRunParallelSubagents(ctx context.Context, a *Agent, args map[string]i
 	for taskID, result := range resultMap {
 		if stdout, ok := result["stdout"]; ok {
 			summary := extractSubagentSummary(stdout)
+			costChildren := takeSubagentCostTree(summary)
 
 			// Track subagent costs in parent agent's totals
 			if totalTokensStr, ok := summary["subagent_total_tokens"]; ok {
@@ -1077,6 +1153,7 @@ func handleRunParallelSubagents(ctx context.Context, a *Agent, args map[string]i
 
 					// Add to parent agent's totals using TrackMetricsFromResponse
 					a.TrackMetricsFromResponse(promptTokens, completionTokens, totalTokens, totalCost, cachedTokens)
+					a.recordSubagentUsage(taskID, promptTokens, completionTokens, totalCost, costChildren)
 					a.debugLog("Tracked parallel subagent [%s] costs: %d tokens, $%.6f\n", taskID, totalTokens, totalCost)
 				}
 			}
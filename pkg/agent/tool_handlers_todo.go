@@ -52,6 +52,7 @@ func handleTodoWrite(ctx context.Context, a *Agent, args map[string]interface{})
 		if todo.Status == "" {
 			return "", errors.New("each todo requires status")
 		}
+		todo.Source = a.todoSource()
 		todos = append(todos, todo)
 	}
 
@@ -61,6 +62,26 @@ func handleTodoWrite(ctx context.Context, a *Agent, args map[string]interface{})
 	return result, nil
 }
 
+// todoSource describes the task a todo was created for: the latest user
+// request, shortened to one line. TodoWrite keeps the source of todos that
+// were already on the list.
+func (a *Agent) todoSource() string {
+	for i := len(a.messages) - 1; i >= 0; i-- {
+		if a.messages[i].Role != "user" {
+			continue
+		}
+		task := strings.Join(strings.Fields(a.messages[i].Content), " ")
+		if task == "" {
+			continue
+		}
+		if len(task) > 80 {
+			task = task[:80] + "..."
+		}
+		return task
+	}
+	return ""
+}
+
 func handleTodoRead(ctx context.Context, a *Agent, args map[string]interface{}) (string, error) {
 	a.debugLog("TodoRead: returning current todo list\n")
 	todos := tools.TodoRead()
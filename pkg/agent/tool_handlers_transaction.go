@@ -0,0 +1,200 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tools "github.com/alantheprice/ledit/pkg/agent_tools"
+	"github.com/alantheprice/ledit/pkg/events"
+	"github.com/alantheprice/ledit/pkg/filesystem"
+)
+
+// Tool handler implementations for multi-file edit transactions
+
+// transactionDiffMaxLines caps the changed lines shown per file by
+// edit_transaction.
+const transactionDiffMaxLines = 20
+
+// transactionEdits reads the edits argument of edit_transaction.
+func transactionEdits(args map[string]interface{}) ([]tools.TransactionEdit, error) {
+	raw, ok := args["edits"].([]interface{})
+	if !ok || len(raw) == 0 {
+		return nil, errors.New("edits must be a non-empty array")
+	}
+	edits := make([]tools.TransactionEdit, 0, len(raw))
+	for i, item := range raw {
+		fields, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("edit %d must be an object", i+1)
+		}
+		path, err := getFilePath(fields)
+		if err != nil {
+			return nil, fmt.Errorf("edit %d: %w", i+1, err)
+		}
+		edit := tools.TransactionEdit{Path: path}
+		if content, ok := fields["content"].(string); ok {
+			edit.Content = &content
+		} else {
+			edit.OldStr, _ = fields["old_str"].(string)
+			edit.NewStr, _ = fields["new_str"].(string)
+			if edit.OldStr == "" {
+				return nil, fmt.Errorf("edit %d (%s) needs content, or old_str and new_str", i+1, path)
+			}
+		}
+		edits = append(edits, edit)
+	}
+	return edits, nil
+}
+
+// stageTransaction stages the edits and rejects changes that break the
+// schema of known config files or write back redacted secrets, before
+// anything is written.
+func stageTransaction(ctx context.Context, args map[string]interface{}) (*tools.Transaction, error) {
+	edits, err := transactionEdits(args)
+	if err != nil {
+		return nil, err
+	}
+	return stageEdits(ctx, edits)
+}
+
+func stageEdits(ctx context.Context, edits []tools.TransactionEdit) (*tools.Transaction, error) {
+	tx, err := tools.StageTransaction(ctx, edits)
+	if err != nil {
+		return nil, fmt.Errorf("%w; no files were changed", err)
+	}
+	for _, f := range tx.Changed() {
+		if err := checkRedactionPlaceholders("edit_transaction", f.Path, f.Before, f.After); err != nil {
+			return nil, err
+		}
+		if err := checkKnownConfigSchema("edit_transaction", f.Path, f.Before, f.After); err != nil {
+			return nil, err
+		}
+	}
+	return tx, nil
+}
+
+func handleEditTransaction(ctx context.Context, a *Agent, args map[string]interface{}) (string, error) {
+	tx, err := stageTransaction(ctx, args)
+	if err != nil {
+		return "", err
+	}
+	changed := tx.Changed()
+	if len(changed) == 0 {
+		return "The edits leave every file unchanged; nothing was written.", nil
+	}
+	root := filesystem.WorkspaceRootFromContext(ctx)
+	if root == "" {
+		root = a.currentWorkspaceRoot()
+	}
+
+	var gate *ValidationGate
+	gateCtx := ctx
+	if validate, _ := args["validate"].(bool); validate {
+		if gate, err = transactionBuildGate(root); err != nil {
+			return "", fmt.Errorf("%w; no files were changed", err)
+		}
+		if gateCtx, _, err = a.execBackendContext(ctx); err != nil {
+			return "", fmt.Errorf("%w; no files were changed", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("transaction not committed: %w", err)
+	}
+
+	validated := ""
+	if gate != nil {
+		a.PrintLineAsync(fmt.Sprintf("[validate] Building transaction: %s", gate.Build))
+		ran := gate.Run(gateCtx, root)
+		if len(ran) > 0 && !ran[0].Passed {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				return "", fmt.Errorf("build failed and rollback failed: %w", rollbackErr)
+			}
+			return "", fmt.Errorf("build failed, so the transaction was rolled back and no files were changed. `%s` output:\n%s",
+				gate.Build, validationOutputTail(ran[0].Output))
+		}
+		validated = fmt.Sprintf("\n`%s` passed.\n", gate.Build)
+	}
+
+	summary := a.recordTransaction(tx, root)
+	return summary + validated, nil
+}
+
+// ApplyEdits writes edits made outside the conversation, such as an editor
+// plugin's workspace edits, as one transaction. Like edit_transaction, a
+// failing edit changes nothing, and written files publish file_changed
+// events. The change is committed to change tracking as its own revision,
+// described by label.
+func (a *Agent) ApplyEdits(ctx context.Context, label string, edits []tools.TransactionEdit) (string, error) {
+	tx, err := stageEdits(ctx, edits)
+	if err != nil {
+		return "", err
+	}
+	if len(tx.Changed()) == 0 {
+		return "The edits leave every file unchanged; nothing was written.", nil
+	}
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("edits not applied: %w", err)
+	}
+	a.EnableChangeTracking(label)
+	summary := a.recordTransaction(tx, a.currentWorkspaceRoot())
+	if err := a.CommitChanges(summary); err != nil {
+		a.debugLog("Warning: Failed to commit tracked changes: %v\n", err)
+	}
+	return summary, nil
+}
+
+// recordTransaction tracks and announces the files a committed transaction
+// changed, and returns a summary with their diffs.
+func (a *Agent) recordTransaction(tx *tools.Transaction, root string) string {
+	changed := tx.Changed()
+	edits := 0
+	for _, f := range tx.Files() {
+		edits += f.Edits
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Committed %d edit(s) to %d file(s):\n", edits, len(changed))
+	for _, f := range changed {
+		if f.Existed {
+			if trackErr := a.TrackFileEdit(f.Path, f.Before, f.After); trackErr != nil {
+				a.debugLog("Warning: Failed to track file edit: %v\n", trackErr)
+			}
+		} else if trackErr := a.TrackFileWrite(f.Path, f.After); trackErr != nil {
+			a.debugLog("Warning: Failed to track file write: %v\n", trackErr)
+		}
+		a.CheckFileContentSecurity(f.Path, f.After)
+		if a.optimizer != nil {
+			a.optimizer.InvalidateFile(f.Path)
+		}
+		a.noteAgentFileWrite(f.Path)
+		a.publishEvent(events.EventTypeFileChanged, events.FileChangedEvent(f.Path, "edit", f.After))
+
+		display := f.Path
+		if rel, relErr := filepath.Rel(root, f.Path); relErr == nil && !strings.HasPrefix(rel, "..") {
+			display = rel
+		}
+		fmt.Fprintf(&sb, "\n%s:\n%s", display, dryRunDiff(f.Before, f.After, transactionDiffMaxLines))
+	}
+	return sb.String()
+}
+
+// transactionBuildGate returns a gate running only the build command that
+// validates a transaction: the workspace validation gate's build, or go build
+// for Go modules.
+func transactionBuildGate(root string) (*ValidationGate, error) {
+	gate, err := LoadValidationGate(root)
+	if err != nil {
+		return nil, err
+	}
+	if gate != nil && strings.TrimSpace(gate.Build) != "" {
+		return &ValidationGate{Build: gate.Build, Timeout: gate.Timeout}, nil
+	}
+	if _, err := os.Stat(filepath.Join(root, "go.mod")); err == nil {
+		return &ValidationGate{Build: "go build ./..."}, nil
+	}
+	return nil, fmt.Errorf("validate needs a build command in %s", ValidationGateFile)
+}
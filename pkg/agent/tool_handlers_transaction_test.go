@@ -0,0 +1,50 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tools "github.com/alantheprice/ledit/pkg/agent_tools"
+	"github.com/alantheprice/ledit/pkg/events"
+)
+
+func TestApplyEditsPublishesFileChanges(t *testing.T) {
+	agent := makeAgentWithScriptedClient(1, NewScriptedClient())
+	agent.workspaceRoot = t.TempDir()
+	// Change tracking records revisions under the working directory
+	t.Chdir(agent.workspaceRoot)
+	bus := events.NewEventBus()
+	agent.SetEventBus(bus)
+	changes := bus.Subscribe("test")
+	defer bus.Unsubscribe("test")
+
+	path := filepath.Join(agent.workspaceRoot, "main.go")
+	if err := os.WriteFile(path, []byte("package main\n\nfunc old() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	summary, err := agent.ApplyEdits(context.Background(), "rename old", []tools.TransactionEdit{{Path: path, OldStr: "old", NewStr: "renamed"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(summary, "main.go:") || !strings.Contains(summary, "renamed") {
+		t.Fatalf("expected a diff summary, got %q", summary)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "func renamed()") {
+		t.Fatalf("expected the edit to be written, got %q", data)
+	}
+	if agent.GetChangeCount() != 1 || agent.GetRevisionID() == "" {
+		t.Fatalf("expected the edit to be committed as a tracked revision, got %d change(s)", agent.GetChangeCount())
+	}
+	event := <-changes
+	if event.Type != events.EventTypeFileChanged {
+		t.Fatalf("expected a file_changed event, got %q", event.Type)
+	}
+
+	if _, err := agent.ApplyEdits(context.Background(), "rename old", []tools.TransactionEdit{{Path: path, OldStr: "missing", NewStr: "x"}}); err == nil {
+		t.Fatal("expected an edit that does not apply to fail")
+	}
+}
@@ -0,0 +1,116 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	tools "github.com/alantheprice/ledit/pkg/agent_tools"
+	"github.com/alantheprice/ledit/pkg/hooks"
+)
+
+// toolHookOutputLimit caps the hook output added to a tool result or error.
+const toolHookOutputLimit = 2000
+
+// toolHookCalls loads the workspace's hooks file and returns the tool hooks
+// to run for a call. A broken hooks file is reported and runs no hooks.
+func (a *Agent) toolHookCalls(when, toolName string, args map[string]interface{}) ([]hooks.ToolHookCall, string) {
+	if os.Getenv(noHooksEnv) == "1" {
+		return nil, ""
+	}
+	root := a.currentWorkspaceRoot()
+	config, err := hooks.Load(root)
+	if err != nil {
+		a.PrintLineAsync(fmt.Sprintf("[hook] Tool hooks skipped: %v", err))
+		return nil, root
+	}
+	return config.ToolHookCalls(when, toolName, args, root), root
+}
+
+// runBeforeToolHooks runs the before hooks matching a tool call. The first
+// one that fails blocks the call, and its output becomes the tool's error.
+func (a *Agent) runBeforeToolHooks(ctx context.Context, toolName string, args map[string]interface{}) error {
+	calls, root := a.toolHookCalls(hooks.Before, toolName, args)
+	for _, call := range calls {
+		output, err := runToolHook(ctx, call, root, toolName, args, "")
+		if err == nil {
+			continue
+		}
+		a.debugLog("[hook] %s blocked %s: %v\n", call.Hook.Label(), toolName, err)
+		message := fmt.Sprintf("blocked by hook %q (%v)", call.Hook.Label(), err)
+		if output != "" {
+			message += ": " + output
+		}
+		return errors.New(message)
+	}
+	return nil
+}
+
+// runAfterToolHooks runs the after hooks matching a successful tool call and
+// returns the text to add to the tool result: the output of hooks with
+// feedback on. Failing hooks are also reported to the user.
+func (a *Agent) runAfterToolHooks(ctx context.Context, toolName string, args map[string]interface{}, result string) string {
+	calls, root := a.toolHookCalls(hooks.After, toolName, args)
+	var feedback strings.Builder
+	for _, call := range calls {
+		output, err := runToolHook(ctx, call, root, toolName, args, result)
+		if err != nil {
+			a.PrintLine(fmt.Sprintf("[hook] %s failed after %s (%v)", call.Hook.Label(), toolName, err))
+		}
+		if !call.Hook.Feedback || (output == "" && err == nil) {
+			continue
+		}
+		feedback.WriteString(fmt.Sprintf("\n\n[hook %s", call.Hook.Label()))
+		if err != nil {
+			feedback.WriteString(fmt.Sprintf(" failed: %v", err))
+		}
+		feedback.WriteString("]")
+		if output != "" {
+			feedback.WriteString("\n" + output)
+		}
+	}
+	return feedback.String()
+}
+
+// runToolHook runs a hook's command in the workspace root and returns its
+// combined output, trimmed and capped. The call is described in LEDIT_TOOL,
+// LEDIT_TOOL_TARGET and LEDIT_WORKSPACE, and as JSON on stdin with the
+// tool's arguments and, after the call, its result.
+func runToolHook(ctx context.Context, call hooks.ToolHookCall, root, toolName string, args map[string]interface{}, result string) (string, error) {
+	hook := call.Hook
+	ctx, cancel := context.WithTimeout(ctx, hook.TimeoutDuration())
+	defer cancel()
+
+	input, err := json.Marshal(map[string]interface{}{
+		"tool":   toolName,
+		"when":   hook.When,
+		"target": call.Target,
+		"args":   args,
+		"result": result,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	cmd := tools.LocalShell().Command(ctx, hook.Run)
+	cmd.Dir = root
+	cmd.Env = append(os.Environ(), "LEDIT_TOOL="+toolName, "LEDIT_TOOL_TARGET="+call.Target, "LEDIT_WORKSPACE="+root)
+	cmd.Stdin = bytes.NewReader(input)
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	err = cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("timed out after %s", hook.TimeoutDuration())
+	}
+
+	text := strings.TrimSpace(output.String())
+	if len(text) > toolHookOutputLimit {
+		text = text[:toolHookOutputLimit] + "\n... (output truncated)"
+	}
+	return text, err
+}
@@ -0,0 +1,89 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	api "github.com/alantheprice/ledit/pkg/agent_api"
+	"github.com/alantheprice/ledit/pkg/hooks"
+)
+
+func writeHooksFile(t *testing.T, root, content string) {
+	t.Helper()
+	path := filepath.Join(root, hooks.FileName)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestToolHooksBlockAndFeedBack(t *testing.T) {
+	t.Setenv(noHooksEnv, "")
+	agent := makeAgentWithScriptedClient(1, NewScriptedClient())
+	agent.workspaceRoot = t.TempDir()
+	writeHooksFile(t, agent.workspaceRoot, `
+tools:
+  - name: no-generated
+    when: before
+    match: "deploy"
+    run: 'echo "refusing $LEDIT_TOOL"; exit 3'
+  - name: lint
+    when: after
+    match: "lint"
+    run: 'cat; echo; echo "checked in $(basename "$LEDIT_WORKSPACE")"'
+    feedback: true
+  - name: quiet
+    when: after
+    match: "lint"
+    run: echo not for the model
+`)
+
+	deployed := false
+	deploy := func(ctx context.Context, a *Agent, args map[string]interface{}) (string, error) {
+		deployed = true
+		return "deployed", nil
+	}
+	lint := func(ctx context.Context, a *Agent, args map[string]interface{}) (string, error) {
+		return "done", nil
+	}
+	if err := agent.RegisterCustomTool(customToolDefinition("deploy"), deploy); err != nil {
+		t.Fatal(err)
+	}
+	if err := agent.RegisterCustomTool(customToolDefinition("lint"), lint); err != nil {
+		t.Fatal(err)
+	}
+
+	results := NewToolExecutor(agent).ExecuteTools([]api.ToolCall{
+		policyToolCall("call-1", "deploy", `{}`),
+		policyToolCall("call-2", "lint", `{"path":"a.go"}`),
+	})
+	if len(results) != 2 {
+		t.Fatalf("expected two tool results, got %d", len(results))
+	}
+	if !strings.Contains(results[0].Content, `blocked by hook "no-generated"`) || !strings.Contains(results[0].Content, "refusing deploy") || deployed {
+		t.Fatalf("expected the before hook to block the call, got: %s", results[0].Content)
+	}
+	linted := results[1].Content
+	if !strings.HasPrefix(linted, "done\n\n[hook lint]\n") || !strings.Contains(linted, `"result":"done"`) || !strings.Contains(linted, "checked in "+filepath.Base(agent.workspaceRoot)) {
+		t.Fatalf("expected the after hook's output in the result, got: %s", linted)
+	}
+	if strings.Contains(linted, "not for the model") {
+		t.Fatalf("expected hooks without feedback to stay out of the result, got: %s", linted)
+	}
+}
+
+func TestRunToolHookTimesOut(t *testing.T) {
+	config, err := hooks.Parse([]byte("tools:\n  - when: after\n    run: sleep 5\n    timeout: 50ms\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	call := hooks.ToolHookCall{Hook: config.ToolHooks[0]}
+	if _, err := runToolHook(context.Background(), call, t.TempDir(), "read_file", nil, ""); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("expected the hook to time out, got %v", err)
+	}
+}
@@ -0,0 +1,123 @@
+// Tool output pagination: results too large for the context window keep their
+// full text here, and the truncated result carries a continuation token that
+// the fetch_more tool pages through.
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+const (
+	defaultFetchMoreChars = 12000    // characters returned per fetch_more call
+	maxFetchMoreChars     = 50000    // largest max_chars fetch_more accepts
+	toolOutputStoreBytes  = 32 << 20 // total stored output; oldest is dropped first
+)
+
+// toolOutputStore holds the full text of truncated tool results by ID.
+type toolOutputStore struct {
+	mu      sync.Mutex
+	outputs map[string]string
+	order   []string // oldest first, for eviction
+	bytes   int
+	nextID  int
+}
+
+func (a *Agent) toolOutputStoreState() *toolOutputStore {
+	a.toolOutputsOnce.Do(func() {
+		a.toolOutputs = &toolOutputStore{outputs: make(map[string]string)}
+	})
+	return a.toolOutputs
+}
+
+// storeToolOutput keeps output for fetch_more and returns the continuation
+// token for reading it from offset, or "" when nothing is left to read.
+func (a *Agent) storeToolOutput(output string, offset int) string {
+	if offset >= len(output) {
+		return ""
+	}
+	s := a.toolOutputStoreState()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	id := fmt.Sprintf("out%d", s.nextID)
+	s.outputs[id] = output
+	s.order = append(s.order, id)
+	s.bytes += len(output)
+	for s.bytes > toolOutputStoreBytes && len(s.order) > 1 {
+		oldest := s.order[0]
+		s.order = s.order[1:]
+		s.bytes -= len(s.outputs[oldest])
+		delete(s.outputs, oldest)
+	}
+	return continuationToken(id, offset)
+}
+
+func continuationToken(id string, offset int) string {
+	return id + "@" + strconv.Itoa(offset)
+}
+
+// continuationHint tells the model how to read on from token.
+func continuationHint(token string) string {
+	return fmt.Sprintf("Call fetch_more with continuation_token %q to read the omitted output.", token)
+}
+
+// fetchMore returns up to maxChars of a stored output starting at the
+// token's offset, ending at a line break when one is near the end of the
+// chunk, followed by the token for the next chunk.
+func (a *Agent) fetchMore(token string, maxChars int) (string, error) {
+	id, rawOffset, ok := strings.Cut(strings.TrimSpace(token), "@")
+	offset, err := strconv.Atoi(rawOffset)
+	if !ok || err != nil || offset < 0 {
+		return "", fmt.Errorf("invalid continuation token %q", token)
+	}
+	s := a.toolOutputStoreState()
+	s.mu.Lock()
+	output, found := s.outputs[id]
+	s.mu.Unlock()
+	if !found {
+		return "", fmt.Errorf("continuation token %q has expired; run the original tool call again", token)
+	}
+	if offset >= len(output) {
+		return fmt.Sprintf("[End of output: %d characters]", len(output)), nil
+	}
+
+	end := min(offset+maxChars, len(output))
+	if end < len(output) {
+		if newline := strings.LastIndexByte(output[offset:end], '\n'); newline > maxChars/2 {
+			end = offset + newline + 1
+		}
+		for end > offset && !utf8.RuneStart(output[end]) {
+			end--
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "[Characters %d-%d of %d]\n", offset, end, len(output))
+	b.WriteString(output[offset:end])
+	if !strings.HasSuffix(output[offset:end], "\n") {
+		b.WriteString("\n")
+	}
+	if end < len(output) {
+		fmt.Fprintf(&b, "[%d characters remain. Call fetch_more with continuation_token %q for the next chunk.]",
+			len(output)-end, continuationToken(id, end))
+	} else {
+		b.WriteString("[End of output]")
+	}
+	return b.String(), nil
+}
+
+func handleFetchMore(ctx context.Context, a *Agent, args map[string]interface{}) (string, error) {
+	token, _ := args["continuation_token"].(string)
+	maxChars := defaultFetchMoreChars
+	if v, ok := args["max_chars"]; ok {
+		if normalized := normalizePositiveInt(v); normalized > 0 {
+			maxChars = min(normalized, maxFetchMoreChars)
+		}
+	}
+	return a.fetchMore(token, maxChars)
+}
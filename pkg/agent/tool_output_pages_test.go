@@ -0,0 +1,87 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var nextTokenRegex = regexp.MustCompile(`continuation_token "([^"]+)" for the next chunk`)
+
+func TestFetchMorePagesThroughTruncatedOutput(t *testing.T) {
+	a := &Agent{}
+	var lines []string
+	for i := 0; i < 2000; i++ {
+		lines = append(lines, fmt.Sprintf("line %04d", i))
+	}
+	output := strings.Join(lines, "\n")
+
+	token := a.storeToolOutput(output, 100)
+	if token == "" {
+		t.Fatal("expected a continuation token")
+	}
+	var read strings.Builder
+	read.WriteString(output[:100])
+	for chunks := 0; ; chunks++ {
+		if chunks > 10 {
+			t.Fatal("fetch_more did not reach the end of the output")
+		}
+		result, err := handleFetchMore(context.Background(), a, map[string]interface{}{"continuation_token": token, "max_chars": 5000})
+		if err != nil {
+			t.Fatalf("fetch_more: %v", err)
+		}
+		header, rest, _ := strings.Cut(result, "\n")
+		if !strings.HasPrefix(header, "[Characters ") {
+			t.Fatalf("unexpected chunk header %q", header)
+		}
+		match := nextTokenRegex.FindStringSubmatch(rest)
+		if match == nil {
+			if !strings.HasSuffix(rest, "[End of output]") {
+				t.Fatalf("expected the last chunk to say so, got %q", rest[max(0, len(rest)-80):])
+			}
+			read.WriteString(strings.TrimSuffix(rest, "\n[End of output]"))
+			break
+		}
+		chunk := rest[:strings.LastIndex(rest, "\n[")+1]
+		if !strings.HasSuffix(chunk, "\n") || len(chunk) > 5000 {
+			t.Fatalf("expected chunks to end at a line break within max_chars, got %d characters", len(chunk))
+		}
+		read.WriteString(chunk)
+		token = match[1]
+	}
+	if read.String() != output {
+		t.Fatal("expected the chunks to reassemble the full output")
+	}
+
+	if _, err := a.fetchMore("out99@0", 100); err == nil || !strings.Contains(err.Error(), "expired") {
+		t.Fatalf("expected an unknown output to be reported as expired, got %v", err)
+	}
+	if _, err := a.fetchMore("garbage", 100); err == nil {
+		t.Fatal("expected an invalid token to fail")
+	}
+}
+
+func TestTruncationNoticesIncludeContinuationToken(t *testing.T) {
+	notice := buildTruncationNotice(800, 1700, 500, 40, ".ledit/shell_outputs/out.txt", nil, "out1@4096")
+	if !strings.Contains(notice, `continuation_token "out1@4096"`) {
+		t.Fatalf("expected the shell notice to carry the token, got %q", notice)
+	}
+	if match := fullOutputPathRegex.FindStringSubmatch(notice); match == nil || match[1] != ".ledit/shell_outputs/out.txt" {
+		t.Fatalf("expected the saved path to stay extractable, got %v", match)
+	}
+
+	t.Setenv("LEDIT_FETCH_URL_MAX_CHARS", "100")
+	t.Setenv("LEDIT_FETCH_URL_ARCHIVE_DIR", t.TempDir())
+	a := &Agent{}
+	input := strings.Repeat("x", 220)
+	got := a.constrainToolResultForModel("fetch_url", map[string]interface{}{"url": "https://example.com"}, input)
+	if !strings.Contains(got, `Call fetch_more with continuation_token "out1@70"`) {
+		t.Fatalf("expected the fetch_url notice to carry the token, got %q", got)
+	}
+	more, err := a.fetchMore("out1@70", 1000)
+	if err != nil || !strings.Contains(more, strings.Repeat("x", 150)) || !strings.HasSuffix(more, "[End of output]") {
+		t.Fatalf("expected the omitted part and the tail, got %q, %v", more, err)
+	}
+}
@@ -0,0 +1,126 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	tools "github.com/alantheprice/ledit/pkg/agent_tools"
+	"github.com/alantheprice/ledit/pkg/sandbox"
+	"github.com/alantheprice/ledit/pkg/toolpolicy"
+)
+
+type toolPolicyContextKey struct{}
+
+// withPolicyAction records the policy outcome for the security checks that
+// run later in ExecuteTool.
+func withPolicyAction(ctx context.Context, action toolpolicy.Action) context.Context {
+	return context.WithValue(ctx, toolPolicyContextKey{}, action)
+}
+
+// policySkipsApproval reports whether a policy rule already cleared a call
+// the built-in security checks flagged: the user approved a matching ask
+// rule, or an allow rule matched a call that is cautionary but not dangerous.
+func policySkipsApproval(ctx context.Context, secResult tools.SecurityResult) bool {
+	if ctx == nil {
+		return false
+	}
+	switch action, _ := ctx.Value(toolPolicyContextKey{}).(toolpolicy.Action); action {
+	case toolpolicy.Ask:
+		return true
+	case toolpolicy.Allow:
+		return !secResult.ShouldBlock
+	}
+	return false
+}
+
+// ToolPolicy returns the workspace's tool policy, re-reading
+// .ledit/policy.yaml whenever it changes. It returns nil when there is no
+// policy file.
+func (a *Agent) ToolPolicy() (*toolpolicy.Policy, error) {
+	root := a.currentWorkspaceRoot()
+	info, err := os.Stat(filepath.Join(root, toolpolicy.FileName))
+
+	a.toolPolicyMu.Lock()
+	defer a.toolPolicyMu.Unlock()
+	if errors.Is(err, os.ErrNotExist) {
+		a.toolPolicy, a.toolPolicyErr, a.toolPolicyModTime = nil, nil, time.Time{}
+		return nil, nil
+	}
+	if err == nil && !a.toolPolicyModTime.IsZero() && info.ModTime().Equal(a.toolPolicyModTime) {
+		return a.toolPolicy, a.toolPolicyErr
+	}
+
+	a.toolPolicy, a.toolPolicyErr = toolpolicy.Load(root)
+	if err == nil {
+		a.toolPolicyModTime = info.ModTime()
+	}
+	return a.toolPolicy, a.toolPolicyErr
+}
+
+// authorizeToolCall applies the tool policy before any tool runs. Deny rules
+// apply even in unsafe mode; ask rules go through the same approval prompt as
+// the built-in security checks. The returned context carries the outcome so
+// ExecuteTool does not prompt twice.
+func (a *Agent) authorizeToolCall(ctx context.Context, toolName string, args map[string]interface{}) (context.Context, error) {
+	policy, err := a.ToolPolicy()
+	if err != nil {
+		// Fail closed: a broken policy must not silently allow everything.
+		return ctx, fmt.Errorf("policy error: %v (fix or remove %s)", err, toolpolicy.FileName)
+	}
+
+	decision := policy.Evaluate(toolName, args, a.currentWorkspaceRoot())
+	if !decision.Matched() {
+		return ctx, nil
+	}
+	a.debugLog("[policy] %s matched rule %d %q -> %s\n", toolName, decision.Rule.Position, decision.Rule.String(), decision.Action)
+
+	switch decision.Action {
+	case toolpolicy.Deny:
+		return ctx, fmt.Errorf("policy denied: %s blocked by rule %q in %s", toolName, decision.Rule.String(), toolpolicy.FileName)
+	case toolpolicy.Ask:
+		secResult := tools.SecurityResult{
+			Risk:         tools.SecurityCaution,
+			Reasoning:    fmt.Sprintf("matches policy rule %q in %s", decision.Rule.String(), toolpolicy.FileName),
+			ShouldBlock:  true,
+			ShouldPrompt: true,
+		}
+		if err := approveToolCall(a, toolName, args, secResult); err != nil {
+			return ctx, err
+		}
+	}
+	return withPolicyAction(ctx, decision.Action), nil
+}
+
+// shellSandboxContext applies the policy's sandbox section to a shell
+// command. When it covers the command, the returned context runs it in the
+// sandbox. A required sandbox that cannot be set up refuses the command
+// instead of running it unsandboxed.
+func (a *Agent) shellSandboxContext(ctx context.Context, command string) (context.Context, *sandbox.Executor, error) {
+	policy, err := a.ToolPolicy()
+	if err != nil {
+		return ctx, nil, fmt.Errorf("policy error: %v (fix or remove %s)", err, toolpolicy.FileName)
+	}
+	if policy == nil || !policy.Sandbox.Applies(command) {
+		return ctx, nil, nil
+	}
+	executor, err := sandbox.New(SandboxConfig(policy.Sandbox))
+	if err != nil {
+		return ctx, nil, fmt.Errorf("%s requires a sandbox for this command: %w", toolpolicy.FileName, err)
+	}
+	a.debugLog("[sandbox] running in %s: %s\n", executor.Describe(), command)
+	return tools.WithShellSandbox(ctx, executor), executor, nil
+}
+
+// SandboxConfig converts a policy's sandbox section to an executor config.
+func SandboxConfig(s *toolpolicy.Sandbox) sandbox.Config {
+	return sandbox.Config{
+		Backend: sandbox.Backend(s.Backend),
+		Image:   s.Image,
+		Network: s.Network,
+		Mounts:  s.Mounts,
+	}
+}
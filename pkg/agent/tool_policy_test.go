@@ -0,0 +1,122 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	api "github.com/alantheprice/ledit/pkg/agent_api"
+	tools "github.com/alantheprice/ledit/pkg/agent_tools"
+	"github.com/alantheprice/ledit/pkg/toolpolicy"
+)
+
+func policyToolCall(id, name, arguments string) api.ToolCall {
+	var tc api.ToolCall
+	tc.ID = id
+	tc.Type = "function"
+	tc.Function.Name = name
+	tc.Function.Arguments = arguments
+	return tc
+}
+
+func writeToolPolicy(t *testing.T, root, content string) {
+	t.Helper()
+	path := filepath.Join(root, toolpolicy.FileName)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestToolPolicyInterceptsCalls(t *testing.T) {
+	agent := makeAgentWithScriptedClient(1, NewScriptedClient())
+	agent.workspaceRoot = t.TempDir()
+	writeToolPolicy(t, agent.workspaceRoot, "rules:\n  - deny shell: touch*\n  - deny deploy\n")
+
+	deployed := false
+	handler := func(ctx context.Context, a *Agent, args map[string]interface{}) (string, error) {
+		deployed = true
+		return "deployed", nil
+	}
+	if err := agent.RegisterCustomTool(customToolDefinition("deploy"), handler); err != nil {
+		t.Fatal(err)
+	}
+
+	marker := filepath.Join(agent.workspaceRoot, "marker")
+	results := NewToolExecutor(agent).ExecuteTools([]api.ToolCall{
+		policyToolCall("call-1", "shell_command", `{"command":"echo hi && touch `+marker+`"}`),
+		policyToolCall("call-2", "deploy", `{}`),
+	})
+	if len(results) != 2 {
+		t.Fatalf("expected two tool results, got %d", len(results))
+	}
+	for _, result := range results {
+		if !strings.Contains(result.Content, "policy denied") {
+			t.Fatalf("expected the call to be denied by policy, got: %s", result.Content)
+		}
+	}
+	if _, err := os.Stat(marker); !os.IsNotExist(err) {
+		t.Fatal("expected the denied shell command not to run")
+	}
+	if deployed {
+		t.Fatal("expected the denied custom tool not to run")
+	}
+}
+
+func TestToolPolicyReloadsAndFailsClosed(t *testing.T) {
+	agent := makeAgentWithScriptedClient(1, NewScriptedClient())
+	agent.workspaceRoot = t.TempDir()
+
+	if policy, err := agent.ToolPolicy(); policy != nil || err != nil {
+		t.Fatalf("expected no policy, got %v, %v", policy, err)
+	}
+	if _, err := agent.authorizeToolCall(context.Background(), "read_file", map[string]interface{}{"path": "a.txt"}); err != nil {
+		t.Fatalf("expected calls to be allowed without a policy, got %v", err)
+	}
+
+	writeToolPolicy(t, agent.workspaceRoot, "rules:\n  - deny everything\n  - oops\n")
+	if _, err := agent.authorizeToolCall(context.Background(), "read_file", map[string]interface{}{"path": "a.txt"}); err == nil || !strings.Contains(err.Error(), "policy error") {
+		t.Fatalf("expected an invalid policy to refuse calls, got %v", err)
+	}
+
+	writeToolPolicy(t, agent.workspaceRoot, "rules:\n  - \"allow read: **\"\n  - ask write: outside ./src\n")
+	ctx, err := agent.authorizeToolCall(context.Background(), "read_file", map[string]interface{}{"path": "a.txt"})
+	if err != nil {
+		t.Fatalf("expected the fixed policy to be reloaded, got %v", err)
+	}
+	if !policySkipsApproval(ctx, tools.SecurityResult{Risk: tools.SecurityCaution, ShouldPrompt: true}) {
+		t.Fatal("expected an allow rule to skip caution prompts")
+	}
+
+	t.Setenv("LEDIT_SUBAGENT", "1")
+	if _, err := agent.authorizeToolCall(context.Background(), "write_file", map[string]interface{}{"path": "docs/a.md"}); err == nil || !strings.Contains(err.Error(), "policy rule") {
+		t.Fatalf("expected an ask rule to be refused without a way to prompt, got %v", err)
+	}
+}
+
+func TestToolPolicySandboxFailsClosed(t *testing.T) {
+	agent := makeAgentWithScriptedClient(1, NewScriptedClient())
+	agent.workspaceRoot = t.TempDir()
+	writeToolPolicy(t, agent.workspaceRoot, "sandbox:\n  backend: docker\n  commands: [\"touch*\"]\n")
+	t.Setenv("PATH", t.TempDir())
+
+	marker := filepath.Join(agent.workspaceRoot, "marker")
+	results := NewToolExecutor(agent).ExecuteTools([]api.ToolCall{
+		policyToolCall("call-1", "shell_command", `{"command":"touch `+marker+`"}`),
+	})
+	if len(results) != 1 || !strings.Contains(results[0].Content, "requires a sandbox") {
+		t.Fatalf("expected the command to be refused without a sandbox, got %+v", results)
+	}
+	if _, err := os.Stat(marker); !os.IsNotExist(err) {
+		t.Fatal("expected the command not to run unsandboxed")
+	}
+
+	ctx, executor, err := agent.shellSandboxContext(context.Background(), "echo hi")
+	if err != nil || executor != nil || tools.ShellSandboxFromContext(ctx) != nil {
+		t.Fatalf("commands outside the sandbox patterns should run normally, got %v, %v", executor, err)
+	}
+}
@@ -14,7 +14,9 @@ import (
 	tools "github.com/alantheprice/ledit/pkg/agent_tools"
 )
 
-func constrainToolResultForModel(toolName string, args map[string]interface{}, result string) string {
+// constrainToolResultForModel shrinks a tool result before it is sent to the
+// model. Truncated fetch_url output can be paged through with fetch_more.
+func (a *Agent) constrainToolResultForModel(toolName string, args map[string]interface{}, result string) string {
 	if toolName == "analyze_image_content" {
 		return compactAnalyzeImageResultForModel(result)
 	}
@@ -47,18 +49,22 @@ func constrainToolResultForModel(toolName string, args map[string]interface{}, r
 	}
 
 	archivePath, archiveErr := saveFetchURLOutputToFile(args, result)
-	notice := buildFetchURLTruncationNotice(omitted, archivePath, archiveErr)
+	notice := buildFetchURLTruncationNotice(omitted, archivePath, archiveErr, a.storeToolOutput(result, headLen))
 	return result[:headLen] + notice + result[len(result)-tailLen:]
 }
 
-func buildFetchURLTruncationNotice(omitted int, archivePath string, archiveErr error) string {
+func buildFetchURLTruncationNotice(omitted int, archivePath string, archiveErr error, continuation string) string {
+	notice := fmt.Sprintf("FETCH_URL OUTPUT TRUNCATED FOR MODEL CONTEXT: omitted %d characters. Set LEDIT_FETCH_URL_MAX_CHARS to adjust.", omitted)
+	if continuation != "" {
+		notice += " " + continuationHint(continuation)
+	}
 	if archivePath == "" {
 		if archiveErr != nil {
-			return fmt.Sprintf("\n\n[FETCH_URL OUTPUT TRUNCATED FOR MODEL CONTEXT: omitted %d characters. Set LEDIT_FETCH_URL_MAX_CHARS to adjust. Failed to save full output: %v]\n\n", omitted, archiveErr)
+			return fmt.Sprintf("\n\n[%s Failed to save full output: %v]\n\n", notice, archiveErr)
 		}
-		return fmt.Sprintf("\n\n[FETCH_URL OUTPUT TRUNCATED FOR MODEL CONTEXT: omitted %d characters. Set LEDIT_FETCH_URL_MAX_CHARS to adjust. Full output path unavailable.]\n\n", omitted)
+		return fmt.Sprintf("\n\n[%s Full output path unavailable.]\n\n", notice)
 	}
-	return fmt.Sprintf("\n\n[FETCH_URL OUTPUT TRUNCATED FOR MODEL CONTEXT: omitted %d characters. Set LEDIT_FETCH_URL_MAX_CHARS to adjust. Full output saved to %s]\n\n", omitted, archivePath)
+	return fmt.Sprintf("\n\n[%s Full output saved to %s]\n\n", notice, archivePath)
 }
 
 func saveFetchURLOutputToFile(args map[string]interface{}, output string) (string, error) {
@@ -62,8 +62,13 @@ func (a *Agent) executeTool(toolCall api.ToolCall) (string, error) {
 		return "", fmt.Errorf("unknown tool '%s'. Valid tools are: %s", toolName, strings.Join(validTools, ", "))
 	}
 
+	ctx, err := a.authorizeToolCall(context.Background(), toolName, args)
+	if err != nil {
+		return "", err
+	}
+
 	// Use the tool registry for data-driven tool execution
-	_, result, err := registry.ExecuteTool(context.Background(), toolName, args, a)
+	_, result, err := registry.ExecuteTool(ctx, toolName, args, a)
 
 	// If tool not found in registry, check for special cases
 	if err != nil && strings.Contains(err.Error(), "unknown tool") {
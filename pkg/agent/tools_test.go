@@ -8,6 +8,7 @@ import (
 
 	api "github.com/alantheprice/ledit/pkg/agent_api"
 	"github.com/alantheprice/ledit/pkg/configuration"
+	"github.com/alantheprice/ledit/pkg/filesystem"
 )
 
 // TestGetOptimizedToolDefinitions verifies that the agent gets both standard and MCP tools
@@ -291,6 +292,9 @@ func TestExecuteToolRoutesJSONWritesAndEditsThroughStructuredValidation(t *testi
 	if err := os.WriteFile(jsonPath, []byte(`{"x":1}`), 0644); err != nil {
 		t.Fatalf("failed to seed json file: %v", err)
 	}
+	// The file was rewritten outside the agent; read it first as the model
+	// would, or the edit is refused as a conflict
+	filesystem.Locks().ObserveFile(jsonPath)
 
 	editCall := api.ToolCall{ID: "call_guard_edit", Type: "function"}
 	editCall.Function.Name = "edit_file"
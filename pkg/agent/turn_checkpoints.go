@@ -210,30 +210,11 @@ func (a *Agent) TriggerCompaction() bool {
 		return false
 	}
 
-	// Try checkpoint compaction first (lighter weight)
-	if a.HasTurnCheckpoints() {
-		checkpointed, remaining := a.BuildCheckpointCompactedMessages(a.messages)
-		if len(checkpointed) < len(a.messages) {
-			a.messages = checkpointed
-			a.ReplaceTurnCheckpoints(remaining)
-			if a.debug {
-				a.debugLog("[~] Context limit exceeded - applied checkpoint compaction\n")
-			}
-			return true
-		}
-	}
-
-	// Try structural compaction (LLM-based)
-	if a.optimizer != nil && a.optimizer.IsEnabled() {
-		llmCompacted := a.optimizer.CompactConversation(a.messages)
-		if len(llmCompacted) < len(a.messages) {
-			a.messages = llmCompacted
-			a.clearTurnCheckpoints()
-			if a.debug {
-				a.debugLog("[~] Context limit exceeded - applied LLM structural compaction\n")
-			}
-			return true
+	if result, ok := a.CompactHistory(); ok {
+		if a.debug {
+			a.debugLog("[~] Context limit exceeded - applied %s compaction\n", result.Method)
 		}
+		return true
 	}
 
 	// Last resort: emergency truncation
@@ -54,14 +54,6 @@ type AgentState struct {
 	CachedCostSavings       float64 `json:"cached_cost_savings"`
 }
 
-// DiffChange represents a change region in the diff
-type DiffChange struct {
-	OldStart  int
-	OldLength int
-	NewStart  int
-	NewLength int
-}
-
 // CircuitBreakerAction tracks repetitive actions for circuit breaker logic
 type CircuitBreakerAction struct {
 	ActionType string // "edit_file", "shell_command", etc.
@@ -37,6 +37,10 @@ func (a *Agent) getModelContextLimit() int {
 		if a.debug {
 			a.debugLog("[WARN] Failed to get model context limit: %v, using default\n", err)
 		}
+		// Prefer the capability registry's window over the blind default
+		if window := a.GetModelCapabilities().ContextWindow; window > 0 {
+			return window
+		}
 		return 32000
 	}
 	return limit
@@ -137,13 +141,12 @@ func (a *Agent) printLineInternalLocked(text string, manageLock bool) {
 
 // estimateContextTokens estimates the token count for messages
 func (a *Agent) estimateContextTokens(messages []api.Message) int {
-	totalChars := 0
+	total := 0
 	for _, msg := range messages {
-		totalChars += len(msg.Content)
-		totalChars += len(msg.ReasoningContent)
+		total += EstimateTokens(msg.Content)
+		total += EstimateTokens(msg.ReasoningContent)
 	}
-	// Rough estimate: 4 chars per token (conservative)
-	return totalChars / 4
+	return total
 }
 
 // formatTokenCount formats token count with thousands/millions separators
@@ -0,0 +1,326 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	api "github.com/alantheprice/ledit/pkg/agent_api"
+	tools "github.com/alantheprice/ledit/pkg/agent_tools"
+	"gopkg.in/yaml.v3"
+)
+
+// ValidationGateFile is the validation gate's config path relative to the
+// workspace root.
+var ValidationGateFile = filepath.Join(".ledit", "validation.yaml")
+
+const (
+	defaultValidationGateTimeout = 5 * time.Minute
+	validationGateOutputLimit    = 3000
+)
+
+// Placeholders the test command can use to run only the tests affected by
+// the files modified since the last run.
+const (
+	affectedPackagesPlaceholder = "{packages}" // affected Go packages, e.g. ./pkg/a ./pkg/b
+	affectedTestsPlaceholder    = "{tests}"    // affected test files
+)
+
+// ValidationGate is a workspace's .ledit/validation.yaml: commands run
+// automatically once the agent has modified Every files, with failures fed
+// back to the model.
+type ValidationGate struct {
+	// Every is how many file modifications trigger a run; 0 means every one.
+	Every int    `yaml:"every"`
+	Build string `yaml:"build"`
+	Lint  string `yaml:"lint"`
+	// Test may use {packages} or {tests} to run only the affected tests.
+	Test string `yaml:"test"`
+	// Timeout bounds each command; 0 means five minutes.
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// ValidationStep is one gate command and its outcome.
+type ValidationStep struct {
+	Name    string
+	Command string
+	Passed  bool
+	Output  string
+}
+
+// LoadValidationGate reads root's validation gate config. It returns nil
+// when the file does not exist.
+func LoadValidationGate(root string) (*ValidationGate, error) {
+	path := filepath.Join(root, ValidationGateFile)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var gate ValidationGate
+	if err := yaml.Unmarshal(data, &gate); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if gate.Every < 0 {
+		return nil, fmt.Errorf("invalid %s: every must not be negative", path)
+	}
+	if len(gate.Steps()) == 0 {
+		return nil, fmt.Errorf("invalid %s: set at least one of build, lint or test", path)
+	}
+	return &gate, nil
+}
+
+// Threshold is the number of file modifications that triggers a run.
+func (g *ValidationGate) Threshold() int {
+	if g.Every < 1 {
+		return 1
+	}
+	return g.Every
+}
+
+// Steps returns the configured commands in the order they run.
+func (g *ValidationGate) Steps() []ValidationStep {
+	var steps []ValidationStep
+	for _, step := range []ValidationStep{{Name: "build", Command: g.Build}, {Name: "lint", Command: g.Lint}, {Name: "test", Command: g.Test}} {
+		if strings.TrimSpace(step.Command) != "" {
+			steps = append(steps, step)
+		}
+	}
+	return steps
+}
+
+// Run runs the steps in dir, on the context's execution backend if it has
+// one, and stops at the first failure, since later steps rarely pass once the
+// build is broken. It returns the steps that ran.
+func (g *ValidationGate) Run(ctx context.Context, dir string) []ValidationStep {
+	timeout := g.Timeout
+	if timeout <= 0 {
+		timeout = defaultValidationGateTimeout
+	}
+	var ran []ValidationStep
+	for _, step := range g.Steps() {
+		stepCtx, cancel := context.WithTimeout(ctx, timeout)
+		var cmd *exec.Cmd
+		var finish func(string) (string, error)
+		if backend := tools.ExecBackendFromContext(stepCtx); backend != nil {
+			var err error
+			if cmd, finish, err = backend.Command(stepCtx, dir, step.Command); err != nil {
+				cancel()
+				step.Output = fmt.Sprintf("failed to run on %s: %v", backend.Describe(), err)
+				ran = append(ran, step)
+				break
+			}
+		} else {
+			cmd = tools.LocalShell().Command(stepCtx, step.Command)
+			cmd.Dir = dir
+		}
+		var output bytes.Buffer
+		cmd.Stdout = &output
+		cmd.Stderr = &output
+		err := cmd.Run()
+		if stepCtx.Err() == context.DeadlineExceeded {
+			output.WriteString(fmt.Sprintf("\n(timed out after %s)", timeout))
+		}
+
+		step.Passed = err == nil
+		step.Output = output.String()
+		if finish != nil {
+			var syncErr error
+			if step.Output, syncErr = finish(step.Output); syncErr != nil {
+				step.Output += fmt.Sprintf("\n[remote] Warning: %v", syncErr)
+			}
+		}
+		cancel()
+		ran = append(ran, step)
+		if !step.Passed {
+			break
+		}
+	}
+	return ran
+}
+
+// ScopeTests returns a copy of the gate whose test command has its
+// placeholders replaced with the packages and test files affected by files,
+// according to the workspace's dependency graph. When the command uses
+// placeholders and nothing affected has tests, the test step is dropped.
+func (g *ValidationGate) ScopeTests(ctx context.Context, root string, files []string) (*ValidationGate, error) {
+	scoped := *g
+	if !strings.Contains(g.Test, affectedPackagesPlaceholder) && !strings.Contains(g.Test, affectedTestsPlaceholder) {
+		return &scoped, nil
+	}
+	graph, err := BuildDependencyGraph(ctx, root)
+	if err != nil {
+		return nil, err
+	}
+	packages := map[string]bool{}
+	tests := map[string]bool{}
+	for _, file := range files {
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(graph.root, file)
+		}
+		if resolved, err := filepath.EvalSymlinks(file); err == nil {
+			file = resolved
+		}
+		impact := graph.Impact(file)
+		for _, pkg := range impact.GoPackages {
+			packages[pkg] = true
+		}
+		for _, test := range impact.Tests {
+			tests[test] = true
+		}
+	}
+	if strings.Contains(g.Test, affectedPackagesPlaceholder) && len(packages) == 0 ||
+		strings.Contains(g.Test, affectedTestsPlaceholder) && len(tests) == 0 {
+		scoped.Test = ""
+		return &scoped, nil
+	}
+	scoped.Test = strings.ReplaceAll(g.Test, affectedPackagesPlaceholder, quotedShellArgs(packages))
+	scoped.Test = strings.ReplaceAll(scoped.Test, affectedTestsPlaceholder, quotedShellArgs(tests))
+	return &scoped, nil
+}
+
+// quotedShellArgs joins a set of arguments, sorted and shell-quoted.
+func quotedShellArgs(set map[string]bool) string {
+	args := make([]string, 0, len(set))
+	for arg := range set {
+		if arg == "" || strings.Trim(arg, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-./@+") != "" {
+			arg = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+		}
+		args = append(args, arg)
+	}
+	sort.Strings(args)
+	return strings.Join(args, " ")
+}
+
+// fileModifyingTools are the tools counted towards the validation gate.
+var fileModifyingTools = map[string]bool{
+	"write_file": true, "edit_file": true, "write_structured_file": true, "patch_structured_file": true,
+	"rename_symbol": true, "edit_transaction": true,
+}
+
+// countFileModifications counts the successful file writes in a batch of
+// tool calls.
+func countFileModifications(toolCalls []api.ToolCall, succeeded map[string]bool) int {
+	return len(modifiedFilePaths(toolCalls, succeeded))
+}
+
+// modifiedFilePaths returns the path of each successful file write in a
+// batch of tool calls, or "" where the arguments name none. succeeded holds
+// the IDs of the calls the executor ran without error.
+func modifiedFilePaths(toolCalls []api.ToolCall, succeeded map[string]bool) []string {
+	var paths []string
+	for _, tc := range toolCalls {
+		if !fileModifyingTools[tc.Function.Name] || !succeeded[tc.ID] {
+			continue
+		}
+		var args map[string]interface{}
+		path := ""
+		if json.Unmarshal([]byte(tc.Function.Arguments), &args) == nil {
+			if edits, ok := args["edits"].([]interface{}); ok && tc.Function.Name == "edit_transaction" {
+				for _, item := range edits {
+					edit, _ := item.(map[string]interface{})
+					editPath, _ := getFilePath(edit)
+					paths = append(paths, editPath)
+				}
+				continue
+			}
+			path, _ = getFilePath(args)
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// runValidationGate counts the file modifications in a batch of tool calls
+// and, once the workspace's threshold is reached, runs its validation
+// commands. Failures are queued for the model to fix on its next turn.
+func (ch *ConversationHandler) runValidationGate(toolCalls []api.ToolCall, succeeded map[string]bool) {
+	modified := modifiedFilePaths(toolCalls, succeeded)
+	if len(modified) == 0 || ch.agent.DryRun() {
+		return
+	}
+	root := ch.agent.currentWorkspaceRoot()
+	gate, err := LoadValidationGate(root)
+	if err != nil {
+		ch.agent.PrintLineAsync(fmt.Sprintf("[validate] %v", err))
+		return
+	}
+	if gate == nil {
+		return
+	}
+	ch.editsSinceValidation += len(modified)
+	for _, path := range modified {
+		if path != "" {
+			ch.filesSinceValidation = append(ch.filesSinceValidation, path)
+		}
+	}
+	if ch.editsSinceValidation < gate.Threshold() {
+		return
+	}
+	files := ch.filesSinceValidation
+	ch.editsSinceValidation = 0
+	ch.filesSinceValidation = nil
+
+	ctx := ch.agent.interruptCtx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	scoped, err := gate.ScopeTests(ctx, root, files)
+	if err != nil {
+		ch.agent.PrintLineAsync(fmt.Sprintf("[validate] Could not find the affected tests: %v", err))
+		return
+	}
+	if len(scoped.Steps()) == 0 {
+		ch.agent.PrintLine("[validate] No tests are affected by the modified files")
+		return
+	}
+	ctx, _, err = ch.agent.execBackendContext(ctx)
+	if err != nil {
+		ch.agent.PrintLineAsync(fmt.Sprintf("[validate] Skipped: %v", err))
+		return
+	}
+	ch.agent.PrintLine("[validate] Running validation gate")
+	steps := scoped.Run(ctx, root)
+	last := steps[len(steps)-1]
+	if last.Passed {
+		names := make([]string, 0, len(steps))
+		for _, step := range steps {
+			names = append(names, step.Name)
+		}
+		ch.agent.PrintLine(fmt.Sprintf("[validate] Passed: %s", strings.Join(names, ", ")))
+		return
+	}
+
+	ch.agent.PrintLine(fmt.Sprintf("[validate] %s failed: %s", last.Name, last.Command))
+	ch.enqueueTransientMessage(api.Message{
+		Role:    "user",
+		Content: validationFailureMessage(last),
+	})
+}
+
+// validationFailureMessage tells the model which step failed, with the tail
+// of its output.
+func validationFailureMessage(step ValidationStep) string {
+	return fmt.Sprintf("Automatic validation after your edits failed at the %s step (`%s`). Fix the problems below before continuing:\n\n```\n%s\n```",
+		step.Name, step.Command, validationOutputTail(step.Output))
+}
+
+// validationOutputTail trims a step's output to its last
+// validationGateOutputLimit bytes, where the errors usually are.
+func validationOutputTail(output string) string {
+	output = strings.TrimSpace(output)
+	if len(output) > validationGateOutputLimit {
+		output = "..." + output[len(output)-validationGateOutputLimit:]
+	}
+	return output
+}
@@ -0,0 +1,139 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	api "github.com/alantheprice/ledit/pkg/agent_api"
+	"github.com/alantheprice/ledit/pkg/configuration"
+)
+
+func writeToolCall(id, name string) api.ToolCall {
+	var tc api.ToolCall
+	tc.ID = id
+	tc.Function.Name = name
+	return tc
+}
+
+func TestLoadValidationGate(t *testing.T) {
+	root := t.TempDir()
+	gate, err := LoadValidationGate(root)
+	if err != nil || gate != nil {
+		t.Fatalf("missing file should disable the gate, got %+v, %v", gate, err)
+	}
+
+	writeTestFile(t, root, ValidationGateFile, "every: 3\n")
+	if _, err := LoadValidationGate(root); err == nil {
+		t.Fatal("expected an error for a gate without commands")
+	}
+
+	writeTestFile(t, root, ValidationGateFile, "every: 3\nbuild: go build ./...\ntest: go test ./...\ntimeout: 2m\n")
+	gate, err = LoadValidationGate(root)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gate.Threshold() != 3 || gate.Timeout.Minutes() != 2 {
+		t.Fatalf("unexpected gate: %+v", gate)
+	}
+	steps := gate.Steps()
+	if len(steps) != 2 || steps[0].Name != "build" || steps[1].Name != "test" {
+		t.Fatalf("unexpected steps: %+v", steps)
+	}
+}
+
+func TestValidationGateRunStopsAtFirstFailure(t *testing.T) {
+	gate := &ValidationGate{Build: "echo built", Lint: "echo 'bad style' && exit 1", Test: "echo tested"}
+	steps := gate.Run(t.Context(), t.TempDir())
+	if len(steps) != 2 {
+		t.Fatalf("expected test to be skipped after the lint failure, got %+v", steps)
+	}
+	if !steps[0].Passed || steps[1].Passed || !strings.Contains(steps[1].Output, "bad style") {
+		t.Fatalf("unexpected results: %+v", steps)
+	}
+}
+
+func TestCountFileModificationsSkipsFailures(t *testing.T) {
+	calls := []api.ToolCall{
+		writeToolCall("1", "write_file"),
+		writeToolCall("2", "edit_file"),
+		writeToolCall("3", "read_file"),
+	}
+	succeeded := map[string]bool{"1": true, "3": true}
+	if got := countFileModifications(calls, succeeded); got != 1 {
+		t.Fatalf("countFileModifications = %d, want 1", got)
+	}
+}
+
+func TestRunValidationGateSkipsBlockedWrites(t *testing.T) {
+	root := t.TempDir()
+	t.Chdir(root)
+	t.Setenv("LEDIT_FROM_AGENT", "")
+	t.Setenv("LEDIT_SUBAGENT", "")
+	writeTestFile(t, root, ValidationGateFile, "every: 1\nbuild: \"echo 'main.go:3: undefined: foo' && exit 2\"\n")
+	agent := newTestAgent(t)
+	agent.SetWorkspaceRoot(root)
+	if err := agent.configManager.UpdateConfigNoSave(func(cfg *configuration.Config) error {
+		cfg.SkipPrompt = true
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	ch := NewConversationHandler(agent)
+
+	// A write without a path argument is held for the model to confirm
+	blocked := []api.ToolCall{policyToolCall("call-1", "write_file", `{"file_path": "main.go", "content": "package main\n"}`)}
+	results := ch.toolExecutor.ExecuteTools(blocked)
+	if !strings.HasPrefix(results[0].Content, "SECURITY_CAUTION_REQUIRED") {
+		t.Fatalf("expected the write to be held for verification, got: %s", results[0].Content)
+	}
+	ch.runValidationGate(blocked, ch.toolExecutor.succeededCalls())
+	if len(ch.transientMessages) != 0 || ch.editsSinceValidation != 0 {
+		t.Fatalf("a blocked write should not count towards the gate, got %d edits", ch.editsSinceValidation)
+	}
+
+	// A dry-run preview changes nothing either
+	t.Setenv(dryRunEnv, "1")
+	previewed := []api.ToolCall{policyToolCall("call-2", "write_file", `{"path": "main.go", "content": "package main\n"}`)}
+	results = ch.toolExecutor.ExecuteTools(previewed)
+	if !strings.HasPrefix(results[0].Content, dryRunNote) {
+		t.Fatalf("expected a dry-run preview, got: %s", results[0].Content)
+	}
+	if got := countFileModifications(previewed, ch.toolExecutor.succeededCalls()); got != 0 {
+		t.Fatalf("a dry-run preview should not count as a modification, got %d", got)
+	}
+
+	t.Setenv(dryRunEnv, "")
+	written := []api.ToolCall{policyToolCall("call-3", "write_file", `{"path": "main.go", "content": "package main\n"}`)}
+	ch.toolExecutor.ExecuteTools(written)
+	ch.runValidationGate(written, ch.toolExecutor.succeededCalls())
+	if len(ch.transientMessages) != 1 {
+		t.Fatalf("expected the write to run the gate, got %d messages", len(ch.transientMessages))
+	}
+}
+
+func TestRunValidationGateFeedsFailuresBack(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, root, ValidationGateFile, "every: 2\nbuild: \"echo 'main.go:3: undefined: foo' && exit 2\"\n")
+	agent := newTestAgent(t)
+	agent.SetWorkspaceRoot(root)
+	ch := NewConversationHandler(agent)
+
+	calls := []api.ToolCall{writeToolCall("1", "write_file")}
+	succeeded := map[string]bool{"1": true}
+	ch.runValidationGate(calls, succeeded)
+	if len(ch.transientMessages) != 0 {
+		t.Fatal("gate should wait for the second modification")
+	}
+
+	ch.runValidationGate(calls, succeeded)
+	if len(ch.transientMessages) != 1 {
+		t.Fatalf("expected one failure message, got %d", len(ch.transientMessages))
+	}
+	msg := ch.transientMessages[0].Content
+	if !strings.Contains(msg, "build step") || !strings.Contains(msg, "undefined: foo") {
+		t.Fatalf("unexpected failure message: %s", msg)
+	}
+	if ch.editsSinceValidation != 0 {
+		t.Fatalf("counter should reset after a run, got %d", ch.editsSinceValidation)
+	}
+}
@@ -0,0 +1,335 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alantheprice/ledit/pkg/events"
+	"github.com/alantheprice/ledit/pkg/index"
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	workspaceWatchDebounce      = 300 * time.Millisecond
+	workspaceWatchFlushInterval = 250 * time.Millisecond
+	workspaceSelfWriteWindow    = 3 * time.Second
+	workspaceSymbolRefreshDelay = 2 * time.Second
+)
+
+// workspaceWatchExcludedDirs are never watched: they are either version control
+// metadata, ledit's own state, or dependency/build trees that churn constantly.
+var workspaceWatchExcludedDirs = map[string]bool{
+	".git":         true,
+	".ledit":       true,
+	"node_modules": true,
+	".venv":        true,
+	"vendor":       true,
+	"dist":         true,
+	"build":        true,
+	".cache":       true,
+}
+
+// workspaceSymbolExts mirrors the source extensions indexed by index.BuildSymbols.
+var workspaceSymbolExts = map[string]bool{
+	".go": true, ".py": true, ".js": true, ".jsx": true, ".mjs": true, ".cjs": true,
+	".ts": true, ".tsx": true, ".rb": true, ".php": true, ".rs": true, ".java": true,
+}
+
+// pendingWorkspaceChange is a debounced filesystem change awaiting publication.
+type pendingWorkspaceChange struct {
+	action   string
+	lastSeen time.Time
+}
+
+// workspaceWatcher keeps the agent's view of the workspace fresh while files
+// change underneath it (agent edits, IDE saves, git checkouts). Changes are
+// debounced, queued for optimizer invalidation, republished as file_changed
+// events, and trigger a refresh of the persisted symbol index.
+type workspaceWatcher struct {
+	agent     *Agent
+	root      string
+	fsWatcher *fsnotify.Watcher
+	cancel    context.CancelFunc
+
+	mu           sync.Mutex
+	pending      map[string]pendingWorkspaceChange // absolute path → change
+	stale        map[string]bool                   // absolute paths awaiting optimizer invalidation
+	selfWrites   map[string]time.Time              // absolute path → last agent write
+	symbolsDirty time.Time                         // zero when the symbol index is current
+	rebuilding   bool
+}
+
+// StartWorkspaceWatcher begins watching root recursively for file changes.
+// Calling it again replaces any running watcher. The watcher stops when ctx is
+// cancelled or StopWorkspaceWatcher is called.
+func (a *Agent) StartWorkspaceWatcher(ctx context.Context, root string) error {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return fmt.Errorf("failed to resolve workspace root: %w", err)
+	}
+
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create file watcher: %w", err)
+	}
+
+	ww := &workspaceWatcher{
+		agent:      a,
+		root:       absRoot,
+		fsWatcher:  w,
+		pending:    make(map[string]pendingWorkspaceChange),
+		stale:      make(map[string]bool),
+		selfWrites: make(map[string]time.Time),
+	}
+	ww.addTree(absRoot)
+
+	a.StopWorkspaceWatcher()
+
+	ctx, cancel := context.WithCancel(ctx)
+	ww.cancel = cancel
+
+	a.workspaceWatcherMu.Lock()
+	a.workspaceWatcher = ww
+	a.workspaceWatcherMu.Unlock()
+
+	go ww.eventLoop(ctx)
+	a.debugLog("[watch] Watching workspace %s\n", absRoot)
+	return nil
+}
+
+// StopWorkspaceWatcher stops the workspace watcher if one is running.
+func (a *Agent) StopWorkspaceWatcher() {
+	a.workspaceWatcherMu.Lock()
+	ww := a.workspaceWatcher
+	a.workspaceWatcher = nil
+	a.workspaceWatcherMu.Unlock()
+
+	if ww == nil {
+		return
+	}
+	ww.cancel()
+	_ = ww.fsWatcher.Close()
+}
+
+// noteAgentFileWrite records that the agent itself wrote path so the watcher
+// does not republish a duplicate file_changed event for it.
+func (a *Agent) noteAgentFileWrite(path string) {
+	a.workspaceWatcherMu.Lock()
+	ww := a.workspaceWatcher
+	a.workspaceWatcherMu.Unlock()
+	if ww == nil {
+		return
+	}
+
+	abs := path
+	if !filepath.IsAbs(abs) {
+		abs = filepath.Join(ww.root, abs)
+	}
+	ww.mu.Lock()
+	ww.selfWrites[filepath.Clean(abs)] = time.Now()
+	ww.mu.Unlock()
+}
+
+// applyWorkspaceInvalidations drops optimizer records for files changed on
+// disk since the last call, and any deduplicated tool results. The optimizer
+// is not safe for concurrent use, so the watcher only queues paths and the
+// conversation loop applies them.
+func (a *Agent) applyWorkspaceInvalidations() {
+	a.workspaceWatcherMu.Lock()
+	ww := a.workspaceWatcher
+	a.workspaceWatcherMu.Unlock()
+	if ww == nil {
+		return
+	}
+
+	ww.mu.Lock()
+	stale := ww.stale
+	ww.stale = make(map[string]bool)
+	ww.mu.Unlock()
+
+	if len(stale) > 0 {
+		a.resetToolDedup()
+	}
+	if a.optimizer == nil {
+		return
+	}
+	for abs := range stale {
+		for _, key := range ww.pathKeys(abs) {
+			a.optimizer.InvalidateFile(key)
+		}
+	}
+}
+
+// pathKeys returns the spellings a tool call may have used for abs.
+func (ww *workspaceWatcher) pathKeys(abs string) []string {
+	keys := []string{abs}
+	if rel, err := filepath.Rel(ww.root, abs); err == nil && !strings.HasPrefix(rel, "..") {
+		keys = append(keys, rel, "."+string(filepath.Separator)+rel)
+		if slashed := filepath.ToSlash(rel); slashed != rel {
+			keys = append(keys, slashed, "./"+slashed)
+		}
+	}
+	return keys
+}
+
+// addTree registers dir and all non-excluded subdirectories with fsnotify.
+func (ww *workspaceWatcher) addTree(dir string) {
+	_ = filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		if path != dir && workspaceWatchExcludedDirs[d.Name()] {
+			return filepath.SkipDir
+		}
+		if addErr := ww.fsWatcher.Add(path); addErr != nil {
+			ww.agent.debugLog("[watch] failed to watch %s: %v\n", path, addErr)
+		}
+		return nil
+	})
+}
+
+// isExcluded reports whether path lies inside an excluded directory.
+func (ww *workspaceWatcher) isExcluded(path string) bool {
+	rel, err := filepath.Rel(ww.root, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return true
+	}
+	for _, part := range strings.Split(filepath.ToSlash(rel), "/") {
+		if workspaceWatchExcludedDirs[part] {
+			return true
+		}
+	}
+	return false
+}
+
+func (ww *workspaceWatcher) eventLoop(ctx context.Context) {
+	ticker := time.NewTicker(workspaceWatchFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case fse, ok := <-ww.fsWatcher.Events:
+			if !ok {
+				return
+			}
+			ww.record(fse)
+		case err, ok := <-ww.fsWatcher.Errors:
+			if !ok {
+				return
+			}
+			ww.agent.debugLog("[watch] fsnotify error: %v\n", err)
+		case now := <-ticker.C:
+			ww.flush(now)
+		}
+	}
+}
+
+// record queues a filesystem event for debounced publication.
+func (ww *workspaceWatcher) record(fse fsnotify.Event) {
+	if fse.Op&(fsnotify.Create|fsnotify.Write|fsnotify.Remove|fsnotify.Rename) == 0 {
+		return
+	}
+	path := filepath.Clean(fse.Name)
+	if ww.isExcluded(path) {
+		return
+	}
+
+	action := "write"
+	switch {
+	case fse.Op&(fsnotify.Remove|fsnotify.Rename) != 0:
+		action = "deleted"
+	case fse.Op&fsnotify.Create != 0:
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			// New directories are watched but not reported; files created
+			// inside them arrive as their own events.
+			ww.addTree(path)
+			return
+		}
+		action = "created"
+	}
+
+	ww.mu.Lock()
+	defer ww.mu.Unlock()
+	if prev, ok := ww.pending[path]; ok && prev.action == "created" && action == "write" {
+		action = "created"
+	}
+	ww.pending[path] = pendingWorkspaceChange{action: action, lastSeen: time.Now()}
+}
+
+// flush publishes changes that have been quiet for the debounce interval and
+// kicks off a symbol index refresh once source edits settle.
+func (ww *workspaceWatcher) flush(now time.Time) {
+	type readyChange struct {
+		path   string
+		action string
+	}
+	var ready []readyChange
+
+	ww.mu.Lock()
+	for path, change := range ww.pending {
+		if now.Sub(change.lastSeen) < workspaceWatchDebounce {
+			continue
+		}
+		delete(ww.pending, path)
+		ww.stale[path] = true
+		if workspaceSymbolExts[strings.ToLower(filepath.Ext(path))] {
+			ww.symbolsDirty = now
+		}
+		if wrote, ok := ww.selfWrites[path]; ok && now.Sub(wrote) < workspaceSelfWriteWindow {
+			continue
+		}
+		ready = append(ready, readyChange{path: path, action: change.action})
+	}
+	for path, wrote := range ww.selfWrites {
+		if now.Sub(wrote) >= workspaceSelfWriteWindow {
+			delete(ww.selfWrites, path)
+		}
+	}
+	refreshSymbols := !ww.symbolsDirty.IsZero() && !ww.rebuilding &&
+		now.Sub(ww.symbolsDirty) >= workspaceSymbolRefreshDelay
+	if refreshSymbols {
+		ww.symbolsDirty = time.Time{}
+		ww.rebuilding = true
+	}
+	ww.mu.Unlock()
+
+	for _, change := range ready {
+		display := change.path
+		if rel, err := filepath.Rel(ww.root, change.path); err == nil {
+			display = filepath.ToSlash(rel)
+		}
+		ww.agent.publishEvent(events.EventTypeFileChanged, events.FileChangedEvent(display, change.action, ""))
+		ww.agent.debugLog("[watch] %s: %s\n", change.action, display)
+	}
+
+	if refreshSymbols {
+		go ww.refreshSymbolIndex()
+	}
+}
+
+// refreshSymbolIndex rebuilds .ledit/symbols.json when the workspace already
+// has one, so symbol-based file ranking reflects the latest edits.
+func (ww *workspaceWatcher) refreshSymbolIndex() {
+	defer func() {
+		ww.mu.Lock()
+		ww.rebuilding = false
+		ww.mu.Unlock()
+	}()
+
+	if _, err := os.Stat(filepath.Join(ww.root, ".ledit", "symbols.json")); err != nil {
+		return
+	}
+	idx, err := index.BuildSymbols(ww.root)
+	if err != nil {
+		ww.agent.debugLog("[watch] symbol index refresh failed: %v\n", err)
+		return
+	}
+	ww.agent.debugLog("[watch] symbol index refreshed (%s)\n", idx.Stats)
+}
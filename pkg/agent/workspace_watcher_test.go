@@ -0,0 +1,139 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alantheprice/ledit/pkg/events"
+)
+
+// waitForFileChanged returns the first file_changed payload for path, or nil on timeout.
+func waitForFileChanged(ch <-chan events.UIEvent, path string, timeout time.Duration) map[string]interface{} {
+	deadline := time.After(timeout)
+	for {
+		select {
+		case ev := <-ch:
+			if ev.Type != events.EventTypeFileChanged {
+				continue
+			}
+			data, ok := ev.Data.(map[string]interface{})
+			if ok && data["file_path"] == path {
+				return data
+			}
+		case <-deadline:
+			return nil
+		}
+	}
+}
+
+// collectFileChanges records the paths of file_changed events until one for
+// until arrives, plus one more flush so events published alongside it are
+// included, or until timeout.
+func collectFileChanges(ch <-chan events.UIEvent, until string, timeout time.Duration) map[string]bool {
+	seen := make(map[string]bool)
+	deadline := time.After(timeout)
+	var grace <-chan time.Time
+	for {
+		select {
+		case ev := <-ch:
+			data, ok := ev.Data.(map[string]interface{})
+			if ev.Type != events.EventTypeFileChanged || !ok {
+				continue
+			}
+			path, _ := data["file_path"].(string)
+			seen[path] = true
+			if path == until && grace == nil {
+				grace = time.After(2 * workspaceWatchFlushInterval)
+			}
+		case <-grace:
+			return seen
+		case <-deadline:
+			return seen
+		}
+	}
+}
+
+func newWatchedTestAgent(t *testing.T) (*Agent, string, <-chan events.UIEvent) {
+	t.Helper()
+	root := t.TempDir()
+	bus := events.NewEventBus()
+	a := &Agent{
+		eventBus:  bus,
+		optimizer: NewConversationOptimizer(true, false),
+	}
+	ch := bus.Subscribe("watch-test")
+	t.Cleanup(func() { bus.Unsubscribe("watch-test") })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	if err := a.StartWorkspaceWatcher(ctx, root); err != nil {
+		t.Fatalf("StartWorkspaceWatcher: %v", err)
+	}
+	t.Cleanup(a.StopWorkspaceWatcher)
+	return a, root, ch
+}
+
+func TestWorkspaceWatcherPublishesExternalChanges(t *testing.T) {
+	a, root, ch := newWatchedTestAgent(t)
+
+	if err := os.MkdirAll(filepath.Join(root, "pkg"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// Give the watcher a moment to register the new directory.
+	time.Sleep(100 * time.Millisecond)
+	if err := os.WriteFile(filepath.Join(root, "pkg", "foo.go"), []byte("package pkg\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	data := waitForFileChanged(ch, "pkg/foo.go", 3*time.Second)
+	if data == nil {
+		t.Fatal("expected file_changed event for pkg/foo.go")
+	}
+	if data["action"] != "created" {
+		t.Errorf("action = %v, want created", data["action"])
+	}
+
+	a.optimizer.fileReads["pkg/foo.go"] = &FileReadRecord{FilePath: "pkg/foo.go"}
+	a.applyWorkspaceInvalidations()
+	if _, ok := a.optimizer.fileReads["pkg/foo.go"]; ok {
+		t.Error("expected optimizer record for pkg/foo.go to be invalidated")
+	}
+}
+
+func TestWorkspaceWatcherSkipsAgentWritesAndExcludedDirs(t *testing.T) {
+	a, root, ch := newWatchedTestAgent(t)
+
+	if err := os.MkdirAll(filepath.Join(root, "node_modules"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// Give the watcher the same moment it needs to register a new directory,
+	// so dep.js would be seen if node_modules were not excluded.
+	time.Sleep(100 * time.Millisecond)
+	a.noteAgentFileWrite("own.txt")
+	if err := os.WriteFile(filepath.Join(root, "own.txt"), []byte("agent"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "node_modules", "dep.js"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// An external write after the suppressed ones shows the watcher is
+	// publishing, so missing events below mean they were filtered.
+	if err := os.WriteFile(filepath.Join(root, "external.txt"), []byte("user"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	seen := collectFileChanges(ch, "external.txt", 3*time.Second)
+	if !seen["external.txt"] {
+		t.Fatalf("expected file_changed event for external.txt, got %v", seen)
+	}
+	if seen["own.txt"] {
+		t.Error("unexpected file_changed event for agent write own.txt")
+	}
+	if seen["node_modules/dep.js"] {
+		t.Error("unexpected file_changed event inside node_modules")
+	}
+}
@@ -1,8 +1,9 @@
 package api
 
 import (
-	"strings"
 	"sync"
+
+	"github.com/alantheprice/ledit/pkg/tokenizer"
 )
 
 // Token estimation constants
@@ -26,74 +27,38 @@ const (
 )
 
 var (
-	tokenCache   = make(map[string]int)
-	tokenCacheMu sync.RWMutex
+	tokenCache     = make(map[string]int)
+	tokenCacheName string
+	tokenCacheMu   sync.RWMutex
 )
 
-// EstimateTokens provides a token estimation based on OpenAI's tiktoken approach.
-// This is the centralized implementation that all providers should use for consistency.
+// EstimateTokens counts the tokens in text with the tokenizer for the
+// current model (see tokenizer.SetModel), or estimates them when no
+// tokenizer file is available. All providers use it for consistency.
 func EstimateTokens(text string) int {
 	if text == "" {
 		return 0
 	}
+	t := tokenizer.Active()
 
 	// Fast path: cached
 	tokenCacheMu.RLock()
 	cached, ok := tokenCache[text]
+	ok = ok && tokenCacheName == t.Name()
 	tokenCacheMu.RUnlock()
 	if ok {
 		return cached
 	}
 
-	// Count words and characters for better estimation
-	words := strings.Fields(text)
-	charCount := len(text)
-
-	// Count special tokens (newlines, punctuation, etc.)
-	specialTokens := 0
-	for _, char := range text {
-		if char == '\n' || char == '\r' || char == '\t' {
-			specialTokens++
-		}
-	}
-
-	// More sophisticated estimation based on OpenAI's patterns:
-	// - English text: ~0.75 tokens per word
-	// - Code: ~1.2 tokens per word (more tokens due to syntax)
-	// - Special characters: ~0.5 tokens each
-
-	// Detect if this looks like code
-	isCode := detectCode(text)
-
-	var tokensPerWord float64
-	if isCode {
-		tokensPerWord = 1.2
-	} else {
-		tokensPerWord = 0.75
-	}
-
-	// Calculate estimated tokens
-	wordTokens := float64(len(words)) * tokensPerWord
-	charTokens := float64(charCount) * 0.25 // Rough character-to-token ratio
-	specialTokenCost := float64(specialTokens) * 0.5
-
-	// Use the higher of word-based or character-based estimation
-	var baseTokens float64
-	if wordTokens > charTokens {
-		baseTokens = wordTokens
-	} else {
-		baseTokens = charTokens
-	}
-
-	totalTokens := int(baseTokens + specialTokenCost)
+	totalTokens := t.Count(text)
 
-	// Ensure minimum token count
-	if totalTokens < 1 {
-		totalTokens = 1
-	}
-
-	// Store in cache (limit cache size to prevent memory issues)
+	// Store in cache (limit cache size to prevent memory issues). Counts
+	// from another tokenizer are dropped when the model changes.
 	tokenCacheMu.Lock()
+	if tokenCacheName != t.Name() {
+		tokenCache = make(map[string]int)
+		tokenCacheName = t.Name()
+	}
 	if len(tokenCache) < 10000 {
 		tokenCache[text] = totalTokens
 	}
@@ -104,22 +69,7 @@ func EstimateTokens(text string) int {
 
 // detectCode determines if text appears to be code
 func detectCode(text string) bool {
-	return strings.Contains(text, "func ") ||
-		strings.Contains(text, "import ") ||
-		strings.Contains(text, "package ") ||
-		strings.Contains(text, "if ") ||
-		strings.Contains(text, "for ") ||
-		strings.Contains(text, "return ") ||
-		strings.Contains(text, "var ") ||
-		strings.Contains(text, "const ") ||
-		strings.Contains(text, "struct ") ||
-		strings.Contains(text, "interface ") ||
-		strings.Contains(text, "func(") ||
-		strings.Contains(text, "{\n") ||
-		strings.Contains(text, "}\n") ||
-		strings.Contains(text, "();") ||
-		strings.Contains(text, "= {") ||
-		strings.Contains(text, "=> {")
+	return tokenizer.LooksLikeCode(text)
 }
 
 // EstimateInputTokens estimates total input tokens for messages and tools.
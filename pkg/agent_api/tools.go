@@ -135,7 +135,7 @@ func GetToolDefinitions() []Tool {
 				Parameters  interface{} `json:"parameters"`
 			}{
 				Name:        "read_file",
-				Description: "Read file contents, optionally with line range",
+				Description: "Read file contents, optionally with line range. Files over the size limit return their first and last lines with suggested view_range values; binary files return their type, size and a hex dump",
 				Parameters: map[string]interface{}{
 					"type": "object",
 					"properties": map[string]interface{}{
@@ -155,6 +155,154 @@ func GetToolDefinitions() []Tool {
 				},
 			},
 		},
+		{
+			Type: "function",
+			Function: struct {
+				Name        string      `json:"name"`
+				Description string      `json:"description"`
+				Parameters  interface{} `json:"parameters"`
+			}{
+				Name:        "read_symbol",
+				Description: "Read just one function, method, type, constant or variable from a file, with its doc comment. Prefer over read_file when you only need a known symbol",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"path": map[string]interface{}{
+							"type":        "string",
+							"description": "Path to file containing the symbol",
+							"minLength":   1,
+						},
+						"symbol": map[string]interface{}{
+							"type":        "string",
+							"description": "Symbol name, e.g. ParseConfig; use Type.Method for methods",
+							"minLength":   1,
+						},
+					},
+					"required":             []string{"path", "symbol"},
+					"additionalProperties": false,
+				},
+			},
+		},
+		{
+			Type: "function",
+			Function: struct {
+				Name        string      `json:"name"`
+				Description string      `json:"description"`
+				Parameters  interface{} `json:"parameters"`
+			}{
+				Name:        "impact_of_change",
+				Description: "List the files that import a file directly or transitively, the tests likely affected by editing it, and the Go packages to test",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"path": map[string]interface{}{
+							"type":        "string",
+							"description": "Path to the file being changed",
+							"minLength":   1,
+						},
+					},
+					"required":             []string{"path"},
+					"additionalProperties": false,
+				},
+			},
+		},
+		{
+			Type: "function",
+			Function: struct {
+				Name        string      `json:"name"`
+				Description string      `json:"description"`
+				Parameters  interface{} `json:"parameters"`
+			}{
+				Name:        "file_history",
+				Description: "Show a file's recent commits (hash, date, author, subject) and who last changed each line, grouped into blame segments, for a line range or the whole file. Use it to learn why code is the way it is, and before reverting or rewriting code that was changed recently on purpose",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"path": map[string]interface{}{
+							"type":        "string",
+							"description": "Path to the file",
+							"minLength":   1,
+						},
+						"start_line": map[string]interface{}{
+							"type":        "integer",
+							"description": "First line to blame (1-based); omit to start at the first line",
+							"minimum":     1,
+						},
+						"end_line": map[string]interface{}{
+							"type":        "integer",
+							"description": "Last line to blame; omit to end at the last line",
+							"minimum":     1,
+						},
+						"max_commits": map[string]interface{}{
+							"type":        "integer",
+							"description": "Recent commits to list (default: 10)",
+							"minimum":     1,
+						},
+					},
+					"required":             []string{"path"},
+					"additionalProperties": false,
+				},
+			},
+		},
+		{
+			Type: "function",
+			Function: struct {
+				Name        string      `json:"name"`
+				Description string      `json:"description"`
+				Parameters  interface{} `json:"parameters"`
+			}{
+				Name:        "test_coverage",
+				Description: "Report Go test coverage per function, listing the functions with the most uncovered statements first. Use it before adding tests to improve coverage so the new tests target real gaps. Coverage is stored in .ledit/coverage.json; it is measured by running the tests when nothing is stored or refresh is true",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"path": map[string]interface{}{
+							"type":        "string",
+							"description": "File or directory to report on; omit for the whole workspace",
+						},
+						"refresh": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Run the tests again instead of using the stored coverage, e.g. after adding tests",
+						},
+					},
+					"additionalProperties": false,
+				},
+			},
+		},
+		{
+			Type: "function",
+			Function: struct {
+				Name        string      `json:"name"`
+				Description string      `json:"description"`
+				Parameters  interface{} `json:"parameters"`
+			}{
+				Name:        "run_linters",
+				Description: "Run the project's linters (golangci-lint for Go modules, eslint for Node projects, ruff for Python projects) and return a deduplicated summary of diagnostics with file, line, rule and severity, errors first. Prefer it over running linters through shell_command",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"path": map[string]interface{}{
+							"type":        "string",
+							"description": "File or directory to lint; omit for the whole workspace",
+						},
+						"linters": map[string]interface{}{
+							"type":        "array",
+							"description": "Linters to run; omit to use the ones matching the project",
+							"items": map[string]interface{}{
+								"type": "string",
+								"enum": []string{"golangci-lint", "eslint", "ruff"},
+							},
+						},
+						"max_results": map[string]interface{}{
+							"type":        "integer",
+							"description": "Maximum diagnostics to list (default: 50)",
+							"minimum":     1,
+						},
+					},
+					"additionalProperties": false,
+				},
+			},
+		},
 		{
 			Type: "function",
 			Function: struct {
@@ -214,6 +362,95 @@ func GetToolDefinitions() []Tool {
 				},
 			},
 		},
+		{
+			Type: "function",
+			Function: struct {
+				Name        string      `json:"name"`
+				Description string      `json:"description"`
+				Parameters  interface{} `json:"parameters"`
+			}{
+				Name:        "rename_symbol",
+				Description: "Rename a Go function, type, method, field, variable or constant and every reference to it across the workspace, using gopls. Type-checked, so it neither misses references nor renames unrelated identifiers; prefer it over edit_file for renames. Returns the changed lines per file",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"path": map[string]interface{}{
+							"type":        "string",
+							"description": "Go file declaring or using the symbol",
+							"minLength":   1,
+						},
+						"symbol": map[string]interface{}{
+							"type":        "string",
+							"description": "Current name, e.g. ParseConfig; use Type.Method or Type.Field for members",
+							"minLength":   1,
+						},
+						"new_name": map[string]interface{}{
+							"type":        "string",
+							"description": "New name",
+							"minLength":   1,
+						},
+						"line": map[string]interface{}{
+							"type":        "integer",
+							"description": "1-based line of the identifier in path; required for local variables and parameters",
+							"minimum":     1,
+						},
+					},
+					"required":             []string{"path", "symbol", "new_name"},
+					"additionalProperties": false,
+				},
+			},
+		},
+		{
+			Type: "function",
+			Function: struct {
+				Name        string      `json:"name"`
+				Description string      `json:"description"`
+				Parameters  interface{} `json:"parameters"`
+			}{
+				Name:        "edit_transaction",
+				Description: "Apply related edits across several files as one transaction: all edits are applied in memory first, and files are written only if every edit succeeds, so a failing edit leaves the workspace unchanged. With validate, the build runs after writing and a failing build rolls every file back. Use it for changes that only make sense together, such as a signature change and its callers",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"edits": map[string]interface{}{
+							"type":        "array",
+							"description": "Edits applied in order; later edits to the same file see earlier ones",
+							"minItems":    1,
+							"items": map[string]interface{}{
+								"type": "object",
+								"properties": map[string]interface{}{
+									"path": map[string]interface{}{
+										"type":        "string",
+										"description": "File to edit or create",
+										"minLength":   1,
+									},
+									"old_str": map[string]interface{}{
+										"type":        "string",
+										"description": "Exact text to replace, as in edit_file",
+									},
+									"new_str": map[string]interface{}{
+										"type":        "string",
+										"description": "Replacement text",
+									},
+									"content": map[string]interface{}{
+										"type":        "string",
+										"description": "Whole new file content, as in write_file; use instead of old_str and new_str",
+									},
+								},
+								"required":             []string{"path"},
+								"additionalProperties": false,
+							},
+						},
+						"validate": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Run the workspace build (validation gate build, or go build ./...) and roll back if it fails",
+						},
+					},
+					"required":             []string{"edits"},
+					"additionalProperties": false,
+				},
+			},
+		},
 		{
 			Type: "function",
 			Function: struct {
@@ -389,6 +626,32 @@ func GetToolDefinitions() []Tool {
 				},
 			},
 		},
+		{
+			Type: "function",
+			Function: struct {
+				Name        string      `json:"name"`
+				Description string      `json:"description"`
+				Parameters  interface{} `json:"parameters"`
+			}{
+				Name:        "fetch_more",
+				Description: "Read the next chunk of a truncated tool result. Truncated shell_command and fetch_url output includes a continuation_token; each chunk ends with the token for the one after it.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"continuation_token": map[string]interface{}{
+							"type":        "string",
+							"description": "Continuation token from a truncated result or the previous chunk",
+						},
+						"max_chars": map[string]interface{}{
+							"type":        "integer",
+							"description": "Maximum characters to return (default: 12000, at most 50000)",
+						},
+					},
+					"required":             []string{"continuation_token"},
+					"additionalProperties": false,
+				},
+			},
+		},
 		{
 			Type: "function",
 			Function: struct {
@@ -545,8 +808,8 @@ func GetToolDefinitions() []Tool {
 						},
 						"persona": map[string]interface{}{
 							"type":        "string",
-							"description": "REQUIRED: Subagent persona - choose from: general, coder, refactor, debugger, tester, code_reviewer, researcher, web_scraper",
-							"enum":        []string{"general", "coder", "refactor", "debugger", "tester", "code_reviewer", "researcher", "web_scraper"},
+							"description": "REQUIRED: Subagent persona - choose from: general, coder, refactor, debugger, tester, code_reviewer, security_reviewer, researcher, web_scraper",
+							"enum":        []string{"general", "coder", "refactor", "debugger", "tester", "code_reviewer", "security_reviewer", "researcher", "web_scraper"},
 						},
 						"context": map[string]interface{}{
 							"type":        "string",
@@ -626,6 +889,16 @@ func GetToolDefinitions() []Tool {
 							"description": "Whether the search should be case sensitive",
 							"default":     false,
 						},
+						"fixed_strings": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Treat search_pattern as a literal string instead of a regular expression",
+							"default":     false,
+						},
+						"multiline": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Allow regex matches to span multiple lines ('.' also matches newlines)",
+							"default":     false,
+						},
 						"max_results": map[string]interface{}{
 							"type":        "integer",
 							"description": "Maximum number of results to return",
@@ -830,6 +1103,39 @@ func GetToolDefinitions() []Tool {
 				},
 			},
 		},
+		{
+			Type: "function",
+			Function: struct {
+				Name        string      `json:"name"`
+				Description string      `json:"description"`
+				Parameters  interface{} `json:"parameters"`
+			}{
+				Name:        "ask_user",
+				Description: "Ask the user a question and wait for the answer, instead of guessing when a requirement is ambiguous or a decision is theirs to make. Offer options when the likely answers are known.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"question": map[string]interface{}{
+							"type":        "string",
+							"description": "The question to ask, with the context the user needs to answer it",
+						},
+						"options": map[string]interface{}{
+							"type":        "array",
+							"description": "Possible answers for the user to choose from, most likely first",
+							"items": map[string]interface{}{
+								"type": "string",
+							},
+						},
+						"allow_other": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Let the user type an answer that is not among the options (default: true)",
+						},
+					},
+					"required":             []string{"question"},
+					"additionalProperties": false,
+				},
+			},
+		},
 		{
 			Type: "function",
 			Function: struct {
@@ -852,5 +1158,60 @@ func GetToolDefinitions() []Tool {
 				},
 			},
 		},
+		{
+			Type: "function",
+			Function: struct {
+				Name        string      `json:"name"`
+				Description string      `json:"description"`
+				Parameters  interface{} `json:"parameters"`
+			}{
+				Name:        "pr",
+				Description: "Work with the pull request (GitLab: merge request) of the current branch on the origin remote's GitHub or GitLab. Actions: status, create, comments, reply.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"action": map[string]interface{}{
+							"type":        "string",
+							"enum":        []string{"status", "create", "comments", "reply"},
+							"description": "status shows the branch and its open pull request; create opens a pull request; comments lists review comments; reply answers a comment",
+						},
+						"title": map[string]interface{}{
+							"type":        "string",
+							"description": "Pull request title (create)",
+						},
+						"body": map[string]interface{}{
+							"type":        "string",
+							"description": "Pull request description (create) or reply text (reply)",
+						},
+						"base": map[string]interface{}{
+							"type":        "string",
+							"description": "Base branch (create, defaults to the remote's default branch)",
+						},
+						"draft": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Open the pull request as a draft (create)",
+						},
+						"push": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Push the branch first if the remote does not have it (create)",
+						},
+						"number": map[string]interface{}{
+							"type":        "integer",
+							"description": "Pull request number (comments, reply; defaults to the current branch's pull request)",
+						},
+						"add_todos": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Add a todo for every review thread so the comments can be addressed one by one (comments)",
+						},
+						"comment_id": map[string]interface{}{
+							"type":        "string",
+							"description": "ID of the comment to reply to, as listed by comments (reply)",
+						},
+					},
+					"required":             []string{"action"},
+					"additionalProperties": false,
+				},
+			},
+		},
 	}
 }
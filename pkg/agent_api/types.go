@@ -67,6 +67,14 @@ type Tool struct {
 	} `json:"function"`
 }
 
+// ResponseFormat asks for a reply that is a JSON document matching Schema.
+// Providers that support structured outputs enforce it; the rest treat it as
+// a hint, so callers still validate the reply.
+type ResponseFormat struct {
+	Name   string                 `json:"name"`
+	Schema map[string]interface{} `json:"schema"`
+}
+
 type ChatRequest struct {
 	Model      string    `json:"model"`
 	Messages   []Message `json:"messages"`
@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alantheprice/ledit/pkg/agent"
+)
+
+// AttachCommand queues an image file for the next query. Vision-capable
+// models receive the image itself; others get its path and can use the
+// image analysis tools.
+type AttachCommand struct{}
+
+// Name returns the command name
+func (c *AttachCommand) Name() string {
+	return "attach"
+}
+
+// Description returns the command description
+func (c *AttachCommand) Description() string {
+	return "Attach an image to your next message: /attach <path> | list | clear"
+}
+
+// Execute runs the attach command
+func (c *AttachCommand) Execute(args []string, chatAgent *agent.Agent) error {
+	if chatAgent == nil {
+		return fmt.Errorf("agent not available")
+	}
+
+	if len(args) == 0 || strings.EqualFold(args[0], "list") {
+		printImageAttachments(chatAgent.PendingImageAttachments())
+		return nil
+	}
+	switch strings.ToLower(args[0]) {
+	case "clear":
+		fmt.Printf("[img] Removed %d queued image(s)\r\n", chatAgent.ClearImageAttachments())
+		return nil
+	case "help", "-h", "--help":
+		fmt.Print("[img] Usage: /attach <path> | list | clear\r\n")
+		fmt.Print("[img] You can also drag an image file into the prompt or paste an image.\r\n")
+		return nil
+	}
+
+	attachment, err := chatAgent.AttachImage(strings.Join(args, " "))
+	if err != nil {
+		return fmt.Errorf("failed to attach image: %w", err)
+	}
+	note := ""
+	if attachment.Downscaled {
+		note = ", downscaled"
+	}
+	fmt.Printf("[img] Attached %s (%s%s); it will be sent with your next message\r\n",
+		attachment.Source, formatAttachmentSize(attachment.Bytes), note)
+	if !chatAgent.SupportsImageInput() {
+		fmt.Printf("[img] %s does not accept images directly; it will be given the image path to analyze with its tools\r\n", chatAgent.GetModel())
+	}
+	return nil
+}
+
+func printImageAttachments(attachments []agent.ImageAttachment) {
+	if len(attachments) == 0 {
+		fmt.Print("[img] No images queued. Attach one with /attach <path>\r\n")
+		return
+	}
+	fmt.Printf("[img] %d image(s) queued for your next message:\r\n", len(attachments))
+	for i, attachment := range attachments {
+		fmt.Printf("  %d. %s (%s)\r\n", i+1, attachment.Source, formatAttachmentSize(attachment.Bytes))
+	}
+}
+
+func formatAttachmentSize(n int) string {
+	if n >= 1024*1024 {
+		return fmt.Sprintf("%.1f MB", float64(n)/(1024*1024))
+	}
+	return fmt.Sprintf("%d KB", (n+1023)/1024)
+}
@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/alantheprice/ledit/pkg/agent"
+	"github.com/alantheprice/ledit/pkg/jobs"
+)
+
+// BackgroundStatusSegment summarizes background activity for the input
+// footer, such as "indexing 42% · 2 jobs running". It is empty when nothing
+// is running.
+func BackgroundStatusSegment(chatAgent *agent.Agent) string {
+	var activity agent.BackgroundActivity
+	if chatAgent != nil {
+		activity = chatAgent.BackgroundActivity()
+	}
+	return backgroundSegment(activity, jobs.Default().Running(), time.Now())
+}
+
+func backgroundSegment(activity agent.BackgroundActivity, jobsRunning int, now time.Time) string {
+	var parts []string
+	if activity.Indexing {
+		parts = append(parts, fmt.Sprintf("indexing %d%%", activity.IndexPercent()))
+	}
+	switch {
+	case jobsRunning == 1:
+		parts = append(parts, "1 job running")
+	case jobsRunning > 1:
+		parts = append(parts, fmt.Sprintf("%d jobs running", jobsRunning))
+	}
+	if wait := activity.RateLimitedUntil.Sub(now); wait > 0 {
+		parts = append(parts, fmt.Sprintf("rate-limited %s", wait.Round(time.Second)))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// printBackgroundStatus prints the detailed background view for /status.
+func printBackgroundStatus(chatAgent *agent.Agent) {
+	activity := chatAgent.BackgroundActivity()
+	fmt.Println("\n[bg] Background:")
+	if activity.Watching {
+		fmt.Printf("  Workspace Watcher: watching %s (%d change(s) pending)\n", activity.WatchRoot, activity.PendingChanges)
+	} else {
+		fmt.Println("  Workspace Watcher: off")
+	}
+	if activity.Indexing {
+		fmt.Printf("  Symbol Index: building, %d of %d files (%d%%)\n", activity.IndexedFiles, activity.IndexTotal, activity.IndexPercent())
+	} else {
+		fmt.Println("  Symbol Index: idle")
+	}
+	fmt.Printf("  Jobs: %d running (see /jobs)\n", jobs.Default().Running())
+	if wait := time.Until(activity.RateLimitedUntil); wait > 0 {
+		fmt.Printf("  Rate Limit: waiting %s for %s\n", wait.Round(time.Second), chatAgent.GetProvider())
+	} else {
+		fmt.Println("  Rate Limit: clear")
+	}
+}
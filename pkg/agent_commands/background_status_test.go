@@ -0,0 +1,30 @@
+package commands
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alantheprice/ledit/pkg/agent"
+)
+
+func TestBackgroundSegment(t *testing.T) {
+	now := time.Now()
+	activity := agent.BackgroundActivity{
+		Indexing:         true,
+		IndexedFiles:     42,
+		IndexTotal:       100,
+		RateLimitedUntil: now.Add(12 * time.Second),
+	}
+	if got := backgroundSegment(activity, 2, now); got != "indexing 42% · 2 jobs running · rate-limited 12s" {
+		t.Errorf("unexpected segment %q", got)
+	}
+
+	// An expired rate limit and an idle index leave nothing to show
+	activity = agent.BackgroundActivity{RateLimitedUntil: now.Add(-time.Second)}
+	if got := backgroundSegment(activity, 0, now); got != "" {
+		t.Errorf("expected an empty segment, got %q", got)
+	}
+	if got := backgroundSegment(activity, 1, now); got != "1 job running" {
+		t.Errorf("unexpected segment %q", got)
+	}
+}
@@ -76,7 +76,7 @@ func (s *StatusCommand) Name() string {
 
 // Description returns the command description
 func (s *StatusCommand) Description() string {
-	return "Show session status, provider, model, token usage, and files modified"
+	return "Show session status, provider, model, token usage, files modified and background activity"
 }
 
 // Execute shows the current status
@@ -144,6 +144,8 @@ func (s *StatusCommand) Execute(args []string, chatAgent *agent.Agent) error {
 		fmt.Printf("Tracking: %s\n", getChangeTrackingStatus(chatAgent))
 	}
 
+	printBackgroundStatus(chatAgent)
+
 	// Session
 	fmt.Printf("\n[tag] Session: %s\n", chatAgent.GetSessionID())
 
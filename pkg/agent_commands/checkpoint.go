@@ -0,0 +1,223 @@
+package commands
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/alantheprice/ledit/pkg/agent"
+	tools "github.com/alantheprice/ledit/pkg/agent_tools"
+	"github.com/alantheprice/ledit/pkg/history"
+)
+
+// CheckpointCommand snapshots the conversation, todos, revision IDs and
+// agent-modified files so a branch can later be started from it.
+type CheckpointCommand struct{}
+
+// Name returns the command name
+func (c *CheckpointCommand) Name() string {
+	return "checkpoint"
+}
+
+// Description returns the command description
+func (c *CheckpointCommand) Description() string {
+	return "Snapshot conversation and file state: /checkpoint [name] | list | diff <a> [b]"
+}
+
+// Execute runs the checkpoint command
+func (c *CheckpointCommand) Execute(args []string, chatAgent *agent.Agent) error {
+	if chatAgent == nil {
+		return fmt.Errorf("agent not available")
+	}
+
+	sub := ""
+	if len(args) > 0 {
+		sub = strings.ToLower(args[0])
+	}
+	switch sub {
+	case "list", "ls":
+		printCheckpoints(chatAgent)
+		return nil
+	case "diff":
+		if len(args) < 2 {
+			return fmt.Errorf("usage: /checkpoint diff <checkpoint> [checkpoint]")
+		}
+		to := ""
+		if len(args) > 2 {
+			to = args[2]
+		}
+		diff, err := chatAgent.DiffCheckpoints(args[1], to)
+		if err != nil {
+			return err
+		}
+		printCheckpointDiff(chatAgent, diff)
+		return nil
+	case "help", "-h", "--help":
+		printCheckpointHelp()
+		return nil
+	}
+
+	cp, err := chatAgent.CreateCheckpoint(strings.Join(args, " "))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("[checkpoint] Saved %s on branch %s (%d messages, %d todos, %d files)\r\n",
+		describeCheckpoint(cp), cp.Branch, len(cp.Messages), len(cp.Todos), len(cp.Files))
+	fmt.Print("[checkpoint] Try an alternative with /branch <name>\r\n")
+	return nil
+}
+
+// BranchCommand lists, creates and switches between conversation branches.
+type BranchCommand struct{}
+
+// Name returns the command name
+func (b *BranchCommand) Name() string {
+	return "branch"
+}
+
+// Description returns the command description
+func (b *BranchCommand) Description() string {
+	return "Branch from a checkpoint: /branch [list] | <name> [checkpoint] | switch <name>"
+}
+
+// Execute runs the branch command
+func (b *BranchCommand) Execute(args []string, chatAgent *agent.Agent) error {
+	if chatAgent == nil {
+		return fmt.Errorf("agent not available")
+	}
+
+	if len(args) == 0 || strings.EqualFold(args[0], "list") || strings.EqualFold(args[0], "ls") {
+		printBranches(chatAgent)
+		return nil
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "help", "-h", "--help":
+		printCheckpointHelp()
+		return nil
+	case "switch", "checkout":
+		if len(args) < 2 {
+			return fmt.Errorf("usage: /branch switch <name>")
+		}
+		cp, err := chatAgent.SwitchBranch(args[1])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("[branch] Switched to %s at %s (%d messages, %d todos)\r\n",
+			args[1], describeCheckpoint(cp), len(cp.Messages), len(cp.Todos))
+		return nil
+	}
+
+	from := ""
+	if len(args) > 1 {
+		from = args[1]
+	}
+	cp, err := chatAgent.CreateBranch(args[0], from)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("[branch] Created %s from %s and switched to it (%d messages, %d todos)\r\n",
+		args[0], describeCheckpoint(cp), len(cp.Messages), len(cp.Todos))
+	fmt.Print("[branch] The previous state was saved; return with /branch switch <name>\r\n")
+	return nil
+}
+
+func describeCheckpoint(cp agent.ConversationCheckpoint) string {
+	if cp.Name != "" {
+		return fmt.Sprintf("%s (%s)", cp.ID, cp.Name)
+	}
+	return cp.ID
+}
+
+func printCheckpoints(chatAgent *agent.Agent) {
+	checkpoints := chatAgent.ListCheckpoints()
+	if len(checkpoints) == 0 {
+		fmt.Print("[checkpoint] No checkpoints yet. Create one with /checkpoint [name]\r\n")
+		return
+	}
+	fmt.Print("[checkpoint] Checkpoints:\r\n")
+	for _, cp := range checkpoints {
+		note := ""
+		if cp.Auto {
+			note = " [auto]"
+		}
+		fmt.Printf("  %-6s %-20s %-12s %s  %3d msgs  %d todos  %d files%s\r\n",
+			cp.ID, cp.Name, cp.Branch, cp.CreatedAt.Format("15:04:05"),
+			len(cp.Messages), len(cp.Todos), len(cp.Files), note)
+	}
+}
+
+func printBranches(chatAgent *agent.Agent) {
+	current, branches := chatAgent.ListBranches()
+	fmt.Print("[branch] Branches:\r\n")
+	for _, br := range branches {
+		marker := " "
+		if br.Name == current {
+			marker = "*"
+		}
+		head := br.Head
+		if head == "" {
+			head = "(no checkpoint)"
+		}
+		line := fmt.Sprintf("  %s %-16s head %s", marker, br.Name, head)
+		if br.ForkedFrom != "" {
+			line += fmt.Sprintf(", forked from %s", br.ForkedFrom)
+		}
+		fmt.Print(line + "\r\n")
+	}
+}
+
+func printCheckpointDiff(chatAgent *agent.Agent, diff agent.CheckpointDiff) {
+	fmt.Printf("[checkpoint] %s → %s\r\n", diff.From, diff.To)
+	fmt.Printf("  Messages: %d → %d\r\n", diff.MessagesFrom, diff.MessagesTo)
+	fmt.Printf("  Todos: %s → %s\r\n", summarizeTodos(diff.TodosFrom), summarizeTodos(diff.TodosTo))
+	if len(diff.RevisionsAdded) > 0 {
+		fmt.Printf("  Revisions added: %s\r\n", strings.Join(diff.RevisionsAdded, ", "))
+	}
+	if len(diff.RevisionsRemoved) > 0 {
+		fmt.Printf("  Revisions removed: %s\r\n", strings.Join(diff.RevisionsRemoved, ", "))
+	}
+	if len(diff.Files) == 0 {
+		fmt.Print("  Files: no differences\r\n")
+		return
+	}
+
+	root := chatAgent.GetWorkspaceRoot()
+	for _, file := range diff.Files {
+		name := file.Path
+		if rel, err := filepath.Rel(root, file.Path); err == nil && !strings.HasPrefix(rel, "..") {
+			name = rel
+		}
+		switch {
+		case !file.Before.Exists:
+			fmt.Printf("\r\n--- %s (created) ---\r\n", name)
+		case !file.After.Exists:
+			fmt.Printf("\r\n--- %s (deleted) ---\r\n", name)
+		default:
+			fmt.Printf("\r\n--- %s ---\r\n", name)
+		}
+		fmt.Print(history.GetDiff(name, file.Before.Content, file.After.Content) + "\r\n")
+	}
+}
+
+// summarizeTodos renders a todo list as "3 (1 done)".
+func summarizeTodos(todos []tools.TodoItem) string {
+	done := 0
+	for _, todo := range todos {
+		if todo.Status == "completed" {
+			done++
+		}
+	}
+	return fmt.Sprintf("%d (%d done)", len(todos), done)
+}
+
+func printCheckpointHelp() {
+	fmt.Print(`[checkpoint] Checkpoints and branches
+  /checkpoint [name]           Snapshot conversation, todos, revisions and modified files
+  /checkpoint list             List checkpoints
+  /checkpoint diff <a> [b]     Compare two checkpoints (b defaults to the current state)
+  /branch                      List branches (* marks the current one)
+  /branch <name> [checkpoint]  Start a branch from a checkpoint (default: latest on this branch)
+  /branch switch <name>        Save the current state and switch to another branch
+`)
+}
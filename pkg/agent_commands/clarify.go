@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/alantheprice/ledit/pkg/agent"
+	"github.com/alantheprice/ledit/pkg/configuration"
+)
+
+// ClarifyCommand implements the /clarify slash command.
+type ClarifyCommand struct{}
+
+func (c *ClarifyCommand) Name() string {
+	return "clarify"
+}
+
+func (c *ClarifyCommand) Description() string {
+	return "Show or toggle asking clarifying questions about ambiguous requests: on, off"
+}
+
+func (c *ClarifyCommand) Execute(args []string, chatAgent *agent.Agent) error {
+	if chatAgent == nil {
+		return errors.New("agent is not initialized")
+	}
+
+	configManager := chatAgent.GetConfigManager()
+	cfg := configManager.GetConfig()
+	if cfg == nil {
+		return errors.New("configuration is not initialized")
+	}
+
+	if len(args) == 0 {
+		settings := cfg.GetClarification()
+		state := "off"
+		if settings.Enabled {
+			state = "on"
+		}
+		fmt.Printf("\nClarification: %s (threshold %.2f, up to %d questions, requests of %d+ words)\n",
+			state, settings.Threshold, settings.MaxQuestions, settings.MinWords)
+		fmt.Println("Usage: /clarify <on|off>")
+		fmt.Println("  on: score each request for ambiguity and ask clarifying questions above the threshold")
+		fmt.Println("  off: start work on requests as given")
+		return nil
+	}
+	if len(args) > 1 {
+		return errors.New("usage: /clarify <on|off>")
+	}
+
+	var enabled bool
+	switch strings.ToLower(strings.TrimSpace(args[0])) {
+	case "on", "true", "enable":
+		enabled = true
+	case "off", "false", "disable":
+		enabled = false
+	default:
+		return fmt.Errorf("unknown clarification mode %q; use on or off", args[0])
+	}
+
+	if err := configManager.UpdateConfig(func(c *configuration.Config) error {
+		if c.Clarification == nil {
+			c.Clarification = &configuration.ClarificationConfig{}
+		}
+		c.Clarification.Enabled = enabled
+		return nil
+	}); err != nil {
+		return fmt.Errorf("clarify set mode: %w", err)
+	}
+
+	if enabled {
+		fmt.Println("[OK] Clarification enabled: ambiguous requests get up to a few questions before work starts.")
+	} else {
+		fmt.Println("[OK] Clarification disabled.")
+	}
+	return nil
+}
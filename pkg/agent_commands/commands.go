@@ -60,6 +60,7 @@ func NewCommandRegistry() *CommandRegistry {
 	registry.Register(&CommitCommand{})
 	registry.Register(&ExecCommand{})
 	registry.Register(&ShellCommand{})
+	registry.Register(&AttachCommand{})
 	registry.Register(&StatsCommand{})
 
 	// Register subagent configuration commands
@@ -73,21 +74,70 @@ func NewCommandRegistry() *CommandRegistry {
 
 	// Register change tracking commands
 	registry.Register(&ChangesCommand{})
+	registry.Register(&DiffCommand{})
 	registry.Register(&StatusCommand{})
 	registry.Register(&LogCommand{})
 	registry.Register(&RollbackCommand{})
+	registry.Register(&CheckpointCommand{})
+	registry.Register(&BranchCommand{})
+	registry.Register(&TodosCommand{})
+	registry.Register(&PRCommand{})
+	registry.Register(&ResolveCommand{})
 
 	// Register MCP commands
 	registry.Register(&MCPCommand{})
 
+	// Register tool policy command
+	registry.Register(&PolicyCommand{})
+
 	// Register code review command
 	registry.Register(&ReviewCommand{})
 	registry.Register(&ReviewDeepCommand{})
 	registry.Register(&SelfReviewCommand{})
 	registry.Register(&SelfReviewGateCommand{})
+	registry.Register(&ClarifyCommand{})
+
+	// Register test-driven repair loop
+	registry.Register(&FixTestsCommand{})
 
-	// Register compaction command
+	// Register interactive plan-and-execute workflow
+	registry.Register(&PlanCommand{})
+
+	// Register compaction and context inspection commands
 	registry.Register(&CompactCommand{})
+	registry.Register(&ContextCommand{})
+
+	// Register background job commands
+	registry.Register(&BgCommand{})
+	registry.Register(&JobsCommand{})
+
+	// Register clipboard and transcript export commands
+	registry.Register(&CopyCommand{})
+	registry.Register(&ExportCommand{})
+
+	// Register quick-open for files mentioned in the output
+	registry.Register(&OpenCommand{})
+
+	// Register console theme command
+	registry.Register(&ThemeCommand{})
+
+	// Register dry-run toggle
+	registry.Register(&DryRunCommand{})
+
+	// Register instruction file listing
+	registry.Register(&InstructionsCommand{})
+
+	// Register system prompt layer inspection and overrides
+	registry.Register(&PromptCommand{})
+
+	// Register long-term memory management
+	registry.Register(&MemoryCommand{})
+
+	// Register whole-workspace snapshots
+	registry.Register(&SnapshotCommand{})
+
+	// Register user-defined commands from ~/.ledit/commands and .ledit/commands
+	registry.registerCustomCommands()
 
 	return registry
 }
@@ -5,17 +5,24 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 
 	"github.com/alantheprice/ledit/pkg/agent"
 	api "github.com/alantheprice/ledit/pkg/agent_api"
 	"github.com/alantheprice/ledit/pkg/configuration"
+	"github.com/alantheprice/ledit/pkg/console"
 	"github.com/alantheprice/ledit/pkg/factory"
+	"github.com/alantheprice/ledit/pkg/filediscovery"
 	gitops "github.com/alantheprice/ledit/pkg/git"
 	"github.com/alantheprice/ledit/pkg/security"
 	"github.com/alantheprice/ledit/pkg/utils"
 )
 
+// commitDiffPreviewLines is how much of the staged diff is shown before
+// asking for a commit message by hand.
+const commitDiffPreviewLines = 80
+
 // --- Output helpers ---
 
 // printf prints formatted output with proper newline handling
@@ -41,6 +48,10 @@ func (c *CommitCommand) parseFlags(args []string) []string {
 			c.dryRun = true
 		case "--allow-secrets":
 			c.allowSecrets = true
+		case "--conventional":
+			c.conventional = true
+		case "--pr-description", "--pr":
+			c.prDescription = true
 		default:
 			cleanArgs = append(cleanArgs, arg)
 		}
@@ -121,6 +132,7 @@ func (c *CommitCommand) Execute(args []string, chatAgent *agent.Agent) error {
 	// Default behavior: use new interactive commit flow with flags
 	flow := NewCommitFlowWithFlags(chatAgent, c.skipPrompt, c.dryRun, c.allowSecrets)
 	flow.SetUserInstructions(c.userInstructions)
+	flow.SetMessageOptions(c.conventional, c.prDescription)
 	return flow.Execute()
 }
 
@@ -135,6 +147,7 @@ func (c *CommitCommand) ExecuteWithJSONOutput(args []string, chatAgent *agent.Ag
 	// Run commit flow
 	flow := NewCommitFlowWithFlags(chatAgent, c.skipPrompt, c.dryRun, c.allowSecrets)
 	flow.SetUserInstructions(c.userInstructions)
+	flow.SetMessageOptions(c.conventional, c.prDescription)
 	if err := flow.Execute(); err != nil {
 		result := CommitJSONResult{
 			Status: CommitStatusError,
@@ -311,7 +324,14 @@ func (c *CommitCommand) showHelp() error {
 	fmt.Println("/commit          - Interactive commit workflow for staged files")
 	fmt.Println("/commit help     - Show this help message")
 	fmt.Println()
-	fmt.Println("The interactive workflow helps you commit staged files")
+	fmt.Println("Flags:")
+	fmt.Println("  --conventional    Write a Conventional Commit (type(scope): description)")
+	fmt.Println("  --pr-description  Also generate a pull request description")
+	fmt.Println("  --dry-run         Generate the message without committing")
+	fmt.Println("  --skip-prompt     Commit without confirmation")
+	fmt.Println()
+	fmt.Println("The interactive workflow helps you commit staged files. Set commit_style")
+	fmt.Println("to \"conventional\" in the config to always use Conventional Commits.")
 	fmt.Println()
 	return nil
 }
@@ -341,6 +361,13 @@ func (c *CommitCommand) generateAndCommit(chatAgent *agent.Agent, reader *bufio.
 		commitModel := cfg.GetCommitModel()
 		c.printf("Using provider: %s, model: %s for commit message generation\n", commitProvider, commitModel)
 	}
+	style := gitops.CommitStyleDefault
+	if cfg != nil {
+		style = cfg.GetCommitStyle()
+	}
+	if c.conventional {
+		style = gitops.CommitStyleConventional
+	}
 
 	// Get staged diff
 	diffOutput, err := exec.Command("git", "diff", "--staged").CombinedOutput()
@@ -414,6 +441,17 @@ func (c *CommitCommand) generateAndCommit(chatAgent *agent.Agent, reader *bufio.
 		}
 	}
 
+	messageOptions := gitops.CommitMessageOptions{
+		Diff:             string(diffOutput),
+		Branch:           branch,
+		FileChanges:      fileChanges,
+		UserInstructions: c.userInstructions,
+		Style:            style,
+	}
+	if style == gitops.CommitStyleConventional {
+		messageOptions.ProjectType = filediscovery.DetectProjectType(".")
+	}
+
 	var commitMessage string
 
 	// Retry loop for commit message generation (LLM if available, otherwise manual input)
@@ -422,12 +460,8 @@ retryLoop:
 		if client == nil {
 			// Manual fallback when LLM client isn't available
 			c.println("")
-			c.println("[receipt] Staged diff (truncated):")
-			preview := string(diffOutput)
-			if len(preview) > 2000 {
-				preview = preview[:2000] + "\n... (truncated)"
-			}
-			c.println(preview)
+			c.println("[receipt] Staged diff:")
+			c.printf("%s", console.NewDiffView(commitDiffPreviewLines).RenderPatch(string(diffOutput)))
 			c.println("")
 			c.println("[edit] Enter commit message (end with a blank line):")
 			var b strings.Builder
@@ -452,12 +486,7 @@ retryLoop:
 
 			break
 		}
-		result, err := gitops.GenerateCommitMessageFromStagedDiff(client, gitops.CommitMessageOptions{
-			Diff:             string(diffOutput),
-			Branch:           branch,
-			FileChanges:      fileChanges,
-			UserInstructions: c.userInstructions,
-		})
+		result, err := gitops.GenerateCommitMessageFromStagedDiff(client, messageOptions)
 		if err != nil {
 			return fmt.Errorf("failed to generate commit message: %w", err)
 		}
@@ -509,12 +538,17 @@ retryLoop:
 					{Label: "Approve", Value: "y"},
 					{Label: "Retry", Value: "r"},
 					{Label: "Edit", Value: "e"},
+					{Label: "View diff", Value: "d"},
 					{Label: "Cancel", Value: "n"},
 				}
 				c.println("-----------------------------\n")
 				prompt := "Proceed with commit?"
 
 				choice, err := chatAgent.PromptChoice(prompt, choices)
+				for err == nil && choice == "d" {
+					c.printf("%s", console.NewDiffView(0).RenderPatch(string(diffOutput)))
+					choice, err = chatAgent.PromptChoice(prompt, choices)
+				}
 				if err != nil {
 					return fmt.Errorf("confirmation failed: %w", err)
 				}
@@ -544,9 +578,15 @@ retryLoop:
 			} else {
 				// Confirmation with retry option via stdin
 				c.println("")
-				c.printf("Proceed with commit? (y/n/e to edit/r to retry): ")
+				c.printf("Proceed with commit? (y/n/e to edit/r to retry/d to view diff): ")
 				input, _ := reader.ReadString('\n')
 				input = strings.TrimSpace(strings.ToLower(input))
+				for input == "d" || input == "diff" {
+					c.printf("%s\n", console.NewDiffView(0).RenderPatch(string(diffOutput)))
+					c.printf("Proceed with commit? (y/n/e to edit/r to retry/d to view diff): ")
+					input, _ = reader.ReadString('\n')
+					input = strings.TrimSpace(strings.ToLower(input))
+				}
 
 				if input == "r" || input == "retry" {
 					c.println("Regenerating commit message...")
@@ -569,7 +609,7 @@ retryLoop:
 					c.println("Commit cancelled")
 					return nil
 				} else {
-					c.printf("Invalid option: %s. Please use y/n/e/r\n", input)
+					c.printf("Invalid option: %s. Please use y/n/e/r/d\n", input)
 					continue // Show the confirmation prompt again
 				}
 			}
@@ -583,6 +623,7 @@ retryLoop:
 		c.println("[search] Dry-run mode: Commit message generated successfully!")
 		c.println("[i] The commit was not created due to --dry-run flag")
 		c.println("[edit] To create the commit, run the command again without --dry-run")
+		c.generatePRDescription(client, messageOptions, commitMessage)
 		return nil
 	}
 
@@ -626,6 +667,41 @@ retryLoop:
 
 	c.println("[OK] Commit created successfully!")
 	c.printf("Output: %s\n", string(output))
+	c.generatePRDescription(client, messageOptions, commitMessage)
 
 	return nil
 }
+
+// prDescriptionFile is where --pr-description saves the generated description.
+var prDescriptionFile = filepath.Join(".ledit", "pr_description.md")
+
+// generatePRDescription prints a pull request description for the commit
+// when --pr-description is set and saves it to prDescriptionFile. Failures
+// are reported but do not fail the commit.
+func (c *CommitCommand) generatePRDescription(client api.ClientInterface, opts gitops.CommitMessageOptions, commitMessage string) {
+	if !c.prDescription {
+		return
+	}
+	if client == nil {
+		c.println("[WARN] Skipping PR description: no LLM client available")
+		return
+	}
+	c.println("")
+	c.println("[bot] Generating PR description...")
+	description, err := gitops.GeneratePullRequestDescription(client, opts, commitMessage)
+	if err != nil {
+		c.printf("[WARN] %v\n", err)
+		return
+	}
+	c.println("")
+	c.println(description)
+	c.println("")
+	if err := os.MkdirAll(filepath.Dir(prDescriptionFile), 0o755); err == nil {
+		err = os.WriteFile(prDescriptionFile, []byte(description+"\n"), 0o644)
+		if err == nil {
+			c.printf("[OK] PR description saved to %s\n", prDescriptionFile)
+			return
+		}
+	}
+	c.printf("[WARN] Failed to save PR description to %s\n", prDescriptionFile)
+}
@@ -23,6 +23,8 @@ type CommitFlow struct {
 	skipPrompt       bool
 	dryRun           bool
 	allowSecrets     bool
+	conventional     bool
+	prDescription    bool
 	userInstructions string
 }
 
@@ -50,6 +52,26 @@ func (cf *CommitFlow) SetUserInstructions(instructions string) {
 	cf.userInstructions = instructions
 }
 
+// SetMessageOptions forces Conventional Commits for this flow and enables
+// generating a pull request description after the commit.
+func (cf *CommitFlow) SetMessageOptions(conventional, prDescription bool) {
+	cf.conventional = conventional
+	cf.prDescription = prDescription
+}
+
+// commitCommand returns a CommitCommand carrying the flow's flags so the
+// shared generation logic honors them.
+func (cf *CommitFlow) commitCommand() *CommitCommand {
+	return &CommitCommand{
+		skipPrompt:       cf.skipPrompt,
+		dryRun:           cf.dryRun,
+		allowSecrets:     cf.allowSecrets,
+		conventional:     cf.conventional,
+		prDescription:    cf.prDescription,
+		userInstructions: cf.userInstructions,
+	}
+}
+
 func (cf *CommitFlow) printf(format string, args ...interface{}) {
 	fmt.Fprint(os.Stdout, normalizeNewlines(fmt.Sprintf(format, args...)))
 }
@@ -326,20 +348,13 @@ func (cf *CommitFlow) generateCommitMessageAndCommit() error {
 	cf.println("[bot] Generating commit message...")
 
 	// Create a temporary CommitCommand to reuse the existing logic
-	commitCmd := &CommitCommand{
-		skipPrompt:       cf.skipPrompt,
-		dryRun:           cf.dryRun,
-		allowSecrets:     cf.allowSecrets,
-		userInstructions: cf.userInstructions,
-	}
-	return commitCmd.generateAndCommit(cf.agent, nil)
+	return cf.commitCommand().generateAndCommit(cf.agent, nil)
 }
 
 // executeNonInteractive handles non-interactive mode (fallback)
 func (cf *CommitFlow) executeNonInteractive() error {
 	// Fallback to original commit logic for non-terminal environments
-	commitCmd := &CommitCommand{}
-	return commitCmd.executeMultiFileCommit(cf.agent)
+	return cf.commitCommand().executeMultiFileCommit(cf.agent)
 }
 
 // CommitStagedWithMessage commits staged files with an optional message or auto-generated message
@@ -18,6 +18,8 @@ type CommitCommand struct {
 	skipPrompt       bool
 	dryRun           bool
 	allowSecrets     bool
+	conventional     bool   // Use Conventional Commits regardless of the commit_style config
+	prDescription    bool   // Also generate a pull request description
 	agentError       error  // Store agent creation error for better error reporting
 	review           string // Store the commit review result
 	userInstructions string // User-provided instructions for the commit message
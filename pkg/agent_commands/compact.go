@@ -3,6 +3,7 @@ package commands
 import (
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/alantheprice/ledit/pkg/agent"
 )
@@ -17,7 +18,7 @@ func (c *CompactCommand) Name() string {
 
 // Description returns the command description
 func (c *CompactCommand) Description() string {
-	return "Force immediate context compaction to reduce token usage"
+	return "Compact older turns to reduce token usage, or pin facts/files that must survive compaction"
 }
 
 // Execute runs the compact command
@@ -25,41 +26,84 @@ func (c *CompactCommand) Execute(args []string, chatAgent *agent.Agent) error {
 	if chatAgent == nil {
 		return errors.New("agent not available")
 	}
-
-	// Check if there are turn checkpoints to compact
-	if !chatAgent.HasTurnCheckpoints() {
-		fmt.Println("\n[info] No turn checkpoints available for compaction.")
-		fmt.Println("       Compaction requires conversation history with multiple turns.")
-		return nil
+	if len(args) == 0 {
+		return c.compact(chatAgent)
 	}
 
-	// Get current message count before compaction
-	initialMessageCount := len(chatAgent.GetMessages())
-
-	// Force checkpoint compaction
-	compactedMessages, remainingCheckpoints := chatAgent.BuildCheckpointCompactedMessages(chatAgent.GetMessages())
+	subArgs := strings.TrimSpace(strings.Join(args[1:], " "))
+	switch args[0] {
+	case "pin":
+		if err := chatAgent.PinFact(subArgs); err != nil {
+			return fmt.Errorf("usage: /compact pin <fact>")
+		}
+		fmt.Printf("\n[pin] Pinned fact: %s\n", subArgs)
+	case "pin-file":
+		if err := chatAgent.PinFile(subArgs); err != nil {
+			return fmt.Errorf("usage: /compact pin-file <path>")
+		}
+		fmt.Printf("\n[pin] Pinned file: %s\n", subArgs)
+	case "unpin":
+		if subArgs == "all" {
+			chatAgent.ClearPins()
+			fmt.Println("\n[pin] Cleared all pins")
+			return nil
+		}
+		if !chatAgent.Unpin(subArgs) {
+			return fmt.Errorf("nothing pinned matches %q. Use '/compact pins' to list pins", subArgs)
+		}
+		fmt.Printf("\n[pin] Unpinned: %s\n", subArgs)
+	case "pins":
+		c.listPins(chatAgent)
+	case "help", "-h", "--help":
+		c.showHelp()
+	default:
+		return fmt.Errorf("unknown subcommand: %s. Use '/compact help' for usage", args[0])
+	}
+	return nil
+}
 
-	// Validate compaction result - ensure we actually reduced messages
-	if len(compactedMessages) >= initialMessageCount {
-		fmt.Println("\n[info] Compaction did not reduce message count.")
-		fmt.Println("       Checkpoints may already be applied or none available to compact.")
+func (c *CompactCommand) compact(chatAgent *agent.Agent) error {
+	result, ok := chatAgent.CompactHistory()
+	if !ok {
+		fmt.Println("\n[info] Nothing to compact.")
+		fmt.Println("       Compaction needs completed turns or a longer conversation history.")
 		return nil
 	}
 
-	// Update the agent's message list
-	chatAgent.SetMessages(compactedMessages)
-
-	// Update the remaining checkpoints
-	chatAgent.ReplaceTurnCheckpoints(remainingCheckpoints)
-
-	// Calculate the reduction
-	messageDiff := initialMessageCount - len(compactedMessages)
+	fmt.Printf("\n[compact] Context compaction complete (%s):\n", result.Method)
+	fmt.Printf("       Before: %d messages (~%d tokens)\n", result.BeforeMessages, result.BeforeTokens)
+	fmt.Printf("       After:  %d messages (~%d tokens)\n", result.AfterMessages, result.AfterTokens)
+	if maxTokens := chatAgent.GetMaxContextTokens(); maxTokens > 0 {
+		fmt.Printf("       Context: %.0f%% of %d tokens\n", float64(result.AfterTokens)/float64(maxTokens)*100, maxTokens)
+	}
+	return nil
+}
 
-	fmt.Println("\n[compact] Context compaction complete:")
-	fmt.Printf("       Before: %d messages\n", initialMessageCount)
-	fmt.Printf("       After:  %d messages\n", len(compactedMessages))
-	fmt.Printf("       Removed: %d messages\n", messageDiff)
-	fmt.Printf("       Remaining checkpoints: %d\n", len(remainingCheckpoints))
+func (c *CompactCommand) listPins(chatAgent *agent.Agent) {
+	facts, files := chatAgent.GetPins()
+	if len(facts) == 0 && len(files) == 0 {
+		fmt.Println("\n[pin] Nothing pinned. Use '/compact pin <fact>' or '/compact pin-file <path>'.")
+		return
+	}
+	fmt.Println("\n[pin] Pinned context:")
+	for _, fact := range facts {
+		fmt.Printf("       fact: %s\n", fact)
+	}
+	for _, file := range files {
+		fmt.Printf("       file: %s\n", file)
+	}
+}
 
-	return nil
+func (c *CompactCommand) showHelp() {
+	fmt.Println("Context Compaction")
+	fmt.Println("==================")
+	fmt.Println()
+	fmt.Println("Older turns are compacted automatically as the context window fills up.")
+	fmt.Println()
+	fmt.Println("Available subcommands:")
+	fmt.Println("  /compact                  - Compact older turns now")
+	fmt.Println("  /compact pin <fact>       - Keep a fact in context across compaction")
+	fmt.Println("  /compact pin-file <path>  - Keep a key file's latest read across compaction")
+	fmt.Println("  /compact pins             - List pinned facts and files")
+	fmt.Println("  /compact unpin <text|all> - Remove a pin")
 }
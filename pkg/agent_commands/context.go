@@ -0,0 +1,207 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/alantheprice/ledit/pkg/agent"
+)
+
+// contextHeatWidth is the width of the bar that shows a message's share of
+// the largest message.
+const contextHeatWidth = 10
+
+// contextTopFiles is how many files /context lists by token share.
+const contextTopFiles = 10
+
+// ContextCommand implements the /context slash command
+type ContextCommand struct{}
+
+// Name returns the command name
+func (c *ContextCommand) Name() string {
+	return "context"
+}
+
+// Description returns the command description
+func (c *ContextCommand) Description() string {
+	return "Inspect the conversation's token usage per message and file, and drop or pin messages"
+}
+
+// Execute runs the context command
+func (c *ContextCommand) Execute(args []string, chatAgent *agent.Agent) error {
+	if chatAgent == nil {
+		return errors.New("agent not available")
+	}
+	if len(args) == 0 {
+		c.show(chatAgent)
+		return nil
+	}
+
+	subArgs := strings.TrimSpace(strings.Join(args[1:], " "))
+	switch args[0] {
+	case "drop":
+		indexes, err := parseContextIndexes(subArgs)
+		if err != nil {
+			return fmt.Errorf("usage: /context drop <n>[,<n>|<n>-<m>...]: %w", err)
+		}
+		removed, cleared, err := chatAgent.DropContextMessages(indexes)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("\n[context] Removed %d message(s), cleared %d tool result(s)\n", removed, cleared)
+		entries, _ := chatAgent.InspectContext()
+		fmt.Printf("       Conversation now ~%s tokens\n", formatTokenCount(contextTokens(entries)))
+	case "pin":
+		index, err := parseContextIndex(subArgs)
+		if err != nil {
+			return fmt.Errorf("usage: /context pin <n>: %w", err)
+		}
+		pinned, err := chatAgent.PinContextMessage(index)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("\n[pin] Pinned %s (message %d)\n", pinned, index+1)
+	case "unpin":
+		index, err := parseContextIndex(subArgs)
+		if err != nil {
+			return fmt.Errorf("usage: /context unpin <n>: %w", err)
+		}
+		if err := chatAgent.UnpinContextMessage(index); err != nil {
+			return err
+		}
+		fmt.Printf("\n[pin] Unpinned message %d\n", index+1)
+	case "help", "-h", "--help":
+		c.showHelp()
+	default:
+		return fmt.Errorf("unknown subcommand: %s. Use '/context help' for usage", args[0])
+	}
+	return nil
+}
+
+func (c *ContextCommand) show(chatAgent *agent.Agent) {
+	entries, files := chatAgent.InspectContext()
+	if len(entries) == 0 {
+		fmt.Println("\n[context] The conversation is empty.")
+		return
+	}
+
+	total, largest := contextTokens(entries), 0
+	for _, entry := range entries {
+		if entry.Tokens > largest {
+			largest = entry.Tokens
+		}
+	}
+
+	fmt.Printf("\n[context] %d message(s), ~%s tokens", len(entries), formatTokenCount(total))
+	if maxTokens := chatAgent.GetMaxContextTokens(); maxTokens > 0 {
+		fmt.Printf(" (%.0f%% of %s)", float64(total)/float64(maxTokens)*100, formatTokenCount(maxTokens))
+	}
+	fmt.Println()
+	fmt.Printf("\n%4s  %-9s %7s  %-*s %-7s %-3s %s\n", "#", "ROLE", "TOKENS", contextHeatWidth, "", "AGE", "PIN", "PREVIEW")
+	for _, entry := range entries {
+		pin := ""
+		if entry.Pinned {
+			pin = "yes"
+		}
+		fmt.Printf("%4d  %-9s %7s  %-*s %-7s %-3s %s\n", entry.Index+1, entry.Role, formatTokenCount(entry.Tokens),
+			contextHeatWidth, contextHeatBar(entry.Tokens, largest), formatContextAge(entry.Age), pin, entry.Preview)
+	}
+
+	if len(files) > 0 {
+		fmt.Println("\nFiles by token share:")
+		for i, file := range files {
+			if i == contextTopFiles {
+				fmt.Printf("       ... and %d more\n", len(files)-contextTopFiles)
+				break
+			}
+			fmt.Printf("%7s  %3.0f%%  %s (%d message(s))\n", formatTokenCount(file.Tokens),
+				float64(file.Tokens)/float64(total)*100, file.Path, file.Messages)
+		}
+	}
+	fmt.Println("\nUse '/context drop <n>' to remove messages or '/context pin <n>' to keep one across compaction.")
+}
+
+func (c *ContextCommand) showHelp() {
+	fmt.Println("Context Inspector")
+	fmt.Println("=================")
+	fmt.Println()
+	fmt.Println("Lists every message in the conversation with its role, estimated tokens,")
+	fmt.Println("age in user turns and pin state, then the files whose contents use the most tokens.")
+	fmt.Println()
+	fmt.Println("Available subcommands:")
+	fmt.Println("  /context                        - Show messages and the files that dominate the context")
+	fmt.Println("  /context drop <n>[,<n>|<n>-<m>] - Remove messages; dropping a tool call removes its results")
+	fmt.Println("  /context pin <n>                - Keep a message (or a file read's file) across compaction")
+	fmt.Println("  /context unpin <n>              - Remove the pin covering a message")
+}
+
+func contextTokens(entries []agent.ContextEntry) int {
+	total := 0
+	for _, entry := range entries {
+		total += entry.Tokens
+	}
+	return total
+}
+
+// contextHeatBar renders tokens as a share of largest.
+func contextHeatBar(tokens, largest int) string {
+	if largest <= 0 || tokens <= 0 {
+		return ""
+	}
+	width := tokens * contextHeatWidth / largest
+	if width == 0 {
+		width = 1
+	}
+	return strings.Repeat("█", width)
+}
+
+func formatContextAge(turns int) string {
+	switch turns {
+	case 0:
+		return "now"
+	case 1:
+		return "1 turn"
+	default:
+		return fmt.Sprintf("%d turns", turns)
+	}
+}
+
+// parseContextIndex parses a 1-based message number into an index.
+func parseContextIndex(arg string) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(arg))
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid message number %q", arg)
+	}
+	return n - 1, nil
+}
+
+// parseContextIndexes parses 1-based message numbers and ranges such as
+// "3,5-7" into indexes.
+func parseContextIndexes(arg string) ([]int, error) {
+	var indexes []int
+	for _, part := range strings.FieldsFunc(arg, func(r rune) bool { return r == ',' || r == ' ' }) {
+		from, to, isRange := strings.Cut(part, "-")
+		start, err := parseContextIndex(from)
+		if err != nil {
+			return nil, err
+		}
+		end := start
+		if isRange {
+			if end, err = parseContextIndex(to); err != nil {
+				return nil, err
+			}
+			if end < start {
+				return nil, fmt.Errorf("invalid range %q", part)
+			}
+		}
+		for i := start; i <= end; i++ {
+			indexes = append(indexes, i)
+		}
+	}
+	if len(indexes) == 0 {
+		return nil, errors.New("no message numbers given")
+	}
+	return indexes, nil
+}
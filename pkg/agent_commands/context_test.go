@@ -0,0 +1,34 @@
+package commands
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseContextIndexes(t *testing.T) {
+	indexes, err := parseContextIndexes("3, 5-7,1")
+	if err != nil {
+		t.Fatalf("parseContextIndexes: %v", err)
+	}
+	if want := []int{2, 4, 5, 6, 0}; !reflect.DeepEqual(indexes, want) {
+		t.Fatalf("got %v, want %v", indexes, want)
+	}
+
+	for _, arg := range []string{"", "0", "x", "4-2", "2-"} {
+		if _, err := parseContextIndexes(arg); err == nil {
+			t.Errorf("expected %q to be rejected", arg)
+		}
+	}
+}
+
+func TestContextHeatBar(t *testing.T) {
+	if got := contextHeatBar(100, 100); got != "██████████" {
+		t.Errorf("expected a full bar, got %q", got)
+	}
+	if got := contextHeatBar(1, 1000); got != "█" {
+		t.Errorf("expected small messages to show one block, got %q", got)
+	}
+	if got := contextHeatBar(0, 1000); got != "" {
+		t.Errorf("expected an empty bar, got %q", got)
+	}
+}
@@ -0,0 +1,111 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/alantheprice/ledit/pkg/agent"
+	api "github.com/alantheprice/ledit/pkg/agent_api"
+	"github.com/alantheprice/ledit/pkg/console"
+)
+
+// CopyCommand copies code blocks from the last answer to the clipboard.
+type CopyCommand struct{}
+
+// Name returns the command name
+func (c *CopyCommand) Name() string {
+	return "copy"
+}
+
+// Description returns the command description
+func (c *CopyCommand) Description() string {
+	return "Copy a code block from the last answer to the clipboard: /copy [n|list]"
+}
+
+// Execute runs the copy command
+func (c *CopyCommand) Execute(args []string, chatAgent *agent.Agent) error {
+	if chatAgent == nil {
+		return fmt.Errorf("agent not available")
+	}
+	answer, ok := lastAnswer(chatAgent.GetMessages())
+	if !ok {
+		return fmt.Errorf("no answer to copy from yet")
+	}
+	blocks := console.CodeBlocks(answer)
+
+	if len(args) > 0 {
+		switch strings.ToLower(args[0]) {
+		case "help", "-h", "--help":
+			fmt.Print(normalizeNewlines(`Usage:
+  /copy          Copy the last code block of the last answer
+  /copy <n>      Copy the n-th code block (1 is the first)
+  /copy list     Number the code blocks of the last answer
+  /copy all      Copy the whole last answer
+`))
+			return nil
+		case "list", "ls":
+			printCodeBlocks(blocks)
+			return nil
+		case "all":
+			return copyText(answer, "the last answer")
+		}
+	}
+
+	if len(blocks) == 0 {
+		return fmt.Errorf("the last answer has no code blocks (use /copy all to copy the whole answer)")
+	}
+	index := len(blocks)
+	if len(args) > 0 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 1 || n > len(blocks) {
+			return fmt.Errorf("code block must be between 1 and %d (see /copy list)", len(blocks))
+		}
+		index = n
+	}
+	block := blocks[index-1]
+	return copyText(block.Code+"\n", fmt.Sprintf("code block %d of %d%s", index, len(blocks), languageSuffix(block.Language)))
+}
+
+// lastAnswer returns the text of the most recent assistant message that has
+// any, skipping tool-call-only turns.
+func lastAnswer(messages []api.Message) (string, bool) {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "assistant" && strings.TrimSpace(messages[i].Content) != "" {
+			return messages[i].Content, true
+		}
+	}
+	return "", false
+}
+
+func copyText(text, what string) error {
+	backend, err := console.CopyToClipboard(text)
+	if err != nil {
+		return err
+	}
+	lines := strings.Count(strings.TrimRight(text, "\n"), "\n") + 1
+	fmt.Printf("[copy] Copied %s (%d lines) via %s\r\n", what, lines, backend)
+	return nil
+}
+
+func printCodeBlocks(blocks []console.CodeBlock) {
+	if len(blocks) == 0 {
+		fmt.Printf("[copy] The last answer has no code blocks\r\n")
+		return
+	}
+	for i, block := range blocks {
+		first := strings.TrimSpace(strings.SplitN(block.Code, "\n", 2)[0])
+		if runes := []rune(first); len(runes) > 60 {
+			first = string(runes[:57]) + "..."
+		}
+		lines := strings.Count(block.Code, "\n") + 1
+		fmt.Printf("  %d. %d lines%s: %s\r\n", i+1, lines, languageSuffix(block.Language), first)
+	}
+}
+
+func languageSuffix(language string) string {
+	if language == "" {
+		return ""
+	}
+	return " (" + language + ")"
+}
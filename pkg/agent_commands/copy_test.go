@@ -0,0 +1,24 @@
+package commands
+
+import (
+	"testing"
+
+	api "github.com/alantheprice/ledit/pkg/agent_api"
+)
+
+func TestLastAnswerSkipsToolCallTurns(t *testing.T) {
+	messages := []api.Message{
+		{Role: "user", Content: "write a script"},
+		{Role: "assistant", Content: "```sh\necho hi\n```"},
+		{Role: "user", Content: "now run it"},
+		{Role: "assistant", Content: ""},
+		{Role: "tool", Content: "hi"},
+	}
+	answer, ok := lastAnswer(messages)
+	if !ok || answer != "```sh\necho hi\n```" {
+		t.Fatalf("lastAnswer = %q, %v", answer, ok)
+	}
+	if _, ok := lastAnswer(messages[:1]); ok {
+		t.Fatal("expected no answer before the assistant replies")
+	}
+}
@@ -0,0 +1,271 @@
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/alantheprice/ledit/pkg/agent"
+	"github.com/alantheprice/ledit/pkg/configuration"
+	"gopkg.in/yaml.v3"
+)
+
+// CustomCommandsDir holds a workspace's custom slash commands, relative to
+// the workspace root. User-wide commands live in "commands" under the ledit
+// config directory.
+var CustomCommandsDir = filepath.Join(".ledit", "commands")
+
+// templatePlaceholder matches $ARGUMENTS and the positional $1..$9.
+var templatePlaceholder = regexp.MustCompile(`\$(ARGUMENTS|[1-9])`)
+
+// PromptTemplateCommand is a custom command defined by a Markdown prompt
+// template. Running it sends the template, with the arguments substituted,
+// to the agent.
+type PromptTemplateCommand struct {
+	name string
+	path string
+	// description and argumentHint come from the file's YAML frontmatter.
+	description  string
+	argumentHint string
+}
+
+// promptTemplateFrontmatter is the optional frontmatter of a template.
+type promptTemplateFrontmatter struct {
+	Description  string `yaml:"description"`
+	ArgumentHint string `yaml:"argument-hint"`
+}
+
+// Name returns the command name
+func (c *PromptTemplateCommand) Name() string {
+	return c.name
+}
+
+// Description returns the command description
+func (c *PromptTemplateCommand) Description() string {
+	return customCommandDescription(c.name, c.path, c.description, c.argumentHint)
+}
+
+// Execute runs the prompt template
+func (c *PromptTemplateCommand) Execute(args []string, chatAgent *agent.Agent) error {
+	if chatAgent == nil {
+		return fmt.Errorf("/%s needs an active agent", c.name)
+	}
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", c.path, err)
+	}
+	_, body, err := parsePromptTemplate(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", c.path, err)
+	}
+	prompt := expandPromptTemplate(body, args)
+	if strings.TrimSpace(prompt) == "" {
+		return fmt.Errorf("%s has an empty prompt", c.path)
+	}
+	fmt.Printf("[cmd] Running /%s from %s\r\n", c.name, c.path)
+	_, err = chatAgent.ProcessQuery(prompt)
+	return err
+}
+
+// parsePromptTemplate splits a template into its frontmatter and body.
+func parsePromptTemplate(data []byte) (promptTemplateFrontmatter, string, error) {
+	var meta promptTemplateFrontmatter
+	content := strings.ReplaceAll(string(data), "\r\n", "\n")
+	if !strings.HasPrefix(content, "---\n") {
+		return meta, content, nil
+	}
+	end := strings.Index(content[4:], "\n---")
+	if end < 0 {
+		return meta, content, nil
+	}
+	if err := yaml.Unmarshal([]byte(content[4:4+end]), &meta); err != nil {
+		return meta, "", err
+	}
+	return meta, strings.TrimPrefix(content[4+end+len("\n---"):], "\n"), nil
+}
+
+// expandPromptTemplate substitutes $ARGUMENTS with all the arguments and $1
+// to $9 with the positional ones; missing ones become empty. A template
+// without placeholders gets the arguments appended instead.
+func expandPromptTemplate(body string, args []string) string {
+	if !templatePlaceholder.MatchString(body) {
+		if len(args) == 0 {
+			return body
+		}
+		return strings.TrimRight(body, "\n") + "\n\n" + strings.Join(args, " ")
+	}
+	return templatePlaceholder.ReplaceAllStringFunc(body, func(match string) string {
+		if match == "$ARGUMENTS" {
+			return strings.Join(args, " ")
+		}
+		if i := int(match[1] - '1'); i < len(args) {
+			return args[i]
+		}
+		return ""
+	})
+}
+
+// ScriptCommand is a custom command backed by a shell script, run with the
+// command's arguments in the workspace root.
+type ScriptCommand struct {
+	name        string
+	path        string
+	description string
+}
+
+// Name returns the command name
+func (c *ScriptCommand) Name() string {
+	return c.name
+}
+
+// Description returns the command description
+func (c *ScriptCommand) Description() string {
+	return customCommandDescription(c.name, c.path, c.description, "")
+}
+
+// Execute runs the script
+func (c *ScriptCommand) Execute(args []string, chatAgent *agent.Agent) error {
+	display := strings.TrimSpace(c.path + " " + strings.Join(args, " "))
+	if chatAgent != nil && chatAgent.DryRun() {
+		fmt.Printf("[dry-run] Would run %s\r\n", display)
+		return nil
+	}
+
+	cmd := exec.Command("sh", append([]string{c.path}, args...)...)
+	cmd.Dir = memoryProjectRoot(chatAgent)
+	cmd.Stdin = os.Stdin
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	fmt.Printf("[cmd] Running %s\r\n", display)
+	err := cmd.Run()
+	if output.Len() > 0 {
+		fmt.Print(normalizeNewlines(strings.TrimRight(output.String(), "\n") + "\n"))
+	}
+	if err != nil {
+		return fmt.Errorf("/%s failed: %w", c.name, err)
+	}
+	return nil
+}
+
+// scriptDescription returns a script's "# description:" line, or else the
+// first comment after the shebang.
+func scriptDescription(path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	first := ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "#!") || line == "" || line == "#" {
+			continue
+		}
+		if !strings.HasPrefix(line, "#") {
+			break
+		}
+		comment := strings.TrimSpace(strings.TrimPrefix(line, "#"))
+		if strings.HasPrefix(strings.ToLower(comment), "description:") {
+			return strings.TrimSpace(comment[len("description:"):])
+		}
+		if first == "" {
+			first = comment
+		}
+	}
+	return first
+}
+
+func customCommandDescription(name, path, description, argumentHint string) string {
+	if description == "" {
+		description = "Custom command from " + path
+	}
+	if argumentHint != "" {
+		description += fmt.Sprintf(" (usage: /%s %s)", name, argumentHint)
+	}
+	return description
+}
+
+// LoadCustomCommands loads the *.md prompt templates and *.sh scripts in
+// dirs. A command in a later directory replaces one with the same name in
+// an earlier one. Missing directories are skipped.
+func LoadCustomCommands(dirs ...string) []Command {
+	byName := make(map[string]Command)
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			ext := filepath.Ext(entry.Name())
+			name := strings.TrimSuffix(entry.Name(), ext)
+			if name == "" || !isLikelySlashCommandName(name) {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			switch ext {
+			case ".md":
+				data, err := os.ReadFile(path)
+				if err != nil {
+					continue
+				}
+				// A broken frontmatter is reported when the command runs.
+				meta, _, _ := parsePromptTemplate(data)
+				byName[name] = &PromptTemplateCommand{
+					name:         name,
+					path:         path,
+					description:  strings.TrimSpace(meta.Description),
+					argumentHint: strings.TrimSpace(meta.ArgumentHint),
+				}
+			case ".sh":
+				byName[name] = &ScriptCommand{name: name, path: path, description: scriptDescription(path)}
+			}
+		}
+	}
+
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	commands := make([]Command, 0, len(names))
+	for _, name := range names {
+		commands = append(commands, byName[name])
+	}
+	return commands
+}
+
+// customCommandDirs returns the user and workspace command directories, in
+// increasing precedence.
+func customCommandDirs() []string {
+	var dirs []string
+	if configDir, err := configuration.GetConfigDir(); err == nil {
+		dirs = append(dirs, filepath.Join(configDir, "commands"))
+	}
+	if cwd, err := os.Getwd(); err == nil {
+		dirs = append(dirs, filepath.Join(cwd, CustomCommandsDir))
+	}
+	return dirs
+}
+
+// registerCustomCommands registers the user's custom commands. They cannot
+// replace built-in commands.
+func (r *CommandRegistry) registerCustomCommands() {
+	for _, cmd := range LoadCustomCommands(customCommandDirs()...) {
+		if _, exists := r.commands[cmd.Name()]; exists {
+			continue
+		}
+		r.Register(cmd)
+	}
+}
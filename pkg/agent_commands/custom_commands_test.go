@@ -0,0 +1,109 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeCommandFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadCustomCommands(t *testing.T) {
+	userDir := t.TempDir()
+	projectDir := t.TempDir()
+	writeCommandFile(t, userDir, "greet.md", "Say hello")
+	writeCommandFile(t, userDir, "deploy.sh", "#!/bin/sh\n# description: Deploy the app\necho deploying\n")
+	writeCommandFile(t, projectDir, "greet.md", "---\ndescription: Greet someone\nargument-hint: <name>\n---\nSay hello to $1\n")
+	writeCommandFile(t, projectDir, "notes.txt", "ignored")
+	writeCommandFile(t, projectDir, "bad name.md", "ignored")
+
+	commands := LoadCustomCommands(userDir, projectDir, filepath.Join(projectDir, "missing"))
+	if len(commands) != 2 {
+		t.Fatalf("expected 2 commands, got %d", len(commands))
+	}
+	if commands[0].Name() != "deploy" || commands[0].Description() != "Deploy the app" {
+		t.Errorf("unexpected script command %q: %q", commands[0].Name(), commands[0].Description())
+	}
+	greet, ok := commands[1].(*PromptTemplateCommand)
+	if !ok || greet.path != filepath.Join(projectDir, "greet.md") {
+		t.Fatalf("expected the project greet template to win, got %#v", commands[1])
+	}
+	if got, want := greet.Description(), "Greet someone (usage: /greet <name>)"; got != want {
+		t.Errorf("Description() = %q, want %q", got, want)
+	}
+}
+
+func TestParsePromptTemplate(t *testing.T) {
+	meta, body, err := parsePromptTemplate([]byte("---\r\ndescription: Review\r\n---\r\nReview $ARGUMENTS\r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Description != "Review" || body != "Review $ARGUMENTS\n" {
+		t.Errorf("got %q / %q", meta.Description, body)
+	}
+
+	_, body, err = parsePromptTemplate([]byte("No frontmatter\n---\n"))
+	if err != nil || body != "No frontmatter\n---\n" {
+		t.Errorf("expected the whole file as body, got %q (%v)", body, err)
+	}
+
+	if _, _, err := parsePromptTemplate([]byte("---\ndescription: [unclosed\n---\nbody")); err == nil {
+		t.Error("expected an error for invalid frontmatter")
+	}
+}
+
+func TestExpandPromptTemplate(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		args []string
+		want string
+	}{
+		{"all arguments", "Fix $ARGUMENTS now", []string{"the", "bug"}, "Fix the bug now"},
+		{"positional", "Move $1 to $2", []string{"a.go", "b.go"}, "Move a.go to b.go"},
+		{"missing positional", "Compare $1 and $2", []string{"x"}, "Compare x and "},
+		{"no placeholders", "Explain this repo\n", []string{"briefly"}, "Explain this repo\n\nbriefly"},
+		{"no placeholders or args", "Explain this repo\n", nil, "Explain this repo\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := expandPromptTemplate(tt.body, tt.args); got != tt.want {
+				t.Errorf("expandPromptTemplate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCustomCommandsRegistered(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv("LEDIT_CONFIG", configDir)
+	workspace := t.TempDir()
+	t.Chdir(workspace)
+	writeCommandFile(t, filepath.Join(configDir, "commands"), "summarize.md", "Summarize $ARGUMENTS")
+	writeCommandFile(t, filepath.Join(workspace, CustomCommandsDir), "help.md", "Not the built-in help")
+	writeCommandFile(t, filepath.Join(workspace, CustomCommandsDir), "touch-marker.sh", "# Create a marker file\ntouch \"marker-$1\"\n")
+
+	registry := NewCommandRegistry()
+	if _, ok := registry.GetCommand("summarize"); !ok {
+		t.Error("expected the user command to be registered")
+	}
+	if cmd, _ := registry.GetCommand("help"); cmd == nil || strings.HasPrefix(cmd.Description(), "Custom command") {
+		t.Error("a custom command must not replace a built-in")
+	}
+
+	if err := registry.Execute("/touch-marker one", nil); err != nil {
+		t.Fatalf("script command failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(workspace, "marker-one")); err != nil {
+		t.Errorf("expected the script to run in the workspace: %v", err)
+	}
+}
@@ -0,0 +1,243 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/alantheprice/ledit/pkg/agent"
+	"github.com/alantheprice/ledit/pkg/console"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// DiffCommand shows every file the agent changed this session as one diff
+// against the content before its first change.
+type DiffCommand struct{}
+
+// Name returns the command name
+func (c *DiffCommand) Name() string {
+	return "diff"
+}
+
+// Description returns the command description
+func (c *DiffCommand) Description() string {
+	return "Show all file changes of this session as one diff: /diff [--stat] [--split|--unified] [--patch [path]]"
+}
+
+// sessionFileDiff is the unified diff of one changed file.
+type sessionFileDiff struct {
+	Name       string
+	Status     string // "created", "deleted" or "modified"
+	Patch      string
+	Insertions int
+	Deletions  int
+	Binary     bool
+}
+
+// Execute runs the diff command
+func (c *DiffCommand) Execute(args []string, chatAgent *agent.Agent) error {
+	if chatAgent == nil {
+		return fmt.Errorf("agent not available")
+	}
+
+	if len(args) > 0 && strings.ToLower(args[0]) == "layout" {
+		return setDiffLayout(args[1:])
+	}
+
+	statOnly, writePatch, path := false, false, ""
+	view := console.NewDiffView(0)
+	for _, arg := range args {
+		switch strings.ToLower(arg) {
+		case "help", "-h", "--help":
+			fmt.Print(normalizeNewlines(`Usage:
+  /diff [--stat] [--split|--unified] [--patch [path]]
+  /diff layout [unified|split]
+
+Shows every file changed through the agent's file tools this session,
+diffed against its content before the first change, with the number of
+files changed, insertions and deletions. --stat shows only the counts.
+--split shows the old and new versions side by side and --unified one
+above the other; /diff layout sets the default for the session, which
+also applies to edit previews and the commit flow. Terminals narrower
+than 100 columns always get the unified layout.
+--patch also writes the diff to a patch file that git apply accepts; the
+path defaults to ledit-session-<time>.patch in the workspace.
+`))
+			return nil
+		case "--stat":
+			statOnly = true
+		case "--split", "--side-by-side":
+			view.Layout = console.DiffSplit
+		case "--unified":
+			view.Layout = console.DiffUnified
+		case "--patch", "-o", "--output":
+			writePatch = true
+		default:
+			if !writePatch || path != "" {
+				return fmt.Errorf("usage: /diff [--stat] [--split|--unified] [--patch [path]]")
+			}
+			path = arg
+		}
+	}
+
+	root := chatAgent.GetWorkspaceRoot()
+	diffs := buildSessionDiffs(root, chatAgent.SessionDiff())
+	if len(diffs) == 0 {
+		fmt.Print("[diff] No files have changed this session\r\n")
+		return nil
+	}
+
+	if !statOnly {
+		for _, d := range diffs {
+			printSessionFileDiff(d, view)
+		}
+		fmt.Print("\r\n")
+	}
+	printSessionDiffStat(diffs)
+
+	if !writePatch {
+		return nil
+	}
+	if path == "" {
+		path = "ledit-session-" + time.Now().Format("20060102-150405") + ".patch"
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	var patch strings.Builder
+	for _, d := range diffs {
+		patch.WriteString(d.Patch)
+	}
+	if err := os.WriteFile(path, []byte(patch.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write patch: %w", err)
+	}
+	fmt.Printf("[diff] Patch (%d files) written to %s\r\n", len(diffs), path)
+	return nil
+}
+
+// buildSessionDiffs renders each changed file as a git-style unified diff,
+// with paths relative to root.
+func buildSessionDiffs(root string, files []agent.FileDiff) []sessionFileDiff {
+	diffs := make([]sessionFileDiff, 0, len(files))
+	for _, file := range files {
+		name := file.Path
+		if rel, err := filepath.Rel(root, file.Path); err == nil && !strings.HasPrefix(rel, "..") {
+			name = filepath.ToSlash(rel)
+		}
+		d := sessionFileDiff{Name: name, Status: "modified"}
+		fromFile, toFile := "a/"+name, "b/"+name
+		header := fmt.Sprintf("diff --git a/%s b/%s\n", name, name)
+		switch {
+		case !file.Before.Exists:
+			d.Status = "created"
+			fromFile = "/dev/null"
+			header += "new file mode 100644\n"
+		case !file.After.Exists:
+			d.Status = "deleted"
+			toFile = "/dev/null"
+			header += "deleted file mode 100644\n"
+		}
+
+		if strings.ContainsRune(file.Before.Content, 0) || strings.ContainsRune(file.After.Content, 0) {
+			d.Binary = true
+			d.Patch = header + fmt.Sprintf("Binary files %s and %s differ\n", fromFile, toFile)
+			diffs = append(diffs, d)
+			continue
+		}
+
+		before, after := splitPatchLines(file.Before.Content), splitPatchLines(file.After.Content)
+		for _, op := range difflib.NewMatcher(before, after).GetOpCodes() {
+			switch op.Tag {
+			case 'r':
+				d.Deletions += op.I2 - op.I1
+				d.Insertions += op.J2 - op.J1
+			case 'd':
+				d.Deletions += op.I2 - op.I1
+			case 'i':
+				d.Insertions += op.J2 - op.J1
+			}
+		}
+		body, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        before,
+			B:        after,
+			FromFile: fromFile,
+			ToFile:   toFile,
+			Context:  3,
+		})
+		d.Patch = header + body
+		diffs = append(diffs, d)
+	}
+	return diffs
+}
+
+// splitPatchLines splits content into lines that keep their newline. A last
+// line without one carries the unified diff marker for it.
+func splitPatchLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(content, "\n")
+	if last := lines[len(lines)-1]; last == "" {
+		lines = lines[:len(lines)-1]
+	} else {
+		lines[len(lines)-1] = last + "\n\\ No newline at end of file\n"
+	}
+	return lines
+}
+
+// setDiffLayout shows or sets the layout diffs are rendered in.
+func setDiffLayout(args []string) error {
+	if len(args) == 0 {
+		fmt.Printf("[diff] Diffs use the %s layout\r\n", console.ActiveDiffLayout())
+		return nil
+	}
+	layout, ok := console.ParseDiffLayout(args[0])
+	if len(args) > 1 || !ok {
+		return fmt.Errorf("usage: /diff layout [unified|split]")
+	}
+	console.SetDiffLayout(layout)
+	fmt.Printf("[diff] Diffs now use the %s layout\r\n", layout)
+	return nil
+}
+
+func printSessionFileDiff(d sessionFileDiff, view console.DiffView) {
+	fmt.Print("\r\n" + strings.ReplaceAll(view.RenderPatch(d.Patch), "\n", "\r\n"))
+}
+
+// printSessionDiffStat prints a line per file and the totals, like git diff --stat.
+func printSessionDiffStat(diffs []sessionFileDiff) {
+	width := 0
+	for _, d := range diffs {
+		width = max(width, len(d.Name))
+	}
+	insertions, deletions := 0, 0
+	for _, d := range diffs {
+		insertions += d.Insertions
+		deletions += d.Deletions
+		change := "Bin"
+		if !d.Binary {
+			change = fmt.Sprintf("+%d -%d", d.Insertions, d.Deletions)
+		}
+		note := ""
+		if d.Status != "modified" {
+			note = " (" + d.Status + ")"
+		}
+		fmt.Printf(" %-*s | %s%s\r\n", width, d.Name, change, note)
+	}
+	fmt.Printf(" %d %s changed, %d %s(+), %d %s(-)\r\n",
+		len(diffs), plural(len(diffs), "file", "files"),
+		insertions, plural(insertions, "insertion", "insertions"),
+		deletions, plural(deletions, "deletion", "deletions"))
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
@@ -0,0 +1,86 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alantheprice/ledit/pkg/agent"
+	"github.com/alantheprice/ledit/pkg/console"
+)
+
+func TestDiffCommandWritesSessionPatch(t *testing.T) {
+	root := t.TempDir()
+	a := &agent.Agent{}
+	a.SetWorkspaceRoot(root)
+
+	edited := filepath.Join(root, "main.go")
+	if err := os.WriteFile(edited, []byte("package main\n\nfunc main() {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	write := func(path, content string) {
+		t.Helper()
+		if err := a.TrackFileWrite(path, content); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(edited, "package main\n\nfunc main() {\n\tprintln(\"hi\")\n}\n")
+	write(edited, "package main\n\nfunc main() {\n\tprintln(\"hello\")\n}\n")
+	write(filepath.Join(root, "docs", "notes.txt"), "no newline")
+
+	out := filepath.Join(root, "session.patch")
+	if err := (&DiffCommand{}).Execute([]string{"--stat", "--patch", out}, a); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("patch not written: %v", err)
+	}
+	patch := string(data)
+	for _, want := range []string{
+		"diff --git a/docs/notes.txt b/docs/notes.txt\nnew file mode 100644\n--- /dev/null\n+++ b/docs/notes.txt\n",
+		"+no newline\n\\ No newline at end of file\n",
+		"--- a/main.go\n+++ b/main.go\n",
+		"-func main() {}\n+func main() {\n+\tprintln(\"hello\")\n+}\n",
+	} {
+		if !strings.Contains(patch, want) {
+			t.Fatalf("expected %q in patch:\n%s", want, patch)
+		}
+	}
+	if strings.Contains(patch, "\"hi\"") {
+		t.Fatalf("expected the diff against the session start only, got:\n%s", patch)
+	}
+
+	diffs := buildSessionDiffs(root, a.SessionDiff())
+	if len(diffs) != 2 || diffs[1].Name != "main.go" || diffs[1].Insertions != 3 || diffs[1].Deletions != 1 {
+		t.Fatalf("unexpected stats %+v", diffs)
+	}
+
+	// Restoring a file's original content drops it from the diff.
+	write(edited, "package main\n\nfunc main() {}\n")
+	if diffs := a.SessionDiff(); len(diffs) != 1 {
+		t.Fatalf("expected only the created file to differ, got %+v", diffs)
+	}
+}
+
+func TestDiffCommandSetsLayout(t *testing.T) {
+	defer console.SetDiffLayout(console.ActiveDiffLayout())
+	a := &agent.Agent{}
+
+	if err := (&DiffCommand{}).Execute([]string{"layout", "side-by-side"}, a); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if got := console.ActiveDiffLayout(); got != console.DiffSplit {
+		t.Fatalf("expected the split layout, got %q", got)
+	}
+	if err := (&DiffCommand{}).Execute([]string{"layout", "stacked"}, a); err == nil {
+		t.Fatal("expected an unknown layout to be rejected")
+	}
+}
@@ -0,0 +1,50 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/alantheprice/ledit/pkg/agent"
+)
+
+// DryRunCommand implements the /dryrun slash command.
+type DryRunCommand struct{}
+
+// Name returns the command name
+func (c *DryRunCommand) Name() string {
+	return "dryrun"
+}
+
+// Description returns the command description
+func (c *DryRunCommand) Description() string {
+	return "Show or toggle dry-run mode, where changes are previewed instead of applied: /dryrun [on|off]"
+}
+
+// Execute runs the dryrun command
+func (c *DryRunCommand) Execute(args []string, chatAgent *agent.Agent) error {
+	if chatAgent == nil {
+		return errors.New("agent is not initialized")
+	}
+	if len(args) > 1 {
+		return errors.New("usage: /dryrun [on|off]")
+	}
+
+	if len(args) == 1 {
+		switch strings.ToLower(strings.TrimSpace(args[0])) {
+		case "on", "true", "1":
+			chatAgent.SetDryRun(true)
+		case "off", "false", "0":
+			chatAgent.SetDryRun(false)
+		default:
+			return errors.New("usage: /dryrun [on|off]")
+		}
+	}
+
+	if chatAgent.DryRun() {
+		fmt.Printf("[dryrun] On: file writes, edits, git, commits and non-read-only shell commands are previewed, not applied\r\n")
+	} else {
+		fmt.Printf("[dryrun] Off: tool calls are applied\r\n")
+	}
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/alantheprice/ledit/pkg/agent"
 	tools "github.com/alantheprice/ledit/pkg/agent_tools"
+	"github.com/alantheprice/ledit/pkg/console"
 )
 
 // ExecCommand handles the /exec slash command
@@ -31,7 +32,7 @@ func (c *ExecCommand) Execute(args []string, chatAgent *agent.Agent) error {
 	command := strings.Join(args, " ")
 
 	// Execute the shell command using the same pattern as direct shell execution
-	fmt.Printf("\033[34m[shell]\033[0m Executing: %s\n", command)
+	fmt.Printf("%s Executing: %s\n", console.Colorize("[shell]", console.ColorBlue), command)
 	result, err := tools.ExecuteShellCommand(context.Background(), command)
 	if err != nil {
 		fmt.Printf("Error: %v\n", err)
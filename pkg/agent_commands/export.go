@@ -0,0 +1,93 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/alantheprice/ledit/pkg/agent"
+	"github.com/alantheprice/ledit/pkg/transcript"
+)
+
+// ExportCommand writes the current session as a Markdown or HTML transcript.
+type ExportCommand struct{}
+
+// Name returns the command name
+func (c *ExportCommand) Name() string {
+	return "export"
+}
+
+// Description returns the command description
+func (c *ExportCommand) Description() string {
+	return "Export this session as a transcript: /export [md|html] [--condense|--only-changes] [path]"
+}
+
+// Execute runs the export command
+func (c *ExportCommand) Execute(args []string, chatAgent *agent.Agent) error {
+	if chatAgent == nil {
+		return fmt.Errorf("agent not available")
+	}
+
+	format, path := "", ""
+	mode := transcript.ModeFull
+	for _, arg := range args {
+		switch strings.ToLower(arg) {
+		case "help", "-h", "--help":
+			fmt.Print(normalizeNewlines(`Usage:
+  /export [md|html] [--condense|--only-changes] [path]
+
+Writes prompts, responses, tool calls with their arguments and results,
+diffs and the cost summary of this session. The format defaults to html
+for .html paths and markdown otherwise; the path defaults to
+ledit-transcript-<time>.<ext> in the workspace.
+`))
+			return nil
+		case "md", "markdown", "html":
+			format = arg
+		case "--full":
+			mode = transcript.ModeFull
+		case "--condense":
+			mode = transcript.ModeCondense
+		case "--only-changes":
+			mode = transcript.ModeOnlyChanges
+		default:
+			if path != "" {
+				return fmt.Errorf("usage: /export [md|html] [--condense|--only-changes] [path]")
+			}
+			path = arg
+		}
+	}
+
+	format, err := transcript.ResolveFormat(format, path)
+	if err != nil {
+		return err
+	}
+	if path == "" {
+		ext := ".md"
+		if format == "html" {
+			ext = ".html"
+		}
+		path = "ledit-transcript-" + time.Now().Format("20060102-150405") + ext
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(chatAgent.GetWorkspaceRoot(), path)
+	}
+
+	t, err := transcript.Build(chatAgent.SnapshotState(), mode)
+	if err != nil {
+		return fmt.Errorf("failed to build transcript: %w", err)
+	}
+	if len(t.Entries) == 0 {
+		return fmt.Errorf("nothing to export yet")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(transcript.Render(t, format)), 0644); err != nil {
+		return fmt.Errorf("failed to write transcript: %w", err)
+	}
+	fmt.Printf("[export] Transcript (%s, %d entries) written to %s\r\n", mode, len(t.Entries), path)
+	return nil
+}
@@ -0,0 +1,102 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/alantheprice/ledit/pkg/agent"
+	"github.com/alantheprice/ledit/pkg/fixtests"
+)
+
+// FixTestsCommand implements the /fix-tests slash command.
+type FixTestsCommand struct{}
+
+func (c *FixTestsCommand) Name() string {
+	return "fix-tests"
+}
+
+func (c *FixTestsCommand) Description() string {
+	return "Run the test suite and let the agent repair failures until green (usage: /fix-tests [--max N] [--full] [test command])"
+}
+
+func (c *FixTestsCommand) Execute(args []string, chatAgent *agent.Agent) error {
+	if chatAgent == nil {
+		return errors.New("agent is not initialized")
+	}
+
+	opts, err := parseFixTestsArgs(args)
+	if err != nil {
+		return err
+	}
+	loop, err := fixtests.NewAgentLoop(chatAgent, opts)
+	if err != nil {
+		return err
+	}
+	loop.OnIteration = func(iter fixtests.IterationReport) {
+		fmt.Printf("\n[test] %s\n", fixtests.FormatIteration(iter))
+	}
+
+	framework := loop.Framework
+	if framework == "" {
+		framework = "custom"
+	}
+	fmt.Printf("\n[test] Running `%s` (%s)\n", loop.Command, framework)
+
+	report, err := loop.Run(context.Background())
+	if err != nil {
+		return fmt.Errorf("fix-tests failed: %w", err)
+	}
+
+	switch {
+	case report.Passed && len(report.Iterations) == 0:
+		fmt.Println("[OK] Tests already pass")
+	case report.Passed:
+		fmt.Printf("[OK] Tests pass after %d repair iteration(s)\n", len(report.Iterations))
+	default:
+		remaining := len(report.Final.Failures)
+		fmt.Printf("[FAIL] Tests still failing after %d repair iteration(s)", len(report.Iterations))
+		if remaining > 0 {
+			fmt.Printf(" (%d failure(s) remaining)", remaining)
+		}
+		fmt.Println()
+	}
+	return nil
+}
+
+// parseFixTestsArgs reads an optional --max N (or --max=N) and --full,
+// followed by an optional test command.
+func parseFixTestsArgs(args []string) (fixtests.Options, error) {
+	var opts fixtests.Options
+	var command []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		value := ""
+		switch {
+		case arg == "--max" || arg == "--max-iterations":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("%s requires a value", arg)
+			}
+			i++
+			value = args[i]
+		case strings.HasPrefix(arg, "--max="), strings.HasPrefix(arg, "--max-iterations="):
+			value = arg[strings.Index(arg, "=")+1:]
+		case arg == "--full":
+			opts.FullSuite = true
+			continue
+		default:
+			command = append(command, args[i:]...)
+			i = len(args)
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			return opts, fmt.Errorf("invalid iteration budget %q: must be a positive integer", value)
+		}
+		opts.MaxIterations = n
+	}
+	opts.Command = strings.Join(command, " ")
+	return opts, nil
+}
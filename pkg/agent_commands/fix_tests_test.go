@@ -0,0 +1,29 @@
+package commands
+
+import "testing"
+
+func TestParseFixTestsArgs(t *testing.T) {
+	opts, err := parseFixTestsArgs([]string{"--max", "3", "go", "test", "./pkg/..."})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.MaxIterations != 3 || opts.Command != "go test ./pkg/..." {
+		t.Fatalf("unexpected options: %+v", opts)
+	}
+
+	opts, err = parseFixTestsArgs([]string{"--max=2"})
+	if err != nil || opts.MaxIterations != 2 || opts.Command != "" {
+		t.Fatalf("unexpected options: %+v, %v", opts, err)
+	}
+
+	opts, err = parseFixTestsArgs([]string{"--full", "--max=2"})
+	if err != nil || !opts.FullSuite || opts.MaxIterations != 2 {
+		t.Fatalf("unexpected options: %+v, %v", opts, err)
+	}
+
+	for _, args := range [][]string{{"--max"}, {"--max", "0"}, {"--max=x"}} {
+		if _, err := parseFixTestsArgs(args); err == nil {
+			t.Fatalf("expected error for %v", args)
+		}
+	}
+}
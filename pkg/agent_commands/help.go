@@ -57,6 +57,7 @@ AVAILABLE TOOLS:
   • run_subagent - Delegate to subagent
   • run_parallel_subagents - Run multiple subagents in parallel
   • list_skills/activate_skill - Load skill instructions into context
+  • ask_user - Ask you a question instead of guessing
 
 KEY COMMANDS:
   /help       - Show this help message
@@ -67,6 +68,7 @@ KEY COMMANDS:
   /subagent-persona - Configure a specific persona
   /persona    - Apply/configure direct personas (provider/model/tools/prompt)
   /self-review-gate - Configure automatic self-review gate mode
+  /clarify    - Ask clarifying questions about ambiguous requests (on/off)
 
 Type 'exit' or 'quit' to end the session.
 
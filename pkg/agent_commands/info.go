@@ -2,6 +2,9 @@ package commands
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/alantheprice/ledit/pkg/agent"
 )
@@ -16,13 +19,144 @@ func (s *StatsCommand) Name() string {
 
 // Description returns the command description
 func (s *StatsCommand) Description() string {
-	return "Show detailed conversation summary and token usage"
+	return "Show detailed conversation summary and token usage: /stats [tree] [--detailed] [--export <file.csv|file.json>]"
 }
 
 // Execute runs the stats command
 func (s *StatsCommand) Execute(args []string, chatAgent *agent.Agent) error {
+	if chatAgent == nil {
+		return fmt.Errorf("agent not available")
+	}
+
+	detailed := false
+	tree := false
+	exportPath := ""
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "tree", "--tree":
+			tree = true
+		case "--detailed", "-d", "detailed":
+			detailed = true
+		case "--export", "export":
+			if i+1 >= len(args) {
+				return fmt.Errorf("usage: /stats --export <file.csv|file.json>")
+			}
+			i++
+			exportPath = args[i]
+		default:
+			return fmt.Errorf("unknown option: %s. Usage: /stats [tree] [--detailed] [--export <file.csv|file.json>]", args[i])
+		}
+	}
+
+	if exportPath != "" {
+		return exportUsageReport(chatAgent.TokenUsageReport(), exportPath)
+	}
+
+	if tree {
+		printCostTree(chatAgent)
+		return nil
+	}
+
 	fmt.Println("\n[chart] Detailed Conversation Summary:")
 	fmt.Println("=====================================")
 	chatAgent.PrintConversationSummary(true)
+	if detailed {
+		printUsageReport(chatAgent.TokenUsageReport())
+	}
+	return nil
+}
+
+func printCostTree(chatAgent *agent.Agent) {
+	fmt.Println("\n[tree] Subagent Cost Tree")
+	fmt.Println("══════════════════════════════")
+	report := chatAgent.CostTreeReport()
+	if report == "" {
+		fmt.Println("No subagents have run in this session.")
+		return
+	}
+	fmt.Println(report)
+	fmt.Println("\nEach agent's totals include its subagents; own usage excludes them.")
+}
+
+// maxUsageRows limits each table in /stats --detailed; exports include every row.
+const maxUsageRows = 15
+
+func printUsageReport(report agent.UsageReport) {
+	fmt.Println("\n[chart] Token Attribution")
+	fmt.Println("══════════════════════════════")
+	total := report.PromptTokens + report.CompletionTokens
+	if total == 0 {
+		fmt.Println("No token usage recorded yet.")
+		return
+	}
+	fmt.Printf("%-30s %8s %10s %10s %10s\n", "", "Calls", "Prompt", "Output", "Cost")
+	printUsageRow("Conversation (requests)", report.Conversation)
+
+	sections := []struct {
+		title   string
+		entries []agent.UsageEntry
+	}{
+		{"By tool", report.Tools},
+		{"By subagent", report.Subagents},
+		{"By file", report.Files},
+	}
+	for _, section := range sections {
+		if len(section.entries) == 0 {
+			continue
+		}
+		fmt.Printf("\n%s\n", section.title)
+		for i, entry := range section.entries {
+			if i == maxUsageRows {
+				fmt.Printf("  ... %d more (use /stats --export for all rows)\n", len(section.entries)-maxUsageRows)
+				break
+			}
+			printUsageRow("  "+entry.Name, entry)
+		}
+	}
+	fmt.Println("\nPrompt tokens are split by the estimated size of tool calls and results in each request;")
+	fmt.Println("output tokens by the size of each response's tool calls. Files overlap their tools.")
+}
+
+func printUsageRow(label string, entry agent.UsageEntry) {
+	if len(label) > 30 {
+		label = "..." + label[len(label)-27:]
+	}
+	fmt.Printf("%-30s %8d %10s %10s %10s\n", label, entry.Calls,
+		formatTokenCount(entry.PromptTokens), formatTokenCount(entry.CompletionTokens), fmt.Sprintf("$%.4f", entry.Cost))
+}
+
+func formatTokenCount(n int) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1_000_000)
+	case n >= 10_000:
+		return fmt.Sprintf("%.1fK", float64(n)/1_000)
+	default:
+		return fmt.Sprintf("%d", n)
+	}
+}
+
+func exportUsageReport(report agent.UsageReport, path string) error {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext != ".csv" && ext != ".json" {
+		return fmt.Errorf("unsupported export format %q: use a .csv or .json file", ext)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	if ext == ".csv" {
+		err = report.WriteCSV(f)
+	} else {
+		err = report.WriteJSON(f)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	fmt.Printf("[chart] Exported token usage (%d rows) to %s\n", len(report.Entries()), path)
 	return nil
 }
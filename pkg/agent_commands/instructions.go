@@ -0,0 +1,51 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/alantheprice/ledit/pkg/agent"
+)
+
+// InstructionsCommand lists the instruction files merged into the system prompt.
+type InstructionsCommand struct{}
+
+// Name returns the command name
+func (c *InstructionsCommand) Name() string {
+	return "instructions"
+}
+
+// Description returns the command description
+func (c *InstructionsCommand) Description() string {
+	return "Show the instruction files (LEDIT.md, AGENTS.md, CLAUDE.md) that apply here: /instructions [show]"
+}
+
+// Execute runs the instructions command
+func (c *InstructionsCommand) Execute(args []string, chatAgent *agent.Agent) error {
+	if len(args) > 1 || (len(args) == 1 && !strings.EqualFold(args[0], "show")) {
+		return fmt.Errorf("usage: /instructions [show]")
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current working directory: %w", err)
+	}
+
+	files := agent.DiscoverInstructionFiles(cwd)
+	if len(files) == 0 {
+		fmt.Printf("[instructions] No instruction files found. Add one of %s to the repository root or a subdirectory\r\n",
+			strings.Join(agent.InstructionFileNames, ", "))
+		return nil
+	}
+	if len(args) == 1 {
+		fmt.Print(normalizeNewlines(strings.TrimLeft(agent.FormatInstructionFiles(files), "\n-") + "\n"))
+		return nil
+	}
+
+	fmt.Printf("[instructions] Active instruction files, later ones taking precedence:\r\n")
+	for i, file := range files {
+		fmt.Printf("  %d. %s (%s, %d bytes)\r\n", i+1, file.Path, file.Describe(), len(file.Content))
+	}
+	fmt.Printf("[instructions] Files are read when a session starts; use /instructions show to see the merged text\r\n")
+	return nil
+}
@@ -0,0 +1,173 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/alantheprice/ledit/pkg/agent"
+	"github.com/alantheprice/ledit/pkg/jobs"
+)
+
+// BgCommand runs a task in the background while the console stays usable.
+type BgCommand struct{}
+
+// Name returns the command name
+func (c *BgCommand) Name() string {
+	return "bg"
+}
+
+// Description returns the command description
+func (c *BgCommand) Description() string {
+	return "Run a task in the background and get notified when it finishes: /bg <prompt>"
+}
+
+// Execute runs the bg command
+func (c *BgCommand) Execute(args []string, chatAgent *agent.Agent) error {
+	if chatAgent == nil {
+		return fmt.Errorf("agent not available")
+	}
+	prompt := strings.TrimSpace(strings.Join(args, " "))
+	if prompt == "" {
+		return fmt.Errorf("usage: /bg <prompt>")
+	}
+	job, err := jobs.Default().Submit(jobs.Spec{
+		Prompt:   prompt,
+		WorkDir:  chatAgent.GetWorkspaceRoot(),
+		Provider: chatAgent.GetProvider(),
+		Model:    chatAgent.GetModel(),
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("[bg] Started job %s with %s/%s\r\n", job.ID, job.Spec.Provider, job.Spec.Model)
+	fmt.Printf("[bg] Monitor with /jobs %s, cancel with /jobs cancel %s. Output: %s\r\n", job.ID, job.ID, job.LogPath)
+	return nil
+}
+
+// JobsCommand lists and manages background jobs.
+type JobsCommand struct{}
+
+// Name returns the command name
+func (c *JobsCommand) Name() string {
+	return "jobs"
+}
+
+// Description returns the command description
+func (c *JobsCommand) Description() string {
+	return "Monitor background jobs: /jobs [id] | cancel <id>"
+}
+
+// Execute runs the jobs command
+func (c *JobsCommand) Execute(args []string, chatAgent *agent.Agent) error {
+	manager := jobs.Default()
+	if len(args) == 0 {
+		printJobList(manager.List())
+		return nil
+	}
+	switch strings.ToLower(args[0]) {
+	case "help", "-h", "--help":
+		fmt.Print(normalizeNewlines(`Usage:
+  /bg <prompt>        Run a task in the background
+  /jobs               List background jobs
+  /jobs <id>          Show a job's progress, or its summary once finished
+  /jobs cancel <id>   Stop a running job
+`))
+		return nil
+	case "cancel", "stop", "kill":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: /jobs cancel <id>")
+		}
+		if err := manager.CancelTask(args[1]); err != nil {
+			return err
+		}
+		fmt.Printf("[bg] Cancelling job %s\r\n", strings.TrimPrefix(args[1], "#"))
+		return nil
+	default:
+		job, err := manager.MonitorProgress(args[0])
+		if err != nil {
+			return err
+		}
+		printJobDetails(job)
+		return nil
+	}
+}
+
+func printJobList(list []jobs.Job) {
+	if len(list) == 0 {
+		fmt.Print("[bg] No background jobs. Start one with /bg <prompt>\r\n")
+		return
+	}
+	for _, job := range list {
+		activity := string(job.Status)
+		if !job.Done() {
+			activity = describeJobActivity(job.Progress)
+		}
+		fmt.Printf("  %-3s %-10s %8s  %-40s  %s\r\n", job.ID, job.Status, job.Duration().Round(time.Second),
+			truncateJobText(job.Spec.Prompt, 40), activity)
+	}
+}
+
+func printJobDetails(job jobs.Job) {
+	fmt.Printf("[bg] Job %s: %s (%s)\r\n", job.ID, job.Status, job.Duration().Round(time.Second))
+	fmt.Printf("  Prompt:   %s\r\n", truncateJobText(job.Spec.Prompt, 200))
+	fmt.Printf("  Model:    %s/%s\r\n", job.Spec.Provider, job.Spec.Model)
+	p := job.Progress
+	fmt.Printf("  Progress: iteration %d, %d tool calls, %d tokens, $%.4f\r\n", p.Iteration, p.ToolCalls, p.TotalTokens, p.Cost)
+	if !job.Done() {
+		fmt.Printf("  Activity: %s (%s ago)\r\n", describeJobActivity(p), time.Since(p.UpdatedAt).Round(time.Second))
+	}
+	if len(job.FilesChanged) > 0 {
+		fmt.Printf("  Files:    %s\r\n", strings.Join(job.FilesChanged, ", "))
+	}
+	fmt.Printf("  Log:      %s\r\n", job.LogPath)
+	if job.Err != "" {
+		fmt.Printf("  Error:    %s\r\n", job.Err)
+	}
+	if job.Result != nil && strings.TrimSpace(job.Result.Response) != "" {
+		fmt.Print("\r\n")
+		fmt.Print(normalizeNewlines(strings.TrimSpace(job.Result.Response) + "\n"))
+	} else if job.Done() {
+		printJobLogTail(job.LogPath, 10)
+	}
+}
+
+func describeJobActivity(p jobs.Progress) string {
+	switch {
+	case p.CurrentTool != "":
+		return "running " + p.CurrentTool
+	case p.Message != "":
+		return truncateJobText(p.Message, 60)
+	case p.Iteration > 0:
+		return fmt.Sprintf("thinking (iteration %d)", p.Iteration)
+	default:
+		return "starting"
+	}
+}
+
+// printJobLogTail shows the last lines of a job's log, for jobs that ended
+// without a response.
+func printJobLogTail(path string, lines int) {
+	data, err := os.ReadFile(path)
+	if err != nil || len(data) == 0 {
+		return
+	}
+	all := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(all) > lines {
+		all = all[len(all)-lines:]
+	}
+	fmt.Print("  Last output:\r\n")
+	for _, line := range all {
+		fmt.Printf("    %s\r\n", line)
+	}
+}
+
+func truncateJobText(s string, max int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	runes := []rune(s)
+	if len(runes) > max {
+		return string(runes[:max-3]) + "..."
+	}
+	return s
+}
@@ -0,0 +1,151 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/alantheprice/ledit/pkg/agent"
+)
+
+// MemoryCommand manages the project and user memories the agent loads into
+// its context.
+type MemoryCommand struct{}
+
+// Name returns the command name
+func (c *MemoryCommand) Name() string {
+	return "memory"
+}
+
+// Description returns the command description
+func (c *MemoryCommand) Description() string {
+	return "Manage long-term memories: /memory [list] | show <name> | add [--user] <name> <text> | delete [--user|--project] <name> | search <query>"
+}
+
+// Execute runs the memory command
+func (c *MemoryCommand) Execute(args []string, chatAgent *agent.Agent) error {
+	root := memoryProjectRoot(chatAgent)
+	if len(args) == 0 {
+		return printMemories(root)
+	}
+
+	sub := strings.ToLower(args[0])
+	args = args[1:]
+	switch sub {
+	case "list", "ls":
+		return printMemories(root)
+	case "help", "-h", "--help":
+		printMemoryHelp()
+		return nil
+	case "show", "read":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: /memory show <name>")
+		}
+		memory, err := agent.FindMemory(root, "", args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("[memory] %s (%s, %s)\r\n", memory.Name, memory.Scope, memory.Path)
+		fmt.Print(normalizeNewlines(strings.TrimRight(memory.Content, "\n") + "\n"))
+		return nil
+	case "add", "set":
+		scope, rest := memoryScopeFlag(args, agent.MemoryScopeProject)
+		if len(rest) < 2 {
+			return fmt.Errorf("usage: /memory add [--user] <name> <text>")
+		}
+		path, err := agent.SaveMemory(root, scope, rest[0], strings.Join(rest[1:], " ")+"\n")
+		if err != nil {
+			return err
+		}
+		fmt.Printf("[memory] Saved %s memory to %s\r\n", scope, path)
+		return nil
+	case "delete", "rm", "remove":
+		scope, rest := memoryScopeFlag(args, "")
+		if len(rest) != 1 {
+			return fmt.Errorf("usage: /memory delete [--user|--project] <name>")
+		}
+		memory, err := agent.DeleteMemory(root, scope, rest[0])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("[memory] Deleted %s memory %s\r\n", memory.Scope, memory.Name)
+		return nil
+	case "search", "find":
+		query := strings.Join(args, " ")
+		if strings.TrimSpace(query) == "" {
+			return fmt.Errorf("usage: /memory search <query>")
+		}
+		memories, err := agent.LoadAllMemories(root)
+		if err != nil {
+			return err
+		}
+		ranked := agent.RankMemories(memories, query)
+		if len(ranked) == 0 {
+			fmt.Printf("[memory] No memories match %q\r\n", query)
+			return nil
+		}
+		for _, memory := range ranked {
+			printMemoryLine(memory)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown /memory subcommand %q. Use '/memory help' for usage", sub)
+}
+
+// memoryProjectRoot is the workspace whose project memories the command uses.
+func memoryProjectRoot(chatAgent *agent.Agent) string {
+	if chatAgent != nil && chatAgent.GetWorkspaceRoot() != "" {
+		return chatAgent.GetWorkspaceRoot()
+	}
+	cwd, _ := os.Getwd()
+	return cwd
+}
+
+// memoryScopeFlag removes a leading --user or --project flag from args.
+func memoryScopeFlag(args []string, fallback agent.MemoryScope) (agent.MemoryScope, []string) {
+	if len(args) > 0 {
+		switch strings.ToLower(args[0]) {
+		case "--user", "-u":
+			return agent.MemoryScopeUser, args[1:]
+		case "--project", "-p":
+			return agent.MemoryScopeProject, args[1:]
+		}
+	}
+	return fallback, args
+}
+
+func printMemories(root string) error {
+	memories, err := agent.ListMemories(root)
+	if err != nil {
+		return err
+	}
+	if len(memories) == 0 {
+		fmt.Print("[memory] No memories saved. Add one with /memory add <name> <text>, or ask the agent to remember something\r\n")
+		return nil
+	}
+	fmt.Printf("[memory] %d memories (project memories override user memories with the same name):\r\n", len(memories))
+	for _, memory := range memories {
+		printMemoryLine(memory)
+	}
+	return nil
+}
+
+func printMemoryLine(memory agent.MemoryInfo) {
+	title := strings.TrimSpace(strings.SplitN(strings.TrimLeft(strings.TrimSpace(memory.Content), "# "), "\n", 2)[0])
+	if len(title) > 80 {
+		title = title[:77] + "..."
+	}
+	fmt.Printf("  %-8s %-24s %s\r\n", memory.Scope, memory.Name, title)
+}
+
+func printMemoryHelp() {
+	fmt.Print("[memory] Usage:\r\n")
+	fmt.Print("  /memory                          List project and user memories\r\n")
+	fmt.Print("  /memory show <name>              Show a memory\r\n")
+	fmt.Print("  /memory add [--user] <name> <text>\r\n")
+	fmt.Print("                                   Save a project memory, or a user memory with --user\r\n")
+	fmt.Print("  /memory delete [--user|--project] <name>\r\n")
+	fmt.Print("                                   Delete a memory (the project one first by default)\r\n")
+	fmt.Print("  /memory search <query>           List memories by relevance to a query\r\n")
+	fmt.Print("Project memories are stored in .ledit/memories/ in the workspace; user memories in ~/.ledit/memories/.\r\n")
+}
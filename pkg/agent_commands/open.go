@@ -0,0 +1,94 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/alantheprice/ledit/pkg/agent"
+	"github.com/alantheprice/ledit/pkg/console"
+)
+
+// OpenCommand opens files named in the output in the user's editor.
+type OpenCommand struct{}
+
+// Name returns the command name
+func (c *OpenCommand) Name() string {
+	return "open"
+}
+
+// Description returns the command description
+func (c *OpenCommand) Description() string {
+	return "Open a file mentioned in the output in your editor: /open [n|path[:line]]"
+}
+
+// Execute runs the open command
+func (c *OpenCommand) Execute(args []string, chatAgent *agent.Agent) error {
+	refs := console.RecentFileRefs()
+	if len(args) > 0 {
+		switch strings.ToLower(args[0]) {
+		case "help", "-h", "--help":
+			fmt.Print(normalizeNewlines(`Usage:
+  /open               Number the files recently mentioned in the output
+  /open <n>           Open the n-th file of the list
+  /open <path[:line]> Open a file, at a line
+
+Files open with editor_command from the config, such as "code -g {file}:{line}",
+or with $VISUAL or $EDITOR.
+`))
+			return nil
+		case "list", "ls":
+			printFileRefs(refs)
+			return nil
+		}
+	}
+	if len(args) == 0 {
+		printFileRefs(refs)
+		return nil
+	}
+
+	ref, err := resolveOpenTarget(args[0], refs)
+	if err != nil {
+		return err
+	}
+	if err := console.OpenInEditor(ref.Path, ref.Line); err != nil {
+		return err
+	}
+	fmt.Printf("[open] Opened %s\r\n", ref)
+	return nil
+}
+
+// resolveOpenTarget turns a list number or a path[:line] argument into a
+// file reference.
+func resolveOpenTarget(arg string, refs []console.FileRef) (console.FileRef, error) {
+	if n, err := strconv.Atoi(arg); err == nil {
+		if n < 1 || n > len(refs) {
+			if len(refs) == 0 {
+				return console.FileRef{}, fmt.Errorf("no files have been mentioned in the output yet")
+			}
+			return console.FileRef{}, fmt.Errorf("file must be between 1 and %d (see /open)", len(refs))
+		}
+		return refs[n-1], nil
+	}
+
+	root, err := os.Getwd()
+	if err != nil {
+		return console.FileRef{}, fmt.Errorf("failed to get working directory: %w", err)
+	}
+	ref, ok := console.ParseFileRef(arg, root)
+	if !ok {
+		return console.FileRef{}, fmt.Errorf("no such file: %s", arg)
+	}
+	return ref, nil
+}
+
+func printFileRefs(refs []console.FileRef) {
+	if len(refs) == 0 {
+		fmt.Printf("[open] No files have been mentioned in the output yet\r\n")
+		return
+	}
+	for i, ref := range refs {
+		fmt.Printf("  %d. %s\r\n", i+1, ref)
+	}
+}
@@ -0,0 +1,36 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alantheprice/ledit/pkg/console"
+)
+
+func TestResolveOpenTarget(t *testing.T) {
+	root := t.TempDir()
+	file := filepath.Join(root, "main.go")
+	if err := os.WriteFile(file, []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(root)
+	refs := []console.FileRef{{Path: "/src/a.go", Line: 3}, {Path: "/src/b.go"}}
+
+	if ref, err := resolveOpenTarget("2", refs); err != nil || ref.Path != "/src/b.go" {
+		t.Fatalf("expected the second file of the list, got %+v, %v", ref, err)
+	}
+	if _, err := resolveOpenTarget("3", refs); err == nil || !strings.Contains(err.Error(), "between 1 and 2") {
+		t.Fatalf("expected an out-of-range error, got %v", err)
+	}
+	if _, err := resolveOpenTarget("1", nil); err == nil || !strings.Contains(err.Error(), "no files") {
+		t.Fatalf("expected an empty-list error, got %v", err)
+	}
+	if ref, err := resolveOpenTarget("main.go:9", refs); err != nil || ref.Line != 9 || filepath.Base(ref.Path) != "main.go" {
+		t.Fatalf("expected main.go at line 9, got %+v, %v", ref, err)
+	}
+	if _, err := resolveOpenTarget("missing.go", refs); err == nil {
+		t.Fatal("expected a missing file to be rejected")
+	}
+}
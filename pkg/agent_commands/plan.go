@@ -0,0 +1,239 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/alantheprice/ledit/pkg/agent"
+	"github.com/alantheprice/ledit/pkg/console"
+	"github.com/alantheprice/ledit/pkg/execplan"
+	"golang.org/x/term"
+)
+
+const planEditHelp = `Edit the plan, then approve it:
+  m <from> <to>   move a step (e.g. "m 4 2")
+  r <n>           remove a step
+  y               approve and execute
+  q               cancel`
+
+// PlanCommand implements the /plan slash command.
+type PlanCommand struct{}
+
+func (c *PlanCommand) Name() string {
+	return "plan"
+}
+
+func (c *PlanCommand) Description() string {
+	return "Draft an editable step-by-step plan for a goal, then execute it after approval (usage: /plan <goal>)"
+}
+
+func (c *PlanCommand) Execute(args []string, chatAgent *agent.Agent) error {
+	if chatAgent == nil {
+		return errors.New("agent is not initialized")
+	}
+	goal := strings.TrimSpace(strings.Join(args, " "))
+	if goal == "" {
+		return errors.New("usage: /plan <goal>")
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return errors.New("/plan requires an interactive terminal to review the plan")
+	}
+
+	runner, err := execplan.NewAgentRunner(chatAgent)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\n[plan] Drafting a plan for: %s\n", goal)
+	plan, err := runner.CreatePlan(goal)
+	if err != nil {
+		return err
+	}
+
+	approved, err := editPlan(plan, bufio.NewReader(os.Stdin), os.Stdout)
+	if err != nil {
+		return err
+	}
+	if !approved {
+		fmt.Println("[plan] Cancelled")
+		return nil
+	}
+
+	footer := newPlanFooter()
+	runner.OnProgress = func(p execplan.Progress) {
+		footer.Draw(planProgressLine(p))
+		publishPlanProgress(chatAgent, p)
+	}
+	err = runner.ExecutePlan(context.Background(), plan)
+	footer.Close()
+	if err != nil {
+		return fmt.Errorf("plan execution stopped: %w", err)
+	}
+
+	completed, failed, cancelled := plan.Counts()
+	fmt.Printf("\n%s", execplan.Render(plan))
+	if failed == 0 && cancelled == 0 {
+		fmt.Printf("[OK] Plan complete: %d/%d steps done\n", completed, len(plan.Steps))
+	} else {
+		fmt.Printf("[WARN] Plan finished with %d done, %d failed, %d skipped\n", completed, failed, cancelled)
+	}
+	return nil
+}
+
+// editPlan shows the plan and applies move/remove edits read from in until
+// the user approves (true) or cancels (false).
+func editPlan(plan *execplan.ExecutionPlan, in *bufio.Reader, out io.Writer) (bool, error) {
+	fmt.Fprintf(out, "\n%s\n%s\n", execplan.Render(plan), planEditHelp)
+	for {
+		fmt.Fprint(out, "plan> ")
+		line, err := in.ReadString('\n')
+		if err != nil && (err != io.EOF || strings.TrimSpace(line) == "") {
+			if err == io.EOF {
+				return false, nil
+			}
+			return false, err
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		var editErr error
+		switch strings.ToLower(fields[0]) {
+		case "y", "yes", "go", "approve":
+			if len(plan.Steps) == 0 {
+				fmt.Fprintln(out, "The plan has no steps left.")
+				continue
+			}
+			return true, nil
+		case "q", "quit", "cancel":
+			return false, nil
+		case "m", "move":
+			var from, to int
+			if from, to, editErr = parseTwoPositions(fields[1:]); editErr == nil {
+				editErr = plan.Move(from, to)
+			}
+		case "r", "rm", "remove":
+			var n int
+			if n, editErr = parsePosition(fields[1:]); editErr == nil {
+				editErr = plan.Remove(n)
+			}
+		default:
+			fmt.Fprintln(out, planEditHelp)
+			continue
+		}
+		if editErr != nil {
+			fmt.Fprintf(out, "[FAIL] %v\n", editErr)
+			continue
+		}
+		fmt.Fprintf(out, "\n%s\n", execplan.Render(plan))
+	}
+}
+
+func parsePosition(args []string) (int, error) {
+	if len(args) != 1 {
+		return 0, errors.New("expected one step number")
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil {
+		return 0, fmt.Errorf("invalid step number %q", args[0])
+	}
+	return n, nil
+}
+
+func parseTwoPositions(args []string) (int, int, error) {
+	if len(args) != 2 {
+		return 0, 0, errors.New("expected two step numbers")
+	}
+	from, err := parsePosition(args[:1])
+	if err != nil {
+		return 0, 0, err
+	}
+	to, err := parsePosition(args[1:])
+	if err != nil {
+		return 0, 0, err
+	}
+	return from, to, nil
+}
+
+// planProgressLine summarizes execution as "[plan 2/5] [x][~][ ][ ][ ] title".
+func planProgressLine(p execplan.Progress) string {
+	var marks strings.Builder
+	for _, step := range p.Plan.Steps {
+		switch step.Status {
+		case execplan.StepCompleted:
+			marks.WriteString("x")
+		case execplan.StepInProgress:
+			marks.WriteString("~")
+		case execplan.StepFailed:
+			marks.WriteString("!")
+		case execplan.StepCancelled:
+			marks.WriteString("-")
+		default:
+			marks.WriteString(".")
+		}
+	}
+	return fmt.Sprintf("[plan %d/%d] %s %s (%s)", p.Index, p.Total, marks.String(), p.Step.Title, p.Step.Status)
+}
+
+// publishPlanProgress mirrors plan progress to the WebUI as todo updates.
+func publishPlanProgress(chatAgent *agent.Agent, p execplan.Progress) {
+	todos := make([]map[string]interface{}, 0, len(p.Plan.Steps))
+	for _, step := range p.Plan.Steps {
+		todos = append(todos, map[string]interface{}{
+			"id":      step.ID,
+			"content": step.Title,
+			"status":  string(step.Status),
+		})
+	}
+	chatAgent.PublishTodoUpdate(todos)
+	chatAgent.PublishQueryProgress(planProgressLine(p), p.Index, 0)
+}
+
+// planFooter pins a status line to the bottom row of the terminal by
+// shrinking the scroll region above it. Without a terminal it prints each
+// update as a regular line.
+type planFooter struct {
+	height int
+}
+
+func newPlanFooter() *planFooter {
+	f := &planFooter{}
+	if !term.IsTerminal(int(os.Stdout.Fd())) {
+		return f
+	}
+	_, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || height < 5 {
+		return f
+	}
+	f.height = height
+	// Make room for the footer, then confine scrolling to the rows above it.
+	fmt.Print("\n" + console.MoveCursorUpSeq(1) + "\033[s" + console.SetScrollRegionSeq(1, height-1) + "\033[u")
+	return f
+}
+
+// Draw replaces the footer text.
+func (f *planFooter) Draw(text string) {
+	if f.height == 0 {
+		fmt.Printf("\n%s\n", text)
+		return
+	}
+	fmt.Print("\033[s" + console.MoveCursorSeq(1, f.height) + console.ClearLineSeq() +
+		console.Colorize(text, console.ActivePalette().Focus) + "\033[u")
+}
+
+// Close clears the footer and restores full-screen scrolling.
+func (f *planFooter) Close() {
+	if f.height == 0 {
+		return
+	}
+	fmt.Print("\033[s" + console.MoveCursorSeq(1, f.height) + console.ClearLineSeq() +
+		console.ResetScrollRegionSeq() + "\033[u")
+	f.height = 0
+}
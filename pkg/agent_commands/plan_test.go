@@ -0,0 +1,52 @@
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/alantheprice/ledit/pkg/execplan"
+)
+
+func testPlan(t *testing.T) *execplan.ExecutionPlan {
+	t.Helper()
+	plan, err := execplan.ParsePlan("goal", `{"steps": [
+		{"id": "a", "title": "First"},
+		{"id": "b", "title": "Second", "depends_on": ["a"]},
+		{"id": "c", "title": "Third"}
+	]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return plan
+}
+
+func TestEditPlanAppliesEditsThenApproves(t *testing.T) {
+	plan := testPlan(t)
+	var out bytes.Buffer
+	input := "m 3 1\nm 3 1\nr 2\nbogus\ny\n"
+
+	approved, err := editPlan(plan, bufio.NewReader(strings.NewReader(input)), &out)
+	if err != nil || !approved {
+		t.Fatalf("expected approval, got %v, %v", approved, err)
+	}
+	if len(plan.Steps) != 2 || plan.Steps[0].Title != "Third" || plan.Steps[1].Title != "Second" {
+		t.Fatalf("unexpected plan after edits: %+v", plan.Steps)
+	}
+	if !strings.Contains(out.String(), "[FAIL] step 1 (Second) must come after") {
+		t.Fatalf("expected rejected move to be reported, got:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "m <from> <to>") {
+		t.Fatalf("expected help for unknown input, got:\n%s", out.String())
+	}
+}
+
+func TestEditPlanCancel(t *testing.T) {
+	for _, input := range []string{"q\n", ""} {
+		approved, err := editPlan(testPlan(t), bufio.NewReader(strings.NewReader(input)), &bytes.Buffer{})
+		if err != nil || approved {
+			t.Fatalf("input %q: expected cancel, got %v, %v", input, approved, err)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/alantheprice/ledit/pkg/agent"
+	"github.com/alantheprice/ledit/pkg/sandbox"
+	"github.com/alantheprice/ledit/pkg/toolpolicy"
+)
+
+// PolicyCommand implements the /policy slash command
+type PolicyCommand struct{}
+
+// Name returns the command name
+func (c *PolicyCommand) Name() string {
+	return "policy"
+}
+
+// Description returns the command description
+func (c *PolicyCommand) Description() string {
+	return "Show the tool permission rules from .ledit/policy.yaml, or check how a call would be handled"
+}
+
+// Execute runs the policy command
+func (c *PolicyCommand) Execute(args []string, chatAgent *agent.Agent) error {
+	if chatAgent == nil {
+		return errors.New("agent not available")
+	}
+	if len(args) == 0 {
+		return c.show(chatAgent)
+	}
+
+	switch args[0] {
+	case "check":
+		if len(args) < 2 {
+			return fmt.Errorf("usage: /policy check <category|tool> [command|path|url]")
+		}
+		return c.check(chatAgent, args[1], strings.Join(args[2:], " "))
+	case "help", "-h", "--help":
+		c.showHelp()
+	default:
+		return fmt.Errorf("unknown subcommand: %s. Use '/policy help' for usage", args[0])
+	}
+	return nil
+}
+
+func (c *PolicyCommand) show(chatAgent *agent.Agent) error {
+	policy, err := chatAgent.ToolPolicy()
+	if err != nil {
+		fmt.Printf("\n[policy] %v\n", err)
+		fmt.Println("       Every tool call is refused until the file is fixed or removed.")
+		return nil
+	}
+	if policy == nil || (len(policy.Rules) == 0 && policy.Sandbox == nil) {
+		fmt.Printf("\n[policy] No rules in %s. Built-in security checks apply.\n", toolpolicy.FileName)
+		fmt.Println("       Use '/policy help' for the rule syntax.")
+		return nil
+	}
+
+	fmt.Printf("\n[policy] Effective rules from %s:\n", policy.Path)
+	summary := policy.Summary()
+	for _, action := range []toolpolicy.Action{toolpolicy.Deny, toolpolicy.Ask, toolpolicy.Allow} {
+		for _, rule := range summary[action] {
+			fmt.Printf("       %2d. %s\n", rule.Position, rule.String())
+		}
+	}
+	fmt.Println()
+	fmt.Println("       deny overrides ask, ask overrides allow. Calls no rule matches use the built-in security checks.")
+	showSandbox(policy.Sandbox)
+	return nil
+}
+
+// showSandbox describes the policy's shell sandbox and whether it can run.
+func showSandbox(s *toolpolicy.Sandbox) {
+	if s == nil {
+		return
+	}
+	scope := "every shell command"
+	if len(s.Commands) > 0 {
+		scope = "shell commands matching " + strings.Join(s.Commands, ", ")
+	}
+	fmt.Printf("\n[policy] Sandbox: %s\n", scope)
+	executor, err := sandbox.New(agent.SandboxConfig(s))
+	if err != nil {
+		fmt.Printf("       Unavailable: %v. Sandboxed commands are refused.\n", err)
+		return
+	}
+	fmt.Printf("       Runs in %s with only the workspace writable.\n", executor.Describe())
+}
+
+func (c *PolicyCommand) check(chatAgent *agent.Agent, target, subject string) error {
+	policy, err := chatAgent.ToolPolicy()
+	if err != nil {
+		return err
+	}
+
+	toolName, args := checkCall(target, subject)
+	decision := policy.Evaluate(toolName, args, chatAgent.GetWorkspaceRoot())
+	if !decision.Matched() {
+		fmt.Printf("\n[policy] %s: no rule matches; built-in security checks apply\n", toolName)
+	} else {
+		fmt.Printf("\n[policy] %s: %s (rule %d: %s)\n", toolName, decision.Action, decision.Rule.Position, decision.Rule.String())
+	}
+	if toolName == "shell_command" && policy != nil && policy.Sandbox.Applies(subject) {
+		fmt.Println("       Runs in the sandbox.")
+	}
+	return nil
+}
+
+// checkCall builds a representative tool call for a category or tool name.
+func checkCall(target, subject string) (string, map[string]interface{}) {
+	representative := map[string]string{
+		toolpolicy.CategoryShell: "shell_command",
+		toolpolicy.CategoryRead:  "read_file",
+		toolpolicy.CategoryWrite: "write_file",
+		toolpolicy.CategoryGit:   "git",
+		toolpolicy.CategoryWeb:   "fetch_url",
+	}
+	toolName := target
+	if tool, ok := representative[target]; ok {
+		toolName = tool
+	}
+
+	args := map[string]interface{}{}
+	if subject != "" {
+		for _, name := range []string{"command", "path", "operation", "url"} {
+			args[name] = subject
+		}
+	}
+	return toolName, args
+}
+
+func (c *PolicyCommand) showHelp() {
+	fmt.Println("Tool Policy")
+	fmt.Println("===========")
+	fmt.Println()
+	fmt.Printf("Rules in %s are checked before every tool call:\n", toolpolicy.FileName)
+	fmt.Println()
+	fmt.Println("  rules:")
+	fmt.Println("    - deny shell: rm*")
+	fmt.Println("    - ask write: outside ./src")
+	fmt.Println("    - \"allow read: **\"")
+	fmt.Println("  sandbox:                 # run shell commands isolated, without network")
+	fmt.Println("    backend: auto          # bubblewrap, podman or docker")
+	fmt.Println()
+	fmt.Printf("Targets: %s, a tool name, or *\n", strings.Join(toolpolicy.Categories, ", "))
+	fmt.Println()
+	fmt.Println("Available subcommands:")
+	fmt.Println("  /policy                               - Show the effective rules")
+	fmt.Println("  /policy check <category|tool> [value] - Show which rule applies to a call")
+}
@@ -0,0 +1,270 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/alantheprice/ledit/pkg/agent"
+	"github.com/alantheprice/ledit/pkg/credentials"
+	"github.com/alantheprice/ledit/pkg/forge"
+	"golang.org/x/term"
+)
+
+// PRCommand opens pull requests (GitLab: merge requests) for the current
+// branch and turns their review comments into todos.
+type PRCommand struct{}
+
+// Name returns the command name
+func (c *PRCommand) Name() string {
+	return "pr"
+}
+
+// Description returns the command description
+func (c *PRCommand) Description() string {
+	return "Pull requests on GitHub/GitLab: /pr [status] | create [--draft] [--base <branch>] [title] | comments [n] [--todos] | token <github|gitlab|jira>"
+}
+
+// Execute runs the pr command
+func (c *PRCommand) Execute(args []string, chatAgent *agent.Agent) error {
+	if chatAgent == nil {
+		return fmt.Errorf("agent not available")
+	}
+	sub := "status"
+	if len(args) > 0 {
+		sub = strings.ToLower(args[0])
+		args = args[1:]
+	}
+
+	switch sub {
+	case "help", "-h", "--help":
+		printPRHelp()
+		return nil
+	case "token":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: /pr token <github|gitlab|jira>")
+		}
+		return storeForgeToken(strings.ToLower(args[0]))
+	}
+
+	ws, client, err := chatAgent.OpenForge()
+	if err != nil {
+		if errors.Is(err, forge.ErrNoToken) {
+			return fmt.Errorf("%w. Use '/pr help' for usage", err)
+		}
+		return err
+	}
+	ctx := context.Background()
+
+	switch sub {
+	case "status":
+		return printPRStatus(ctx, chatAgent, ws, client)
+	case "create", "open":
+		return createPR(ctx, chatAgent, ws, client, args)
+	case "comments", "review":
+		return showPRComments(ctx, ws, client, args)
+	default:
+		return fmt.Errorf("unknown subcommand: %s. Use '/pr help' for usage", sub)
+	}
+}
+
+func printPRHelp() {
+	fmt.Print(normalizeNewlines(`Usage:
+  /pr                          Show the branch and its open pull request
+  /pr create [title]           Open a pull request for the current branch
+      --draft                  Open it as a draft
+      --base <branch>          Target branch (default: the remote's default branch)
+  /pr comments [n] [--todos]   List review comments; --todos adds a todo per thread
+  /pr token <kind>             Store a github, gitlab or jira API token (or set
+                               GITHUB_TOKEN / GITLAB_TOKEN / JIRA_API_TOKEN)
+
+The forge is detected from the origin remote. Add self-managed hosts to
+forge_hosts in the config, e.g. {"git.example.com": "gitlab"}.
+`))
+}
+
+func printPRStatus(ctx context.Context, chatAgent *agent.Agent, ws *forge.Workspace, client forge.Forge) error {
+	pushed := "not pushed"
+	if ws.IsPushed(chatAgent.GetWorkspaceRoot()) {
+		pushed = "pushed"
+	}
+	fmt.Printf("[pr] %s on %s, branch %s (%s), base %s\r\n", ws.Repo.FullName(), ws.Repo.Host, ws.Branch, pushed, ws.Base)
+	pr, err := client.FindPullRequest(ctx, ws.Branch)
+	if err != nil {
+		return err
+	}
+	if pr == nil {
+		fmt.Print("[pr] No open pull request. Open one with /pr create [title]\r\n")
+		return nil
+	}
+	draft := ""
+	if pr.Draft {
+		draft = " (draft)"
+	}
+	fmt.Printf("[pr] #%d%s %s\r\n[pr] %s\r\n", pr.Number, draft, pr.Title, pr.URL)
+	return nil
+}
+
+func createPR(ctx context.Context, chatAgent *agent.Agent, ws *forge.Workspace, client forge.Forge, args []string) error {
+	req := forge.CreateRequest{Head: ws.Branch, Base: ws.Base}
+	var titleWords []string
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--draft":
+			req.Draft = true
+		case "--base":
+			if i+1 >= len(args) {
+				return fmt.Errorf("--base requires a branch name")
+			}
+			i++
+			req.Base = args[i]
+		default:
+			titleWords = append(titleWords, args[i])
+		}
+	}
+	if ws.Branch == req.Base {
+		return fmt.Errorf("current branch %s is the base branch; create a feature branch first", ws.Branch)
+	}
+	if existing, err := client.FindPullRequest(ctx, ws.Branch); err != nil {
+		return err
+	} else if existing != nil {
+		fmt.Printf("[pr] #%d already open for %s: %s\r\n", existing.Number, ws.Branch, existing.URL)
+		return nil
+	}
+
+	title, body := defaultPRText(req.Base)
+	if len(titleWords) > 0 {
+		title = strings.Join(titleWords, " ")
+	}
+	reader := bufio.NewReader(os.Stdin)
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		if answer := promptPRLine(reader, fmt.Sprintf("Title [%s]: ", title)); answer != "" {
+			title = answer
+		}
+	}
+	if strings.TrimSpace(title) == "" {
+		return fmt.Errorf("usage: /pr create [--draft] [--base <branch>] <title>")
+	}
+	req.Title = title
+	req.Body = body
+
+	dir := chatAgent.GetWorkspaceRoot()
+	if !ws.IsPushed(dir) {
+		if term.IsTerminal(int(os.Stdin.Fd())) {
+			answer := promptPRLine(reader, fmt.Sprintf("Push %s to %s first? [Y/n]: ", ws.Branch, ws.Remote))
+			if strings.EqualFold(answer, "n") || strings.EqualFold(answer, "no") {
+				return fmt.Errorf("branch %s is not pushed to %s", ws.Branch, ws.Remote)
+			}
+		}
+		fmt.Printf("[pr] Pushing %s to %s...\r\n", ws.Branch, ws.Remote)
+		if err := ws.Push(ctx, dir); err != nil {
+			return err
+		}
+	}
+
+	pr, err := client.CreatePullRequest(ctx, req)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("[pr] Opened #%d (%s → %s): %s\r\n", pr.Number, req.Head, req.Base, pr.URL)
+	return nil
+}
+
+// defaultPRText proposes a title and description from the commits between
+// base and HEAD: the subject of a single commit, or the branch's subjects as
+// a list.
+func defaultPRText(base string) (string, string) {
+	out, err := exec.Command("git", "log", "--reverse", "--format=%s", "origin/"+base+"..HEAD").Output()
+	if err != nil {
+		return "", ""
+	}
+	subjects := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if len(subjects) == 0 || subjects[0] == "" {
+		return "", ""
+	}
+	if len(subjects) == 1 {
+		return subjects[0], ""
+	}
+	var body strings.Builder
+	for _, subject := range subjects {
+		fmt.Fprintf(&body, "- %s\n", subject)
+	}
+	return "", body.String()
+}
+
+func promptPRLine(reader *bufio.Reader, prompt string) string {
+	fmt.Print(prompt)
+	line, _ := reader.ReadString('\n')
+	return strings.TrimSpace(line)
+}
+
+func showPRComments(ctx context.Context, ws *forge.Workspace, client forge.Forge, args []string) error {
+	number, addTodos := 0, false
+	for _, arg := range args {
+		if arg == "--todos" {
+			addTodos = true
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimPrefix(arg, "#"))
+		if err != nil || n <= 0 {
+			return fmt.Errorf("usage: /pr comments [n] [--todos]")
+		}
+		number = n
+	}
+	number, err := agent.ResolvePullRequest(ctx, ws, client, number)
+	if err != nil {
+		return err
+	}
+	comments, err := client.ListComments(ctx, number)
+	if err != nil {
+		return err
+	}
+	if len(comments) == 0 {
+		fmt.Printf("[pr] #%d has no comments\r\n", number)
+		return nil
+	}
+	fmt.Printf("[pr] #%d: %d comments\r\n", number, len(comments))
+	fmt.Print(normalizeNewlines(agent.FormatPullRequestComments(comments)))
+	if !addTodos {
+		fmt.Print("[pr] Add them as todos with /pr comments --todos\r\n")
+		return nil
+	}
+	added, err := agent.AddPullRequestTodos(number, comments)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("[pr] Added %d todos; see /todos\r\n", added)
+	return nil
+}
+
+// storeForgeToken reads an API token with hidden input and stores it in the
+// credential backend.
+func storeForgeToken(kind string) error {
+	if kind != forge.KindGitHub && kind != forge.KindGitLab && kind != forge.KindJira {
+		return fmt.Errorf("unknown forge %q: use github, gitlab or jira", kind)
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return fmt.Errorf("/pr token needs a terminal; set %s instead", credentials.ProviderEnvVar(kind))
+	}
+	fmt.Printf("[pr] %s token (input hidden): ", kind)
+	token, err := term.ReadPassword(int(syscall.Stdin))
+	fmt.Print("\r\n")
+	if err != nil {
+		return fmt.Errorf("failed to read token: %w", err)
+	}
+	value := strings.TrimSpace(string(token))
+	if value == "" {
+		return fmt.Errorf("no token entered")
+	}
+	if err := credentials.SetToActiveBackend(kind, value); err != nil {
+		return fmt.Errorf("failed to store token: %w", err)
+	}
+	fmt.Printf("[pr] Stored %s token\r\n", kind)
+	return nil
+}
@@ -0,0 +1,129 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/alantheprice/ledit/pkg/agent"
+)
+
+// PromptCommand implements the /prompt slash command
+type PromptCommand struct{}
+
+// Name returns the command name
+func (c *PromptCommand) Name() string {
+	return "prompt"
+}
+
+// Description returns the command description
+func (c *PromptCommand) Description() string {
+	return "Inspect the layers of the system prompt and override one from a file for this session"
+}
+
+// Execute runs the prompt command
+func (c *PromptCommand) Execute(args []string, chatAgent *agent.Agent) error {
+	if chatAgent == nil {
+		return errors.New("agent not available")
+	}
+	if len(args) == 0 {
+		c.list(chatAgent)
+		return nil
+	}
+
+	switch args[0] {
+	case "show":
+		if len(args) == 1 {
+			c.list(chatAgent)
+			return nil
+		}
+		layer, ok := findPromptLayer(chatAgent.PromptLayers(), args[1])
+		if !ok {
+			return fmt.Errorf("no %q layer in the system prompt. Use '/prompt show' to list layers", args[1])
+		}
+		fmt.Printf("\n[prompt] %s (%s):\n\n%s\n", layer.Name, promptLayerSource(layer), promptLayerText(layer))
+	case "override":
+		var name, path string
+		switch len(args) {
+		case 2:
+			// A bare file replaces the first layer: the base or persona prompt
+			name, path = chatAgent.PromptLayers()[0].Name, args[1]
+		case 3:
+			name, path = args[1], args[2]
+		default:
+			return errors.New("usage: /prompt override [layer] <file>")
+		}
+		if err := chatAgent.OverridePromptLayer(name, path); err != nil {
+			return err
+		}
+		fmt.Printf("\n[prompt] The %s layer now comes from %s; edits to the file apply from the next request\n", strings.ToLower(name), path)
+	case "reset":
+		if removed := chatAgent.ResetPromptOverrides(args[1:]...); removed == 0 {
+			fmt.Println("\n[prompt] No overrides to reset")
+		} else {
+			fmt.Printf("\n[prompt] Reset %d override(s)\n", removed)
+		}
+	case "help", "-h", "--help":
+		c.showHelp()
+	default:
+		return fmt.Errorf("unknown subcommand: %s. Use '/prompt help' for usage", args[0])
+	}
+	return nil
+}
+
+func (c *PromptCommand) list(chatAgent *agent.Agent) {
+	layers := chatAgent.PromptLayers()
+	total := 0
+	for _, layer := range layers {
+		total += agent.EstimateTokens(layer.Content)
+	}
+
+	fmt.Printf("\n[prompt] %d layer(s), ~%s tokens\n", len(layers), formatTokenCount(total))
+	fmt.Printf("\n%-13s %7s  %s\n", "LAYER", "TOKENS", "SOURCE")
+	for _, layer := range layers {
+		fmt.Printf("%-13s %7s  %s\n", layer.Name, formatTokenCount(agent.EstimateTokens(layer.Content)), promptLayerSource(layer))
+	}
+	fmt.Println("\nUse '/prompt show <layer>' to read a layer or '/prompt override <layer> <file>' to replace one.")
+}
+
+func (c *PromptCommand) showHelp() {
+	fmt.Println("System Prompt Layers")
+	fmt.Println("====================")
+	fmt.Println()
+	fmt.Println("The system prompt is built from layers: the base (or persona) prompt, the date and time,")
+	fmt.Println("project instructions, memories and skills, plus the pinned context and tool documentation")
+	fmt.Println("added to each request. Overrides last for the session and are re-read when the file changes.")
+	fmt.Println()
+	fmt.Println("Available subcommands:")
+	fmt.Println("  /prompt                          - List the layers with their size and source")
+	fmt.Println("  /prompt show <layer>             - Print a layer as the model sees it")
+	fmt.Println("  /prompt override [layer] <file>  - Replace a layer (default: the base prompt) with a file")
+	fmt.Println("  /prompt reset [layer...]         - Drop overrides, or all of them when no layer is named")
+}
+
+// findPromptLayer looks a layer up by name, ignoring case.
+func findPromptLayer(layers []agent.PromptLayer, name string) (agent.PromptLayer, bool) {
+	for _, layer := range layers {
+		if strings.EqualFold(layer.Name, strings.TrimSpace(name)) {
+			return layer, true
+		}
+	}
+	return agent.PromptLayer{}, false
+}
+
+func promptLayerSource(layer agent.PromptLayer) string {
+	if layer.Override != "" {
+		return "overridden by " + layer.Override
+	}
+	return layer.Source
+}
+
+// promptLayerText strips the separators that join a layer to its neighbours.
+func promptLayerText(layer agent.PromptLayer) string {
+	text := strings.TrimSpace(layer.Content)
+	text = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(text, "---"), "---"))
+	if text == "" {
+		return "(empty)"
+	}
+	return text
+}
@@ -0,0 +1,28 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/alantheprice/ledit/pkg/agent"
+)
+
+func TestPromptLayerText(t *testing.T) {
+	layer := agent.PromptLayer{Content: "\n\n## Current Date and Time\n\nCurrent date: 2026-10-16\n\n---\n"}
+	if got := promptLayerText(layer); got != "## Current Date and Time\n\nCurrent date: 2026-10-16" {
+		t.Errorf("unexpected text %q", got)
+	}
+	if got := promptLayerText(agent.PromptLayer{Content: "\n\n---\n\n"}); got != "(empty)" {
+		t.Errorf("expected an empty layer, got %q", got)
+	}
+}
+
+func TestFindPromptLayer(t *testing.T) {
+	layers := []agent.PromptLayer{{Name: agent.PromptLayerBase}, {Name: agent.PromptLayerTools, Override: "tools.md"}}
+	layer, ok := findPromptLayer(layers, " Tools")
+	if !ok || promptLayerSource(layer) != "overridden by tools.md" {
+		t.Fatalf("unexpected layer %+v, %v", layer, ok)
+	}
+	if _, ok := findPromptLayer(layers, "skills"); ok {
+		t.Fatal("expected a missing layer not to be found")
+	}
+}
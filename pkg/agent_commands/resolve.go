@@ -0,0 +1,285 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/alantheprice/ledit/pkg/agent"
+	api "github.com/alantheprice/ledit/pkg/agent_api"
+	"github.com/alantheprice/ledit/pkg/factory"
+	"github.com/alantheprice/ledit/pkg/filediscovery"
+	"github.com/alantheprice/ledit/pkg/filesystem"
+	"github.com/alantheprice/ledit/pkg/fixtests"
+	gitops "github.com/alantheprice/ledit/pkg/git"
+	"golang.org/x/term"
+)
+
+// ResolveCommand walks through merge conflicts one hunk at a time, proposes
+// a resolution for each and applies only the ones the user approves.
+type ResolveCommand struct{}
+
+// Name returns the command name
+func (c *ResolveCommand) Name() string {
+	return "resolve"
+}
+
+// Description returns the command description
+func (c *ResolveCommand) Description() string {
+	return "Resolve merge/rebase conflicts with proposed resolutions you approve: /resolve [file...] [--no-validate]"
+}
+
+// errResolveQuit stops the walk through the remaining conflicts.
+var errResolveQuit = errors.New("quit")
+
+// conflictResolver holds the hooks of one /resolve run so the walk through
+// the conflicts does not depend on the terminal or the model.
+type conflictResolver struct {
+	root    string
+	op      gitops.Operation
+	propose func(path, content string, hunk gitops.ConflictHunk) (*gitops.ConflictProposal, error)
+	// choose shows the hunk and proposal and returns the resolution to
+	// apply, ok=false to skip the hunk, or errResolveQuit.
+	choose func(path string, index, total int, hunk gitops.ConflictHunk, proposal *gitops.ConflictProposal) (resolution string, ok bool, err error)
+}
+
+// Execute runs the resolve command
+func (c *ResolveCommand) Execute(args []string, chatAgent *agent.Agent) error {
+	if chatAgent == nil {
+		return fmt.Errorf("agent not available")
+	}
+	validate := true
+	var paths []string
+	for _, arg := range args {
+		switch arg {
+		case "--no-validate":
+			validate = false
+		case "help", "-h", "--help":
+			fmt.Print("[resolve] Usage: /resolve [file...] [--no-validate]\r\n" +
+				"  For each conflict: [a]ccept the proposal, keep [o]urs, [t]heirs or [b]oth, [s]kip, or [q]uit.\r\n" +
+				"  Fully resolved files are staged; the project build is checked afterwards.\r\n")
+			return nil
+		default:
+			paths = append(paths, arg)
+		}
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return fmt.Errorf("/resolve needs an interactive terminal to approve each resolution")
+	}
+
+	root := chatAgent.GetWorkspaceRoot()
+	conflicted, err := gitops.ConflictedFiles(root)
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		paths = conflicted
+	}
+	if len(paths) == 0 {
+		fmt.Print("[resolve] No merge conflicts\r\n")
+		return nil
+	}
+
+	client, err := factory.CreateProviderClient(api.ClientType(chatAgent.GetProvider()), chatAgent.GetModel())
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+	op := gitops.InProgressOperation(root)
+	reader := bufio.NewReader(os.Stdin)
+	r := &conflictResolver{
+		root: root,
+		op:   op,
+		propose: func(path, content string, hunk gitops.ConflictHunk) (*gitops.ConflictProposal, error) {
+			return gitops.ProposeConflictResolution(client, path, content, hunk, op)
+		},
+		choose: func(path string, index, total int, hunk gitops.ConflictHunk, proposal *gitops.ConflictProposal) (string, bool, error) {
+			printConflict(path, index, total, hunk, proposal)
+			return chooseResolution(reader, hunk, proposal)
+		},
+	}
+
+	resolvedFiles := 0
+	for _, path := range paths {
+		done, err := r.resolveFile(path)
+		if errors.Is(err, errResolveQuit) {
+			break
+		}
+		if err != nil {
+			fmt.Printf("[resolve] %s: %v\r\n", path, err)
+			continue
+		}
+		if done {
+			resolvedFiles++
+		}
+	}
+
+	remaining, err := gitops.ConflictedFiles(root)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("[resolve] %d file(s) resolved and staged, %d still conflicted\r\n", resolvedFiles, len(remaining))
+	if resolvedFiles > 0 && validate {
+		runConflictValidation(root)
+	}
+	if len(remaining) == 0 && op != gitops.OperationNone {
+		fmt.Printf("[resolve] All conflicts resolved. Continue the %s with: %s\r\n", op, op.ContinueCommand())
+	}
+	return nil
+}
+
+// resolveFile offers a resolution for every conflict in path, writes the
+// approved ones back and stages the file once no conflicts remain. It
+// reports whether the file was fully resolved.
+func (r *conflictResolver) resolveFile(path string) (bool, error) {
+	fullPath := path
+	if !filepath.IsAbs(fullPath) {
+		fullPath = filepath.Join(r.root, path)
+	}
+	data, err := os.ReadFile(fullPath)
+	if err != nil {
+		return false, err
+	}
+	content := string(data)
+	hunks, err := gitops.ParseConflicts(content)
+	if err != nil {
+		return false, err
+	}
+	if len(hunks) == 0 {
+		fmt.Printf("[resolve] %s has no conflict markers\r\n", path)
+		return false, nil
+	}
+
+	resolutions := make(map[int]string)
+	var quit error
+	for i, hunk := range hunks {
+		proposal, err := r.propose(path, content, hunk)
+		if err != nil {
+			fmt.Printf("[resolve] No proposal for %s conflict %d: %v\r\n", path, i+1, err)
+		}
+		resolution, ok, err := r.choose(path, i+1, len(hunks), hunk, proposal)
+		if err != nil {
+			quit = err
+			break
+		}
+		if ok {
+			resolutions[i] = resolution
+		}
+	}
+
+	if len(resolutions) > 0 {
+		resolved, err := gitops.ApplyConflictResolutions(content, resolutions)
+		if err != nil {
+			return false, err
+		}
+		info, err := os.Stat(fullPath)
+		if err != nil {
+			return false, err
+		}
+		// Record the write as ledit's own, so the agent's next edit of the
+		// file is not refused as a change made outside ledit
+		locks := filesystem.Locks()
+		unlock, err := locks.Lock(fullPath)
+		if err != nil {
+			return false, err
+		}
+		err = os.WriteFile(fullPath, []byte(resolved), info.Mode().Perm())
+		if err == nil {
+			locks.RecordWrite(fullPath, []byte(resolved))
+		}
+		unlock()
+		if err != nil {
+			return false, err
+		}
+	}
+
+	done := len(resolutions) == len(hunks)
+	if done {
+		cmd := exec.Command("git", "add", "--", path)
+		cmd.Dir = r.root
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return false, fmt.Errorf("git add: %s", strings.TrimSpace(string(out)))
+		}
+		fmt.Printf("[resolve] %s resolved and staged\r\n", path)
+	} else if len(resolutions) > 0 {
+		fmt.Printf("[resolve] %s: %d of %d conflicts resolved\r\n", path, len(resolutions), len(hunks))
+	}
+	return done, quit
+}
+
+func printConflict(path string, index, total int, hunk gitops.ConflictHunk, proposal *gitops.ConflictProposal) {
+	fmt.Printf("\r\n[resolve] %s: conflict %d/%d (lines %d-%d)\r\n", path, index, total, hunk.StartLine, hunk.EndLine)
+	printConflictSide("ours "+hunk.OursLabel, hunk.Ours)
+	if hunk.HasBase {
+		printConflictSide("base", hunk.Base)
+	}
+	printConflictSide("theirs "+hunk.TheirsLabel, hunk.Theirs)
+	if proposal != nil {
+		printConflictSide("proposed", proposal.Resolution)
+		if proposal.Explanation != "" {
+			fmt.Printf("  %s\r\n", proposal.Explanation)
+		}
+	}
+}
+
+func printConflictSide(title, text string) {
+	fmt.Printf("--- %s ---\r\n", strings.TrimSpace(title))
+	if text == "" {
+		fmt.Print("  (empty)\r\n")
+		return
+	}
+	fmt.Print(normalizeNewlines(text))
+}
+
+// chooseResolution asks which resolution to apply for hunk.
+func chooseResolution(reader *bufio.Reader, hunk gitops.ConflictHunk, proposal *gitops.ConflictProposal) (string, bool, error) {
+	prompt := "[a]ccept proposal, [o]urs, [t]heirs, [b]oth, [s]kip, [q]uit: "
+	if proposal == nil {
+		prompt = "[o]urs, [t]heirs, [b]oth, [s]kip, [q]uit: "
+	}
+	for {
+		fmt.Print(prompt)
+		line, err := reader.ReadString('\n')
+		answer := strings.ToLower(strings.TrimSpace(line))
+		switch {
+		case answer == "a" && proposal != nil:
+			return proposal.Resolution, true, nil
+		case answer == "o":
+			return hunk.Ours, true, nil
+		case answer == "t":
+			return hunk.Theirs, true, nil
+		case answer == "b":
+			return hunk.Ours + hunk.Theirs, true, nil
+		case answer == "s":
+			return "", false, nil
+		case answer == "q":
+			return "", false, errResolveQuit
+		}
+		if err != nil {
+			return "", false, errResolveQuit
+		}
+	}
+}
+
+// runConflictValidation builds the project after conflicts were resolved.
+func runConflictValidation(root string) {
+	command := filediscovery.BuildCommand(root)
+	if command == "" {
+		fmt.Print("[resolve] No build command detected; check the result before continuing\r\n")
+		return
+	}
+	fmt.Printf("[resolve] Validating with `%s`...\r\n", command)
+	result, err := fixtests.RunTests(context.Background(), root, command, "")
+	switch {
+	case err != nil:
+		fmt.Printf("[resolve] Validation could not run: %v\r\n", err)
+	case result.Passed:
+		fmt.Print("[resolve] [OK] Build passes\r\n")
+	default:
+		fmt.Printf("[resolve] [FAIL] Build fails (exit %d):\r\n%s\r\n", result.ExitCode, normalizeNewlines(strings.TrimSpace(result.Output)))
+	}
+}
@@ -0,0 +1,96 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alantheprice/ledit/pkg/agent"
+	"github.com/alantheprice/ledit/pkg/filesystem"
+	"github.com/alantheprice/ledit/pkg/snapshot"
+)
+
+// SnapshotCommand creates, lists and restores whole-workspace snapshots.
+type SnapshotCommand struct{}
+
+// Name returns the command name
+func (s *SnapshotCommand) Name() string {
+	return "snapshot"
+}
+
+// Description returns the command description
+func (s *SnapshotCommand) Description() string {
+	return "Snapshot the whole workspace: /snapshot [create] [name] | list | restore <id> [--dry-run] | delete <id>"
+}
+
+// Execute runs the snapshot command
+func (s *SnapshotCommand) Execute(args []string, chatAgent *agent.Agent) error {
+	root := memoryProjectRoot(chatAgent)
+
+	sub := "create"
+	if len(args) > 0 {
+		sub = strings.ToLower(args[0])
+	}
+	switch sub {
+	case "list", "ls":
+		snapshots, err := snapshot.List(root)
+		if err != nil {
+			return err
+		}
+		if len(snapshots) == 0 {
+			fmt.Print("[snapshot] No snapshots yet. Create one with /snapshot [name]\r\n")
+			return nil
+		}
+		fmt.Print("[snapshot] Snapshots:\r\n")
+		for _, snap := range snapshots {
+			fmt.Printf("  %-4s %-24s %s  %s\r\n", snap.ID, snap.Name, snap.Created.Format("01-02 15:04"), snap.Summary())
+		}
+		return nil
+	case "restore":
+		if len(args) < 2 {
+			return fmt.Errorf("usage: /snapshot restore <id|name> [--dry-run]")
+		}
+		dryRun := len(args) > 2 && args[2] == "--dry-run"
+		// Restored files are ledit's own changes, not edits made outside it
+		locks := filesystem.Locks()
+		seen := locks.Snapshot()
+		result, err := snapshot.Restore(root, args[1], snapshot.RestoreOptions{DryRun: dryRun})
+		locks.Adopt(seen)
+		if err != nil {
+			return err
+		}
+		if dryRun {
+			for _, path := range result.Restored {
+				fmt.Printf("  restore %s\r\n", path)
+			}
+			for _, path := range result.Deleted {
+				fmt.Printf("  delete  %s\r\n", path)
+			}
+			fmt.Printf("[snapshot] Would restore %d and delete %d files; %d unchanged\r\n", len(result.Restored), len(result.Deleted), result.Unchanged)
+			return nil
+		}
+		fmt.Printf("[snapshot] Restored %s: %d files restored, %d deleted, %d unchanged\r\n",
+			args[1], len(result.Restored), len(result.Deleted), result.Unchanged)
+		fmt.Printf("[snapshot] The previous state was saved as snapshot %s; undo with /snapshot restore %s\r\n", result.Backup.ID, result.Backup.ID)
+		return nil
+	case "delete", "rm":
+		if len(args) < 2 {
+			return fmt.Errorf("usage: /snapshot delete <id|name>")
+		}
+		snap, err := snapshot.Delete(root, args[1])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("[snapshot] Deleted %s\r\n", snap.Label())
+		return nil
+	case "create":
+		args = args[min(1, len(args)):]
+	}
+
+	snap, err := snapshot.Create(root, strings.Join(args, " "))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("[snapshot] Created %s: %s\r\n", snap.Label(), snap.Summary())
+	fmt.Printf("[snapshot] Return to it with /snapshot restore %s\r\n", snap.ID)
+	return nil
+}
@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alantheprice/ledit/pkg/agent"
+	"github.com/alantheprice/ledit/pkg/configuration"
+	"github.com/alantheprice/ledit/pkg/console"
+)
+
+// ThemeCommand lists the console color themes and switches between them.
+type ThemeCommand struct{}
+
+// Name returns the command name
+func (c *ThemeCommand) Name() string {
+	return "theme"
+}
+
+// Description returns the command description
+func (c *ThemeCommand) Description() string {
+	return "Show or set the console color theme: /theme [default|light|solarized|colorblind|no-color]"
+}
+
+// Execute runs the theme command
+func (c *ThemeCommand) Execute(args []string, chatAgent *agent.Agent) error {
+	if len(args) == 0 || strings.EqualFold(args[0], "list") {
+		printThemes()
+		return nil
+	}
+	if len(args) > 1 {
+		return fmt.Errorf("usage: /theme [%s]", strings.Join(console.PaletteNames(), "|"))
+	}
+
+	palette, ok := console.LookupPalette(args[0])
+	if !ok {
+		return fmt.Errorf("unknown theme %q: must be one of %s", args[0], strings.Join(console.PaletteNames(), ", "))
+	}
+	console.SetPalette(palette.Name)
+
+	if chatAgent != nil && chatAgent.GetConfigManager() != nil {
+		if err := chatAgent.GetConfigManager().UpdateConfig(func(cfg *configuration.Config) error {
+			cfg.ColorPalette = palette.Name
+			return nil
+		}); err != nil {
+			return fmt.Errorf("theme applied but failed to save config: %w", err)
+		}
+	}
+	fmt.Printf("[theme] Using %s\r\n", palette.Name)
+	if console.NoColorRequested() && palette.Name != console.PaletteNoColor {
+		fmt.Printf("[theme] NO_COLOR is set, so colors stay off until it is unset\r\n")
+	}
+	return nil
+}
+
+// printThemes lists the built-in themes with a sample of their colors and
+// marks the active one.
+func printThemes() {
+	current := console.ActivePalette().Name
+	// Samples are shown even while no-color is active so themes can be
+	// compared; only NO_COLOR suppresses them.
+	colors := !console.NoColorRequested()
+	for _, name := range console.PaletteNames() {
+		marker := " "
+		if name == current {
+			marker = "*"
+		}
+		palette, _ := console.LookupPalette(name)
+		sample := ""
+		if colors && palette.Name != console.PaletteNoColor {
+			reset := console.ColorReset
+			sample = fmt.Sprintf("  %s+added%s %s-removed%s %sheading%s %scomment%s",
+				palette.Added, reset, palette.Removed, reset, palette.Accent, reset, palette.Muted, reset)
+		}
+		fmt.Printf("%s %-11s%s\r\n", marker, name, sample)
+	}
+}
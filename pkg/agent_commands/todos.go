@@ -0,0 +1,205 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alantheprice/ledit/pkg/agent"
+	tools "github.com/alantheprice/ledit/pkg/agent_tools"
+	"golang.org/x/term"
+)
+
+// TodosCommand shows the workspace todo list shared with the agent's
+// TodoWrite tool and lets the user change todo statuses.
+type TodosCommand struct{}
+
+// Name returns the command name
+func (c *TodosCommand) Name() string {
+	return "todos"
+}
+
+// Description returns the command description
+func (c *TodosCommand) Description() string {
+	return "Show and update the workspace todo list: /todos [toggle|start|done|pending|cancel <n>] | add <text> | remove <n> | clear"
+}
+
+// Execute runs the todos command
+func (c *TodosCommand) Execute(args []string, chatAgent *agent.Agent) error {
+	if len(args) == 0 {
+		todos := tools.TodoRead()
+		printTodos(todos)
+		if len(todos) > 0 && term.IsTerminal(int(os.Stdin.Fd())) {
+			return toggleTodosInteractively()
+		}
+		return nil
+	}
+
+	sub := strings.ToLower(args[0])
+	switch sub {
+	case "list", "ls":
+		printTodos(tools.TodoRead())
+		return nil
+	case "help", "-h", "--help":
+		printTodosHelp()
+		return nil
+	case "add":
+		content := strings.TrimSpace(strings.Join(args[1:], " "))
+		if content == "" {
+			return fmt.Errorf("usage: /todos add <text>")
+		}
+		todos := tools.TodoRead()
+		todos = append(todos, tools.TodoItem{
+			ID:       fmt.Sprintf("todo-%d", time.Now().UnixNano()),
+			Content:  content,
+			Status:   "pending",
+			Priority: "medium",
+			Source:   "console",
+		})
+		return saveTodos(todos)
+	case "clear":
+		return saveTodos(nil)
+	}
+
+	if len(args) < 2 {
+		return fmt.Errorf("usage: /todos %s <n>. Use '/todos help' for usage", sub)
+	}
+	todos := tools.TodoRead()
+	index, err := todoIndex(args[1], len(todos))
+	if err != nil {
+		return err
+	}
+
+	switch sub {
+	case "toggle":
+		todos[index].Status = nextTodoStatus(todos[index].Status)
+	case "start":
+		todos[index].Status = "in_progress"
+	case "done", "complete":
+		todos[index].Status = "completed"
+	case "pending", "reopen":
+		todos[index].Status = "pending"
+	case "cancel":
+		todos[index].Status = "cancelled"
+	case "remove", "rm":
+		todos = append(todos[:index], todos[index+1:]...)
+	default:
+		return fmt.Errorf("unknown subcommand: %s. Use '/todos help' for usage", args[0])
+	}
+	return saveTodos(todos)
+}
+
+// toggleTodosInteractively advances the status of the todos the user picks
+// until an empty line is entered.
+func toggleTodosInteractively() error {
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		fmt.Print("\r\nToggle todo number (Enter to finish): ")
+		input, err := reader.ReadString('\n')
+		input = strings.TrimSpace(input)
+		if input == "" || strings.EqualFold(input, "q") {
+			return nil
+		}
+
+		todos := tools.TodoRead()
+		index, indexErr := todoIndex(input, len(todos))
+		if indexErr != nil {
+			fmt.Printf("[todos] %v\r\n", indexErr)
+		} else {
+			todos[index].Status = nextTodoStatus(todos[index].Status)
+			if saveErr := saveTodos(todos); saveErr != nil {
+				return saveErr
+			}
+		}
+		if err != nil {
+			return nil
+		}
+	}
+}
+
+func saveTodos(todos []tools.TodoItem) error {
+	err := tools.UpdateTodos(todos)
+	printTodos(tools.TodoRead())
+	if err != nil {
+		return fmt.Errorf("failed to save todos: %w", err)
+	}
+	return nil
+}
+
+func todoIndex(arg string, count int) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(arg))
+	if err != nil || n < 1 || n > count {
+		return 0, fmt.Errorf("invalid todo number %q (1-%d)", arg, count)
+	}
+	return n - 1, nil
+}
+
+// nextTodoStatus cycles pending -> in_progress -> completed -> pending.
+func nextTodoStatus(status string) string {
+	switch status {
+	case "pending":
+		return "in_progress"
+	case "in_progress":
+		return "completed"
+	default:
+		return "pending"
+	}
+}
+
+func todoStatusMarker(status string) string {
+	switch status {
+	case "in_progress":
+		return "[~]"
+	case "completed":
+		return "[x]"
+	case "cancelled":
+		return "[-]"
+	default:
+		return "[ ]"
+	}
+}
+
+func printTodos(todos []tools.TodoItem) {
+	if len(todos) == 0 {
+		fmt.Print("[todos] No todos. The agent adds them with TodoWrite, or use /todos add <text>\r\n")
+		return
+	}
+	location := "memory only"
+	if path := tools.TodoFilePath(); path != "" {
+		location = path
+	}
+	fmt.Printf("[todos] %d todos (%s):\r\n", len(todos), location)
+	for i, todo := range todos {
+		line := fmt.Sprintf("  %2d. %s %s", i+1, todoStatusMarker(todo.Status), todo.Content)
+		if todo.Priority != "" && todo.Priority != "medium" {
+			line += fmt.Sprintf(" (%s)", todo.Priority)
+		}
+		fmt.Printf("%s\r\n", line)
+
+		var meta []string
+		if todo.Source != "" {
+			meta = append(meta, "from: "+todo.Source)
+		}
+		if !todo.UpdatedAt.IsZero() {
+			meta = append(meta, "updated "+todo.UpdatedAt.Local().Format("2006-01-02 15:04"))
+		}
+		if len(meta) > 0 {
+			fmt.Printf("         %s\r\n", strings.Join(meta, " | "))
+		}
+	}
+}
+
+func printTodosHelp() {
+	fmt.Print("[todos] Usage:\r\n")
+	fmt.Print("  /todos                 Show todos; on a terminal, toggle statuses by number\r\n")
+	fmt.Print("  /todos toggle <n>      Cycle pending -> in progress -> completed\r\n")
+	fmt.Print("  /todos start|done|pending|cancel <n>\r\n")
+	fmt.Print("                         Set a todo's status\r\n")
+	fmt.Print("  /todos add <text>      Add a pending todo\r\n")
+	fmt.Print("  /todos remove <n>      Remove a todo\r\n")
+	fmt.Print("  /todos clear           Remove all todos\r\n")
+	fmt.Printf("Todos are saved to %s and reloaded when ledit starts in this workspace.\r\n", tools.TodoFileName)
+}
@@ -187,6 +187,18 @@ func (p *GenericProvider) SendChatRequest(messages []api.Message, tools []api.To
 		return nil, fmt.Errorf("failed to build chat request: %w", err)
 	}
 
+	response, err := p.sendChatRequest(requestBody)
+	if err != nil {
+		return nil, err
+	}
+	if p.usesStrictTools(tools) {
+		dropNullToolArguments(response)
+	}
+	return response, nil
+}
+
+// sendChatRequest posts a non-streaming request body and decodes the reply
+func (p *GenericProvider) sendChatRequest(requestBody []byte) (*api.ChatResponse, error) {
 	req, err := p.buildHTTPRequest(requestBody, false)
 	if err != nil {
 		// Log request on build error
@@ -258,6 +270,18 @@ func (p *GenericProvider) SendChatRequestStream(messages []api.Message, tools []
 		return nil, fmt.Errorf("failed to build chat request: %w", err)
 	}
 
+	response, err := p.sendChatRequestStream(requestBody, callback)
+	if err != nil {
+		return nil, err
+	}
+	if p.usesStrictTools(tools) {
+		dropNullToolArguments(response)
+	}
+	return response, nil
+}
+
+// sendChatRequestStream posts a streaming request body and assembles the reply
+func (p *GenericProvider) sendChatRequestStream(requestBody []byte, callback api.StreamCallback) (*api.ChatResponse, error) {
 	req, err := p.buildHTTPRequest(requestBody, true)
 	if err != nil {
 		// Log request on build error
@@ -395,6 +419,12 @@ func (p *GenericProvider) GetModelContextLimit() (int, error) {
 	return p.config.GetContextLimit(p.model), nil
 }
 
+// GetMaxRequestBytes returns the largest request body the provider accepts, or 0
+// when the limit is unknown.
+func (p *GenericProvider) GetMaxRequestBytes() int {
+	return p.config.MaxRequestBytes
+}
+
 // ListModels returns available models
 // Priority:
 // 1. Fetch from provider API models endpoint (primary source of truth)
@@ -657,6 +687,15 @@ func (p *GenericProvider) ResetTPSStats() {
 
 // buildChatRequest builds the request body for chat completion
 func (p *GenericProvider) buildChatRequest(messages []api.Message, tools []api.Tool, reasoning string, disableThinking bool, stream bool) ([]byte, error) {
+	request, err := p.newChatRequest(messages, tools, reasoning, disableThinking, stream)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(request)
+}
+
+// newChatRequest builds the request payload before it is encoded
+func (p *GenericProvider) newChatRequest(messages []api.Message, tools []api.Tool, reasoning string, disableThinking bool, stream bool) (map[string]interface{}, error) {
 	if err := p.ensureModel(); err != nil {
 		return nil, fmt.Errorf("ensure model: %w", err)
 	}
@@ -729,10 +768,10 @@ func (p *GenericProvider) buildChatRequest(messages []api.Message, tools []api.T
 
 	// Add tools if provided
 	if len(tools) > 0 {
-		request["tools"] = tools
+		request["tools"] = p.requestTools(tools)
 	}
 
-	return json.Marshal(request)
+	return request, nil
 }
 
 func applyReasoningEffort(model, reasoning string, request map[string]interface{}) {
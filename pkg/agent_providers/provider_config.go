@@ -21,6 +21,11 @@ type ProviderConfig struct {
 	Models     ModelConfig       `json:"models"`
 	Retry      RetryConfig       `json:"retry"`
 	Cost       CostConfig        `json:"cost"`
+	// MaxRequestBytes is the largest request body the provider accepts; 0 means no known limit.
+	MaxRequestBytes int `json:"max_request_bytes,omitempty"`
+	// StructuredOutputs overrides the capability registry's structured output
+	// mode for every model of this provider: json_schema, grammar, or none.
+	StructuredOutputs string `json:"structured_outputs,omitempty"`
 }
 
 // AuthConfig defines authentication configuration
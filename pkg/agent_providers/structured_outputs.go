@@ -0,0 +1,335 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	api "github.com/alantheprice/ledit/pkg/agent_api"
+	modelsettings "github.com/alantheprice/ledit/pkg/model_settings"
+)
+
+// strictDroppedKeywords are JSON Schema keywords strict mode rejects but that
+// only narrow a value; they are dropped and the tool validates them instead.
+var strictDroppedKeywords = map[string]bool{
+	"default":       true,
+	"examples":      true,
+	"minLength":     true,
+	"maxLength":     true,
+	"minProperties": true,
+	"maxProperties": true,
+}
+
+// strictIncompatibleKeywords change what a schema accepts in ways strict mode
+// can't express, so schemas using them are sent unchanged.
+var strictIncompatibleKeywords = map[string]bool{
+	"oneOf":             true,
+	"allOf":             true,
+	"not":               true,
+	"if":                true,
+	"then":              true,
+	"else":              true,
+	"patternProperties": true,
+	"dependentRequired": true,
+	"dependentSchemas":  true,
+}
+
+// structuredOutputs returns how replies from the current model can be
+// constrained: the provider config wins, then the capability registry.
+func (p *GenericProvider) structuredOutputs() string {
+	mode := strings.ToLower(strings.TrimSpace(p.config.StructuredOutputs))
+	if mode == "" {
+		return modelsettings.ResolveModelCapabilities(p.config.Name, p.model).StructuredOutputs
+	}
+	if mode == modelsettings.StructuredOutputsNone {
+		return ""
+	}
+	return mode
+}
+
+// usesStrictTools reports whether tools are sent with strict schemas.
+func (p *GenericProvider) usesStrictTools(tools []api.Tool) bool {
+	return len(tools) > 0 && p.structuredOutputs() == modelsettings.StructuredOutputsJSONSchema
+}
+
+// requestTools marks tools strict when the model enforces JSON schemas, so
+// their arguments always parse and match the schema. Tools whose schema can't
+// be made strict are sent unchanged. Grammar-based servers already constrain
+// tool calls themselves.
+func (p *GenericProvider) requestTools(tools []api.Tool) interface{} {
+	if !p.usesStrictTools(tools) {
+		return tools
+	}
+	converted := make([]map[string]interface{}, 0, len(tools))
+	for _, tool := range tools {
+		function := map[string]interface{}{
+			"name":        tool.Function.Name,
+			"description": tool.Function.Description,
+			"parameters":  tool.Function.Parameters,
+		}
+		if schema, ok := strictJSONSchema(tool.Function.Parameters); ok {
+			function["parameters"] = schema
+			function["strict"] = true
+		}
+		converted = append(converted, map[string]interface{}{
+			"type":     tool.Type,
+			"function": function,
+		})
+	}
+	return converted
+}
+
+// SendStructuredRequest sends a non-streaming request without tools for a
+// reply matching format. The schema is enforced when the model supports
+// structured outputs; other models get a plain request, so the prompt should
+// still describe the expected JSON and callers should validate the reply.
+func (p *GenericProvider) SendStructuredRequest(messages []api.Message, format api.ResponseFormat) (*api.ChatResponse, error) {
+	request, err := p.newChatRequest(messages, nil, "", false, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build chat request: %w", err)
+	}
+	responseFormat, strict := p.responseFormat(format)
+	if responseFormat != nil {
+		request["response_format"] = responseFormat
+	}
+	requestBody, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build chat request: %w", err)
+	}
+
+	response, err := p.sendChatRequest(requestBody)
+	if err != nil {
+		return nil, err
+	}
+	if strict {
+		for i := range response.Choices {
+			response.Choices[i].Message.Content = dropNullJSONFields(response.Choices[i].Message.Content)
+		}
+	}
+	return response, nil
+}
+
+// responseFormat returns the response_format for format under the current
+// structured output mode, and whether the schema was sent as strict.
+func (p *GenericProvider) responseFormat(format api.ResponseFormat) (map[string]interface{}, bool) {
+	name := format.Name
+	if name == "" {
+		name = "response"
+	}
+	jsonSchema := map[string]interface{}{"name": name, "schema": format.Schema}
+	strict := false
+
+	switch p.structuredOutputs() {
+	case modelsettings.StructuredOutputsJSONSchema:
+		if schema, ok := strictJSONSchema(format.Schema); ok {
+			jsonSchema["schema"] = schema
+			jsonSchema["strict"] = true
+			strict = true
+		}
+	case modelsettings.StructuredOutputsGrammar:
+		// llama.cpp-based servers compile the schema into a sampling grammar
+	default:
+		return nil, false
+	}
+	return map[string]interface{}{"type": "json_schema", "json_schema": jsonSchema}, strict
+}
+
+// strictJSONSchema rewrites an object schema into the subset strict
+// structured outputs accept: every object lists all of its properties as
+// required and allows no others, and optional properties accept null
+// instead. It reports false when the schema can't be expressed that way.
+func strictJSONSchema(schema interface{}) (map[string]interface{}, bool) {
+	if schema == nil {
+		return nil, false
+	}
+	// Round-trip through JSON so nested values have uniform types
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return nil, false
+	}
+	var root map[string]interface{}
+	if err := json.Unmarshal(data, &root); err != nil || !schemaHasType(root, "object") {
+		return nil, false
+	}
+	return strictSchemaNode(root)
+}
+
+func strictSchemaNode(schema map[string]interface{}) (map[string]interface{}, bool) {
+	out := make(map[string]interface{}, len(schema))
+	for key, value := range schema {
+		if strictIncompatibleKeywords[key] {
+			return nil, false
+		}
+		if !strictDroppedKeywords[key] {
+			out[key] = value
+		}
+	}
+
+	if items, ok := out["items"].(map[string]interface{}); ok {
+		strictItems, ok := strictSchemaNode(items)
+		if !ok {
+			return nil, false
+		}
+		out["items"] = strictItems
+	} else if schemaHasType(out, "array") {
+		return nil, false
+	}
+
+	if anyOf, ok := out["anyOf"].([]interface{}); ok {
+		branches := make([]interface{}, 0, len(anyOf))
+		for _, branch := range anyOf {
+			branchSchema, ok := branch.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			strictBranch, ok := strictSchemaNode(branchSchema)
+			if !ok {
+				return nil, false
+			}
+			branches = append(branches, strictBranch)
+		}
+		out["anyOf"] = branches
+	}
+
+	if !schemaHasType(out, "object") {
+		return out, true
+	}
+
+	// Free-form objects can't be strict
+	properties, ok := out["properties"].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	if additional, ok := out["additionalProperties"]; ok && additional != false {
+		return nil, false
+	}
+
+	required := make(map[string]bool)
+	if names, ok := out["required"].([]interface{}); ok {
+		for _, name := range names {
+			if s, ok := name.(string); ok {
+				required[s] = true
+			}
+		}
+	}
+
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	strictProperties := make(map[string]interface{}, len(properties))
+	allRequired := make([]interface{}, 0, len(names))
+	for _, name := range names {
+		property, ok := properties[name].(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		strictProperty, ok := strictSchemaNode(property)
+		if !ok {
+			return nil, false
+		}
+		if !required[name] {
+			strictProperty = nullableSchema(strictProperty)
+		}
+		strictProperties[name] = strictProperty
+		allRequired = append(allRequired, name)
+	}
+	out["properties"] = strictProperties
+	out["required"] = allRequired
+	out["additionalProperties"] = false
+	return out, true
+}
+
+// nullableSchema lets schema also accept null.
+func nullableSchema(schema map[string]interface{}) map[string]interface{} {
+	switch typ := schema["type"].(type) {
+	case string:
+		if typ != "null" {
+			schema["type"] = []interface{}{typ, "null"}
+		}
+	case []interface{}:
+		if !containsValue(typ, "null") {
+			schema["type"] = append(typ, "null")
+		}
+	default:
+		if anyOf, ok := schema["anyOf"].([]interface{}); ok {
+			schema["anyOf"] = append(anyOf, map[string]interface{}{"type": "null"})
+		}
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok && !containsValue(enum, nil) {
+		schema["enum"] = append(enum, nil)
+	}
+	return schema
+}
+
+func schemaHasType(schema map[string]interface{}, want string) bool {
+	switch typ := schema["type"].(type) {
+	case string:
+		return typ == want
+	case []interface{}:
+		return containsValue(typ, want)
+	}
+	return false
+}
+
+func containsValue(values []interface{}, want interface{}) bool {
+	for _, value := range values {
+		if value == want {
+			return true
+		}
+	}
+	return false
+}
+
+// dropNullToolArguments removes the nulls strict mode sends in place of
+// omitted optional arguments, so tools see those arguments as absent.
+func dropNullToolArguments(response *api.ChatResponse) {
+	if response == nil {
+		return
+	}
+	for i := range response.Choices {
+		calls := response.Choices[i].Message.ToolCalls
+		for j := range calls {
+			calls[j].Function.Arguments = dropNullJSONFields(calls[j].Function.Arguments)
+		}
+	}
+}
+
+// dropNullJSONFields removes null object fields from a JSON object. Anything
+// else, including invalid JSON, is returned unchanged.
+func dropNullJSONFields(text string) string {
+	var object map[string]interface{}
+	if err := json.Unmarshal([]byte(text), &object); err != nil || !dropNulls(object) {
+		return text
+	}
+	cleaned, err := json.Marshal(object)
+	if err != nil {
+		return text
+	}
+	return string(cleaned)
+}
+
+func dropNulls(value interface{}) bool {
+	changed := false
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if field == nil {
+				delete(v, key)
+				changed = true
+			} else if dropNulls(field) {
+				changed = true
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if dropNulls(item) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
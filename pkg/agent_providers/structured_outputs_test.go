@@ -0,0 +1,168 @@
+package providers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	api "github.com/alantheprice/ledit/pkg/agent_api"
+)
+
+func TestStrictJSONSchema(t *testing.T) {
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"path":  map[string]interface{}{"type": "string", "minLength": 1},
+			"mode":  map[string]interface{}{"type": "string", "enum": []string{"read", "write"}, "default": "read"},
+			"lines": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "integer"}},
+		},
+		"required": []string{"path"},
+	}
+	strict, ok := strictJSONSchema(schema)
+	if !ok {
+		t.Fatal("expected the schema to convert")
+	}
+	want := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"path":  map[string]interface{}{"type": "string"},
+			"mode":  map[string]interface{}{"type": []interface{}{"string", "null"}, "enum": []interface{}{"read", "write", nil}},
+			"lines": map[string]interface{}{"type": []interface{}{"array", "null"}, "items": map[string]interface{}{"type": "integer"}},
+		},
+		"required":             []interface{}{"lines", "mode", "path"},
+		"additionalProperties": false,
+	}
+	if !reflect.DeepEqual(strict, want) {
+		t.Fatalf("unexpected strict schema:\n got %#v\nwant %#v", strict, want)
+	}
+
+	for name, unsupported := range map[string]map[string]interface{}{
+		"oneOf":      {"type": "object", "properties": map[string]interface{}{"v": map[string]interface{}{"oneOf": []interface{}{}}}},
+		"free-form":  {"type": "object", "properties": map[string]interface{}{"v": map[string]interface{}{"type": "object"}}},
+		"open":       {"type": "object", "properties": map[string]interface{}{}, "additionalProperties": true},
+		"not object": {"type": "string"},
+	} {
+		if _, ok := strictJSONSchema(unsupported); ok {
+			t.Errorf("expected %s schema to stay non-strict", name)
+		}
+	}
+}
+
+// structuredTestProvider returns a provider whose server records the request
+// body and replies with reply.
+func structuredTestProvider(t *testing.T, model, mode, reply string) (*GenericProvider, *map[string]interface{}) {
+	t.Helper()
+	var sent map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&sent); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(reply))
+	}))
+	t.Cleanup(server.Close)
+
+	provider, err := NewGenericProvider(&ProviderConfig{
+		Name:              "structured-test",
+		Endpoint:          server.URL,
+		Auth:              AuthConfig{Type: "none"},
+		Defaults:          RequestDefaults{Model: model},
+		Models:            ModelConfig{DefaultContextLimit: 4096, DefaultModel: model},
+		StructuredOutputs: mode,
+	})
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	return provider, &sent
+}
+
+func TestGenericProviderSendsStrictToolsAndDropsNulls(t *testing.T) {
+	reply := `{"choices":[{"message":{"role":"assistant","tool_calls":[{"id":"c1","type":"function","function":{"name":"read_file","arguments":"{\"file_path\":\"a.go\",\"view_range\":null}"}}]},"finish_reason":"tool_calls"}]}`
+	provider, sent := structuredTestProvider(t, "gpt-4o", "", reply)
+
+	var tool api.Tool
+	tool.Type = "function"
+	tool.Function.Name = "read_file"
+	tool.Function.Parameters = map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"file_path":  map[string]interface{}{"type": "string"},
+			"view_range": map[string]interface{}{"type": "string"},
+		},
+		"required": []string{"file_path"},
+	}
+	resp, err := provider.SendChatRequest([]api.Message{{Role: "user", Content: "read a.go"}}, []api.Tool{tool}, "", false)
+	if err != nil {
+		t.Fatalf("SendChatRequest: %v", err)
+	}
+
+	function := (*sent)["tools"].([]interface{})[0].(map[string]interface{})["function"].(map[string]interface{})
+	if function["strict"] != true || function["parameters"].(map[string]interface{})["additionalProperties"] != false {
+		t.Fatalf("expected a strict tool for gpt-4o, got %v", function)
+	}
+	if args := resp.Choices[0].Message.ToolCalls[0].Function.Arguments; args != `{"file_path":"a.go"}` {
+		t.Fatalf("expected null arguments to be dropped, got %s", args)
+	}
+
+	// The provider config can turn strict tools off
+	provider, sent = structuredTestProvider(t, "gpt-4o", "none", reply)
+	if _, err := provider.SendChatRequest([]api.Message{{Role: "user", Content: "read a.go"}}, []api.Tool{tool}, "", false); err != nil {
+		t.Fatalf("SendChatRequest: %v", err)
+	}
+	function = (*sent)["tools"].([]interface{})[0].(map[string]interface{})["function"].(map[string]interface{})
+	if _, ok := function["strict"]; ok {
+		t.Fatalf("expected no strict flag with structured_outputs none, got %v", function)
+	}
+}
+
+func TestGenericProviderSendStructuredRequest(t *testing.T) {
+	reply := `{"choices":[{"message":{"role":"assistant","content":"{\"steps\":[{\"id\":\"1\",\"note\":null}]}"},"finish_reason":"stop"}]}`
+	format := api.ResponseFormat{
+		Name: "plan",
+		Schema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"steps": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "object", "properties": map[string]interface{}{
+					"id":   map[string]interface{}{"type": "string"},
+					"note": map[string]interface{}{"type": "string"},
+				}}},
+			},
+		},
+	}
+	messages := []api.Message{{Role: "user", Content: "plan it"}}
+
+	tests := []struct {
+		name, model, mode string
+		wantFormat        bool
+		wantStrict        bool
+		wantContent       string
+	}{
+		{"json_schema", "gpt-4.1", "", true, true, `{"steps":[{"id":"1"}]}`},
+		{"grammar", "qwen3-coder:30b", "grammar", true, false, `{"steps":[{"id":"1","note":null}]}`},
+		{"unsupported", "deepseek-chat", "", false, false, `{"steps":[{"id":"1","note":null}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, sent := structuredTestProvider(t, tt.model, tt.mode, reply)
+			resp, err := provider.SendStructuredRequest(messages, format)
+			if err != nil {
+				t.Fatalf("SendStructuredRequest: %v", err)
+			}
+			responseFormat, ok := (*sent)["response_format"].(map[string]interface{})
+			if ok != tt.wantFormat {
+				t.Fatalf("response_format sent = %v, want %v", ok, tt.wantFormat)
+			}
+			if ok {
+				jsonSchema := responseFormat["json_schema"].(map[string]interface{})
+				if responseFormat["type"] != "json_schema" || jsonSchema["name"] != "plan" || (jsonSchema["strict"] == true) != tt.wantStrict {
+					t.Fatalf("unexpected response_format %v", responseFormat)
+				}
+			}
+			if got := resp.Choices[0].Message.Content; got != tt.wantContent {
+				t.Fatalf("content = %s, want %s", got, tt.wantContent)
+			}
+		})
+	}
+}
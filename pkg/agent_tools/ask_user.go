@@ -3,10 +3,16 @@ package tools
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
+	"strconv"
 	"strings"
 )
 
+// askUserAttempts is how many times AskUserChoice asks again after input
+// that is neither an option number nor an allowed answer.
+const askUserAttempts = 3
+
 // AskUser prompts the user with a question and reads input from stdin.
 func AskUser(question string) (string, error) {
 	if question == "" {
@@ -24,3 +30,45 @@ func AskUser(question string) (string, error) {
 	answer = strings.TrimSpace(answer)
 	return answer, nil
 }
+
+// AskUserChoice shows question with numbered options on stdout and reads the
+// user's pick from stdin. When allowOther is set, text that is not an option
+// number is returned as the answer. An empty answer means the user declined.
+func AskUserChoice(question string, options []string, allowOther bool) (string, error) {
+	return readUserChoice(os.Stdin, os.Stdout, question, options, allowOther)
+}
+
+func readUserChoice(in io.Reader, out io.Writer, question string, options []string, allowOther bool) (string, error) {
+	if question == "" {
+		return "", fmt.Errorf("empty question provided")
+	}
+	fmt.Fprintf(out, "\n[?] %s\n", question)
+	for i, option := range options {
+		fmt.Fprintf(out, "  %d. %s\n", i+1, option)
+	}
+	prompt := fmt.Sprintf("Enter 1-%d (empty to skip): ", len(options))
+	if allowOther {
+		prompt = fmt.Sprintf("Enter 1-%d or type your own answer (empty to skip): ", len(options))
+	}
+
+	reader := bufio.NewReader(in)
+	for attempt := 0; attempt < askUserAttempts; attempt++ {
+		fmt.Fprint(out, prompt)
+		line, err := reader.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return "", fmt.Errorf("failed to read user input: %w", err)
+		}
+		answer := strings.TrimSpace(line)
+		if answer == "" {
+			return "", nil
+		}
+		if n, convErr := strconv.Atoi(answer); convErr == nil && n >= 1 && n <= len(options) {
+			return options[n-1], nil
+		}
+		if allowOther {
+			return answer, nil
+		}
+		fmt.Fprintf(out, "Please enter a number between 1 and %d.\n", len(options))
+	}
+	return "", fmt.Errorf("no valid option chosen after %d attempts", askUserAttempts)
+}
@@ -0,0 +1,40 @@
+package tools
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestReadUserChoice(t *testing.T) {
+	options := []string{"PostgreSQL", "SQLite"}
+	tests := []struct {
+		name       string
+		input      string
+		allowOther bool
+		want       string
+		wantErr    bool
+	}{
+		{name: "option number", input: "2\n", want: "SQLite"},
+		{name: "own answer", input: "MySQL\n", allowOther: true, want: "MySQL"},
+		{name: "retries invalid input", input: "MySQL\n1\n", want: "PostgreSQL"},
+		{name: "skipped", input: "\n", want: ""},
+		{name: "answer without newline", input: "1", want: "PostgreSQL"},
+		{name: "gives up", input: "a\nb\nc\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			got, err := readUserChoice(strings.NewReader(tt.input), &out, "Which database?", options, tt.allowOther)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("answer = %q, want %q", got, tt.want)
+			}
+			if !strings.Contains(out.String(), "1. PostgreSQL") {
+				t.Errorf("expected numbered options, got %q", out.String())
+			}
+		})
+	}
+}
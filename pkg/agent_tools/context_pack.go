@@ -0,0 +1,253 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/alantheprice/ledit/pkg/filesystem"
+)
+
+// PackMode is how much of a file a context pack includes.
+type PackMode string
+
+// Context pack modes, from most to least complete.
+const (
+	PackFull    PackMode = "full"
+	PackSymbols PackMode = "symbols"
+	PackOutline PackMode = "outline"
+	PackOmitted PackMode = "omitted"
+)
+
+// packModeValue weighs how useful each mode is to the reader of a prompt.
+var packModeValue = map[PackMode]float64{
+	PackFull:    1,
+	PackSymbols: 0.6,
+	PackOutline: 0.25,
+	PackOmitted: 0,
+}
+
+const (
+	// outlineHeadLines is how many lines the outline of a file without
+	// declarations shows.
+	outlineHeadLines = 30
+	// outlineLineWidth caps each declaration line in an outline.
+	outlineLineWidth = 120
+)
+
+// taskIdentPattern matches the identifiers, including Type.Method, a task
+// may name.
+var taskIdentPattern = regexp.MustCompile(`[A-Za-z_][\w]*(?:\.[A-Za-z_][\w]*)?`)
+
+// PackedFile is one file of a context pack.
+type PackedFile struct {
+	Path string
+	Mode PackMode
+	// Text is the content included for the file, or a note in PackOmitted
+	// mode.
+	Text   string
+	Tokens int
+	// Symbols are the declarations included in PackSymbols mode.
+	Symbols []string
+	// Err is set when the file could not be read; Text is then empty.
+	Err error
+}
+
+// packOption is one way to include a file.
+type packOption struct {
+	mode    PackMode
+	text    string
+	tokens  int
+	symbols []string
+}
+
+// PackFiles chooses how much of each file to include in a prompt about task
+// so that together they fit in budget tokens, as counted by estimate. Each
+// file is included whole, as the declarations task names, as an outline of
+// its declarations, or as a one-line note. The choice starts from the notes
+// and repeatedly applies the upgrade adding the most value per extra token
+// that still fits, so small files are included whole before large ones are
+// sliced.
+func PackFiles(ctx context.Context, paths []string, task string, budget int, estimate func(string) int) []PackedFile {
+	packed := make([]PackedFile, len(paths))
+	options := make([][]packOption, len(paths))
+	chosen := make([]int, len(paths))
+	used := 0
+	for i, path := range paths {
+		packed[i].Path = path
+		content, err := readPackContent(ctx, path)
+		if err != nil {
+			packed[i].Err = err
+			continue
+		}
+		options[i] = packOptions(path, content, task, estimate)
+		chosen[i] = len(options[i]) - 1 // the note
+		used += options[i][chosen[i]].tokens
+	}
+
+	for {
+		best, bestOption, bestRatio := -1, 0, 0.0
+		for i := range options {
+			if options[i] == nil {
+				continue
+			}
+			current := options[i][chosen[i]]
+			for j := 0; j < chosen[i]; j++ {
+				extra := options[i][j].tokens - current.tokens
+				if used+extra > budget {
+					continue
+				}
+				ratio := (packModeValue[options[i][j].mode] - packModeValue[current.mode]) / float64(max(extra, 1))
+				if ratio > bestRatio {
+					best, bestOption, bestRatio = i, j, ratio
+				}
+			}
+		}
+		if best < 0 {
+			break
+		}
+		used += options[best][bestOption].tokens - options[best][chosen[best]].tokens
+		chosen[best] = bestOption
+	}
+
+	for i := range packed {
+		if options[i] == nil {
+			continue
+		}
+		option := options[i][chosen[i]]
+		packed[i].Mode, packed[i].Text, packed[i].Tokens, packed[i].Symbols = option.mode, option.text, option.tokens, option.symbols
+	}
+	return packed
+}
+
+// readPackContent reads a text file for packing.
+func readPackContent(ctx context.Context, path string) (string, error) {
+	cleanPath, err := filesystem.SafeResolvePathWithBypass(ctx, path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve file path: %w", err)
+	}
+	info, err := os.Stat(cleanPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to access file %s: %w", cleanPath, err)
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("path is a directory, not a file: %s", cleanPath)
+	}
+	if isNonTextFileExtension(cleanPath) {
+		return "", fmt.Errorf("%w. %s appears to be a non-text file", ErrNonTextFile, cleanPath)
+	}
+	if info.Size() > maxSymbolFileSize {
+		return "", fmt.Errorf("file %s is too large to include (%d bytes)", cleanPath, info.Size())
+	}
+	content, err := os.ReadFile(cleanPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file %s: %w", cleanPath, err)
+	}
+	if isBinaryContent(content) {
+		return "", binaryContentError(cleanPath)
+	}
+	return string(content), nil
+}
+
+// packOptions returns the ways to include a file, most complete first and
+// ending with the note.
+func packOptions(path, content, task string, estimate func(string) int) []packOption {
+	lines := strings.Split(content, "\n")
+	decls := fileDeclarations(path, content)
+	full := packOption{mode: PackFull, text: content, tokens: estimate(content)}
+	options := []packOption{full}
+
+	if slices, names := symbolSlices(lines, decls, task); len(names) > 0 {
+		if tokens := estimate(slices); tokens < full.tokens {
+			options = append(options, packOption{mode: PackSymbols, text: slices, tokens: tokens, symbols: names})
+		}
+	}
+
+	outline := fileOutline(lines, decls)
+	if tokens := estimate(outline); tokens < full.tokens {
+		options = append(options, packOption{mode: PackOutline, text: outline, tokens: tokens})
+	}
+
+	note := fmt.Sprintf("[%d lines, ~%d tokens; omitted to fit the context window, read it with read_file]", len(lines), full.tokens)
+	return append(options, packOption{mode: PackOmitted, text: note, tokens: estimate(note)})
+}
+
+// fileDeclarations lists the declarations of a file, nested ones included
+// outside Go.
+func fileDeclarations(path, content string) []declaration {
+	if strings.EqualFold(filepath.Ext(path), ".go") {
+		decls, _ := goDeclarations(path, []byte(content))
+		return decls
+	}
+	indentBlocks := strings.EqualFold(filepath.Ext(path), ".py")
+	lines := strings.Split(content, "\n")
+	var decls []declaration
+	for i, line := range lines {
+		name := declaredSymbol(line, indentBlocks)
+		if name == "" {
+			continue
+		}
+		end := braceBlockEnd(lines, i)
+		if indentBlocks {
+			end = indentBlockEnd(lines, i)
+		}
+		decls = append(decls, declaration{name: name, line: i + 1, span: symbolSpan{start: symbolCommentStart(lines, i) + 1, end: end + 1}})
+	}
+	return decls
+}
+
+// symbolSlices returns the source of the declarations task names, as
+// "Type.Method", "Method" or a top-level name, and their names.
+func symbolSlices(lines []string, decls []declaration, task string) (string, []string) {
+	mentioned := make(map[string]bool)
+	for _, ident := range taskIdentPattern.FindAllString(task, -1) {
+		mentioned[ident] = true
+		if dot := strings.LastIndex(ident, "."); dot >= 0 {
+			mentioned[ident[dot+1:]] = true
+		}
+	}
+
+	var sb strings.Builder
+	var names []string
+	covered := 0 // last line already included, so nested matches are not repeated
+	for _, decl := range decls {
+		short := decl.name
+		if dot := strings.LastIndex(short, "."); dot >= 0 {
+			short = short[dot+1:]
+		}
+		if (!mentioned[decl.name] && !mentioned[short]) || decl.span.end <= covered {
+			continue
+		}
+		if sb.Len() > 0 {
+			sb.WriteString("\n\n")
+		}
+		fmt.Fprintf(&sb, "%s (lines %d-%d):\n%s", decl.name, decl.span.start, decl.span.end,
+			strings.Join(lines[decl.span.start-1:decl.span.end], "\n"))
+		names = append(names, decl.name)
+		covered = decl.span.end
+	}
+	return sb.String(), names
+}
+
+// fileOutline lists a file's declarations with their line numbers, or shows
+// its first lines when it declares nothing.
+func fileOutline(lines []string, decls []declaration) string {
+	var sb strings.Builder
+	if len(decls) == 0 {
+		shown := min(len(lines), outlineHeadLines)
+		fmt.Fprintf(&sb, "First %d of %d lines:\n%s", shown, len(lines), strings.Join(lines[:shown], "\n"))
+		return sb.String()
+	}
+	fmt.Fprintf(&sb, "%d lines; declarations:\n", len(lines))
+	for _, decl := range decls {
+		line := strings.TrimSpace(lines[decl.line-1])
+		if len(line) > outlineLineWidth {
+			line = strings.ToValidUTF8(line[:outlineLineWidth], "") + "..."
+		}
+		fmt.Fprintf(&sb, "%d-%d: %s\n", decl.span.start, decl.span.end, line)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
@@ -0,0 +1,72 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+)
+
+// packTokens counts words, which keeps the budgets in these tests readable.
+func packTokens(s string) int {
+	return len(strings.Fields(s))
+}
+
+func TestPackFilesIncludesEverythingThatFits(t *testing.T) {
+	ctx, _ := transactionWorkspace(t, map[string]string{
+		"a.go": "package a\n\nfunc A() {}\n",
+		"b.md": "# Notes\n\nshort\n",
+	})
+	packed := PackFiles(ctx, []string{"a.go", "b.md"}, "look at A", 1000, packTokens)
+	for _, file := range packed {
+		if file.Err != nil || file.Mode != PackFull {
+			t.Fatalf("expected %s in full, got %+v", file.Path, file)
+		}
+	}
+	if packed[0].Text != "package a\n\nfunc A() {}\n" {
+		t.Fatalf("unexpected text for a.go:\n%s", packed[0].Text)
+	}
+}
+
+func TestPackFilesSlicesNamedSymbolsUnderTightBudget(t *testing.T) {
+	filler := strings.Repeat("\tx := 1 + 2 + 3 + 4 + 5\n", 40)
+	big := "package a\n\n// Target is what the task names.\nfunc Target() {\n\treturn\n}\n\nfunc Other() {\n" + filler + "}\n"
+	ctx, _ := transactionWorkspace(t, map[string]string{
+		"big.go":   big,
+		"other.go": "package a\n\nfunc Unrelated() {\n" + filler + "}\n",
+		"small.go": "package a\n\nvar small = 1\n",
+	})
+
+	packed := PackFiles(ctx, []string{"big.go", "other.go", "small.go"}, "Fix Target so it returns early", 60, packTokens)
+	if packed[0].Mode != PackSymbols || len(packed[0].Symbols) != 1 || packed[0].Symbols[0] != "Target" {
+		t.Fatalf("expected big.go sliced to Target, got %+v", packed[0])
+	}
+	if !strings.Contains(packed[0].Text, "// Target is what the task names.") || strings.Contains(packed[0].Text, "Other") {
+		t.Fatalf("unexpected slice:\n%s", packed[0].Text)
+	}
+	if packed[1].Mode != PackOutline && packed[1].Mode != PackOmitted {
+		t.Fatalf("expected other.go outlined or omitted, got %+v", packed[1])
+	}
+	if packed[2].Mode != PackFull {
+		t.Fatalf("expected the small file in full, got %+v", packed[2])
+	}
+
+	total := 0
+	for _, file := range packed {
+		total += file.Tokens
+	}
+	if total > 60 {
+		t.Fatalf("pack uses %d tokens, over the budget of 60", total)
+	}
+}
+
+func TestPackFilesOmitsWhenNothingFits(t *testing.T) {
+	ctx, _ := transactionWorkspace(t, map[string]string{
+		"a.txt": strings.Repeat("word ", 200),
+	})
+	packed := PackFiles(ctx, []string{"a.txt", "missing.txt"}, "", 0, packTokens)
+	if packed[0].Mode != PackOmitted || !strings.Contains(packed[0].Text, "read it with read_file") {
+		t.Fatalf("expected a.txt omitted, got %+v", packed[0])
+	}
+	if packed[1].Err == nil {
+		t.Fatal("expected an error for the missing file")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/alantheprice/ledit/pkg/filesystem"
+	"github.com/alantheprice/ledit/pkg/utils"
 )
 
 func EditFile(ctx context.Context, filePath, oldString, newString string) (string, error) {
@@ -21,6 +22,19 @@ func EditFile(ctx context.Context, filePath, oldString, newString string) (strin
 		return "", fmt.Errorf("failed to resolve and validate file %s: %w", filePath, err)
 	}
 
+	// Hold the file's lock from read to write so a concurrent agent's write
+	// cannot be lost in between, and refuse to edit a file that changed
+	// since it was read: the replacement was written against that version
+	locks := filesystem.Locks()
+	unlock, err := locks.Lock(cleanPath)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+	if err := locks.CheckConflict(cleanPath); err != nil {
+		return "", err
+	}
+
 	// Step 3: Read file content
 	contentStr, err := readFileContent(cleanPath)
 	if err != nil {
@@ -37,6 +51,7 @@ func EditFile(ctx context.Context, filePath, oldString, newString string) (strin
 	if err := writeFileWithPermissions(cleanPath, []byte(newContent), originalMode.Perm()); err != nil {
 		return "", fmt.Errorf("failed to write file %s: %w", cleanPath, err)
 	}
+	locks.RecordWrite(cleanPath, []byte(newContent))
 
 	// Step 6: Verify edit was successful
 	if err := verifyEdit(cleanPath, newString); err != nil {
@@ -47,6 +62,27 @@ func EditFile(ctx context.Context, filePath, oldString, newString string) (strin
 	return fmt.Sprintf("Edited %s: replaced %d characters with %d characters", cleanPath, len(oldString), len(newString)), nil
 }
 
+// PreviewEdit returns the content of filePath before and after replacing
+// oldString with newString, without writing the file.
+func PreviewEdit(ctx context.Context, filePath, oldString, newString string) (string, string, error) {
+	if err := validateEditInputs(filePath, oldString, newString); err != nil {
+		return "", "", fmt.Errorf("failed to validate edit inputs: %w", err)
+	}
+	cleanPath, _, err := resolveAndValidateFile(ctx, filePath)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to resolve and validate file %s: %w", filePath, err)
+	}
+	contentStr, err := readFileContent(cleanPath)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read file %s: %w", cleanPath, err)
+	}
+	newContent, err := determineAndPerformReplacement(contentStr, oldString, newString, cleanPath)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to perform replacement: %w", err)
+	}
+	return contentStr, newContent, nil
+}
+
 // validateEditInputs validates filePath, oldString, newString and checks for suspicious patterns
 func validateEditInputs(filePath, oldString, newString string) error {
 	if filePath == "" {
@@ -151,6 +187,15 @@ func performNormalizedReplacement(content, oldString, newString string) (string,
 // determineAndPerformReplacement determines if exact match or normalized match is needed
 // Returns the new content
 func determineAndPerformReplacement(content, oldString, newString, cleanPath string) (string, error) {
+	// Models write "\n" line endings; in a CRLF file, match and write CRLF
+	// so the edit neither misses nor leaves mixed line endings behind.
+	if ending := utils.LineEnding(content); ending == "\r\n" {
+		newString = utils.WithLineEnding(newString, ending)
+		if !strings.Contains(content, oldString) {
+			oldString = utils.WithLineEnding(oldString, ending)
+		}
+	}
+
 	// Track if we need exact match or used normalized match
 	usedNormalizedMatch := false
 
@@ -221,8 +266,9 @@ func verifyEdit(cleanPath string, newString string) error {
 		return fmt.Errorf("failed to verify file edit by reading back %s: %w", cleanPath, err)
 	}
 
-	// Check that the replacement actually happened
-	if !strings.Contains(string(updatedContent), newString) {
+	// Check that the replacement actually happened, whatever line endings
+	// it was written with
+	if !strings.Contains(utils.WithLineEnding(string(updatedContent), "\n"), utils.WithLineEnding(newString, "\n")) {
 		return fmt.Errorf("edit verification failed - new string not found in file after write")
 	}
 
@@ -147,3 +147,21 @@ func TestValidateEditInputs_DocumentationExamples(t *testing.T) {
 		})
 	}
 }
+
+func TestReplacementKeepsCRLFLineEndings(t *testing.T) {
+	content := "package main\r\n\r\nfunc main() {\r\n\tprintln(\"hi\")\r\n}\r\n"
+	got, err := determineAndPerformReplacement(content, "func main() {\n\tprintln(\"hi\")\n}", "func main() {\n\tprintln(\"hi\")\n\tprintln(\"bye\")\n}", "main.go")
+	if err != nil {
+		t.Fatalf("replacement: %v", err)
+	}
+	want := "package main\r\n\r\nfunc main() {\r\n\tprintln(\"hi\")\r\n\tprintln(\"bye\")\r\n}\r\n"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// LF files are left alone.
+	got, err = determineAndPerformReplacement("a\nb\n", "a\nb", "a\r\nc", "x.txt")
+	if err != nil || got != "a\r\nc\n" {
+		t.Errorf("expected an LF file to be edited as given, got %q, %v", got, err)
+	}
+}
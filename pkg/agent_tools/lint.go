@@ -0,0 +1,457 @@
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Lint summary limits.
+const (
+	DefaultLintLimit    = 50  // diagnostics listed by LintReport.Summary
+	lintMessageMaxChars = 200 // longer messages are truncated in summaries
+	lintRuleSummaryTop  = 10  // rules listed in the per-rule counts
+)
+
+// Diagnostic is one linter finding, normalized across linters.
+type Diagnostic struct {
+	Linter   string `json:"linter"`
+	File     string `json:"file"` // relative to the workspace root when inside it
+	Line     int    `json:"line"`
+	Column   int    `json:"column,omitempty"`
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"` // "error" or "warning"
+	Message  string `json:"message"`
+}
+
+// linterSpec describes how to run one linter and read its output.
+type linterSpec struct {
+	name       string
+	markers    []string // files at the workspace root that enable it
+	extensions []string // source files it checks
+	// args returns the arguments for linting target, a workspace-relative
+	// file or directory ("." for everything).
+	args  func(target string, isDir bool) []string
+	parse func(stdout []byte) ([]Diagnostic, error)
+}
+
+var linterSpecs = []linterSpec{
+	{
+		name:       "golangci-lint",
+		markers:    []string{"go.mod"},
+		extensions: []string{".go"},
+		args: func(target string, isDir bool) []string {
+			switch {
+			case !isDir && filepath.Dir(target) == ".":
+				return []string{"run", "."}
+			case !isDir:
+				return []string{"run", "./" + filepath.ToSlash(filepath.Dir(target))}
+			case target == ".":
+				return []string{"run", "./..."}
+			default:
+				return []string{"run", "./" + filepath.ToSlash(target) + "/..."}
+			}
+		},
+		parse: parseGolangciText,
+	},
+	{
+		name:       "eslint",
+		markers:    []string{"package.json"},
+		extensions: []string{".js", ".jsx", ".mjs", ".cjs", ".ts", ".tsx", ".vue"},
+		args: func(target string, _ bool) []string {
+			return []string{"--format", "json", target}
+		},
+		parse: parseESLintJSON,
+	},
+	{
+		name:       "ruff",
+		markers:    []string{"pyproject.toml", "ruff.toml", ".ruff.toml", "setup.py", "requirements.txt"},
+		extensions: []string{".py", ".pyi"},
+		args: func(target string, _ bool) []string {
+			return []string{"check", "--output-format", "json", target}
+		},
+		parse: parseRuffJSON,
+	},
+}
+
+// LintReport is the outcome of RunLinters.
+type LintReport struct {
+	Diagnostics []Diagnostic `json:"diagnostics"`
+	Ran         []string     `json:"ran"`
+	// Skipped and Failed explain linters that produced no diagnostics, e.g.
+	// "eslint: not installed".
+	Skipped []string `json:"skipped,omitempty"`
+	Failed  []string `json:"failed,omitempty"`
+}
+
+// LinterNames lists the supported linters.
+func LinterNames() []string {
+	names := make([]string, len(linterSpecs))
+	for i, spec := range linterSpecs {
+		names[i] = spec.name
+	}
+	return names
+}
+
+// RunLinters runs the linters that apply to the project at root: golangci-lint
+// for Go modules, eslint for Node projects and ruff for Python projects.
+// Linting can be narrowed to path, a file or directory inside root, and to
+// the linters named in only. Diagnostics are deduplicated and sorted, errors
+// first. Linters that are not installed are skipped rather than failing.
+func RunLinters(ctx context.Context, root, path string, only []string) (*LintReport, error) {
+	target, isDir := ".", true
+	if path != "" {
+		absPath := path
+		if !filepath.IsAbs(absPath) {
+			absPath = filepath.Join(root, path)
+		}
+		info, err := os.Stat(absPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat %s: %w", path, err)
+		}
+		rel, err := filepath.Rel(root, absPath)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("path is outside the workspace: %s", path)
+		}
+		target, isDir = rel, info.IsDir()
+	}
+
+	wanted := make(map[string]bool, len(only))
+	for _, name := range only {
+		wanted[strings.TrimSpace(name)] = true
+	}
+	for name := range wanted {
+		if !isKnownLinter(name) {
+			return nil, fmt.Errorf("unknown linter %q; supported: %s", name, strings.Join(LinterNames(), ", "))
+		}
+	}
+
+	report := &LintReport{}
+	for _, spec := range linterSpecs {
+		if len(wanted) > 0 && !wanted[spec.name] {
+			continue
+		}
+		if len(wanted) == 0 && !hasAnyFile(root, spec.markers) {
+			continue
+		}
+		if !isDir && !hasExtension(target, spec.extensions) {
+			continue
+		}
+		binary := linterBinary(root, spec.name)
+		if binary == "" {
+			report.Skipped = append(report.Skipped, spec.name+": not installed")
+			continue
+		}
+
+		diagnostics, err := runLinter(ctx, root, binary, spec, target, isDir)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err != nil {
+			report.Failed = append(report.Failed, fmt.Sprintf("%s: %v", spec.name, err))
+			continue
+		}
+		report.Ran = append(report.Ran, spec.name)
+		for _, d := range diagnostics {
+			d.Linter = spec.name
+			d.File = relativeToRoot(root, d.File)
+			// golangci-lint lints whole packages; keep a file scope.
+			if !isDir && d.File != filepath.ToSlash(target) {
+				continue
+			}
+			report.Diagnostics = append(report.Diagnostics, d)
+		}
+	}
+	if len(report.Ran) == 0 && len(report.Skipped) == 0 && len(report.Failed) == 0 {
+		return nil, errors.New("no supported linter applies to this project (looked for golangci-lint, eslint and ruff projects)")
+	}
+	report.Diagnostics = dedupeDiagnostics(report.Diagnostics)
+	return report, nil
+}
+
+func isKnownLinter(name string) bool {
+	for _, spec := range linterSpecs {
+		if spec.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAnyFile(root string, names []string) bool {
+	for _, name := range names {
+		if _, err := os.Stat(filepath.Join(root, name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func hasExtension(path string, extensions []string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, e := range extensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// linterBinary prefers a project-local install over one on PATH.
+func linterBinary(root, name string) string {
+	for _, local := range []string{
+		filepath.Join(root, "node_modules", ".bin", name),
+		filepath.Join(root, ".venv", "bin", name),
+	} {
+		if info, err := os.Stat(local); err == nil && !info.IsDir() {
+			return local
+		}
+	}
+	if path, err := exec.LookPath(name); err == nil {
+		return path
+	}
+	return ""
+}
+
+// runLinter runs one linter. Linters exit non-zero when they report
+// problems, so only output that cannot be parsed is an error.
+func runLinter(ctx context.Context, root, binary string, spec linterSpec, target string, isDir bool) ([]Diagnostic, error) {
+	cmd := exec.CommandContext(ctx, binary, spec.args(target, isDir)...)
+	cmd.Dir = root
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	runErr := cmd.Run()
+
+	var exitErr *exec.ExitError
+	if runErr != nil && !errors.As(runErr, &exitErr) {
+		return nil, runErr
+	}
+	diagnostics, err := spec.parse(stdout.Bytes())
+	if err != nil || (runErr != nil && len(diagnostics) == 0) {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = strings.TrimSpace(stdout.String())
+		}
+		if len(msg) > 500 {
+			msg = msg[:500] + "..."
+		}
+		if err == nil {
+			err = runErr
+		}
+		return nil, fmt.Errorf("%v: %s", err, msg)
+	}
+	return diagnostics, nil
+}
+
+// golangciLinePattern matches golangci-lint's text output, e.g.
+// "pkg/a/a.go:12:3: Error return value is not checked (errcheck)".
+var golangciLinePattern = regexp.MustCompile(`^(.+?\.go):(\d+)(?::(\d+))?: (.+) \(([\w-]+)\)$`)
+
+// parseGolangciText reads golangci-lint's default text output, which is the
+// same in every major version, unlike its JSON flags.
+func parseGolangciText(stdout []byte) ([]Diagnostic, error) {
+	var diagnostics []Diagnostic
+	scanner := bufio.NewScanner(bytes.NewReader(stdout))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		m := golangciLinePattern.FindStringSubmatch(scanner.Text())
+		if m == nil {
+			continue
+		}
+		line, _ := strconv.Atoi(m[2])
+		column, _ := strconv.Atoi(m[3])
+		severity := "warning"
+		if m[5] == "typecheck" {
+			severity = "error"
+		}
+		diagnostics = append(diagnostics, Diagnostic{
+			File: m[1], Line: line, Column: column, Rule: m[5], Severity: severity, Message: m[4],
+		})
+	}
+	return diagnostics, scanner.Err()
+}
+
+func parseESLintJSON(stdout []byte) ([]Diagnostic, error) {
+	var results []struct {
+		FilePath string `json:"filePath"`
+		Messages []struct {
+			RuleID   *string `json:"ruleId"`
+			Severity int     `json:"severity"`
+			Message  string  `json:"message"`
+			Line     int     `json:"line"`
+			Column   int     `json:"column"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(bytes.TrimSpace(stdout), &results); err != nil {
+		return nil, fmt.Errorf("failed to parse eslint output: %w", err)
+	}
+	var diagnostics []Diagnostic
+	for _, result := range results {
+		for _, m := range result.Messages {
+			rule := "parse"
+			if m.RuleID != nil {
+				rule = *m.RuleID
+			}
+			severity := "warning"
+			if m.Severity >= 2 {
+				severity = "error"
+			}
+			diagnostics = append(diagnostics, Diagnostic{
+				File: result.FilePath, Line: m.Line, Column: m.Column, Rule: rule, Severity: severity, Message: m.Message,
+			})
+		}
+	}
+	return diagnostics, nil
+}
+
+func parseRuffJSON(stdout []byte) ([]Diagnostic, error) {
+	var results []struct {
+		Code     *string `json:"code"`
+		Message  string  `json:"message"`
+		Filename string  `json:"filename"`
+		Location struct {
+			Row    int `json:"row"`
+			Column int `json:"column"`
+		} `json:"location"`
+	}
+	if err := json.Unmarshal(bytes.TrimSpace(stdout), &results); err != nil {
+		return nil, fmt.Errorf("failed to parse ruff output: %w", err)
+	}
+	diagnostics := make([]Diagnostic, 0, len(results))
+	for _, r := range results {
+		// Syntax errors have no code (or E999 in older versions).
+		rule, severity := "syntax", "error"
+		if r.Code != nil && *r.Code != "E999" {
+			rule, severity = *r.Code, "warning"
+		}
+		diagnostics = append(diagnostics, Diagnostic{
+			File: r.Filename, Line: r.Location.Row, Column: r.Location.Column, Rule: rule, Severity: severity, Message: r.Message,
+		})
+	}
+	return diagnostics, nil
+}
+
+func relativeToRoot(root, path string) string {
+	if !filepath.IsAbs(path) {
+		return filepath.ToSlash(filepath.Clean(path))
+	}
+	if rel, err := filepath.Rel(root, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
+
+// dedupeDiagnostics drops repeated findings, which golangci-lint reports
+// once per build tag set and test variant, and sorts errors first, then by
+// position.
+func dedupeDiagnostics(diagnostics []Diagnostic) []Diagnostic {
+	type key struct {
+		file, rule, message string
+		line                int
+	}
+	seen := make(map[key]bool, len(diagnostics))
+	unique := diagnostics[:0]
+	for _, d := range diagnostics {
+		k := key{d.File, d.Rule, d.Message, d.Line}
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		unique = append(unique, d)
+	}
+	sort.SliceStable(unique, func(i, j int) bool {
+		a, b := unique[i], unique[j]
+		if (a.Severity == "error") != (b.Severity == "error") {
+			return a.Severity == "error"
+		}
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return unique
+}
+
+// Summary renders the report for the model: totals, the most frequent rules
+// and at most limit diagnostics (DefaultLintLimit when limit <= 0).
+func (r *LintReport) Summary(limit int) string {
+	if limit <= 0 {
+		limit = DefaultLintLimit
+	}
+	var sb strings.Builder
+	errorCount := 0
+	ruleCounts := make(map[string]int)
+	for _, d := range r.Diagnostics {
+		if d.Severity == "error" {
+			errorCount++
+		}
+		ruleCounts[d.Linter+"/"+d.Rule]++
+	}
+
+	if len(r.Ran) > 0 {
+		fmt.Fprintf(&sb, "Ran %s: %d diagnostic(s), %d error(s), %d warning(s)\n",
+			strings.Join(r.Ran, ", "), len(r.Diagnostics), errorCount, len(r.Diagnostics)-errorCount)
+	}
+	for _, s := range r.Skipped {
+		fmt.Fprintf(&sb, "Skipped %s\n", s)
+	}
+	for _, f := range r.Failed {
+		fmt.Fprintf(&sb, "Failed %s\n", f)
+	}
+	if len(r.Diagnostics) == 0 {
+		if len(r.Ran) > 0 {
+			sb.WriteString("No problems found.\n")
+		}
+		return sb.String()
+	}
+
+	rules := make([]string, 0, len(ruleCounts))
+	for rule := range ruleCounts {
+		rules = append(rules, rule)
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		if ruleCounts[rules[i]] != ruleCounts[rules[j]] {
+			return ruleCounts[rules[i]] > ruleCounts[rules[j]]
+		}
+		return rules[i] < rules[j]
+	})
+	if len(rules) > lintRuleSummaryTop {
+		rules = rules[:lintRuleSummaryTop]
+	}
+	sb.WriteString("By rule:")
+	for _, rule := range rules {
+		fmt.Fprintf(&sb, " %s (%d)", rule, ruleCounts[rule])
+	}
+	sb.WriteString("\n\n")
+
+	for i, d := range r.Diagnostics {
+		if i == limit {
+			fmt.Fprintf(&sb, "... and %d more; narrow the path or linters to see them\n", len(r.Diagnostics)-limit)
+			break
+		}
+		message := strings.Join(strings.Fields(d.Message), " ")
+		if len(message) > lintMessageMaxChars {
+			message = message[:lintMessageMaxChars] + "..."
+		}
+		position := fmt.Sprintf("%s:%d", d.File, d.Line)
+		if d.Column > 0 {
+			position += fmt.Sprintf(":%d", d.Column)
+		}
+		fmt.Fprintf(&sb, "%s [%s] %s: %s\n", position, d.Severity, d.Rule, message)
+	}
+	return sb.String()
+}
@@ -0,0 +1,133 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestParseGolangciText(t *testing.T) {
+	output := "pkg/a/a.go:12:3: Error return value of `f.Close` is not checked (errcheck)\n" +
+		"\tdefer f.Close()\n" +
+		"\t      ^\n" +
+		"pkg/b/b.go:4: undefined: Foo (typecheck)\n" +
+		"2 issues:\n"
+	diagnostics, err := parseGolangciText([]byte(output))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Diagnostic{
+		{File: "pkg/a/a.go", Line: 12, Column: 3, Rule: "errcheck", Severity: "warning", Message: "Error return value of `f.Close` is not checked"},
+		{File: "pkg/b/b.go", Line: 4, Rule: "typecheck", Severity: "error", Message: "undefined: Foo"},
+	}
+	if len(diagnostics) != len(want) {
+		t.Fatalf("got %+v", diagnostics)
+	}
+	for i := range want {
+		if diagnostics[i] != want[i] {
+			t.Errorf("diagnostic %d = %+v, want %+v", i, diagnostics[i], want[i])
+		}
+	}
+}
+
+func TestParseESLintAndRuffJSON(t *testing.T) {
+	eslint := `[{"filePath":"/w/src/app.js","messages":[
+		{"ruleId":"no-unused-vars","severity":1,"message":"'x' is assigned a value but never used.","line":3,"column":7},
+		{"ruleId":null,"severity":2,"message":"Parsing error: Unexpected token","line":9,"column":1}]}]`
+	diagnostics, err := parseESLintJSON([]byte(eslint))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diagnostics) != 2 || diagnostics[0].Rule != "no-unused-vars" || diagnostics[0].Severity != "warning" ||
+		diagnostics[1].Rule != "parse" || diagnostics[1].Severity != "error" {
+		t.Errorf("unexpected eslint diagnostics: %+v", diagnostics)
+	}
+
+	ruff := `[{"code":"F401","message":"os imported but unused","filename":"/w/app.py","location":{"row":1,"column":8}},
+		{"code":null,"message":"SyntaxError: unexpected indent","filename":"/w/bad.py","location":{"row":2,"column":1}}]`
+	diagnostics, err = parseRuffJSON([]byte(ruff))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diagnostics) != 2 || diagnostics[0].Rule != "F401" || diagnostics[0].Line != 1 ||
+		diagnostics[1].Rule != "syntax" || diagnostics[1].Severity != "error" {
+		t.Errorf("unexpected ruff diagnostics: %+v", diagnostics)
+	}
+
+	if _, err := parseRuffJSON([]byte("error: unrecognized option")); err == nil {
+		t.Error("expected non-JSON output to be an error")
+	}
+}
+
+func TestLintReportSummaryDedupesAndCaps(t *testing.T) {
+	report := &LintReport{Ran: []string{"golangci-lint"}, Skipped: []string{"ruff: not installed"}}
+	for i := 0; i < 3; i++ {
+		report.Diagnostics = append(report.Diagnostics,
+			Diagnostic{Linter: "golangci-lint", File: "b.go", Line: 2, Rule: "errcheck", Severity: "warning", Message: "unchecked"},
+			Diagnostic{Linter: "golangci-lint", File: "b.go", Line: 10 + i, Rule: "errcheck", Severity: "warning", Message: "unchecked"},
+		)
+	}
+	report.Diagnostics = append(report.Diagnostics,
+		Diagnostic{Linter: "golangci-lint", File: "z.go", Line: 1, Rule: "typecheck", Severity: "error", Message: "undefined: x"})
+	report.Diagnostics = dedupeDiagnostics(report.Diagnostics)
+	if len(report.Diagnostics) != 5 || report.Diagnostics[0].File != "z.go" {
+		t.Fatalf("expected 5 diagnostics, errors first: %+v", report.Diagnostics)
+	}
+
+	summary := report.Summary(2)
+	for _, want := range []string{
+		"Ran golangci-lint: 5 diagnostic(s), 1 error(s), 4 warning(s)",
+		"Skipped ruff: not installed",
+		"By rule: golangci-lint/errcheck (4) golangci-lint/typecheck (1)",
+		"z.go:1 [error] typecheck: undefined: x",
+		"b.go:2 [warning] errcheck: unchecked",
+		"... and 3 more",
+	} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("summary missing %q:\n%s", want, summary)
+		}
+	}
+}
+
+func TestRunLintersUsesDetectedLocalLinter(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as the linter")
+	}
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "package.json"), []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "app.js"), []byte("var x = 1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	bin := filepath.Join(root, "node_modules", ".bin")
+	if err := os.MkdirAll(bin, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// eslint exits 1 when it reports problems.
+	script := "#!/bin/sh\necho '[{\"filePath\":\"'\"$PWD\"'/app.js\",\"messages\":[{\"ruleId\":\"no-var\",\"severity\":2,\"message\":\"Unexpected var.\",\"line\":1,\"column\":1}]}]'\nexit 1\n"
+	if err := os.WriteFile(filepath.Join(bin, "eslint"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := RunLinters(context.Background(), root, "app.js", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(report.Ran, ",") != "eslint" || len(report.Diagnostics) != 1 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if d := report.Diagnostics[0]; d.File != "app.js" || d.Rule != "no-var" || d.Linter != "eslint" || d.Severity != "error" {
+		t.Errorf("unexpected diagnostic: %+v", d)
+	}
+
+	if _, err := RunLinters(context.Background(), root, "", []string{"pylint"}); err == nil {
+		t.Error("expected an unknown linter to be rejected")
+	}
+	if _, err := RunLinters(context.Background(), t.TempDir(), "", nil); err == nil {
+		t.Error("expected an error when no linter applies")
+	}
+}
@@ -1,6 +1,8 @@
 package tools
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -15,13 +17,17 @@ import (
 // File size constants for read operations
 const (
 	// defaultMaxFileSize is the maximum file size (in bytes) for reading files
-	// Files larger than this will be truncated with head+tail and a warning
+	// Files larger than this are previewed as their first and last lines
 	// ~20,000 tokens at 4 chars/token heuristic (~15% of 128K context window)
 	defaultMaxFileSize = 80 * 1024 // 80KB default
 
-	// lineRangeMaxSize is the maximum file size (in bytes) when a line range is requested
-	// This is larger to ensure we can read enough content for accurate line counts
-	lineRangeMaxSize = 10 * 1024 * 1024 // 10MB for line range requests
+	// binarySniffSize is how much of a file is checked for binary content
+	// when it is not read whole.
+	binarySniffSize = 8 * 1024
+
+	// minSuggestedRangeLines is the smallest view_range suggested for the
+	// omitted part of a large file.
+	minSuggestedRangeLines = 20
 )
 
 func getFileReadMaxSize() int {
@@ -37,6 +43,11 @@ func ReadFile(ctx context.Context, filePath string) (string, error) {
 	return ReadFileWithRange(ctx, filePath, 0, 0)
 }
 
+// ReadFileWithRange reads filePath, or lines startLine to endLine of it when
+// either is set. Files larger than the context's ReadLimits are previewed as
+// their first and last lines with suggested view_range values for the rest;
+// ranges are read without loading the whole file. Binary files return an
+// error wrapping ErrNonTextFile.
 func ReadFileWithRange(ctx context.Context, filePath string, startLine, endLine int) (string, error) {
 	// SECURITY: Validate path is within working directory (handles symlinks properly)
 	cleanPath, err := filesystem.SafeResolvePathWithBypass(ctx, filePath)
@@ -60,17 +71,10 @@ func ReadFileWithRange(ctx context.Context, filePath string, startLine, endLine
 
 	// Check file extension for common non-text file types
 	if isNonTextFileExtension(cleanPath) {
-		return "", fmt.Errorf("only text content files can be read. %s appears to be a non-text file", cleanPath)
+		return "", fmt.Errorf("%w. %s appears to be a non-text file", ErrNonTextFile, cleanPath)
 	}
 
-	// When a line range is requested, we need to read the full file to ensure accuracy
-	// Otherwise, truncation can cause incorrect line counts (e.g., 700-line file truncated to 295 lines)
-
-	maxFileSize := getFileReadMaxSize()
-	if startLine > 0 || endLine > 0 {
-		// For line range requests, read much more to ensure we get the requested lines
-		maxFileSize = lineRangeMaxSize
-	}
+	limits := ReadLimitsFromContext(ctx)
 
 	// Open and read the file
 	file, err := os.Open(cleanPath)
@@ -79,118 +83,194 @@ func ReadFileWithRange(ctx context.Context, filePath string, startLine, endLine
 	}
 	defer file.Close()
 
-	var content []byte
-	var truncated bool
-	var headLines, tailLines int
-
+	// Line ranges are streamed, so line numbers stay exact however large
+	// the file is
 	if startLine > 0 || endLine > 0 {
-		// For line-range reads, just read up to maxFileSize (could be lineRangeMaxSize)
-		content, err = io.ReadAll(file)
-		if err != nil {
-			return "", fmt.Errorf("failed to read file %s: %w", cleanPath, err)
-		}
-		if int64(len(content)) > int64(maxFileSize) {
-			content = content[:maxFileSize]
-			truncated = true
-		}
-	} else if info.Size() > int64(maxFileSize) {
-		// Head+tail truncation: read 60% from start, 40% from end
-		headSize := maxFileSize * 60 / 100
-		tailSize := maxFileSize - headSize
-
-		head := make([]byte, headSize)
-		n, err := file.Read(head)
-		if err != nil && err != io.EOF {
-			return "", fmt.Errorf("failed to read file %s: %w", cleanPath, err)
-		}
-		head = head[:n]
-		headLines = strings.Count(string(head), "\n")
-
-		// Seek to position for tail
-		tailOffset := info.Size() - int64(tailSize)
-		if tailOffset < 0 {
-			tailOffset = 0
-		}
-		if _, err := file.Seek(tailOffset, io.SeekStart); err != nil {
-			return "", fmt.Errorf("failed to seek in file %s: %w", cleanPath, err)
-		}
-
-		tail := make([]byte, tailSize)
-		n, err = file.Read(tail)
-		if err != nil && err != io.EOF {
-			return "", fmt.Errorf("failed to read file %s: %w", cleanPath, err)
-		}
-		tail = tail[:n]
-		tailLines = strings.Count(string(tail), "\n")
-
-		omittedKB := (info.Size() - int64(headSize) - int64(tailSize)) / 1024
-		if omittedKB < 1 {
-			omittedKB = 1
-		}
+		return readLineRange(file, cleanPath, startLine, endLine, limits.RangeMaxBytes)
+	}
 
-		content = []byte(string(head) + "\n\n... [~" + fmt.Sprintf("%d", omittedKB) + "KB omitted] ...\n\n" + string(tail))
-		truncated = true
-	} else {
-		// For smaller files, read all content
-		content, err = io.ReadAll(file)
-		if err != nil {
-			return "", fmt.Errorf("failed to read file %s: %w", cleanPath, err)
-		}
+	if info.Size() > int64(limits.MaxBytes) {
+		return previewLargeFile(file, cleanPath, info.Size(), limits.MaxBytes)
 	}
 
-	// Check if content appears to be binary/non-text
+	// For smaller files, read all content
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file %s: %w", cleanPath, err)
+	}
 	if isBinaryContent(content) {
-		return "", fmt.Errorf("only text content files can be read. %s appears to contain binary/non-text content", cleanPath)
+		return "", binaryContentError(cleanPath)
 	}
+	return string(content), nil
+}
 
-	fileContent := string(content)
+func binaryContentError(path string) error {
+	return fmt.Errorf("%w. %s appears to contain binary/non-text content", ErrNonTextFile, path)
+}
 
-	// If line range is specified, extract only those lines
-	if startLine > 0 || endLine > 0 {
-		lines := strings.Split(fileContent, "\n")
-		totalLines := len(lines)
+// readLineRange returns lines startLine to endLine (1-based, inclusive; an
+// endLine below 1 or past the end means the last line) read from r, capped
+// at maxBytes of content.
+func readLineRange(r io.Reader, path string, startLine, endLine, maxBytes int) (string, error) {
+	reader := bufio.NewReaderSize(r, 64*1024)
+	if head, _ := reader.Peek(binarySniffSize); isBinaryContent(head) {
+		return "", binaryContentError(path)
+	}
+	if startLine < 1 {
+		startLine = 1
+	}
 
-		// Validate line ranges
-		if startLine < 1 {
-			startLine = 1
+	// Lines are counted as strings.Split(content, "\n") would, so a
+	// trailing newline ends with an empty last line
+	var selected strings.Builder
+	totalLines, lastShown := 0, 0
+	capped := false
+	for {
+		line, readErr := reader.ReadString('\n')
+		if readErr != nil && readErr != io.EOF {
+			return "", fmt.Errorf("failed to read file %s: %w", path, readErr)
 		}
-		if endLine < 1 || endLine > totalLines {
-			endLine = totalLines
+		totalLines++
+		if totalLines >= startLine && (endLine < 1 || totalLines <= endLine) && !capped {
+			line = strings.TrimSuffix(line, "\n")
+			if lastShown > 0 {
+				line = "\n" + line
+			}
+			if selected.Len()+len(line) > maxBytes {
+				capped = true
+				if lastShown == 0 {
+					// A single line longer than the cap is cut
+					selected.WriteString(strings.ToValidUTF8(line[:maxBytes], ""))
+					lastShown = totalLines
+				}
+			} else {
+				selected.WriteString(line)
+				lastShown = totalLines
+			}
 		}
-		if startLine > totalLines {
-			return "", fmt.Errorf("start line %d exceeds file length %d", startLine, totalLines)
-		}
-		if startLine > endLine {
-			return "", fmt.Errorf("start line %d is greater than end line %d", startLine, endLine)
+		if readErr == io.EOF {
+			break
 		}
+	}
 
-		// Extract the specified range (convert to 0-based indexing)
-		selectedLines := lines[startLine-1 : endLine]
-		fileContent = strings.Join(selectedLines, "\n")
+	// Validate line ranges
+	if endLine < 1 || endLine > totalLines {
+		endLine = totalLines
+	}
+	if startLine > totalLines {
+		return "", fmt.Errorf("start line %d exceeds file length %d", startLine, totalLines)
+	}
+	if startLine > endLine {
+		return "", fmt.Errorf("start line %d is greater than end line %d", startLine, endLine)
+	}
 
-		// Warn if file was truncated during line range request
-		if truncated {
-			fileContent = fmt.Sprintf("[WARN] Warning: File was truncated due to size. Requested lines %d-%d, but only %d lines were available in the truncated content.\n\n%s",
-				startLine, endLine, totalLines, fileContent)
+	if capped {
+		next := lastShown + 1
+		if next > endLine {
+			next = endLine
 		}
+		return fmt.Sprintf("[WARN] Lines %d-%d exceed the %dKB range limit; showing lines %d-%d of %d. Continue with view_range=[%d,%d].\n\nLines %d-%d of %s:\n%s",
+			startLine, endLine, maxBytes/1024, startLine, lastShown, totalLines, next, endLine, startLine, lastShown, path, selected.String()), nil
+	}
+	return fmt.Sprintf("Lines %d-%d of %s:\n%s", startLine, endLine, path, selected.String()), nil
+}
 
-		// Add line range info to result
-		return fmt.Sprintf("Lines %d-%d of %s:\n%s", startLine, endLine, cleanPath, fileContent), nil
+// previewLargeFile returns the first and last lines of a file larger than
+// maxBytes, about 60% and 40% of maxBytes, with its line count and view_range
+// suggestions for the omitted middle.
+func previewLargeFile(file *os.File, path string, size int64, maxBytes int) (string, error) {
+	headSize := maxBytes * 60 / 100
+	tailSize := maxBytes - headSize
+
+	head := make([]byte, headSize)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return "", fmt.Errorf("failed to read file %s: %w", path, err)
+	}
+	head = head[:n]
+	if isBinaryContent(head) {
+		return "", binaryContentError(path)
+	}
+	// Show whole lines only, unless a single line fills the preview
+	if cut := bytes.LastIndexByte(head, '\n'); cut >= 0 {
+		head = head[:cut+1]
+	}
+
+	tail := make([]byte, tailSize)
+	n, err = file.ReadAt(tail, size-int64(tailSize))
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("failed to read file %s: %w", path, err)
+	}
+	tail = tail[:n]
+	if cut := bytes.IndexByte(tail, '\n'); cut >= 0 && cut < len(tail)-1 {
+		tail = tail[cut+1:]
 	}
 
-	// Add truncation warning if file was truncated (head+tail mode only)
-	if truncated && (headLines > 0 || tailLines > 0) {
-		shownKB := maxFileSize / 1024
-		omittedKB := (info.Size() - int64(maxFileSize)) / 1024
-		if omittedKB < 1 {
-			omittedKB = 1
+	totalLines, err := countLines(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file %s: %w", path, err)
+	}
+	headLines := max(bytes.Count(head, []byte("\n")), 1)
+	tailLines := bytes.Count(bytes.TrimSuffix(tail, []byte("\n")), []byte("\n")) + 1
+	tailStart := max(totalLines-tailLines+1, headLines+1)
+	omittedKB := max((size-int64(len(head))-int64(len(tail)))/1024, 1)
+
+	// Suggest ranges of about as many bytes as the head shows
+	rangeLines := minSuggestedRangeLines
+	if totalLines > 0 {
+		rangeLines = max(int(int64(headSize)*int64(totalLines)/size), minSuggestedRangeLines)
+	}
+	firstOmitted, lastOmitted := headLines+1, tailStart-1
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "[WARN] File truncated — total %d bytes (%dKB), %d lines. Showing lines 1-%d and %d-%d",
+		size, size/1024+1, totalLines, headLines, tailStart, totalLines)
+	if firstOmitted <= lastOmitted {
+		fmt.Fprintf(&sb, "; lines %d-%d (~%dKB) are omitted. Use the view_range parameter to read specific line ranges, e.g. view_range=[%d,%d] continues after the first lines",
+			firstOmitted, lastOmitted, omittedKB, firstOmitted, min(firstOmitted+rangeLines-1, lastOmitted))
+		if middle := (firstOmitted + lastOmitted) / 2; middle > firstOmitted+rangeLines {
+			fmt.Fprintf(&sb, " and view_range=[%d,%d] is the middle of the file", middle, min(middle+rangeLines-1, lastOmitted))
 		}
-		fileContent = fmt.Sprintf("[WARN] File truncated — total %d bytes (%dKB). Showing ~%dKB: first %d lines and last %d lines, ~%dKB omitted. Use view_range parameter to read specific line ranges, e.g. view_range=[1,50]",
-			info.Size(), info.Size()/1024+1, shownKB, headLines, tailLines, omittedKB)
-		fileContent += "\n" + string(content)
+		sb.WriteString(", or search_files to find the lines you need")
+	} else {
+		fmt.Fprintf(&sb, "; ~%dKB of long lines are cut. Use the view_range parameter to read specific line ranges, e.g. view_range=[1,50]", omittedKB)
 	}
+	sb.WriteString("\n")
+	sb.Write(head)
+	if firstOmitted <= lastOmitted {
+		fmt.Fprintf(&sb, "\n... [lines %d-%d omitted] ...\n\n", firstOmitted, lastOmitted)
+	} else {
+		fmt.Fprintf(&sb, "\n... [~%dKB omitted] ...\n\n", omittedKB)
+	}
+	sb.Write(tail)
+	return sb.String(), nil
+}
 
-	return fileContent, nil
+// countLines counts the lines of file as an editor numbers them: a final
+// line without a trailing newline still counts.
+func countLines(file *os.File) (int, error) {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	buf := make([]byte, 64*1024)
+	lines, last := 0, byte('\n')
+	for {
+		n, err := file.Read(buf)
+		if n > 0 {
+			lines += bytes.Count(buf[:n], []byte("\n"))
+			last = buf[n-1]
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	if last != '\n' {
+		lines++
+	}
+	return lines, nil
 }
 
 // isNonTextFileExtension checks if the file extension indicates a non-text file
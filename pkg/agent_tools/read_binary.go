@@ -0,0 +1,114 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/alantheprice/ledit/pkg/filesystem"
+)
+
+// ErrNonTextFile is returned when a file read as text is binary.
+var ErrNonTextFile = errors.New("only text content files can be read")
+
+// binaryMagic names formats http.DetectContentType reports only as
+// application/octet-stream.
+var binaryMagic = []struct {
+	prefix string
+	name   string
+}{
+	{"\x7fELF", "ELF executable or library"},
+	{"\xcf\xfa\xed\xfe", "Mach-O executable or library"},
+	{"\xce\xfa\xed\xfe", "Mach-O executable or library"},
+	{"\xca\xfe\xba\xbe", "Mach-O universal binary or Java class file"},
+	{"MZ", "Windows executable (PE)"},
+	{"\x00asm", "WebAssembly module"},
+	{"SQLite format 3\x00", "SQLite database"},
+	{"PAR1", "Parquet file"},
+}
+
+// DescribeBinaryFile summarizes a binary file for the model instead of
+// returning its content: size, detected type, SHA-256 and a hex dump of its
+// first bytes.
+func DescribeBinaryFile(ctx context.Context, filePath string) (string, error) {
+	cleanPath, err := filesystem.SafeResolvePathWithBypass(ctx, filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve file path: %w", err)
+	}
+	file, err := os.Open(cleanPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file %s: %w", cleanPath, err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return "", fmt.Errorf("failed to access file %s: %w", cleanPath, err)
+	}
+
+	limits := ReadLimitsFromContext(ctx)
+	sniffSize := 512
+	if limits.BinaryPreviewBytes > sniffSize {
+		sniffSize = limits.BinaryPreviewBytes
+	}
+	head := make([]byte, sniffSize)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return "", fmt.Errorf("failed to read file %s: %w", cleanPath, err)
+	}
+	head = head[:n]
+
+	hash := sha256.New()
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("failed to seek in file %s: %w", cleanPath, err)
+	}
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", fmt.Errorf("failed to read file %s: %w", cleanPath, err)
+	}
+
+	preview := head
+	if len(preview) > limits.BinaryPreviewBytes {
+		preview = preview[:limits.BinaryPreviewBytes]
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s is a binary file; its content is not shown as text.\n", cleanPath)
+	fmt.Fprintf(&sb, "Size: %s (%d bytes)\n", formatByteSize(info.Size()), info.Size())
+	fmt.Fprintf(&sb, "Type: %s\n", detectBinaryType(head))
+	fmt.Fprintf(&sb, "Modified: %s\n", info.ModTime().Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(&sb, "SHA-256: %x\n", hash.Sum(nil))
+	if len(preview) > 0 {
+		fmt.Fprintf(&sb, "First %d bytes:\n%s", len(preview), hex.Dump(preview))
+	}
+	sb.WriteString("Use a format-specific command through shell_command to inspect it further (e.g. file, unzip -l, sqlite3, objdump).")
+	return sb.String(), nil
+}
+
+// detectBinaryType names the format of content from its first bytes.
+func detectBinaryType(head []byte) string {
+	for _, magic := range binaryMagic {
+		if bytes.HasPrefix(head, []byte(magic.prefix)) {
+			return magic.name
+		}
+	}
+	return http.DetectContentType(head)
+}
+
+// formatByteSize renders size in the largest whole unit.
+func formatByteSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
+}
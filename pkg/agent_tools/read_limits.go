@@ -0,0 +1,50 @@
+package tools
+
+import "context"
+
+// ReadLimits bounds what read_file returns for large and binary files. Zero
+// fields take their defaults.
+type ReadLimits struct {
+	// MaxBytes is the largest file returned whole; larger files are
+	// previewed as their first and last lines.
+	MaxBytes int
+	// RangeMaxBytes caps the content a view_range read returns.
+	RangeMaxBytes int
+	// BinaryPreviewBytes is how much of a binary file is shown as a hex dump.
+	BinaryPreviewBytes int
+}
+
+const (
+	// rangeMaxSizeFactor sets the default RangeMaxBytes as a multiple of
+	// MaxBytes: a range read is explicit, so it may return more.
+	rangeMaxSizeFactor = 4
+	// defaultBinaryPreviewBytes is the default hex dump length of a binary
+	// file.
+	defaultBinaryPreviewBytes = 256
+)
+
+type readLimitsKey struct{}
+
+// WithReadLimits returns a context whose file reads use limits.
+func WithReadLimits(ctx context.Context, limits ReadLimits) context.Context {
+	return context.WithValue(ctx, readLimitsKey{}, limits)
+}
+
+// ReadLimitsFromContext returns the read limits carried on ctx, with unset
+// fields filled from LEDIT_READ_FILE_MAX_BYTES and the defaults.
+func ReadLimitsFromContext(ctx context.Context) ReadLimits {
+	var limits ReadLimits
+	if ctx != nil {
+		limits, _ = ctx.Value(readLimitsKey{}).(ReadLimits)
+	}
+	if limits.MaxBytes <= 0 {
+		limits.MaxBytes = getFileReadMaxSize()
+	}
+	if limits.RangeMaxBytes <= 0 {
+		limits.RangeMaxBytes = limits.MaxBytes * rangeMaxSizeFactor
+	}
+	if limits.BinaryPreviewBytes <= 0 {
+		limits.BinaryPreviewBytes = defaultBinaryPreviewBytes
+	}
+	return limits
+}
@@ -0,0 +1,389 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/alantheprice/ledit/pkg/filesystem"
+)
+
+// maxSymbolFileSize is the largest file searched for symbols.
+const maxSymbolFileSize = 10 * 1024 * 1024
+
+// symbolSpan is one declaration found in a file, as 1-based inclusive lines.
+type symbolSpan struct {
+	start, end int
+}
+
+// ReadSymbol returns the source of the function, method, type, constant or
+// variable named symbol in filePath, including its doc comment. Go files are
+// parsed with go/ast; other languages use declaration patterns, with Python
+// blocks ending at the indentation and other blocks at the matching brace.
+// Methods can be named as "Type.Method". If the symbol is not found, the
+// error lists the symbols the file does declare.
+func ReadSymbol(ctx context.Context, filePath, symbol string) (string, error) {
+	symbol = strings.TrimSpace(symbol)
+	if symbol == "" {
+		return "", fmt.Errorf("symbol name is required")
+	}
+
+	cleanPath, err := filesystem.SafeResolvePathWithBypass(ctx, filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve file path: %w", err)
+	}
+	info, err := os.Stat(cleanPath)
+	if os.IsNotExist(err) {
+		return "", fmt.Errorf("file does not exist: %s", cleanPath)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to access file %s: %w", cleanPath, err)
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("path is a directory, not a file: %s", cleanPath)
+	}
+	if isNonTextFileExtension(cleanPath) {
+		return "", fmt.Errorf("%w. %s appears to be a non-text file", ErrNonTextFile, cleanPath)
+	}
+	if info.Size() > maxSymbolFileSize {
+		return "", fmt.Errorf("file %s is too large to search for symbols (%d bytes)", cleanPath, info.Size())
+	}
+
+	content, err := os.ReadFile(cleanPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file %s: %w", cleanPath, err)
+	}
+	if isBinaryContent(content) {
+		return "", binaryContentError(cleanPath)
+	}
+
+	var spans []symbolSpan
+	var available []string
+	if strings.EqualFold(filepath.Ext(cleanPath), ".go") {
+		spans, available, err = findGoSymbol(cleanPath, content, symbol)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		spans, available = findSymbolByPattern(cleanPath, string(content), symbol)
+	}
+
+	if len(spans) == 0 {
+		if len(available) == 0 {
+			return "", fmt.Errorf("symbol %q not found in %s", symbol, cleanPath)
+		}
+		return "", fmt.Errorf("symbol %q not found in %s. Declared symbols: %s", symbol, cleanPath, strings.Join(available, ", "))
+	}
+
+	lines := strings.Split(string(content), "\n")
+	var result strings.Builder
+	for i, span := range spans {
+		if i > 0 {
+			result.WriteString("\n\n")
+		}
+		fmt.Fprintf(&result, "%s (lines %d-%d of %s):\n%s", symbol, span.start, span.end, cleanPath,
+			strings.Join(lines[span.start-1:span.end], "\n"))
+	}
+	return result.String(), nil
+}
+
+// findGoSymbol finds top-level Go declarations named symbol. Methods match
+// "Method", "Type.Method" or "(*Type).Method".
+func findGoSymbol(path string, content []byte, symbol string) ([]symbolSpan, []string, error) {
+	decls, err := goDeclarations(path, content)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	recvName, name := "", symbol
+	if dot := strings.LastIndex(symbol, "."); dot >= 0 {
+		recvName = strings.Trim(symbol[:dot], "()*")
+		name = symbol[dot+1:]
+	}
+
+	var spans []symbolSpan
+	var available []string
+	for _, decl := range decls {
+		available = append(available, decl.name)
+		recv, declName := "", decl.name
+		if dot := strings.LastIndex(decl.name, "."); dot >= 0 {
+			recv, declName = decl.name[:dot], decl.name[dot+1:]
+		}
+		if declName != name || (recvName != "" && recvName != recv) {
+			continue
+		}
+		spans = append(spans, decl.span)
+	}
+	return spans, available, nil
+}
+
+// declaration is a named top-level declaration of a file.
+type declaration struct {
+	// name is "Type.Method" for Go methods.
+	name string
+	// line is the 1-based line holding the name; span includes the doc
+	// comment and body.
+	line int
+	span symbolSpan
+}
+
+// goDeclarations lists the top-level declarations of a Go file in order.
+func goDeclarations(path string, content []byte) ([]declaration, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, content, parser.ParseComments)
+	if file == nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	span := func(doc *ast.CommentGroup, from, to token.Pos) symbolSpan {
+		if doc != nil {
+			from = doc.Pos()
+		}
+		return symbolSpan{start: fset.Position(from).Line, end: fset.Position(to).Line}
+	}
+
+	var decls []declaration
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			name := d.Name.Name
+			recv := goReceiverName(d)
+			if recv != "" {
+				name = recv + "." + name
+			}
+			decls = append(decls, declaration{name: name, line: fset.Position(d.Name.Pos()).Line, span: span(d.Doc, d.Pos(), d.End())})
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				var names []*ast.Ident
+				var doc *ast.CommentGroup
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					names, doc = []*ast.Ident{s.Name}, s.Doc
+				case *ast.ValueSpec:
+					names, doc = s.Names, s.Doc
+				}
+				for _, ident := range names {
+					// An ungrouped declaration keeps its keyword and doc comment.
+					sp := span(doc, spec.Pos(), spec.End())
+					if !d.Lparen.IsValid() {
+						sp = span(d.Doc, d.Pos(), d.End())
+					}
+					decls = append(decls, declaration{name: ident.Name, line: fset.Position(ident.Pos()).Line, span: sp})
+				}
+			}
+		}
+	}
+	return decls, nil
+}
+
+// goReceiverName returns the receiver type of a method, or "" for a function.
+func goReceiverName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return ""
+	}
+	expr := fn.Recv.List[0].Type
+	for {
+		switch t := expr.(type) {
+		case *ast.StarExpr:
+			expr = t.X
+		case *ast.IndexExpr:
+			expr = t.X
+		case *ast.IndexListExpr:
+			expr = t.X
+		case *ast.Ident:
+			return t.Name
+		default:
+			return ""
+		}
+	}
+}
+
+var (
+	// symbolKeywordPattern matches keyword declarations in most languages:
+	// def/class in Python, function/class in JS/TS/PHP, fn/struct in Rust...
+	symbolKeywordPattern = regexp.MustCompile(`\b(?:def|class|function|interface|struct|enum|trait|fn|type|impl|record|object|module|union)\s+([A-Za-z_$][\w$]*)`)
+	// symbolAssignPattern matches JS/TS declarations such as "const fn = (".
+	symbolAssignPattern = regexp.MustCompile(`^\s*(?:export\s+)?(?:const|let|var)\s+([A-Za-z_$][\w$]*)\s*(?::[^=]*)?=`)
+	// symbolMethodPattern matches method and function definitions written as
+	// "modifiers type name(", such as Java, C# and class methods in JS/TS.
+	symbolMethodPattern = regexp.MustCompile(`^\s*(?:[\w<>\[\],.*&:?]+\s+)*?([A-Za-z_$~][\w$]*)\s*(?:<[^>()]*>)?\s*\([^;]*$`)
+)
+
+// symbolControlWords start lines that symbolMethodPattern must not treat as
+// definitions.
+var symbolControlWords = map[string]bool{
+	"if": true, "for": true, "while": true, "switch": true, "catch": true, "return": true,
+	"else": true, "do": true, "try": true, "with": true, "elif": true, "new": true,
+	"await": true, "throw": true, "case": true, "foreach": true, "using": true, "lock": true,
+}
+
+// findSymbolByPattern finds declarations named symbol in a non-Go file.
+func findSymbolByPattern(path, content, symbol string) ([]symbolSpan, []string) {
+	name := symbol
+	if dot := strings.LastIndexAny(symbol, ".:"); dot >= 0 {
+		name = symbol[dot+1:]
+	}
+	indentBlocks := strings.EqualFold(filepath.Ext(path), ".py")
+
+	lines := strings.Split(content, "\n")
+	var spans []symbolSpan
+	seen := map[string]bool{}
+	var available []string
+	for i := 0; i < len(lines); i++ {
+		declared := declaredSymbol(lines[i], indentBlocks)
+		if declared == "" {
+			continue
+		}
+		if !seen[declared] {
+			seen[declared] = true
+			available = append(available, declared)
+		}
+		if declared != name {
+			continue
+		}
+		start := symbolCommentStart(lines, i)
+		var end int
+		if indentBlocks {
+			end = indentBlockEnd(lines, i)
+		} else {
+			end = braceBlockEnd(lines, i)
+		}
+		spans = append(spans, symbolSpan{start: start + 1, end: end + 1})
+		// Skip the body so nested declarations of the same name are not
+		// reported twice.
+		i = end
+	}
+	return spans, available
+}
+
+// declaredSymbol returns the name a line declares, or "". keywordsOnly is
+// set for languages like Python where calls look like C-style definitions.
+func declaredSymbol(line string, keywordsOnly bool) string {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || strings.HasPrefix(trimmed, "//") || strings.HasPrefix(trimmed, "#") ||
+		strings.HasPrefix(trimmed, "*") || strings.HasPrefix(trimmed, "/*") {
+		return ""
+	}
+	if m := symbolKeywordPattern.FindStringSubmatch(line); m != nil {
+		return m[1]
+	}
+	if m := symbolAssignPattern.FindStringSubmatch(line); m != nil {
+		return m[1]
+	}
+	if keywordsOnly {
+		return ""
+	}
+	if first := strings.FieldsFunc(trimmed, func(r rune) bool { return r == ' ' || r == '(' || r == '\t' }); len(first) > 0 && symbolControlWords[first[0]] {
+		return ""
+	}
+	if strings.Contains(trimmed, "=") && !strings.Contains(trimmed, "=>") {
+		return ""
+	}
+	m := symbolMethodPattern.FindStringSubmatchIndex(line)
+	if m == nil {
+		return ""
+	}
+	name := line[m[2]:m[3]]
+	// A bare "name(...)" is a call unless it opens a body.
+	if symbolControlWords[name] || (strings.TrimSpace(line[:m[2]]) == "" && !strings.HasSuffix(trimmed, "{")) {
+		return ""
+	}
+	return name
+}
+
+// symbolCommentStart walks back from a declaration over the comments,
+// decorators and annotations directly above it.
+func symbolCommentStart(lines []string, decl int) int {
+	start := decl
+	for i := decl - 1; i >= 0; i-- {
+		trimmed := strings.TrimSpace(lines[i])
+		if trimmed == "" {
+			break
+		}
+		if strings.HasPrefix(trimmed, "//") || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "/*") ||
+			strings.HasPrefix(trimmed, "*") || strings.HasPrefix(trimmed, "@") || strings.HasPrefix(trimmed, "[") {
+			start = i
+			continue
+		}
+		break
+	}
+	return start
+}
+
+// indentBlockEnd returns the last line of an indentation block, such as a
+// Python def or class.
+func indentBlockEnd(lines []string, decl int) int {
+	indent := indentWidth(lines[decl])
+	end := decl
+	for i := decl + 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "" {
+			continue
+		}
+		if indentWidth(lines[i]) <= indent {
+			break
+		}
+		end = i
+	}
+	return end
+}
+
+func indentWidth(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
+
+// braceBlockEnd returns the line holding the brace that closes the block
+// opened on or after the declaration. Braces inside strings and comments are
+// ignored. A declaration that ends with ";" before any brace is one line.
+func braceBlockEnd(lines []string, decl int) int {
+	depth := 0
+	opened := false
+	inBlockComment := false
+	var quote byte
+	for i := decl; i < len(lines); i++ {
+		line := lines[i]
+		for j := 0; j < len(line); j++ {
+			c := line[j]
+			switch {
+			case inBlockComment:
+				if c == '*' && j+1 < len(line) && line[j+1] == '/' {
+					inBlockComment = false
+					j++
+				}
+			case quote != 0:
+				if c == '\\' {
+					j++
+				} else if c == quote {
+					quote = 0
+				}
+			case c == '/' && j+1 < len(line) && line[j+1] == '/':
+				j = len(line)
+			case c == '/' && j+1 < len(line) && line[j+1] == '*':
+				inBlockComment = true
+				j++
+			case c == '"' || c == '\'' || c == '`':
+				quote = c
+			case c == '{':
+				depth++
+				opened = true
+			case c == '}':
+				depth--
+				if opened && depth == 0 {
+					return i
+				}
+			case c == ';' && !opened:
+				return i
+			}
+		}
+		// Template strings can span lines; other quotes end with the line.
+		if quote != '`' {
+			quote = 0
+		}
+	}
+	return len(lines) - 1
+}
@@ -0,0 +1,184 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const readSymbolGoSource = `package shapes
+
+import "fmt"
+
+// Shape is anything with an area.
+type Shape interface {
+	Area() float64
+}
+
+// Square is a Shape.
+type Square struct {
+	Side float64
+}
+
+// Area returns the square's area.
+func (s *Square) Area() float64 {
+	return s.Side * s.Side
+}
+
+const (
+	// Pi is close enough.
+	Pi = 3.14
+	E  = 2.71
+)
+
+// Describe formats a shape.
+func Describe(s Shape) string {
+	return fmt.Sprintf("%.2f", s.Area())
+}
+`
+
+func writeSymbolFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadSymbolGo(t *testing.T) {
+	path := writeSymbolFile(t, "shapes.go", readSymbolGoSource)
+
+	tests := []struct {
+		symbol  string
+		want    []string
+		notWant []string
+	}{
+		{"Describe", []string{"// Describe formats a shape.", "func Describe(s Shape) string {", "lines 26-29"}, []string{"Square"}},
+		{"Square.Area", []string{"// Area returns the square's area.", "return s.Side * s.Side"}, []string{"type Square"}},
+		{"(*Square).Area", []string{"func (s *Square) Area() float64"}, nil},
+		{"Square", []string{"// Square is a Shape.", "type Square struct {", "Side float64"}, []string{"Area"}},
+		{"Pi", []string{"// Pi is close enough.", "Pi = 3.14"}, []string{"E  = 2.71", "const ("}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.symbol, func(t *testing.T) {
+			result, err := ReadSymbol(context.Background(), path, tt.symbol)
+			if err != nil {
+				t.Fatalf("ReadSymbol failed: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(result, want) {
+					t.Errorf("expected %q in:\n%s", want, result)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(result, notWant) {
+					t.Errorf("did not expect %q in:\n%s", notWant, result)
+				}
+			}
+		})
+	}
+}
+
+func TestReadSymbolNotFoundListsSymbols(t *testing.T) {
+	path := writeSymbolFile(t, "shapes.go", readSymbolGoSource)
+
+	_, err := ReadSymbol(context.Background(), path, "Circle")
+	if err == nil {
+		t.Fatal("expected an error for a missing symbol")
+	}
+	for _, want := range []string{`"Circle" not found`, "Shape", "Square.Area", "Describe"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in error: %v", want, err)
+		}
+	}
+}
+
+func TestReadSymbolPython(t *testing.T) {
+	path := writeSymbolFile(t, "app.py", `import os
+
+# Loads settings from the environment.
+@cache
+def load(name):
+    value = os.getenv(name)
+
+    return value
+
+
+class Store:
+    def get(self, key):
+        return load(key)
+`)
+
+	result, err := ReadSymbol(context.Background(), path, "load")
+	if err != nil {
+		t.Fatalf("ReadSymbol failed: %v", err)
+	}
+	if !strings.Contains(result, "# Loads settings") || !strings.Contains(result, "@cache") || !strings.Contains(result, "return value") {
+		t.Errorf("expected the comment, decorator and body, got:\n%s", result)
+	}
+	if strings.Contains(result, "class Store") {
+		t.Errorf("expected the block to end at the dedent, got:\n%s", result)
+	}
+
+	result, err = ReadSymbol(context.Background(), path, "Store.get")
+	if err != nil {
+		t.Fatalf("ReadSymbol failed: %v", err)
+	}
+	if !strings.Contains(result, "def get(self, key):") || strings.Contains(result, "def load") {
+		t.Errorf("unexpected method source:\n%s", result)
+	}
+}
+
+func TestReadSymbolBraceLanguages(t *testing.T) {
+	path := writeSymbolFile(t, "api.ts", `import { x } from "./x";
+
+/**
+ * Fetches a user.
+ */
+export async function fetchUser(id: string): Promise<User> {
+  const url = "/users/{" + id + "}";
+  if (!id) {
+    throw new Error("missing id");
+  }
+  return get(url);
+}
+
+export const limit = 10;
+
+class Client {
+  send(body: string) {
+    return post(body);
+  }
+}
+`)
+
+	result, err := ReadSymbol(context.Background(), path, "fetchUser")
+	if err != nil {
+		t.Fatalf("ReadSymbol failed: %v", err)
+	}
+	if !strings.Contains(result, "* Fetches a user.") || !strings.Contains(result, "return get(url);") {
+		t.Errorf("expected the doc comment and full body, got:\n%s", result)
+	}
+	if strings.Contains(result, "limit") {
+		t.Errorf("expected the block to end at its closing brace, got:\n%s", result)
+	}
+
+	result, err = ReadSymbol(context.Background(), path, "limit")
+	if err != nil {
+		t.Fatalf("ReadSymbol failed: %v", err)
+	}
+	if !strings.Contains(result, "export const limit = 10;") || strings.Contains(result, "class Client") {
+		t.Errorf("unexpected constant source:\n%s", result)
+	}
+
+	result, err = ReadSymbol(context.Background(), path, "Client.send")
+	if err != nil {
+		t.Fatalf("ReadSymbol failed: %v", err)
+	}
+	if !strings.Contains(result, "return post(body);") || strings.Contains(result, "class Client") {
+		t.Errorf("unexpected method source:\n%s", result)
+	}
+}
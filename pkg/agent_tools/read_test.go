@@ -2,6 +2,7 @@ package tools
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -277,3 +278,94 @@ func TestReadFileDirectory(t *testing.T) {
 		t.Errorf("Expected error message about directory, got: %v", err)
 	}
 }
+
+// TestReadFilePreviewsLargeFileWithRangeSuggestions tests the head/tail
+// preview of a file over the read limit
+func TestReadFilePreviewsLargeFileWithRangeSuggestions(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "app.log")
+	var content strings.Builder
+	for i := 1; i <= 5000; i++ {
+		content.WriteString(fmt.Sprintf("Line %d: request handled\n", i))
+	}
+	if err := os.WriteFile(testFile, []byte(content.String()), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	ctx := WithReadLimits(context.Background(), ReadLimits{MaxBytes: 4096})
+	result, err := ReadFile(ctx, testFile)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	warning, body, _ := strings.Cut(result, "\n")
+	for _, want := range []string{"5000 lines", "Showing lines 1-", "-5000", "view_range=[", "search_files"} {
+		if !strings.Contains(warning, want) {
+			t.Errorf("Expected warning to contain %q, got: %s", want, warning)
+		}
+	}
+	if !strings.HasPrefix(body, "Line 1: ") || !strings.HasSuffix(body, "Line 5000: request handled\n") {
+		t.Errorf("Expected whole first and last lines, got: %q ... %q", body[:40], body[len(body)-40:])
+	}
+	if !strings.Contains(body, "omitted] ...") || strings.Contains(body, "Line 2500:") {
+		t.Errorf("Expected the middle of the file to be omitted")
+	}
+
+	// The suggested range starts right after the last line shown
+	var first, last int
+	if _, err := fmt.Sscanf(warning[strings.Index(warning, "view_range=["):], "view_range=[%d,%d]", &first, &last); err != nil {
+		t.Fatalf("No parsable range suggestion in: %s", warning)
+	}
+	if !strings.Contains(body, fmt.Sprintf("Line %d: ", first-1)) || strings.Contains(body, fmt.Sprintf("Line %d: ", first)) {
+		t.Errorf("Suggested range [%d,%d] does not continue after the head", first, last)
+	}
+}
+
+// TestReadFileWithRangeCapsOutput tests that a range larger than the range
+// limit is cut with a continuation hint
+func TestReadFileWithRangeCapsOutput(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "test.txt")
+	var content strings.Builder
+	for i := 1; i <= 100; i++ {
+		content.WriteString(fmt.Sprintf("Line %d\n", i))
+	}
+	if err := os.WriteFile(testFile, []byte(content.String()), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	ctx := WithReadLimits(context.Background(), ReadLimits{RangeMaxBytes: 50})
+	result, err := ReadFileWithRange(ctx, testFile, 1, 0)
+	if err != nil {
+		t.Fatalf("ReadFileWithRange failed: %v", err)
+	}
+	if !strings.Contains(result, "showing lines 1-7 of 101") || !strings.Contains(result, "view_range=[8,101]") {
+		t.Errorf("Expected capped output with a continuation hint, got: %s", result)
+	}
+	if strings.Contains(result, "Line 8\n") {
+		t.Errorf("Expected output to stop before line 8, got: %s", result)
+	}
+}
+
+// TestDescribeBinaryFile tests the metadata summary of a binary file
+func TestDescribeBinaryFile(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "server")
+	data := append([]byte("\x7fELF\x02\x01\x01\x00"), make([]byte, 600)...)
+	if err := os.WriteFile(testFile, data, 0755); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	ctx := WithReadLimits(context.Background(), ReadLimits{BinaryPreviewBytes: 32})
+	if _, err := ReadFile(ctx, testFile); !errors.Is(err, ErrNonTextFile) {
+		t.Fatalf("Expected ErrNonTextFile, got: %v", err)
+	}
+	summary, err := DescribeBinaryFile(ctx, testFile)
+	if err != nil {
+		t.Fatalf("DescribeBinaryFile failed: %v", err)
+	}
+	for _, want := range []string{"is a binary file", "Size: 608 B (608 bytes)", "Type: ELF executable", "SHA-256: ", "First 32 bytes:", "7f 45 4c 46"} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("Expected summary to contain %q, got:\n%s", want, summary)
+		}
+	}
+	if strings.Contains(summary, "00000020") {
+		t.Errorf("Expected the hex dump to stop at 32 bytes, got:\n%s", summary)
+	}
+}
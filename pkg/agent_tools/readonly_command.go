@@ -0,0 +1,214 @@
+package tools
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// readOnlyCommands only read files or report on the system, whatever their
+// arguments (the exceptions are checked in isReadOnlySingleCommand).
+var readOnlyCommands = map[string]bool{
+	"cat": true, "head": true, "tail": true, "wc": true, "nl": true,
+	"ls": true, "tree": true, "file": true, "stat": true, "du": true, "df": true,
+	"pwd": true, "cd": true, "echo": true, "printf": true, "true": true, "false": true, "test": true,
+	"which": true, "type": true, "whereis": true, "basename": true, "dirname": true, "realpath": true,
+	"sort": true, "uniq": true, "cut": true, "tr": true, "column": true, "fold": true,
+	"diff": true, "cmp": true, "comm": true,
+	"grep": true, "egrep": true, "fgrep": true, "rg": true, "ag": true, "jq": true,
+	"date": true, "whoami": true, "id": true, "uname": true, "printenv": true, "seq": true,
+	"sed": true, "awk": true, "find": true, "git": true, "go": true,
+}
+
+// findWriteFlags make find run commands or write files.
+var findWriteFlags = map[string]bool{
+	"-exec": true, "-execdir": true, "-ok": true, "-okdir": true, "-delete": true,
+	"-fprint": true, "-fprint0": true, "-fprintf": true, "-fls": true,
+}
+
+// readOnlyGitCommands never change the repository. branch, tag, remote,
+// stash and config are read-only only in their listing forms.
+var readOnlyGitCommands = map[string]bool{
+	"status": true, "log": true, "diff": true, "show": true, "blame": true, "shortlog": true,
+	"describe": true, "rev-parse": true, "rev-list": true, "ls-files": true, "ls-tree": true,
+	"ls-remote": true, "cat-file": true, "grep": true, "for-each-ref": true, "name-rev": true,
+	"merge-base": true, "whatchanged": true, "version": true, "help": true,
+}
+
+// sedWriteCommand matches sed's w and W commands, which write to a file, and
+// its e command and flag, which run one.
+var sedWriteCommand = regexp.MustCompile(`(^|[;{}/0-9$])\s*[wWe](\s|$)`)
+
+// IsReadOnlyShellCommand reports whether cmd only reads: every command in a
+// chain or pipeline is a known read-only command, nothing is redirected to a
+// file other than /dev/null, and there are no command substitutions or
+// heredocs whose content cannot be checked. It is deliberately strict; an
+// unknown command is treated as a write.
+func IsReadOnlyShellCommand(cmd string) bool {
+	cmd = strings.TrimSpace(cmd)
+	if cmd == "" || strings.ContainsAny(cmd, "`\n") || strings.Contains(cmd, "$(") || strings.Contains(cmd, "<<") {
+		return false
+	}
+	withoutNull := cmd
+	for _, sink := range []string{"2>/dev/null", "2> /dev/null", "&>/dev/null", "&> /dev/null", ">/dev/null", "> /dev/null", "2>&1", "&&"} {
+		withoutNull = strings.ReplaceAll(withoutNull, sink, "")
+	}
+	// A lone & starts a background command that is not split out below
+	if containsRedirection(withoutNull) || strings.Contains(stripQuotedSections(withoutNull), "&") {
+		return false
+	}
+	for _, part := range splitCommandChain(cmd) {
+		if !isReadOnlySingleCommand(part) {
+			return false
+		}
+	}
+	return true
+}
+
+func isReadOnlySingleCommand(cmd string) bool {
+	fields := shellFields(cmd)
+	// Skip leading VAR=value assignments
+	for len(fields) > 0 && strings.Contains(fields[0], "=") && !strings.HasPrefix(fields[0], "-") {
+		fields = fields[1:]
+	}
+	if len(fields) == 0 {
+		return true
+	}
+	name, args := filepath.Base(fields[0]), fields[1:]
+	if !readOnlyCommands[name] {
+		return false
+	}
+
+	switch name {
+	case "sort":
+		return !hasArg(args, "-o", "--output")
+	case "sed":
+		for _, arg := range args {
+			if strings.HasPrefix(arg, "-i") || strings.HasPrefix(arg, "--in-place") {
+				return false
+			}
+			if !strings.HasPrefix(arg, "-") && sedWriteCommand.MatchString(arg) {
+				return false
+			}
+		}
+	case "awk":
+		for _, arg := range args {
+			if strings.Contains(arg, "system") || strings.Contains(arg, ">") || strings.Contains(arg, "|") {
+				return false
+			}
+		}
+	case "find":
+		for _, arg := range args {
+			if findWriteFlags[arg] {
+				return false
+			}
+		}
+	case "go":
+		if len(args) == 0 {
+			return false
+		}
+		switch args[0] {
+		case "list", "version", "doc":
+			return true
+		case "env":
+			return !hasArg(args, "-w", "-u")
+		}
+		return false
+	case "git":
+		return isReadOnlyGitCommand(args)
+	}
+	return true
+}
+
+func isReadOnlyGitCommand(args []string) bool {
+	// Skip global options such as -C <dir>, -c key=value and --no-pager
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		if (args[0] == "-C" || args[0] == "-c") && len(args) > 1 {
+			args = args[1:]
+		}
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		return true
+	}
+	sub, rest := args[0], args[1:]
+	if readOnlyGitCommands[sub] {
+		return !hasArg(rest, "--output")
+	}
+
+	var positional []string
+	for _, arg := range rest {
+		if !strings.HasPrefix(arg, "-") {
+			positional = append(positional, arg)
+		}
+	}
+	listing := hasArg(rest, "-l", "--list")
+	switch sub {
+	case "branch":
+		if hasArg(rest, "-d", "-D", "--delete", "-m", "-M", "--move", "-c", "-C", "--copy", "-f", "--force",
+			"-u", "--set-upstream-to", "--unset-upstream", "--edit-description") {
+			return false
+		}
+		return len(positional) == 0 || listing || hasArg(rest, "--contains", "--merged", "--no-merged", "--points-at")
+	case "tag":
+		if hasArg(rest, "-d", "--delete", "-a", "-s", "-f", "--force", "-m") {
+			return false
+		}
+		return len(positional) == 0 || listing || hasArg(rest, "--contains", "--merged", "--no-merged", "--points-at")
+	case "remote":
+		return len(positional) == 0 || positional[0] == "show" || positional[0] == "get-url"
+	case "stash":
+		return len(positional) > 0 && (positional[0] == "list" || positional[0] == "show")
+	case "config":
+		return hasArg(rest, "--get", "--get-all", "--get-regexp", "-l", "--list")
+	}
+	return false
+}
+
+// hasArg reports whether args contains any of flags, alone or as --flag=value.
+func hasArg(args []string, flags ...string) bool {
+	for _, arg := range args {
+		for _, flag := range flags {
+			if arg == flag || strings.HasPrefix(arg, flag+"=") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// shellFields splits a command into words, keeping quoted strings together
+// and dropping the quotes.
+func shellFields(cmd string) []string {
+	var fields []string
+	var current strings.Builder
+	inWord := false
+	quote := byte(0)
+	for i := 0; i < len(cmd); i++ {
+		c := cmd[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else {
+				current.WriteByte(c)
+			}
+		case c == '\'' || c == '"':
+			quote = c
+			inWord = true
+		case c == ' ' || c == '\t' || c == '\n':
+			if inWord {
+				fields = append(fields, current.String())
+				current.Reset()
+				inWord = false
+			}
+		default:
+			current.WriteByte(c)
+			inWord = true
+		}
+	}
+	if inWord {
+		fields = append(fields, current.String())
+	}
+	return fields
+}
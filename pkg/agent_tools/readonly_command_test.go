@@ -0,0 +1,53 @@
+package tools
+
+import "testing"
+
+func TestIsReadOnlyShellCommand(t *testing.T) {
+	readOnly := []string{
+		"ls -la",
+		"cat go.mod | head -20",
+		"grep -rn 'a|b' pkg/ 2>/dev/null",
+		"sed -n '1,40p' main.go",
+		"find . -name '*.go' -type f",
+		"git status && git diff --stat",
+		"git -C sub log --oneline -5",
+		"git branch -a",
+		"git stash list",
+		"go list ./...",
+		"LC_ALL=C sort file.txt | uniq -c",
+	}
+	for _, cmd := range readOnly {
+		if !IsReadOnlyShellCommand(cmd) {
+			t.Errorf("expected %q to be read-only", cmd)
+		}
+	}
+
+	writes := []string{
+		"",
+		"rm -rf build",
+		"echo hi > notes.txt",
+		"cat a >> b",
+		"sed -i 's/a/b/' main.go",
+		"sed 's/a/b/w out.txt' main.go",
+		"find . -name '*.tmp' -delete",
+		"find . -exec rm {} \\;",
+		"git commit -m wip",
+		"git branch -D old",
+		"git branch feature",
+		"git stash",
+		"go test ./...",
+		"go env -w GOFLAGS=-mod=mod",
+		"sort -o out.txt in.txt",
+		"awk '{ system(\"rm x\") }' f",
+		"cat $(ls)",
+		"ls & rm -rf x",
+		"ls\nrm x",
+		"make build",
+		"npm install",
+	}
+	for _, cmd := range writes {
+		if IsReadOnlyShellCommand(cmd) {
+			t.Errorf("expected %q not to be read-only", cmd)
+		}
+	}
+}
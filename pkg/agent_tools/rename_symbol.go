@@ -0,0 +1,118 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alantheprice/ledit/pkg/filesystem"
+	"github.com/alantheprice/ledit/pkg/lsp/semantic"
+)
+
+// RenameSymbol renames the Go symbol declared in filePath, and every
+// reference to it across the workspace, to newName using gopls. symbol is a
+// top-level name, "Type.Method" or "Type.Field". Local variables, parameters
+// and other identifiers need line, the line they appear on in filePath.
+// It returns the changed files with their content before and after.
+func RenameSymbol(ctx context.Context, workspaceRoot, filePath, symbol, newName string, line int) ([]semantic.RenamedFile, error) {
+	symbol = strings.TrimSpace(symbol)
+	newName = strings.TrimSpace(newName)
+	if symbol == "" {
+		return nil, fmt.Errorf("symbol name is required")
+	}
+	if !token.IsIdentifier(newName) {
+		return nil, fmt.Errorf("new name %q is not a valid Go identifier", newName)
+	}
+
+	cleanPath, err := filesystem.SafeResolvePathWithBypass(ctx, filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve file path: %w", err)
+	}
+	if filepath.Ext(cleanPath) != ".go" {
+		return nil, fmt.Errorf("rename_symbol supports Go files only; got %s", filePath)
+	}
+	content, err := os.ReadFile(cleanPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s: %w", cleanPath, err)
+	}
+
+	pos, err := goIdentPosition(cleanPath, content, symbol, line)
+	if err != nil {
+		return nil, err
+	}
+	return semantic.RenameGo(ctx, workspaceRoot, cleanPath, pos, newName)
+}
+
+// goIdentPosition finds the identifier to rename. With line set it is the
+// first identifier named symbol on that line; otherwise it is the name of the
+// top-level declaration, method or struct field symbol refers to.
+func goIdentPosition(path string, content []byte, symbol string, line int) (semantic.Position, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, content, parser.SkipObjectResolution)
+	if file == nil {
+		return semantic.Position{}, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	position := func(ident *ast.Ident) semantic.Position {
+		p := fset.Position(ident.Pos())
+		return semantic.Position{Line: p.Line, Column: p.Column}
+	}
+
+	recvName, name := "", symbol
+	if dot := strings.LastIndex(symbol, "."); dot >= 0 {
+		recvName = strings.Trim(symbol[:dot], "()*")
+		name = symbol[dot+1:]
+	}
+
+	if line > 0 {
+		var found *ast.Ident
+		ast.Inspect(file, func(n ast.Node) bool {
+			if ident, ok := n.(*ast.Ident); ok && found == nil && ident.Name == name && fset.Position(ident.Pos()).Line == line {
+				found = ident
+			}
+			return found == nil
+		})
+		if found == nil {
+			return semantic.Position{}, fmt.Errorf("no identifier %q on line %d of %s", name, line, path)
+		}
+		return position(found), nil
+	}
+
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Name.Name == name && (recvName == "" || recvName == goReceiverName(d)) {
+				return position(d.Name), nil
+			}
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					if recvName == "" && s.Name.Name == name {
+						return position(s.Name), nil
+					}
+					if st, ok := s.Type.(*ast.StructType); ok && s.Name.Name == recvName {
+						for _, field := range st.Fields.List {
+							for _, ident := range field.Names {
+								if ident.Name == name {
+									return position(ident), nil
+								}
+							}
+						}
+					}
+				case *ast.ValueSpec:
+					for _, ident := range s.Names {
+						if recvName == "" && ident.Name == name {
+							return position(ident), nil
+						}
+					}
+				}
+			}
+		}
+	}
+	return semantic.Position{}, fmt.Errorf("symbol %q is not declared at the top level of %s; pass line to rename a local identifier", symbol, path)
+}
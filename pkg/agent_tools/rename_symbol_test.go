@@ -0,0 +1,93 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGoIdentPosition(t *testing.T) {
+	tests := []struct {
+		symbol       string
+		line         int
+		wantLine     int
+		wantColumn   int
+		wantNotFound bool
+	}{
+		{symbol: "Square", wantLine: 11, wantColumn: 6},
+		{symbol: "Square.Area", wantLine: 16, wantColumn: 18},
+		{symbol: "(*Square).Area", wantLine: 16, wantColumn: 18},
+		{symbol: "Square.Side", wantLine: 12, wantColumn: 2},
+		{symbol: "E", wantLine: 23, wantColumn: 2},
+		{symbol: "s", line: 27, wantLine: 27, wantColumn: 15},
+		{symbol: "Missing", wantNotFound: true},
+		{symbol: "s", line: 3, wantNotFound: true},
+	}
+	for _, tt := range tests {
+		pos, err := goIdentPosition("shapes.go", []byte(readSymbolGoSource), tt.symbol, tt.line)
+		if tt.wantNotFound {
+			if err == nil {
+				t.Errorf("%s: expected an error, got %+v", tt.symbol, pos)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.symbol, err)
+			continue
+		}
+		if pos.Line != tt.wantLine || pos.Column != tt.wantColumn {
+			t.Errorf("%s: got %d:%d, want %d:%d", tt.symbol, pos.Line, pos.Column, tt.wantLine, tt.wantColumn)
+		}
+	}
+}
+
+func TestRenameSymbolValidatesInput(t *testing.T) {
+	dir := t.TempDir()
+	goFile := filepath.Join(dir, "shapes.go")
+	if err := os.WriteFile(goFile, []byte(readSymbolGoSource), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	jsFile := filepath.Join(dir, "app.js")
+	if err := os.WriteFile(jsFile, []byte("function f() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := RenameSymbol(context.Background(), dir, goFile, "Square", "not valid", 0); err == nil || !strings.Contains(err.Error(), "not a valid Go identifier") {
+		t.Errorf("expected an invalid identifier error, got %v", err)
+	}
+	if _, err := RenameSymbol(context.Background(), dir, jsFile, "f", "g", 0); err == nil || !strings.Contains(err.Error(), "Go files only") {
+		t.Errorf("expected non-Go files to be rejected, got %v", err)
+	}
+}
+
+func TestRenameSymbolWithGopls(t *testing.T) {
+	if _, err := exec.LookPath("gopls"); err != nil {
+		t.Skip("gopls is not installed")
+	}
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":    "module example.com/shapes\n\ngo 1.21\n",
+		"shapes.go": readSymbolGoSource,
+		"use.go":    "package shapes\n\nfunc Unit() *Square { return &Square{Side: 1} }\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	renamed, err := RenameSymbol(context.Background(), dir, filepath.Join(dir, "shapes.go"), "Square", "Box", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(renamed) != 2 {
+		t.Fatalf("expected both files to change, got %d", len(renamed))
+	}
+	use, _ := os.ReadFile(filepath.Join(dir, "use.go"))
+	if !strings.Contains(string(use), "*Box") || strings.Contains(string(use), "Square") {
+		t.Errorf("references were not renamed:\n%s", use)
+	}
+}
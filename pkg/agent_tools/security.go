@@ -14,8 +14,9 @@
 //   - Relative path traversal is not resolved. "rm -rf ../important-project" bypasses
 //     all safe-directory checks because the classifier only matches the first path
 //     component literally (".." has no special meaning here).
-//   - Path normalization is not performed. Multiple slashes ("//"), "." segments,
-//     and case variations on case-insensitive filesystems are not normalized.
+//   - Command arguments are not normalized. Multiple slashes ("//"), "." segments,
+//     and case variations on case-insensitive filesystems are matched literally.
+//     Only write paths are normalized (see normalizeSecurityPath).
 //   - Environment variable expansion, glob expansion, and shell aliases are not
 //     considered. "rm -rf $BUILD_DIR" is classified as CAUTION (command substitution),
 //     not DANGEROUS, because the classifier cannot resolve the variable.
@@ -29,7 +30,10 @@
 // should be handled by separate layers.
 package tools
 
-import "strings"
+import (
+	"path"
+	"strings"
+)
 
 // SecurityRisk represents the risk level of a tool call
 type SecurityRisk int
@@ -66,11 +70,11 @@ type SecurityResult struct {
 
 // Readonly tools map - package level to avoid recreation
 var readonlyTools = map[string]bool{
-	"read_file": true, "search_files": true, "web_search": true,
-	"fetch_url": true, "browse_url": true,
+	"read_file": true, "read_symbol": true, "impact_of_change": true, "file_history": true, "run_linters": true, "search_files": true, "web_search": true,
+	"fetch_url": true, "fetch_more": true, "browse_url": true,
 	"analyze_ui_screenshot": true, "analyze_image_content": true,
 	"view_history": true, "TodoRead": true, "TodoWrite": true,
-	"list_skills": true, "run_subagent": true, "run_parallel_subagents": true,
+	"list_skills": true, "list_personas": true, "ask_user": true, "run_subagent": true, "run_parallel_subagents": true,
 	"glob": true, "list_directory": true, "get_file_info": true,
 	"list_processes": true, "self_review": true,
 }
@@ -89,10 +93,16 @@ func ClassifyToolCall(toolName string, args map[string]interface{}) SecurityResu
 	switch toolName {
 	case "shell_command":
 		return classifyShellCommand(args)
-	case "write_file", "edit_file", "write_structured_file", "patch_structured_file":
+	case "write_file", "edit_file", "write_structured_file", "patch_structured_file", "rename_symbol":
 		return classifyWriteOperation(args)
 	case "git":
 		return classifyGitOperation(args)
+	case "pr":
+		return classifyPullRequestOperation(args)
+	case "test_coverage":
+		return classifyCoverageOperation(args)
+	case "edit_transaction":
+		return classifyEditTransaction(args)
 	default:
 		return SecurityResult{Risk: SecurityCaution, Reasoning: "Unknown tool type - manual review recommended", ShouldPrompt: true}
 	}
@@ -148,7 +158,16 @@ func classifyChainedCommand(cmd string) []SecurityRisk {
 		}
 	}
 
-	// Split on &&, ||, ;, | but respect quotes
+	var risks []SecurityRisk
+	for _, part := range splitCommandChain(cmd) {
+		risks = append(risks, classifySingleCommand(part))
+	}
+	return risks
+}
+
+// splitCommandChain splits cmd on &&, ||, ; and | outside quotes and returns
+// the non-empty commands.
+func splitCommandChain(cmd string) []string {
 	var parts []string
 	current := &strings.Builder{}
 	inQuote := false
@@ -203,15 +222,13 @@ func classifyChainedCommand(cmd string) []SecurityRisk {
 		parts = append(parts, strings.TrimSpace(current.String()))
 	}
 
-	var risks []SecurityRisk
+	commands := parts[:0]
 	for _, part := range parts {
-		part = strings.TrimSpace(part)
-		if part == "" {
-			continue
+		if part = strings.TrimSpace(part); part != "" {
+			commands = append(commands, part)
 		}
-		risks = append(risks, classifySingleCommand(part))
 	}
-	return risks
+	return commands
 }
 
 // classifySingleCommand classifies a single command (no chaining)
@@ -807,6 +824,11 @@ func isDangerousPattern(cmd string) bool {
 		}
 	}
 
+	// Recursive deletes with cmd.exe or PowerShell have no safe allowlist
+	if isWindowsRecursiveDelete(cmdLower) {
+		return true
+	}
+
 	// Check for rm -rf or rm -fr (case-insensitive) - default to dangerous
 	// Check if an rm -rf target is safe (O(1) map lookup)
 	if isSafeRmRfPrefix(cmdLower) {
@@ -984,7 +1006,7 @@ func classifyWriteOperation(args map[string]interface{}) SecurityResult {
 		return SecurityResult{Risk: SecurityCaution, Reasoning: "Empty or invalid path", ShouldPrompt: true}
 	}
 
-	path := pathRaw
+	path := normalizeSecurityPath(pathRaw)
 
 	// Check for critical system files and directories
 	for _, critical := range []string{
@@ -992,10 +1014,13 @@ func classifyWriteOperation(args map[string]interface{}) SecurityResult {
 		"/root/.ssh/authorized_keys", "/etc/hosts", "/etc/resolv.conf",
 		"/usr/", "/etc/", "/bin/", "/sbin/", "/var/", "/opt/", "/boot/", "/lib/", "/lib64/",
 	} {
-		if path == critical || strings.HasPrefix(path, critical) {
-			return SecurityResult{Risk: SecurityDangerous, Reasoning: "Writing to critical system file or directory: " + path, ShouldBlock: true, ShouldPrompt: true, IsHardBlock: true, RiskType: "system_integrity"}
+		if path == strings.TrimSuffix(critical, "/") || strings.HasPrefix(path, critical) {
+			return SecurityResult{Risk: SecurityDangerous, Reasoning: "Writing to critical system file or directory: " + pathRaw, ShouldBlock: true, ShouldPrompt: true, IsHardBlock: true, RiskType: "system_integrity"}
 		}
 	}
+	if isWindowsSystemPath(path) {
+		return SecurityResult{Risk: SecurityDangerous, Reasoning: "Writing to critical system file or directory: " + pathRaw, ShouldBlock: true, ShouldPrompt: true, IsHardBlock: true, RiskType: "system_integrity"}
+	}
 
 	if strings.HasPrefix(path, "/tmp/") || path == "/tmp" {
 		return SecurityResult{Risk: SecuritySafe, Reasoning: "Writing to temporary directory"}
@@ -1041,6 +1066,52 @@ func classifyGitOperation(args map[string]interface{}) SecurityResult {
 	return SecurityResult{Risk: SecurityCaution, Reasoning: "Unknown git operation: " + op, ShouldPrompt: true}
 }
 
+// classifyPullRequestOperation classifies pr tool actions. Reading pull
+// request state is safe; creating pull requests and replying to comments
+// publish to the forge and need approval.
+func classifyPullRequestOperation(args map[string]interface{}) SecurityResult {
+	action, _ := args["action"].(string)
+	switch strings.ToLower(strings.TrimSpace(action)) {
+	case "status", "comments":
+		return SecurityResult{Risk: SecuritySafe, Reasoning: "Read-only pull request operation"}
+	case "create", "reply":
+		return SecurityResult{Risk: SecurityCaution, Reasoning: "Publishes to the code hosting service: pr " + action, ShouldPrompt: true}
+	default:
+		return SecurityResult{Risk: SecurityCaution, Reasoning: "Unknown pull request action: " + action, ShouldPrompt: true}
+	}
+}
+
+// classifyCoverageOperation allows reading stored coverage; refreshing it runs
+// the workspace's tests, which is as risky as running go test.
+func classifyCoverageOperation(args map[string]interface{}) SecurityResult {
+	if refresh, _ := args["refresh"].(bool); refresh {
+		return SecurityResult{Risk: SecurityCaution, Reasoning: "Runs the workspace's Go tests"}
+	}
+	return SecurityResult{Risk: SecuritySafe, Reasoning: "Reads stored test coverage"}
+}
+
+// classifyEditTransaction classifies each file of an edit transaction as a
+// write and returns the riskiest result.
+func classifyEditTransaction(args map[string]interface{}) SecurityResult {
+	edits, ok := args["edits"].([]interface{})
+	if !ok || len(edits) == 0 {
+		return SecurityResult{Risk: SecurityCaution, Reasoning: "Empty or invalid edits", ShouldPrompt: true}
+	}
+	result := SecurityResult{Risk: SecuritySafe, Reasoning: "Workspace file operation"}
+	for _, item := range edits {
+		edit, _ := item.(map[string]interface{})
+		path, _ := edit["path"].(string)
+		if path == "" {
+			path, _ = edit["file_path"].(string)
+		}
+		r := classifyWriteOperation(map[string]interface{}{"path": path})
+		if r.Risk > result.Risk || (r.ShouldPrompt && !result.ShouldPrompt) {
+			result = r
+		}
+	}
+	return result
+}
+
 // isCriticalSystemOperation checks for critical system operations that should always be blocked
 func isCriticalSystemOperation(toolName string, args map[string]interface{}) bool {
 	if toolName != "shell_command" {
@@ -1072,6 +1143,23 @@ func isCriticalSystemOperation(toolName string, args map[string]interface{}) boo
 	if strings.HasPrefix(cmd, "mkfs") || strings.HasPrefix(cmd, "mkfs.") {
 		return true
 	}
+	if len(parts) >= 2 && strings.EqualFold(parts[0], "format") && isWindowsDrivePath(parts[1]) {
+		return true
+	}
+
+	// Windows: recursive deletes of a drive root or the system directories
+	if isWindowsRecursiveDelete(strings.ToLower(cmd)) {
+		for _, arg := range parts[1:] {
+			lower := strings.ToLower(arg)
+			if strings.Contains(lower, "systemroot") || strings.Contains(lower, "windir") {
+				return true
+			}
+			target := normalizeSecurityPath(strings.Trim(arg, `"'`))
+			if isWindowsDrivePath(target) && (len(target) == 2 || target[2:] == "/*" || isWindowsSystemPath(target)) {
+				return true
+			}
+		}
+	}
 
 	// Fork bomb
 	if strings.Contains(cmd, "() { :|: }") || strings.Contains(cmd, "fork bomb") ||
@@ -1107,6 +1195,70 @@ func isCriticalSystemOperation(toolName string, args map[string]interface{}) boo
 	return false
 }
 
+// windowsSystemDirs are the Windows directories writes must never touch,
+// relative to a drive root and lowercased.
+var windowsSystemDirs = []string{"/windows", "/program files", "/program files (x86)"}
+
+// normalizeSecurityPath puts a write path in the canonical form the critical
+// path checks compare against: forward slashes, repeated slashes and "." and
+// ".." segments resolved, the \\?\ long-path prefix dropped, and Windows drive
+// paths, whose filesystems ignore case, lowercased.
+func normalizeSecurityPath(p string) string {
+	p = strings.TrimPrefix(strings.ReplaceAll(p, `\`, "/"), "//?/")
+	if p == "" {
+		return p
+	}
+	p = path.Clean(p)
+	if isWindowsDrivePath(p) {
+		p = strings.ToLower(p)
+	}
+	return p
+}
+
+// isWindowsDrivePath reports whether p starts with a drive letter, as in
+// C: or C:/Windows.
+func isWindowsDrivePath(p string) bool {
+	if len(p) < 2 || p[1] != ':' {
+		return false
+	}
+	c := p[0] | 0x20
+	return c >= 'a' && c <= 'z' && (len(p) == 2 || p[2] == '/' || p[2] == '\\')
+}
+
+// isWindowsSystemPath reports whether a normalized path is in one of the
+// Windows system directories on any drive.
+func isWindowsSystemPath(p string) bool {
+	if !isWindowsDrivePath(p) {
+		return false
+	}
+	rest := p[2:]
+	for _, dir := range windowsSystemDirs {
+		if rest == dir || strings.HasPrefix(rest, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// isWindowsRecursiveDelete matches rd /s, rmdir /s, del /s and Remove-Item
+// -Recurse (and its ri alias), the cmd.exe and PowerShell counterparts of
+// rm -rf. cmd must be lowercased.
+func isWindowsRecursiveDelete(cmd string) bool {
+	fields := strings.Fields(cmd)
+	if len(fields) == 0 {
+		return false
+	}
+	switch fields[0] {
+	case "rd", "rmdir", "del", "erase", "remove-item", "ri":
+		for _, field := range fields[1:] {
+			if field == "/s" || strings.HasPrefix(field, "-r") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // stripQuotedSections replaces the content of quoted strings (single and double
 // quotes) with spaces, preserving string length. This is used before pattern
 // matching to avoid false positives from | or other shell metacharacters that
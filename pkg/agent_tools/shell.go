@@ -13,8 +13,66 @@ import (
 	"syscall"
 
 	"github.com/alantheprice/ledit/pkg/filesystem"
+	"github.com/alantheprice/ledit/pkg/sandbox"
 )
 
+type shellSandboxKey struct{}
+
+// WithShellSandbox returns a context whose shell commands run in executor.
+func WithShellSandbox(ctx context.Context, executor *sandbox.Executor) context.Context {
+	return context.WithValue(ctx, shellSandboxKey{}, executor)
+}
+
+// ShellSandboxFromContext returns the sandbox carried on ctx, if any.
+func ShellSandboxFromContext(ctx context.Context) *sandbox.Executor {
+	if ctx == nil {
+		return nil
+	}
+	executor, _ := ctx.Value(shellSandboxKey{}).(*sandbox.Executor)
+	return executor
+}
+
+type execBackendKey struct{}
+
+// ExecBackend runs shell commands somewhere other than the local machine,
+// such as a remote host or a devcontainer.
+type ExecBackend interface {
+	// Describe names where commands run, for status messages.
+	Describe() string
+	// Command prepares shellCommand to run in dir, a workspace directory.
+	// Once it has exited, finish is given its output and returns the output
+	// to show.
+	Command(ctx context.Context, dir, shellCommand string) (cmd *exec.Cmd, finish func(output string) (string, error), err error)
+}
+
+// WithExecBackend returns a context whose shell commands run on backend.
+func WithExecBackend(ctx context.Context, backend ExecBackend) context.Context {
+	return context.WithValue(ctx, execBackendKey{}, backend)
+}
+
+// ExecBackendFromContext returns the execution backend carried on ctx, if
+// any.
+func ExecBackendFromContext(ctx context.Context) ExecBackend {
+	if ctx == nil {
+		return nil
+	}
+	backend, _ := ctx.Value(execBackendKey{}).(ExecBackend)
+	return backend
+}
+
+// finishShellOutput hands output to an execution backend's finish. A failure
+// there is reported in the output, since the command itself has run.
+func finishShellOutput(finish func(string) (string, error), output string) string {
+	if finish == nil {
+		return output
+	}
+	output, err := finish(output)
+	if err != nil {
+		output += fmt.Sprintf("\n[remote] Warning: %v", err)
+	}
+	return output
+}
+
 // ExecuteShellCommand executes a shell command with safety checks
 func ExecuteShellCommand(ctx context.Context, command string) (string, error) {
 	return ExecuteShellCommandWithSafety(ctx, command, true, "", false)
@@ -30,18 +88,25 @@ func ExecuteShellCommandWithSafety(ctx context.Context, command string, interact
 
 	// NOTE: Security validation is handled by the static classifier in security.go, invoked at the tool registry level
 
-	// Create command with context
-	shell := os.Getenv("SHELL")
-	if shell == "" {
-		shell = "/bin/sh"
+	// Explicitly set working directory to the workspace carried on the context.
+	dir := filesystem.WorkspaceRootFromContext(ctx)
+	if dir == "" {
+		dir, _ = os.Getwd()
 	}
-	cmd := exec.CommandContext(ctx, shell, "-c", command)
 
-	// Explicitly set working directory to the workspace carried on the context.
-	if wd := filesystem.WorkspaceRootFromContext(ctx); wd != "" {
-		cmd.Dir = wd
-	} else if wd, err := os.Getwd(); err == nil {
-		cmd.Dir = wd
+	// Create command with context
+	var cmd *exec.Cmd
+	var finish func(string) (string, error)
+	if backend := ExecBackendFromContext(ctx); backend != nil && dir != "" {
+		var err error
+		if cmd, finish, err = backend.Command(ctx, dir, command); err != nil {
+			return "", fmt.Errorf("failed to run on %s: %w", backend.Describe(), err)
+		}
+	} else if executor := ShellSandboxFromContext(ctx); executor != nil && dir != "" {
+		cmd = executor.Command(ctx, dir, command)
+	} else {
+		cmd = LocalShell().Command(ctx, command)
+		cmd.Dir = dir
 	}
 
 	if streamOutput {
@@ -58,6 +123,7 @@ func ExecuteShellCommandWithSafety(ctx context.Context, command string, interact
 
 		// Start the command
 		if err := cmd.Start(); err != nil {
+			finishShellOutput(finish, "")
 			return "", fmt.Errorf("failed to start command: %w", err)
 		}
 
@@ -99,7 +165,7 @@ func ExecuteShellCommandWithSafety(ctx context.Context, command string, interact
 		}
 
 		// Build the final output with status header
-		finalOutput := buildShellOutputWithStatus(outputBuf.String(), command, exitCode, err)
+		finalOutput := finishShellOutput(finish, buildShellOutputWithStatus(outputBuf.String(), command, exitCode, err))
 
 		// Shell tool execution is always successful as long as we can run the command
 		// Non-zero exit codes are normal command outcomes, not tool failures
@@ -133,7 +199,7 @@ func ExecuteShellCommandWithSafety(ctx context.Context, command string, interact
 	}
 
 	// Build the final output with status header
-	finalOutput := buildShellOutputWithStatus(output, command, exitCode, err)
+	finalOutput := finishShellOutput(finish, buildShellOutputWithStatus(output, command, exitCode, err))
 
 	return finalOutput, nil
 }
@@ -0,0 +1,12 @@
+//go:build !windows
+// +build !windows
+
+package tools
+
+import "os/exec"
+
+// setCmdCommandLine passes command to a cmd.exe-style shell, which only
+// Windows has.
+func setCmdCommandLine(cmd *exec.Cmd, command string) {
+	cmd.Args = append(cmd.Args, "/d", "/s", "/c", command)
+}
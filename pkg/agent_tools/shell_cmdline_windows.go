@@ -0,0 +1,18 @@
+//go:build windows
+// +build windows
+
+package tools
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setCmdCommandLine passes command to cmd.exe verbatim. cmd.exe does not
+// follow the quoting rules exec uses for arguments, so the command line is
+// built by hand: /s strips the outer quotes and leaves the rest as typed.
+func setCmdCommandLine(cmd *exec.Cmd, command string) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		CmdLine: syscall.EscapeArg(cmd.Path) + ` /d /s /c "` + command + `"`,
+	}
+}
@@ -0,0 +1,92 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// ShellKind is the command syntax a shell accepts.
+type ShellKind string
+
+const (
+	ShellPOSIX      ShellKind = "posix"
+	ShellPowerShell ShellKind = "powershell"
+	ShellCmd        ShellKind = "cmd"
+)
+
+// Shell is the local shell that runs shell_command commands.
+type Shell struct {
+	Kind ShellKind
+	Path string
+}
+
+var (
+	localShellOnce sync.Once
+	localShell     Shell
+)
+
+// LocalShell returns the shell local commands run in. On Unix that is
+// $SHELL, or /bin/sh. On Windows it is the shell $SHELL names when it can be
+// found, as under Git Bash or MSYS2, and otherwise PowerShell 7 (pwsh),
+// Windows PowerShell, then cmd.exe.
+func LocalShell() Shell {
+	localShellOnce.Do(func() {
+		localShell = detectShell(runtime.GOOS, os.Getenv, exec.LookPath)
+	})
+	return localShell
+}
+
+func detectShell(goos string, getenv func(string) string, lookPath func(string) (string, error)) Shell {
+	shell := getenv("SHELL")
+	if goos != "windows" {
+		if shell == "" {
+			shell = "/bin/sh"
+		}
+		return Shell{Kind: ShellPOSIX, Path: shell}
+	}
+
+	if shell != "" {
+		// MSYS shells export POSIX paths such as /usr/bin/bash; the shell
+		// itself is on PATH.
+		for _, candidate := range []string{shell, path.Base(strings.ReplaceAll(shell, `\`, "/"))} {
+			if found, err := lookPath(candidate); err == nil {
+				return Shell{Kind: ShellPOSIX, Path: found}
+			}
+		}
+	}
+	for _, name := range []string{"pwsh", "powershell"} {
+		if found, err := lookPath(name); err == nil {
+			return Shell{Kind: ShellPowerShell, Path: found}
+		}
+	}
+	comspec := getenv("ComSpec")
+	if comspec == "" {
+		comspec = "cmd.exe"
+	}
+	return Shell{Kind: ShellCmd, Path: comspec}
+}
+
+// Name returns the shell's executable name, such as pwsh or bash.
+func (s Shell) Name() string {
+	name := path.Base(strings.ReplaceAll(s.Path, `\`, "/"))
+	return strings.TrimSuffix(strings.TrimSuffix(name, ".exe"), ".EXE")
+}
+
+// Command returns a command running command in the shell.
+func (s Shell) Command(ctx context.Context, command string) *exec.Cmd {
+	switch s.Kind {
+	case ShellPowerShell:
+		return exec.CommandContext(ctx, s.Path, "-NoProfile", "-NonInteractive", "-Command", command)
+	case ShellCmd:
+		cmd := exec.CommandContext(ctx, s.Path)
+		setCmdCommandLine(cmd, command)
+		return cmd
+	default:
+		return exec.CommandContext(ctx, s.Path, "-c", command)
+	}
+}
@@ -2,11 +2,14 @@ package tools
 
 import (
 	"context"
+	"errors"
 	"io"
 	"os"
+	"os/exec"
 	"strings"
 	"testing"
 
+	"github.com/alantheprice/ledit/pkg/filesystem"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -150,3 +153,62 @@ func TestExecuteShellCommandDoesNotPrintPreviewDuringTests(t *testing.T) {
 
 	assert.Empty(t, string(captured), "silent shell execution should not print preview output during tests")
 }
+
+// prefixBackend runs commands locally with a marker, standing in for a
+// remote host.
+type prefixBackend struct {
+	finished string
+}
+
+func (b *prefixBackend) Describe() string { return "test backend" }
+
+func (b *prefixBackend) Command(ctx context.Context, dir, shellCommand string) (*exec.Cmd, func(string) (string, error), error) {
+	cmd := exec.CommandContext(ctx, "sh", "-c", "echo on-backend; "+shellCommand)
+	cmd.Dir = dir
+	return cmd, func(output string) (string, error) {
+		b.finished = output
+		return strings.ReplaceAll(output, "on-backend", "mapped"), errors.New("sync failed")
+	}, nil
+}
+
+func TestShellCommandRunsOnExecBackend(t *testing.T) {
+	backend := &prefixBackend{}
+	ctx := filesystem.WithWorkspaceRoot(WithExecBackend(context.Background(), backend), t.TempDir())
+
+	output, err := ExecuteShellCommand(ctx, "echo hello")
+	require.NoError(t, err)
+	assert.Equal(t, "on-backend\nhello\n", backend.finished)
+	assert.Equal(t, "mapped\nhello\n\n[remote] Warning: sync failed", output)
+}
+
+func TestDetectShell(t *testing.T) {
+	lookPath := func(installed ...string) func(string) (string, error) {
+		return func(name string) (string, error) {
+			for _, bin := range installed {
+				if bin == name {
+					return `C:\bin\` + name + ".exe", nil
+				}
+			}
+			return "", exec.ErrNotFound
+		}
+	}
+	env := func(vars map[string]string) func(string) string {
+		return func(key string) string { return vars[key] }
+	}
+
+	if got := detectShell("linux", env(nil), lookPath()); got != (Shell{Kind: ShellPOSIX, Path: "/bin/sh"}) {
+		t.Errorf("linux default = %+v", got)
+	}
+	if got := detectShell("darwin", env(map[string]string{"SHELL": "/bin/zsh"}), lookPath()); got.Path != "/bin/zsh" {
+		t.Errorf("expected $SHELL on macOS, got %+v", got)
+	}
+	if got := detectShell("windows", env(map[string]string{"SHELL": "/usr/bin/bash"}), lookPath("bash", "pwsh")); got.Kind != ShellPOSIX || got.Name() != "bash" {
+		t.Errorf("expected Git Bash's bash, got %+v", got)
+	}
+	if got := detectShell("windows", env(nil), lookPath("powershell", "pwsh")); got.Kind != ShellPowerShell || got.Name() != "pwsh" {
+		t.Errorf("expected pwsh first, got %+v", got)
+	}
+	if got := detectShell("windows", env(map[string]string{"ComSpec": `C:\Windows\system32\cmd.exe`}), lookPath()); got.Kind != ShellCmd || got.Name() != "cmd" {
+		t.Errorf("expected cmd.exe, got %+v", got)
+	}
+}
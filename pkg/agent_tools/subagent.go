@@ -33,6 +33,10 @@ const DefaultSubagentTimeout = 0 // 0 means no timeout
 // Default max tokens for subagent execution
 const DefaultSubagentMaxTokens = 2_000_000 // 2M tokens default budget
 
+// DefaultSubagentMaxDepth is how deep subagents may nest by default: only
+// the primary agent may spawn subagents.
+const DefaultSubagentMaxDepth = 1
+
 // GetSubagentTimeout returns the configured timeout for subagent execution.
 // It reads from LEDIT_SUBAGENT_TIMEOUT environment variable if set.
 // A value of "0" or unset means NO timeout (runs indefinitely).
@@ -92,6 +96,40 @@ func GetSubagentMaxTokens() int {
 	return DefaultSubagentMaxTokens
 }
 
+// SubagentDepth returns how deeply the current process is nested below the
+// primary agent: 0 for the primary agent, 1 for its subagents and so on.
+// It reads LEDIT_SUBAGENT_DEPTH, which is set for every spawned subagent.
+func SubagentDepth() int {
+	if depth, err := strconv.Atoi(os.Getenv("LEDIT_SUBAGENT_DEPTH")); err == nil && depth >= 0 {
+		return depth
+	}
+	if os.Getenv("LEDIT_SUBAGENT") == "1" {
+		return 1
+	}
+	return 0
+}
+
+// GetSubagentMaxDepth returns how deeply subagents may nest. The
+// LEDIT_SUBAGENT_MAX_DEPTH environment variable overrides configured, and a
+// configured value of 0 means DefaultSubagentMaxDepth.
+func GetSubagentMaxDepth(configured int) int {
+	if envMaxDepth := os.Getenv("LEDIT_SUBAGENT_MAX_DEPTH"); envMaxDepth != "" {
+		if maxDepth, err := strconv.Atoi(envMaxDepth); err == nil && maxDepth >= 0 {
+			return maxDepth
+		}
+		log.Printf("[WARNING] Invalid LEDIT_SUBAGENT_MAX_DEPTH value '%s', ignoring it\n", envMaxDepth)
+	}
+	if configured > 0 {
+		return configured
+	}
+	return DefaultSubagentMaxDepth
+}
+
+// subagentEnv returns the environment of a subagent spawned by this process.
+func subagentEnv() []string {
+	return append(os.Environ(), "LEDIT_FROM_AGENT=1", "LEDIT_SUBAGENT=1", "LEDIT_SUBAGENT_DEPTH="+strconv.Itoa(SubagentDepth()+1))
+}
+
 // RunSubagent spawns an agent subprocess, waits for completion, and returns all output.
 // This enables the planner agent to delegate work to execution sub-agents, wait for them
 // to complete, and immediately retrieve their output for evaluation.
@@ -114,6 +152,7 @@ func GetSubagentMaxTokens() int {
 //   - model: Optional model override (e.g., "qwen/qwen-coder-32b")
 //   - provider: Optional provider override (e.g., "openrouter")
 //   - systemPrompt: Optional path to system prompt file for specialized personas
+//   - maxTokens: Token budget; the subagent is cancelled once it uses this many (0 disables the budget)
 //
 // Returns map containing:
 //   - stdout: Combined stdout output
@@ -121,7 +160,7 @@ func GetSubagentMaxTokens() int {
 //   - exit_code: Process exit code (0 for success)
 //   - completed: true if process ran to completion (always true for blocking mode)
 //   - timed_out: true if the subprocess was terminated due to timeout (always false with no timeout)
-func RunSubagent(workspaceRoot string, prompt, model, provider string, streamCallback StreamCallback, systemPromptPath, systemPromptText, persona string) (map[string]string, error) {
+func RunSubagent(workspaceRoot string, prompt, model, provider string, streamCallback StreamCallback, systemPromptPath, systemPromptText, persona string, maxTokens int) (map[string]string, error) {
 	// Build command: ledit agent with the given prompt
 	args := []string{"agent"}
 
@@ -153,7 +192,6 @@ func RunSubagent(workspaceRoot string, prompt, model, provider string, streamCal
 
 	// Create context (with optional timeout)
 	timeout := GetSubagentTimeout()
-	maxTokens := GetSubagentMaxTokens()
 	var ctx context.Context
 	var cancel context.CancelFunc
 
@@ -213,7 +251,7 @@ func RunSubagent(workspaceRoot string, prompt, model, provider string, streamCal
 	} else if wd, err := os.Getwd(); err == nil {
 		cmd.Dir = wd
 	}
-	cmd.Env = append(os.Environ(), "LEDIT_FROM_AGENT=1", "LEDIT_SUBAGENT=1")
+	cmd.Env = subagentEnv()
 	if persona != "" {
 		cmd.Env = append(cmd.Env, "LEDIT_PERSONA="+persona)
 	}
@@ -560,7 +598,7 @@ func spawnSubagent(workspaceRoot string, task ParallelSubagentTask, noTimeout bo
 	}
 
 	// Propagate important environment variables to subagent processes
-	cmd.Env = append(os.Environ(), "LEDIT_FROM_AGENT=1", "LEDIT_SUBAGENT=1")
+	cmd.Env = subagentEnv()
 	if debug := os.Getenv("LEDIT_DEBUG"); debug != "" {
 		cmd.Env = append(cmd.Env, "LEDIT_DEBUG="+debug)
 	}
@@ -102,3 +102,34 @@ func TestDefaultSubagentTimeout(t *testing.T) {
 		t.Errorf("DefaultSubagentTimeout = %v, want %v", DefaultSubagentTimeout, want)
 	}
 }
+
+func TestSubagentDepthAndMaxDepth(t *testing.T) {
+	t.Setenv("LEDIT_SUBAGENT", "")
+	t.Setenv("LEDIT_SUBAGENT_DEPTH", "")
+	t.Setenv("LEDIT_SUBAGENT_MAX_DEPTH", "")
+	if got := SubagentDepth(); got != 0 {
+		t.Errorf("primary agent depth = %d, want 0", got)
+	}
+	t.Setenv("LEDIT_SUBAGENT", "1")
+	if got := SubagentDepth(); got != 1 {
+		t.Errorf("subagent depth without LEDIT_SUBAGENT_DEPTH = %d, want 1", got)
+	}
+	t.Setenv("LEDIT_SUBAGENT_DEPTH", "2")
+	if got := SubagentDepth(); got != 2 {
+		t.Errorf("depth = %d, want 2", got)
+	}
+	if env := subagentEnv(); env[len(env)-1] != "LEDIT_SUBAGENT_DEPTH=3" {
+		t.Errorf("expected children to run one level deeper, got %q", env[len(env)-1])
+	}
+
+	if got := GetSubagentMaxDepth(0); got != DefaultSubagentMaxDepth {
+		t.Errorf("GetSubagentMaxDepth(0) = %d, want the default", got)
+	}
+	if got := GetSubagentMaxDepth(3); got != 3 {
+		t.Errorf("GetSubagentMaxDepth(3) = %d, want 3", got)
+	}
+	t.Setenv("LEDIT_SUBAGENT_MAX_DEPTH", "0")
+	if got := GetSubagentMaxDepth(3); got != 0 {
+		t.Errorf("expected the environment to override the config, got %d", got)
+	}
+}
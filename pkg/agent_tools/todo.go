@@ -1,40 +1,195 @@
 package tools
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"sync"
+	"time"
 )
 
+// TodoFileName is the todo list's path relative to the workspace root.
+var TodoFileName = filepath.Join(".ledit", "todos.json")
+
 // TodoItem represents a single todo item matching Claude Code's TodoWrite/TodoRead schema
 type TodoItem struct {
-	ID       string `json:"id"`
-	Content  string `json:"content"`
-	Status   string `json:"status"`   // pending, in_progress, completed
-	Priority string `json:"priority"` // high, medium, low
+	ID        string    `json:"id"`
+	Content   string    `json:"content"`
+	Status    string    `json:"status"`   // pending, in_progress, completed
+	Priority  string    `json:"priority"` // high, medium, low
+	Source    string    `json:"source,omitempty"`
+	CreatedAt time.Time `json:"created_at,omitzero"`
+	UpdatedAt time.Time `json:"updated_at,omitzero"`
 }
 
 // TodoManager manages the todo list for the current session
 type TodoManager struct {
 	items []TodoItem
+	path  string // persisted todo file; "" keeps the list in memory only
 	mutex sync.RWMutex
 }
 
+// todoFile is the on-disk format of TodoFileName.
+type todoFile struct {
+	UpdatedAt time.Time  `json:"updated_at"`
+	Todos     []TodoItem `json:"todos"`
+}
+
 var globalTodoManager = &TodoManager{
 	items: make([]TodoItem, 0),
 }
 
+// LoadTodos makes workspaceRoot's .ledit/todos.json the backing store for
+// the todo list and loads the todos saved by earlier sessions. A missing
+// file starts an empty list.
+func LoadTodos(workspaceRoot string) error {
+	path := filepath.Join(workspaceRoot, TodoFileName)
+	items, err := readTodoFile(path)
+
+	globalTodoManager.mutex.Lock()
+	defer globalTodoManager.mutex.Unlock()
+
+	globalTodoManager.items = items
+	globalTodoManager.path = path
+	if err != nil {
+		// Keep an unreadable file intact rather than overwrite it.
+		globalTodoManager.path = ""
+	}
+	return err
+}
+
+func readTodoFile(path string) ([]TodoItem, error) {
+	items := make([]TodoItem, 0)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return items, nil
+	}
+	if err != nil {
+		return items, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var file todoFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return items, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if file.Todos != nil {
+		items = file.Todos
+	}
+	return items, nil
+}
+
+// TodoFilePath returns the file the todo list is saved to, or "" when it is
+// kept in memory only.
+func TodoFilePath() string {
+	globalTodoManager.mutex.RLock()
+	defer globalTodoManager.mutex.RUnlock()
+	return globalTodoManager.path
+}
+
 // TodoWrite creates and manages a structured task list for the current session
 func TodoWrite(todos []TodoItem) string {
+	err := UpdateTodos(todos)
+
+	result := fmt.Sprintf("Todo list updated with %d items", len(todos))
+	if len(todos) == 0 {
+		result = "Todo list cleared"
+	}
+	if err != nil {
+		result += fmt.Sprintf(" (not saved: %v)", err)
+	}
+	return result
+}
+
+// UpdateTodos replaces the todo list and saves it to the workspace todo
+// file, keeping the creation time and provenance of todos that were
+// already on the list. The in-memory list is updated even if saving fails.
+func UpdateTodos(todos []TodoItem) error {
 	globalTodoManager.mutex.Lock()
 	defer globalTodoManager.mutex.Unlock()
 
-	// Replace all items with the new list
-	globalTodoManager.items = todos
+	globalTodoManager.items = stampTodos(globalTodoManager.items, todos, time.Now())
+	return globalTodoManager.saveLocked()
+}
 
-	if len(todos) == 0 {
-		return "Todo list cleared"
+// stampTodos returns next with timestamps filled in. Todos are matched to
+// previous ones by ID, or by content when they have no ID.
+func stampTodos(previous, next []TodoItem, now time.Time) []TodoItem {
+	byKey := make(map[string]TodoItem, len(previous))
+	for _, item := range previous {
+		byKey[todoKey(item)] = item
+	}
+
+	items := make([]TodoItem, len(next))
+	for i, item := range next {
+		old, existed := byKey[todoKey(item)]
+		if existed {
+			if item.CreatedAt.IsZero() {
+				item.CreatedAt = old.CreatedAt
+			}
+			if old.Source != "" {
+				item.Source = old.Source
+			}
+			if item.UpdatedAt.IsZero() && item.Status == old.Status && item.Content == old.Content && item.Priority == old.Priority {
+				item.UpdatedAt = old.UpdatedAt
+			}
+		}
+		if item.CreatedAt.IsZero() {
+			item.CreatedAt = now
+		}
+		if item.UpdatedAt.IsZero() {
+			item.UpdatedAt = now
+		}
+		items[i] = item
+	}
+	return items
+}
+
+func todoKey(item TodoItem) string {
+	if item.ID != "" {
+		return "id:" + item.ID
+	}
+	return "content:" + item.Content
+}
+
+// saveLocked writes the todo list to its file. An empty list only replaces
+// an existing file so workspaces that never used todos stay untouched.
+func (m *TodoManager) saveLocked() error {
+	if m.path == "" {
+		return nil
+	}
+	if len(m.items) == 0 {
+		if _, err := os.Stat(m.path); errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+	}
+
+	data, err := json.MarshalIndent(todoFile{UpdatedAt: time.Now(), Todos: m.items}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(m.path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(m.path), ".todos-*.json")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, m.path); err != nil {
+		os.Remove(tmpPath)
+		return err
 	}
-	return fmt.Sprintf("Todo list updated with %d items", len(todos))
+	return nil
 }
 
 // TodoRead returns the current todo list
@@ -1,6 +1,8 @@
 package tools
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -126,3 +128,80 @@ func TestGetTodoListCompact(t *testing.T) {
 		t.Fatalf("expected 2 todos, got: %d", len(result))
 	}
 }
+
+// useTodoWorkspace points the global todo list at a temporary workspace.
+func useTodoWorkspace(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	t.Cleanup(func() {
+		globalTodoManager.mutex.Lock()
+		globalTodoManager.path = ""
+		globalTodoManager.items = make([]TodoItem, 0)
+		globalTodoManager.mutex.Unlock()
+	})
+	if err := LoadTodos(root); err != nil {
+		t.Fatalf("LoadTodos on empty workspace: %v", err)
+	}
+	return root
+}
+
+func TestLoadTodos_PersistsAcrossSessions(t *testing.T) {
+	root := useTodoWorkspace(t)
+
+	TodoWrite([]TodoItem{})
+	if _, err := os.Stat(filepath.Join(root, TodoFileName)); !os.IsNotExist(err) {
+		t.Fatalf("clearing an unused list should not create %s", TodoFileName)
+	}
+
+	TodoWrite([]TodoItem{{ID: "1", Content: "Add parser", Status: "pending", Source: "implement the parser"}})
+	first := TodoRead()[0]
+	if first.CreatedAt.IsZero() || first.UpdatedAt.IsZero() {
+		t.Fatalf("expected timestamps to be set, got %+v", first)
+	}
+
+	// A later task updating the same todo keeps its creation time and source.
+	if err := UpdateTodos([]TodoItem{{ID: "1", Content: "Add parser", Status: "completed", Source: "fix the tests"}}); err != nil {
+		t.Fatalf("UpdateTodos: %v", err)
+	}
+
+	// A new session reloads the saved list.
+	globalTodoManager.mutex.Lock()
+	globalTodoManager.items = nil
+	globalTodoManager.mutex.Unlock()
+	if err := LoadTodos(root); err != nil {
+		t.Fatalf("LoadTodos: %v", err)
+	}
+
+	todos := TodoRead()
+	if len(todos) != 1 {
+		t.Fatalf("expected 1 reloaded todo, got %d", len(todos))
+	}
+	got := todos[0]
+	if got.Status != "completed" || got.Source != "implement the parser" {
+		t.Errorf("unexpected reloaded todo: %+v", got)
+	}
+	if !got.CreatedAt.Equal(first.CreatedAt) || got.UpdatedAt.Before(first.UpdatedAt) {
+		t.Errorf("unexpected timestamps: created %v updated %v, first %+v", got.CreatedAt, got.UpdatedAt, first)
+	}
+}
+
+func TestLoadTodos_KeepsUnreadableFile(t *testing.T) {
+	root := useTodoWorkspace(t)
+	path := filepath.Join(root, TodoFileName)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := LoadTodos(root); err == nil {
+		t.Fatal("expected a parse error")
+	}
+	TodoWrite([]TodoItem{{Content: "Task", Status: "pending"}})
+
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "{not json" {
+		t.Errorf("unreadable todo file was overwritten: %q %v", data, err)
+	}
+}
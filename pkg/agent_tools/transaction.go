@@ -0,0 +1,228 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/alantheprice/ledit/pkg/filesystem"
+	"github.com/alantheprice/ledit/pkg/utils"
+)
+
+// TransactionEdit is one change in an edit transaction: OldStr replaced by
+// NewStr as edit_file does, or, when Content is set, the file's whole new
+// content as write_file does.
+type TransactionEdit struct {
+	Path    string
+	OldStr  string
+	NewStr  string
+	Content *string
+}
+
+// StagedFile is one file of a transaction with its content before and after
+// all of the transaction's edits to it.
+type StagedFile struct {
+	Path    string // resolved absolute path
+	Existed bool
+	Mode    os.FileMode
+	Before  string
+	After   string
+	Edits   int
+}
+
+// Transaction is a set of edits staged in memory and written together.
+type Transaction struct {
+	files  []*StagedFile
+	byPath map[string]*StagedFile
+	// written are the files Commit changed on disk, for Rollback.
+	written []*StagedFile
+}
+
+// StageTransaction applies edits in order to in-memory copies of their files,
+// so later edits see earlier ones. Nothing is written; a failing edit
+// returns an error naming it and leaves every file untouched.
+func StageTransaction(ctx context.Context, edits []TransactionEdit) (*Transaction, error) {
+	if len(edits) == 0 {
+		return nil, errors.New("no edits given")
+	}
+	tx := &Transaction{byPath: make(map[string]*StagedFile)}
+	for i, edit := range edits {
+		if err := tx.stage(ctx, edit); err != nil {
+			return nil, fmt.Errorf("edit %d (%s): %w", i+1, edit.Path, err)
+		}
+	}
+	return tx, nil
+}
+
+func (tx *Transaction) stage(ctx context.Context, edit TransactionEdit) error {
+	if edit.Path == "" {
+		return errors.New("empty file path provided")
+	}
+	cleanPath, err := filesystem.SafeResolvePathForWriteWithBypass(ctx, edit.Path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve file path: %w", err)
+	}
+	file, ok := tx.byPath[cleanPath]
+	if !ok {
+		file = &StagedFile{Path: cleanPath, Mode: 0o644}
+		info, err := os.Stat(cleanPath)
+		switch {
+		case err == nil && info.IsDir():
+			return fmt.Errorf("%s is a directory", cleanPath)
+		case err == nil:
+			content, err := readFileContent(cleanPath)
+			if err != nil {
+				return err
+			}
+			file.Existed, file.Mode, file.Before = true, info.Mode().Perm(), content
+		case !os.IsNotExist(err):
+			return fmt.Errorf("failed to stat file %s: %w", cleanPath, err)
+		}
+		file.After = file.Before
+		tx.byPath[cleanPath] = file
+		tx.files = append(tx.files, file)
+	}
+
+	if edit.Content != nil {
+		file.After = *edit.Content
+		if file.Existed && utils.LineEnding(file.Before) == "\r\n" {
+			file.After = utils.WithLineEnding(file.After, "\r\n")
+		}
+	} else {
+		if !file.Existed && file.Edits == 0 {
+			return fmt.Errorf("file does not exist: %s", cleanPath)
+		}
+		if err := validateEditInputs(edit.Path, edit.OldStr, edit.NewStr); err != nil {
+			return err
+		}
+		after, err := determineAndPerformReplacement(file.After, edit.OldStr, edit.NewStr, cleanPath)
+		if err != nil {
+			return err
+		}
+		file.After = after
+	}
+	file.Edits++
+	return nil
+}
+
+// Files returns the staged files in the order they were first edited.
+func (tx *Transaction) Files() []*StagedFile {
+	return tx.files
+}
+
+// Changed returns the staged files whose content the transaction changes.
+func (tx *Transaction) Changed() []*StagedFile {
+	var changed []*StagedFile
+	for _, f := range tx.files {
+		if !f.Existed || f.After != f.Before {
+			changed = append(changed, f)
+		}
+	}
+	return changed
+}
+
+// Commit writes every changed file. It holds all of their locks while
+// writing, refuses to write when another agent or process changed one of
+// them since it was staged, and writes each file through a temporary file
+// and a rename. If any write fails, the files already written are restored,
+// so the workspace ends up with all of the edits or none of them.
+func (tx *Transaction) Commit() error {
+	changed := tx.Changed()
+	// Lock in path order so two transactions cannot deadlock.
+	ordered := append([]*StagedFile(nil), changed...)
+	sort.Slice(ordered, func(i, j int) bool { return ordered[i].Path < ordered[j].Path })
+	locks := filesystem.Locks()
+	for _, f := range ordered {
+		unlock, err := locks.Lock(f.Path)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+	}
+
+	for _, f := range changed {
+		if err := locks.CheckConflict(f.Path); err != nil {
+			return err
+		}
+		current, err := os.ReadFile(f.Path)
+		switch {
+		case err == nil && (!f.Existed || string(current) != f.Before):
+			return fmt.Errorf("%s changed after the transaction was staged; nothing was written", f.Path)
+		case err != nil && f.Existed:
+			return fmt.Errorf("failed to read %s: %w", f.Path, err)
+		}
+	}
+
+	tx.written = nil
+	for _, f := range changed {
+		if err := writeFileAtomic(f.Path, []byte(f.After), f.Mode); err != nil {
+			return errors.Join(err, tx.restore())
+		}
+		tx.written = append(tx.written, f)
+		locks.RecordWrite(f.Path, []byte(f.After))
+	}
+	return nil
+}
+
+// Rollback restores the files Commit wrote to their content before the
+// transaction, removing the ones it created.
+func (tx *Transaction) Rollback() error {
+	locks := filesystem.Locks()
+	for _, f := range tx.written {
+		unlock, err := locks.Lock(f.Path)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+	}
+	return tx.restore()
+}
+
+func (tx *Transaction) restore() error {
+	var errs []error
+	for i := len(tx.written) - 1; i >= 0; i-- {
+		f := tx.written[i]
+		if !f.Existed {
+			if err := os.Remove(f.Path); err != nil && !os.IsNotExist(err) {
+				errs = append(errs, fmt.Errorf("failed to remove %s: %w", f.Path, err))
+			}
+			filesystem.Locks().Forget(f.Path)
+			continue
+		}
+		if err := writeFileAtomic(f.Path, []byte(f.Before), f.Mode); err != nil {
+			errs = append(errs, fmt.Errorf("failed to restore %s: %w", f.Path, err))
+			continue
+		}
+		filesystem.Locks().RecordWrite(f.Path, []byte(f.Before))
+	}
+	tx.written = nil
+	return errors.Join(errs...)
+}
+
+// writeFileAtomic replaces path's content through a temporary file in the
+// same directory, so readers never see a partly written file.
+func writeFileAtomic(path string, content []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".ledit-*")
+	if err != nil {
+		return fmt.Errorf("failed to write file %s: %w", path, err)
+	}
+	tmpPath := tmp.Name()
+	_, writeErr := tmp.Write(content)
+	closeErr := tmp.Close()
+	if err := errors.Join(writeErr, closeErr, os.Chmod(tmpPath, perm)); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write file %s: %w", path, err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write file %s: %w", path, err)
+	}
+	return nil
+}
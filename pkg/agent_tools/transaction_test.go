@@ -0,0 +1,112 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alantheprice/ledit/pkg/filesystem"
+)
+
+func transactionWorkspace(t *testing.T, files map[string]string) (context.Context, string) {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return filesystem.WithWorkspaceRoot(context.Background(), root), root
+}
+
+func readTransactionFile(t *testing.T, path string) string {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+func TestTransactionCommitAndRollback(t *testing.T) {
+	ctx, root := transactionWorkspace(t, map[string]string{
+		"a.go": "package a\n\nfunc Old() {}\n\nvar x = 1\n",
+		"b.go": "package a\n\nfunc use() { Old() }\n",
+	})
+	created := "package a\n\nconst c = 3\n"
+	tx, err := StageTransaction(ctx, []TransactionEdit{
+		{Path: "a.go", OldStr: "func Old()", NewStr: "func New()"},
+		{Path: "a.go", OldStr: "var x = 1", NewStr: "var x = 2"},
+		{Path: "b.go", OldStr: "Old()", NewStr: "New()"},
+		{Path: "c.go", Content: &created},
+	})
+	if err != nil {
+		t.Fatalf("StageTransaction: %v", err)
+	}
+	if len(tx.Files()) != 3 || tx.Files()[0].Edits != 2 {
+		t.Fatalf("expected 3 staged files with 2 edits to a.go, got %+v", tx.Files())
+	}
+	if got := readTransactionFile(t, filepath.Join(root, "a.go")); strings.Contains(got, "New") {
+		t.Fatalf("staging wrote a.go:\n%s", got)
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	if got := readTransactionFile(t, filepath.Join(root, "a.go")); got != "package a\n\nfunc New() {}\n\nvar x = 2\n" {
+		t.Fatalf("unexpected a.go:\n%s", got)
+	}
+	if got := readTransactionFile(t, filepath.Join(root, "c.go")); got != created {
+		t.Fatalf("unexpected c.go:\n%s", got)
+	}
+
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+	if got := readTransactionFile(t, filepath.Join(root, "b.go")); got != "package a\n\nfunc use() { Old() }\n" {
+		t.Fatalf("b.go not restored:\n%s", got)
+	}
+	if _, err := os.Stat(filepath.Join(root, "c.go")); !os.IsNotExist(err) {
+		t.Fatalf("expected the created c.go to be removed, got %v", err)
+	}
+}
+
+func TestStageTransactionFailingEditNamesIt(t *testing.T) {
+	ctx, root := transactionWorkspace(t, map[string]string{"a.txt": "alpha\n", "b.txt": "beta\n"})
+	_, err := StageTransaction(ctx, []TransactionEdit{
+		{Path: "a.txt", OldStr: "alpha", NewStr: "ALPHA"},
+		{Path: "b.txt", OldStr: "gamma", NewStr: "GAMMA"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "edit 2 (b.txt)") {
+		t.Fatalf("expected edit 2 to fail, got %v", err)
+	}
+	if got := readTransactionFile(t, filepath.Join(root, "a.txt")); got != "alpha\n" {
+		t.Fatalf("a failed transaction changed a.txt:\n%s", got)
+	}
+
+	if _, err := StageTransaction(ctx, []TransactionEdit{{Path: "missing.txt", OldStr: "x", NewStr: "y"}}); err == nil {
+		t.Fatal("expected replacing text in a missing file to fail")
+	}
+}
+
+func TestTransactionCommitRefusesChangedFiles(t *testing.T) {
+	ctx, root := transactionWorkspace(t, map[string]string{"a.txt": "alpha\n", "b.txt": "beta\n"})
+	tx, err := StageTransaction(ctx, []TransactionEdit{
+		{Path: "a.txt", OldStr: "alpha", NewStr: "ALPHA"},
+		{Path: "b.txt", OldStr: "beta", NewStr: "BETA"},
+	})
+	if err != nil {
+		t.Fatalf("StageTransaction: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "b.txt"), []byte("beta changed elsewhere\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err == nil || !strings.Contains(err.Error(), "changed after the transaction was staged") {
+		t.Fatalf("expected a conflict error, got %v", err)
+	}
+	if got := readTransactionFile(t, filepath.Join(root, "a.txt")); got != "alpha\n" {
+		t.Fatalf("a refused commit changed a.txt:\n%s", got)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 
 	"github.com/alantheprice/ledit/pkg/filesystem"
+	"github.com/alantheprice/ledit/pkg/utils"
 )
 
 func WriteFile(ctx context.Context, filePath, content string) (string, error) {
@@ -22,11 +23,31 @@ func WriteFile(ctx context.Context, filePath, content string) (string, error) {
 		return "", fmt.Errorf("failed to create directory %s: %w", dir, err)
 	}
 
+	// Serialize with other agents writing the same file, and refuse to
+	// overwrite changes another agent made since this one read the file
+	locks := filesystem.Locks()
+	unlock, err := locks.Lock(cleanPath)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+	if err := locks.CheckConflict(cleanPath); err != nil {
+		return "", err
+	}
+
+	// Keep the line endings of a CRLF file being rewritten
+	if existing, err := os.ReadFile(cleanPath); err == nil {
+		if ending := utils.LineEnding(string(existing)); ending == "\r\n" {
+			content = utils.WithLineEnding(content, ending)
+		}
+	}
+
 	// Write the file
 	err = os.WriteFile(cleanPath, []byte(content), 0644)
 	if err != nil {
 		return "", fmt.Errorf("failed to write file %s: %w", cleanPath, err)
 	}
+	locks.RecordWrite(cleanPath, []byte(content))
 
 	// Read back the file to confirm successful write and return content
 	readContent, readErr := os.ReadFile(cleanPath)
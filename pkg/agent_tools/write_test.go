@@ -0,0 +1,72 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alantheprice/ledit/pkg/filesystem"
+)
+
+// TestWriteFileRefusesToOverwriteAnotherAgentsChanges simulates a parallel
+// subagent writing a file after this process read it.
+func TestWriteFileRefusesToOverwriteAnotherAgentsChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(path, []byte("one\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	filesystem.Locks().ObserveFile(path)
+
+	other := filesystem.NewFileLocks(filepath.Join(os.TempDir(), "ledit-locks"), "a subagent (pid 0)")
+	if err := os.WriteFile(path, []byte("one\ntwo\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	other.RecordWrite(path, []byte("one\ntwo\n"))
+
+	_, err := WriteFile(context.Background(), path, "one\nthree\n")
+	var conflict *filesystem.WriteConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("expected a write conflict, got %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "one\ntwo\n" {
+		t.Errorf("file was overwritten: %q", data)
+	}
+
+	// Edits were written against the stale read too, so they are refused
+	// until the file is re-read; both succeed after re-reading.
+	if _, err := EditFile(context.Background(), path, "one\n", "zero\none\n"); !errors.As(err, &conflict) {
+		t.Fatalf("expected EditFile to report the conflict, got %v", err)
+	}
+	filesystem.Locks().ObserveFile(path)
+	if _, err := EditFile(context.Background(), path, "two\n", "two\nthree\n"); err != nil {
+		t.Fatalf("EditFile after re-read: %v", err)
+	}
+	if _, err := WriteFile(context.Background(), path, "one\ntwo\nthree\nfour\n"); err != nil {
+		t.Fatalf("WriteFile after edit: %v", err)
+	}
+}
+
+// TestEditFileRefusesChangesMadeInAnEditor simulates an IDE save after the
+// agent read the file.
+func TestEditFileRefusesChangesMadeInAnEditor(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "main.go")
+	if err := os.WriteFile(path, []byte("package main\n\nfunc a() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	filesystem.Locks().ObserveFile(path)
+	if err := os.WriteFile(path, []byte("package main\n\nfunc a() { b() }\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := EditFile(context.Background(), path, "package main", "package app")
+	var conflict *filesystem.WriteConflictError
+	if !errors.As(err, &conflict) || !strings.Contains(conflict.Diff, "+func a() { b() }") {
+		t.Fatalf("expected a conflict showing the editor's change, got %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "package main\n\nfunc a() { b() }\n" {
+		t.Errorf("file was edited: %q", data)
+	}
+}
@@ -0,0 +1,205 @@
+// Package capabilities describes what an installed ledit build supports, so
+// editor plugins and scripts can feature-detect instead of parsing help text.
+package capabilities
+
+import (
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/alantheprice/ledit/pkg/agent"
+	api "github.com/alantheprice/ledit/pkg/agent_api"
+	commands "github.com/alantheprice/ledit/pkg/agent_commands"
+	"github.com/alantheprice/ledit/pkg/configuration"
+	"github.com/alantheprice/ledit/pkg/editorbridge"
+	"github.com/alantheprice/ledit/pkg/mcp"
+)
+
+// SchemaVersion is the version of the Capabilities document itself. It is
+// bumped when fields are removed or change meaning; new fields may be added
+// without a bump.
+const SchemaVersion = 1
+
+// Version is the ledit build version reported in the handshake. The CLI sets
+// it at startup from its build-time version information.
+var Version = "dev"
+
+// Capabilities is the handshake document returned by `ledit capabilities
+// --json` and GET /api/capabilities.
+type Capabilities struct {
+	SchemaVersion int             `json:"schema_version"`
+	Version       string          `json:"version"`
+	Platform      string          `json:"platform"`
+	Tools         []Tool          `json:"tools"`
+	Providers     []string        `json:"providers"`
+	Commands      []Command       `json:"commands"`
+	ConfigSchema  []ConfigField   `json:"config_schema"`
+	Protocols     []Protocol      `json:"protocols"`
+	Features      map[string]bool `json:"features"`
+}
+
+// Tool is a built-in tool the agent can call.
+type Tool struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Parameters  interface{} `json:"parameters,omitempty"`
+}
+
+// Command is an interactive slash command.
+type Command struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// ConfigField is one key of the config file. Nested keys use dotted paths.
+type ConfigField struct {
+	Key  string `json:"key"`
+	Type string `json:"type"`
+}
+
+// Protocol is a machine-readable interface and the version this build speaks.
+type Protocol struct {
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	Description string `json:"description"`
+}
+
+// Collect builds the capabilities document for this build.
+func Collect() *Capabilities {
+	return &Capabilities{
+		SchemaVersion: SchemaVersion,
+		Version:       Version,
+		Platform:      runtime.GOOS + "/" + runtime.GOARCH,
+		Tools:         collectTools(),
+		Providers:     collectProviders(),
+		Commands:      collectCommands(),
+		ConfigSchema:  ConfigSchema(),
+		Protocols:     collectProtocols(),
+		Features: map[string]bool{
+			"headless_json_output": true,
+			"run_recording":        true,
+			"run_replay":           true,
+			"transcript_export":    true,
+			"secret_redaction":     true,
+			"mcp":                  true,
+			"subagents":            true,
+		},
+	}
+}
+
+func collectTools() []Tool {
+	defs := api.GetToolDefinitions()
+	tools := make([]Tool, 0, len(defs))
+	for _, def := range defs {
+		tools = append(tools, Tool{
+			Name:        def.Function.Name,
+			Description: def.Function.Description,
+			Parameters:  def.Function.Parameters,
+		})
+	}
+	sort.Slice(tools, func(i, j int) bool { return tools[i].Name < tools[j].Name })
+	return tools
+}
+
+func collectProviders() []string {
+	providers := append([]string(nil), configuration.GetAvailableProviders()...)
+	sort.Strings(providers)
+	return providers
+}
+
+func collectCommands() []Command {
+	registered := commands.NewCommandRegistry().ListCommands()
+	cmds := make([]Command, 0, len(registered))
+	for _, cmd := range registered {
+		cmds = append(cmds, Command{Name: cmd.Name(), Description: cmd.Description()})
+	}
+	sort.Slice(cmds, func(i, j int) bool { return cmds[i].Name < cmds[j].Name })
+	return cmds
+}
+
+func collectProtocols() []Protocol {
+	return []Protocol{
+		{Name: "capabilities", Version: "1", Description: "This handshake document"},
+		{Name: "agent-json-stream", Version: "1", Description: "Newline-delimited JSON events from `ledit agent --output json`"},
+		{Name: "editor-bridge", Version: editorbridge.ProtocolVersion, Description: "JSON-RPC for editor plugins from `ledit serve --editor`"},
+		{Name: "run-archive", Version: strconv.Itoa(agent.RunArchiveVersion), Description: "Run recordings read by `ledit replay`"},
+		{Name: "mcp-stdio", Version: mcp.StdioProtocolVersion, Description: "Model Context Protocol over stdio"},
+		{Name: "mcp-http", Version: mcp.HTTPProtocolVersion, Description: "Model Context Protocol over streamable HTTP"},
+		{Name: "webui-http", Version: "1", Description: "Web UI REST API under /api"},
+		{Name: "webui-websocket", Version: "1", Description: "Web UI event stream on /ws"},
+	}
+}
+
+// ConfigSchema lists the keys of the config file with their JSON types,
+// derived from the json tags of configuration.Config.
+func ConfigSchema() []ConfigField {
+	var fields []ConfigField
+	walkConfigType(reflect.TypeOf(configuration.Config{}), "", 0, &fields)
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Key < fields[j].Key })
+	return fields
+}
+
+// maxConfigDepth bounds recursion into nested config structs.
+const maxConfigDepth = 4
+
+func walkConfigType(t reflect.Type, prefix string, depth int, fields *[]ConfigField) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		// Untagged embedded structs are flattened by encoding/json.
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			walkConfigType(f.Type, prefix, depth, fields)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+
+		ft := f.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		*fields = append(*fields, ConfigField{Key: key, Type: jsonTypeName(ft)})
+		if ft.Kind() == reflect.Struct && depth < maxConfigDepth && ft.PkgPath() != "time" {
+			walkConfigType(ft, key, depth+1, fields)
+		}
+	}
+}
+
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.String:
+		return "string"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if t.PkgPath() == "time" && t.Name() == "Duration" {
+			return "duration"
+		}
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct, reflect.Interface:
+		if t.PkgPath() == "time" && t.Name() == "Time" {
+			return "string"
+		}
+		return "object"
+	default:
+		return t.Kind().String()
+	}
+}
@@ -0,0 +1,98 @@
+package capabilities
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollect(t *testing.T) {
+	caps := Collect()
+
+	assert.Equal(t, SchemaVersion, caps.SchemaVersion)
+	assert.NotEmpty(t, caps.Platform)
+	assert.NotEmpty(t, caps.Providers)
+
+	toolNames := make(map[string]bool)
+	for _, tool := range caps.Tools {
+		toolNames[tool.Name] = true
+	}
+	assert.True(t, toolNames["read_file"])
+	assert.True(t, toolNames["shell_command"])
+
+	commandNames := make(map[string]bool)
+	for _, cmd := range caps.Commands {
+		commandNames[cmd.Name] = true
+	}
+	assert.True(t, commandNames["help"])
+	assert.True(t, commandNames["fix-tests"])
+
+	protocols := make(map[string]string)
+	for _, p := range caps.Protocols {
+		protocols[p.Name] = p.Version
+	}
+	assert.Equal(t, "1", protocols["capabilities"])
+	assert.NotEmpty(t, protocols["mcp-stdio"])
+	assert.NotEmpty(t, protocols["editor-bridge"])
+}
+
+func TestCollect_JSONRoundTrip(t *testing.T) {
+	data, err := json.Marshal(Collect())
+	require.NoError(t, err)
+
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	for _, key := range []string{"schema_version", "version", "tools", "providers", "commands", "config_schema", "protocols"} {
+		assert.Contains(t, decoded, key)
+	}
+}
+
+func TestConfigSchema(t *testing.T) {
+	types := make(map[string]string)
+	for _, f := range ConfigSchema() {
+		types[f.Key] = f.Type
+	}
+
+	assert.Equal(t, "string", types["version"])
+	assert.Equal(t, "boolean", types["secret_redaction_enabled"])
+	assert.Equal(t, "object", types["provider_models"])
+	assert.Equal(t, "integer", types["api_timeouts.connection_timeout_sec"])
+}
+
+func TestWalkConfigType(t *testing.T) {
+	type nested struct {
+		Limit int `json:"limit"`
+	}
+	type embedded struct {
+		Flat string `json:"flat"`
+	}
+	type sample struct {
+		embedded
+		Name     string        `json:"name,omitempty"`
+		Enabled  *bool         `json:"enabled"`
+		Tags     []string      `json:"tags"`
+		Nested   *nested       `json:"nested"`
+		Timeout  time.Duration `json:"timeout"`
+		Skipped  string        `json:"-"`
+		Untagged float64
+		hidden   string
+	}
+
+	var fields []ConfigField
+	walkConfigType(reflect.TypeOf(sample{}), "", 0, &fields)
+
+	assert.Equal(t, []ConfigField{
+		{Key: "flat", Type: "string"},
+		{Key: "name", Type: "string"},
+		{Key: "enabled", Type: "boolean"},
+		{Key: "tags", Type: "array"},
+		{Key: "nested", Type: "object"},
+		{Key: "nested.limit", Type: "integer"},
+		{Key: "timeout", Type: "duration"},
+		{Key: "Untagged", Type: "number"},
+	}, fields)
+}
@@ -0,0 +1,145 @@
+// Package changelog keeps CHANGELOG.md in the Keep a Changelog format
+// (https://keepachangelog.com). It collects the commits since the last
+// release and the agent's uncommitted session changes, groups them into
+// Added, Changed, Fixed and the other sections, and merges them into the
+// Unreleased section or a new release.
+package changelog
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Keep a Changelog sections, in the order they are written.
+const (
+	SectionAdded      = "Added"
+	SectionChanged    = "Changed"
+	SectionDeprecated = "Deprecated"
+	SectionRemoved    = "Removed"
+	SectionFixed      = "Fixed"
+	SectionSecurity   = "Security"
+)
+
+// Sections lists the Keep a Changelog sections in order.
+var Sections = []string{SectionAdded, SectionChanged, SectionDeprecated, SectionRemoved, SectionFixed, SectionSecurity}
+
+// defaultHeader starts a new CHANGELOG.md.
+const defaultHeader = `# Changelog
+
+All notable changes to this project will be documented in this file.
+
+The format is based on [Keep a Changelog](https://keepachangelog.com/en/1.1.0/).`
+
+var (
+	releaseHeading    = regexp.MustCompile(`^## `)
+	unreleasedHeading = regexp.MustCompile(`(?i)^## \[?unreleased\]?\s*$`)
+	sectionHeading    = regexp.MustCompile(`^### (.+)$`)
+)
+
+// Entry is one line of the changelog.
+type Entry struct {
+	Section string   // one of Sections
+	Text    string   // what changed, for users of the project
+	Refs    []string // short hashes of the commits it comes from
+}
+
+// String renders the entry as a Markdown list item.
+func (e Entry) String() string {
+	line := "- " + strings.TrimSpace(e.Text)
+	if len(e.Refs) > 0 {
+		line += " (" + strings.Join(e.Refs, ", ") + ")"
+	}
+	return line
+}
+
+// NormalizeSection returns the Keep a Changelog section matching name, or
+// Changed when it matches none.
+func NormalizeSection(name string) string {
+	for _, section := range Sections {
+		if strings.EqualFold(strings.TrimSpace(name), section) {
+			return section
+		}
+	}
+	return SectionChanged
+}
+
+// Update merges entries into the changelog content and returns the new
+// content and the section that was written. Without a version the entries
+// go into the Unreleased section; with one, that section and the entries
+// become the release "## [version] - date". Entries already listed are not
+// repeated, and earlier releases are left as they are.
+func Update(content string, entries []Entry, version string, date time.Time) (string, string) {
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	if strings.TrimSpace(content) == "" {
+		lines = strings.Split(defaultHeader, "\n")
+	}
+
+	first := len(lines)
+	for i, line := range lines {
+		if releaseHeading.MatchString(line) {
+			first = i
+			break
+		}
+	}
+	preamble, rest := lines[:first], lines[first:]
+
+	// Fold an existing Unreleased section into the one being written
+	items := map[string][]string{}
+	if len(rest) > 0 && unreleasedHeading.MatchString(rest[0]) {
+		end := len(rest)
+		for i := 1; i < len(rest); i++ {
+			if releaseHeading.MatchString(rest[i]) {
+				end = i
+				break
+			}
+		}
+		section := SectionChanged
+		for _, line := range rest[1:end] {
+			if m := sectionHeading.FindStringSubmatch(line); m != nil {
+				section = NormalizeSection(m[1])
+			} else if strings.HasPrefix(strings.TrimSpace(line), "- ") {
+				items[section] = append(items[section], strings.TrimSpace(line))
+			}
+		}
+		rest = rest[end:]
+	}
+	for _, entry := range entries {
+		section := NormalizeSection(entry.Section)
+		line := entry.String()
+		if !containsLine(items[section], line) {
+			items[section] = append(items[section], line)
+		}
+	}
+
+	heading := "## [Unreleased]"
+	if version != "" {
+		heading = fmt.Sprintf("## [%s] - %s", version, date.Format("2006-01-02"))
+	}
+	var section strings.Builder
+	section.WriteString(heading + "\n")
+	for _, name := range Sections {
+		if len(items[name]) == 0 {
+			continue
+		}
+		fmt.Fprintf(&section, "\n### %s\n\n%s\n", name, strings.Join(items[name], "\n"))
+	}
+
+	var out strings.Builder
+	out.WriteString(strings.TrimRight(strings.Join(preamble, "\n"), "\n"))
+	out.WriteString("\n\n" + section.String())
+	if tail := strings.Trim(strings.Join(rest, "\n"), "\n"); tail != "" {
+		out.WriteString("\n" + tail + "\n")
+	}
+	return out.String(), section.String()
+}
+
+func containsLine(lines []string, line string) bool {
+	for _, l := range lines {
+		if l == line {
+			return true
+		}
+	}
+	return false
+}
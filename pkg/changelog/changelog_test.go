@@ -0,0 +1,173 @@
+package changelog
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUpdateMergesIntoUnreleased(t *testing.T) {
+	existing := `# Changelog
+
+All notable changes to ledit will be documented in this file.
+
+## [Unreleased]
+
+### Fixed
+
+- Fix crash on empty config (aaaaaaaa)
+
+## [v0.15.4] - 2026-04-18
+
+- Updates 3 files (acae80df)
+`
+	entries := []Entry{
+		{Section: "added", Text: "Add the changelog command", Refs: []string{"bbbbbbbb"}},
+		{Section: SectionFixed, Text: "Fix crash on empty config", Refs: []string{"aaaaaaaa"}},
+		{Section: "Improved", Text: "Speed up search"},
+	}
+	updated, section := Update(existing, entries, "", time.Time{})
+
+	want := `# Changelog
+
+All notable changes to ledit will be documented in this file.
+
+## [Unreleased]
+
+### Added
+
+- Add the changelog command (bbbbbbbb)
+
+### Changed
+
+- Speed up search
+
+### Fixed
+
+- Fix crash on empty config (aaaaaaaa)
+
+## [v0.15.4] - 2026-04-18
+
+- Updates 3 files (acae80df)
+`
+	if updated != want {
+		t.Fatalf("unexpected changelog:\n%s", updated)
+	}
+	if !strings.HasPrefix(section, "## [Unreleased]\n") || strings.Contains(section, "v0.15.4") {
+		t.Fatalf("unexpected section:\n%s", section)
+	}
+}
+
+func TestUpdateRelease(t *testing.T) {
+	date := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+	updated, _ := Update("", []Entry{{Section: SectionAdded, Text: "Add login"}}, "v1.0.0", date)
+	if !strings.HasPrefix(updated, defaultHeader+"\n\n## [v1.0.0] - 2026-10-16\n\n### Added\n\n- Add login\n") {
+		t.Fatalf("unexpected new changelog:\n%s", updated)
+	}
+
+	// Releasing moves the Unreleased entries into the release
+	updated, _ = Update("# Changelog\n\n## [Unreleased]\n\n### Added\n\n- Add login\n", nil, "v1.0.0", date)
+	if strings.Contains(updated, "Unreleased") || !strings.Contains(updated, "## [v1.0.0] - 2026-10-16\n\n### Added\n\n- Add login\n") {
+		t.Fatalf("unexpected release:\n%s", updated)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	changes := []Change{
+		{Kind: KindCommit, Ref: "11111111", Summary: "feat(cli): add changelog command"},
+		{Kind: KindCommit, Ref: "22222222", Summary: "Fix race in watcher"},
+		{Kind: KindCommit, Ref: "33333333", Summary: "test: cover parser"},
+		{Kind: KindCommit, Ref: "44444444", Summary: "Remove the legacy flag"},
+		{Kind: KindCommit, Ref: "55555555", Summary: "Merge branch 'main'"},
+		{Kind: KindSession, Ref: "rev", Summary: "Refine the status output"},
+	}
+	got := Classify(changes)
+	want := []Entry{
+		{Section: SectionAdded, Text: "Add changelog command", Refs: []string{"11111111"}},
+		{Section: SectionFixed, Text: "Fix race in watcher", Refs: []string{"22222222"}},
+		{Section: SectionRemoved, Text: "Remove the legacy flag", Refs: []string{"44444444"}},
+		{Section: SectionChanged, Text: "Refine the status output"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Classify = %+v", got)
+	}
+	for i := range want {
+		if got[i].String() != want[i].String() || got[i].Section != want[i].Section {
+			t.Errorf("entry %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestParseEntries(t *testing.T) {
+	changes := []Change{{Kind: KindCommit, Ref: "1a2b3c4d"}}
+	reply := "```json\n" + `{"entries": [
+		{"section": "fixed", "text": "- Fix login", "refs": ["1a2b3c4d5e6f", "deadbeef"]},
+		{"section": "Added", "text": " "}
+	]}` + "\n```"
+	entries, err := parseEntries(reply, changes)
+	if err != nil {
+		t.Fatalf("parseEntries: %v", err)
+	}
+	if len(entries) != 1 || entries[0].String() != "- Fix login (1a2b3c4d)" || entries[0].Section != SectionFixed {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+	if _, err := parseEntries("no JSON here", changes); err == nil {
+		t.Fatal("expected an error for a reply without JSON")
+	}
+}
+
+func TestCollectCommits(t *testing.T) {
+	dir := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=Ada", "GIT_AUTHOR_EMAIL=ada@example.com",
+			"GIT_COMMITTER_NAME=Ada", "GIT_COMMITTER_EMAIL=ada@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	run("init", "-q")
+	write("a.go", "package a\n")
+	run("add", ".")
+	run("commit", "-q", "-m", "Initial commit")
+	run("tag", "v0.1.0")
+	write("b.go", "package a\n")
+	run("add", ".")
+	run("commit", "-q", "-m", "[PROJ-7] Add b", "-m", "Adds the b file.")
+	write("a.go", "package a // changed\n")
+
+	r, err := ResolveRange(dir, "")
+	if err != nil {
+		t.Fatalf("ResolveRange: %v", err)
+	}
+	if r.From != "v0.1.0" || r.Since.IsZero() {
+		t.Fatalf("unexpected range %+v", r)
+	}
+	commits, err := CollectCommits(dir, r)
+	if err != nil {
+		t.Fatalf("CollectCommits: %v", err)
+	}
+	if len(commits) != 1 || commits[0].Summary != "Add b" || commits[0].Details != "Adds the b file." ||
+		len(commits[0].Files) != 1 || commits[0].Files[0] != "b.go" || len(commits[0].Ref) != shortHashLength {
+		t.Fatalf("unexpected commits %+v", commits)
+	}
+	if kept := Unrecorded(commits, "- Add b ("+commits[0].Ref+")"); len(kept) != 0 {
+		t.Fatalf("expected recorded commits to be dropped, got %+v", kept)
+	}
+
+	files, err := uncommittedFiles(dir)
+	if err != nil || !files["a.go"] || files["b.go"] {
+		t.Fatalf("uncommittedFiles = %v, %v", files, err)
+	}
+}
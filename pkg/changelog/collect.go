@@ -0,0 +1,219 @@
+package changelog
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/alantheprice/ledit/pkg/history"
+)
+
+// Change kinds.
+const (
+	KindCommit  = "commit"
+	KindSession = "session" // agent changes not committed yet
+)
+
+// maxCommits bounds the commits read when the repository has no tags.
+const maxCommits = 200
+
+// shortHashLength matches the hashes already in the changelog.
+const shortHashLength = 8
+
+// Change is a commit or an uncommitted agent session change to describe in
+// the changelog.
+type Change struct {
+	Kind    string
+	Ref     string // short commit hash, or the session revision ID
+	Summary string // commit subject, or the request the agent worked on
+	Details string // commit body, shortened
+	Files   []string
+}
+
+// Range is the part of the history the changelog covers.
+type Range struct {
+	From  string    // ref the range starts after, empty for the whole history
+	Since time.Time // time of From, zero for the whole history
+}
+
+// String describes the range, e.g. "since v1.2.0".
+func (r Range) String() string {
+	if r.From == "" {
+		return "in the whole history"
+	}
+	return "since " + r.From
+}
+
+// ResolveRange returns the range starting after from, or after the latest
+// tag reachable from HEAD when from is empty.
+func ResolveRange(dir, from string) (Range, error) {
+	if from == "" {
+		tag, err := gitOutput(dir, "describe", "--tags", "--abbrev=0")
+		if err != nil {
+			return Range{}, nil // no tags yet
+		}
+		from = tag
+	}
+	date, err := gitOutput(dir, "log", "-1", "--format=%cI", from)
+	if err != nil {
+		return Range{}, fmt.Errorf("unknown revision %q: %w", from, err)
+	}
+	since, _ := time.Parse(time.RFC3339, date)
+	return Range{From: from, Since: since}, nil
+}
+
+// bracketTag matches the leading "[tag] " of commit subjects such as
+// "[ISSUE-12] Fix login".
+var bracketTag = regexp.MustCompile(`^\[[^\]]*\]\s*`)
+
+// CollectCommits returns the non-merge commits of the range, newest first.
+func CollectCommits(dir string, r Range) ([]Change, error) {
+	args := []string{"log", "--no-merges", "--name-only", "--format=%x1e%H%x1f%s%x1f%b%x1f"}
+	if r.From != "" {
+		args = append(args, r.From+"..HEAD")
+	} else {
+		args = append(args, "-n", fmt.Sprint(maxCommits))
+	}
+	out, err := gitOutput(dir, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read commits: %w", err)
+	}
+
+	var changes []Change
+	for _, record := range strings.Split(out, "\x1e") {
+		fields := strings.Split(record, "\x1f")
+		if len(fields) != 4 || len(fields[0]) < shortHashLength {
+			continue
+		}
+		change := Change{
+			Kind:    KindCommit,
+			Ref:     fields[0][:shortHashLength],
+			Summary: bracketTag.ReplaceAllString(strings.TrimSpace(fields[1]), ""),
+			Details: truncate(strings.TrimSpace(fields[2]), 400),
+		}
+		for _, file := range strings.Split(fields[3], "\n") {
+			if file = strings.TrimSpace(file); file != "" {
+				change.Files = append(change.Files, file)
+			}
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// CollectSessionChanges returns the agent's revisions after since whose
+// changes are still in effect and not committed yet, newest first. History
+// must already point at the workspace (history.InitializeHistoryPaths).
+func CollectSessionChanges(dir string, since time.Time) ([]Change, error) {
+	uncommitted, err := uncommittedFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(uncommitted) == 0 {
+		return nil, nil
+	}
+	groups, err := history.GetRevisionGroups()
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []Change
+	for _, group := range groups {
+		if !group.Timestamp.After(since) {
+			continue
+		}
+		change := Change{Kind: KindSession, Ref: group.RevisionID, Summary: truncate(firstLine(group.Instructions), 200)}
+		seen := map[string]bool{}
+		for _, c := range group.Changes {
+			file := relativePath(dir, c.Filename)
+			if c.Status != "active" || !uncommitted[file] || seen[file] {
+				continue
+			}
+			seen[file] = true
+			change.Files = append(change.Files, file)
+			if change.Details == "" {
+				change.Details = truncate(strings.TrimSpace(c.Description), 400)
+			}
+		}
+		if len(change.Files) > 0 && change.Summary != "" {
+			changes = append(changes, change)
+		}
+	}
+	return changes, nil
+}
+
+// Unrecorded drops the commits whose hash the changelog already mentions,
+// so running the command again only adds what is new.
+func Unrecorded(changes []Change, content string) []Change {
+	var kept []Change
+	for _, change := range changes {
+		if change.Kind == KindCommit && strings.Contains(content, change.Ref) {
+			continue
+		}
+		kept = append(kept, change)
+	}
+	return kept
+}
+
+// uncommittedFiles returns the workspace-relative paths with staged,
+// unstaged or untracked changes.
+func uncommittedFiles(dir string) (map[string]bool, error) {
+	out, err := gitOutput(dir, "status", "--porcelain", "--untracked-files=all")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read git status: %w", err)
+	}
+	files := map[string]bool{}
+	for _, line := range strings.Split(out, "\n") {
+		if len(line) < 4 {
+			continue
+		}
+		path := line[3:]
+		if _, renamed, ok := strings.Cut(path, " -> "); ok {
+			path = renamed
+		}
+		files[strings.Trim(path, `"`)] = true
+	}
+	return files, nil
+}
+
+// relativePath returns path relative to the git root at dir, with slashes.
+func relativePath(dir, path string) string {
+	if filepath.IsAbs(path) {
+		if rel, err := filepath.Rel(dir, path); err == nil {
+			path = rel
+		}
+	}
+	return filepath.ToSlash(filepath.Clean(path))
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(line)
+}
+
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max]) + "..."
+}
+
+func gitOutput(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", err
+	}
+	// Keep leading spaces: they are part of git status --porcelain lines
+	return strings.TrimRight(string(out), " \t\r\n"), nil
+}
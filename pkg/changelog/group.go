@@ -0,0 +1,176 @@
+package changelog
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	api "github.com/alantheprice/ledit/pkg/agent_api"
+)
+
+// groupPrompt asks the model to turn changes into changelog entries.
+const groupPrompt = `Write changelog entries in the Keep a Changelog format for these changes to the project.
+
+Changes (commits as "[hash]", uncommitted agent work as "[session]"):
+%s
+Rules:
+- Write for users of the project: what they can now do, what behaves differently, what was fixed. No file names or implementation details.
+- Merge changes that belong together into one entry and list all their hashes in "refs".
+- Sections: Added (new features), Changed (changes and refactors users notice), Deprecated, Removed, Fixed (bug fixes), Security.
+- Leave out changes users won't notice: tests, CI, formatting, internal refactors, changelog updates, merges.
+- Each entry is one sentence in the imperative or past tense, matching the others.
+
+Reply with only JSON:
+{"entries": [{"section": "Added", "text": "...", "refs": ["1a2b3c4d"]}]}`
+
+// Group asks the model to describe changes as changelog entries.
+func Group(client api.ClientInterface, changes []Change) ([]Entry, error) {
+	if client == nil {
+		return nil, fmt.Errorf("client is required")
+	}
+	var list strings.Builder
+	for _, change := range changes {
+		ref := "session"
+		if change.Kind == KindCommit {
+			ref = change.Ref
+		}
+		fmt.Fprintf(&list, "- [%s] %s", ref, change.Summary)
+		if len(change.Files) > 0 {
+			fmt.Fprintf(&list, " (files: %s)", strings.Join(limit(change.Files, 8), ", "))
+		}
+		list.WriteString("\n")
+		if change.Details != "" {
+			fmt.Fprintf(&list, "  %s\n", strings.ReplaceAll(change.Details, "\n", "\n  "))
+		}
+	}
+
+	messages := []api.Message{
+		{Role: "system", Content: "You maintain the changelog of a software project. You reply with JSON only."},
+		{Role: "user", Content: fmt.Sprintf(groupPrompt, list.String())},
+	}
+	resp, err := client.SendChatRequest(messages, nil, "", false)
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil || len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("no response from model")
+	}
+	return parseEntries(resp.Choices[0].Message.Content, changes)
+}
+
+// parseEntries reads the model's JSON reply. Refs that are not hashes of
+// the changes are dropped.
+func parseEntries(reply string, changes []Change) ([]Entry, error) {
+	reply = strings.TrimSpace(reply)
+	if start, end := strings.Index(reply, "{"), strings.LastIndex(reply, "}"); start >= 0 && end > start {
+		reply = reply[start : end+1]
+	}
+	var parsed struct {
+		Entries []Entry `json:"entries"`
+	}
+	if err := json.Unmarshal([]byte(reply), &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse changelog entries: %w", err)
+	}
+	known := map[string]bool{}
+	for _, change := range changes {
+		if change.Kind == KindCommit {
+			known[change.Ref] = true
+		}
+	}
+
+	var entries []Entry
+	for _, entry := range parsed.Entries {
+		entry.Text = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(entry.Text), "- "))
+		if entry.Text == "" {
+			continue
+		}
+		entry.Section = NormalizeSection(entry.Section)
+		var refs []string
+		for _, ref := range entry.Refs {
+			if ref = strings.TrimSpace(ref); len(ref) >= shortHashLength && known[ref[:shortHashLength]] {
+				refs = append(refs, ref[:shortHashLength])
+			}
+		}
+		entry.Refs = refs
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+var conventionalPrefix = regexp.MustCompile(`^([a-z]+)(?:\([^)]*\))?(!)?:\s*(.+)$`)
+
+// Classify sorts changes into sections from their wording alone: a
+// Conventional Commits type, or the first verb of the summary. Changes that
+// users won't notice (tests, docs, CI, chores) are left out. It is the
+// fallback when no model is available.
+func Classify(changes []Change) []Entry {
+	var entries []Entry
+	for _, change := range changes {
+		section, text := classify(change.Summary)
+		if section == "" {
+			continue
+		}
+		entry := Entry{Section: section, Text: text}
+		if change.Kind == KindCommit {
+			entry.Refs = []string{change.Ref}
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func classify(summary string) (string, string) {
+	summary = strings.TrimSpace(summary)
+	if m := conventionalPrefix.FindStringSubmatch(summary); m != nil {
+		text := capitalize(m[3])
+		switch m[1] {
+		case "feat":
+			return SectionAdded, text
+		case "fix":
+			return SectionFixed, text
+		case "perf", "refactor", "revert":
+			return SectionChanged, text
+		case "security":
+			return SectionSecurity, text
+		default: // docs, style, test, build, ci, chore
+			return "", ""
+		}
+	}
+
+	lower := strings.ToLower(summary)
+	verb, _, _ := strings.Cut(lower, " ")
+	switch {
+	case strings.HasPrefix(lower, "merge "), strings.Contains(lower, "changelog"):
+		return "", ""
+	case strings.Contains(lower, "security"), strings.Contains(lower, "vulnerab"), strings.Contains(lower, "cve-"):
+		return SectionSecurity, summary
+	}
+	switch verb {
+	case "add", "adds", "added", "implement", "implements", "introduce", "introduces", "support", "supports", "allow", "allows":
+		return SectionAdded, summary
+	case "fix", "fixes", "fixed", "correct", "corrects", "resolve", "resolves", "handle", "handles", "prevent", "prevents":
+		return SectionFixed, summary
+	case "remove", "removes", "removed", "drop", "drops", "delete", "deletes":
+		return SectionRemoved, summary
+	case "deprecate", "deprecates", "deprecated":
+		return SectionDeprecated, summary
+	case "test", "tests", "docs", "doc", "document", "documents", "bump", "ci", "chore", "lint", "format":
+		return "", ""
+	}
+	return SectionChanged, summary
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+func limit(items []string, max int) []string {
+	if len(items) <= max {
+		return items
+	}
+	return append(append([]string(nil), items[:max]...), fmt.Sprintf("%d more", len(items)-max))
+}
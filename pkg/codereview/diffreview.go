@@ -0,0 +1,371 @@
+package codereview
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ReviewPersona is the persona that reviews diffs.
+const ReviewPersona = "code_reviewer"
+
+// DefaultDiffChunkBytes is the size of the diff sent in one review request.
+const DefaultDiffChunkBytes = 40000
+
+// DiffChunk is a part of a diff small enough for one review request: whole
+// files, or the hunks of a large file under a copy of its header.
+type DiffChunk struct {
+	Files []string
+	Diff  string
+}
+
+// fileDiff is the diff of one file.
+type fileDiff struct {
+	path   string
+	header string   // "diff --git" line up to the first hunk
+	hunks  []string // each starting with its "@@" line
+}
+
+// splitDiff splits a unified git diff into files. Deleted files are left
+// out, as there is no new version to comment on.
+func splitDiff(diff string) []fileDiff {
+	var files []fileDiff
+	var current *fileDiff
+	var section strings.Builder
+	flush := func() {
+		if current == nil {
+			return
+		}
+		if len(current.hunks) == 0 {
+			current.header = section.String()
+		} else {
+			current.hunks[len(current.hunks)-1] = section.String()
+		}
+		section.Reset()
+	}
+	for _, line := range strings.SplitAfter(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			flush()
+			files = append(files, fileDiff{path: diffHeaderPath(line)})
+			current = &files[len(files)-1]
+		case current == nil:
+			continue
+		case strings.HasPrefix(line, "@@"):
+			flush()
+			current.hunks = append(current.hunks, "")
+		case len(current.hunks) == 0 && strings.HasPrefix(line, "+++ "):
+			if path := strings.TrimSpace(strings.TrimPrefix(line, "+++ ")); path == "/dev/null" {
+				current.path = ""
+			} else {
+				current.path = strings.TrimPrefix(path, "b/")
+			}
+		}
+		section.WriteString(line)
+	}
+	flush()
+
+	kept := files[:0]
+	for _, file := range files {
+		if file.path != "" {
+			kept = append(kept, file)
+		}
+	}
+	return kept
+}
+
+// diffHeaderPath returns the new path of a "diff --git a/x b/y" line.
+func diffHeaderPath(line string) string {
+	line = strings.TrimSpace(line)
+	if i := strings.LastIndex(line, " b/"); i >= 0 {
+		return line[i+3:]
+	}
+	return ""
+}
+
+// ChunkDiff splits a diff into chunks of at most maxBytes, packing small
+// files together and splitting large ones between hunks. A single hunk
+// larger than maxBytes becomes a chunk of its own. Files skip reports true
+// for, and binary files, are left out.
+func ChunkDiff(diff string, maxBytes int, skip func(path string) bool) []DiffChunk {
+	if maxBytes <= 0 {
+		maxBytes = DefaultDiffChunkBytes
+	}
+	var chunks []DiffChunk
+	var current DiffChunk
+	add := func(path, text string) {
+		if current.Diff != "" && len(current.Diff)+len(text) > maxBytes {
+			chunks = append(chunks, current)
+			current = DiffChunk{}
+		}
+		if len(current.Files) == 0 || current.Files[len(current.Files)-1] != path {
+			current.Files = append(current.Files, path)
+		}
+		current.Diff += text
+	}
+
+	for _, file := range splitDiff(diff) {
+		if len(file.hunks) == 0 || (skip != nil && skip(file.path)) {
+			continue
+		}
+		whole := file.header + strings.Join(file.hunks, "")
+		if len(whole) <= maxBytes {
+			add(file.path, whole)
+			continue
+		}
+		// Large file: pack its hunks, repeating the header in each chunk
+		part := file.header
+		for _, hunk := range file.hunks {
+			if part != file.header && len(part)+len(hunk) > maxBytes {
+				add(file.path, part)
+				part = file.header
+			}
+			part += hunk
+		}
+		add(file.path, part)
+	}
+	if current.Diff != "" {
+		chunks = append(chunks, current)
+	}
+	return chunks
+}
+
+var hunkHeaderPattern = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,\d+)? @@`)
+
+// DiffLines returns, for each file, the lines of its new version that the
+// diff shows (added or context lines): the lines inline comments can be
+// attached to.
+func DiffLines(diff string) map[string]map[int]bool {
+	lines := make(map[string]map[int]bool)
+	for _, file := range splitDiff(diff) {
+		shown := make(map[int]bool)
+		for _, hunk := range file.hunks {
+			line := 0
+			for _, text := range strings.Split(hunk, "\n") {
+				if m := hunkHeaderPattern.FindStringSubmatch(text); m != nil {
+					line, _ = strconv.Atoi(m[1])
+					continue
+				}
+				switch {
+				case strings.HasPrefix(text, "+"), strings.HasPrefix(text, " "):
+					shown[line] = true
+					line++
+				}
+			}
+		}
+		lines[file.path] = shown
+	}
+	return lines
+}
+
+// ReviewFinding is an issue a review found in a diff.
+type ReviewFinding struct {
+	Severity   string `json:"severity"`
+	Category   string `json:"category,omitempty"` // bug, security, performance, maintainability, style
+	File       string `json:"file"`
+	Line       int    `json:"line,omitempty"`
+	Title      string `json:"title"`
+	Body       string `json:"body,omitempty"`
+	Suggestion string `json:"suggestion,omitempty"`
+}
+
+// Location returns file:line, or the file when the finding has no line.
+func (f ReviewFinding) Location() string {
+	if f.Line > 0 {
+		return fmt.Sprintf("%s:%d", f.File, f.Line)
+	}
+	return f.File
+}
+
+// DiffReviewPrompt returns the task for the reviewer persona: review one
+// chunk of a diff and reply with findings as JSON. about describes the
+// change (pull request title and description, or the revision range) and
+// head is the revision the new versions of the files are at.
+func DiffReviewPrompt(chunk DiffChunk, about, head string, part, parts int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Review this change (part %d of %d of the diff).\n\n", part, parts)
+	if strings.TrimSpace(about) != "" {
+		fmt.Fprintf(&b, "## About the change\n\n%s\n\n", strings.TrimSpace(about))
+	}
+	if head != "" {
+		fmt.Fprintf(&b, "The new versions of the files are at revision %s; the working tree may differ, so read them with `git show %s:<path>` when you need more context.\n\n", head, head)
+	}
+	fmt.Fprintf(&b, "## Diff\n\n```diff\n%s```\n", chunk.Diff)
+	b.WriteString(`
+Report real problems the change introduces: bugs, security issues, data loss, race conditions, missing error handling, performance problems, and maintainability issues that matter. Do not report style preferences, or problems in code the change does not touch. Do not modify any files.
+
+"line" is the line number in the new version of the file, on a line the diff shows. Keep "body" to a few sentences explaining the problem and its impact; put the fix in "suggestion".
+
+When you are done, reply with only JSON:
+{"findings": [{"severity": "critical|high|medium|low|info", "category": "bug|security|performance|maintainability|style", "file": "path", "line": 42, "title": "...", "body": "...", "suggestion": "..."}]}
+Reply with {"findings": []} when the change looks good.`)
+	return b.String()
+}
+
+// ParseReviewFindings reads the findings from the reviewer's reply.
+// Findings without a file or title are dropped.
+func ParseReviewFindings(reply string) ([]ReviewFinding, error) {
+	var lastErr error
+	for _, candidate := range extractStructuredReviewCandidates(reply) {
+		var parsed struct {
+			Findings *[]ReviewFinding `json:"findings"`
+		}
+		if err := json.Unmarshal([]byte(candidate), &parsed); err != nil {
+			lastErr = err
+			continue
+		}
+		if parsed.Findings == nil {
+			continue
+		}
+		var findings []ReviewFinding
+		for _, finding := range *parsed.Findings {
+			finding.File = strings.TrimPrefix(filepath.ToSlash(strings.TrimSpace(finding.File)), "./")
+			finding.File = strings.TrimPrefix(finding.File, "b/")
+			finding.Title = strings.TrimSpace(finding.Title)
+			if finding.File == "" || finding.Title == "" {
+				continue
+			}
+			finding.Severity = normalizeSeverity(finding.Severity)
+			finding.Category = strings.ToLower(strings.TrimSpace(finding.Category))
+			findings = append(findings, finding)
+		}
+		return findings, nil
+	}
+	if lastErr != nil {
+		return nil, fmt.Errorf("failed to parse review findings: %w", lastErr)
+	}
+	return nil, fmt.Errorf("review returned no JSON findings")
+}
+
+// RankReviewFindings removes duplicates and orders findings by severity,
+// then file and line. Two findings are duplicates when they are in the same
+// file within a few lines of each other and have the same title, or the
+// same category on the same line; the more severe one is kept.
+func RankReviewFindings(findings []ReviewFinding) []ReviewFinding {
+	var ranked []ReviewFinding
+	for _, finding := range findings {
+		duplicate := -1
+		for i, kept := range ranked {
+			if isDuplicateFinding(kept, finding) {
+				duplicate = i
+				break
+			}
+		}
+		switch {
+		case duplicate < 0:
+			ranked = append(ranked, finding)
+		case severityRank[finding.Severity] < severityRank[ranked[duplicate].Severity]:
+			ranked[duplicate] = finding
+		}
+	}
+	sortBySeverity(ranked, func(f ReviewFinding) (string, string, int) { return f.Severity, f.File, f.Line })
+	return ranked
+}
+
+func isDuplicateFinding(a, b ReviewFinding) bool {
+	if a.File != b.File {
+		return false
+	}
+	distance := a.Line - b.Line
+	if distance < 0 {
+		distance = -distance
+	}
+	if normalizeTitle(a.Title) == normalizeTitle(b.Title) && distance <= 3 {
+		return true
+	}
+	return a.Line > 0 && a.Line == b.Line && a.Category != "" && a.Category == b.Category
+}
+
+var nonWordPattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+func normalizeTitle(title string) string {
+	return strings.Trim(nonWordPattern.ReplaceAllString(strings.ToLower(title), " "), " ")
+}
+
+// InlineComment is a review comment ready to post on a line of a pull
+// request's diff.
+type InlineComment struct {
+	Path     string `json:"path"`
+	Line     int    `json:"line"`
+	Side     string `json:"side"` // always "RIGHT": the new version of the file
+	Severity string `json:"severity"`
+	Body     string `json:"body"`
+}
+
+// DiffReviewResult is the outcome of a diff review.
+type DiffReviewResult struct {
+	Target   string          `json:"target"`
+	Summary  string          `json:"summary"`
+	Comments []InlineComment `json:"comments"`          // findings on lines of the diff
+	General  []ReviewFinding `json:"general,omitempty"` // findings that cannot be inline comments
+	Findings []ReviewFinding `json:"findings"`
+}
+
+// BuildDiffReviewResult turns ranked findings into inline comments for the
+// lines the diff shows (see DiffLines) and general findings for the rest.
+func BuildDiffReviewResult(target string, findings []ReviewFinding, lines map[string]map[int]bool) DiffReviewResult {
+	result := DiffReviewResult{Target: target, Comments: []InlineComment{}, Findings: findings}
+	if result.Findings == nil {
+		result.Findings = []ReviewFinding{}
+	}
+	for _, finding := range findings {
+		if finding.Line > 0 && lines[finding.File][finding.Line] {
+			result.Comments = append(result.Comments, InlineComment{
+				Path:     finding.File,
+				Line:     finding.Line,
+				Side:     "RIGHT",
+				Severity: finding.Severity,
+				Body:     FormatFindingComment(finding),
+			})
+		} else {
+			result.General = append(result.General, finding)
+		}
+	}
+	result.Summary = summarizeDiffReview(result)
+	return result
+}
+
+// FormatFindingComment formats a finding as the Markdown body of a comment.
+func FormatFindingComment(f ReviewFinding) string {
+	var b strings.Builder
+	label := f.Severity
+	if f.Category != "" {
+		label += ", " + f.Category
+	}
+	fmt.Fprintf(&b, "**%s** (%s)", f.Title, label)
+	if f.Body != "" {
+		fmt.Fprintf(&b, "\n\n%s", f.Body)
+	}
+	if f.Suggestion != "" {
+		fmt.Fprintf(&b, "\n\n**Suggestion:** %s", f.Suggestion)
+	}
+	return b.String()
+}
+
+// summarizeDiffReview returns the Markdown body of the review: the counts
+// by severity and the findings that are not inline comments.
+func summarizeDiffReview(result DiffReviewResult) string {
+	var b strings.Builder
+	if len(result.Findings) == 0 {
+		b.WriteString("Review found no issues.")
+		return b.String()
+	}
+	counts := make(map[string]int)
+	for _, finding := range result.Findings {
+		counts[finding.Severity]++
+	}
+	var summary []string
+	for _, severity := range []string{SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow, SeverityInfo} {
+		if counts[severity] > 0 {
+			summary = append(summary, fmt.Sprintf("%d %s", counts[severity], severity))
+		}
+	}
+	fmt.Fprintf(&b, "Review found %d issue(s): %s.", len(result.Findings), strings.Join(summary, ", "))
+	for _, finding := range result.General {
+		fmt.Fprintf(&b, "\n\n`%s` %s", finding.Location(), FormatFindingComment(finding))
+	}
+	return b.String()
+}
@@ -0,0 +1,116 @@
+package codereview
+
+import (
+	"strings"
+	"testing"
+)
+
+const sampleDiff = `diff --git a/app.go b/app.go
+index 1111111..2222222 100644
+--- a/app.go
++++ b/app.go
+@@ -1,3 +1,4 @@
+ package app
++
++func Run() error { return nil }
+ // end
+@@ -20,2 +21,3 @@ func other() {
+ 	x := 1
++	y := 2
+ }
+diff --git a/go.sum b/go.sum
+index 3333333..4444444 100644
+--- a/go.sum
++++ b/go.sum
+@@ -1 +1,2 @@
+ a v1
++b v1
+diff --git a/old.go b/old.go
+deleted file mode 100644
+index 5555555..0000000
+--- a/old.go
++++ /dev/null
+@@ -1 +0,0 @@
+-package app
+diff --git a/logo.png b/logo.png
+index 6666666..7777777 100644
+Binary files a/logo.png and b/logo.png differ
+`
+
+func TestChunkDiff(t *testing.T) {
+	skipSum := func(path string) bool { return strings.HasSuffix(path, ".sum") }
+
+	chunks := ChunkDiff(sampleDiff, 10000, skipSum)
+	if len(chunks) != 1 || strings.Join(chunks[0].Files, ",") != "app.go" {
+		t.Fatalf("expected one chunk with app.go, got %+v", chunks)
+	}
+	if strings.Contains(chunks[0].Diff, "go.sum") || strings.Contains(chunks[0].Diff, "old.go") {
+		t.Fatalf("expected skipped and deleted files to be left out:\n%s", chunks[0].Diff)
+	}
+
+	// A small budget splits app.go between its hunks, each under the header
+	chunks = ChunkDiff(sampleDiff, 150, nil)
+	if len(chunks) != 3 {
+		t.Fatalf("expected 3 chunks, got %d: %+v", len(chunks), chunks)
+	}
+	for _, chunk := range chunks[:2] {
+		if !strings.HasPrefix(chunk.Diff, "diff --git a/app.go b/app.go\n") || strings.Count(chunk.Diff, "\n@@ -") != 1 {
+			t.Errorf("expected a hunk of app.go under its header, got:\n%s", chunk.Diff)
+		}
+	}
+	if chunks[2].Files[0] != "go.sum" {
+		t.Errorf("expected go.sum in the last chunk, got %+v", chunks[2])
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	lines := DiffLines(sampleDiff)
+	for _, line := range []int{1, 2, 3, 4, 21, 22, 23} {
+		if !lines["app.go"][line] {
+			t.Errorf("expected app.go:%d in the diff", line)
+		}
+	}
+	if lines["app.go"][5] || lines["app.go"][20] {
+		t.Errorf("unexpected lines %v", lines["app.go"])
+	}
+	if _, ok := lines["old.go"]; ok {
+		t.Error("expected deleted files to have no lines")
+	}
+}
+
+func TestRankAndBuildDiffReview(t *testing.T) {
+	reply := "```json\n" + `{"findings": [
+		{"severity": "medium", "category": "bug", "file": "b/app.go", "line": 3, "title": "Run ignores errors", "body": "Errors are dropped."},
+		{"severity": "high", "category": "bug", "file": "app.go", "line": 4, "title": "Run ignores errors!", "suggestion": "Return the error."},
+		{"severity": "low", "category": "style", "file": "app.go", "line": 40, "title": "Long function"},
+		{"severity": "critical", "category": "security", "file": "app.go", "line": 22, "title": "Hardcoded secret"}
+	]}` + "\n```"
+	findings, err := ParseReviewFindings(reply)
+	if err != nil {
+		t.Fatalf("ParseReviewFindings: %v", err)
+	}
+	ranked := RankReviewFindings(findings)
+	if len(ranked) != 3 {
+		t.Fatalf("expected the duplicate to be merged, got %+v", ranked)
+	}
+	if ranked[0].Title != "Hardcoded secret" || ranked[1].Severity != SeverityHigh || ranked[2].Line != 40 {
+		t.Fatalf("unexpected ranking %+v", ranked)
+	}
+
+	result := BuildDiffReviewResult("main..HEAD", ranked, DiffLines(sampleDiff))
+	if len(result.Comments) != 2 || len(result.General) != 1 || result.General[0].Line != 40 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if c := result.Comments[1]; c.Path != "app.go" || c.Line != 4 || c.Side != "RIGHT" ||
+		c.Body != "**Run ignores errors!** (high, bug)\n\n**Suggestion:** Return the error." {
+		t.Fatalf("unexpected comment %+v", c)
+	}
+	if !strings.HasPrefix(result.Summary, "Review found 3 issue(s): 1 critical, 1 high, 1 low.") ||
+		!strings.Contains(result.Summary, "`app.go:40` **Long function** (low, style)") {
+		t.Fatalf("unexpected summary %q", result.Summary)
+	}
+
+	if empty := BuildDiffReviewResult("x", nil, nil); empty.Summary != "Review found no issues." || empty.Comments == nil || empty.Findings == nil {
+		t.Fatalf("unexpected empty result %+v", empty)
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	api "github.com/alantheprice/ledit/pkg/agent_api"
 	"github.com/alantheprice/ledit/pkg/prompts"
+	"github.com/alantheprice/ledit/pkg/tokenizer"
 	"github.com/alantheprice/ledit/pkg/types"
 )
 
@@ -113,7 +114,10 @@ func (s *CodeReviewService) prepareReviewContextForPrompt(ctx *ReviewContext) *R
 		prepared.RelatedFiles = append(prepared.RelatedFiles[:maxReviewRelatedFiles], fmt.Sprintf("... (%d additional related files omitted)", omitted))
 	}
 
-	promptBudget := s.reviewPromptByteBudget(&prepared)
+	tokenBudget, tok := s.reviewPromptTokenBudget(&prepared)
+	fits := func(prompt string) bool {
+		return len(prompt) <= maxReviewPromptBytes && (tokenBudget == 0 || tok.Count(prompt) <= tokenBudget)
+	}
 	promptDirty := true
 	prompt := ""
 	rebuildPrompt := func() string {
@@ -125,7 +129,7 @@ func (s *CodeReviewService) prepareReviewContextForPrompt(ctx *ReviewContext) *R
 	}
 
 	prompt = rebuildPrompt()
-	if len(prompt) <= promptBudget {
+	if fits(prompt) {
 		return &prepared
 	}
 
@@ -135,7 +139,7 @@ func (s *CodeReviewService) prepareReviewContextForPrompt(ctx *ReviewContext) *R
 		prompt = rebuildPrompt()
 	}
 
-	if len(prompt) <= promptBudget {
+	if fits(prompt) {
 		return &prepared
 	}
 
@@ -145,7 +149,7 @@ func (s *CodeReviewService) prepareReviewContextForPrompt(ctx *ReviewContext) *R
 		prompt = rebuildPrompt()
 	}
 
-	if len(prompt) <= promptBudget {
+	if fits(prompt) {
 		return &prepared
 	}
 
@@ -157,7 +161,7 @@ func (s *CodeReviewService) prepareReviewContextForPrompt(ctx *ReviewContext) *R
 	promptDirty = true
 	prompt = rebuildPrompt()
 
-	if len(prompt) <= promptBudget {
+	if fits(prompt) {
 		return &prepared
 	}
 
@@ -165,25 +169,36 @@ func (s *CodeReviewService) prepareReviewContextForPrompt(ctx *ReviewContext) *R
 	overheadCtx.Diff = ""
 	overheadCtx.FullFileContext = ""
 	overheadCtx.RelatedFiles = nil
-	overhead := len(s.buildEnhancedReviewPrompt(&overheadCtx, false))
-	remaining := promptBudget - overhead - len("\n## Code Changes to Review\n```diff\n\n```")
+	overheadPrompt := s.buildEnhancedReviewPrompt(&overheadCtx, false) + "\n## Code Changes to Review\n```diff\n\n```"
+	remaining := maxReviewPromptBytes - len(overheadPrompt)
 	if remaining < 8*1024 {
 		remaining = 8 * 1024
 	}
 	prepared.Diff = truncateForPromptSection(prepared.Diff, remaining, "diff")
 
+	if tokenBudget > 0 {
+		remainingTokens := tokenBudget - tok.Count(overheadPrompt)
+		if remainingTokens < 2*1024 {
+			remainingTokens = 2 * 1024
+		}
+		prepared.Diff = truncateForPromptTokens(prepared.Diff, remainingTokens, tok, "diff")
+	}
+
 	return &prepared
 }
 
-func (s *CodeReviewService) reviewPromptByteBudget(ctx *ReviewContext) int {
-	budget := maxReviewPromptBytes
+// reviewPromptTokenBudget returns how many tokens of the model's context
+// window the review prompt may use, and the tokenizer that counts them for
+// the client's model. The budget is 0 when the context limit is unknown.
+func (s *CodeReviewService) reviewPromptTokenBudget(ctx *ReviewContext) (int, tokenizer.Tokenizer) {
 	if ctx == nil || ctx.AgentClient == nil {
-		return budget
+		return 0, tokenizer.Heuristic{}
 	}
 
+	tok := tokenizer.ForModel(ctx.AgentClient.GetModel())
 	contextLimit, err := ctx.AgentClient.GetModelContextLimit()
 	if err != nil || contextLimit <= 0 {
-		return budget
+		return 0, tok
 	}
 
 	usableTokens := int(float64(contextLimit) * 0.60)
@@ -196,12 +211,28 @@ func (s *CodeReviewService) reviewPromptByteBudget(ctx *ReviewContext) int {
 	if usableTokens < 1024 {
 		usableTokens = 1024
 	}
+	return usableTokens, tok
+}
 
-	modelBudget := usableTokens * estimatedCharsPerToken
-	if modelBudget < budget {
-		return modelBudget
+// truncateForPromptTokens truncates content as truncateForPromptSection does,
+// keeping as much as fits in maxTokens counted with tok.
+func truncateForPromptTokens(content string, maxTokens int, tok tokenizer.Tokenizer, label string) string {
+	if tok.Count(content) <= maxTokens {
+		return content
+	}
+	low, high := 0, len(content)
+	for low < high {
+		mid := (low + high + 1) / 2
+		if tok.Count(truncateForPromptSection(content, mid, label)) <= maxTokens {
+			low = mid
+		} else {
+			high = mid - 1
+		}
+	}
+	if low == 0 {
+		return ""
 	}
-	return budget
+	return truncateForPromptSection(content, low, label)
 }
 
 func truncateForPromptSection(content string, maxBytes int, label string) string {
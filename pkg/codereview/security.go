@@ -0,0 +1,299 @@
+package codereview
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alantheprice/ledit/pkg/utils"
+)
+
+// SecurityPersona is the persona that performs security reviews.
+const SecurityPersona = "security_reviewer"
+
+// SecurityFindingsFile is where security findings are kept, relative to the
+// workspace root, so later reviews and sessions can see what is still open.
+const SecurityFindingsFile = ".ledit/security_findings.json"
+
+// Severities of security findings, most severe first.
+const (
+	SeverityCritical = "critical"
+	SeverityHigh     = "high"
+	SeverityMedium   = "medium"
+	SeverityLow      = "low"
+	SeverityInfo     = "info"
+)
+
+var severityRank = map[string]int{SeverityCritical: 0, SeverityHigh: 1, SeverityMedium: 2, SeverityLow: 3, SeverityInfo: 4}
+
+// SecurityFinding is a vulnerability found by a security review. Source and
+// Sink describe the taint flow: where untrusted data enters and where it is
+// used unsafely.
+type SecurityFinding struct {
+	Severity    string    `json:"severity"`
+	CWE         string    `json:"cwe,omitempty"` // e.g. "CWE-78"
+	Title       string    `json:"title"`
+	File        string    `json:"file"`
+	Line        int       `json:"line,omitempty"`
+	Source      string    `json:"source,omitempty"`
+	Sink        string    `json:"sink,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Remediation string    `json:"remediation,omitempty"`
+	FirstSeen   time.Time `json:"first_seen,omitzero"`
+	LastSeen    time.Time `json:"last_seen,omitzero"`
+}
+
+// Key identifies a finding across reviews: the same weakness in the same
+// file is the same finding even when lines move.
+func (f SecurityFinding) Key() string {
+	return strings.ToLower(f.File + "|" + f.CWE + "|" + f.Title)
+}
+
+// SecurityReviewPrompt returns the task for the security persona: review the
+// files and reply with the findings as JSON. whole says whether the files
+// are the whole repository rather than the changed files.
+func SecurityReviewPrompt(files []string, whole bool) string {
+	var b strings.Builder
+	if whole {
+		b.WriteString("Perform a security review of this repository.\n\n")
+	} else {
+		b.WriteString("Perform a security review of these changed files. Review the code the changes touch, following data into and out of the rest of the repository where needed.\n\n")
+	}
+	b.WriteString("Files:\n")
+	for _, file := range files {
+		fmt.Fprintf(&b, "- %s\n", file)
+	}
+	b.WriteString(`
+Trace untrusted input (request parameters, headers, files, environment, command-line arguments, data from external services) from where it enters the program (source) to where it is used in a dangerous operation (sink): SQL queries, shell commands, file paths, templates and HTML, deserialization, redirects, outbound requests, logs. Also check authentication and authorization, secrets in code, cryptography, and error handling that leaks data.
+
+Only report weaknesses you have confirmed by reading the code; read the files rather than guessing. Do not modify any files.
+
+When you are done, reply with only JSON:
+{"findings": [{"severity": "critical|high|medium|low|info", "cwe": "CWE-78", "title": "...", "file": "path/relative/to/repo", "line": 42, "source": "where untrusted data enters", "sink": "where it is used unsafely", "description": "...", "remediation": "..."}]}
+Reply with {"findings": []} when there is nothing to report.`)
+	return b.String()
+}
+
+var cwePattern = regexp.MustCompile(`(?i)^(?:cwe)?[-\s:]*(\d+)$`)
+
+// ParseSecurityFindings reads the findings from the security persona's
+// reply. Findings without a file or title are dropped.
+func ParseSecurityFindings(reply string) ([]SecurityFinding, error) {
+	candidates := extractStructuredReviewCandidates(reply)
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("security review returned no JSON findings")
+	}
+	var lastErr error
+	for _, candidate := range candidates {
+		var parsed struct {
+			Findings *[]SecurityFinding `json:"findings"`
+		}
+		if err := json.Unmarshal([]byte(candidate), &parsed); err != nil {
+			lastErr = err
+			continue
+		}
+		if parsed.Findings == nil {
+			continue
+		}
+		var findings []SecurityFinding
+		for _, finding := range *parsed.Findings {
+			finding.File = strings.TrimPrefix(filepath.ToSlash(strings.TrimSpace(finding.File)), "./")
+			finding.Title = strings.TrimSpace(finding.Title)
+			if finding.File == "" || finding.Title == "" {
+				continue
+			}
+			finding.Severity = normalizeSeverity(finding.Severity)
+			if m := cwePattern.FindStringSubmatch(strings.TrimSpace(finding.CWE)); m != nil {
+				finding.CWE = "CWE-" + m[1]
+			}
+			findings = append(findings, finding)
+		}
+		SortSecurityFindings(findings)
+		return findings, nil
+	}
+	if lastErr != nil {
+		return nil, fmt.Errorf("failed to parse security findings: %w", lastErr)
+	}
+	return nil, fmt.Errorf("security review returned no JSON findings")
+}
+
+func normalizeSeverity(severity string) string {
+	severity = strings.ToLower(strings.TrimSpace(severity))
+	if _, ok := severityRank[severity]; ok {
+		return severity
+	}
+	switch severity {
+	case "moderate":
+		return SeverityMedium
+	case "informational", "note":
+		return SeverityInfo
+	}
+	return SeverityMedium
+}
+
+// SortSecurityFindings orders findings by severity, then file and line.
+func SortSecurityFindings(findings []SecurityFinding) {
+	sortBySeverity(findings, func(f SecurityFinding) (string, string, int) { return f.Severity, f.File, f.Line })
+}
+
+// sortBySeverity orders items by severity, then file and line, as returned
+// by key.
+func sortBySeverity[T any](items []T, key func(T) (severity, file string, line int)) {
+	sort.SliceStable(items, func(i, j int) bool {
+		si, fi, li := key(items[i])
+		sj, fj, lj := key(items[j])
+		if severityRank[si] != severityRank[sj] {
+			return severityRank[si] < severityRank[sj]
+		}
+		if fi != fj {
+			return fi < fj
+		}
+		return li < lj
+	})
+}
+
+// LoadSecurityFindings reads the findings stored in the workspace. A missing
+// file means there are none.
+func LoadSecurityFindings(root string) ([]SecurityFinding, error) {
+	data, err := os.ReadFile(filepath.Join(root, SecurityFindingsFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", SecurityFindingsFile, err)
+	}
+	var file struct {
+		Findings []SecurityFinding `json:"findings"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", SecurityFindingsFile, err)
+	}
+	return file.Findings, nil
+}
+
+// RecordSecurityFindings merges the findings of a review into the workspace's
+// stored findings and saves them. Stored findings for the reviewed files
+// that the review no longer reports are resolved and dropped; findings for
+// other files are kept. It returns the stored findings.
+func RecordSecurityFindings(root string, reviewed []string, findings []SecurityFinding, now time.Time) ([]SecurityFinding, error) {
+	stored, err := LoadSecurityFindings(root)
+	if err != nil {
+		return nil, err
+	}
+	inReview := make(map[string]bool, len(reviewed))
+	for _, file := range reviewed {
+		inReview[filepath.ToSlash(file)] = true
+	}
+	previous := make(map[string]SecurityFinding, len(stored))
+	var merged []SecurityFinding
+	for _, finding := range stored {
+		previous[finding.Key()] = finding
+		if !inReview[finding.File] {
+			merged = append(merged, finding)
+		}
+	}
+	seen := make(map[string]bool, len(findings))
+	for _, finding := range findings {
+		key := finding.Key()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if old, ok := previous[key]; ok && !old.FirstSeen.IsZero() {
+			finding.FirstSeen = old.FirstSeen
+		} else {
+			finding.FirstSeen = now
+		}
+		finding.LastSeen = now
+		if !inReview[finding.File] {
+			// Reported outside the reviewed files: replace any stored copy
+			for i := range merged {
+				if merged[i].Key() == key {
+					merged = append(merged[:i], merged[i+1:]...)
+					break
+				}
+			}
+		}
+		merged = append(merged, finding)
+	}
+	SortSecurityFindings(merged)
+
+	data, err := json.MarshalIndent(struct {
+		UpdatedAt time.Time         `json:"updated_at"`
+		Findings  []SecurityFinding `json:"findings"`
+	}{now, merged}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(root, SecurityFindingsFile)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", filepath.Dir(SecurityFindingsFile), err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", SecurityFindingsFile, err)
+	}
+	return merged, nil
+}
+
+// RenderSecurityReport formats findings as a Markdown report grouped by
+// severity.
+func RenderSecurityReport(findings []SecurityFinding) string {
+	var b strings.Builder
+	b.WriteString("# Security Review\n\n")
+	if len(findings) == 0 {
+		b.WriteString("No security findings.\n")
+		return b.String()
+	}
+
+	counts := make(map[string]int)
+	for _, finding := range findings {
+		counts[finding.Severity]++
+	}
+	var summary []string
+	for _, severity := range []string{SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow, SeverityInfo} {
+		if counts[severity] > 0 {
+			summary = append(summary, fmt.Sprintf("%d %s", counts[severity], severity))
+		}
+	}
+	fmt.Fprintf(&b, "%d finding(s): %s\n", len(findings), strings.Join(summary, ", "))
+
+	severity := ""
+	for _, finding := range findings {
+		if finding.Severity != severity {
+			severity = finding.Severity
+			fmt.Fprintf(&b, "\n## %s\n", utils.CapitalizeWords(severity))
+		}
+		location := finding.File
+		if finding.Line > 0 {
+			location = fmt.Sprintf("%s:%d", finding.File, finding.Line)
+		}
+		title := finding.Title
+		if finding.CWE != "" {
+			title = finding.CWE + ": " + title
+		}
+		fmt.Fprintf(&b, "\n### %s\n\n`%s`\n\n", title, location)
+		if finding.Source != "" || finding.Sink != "" {
+			fmt.Fprintf(&b, "- Source: %s\n- Sink: %s\n", orDash(finding.Source), orDash(finding.Sink))
+		}
+		if finding.Description != "" {
+			fmt.Fprintf(&b, "\n%s\n", finding.Description)
+		}
+		if finding.Remediation != "" {
+			fmt.Fprintf(&b, "\n**Remediation:** %s\n", finding.Remediation)
+		}
+	}
+	return b.String()
+}
+
+func orDash(s string) string {
+	if strings.TrimSpace(s) == "" {
+		return "-"
+	}
+	return s
+}
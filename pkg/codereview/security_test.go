@@ -0,0 +1,99 @@
+package codereview
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSecurityFindings(t *testing.T) {
+	reply := "I traced the handlers.\n```json\n" + `{"findings": [
+		{"severity": "Low", "cwe": "532", "title": "Token logged", "file": "./pkg/auth/login.go", "line": 12},
+		{"severity": "CRITICAL", "cwe": "cwe-78", "title": "Command injection", "file": "cmd/run.go", "line": 40,
+		 "source": "query parameter name", "sink": "exec.Command(\"sh\", \"-c\", name)", "remediation": "Pass arguments without a shell"},
+		{"severity": "high", "title": "", "file": "x.go"}
+	]}` + "\n```"
+	findings, err := ParseSecurityFindings(reply)
+	if err != nil {
+		t.Fatalf("ParseSecurityFindings: %v", err)
+	}
+	if len(findings) != 2 {
+		t.Fatalf("expected 2 findings, got %+v", findings)
+	}
+	if f := findings[0]; f.Severity != SeverityCritical || f.CWE != "CWE-78" || f.File != "cmd/run.go" {
+		t.Errorf("unexpected first finding %+v", f)
+	}
+	if f := findings[1]; f.Severity != SeverityLow || f.CWE != "CWE-532" || f.File != "pkg/auth/login.go" {
+		t.Errorf("unexpected second finding %+v", f)
+	}
+
+	if findings, err := ParseSecurityFindings(`{"findings": []}`); err != nil || len(findings) != 0 {
+		t.Errorf("expected no findings, got %+v, %v", findings, err)
+	}
+	if _, err := ParseSecurityFindings("Looks fine to me."); err == nil {
+		t.Error("expected an error for a reply without JSON")
+	}
+}
+
+func TestRecordSecurityFindings(t *testing.T) {
+	root := t.TempDir()
+	first := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	_, err := RecordSecurityFindings(root, []string{"a.go", "b.go"}, []SecurityFinding{
+		{Severity: SeverityHigh, CWE: "CWE-89", Title: "SQL injection", File: "a.go", Line: 10},
+		{Severity: SeverityLow, CWE: "CWE-209", Title: "Error leaks path", File: "b.go", Line: 3},
+	}, first)
+	if err != nil {
+		t.Fatalf("RecordSecurityFindings: %v", err)
+	}
+
+	// Reviewing a.go again keeps b.go's finding, keeps a.go's still-reported
+	// finding with its first sighting, and drops nothing else
+	second := first.Add(24 * time.Hour)
+	stored, err := RecordSecurityFindings(root, []string{"a.go"}, []SecurityFinding{
+		{Severity: SeverityHigh, CWE: "CWE-89", Title: "SQL injection", File: "a.go", Line: 14},
+		{Severity: SeverityCritical, CWE: "CWE-78", Title: "Command injection", File: "a.go", Line: 30},
+	}, second)
+	if err != nil {
+		t.Fatalf("RecordSecurityFindings: %v", err)
+	}
+	loaded, err := LoadSecurityFindings(root)
+	if err != nil || len(loaded) != len(stored) || len(stored) != 3 {
+		t.Fatalf("stored %+v, loaded %+v, %v", stored, loaded, err)
+	}
+	if loaded[0].CWE != "CWE-78" || !loaded[0].FirstSeen.Equal(second) {
+		t.Errorf("expected the new critical finding first, got %+v", loaded[0])
+	}
+	if loaded[1].Line != 14 || !loaded[1].FirstSeen.Equal(first) || !loaded[1].LastSeen.Equal(second) {
+		t.Errorf("expected the SQL injection finding to keep its first sighting, got %+v", loaded[1])
+	}
+	if loaded[2].File != "b.go" {
+		t.Errorf("expected b.go's finding to be kept, got %+v", loaded[2])
+	}
+
+	// A finding that is no longer reported for a reviewed file is resolved
+	stored, err = RecordSecurityFindings(root, []string{"a.go"}, nil, second)
+	if err != nil || len(stored) != 1 || stored[0].File != "b.go" {
+		t.Fatalf("expected only b.go's finding, got %+v, %v", stored, err)
+	}
+}
+
+func TestRenderSecurityReport(t *testing.T) {
+	report := RenderSecurityReport([]SecurityFinding{
+		{Severity: SeverityHigh, CWE: "CWE-22", Title: "Path traversal", File: "srv/files.go", Line: 8,
+			Source: "URL path", Sink: "os.Open", Remediation: "Check the path stays under the root"},
+		{Severity: SeverityLow, Title: "Verbose errors", File: "srv/errors.go"},
+	})
+	for _, want := range []string{
+		"2 finding(s): 1 high, 1 low",
+		"## High\n\n### CWE-22: Path traversal\n\n`srv/files.go:8`\n\n- Source: URL path\n- Sink: os.Open\n",
+		"**Remediation:** Check the path stays under the root",
+		"## Low\n\n### Verbose errors\n\n`srv/errors.go`\n",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report missing %q:\n%s", want, report)
+		}
+	}
+	if !strings.Contains(RenderSecurityReport(nil), "No security findings.") {
+		t.Error("expected an empty report to say so")
+	}
+}
@@ -13,7 +13,6 @@ const (
 	maxReviewPromptBytes        = 700 * 1024
 	maxReviewMetadataFieldBytes = 16 * 1024
 	maxReviewRelatedFiles       = 50
-	estimatedCharsPerToken      = 4
 )
 
 // ReviewContext represents the context for a code review request
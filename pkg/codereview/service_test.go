@@ -7,6 +7,7 @@ import (
 	api "github.com/alantheprice/ledit/pkg/agent_api"
 	"github.com/alantheprice/ledit/pkg/configuration"
 	"github.com/alantheprice/ledit/pkg/factory"
+	"github.com/alantheprice/ledit/pkg/tokenizer"
 	"github.com/alantheprice/ledit/pkg/types"
 	"github.com/alantheprice/ledit/pkg/utils"
 )
@@ -71,7 +72,7 @@ func TestPrepareReviewContextForPromptTruncatesDiffAsLastResort(t *testing.T) {
 	}
 }
 
-func TestReviewPromptByteBudgetUsesModelContextLimit(t *testing.T) {
+func TestReviewPromptTokenBudgetUsesModelContextLimit(t *testing.T) {
 	cfg := &configuration.Config{}
 	logger := utils.GetLogger(true)
 	service := NewCodeReviewService(cfg, logger)
@@ -82,10 +83,35 @@ func TestReviewPromptByteBudgetUsesModelContextLimit(t *testing.T) {
 		AgentClient: client,
 	}
 
-	budget := service.reviewPromptByteBudget(ctx)
-	expected := (4096 / 2) * estimatedCharsPerToken
-	if budget != expected {
-		t.Fatalf("expected model-aware budget %d, got %d", expected, budget)
+	budget, tok := service.reviewPromptTokenBudget(ctx)
+	if budget != 4096/2 {
+		t.Fatalf("expected model-aware budget %d, got %d", 4096/2, budget)
+	}
+	if tok.Name() != tokenizer.ForModel(client.GetModel()).Name() {
+		t.Fatalf("expected the tokenizer for %s, got %s", client.GetModel(), tok.Name())
+	}
+}
+
+func TestPrepareReviewContextForPromptTruncatesDiffByTokens(t *testing.T) {
+	cfg := &configuration.Config{}
+	logger := utils.GetLogger(true)
+	service := NewCodeReviewService(cfg, logger)
+	client := &fixedLimitClient{limit: 16000}
+
+	// Short tokens: far more tokens per byte than a fixed 4:1 ratio assumes
+	largeDiff := strings.Repeat("diff --git a/a.go b/a.go\n@@\n+x := []int{1, 2, 3, 4, 5, 6, 7, 8}\n", 2000)
+	ctx := &ReviewContext{
+		Diff:        largeDiff,
+		AgentClient: client,
+	}
+
+	prepared := service.prepareReviewContextForPrompt(ctx)
+	if len(prepared.Diff) >= len(largeDiff) {
+		t.Fatal("expected the diff to be truncated to the model's context window")
+	}
+	budget, tok := service.reviewPromptTokenBudget(ctx)
+	if tokens := tok.Count(service.buildEnhancedReviewPrompt(prepared, false)); tokens > budget {
+		t.Fatalf("expected the prompt to fit in %d tokens, got %d", budget, tokens)
 	}
 }
 
@@ -52,6 +52,9 @@ var knownProviderDisplayNames = map[string]string{
 	"lmstudio":     "LM Studio",
 	"mistral":      "Mistral",
 	"jinaai":       "JinaAI",
+	"github":       "GitHub",
+	"gitlab":       "GitLab",
+	"jira":         "Jira",
 }
 
 // keyValidationMutex protects ValidateAndSaveAPIKey from concurrent access.
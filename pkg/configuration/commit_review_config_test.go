@@ -348,3 +348,17 @@ func TestReviewModelFallbackUsesReviewProvider(t *testing.T) {
 	result := cfg.GetReviewModel()
 	assert.Equal(t, "deepseek-v3.1:671b", result)
 }
+
+// TestGetCommitStyle_NormalizesValues tests that GetCommitStyle accepts conventional aliases and defaults otherwise
+func TestGetCommitStyle_NormalizesValues(t *testing.T) {
+	for value, want := range map[string]string{
+		"":                     "default",
+		"default":              "default",
+		"Conventional":         "conventional",
+		"conventional-commits": "conventional",
+		"gitmoji":              "default",
+	} {
+		cfg := &Config{CommitStyle: value}
+		assert.Equal(t, want, cfg.GetCommitStyle(), "commit_style %q", value)
+	}
+}
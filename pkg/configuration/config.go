@@ -13,6 +13,7 @@ import (
 
 	"github.com/alantheprice/ledit/pkg/agent_providers"
 	"github.com/alantheprice/ledit/pkg/mcp"
+	modelsettings "github.com/alantheprice/ledit/pkg/model_settings"
 	"github.com/alantheprice/ledit/pkg/personas"
 )
 
@@ -54,10 +55,25 @@ type Config struct {
 	// When false, ALL personas must use the git tool for write operations.
 	AllowOrchestratorGitWrite bool `json:"allow_orchestrator_git_write,omitempty"`
 
+	// SecretRedactionEnabled controls whether tool output (including file contents)
+	// is scanned for secrets and redacted before it is sent to an LLM provider.
+	// Defaults to true when unset.
+	SecretRedactionEnabled *bool `json:"secret_redaction_enabled,omitempty"`
+
+	// DependencyInstallProposals controls whether imports missing from go.mod or
+	// package.json are detected after file writes and an install command is
+	// proposed for approval. Defaults to true when unset.
+	DependencyInstallProposals *bool `json:"dependency_install_proposals,omitempty"`
+
 	// ResourceDirectory stores captured web/vision resources relative to the current working directory.
 	// This can be overridden at runtime with --resource-directory.
 	ResourceDirectory string `json:"resource_directory,omitempty"`
 
+	// ModelCapabilities overrides the built-in model capability registry
+	// (context window, native tool calls, vision, streaming, cost table) for
+	// matching models, e.g. custom or self-hosted ones.
+	ModelCapabilities []modelsettings.CapabilityProfile `json:"model_capabilities,omitempty"`
+
 	// ReasoningEffort sets a global default reasoning effort for chat requests.
 	// Valid values: "low", "medium", "high". Empty means automatic selection.
 	ReasoningEffort string `json:"reasoning_effort,omitempty"`
@@ -81,6 +97,33 @@ type Config struct {
 	// API Timeout Configuration (in seconds)
 	APITimeouts *APITimeoutConfig `json:"api_timeouts,omitempty"`
 
+	// Rate Limits by provider name, shared by the agent and its subagents
+	RateLimits map[string]RateLimitConfig `json:"rate_limits,omitempty"`
+
+	// Response Cache Configuration (opt-in)
+	ResponseCache *ResponseCacheConfig `json:"response_cache,omitempty"`
+
+	// File Read Limits for large and binary files
+	FileRead *FileReadConfig `json:"file_read,omitempty"`
+
+	// Clarification pre-flight for ambiguous requests (opt-in)
+	Clarification *ClarificationConfig `json:"clarification,omitempty"`
+
+	// Model price refresh and per-model price overrides for cost reporting
+	Pricing *PricingConfig `json:"pricing,omitempty"`
+
+	// Tokenizer files by model name prefix, for exact token counts
+	Tokenizers map[string]string `json:"tokenizers,omitempty"`
+
+	// Remote host that runs shell commands, builds and tests over SSH
+	Remote *RemoteConfig `json:"remote,omitempty"`
+
+	// Run shell commands, builds and tests in the project's devcontainer
+	Devcontainer *DevcontainerConfig `json:"devcontainer,omitempty"`
+
+	// Local provider and model used by --offline
+	Offline *OfflineConfig `json:"offline,omitempty"`
+
 	// Custom Providers Configuration
 	CustomProviders map[string]CustomProviderConfig `json:"custom_providers,omitempty"`
 
@@ -91,6 +134,13 @@ type Config struct {
 	// Change History Configuration
 	HistoryScope string `json:"history_scope,omitempty"` // "project" or "global"
 
+	// Terminal Output Configuration
+	ColorPalette  string `json:"color_palette,omitempty"`  // "default", "light", "solarized", "colorblind" or "no-color"; NO_COLOR disables colors entirely
+	PlainOutput   bool   `json:"plain_output,omitempty"`   // Print streamed responses as raw text instead of rendered markdown
+	Mouse         *bool  `json:"mouse,omitempty"`          // Mouse reporting in the interactive console: wheel scrolling, click focus, opening clicked paths (default: true)
+	Hyperlinks    *bool  `json:"hyperlinks,omitempty"`     // Show file:line references in output as clickable OSC 8 links (default: true)
+	EditorCommand string `json:"editor_command,omitempty"` // Command opening files from the output, e.g. "code -g {file}:{line}" (default: $VISUAL or $EDITOR)
+
 	// Self-Review Gate Configuration
 	SelfReviewGateMode string `json:"self_review_gate_mode,omitempty"` // "off", "code", or "always"
 
@@ -100,15 +150,33 @@ type Config struct {
 	SubagentTypes          map[string]SubagentType `json:"subagent_types,omitempty"`    // Named subagent personas (coder, tester, etc.)
 	SubagentMaxParallel    int                     `json:"subagent_max_parallel,omitempty"`     // Maximum number of parallel subagents (default: 2)
 	SubagentParallelEnabled *bool                   `json:"subagent_parallel_enabled,omitempty"` // Enable/disable parallel subagent execution (default: true)
+	SubagentMaxDepth       int                     `json:"subagent_max_depth,omitempty"`        // How deeply subagents may nest (default: 1, only the primary agent spawns subagents)
+
+	// shadowedSubagentTypes keeps the personas a project persona replaced, so
+	// Save persists them instead of the project's definitions.
+	shadowedSubagentTypes map[string]SubagentType
+
+	// layers holds the settings of the project config, LEDIT_CONFIG_*
+	// variables and --set flags applied over config.json; see layers.go.
+	layers *configLayers
 
 	// Commit Configuration
 	CommitProvider string `json:"commit_provider,omitempty"` // Provider for commit message generation (defaults to LastUsedProvider)
 	CommitModel    string `json:"commit_model,omitempty"`    // Model for commit message generation (defaults to provider's default model)
+	CommitStyle    string `json:"commit_style,omitempty"`    // Commit message convention: "default" or "conventional" (Conventional Commits)
+
+	// Pull Request Configuration
+	ForgeHosts map[string]string `json:"forge_hosts,omitempty"` // Self-managed forge hosts by kind, e.g. {"git.example.com": "gitlab"}
+	Jira       *JiraConfig       `json:"jira,omitempty"`        // Jira site that work-on reads issues from
 
 	// Review Configuration
 	ReviewProvider string `json:"review_provider,omitempty"` // Provider for review commands (defaults to LastUsedProvider)
 	ReviewModel    string `json:"review_model,omitempty"`    // Model for review commands (defaults to provider's default model)
 
+	// Model Escalation Configuration
+	EscalationProvider string `json:"escalation_provider,omitempty"` // Provider of the escalation model (defaults to the current provider)
+	EscalationModel    string `json:"escalation_model,omitempty"`    // Stronger model used for a todo the current model failed twice (empty disables escalation)
+
 	// PDF OCR Configuration
 	PDFOCREnabled    bool   `json:"pdf_ocr_enabled,omitempty"`    // Enable PDF OCR processing
 	PDFOCRProvider   string `json:"pdf_ocr_provider,omitempty"`   // Provider for PDF OCR (e.g., "ollama", "openai", "deepinfra")
@@ -135,6 +203,90 @@ type APITimeoutConfig struct {
 	CommitMessageTimeoutSec int `json:"commit_message_timeout_sec,omitempty"` // Timeout for commit message generation (default: 300)
 }
 
+// RateLimitConfig caps the LLM requests sent to one provider. Zero fields
+// are unlimited.
+type RateLimitConfig struct {
+	RequestsPerMinute int `json:"requests_per_minute,omitempty"`
+	TokensPerMinute   int `json:"tokens_per_minute,omitempty"`
+}
+
+// ResponseCacheConfig serves identical LLM requests from disk instead of
+// sending them again.
+type ResponseCacheConfig struct {
+	Enabled   bool `json:"enabled,omitempty"`
+	TTLHours  int  `json:"ttl_hours,omitempty"`   // How long responses are reused (default: 168)
+	MaxSizeMB int  `json:"max_size_mb,omitempty"` // Oldest responses are evicted past this size (default: 100)
+}
+
+// RemoteConfig runs the agent's shell commands on another host over SSH,
+// with the workspace synced to the directory it maps to there.
+type RemoteConfig struct {
+	Host         string            `json:"host"`                    // [user@]host, or a Host alias from ~/.ssh/config
+	Port         int               `json:"port,omitempty"`          // SSH port (default: 22, or ~/.ssh/config)
+	IdentityFile string            `json:"identity_file,omitempty"` // Private key (default: the SSH agent and ~/.ssh/config)
+	Paths        map[string]string `json:"paths,omitempty"`         // Local directory -> directory on the host (default: home -> remote home)
+	Exclude      []string          `json:"exclude,omitempty"`       // Directory names never synced (default: .git, .ledit, node_modules, .venv, __pycache__, .cache)
+	Disabled     bool              `json:"disabled,omitempty"`      // Keep the settings but run commands locally
+}
+
+// DevcontainerConfig runs the agent's shell commands inside the container
+// described by a workspace's .devcontainer/devcontainer.json.
+type DevcontainerConfig struct {
+	Enabled bool   `json:"enabled,omitempty"` // Use the devcontainer in workspaces that have one
+	Runtime string `json:"runtime,omitempty"` // docker or podman (default: the first installed)
+}
+
+// OfflineConfig chooses the local model --offline switches to.
+type OfflineConfig struct {
+	Provider string `json:"provider,omitempty"` // A provider running on this machine (default: ollama-local)
+	Model    string `json:"model,omitempty"`    // Model to use (default: the provider's model in provider_models)
+}
+
+// JiraConfig locates the Jira site of the project. The API token is stored
+// like other credentials, under "jira" (JIRA_API_TOKEN).
+type JiraConfig struct {
+	URL   string `json:"url,omitempty"`   // Site URL, e.g. https://acme.atlassian.net
+	Email string `json:"email,omitempty"` // Account of a Jira Cloud API token; leave empty for a Data Center personal access token
+}
+
+// FileReadConfig bounds what read_file returns. Zero fields use the defaults.
+type FileReadConfig struct {
+	MaxKB              int `json:"max_kb,omitempty"`               // Larger files are previewed as their first and last lines (default: 80, or LEDIT_READ_FILE_MAX_BYTES)
+	RangeMaxKB         int `json:"range_max_kb,omitempty"`         // Most content a view_range read returns (default: 4 × max_kb)
+	BinaryPreviewBytes int `json:"binary_preview_bytes,omitempty"` // Bytes of a binary file shown as a hex dump (default: 256)
+}
+
+// Clarification defaults; at most MaxClarificationQuestions are ever asked.
+const (
+	DefaultClarificationThreshold = 0.6
+	DefaultClarificationMinWords  = 6
+	MaxClarificationQuestions     = 3
+)
+
+// ClarificationConfig controls the pre-flight that scores a request for
+// ambiguity before the agent starts on it and asks clarifying questions when
+// the score is at or above the threshold. Zero fields use the defaults.
+type ClarificationConfig struct {
+	Enabled      bool    `json:"enabled,omitempty"`
+	Threshold    float64 `json:"threshold,omitempty"`     // Ambiguity score from 0 to 1 at which questions are asked (default: 0.6)
+	MaxQuestions int     `json:"max_questions,omitempty"` // Questions asked at most, up to 3 (default: 3)
+	MinWords     int     `json:"min_words,omitempty"`     // Shorter requests are not checked (default: 6)
+}
+
+// DefaultPricingRefreshHours is how old cached model prices may get before
+// they are refreshed.
+const DefaultPricingRefreshHours = 24
+
+// PricingConfig controls the prices used to estimate request costs when a
+// provider doesn't report them. Refreshed prices replace the built-in ones;
+// Models entries, keyed by "provider/model" or a model name, replace both.
+type PricingConfig struct {
+	AutoRefresh  *bool                               `json:"auto_refresh,omitempty"`  // Refresh stale prices in the background (default: true)
+	RefreshHours int                                 `json:"refresh_hours,omitempty"` // Age at which cached prices are refreshed (default: 24)
+	SourceURL    string                              `json:"source_url,omitempty"`    // Price source (default: OpenRouter's model list)
+	Models       map[string]modelsettings.ModelPrice `json:"models,omitempty"`        // Prices in USD per million tokens; zero makes a model free
+}
+
 // MCPConfig moved to pkg/mcp package for consolidation
 // Import from there: github.com/alantheprice/ledit/pkg/mcp
 
@@ -163,6 +315,7 @@ type CustomProviderConfig struct {
 	VisionModel            string                      `json:"vision_model,omitempty"`             // Vision-capable model for this provider
 	VisionFallbackProvider string                      `json:"vision_fallback_provider,omitempty"` // Optional fallback provider for vision
 	VisionFallbackModel    string                      `json:"vision_fallback_model,omitempty"`    // Optional fallback model for vision provider
+	StructuredOutputs      string                      `json:"structured_outputs,omitempty"`       // Optional structured output mode: json_schema, grammar (llama.cpp servers), or none
 }
 
 // SubagentType defines a specialized subagent persona with its own configuration
@@ -177,6 +330,8 @@ type SubagentType struct {
 	AllowedTools     []string `json:"allowed_tools,omitempty"`      // Optional explicit tool allowlist for focused persona behavior
 	Aliases          []string `json:"aliases,omitempty"`            // Optional aliases (e.g., "web-scraper")
 	Enabled          bool     `json:"enabled"`                      // Whether this subagent type is available for use
+	MaxTokens        int      `json:"max_tokens,omitempty"`         // Optional token budget per run (0 uses LEDIT_SUBAGENT_MAX_TOKENS or the default)
+	Source           string   `json:"-"`                            // PersonaSourceProject for personas defined in .ledit/personas/
 }
 
 // Skill defines an Agent Skill that can be loaded into context
@@ -300,9 +455,12 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("get config path for default: %w", err)
 	}
 
-	// If config doesn't exist, return new default config
+	// If config doesn't exist, return new default config with the project's personas
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		return NewConfig(), nil
+		config := NewConfig()
+		config.applyLayers(nil)
+		discoverProjectPersonas(config)
+		return config, nil
 	}
 
 	// Migrate any api_key values from config.json custom_providers to the credential store
@@ -320,6 +478,7 @@ func Load() (*Config, error) {
 	if err := json.Unmarshal(data, &config); err != nil {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
+	config.applyLayers(data)
 
 	// Ensure maps are initialized
 	if config.ProviderModels == nil {
@@ -359,6 +518,9 @@ func Load() (*Config, error) {
 	// Discover project-specific skills from .ledit/skills/
 	discoverProjectSkills(&config)
 
+	// Load project-specific personas from .ledit/personas/
+	discoverProjectPersonas(&config)
+
 	// Set version if not present
 	if config.Version == "" {
 		config.Version = ConfigVersion
@@ -421,6 +583,7 @@ func Load() (*Config, error) {
 	// Check if enable_zsh_command_detection exists in the raw JSON
 	var rawConfig map[string]interface{}
 	if err := json.Unmarshal(data, &rawConfig); err == nil {
+		mergeSettings(rawConfig, config.layers.overlay())
 		if _, exists := rawConfig["enable_zsh_command_detection"]; !exists {
 			// Field doesn't exist in config file, apply default
 			config.EnableZshCommandDetection = true
@@ -459,7 +622,8 @@ func (c *Config) Save() error {
 	persisted := *c
 	persisted.Version = ConfigVersion
 	persisted.CustomProviders = nil
-	data, err := json.MarshalIndent(&persisted, "", "  ")
+	persisted.SubagentTypes = c.persistedSubagentTypes()
+	data, err := c.persistedSettings(&persisted)
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
@@ -620,6 +784,17 @@ func (c *Config) SetCommitModel(model string) {
 	c.CommitModel = model
 }
 
+// GetCommitStyle returns the commit message convention, "conventional" or
+// "default". Unknown values fall back to "default".
+func (c *Config) GetCommitStyle() string {
+	switch strings.ToLower(strings.TrimSpace(c.CommitStyle)) {
+	case "conventional", "conventional-commits", "conventionalcommits":
+		return "conventional"
+	default:
+		return "default"
+	}
+}
+
 // GetReviewProvider returns the configured provider for review commands
 // If not explicitly set, falls back to the last used provider
 func (c *Config) GetReviewProvider() string {
@@ -658,6 +833,13 @@ func (c *Config) SetReviewModel(model string) {
 	c.ReviewModel = model
 }
 
+// GetEscalation returns the provider and model a failing todo is escalated
+// to. The provider is empty when the current provider should be used, and
+// the model is empty when escalation is disabled.
+func (c *Config) GetEscalation() (provider, model string) {
+	return strings.TrimSpace(c.EscalationProvider), strings.TrimSpace(c.EscalationModel)
+}
+
 // GetSubagentType retrieves a subagent type configuration by ID
 // Returns nil if the subagent type doesn't exist or is disabled
 func (c *Config) GetSubagentType(id string) *SubagentType {
@@ -802,6 +984,7 @@ func defaultSubagentTypes() map[string]SubagentType {
 			AllowedTools:     append([]string{}, definition.AllowedTools...),
 			Aliases:          append([]string{}, definition.Aliases...),
 			Enabled:          definition.Enabled,
+			MaxTokens:        definition.MaxTokens,
 		}
 	}
 
@@ -1111,6 +1294,93 @@ func (c *Config) GetSubagentMaxParallel() int {
 	return 2 // Default
 }
 
+// GetSecretRedactionEnabled returns whether secrets are redacted from content sent to providers
+// Defaults to true if not explicitly set (nil pointer)
+func (c *Config) GetSecretRedactionEnabled() bool {
+	if c.SecretRedactionEnabled == nil {
+		return true // default when not configured
+	}
+	return *c.SecretRedactionEnabled
+}
+
+// GetDependencyInstallProposalsEnabled returns whether missing dependencies are proposed for installation
+// Defaults to true if not explicitly set (nil pointer)
+func (c *Config) GetDependencyInstallProposalsEnabled() bool {
+	if c.DependencyInstallProposals == nil {
+		return true // default when not configured
+	}
+	return *c.DependencyInstallProposals
+}
+
+// GetPricing returns the pricing settings with the defaults applied.
+func (c *Config) GetPricing() PricingConfig {
+	var pricing PricingConfig
+	if c.Pricing != nil {
+		pricing = *c.Pricing
+	}
+	if pricing.AutoRefresh == nil {
+		enabled := true
+		pricing.AutoRefresh = &enabled
+	}
+	if pricing.RefreshHours <= 0 {
+		pricing.RefreshHours = DefaultPricingRefreshHours
+	}
+	if strings.TrimSpace(pricing.SourceURL) == "" {
+		pricing.SourceURL = modelsettings.DefaultPricingURL
+	}
+	return pricing
+}
+
+// ResolveModelCapabilities resolves provider and model in the capability
+// registry with the model_capabilities overrides applied, then applies the
+// pricing.models price for the model, if any.
+func (c *Config) ResolveModelCapabilities(provider, model string) modelsettings.ModelCapabilities {
+	caps := modelsettings.ResolveModelCapabilities(provider, model, c.ModelCapabilities...)
+	if price, ok := modelsettings.LookupPriceOverride(c.GetPricing().Models, provider, model); ok {
+		caps.InputCostPerMTok, caps.OutputCostPerMTok = price.InputPerMTok, price.OutputPerMTok
+		caps.PriceSource = "config"
+	}
+	return caps
+}
+
+// GetClarification returns the clarification pre-flight settings with the
+// defaults filled in. The pre-flight is off unless enabled.
+func (c *Config) GetClarification() ClarificationConfig {
+	var clarification ClarificationConfig
+	if c.Clarification != nil {
+		clarification = *c.Clarification
+	}
+	if clarification.Threshold <= 0 || clarification.Threshold > 1 {
+		clarification.Threshold = DefaultClarificationThreshold
+	}
+	if clarification.MaxQuestions <= 0 || clarification.MaxQuestions > MaxClarificationQuestions {
+		clarification.MaxQuestions = MaxClarificationQuestions
+	}
+	if clarification.MinWords <= 0 {
+		clarification.MinWords = DefaultClarificationMinWords
+	}
+	return clarification
+}
+
+// GetMouseEnabled returns whether the interactive console takes mouse input.
+// Defaults to true if not explicitly set (nil pointer)
+func (c *Config) GetMouseEnabled() bool {
+	if c.Mouse == nil {
+		return true
+	}
+	return *c.Mouse
+}
+
+// GetHyperlinksEnabled returns whether file references in output are shown
+// as terminal hyperlinks.
+// Defaults to true if not explicitly set (nil pointer)
+func (c *Config) GetHyperlinksEnabled() bool {
+	if c.Hyperlinks == nil {
+		return true
+	}
+	return *c.Hyperlinks
+}
+
 // GetSubagentParallelEnabled returns whether parallel subagent execution is enabled
 // Defaults to true if not explicitly set (nil pointer)
 func (c *Config) GetSubagentParallelEnabled() bool {
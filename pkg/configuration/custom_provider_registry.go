@@ -16,6 +16,7 @@ import (
 	api "github.com/alantheprice/ledit/pkg/agent_api"
 	providers "github.com/alantheprice/ledit/pkg/agent_providers"
 	"github.com/alantheprice/ledit/pkg/credentials"
+	modelsettings "github.com/alantheprice/ledit/pkg/model_settings"
 )
 
 const ProvidersDirName = "providers"
@@ -167,6 +168,12 @@ func NormalizeCustomProviderConfig(cfg CustomProviderConfig) (CustomProviderConf
 	cfg.VisionFallbackProvider = strings.TrimSpace(cfg.VisionFallbackProvider)
 	cfg.VisionFallbackModel = strings.TrimSpace(cfg.VisionFallbackModel)
 	cfg.ToolCalls = normalizeUniqueStrings(cfg.ToolCalls)
+	cfg.StructuredOutputs = strings.ToLower(strings.TrimSpace(cfg.StructuredOutputs))
+	switch cfg.StructuredOutputs {
+	case "", modelsettings.StructuredOutputsJSONSchema, modelsettings.StructuredOutputsGrammar, modelsettings.StructuredOutputsNone:
+	default:
+		return CustomProviderConfig{}, fmt.Errorf("unknown structured_outputs %q (want json_schema, grammar, or none)", cfg.StructuredOutputs)
+	}
 
 	// Initialize model context sizes map if nil
 	if cfg.ModelContextSizes == nil {
@@ -325,6 +332,7 @@ func (c CustomProviderConfig) ToProviderConfig() (*providers.ProviderConfig, err
 			OutputTokenCost: 0.002,
 			Currency:        "USD",
 		},
+		StructuredOutputs: normalized.StructuredOutputs,
 	}, nil
 }
 
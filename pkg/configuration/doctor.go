@@ -0,0 +1,175 @@
+package configuration
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Issue severities reported by Diagnose.
+const (
+	IssueError   = "error"   // the setting is invalid or cannot work
+	IssueWarning = "warning" // the setting has no effect or is likely a mistake
+)
+
+// Issue is a problem Diagnose found with a setting or a combination of
+// settings.
+type Issue struct {
+	Severity string
+	Key      string // the setting concerned, e.g. "subagent_provider"
+	Message  string
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%s: %s: %s", i.Severity, i.Key, i.Message)
+}
+
+// Diagnose checks the effective config for invalid values and invalid
+// combinations of settings, errors first.
+func Diagnose(c *Config) []Issue {
+	var issues []Issue
+	add := func(severity, key, format string, args ...interface{}) {
+		issues = append(issues, Issue{Severity: severity, Key: key, Message: fmt.Sprintf(format, args...)})
+	}
+
+	for _, err := range c.LayerErrors() {
+		add(IssueError, "layers", "%v", err)
+	}
+
+	// Providers and models
+	provider := strings.TrimSpace(c.LastUsedProvider)
+	if provider == "" {
+		add(IssueWarning, "last_used_provider", "no provider selected; one is picked from API keys in the environment at startup")
+	} else if _, err := MapProviderStringToClientType(c, provider); err != nil {
+		add(IssueError, "last_used_provider", "unknown provider %q", provider)
+	} else if RequiresAPIKey(provider) && !HasProviderAuth(provider) {
+		add(IssueError, "last_used_provider", "no API key for %s; set %s or add one with /providers", provider, GetProviderEnvVarName(provider))
+	}
+	for _, key := range []string{"subagent_provider", "commit_provider", "review_provider", "escalation_provider", "pdf_ocr_provider"} {
+		value, _ := c.Setting(key)
+		if value == "" {
+			continue
+		}
+		if _, err := MapProviderStringToClientType(c, value); err != nil {
+			add(IssueError, key, "unknown provider %q", value)
+		}
+	}
+	for _, pair := range [][2]string{{"subagent_model", "subagent_provider"}, {"commit_model", "commit_provider"}, {"review_model", "review_provider"}} {
+		model, _ := c.Setting(pair[0])
+		providerSet, _ := c.Setting(pair[1])
+		if model != "" && providerSet == "" && provider == "" {
+			add(IssueWarning, pair[0], "set without %s or last_used_provider, so the provider it belongs to is unknown", pair[1])
+		}
+	}
+	for _, name := range sortedKeys(c.ProviderModels) {
+		if _, err := MapProviderStringToClientType(c, name); err != nil {
+			add(IssueWarning, "provider_models."+name, "model set for unknown provider %q", name)
+		}
+	}
+	if c.Offline != nil || IsOffline() {
+		if _, err := OfflineOverrides(c); err != nil {
+			add(IssueError, "offline.provider", "%s", strings.TrimPrefix(err.Error(), "offline.provider: "))
+		}
+	}
+	switch strings.ToLower(strings.TrimSpace(c.ReasoningEffort)) {
+	case "", "low", "medium", "high":
+		if c.ReasoningEffort != "" && c.DisableThinking {
+			add(IssueWarning, "reasoning_effort", "has no effect while disable_thinking is true")
+		}
+	default:
+		add(IssueError, "reasoning_effort", "invalid value %q (allowed: low, medium, high)", c.ReasoningEffort)
+	}
+
+	// Budgets
+	if c.SubagentMaxParallel < 0 {
+		add(IssueError, "subagent_max_parallel", "must not be negative")
+	} else if c.SubagentMaxParallel > 1 && !c.GetSubagentParallelEnabled() {
+		add(IssueWarning, "subagent_max_parallel", "has no effect while subagent_parallel_enabled is false")
+	}
+	if c.SubagentMaxDepth < 0 {
+		add(IssueError, "subagent_max_depth", "must not be negative")
+	}
+	if t := c.APITimeouts; t != nil {
+		if t.OverallTimeoutSec > 0 && t.FirstChunkTimeoutSec > t.OverallTimeoutSec {
+			add(IssueWarning, "api_timeouts.first_chunk_timeout_sec", "is longer than overall_timeout_sec (%ds), which ends the request first", t.OverallTimeoutSec)
+		}
+		if t.ConnectionTimeoutSec < 0 || t.FirstChunkTimeoutSec < 0 || t.ChunkTimeoutSec < 0 || t.OverallTimeoutSec < 0 {
+			add(IssueError, "api_timeouts", "timeouts must not be negative")
+		}
+	}
+	for _, name := range sortedKeys(c.RateLimits) {
+		if limit := c.RateLimits[name]; limit.RequestsPerMinute < 0 || limit.TokensPerMinute < 0 {
+			add(IssueError, "rate_limits."+name, "limits must not be negative")
+		}
+	}
+	if r := c.FileRead; r != nil && r.MaxKB > 0 && r.RangeMaxKB > 0 && r.RangeMaxKB < r.MaxKB {
+		add(IssueWarning, "file_read.range_max_kb", "is below max_kb (%d), so ranged reads return less than whole-file reads", r.MaxKB)
+	}
+	if cl := c.Clarification; cl != nil {
+		if cl.Threshold < 0 || cl.Threshold > 1 {
+			add(IssueError, "clarification.threshold", "must be between 0 and 1")
+		}
+		if cl.MaxQuestions > MaxClarificationQuestions {
+			add(IssueWarning, "clarification.max_questions", "at most %d questions are asked", MaxClarificationQuestions)
+		}
+	}
+
+	// Approvals and automation
+	if _, ok := NormalizeSelfReviewGateMode(c.SelfReviewGateMode); !ok {
+		add(IssueError, "self_review_gate_mode", "invalid value %q (allowed: off, code, always)", c.SelfReviewGateMode)
+	}
+	if c.AutoExecuteDetectedCommands && !c.EnableZshCommandDetection {
+		add(IssueWarning, "auto_execute_detected_commands", "has no effect while enable_zsh_command_detection is false")
+	}
+	if c.SkipPrompt && c.AllowOrchestratorGitWrite {
+		add(IssueWarning, "allow_orchestrator_git_write", "lets unattended (skip_prompt) runs commit and push through shell commands")
+	}
+
+	// Execution backends
+	remoteEnabled := c.Remote != nil && !c.Remote.Disabled && strings.TrimSpace(c.Remote.Host) != ""
+	if c.Remote != nil && !c.Remote.Disabled && strings.TrimSpace(c.Remote.Host) == "" {
+		add(IssueError, "remote.host", "a remote section needs a host")
+	}
+	if d := c.Devcontainer; d != nil {
+		if remoteEnabled && d.Enabled {
+			add(IssueError, "devcontainer.enabled", "remote and devcontainer execution cannot both be enabled")
+		}
+		switch d.Runtime {
+		case "", "docker", "podman":
+		default:
+			add(IssueError, "devcontainer.runtime", "invalid value %q (allowed: docker, podman)", d.Runtime)
+		}
+	}
+
+	// Other values
+	switch c.HistoryScope {
+	case "", "project", "global":
+	default:
+		add(IssueError, "history_scope", "invalid value %q (allowed: project, global)", c.HistoryScope)
+	}
+	switch strings.ToLower(strings.TrimSpace(c.CommitStyle)) {
+	case "", "default", "conventional", "conventional-commits", "conventionalcommits":
+	default:
+		add(IssueWarning, "commit_style", "unknown value %q is treated as default (allowed: default, conventional)", c.CommitStyle)
+	}
+	if c.PDFOCREnabled && (c.PDFOCRProvider == "" || c.PDFOCRModel == "") {
+		add(IssueError, "pdf_ocr_enabled", "PDF OCR needs pdf_ocr_provider and pdf_ocr_model")
+	}
+	if rc := c.ResponseCache; rc != nil && (rc.TTLHours < 0 || rc.MaxSizeMB < 0) {
+		add(IssueError, "response_cache", "ttl_hours and max_size_mb must not be negative")
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].Severity == IssueError && issues[j].Severity != IssueError
+	})
+	return issues
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
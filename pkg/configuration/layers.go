@@ -0,0 +1,545 @@
+package configuration
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Configuration layers, lowest precedence first. A setting comes from the
+// highest layer that sets it.
+const (
+	LayerDefault = "default"
+	LayerUser    = "user"    // config.json in the config directory
+	LayerProject = "project" // .ledit/config.yaml in the working directory
+	LayerEnv     = "env"     // LEDIT_CONFIG_* environment variables
+	LayerFlag    = "flag"    // --set key=value
+)
+
+// ProjectConfigFile is the project config's path relative to the project
+// root. It uses the keys of config.json, written as YAML.
+var ProjectConfigFile = filepath.Join(ConfigDirName, "config.yaml")
+
+// EnvPrefix starts the environment variables that override settings. The
+// rest of the name is the setting's key in upper case, with __ between
+// nested keys: LEDIT_CONFIG_API_TIMEOUTS__OVERALL_TIMEOUT_SEC=600.
+const EnvPrefix = "LEDIT_CONFIG_"
+
+var (
+	flagOverridesMu sync.RWMutex
+	flagOverrides   map[string]interface{}
+
+	layerWarningOnce sync.Once
+)
+
+// configLayers records what each layer above config.json set, so the
+// sources of settings can be reported and Save writes back only what the
+// user layer holds.
+type configLayers struct {
+	user    map[string]interface{} // config.json as read
+	base    map[string]interface{} // the config before the layers above config.json
+	project map[string]interface{}
+	env     map[string]interface{}
+	flag    map[string]interface{}
+	errs    []error
+}
+
+// overlay returns the merged settings of the layers above config.json.
+func (l *configLayers) overlay() map[string]interface{} {
+	merged := make(map[string]interface{})
+	for _, layer := range []map[string]interface{}{l.project, l.env, l.flag} {
+		mergeSettings(merged, layer)
+	}
+	return merged
+}
+
+// SetFlagOverrides sets the flag layer from key=value assignments, e.g.
+// "subagent_max_parallel=4" or "provider_models.openai=gpt-5". It applies
+// to configs loaded afterwards.
+func SetFlagOverrides(assignments []string) error {
+	settings := make(map[string]interface{})
+	for _, assignment := range assignments {
+		key, raw, ok := strings.Cut(assignment, "=")
+		if !ok {
+			return fmt.Errorf("invalid setting %q: expected key=value", assignment)
+		}
+		value, err := ParseSetting(strings.TrimSpace(key), raw)
+		if err != nil {
+			return err
+		}
+		setPath(settings, splitKey(strings.TrimSpace(key)), value)
+	}
+	flagOverridesMu.Lock()
+	defer flagOverridesMu.Unlock()
+	flagOverrides = normalizeSettings(settings)
+	return nil
+}
+
+// ProjectConfigPath returns the project config of the project rooted at root.
+func ProjectConfigPath(root string) string {
+	return filepath.Join(root, ProjectConfigFile)
+}
+
+// LoadProjectConfig reads the settings in the project config of the project
+// rooted at root. It returns nil when there is none.
+func LoadProjectConfig(root string) (map[string]interface{}, error) {
+	path := ProjectConfigPath(root)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var settings map[string]interface{}
+	if err := yaml.Unmarshal(data, &settings); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	settings = normalizeSettings(settings)
+	if err := checkSettings(settings, nil); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return settings, nil
+}
+
+// SetProjectSetting sets key to value in the project config of the project
+// rooted at root, creating the file if needed and keeping its comments.
+func SetProjectSetting(root, key, raw string) error {
+	value, err := ParseSetting(key, raw)
+	if err != nil {
+		return err
+	}
+	path := ProjectConfigPath(root)
+	var doc yaml.Node
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	default:
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	}
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	var valueNode yaml.Node
+	if err := valueNode.Encode(value); err != nil {
+		return err
+	}
+	if err := setYAMLPath(doc.Content[0], splitKey(key), &valueNode); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	out, err := yaml.Marshal(&doc)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	return os.WriteFile(path, out, 0644)
+}
+
+func setYAMLPath(node *yaml.Node, path []string, value *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("%s is not a mapping", strings.Join(path, "."))
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value != path[0] {
+			continue
+		}
+		if len(path) == 1 {
+			node.Content[i+1] = value
+			return nil
+		}
+		return setYAMLPath(node.Content[i+1], path[1:], value)
+	}
+	child := value
+	for i := len(path) - 1; i > 0; i-- {
+		child = &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{
+			{Kind: yaml.ScalarNode, Value: path[i]}, child,
+		}}
+	}
+	node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: path[0]}, child)
+	return nil
+}
+
+// envSettings reads the settings set by LEDIT_CONFIG_* variables in environ.
+// Variables naming no setting, or with values of the wrong type, are
+// reported and left out.
+func envSettings(environ []string) (map[string]interface{}, []error) {
+	settings := make(map[string]interface{})
+	var errs []error
+	for _, entry := range environ {
+		name, raw, _ := strings.Cut(entry, "=")
+		if !strings.HasPrefix(name, EnvPrefix) || len(name) == len(EnvPrefix) {
+			continue
+		}
+		key := strings.ToLower(strings.ReplaceAll(name[len(EnvPrefix):], "__", "."))
+		value, err := ParseSetting(key, raw)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		setPath(settings, splitKey(key), value)
+	}
+	return normalizeSettings(settings), errs
+}
+
+// ParseSetting parses raw as the value of the setting key. Text settings
+// take raw as it is; others take it as JSON, so true, 4 and ["a","b"] work.
+func ParseSetting(key, raw string) (interface{}, error) {
+	t, err := settingType(splitKey(key))
+	if err != nil {
+		return nil, err
+	}
+	if t.Kind() == reflect.String {
+		return raw, nil
+	}
+	if t.Kind() == reflect.Interface && !json.Valid([]byte(raw)) {
+		return raw, nil // free-form settings such as preferences take text too
+	}
+	raw = strings.TrimSpace(raw)
+	if err := json.Unmarshal([]byte(raw), reflect.New(t).Interface()); err != nil {
+		return nil, fmt.Errorf("invalid value %q for %s: expected %s", raw, key, describeType(t))
+	}
+	var value interface{}
+	if err := json.Unmarshal([]byte(raw), &value); err != nil {
+		return nil, fmt.Errorf("invalid value %q for %s: %w", raw, key, err)
+	}
+	return value, nil
+}
+
+// IsSetting reports whether key, e.g. "api_timeouts.chunk_timeout_sec",
+// names a setting of config.json.
+func IsSetting(key string) bool {
+	_, err := settingType(splitKey(key))
+	return err == nil
+}
+
+// settingType returns the type of the setting at path, following the JSON
+// keys of Config.
+func settingType(path []string) (reflect.Type, error) {
+	t := reflect.TypeOf(Config{})
+	for i, name := range path {
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		switch t.Kind() {
+		case reflect.Struct:
+			field, ok := jsonField(t, name)
+			if !ok {
+				return nil, fmt.Errorf("unknown setting %q", strings.Join(path[:i+1], "."))
+			}
+			t = field.Type
+		case reflect.Map:
+			t = t.Elem()
+		case reflect.Interface:
+			return t, nil
+		default:
+			return nil, fmt.Errorf("unknown setting %q: %s is a %s", strings.Join(path, "."), strings.Join(path[:i], "."), describeType(t))
+		}
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t, nil
+}
+
+// jsonField finds the field of struct type t with JSON key name.
+func jsonField(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if tag == "-" {
+			continue
+		}
+		if tag == "" {
+			tag = field.Name
+		}
+		if tag == name {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func describeType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "true or false"
+	case reflect.Int, reflect.Int64, reflect.Int32:
+		return "a whole number"
+	case reflect.Float64, reflect.Float32:
+		return "a number"
+	case reflect.Slice:
+		return "a JSON array"
+	case reflect.Map, reflect.Struct:
+		return "a JSON object"
+	}
+	return t.Kind().String()
+}
+
+// checkSettings reports keys of settings that name no setting and values of
+// the wrong type.
+func checkSettings(settings map[string]interface{}, prefix []string) error {
+	var errs []error
+	for _, key := range sortedSettingKeys(settings) {
+		path := append(append([]string(nil), prefix...), key)
+		t, err := settingType(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if nested, ok := settings[key].(map[string]interface{}); ok && (t.Kind() == reflect.Struct || t.Kind() == reflect.Map) {
+			if err := checkSettings(nested, path); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		data, _ := json.Marshal(settings[key])
+		if err := json.Unmarshal(data, reflect.New(t).Interface()); err != nil {
+			errs = append(errs, fmt.Errorf("invalid value for %s: expected %s", strings.Join(path, "."), describeType(t)))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// applyLayers applies the layers above config.json, whose content is
+// userData (nil when there is none), to c.
+func (c *Config) applyLayers(userData []byte) {
+	layers := &configLayers{user: map[string]interface{}{}}
+	if len(userData) > 0 {
+		_ = json.Unmarshal(userData, &layers.user)
+	}
+	layers.base, _ = configToMap(c)
+	if cwd, err := os.Getwd(); err == nil {
+		project, err := LoadProjectConfig(cwd)
+		if err != nil {
+			layers.errs = append(layers.errs, err)
+		} else {
+			layers.project = project
+		}
+	}
+	env, errs := envSettings(os.Environ())
+	layers.env = env
+	layers.errs = append(layers.errs, errs...)
+	flagOverridesMu.RLock()
+	layers.flag = flagOverrides
+	flagOverridesMu.RUnlock()
+
+	if len(layers.errs) > 0 {
+		layerWarningOnce.Do(func() {
+			fmt.Fprintf(os.Stderr, "WARNING: ignoring invalid settings (check with 'ledit config doctor'): %v\n", errors.Join(layers.errs...))
+		})
+	}
+	c.layers = layers
+	overlay := layers.overlay()
+	if len(overlay) == 0 {
+		return
+	}
+	data, err := json.Marshal(overlay)
+	if err != nil {
+		return
+	}
+	// Unmarshaling onto the loaded config merges objects key by key and
+	// replaces everything else.
+	if err := json.Unmarshal(data, c); err != nil {
+		layers.errs = append(layers.errs, fmt.Errorf("failed to apply settings: %w", err))
+	}
+}
+
+// persistedSettings returns persisted, a copy of c, as written to
+// config.json: settings still holding the value a layer above config.json
+// gave them are written as they were before it, so overrides never leak
+// into config.json.
+func (c *Config) persistedSettings(persisted *Config) ([]byte, error) {
+	data, err := json.MarshalIndent(persisted, "", "  ")
+	if err != nil || c.layers == nil {
+		return data, err
+	}
+	overlay := c.layers.overlay()
+	if len(overlay) == 0 {
+		return data, nil
+	}
+	var settings map[string]interface{}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return nil, err
+	}
+	restoreUserSettings(settings, overlay, c.layers.base)
+	return json.MarshalIndent(settings, "", "  ")
+}
+
+func restoreUserSettings(settings, overlay, user map[string]interface{}) {
+	for key, override := range overlay {
+		current, ok := settings[key]
+		if !ok {
+			continue
+		}
+		if nested, isMap := override.(map[string]interface{}); isMap {
+			if currentMap, ok := current.(map[string]interface{}); ok {
+				userMap, _ := user[key].(map[string]interface{})
+				restoreUserSettings(currentMap, nested, userMap)
+			}
+			continue
+		}
+		if !reflect.DeepEqual(current, override) {
+			continue // changed since loading; the change is the user's
+		}
+		if value, ok := user[key]; ok {
+			settings[key] = value
+		} else {
+			delete(settings, key)
+		}
+	}
+}
+
+// SettingSource returns the layer the setting key comes from: LayerFlag,
+// LayerEnv, LayerProject, LayerUser, or LayerDefault when none sets it.
+func (c *Config) SettingSource(key string) string {
+	if c.layers == nil {
+		return LayerDefault
+	}
+	path := splitKey(key)
+	for _, layer := range []struct {
+		name     string
+		settings map[string]interface{}
+	}{
+		{LayerFlag, c.layers.flag},
+		{LayerEnv, c.layers.env},
+		{LayerProject, c.layers.project},
+		{LayerUser, c.layers.user},
+	} {
+		if _, ok := lookupPath(layer.settings, path); ok {
+			return layer.name
+		}
+	}
+	return LayerDefault
+}
+
+// LayerErrors returns the problems found in the layers above config.json
+// while loading. The settings concerned were ignored.
+func (c *Config) LayerErrors() []error {
+	if c.layers == nil {
+		return nil
+	}
+	return c.layers.errs
+}
+
+// Setting returns the value of the setting key, as JSON for anything other
+// than text. ok is false for settings that are unset.
+func (c *Config) Setting(key string) (value string, ok bool) {
+	settings, err := configToMap(c)
+	if err != nil {
+		return "", false
+	}
+	v, ok := lookupPath(settings, splitKey(key))
+	if !ok || v == nil {
+		return "", false
+	}
+	if s, isString := v.(string); isString {
+		return s, true
+	}
+	data, _ := json.Marshal(v)
+	return string(data), true
+}
+
+// ApplySetting sets the setting key of c to raw, parsed as ParseSetting does.
+func (c *Config) ApplySetting(key, raw string) error {
+	value, err := ParseSetting(key, raw)
+	if err != nil {
+		return err
+	}
+	settings := make(map[string]interface{})
+	setPath(settings, splitKey(key), value)
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, c)
+}
+
+func splitKey(key string) []string {
+	return strings.Split(key, ".")
+}
+
+func lookupPath(settings map[string]interface{}, path []string) (interface{}, bool) {
+	var value interface{} = settings
+	for _, name := range path {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = m[name]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+func setPath(settings map[string]interface{}, path []string, value interface{}) {
+	for _, name := range path[:len(path)-1] {
+		next, ok := settings[name].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			settings[name] = next
+		}
+		settings = next
+	}
+	settings[path[len(path)-1]] = value
+}
+
+// mergeSettings merges src into dst, key by key for objects.
+func mergeSettings(dst, src map[string]interface{}) {
+	for key, value := range src {
+		if nested, ok := value.(map[string]interface{}); ok {
+			if existing, ok := dst[key].(map[string]interface{}); ok {
+				mergeSettings(existing, nested)
+				continue
+			}
+			copied := make(map[string]interface{})
+			mergeSettings(copied, nested)
+			dst[key] = copied
+			continue
+		}
+		dst[key] = value
+	}
+}
+
+// normalizeSettings converts settings to the types JSON decodes to, so
+// values from YAML compare equal to the same values from config.json.
+func normalizeSettings(settings map[string]interface{}) map[string]interface{} {
+	if len(settings) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return settings
+	}
+	var out map[string]interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return settings
+	}
+	return out
+}
+
+func sortedSettingKeys(settings map[string]interface{}) []string {
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,179 @@
+package configuration
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeProjectConfig(t *testing.T, root, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(root, ConfigDirName), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(ProjectConfigPath(root), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLayersOverrideUserConfig(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv("LEDIT_CONFIG", configDir)
+	userConfig := `{"last_used_provider": "openai", "provider_models": {"openai": "gpt-4o", "deepseek": "deepseek-chat"}, "subagent_max_parallel": 2, "commit_model": "user-model"}`
+	if err := os.WriteFile(filepath.Join(configDir, ConfigFileName), []byte(userConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+	root := t.TempDir()
+	writeProjectConfig(t, root, `# Team settings
+provider_models:
+  openai: gpt-5
+subagent_max_parallel: 3
+self_review_gate_mode: always
+`)
+	t.Chdir(root)
+	t.Setenv("LEDIT_CONFIG_SUBAGENT_MAX_PARALLEL", "4")
+	t.Setenv("LEDIT_CONFIG_API_TIMEOUTS__OVERALL_TIMEOUT_SEC", "90")
+	if err := SetFlagOverrides([]string{"subagent_max_parallel=5"}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = SetFlagOverrides(nil) })
+
+	config, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if config.ProviderModels["openai"] != "gpt-5" || config.ProviderModels["deepseek"] != "deepseek-chat" {
+		t.Errorf("expected project models merged over the user's, got %v", config.ProviderModels)
+	}
+	if config.SubagentMaxParallel != 5 || config.APITimeouts.OverallTimeoutSec != 90 || config.SelfReviewGateMode != "always" {
+		t.Errorf("unexpected effective settings: parallel=%d overall=%d gate=%q", config.SubagentMaxParallel, config.APITimeouts.OverallTimeoutSec, config.SelfReviewGateMode)
+	}
+	for key, want := range map[string]string{
+		"subagent_max_parallel":            LayerFlag,
+		"api_timeouts.overall_timeout_sec": LayerEnv,
+		"provider_models.openai":           LayerProject,
+		"provider_models.deepseek":         LayerUser,
+		"reasoning_effort":                 LayerDefault,
+	} {
+		if got := config.SettingSource(key); got != want {
+			t.Errorf("SettingSource(%q) = %q, want %q", key, got, want)
+		}
+	}
+
+	// Saving writes back what config.json had for overridden settings, but
+	// keeps changes made since loading.
+	config = cloneConfig(config)
+	config.CommitModel = "new-model"
+	if err := config.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(configDir, ConfigFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved Config
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	if saved.ProviderModels["openai"] != "gpt-4o" || saved.SubagentMaxParallel != 2 || saved.SelfReviewGateMode != "" {
+		t.Errorf("overrides leaked into config.json: %s", data)
+	}
+	if saved.CommitModel != "new-model" {
+		t.Errorf("expected the change to be saved, got %q", saved.CommitModel)
+	}
+}
+
+func TestInvalidLayerSettingsAreIgnored(t *testing.T) {
+	t.Setenv("LEDIT_CONFIG", t.TempDir())
+	root := t.TempDir()
+	writeProjectConfig(t, root, "subagent_max_parallel: many\n")
+	t.Chdir(root)
+	t.Setenv("LEDIT_CONFIG_NO_SUCH_SETTING", "1")
+	t.Setenv("LEDIT_CONFIG_SUBAGENT_MAX_DEPTH", "2")
+
+	config, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if config.SubagentMaxDepth != 2 || config.SubagentMaxParallel != 2 {
+		t.Errorf("expected only the valid setting applied, got depth=%d parallel=%d", config.SubagentMaxDepth, config.SubagentMaxParallel)
+	}
+	errs := config.LayerErrors()
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 layer errors, got %v", errs)
+	}
+	joined := errs[0].Error() + "\n" + errs[1].Error()
+	for _, want := range []string{"subagent_max_parallel: expected a whole number", `unknown setting "no_such_setting"`} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("expected %q in %s", want, joined)
+		}
+	}
+
+	if err := SetFlagOverrides([]string{"subagent_max_parallel"}); err == nil {
+		t.Error("expected an assignment without = to be rejected")
+	}
+	if err := SetFlagOverrides([]string{"secret_redaction_enabled=maybe"}); err == nil {
+		t.Error("expected a non-boolean value to be rejected")
+	}
+}
+
+func TestSetProjectSettingKeepsComments(t *testing.T) {
+	root := t.TempDir()
+	writeProjectConfig(t, root, "# Shared by the team\nreasoning_effort: low\n")
+	if err := SetProjectSetting(root, "reasoning_effort", "high"); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetProjectSetting(root, "provider_models.openai", "gpt-5"); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetProjectSetting(root, "subagent_max_parallel", "4"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(ProjectConfigPath(root))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# Shared by the team\nreasoning_effort: high\nprovider_models:\n    openai: gpt-5\nsubagent_max_parallel: 4\n"
+	if string(data) != want {
+		t.Errorf("project config = %q, want %q", data, want)
+	}
+	settings, err := LoadProjectConfig(root)
+	if err != nil || settings["subagent_max_parallel"] != float64(4) {
+		t.Errorf("unexpected settings %v, %v", settings, err)
+	}
+}
+
+func TestDiagnoseFlagsInvalidCombinations(t *testing.T) {
+	disabled := false
+	config := &Config{
+		LastUsedProvider:            "no-such-provider",
+		ReasoningEffort:             "extreme",
+		SubagentMaxParallel:         4,
+		SubagentParallelEnabled:     &disabled,
+		AutoExecuteDetectedCommands: true,
+		Remote:                      &RemoteConfig{Host: "dev@buildbox"},
+		Devcontainer:                &DevcontainerConfig{Enabled: true, Runtime: "lxc"},
+	}
+	issues := Diagnose(config)
+	got := make(map[string]string)
+	for _, issue := range issues {
+		got[issue.Key] = issue.Severity
+	}
+	for key, severity := range map[string]string{
+		"last_used_provider":             IssueError,
+		"reasoning_effort":               IssueError,
+		"subagent_max_parallel":          IssueWarning,
+		"auto_execute_detected_commands": IssueWarning,
+		"devcontainer.enabled":           IssueError,
+		"devcontainer.runtime":           IssueError,
+	} {
+		if got[key] != severity {
+			t.Errorf("expected %s for %s, got %q (issues: %v)", severity, key, got[key], issues)
+		}
+	}
+	if issues[len(issues)-1].Severity != IssueWarning || issues[0].Severity != IssueError {
+		t.Errorf("expected errors before warnings: %v", issues)
+	}
+}
@@ -442,6 +442,8 @@ func cloneConfig(cfg *Config) *Config {
 	if err := json.Unmarshal(data, &out); err != nil {
 		return nil
 	}
+	out.copyPersonaSources(cfg)
+	out.layers = cfg.layers
 	return &out
 }
 
@@ -0,0 +1,97 @@
+package configuration
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// OfflineEnv enables offline mode. It is set by --offline, and subagent
+// processes inherit it.
+const OfflineEnv = "LEDIT_OFFLINE"
+
+// DefaultOfflineProvider is the provider offline mode uses when
+// offline.provider is not set.
+const DefaultOfflineProvider = "ollama-local"
+
+// IsOffline reports whether ledit runs without the network: only a local
+// provider is used and tools that need the network are disabled.
+func IsOffline() bool {
+	enabled, err := strconv.ParseBool(strings.TrimSpace(os.Getenv(OfflineEnv)))
+	return err == nil && enabled
+}
+
+// OfflineProvider returns the local provider and model offline mode
+// switches to. The model is empty when neither offline.model nor the
+// provider's entry in provider_models is set.
+func (c *Config) OfflineProvider() (provider, model string) {
+	if c.Offline != nil {
+		provider = strings.ToLower(strings.TrimSpace(c.Offline.Provider))
+		model = strings.TrimSpace(c.Offline.Model)
+	}
+	if provider == "" {
+		provider = DefaultOfflineProvider
+	}
+	if model == "" {
+		model = c.ProviderModels[provider]
+	}
+	return provider, model
+}
+
+// IsLocalProvider reports whether provider runs on this machine: Ollama,
+// LM Studio, or a custom provider whose endpoint is a loopback address.
+func IsLocalProvider(c *Config, provider string) bool {
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	switch provider {
+	case "ollama", "ollama-local", "lmstudio":
+		return true
+	}
+	if c == nil {
+		return false
+	}
+	custom, ok := c.CustomProviders[provider]
+	if !ok {
+		return false
+	}
+	endpoint, err := url.Parse(custom.Endpoint)
+	if err != nil {
+		return false
+	}
+	switch endpoint.Hostname() {
+	case "localhost", "127.0.0.1", "::1":
+		return true
+	}
+	return false
+}
+
+// OfflineOverrides returns the --set assignments that make every LLM call,
+// including subagents, commit messages and reviews, use the offline
+// provider. It fails when that provider is not a local one.
+func OfflineOverrides(c *Config) ([]string, error) {
+	provider, model := c.OfflineProvider()
+	if _, err := MapProviderStringToClientType(c, provider); err != nil {
+		return nil, fmt.Errorf("offline.provider: unknown provider %q", provider)
+	}
+	if !IsLocalProvider(c, provider) {
+		return nil, fmt.Errorf("offline.provider: %s needs the network; choose ollama-local, lmstudio or a custom provider on localhost", provider)
+	}
+	// Models chosen for other providers would not exist locally, so the
+	// others follow the main model when offline.model is not set
+	overrides := []string{
+		"last_used_provider=" + provider,
+		"subagent_provider=" + provider,
+		"subagent_model=" + model,
+		"commit_provider=" + provider,
+		"commit_model=" + model,
+		"review_provider=" + provider,
+		"review_model=" + model,
+		"escalation_provider=",
+		"escalation_model=",
+	}
+	if model != "" {
+		overrides = append(overrides, "provider_models."+provider+"="+model)
+	}
+	return overrides, nil
+}
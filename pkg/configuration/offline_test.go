@@ -0,0 +1,62 @@
+package configuration
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOfflineOverridesSwitchToLocalProvider(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv("LEDIT_CONFIG", configDir)
+	t.Chdir(t.TempDir())
+	userConfig := `{"last_used_provider": "openai", "provider_models": {"openai": "gpt-4o"}, "subagent_provider": "deepseek", "escalation_model": "gpt-5", "offline": {"provider": "lmstudio", "model": "qwen2.5-coder"}}`
+	if err := os.WriteFile(filepath.Join(configDir, ConfigFileName), []byte(userConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+	config, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	overrides, err := OfflineOverrides(config)
+	if err != nil {
+		t.Fatalf("OfflineOverrides: %v", err)
+	}
+	if err := SetFlagOverrides(overrides); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = SetFlagOverrides(nil) })
+
+	config, err = Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if config.LastUsedProvider != "lmstudio" || config.ProviderModels["lmstudio"] != "qwen2.5-coder" {
+		t.Errorf("expected the offline model, got %s:%s", config.LastUsedProvider, config.ProviderModels["lmstudio"])
+	}
+	if config.SubagentProvider != "lmstudio" || config.SubagentModel != "qwen2.5-coder" {
+		t.Errorf("expected subagents on the offline model, got %s:%s", config.SubagentProvider, config.SubagentModel)
+	}
+	if config.EscalationModel != "" {
+		t.Errorf("expected escalation to be disabled offline, got %s", config.EscalationModel)
+	}
+}
+
+func TestOfflineRejectsRemoteProviders(t *testing.T) {
+	config := &Config{
+		Offline: &OfflineConfig{Provider: "openai"},
+		CustomProviders: map[string]CustomProviderConfig{
+			"llamacpp": {Endpoint: "http://127.0.0.1:8080/v1"},
+			"together": {Endpoint: "https://api.together.xyz/v1"},
+		},
+	}
+	if _, err := OfflineOverrides(config); err == nil {
+		t.Error("expected openai to be rejected as an offline provider")
+	}
+	if !IsLocalProvider(config, "llamacpp") || IsLocalProvider(config, "together") {
+		t.Error("expected only the loopback custom provider to be local")
+	}
+	if provider, _ := (&Config{}).OfflineProvider(); provider != DefaultOfflineProvider {
+		t.Errorf("expected %s by default, got %s", DefaultOfflineProvider, provider)
+	}
+}
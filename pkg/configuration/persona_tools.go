@@ -31,6 +31,7 @@ func getKnownPersonaToolNames() map[string]struct{} {
 		known["self_review"] = struct{}{}
 		known["list_skills"] = struct{}{}
 		known["activate_skill"] = struct{}{}
+		known["list_personas"] = struct{}{}
 
 		personaToolNames = known
 	})
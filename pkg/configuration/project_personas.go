@@ -0,0 +1,237 @@
+package configuration
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// PersonaSourceProject marks a persona loaded from the project's
+// .ledit/personas/ directory.
+const PersonaSourceProject = "project"
+
+// personaIDPattern is the form of a normalized persona ID, which is also its
+// file name.
+var personaIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_]*$`)
+
+var projectPersonasWarningOnce sync.Once
+
+// ProjectPersonasDir returns the directory holding the personas of the
+// project rooted at root.
+func ProjectPersonasDir(root string) string {
+	return filepath.Join(root, ConfigDirName, "personas")
+}
+
+// ProjectPersonaPath returns the file defining persona id in the project
+// rooted at root.
+func ProjectPersonaPath(root, id string) string {
+	return filepath.Join(ProjectPersonasDir(root), normalizePersonaID(id)+".json")
+}
+
+// LoadProjectPersonas reads the persona definitions in the project rooted at
+// root, keyed by normalized ID. A definition that cannot be read or fails
+// ValidatePersona is reported in the returned error and left out.
+func LoadProjectPersonas(root string) (map[string]SubagentType, error) {
+	dir := ProjectPersonasDir(root)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+
+	loaded := make(map[string]SubagentType)
+	var errs []error
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		persona, err := readProjectPersona(path)
+		if err == nil {
+			if want := strings.TrimSuffix(entry.Name(), ".json"); normalizePersonaID(persona.ID) != want {
+				err = fmt.Errorf("id %q does not match the file name", persona.ID)
+			} else {
+				err = ValidatePersona(root, persona)
+			}
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("persona %s: %w", path, err))
+			continue
+		}
+		persona.ID = normalizePersonaID(persona.ID)
+		persona.Source = PersonaSourceProject
+		loaded[persona.ID] = persona
+	}
+	return loaded, errors.Join(errs...)
+}
+
+// ReadProjectPersona reads the definition of persona id in the project
+// rooted at root without validating it, so it can be corrected.
+func ReadProjectPersona(root, id string) (SubagentType, error) {
+	return readProjectPersona(ProjectPersonaPath(root, id))
+}
+
+func readProjectPersona(path string) (SubagentType, error) {
+	var persona SubagentType
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return persona, err
+	}
+	if err := json.Unmarshal(data, &persona); err != nil {
+		return persona, fmt.Errorf("invalid JSON: %w", err)
+	}
+	return persona, nil
+}
+
+// ValidatePersona checks a persona definition of the project rooted at root:
+// its ID, name and description, that it has exactly one system prompt and a
+// prompt file exists, that its tools are known and its budget is not
+// negative. Every problem is reported.
+func ValidatePersona(root string, persona SubagentType) error {
+	var problems []string
+	if id := normalizePersonaID(persona.ID); !personaIDPattern.MatchString(id) {
+		problems = append(problems, fmt.Sprintf("id %q must be letters, digits, '_' or '-'", persona.ID))
+	}
+	if strings.TrimSpace(persona.Name) == "" {
+		problems = append(problems, "name is required")
+	}
+	if strings.TrimSpace(persona.Description) == "" {
+		problems = append(problems, "description is required, so the orchestrator knows when to use the persona")
+	}
+
+	promptPath := strings.TrimSpace(persona.SystemPrompt)
+	promptText := strings.TrimSpace(persona.SystemPromptText)
+	switch {
+	case promptPath != "" && promptText != "":
+		problems = append(problems, "set system_prompt or system_prompt_text, not both")
+	case promptPath == "" && promptText == "":
+		problems = append(problems, "system_prompt or system_prompt_text is required")
+	case promptPath != "":
+		if !filepath.IsAbs(promptPath) {
+			promptPath = filepath.Join(root, promptPath)
+		}
+		if info, err := os.Stat(promptPath); err != nil {
+			problems = append(problems, fmt.Sprintf("system_prompt file %s: %v", persona.SystemPrompt, err))
+		} else if info.IsDir() {
+			problems = append(problems, fmt.Sprintf("system_prompt %s is a directory", persona.SystemPrompt))
+		}
+	}
+
+	if unknown := UnknownPersonaTools(persona.AllowedTools); len(unknown) > 0 {
+		problems = append(problems, "unknown allowed_tools: "+strings.Join(unknown, ", "))
+	}
+	if persona.MaxTokens < 0 {
+		problems = append(problems, "max_tokens must not be negative")
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(problems, "; "))
+}
+
+// SaveProjectPersona validates persona and writes it to the project rooted at
+// root, replacing any definition with the same ID.
+func SaveProjectPersona(root string, persona SubagentType) error {
+	persona.ID = normalizePersonaID(persona.ID)
+	if err := ValidatePersona(root, persona); err != nil {
+		return fmt.Errorf("invalid persona %q: %w", persona.ID, err)
+	}
+	persona.Source = ""
+	data, err := json.MarshalIndent(persona, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal persona: %w", err)
+	}
+	if err := os.MkdirAll(ProjectPersonasDir(root), 0755); err != nil {
+		return fmt.Errorf("failed to create personas directory: %w", err)
+	}
+	if err := os.WriteFile(ProjectPersonaPath(root, persona.ID), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write persona: %w", err)
+	}
+	return nil
+}
+
+// discoverProjectPersonas adds the personas defined in .ledit/personas/ of
+// the current directory to the config, replacing global personas with the
+// same ID for this session. Invalid definitions are skipped with a warning.
+func discoverProjectPersonas(config *Config) {
+	if config == nil {
+		return
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return
+	}
+	project, err := LoadProjectPersonas(cwd)
+	if err != nil {
+		projectPersonasWarningOnce.Do(func() {
+			fmt.Fprintf(os.Stderr, "WARNING: skipping invalid project personas (check with 'ledit personas validate'): %v\n", err)
+		})
+	}
+	if len(project) == 0 {
+		return
+	}
+
+	if config.SubagentTypes == nil {
+		config.SubagentTypes = make(map[string]SubagentType)
+	}
+	ids := make([]string, 0, len(project))
+	for id := range project {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		for existingID, existing := range config.SubagentTypes {
+			if normalizePersonaID(existingID) != id {
+				continue
+			}
+			if existing.Source != PersonaSourceProject {
+				if config.shadowedSubagentTypes == nil {
+					config.shadowedSubagentTypes = make(map[string]SubagentType)
+				}
+				config.shadowedSubagentTypes[existingID] = existing
+			}
+			delete(config.SubagentTypes, existingID)
+		}
+		config.SubagentTypes[id] = project[id]
+	}
+}
+
+// persistedSubagentTypes returns the personas Save writes to the global
+// config: project personas are left out and the ones they replaced restored.
+func (c *Config) persistedSubagentTypes() map[string]SubagentType {
+	if c.SubagentTypes == nil {
+		return nil
+	}
+	persisted := make(map[string]SubagentType, len(c.SubagentTypes))
+	for id, persona := range c.SubagentTypes {
+		if persona.Source != PersonaSourceProject {
+			persisted[id] = persona
+		}
+	}
+	for id, persona := range c.shadowedSubagentTypes {
+		if _, exists := persisted[id]; !exists {
+			persisted[id] = persona
+		}
+	}
+	return persisted
+}
+
+// copyPersonaSources carries the persona state JSON leaves out from src to a
+// copy of it.
+func (c *Config) copyPersonaSources(src *Config) {
+	for id, persona := range c.SubagentTypes {
+		persona.Source = src.SubagentTypes[id].Source
+		c.SubagentTypes[id] = persona
+	}
+	c.shadowedSubagentTypes = maps.Clone(src.shadowedSubagentTypes)
+}
@@ -0,0 +1,99 @@
+package configuration
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeProjectPersonaFile(t *testing.T, root, name, content string) {
+	t.Helper()
+	dir := ProjectPersonasDir(root)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidatePersona(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "auditor.md"), []byte("You audit."), 0644); err != nil {
+		t.Fatal(err)
+	}
+	valid := SubagentType{ID: "security-auditor", Name: "Security Auditor", Description: "Audits code", SystemPrompt: "auditor.md", AllowedTools: []string{"read_file", "search_files"}, MaxTokens: 50000}
+	if err := ValidatePersona(root, valid); err != nil {
+		t.Fatalf("expected a valid persona, got %v", err)
+	}
+
+	err := ValidatePersona(root, SubagentType{ID: "bad id", SystemPrompt: "missing.md", SystemPromptText: "inline", AllowedTools: []string{"not_a_tool"}, MaxTokens: -1})
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+	for _, want := range []string{`id "bad id"`, "name is required", "description is required", "not both", "unknown allowed_tools: not_a_tool", "max_tokens"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in %v", want, err)
+		}
+	}
+
+	missing := valid
+	missing.SystemPrompt = "missing.md"
+	if err := ValidatePersona(root, missing); err == nil || !strings.Contains(err.Error(), "missing.md") {
+		t.Fatalf("expected a missing prompt file error, got %v", err)
+	}
+}
+
+func TestSaveAndLoadProjectPersonas(t *testing.T) {
+	root := t.TempDir()
+	persona := SubagentType{ID: "Doc-Writer", Name: "Doc Writer", Description: "Writes docs", SystemPromptText: "You write docs.", Enabled: true, MaxTokens: 1000}
+	if err := SaveProjectPersona(root, persona); err != nil {
+		t.Fatalf("SaveProjectPersona: %v", err)
+	}
+	writeProjectPersonaFile(t, root, "broken.json", `{"id": "broken", "name": "Broken"}`)
+	writeProjectPersonaFile(t, root, "renamed.json", `{"id": "other", "name": "X", "description": "X", "system_prompt_text": "X"}`)
+
+	loaded, err := LoadProjectPersonas(root)
+	if err == nil || !strings.Contains(err.Error(), "broken.json") || !strings.Contains(err.Error(), "does not match the file name") {
+		t.Fatalf("expected errors for the invalid definitions, got %v", err)
+	}
+	if len(loaded) != 1 {
+		t.Fatalf("expected only the valid persona, got %v", loaded)
+	}
+	got := loaded["doc_writer"]
+	if got.Name != "Doc Writer" || got.MaxTokens != 1000 || got.Source != PersonaSourceProject {
+		t.Fatalf("unexpected persona: %+v", got)
+	}
+
+	if personas, err := LoadProjectPersonas(t.TempDir()); err != nil || personas != nil {
+		t.Fatalf("expected no personas and no error without a personas directory, got %v, %v", personas, err)
+	}
+}
+
+func TestProjectPersonasAreNotSavedGlobally(t *testing.T) {
+	root := t.TempDir()
+	writeProjectPersonaFile(t, root, "coder.json", `{"id": "coder", "name": "Project Coder", "description": "Codes here", "system_prompt_text": "Code.", "enabled": true}`)
+	t.Chdir(root)
+
+	global := SubagentType{ID: "coder", Name: "Coder", Description: "Codes", Enabled: true}
+	config := &Config{SubagentTypes: map[string]SubagentType{"coder": global}}
+	discoverProjectPersonas(config)
+
+	if got := config.GetSubagentType("coder"); got == nil || got.Name != "Project Coder" {
+		t.Fatalf("expected the project persona to replace the global one, got %+v", got)
+	}
+	config = cloneConfig(config)
+	persisted := config.persistedSubagentTypes()
+	if persisted["coder"].Name != "Coder" {
+		t.Fatalf("expected the global persona to be persisted, got %+v", persisted["coder"])
+	}
+	data, err := json.Marshal(persisted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "Project Coder") {
+		t.Fatalf("project persona leaked into the saved config: %s", data)
+	}
+}
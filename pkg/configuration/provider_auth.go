@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	providers "github.com/alantheprice/ledit/pkg/agent_providers"
+	"github.com/alantheprice/ledit/pkg/credentials"
 )
 
 type ProviderAuthMetadata struct {
@@ -39,6 +40,16 @@ func GetProviderAuthMetadata(provider string) (ProviderAuthMetadata, error) {
 			EnvVar:         "JINA_API_KEY",
 			AuthType:       "bearer",
 		}, nil
+	case "github", "gitlab", "jira":
+		// Forge and Jira tokens are used by the pull request and issue
+		// integrations (pkg/forge).
+		return ProviderAuthMetadata{
+			Provider:       name,
+			DisplayName:    getProviderDisplayName(name),
+			RequiresAPIKey: true,
+			EnvVar:         credentials.ProviderEnvVar(name),
+			AuthType:       "bearer",
+		}, nil
 	}
 
 	if cfg, err := Load(); err == nil {
@@ -4,13 +4,21 @@ import "fmt"
 
 // ANSI escape sequence helpers for consistent terminal control.
 
-// Colorize wraps text with a color code and reset
+// Colorize wraps text with a color code and reset. Text is returned unchanged
+// when colors are disabled.
 func Colorize(text, color string) string {
+	if !ColorsEnabled() {
+		return text
+	}
 	return color + text + ColorReset
 }
 
-// ColorizeBold wraps text with bold and a color code
+// ColorizeBold wraps text with bold and a color code. Text is returned
+// unchanged when colors are disabled.
 func ColorizeBold(text, color string) string {
+	if !ColorsEnabled() {
+		return text
+	}
 	return ColorBold + color + text + ColorReset
 }
 
@@ -0,0 +1,95 @@
+package console
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// ErrNoClipboard is returned when no clipboard backend is available.
+var ErrNoClipboard = errors.New("no clipboard available: install wl-clipboard, xclip or xsel, or use a terminal that supports OSC 52")
+
+// clipboardCommand is a program that reads text to copy from stdin.
+type clipboardCommand struct {
+	name string
+	args []string
+}
+
+// Replaced in tests.
+var (
+	clipboardLookPath           = exec.LookPath
+	clipboardTerminal           = func() bool { return term.IsTerminal(int(os.Stdout.Fd())) }
+	clipboardOut      io.Writer = os.Stdout
+)
+
+// clipboardCommands returns the native clipboard programs for this system in
+// the order they are tried.
+func clipboardCommands() []clipboardCommand {
+	switch runtime.GOOS {
+	case "darwin":
+		return []clipboardCommand{{name: "pbcopy"}}
+	case "windows":
+		return []clipboardCommand{{name: "clip.exe"}}
+	}
+	var commands []clipboardCommand
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		commands = append(commands, clipboardCommand{name: "wl-copy"})
+	}
+	if os.Getenv("DISPLAY") != "" {
+		commands = append(commands,
+			clipboardCommand{name: "xclip", args: []string{"-selection", "clipboard"}},
+			clipboardCommand{name: "xsel", args: []string{"--clipboard", "--input"}})
+	}
+	// WSL can reach the Windows clipboard.
+	return append(commands, clipboardCommand{name: "clip.exe"})
+}
+
+// CopyToClipboard puts text on the system clipboard and returns the name of
+// the backend used. Over SSH the terminal's OSC 52 support is preferred so
+// the text lands on the local machine rather than the remote host's X
+// server; otherwise native tools (pbcopy, wl-copy, xclip, xsel, clip.exe)
+// are tried first and OSC 52 is the fallback.
+func CopyToClipboard(text string) (string, error) {
+	remote := os.Getenv("SSH_TTY") != "" || os.Getenv("SSH_CONNECTION") != ""
+	if remote && clipboardTerminal() {
+		return "OSC 52", writeOSC52(text)
+	}
+	for _, command := range clipboardCommands() {
+		path, err := clipboardLookPath(command.name)
+		if err != nil {
+			continue
+		}
+		cmd := exec.Command(path, command.args...)
+		cmd.Stdin = strings.NewReader(text)
+		if err := cmd.Run(); err == nil {
+			return command.name, nil
+		}
+	}
+	if clipboardTerminal() {
+		return "OSC 52", writeOSC52(text)
+	}
+	return "", ErrNoClipboard
+}
+
+// writeOSC52 asks the terminal to set its clipboard. Inside tmux or screen
+// the sequence is wrapped so it is passed through to the outer terminal.
+func writeOSC52(text string) error {
+	seq := "\033]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+	switch {
+	case os.Getenv("TMUX") != "":
+		seq = "\033Ptmux;" + strings.ReplaceAll(seq, "\033", "\033\033") + "\033\\"
+	case strings.HasPrefix(os.Getenv("TERM"), "screen"):
+		seq = "\033P" + seq + "\033\\"
+	}
+	if _, err := io.WriteString(clipboardOut, seq); err != nil {
+		return fmt.Errorf("failed to write OSC 52 sequence: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package console
+
+import (
+	"encoding/base64"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestCodeBlocks(t *testing.T) {
+	markdown := "Intro\n```go\nfmt.Println(1)\n```\ntext\n~~~\nplain\n\nblock\n~~~\n```sh\necho open"
+	blocks := CodeBlocks(markdown)
+	want := []CodeBlock{
+		{Language: "go", Code: "fmt.Println(1)"},
+		{Code: "plain\n\nblock"},
+		{Language: "sh", Code: "echo open"},
+	}
+	if len(blocks) != len(want) {
+		t.Fatalf("expected %d blocks, got %#v", len(want), blocks)
+	}
+	for i := range want {
+		if blocks[i] != want[i] {
+			t.Errorf("block %d = %#v, want %#v", i+1, blocks[i], want[i])
+		}
+	}
+}
+
+func stubClipboard(t *testing.T, terminal bool) *strings.Builder {
+	t.Helper()
+	var out strings.Builder
+	oldLookPath, oldTerminal, oldOut := clipboardLookPath, clipboardTerminal, clipboardOut
+	clipboardLookPath = func(string) (string, error) { return "", exec.ErrNotFound }
+	clipboardTerminal = func() bool { return terminal }
+	clipboardOut = &out
+	t.Cleanup(func() {
+		clipboardLookPath, clipboardTerminal, clipboardOut = oldLookPath, oldTerminal, oldOut
+	})
+	for _, name := range []string{"SSH_TTY", "SSH_CONNECTION", "TMUX", "TERM"} {
+		t.Setenv(name, "")
+	}
+	return &out
+}
+
+func TestCopyToClipboardFallsBackToOSC52(t *testing.T) {
+	out := stubClipboard(t, true)
+
+	backend, err := CopyToClipboard("hello")
+	if err != nil {
+		t.Fatalf("CopyToClipboard: %v", err)
+	}
+	if backend != "OSC 52" {
+		t.Fatalf("expected OSC 52 backend, got %q", backend)
+	}
+	want := "\033]52;c;" + base64.StdEncoding.EncodeToString([]byte("hello")) + "\a"
+	if out.String() != want {
+		t.Fatalf("unexpected sequence %q", out.String())
+	}
+
+	out.Reset()
+	t.Setenv("TMUX", "/tmp/tmux-1000/default,1,0")
+	if _, err := CopyToClipboard("hello"); err != nil {
+		t.Fatalf("CopyToClipboard: %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "\033Ptmux;\033\033]52;c;") || !strings.HasSuffix(out.String(), "\033\\") {
+		t.Fatalf("expected tmux passthrough, got %q", out.String())
+	}
+}
+
+func TestCopyToClipboardWithoutBackend(t *testing.T) {
+	stubClipboard(t, false)
+	if _, err := CopyToClipboard("hello"); !errors.Is(err, ErrNoClipboard) {
+		t.Fatalf("expected ErrNoClipboard, got %v", err)
+	}
+}
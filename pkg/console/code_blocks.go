@@ -0,0 +1,43 @@
+package console
+
+import "strings"
+
+// CodeBlock is a fenced code block from a markdown document.
+type CodeBlock struct {
+	Language string
+	Code     string
+}
+
+// CodeBlocks returns the fenced code blocks in markdown, in order. A block
+// left open at the end of the text runs to the end.
+func CodeBlocks(markdown string) []CodeBlock {
+	var blocks []CodeBlock
+	var current *CodeBlock
+	var fence string
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(markdown, "\r\n", "\n"), "\n") {
+		if current == nil {
+			if m := fenceLine.FindStringSubmatch(line); m != nil {
+				fence = m[1]
+				current = &CodeBlock{}
+				if info := strings.Fields(m[2]); len(info) > 0 {
+					current.Language = info[0]
+				}
+				lines = nil
+			}
+			continue
+		}
+		if isClosingFence(line, fence) {
+			current.Code = strings.Join(lines, "\n")
+			blocks = append(blocks, *current)
+			current = nil
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if current != nil {
+		current.Code = strings.Join(lines, "\n")
+		blocks = append(blocks, *current)
+	}
+	return blocks
+}
@@ -0,0 +1,360 @@
+package console
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// DiffLayout is how a DiffView lays out changes.
+type DiffLayout string
+
+const (
+	// DiffUnified shows removed lines above the added lines that replace them.
+	DiffUnified DiffLayout = "unified"
+	// DiffSplit shows the old version on the left and the new one on the right.
+	DiffSplit DiffLayout = "split"
+)
+
+const (
+	// minSplitWidth is the narrowest terminal that fits two readable
+	// columns; narrower terminals get the unified layout.
+	minSplitWidth = 100
+	// diffTabWidth is how many columns a tab takes in the split layout.
+	diffTabWidth = 4
+	// minHighlightRatio is how alike two lines must be for their differing
+	// words to be highlighted rather than the lines as a whole.
+	minHighlightRatio = 0.5
+	// diffEmphasis marks the changed words of a modified line.
+	diffEmphasis = "\033[7m"
+)
+
+var (
+	diffLayoutMu     sync.RWMutex
+	activeDiffLayout = DiffUnified
+
+	diffWords  = regexp.MustCompile(`\w+|\s+|[^\w\s]`)
+	hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+)
+
+// SetDiffLayout sets the layout NewDiffView uses.
+func SetDiffLayout(layout DiffLayout) {
+	diffLayoutMu.Lock()
+	defer diffLayoutMu.Unlock()
+	activeDiffLayout = layout
+}
+
+// ActiveDiffLayout returns the layout NewDiffView uses.
+func ActiveDiffLayout() DiffLayout {
+	diffLayoutMu.RLock()
+	defer diffLayoutMu.RUnlock()
+	return activeDiffLayout
+}
+
+// ParseDiffLayout returns the layout with the given name. "side-by-side" is
+// accepted for the split layout.
+func ParseDiffLayout(name string) (DiffLayout, bool) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case string(DiffUnified):
+		return DiffUnified, true
+	case string(DiffSplit), "side-by-side":
+		return DiffSplit, true
+	}
+	return "", false
+}
+
+// DiffView renders the changes between two versions of a text, with the
+// changed words of modified lines highlighted.
+type DiffView struct {
+	Layout   DiffLayout
+	Width    int // terminal columns; the split layout needs minSplitWidth
+	Context  int // unchanged lines shown around each change
+	MaxLines int // lines to show before truncating; 0 shows everything
+}
+
+// NewDiffView returns a view in the active layout, sized to the terminal.
+func NewDiffView(maxLines int) DiffView {
+	return DiffView{Layout: ActiveDiffLayout(), Width: stdoutWidth(), Context: 3, MaxLines: maxLines}
+}
+
+// Render returns the diff from oldText to newText.
+func (v DiffView) Render(oldText, newText string) string {
+	oldLines, newLines := splitDiffLines(oldText), splitDiffLines(newText)
+	if strings.Join(oldLines, "\n") == strings.Join(newLines, "\n") {
+		return "No changes detected\n"
+	}
+	r := &diffRenderer{view: v}
+	r.hunks(oldLines, newLines, 1, 1)
+	return r.String()
+}
+
+// RenderPatch renders a unified diff, such as git diff output, in the view's
+// layout. File headers are kept; lines outside hunks pass through.
+func (v DiffView) RenderPatch(patch string) string {
+	r := &diffRenderer{view: v}
+	lines := strings.Split(strings.TrimSuffix(strings.ReplaceAll(patch, "\r\n", "\n"), "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		m := hunkHeader.FindStringSubmatch(lines[i])
+		if m == nil {
+			line := lines[i]
+			if strings.HasPrefix(line, "diff --git") && r.lines > 0 {
+				r.line("")
+			}
+			if isPatchFileHeader(line) {
+				line = r.c(ColorBold) + line + r.c(ColorReset)
+			}
+			r.line(line)
+			continue
+		}
+
+		oldStart, oldCount := hunkRange(m[1], m[2])
+		newStart, newCount := hunkRange(m[3], m[4])
+		var oldLines, newLines []string
+	body:
+		for i+1 < len(lines) && (len(oldLines) < oldCount || len(newLines) < newCount) {
+			body := lines[i+1]
+			if body == "" {
+				body = " "
+			}
+			switch body[0] {
+			case ' ':
+				oldLines = append(oldLines, body[1:])
+				newLines = append(newLines, body[1:])
+			case '-':
+				oldLines = append(oldLines, body[1:])
+			case '+':
+				newLines = append(newLines, body[1:])
+			case '\\':
+			default:
+				break body
+			}
+			i++
+		}
+		// Skip a "\ No newline at end of file" marker after the last line
+		if i+1 < len(lines) && strings.HasPrefix(lines[i+1], "\\") {
+			i++
+		}
+		r.hunks(oldLines, newLines, oldStart, newStart)
+	}
+	return r.String()
+}
+
+func isPatchFileHeader(line string) bool {
+	for _, prefix := range []string{"diff --git", "--- ", "+++ ", "new file mode", "deleted file mode", "Binary files"} {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// hunkRange parses one side of a hunk header into the number of its first
+// line and its line count. An empty side names the line before the change.
+func hunkRange(start, count string) (int, int) {
+	s, _ := strconv.Atoi(start)
+	n := 1
+	if count != "" {
+		n, _ = strconv.Atoi(count)
+	}
+	if n == 0 {
+		s++
+	}
+	return s, n
+}
+
+// splitDiffLines splits text into lines without their endings.
+func splitDiffLines(text string) []string {
+	text = strings.TrimSuffix(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+// diffRenderer accumulates rendered lines up to the view's MaxLines.
+type diffRenderer struct {
+	view      DiffView
+	sb        strings.Builder
+	lines     int
+	truncated bool
+}
+
+func (r *diffRenderer) String() string {
+	if r.truncated {
+		fmt.Fprintf(&r.sb, "... (truncated after %d lines)\n", r.view.MaxLines)
+	}
+	return r.sb.String()
+}
+
+// line adds a rendered line, reporting false once MaxLines is reached.
+func (r *diffRenderer) line(s string) bool {
+	if r.view.MaxLines > 0 && r.lines >= r.view.MaxLines {
+		r.truncated = true
+		return false
+	}
+	r.sb.WriteString(s)
+	r.sb.WriteString("\n")
+	r.lines++
+	return true
+}
+
+// c returns code when colors are enabled.
+func (r *diffRenderer) c(code string) string {
+	if !ColorsEnabled() {
+		return ""
+	}
+	return code
+}
+
+func (r *diffRenderer) split() bool {
+	return r.view.Layout == DiffSplit && r.view.Width >= minSplitWidth
+}
+
+// hunks renders the changes from oldLines to newLines, which start at lines
+// oldStart and newStart of their files.
+func (r *diffRenderer) hunks(oldLines, newLines []string, oldStart, newStart int) {
+	context := r.view.Context
+	if context < 0 {
+		context = 0
+	}
+	matcher := difflib.NewMatcher(oldLines, newLines)
+	numWidth := len(strconv.Itoa(max(oldStart+len(oldLines), newStart+len(newLines))))
+	p := ActivePalette()
+
+	for _, group := range matcher.GetGroupedOpCodes(context) {
+		first, last := group[0], group[len(group)-1]
+		header := fmt.Sprintf("@@ -%s +%s @@", hunkSpan(oldStart+first.I1, last.I2-first.I1), hunkSpan(newStart+first.J1, last.J2-first.J1))
+		if !r.line(r.c(p.Hunk) + header + r.c(ColorReset)) {
+			return
+		}
+		for _, op := range group {
+			before, after := oldLines[op.I1:op.I2], newLines[op.J1:op.J2]
+			if r.split() {
+				if !r.splitRows(op.Tag, before, after, oldStart+op.I1, newStart+op.J1, numWidth) {
+					return
+				}
+			} else if !r.unifiedRows(op.Tag, before, after) {
+				return
+			}
+		}
+	}
+}
+
+func hunkSpan(start, count int) string {
+	if count == 0 {
+		start--
+	}
+	if count == 1 {
+		return strconv.Itoa(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// unifiedRows renders one opcode as removed lines followed by added lines.
+func (r *diffRenderer) unifiedRows(tag byte, before, after []string) bool {
+	p := ActivePalette()
+	if tag == 'e' {
+		for _, line := range before {
+			if !r.line("  " + line) {
+				return false
+			}
+		}
+		return true
+	}
+	for i, line := range before {
+		if !r.line(r.highlight("- ", line, pairedLine(after, i, tag), p.Removed)) {
+			return false
+		}
+	}
+	for i, line := range after {
+		if !r.line(r.highlight("+ ", line, pairedLine(before, i, tag), p.Added)) {
+			return false
+		}
+	}
+	return true
+}
+
+// splitRows renders one opcode as rows of old and new lines side by side.
+func (r *diffRenderer) splitRows(tag byte, before, after []string, oldNo, newNo, numWidth int) bool {
+	p := ActivePalette()
+	colWidth := (r.view.Width - 3) / 2
+	textWidth := colWidth - numWidth - 3
+	for i := 0; i < max(len(before), len(after)); i++ {
+		left, right := "", ""
+		if i < len(before) {
+			text := fitDiffColumn(before[i], textWidth)
+			marker, color := "  ", ""
+			if tag != 'e' {
+				marker, color = "- ", p.Removed
+			}
+			left = fmt.Sprintf("%*d ", numWidth, oldNo+i) + r.highlight(marker, text, fitDiffColumn(pairedLine(after, i, tag), textWidth), color)
+		}
+		if i < len(after) {
+			text := fitDiffColumn(after[i], textWidth)
+			marker, color := "  ", ""
+			if tag != 'e' {
+				marker, color = "+ ", p.Added
+			}
+			right = fmt.Sprintf("%*d ", numWidth, newNo+i) + r.highlight(marker, text, fitDiffColumn(pairedLine(before, i, tag), textWidth), color)
+		}
+		if !r.line(pad(left, colWidth, "left") + " " + r.c(p.Muted) + "│" + r.c(ColorReset) + " " + right) {
+			return false
+		}
+	}
+	return true
+}
+
+// pairedLine returns the line a modified line is compared against: the one
+// at the same position on the other side of a replacement.
+func pairedLine(other []string, i int, tag byte) string {
+	if tag != 'r' || i >= len(other) {
+		return ""
+	}
+	return other[i]
+}
+
+// highlight colors a changed line. When it has a similar counterpart, only
+// the words that differ from it are emphasized.
+func (r *diffRenderer) highlight(marker, line, counterpart, color string) string {
+	if color == "" || !ColorsEnabled() {
+		return marker + line
+	}
+	if counterpart == "" {
+		return color + marker + line + ColorReset
+	}
+	words, otherWords := diffWords.FindAllString(line, -1), diffWords.FindAllString(counterpart, -1)
+	matcher := difflib.NewMatcher(words, otherWords)
+	if matcher.Ratio() < minHighlightRatio {
+		return color + marker + line + ColorReset
+	}
+
+	var sb strings.Builder
+	sb.WriteString(color + marker)
+	for _, op := range matcher.GetOpCodes() {
+		segment := strings.Join(words[op.I1:op.I2], "")
+		if segment == "" {
+			continue
+		}
+		if op.Tag == 'e' {
+			sb.WriteString(segment)
+		} else {
+			sb.WriteString(diffEmphasis + segment + ColorReset + color)
+		}
+	}
+	sb.WriteString(ColorReset)
+	return sb.String()
+}
+
+// fitDiffColumn expands tabs and cuts line to width columns.
+func fitDiffColumn(line string, width int) string {
+	line = strings.ReplaceAll(line, "\t", strings.Repeat(" ", diffTabWidth))
+	runes := []rune(line)
+	if width < 1 || len(runes) <= width {
+		return line
+	}
+	return string(runes[:width-1]) + "…"
+}
@@ -0,0 +1,105 @@
+package console
+
+import (
+	"strings"
+	"testing"
+)
+
+const (
+	diffBefore = "package main\n\nfunc main() {\n\tprintln(\"hi\")\n}\n"
+	diffAfter  = "package main\n\nfunc main() {\n\tprintln(\"hello\")\n}\n"
+)
+
+func TestDiffViewUnified(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+	v := DiffView{Layout: DiffUnified, Width: 80, Context: 1}
+
+	want := "@@ -3,3 +3,3 @@\n  func main() {\n- \tprintln(\"hi\")\n+ \tprintln(\"hello\")\n  }\n"
+	if got := v.Render(diffBefore, diffAfter); got != want {
+		t.Fatalf("unexpected unified diff\ngot:\n%s\nwant:\n%s", got, want)
+	}
+	if got := v.Render(diffBefore, diffBefore); got != "No changes detected\n" {
+		t.Fatalf("expected no changes, got %q", got)
+	}
+
+	v.MaxLines = 2
+	if got := v.Render(diffBefore, diffAfter); !strings.HasSuffix(got, "... (truncated after 2 lines)\n") || strings.Count(got, "\n") != 3 {
+		t.Fatalf("expected the diff to be truncated, got:\n%s", got)
+	}
+}
+
+func TestDiffViewSplit(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+	v := DiffView{Layout: DiffSplit, Width: 100, Context: 0}
+
+	lines := strings.Split(strings.TrimSuffix(v.Render(diffBefore, diffAfter), "\n"), "\n")
+	if len(lines) != 2 || lines[0] != "@@ -4 +4 @@" {
+		t.Fatalf("unexpected split diff %q", lines)
+	}
+	left, right, ok := strings.Cut(lines[1], " │ ")
+	if !ok || visibleWidth(left) != (100-3)/2 {
+		t.Fatalf("expected two columns, got %q", lines[1])
+	}
+	if strings.TrimSpace(left) != "4 -     println(\"hi\")" || right != "4 +     println(\"hello\")" {
+		t.Fatalf("unexpected columns %q and %q", left, right)
+	}
+
+	// Narrow terminals get the unified layout
+	v.Width = minSplitWidth - 1
+	if got := v.Render(diffBefore, diffAfter); strings.Contains(got, "│") {
+		t.Fatalf("expected a unified diff on a narrow terminal, got:\n%s", got)
+	}
+}
+
+func TestDiffViewHighlightsChangedWords(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	v := DiffView{Layout: DiffUnified, Width: 80}
+	p := ActivePalette()
+
+	got := v.Render("total := price * count\n", "total := price * quantity\n")
+	if !strings.Contains(got, p.Removed+"- total := price * "+diffEmphasis+"count"+ColorReset) {
+		t.Fatalf("expected only the changed word to be emphasized, got %q", got)
+	}
+	if !strings.Contains(got, diffEmphasis+"quantity"+ColorReset+p.Added) {
+		t.Fatalf("expected the added word to be emphasized, got %q", got)
+	}
+
+	// Unrelated lines are colored as a whole
+	got = v.Render("alpha beta\n", "gamma delta\n")
+	if strings.Contains(got, diffEmphasis) || !strings.Contains(got, p.Added+"+ gamma delta"+ColorReset) {
+		t.Fatalf("expected whole-line colors for unrelated lines, got %q", got)
+	}
+}
+
+func TestDiffViewRenderPatch(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+	patch := "diff --git a/main.go b/main.go\n--- a/main.go\n+++ b/main.go\n" +
+		"@@ -10,3 +10,4 @@ func main() {\n a\n-b\n+c\n+d\n e\n" +
+		"diff --git a/notes.txt b/notes.txt\nnew file mode 100644\n--- /dev/null\n+++ b/notes.txt\n" +
+		"@@ -0,0 +1 @@\n+no newline\n\\ No newline at end of file\n"
+
+	want := "diff --git a/main.go b/main.go\n--- a/main.go\n+++ b/main.go\n" +
+		"@@ -10,3 +10,4 @@\n  a\n- b\n+ c\n+ d\n  e\n" +
+		"\ndiff --git a/notes.txt b/notes.txt\nnew file mode 100644\n--- /dev/null\n+++ b/notes.txt\n" +
+		"@@ -0,0 +1 @@\n+ no newline\n"
+	if got := (DiffView{Layout: DiffUnified, Width: 80, Context: 3}).RenderPatch(patch); got != want {
+		t.Fatalf("unexpected rendered patch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Split rows are numbered from the hunk's position in the file
+	got := (DiffView{Layout: DiffSplit, Width: 120, Context: 3}).RenderPatch(patch)
+	if !strings.Contains(got, "11 - b") || !strings.Contains(got, "12 + d") || !strings.Contains(got, " 1 + no newline") {
+		t.Fatalf("expected file line numbers, got:\n%s", got)
+	}
+}
+
+func TestParseDiffLayout(t *testing.T) {
+	for name, want := range map[string]DiffLayout{"unified": DiffUnified, "Split": DiffSplit, " side-by-side": DiffSplit} {
+		if got, ok := ParseDiffLayout(name); !ok || got != want {
+			t.Errorf("ParseDiffLayout(%q) = %q, %v; want %q", name, got, ok, want)
+		}
+	}
+	if _, ok := ParseDiffLayout("stacked"); ok {
+		t.Error("expected an unknown layout to be rejected")
+	}
+}
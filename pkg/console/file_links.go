@@ -0,0 +1,290 @@
+package console
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// recentFileRefLimit is how many file references the quick-open list keeps.
+const recentFileRefLimit = 20
+
+// fileRefWord matches a path-like word with an optional :line or
+// :line:column suffix.
+var (
+	fileRefWord   = regexp.MustCompile(`[\w.~/-]+(?::(\d+)(?::\d+)?)?`)
+	fileRefWordAt = regexp.MustCompile(`^` + fileRefWord.String())
+)
+
+// FileRef is a file named in the output, with the line it points at when the
+// reference had one.
+type FileRef struct {
+	Path string // absolute path
+	Line int
+}
+
+// String returns the path relative to the working directory when it is
+// inside it, with the line.
+func (r FileRef) String() string {
+	path := r.Path
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, path); err == nil && !strings.HasPrefix(rel, "..") {
+			path = rel
+		}
+	}
+	if r.Line > 0 {
+		return path + ":" + strconv.Itoa(r.Line)
+	}
+	return path
+}
+
+var fileLinks = struct {
+	mu         sync.Mutex
+	hyperlinks bool
+	editor     string
+	recent     []FileRef
+}{hyperlinks: true}
+
+// SetHyperlinks turns OSC 8 hyperlinks for file references in the output on
+// or off.
+func SetHyperlinks(enabled bool) {
+	fileLinks.mu.Lock()
+	defer fileLinks.mu.Unlock()
+	fileLinks.hyperlinks = enabled
+}
+
+func hyperlinksEnabled() bool {
+	fileLinks.mu.Lock()
+	defer fileLinks.mu.Unlock()
+	return fileLinks.hyperlinks && os.Getenv("TERM") != "dumb"
+}
+
+// SetEditorCommand sets the command files from the output are opened with,
+// such as "code -g {file}:{line}". {file} is replaced with the path and
+// {line} with the line number; without {file} the path is added at the end.
+// An empty command falls back to $VISUAL or $EDITOR.
+func SetEditorCommand(command string) {
+	fileLinks.mu.Lock()
+	defer fileLinks.mu.Unlock()
+	fileLinks.editor = strings.TrimSpace(command)
+}
+
+func editorCommandTemplate() string {
+	fileLinks.mu.Lock()
+	defer fileLinks.mu.Unlock()
+	return fileLinks.editor
+}
+
+// expandEditorCommand fills in the placeholders of an editor command
+// template.
+func expandEditorCommand(template, path string, line int) []string {
+	if line <= 0 {
+		line = 1
+	}
+	fields := strings.Fields(template)
+	hasFile := false
+	for i, field := range fields {
+		if strings.Contains(field, "{file}") {
+			hasFile = true
+		}
+		fields[i] = strings.NewReplacer("{file}", path, "{line}", strconv.Itoa(line)).Replace(field)
+	}
+	if !hasFile {
+		fields = append(fields, path)
+	}
+	return fields
+}
+
+// RecentFileRefs returns the files most recently named in the output, newest
+// first, for the quick-open list.
+func RecentFileRefs() []FileRef {
+	fileLinks.mu.Lock()
+	defer fileLinks.mu.Unlock()
+	refs := make([]FileRef, 0, len(fileLinks.recent))
+	for i := len(fileLinks.recent) - 1; i >= 0; i-- {
+		refs = append(refs, fileLinks.recent[i])
+	}
+	return refs
+}
+
+// recordFileRefs adds the files named in a line of output to the quick-open
+// list.
+func recordFileRefs(line string) {
+	if !strings.ContainsAny(line, "./") {
+		return
+	}
+	root, err := os.Getwd()
+	if err != nil {
+		return
+	}
+	matches := findFileRefs(line, root)
+	if len(matches) == 0 {
+		return
+	}
+
+	fileLinks.mu.Lock()
+	defer fileLinks.mu.Unlock()
+	for _, m := range matches {
+		for i, ref := range fileLinks.recent {
+			if ref == m.ref {
+				fileLinks.recent = append(fileLinks.recent[:i], fileLinks.recent[i+1:]...)
+				break
+			}
+		}
+		fileLinks.recent = append(fileLinks.recent, m.ref)
+	}
+	if over := len(fileLinks.recent) - recentFileRefLimit; over > 0 {
+		fileLinks.recent = append([]FileRef(nil), fileLinks.recent[over:]...)
+	}
+}
+
+type fileRefMatch struct {
+	start, end int // byte offsets of the reference in the text
+	ref        FileRef
+}
+
+// findFileRefs returns the references in text to files that exist, resolved
+// against root. Words without a slash or a dot are never paths.
+func findFileRefs(text, root string) []fileRefMatch {
+	var matches []fileRefMatch
+	for _, loc := range fileRefWord.FindAllStringSubmatchIndex(text, -1) {
+		start, end := loc[0], loc[1]
+		if start > 0 && text[start-1] == ':' {
+			continue // part of a URL or a longer reference
+		}
+		if k := strings.LastIndex(text[:start], "\033["); k >= 0 && strings.Trim(text[k+2:start], "0123456789;") == "" {
+			// The word starts with the end of a color escape
+			rest := strings.TrimLeft(text[start:end], "0123456789")
+			if !strings.HasPrefix(rest, "m") {
+				continue
+			}
+			start = end - len(rest) + 1
+		}
+		word, line := text[start:end], 0
+		if loc[2] >= 0 {
+			line, _ = strconv.Atoi(text[loc[2]:loc[3]])
+			word = text[start : strings.IndexByte(text[start:end], ':')+start]
+		} else {
+			word = strings.TrimRight(word, ".-")
+			end = start + len(word)
+		}
+		if !strings.ContainsAny(word, "./") || strings.HasPrefix(word, "//") {
+			continue
+		}
+		if path, ok := resolveFilePath(word, root); ok {
+			matches = append(matches, fileRefMatch{start: start, end: end, ref: FileRef{Path: path, Line: line}})
+		}
+	}
+	return matches
+}
+
+// ParseFileRef parses a path, path:line or path:line:column argument naming
+// an existing file, resolved against root.
+func ParseFileRef(arg, root string) (FileRef, bool) {
+	word, line := splitPathLine(arg)
+	path, ok := resolveFilePath(word, root)
+	if !ok {
+		return FileRef{}, false
+	}
+	return FileRef{Path: path, Line: line}, true
+}
+
+// fileRefAt returns the reference to an existing file that text starts
+// with, if there is one.
+func fileRefAt(text, root string) (fileRefMatch, bool) {
+	loc := fileRefWordAt.FindStringIndex(text)
+	if loc == nil {
+		return fileRefMatch{}, false
+	}
+	matches := findFileRefs(text[:loc[1]], root)
+	if len(matches) == 0 || matches[0].start != 0 {
+		return fileRefMatch{}, false
+	}
+	return matches[0], true
+}
+
+// isPathByte reports whether b can be part of a path in the output.
+func isPathByte(b byte) bool {
+	return isWordByte(b) || b == '.' || b == '~' || b == '/' || b == '-'
+}
+
+// resolveFilePath returns the absolute path of a file named in the output,
+// resolved against root. Git's a/ and b/ prefixes are dropped when the path
+// doesn't exist as written.
+func resolveFilePath(word, root string) (string, bool) {
+	if rest, ok := strings.CutPrefix(word, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			word = filepath.Join(home, rest)
+		}
+	}
+	candidates := []string{word}
+	if rest, ok := strings.CutPrefix(word, "a/"); ok {
+		candidates = append(candidates, rest)
+	} else if rest, ok := strings.CutPrefix(word, "b/"); ok {
+		candidates = append(candidates, rest)
+	}
+	for _, candidate := range candidates {
+		if candidate == "" {
+			continue
+		}
+		if !filepath.IsAbs(candidate) {
+			candidate = filepath.Join(root, candidate)
+		}
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
+// LinkFileRefs wraps the references in text to existing files in OSC 8
+// hyperlinks, which terminals that support them make clickable. Text is
+// returned as is when hyperlinks are off.
+func LinkFileRefs(text string) string {
+	if !hyperlinksEnabled() || !ColorsEnabled() || !strings.ContainsAny(text, "./") {
+		return text
+	}
+	root, err := os.Getwd()
+	if err != nil {
+		return text
+	}
+	return linkFileRefs(text, root)
+}
+
+func linkFileRefs(text, root string) string {
+	matches := findFileRefs(text, root)
+	if len(matches) == 0 {
+		return text
+	}
+	var sb strings.Builder
+	last := 0
+	for _, m := range matches {
+		sb.WriteString(text[last:m.start])
+		sb.WriteString(hyperlink(fileURL(m.ref), text[m.start:m.end]))
+		last = m.end
+	}
+	sb.WriteString(text[last:])
+	return sb.String()
+}
+
+// hyperlink wraps text in an OSC 8 hyperlink to target.
+func hyperlink(target, text string) string {
+	return "\033]8;;" + target + "\033\\" + text + "\033]8;;\033\\"
+}
+
+// fileURL returns the file:// URL for a reference. The line goes in the
+// fragment, which editors and terminals that understand it use to jump there.
+func fileURL(ref FileRef) string {
+	u := url.URL{Scheme: "file", Path: filepath.ToSlash(ref.Path)}
+	if host, err := os.Hostname(); err == nil {
+		u.Host = host
+	}
+	if ref.Line > 0 {
+		u.Fragment = strconv.Itoa(ref.Line)
+	}
+	return u.String()
+}
@@ -0,0 +1,130 @@
+package console
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// chdirWithFile switches to a temporary directory holding pkg/main.go.
+func chdirWithFile(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "pkg"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "pkg", "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(root)
+	// Resolve symlinks in the temp dir the way os.Getwd reports it
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return wd
+}
+
+func TestFindFileRefs(t *testing.T) {
+	root := chdirWithFile(t)
+	file := filepath.Join(root, "pkg", "main.go")
+
+	text := "See pkg/main.go:12:3, \033[1;32mpkg/main.go\033[0m and https://example.com/pkg/main.go, not pkg/other.go or v1.2."
+	matches := findFileRefs(text, root)
+	if len(matches) != 2 {
+		t.Fatalf("expected two references, got %+v", matches)
+	}
+	if got := text[matches[0].start:matches[0].end]; got != "pkg/main.go:12:3" || matches[0].ref != (FileRef{Path: file, Line: 12}) {
+		t.Errorf("unexpected first reference %q %+v", got, matches[0].ref)
+	}
+	if got := text[matches[1].start:matches[1].end]; got != "pkg/main.go" || matches[1].ref.Line != 0 {
+		t.Errorf("unexpected colored reference %q %+v", got, matches[1].ref)
+	}
+
+	if ref, ok := ParseFileRef("b/pkg/main.go:7", root); !ok || ref != (FileRef{Path: file, Line: 7}) {
+		t.Errorf("ParseFileRef = %+v, %v", ref, ok)
+	}
+	if ref := (FileRef{Path: file, Line: 7}); ref.String() != filepath.Join("pkg", "main.go")+":7" {
+		t.Errorf("expected a relative path, got %q", ref.String())
+	}
+}
+
+func TestLinkFileRefs(t *testing.T) {
+	root := chdirWithFile(t)
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("TERM", "xterm-256color")
+
+	got := LinkFileRefs("Edited pkg/main.go:3.\n")
+	want := "Edited " + hyperlink(fileURL(FileRef{Path: filepath.Join(root, "pkg", "main.go"), Line: 3}), "pkg/main.go:3") + ".\n"
+	if got != want {
+		t.Fatalf("unexpected linked text %q", got)
+	}
+	if !strings.Contains(got, "#3\033\\") || stripANSIEscapeCodes(got) != "Edited pkg/main.go:3.\n" {
+		t.Fatalf("expected a link to line 3 that strips to the plain text, got %q", got)
+	}
+
+	SetHyperlinks(false)
+	t.Cleanup(func() { SetHyperlinks(true) })
+	if got := LinkFileRefs("pkg/main.go"); got != "pkg/main.go" {
+		t.Fatalf("expected no links when hyperlinks are off, got %q", got)
+	}
+}
+
+func TestStreamingFormatterLinksFileRefs(t *testing.T) {
+	chdirWithFile(t)
+	t.Setenv("TERM", "xterm-256color")
+
+	var out strings.Builder
+	f := NewStreamingFormatter(&out)
+	f.SetColors(true)
+	f.SetWidth(80)
+	f.Write("Fixed `pkg/main.go:2` and pkg/main.go in | a table |\n")
+	f.Flush()
+
+	if n := strings.Count(out.String(), "\033]8;;file://"); n != 2 {
+		t.Fatalf("expected the code span and the plain path to be linked, got %d links in %q", n, out.String())
+	}
+	if visibleWidth(hyperlink("file:///x", "abc")) != 3 {
+		t.Fatal("expected hyperlinks to take no columns")
+	}
+}
+
+func TestRecordOutputRemembersFileRefs(t *testing.T) {
+	root := chdirWithFile(t)
+	resetScrollback(t)
+	fileLinks.mu.Lock()
+	saved := fileLinks.recent
+	fileLinks.recent = nil
+	fileLinks.mu.Unlock()
+	t.Cleanup(func() {
+		fileLinks.mu.Lock()
+		fileLinks.recent = saved
+		fileLinks.mu.Unlock()
+	})
+	if err := os.WriteFile(filepath.Join(root, "README.md"), []byte("# hi\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	RecordOutput("read pkg/main.go:4 then README.md\n")
+	RecordOutput("pkg/main.go:4 again, and pkg/main.go")
+	refs := RecentFileRefs()
+	if len(refs) != 2 || !strings.HasSuffix(refs[0].Path, "README.md") || refs[1].Line != 4 {
+		t.Fatalf("expected the files of completed lines, newest first, got %+v", refs)
+	}
+
+	RecordOutput("\n")
+	refs = RecentFileRefs()
+	if len(refs) != 3 || refs[0].Line != 0 || refs[1].Line != 4 || !strings.HasSuffix(refs[2].Path, "README.md") {
+		t.Fatalf("expected a mentioned file to move to the top, got %+v", refs)
+	}
+}
+
+func TestExpandEditorCommand(t *testing.T) {
+	if got := expandEditorCommand("code -g {file}:{line}", "/src/main.go", 12); strings.Join(got, " ") != "code -g /src/main.go:12" {
+		t.Errorf("unexpected command %q", got)
+	}
+	if got := expandEditorCommand("subl", "/src/main.go", 0); strings.Join(got, " ") != "subl /src/main.go" {
+		t.Errorf("expected the path to be appended, got %q", got)
+	}
+}
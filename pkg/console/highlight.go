@@ -0,0 +1,277 @@
+package console
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// languageSpec describes the lexical features the code highlighter colors.
+type languageSpec struct {
+	keywords     map[string]bool
+	constants    map[string]bool
+	lineComments []string
+	blockComment [2]string
+	quotes       string
+	// keyColon colors identifiers and strings followed by ":" as keys, as in
+	// JSON and YAML.
+	keyColon bool
+	// diff colors whole lines by their +/- prefix.
+	diff bool
+}
+
+func wordSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range strings.Fields(words) {
+		set[w] = true
+	}
+	return set
+}
+
+var (
+	cConstants = wordSet("true false NULL nullptr")
+
+	languageSpecs = map[string]*languageSpec{
+		"go": {
+			keywords:     wordSet("break case chan const continue default defer else fallthrough for func go goto if import interface map package range return select struct switch type var"),
+			constants:    wordSet("true false nil iota"),
+			lineComments: []string{"//"},
+			blockComment: [2]string{"/*", "*/"},
+			quotes:       "\"'`",
+		},
+		"python": {
+			keywords:     wordSet("and as assert async await break class continue def del elif else except finally for from global if import in is lambda nonlocal not or pass raise return try while with yield"),
+			constants:    wordSet("True False None self"),
+			lineComments: []string{"#"},
+			quotes:       "\"'",
+		},
+		"javascript": {
+			keywords:     wordSet("async await break case catch class const continue debugger default delete do else export extends finally for from function if import in instanceof let new of return static super switch this throw try typeof var void while yield interface type enum implements public private protected readonly as"),
+			constants:    wordSet("true false null undefined NaN"),
+			lineComments: []string{"//"},
+			blockComment: [2]string{"/*", "*/"},
+			quotes:       "\"'`",
+		},
+		"shell": {
+			keywords:     wordSet("if then else elif fi for while until do done case esac function in return export local readonly set unset source echo cd exit"),
+			constants:    wordSet("true false"),
+			lineComments: []string{"#"},
+			quotes:       "\"'",
+		},
+		"json": {
+			constants: wordSet("true false null"),
+			quotes:    "\"",
+			keyColon:  true,
+		},
+		"yaml": {
+			constants:    wordSet("true false null yes no on off"),
+			lineComments: []string{"#"},
+			quotes:       "\"'",
+			keyColon:     true,
+		},
+		"rust": {
+			keywords:     wordSet("as async await break const continue crate dyn else enum extern fn for if impl in let loop match mod move mut pub ref return self Self static struct super trait type unsafe use where while"),
+			constants:    wordSet("true false None Some Ok Err"),
+			lineComments: []string{"//"},
+			blockComment: [2]string{"/*", "*/"},
+			quotes:       "\"",
+		},
+		"java": {
+			keywords:     wordSet("abstract break case catch class continue default do else enum extends final finally for if implements import instanceof interface new package private protected public return static super switch synchronized this throw throws try void volatile while val var fun object when"),
+			constants:    wordSet("true false null"),
+			lineComments: []string{"//"},
+			blockComment: [2]string{"/*", "*/"},
+			quotes:       "\"'",
+		},
+		"c": {
+			keywords:     wordSet("auto break case char class const continue default delete do double else enum extern float for goto if inline int long namespace new private protected public register return short signed sizeof static struct switch template this typedef typename union unsigned using virtual void volatile while #include #define #ifdef #ifndef #endif #if #else #pragma"),
+			constants:    cConstants,
+			lineComments: []string{"//"},
+			blockComment: [2]string{"/*", "*/"},
+			quotes:       "\"'",
+		},
+		"ruby": {
+			keywords:     wordSet("begin class def do else elsif end ensure if module next redo rescue retry return self super then unless until when while yield require"),
+			constants:    wordSet("true false nil"),
+			lineComments: []string{"#"},
+			quotes:       "\"'",
+		},
+		"sql": {
+			keywords:     wordSet("SELECT FROM WHERE INSERT INTO VALUES UPDATE SET DELETE CREATE TABLE ALTER DROP INDEX JOIN LEFT RIGHT INNER OUTER ON AND OR NOT IN IS AS GROUP BY ORDER HAVING LIMIT OFFSET UNION PRIMARY KEY FOREIGN REFERENCES DISTINCT select from where insert into values update set delete create table alter drop index join left right inner outer on and or not in is as group by order having limit offset union primary key foreign references distinct"),
+			constants:    wordSet("NULL TRUE FALSE null true false"),
+			lineComments: []string{"--"},
+			blockComment: [2]string{"/*", "*/"},
+			quotes:       "'\"",
+		},
+		"diff":    {diff: true},
+		"generic": {quotes: "\"'"},
+	}
+
+	languageAliases = map[string]string{
+		"golang": "go",
+		"py":     "python", "python3": "python",
+		"js": "javascript", "jsx": "javascript", "ts": "javascript", "tsx": "javascript", "typescript": "javascript", "mjs": "javascript",
+		"sh": "shell", "bash": "shell", "zsh": "shell", "console": "shell", "shell-session": "shell",
+		"yml":    "yaml",
+		"rs":     "rust",
+		"kotlin": "java", "kt": "java", "scala": "java", "csharp": "java", "cs": "java",
+		"cpp": "c", "c++": "c", "h": "c", "hpp": "c", "cc": "c", "objc": "c",
+		"rb":    "ruby",
+		"patch": "diff",
+	}
+)
+
+// lookupLanguage returns the spec for a fenced code block's info string.
+func lookupLanguage(info string) *languageSpec {
+	lang := strings.ToLower(strings.TrimSpace(info))
+	if fields := strings.Fields(lang); len(fields) > 0 {
+		lang = fields[0]
+	}
+	if alias, ok := languageAliases[lang]; ok {
+		lang = alias
+	}
+	if spec, ok := languageSpecs[lang]; ok {
+		return spec
+	}
+	return languageSpecs["generic"]
+}
+
+// codeHighlighter colors the lines of one code block. It keeps state so a
+// block comment spanning lines stays colored.
+type codeHighlighter struct {
+	spec           *languageSpec
+	palette        Palette
+	inBlockComment bool
+}
+
+// newCodeHighlighter returns a highlighter for lang using the palette's
+// syntax colors; constants use its accent and keys its info color.
+func newCodeHighlighter(lang string, palette Palette) *codeHighlighter {
+	return &codeHighlighter{spec: lookupLanguage(lang), palette: palette}
+}
+
+// Line returns line with ANSI syntax colors.
+func (h *codeHighlighter) Line(line string) string {
+	spec, p := h.spec, h.palette
+	if spec.diff {
+		switch {
+		case strings.HasPrefix(line, "+"):
+			return p.Added + line + ColorReset
+		case strings.HasPrefix(line, "-"):
+			return p.Removed + line + ColorReset
+		case strings.HasPrefix(line, "@@"):
+			return p.Hunk + line + ColorReset
+		}
+		return line
+	}
+
+	var out strings.Builder
+	i := 0
+	for i < len(line) {
+		if h.inBlockComment {
+			end := strings.Index(line[i:], spec.blockComment[1])
+			if end < 0 {
+				out.WriteString(p.Comment + line[i:] + ColorReset)
+				return out.String()
+			}
+			end += i + len(spec.blockComment[1])
+			out.WriteString(p.Comment + line[i:end] + ColorReset)
+			h.inBlockComment = false
+			i = end
+			continue
+		}
+
+		rest := line[i:]
+		if spec.blockComment[0] != "" && strings.HasPrefix(rest, spec.blockComment[0]) {
+			h.inBlockComment = true
+			out.WriteString(p.Comment + spec.blockComment[0])
+			out.WriteString(ColorReset)
+			i += len(spec.blockComment[0])
+			continue
+		}
+		if hasLineComment(spec, line, i) {
+			out.WriteString(p.Comment + rest + ColorReset)
+			return out.String()
+		}
+
+		r, size := utf8.DecodeRuneInString(rest)
+		switch {
+		case strings.ContainsRune(spec.quotes, r):
+			end := stringEnd(line, i, byte(r))
+			color := p.String
+			if spec.keyColon && strings.HasPrefix(strings.TrimLeft(line[end:], " "), ":") {
+				color = p.Info
+			}
+			out.WriteString(color + line[i:end] + ColorReset)
+			i = end
+		case unicode.IsDigit(r):
+			end := i
+			for end < len(line) && (isWordByte(line[end]) || line[end] == '.') {
+				end++
+			}
+			out.WriteString(p.Number + line[i:end] + ColorReset)
+			i = end
+		case isWordStart(r):
+			end := i + size
+			for end < len(line) && (isWordByte(line[end]) || (spec.keyColon && (line[end] == '-' || line[end] == '.'))) {
+				end++
+			}
+			word := line[i:end]
+			switch {
+			case spec.keyColon && strings.HasPrefix(line[end:], ":"):
+				out.WriteString(p.Info + word + ColorReset)
+			case spec.keywords[word]:
+				out.WriteString(p.Keyword + word + ColorReset)
+			case spec.constants[word]:
+				out.WriteString(p.Accent + word + ColorReset)
+			default:
+				out.WriteString(word)
+			}
+			i = end
+		default:
+			out.WriteString(rest[:size])
+			i += size
+		}
+	}
+	return out.String()
+}
+
+// hasLineComment reports whether a line comment starts at line[i]. "#" only
+// starts a comment at the beginning of a line or after whitespace, so shell
+// expansions like ${#var} and URLs with fragments are left alone.
+func hasLineComment(spec *languageSpec, line string, i int) bool {
+	for _, marker := range spec.lineComments {
+		if !strings.HasPrefix(line[i:], marker) {
+			continue
+		}
+		if marker == "#" && i > 0 && line[i-1] != ' ' && line[i-1] != '\t' {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// stringEnd returns the index just past the string literal starting at
+// line[start], or len(line) when it is not closed on this line.
+func stringEnd(line string, start int, quote byte) int {
+	for i := start + 1; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			if quote != '`' {
+				i++
+			}
+		case quote:
+			return i + 1
+		}
+	}
+	return len(line)
+}
+
+func isWordStart(r rune) bool {
+	return r == '_' || r == '#' || unicode.IsLetter(r)
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || b >= 0x80 || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || (b >= '0' && b <= '9')
+}
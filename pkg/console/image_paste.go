@@ -4,8 +4,11 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"time"
 )
 
@@ -113,3 +116,80 @@ func SavePastedImage(data []byte, baseDir string) (string, error) {
 	relativePath := "./" + filepath.Join(PastedImageDirName, filename)
 	return relativePath, nil
 }
+
+// imageExtensions are the file extensions of the formats DetectImageMagic knows.
+var imageExtensions = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true,
+	".webp": true, ".bmp": true, ".avif": true,
+}
+
+// PastedImagePath reports whether pasted text is the path of an existing
+// image file, as terminals insert when a file is dragged onto them. Quoted
+// paths, backslash-escaped spaces, "~/" and file:// URLs are accepted.
+func PastedImagePath(text string) (string, bool) {
+	path := strings.TrimSpace(text)
+	if path == "" || strings.ContainsAny(path, "\n\r") {
+		return "", false
+	}
+	if len(path) >= 2 && (path[0] == '\'' || path[0] == '"') && path[len(path)-1] == path[0] {
+		path = path[1 : len(path)-1]
+	} else if runtime.GOOS != "windows" && strings.Contains(path, "\\") {
+		path = unescapeShellPath(path)
+	}
+	if strings.HasPrefix(path, "file://") {
+		u, err := url.Parse(path)
+		if err != nil {
+			return "", false
+		}
+		path = u.Path
+	}
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[2:])
+		}
+	}
+
+	// Dragged files always arrive as absolute paths; a relative path is
+	// more likely text the user meant to type.
+	if !filepath.IsAbs(path) || !imageExtensions[strings.ToLower(filepath.Ext(path))] {
+		return "", false
+	}
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return "", false
+	}
+	return path, true
+}
+
+// unescapeShellPath removes the backslash escapes terminals add to dragged
+// paths, e.g. "My\ Screenshot.png".
+func unescapeShellPath(path string) string {
+	var b strings.Builder
+	escaped := false
+	for _, r := range path {
+		if r == '\\' && !escaped {
+			escaped = true
+			continue
+		}
+		escaped = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// SaveImageFile copies the image at path into .ledit/pasted-images/ like a
+// pasted image. Files over MaxPastedImageSize are rejected.
+func SaveImageFile(path, baseDir string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if info.Size() > MaxPastedImageSize {
+		return "", fmt.Errorf("image is %d bytes, over the %d byte limit; use /attach to downscale it", info.Size(), MaxPastedImageSize)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return SavePastedImage(data, baseDir)
+}
@@ -366,3 +366,77 @@ func TestSavePastedImage_WithBaseDir(t *testing.T) {
 	}
 }
 
+
+func TestPastedImagePath(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "My Shots")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	imgPath := filepath.Join(dir, "screen shot.png")
+	if err := os.WriteFile(imgPath, []byte{0x89, 0x50, 0x4E, 0x47, 0x0D, 0x0A, 0x1A, 0x0A}, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	textPath := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(textPath, []byte("notes"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	accepted := []string{
+		imgPath,
+		"  " + imgPath + "\n",
+		"'" + imgPath + "'",
+		`"` + imgPath + `"`,
+		"file://" + strings.ReplaceAll(imgPath, " ", "%20"),
+	}
+	if filepath.Separator == '/' {
+		accepted = append(accepted, strings.ReplaceAll(imgPath, " ", `\ `))
+	}
+	for _, text := range accepted {
+		if got, ok := PastedImagePath(text); !ok || got != imgPath {
+			t.Errorf("PastedImagePath(%q) = %q, %v; want %q", text, got, ok, imgPath)
+		}
+	}
+
+	rejected := []string{
+		"",
+		textPath,
+		filepath.Join(dir, "missing.png"),
+		"screen shot.png",
+		imgPath + "\n" + imgPath,
+		"look at " + imgPath,
+	}
+	for _, text := range rejected {
+		if got, ok := PastedImagePath(text); ok {
+			t.Errorf("PastedImagePath(%q) = %q, want no match", text, got)
+		}
+	}
+}
+
+func TestSaveImageFile(t *testing.T) {
+	base := t.TempDir()
+	src := filepath.Join(t.TempDir(), "shot.png")
+	data := []byte{0x89, 0x50, 0x4E, 0x47, 0x0D, 0x0A, 0x1A, 0x0A, 0x01}
+	if err := os.WriteFile(src, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := SaveImageFile(src, base)
+	if err != nil {
+		t.Fatalf("SaveImageFile: %v", err)
+	}
+	if !strings.HasPrefix(saved, "./"+PastedImageDirName+"/") || !strings.HasSuffix(saved, ".png") {
+		t.Errorf("unexpected saved path %q", saved)
+	}
+	copied, err := os.ReadFile(filepath.Join(base, saved))
+	if err != nil || string(copied) != string(data) {
+		t.Errorf("copied image = %v, %v", copied, err)
+	}
+
+	big := filepath.Join(t.TempDir(), "big.png")
+	if err := os.WriteFile(big, make([]byte, MaxPastedImageSize+1), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SaveImageFile(big, base); err == nil || !strings.Contains(err.Error(), "/attach") {
+		t.Errorf("expected size cap error mentioning /attach, got %v", err)
+	}
+}
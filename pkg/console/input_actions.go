@@ -0,0 +1,183 @@
+package console
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// Cursor shapes (DECSCUSR) marking vim normal and insert mode.
+const (
+	cursorShapeDefault = "\033[0 q"
+	cursorShapeBlock   = "\033[2 q"
+	cursorShapeBar     = "\033[6 q"
+)
+
+// runAction applies an editing action bound to event's key. Unbound
+// printable characters are inserted, except in vim normal mode where they
+// are commands.
+func (ir *InputReader) runAction(action KeyAction, event *InputEvent) {
+	if ir.vimNormal && event.Type == EventChar {
+		ir.vimNormalKey(event.Data)
+		return
+	}
+	switch action {
+	case ActionCursorLeft:
+		ir.MoveCursor(-1)
+	case ActionCursorRight:
+		ir.MoveCursor(1)
+	case ActionWordLeft:
+		ir.SetCursor(previousWordStart(ir.line, ir.cursorPos))
+	case ActionWordRight:
+		ir.SetCursor(nextWordStart(ir.line, ir.cursorPos))
+	case ActionLineStart:
+		ir.SetCursor(0)
+	case ActionLineEnd:
+		ir.SetCursor(len(ir.line))
+	case ActionHistoryPrev:
+		ir.HandleEvent(&InputEvent{Type: EventUp})
+	case ActionHistoryNext:
+		ir.HandleEvent(&InputEvent{Type: EventDown})
+	case ActionDeleteBackward:
+		ir.Backspace()
+	case ActionDeleteForward:
+		ir.Delete()
+	case ActionDeleteWordBackward:
+		ir.deleteRange(previousWordStart(ir.line, ir.cursorPos), ir.cursorPos)
+	case ActionKillToEnd:
+		ir.deleteRange(ir.cursorPos, len(ir.line))
+	case ActionKillToStart:
+		ir.deleteRange(0, ir.cursorPos)
+	case ActionNewline:
+		ir.InsertChar("\n")
+	case ActionCancel:
+		ir.HandleEvent(&InputEvent{Type: EventEscape})
+	case ActionVimNormal:
+		ir.HandleEvent(&InputEvent{Type: EventEscape})
+		ir.setVimNormal(true)
+	case ActionNone:
+		if event.Type == EventChar {
+			ir.HandleEvent(event)
+		}
+	}
+}
+
+// vimNormalKey runs a vim normal mode command. Unknown keys are ignored.
+func (ir *InputReader) vimNormalKey(key string) {
+	action, ok := vimNormalKeys[key]
+	if !ok {
+		return
+	}
+	ir.runAction(action, &InputEvent{Type: EventKey, Data: key})
+	if vimInsertAfter[key] {
+		ir.setVimNormal(false)
+	}
+}
+
+// setVimNormal switches between vim normal and insert mode, shown by a
+// block or bar cursor.
+func (ir *InputReader) setVimNormal(normal bool) {
+	ir.vimNormal = normal
+	if normal {
+		fmt.Print(cursorShapeBlock)
+	} else {
+		fmt.Print(cursorShapeBar)
+	}
+}
+
+// deleteRange removes line[start:end] and leaves the cursor at start.
+func (ir *InputReader) deleteRange(start, end int) {
+	if start < 0 || end > len(ir.line) || start >= end {
+		return
+	}
+	// Offsets of collapsed pastes would no longer line up; show them in full.
+	ir.collapsedPastes = ir.collapsedPastes[:0]
+	ir.hasEditedLine = true
+	ir.historyIndex = -1
+	ir.line = ir.line[:start] + ir.line[end:]
+	ir.cursorPos = start
+	ir.Refresh()
+}
+
+// interrupt abandons the current input.
+func (ir *InputReader) interrupt() (string, error) {
+	ir.focusInput()
+	fmt.Printf("\r%s", ClearToEndOfLineSeq()) // Clear line
+	fmt.Println("^C")
+	return "", fmt.Errorf("interrupted")
+}
+
+// suspend stops the process like a shell's Ctrl+Z and restores raw mode
+// once it is resumed. It returns the terminal state to restore later.
+func (ir *InputReader) suspend(oldState *term.State, nonBlocking bool) *term.State {
+	ir.focusInput()
+	// Re-enter cooked mode before suspension so the shell
+	// state is clean while the user is away.
+	if ir.mouse {
+		fmt.Print(MouseTrackingDisable)
+	}
+	restoreTerminal(ir.termFd, oldState)
+	suspendTerminal()
+
+	// Execution resumes here after SIGCONT (e.g. "fg").
+	ignoreTerminalSignals()
+
+	// Drain any bytes that arrived while suspended (e.g.
+	// keystrokes, newline from "fg" command).  Only possible
+	// when the fd is in non-blocking mode; otherwise there is
+	// no safe way to poll without blocking indefinitely.
+	if nonBlocking {
+		time.Sleep(50 * time.Millisecond)
+		discardBuf := make([]byte, 256)
+		for {
+			n, _ := os.Stdin.Read(discardBuf)
+			if n <= 0 {
+				break
+			}
+		}
+	}
+
+	// Re-enter raw mode.
+	if newState, err := makeRaw(ir.termFd); err == nil {
+		oldState = newState
+	}
+
+	// Re-enable bracketed paste mode (lost when we exited raw mode).
+	fmt.Print(bracketedPasteEnable + ir.mouseTracking())
+
+	resetTerminalSignals()
+
+	// Clear the current line and redisplay the prompt.
+	fmt.Printf("\r%s%s", ClearLineSeq(), ir.prompt)
+	ir.line = ""
+	ir.cursorPos = 0
+	return oldState
+}
+
+// previousWordStart returns the start of the word before pos.
+func previousWordStart(line string, pos int) int {
+	for pos > 0 && isWordSpace(line[pos-1]) {
+		pos--
+	}
+	for pos > 0 && !isWordSpace(line[pos-1]) {
+		pos--
+	}
+	return pos
+}
+
+// nextWordStart returns the start of the word after pos.
+func nextWordStart(line string, pos int) int {
+	for pos < len(line) && !isWordSpace(line[pos]) {
+		pos++
+	}
+	for pos < len(line) && isWordSpace(line[pos]) {
+		pos++
+	}
+	return pos
+}
+
+func isWordSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n'
+}
@@ -3,7 +3,6 @@ package console
 import (
 	"fmt"
 	"os"
-	"os/signal"
 	"strings"
 	"time"
 
@@ -45,6 +44,13 @@ const (
 	EventPasteEnd
 	// Mouse events
 	EventMouse
+	// EventNewline inserts a line break without submitting (Alt+Enter, Ctrl+J)
+	EventNewline
+	// EventExternalEditor opens the input in $VISUAL/$EDITOR (Ctrl+E)
+	EventExternalEditor
+	// EventKey is another named key such as "ctrl+a" or "f1"; Data holds
+	// the name
+	EventKey
 )
 
 // InputReader handles interactive input with proper escape sequence handling
@@ -80,6 +86,9 @@ type InputReader struct {
 
 	// Track current physical line (for multi-line wrapped input)
 	currentPhysicalLine int
+	// Physical lines drawn by the last Refresh; 0 means derive it from
+	// lastLineLength (single-line input drawn by the fast path)
+	lastRowCount int
 
 	// Context menu for right-click handling
 	contextMenu *ContextMenu
@@ -87,6 +96,14 @@ type InputReader struct {
 	// Mouse position tracking
 	mouseRow int
 	mouseCol int
+
+	// Mouse reporting, and the output view shown while the output has focus
+	mouse  bool
+	output *outputView
+
+	// Key bindings, and whether the vim preset is in normal mode
+	keymap    *Keymap
+	vimNormal bool
 }
 
 type pasteSpan struct {
@@ -112,11 +129,25 @@ func NewInputReader(prompt string) *InputReader {
 		historyIndex:    -1,
 		collapsedPastes: make([]pasteSpan, 0, 8),
 		contextMenu:     NewContextMenu(),
+		keymap:          DefaultKeymap(),
 	}
 	ir.updateTerminalWidth()
 	return ir
 }
 
+// SetKeymap replaces the key bindings; nil restores the defaults.
+func (ir *InputReader) SetKeymap(km *Keymap) {
+	if km == nil {
+		km = DefaultKeymap()
+	}
+	ir.keymap = km
+}
+
+// SetPrompt replaces the prompt drawn before the input.
+func (ir *InputReader) SetPrompt(prompt string) {
+	ir.prompt = prompt
+}
+
 // ReadLine reads a line of input with proper escape sequence handling
 func (ir *InputReader) ReadLine() (string, error) {
 	// Check if we're in a terminal
@@ -125,17 +156,19 @@ func (ir *InputReader) ReadLine() (string, error) {
 	}
 
 	// Save terminal state and set raw mode
-	oldState, err := term.MakeRaw(ir.termFd)
+	oldState, err := makeRaw(ir.termFd)
 	if err != nil {
 		return ir.fallbackReadLine()
 	}
-	defer term.Restore(ir.termFd, oldState)
+	defer restoreTerminal(ir.termFd, oldState)
 	fmt.Print(bracketedPasteEnable)
 	defer fmt.Print(bracketedPasteDisable)
 
-	// Enable mouse tracking (SGR mode for extended coordinates)
-	fmt.Print(MouseTrackingSGR)
-	defer fmt.Print(MouseTrackingDisable)
+	if ir.mouse {
+		fmt.Print(MouseTrackingEnable)
+		defer fmt.Print(MouseTrackingDisable)
+		defer ir.focusInput()
+	}
 
 	// Initialize line state
 	ir.line = ""
@@ -144,6 +177,7 @@ func (ir *InputReader) ReadLine() (string, error) {
 	ir.hasEditedLine = false
 	ir.updateTerminalWidth()
 	ir.lastLineLength = 0
+	ir.lastRowCount = 0
 	ir.lastWrapPending = false
 	ir.currentPhysicalLine = 0
 	ir.pasteBuffer.Reset()
@@ -155,16 +189,16 @@ func (ir *InputReader) ReadLine() (string, error) {
 	ir.collapsedPastes = ir.collapsedPastes[:0]
 	ir.rawPasteBuffer = nil
 	ir.lastCharTime = time.Now()
+	if ir.keymap.vim() {
+		ir.setVimNormal(false)
+		defer fmt.Print(cursorShapeDefault)
+	}
 	fmt.Printf("%s", ir.prompt) // Simple initial prompt
 
 	parser := NewEscapeParser()
 	buf := make([]byte, 32)
-	var resizeCh chan os.Signal
-	if sig := resizeSignal(); sig != nil {
-		resizeCh = make(chan os.Signal, 1)
-		signal.Notify(resizeCh, sig)
-		defer signal.Stop(resizeCh)
-	}
+	resizeCh, stopResize := notifyResize()
+	defer stopResize()
 
 	// Set stdin to non-blocking for paste detection
 	nonBlocking := true
@@ -244,52 +278,16 @@ func (ir *InputReader) ReadLine() (string, error) {
 			// Detect paste: rapid character input
 			timeSinceLastChar := now.Sub(ir.lastCharTime)
 
-			// Handle Ctrl+C and Ctrl+Z directly before parsing
-			if b == 3 { // Ctrl+C
-				fmt.Printf("\r%s", ClearToEndOfLineSeq()) // Clear line
-				fmt.Println("^C")
-				return "", fmt.Errorf("interrupted")
-			}
-
-			if b == 26 { // Ctrl+Z
-				// Re-enter cooked mode before suspension so the shell
-				// state is clean while the user is away.
-				term.Restore(ir.termFd, oldState)
-				suspendTerminal()
-
-				// Execution resumes here after SIGCONT (e.g. "fg").
-				ignoreTerminalSignals()
-
-				// Drain any bytes that arrived while suspended (e.g.
-				// keystrokes, newline from "fg" command).  Only possible
-				// when the fd is in non-blocking mode; otherwise there is
-				// no safe way to poll without blocking indefinitely.
-				if nonBlocking {
-					time.Sleep(50 * time.Millisecond)
-					discardBuf := make([]byte, 256)
-					for {
-						n, _ := os.Stdin.Read(discardBuf)
-						if n <= 0 {
-							break
-						}
-					}
-				}
-
-				// Re-enter raw mode.
-				if newState, err := term.MakeRaw(ir.termFd); err == nil {
-					oldState = newState
+			// Handle interrupt and suspend keys directly before parsing, so
+			// they work even while a paste is being collected
+			if parser.state == 0 {
+				switch ir.keymap.Action(controlKeyName(b)) {
+				case ActionInterrupt:
+					return ir.interrupt()
+				case ActionSuspend:
+					oldState = ir.suspend(oldState, nonBlocking)
+					continue
 				}
-
-				// Re-enable bracketed paste mode (lost when we exited raw mode).
-				fmt.Print(bracketedPasteEnable)
-
-				resetTerminalSignals()
-
-				// Clear the current line and redisplay the prompt.
-				fmt.Printf("\r%s%s", ClearLineSeq(), ir.prompt)
-				ir.line = ""
-				ir.cursorPos = 0
-				continue
 			}
 
 			// Check for escape sequences BEFORE paste detection
@@ -368,26 +366,45 @@ func (ir *InputReader) ReadLine() (string, error) {
 				}
 				if event.Type == EventMouse {
 					// Handle mouse event
-					ir.handleMouseEvent(event.Data)
+					oldState = ir.handleMouseEvent(event.Data, oldState, nonBlocking)
 					continue
 				}
-				if event.Type == EventEnter {
+				if ir.output != nil && ir.handleOutputKey(event) {
+					continue
+				}
+				action := ir.keymap.Action(event.Key())
+				switch action {
+				case ActionInterrupt:
+					return ir.interrupt()
+				case ActionSuspend:
+					oldState = ir.suspend(oldState, nonBlocking)
+					continue
+				case ActionExternalEditor:
+					oldState = ir.openExternalEditor(oldState, nonBlocking)
+					continue
+				case ActionHelp:
+					ir.moveCursorToInputEnd()
+					fmt.Println()
+					return "/help", nil
+				case ActionSubmit:
 					// End of input
+					ir.moveCursorToInputEnd()
 					fmt.Println() // Move to next line
 					input := ir.line
 					if input != "" {
 						ir.AddToHistory(input)
 					}
+					RecordOutput(ir.prompt + input + "\n")
 					return input, nil
 				}
-				ir.HandleEvent(event)
+				ir.runAction(action, event)
 			}
 			for parser.hasPending {
 				pending := parser.Parse(0)
 				if pending == nil {
 					break
 				}
-				ir.HandleEvent(pending)
+				ir.runAction(ir.keymap.Action(pending.Key()), pending)
 			}
 		}
 	}
@@ -418,6 +435,8 @@ func (ir *InputReader) HandleEvent(event *InputEvent) {
 		ir.SetCursor(0)
 	case EventEnd:
 		ir.SetCursor(len(ir.line))
+	case EventNewline:
+		ir.InsertChar("\n")
 	case EventUp:
 		// If context menu is visible, navigate it
 		if ir.contextMenu != nil && ir.contextMenu.Visible {
@@ -473,14 +492,15 @@ func (ir *InputReader) InsertChar(char string) {
 	ir.cursorPos += len(char)
 	ir.shiftPasteSpans(insertAt, len(char))
 
-	// For typing at end of line, just output the character (more efficient)
-	if ir.cursorPos == len(ir.line) && len(ir.collapsedPastes) == 0 {
+	// For typing at end of single-line input, just output the character (more efficient)
+	if ir.cursorPos == len(ir.line) && len(ir.collapsedPastes) == 0 && !strings.Contains(ir.line, "\n") {
 		fmt.Printf("%s", char)
 		// Keep refresh bookkeeping in sync even on fast-path writes.
 		promptWidth := visibleRuneWidth(ir.prompt)
 		lineWidth := len([]rune(ir.line))
 		totalWidth := promptWidth + lineWidth
 		ir.lastLineLength = totalWidth
+		ir.lastRowCount = 0
 		cursorPos := promptWidth + ir.cursorPos
 		ir.currentPhysicalLine = cursorLineIndex(ir.terminalWidth, cursorPos)
 		ir.lastWrapPending = isWrapPending(ir.terminalWidth, totalWidth, cursorPos, totalWidth)
@@ -623,13 +643,25 @@ func (ep *EscapeParser) Parse(b byte) *InputEvent {
 			return &InputEvent{Type: EventBackspace}
 		case 13:
 			return &InputEvent{Type: EventEnter}
+		case 10: // Ctrl+J
+			return &InputEvent{Type: EventNewline, Data: "ctrl+j"}
+		case 5: // Ctrl+E
+			return &InputEvent{Type: EventExternalEditor}
 		case 9:
 			return &InputEvent{Type: EventTab}
+		case 3:
+			return &InputEvent{Type: EventInterrupt}
+		case 26:
+			return &InputEvent{Type: EventSuspend}
 		default:
 			// Return regular printable characters as character events
 			if b >= 32 && b <= 126 {
 				return &InputEvent{Type: EventChar, Data: string([]byte{b})}
 			}
+			// Other control keys are only named, for the keymap
+			if name := controlKeyName(b); name != "" {
+				return &InputEvent{Type: EventKey, Data: name}
+			}
 			return nil
 		}
 
@@ -644,6 +676,11 @@ func (ep *EscapeParser) Parse(b byte) *InputEvent {
 			ep.state = 4
 			return nil
 		}
+		// Alt+Enter: terminals send ESC followed by CR (or LF)
+		if b == 13 || b == 10 {
+			ep.Reset()
+			return &InputEvent{Type: EventNewline}
+		}
 		// Not a CSI sequence, treat ESC as escape event
 		// This character could be printable, save it for next call
 		ep.Reset()
@@ -659,19 +696,19 @@ func (ep *EscapeParser) Parse(b byte) *InputEvent {
 		// Check for completed sequences - only look at the last character for simple cases
 		switch b {
 		case 'A': // Up arrow
-			event := &InputEvent{Type: EventUp}
+			event := &InputEvent{Type: EventUp, Data: ep.modifiedKey("up")}
 			ep.Reset()
 			return event
 		case 'B': // Down arrow
-			event := &InputEvent{Type: EventDown}
+			event := &InputEvent{Type: EventDown, Data: ep.modifiedKey("down")}
 			ep.Reset()
 			return event
 		case 'C': // Right arrow
-			event := &InputEvent{Type: EventRight}
+			event := &InputEvent{Type: EventRight, Data: ep.modifiedKey("right")}
 			ep.Reset()
 			return event
 		case 'D': // Left arrow
-			event := &InputEvent{Type: EventLeft}
+			event := &InputEvent{Type: EventLeft, Data: ep.modifiedKey("left")}
 			ep.Reset()
 			return event
 		case 'H': // Home
@@ -712,6 +749,12 @@ func (ep *EscapeParser) Parse(b byte) *InputEvent {
 					return &InputEvent{Type: EventEnd}
 				case "3":
 					return &InputEvent{Type: EventDelete}
+				case "5":
+					return &InputEvent{Type: EventKey, Data: "pgup"}
+				case "6":
+					return &InputEvent{Type: EventKey, Data: "pgdown"}
+				case "11":
+					return &InputEvent{Type: EventKey, Data: "f1"}
 				case "200":
 					return &InputEvent{Type: EventPasteStart}
 				case "201":
@@ -756,6 +799,9 @@ func (ep *EscapeParser) Parse(b byte) *InputEvent {
 			event := &InputEvent{Type: EventEnd}
 			ep.Reset()
 			return event
+		case 'P': // F1
+			ep.Reset()
+			return &InputEvent{Type: EventKey, Data: "f1"}
 		default:
 			// Unknown sequence, this character could be printable
 			ep.Reset()
@@ -786,7 +832,7 @@ func (ep *EscapeParser) Parse(b byte) *InputEvent {
 
 	case 6: // Mouse event tracking (X10 mode: ESC [ M Cb Cx Cy)
 		ep.mouseBuf = append(ep.mouseBuf, b)
-		if len(ep.mouseBuf) == 4 {
+		if len(ep.mouseBuf) == 6 {
 			// Complete X10 mouse event: ESC [ M Cb Cx Cy
 			mouseData := string(ep.mouseBuf)
 			ep.Reset()
@@ -799,6 +845,19 @@ func (ep *EscapeParser) Parse(b byte) *InputEvent {
 	return nil
 }
 
+// modifiedKey names an arrow key pressed with Ctrl or Alt (ESC [ 1 ; 5 D),
+// or returns "" for a plain arrow.
+func (ep *EscapeParser) modifiedKey(name string) string {
+	params := string(ep.buffer)
+	switch {
+	case strings.Contains(params, ";5"):
+		return "ctrl+" + name
+	case strings.Contains(params, ";3"):
+		return "alt+" + name
+	}
+	return ""
+}
+
 // Reset the parser state
 func (ep *EscapeParser) Reset() {
 	ep.state = 0
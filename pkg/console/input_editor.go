@@ -0,0 +1,102 @@
+package console
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// externalEditorCommand returns the editor command from $VISUAL or $EDITOR,
+// falling back to vi (notepad on Windows). The variables may include
+// arguments, such as "code --wait".
+func externalEditorCommand() []string {
+	for _, name := range []string{"VISUAL", "EDITOR"} {
+		if fields := strings.Fields(os.Getenv(name)); len(fields) > 0 {
+			return fields
+		}
+	}
+	if runtime.GOOS == "windows" {
+		return []string{"notepad"}
+	}
+	return []string{"vi"}
+}
+
+// editInExternalEditor writes text to a temporary markdown file, opens it in
+// the external editor and returns the saved contents without the trailing
+// newline editors add.
+func editInExternalEditor(text string) (string, error) {
+	f, err := os.CreateTemp("", "ledit_prompt_*.md")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	path := f.Name()
+	defer os.Remove(path)
+	_, writeErr := f.WriteString(text)
+	if closeErr := f.Close(); writeErr == nil {
+		writeErr = closeErr
+	}
+	if writeErr != nil {
+		return "", fmt.Errorf("failed to write temp file: %w", writeErr)
+	}
+
+	editor := externalEditorCommand()
+	cmd := exec.Command(editor[0], append(editor[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("editor %s failed: %w", editor[0], err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read edited file: %w", err)
+	}
+	edited := strings.ReplaceAll(string(data), "\r\n", "\n")
+	return strings.TrimRight(edited, "\n"), nil
+}
+
+// openExternalEditor hands the terminal to the external editor with the
+// current input, then loads the saved text back into the input for review.
+// It returns the terminal state to restore after the new raw mode.
+func (ir *InputReader) openExternalEditor(oldState *term.State, nonBlocking bool) *term.State {
+	ir.moveCursorToInputEnd()
+	fmt.Print(MouseTrackingDisable + bracketedPasteDisable + "\r\n")
+	restoreTerminal(ir.termFd, oldState)
+	if nonBlocking {
+		// The editor shares stdin and expects blocking reads.
+		_ = setNonblock(ir.termFd, false)
+	}
+
+	edited, err := editInExternalEditor(ir.line)
+
+	if nonBlocking {
+		_ = setNonblock(ir.termFd, true)
+	}
+	if newState, rawErr := makeRaw(ir.termFd); rawErr == nil {
+		oldState = newState
+	}
+	fmt.Print(bracketedPasteEnable + ir.mouseTracking())
+
+	if err != nil {
+		fmt.Printf("[edit] %v\r\n", err)
+	} else {
+		ir.line = edited
+		ir.cursorPos = len(ir.line)
+		ir.collapsedPastes = ir.collapsedPastes[:0]
+		ir.hasEditedLine = true
+		ir.historyIndex = -1
+	}
+
+	// The editor took over the screen; redraw the input on a fresh line.
+	ir.lastLineLength = 0
+	ir.lastRowCount = 0
+	ir.currentPhysicalLine = 0
+	ir.lastWrapPending = false
+	ir.Refresh()
+	return oldState
+}
@@ -1,43 +1,198 @@
 package console
 
-// handleMouseEvent processes mouse events from the terminal
-func (ir *InputReader) handleMouseEvent(data string) {
-	if ir.contextMenu == nil {
-		return
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/term"
+)
+
+// SetMouse turns mouse reporting on or off. With it on, the wheel scrolls
+// through the output, clicks move focus between the input and the output,
+// and clicking a file path in the output opens it in the external editor.
+func (ir *InputReader) SetMouse(enabled bool) {
+	ir.mouse = enabled
+}
+
+// mouseTracking returns the sequence that turns mouse reporting back on, or
+// nothing when it is off.
+func (ir *InputReader) mouseTracking() string {
+	if !ir.mouse {
+		return ""
 	}
+	return MouseTrackingEnable
+}
 
+// handleMouseEvent processes mouse events from the terminal. It returns the
+// terminal state to restore later, which changes when a file is opened in
+// the editor.
+func (ir *InputReader) handleMouseEvent(data string, oldState *term.State, nonBlocking bool) *term.State {
 	// Parse the mouse event
 	mouseEvent, err := ParseMouseEvent(data)
 	if err != nil {
-		return
+		return oldState
 	}
 
 	// Update mouse position
 	ir.mouseRow = mouseEvent.Row
 	ir.mouseCol = mouseEvent.Col
 
-	// Handle right-click (button 2)
-	if mouseEvent.Button == MouseButtonRight && mouseEvent.Kind == MouseEventPress {
-		// Show context menu at mouse position
-		ir.showContextMenu()
-		return
-	}
-
 	// Handle click elsewhere to close menu
-	if mouseEvent.Button == MouseButtonLeft && mouseEvent.Kind == MouseEventPress {
-		// Check if click is outside menu area
-		if ir.contextMenu.Visible {
-			// Close menu if click is not in menu area
+	if ir.contextMenu != nil && ir.contextMenu.Visible {
+		if mouseEvent.Button == MouseButtonLeft && mouseEvent.Kind == MouseEventPress {
 			ir.contextMenu.Hide()
 			if ir.contextMenu.OnEscape != nil {
 				ir.contextMenu.OnEscape()
 			}
 		}
+		return oldState
+	}
+
+	if ir.output != nil {
+		return ir.handleOutputMouse(mouseEvent, oldState, nonBlocking)
+	}
+
+	switch {
+	case mouseEvent.Kind == MouseEventWheelUp:
+		ir.focusOutput(wheelScrollRows)
+	case mouseEvent.Kind == MouseEventPress && mouseEvent.Button == MouseButtonLeft:
+		// A click above the input moves focus to the output
+		if mouseEvent.Row <= ir.terminalHeight()-ir.inputRows() {
+			ir.focusOutput(0)
+		}
+	case mouseEvent.Kind == MouseEventPress && mouseEvent.Button == MouseButtonRight && ir.contextMenu != nil:
+		// Show context menu at mouse position
+		ir.showContextMenu()
+	}
+	return oldState
+}
+
+// handleOutputMouse scrolls the output view, opens a clicked file path or
+// gives focus back to the input.
+func (ir *InputReader) handleOutputMouse(event *MouseEvent, oldState *term.State, nonBlocking bool) *term.State {
+	switch {
+	case event.Kind == MouseEventWheelUp:
+		ir.output.scroll(wheelScrollRows)
+	case event.Kind == MouseEventWheelDown:
+		if ir.output.scroll(-wheelScrollRows) {
+			ir.focusInput()
+			return oldState
+		}
+	case event.Kind == MouseEventPress && event.Button == MouseButtonLeft:
+		if event.Row >= ir.output.height {
+			ir.focusInput()
+			return oldState
+		}
+		row, ok := ir.output.rowAt(event.Row)
+		if !ok {
+			return oldState
+		}
+		root, err := os.Getwd()
+		if err != nil {
+			return oldState
+		}
+		path, line, ok := filePathAt(row, event.Col, root)
+		if !ok {
+			return oldState
+		}
+		return ir.openOutputPath(path, line, oldState, nonBlocking)
+	default:
+		return oldState
+	}
+	fmt.Print(ir.output.render())
+	return oldState
+}
+
+// handleOutputKey scrolls the output view with the arrow and page keys. Esc
+// and Enter give focus back to the input; any other key does too and is then
+// handled by the input, so typing goes on where it left off. It reports
+// whether the key was consumed.
+func (ir *InputReader) handleOutputKey(event *InputEvent) bool {
+	v := ir.output
+	switch event.Key() {
+	case "up":
+		v.scroll(1)
+	case "down":
+		if v.scroll(-1) {
+			ir.focusInput()
+			return true
+		}
+	case "pgup":
+		v.scroll(v.pageRows() - 1)
+	case "pgdown":
+		if v.scroll(1 - v.pageRows()) {
+			ir.focusInput()
+			return true
+		}
+	case "home":
+		v.scroll(len(v.rows))
+	case "end":
+		v.scroll(-len(v.rows))
+	case "esc", "enter":
+		ir.focusInput()
+		return true
+	default:
+		ir.focusInput()
+		return false
+	}
+	fmt.Print(v.render())
+	return true
+}
+
+// focusOutput shows the output view scrolled up by rows, if there is output
+// to show.
+func (ir *InputReader) focusOutput(rows int) {
+	view := newOutputView(ir.terminalWidth, ir.terminalHeight())
+	if len(view.rows) == 0 {
 		return
 	}
+	view.scroll(rows)
+	ir.output = view
+	fmt.Print(altScreenEnter + HideCursorSeq() + view.render())
+}
+
+// focusInput closes the output view, which brings back the screen with the
+// input as it was.
+func (ir *InputReader) focusInput() {
+	if ir.output == nil {
+		return
+	}
+	ir.output = nil
+	fmt.Print(altScreenLeave + ShowCursorSeq())
+}
+
+// openOutputPath hands the terminal to the external editor for a file
+// clicked in the output view, then redraws the view.
+func (ir *InputReader) openOutputPath(path string, line int, oldState *term.State, nonBlocking bool) *term.State {
+	fmt.Print(MouseTrackingDisable + bracketedPasteDisable + ShowCursorSeq())
+	restoreTerminal(ir.termFd, oldState)
+	if nonBlocking {
+		// The editor shares stdin and expects blocking reads.
+		_ = setNonblock(ir.termFd, false)
+	}
+
+	err := OpenInEditor(path, line)
+
+	if nonBlocking {
+		_ = setNonblock(ir.termFd, true)
+	}
+	if newState, rawErr := makeRaw(ir.termFd); rawErr == nil {
+		oldState = newState
+	}
+	fmt.Print(bracketedPasteEnable + ir.mouseTracking() + HideCursorSeq())
+
+	ir.output.resize(ir.terminalWidth, ir.terminalHeight())
+	if err != nil {
+		ir.output.notice = err.Error()
+	}
+	fmt.Print(ir.output.render())
+	return oldState
+}
 
-	// Handle keyboard navigation when menu is visible
-	if ir.contextMenu.Visible {
-		// Keyboard navigation is handled in HandleEvent
+// inputRows returns how many rows the prompt and input take on screen.
+func (ir *InputReader) inputRows() int {
+	if ir.lastRowCount > 0 {
+		return ir.lastRowCount
 	}
+	return cursorLineIndex(ir.terminalWidth, visibleRuneWidth(ir.prompt)+len([]rune(ir.line))) + 1
 }
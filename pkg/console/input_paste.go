@@ -49,6 +49,27 @@ func (ir *InputReader) consumeBracketedPasteByte(b byte) bool {
 	return false
 }
 
+// insertImagePlaceholder inserts the placeholder the agent recognizes as an
+// attached image at the cursor.
+func (ir *InputReader) insertImagePlaceholder(savedPath string) {
+	placeholder := fmt.Sprintf("Pasted image saved to disk: %s ", savedPath)
+	before := ir.line[:ir.cursorPos]
+	after := ir.line[ir.cursorPos:]
+	ir.line = before + placeholder + after
+	ir.cursorPos += len(placeholder)
+	ir.shiftPasteSpans(len(before), len(placeholder))
+	ir.addCollapsedPaste(len(before), ir.cursorPos)
+	ir.hasEditedLine = true
+	ir.historyIndex = -1
+	ir.Refresh()
+	promptWidth := visibleRuneWidth(ir.prompt)
+	lineWidth := len([]rune(ir.line))
+	newLength := promptWidth + lineWidth
+	ir.lastLineLength = newLength
+	cursorPos := promptWidth + ir.cursorPos
+	ir.lastWrapPending = isWrapPending(ir.terminalWidth, newLength, cursorPos, newLength)
+}
+
 // finalizePaste processes pasted content and inserts it literally at cursor.
 func (ir *InputReader) finalizePaste() bool {
 	// Snapshot and clear raw binary buffer for image paste detection
@@ -69,28 +90,24 @@ func (ir *InputReader) finalizePaste() bool {
 				fmt.Fprintf(os.Stderr, "[FAIL] Failed to save pasted image: %v\n", err)
 			} else {
 				fmt.Fprintf(os.Stderr, "[save] Saved to %s\n", savedPath)
-				placeholder := fmt.Sprintf("Pasted image saved to disk: %s ", savedPath)
-				// Insert placeholder at cursor position
-				before := ir.line[:ir.cursorPos]
-				after := ir.line[ir.cursorPos:]
-				ir.line = before + placeholder + after
-				ir.cursorPos += len(placeholder)
-				ir.shiftPasteSpans(len(before), len(placeholder))
-				ir.addCollapsedPaste(len(before), ir.cursorPos)
-				ir.hasEditedLine = true
-				ir.historyIndex = -1
-				ir.Refresh()
-				promptWidth := visibleRuneWidth(ir.prompt)
-				lineWidth := len([]rune(ir.line))
-				newLength := promptWidth + lineWidth
-				ir.lastLineLength = newLength
-				cursorPos := promptWidth + ir.cursorPos
-				ir.lastWrapPending = isWrapPending(ir.terminalWidth, newLength, cursorPos, newLength)
+				ir.insertImagePlaceholder(savedPath)
 				return true
 			}
 		}
 	}
 
+	// A dragged-in image file arrives as its path; attach a copy of the file.
+	if imagePath, ok := PastedImagePath(pastedContent); ok {
+		savedPath, err := SaveImageFile(imagePath, "")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "\n[img] Not attaching %s: %v\n", imagePath, err)
+		} else {
+			fmt.Fprintf(os.Stderr, "\n[img] Attached %s as %s\n", imagePath, savedPath)
+			ir.insertImagePlaceholder(savedPath)
+			return true
+		}
+	}
+
 	// Strip trailing newline that triggered the paste
 	pastedContent = strings.TrimRight(pastedContent, "\n")
 	if pastedContent == "" {
@@ -3,29 +3,26 @@ package console
 import (
 	"fmt"
 	"strings"
+	"unicode/utf8"
 )
 
-// Refresh redraws the current input line
+// Refresh redraws the current input line. Input containing line breaks is
+// drawn one row per line, with continuation rows indented to the prompt.
 func (ir *InputReader) Refresh() {
 	// Calculate display width (accounting for multibyte characters)
-	promptRunes := []rune(stripANSIEscapeCodes(ir.prompt))
+	promptWidth := visibleRuneWidth(ir.prompt)
 	displayLine, displayCursorByte := ir.renderLineWithCollapsedPastes()
-	lineRunes := []rune(displayLine)
-	promptWidth := len(promptRunes)
-	lineWidth := len(lineRunes)
-	totalWidth := promptWidth + lineWidth
+	rows := strings.Split(displayLine, "\n")
+	layout := layoutInput(ir.terminalWidth, promptWidth, displayLine, displayCursorByte)
 
-	currentLineCount := visualLineCount(ir.terminalWidth, totalWidth)
-	previousLineCount := visualLineCount(ir.terminalWidth, ir.lastLineLength)
+	currentLineCount := layout.lines
+	previousLineCount := ir.lastRowCount
+	if previousLineCount == 0 {
+		previousLineCount = visualLineCount(ir.terminalWidth, ir.lastLineLength)
+	}
 	previousCursorLine := ir.currentPhysicalLine
 	previousWrapPending := ir.lastWrapPending
 
-	// Calculate current cursor visual position.
-	displayCursorRunes := runeCountAtByteIndex(displayLine, displayCursorByte)
-	cursorPos := promptWidth + displayCursorRunes
-	cursorLine := cursorLineIndex(ir.terminalWidth, cursorPos)
-	cursorCol := cursorColumnOffset(ir.terminalWidth, cursorPos)
-
 	// Maximum number of wrapped lines we need to clear
 	// Always clear at least as many as we have now, plus what we had before
 	maxLines := currentLineCount
@@ -62,33 +59,88 @@ func (ir *InputReader) Refresh() {
 	}
 
 	// Redraw the prompt and line content
-	fmt.Printf("%s%s", ir.prompt, displayLine)
+	fmt.Printf("%s%s", ir.prompt, rows[0])
+	for _, row := range rows[1:] {
+		// Raw mode needs an explicit carriage return before each new row
+		fmt.Printf("%s\r\n%s%s", ClearToEndOfLineSeq(), continuationPrompt(promptWidth), row)
+	}
 
 	// Clear any trailing content on the last line (in case new content is shorter than old)
 	fmt.Printf("%s", ClearToEndOfLineSeq())
 
 	// Update tracked length AFTER drawing (use display width, not byte length)
-	ir.lastLineLength = totalWidth
+	ir.lastLineLength = promptWidth + utf8.RuneCountInString(displayLine)
+	ir.lastRowCount = currentLineCount
 
 	// Position cursor correctly.
 	// After printing, cursor is at end of content (on line 'currentLineCount - 1').
 	endLine := currentLineCount - 1
-	if endLine > cursorLine {
-		fmt.Printf("%s", MoveCursorUpSeq(endLine-cursorLine))
-	} else if endLine < cursorLine {
-		fmt.Printf("%s", MoveCursorDownSeq(cursorLine-endLine))
+	if endLine > layout.cursorLine {
+		fmt.Printf("%s", MoveCursorUpSeq(endLine-layout.cursorLine))
+	} else if endLine < layout.cursorLine {
+		fmt.Printf("%s", MoveCursorDownSeq(layout.cursorLine-endLine))
 	}
 
 	// Move to target column on that line.
-	if cursorCol > 0 {
-		fmt.Printf("\r\033[%dC", cursorCol)
+	if layout.cursorCol > 0 {
+		fmt.Printf("\r\033[%dC", layout.cursorCol)
 	} else {
 		fmt.Printf("\r")
 	}
 
 	// Track current rendered cursor line (0-based wrapped line index).
-	ir.currentPhysicalLine = cursorLine
-	ir.lastWrapPending = isWrapPending(ir.terminalWidth, totalWidth, cursorPos, promptWidth+lineWidth)
+	ir.currentPhysicalLine = layout.cursorLine
+	ir.lastWrapPending = layout.wrapPending
+}
+
+// moveCursorToInputEnd moves the cursor down to the last physical line of
+// the input so output printed after submitting doesn't overwrite it.
+func (ir *InputReader) moveCursorToInputEnd() {
+	lines := ir.lastRowCount
+	if lines == 0 {
+		lines = visualLineCount(ir.terminalWidth, ir.lastLineLength)
+	}
+	if down := lines - 1 - ir.currentPhysicalLine; down > 0 {
+		fmt.Printf("%s", MoveCursorDownSeq(down))
+	}
+}
+
+// inputLayout is where the input and its cursor land on screen.
+type inputLayout struct {
+	lines       int // physical lines occupied
+	cursorLine  int // 0-based physical line of the cursor
+	cursorCol   int // column of the cursor on that line
+	wrapPending bool
+}
+
+// layoutInput computes the screen layout of display text drawn after a
+// prompt promptWidth columns wide. Each row of multi-line text starts on a
+// new line behind a continuation prompt of the same width.
+func layoutInput(terminalWidth, promptWidth int, display string, cursorByte int) inputLayout {
+	var layout inputLayout
+	offset := 0
+	cursorFound := false
+	for _, row := range strings.Split(display, "\n") {
+		rowWidth := promptWidth + utf8.RuneCountInString(row)
+		if !cursorFound && cursorByte <= offset+len(row) {
+			cursorPos := promptWidth + runeCountAtByteIndex(row, cursorByte-offset)
+			layout.cursorLine = layout.lines + cursorLineIndex(terminalWidth, cursorPos)
+			layout.cursorCol = cursorColumnOffset(terminalWidth, cursorPos)
+			layout.wrapPending = isWrapPending(terminalWidth, rowWidth, cursorPos, rowWidth)
+			cursorFound = true
+		}
+		layout.lines += visualLineCount(terminalWidth, rowWidth)
+		offset += len(row) + 1
+	}
+	return layout
+}
+
+// continuationPrompt marks the rows after the first in multi-line input.
+func continuationPrompt(promptWidth int) string {
+	if promptWidth < 2 {
+		return strings.Repeat(" ", promptWidth)
+	}
+	return strings.Repeat(" ", promptWidth-2) + Code(ColorDim) + "…" + Code(ColorReset) + " "
 }
 
 // visualLineCount calculates how many terminal lines are occupied for a given
@@ -141,12 +193,18 @@ func visibleRuneWidth(s string) int {
 	return len([]rune(stripANSIEscapeCodes(s)))
 }
 
-// stripANSIEscapeCodes removes ANSI CSI escape sequences like \x1b[31m.
+// stripANSIEscapeCodes removes ANSI CSI escape sequences like \x1b[31m and
+// OSC sequences like hyperlinks.
 func stripANSIEscapeCodes(text string) string {
 	var result strings.Builder
 	inEscape := false
 
 	for i := 0; i < len(text); i++ {
+		if text[i] == '\033' && i+1 < len(text) && text[i+1] == ']' {
+			// OSC sequences end with BEL or ST (ESC \)
+			i = oscEnd(text, i+2)
+			continue
+		}
 		if text[i] == '\033' && i+1 < len(text) && text[i+1] == '[' {
 			inEscape = true
 			i++ // skip '['
@@ -163,3 +221,17 @@ func stripANSIEscapeCodes(text string) string {
 
 	return result.String()
 }
+
+// oscEnd returns the index of the last byte of the OSC sequence whose body
+// starts at i, or the end of text when it is unterminated.
+func oscEnd(text string, i int) int {
+	for ; i < len(text); i++ {
+		if text[i] == '\a' {
+			return i
+		}
+		if text[i] == '\033' && i+1 < len(text) && text[i+1] == '\\' {
+			return i + 1
+		}
+	}
+	return len(text)
+}
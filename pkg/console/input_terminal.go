@@ -27,6 +27,8 @@ func (ir *InputReader) processPendingResize(resizeCh <-chan os.Signal, parser *E
 }
 
 func (ir *InputReader) handleResize() bool {
+	// The output view is laid out for the old size; give focus back to the input
+	ir.focusInput()
 	oldWidth := ir.terminalWidth
 	ir.updateTerminalWidth()
 	return ir.applyTerminalWidthChange(oldWidth, ir.terminalWidth)
@@ -43,6 +45,7 @@ func (ir *InputReader) applyTerminalWidthChange(oldWidth, newWidth int) bool {
 
 	ir.terminalWidth = newWidth
 	ir.lastLineLength = 0
+	ir.lastRowCount = 0
 	ir.currentPhysicalLine = 0
 	ir.lastWrapPending = false
 
@@ -53,11 +56,25 @@ func (ir *InputReader) applyTerminalWidthChange(oldWidth, newWidth int) bool {
 	return true
 }
 
-// updateTerminalWidth gets the current terminal width
+// updateTerminalWidth gets the current terminal width. On Windows only the
+// output handle reports the size.
 func (ir *InputReader) updateTerminalWidth() {
 	if width, _, err := term.GetSize(ir.termFd); err == nil {
 		ir.terminalWidth = width
+	} else if width, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil {
+		ir.terminalWidth = width
 	} else {
 		ir.terminalWidth = 80 // Fallback to standard width
 	}
 }
+
+// terminalHeight returns the number of rows of the terminal.
+func (ir *InputReader) terminalHeight() int {
+	if _, height, err := term.GetSize(ir.termFd); err == nil {
+		return height
+	}
+	if _, height, err := term.GetSize(int(os.Stdout.Fd())); err == nil {
+		return height
+	}
+	return 24
+}
@@ -3,6 +3,8 @@ package console
 import (
 	"io"
 	"os"
+	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
 	"time"
@@ -540,3 +542,90 @@ func TestNavigateHistoryClearsCollapsedPastes(t *testing.T) {
 		t.Fatalf("expected collapsed spans to clear on history nav, got %d", len(ir.collapsedPastes))
 	}
 }
+
+func TestEscapeParserMultilineKeys(t *testing.T) {
+	ep := NewEscapeParser()
+	if event := ep.Parse(27); event != nil {
+		t.Fatalf("expected ESC to start a sequence, got %v", event)
+	}
+	if event := ep.Parse(13); event == nil || event.Type != EventNewline {
+		t.Fatalf("expected Alt+Enter to insert a newline, got %v", event)
+	}
+	if event := ep.Parse(10); event == nil || event.Type != EventNewline {
+		t.Fatalf("expected Ctrl+J to insert a newline, got %v", event)
+	}
+	if event := ep.Parse(5); event == nil || event.Type != EventExternalEditor {
+		t.Fatalf("expected Ctrl+E to open the editor, got %v", event)
+	}
+}
+
+func TestRefreshDrawsMultilineInput(t *testing.T) {
+	ir := NewInputReader("> ")
+	ir.terminalWidth = 10
+	ir.termFd = int(os.Stdout.Fd())
+
+	output := captureStdout(t, func() {
+		ir.InsertChar("a")
+		ir.HandleEvent(&InputEvent{Type: EventNewline})
+		ir.InsertChar("b")
+	})
+
+	if ir.line != "a\nb" {
+		t.Fatalf("unexpected line %q", ir.line)
+	}
+	if !strings.Contains(output, "\r\n"+continuationPrompt(2)+"b") {
+		t.Fatalf("expected second row behind a continuation prompt, got %q", output)
+	}
+	if ir.lastRowCount != 2 || ir.currentPhysicalLine != 1 {
+		t.Fatalf("expected cursor on row 2 of 2, got line %d of %d", ir.currentPhysicalLine, ir.lastRowCount)
+	}
+}
+
+func TestLayoutInputWrapsEachRow(t *testing.T) {
+	// "> " + 12 chars wraps onto a second line at width 10; the next row
+	// starts on a third line.
+	display := "abcdefghijkl\nxy"
+	layout := layoutInput(10, 2, display, len(display))
+	if layout.lines != 3 {
+		t.Fatalf("expected 3 physical lines, got %d", layout.lines)
+	}
+	if layout.cursorLine != 2 || layout.cursorCol != 4 {
+		t.Fatalf("expected cursor at line 2 col 4, got line %d col %d", layout.cursorLine, layout.cursorCol)
+	}
+
+	layout = layoutInput(10, 2, display, 3)
+	if layout.cursorLine != 0 || layout.cursorCol != 5 {
+		t.Fatalf("expected cursor at line 0 col 5, got line %d col %d", layout.cursorLine, layout.cursorCol)
+	}
+}
+
+func TestExternalEditorCommand(t *testing.T) {
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "code --wait")
+	if got := strings.Join(externalEditorCommand(), " "); got != "code --wait" {
+		t.Fatalf("expected $EDITOR with its arguments, got %q", got)
+	}
+	t.Setenv("VISUAL", "nvim")
+	if got := strings.Join(externalEditorCommand(), " "); got != "nvim" {
+		t.Fatalf("expected $VISUAL to take precedence, got %q", got)
+	}
+}
+
+func TestEditInExternalEditor(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as the editor")
+	}
+	script := filepath.Join(t.TempDir(), "editor.sh")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\nprintf '\\nsecond line\\n' >> \"$1\"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("VISUAL", script)
+
+	edited, err := editInExternalEditor("first line")
+	if err != nil {
+		t.Fatalf("editInExternalEditor: %v", err)
+	}
+	if edited != "first line\nsecond line" {
+		t.Fatalf("unexpected edited text %q", edited)
+	}
+}
@@ -0,0 +1,306 @@
+package console
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// KeyAction is an input action that a key can be bound to.
+type KeyAction string
+
+// Actions accepted in keymap bindings.
+const (
+	ActionNone               KeyAction = "none"
+	ActionSubmit             KeyAction = "submit"
+	ActionNewline            KeyAction = "newline"
+	ActionInterrupt          KeyAction = "interrupt"
+	ActionSuspend            KeyAction = "suspend"
+	ActionExternalEditor     KeyAction = "external_editor"
+	ActionHelp               KeyAction = "help"
+	ActionCancel             KeyAction = "cancel"
+	ActionCursorLeft         KeyAction = "cursor_left"
+	ActionCursorRight        KeyAction = "cursor_right"
+	ActionWordLeft           KeyAction = "word_left"
+	ActionWordRight          KeyAction = "word_right"
+	ActionLineStart          KeyAction = "line_start"
+	ActionLineEnd            KeyAction = "line_end"
+	ActionHistoryPrev        KeyAction = "history_prev"
+	ActionHistoryNext        KeyAction = "history_next"
+	ActionDeleteBackward     KeyAction = "delete_backward"
+	ActionDeleteForward      KeyAction = "delete_forward"
+	ActionDeleteWordBackward KeyAction = "delete_word_backward"
+	ActionKillToEnd          KeyAction = "kill_to_end"
+	ActionKillToStart        KeyAction = "kill_to_start"
+	ActionVimNormal          KeyAction = "vim_normal"
+)
+
+var keyActions = map[KeyAction]bool{
+	ActionNone: true, ActionSubmit: true, ActionNewline: true, ActionInterrupt: true,
+	ActionSuspend: true, ActionExternalEditor: true, ActionHelp: true, ActionCancel: true,
+	ActionCursorLeft: true, ActionCursorRight: true, ActionWordLeft: true, ActionWordRight: true,
+	ActionLineStart: true, ActionLineEnd: true, ActionHistoryPrev: true, ActionHistoryNext: true,
+	ActionDeleteBackward: true, ActionDeleteForward: true, ActionDeleteWordBackward: true,
+	ActionKillToEnd: true, ActionKillToStart: true, ActionVimNormal: true,
+}
+
+// Keymap presets.
+const (
+	KeymapDefault = "default"
+	KeymapEmacs   = "emacs"
+	KeymapVim     = "vim"
+)
+
+// defaultBindings are the keys every preset starts from.
+var defaultBindings = map[string]KeyAction{
+	"enter":      ActionSubmit,
+	"alt+enter":  ActionNewline,
+	"ctrl+j":     ActionNewline,
+	"ctrl+c":     ActionInterrupt,
+	"ctrl+z":     ActionSuspend,
+	"ctrl+e":     ActionExternalEditor,
+	"f1":         ActionHelp,
+	"esc":        ActionCancel,
+	"tab":        ActionCancel,
+	"left":       ActionCursorLeft,
+	"right":      ActionCursorRight,
+	"ctrl+left":  ActionWordLeft,
+	"ctrl+right": ActionWordRight,
+	"alt+left":   ActionWordLeft,
+	"alt+right":  ActionWordRight,
+	"home":       ActionLineStart,
+	"end":        ActionLineEnd,
+	"up":         ActionHistoryPrev,
+	"down":       ActionHistoryNext,
+	"backspace":  ActionDeleteBackward,
+	"delete":     ActionDeleteForward,
+}
+
+// presetBindings override defaultBindings for each preset. Emacs moves the
+// external editor to Ctrl+X because Ctrl+E is end of line there.
+var presetBindings = map[string]map[string]KeyAction{
+	KeymapDefault: {},
+	KeymapEmacs: {
+		"ctrl+a": ActionLineStart,
+		"ctrl+e": ActionLineEnd,
+		"ctrl+b": ActionCursorLeft,
+		"ctrl+f": ActionCursorRight,
+		"ctrl+p": ActionHistoryPrev,
+		"ctrl+n": ActionHistoryNext,
+		"ctrl+d": ActionDeleteForward,
+		"ctrl+k": ActionKillToEnd,
+		"ctrl+u": ActionKillToStart,
+		"ctrl+w": ActionDeleteWordBackward,
+		"ctrl+x": ActionExternalEditor,
+	},
+	KeymapVim: {
+		"esc":    ActionVimNormal,
+		"ctrl+w": ActionDeleteWordBackward,
+		"ctrl+u": ActionKillToStart,
+	},
+}
+
+// vimNormalKeys are the actions of printable keys in vim normal mode. Keys
+// marked in vimInsertAfter return to insert mode after their action.
+var vimNormalKeys = map[string]KeyAction{
+	"h": ActionCursorLeft,
+	"l": ActionCursorRight,
+	"b": ActionWordLeft,
+	"w": ActionWordRight,
+	"0": ActionLineStart,
+	"^": ActionLineStart,
+	"$": ActionLineEnd,
+	"k": ActionHistoryPrev,
+	"j": ActionHistoryNext,
+	"x": ActionDeleteForward,
+	"X": ActionDeleteBackward,
+	"D": ActionKillToEnd,
+	"C": ActionKillToEnd,
+	"I": ActionLineStart,
+	"A": ActionLineEnd,
+	"a": ActionCursorRight,
+	"i": ActionNone,
+}
+
+var vimInsertAfter = map[string]bool{"i": true, "a": true, "A": true, "I": true, "C": true}
+
+// Keymap maps key names such as "ctrl+a", "alt+enter" or "up" to actions.
+type Keymap struct {
+	Preset   string
+	bindings map[string]KeyAction
+}
+
+// keymapFile is the JSON layout of keymap.json.
+type keymapFile struct {
+	Preset   string            `json:"preset"`
+	Bindings map[string]string `json:"bindings"`
+}
+
+// NewKeymap returns the named preset with overrides applied on top. An
+// override bound to "none" removes the key's binding.
+func NewKeymap(preset string, overrides map[string]string) (*Keymap, error) {
+	preset = strings.ToLower(strings.TrimSpace(preset))
+	if preset == "" {
+		preset = KeymapDefault
+	}
+	extra, ok := presetBindings[preset]
+	if !ok {
+		return nil, fmt.Errorf("unknown keymap preset %q: must be one of %s, %s or %s", preset, KeymapDefault, KeymapEmacs, KeymapVim)
+	}
+
+	km := &Keymap{Preset: preset, bindings: make(map[string]KeyAction, len(defaultBindings)+len(overrides))}
+	for key, action := range defaultBindings {
+		km.bindings[key] = action
+	}
+	for key, action := range extra {
+		km.bindings[key] = action
+	}
+	for key, name := range overrides {
+		key = normalizeKeyName(key)
+		action := KeyAction(strings.ToLower(strings.TrimSpace(name)))
+		if !validKeyName(key) {
+			return nil, fmt.Errorf("unknown key %q in keymap", key)
+		}
+		if !keyActions[action] {
+			return nil, fmt.Errorf("unknown action %q for key %q in keymap", name, key)
+		}
+		km.bindings[key] = action
+	}
+	return km, nil
+}
+
+// DefaultKeymap returns the built-in bindings.
+func DefaultKeymap() *Keymap {
+	km, _ := NewKeymap(KeymapDefault, nil)
+	return km
+}
+
+// LoadKeymap reads a keymap file such as ~/.ledit/keymap.json. A missing file
+// yields the default keymap.
+func LoadKeymap(path string) (*Keymap, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return DefaultKeymap(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keymap: %w", err)
+	}
+	var file keymapFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse keymap %s: %w", path, err)
+	}
+	km, err := NewKeymap(file.Preset, file.Bindings)
+	if err != nil {
+		return nil, fmt.Errorf("invalid keymap %s: %w", path, err)
+	}
+	return km, nil
+}
+
+// Action returns the action bound to key, or ActionNone. A nil keymap uses
+// the default bindings.
+func (km *Keymap) Action(key string) KeyAction {
+	bindings := defaultBindings
+	if km != nil {
+		bindings = km.bindings
+	}
+	if action, ok := bindings[key]; ok {
+		return action
+	}
+	return ActionNone
+}
+
+func (km *Keymap) vim() bool {
+	return km != nil && km.Preset == KeymapVim
+}
+
+// normalizeKeyName lowercases a key name and accepts common spellings such
+// as "C-a", "Ctrl-A", "escape" and "return".
+func normalizeKeyName(key string) string {
+	key = strings.ToLower(strings.TrimSpace(key))
+	key = strings.ReplaceAll(key, "-", "+")
+	if strings.HasPrefix(key, "c+") {
+		key = "ctrl+" + key[2:]
+	} else if strings.HasPrefix(key, "m+") || strings.HasPrefix(key, "meta+") {
+		key = "alt+" + key[strings.Index(key, "+")+1:]
+	}
+	switch key {
+	case "escape":
+		return "esc"
+	case "return":
+		return "enter"
+	case "alt+return":
+		return "alt+enter"
+	case "del":
+		return "delete"
+	}
+	return key
+}
+
+func validKeyName(key string) bool {
+	if _, ok := defaultBindings[key]; ok {
+		return true
+	}
+	if name, ok := strings.CutPrefix(key, "ctrl+"); ok && len(name) == 1 && name[0] >= 'a' && name[0] <= 'z' {
+		return true
+	}
+	return false
+}
+
+// controlKeyName names a control byte, or returns "" for other bytes.
+func controlKeyName(b byte) string {
+	switch b {
+	case 8, 127:
+		return "backspace"
+	case 9:
+		return "tab"
+	case 13:
+		return "enter"
+	case 27:
+		return "esc"
+	}
+	if b >= 1 && b <= 26 {
+		return "ctrl+" + string(rune('a'+b-1))
+	}
+	return ""
+}
+
+// Key returns the name of the key that produced the event, or "" for
+// printable characters, mouse and paste events.
+func (e *InputEvent) Key() string {
+	switch e.Type {
+	case EventUp, EventDown, EventLeft, EventRight:
+		if e.Data != "" {
+			return e.Data
+		}
+		return map[InputEventType]string{EventUp: "up", EventDown: "down", EventLeft: "left", EventRight: "right"}[e.Type]
+	case EventHome:
+		return "home"
+	case EventEnd:
+		return "end"
+	case EventBackspace:
+		return "backspace"
+	case EventDelete:
+		return "delete"
+	case EventEnter:
+		return "enter"
+	case EventTab:
+		return "tab"
+	case EventEscape:
+		return "esc"
+	case EventInterrupt:
+		return "ctrl+c"
+	case EventSuspend:
+		return "ctrl+z"
+	case EventNewline:
+		if e.Data != "" {
+			return e.Data
+		}
+		return "alt+enter"
+	case EventExternalEditor:
+		return "ctrl+e"
+	case EventKey:
+		return e.Data
+	}
+	return ""
+}
@@ -0,0 +1,118 @@
+package console
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestKeymapPresetsAndOverrides(t *testing.T) {
+	km, err := NewKeymap(KeymapEmacs, map[string]string{"C-g": "interrupt", "ctrl+c": "none", "Ctrl-O": "external_editor"})
+	if err != nil {
+		t.Fatalf("NewKeymap: %v", err)
+	}
+	for key, want := range map[string]KeyAction{
+		"ctrl+a": ActionLineStart,
+		"ctrl+e": ActionLineEnd,
+		"ctrl+x": ActionExternalEditor,
+		"ctrl+o": ActionExternalEditor,
+		"ctrl+g": ActionInterrupt,
+		"ctrl+c": ActionNone,
+		"enter":  ActionSubmit,
+		"":       ActionNone,
+	} {
+		if got := km.Action(key); got != want {
+			t.Errorf("Action(%q) = %q, want %q", key, got, want)
+		}
+	}
+
+	if _, err := NewKeymap("nano", nil); err == nil {
+		t.Error("expected an unknown preset to be rejected")
+	}
+	if _, err := NewKeymap("", map[string]string{"hyper+q": "submit"}); err == nil {
+		t.Error("expected an unknown key to be rejected")
+	}
+	if _, err := NewKeymap("", map[string]string{"ctrl+q": "explode"}); err == nil {
+		t.Error("expected an unknown action to be rejected")
+	}
+}
+
+func TestLoadKeymap(t *testing.T) {
+	dir := t.TempDir()
+	km, err := LoadKeymap(filepath.Join(dir, "missing.json"))
+	if err != nil || km.Preset != KeymapDefault {
+		t.Fatalf("expected defaults for a missing file, got %+v, %v", km, err)
+	}
+
+	path := filepath.Join(dir, "keymap.json")
+	if err := os.WriteFile(path, []byte(`{"preset":"vim","bindings":{"ctrl+y":"help"}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	km, err = LoadKeymap(path)
+	if err != nil {
+		t.Fatalf("LoadKeymap: %v", err)
+	}
+	if km.Action("esc") != ActionVimNormal || km.Action("ctrl+y") != ActionHelp {
+		t.Fatalf("unexpected bindings: esc=%q ctrl+y=%q", km.Action("esc"), km.Action("ctrl+y"))
+	}
+}
+
+func TestEscapeParserNamesKeys(t *testing.T) {
+	ep := NewEscapeParser()
+	if event := ep.Parse(1); event == nil || event.Key() != "ctrl+a" {
+		t.Fatalf("expected Ctrl+A, got %v", event)
+	}
+	var event *InputEvent
+	for _, b := range []byte("\033[1;5D") {
+		event = ep.Parse(b)
+	}
+	if event == nil || event.Type != EventLeft || event.Key() != "ctrl+left" {
+		t.Fatalf("expected Ctrl+Left, got %v", event)
+	}
+	for _, b := range []byte("\033OP") {
+		event = ep.Parse(b)
+	}
+	if event == nil || event.Key() != "f1" {
+		t.Fatalf("expected F1, got %v", event)
+	}
+}
+
+func TestRunActionEditsLine(t *testing.T) {
+	ir := NewInputReader("> ")
+	ir.terminalWidth = 80
+	ir.line = "git commit --amend"
+	ir.cursorPos = len(ir.line)
+
+	captureStdout(t, func() {
+		ir.runAction(ActionDeleteWordBackward, &InputEvent{Type: EventKey})
+		ir.runAction(ActionWordLeft, &InputEvent{Type: EventKey})
+		ir.runAction(ActionKillToEnd, &InputEvent{Type: EventKey})
+	})
+	if ir.line != "git " || ir.cursorPos != 4 {
+		t.Fatalf("unexpected line %q cursor %d", ir.line, ir.cursorPos)
+	}
+}
+
+func TestVimNormalMode(t *testing.T) {
+	ir := NewInputReader("> ")
+	ir.terminalWidth = 80
+	km, _ := NewKeymap(KeymapVim, nil)
+	ir.SetKeymap(km)
+	ir.line = "hello world"
+	ir.cursorPos = len(ir.line)
+
+	captureStdout(t, func() {
+		ir.runAction(km.Action("esc"), &InputEvent{Type: EventEscape})
+		for _, key := range []string{"0", "w", "x", "q"} {
+			ir.runAction(ActionNone, &InputEvent{Type: EventChar, Data: key})
+		}
+		ir.runAction(ActionNone, &InputEvent{Type: EventChar, Data: "i"})
+		ir.runAction(ActionNone, &InputEvent{Type: EventChar, Data: "W"})
+	})
+	if ir.line != "hello World" {
+		t.Fatalf("unexpected line %q", ir.line)
+	}
+	if ir.vimNormal {
+		t.Fatal("expected i to return to insert mode")
+	}
+}
@@ -80,6 +80,8 @@ const (
 	MouseTrackingVT200 = "\x1b[?1000h"
 	// Enable mouse tracking with SGR extended coordinates
 	MouseTrackingSGR = "\x1b[?1006h"
+	// Report button presses, releases and the wheel with SGR coordinates
+	MouseTrackingEnable = MouseTrackingVT200 + MouseTrackingSGR
 	// Disable all mouse tracking
 	MouseTrackingDisable = "\x1b[?1006l\x1b[?1000l\x1b[?9l"
 )
@@ -296,6 +298,9 @@ func ParseMouseEvent(data string) (*MouseEvent, error) {
 		}
 		event = parseSGRMouseEvent(data)
 	}
+	if event == nil {
+		return nil, fmt.Errorf("invalid mouse event")
+	}
 
 	return event, nil
 }
@@ -304,134 +309,74 @@ func parseX10MouseEvent(data string) *MouseEvent {
 	// Cb is the button/flags byte
 	// Cx and Cy are 1-based coordinates
 	cb := int(data[3]) - 32
-	cx := int(data[4]) - 32
-	cy := int(data[5]) - 32
-
 	event := &MouseEvent{
-		Row: cy,
-		Col: cx,
-	}
-
-	// Decode button and flags
-	if cb&0x1 != 0 {
-		event.Modifiers.Shift = true
-	}
-	if cb&0x2 != 0 {
-		event.Modifiers.Alt = true
-	}
-	if cb&0x4 != 0 {
-		event.Modifiers.Ctrl = true
+		Row: int(data[5]) - 32,
+		Col: int(data[4]) - 32,
 	}
-
-	// Determine button and kind
-	if cb&0x60 == 0x60 {
-		// Release event
-		switch cb & 0x1f {
-		case 0:
-			event.Button = MouseButtonLeft
-		case 1:
-			event.Button = MouseButtonMiddle
-		case 2:
-			event.Button = MouseButtonRight
-		default:
-			event.Button = MouseButtonExtra1
-		}
+	decodeMouseButton(cb, event)
+	// X10 reports every release as button 3
+	if cb&0x43 == 3 {
 		event.Kind = MouseEventRelease
-	} else if cb&0x20 != 0 {
-		// Motion event
-		event.Kind = MouseEventMotion
-		event.Button = MouseButtonLeft // Motion is always left button
-	} else {
-		// Press event
-		switch cb & 0x1f {
-		case 0:
-			event.Button = MouseButtonLeft
-		case 1:
-			event.Button = MouseButtonMiddle
-		case 2:
-			event.Button = MouseButtonRight
-		default:
-			event.Button = MouseButtonExtra1
-		}
-		event.Kind = MouseEventPress
 	}
-
 	return event
 }
 
 func parseSGRMouseEvent(data string) *MouseEvent {
-	// Find the 'M' at the end
-	endIdx := strings.Index(data, "M")
-	if endIdx < 0 {
+	// The final byte is 'M' for a press and 'm' for a release
+	final := data[len(data)-1]
+	if final != 'M' && final != 'm' {
 		return nil
 	}
 
 	// Extract the middle part: < Cb;Cx;Cy
-	parts := strings.Split(data[4:endIdx], ";")
+	parts := strings.Split(data[3:len(data)-1], ";")
 	if len(parts) != 3 {
 		return nil
 	}
 
-	cb, _ := strconv.Atoi(parts[0])
-	cx, _ := strconv.Atoi(parts[1])
-	cy, _ := strconv.Atoi(parts[2])
+	cb, err1 := strconv.Atoi(parts[0])
+	cx, err2 := strconv.Atoi(parts[1])
+	cy, err3 := strconv.Atoi(parts[2])
+	if err1 != nil || err2 != nil || err3 != nil {
+		return nil
+	}
 
 	event := &MouseEvent{
 		Row: cy,
 		Col: cx,
 	}
-
-	// Decode modifiers (bits 1-3)
-	if cb&0x1 != 0 {
-		event.Modifiers.Shift = true
-	}
-	if cb&0x2 != 0 {
-		event.Modifiers.Alt = true
-	}
-	if cb&0x4 != 0 {
-		event.Modifiers.Ctrl = true
-	}
-
-	// Decode button (bits 4-6)
-	button := (cb >> 4) & 0x7
-
-	// Check for release (bit 7)
-	if cb&0x80 != 0 {
+	decodeMouseButton(cb, event)
+	if final == 'm' {
 		event.Kind = MouseEventRelease
-	} else if cb&0x20 != 0 {
-		event.Kind = MouseEventMotion
-	} else {
-		event.Kind = MouseEventPress
 	}
+	return event
+}
 
-	// Decode button number
-	switch button {
-	case 0:
-		event.Button = MouseButtonLeft
-	case 1:
-		event.Button = MouseButtonMiddle
-	case 2:
-		event.Button = MouseButtonRight
-	case 3:
-		event.Button = MouseButtonExtra1
-	case 64:
-		event.Kind = MouseEventWheelUp
-	case 65:
-		event.Kind = MouseEventWheelDown
-	case 66:
-		event.Kind = MouseEventWheelLeft
-	case 67:
-		event.Kind = MouseEventWheelRight
+// decodeMouseButton fills in an event from the button byte of a mouse
+// report: the low two bits pick the button, 4, 8 and 16 flag Shift, Alt and
+// Ctrl, 32 marks motion and 64 the wheel.
+func decodeMouseButton(cb int, event *MouseEvent) {
+	event.Flags = cb
+	event.Modifiers = MouseModifier{
+		Shift: cb&0x4 != 0,
+		Alt:   cb&0x8 != 0,
+		Ctrl:  cb&0x10 != 0,
+	}
+	button := cb & 0x3
+	event.Button = []MouseButton{MouseButtonLeft, MouseButtonMiddle, MouseButtonRight, MouseButtonExtra1}[button]
+	switch {
+	case cb&0x40 != 0:
+		event.Kind = []MouseEventKind{MouseEventWheelUp, MouseEventWheelDown, MouseEventWheelLeft, MouseEventWheelRight}[button]
+	case cb&0x20 != 0:
+		event.Kind = MouseEventMotion
 	default:
-		event.Button = MouseButtonExtra1
+		event.Kind = MouseEventPress
 	}
-
-	return event
 }
 
 // EnableMouseTracking enables mouse tracking in the terminal
 func EnableMouseTracking() {
-	fmt.Print(MouseTrackingSGR)
+	fmt.Print(MouseTrackingEnable)
 }
 
 // DisableMouseTracking disables mouse tracking
@@ -0,0 +1,174 @@
+package console
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// wheelScrollRows is how far one wheel notch scrolls the output view.
+	wheelScrollRows = 3
+
+	altScreenEnter = "\033[?1049h"
+	altScreenLeave = "\033[?1049l"
+	statusBarStyle = "\033[7m"
+
+	// pathDelimiters end a file path in the output.
+	pathDelimiters = " \t\"'`()[]{}<>,;|"
+)
+
+// outputView shows the scrollback in the terminal's alternate screen while
+// the output has focus. The last row is a status bar; clicking it gives
+// focus back to the input.
+type outputView struct {
+	rows   []string // the scrollback wrapped to the terminal width
+	offset int      // rows scrolled up from the bottom
+	width  int
+	height int
+	notice string // shown in the status bar until the next redraw
+}
+
+func newOutputView(width, height int) *outputView {
+	v := &outputView{}
+	v.resize(width, height)
+	return v
+}
+
+// resize wraps the scrollback for a terminal of the given size.
+func (v *outputView) resize(width, height int) {
+	v.width, v.height = max(width, 1), max(height, 2)
+	v.rows = wrapRows(scrollbackLines(), v.width)
+	v.scroll(0)
+}
+
+// pageRows is how many rows of output fit above the status bar.
+func (v *outputView) pageRows() int {
+	return v.height - 1
+}
+
+// scroll moves the view up by n rows, or down when n is negative. It reports
+// whether the view was scrolled down while already at the bottom.
+func (v *outputView) scroll(n int) bool {
+	pastBottom := n < 0 && v.offset == 0
+	v.offset = min(max(v.offset+n, 0), max(len(v.rows)-v.pageRows(), 0))
+	return pastBottom
+}
+
+// visible returns the rows on screen, from the top.
+func (v *outputView) visible() []string {
+	end := len(v.rows) - v.offset
+	return v.rows[max(end-v.pageRows(), 0):end]
+}
+
+// rowAt returns the text on a 1-based screen row.
+func (v *outputView) rowAt(screenRow int) (string, bool) {
+	rows := v.visible()
+	if screenRow < 1 || screenRow > len(rows) {
+		return "", false
+	}
+	return rows[screenRow-1], true
+}
+
+// render draws the view over the whole screen.
+func (v *outputView) render() string {
+	var sb strings.Builder
+	sb.WriteString(HomeCursorSeq() + ClearScreenSeq())
+	for i, row := range v.visible() {
+		sb.WriteString(MoveCursorSeq(1, i+1) + row)
+	}
+	sb.WriteString(MoveCursorSeq(1, v.height) + v.statusBar())
+	v.notice = ""
+	return sb.String()
+}
+
+func (v *outputView) statusBar() string {
+	text := v.notice
+	if text == "" {
+		end := len(v.rows) - v.offset
+		text = fmt.Sprintf("output %d-%d of %d · wheel or PgUp/PgDn to scroll · click a file path to open it · Esc or click here to type",
+			max(end-v.pageRows(), 0)+1, end, len(v.rows))
+	}
+	text = fitDiffColumn(" "+text, v.width)
+	if !ColorsEnabled() {
+		return text
+	}
+	return statusBarStyle + pad(text, v.width, "left") + ColorReset
+}
+
+// filePathAt returns the file named by the word at a 1-based column of a
+// row, resolved against root, with the line number of a path:line or
+// path:line:column reference.
+func filePathAt(row string, col int, root string) (string, int, bool) {
+	runes := []rune(row)
+	i := col - 1
+	if i < 0 || i >= len(runes) || strings.ContainsRune(pathDelimiters, runes[i]) {
+		return "", 0, false
+	}
+	start, end := i, i+1
+	for start > 0 && !strings.ContainsRune(pathDelimiters, runes[start-1]) {
+		start--
+	}
+	for end < len(runes) && !strings.ContainsRune(pathDelimiters, runes[end]) {
+		end++
+	}
+
+	word, line := splitPathLine(strings.TrimRight(string(runes[start:end]), ".:"))
+	path, ok := resolveFilePath(word, root)
+	if !ok {
+		return "", 0, false
+	}
+	return path, line, true
+}
+
+// splitPathLine splits a trailing :line or :line:column from a path.
+func splitPathLine(word string) (string, int) {
+	parts := strings.Split(word, ":")
+	line := 0
+	for n := 0; n < 2 && len(parts) > 1; n++ {
+		number, err := strconv.Atoi(parts[len(parts)-1])
+		if err != nil {
+			break
+		}
+		line = number
+		parts = parts[:len(parts)-1]
+	}
+	return strings.Join(parts, ":"), line
+}
+
+// OpenInEditor opens a file at a line with the configured editor command,
+// or in $VISUAL or $EDITOR, at the line for editors known to accept one.
+func OpenInEditor(path string, line int) error {
+	if template := editorCommandTemplate(); template != "" {
+		return runEditor(expandEditorCommand(template, path, line))
+	}
+
+	editor := externalEditorCommand()
+	args := append([]string(nil), editor...)
+	switch name := strings.TrimSuffix(filepath.Base(editor[0]), ".exe"); {
+	case line <= 0:
+		args = append(args, path)
+	case name == "code" || name == "codium" || name == "cursor":
+		args = append(args, "--goto", path+":"+strconv.Itoa(line))
+	case name == "vi" || name == "vim" || name == "nvim" || name == "nano" || name == "emacs" || name == "emacsclient" || name == "micro":
+		args = append(args, "+"+strconv.Itoa(line), path)
+	default:
+		args = append(args, path)
+	}
+	return runEditor(args)
+}
+
+// runEditor runs an editor command attached to the terminal.
+func runEditor(command []string) error {
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor %s failed: %w", command[0], err)
+	}
+	return nil
+}
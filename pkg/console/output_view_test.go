@@ -0,0 +1,181 @@
+package console
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func resetScrollback(t *testing.T) {
+	t.Helper()
+	reset := func() {
+		scrollback.mu.Lock()
+		scrollback.lines, scrollback.partial = nil, ""
+		scrollback.mu.Unlock()
+	}
+	reset()
+	t.Cleanup(reset)
+}
+
+func TestParseMouseEventSGR(t *testing.T) {
+	tests := []struct {
+		data   string
+		kind   MouseEventKind
+		button MouseButton
+		row    int
+		col    int
+	}{
+		{"\x1b[<0;12;5M", MouseEventPress, MouseButtonLeft, 5, 12},
+		{"\x1b[<0;12;5m", MouseEventRelease, MouseButtonLeft, 5, 12},
+		{"\x1b[<2;1;1M", MouseEventPress, MouseButtonRight, 1, 1},
+		{"\x1b[<64;40;10M", MouseEventWheelUp, MouseButtonLeft, 10, 40},
+		{"\x1b[<65;40;10M", MouseEventWheelDown, MouseButtonMiddle, 10, 40},
+		{"\x1b[<32;3;4M", MouseEventMotion, MouseButtonLeft, 4, 3},
+	}
+	for _, tt := range tests {
+		event, err := ParseMouseEvent(tt.data)
+		if err != nil {
+			t.Fatalf("ParseMouseEvent(%q): %v", tt.data, err)
+		}
+		if event.Kind != tt.kind || event.Button != tt.button || event.Row != tt.row || event.Col != tt.col {
+			t.Errorf("ParseMouseEvent(%q) = %+v", tt.data, event)
+		}
+	}
+
+	if event, _ := ParseMouseEvent("\x1b[<16;1;1M"); event == nil || !event.Modifiers.Ctrl || event.Modifiers.Shift {
+		t.Errorf("expected a Ctrl+click, got %+v", event)
+	}
+	if _, err := ParseMouseEvent("\x1b[<0;x;1M"); err == nil {
+		t.Error("expected a malformed event to be rejected")
+	}
+}
+
+func TestEscapeParserMouseAndPageKeys(t *testing.T) {
+	parse := func(seq string) *InputEvent {
+		p := NewEscapeParser()
+		var event *InputEvent
+		for i := 0; i < len(seq); i++ {
+			if e := p.Parse(seq[i]); e != nil {
+				event = e
+			}
+		}
+		return event
+	}
+
+	x10 := "\x1b[M" + string([]byte{32 + 64, 32 + 7, 32 + 3})
+	event := parse(x10)
+	if event == nil || event.Type != EventMouse || event.Data != x10 {
+		t.Fatalf("expected the whole X10 report as a mouse event, got %+v", event)
+	}
+	if mouse, err := ParseMouseEvent(event.Data); err != nil || mouse.Kind != MouseEventWheelUp || mouse.Col != 7 || mouse.Row != 3 {
+		t.Fatalf("unexpected X10 wheel event %+v, %v", mouse, err)
+	}
+
+	if event := parse("\x1b[5~"); event == nil || event.Key() != "pgup" {
+		t.Fatalf("expected pgup, got %+v", event)
+	}
+	if event := parse("\x1b[6~"); event == nil || event.Key() != "pgdown" {
+		t.Fatalf("expected pgdown, got %+v", event)
+	}
+}
+
+func TestRecordOutput(t *testing.T) {
+	resetScrollback(t)
+
+	RecordOutput("\033[32mok\033[0m first\r\n")
+	RecordOutput("progress 10%\rprogress 100%\n")
+	RecordOutput("partial ")
+	RecordOutput("line")
+
+	got := strings.Join(scrollbackLines(), "|")
+	if got != "ok first|progress 100%|partial line" {
+		t.Fatalf("unexpected scrollback %q", got)
+	}
+
+	if rows := wrapRows([]string{"abcdefgh", "", "\tx"}, 5); strings.Join(rows, "|") != "abcde|fgh||    x" {
+		t.Fatalf("unexpected rows %q", rows)
+	}
+}
+
+func TestOutputViewScrolling(t *testing.T) {
+	resetScrollback(t)
+	for i := 0; i < 20; i++ {
+		RecordOutput("line\n")
+	}
+	RecordOutput("last\n")
+
+	v := newOutputView(80, 6)
+	if row, ok := v.rowAt(5); !ok || row != "last" {
+		t.Fatalf("expected the last line at the bottom, got %q", row)
+	}
+	if _, ok := v.rowAt(6); ok {
+		t.Fatal("expected the status bar row to hold no output")
+	}
+
+	v.scroll(100)
+	if v.offset != 21-5 {
+		t.Fatalf("expected scrolling to stop at the top, got offset %d", v.offset)
+	}
+	if v.scroll(-3) || v.offset != 13 {
+		t.Fatalf("expected to scroll down three rows, got offset %d", v.offset)
+	}
+	v.scroll(-100)
+	if !v.scroll(-1) {
+		t.Fatal("expected scrolling down at the bottom to be reported")
+	}
+}
+
+func TestOutputViewKeysReturnFocus(t *testing.T) {
+	resetScrollback(t)
+	RecordOutput("one\ntwo\n")
+	ir := &InputReader{terminalWidth: 80, termFd: -1}
+
+	ir.focusOutput(0)
+	if ir.output == nil {
+		t.Fatal("expected the output view to open")
+	}
+	if !ir.handleOutputKey(&InputEvent{Type: EventKey, Data: "pgup"}) || ir.output == nil {
+		t.Fatal("expected PgUp to scroll the output view")
+	}
+	// Typing gives focus back to the input and is handled there
+	if ir.handleOutputKey(&InputEvent{Type: EventChar, Data: "x"}) || ir.output != nil {
+		t.Fatal("expected a typed character to return focus to the input")
+	}
+
+	resetScrollback(t)
+	ir.focusOutput(0)
+	if ir.output != nil {
+		t.Fatal("expected no output view without output")
+	}
+}
+
+func TestFilePathAt(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "pkg"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(root, "pkg", "main.go")
+	if err := os.WriteFile(file, []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	row := `Edited (pkg/main.go:12:3), see "docs" and --- a/pkg/main.go`
+	tests := []struct {
+		col  int
+		ok   bool
+		line int
+	}{
+		{col: 9, ok: true, line: 12},
+		{col: 50, ok: true},
+		{col: 34, ok: false}, // a word that isn't a file
+		{col: 3, ok: false},
+		{col: 27, ok: false}, // a space
+	}
+	for _, tt := range tests {
+		path, line, ok := filePathAt(row, tt.col, root)
+		if ok != tt.ok || line != tt.line || (ok && path != file) {
+			t.Errorf("filePathAt(col %d) = %q, %d, %v", tt.col, path, line, ok)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package console
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Palette names accepted by SetPalette.
+const (
+	PaletteDefault    = "default"
+	PaletteLight      = "light"
+	PaletteSolarized  = "solarized"
+	PaletteColorblind = "colorblind"
+	PaletteNoColor    = "no-color"
+)
+
+// Palette assigns ANSI codes to the semantic roles used for diffs, focus bars,
+// status indicators and rendered markdown. Every status role is paired with a
+// symbol elsewhere, so colors only reinforce meaning and never carry it alone.
+type Palette struct {
+	Name    string
+	Added   string // diff additions
+	Removed string // diff deletions
+	Hunk    string // diff hunk headers
+	Success string
+	Failure string
+	Warning string
+	Info    string
+	Focus   string // pinned focus/status bars
+	Accent  string // headings, labels and list markers
+	Muted   string // secondary text: frames, paths, hints
+	Code    string // inline code
+	Keyword string // syntax highlighting in code blocks
+	String  string
+	Comment string
+	Number  string
+}
+
+// Status symbols shown alongside (or instead of) status colors.
+const (
+	SymbolSuccess = "✓"
+	SymbolFailure = "✗"
+	SymbolWarning = "!"
+	SymbolInfo    = "i"
+)
+
+// color256 returns the escape code for a color of the 256-color palette.
+func color256(n int) string {
+	return fmt.Sprintf("\033[38;5;%dm", n)
+}
+
+var (
+	defaultPalette = Palette{
+		Name:    PaletteDefault,
+		Added:   ColorGreen,
+		Removed: ColorRed,
+		Hunk:    ColorCyan,
+		Success: ColorGreen,
+		Failure: ColorRed,
+		Warning: ColorYellow,
+		Info:    ColorBlue,
+		Focus:   ColorCyan,
+		Accent:  ColorCyan,
+		Muted:   ColorGray,
+		Code:    ColorCyan,
+		Keyword: ColorMagenta,
+		String:  ColorGreen,
+		Comment: ColorGray,
+		Number:  ColorYellow,
+	}
+
+	// lightPalette uses darker shades that keep their contrast on light
+	// backgrounds, where yellow, cyan and bright colors wash out.
+	lightPalette = Palette{
+		Name:    PaletteLight,
+		Added:   color256(28),
+		Removed: color256(124),
+		Hunk:    color256(25),
+		Success: color256(28),
+		Failure: color256(124),
+		Warning: color256(130),
+		Info:    color256(25),
+		Focus:   color256(25),
+		Accent:  color256(25),
+		Muted:   color256(243),
+		Code:    color256(90),
+		Keyword: color256(90),
+		String:  color256(28),
+		Comment: color256(243),
+		Number:  color256(130),
+	}
+
+	// solarizedPalette uses the Solarized accent colors, which read on both
+	// the light and dark Solarized backgrounds.
+	solarizedPalette = Palette{
+		Name:    PaletteSolarized,
+		Added:   color256(64),
+		Removed: color256(160),
+		Hunk:    color256(61),
+		Success: color256(64),
+		Failure: color256(160),
+		Warning: color256(136),
+		Info:    color256(33),
+		Focus:   color256(37),
+		Accent:  color256(33),
+		Muted:   color256(245),
+		Code:    color256(37),
+		Keyword: color256(64),
+		String:  color256(37),
+		Comment: color256(245),
+		Number:  color256(125),
+	}
+
+	// colorblindPalette avoids red/green pairs: blue and orange stay distinct
+	// under protanopia, deuteranopia and tritanopia.
+	colorblindPalette = Palette{
+		Name:    PaletteColorblind,
+		Added:   ColorBrightBlue,
+		Removed: color256(208),
+		Hunk:    ColorMagenta,
+		Success: ColorBrightBlue,
+		Failure: color256(208),
+		Warning: ColorBrightYellow,
+		Info:    ColorWhite,
+		Focus:   ColorBrightWhite,
+		Accent:  ColorBrightBlue,
+		Muted:   ColorGray,
+		Code:    ColorBrightCyan,
+		Keyword: ColorMagenta,
+		String:  ColorBrightBlue,
+		Comment: ColorGray,
+		Number:  color256(208),
+	}
+
+	// noColorPalette turns colors off like NO_COLOR does.
+	noColorPalette = Palette{Name: PaletteNoColor}
+
+	paletteMu     sync.RWMutex
+	activePalette = defaultPalette
+)
+
+// NoColorRequested reports whether the NO_COLOR convention (https://no-color.org)
+// asks for uncolored output: the variable is set to any non-empty value.
+func NoColorRequested() bool {
+	return os.Getenv("NO_COLOR") != ""
+}
+
+// ColorsEnabled reports whether color escape codes should be emitted: NO_COLOR
+// is unset and the no-color palette is not selected.
+func ColorsEnabled() bool {
+	return !NoColorRequested() && activePaletteName() != PaletteNoColor
+}
+
+// PaletteNames lists the built-in palettes.
+func PaletteNames() []string {
+	return []string{PaletteDefault, PaletteLight, PaletteSolarized, PaletteColorblind, PaletteNoColor}
+}
+
+// LookupPalette returns the built-in palette with the given name.
+func LookupPalette(name string) (Palette, bool) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", PaletteDefault, "dark":
+		return defaultPalette, true
+	case PaletteLight:
+		return lightPalette, true
+	case PaletteSolarized:
+		return solarizedPalette, true
+	case PaletteColorblind, "colorblind-safe", "cb":
+		return colorblindPalette, true
+	case PaletteNoColor, "nocolor", "none", "monochrome":
+		return noColorPalette, true
+	}
+	return Palette{}, false
+}
+
+// SetPalette makes the named palette active. Unknown names leave the current
+// palette unchanged and return false.
+func SetPalette(name string) bool {
+	p, ok := LookupPalette(name)
+	if !ok {
+		return false
+	}
+	paletteMu.Lock()
+	activePalette = p
+	paletteMu.Unlock()
+	return true
+}
+
+// ActivePalette returns the palette in use. When colors are disabled every
+// code is empty, so callers can interpolate the fields unconditionally.
+func ActivePalette() Palette {
+	if !ColorsEnabled() {
+		return Palette{Name: activePaletteName()}
+	}
+	paletteMu.RLock()
+	defer paletteMu.RUnlock()
+	return activePalette
+}
+
+// selectedPalette returns the selected palette regardless of NO_COLOR.
+func selectedPalette() Palette {
+	paletteMu.RLock()
+	defer paletteMu.RUnlock()
+	return activePalette
+}
+
+func activePaletteName() string {
+	paletteMu.RLock()
+	defer paletteMu.RUnlock()
+	return activePalette.Name
+}
+
+// Code returns code, or "" when colors are disabled. Use it when writing raw
+// escape constants such as ColorGray or ColorReset into formatted output.
+func Code(code string) string {
+	if !ColorsEnabled() {
+		return ""
+	}
+	return code
+}
+
+// StatusLabel renders a success or failure marker with its symbol and text,
+// so the outcome reads correctly without color.
+func StatusLabel(ok bool, text string) string {
+	p := ActivePalette()
+	if ok {
+		return Colorize(SymbolSuccess+" "+text, p.Success)
+	}
+	return Colorize(SymbolFailure+" "+text, p.Failure)
+}
@@ -0,0 +1,88 @@
+package console
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNoColorDisablesCodes(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+
+	if got := Colorize("done", ColorGreen); got != "done" {
+		t.Errorf("Colorize under NO_COLOR = %q, want plain text", got)
+	}
+	if got := Code(ColorReset); got != "" {
+		t.Errorf("Code under NO_COLOR = %q, want empty", got)
+	}
+	p := ActivePalette()
+	if p.Added != "" || p.Removed != "" || p.Focus != "" {
+		t.Errorf("ActivePalette under NO_COLOR should have no codes: %+v", p)
+	}
+	if got := StatusLabel(false, "tests failed"); got != SymbolFailure+" tests failed" {
+		t.Errorf("StatusLabel = %q, want symbol and text", got)
+	}
+}
+
+func TestSetPalette(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	t.Cleanup(func() { SetPalette(PaletteDefault) })
+
+	if SetPalette("neon") {
+		t.Fatal("SetPalette accepted an unknown palette")
+	}
+	if !SetPalette(PaletteColorblind) {
+		t.Fatal("SetPalette rejected the colorblind palette")
+	}
+	p := ActivePalette()
+	if p.Added == ColorGreen || p.Removed == ColorRed {
+		t.Errorf("colorblind palette should avoid red/green: %+v", p)
+	}
+	if p.Added == p.Removed || p.Success == p.Failure {
+		t.Errorf("colorblind palette roles must differ: %+v", p)
+	}
+	if got := StatusLabel(true, "ok"); !strings.Contains(got, SymbolSuccess+" ok") {
+		t.Errorf("StatusLabel = %q, want success symbol", got)
+	}
+}
+
+func TestNoColorPaletteDisablesCodes(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	t.Cleanup(func() { SetPalette(PaletteDefault) })
+
+	if !SetPalette("none") {
+		t.Fatal("SetPalette rejected the no-color alias")
+	}
+	if ColorsEnabled() {
+		t.Fatal("colors should be disabled by the no-color palette")
+	}
+	if got := Colorize("done", ColorGreen); got != "done" {
+		t.Errorf("Colorize with no-color palette = %q, want plain text", got)
+	}
+	var out strings.Builder
+	f := NewStreamingFormatter(&out)
+	f.SetWidth(80)
+	f.Write("# Title\n")
+	if strings.Contains(out.String(), "\033[") {
+		t.Errorf("formatter should follow the no-color palette, got %q", out.String())
+	}
+}
+
+func TestPalettesDefineEveryRole(t *testing.T) {
+	for _, name := range PaletteNames() {
+		p, ok := LookupPalette(name)
+		if !ok || p.Name != name {
+			t.Fatalf("LookupPalette(%q) = %+v, %v", name, p, ok)
+		}
+		if name == PaletteNoColor {
+			continue
+		}
+		for role, code := range map[string]string{
+			"Added": p.Added, "Removed": p.Removed, "Accent": p.Accent, "Muted": p.Muted,
+			"Code": p.Code, "Keyword": p.Keyword, "String": p.String, "Comment": p.Comment,
+		} {
+			if !strings.HasPrefix(code, "\033[") {
+				t.Errorf("%s palette has no %s color", name, role)
+			}
+		}
+	}
+}
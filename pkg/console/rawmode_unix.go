@@ -0,0 +1,20 @@
+//go:build !windows
+// +build !windows
+
+package console
+
+import "golang.org/x/term"
+
+// makeRaw puts the terminal into raw mode and returns the state to restore.
+func makeRaw(fd int) (*term.State, error) {
+	return term.MakeRaw(fd)
+}
+
+// restoreTerminal restores the state makeRaw returned.
+func restoreTerminal(fd int, state *term.State) error {
+	return term.Restore(fd, state)
+}
+
+// EnableVirtualTerminal makes the terminal interpret ANSI escape sequences.
+// Unix terminals always do.
+func EnableVirtualTerminal() {}
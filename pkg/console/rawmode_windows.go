@@ -0,0 +1,68 @@
+//go:build windows
+// +build windows
+
+package console
+
+import (
+	"os"
+	"sync"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/term"
+)
+
+// outputMode is the console output mode before raw mode changed it.
+var (
+	outputMu   sync.Mutex
+	outputMode *uint32
+)
+
+// makeRaw puts the console into raw mode and returns the state to restore.
+// Input is switched to virtual terminal input, so keys arrive as the same
+// escape sequences a Unix terminal sends (under ConPTY, as in Windows
+// Terminal and VS Code, those are passed through as is), and output to
+// virtual terminal processing so the escape sequences the input line
+// writes are interpreted rather than printed.
+func makeRaw(fd int) (*term.State, error) {
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return nil, err
+	}
+	outputMu.Lock()
+	defer outputMu.Unlock()
+	if mode, ok := enableVirtualTerminalOutput(windows.DISABLE_NEWLINE_AUTO_RETURN); ok && outputMode == nil {
+		outputMode = &mode
+	}
+	return state, nil
+}
+
+// restoreTerminal restores the state makeRaw returned.
+func restoreTerminal(fd int, state *term.State) error {
+	outputMu.Lock()
+	if outputMode != nil {
+		windows.SetConsoleMode(windows.Handle(os.Stdout.Fd()), *outputMode)
+		outputMode = nil
+	}
+	outputMu.Unlock()
+	return term.Restore(fd, state)
+}
+
+// EnableVirtualTerminal makes the console interpret ANSI escape sequences,
+// which colored output relies on. The legacy console host leaves it off.
+func EnableVirtualTerminal() {
+	enableVirtualTerminalOutput(0)
+}
+
+// enableVirtualTerminalOutput turns on virtual terminal processing and
+// extra for stdout, returning the previous mode.
+func enableVirtualTerminalOutput(extra uint32) (uint32, bool) {
+	handle := windows.Handle(os.Stdout.Fd())
+	var mode uint32
+	if err := windows.GetConsoleMode(handle, &mode); err != nil {
+		return 0, false
+	}
+	if err := windows.SetConsoleMode(handle, mode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING|extra); err != nil {
+		return 0, false
+	}
+	return mode, true
+}
@@ -0,0 +1,99 @@
+package console
+
+import (
+	"strings"
+	"sync"
+)
+
+const (
+	// scrollbackLimit is how many lines of output the scrollback keeps.
+	scrollbackLimit = 5000
+	// scrollbackTabWidth is how many columns a tab takes in the output view.
+	scrollbackTabWidth = 4
+)
+
+// scrollback keeps recent console output for the output view, since mouse
+// reporting takes the wheel away from the terminal's own scrollback.
+var scrollback struct {
+	mu      sync.Mutex
+	lines   []string
+	partial string // the last line, until its newline is written
+}
+
+// RecordOutput adds text written to the terminal to the scrollback. Colors
+// are dropped, and a carriage return starts its line over like it does on
+// screen. Files named in completed lines go on the quick-open list.
+func RecordOutput(text string) {
+	text = strings.ReplaceAll(stripANSIEscapeCodes(text), "\r\n", "\n")
+	if text == "" {
+		return
+	}
+	for _, line := range appendScrollback(text) {
+		recordFileRefs(line)
+	}
+}
+
+// appendScrollback adds text to the scrollback and returns the lines it
+// completed.
+func appendScrollback(text string) []string {
+	scrollback.mu.Lock()
+	defer scrollback.mu.Unlock()
+	var completed []string
+	parts := strings.Split(text, "\n")
+	for i, part := range parts {
+		if j := strings.LastIndexByte(part, '\r'); j >= 0 {
+			scrollback.partial, part = "", part[j+1:]
+		}
+		scrollback.partial += part
+		if i < len(parts)-1 {
+			scrollback.lines = append(scrollback.lines, scrollback.partial)
+			completed = append(completed, scrollback.partial)
+			scrollback.partial = ""
+		}
+	}
+	if over := len(scrollback.lines) - scrollbackLimit; over > 0 {
+		scrollback.lines = append([]string(nil), scrollback.lines[over:]...)
+	}
+	return completed
+}
+
+// scrollbackLines returns the recorded output, including an unfinished last
+// line.
+func scrollbackLines() []string {
+	scrollback.mu.Lock()
+	defer scrollback.mu.Unlock()
+	lines := append([]string(nil), scrollback.lines...)
+	if scrollback.partial != "" {
+		lines = append(lines, scrollback.partial)
+	}
+	return lines
+}
+
+// wrapRows breaks lines into rows of at most width columns, with tabs
+// expanded to the next tab stop.
+func wrapRows(lines []string, width int) []string {
+	if width < 1 {
+		width = 1
+	}
+	rows := make([]string, 0, len(lines))
+	for _, line := range lines {
+		var row []rune
+		for _, r := range line {
+			if r == '\t' {
+				for n := scrollbackTabWidth - len(row)%scrollbackTabWidth; n > 0; n-- {
+					row = append(row, ' ')
+				}
+			} else if r >= ' ' {
+				row = append(row, r)
+			}
+			for len(row) >= width {
+				rows = append(rows, string(row[:width]))
+				row = row[width:]
+			}
+		}
+		if len(row) > 0 || len(line) == 0 {
+			rows = append(rows, string(row))
+		}
+	}
+	return rows
+}
@@ -27,9 +27,12 @@ func extraInterruptSignals() []os.Signal {
 	return []os.Signal{syscall.SIGTERM}
 }
 
-// resizeSignal returns the terminal resize signal (SIGWINCH) on Unix.
-func resizeSignal() os.Signal {
-	return syscall.SIGWINCH
+// notifyResize returns a channel that receives SIGWINCH when the terminal is
+// resized, and a function that stops the notifications.
+func notifyResize() (<-chan os.Signal, func()) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGWINCH)
+	return ch, func() { signal.Stop(ch) }
 }
 
 // reRaiseSignal re-raises a signal so the default handler can run (Unix).
@@ -4,7 +4,12 @@
 package console
 
 import (
+	"errors"
 	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
 )
 
 // signalsToCapture returns the list of signals to capture for cleanup on Windows.
@@ -18,8 +23,43 @@ func extraInterruptSignals() []os.Signal {
 	return []os.Signal{}
 }
 
-// resizeSignal returns nil on Windows since SIGWINCH is not available.
-func resizeSignal() os.Signal { return nil }
+// resizeEvent is sent on the resize channel; Windows has no SIGWINCH.
+type resizeEvent struct{}
+
+func (resizeEvent) String() string { return "window resized" }
+func (resizeEvent) Signal()        {}
+
+// notifyResize polls the console size, since Windows has no resize signal,
+// and returns a channel that receives an event when it changes and a
+// function that stops the polling.
+func notifyResize() (<-chan os.Signal, func()) {
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	fd := int(os.Stdout.Fd())
+	width, height, _ := term.GetSize(fd)
+	go func() {
+		ticker := time.NewTicker(250 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				w, h, err := term.GetSize(fd)
+				if err != nil || (w == width && h == height) {
+					continue
+				}
+				width, height = w, h
+				select {
+				case ch <- resizeEvent{}:
+				default:
+				}
+			}
+		}
+	}()
+	var once sync.Once
+	return ch, func() { once.Do(func() { close(done) }) }
+}
 
 // reRaiseSignal cannot re-raise POSIX signals on Windows; exit after cleanup.
 func reRaiseSignal(sig os.Signal) { os.Exit(0) }
@@ -45,8 +85,11 @@ func resetTerminalSignals() {
 	// SIGTTIN/SIGTTOU are not available on Windows
 }
 
-// setNonblock is a no-op on Windows since file descriptor non-blocking mode is not directly supported.
+// setNonblock reports that console handles can't be made non-blocking, so
+// input is read with blocking reads.
 func setNonblock(fd int, nonblock bool) error {
-	// Non-blocking mode works differently on Windows and is handled by os.Stdin.Read()
+	if nonblock {
+		return errors.ErrUnsupported
+	}
 	return nil
 }
@@ -0,0 +1,617 @@
+package console
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+const (
+	// narrowWidth is the terminal width below which code blocks lose their
+	// frame and tables switch to one "column: value" line per cell.
+	narrowWidth = 50
+	// maxRuleWidth caps horizontal rules and code block frames.
+	maxRuleWidth = 80
+)
+
+var (
+	headingLine   = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	bulletLine    = regexp.MustCompile(`^(\s*)([-*+])\s+(\[[ xX]\]\s+)?(.*)$`)
+	orderedLine   = regexp.MustCompile(`^(\s*)(\d{1,9})([.)])\s+(.*)$`)
+	quoteLine     = regexp.MustCompile(`^\s{0,3}>\s?(.*)$`)
+	ruleLine      = regexp.MustCompile(`^\s{0,3}([-*_])(\s*([-*_])){2,}\s*$`)
+	fenceLine     = regexp.MustCompile("^\\s{0,3}(`{3,}|~{3,})\\s*([^`]*)$")
+	tableDivider  = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+	ansiEscape    = regexp.MustCompile(`\x1b\[[0-9;]*m|\x1b\]8;[^\x1b\a]*(\x1b\\|\a)`)
+	markerOnlyRun = regexp.MustCompile(`^[-*_\s]*$`)
+)
+
+// StreamingFormatter renders markdown to the terminal as it streams in.
+// Complete lines are rendered as they arrive: headings, lists, quotes and
+// rules immediately, fenced code blocks line by line with syntax colors, and
+// tables once their last row is known. Paragraph text is written word by word
+// so long answers still appear progressively. On narrow terminals code blocks
+// drop their frame and wide tables are shown as "column: value" lines.
+type StreamingFormatter struct {
+	mu     sync.Mutex
+	out    io.Writer
+	width  func() int
+	colors *bool // nil follows ColorsEnabled and the active palette
+
+	pending  string // the current line, not yet written
+	lineOpen bool   // part of the current line has been written
+	style    string // style of the open line, reapplied after inline spans
+	code     *openCodeBlock
+	table    []string
+}
+
+type openCodeBlock struct {
+	fence       string
+	highlighter *codeHighlighter
+}
+
+// NewStreamingFormatter returns a formatter writing to out. Colors follow
+// the active palette and NO_COLOR; the width is read from the terminal on
+// stdout.
+func NewStreamingFormatter(out io.Writer) *StreamingFormatter {
+	return &StreamingFormatter{out: out, width: stdoutWidth}
+}
+
+// SetWidth overrides the terminal width, for tests and non-terminal output.
+func (f *StreamingFormatter) SetWidth(width int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.width = func() int { return width }
+}
+
+// SetColors turns ANSI colors on or off.
+func (f *StreamingFormatter) SetColors(enabled bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.colors = &enabled
+}
+
+func stdoutWidth() int {
+	if width, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && width > 0 {
+		return width
+	}
+	return maxRuleWidth
+}
+
+// Write renders the complete lines in chunk and as much of the current line
+// as can be rendered without seeing its end.
+func (f *StreamingFormatter) Write(chunk string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pending += strings.ReplaceAll(chunk, "\r\n", "\n")
+	for {
+		i := strings.IndexByte(f.pending, '\n')
+		if i < 0 {
+			break
+		}
+		line := f.pending[:i]
+		f.pending = f.pending[i+1:]
+		f.completeLine(line, true)
+	}
+	f.writePartial()
+}
+
+// Flush renders everything still buffered, closing an unterminated code
+// block or table. Call it when a response ends.
+func (f *StreamingFormatter) Flush() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.pending != "" || f.lineOpen {
+		line := f.pending
+		f.pending = ""
+		f.completeLine(line, false)
+	}
+	f.flushTable()
+	if f.code != nil {
+		f.closeCodeBlock()
+	}
+}
+
+func (f *StreamingFormatter) write(s string) {
+	io.WriteString(f.out, s)
+}
+
+func (f *StreamingFormatter) colorsOn() bool {
+	if f.colors != nil {
+		return *f.colors
+	}
+	return ColorsEnabled()
+}
+
+// c returns code when colors are enabled.
+func (f *StreamingFormatter) c(code string) string {
+	if !f.colorsOn() {
+		return ""
+	}
+	return code
+}
+
+// palette returns the colors to render with, all empty when colors are off.
+func (f *StreamingFormatter) palette() Palette {
+	if !f.colorsOn() {
+		return Palette{}
+	}
+	return selectedPalette()
+}
+
+// completeLine renders a whole line. newline is false for the final line of
+// a response that did not end with one.
+func (f *StreamingFormatter) completeLine(line string, newline bool) {
+	end := ""
+	if newline {
+		end = "\n"
+	}
+	if f.lineOpen {
+		f.write(f.inline(line, f.style) + f.c(ColorReset) + end)
+		f.lineOpen, f.style = false, ""
+		return
+	}
+	if f.code != nil {
+		if isClosingFence(line, f.code.fence) {
+			f.closeCodeBlock()
+		} else {
+			f.writeCodeLine(line)
+		}
+		return
+	}
+	if isTableRow(line) {
+		f.table = append(f.table, line)
+		return
+	}
+	f.flushTable()
+	if m := fenceLine.FindStringSubmatch(line); m != nil {
+		f.openCodeBlock(m[1], m[2])
+		return
+	}
+	if ruleLine.MatchString(line) {
+		f.write(f.palette().Muted + strings.Repeat("─", f.ruleWidth()) + f.c(ColorReset) + end)
+		return
+	}
+	prefix, style, content := f.classify(line)
+	f.write(prefix + style + f.inline(content, style) + f.c(ColorReset) + end)
+}
+
+// writePartial writes the words of an unfinished paragraph or list line
+// whose inline markup is complete, keeping the last (possibly partial) word
+// buffered.
+func (f *StreamingFormatter) writePartial() {
+	if f.code != nil || len(f.table) > 0 || f.pending == "" {
+		return
+	}
+	if !f.lineOpen {
+		trimmed := strings.TrimLeft(f.pending, " \t")
+		if strings.HasPrefix(trimmed, "|") || strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			return
+		}
+		// Wait until the line's first word is complete, so markers like "-",
+		// "1." and "#" can be told apart from rules and fences.
+		if !strings.ContainsAny(trimmed, " \t") {
+			return
+		}
+	}
+	cut := strings.LastIndexAny(f.pending, " \t")
+	if cut < 0 {
+		return
+	}
+	head := f.pending[:cut+1]
+	if !inlineClosed(head) {
+		return
+	}
+	if !f.lineOpen {
+		prefix, style, content := f.classify(head)
+		if markerOnlyRun.MatchString(head) || (prefix != "" && strings.TrimSpace(content) == "") {
+			return
+		}
+		f.write(prefix + style)
+		f.style = style
+		head = content
+	}
+	f.write(f.inline(head, f.style))
+	f.pending = f.pending[cut+1:]
+	f.lineOpen = true
+}
+
+// classify splits a line into its rendered block prefix, the style for its
+// text and the text itself.
+func (f *StreamingFormatter) classify(line string) (prefix, style, content string) {
+	if m := headingLine.FindStringSubmatch(line); m != nil {
+		switch len(m[1]) {
+		case 1:
+			style = f.c(ColorBold+ColorUnderline) + f.palette().Accent
+		case 2:
+			style = f.c(ColorBold) + f.palette().Accent
+		default:
+			style = f.c(ColorBold)
+		}
+		return "", style, m[2]
+	}
+	if m := bulletLine.FindStringSubmatch(line); m != nil {
+		marker := "•"
+		switch strings.ToLower(strings.TrimSpace(m[3])) {
+		case "[ ]":
+			marker = "☐"
+		case "[x]":
+			marker = "☑"
+		}
+		return m[1] + f.palette().Accent + marker + f.c(ColorReset) + " ", "", m[4]
+	}
+	if m := orderedLine.FindStringSubmatch(line); m != nil {
+		return m[1] + f.palette().Accent + m[2] + m[3] + f.c(ColorReset) + " ", "", m[4]
+	}
+	if m := quoteLine.FindStringSubmatch(line); m != nil {
+		return f.palette().Muted + "│ " + f.c(ColorReset), f.c(ColorItalic), m[1]
+	}
+	return "", "", line
+}
+
+// inline renders emphasis, code spans, strikethrough and links. References
+// to files, in code spans or plain text, become hyperlinks. style is
+// restored after each span.
+func (f *StreamingFormatter) inline(text, style string) string {
+	var out strings.Builder
+	restore := f.c(ColorReset) + style
+	root := f.linkRoot(text)
+	for i := 0; i < len(text); {
+		rest := text[i:]
+		switch {
+		case rest[0] == '\\' && len(rest) > 1 && strings.ContainsRune("\\`*_[]()#+-.!|~", rune(rest[1])):
+			out.WriteByte(rest[1])
+			i += 2
+			continue
+		case rest[0] == '`':
+			if end := strings.IndexByte(rest[1:], '`'); end >= 0 {
+				code := rest[1 : end+1]
+				if f.colorsOn() {
+					if m, ok := fileRefAt(code, root); ok && root != "" && m.end == len(code) {
+						code = hyperlink(fileURL(m.ref), code)
+					}
+					out.WriteString(f.palette().Code + code + restore)
+				} else {
+					out.WriteString("`" + code + "`")
+				}
+				i += end + 2
+				continue
+			}
+		case strings.HasPrefix(rest, "**") || strings.HasPrefix(rest, "__") && wordBoundaryBefore(text, i):
+			marker := rest[:2]
+			if end := strings.Index(rest[2:], marker); end > 0 {
+				out.WriteString(f.c(ColorBold) + f.inline(rest[2:end+2], style+f.c(ColorBold)) + restore)
+				i += end + 4
+				continue
+			}
+		case strings.HasPrefix(rest, "~~"):
+			if end := strings.Index(rest[2:], "~~"); end > 0 {
+				out.WriteString(f.c("\033[9m") + rest[2:end+2] + restore)
+				i += end + 4
+				continue
+			}
+		case rest[0] == '*' || rest[0] == '_' && wordBoundaryBefore(text, i):
+			if end := closingEmphasis(rest, rest[0]); end > 0 {
+				out.WriteString(f.c(ColorItalic) + f.inline(rest[1:end], style+f.c(ColorItalic)) + restore)
+				i += end + 1
+				continue
+			}
+		case rest[0] == '[':
+			if label, url, n, ok := parseLink(rest); ok {
+				out.WriteString(f.c(ColorUnderline) + f.palette().Accent + label + restore)
+				if url != label {
+					out.WriteString(" " + f.palette().Muted + "(" + url + ")" + restore)
+				}
+				i += n
+				continue
+			}
+		case root != "" && isPathByte(rest[0]) && (i == 0 || !isPathByte(text[i-1])):
+			if m, ok := fileRefAt(rest, root); ok {
+				out.WriteString(hyperlink(fileURL(m.ref), rest[:m.end]))
+				i += m.end
+				continue
+			}
+		}
+		_, size := utf8.DecodeRuneInString(rest)
+		out.WriteString(rest[:size])
+		i += size
+	}
+	return out.String()
+}
+
+// linkRoot returns the directory file references in text are resolved
+// against, or "" when they shouldn't be linked.
+func (f *StreamingFormatter) linkRoot(text string) string {
+	if !f.colorsOn() || !hyperlinksEnabled() || !strings.ContainsAny(text, "./") {
+		return ""
+	}
+	root, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	return root
+}
+
+// wordBoundaryBefore reports whether text[i] starts a word, so underscores
+// inside identifiers like snake_case are not read as emphasis.
+func wordBoundaryBefore(text string, i int) bool {
+	return i == 0 || !isWordByte(text[i-1])
+}
+
+// closingEmphasis returns the index in s of the marker closing the emphasis
+// s opens, or -1. Emphasis must not start or end with a space.
+func closingEmphasis(s string, marker byte) int {
+	if len(s) < 3 || s[1] == ' ' || s[1] == marker {
+		return -1
+	}
+	for i := 2; i < len(s); i++ {
+		if s[i] != marker || s[i-1] == ' ' {
+			continue
+		}
+		if marker == '_' && i+1 < len(s) && isWordByte(s[i+1]) {
+			continue
+		}
+		return i
+	}
+	return -1
+}
+
+// parseLink parses "[label](url)" at the start of s.
+func parseLink(s string) (label, url string, n int, ok bool) {
+	closeLabel := strings.Index(s, "](")
+	if closeLabel < 1 || strings.ContainsRune(s[1:closeLabel], '[') {
+		return "", "", 0, false
+	}
+	closeURL := strings.IndexByte(s[closeLabel+2:], ')')
+	if closeURL < 0 {
+		return "", "", 0, false
+	}
+	return s[1:closeLabel], s[closeLabel+2 : closeLabel+2+closeURL], closeLabel + 3 + closeURL, true
+}
+
+// inlineClosed reports whether every code span, bold span and link in s is
+// closed, so s can be rendered without seeing the rest of the line.
+func inlineClosed(s string) bool {
+	if strings.Count(s, "`")%2 != 0 || strings.Count(s, "**")%2 != 0 || strings.Count(s, "~~")%2 != 0 {
+		return false
+	}
+	if strings.Count(strings.ReplaceAll(s, "**", ""), "*")%2 != 0 || strings.Count(s, "_")%2 != 0 {
+		return false
+	}
+	if open := strings.LastIndex(s, "["); open >= 0 && !strings.Contains(s[open:], ")") {
+		return false
+	}
+	return true
+}
+
+func (f *StreamingFormatter) narrow() bool {
+	return f.width() < narrowWidth
+}
+
+func (f *StreamingFormatter) ruleWidth() int {
+	width := f.width()
+	if width > maxRuleWidth {
+		width = maxRuleWidth
+	}
+	if width < 3 {
+		width = 3
+	}
+	return width
+}
+
+func (f *StreamingFormatter) openCodeBlock(fence, info string) {
+	f.code = &openCodeBlock{fence: fence, highlighter: newCodeHighlighter(info, f.palette())}
+	lang := strings.TrimSpace(info)
+	if f.narrow() {
+		if lang != "" {
+			f.write(f.palette().Muted + lang + f.c(ColorReset) + "\n")
+		}
+		return
+	}
+	label := "─"
+	if lang != "" {
+		label = "─ " + lang + " "
+	}
+	fill := f.ruleWidth() - utf8.RuneCountInString(label) - 1
+	if fill < 0 {
+		fill = 0
+	}
+	f.write(f.palette().Muted + "╭" + label + strings.Repeat("─", fill) + f.c(ColorReset) + "\n")
+}
+
+func (f *StreamingFormatter) writeCodeLine(line string) {
+	line = strings.ReplaceAll(line, "\t", "    ")
+	if f.colorsOn() {
+		line = f.code.highlighter.Line(line)
+	}
+	if f.narrow() {
+		f.write("  " + line + f.c(ColorReset) + "\n")
+		return
+	}
+	f.write(f.palette().Muted + "│ " + f.c(ColorReset) + line + f.c(ColorReset) + "\n")
+}
+
+func (f *StreamingFormatter) closeCodeBlock() {
+	f.code = nil
+	if f.narrow() {
+		return
+	}
+	f.write(f.palette().Muted + "╰" + strings.Repeat("─", f.ruleWidth()-1) + f.c(ColorReset) + "\n")
+}
+
+func isClosingFence(line, fence string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == ""
+}
+
+func isTableRow(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, "|") && strings.Count(trimmed, "|") >= 2
+}
+
+// splitTableRow splits a table row into trimmed cells, honoring "\|".
+func splitTableRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, "\\|") {
+		line = line[:len(line)-1]
+	}
+	var cells []string
+	var cell strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case line[i] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(line[i])
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// visibleWidth is the number of terminal columns s occupies, ignoring ANSI
+// escapes.
+func visibleWidth(s string) int {
+	return utf8.RuneCountInString(ansiEscape.ReplaceAllString(s, ""))
+}
+
+// flushTable renders the buffered table rows.
+func (f *StreamingFormatter) flushTable() {
+	if len(f.table) == 0 {
+		return
+	}
+	rows := f.table
+	f.table = nil
+
+	var header []string
+	var aligns []string
+	var body [][]string
+	for i, row := range rows {
+		if i == 1 && tableDivider.MatchString(row) {
+			for _, cell := range splitTableRow(row) {
+				switch {
+				case strings.HasPrefix(cell, ":") && strings.HasSuffix(cell, ":"):
+					aligns = append(aligns, "center")
+				case strings.HasSuffix(cell, ":"):
+					aligns = append(aligns, "right")
+				default:
+					aligns = append(aligns, "left")
+				}
+			}
+			continue
+		}
+		cells := splitTableRow(row)
+		rendered := make([]string, len(cells))
+		for j, cell := range cells {
+			rendered[j] = f.inline(cell, "")
+		}
+		if i == 0 && len(rows) > 1 && tableDivider.MatchString(rows[1]) {
+			header = rendered
+			continue
+		}
+		body = append(body, rendered)
+	}
+
+	columns := len(header)
+	for _, row := range body {
+		if len(row) > columns {
+			columns = len(row)
+		}
+	}
+	widths := make([]int, columns)
+	for _, row := range append([][]string{header}, body...) {
+		for j, cell := range row {
+			if w := visibleWidth(cell); w > widths[j] {
+				widths[j] = w
+			}
+		}
+	}
+	total := 1
+	for _, w := range widths {
+		total += w + 3
+	}
+
+	if total > f.width() && header != nil {
+		f.writeTableRecords(header, body)
+		return
+	}
+	f.writeTableGrid(header, body, widths, aligns)
+}
+
+// writeTableGrid draws the table with box-drawing borders.
+func (f *StreamingFormatter) writeTableGrid(header []string, body [][]string, widths []int, aligns []string) {
+	border := func(left, mid, right string) string {
+		parts := make([]string, len(widths))
+		for i, w := range widths {
+			parts[i] = strings.Repeat("─", w+2)
+		}
+		return f.palette().Muted + left + strings.Join(parts, mid) + right + f.c(ColorReset) + "\n"
+	}
+	row := func(cells []string, style string) string {
+		var sb strings.Builder
+		sb.WriteString(f.palette().Muted + "│" + f.c(ColorReset))
+		for i, w := range widths {
+			cell := ""
+			if i < len(cells) {
+				cell = cells[i]
+			}
+			align := "left"
+			if i < len(aligns) {
+				align = aligns[i]
+			}
+			sb.WriteString(" " + style + pad(cell, w, align) + f.c(ColorReset) + " " + f.palette().Muted + "│" + f.c(ColorReset))
+		}
+		return sb.String() + "\n"
+	}
+
+	f.write(border("┌", "┬", "┐"))
+	if header != nil {
+		f.write(row(header, f.c(ColorBold)))
+		f.write(border("├", "┼", "┤"))
+	}
+	for _, cells := range body {
+		f.write(row(cells, ""))
+	}
+	f.write(border("└", "┴", "┘"))
+}
+
+// writeTableRecords shows each row as "column: value" lines, for tables
+// wider than the terminal.
+func (f *StreamingFormatter) writeTableRecords(header []string, body [][]string) {
+	for i, cells := range body {
+		if i > 0 {
+			f.write("\n")
+		}
+		for j, cell := range cells {
+			name := ""
+			if j < len(header) {
+				name = header[j]
+			}
+			f.write(fmt.Sprintf("%s%s:%s %s\n", f.c(ColorBold), name, f.c(ColorReset), cell))
+		}
+	}
+}
+
+func pad(s string, width int, align string) string {
+	gap := width - visibleWidth(s)
+	if gap <= 0 {
+		return s
+	}
+	switch align {
+	case "right":
+		return strings.Repeat(" ", gap) + s
+	case "center":
+		return strings.Repeat(" ", gap/2) + s + strings.Repeat(" ", gap-gap/2)
+	}
+	return s + strings.Repeat(" ", gap)
+}
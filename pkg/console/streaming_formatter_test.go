@@ -0,0 +1,101 @@
+package console
+
+import (
+	"strings"
+	"testing"
+)
+
+const sampleMarkdown = "# Plan\n\nThis is **bold** and *italic* with `code` and a [link](https://example.com).\n\n" +
+	"- first item\n- [x] done item\n1. step one\n> quoted text\n\n" +
+	"```go\nfunc main() {\n\treturn nil // done\n}\n```\n\n" +
+	"| Name | Count |\n|:-----|------:|\n| a | 1 |\n| bb | 22 |\n\nTrailing words"
+
+func renderMarkdown(chunks []string, width int, colors bool) string {
+	var out strings.Builder
+	f := NewStreamingFormatter(&out)
+	f.SetWidth(width)
+	f.SetColors(colors)
+	for _, chunk := range chunks {
+		f.Write(chunk)
+	}
+	f.Flush()
+	return out.String()
+}
+
+func TestStreamingFormatterChunkingDoesNotChangeOutput(t *testing.T) {
+	for _, colors := range []bool{true, false} {
+		whole := renderMarkdown([]string{sampleMarkdown}, 80, colors)
+
+		var chunks []string
+		for i := 0; i < len(sampleMarkdown); i += 3 {
+			chunks = append(chunks, sampleMarkdown[i:min(i+3, len(sampleMarkdown))])
+		}
+		if chunked := renderMarkdown(chunks, 80, colors); chunked != whole {
+			t.Fatalf("colors=%v: chunked output differs\nwhole:\n%s\nchunked:\n%s", colors, whole, chunked)
+		}
+	}
+}
+
+func TestStreamingFormatterPlain(t *testing.T) {
+	got := renderMarkdown([]string{sampleMarkdown}, 80, false)
+	if strings.Contains(got, "\033[") {
+		t.Fatalf("expected no ANSI escapes without colors:\n%q", got)
+	}
+	for _, want := range []string{
+		"Plan\n",
+		"This is bold and italic with `code` and a link (https://example.com).",
+		"• first item\n☑ done item\n1. step one\n│ quoted text\n",
+		"╭─ go ",
+		"│ func main() {\n",
+		"│ Name │ Count │",
+		"│ a    │     1 │",
+		"│ bb   │    22 │",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q:\n%s", want, got)
+		}
+	}
+	if !strings.HasSuffix(got, "Trailing words") {
+		t.Errorf("final line should be flushed without a newline:\n%q", got)
+	}
+}
+
+func TestStreamingFormatterHighlightsCode(t *testing.T) {
+	got := renderMarkdown([]string{"```go\nfunc main() { x := \"hi\" } // note\n```\n"}, 80, true)
+	for _, want := range []string{
+		defaultPalette.Keyword + "func" + ColorReset,
+		defaultPalette.String + "\"hi\"" + ColorReset,
+		defaultPalette.Comment + "// note" + ColorReset,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q:\n%q", want, got)
+		}
+	}
+}
+
+func TestStreamingFormatterNarrowTerminal(t *testing.T) {
+	input := "```sh\necho hi\n```\n| Name | Description |\n|---|---|\n| build | compiles the whole project |\n"
+	got := renderMarkdown([]string{input}, 30, false)
+	want := "sh\n  echo hi\nName: build\nDescription: compiles the whole project\n"
+	if got != want {
+		t.Fatalf("unexpected narrow output:\n%q\nwant:\n%q", got, want)
+	}
+}
+
+func TestStreamingFormatterStreamsParagraphWords(t *testing.T) {
+	var out strings.Builder
+	f := NewStreamingFormatter(&out)
+	f.SetColors(false)
+	f.Write("Hello wor")
+	if out.String() != "Hello " {
+		t.Fatalf("expected complete words to be written, got %q", out.String())
+	}
+	f.Write("ld **bo")
+	if out.String() != "Hello world " {
+		t.Fatalf("expected open bold span to be held back, got %q", out.String())
+	}
+	f.Write("ld** done\n")
+	if out.String() != "Hello world bold done\n" {
+		t.Fatalf("unexpected output %q", out.String())
+	}
+}
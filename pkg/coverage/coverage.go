@@ -0,0 +1,368 @@
+// Package coverage runs Go tests with a cover profile, maps the profile onto
+// functions, and keeps the result in the workspace's .ledit/coverage.json so
+// the agent can target the code tests do not reach.
+package coverage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StoreFile is the coverage store's path relative to the workspace root.
+var StoreFile = filepath.Join(".ledit", "coverage.json")
+
+// outputTailLimit caps the test output kept in errors.
+const outputTailLimit = 2000
+
+var modulePathPattern = regexp.MustCompile(`(?m)^module\s+(\S+)`)
+
+// Block is one block of a Go cover profile.
+type Block struct {
+	File       string
+	StartLine  int
+	StartCol   int
+	EndLine    int
+	EndCol     int
+	Statements int
+	Count      int
+}
+
+// ParseProfile reads a Go cover profile, whose lines look like
+// "example.com/m/pkg/file.go:12.34,15.2 3 1". A block reported by several
+// test binaries is kept once, with its highest count.
+func ParseProfile(data []byte) []Block {
+	type key struct {
+		file                                 string
+		startLine, startCol, endLine, endCol int
+	}
+	index := make(map[key]int)
+	var blocks []Block
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		colon := strings.LastIndex(line, ":")
+		if colon < 0 || strings.HasPrefix(line, "mode:") {
+			continue
+		}
+		var b Block
+		b.File = line[:colon]
+		if _, err := fmt.Sscanf(line[colon+1:], "%d.%d,%d.%d %d %d",
+			&b.StartLine, &b.StartCol, &b.EndLine, &b.EndCol, &b.Statements, &b.Count); err != nil {
+			continue
+		}
+		k := key{b.File, b.StartLine, b.StartCol, b.EndLine, b.EndCol}
+		if i, ok := index[k]; ok {
+			blocks[i].Count = max(blocks[i].Count, b.Count)
+			continue
+		}
+		index[k] = len(blocks)
+		blocks = append(blocks, b)
+	}
+	return blocks
+}
+
+// Profile runs `go test -coverprofile` for packages in dir and returns the
+// profile's blocks and the test output. Failing tests still produce a
+// profile for the tests that ran; only a missing profile is an error.
+func Profile(ctx context.Context, dir string, packages []string) ([]Block, string, error) {
+	tmp, err := os.CreateTemp("", "ledit-coverage-*.out")
+	if err != nil {
+		return nil, "", err
+	}
+	profilePath := tmp.Name()
+	tmp.Close()
+	defer os.Remove(profilePath)
+
+	args := append([]string{"test", "-count=1", "-coverprofile=" + profilePath}, packages...)
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Dir = dir
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	runErr := cmd.Run()
+	if ctx.Err() != nil {
+		return nil, output.String(), ctx.Err()
+	}
+	data, err := os.ReadFile(profilePath)
+	if err != nil || len(data) == 0 {
+		return nil, output.String(), fmt.Errorf("go test produced no coverage profile (%v): %s", runErr, tail(output.String()))
+	}
+	return ParseProfile(data), output.String(), nil
+}
+
+// Function is one function's coverage.
+type Function struct {
+	Name       string `json:"name"` // qualified with its receiver type, e.g. Store.Get
+	StartLine  int    `json:"start_line"`
+	EndLine    int    `json:"end_line"`
+	Statements int    `json:"statements"`
+	Covered    int    `json:"covered"`
+}
+
+// Percent is the share of the function's statements covered.
+func (f Function) Percent() float64 {
+	return percent(f.Covered, f.Statements)
+}
+
+// File is one source file's coverage.
+type File struct {
+	Path       string     `json:"path"` // slash-separated, relative to the workspace root
+	Statements int        `json:"statements"`
+	Covered    int        `json:"covered"`
+	Functions  []Function `json:"functions"`
+	MeasuredAt time.Time  `json:"measured_at"`
+}
+
+// Report is the workspace's stored coverage.
+type Report struct {
+	UpdatedAt time.Time `json:"updated_at"`
+	Command   string    `json:"command"` // the last measuring command
+	Files     []File    `json:"files"`
+}
+
+// Measure runs the tests of packages (default ./...) from root, which must
+// hold the go.mod, and maps the profile onto the functions of each file.
+// The test output is returned for reporting failing tests.
+func Measure(ctx context.Context, root string, packages []string) (*Report, string, error) {
+	data, err := os.ReadFile(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return nil, "", fmt.Errorf("coverage needs a go.mod at the workspace root: %w", err)
+	}
+	m := modulePathPattern.FindSubmatch(data)
+	if m == nil {
+		return nil, "", fmt.Errorf("no module path in %s", filepath.Join(root, "go.mod"))
+	}
+	module := string(m[1])
+	if len(packages) == 0 {
+		packages = []string{"./..."}
+	}
+
+	blocks, output, err := Profile(ctx, root, packages)
+	if err != nil {
+		return nil, output, err
+	}
+	byFile := make(map[string][]Block)
+	for _, b := range blocks {
+		rel, ok := strings.CutPrefix(b.File, module+"/")
+		if !ok {
+			continue
+		}
+		byFile[rel] = append(byFile[rel], b)
+	}
+
+	now := time.Now()
+	report := &Report{UpdatedAt: now, Command: "go test -cover " + strings.Join(packages, " ")}
+	for rel, fileBlocks := range byFile {
+		file := File{Path: rel, MeasuredAt: now}
+		for _, b := range fileBlocks {
+			file.Statements += b.Statements
+			if b.Count > 0 {
+				file.Covered += b.Statements
+			}
+		}
+		if src, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(rel))); err == nil {
+			file.Functions = Functions(src, fileBlocks)
+		}
+		report.Files = append(report.Files, file)
+	}
+	sort.Slice(report.Files, func(i, j int) bool { return report.Files[i].Path < report.Files[j].Path })
+	return report, output, nil
+}
+
+// Functions maps a file's profile blocks onto the functions declared in its
+// source. Function literals count towards the function containing them.
+func Functions(src []byte, blocks []Block) []Function {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil
+	}
+	var functions []Function
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+		name := fn.Name.Name
+		if recv := receiverType(fn); recv != "" {
+			name = recv + "." + name
+		}
+		f := Function{
+			Name:      name,
+			StartLine: fset.Position(fn.Pos()).Line,
+			EndLine:   fset.Position(fn.End()).Line,
+		}
+		for _, b := range blocks {
+			if b.StartLine >= f.StartLine && b.EndLine <= f.EndLine {
+				f.Statements += b.Statements
+				if b.Count > 0 {
+					f.Covered += b.Statements
+				}
+			}
+		}
+		functions = append(functions, f)
+	}
+	return functions
+}
+
+func receiverType(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return ""
+	}
+	expr := fn.Recv.List[0].Type
+	for {
+		switch t := expr.(type) {
+		case *ast.StarExpr:
+			expr = t.X
+		case *ast.IndexExpr:
+			expr = t.X
+		case *ast.IndexListExpr:
+			expr = t.X
+		case *ast.Ident:
+			return t.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// Load reads root's stored coverage. It returns nil when nothing has been
+// measured yet.
+func Load(root string) (*Report, error) {
+	path := filepath.Join(root, StoreFile)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var report Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return &report, nil
+}
+
+// Save stores the report in root's .ledit directory.
+func Save(root string, report *Report) error {
+	path := filepath.Join(root, StoreFile)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// Merge replaces the files measured again in newer and keeps the rest, so
+// measuring one package does not discard the others.
+func (r *Report) Merge(newer *Report) {
+	replaced := make(map[string]bool, len(newer.Files))
+	for _, f := range newer.Files {
+		replaced[f.Path] = true
+	}
+	files := append([]File(nil), newer.Files...)
+	for _, f := range r.Files {
+		if !replaced[f.Path] {
+			files = append(files, f)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	r.Files = files
+	r.UpdatedAt = newer.UpdatedAt
+	r.Command = newer.Command
+}
+
+// Gap is a function with uncovered statements.
+type Gap struct {
+	File     string
+	Function Function
+}
+
+// Uncovered is the number of the function's statements no test reaches.
+func (g Gap) Uncovered() int {
+	return g.Function.Statements - g.Function.Covered
+}
+
+// Scope returns the files whose path is prefix or lies under it; "" or "."
+// selects every file.
+func (r *Report) Scope(prefix string) []File {
+	prefix = strings.TrimSuffix(filepath.ToSlash(filepath.Clean(prefix)), "/")
+	var files []File
+	for _, f := range r.Files {
+		if prefix == "" || prefix == "." || f.Path == prefix || strings.HasPrefix(f.Path, prefix+"/") {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+// Gaps lists the functions in files with uncovered statements, the most
+// uncovered statements first.
+func Gaps(files []File) []Gap {
+	var gaps []Gap
+	for _, f := range files {
+		for _, fn := range f.Functions {
+			if fn.Covered < fn.Statements {
+				gaps = append(gaps, Gap{File: f.Path, Function: fn})
+			}
+		}
+	}
+	sort.SliceStable(gaps, func(i, j int) bool {
+		if gaps[i].Uncovered() != gaps[j].Uncovered() {
+			return gaps[i].Uncovered() > gaps[j].Uncovered()
+		}
+		return gaps[i].Function.Percent() < gaps[j].Function.Percent()
+	})
+	return gaps
+}
+
+// Totals sums the statements of files.
+func Totals(files []File) (statements, covered int) {
+	for _, f := range files {
+		statements += f.Statements
+		covered += f.Covered
+	}
+	return statements, covered
+}
+
+func percent(covered, statements int) float64 {
+	if statements == 0 {
+		return 100
+	}
+	return 100 * float64(covered) / float64(statements)
+}
+
+// FormatPercent formats a covered/total pair as a percentage.
+func FormatPercent(covered, statements int) string {
+	return strconv.FormatFloat(percent(covered, statements), 'f', 1, 64) + "%"
+}
+
+func tail(output string) string {
+	output = strings.TrimSpace(output)
+	if len(output) > outputTailLimit {
+		return "..." + output[len(output)-outputTailLimit:]
+	}
+	return output
+}
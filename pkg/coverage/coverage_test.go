@@ -0,0 +1,156 @@
+package coverage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const storeSource = `package store
+
+type Store struct{ items map[string]string }
+
+func (s *Store) Get(key string) (string, bool) {
+	v, ok := s.items[key]
+	return v, ok
+}
+
+func Parse(input string) []string {
+	if input == "" {
+		return nil
+	}
+	return []string{input}
+}
+`
+
+func TestParseProfileKeepsHighestCount(t *testing.T) {
+	profile := "mode: set\n" +
+		"example.com/m/store/store.go:5.47,8.2 2 0\n" +
+		"example.com/m/store/store.go:10.34,11.17 1 1\n" +
+		"example.com/m/store/store.go:5.47,8.2 2 1\n" +
+		"not a block\n"
+	blocks := ParseProfile([]byte(profile))
+	if len(blocks) != 2 {
+		t.Fatalf("expected 2 blocks, got %+v", blocks)
+	}
+	want := Block{File: "example.com/m/store/store.go", StartLine: 5, StartCol: 47, EndLine: 8, EndCol: 2, Statements: 2, Count: 1}
+	if blocks[0] != want {
+		t.Errorf("block = %+v, want %+v", blocks[0], want)
+	}
+}
+
+func TestFunctionsMapsBlocks(t *testing.T) {
+	blocks := []Block{
+		{StartLine: 5, EndLine: 8, Statements: 2, Count: 1},
+		{StartLine: 10, EndLine: 11, Statements: 1, Count: 1},
+		{StartLine: 11, EndLine: 13, Statements: 1, Count: 0},
+		{StartLine: 14, EndLine: 14, Statements: 1, Count: 0},
+	}
+	functions := Functions([]byte(storeSource), blocks)
+	if len(functions) != 2 {
+		t.Fatalf("expected 2 functions, got %+v", functions)
+	}
+	if get := functions[0]; get.Name != "Store.Get" || get.StartLine != 5 || get.Covered != 2 || get.Percent() != 100 {
+		t.Errorf("unexpected Store.Get coverage: %+v", get)
+	}
+	if parse := functions[1]; parse.Name != "Parse" || parse.Statements != 3 || parse.Covered != 1 {
+		t.Errorf("unexpected Parse coverage: %+v", parse)
+	}
+}
+
+func TestReportMergeScopeAndGaps(t *testing.T) {
+	report := &Report{Files: []File{
+		{Path: "a/a.go", Statements: 4, Covered: 4, Functions: []Function{{Name: "A", Statements: 4, Covered: 4}}},
+		{Path: "b/b.go", Statements: 4, Covered: 0, Functions: []Function{{Name: "B", Statements: 4}}},
+	}}
+	report.Merge(&Report{Command: "go test -cover ./b/...", Files: []File{
+		{Path: "b/b.go", Statements: 10, Covered: 5, Functions: []Function{
+			{Name: "Small", Statements: 2, Covered: 1},
+			{Name: "Big", Statements: 8, Covered: 4},
+		}},
+	}})
+	if len(report.Files) != 2 || report.Command != "go test -cover ./b/..." {
+		t.Fatalf("unexpected merged report: %+v", report)
+	}
+
+	if files := report.Scope("b"); len(files) != 1 || files[0].Path != "b/b.go" {
+		t.Errorf("Scope(b) = %+v", files)
+	}
+	if files := report.Scope("a/a.go"); len(files) != 1 {
+		t.Errorf("Scope(a/a.go) = %+v", files)
+	}
+	all := report.Scope(".")
+	if statements, covered := Totals(all); statements != 14 || covered != 9 {
+		t.Errorf("totals = %d/%d, want 9/14", covered, statements)
+	}
+	if got := FormatPercent(9, 14); got != "64.3%" {
+		t.Errorf("FormatPercent = %s", got)
+	}
+
+	var names []string
+	for _, gap := range Gaps(all) {
+		names = append(names, gap.Function.Name)
+	}
+	if got := strings.Join(names, ","); got != "Big,Small" {
+		t.Errorf("gaps = %s, want Big,Small", got)
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	root := t.TempDir()
+	report, err := Load(root)
+	if err != nil || report != nil {
+		t.Fatalf("expected no stored report, got %+v, %v", report, err)
+	}
+
+	measured := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	want := &Report{UpdatedAt: measured, Command: "go test -cover ./...", Files: []File{{Path: "a.go", Statements: 1, MeasuredAt: measured}}}
+	if err := Save(root, want); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(root, StoreFile)); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Load(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Command != want.Command || len(got.Files) != 1 || !got.Files[0].MeasuredAt.Equal(measured) {
+		t.Errorf("loaded %+v", got)
+	}
+}
+
+func TestMeasure(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs go test")
+	}
+	root := t.TempDir()
+	files := map[string]string{
+		"go.mod":              "module example.com/m\n\ngo 1.21\n",
+		"store/store.go":      storeSource,
+		"store/store_test.go": "package store\n\nimport \"testing\"\n\nfunc TestParse(t *testing.T) { Parse(\"x\") }\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report, _, err := Measure(t.Context(), root, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Files) != 1 || report.Files[0].Path != "store/store.go" {
+		t.Fatalf("unexpected files: %+v", report.Files)
+	}
+	gaps := Gaps(report.Files)
+	if len(gaps) != 2 || gaps[0].Function.Name != "Store.Get" || gaps[0].Function.Covered != 0 {
+		t.Errorf("unexpected gaps: %+v", gaps)
+	}
+}
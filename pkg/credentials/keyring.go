@@ -3,6 +3,7 @@ package credentials
 import (
 	"fmt"
 	"log"
+	"runtime"
 	"strings"
 
 	"github.com/zalando/go-keyring"
@@ -205,3 +206,23 @@ func IsKeyringAvailable() bool {
 	// Probe key exists - keyring is available
 	return true
 }
+
+// KeyringName returns the name of the OS keyring on this platform.
+func KeyringName() string {
+	switch runtime.GOOS {
+	case "darwin":
+		return "macOS Keychain"
+	case "windows":
+		return "Windows Credential Manager"
+	default:
+		return "Secret Service keyring (libsecret)"
+	}
+}
+
+// DescribeBackend returns where backend stores credentials, for messages.
+func DescribeBackend(backend Backend) string {
+	if _, ok := backend.(*OSKeyringBackend); ok {
+		return KeyringName()
+	}
+	return "the encrypted key file"
+}
@@ -163,6 +163,12 @@ func ProviderEnvVar(provider string) string {
 		return "CHUTES_API_KEY"
 	case "mistral":
 		return "MISTRAL_API_KEY"
+	case "github":
+		return "GITHUB_TOKEN"
+	case "gitlab":
+		return "GITLAB_TOKEN"
+	case "jira":
+		return "JIRA_API_TOKEN"
 	case "lmstudio", "test":
 		// Local providers don't require API keys
 		return ""
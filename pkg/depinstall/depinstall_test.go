@@ -0,0 +1,212 @@
+package depinstall
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+}
+
+const sampleGoMod = `module example.com/app
+
+go 1.22
+
+require github.com/stretchr/testify v1.9.0
+
+require (
+	golang.org/x/sync v0.7.0 // indirect
+)
+
+replace example.com/forked => ../forked
+`
+
+func TestFindMissing_Go(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"go.mod": sampleGoMod})
+
+	content := `package app
+
+import (
+	"fmt"
+	"net/http"
+
+	"example.com/app/internal/store"
+	"example.com/forked/lib"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/sync/errgroup"
+	yaml "gopkg.in/yaml.v3"
+)
+`
+	missing := FindMissing(dir, filepath.Join(dir, "pkg", "app.go"), content)
+	require.NotNil(t, missing)
+	assert.Equal(t, EcosystemGo, missing.Ecosystem)
+	assert.Equal(t, dir, missing.ProjectDir)
+	assert.Equal(t, []string{"github.com/google/uuid", "gopkg.in/yaml.v3"}, missing.Packages)
+	assert.False(t, missing.Dev)
+
+	proposal := Propose(dir, missing)
+	require.NotNil(t, proposal)
+	assert.Equal(t, "go get github.com/google/uuid gopkg.in/yaml.v3", proposal.String())
+}
+
+func TestFindMissing_GoAllDeclared(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"go.mod": sampleGoMod})
+
+	content := "package app\n\nimport \"github.com/stretchr/testify/require\"\n"
+	assert.Nil(t, FindMissing(dir, filepath.Join(dir, "app_test.go"), content))
+}
+
+func TestPropose_GoVendored(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod":             sampleGoMod,
+		"vendor/modules.txt": "",
+	})
+
+	missing := FindMissing(dir, filepath.Join(dir, "app_test.go"), "package app\n\nimport \"github.com/google/uuid\"\n")
+	require.NotNil(t, missing)
+	assert.True(t, missing.Dev)
+	assert.Equal(t, "go get github.com/google/uuid && go mod vendor", Propose(dir, missing).String())
+}
+
+func TestFindMissing_Node(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"package.json": `{"name": "web", "dependencies": {"react": "^18.2.0"}, "devDependencies": {"@types/node": "^20.0.0"}}`,
+	})
+
+	content := `import React from 'react';
+import { z } from "zod";
+import type { Node } from '@types/node';
+import fs from 'fs';
+import path from 'node:path';
+import Button from './Button';
+import utils from '@/lib/utils';
+export { format } from 'date-fns/format';
+const lodash = require('lodash');
+const chart = await import('@nivo/bar');
+import 'normalize.css';
+`
+	missing := FindMissing(dir, filepath.Join(dir, "src", "App.tsx"), content)
+	require.NotNil(t, missing)
+	assert.Equal(t, EcosystemNode, missing.Ecosystem)
+	assert.Equal(t, []string{"@nivo/bar", "date-fns", "lodash", "normalize.css", "zod"}, missing.Packages)
+	assert.False(t, missing.Dev)
+}
+
+func TestFindMissing_UnsupportedOrNoManifest(t *testing.T) {
+	dir := t.TempDir()
+	assert.Nil(t, FindMissing(dir, filepath.Join(dir, "main.go"), "package main\n\nimport \"github.com/google/uuid\"\n"))
+	assert.Nil(t, FindMissing(dir, filepath.Join(dir, "README.md"), "import x from 'y'"))
+}
+
+func TestPropose_NodePackageManagers(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		file  string
+		want  string
+	}{
+		{
+			name:  "npm default",
+			files: map[string]string{"package.json": `{"dependencies": {"react": "^18.2.0"}}`},
+			file:  "src/app.js",
+			want:  "npm install zod",
+		},
+		{
+			name: "pnpm lockfile",
+			files: map[string]string{
+				"package.json":   `{"dependencies": {"react": "^18.2.0"}}`,
+				"pnpm-lock.yaml": "",
+			},
+			file: "src/app.js",
+			want: "pnpm add zod",
+		},
+		{
+			name: "yarn lockfile with dev dependency",
+			files: map[string]string{
+				"package.json": `{"dependencies": {"react": "^18.2.0"}}`,
+				"yarn.lock":    "",
+			},
+			file: "src/app.test.js",
+			want: "yarn add --dev zod",
+		},
+		{
+			name:  "packageManager field wins over lockfile",
+			files: map[string]string{"package.json": `{"packageManager": "bun@1.1.0"}`, "package-lock.json": "{}"},
+			file:  "src/app.js",
+			want:  "bun add zod",
+		},
+		{
+			name: "lockfile at monorepo root",
+			files: map[string]string{
+				"pnpm-lock.yaml":           "",
+				"packages/ui/package.json": `{"name": "ui"}`,
+			},
+			file: "packages/ui/src/index.ts",
+			want: "pnpm add zod",
+		},
+		{
+			name:  "exact versions already used",
+			files: map[string]string{"package.json": `{"dependencies": {"react": "18.2.0"}, "devDependencies": {"jest": "29.7.0"}}`},
+			file:  "src/app.js",
+			want:  "npm install --save-exact zod",
+		},
+		{
+			name: "npmrc save-exact",
+			files: map[string]string{
+				"package.json":   `{"dependencies": {"react": "^18.2.0"}}`,
+				".npmrc":         "save-exact=true\n",
+				"pnpm-lock.yaml": "",
+			},
+			file: "src/app.spec.ts",
+			want: "pnpm add --save-dev --save-exact zod",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, tt.files)
+
+			missing := FindMissing(dir, filepath.Join(dir, tt.file), `import { z } from "zod"`)
+			require.NotNil(t, missing)
+			assert.Equal(t, tt.want, Propose(dir, missing).String())
+		})
+	}
+}
+
+func TestNodePackageName(t *testing.T) {
+	tests := map[string]string{
+		"react":                "react",
+		"react-dom/client":     "react-dom",
+		"@scope/pkg":           "@scope/pkg",
+		"@scope/pkg/sub":       "@scope/pkg",
+		"./local":              "",
+		"../up":                "",
+		"/abs":                 "",
+		"node:fs":              "",
+		"fs/promises":          "",
+		"#internal":            "",
+		"~/components":         "",
+		"@/lib":                "",
+		"https://cdn.x/y":      "",
+		"virtual:pwa-register": "",
+	}
+	for specifier, want := range tests {
+		assert.Equal(t, want, nodePackageName(specifier), specifier)
+	}
+}
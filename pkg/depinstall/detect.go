@@ -0,0 +1,325 @@
+// Package depinstall detects imports that are not declared in the project's
+// manifest (go.mod or package.json) and proposes the install command for the
+// project's package manager.
+package depinstall
+
+import (
+	"bufio"
+	"encoding/json"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Ecosystem identifies the manifest a dependency belongs to.
+type Ecosystem string
+
+const (
+	EcosystemGo   Ecosystem = "go"
+	EcosystemNode Ecosystem = "node"
+)
+
+// MissingDependencies are the undeclared imports of one file, grouped by the
+// manifest that should declare them.
+type MissingDependencies struct {
+	Ecosystem  Ecosystem
+	ProjectDir string   // directory holding go.mod or package.json
+	Packages   []string // Go import paths or npm package names, sorted
+	Dev        bool     // imported only from test code
+}
+
+// FindMissing parses the imports of a file written at path and reports the
+// ones its nearest manifest does not declare. The manifest search stops at
+// root. It returns nil when the file type is unsupported, no manifest is
+// found or every import is declared.
+func FindMissing(root, path, content string) *MissingDependencies {
+	switch {
+	case strings.HasSuffix(path, ".go"):
+		return findMissingGo(root, path, content)
+	case isNodeSource(path):
+		return findMissingNode(root, path, content)
+	}
+	return nil
+}
+
+func findMissingGo(root, path, content string) *MissingDependencies {
+	dir := findManifestDir(root, filepath.Dir(path), "go.mod")
+	if dir == "" {
+		return nil
+	}
+	mod, err := readGoMod(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return nil
+	}
+
+	file, err := parser.ParseFile(token.NewFileSet(), path, content, parser.ImportsOnly)
+	if err != nil {
+		return nil
+	}
+
+	var missing []string
+	seen := make(map[string]bool)
+	for _, spec := range file.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil || seen[importPath] {
+			continue
+		}
+		seen[importPath] = true
+		if isGoStdlib(importPath) || mod.provides(importPath) {
+			continue
+		}
+		missing = append(missing, importPath)
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	sort.Strings(missing)
+	return &MissingDependencies{
+		Ecosystem:  EcosystemGo,
+		ProjectDir: dir,
+		Packages:   missing,
+		Dev:        strings.HasSuffix(path, "_test.go"),
+	}
+}
+
+// isGoStdlib uses the goimports heuristic: standard library import paths have
+// no dot in their first element.
+func isGoStdlib(importPath string) bool {
+	first := importPath
+	if i := strings.Index(importPath, "/"); i >= 0 {
+		first = importPath[:i]
+	}
+	return !strings.Contains(first, ".")
+}
+
+// goModule is the subset of go.mod needed to decide whether an import is
+// already satisfied.
+type goModule struct {
+	path     string
+	requires []string
+	replaces []string
+}
+
+func (m *goModule) provides(importPath string) bool {
+	if hasPathPrefix(importPath, m.path) {
+		return true
+	}
+	for _, req := range m.requires {
+		if hasPathPrefix(importPath, req) {
+			return true
+		}
+	}
+	for _, rep := range m.replaces {
+		if hasPathPrefix(importPath, rep) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasPathPrefix(importPath, prefix string) bool {
+	return prefix != "" && (importPath == prefix || strings.HasPrefix(importPath, prefix+"/"))
+}
+
+func readGoMod(path string) (*goModule, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	mod := &goModule{}
+	block := ""
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		if line == "" {
+			continue
+		}
+		if block != "" {
+			if line == ")" {
+				block = ""
+				continue
+			}
+			mod.addDirective(block, strings.Fields(line))
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[1] == "(" {
+			block = fields[0]
+			continue
+		}
+		mod.addDirective(fields[0], fields[1:])
+	}
+	return mod, scanner.Err()
+}
+
+func (m *goModule) addDirective(verb string, args []string) {
+	if len(args) == 0 {
+		return
+	}
+	name := strings.Trim(args[0], `"`)
+	switch verb {
+	case "module":
+		m.path = name
+	case "require":
+		m.requires = append(m.requires, name)
+	case "replace":
+		m.replaces = append(m.replaces, name)
+	}
+}
+
+var nodeSourceExts = map[string]bool{
+	".js": true, ".jsx": true, ".mjs": true, ".cjs": true,
+	".ts": true, ".tsx": true, ".mts": true, ".cts": true,
+}
+
+func isNodeSource(path string) bool {
+	return nodeSourceExts[strings.ToLower(filepath.Ext(path))]
+}
+
+var nodeImportRegexes = []*regexp.Regexp{
+	regexp.MustCompile(`(?m)^\s*(?:import|export)\s[^'";]*?\sfrom\s*['"]([^'"]+)['"]`),
+	regexp.MustCompile(`(?m)^\s*import\s*['"]([^'"]+)['"]`),
+	regexp.MustCompile(`\brequire\(\s*['"]([^'"]+)['"]\s*\)`),
+	regexp.MustCompile(`\bimport\(\s*['"]([^'"]+)['"]\s*\)`),
+}
+
+// nodeBuiltins are core modules importable without the node: prefix.
+var nodeBuiltins = map[string]bool{
+	"assert": true, "async_hooks": true, "buffer": true, "child_process": true,
+	"cluster": true, "console": true, "constants": true, "crypto": true,
+	"dgram": true, "diagnostics_channel": true, "dns": true, "domain": true,
+	"events": true, "fs": true, "http": true, "http2": true, "https": true,
+	"inspector": true, "module": true, "net": true, "os": true, "path": true,
+	"perf_hooks": true, "process": true, "punycode": true, "querystring": true,
+	"readline": true, "repl": true, "stream": true, "string_decoder": true,
+	"sys": true, "timers": true, "tls": true, "trace_events": true, "tty": true,
+	"url": true, "util": true, "v8": true, "vm": true, "wasi": true,
+	"worker_threads": true, "zlib": true,
+}
+
+type packageJSON struct {
+	Name                 string            `json:"name"`
+	PackageManager       string            `json:"packageManager"`
+	Dependencies         map[string]string `json:"dependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+	PeerDependencies     map[string]string `json:"peerDependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+}
+
+func (p *packageJSON) declares(name string) bool {
+	if name == p.Name {
+		return true
+	}
+	for _, deps := range []map[string]string{p.Dependencies, p.DevDependencies, p.PeerDependencies, p.OptionalDependencies} {
+		if _, ok := deps[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func readPackageJSON(dir string) (*packageJSON, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return nil, err
+	}
+	var pkg packageJSON
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, err
+	}
+	return &pkg, nil
+}
+
+func findMissingNode(root, path, content string) *MissingDependencies {
+	dir := findManifestDir(root, filepath.Dir(path), "package.json")
+	if dir == "" {
+		return nil
+	}
+	pkg, err := readPackageJSON(dir)
+	if err != nil {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var missing []string
+	for _, re := range nodeImportRegexes {
+		for _, m := range re.FindAllStringSubmatch(content, -1) {
+			name := nodePackageName(m[1])
+			if name == "" || seen[name] {
+				continue
+			}
+			seen[name] = true
+			if !pkg.declares(name) {
+				missing = append(missing, name)
+			}
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	sort.Strings(missing)
+	return &MissingDependencies{
+		Ecosystem:  EcosystemNode,
+		ProjectDir: dir,
+		Packages:   missing,
+		Dev:        isNodeTestFile(path),
+	}
+}
+
+// nodePackageName returns the npm package an import specifier resolves to,
+// or "" for relative paths, builtins and path aliases.
+func nodePackageName(specifier string) string {
+	if specifier == "" || strings.HasPrefix(specifier, ".") || strings.HasPrefix(specifier, "/") ||
+		strings.HasPrefix(specifier, "node:") || strings.HasPrefix(specifier, "#") ||
+		strings.HasPrefix(specifier, "~") || strings.HasPrefix(specifier, "@/") ||
+		strings.Contains(specifier, ":") {
+		return ""
+	}
+	parts := strings.Split(specifier, "/")
+	if strings.HasPrefix(specifier, "@") {
+		if len(parts) < 2 || parts[1] == "" {
+			return ""
+		}
+		return parts[0] + "/" + parts[1]
+	}
+	if nodeBuiltins[parts[0]] {
+		return ""
+	}
+	return parts[0]
+}
+
+func isNodeTestFile(path string) bool {
+	base := filepath.Base(path)
+	return strings.Contains(base, ".test.") || strings.Contains(base, ".spec.") ||
+		strings.Contains(filepath.ToSlash(path), "/__tests__/")
+}
+
+// findManifestDir walks up from dir until it finds name, without leaving root.
+func findManifestDir(root, dir, name string) string {
+	root, _ = filepath.Abs(root)
+	dir, _ = filepath.Abs(dir)
+	for {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return dir
+		}
+		if dir == root {
+			return ""
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir || !strings.HasPrefix(parent, root) {
+			return ""
+		}
+		dir = parent
+	}
+}
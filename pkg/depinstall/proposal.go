@@ -0,0 +1,197 @@
+package depinstall
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultInstallTimeout bounds how long an approved install may run.
+const DefaultInstallTimeout = 5 * time.Minute
+
+// Package managers the proposal can target.
+const (
+	ManagerGo   = "go"
+	ManagerNPM  = "npm"
+	ManagerPNPM = "pnpm"
+	ManagerYarn = "yarn"
+	ManagerBun  = "bun"
+)
+
+// Proposal is the install command for a set of missing dependencies.
+type Proposal struct {
+	Missing  *MissingDependencies
+	Manager  string
+	Exact    bool       // versions are pinned exactly, per the project's policy
+	Commands [][]string // run in order from Missing.ProjectDir
+}
+
+// String renders the proposal as a shell command line.
+func (p *Proposal) String() string {
+	parts := make([]string, 0, len(p.Commands))
+	for _, argv := range p.Commands {
+		parts = append(parts, strings.Join(argv, " "))
+	}
+	return strings.Join(parts, " && ")
+}
+
+// Propose builds the install command for missing, honouring the project's
+// package manager (from packageManager or the lockfile present) and its
+// version pinning policy.
+func Propose(root string, missing *MissingDependencies) *Proposal {
+	if missing == nil || len(missing.Packages) == 0 {
+		return nil
+	}
+	switch missing.Ecosystem {
+	case EcosystemGo:
+		return proposeGo(missing)
+	case EcosystemNode:
+		return proposeNode(root, missing)
+	}
+	return nil
+}
+
+func proposeGo(missing *MissingDependencies) *Proposal {
+	// go get records the resolved version in go.mod and go.sum, so the
+	// module is always pinned.
+	p := &Proposal{Missing: missing, Manager: ManagerGo, Exact: true}
+	p.Commands = append(p.Commands, append([]string{"go", "get"}, missing.Packages...))
+	// Vendored modules must be refreshed or the build keeps failing.
+	if _, err := os.Stat(filepath.Join(missing.ProjectDir, "vendor", "modules.txt")); err == nil {
+		p.Commands = append(p.Commands, []string{"go", "mod", "vendor"})
+	}
+	return p
+}
+
+func proposeNode(root string, missing *MissingDependencies) *Proposal {
+	pkg, _ := readPackageJSON(missing.ProjectDir)
+	manager := detectNodeManager(root, missing.ProjectDir, pkg)
+	exact := prefersExactVersions(missing.ProjectDir, pkg)
+
+	var argv []string
+	switch manager {
+	case ManagerNPM:
+		argv = []string{"npm", "install"}
+		if missing.Dev {
+			argv = append(argv, "--save-dev")
+		}
+		if exact {
+			argv = append(argv, "--save-exact")
+		}
+	case ManagerPNPM:
+		argv = []string{"pnpm", "add"}
+		if missing.Dev {
+			argv = append(argv, "--save-dev")
+		}
+		if exact {
+			argv = append(argv, "--save-exact")
+		}
+	default: // yarn and bun share flag names
+		argv = []string{manager, "add"}
+		if missing.Dev {
+			argv = append(argv, "--dev")
+		}
+		if exact {
+			argv = append(argv, "--exact")
+		}
+	}
+	argv = append(argv, missing.Packages...)
+	return &Proposal{Missing: missing, Manager: manager, Exact: exact, Commands: [][]string{argv}}
+}
+
+// nodeLockfiles maps lockfiles to the manager that owns them, in precedence order.
+var nodeLockfiles = []struct {
+	file    string
+	manager string
+}{
+	{"pnpm-lock.yaml", ManagerPNPM},
+	{"yarn.lock", ManagerYarn},
+	{"bun.lockb", ManagerBun},
+	{"bun.lock", ManagerBun},
+	{"package-lock.json", ManagerNPM},
+	{"npm-shrinkwrap.json", ManagerNPM},
+}
+
+// detectNodeManager prefers the packageManager field, then the nearest
+// lockfile between projectDir and root (monorepos keep it at the top), and
+// falls back to npm.
+func detectNodeManager(root, projectDir string, pkg *packageJSON) string {
+	if pkg != nil && pkg.PackageManager != "" {
+		name := strings.SplitN(pkg.PackageManager, "@", 2)[0]
+		switch name {
+		case ManagerNPM, ManagerPNPM, ManagerYarn, ManagerBun:
+			return name
+		}
+	}
+
+	root, _ = filepath.Abs(root)
+	dir := projectDir
+	for {
+		for _, lf := range nodeLockfiles {
+			if _, err := os.Stat(filepath.Join(dir, lf.file)); err == nil {
+				return lf.manager
+			}
+		}
+		parent := filepath.Dir(dir)
+		if dir == root || parent == dir || !strings.HasPrefix(parent, root) {
+			break
+		}
+		dir = parent
+	}
+	return ManagerNPM
+}
+
+// prefersExactVersions reports whether new dependencies should be pinned to
+// an exact version: either .npmrc sets save-exact, or every declared
+// dependency already uses an exact version.
+func prefersExactVersions(projectDir string, pkg *packageJSON) bool {
+	if data, err := os.ReadFile(filepath.Join(projectDir, ".npmrc")); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+			if ok && strings.TrimSpace(key) == "save-exact" {
+				return strings.TrimSpace(value) == "true"
+			}
+		}
+	}
+	if pkg == nil {
+		return false
+	}
+
+	total := 0
+	for _, deps := range []map[string]string{pkg.Dependencies, pkg.DevDependencies} {
+		for _, version := range deps {
+			total++
+			if !isExactVersion(version) {
+				return false
+			}
+		}
+	}
+	return total > 0
+}
+
+func isExactVersion(version string) bool {
+	version = strings.TrimSpace(version)
+	return version != "" && version[0] >= '0' && version[0] <= '9' &&
+		!strings.ContainsAny(version, "^~<>=*x| ")
+}
+
+// Run executes the proposal's commands in order from the project directory
+// and returns their combined output.
+func (p *Proposal) Run(ctx context.Context) (string, error) {
+	var out bytes.Buffer
+	for _, argv := range p.Commands {
+		cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+		cmd.Dir = p.Missing.ProjectDir
+		cmd.Stdout = &out
+		cmd.Stderr = &out
+		if err := cmd.Run(); err != nil {
+			return out.String(), fmt.Errorf("%s failed: %w", strings.Join(argv, " "), err)
+		}
+	}
+	return out.String(), nil
+}
@@ -0,0 +1,307 @@
+// Package devcontainer runs the agent's shell commands, builds and tests
+// inside a project's development container, described by
+// .devcontainer/devcontainer.json, so they see the toolchain the team uses.
+// The workspace is bind-mounted into the container, so files need no
+// syncing. Containers are found by the labels the devcontainer CLI and
+// editors put on theirs, so one they started is reused.
+package devcontainer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ConfigPaths are where a workspace's devcontainer.json is looked for, in
+// order, relative to the workspace root.
+var ConfigPaths = []string{
+	filepath.Join(".devcontainer", "devcontainer.json"),
+	".devcontainer.json",
+}
+
+// ErrNotFound is returned for a workspace without a devcontainer.json.
+var ErrNotFound = errors.New("no devcontainer.json in the workspace")
+
+// Config is the part of devcontainer.json needed to create a container and
+// run commands in it, with variables such as ${localWorkspaceFolder}
+// substituted.
+type Config struct {
+	Name              string            `json:"name"`
+	Image             string            `json:"image"`
+	Build             *Build            `json:"build"`
+	DockerFile        string            `json:"dockerFile"` // older spelling of build.dockerfile
+	Context           string            `json:"context"`    // older spelling of build.context
+	DockerComposeFile json.RawMessage   `json:"dockerComposeFile"`
+	WorkspaceFolder   string            `json:"workspaceFolder"`
+	WorkspaceMount    string            `json:"workspaceMount"`
+	Mounts            []Mount           `json:"mounts"`
+	RunArgs           []string          `json:"runArgs"`
+	ContainerEnv      map[string]string `json:"containerEnv"`
+	RemoteEnv         map[string]string `json:"remoteEnv"`
+	ContainerUser     string            `json:"containerUser"`
+	RemoteUser        string            `json:"remoteUser"`
+	OverrideCommand   *bool             `json:"overrideCommand"`
+
+	OnCreateCommand      Command `json:"onCreateCommand"`
+	UpdateContentCommand Command `json:"updateContentCommand"`
+	PostCreateCommand    Command `json:"postCreateCommand"`
+
+	// File is the devcontainer.json the config was read from.
+	File string `json:"-"`
+	// Source is the file's content, which identifies the container built
+	// from it.
+	Source []byte `json:"-"`
+}
+
+// Build describes an image built from a Dockerfile. Paths are resolved
+// against the directory of devcontainer.json.
+type Build struct {
+	Dockerfile string            `json:"dockerfile"`
+	Context    string            `json:"context"`
+	Args       map[string]string `json:"args"`
+	Target     string            `json:"target"`
+}
+
+// Mount is a --mount argument. devcontainer.json gives it as a string or as
+// an object with type, source and target.
+type Mount string
+
+func (m *Mount) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*m = Mount(s)
+		return nil
+	}
+	var obj struct {
+		Type   string `json:"type"`
+		Source string `json:"source"`
+		Target string `json:"target"`
+	}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return fmt.Errorf("mount must be a string or an object: %w", err)
+	}
+	if obj.Type == "" {
+		obj.Type = "bind"
+	}
+	*m = Mount(fmt.Sprintf("type=%s,source=%s,target=%s", obj.Type, obj.Source, obj.Target))
+	return nil
+}
+
+// Command is a lifecycle command as shell command lines. devcontainer.json
+// gives it as a string, an argument array, or an object of named commands.
+type Command []string
+
+func (c *Command) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		if strings.TrimSpace(s) != "" {
+			*c = Command{s}
+		}
+		return nil
+	}
+	var argv []string
+	if err := json.Unmarshal(data, &argv); err == nil {
+		if len(argv) > 0 {
+			*c = Command{shellJoin(argv)}
+		}
+		return nil
+	}
+	var named map[string]Command
+	if err := json.Unmarshal(data, &named); err != nil {
+		return fmt.Errorf("command must be a string, an array or an object: %w", err)
+	}
+	names := make([]string, 0, len(named))
+	for name := range named {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		*c = append(*c, named[name]...)
+	}
+	return nil
+}
+
+// Find returns the path of the workspace's devcontainer.json.
+func Find(root string) (string, error) {
+	for _, rel := range ConfigPaths {
+		file := filepath.Join(root, rel)
+		if info, err := os.Stat(file); err == nil && !info.IsDir() {
+			return file, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrNotFound, root)
+}
+
+// Load reads the devcontainer.json of the workspace at root.
+func Load(root string) (*Config, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	file, err := Find(root)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var cfg Config
+	if err := json.Unmarshal(standardize(data), &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+	}
+	cfg.File, cfg.Source = file, data
+	if err := cfg.resolve(root); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return &cfg, nil
+}
+
+// resolve fills in defaults, checks the config can be run, and substitutes
+// variables.
+func (c *Config) resolve(root string) error {
+	if len(c.DockerComposeFile) > 0 {
+		return errors.New("Docker Compose devcontainers are not supported; start the services yourself and use an image or Dockerfile config")
+	}
+	if c.DockerFile != "" {
+		if c.Build == nil {
+			c.Build = &Build{}
+		}
+		if c.Build.Dockerfile == "" {
+			c.Build.Dockerfile, c.Build.Context = c.DockerFile, c.Context
+		}
+	}
+	if c.Build != nil && c.Build.Dockerfile == "" {
+		c.Build = nil
+	}
+	if c.Image == "" && c.Build == nil {
+		return errors.New("set image or build.dockerfile")
+	}
+	if c.Name == "" {
+		c.Name = filepath.Base(root)
+	}
+
+	expand := func(s string) string { return expandVariables(s, root, c.WorkspaceFolder) }
+	if c.WorkspaceFolder = expand(c.WorkspaceFolder); c.WorkspaceFolder == "" {
+		c.WorkspaceFolder = path.Join("/workspaces", filepath.Base(root))
+	}
+	if c.WorkspaceMount = expand(c.WorkspaceMount); c.WorkspaceMount == "" {
+		c.WorkspaceMount = fmt.Sprintf("type=bind,source=%s,target=%s", root, c.WorkspaceFolder)
+	}
+	for i := range c.Mounts {
+		c.Mounts[i] = Mount(expand(string(c.Mounts[i])))
+	}
+	for i := range c.RunArgs {
+		c.RunArgs[i] = expand(c.RunArgs[i])
+	}
+	for key, value := range c.ContainerEnv {
+		c.ContainerEnv[key] = expand(value)
+	}
+	// remoteEnv may refer to ${containerEnv:NAME}, which only the shell in
+	// the container can resolve; see Container.envScript.
+	for key, value := range c.RemoteEnv {
+		c.RemoteEnv[key] = expand(value)
+	}
+	if c.Build != nil {
+		dir := filepath.Dir(c.File)
+		c.Build.Dockerfile = filepath.Join(dir, expand(c.Build.Dockerfile))
+		if c.Build.Context == "" {
+			c.Build.Context = "."
+		}
+		c.Build.Context = filepath.Join(dir, expand(c.Build.Context))
+		for key, value := range c.Build.Args {
+			c.Build.Args[key] = expand(value)
+		}
+	}
+	return nil
+}
+
+var variablePattern = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// expandVariables substitutes the local variables of devcontainer.json.
+// ${containerEnv:NAME} and unknown variables are left as they are.
+func expandVariables(s, localRoot, workspaceFolder string) string {
+	return variablePattern.ReplaceAllStringFunc(s, func(match string) string {
+		name := match[2 : len(match)-1]
+		switch {
+		case name == "localWorkspaceFolder":
+			return localRoot
+		case name == "localWorkspaceFolderBasename":
+			return filepath.Base(localRoot)
+		case name == "containerWorkspaceFolder" && workspaceFolder != "":
+			return workspaceFolder
+		case name == "containerWorkspaceFolderBasename" && workspaceFolder != "":
+			return path.Base(workspaceFolder)
+		case strings.HasPrefix(name, "localEnv:"), strings.HasPrefix(name, "env:"):
+			_, rest, _ := strings.Cut(name, ":")
+			variable, fallback, _ := strings.Cut(rest, ":")
+			if value, ok := os.LookupEnv(variable); ok {
+				return value
+			}
+			return fallback
+		}
+		return match
+	})
+}
+
+// standardize turns JSON with comments and trailing commas, as
+// devcontainer.json allows, into plain JSON.
+func standardize(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case c == '"':
+			end := i + 1
+			for end < len(data) && data[end] != '"' {
+				if data[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			end = min(end+1, len(data))
+			out = append(out, data[i:end]...)
+			i = end - 1
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i+1 < len(data) && data[i+1] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			end := bytes.Index(data[i+2:], []byte("*/"))
+			if end < 0 {
+				return out
+			}
+			i += end + 3
+			out = append(out, ' ')
+		case c == ']' || c == '}':
+			trimmed := bytes.TrimRight(out, " \t\r\n")
+			if n := len(trimmed); n > 0 && trimmed[n-1] == ',' {
+				out = append(trimmed[:n-1], out[n:]...)
+			}
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// shellJoin quotes argv as a POSIX shell command line.
+func shellJoin(argv []string) string {
+	quoted := make([]string, len(argv))
+	for i, arg := range argv {
+		quoted[i] = shellQuote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,395 @@
+package devcontainer
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Labels identifying a workspace's container. The first two are the ones
+// the devcontainer CLI and editors use, so their containers are reused.
+const (
+	labelLocalFolder = "devcontainer.local_folder"
+	labelConfigFile  = "devcontainer.config_file"
+	labelConfigHash  = "ledit.devcontainer.hash"
+)
+
+// Runtimes lists the container runtimes, in the order an empty
+// Options.Runtime tries them.
+var Runtimes = []string{"docker", "podman"}
+
+// keepAlive keeps a container running with nothing else to do, and stops it
+// promptly when asked to.
+const keepAlive = "echo Container started; trap 'exit 0' TERM; sleep infinity & wait"
+
+// lookPath is replaced in tests.
+var lookPath = exec.LookPath
+
+// Options configure how containers are run.
+type Options struct {
+	// Runtime is docker or podman. Empty picks the first installed.
+	Runtime string
+	// Progress receives status messages while a container is built and
+	// started, which can take minutes.
+	Progress func(message string)
+}
+
+// Container runs commands in a workspace's devcontainer, creating and
+// starting it on first use.
+type Container struct {
+	config    *Config
+	runtime   string
+	localRoot string
+	hash      string
+	progress  func(string)
+
+	mu sync.Mutex
+	id string // set once the container is known to be running
+}
+
+// State describes a workspace's container.
+type State struct {
+	ID      string
+	Running bool
+	// Outdated reports a container ledit created from a devcontainer.json
+	// or Dockerfile that has changed since; Up replaces it.
+	Outdated bool
+}
+
+// New returns a container for the workspace at root. It fails when the
+// workspace has no usable devcontainer.json or no container runtime is
+// installed.
+func New(root string, opts Options) (*Container, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := Load(root)
+	if err != nil {
+		return nil, err
+	}
+	candidates := Runtimes
+	if name := strings.ToLower(strings.TrimSpace(opts.Runtime)); name != "" {
+		candidates = []string{name}
+	}
+	var runtime string
+	for _, candidate := range candidates {
+		if binary, err := lookPath(candidate); err == nil {
+			runtime = binary
+			break
+		}
+	}
+	if runtime == "" {
+		return nil, fmt.Errorf("devcontainers need %s installed", strings.Join(candidates, " or "))
+	}
+	progress := opts.Progress
+	if progress == nil {
+		progress = func(string) {}
+	}
+	return &Container{
+		config:    cfg,
+		runtime:   runtime,
+		localRoot: root,
+		hash:      configHash(cfg),
+		progress:  progress,
+	}, nil
+}
+
+// configHash identifies what a container was created from.
+func configHash(cfg *Config) string {
+	sum := sha256.New()
+	sum.Write(cfg.Source)
+	if cfg.Build != nil {
+		if dockerfile, err := os.ReadFile(cfg.Build.Dockerfile); err == nil {
+			sum.Write(dockerfile)
+		}
+	}
+	return hex.EncodeToString(sum.Sum(nil))[:12]
+}
+
+// Config returns the devcontainer.json the container is created from.
+func (c *Container) Config() *Config {
+	return c.config
+}
+
+// Describe summarizes the container for status messages.
+func (c *Container) Describe() string {
+	return fmt.Sprintf("devcontainer %s", c.config.Name)
+}
+
+// ContainerPath returns the container path of local, a path in the
+// workspace.
+func (c *Container) ContainerPath(local string) (string, error) {
+	rel, err := filepath.Rel(c.localRoot, local)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside the workspace %s", local, c.localRoot)
+	}
+	return path.Join(c.config.WorkspaceFolder, filepath.ToSlash(rel)), nil
+}
+
+// MapOutput rewrites container paths in command output to local ones, so
+// compiler errors and stack traces point at files the local tools can open.
+func (c *Container) MapOutput(output string) string {
+	if c.config.WorkspaceFolder == c.localRoot {
+		return output
+	}
+	return strings.ReplaceAll(output, c.config.WorkspaceFolder, c.localRoot)
+}
+
+// Status looks up the workspace's container. A zero State means there is
+// none.
+func (c *Container) Status(ctx context.Context) (State, error) {
+	out, err := c.output(ctx, "ps", "-a", "-q",
+		"--filter", "label="+labelLocalFolder+"="+c.localRoot,
+		"--filter", "label="+labelConfigFile+"="+c.config.File)
+	if err != nil {
+		return State{}, err
+	}
+	ids := strings.Fields(out)
+	if len(ids) == 0 {
+		return State{}, nil
+	}
+	out, err = c.output(ctx, "inspect", "--format",
+		`{{.State.Running}} {{index .Config.Labels "`+labelConfigHash+`"}}`, ids[0])
+	if err != nil {
+		return State{}, err
+	}
+	running, hash, _ := strings.Cut(strings.TrimSpace(out), " ")
+	// Containers created by other tools carry no hash and are kept.
+	hash = strings.TrimSpace(strings.TrimPrefix(hash, "<no value>"))
+	return State{ID: ids[0], Running: running == "true", Outdated: hash != "" && hash != c.hash}, nil
+}
+
+// Up makes sure the workspace's container is running and returns its ID.
+// It starts a stopped container, replaces an outdated one, and otherwise
+// builds the image if needed, creates the container and runs the
+// onCreate, updateContent and postCreate commands.
+func (c *Container) Up(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.id != "" {
+		return c.id, nil
+	}
+	state, err := c.Status(ctx)
+	if err != nil {
+		return "", err
+	}
+	if state.Outdated {
+		c.progress(fmt.Sprintf("Recreating the %s: devcontainer.json or its Dockerfile changed", c.Describe()))
+		if _, err := c.output(ctx, "rm", "-f", state.ID); err != nil {
+			return "", err
+		}
+		state = State{}
+	}
+	switch {
+	case state.Running:
+	case state.ID != "":
+		c.progress(fmt.Sprintf("Starting the %s", c.Describe()))
+		if _, err := c.output(ctx, "start", state.ID); err != nil {
+			return "", err
+		}
+	default:
+		if state.ID, err = c.create(ctx); err != nil {
+			return "", err
+		}
+	}
+	c.id = state.ID
+	return c.id, nil
+}
+
+// create builds the image if needed and creates and sets up the container.
+func (c *Container) create(ctx context.Context) (string, error) {
+	image := c.config.Image
+	if build := c.config.Build; build != nil {
+		image = fmt.Sprintf("ledit-devcontainer-%s-%s", imageName(filepath.Base(c.localRoot)), c.hash)
+		c.progress(fmt.Sprintf("Building the %s image from %s", c.Describe(), build.Dockerfile))
+		args := []string{"build", "-f", build.Dockerfile, "-t", image}
+		for _, key := range sortedKeys(build.Args) {
+			args = append(args, "--build-arg", key+"="+build.Args[key])
+		}
+		if build.Target != "" {
+			args = append(args, "--target", build.Target)
+		}
+		if _, err := c.output(ctx, append(args, build.Context)...); err != nil {
+			return "", fmt.Errorf("failed to build the devcontainer image: %w", err)
+		}
+	}
+
+	c.progress(fmt.Sprintf("Creating the %s from %s", c.Describe(), image))
+	args := []string{"run", "-d",
+		"--label", labelLocalFolder + "=" + c.localRoot,
+		"--label", labelConfigFile + "=" + c.config.File,
+		"--label", labelConfigHash + "=" + c.hash,
+		"--mount", c.config.WorkspaceMount,
+		"-w", c.config.WorkspaceFolder,
+	}
+	for _, mount := range c.config.Mounts {
+		args = append(args, "--mount", string(mount))
+	}
+	for _, key := range sortedKeys(c.config.ContainerEnv) {
+		args = append(args, "-e", key+"="+c.config.ContainerEnv[key])
+	}
+	if c.config.ContainerUser != "" {
+		args = append(args, "-u", c.config.ContainerUser)
+	}
+	args = append(args, c.config.RunArgs...)
+	if c.config.OverrideCommand == nil || *c.config.OverrideCommand {
+		args = append(args, "--entrypoint", "/bin/sh", image, "-c", keepAlive)
+	} else {
+		args = append(args, image)
+	}
+	out, err := c.output(ctx, args...)
+	if err != nil {
+		return "", fmt.Errorf("failed to create the devcontainer: %w", err)
+	}
+	id := strings.TrimSpace(out)
+	if fields := strings.Fields(id); len(fields) > 0 {
+		id = fields[len(fields)-1]
+	}
+
+	for _, step := range []struct {
+		name     string
+		commands Command
+	}{
+		{"onCreateCommand", c.config.OnCreateCommand},
+		{"updateContentCommand", c.config.UpdateContentCommand},
+		{"postCreateCommand", c.config.PostCreateCommand},
+	} {
+		for _, command := range step.commands {
+			c.progress(fmt.Sprintf("Running %s: %s", step.name, command))
+			cmd := exec.CommandContext(ctx, c.runtime, c.execArgs(id, c.config.WorkspaceFolder, command)...)
+			if out, err := cmd.CombinedOutput(); err != nil {
+				// Remove the container so the next attempt sets it up again.
+				exec.Command(c.runtime, "rm", "-f", id).Run()
+				return "", fmt.Errorf("%s failed: %w\n%s", step.name, err, strings.TrimSpace(string(out)))
+			}
+		}
+	}
+	return id, nil
+}
+
+// Down stops and removes the workspace's container.
+func (c *Container) Down(ctx context.Context) (State, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.id = ""
+	state, err := c.Status(ctx)
+	if err != nil || state.ID == "" {
+		return state, err
+	}
+	_, err = c.output(ctx, "rm", "-f", state.ID)
+	return state, err
+}
+
+// Command prepares shellCommand to run in dir, a workspace directory,
+// inside the container, starting the container first if needed.
+func (c *Container) Command(ctx context.Context, dir, shellCommand string) (*exec.Cmd, func(output string) (string, error), error) {
+	containerDir, err := c.ContainerPath(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	id, err := c.Up(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	cmd := exec.CommandContext(ctx, c.runtime, c.execArgs(id, containerDir, shellCommand)...)
+	cmd.Dir = dir
+	finish := func(output string) (string, error) {
+		return c.MapOutput(output), nil
+	}
+	return cmd, finish, nil
+}
+
+// execArgs returns the runtime arguments running shellCommand in dir.
+func (c *Container) execArgs(id, dir, shellCommand string) []string {
+	args := []string{"exec", "-w", dir}
+	user := c.config.RemoteUser
+	if user == "" {
+		user = c.config.ContainerUser
+	}
+	if user != "" {
+		args = append(args, "-u", user)
+	}
+	return append(args, id, "/bin/sh", "-c", c.envScript()+shellCommand)
+}
+
+var containerEnvPattern = regexp.MustCompile(`\$\{containerEnv:([A-Za-z_][A-Za-z0-9_]*)(?::([^}]*))?\}`)
+
+// envScript exports remoteEnv in the shell that runs a command, which is
+// where ${containerEnv:NAME} references resolve.
+func (c *Container) envScript() string {
+	var script strings.Builder
+	for _, key := range sortedKeys(c.config.RemoteEnv) {
+		value := c.config.RemoteEnv[key]
+		var quoted strings.Builder
+		quoted.WriteByte('"')
+		last := 0
+		for _, match := range containerEnvPattern.FindAllStringSubmatchIndex(value, -1) {
+			quoted.WriteString(escapeDoubleQuoted(value[last:match[0]]))
+			name := value[match[2]:match[3]]
+			if match[4] >= 0 {
+				quoted.WriteString("${" + name + ":-" + escapeDoubleQuoted(value[match[4]:match[5]]) + "}")
+			} else {
+				quoted.WriteString("${" + name + "}")
+			}
+			last = match[1]
+		}
+		quoted.WriteString(escapeDoubleQuoted(value[last:]))
+		quoted.WriteByte('"')
+		fmt.Fprintf(&script, "export %s=%s; ", key, quoted.String())
+	}
+	return script.String()
+}
+
+func escapeDoubleQuoted(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`").Replace(s)
+}
+
+// output runs the container runtime and returns its stdout.
+func (c *Container) output(ctx context.Context, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, c.runtime, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			err = fmt.Errorf("%s %s: %w: %s", filepath.Base(c.runtime), args[0], err, message)
+		} else {
+			err = fmt.Errorf("%s %s: %w", filepath.Base(c.runtime), args[0], err)
+		}
+		if errors.Is(ctx.Err(), context.Canceled) {
+			err = ctx.Err()
+		}
+	}
+	return string(out), err
+}
+
+// imageName reduces name to the characters allowed in an image name.
+func imageName(name string) string {
+	name = strings.ToLower(name)
+	name = regexp.MustCompile(`[^a-z0-9]+`).ReplaceAllString(name, "-")
+	if name = strings.Trim(name, "-"); name == "" {
+		return "workspace"
+	}
+	return name
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
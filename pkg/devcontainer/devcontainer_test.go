@@ -0,0 +1,178 @@
+package devcontainer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, root, content string) {
+	t.Helper()
+	dir := filepath.Join(root, ".devcontainer")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "devcontainer.json"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadResolvesConfig(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("LEDIT_TEST_TOKEN", "secret")
+	writeConfig(t, root, `{
+	// Comments and trailing commas are allowed.
+	"name": "go-dev",
+	"dockerFile": "Dockerfile", /* the older spelling */
+	"build": {"args": {"TOKEN": "${localEnv:LEDIT_TEST_TOKEN}", "MISSING": "${localEnv:LEDIT_TEST_UNSET:none}"}},
+	"workspaceFolder": "/src/${localWorkspaceFolderBasename}",
+	"mounts": [
+		"source=cache,target=/cache,type=volume",
+		{"source": "${localWorkspaceFolder}/.cache", "target": "${containerWorkspaceFolder}/.cache"},
+	],
+	"remoteEnv": {"URL": "http://example.com/a//b"},
+	"postCreateCommand": {"deps": "go mod download", "tools": ["go", "install", "golang.org/x/tools/gopls@latest"]},
+}`)
+
+	cfg, err := Load(root)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	folder := "/src/" + filepath.Base(root)
+	if cfg.Name != "go-dev" || cfg.WorkspaceFolder != folder {
+		t.Errorf("unexpected name or folder: %q, %q", cfg.Name, cfg.WorkspaceFolder)
+	}
+	if want := "type=bind,source=" + root + ",target=" + folder; cfg.WorkspaceMount != want {
+		t.Errorf("workspace mount = %q, want %q", cfg.WorkspaceMount, want)
+	}
+	if cfg.Build == nil || cfg.Build.Dockerfile != filepath.Join(root, ".devcontainer", "Dockerfile") || cfg.Build.Context != filepath.Join(root, ".devcontainer") {
+		t.Errorf("unexpected build %+v", cfg.Build)
+	}
+	if cfg.Build.Args["TOKEN"] != "secret" || cfg.Build.Args["MISSING"] != "none" {
+		t.Errorf("unexpected build args %v", cfg.Build.Args)
+	}
+	wantMounts := []Mount{
+		"source=cache,target=/cache,type=volume",
+		Mount("type=bind,source=" + root + "/.cache,target=" + folder + "/.cache"),
+	}
+	if !reflect.DeepEqual(cfg.Mounts, wantMounts) {
+		t.Errorf("mounts = %v, want %v", cfg.Mounts, wantMounts)
+	}
+	if cfg.RemoteEnv["URL"] != "http://example.com/a//b" {
+		t.Errorf("expected // inside strings to be kept, got %q", cfg.RemoteEnv["URL"])
+	}
+	wantCommands := Command{"go mod download", "'go' 'install' 'golang.org/x/tools/gopls@latest'"}
+	if !reflect.DeepEqual(cfg.PostCreateCommand, wantCommands) {
+		t.Errorf("postCreateCommand = %q, want %q", cfg.PostCreateCommand, wantCommands)
+	}
+
+	writeConfig(t, root, `{"dockerComposeFile": "compose.yml", "service": "app"}`)
+	if _, err := Load(root); err == nil || !strings.Contains(err.Error(), "Compose") {
+		t.Errorf("expected compose configs to be rejected, got %v", err)
+	}
+	if _, err := Load(t.TempDir()); err == nil {
+		t.Error("expected a workspace without devcontainer.json to fail")
+	}
+}
+
+// fakeDocker installs a docker that records its arguments in log and runs
+// exec'd commands locally.
+func fakeDocker(t *testing.T) (log string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a POSIX shell")
+	}
+	dir := t.TempDir()
+	log = filepath.Join(dir, "log")
+	script := filepath.Join(dir, "docker")
+	err := os.WriteFile(script, []byte(`#!/bin/sh
+state="`+dir+`/container"
+echo "$*" >> "`+log+`"
+case "$1" in
+	ps) [ -f "$state" ] && echo c0ffee ;;
+	inspect) [ -f "$state" ] && cat "$state" ;;
+	run) echo "true $(echo "$*" | sed -n 's/.*ledit.devcontainer.hash=\([0-9a-f]*\).*/\1/p')" > "$state"; echo c0ffee ;;
+	rm) rm -f "$state" ;;
+	exec) while [ "$1" != "-c" ]; do shift; done; exec sh -c "$2" ;;
+esac
+exit 0
+`), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := lookPath
+	t.Cleanup(func() { lookPath = previous })
+	lookPath = func(name string) (string, error) {
+		if name == "docker" {
+			return script, nil
+		}
+		return "", os.ErrNotExist
+	}
+	return log
+}
+
+func TestCommandCreatesAndReusesContainer(t *testing.T) {
+	log := fakeDocker(t)
+	root := t.TempDir()
+	writeConfig(t, root, `{
+	"image": "golang:1.25",
+	"remoteEnv": {"GREETING": "hello ${containerEnv:LEDIT_TEST_NAME:world}", "PRICE": "$5"},
+	"postCreateCommand": "echo created > /dev/null"
+}`)
+	var progress []string
+	c, err := New(root, Options{Progress: func(message string) { progress = append(progress, message) }})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	cmd, finish, err := c.Command(context.Background(), filepath.Join(root), `echo "$GREETING $PRICE"; echo /workspaces/`+filepath.Base(root)+`/main.go`)
+	if err != nil {
+		t.Fatalf("Command: %v", err)
+	}
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("run: %v\n%s", err, out)
+	}
+	output, _ := finish(string(out))
+	if want := "hello world $5\n" + filepath.Join(root, "main.go") + "\n"; output != want {
+		t.Errorf("output = %q, want %q", output, want)
+	}
+	if len(progress) != 2 || !strings.Contains(progress[1], "postCreateCommand") {
+		t.Errorf("unexpected progress %q", progress)
+	}
+
+	// A second instance finds the running container instead of creating one.
+	c, err = New(root, Options{})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, err := c.Up(context.Background()); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	data, _ := os.ReadFile(log)
+	if strings.Count(string(data), "run -d") != 1 {
+		t.Errorf("expected one container to be created, log:\n%s", data)
+	}
+
+	// Changing devcontainer.json replaces the container.
+	writeConfig(t, root, `{"image": "golang:1.26"}`)
+	if c, err = New(root, Options{}); err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if state, err := c.Status(context.Background()); err != nil || !state.Outdated {
+		t.Fatalf("expected the container to be outdated, got %+v, %v", state, err)
+	}
+	if _, err := c.Up(context.Background()); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	data, _ = os.ReadFile(log)
+	if !strings.Contains(string(data), "rm -f c0ffee") || !strings.Contains(string(data), "golang:1.26") {
+		t.Errorf("expected the outdated container to be replaced, log:\n%s", data)
+	}
+	if _, _, err := c.Command(context.Background(), t.TempDir(), "true"); err == nil {
+		t.Error("expected a directory outside the workspace to be rejected")
+	}
+}
@@ -0,0 +1,206 @@
+package docsync
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var (
+	commandWordPattern = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+	longFlagPattern    = regexp.MustCompile(`(?:^|[\[(|,])(--[A-Za-z0-9][\w-]*)`)
+	shortFlagPattern   = regexp.MustCompile(`(?:^|[\[(|,])(-[A-Za-z])(?:$|[\])|,=])`)
+)
+
+// builtinCommands and builtinFlags are added by cobra when the CLI runs.
+var (
+	builtinCommands = map[string]bool{"help": true, "completion": true}
+	builtinFlags    = map[string]bool{"--help": true, "-h": true}
+)
+
+// checkCommandLine checks a documented command line, split into words,
+// against the CLI: each subcommand must exist and each flag must be one of
+// the command's own or inherited flags. It returns the command the line
+// resolves to.
+func (c *Checker) checkCommandLine(words []string, line int, report reportFunc) *cobra.Command {
+	cmd := c.CLI
+	rest := words[1:]
+	for len(rest) > 0 && commandWordPattern.MatchString(rest[0]) {
+		sub := findSubcommand(cmd, rest[0])
+		if sub == nil {
+			if builtinCommands[rest[0]] {
+				return nil
+			}
+			// Runnable subcommands take arguments; the root command and
+			// command groups do not (cobra rejects unknown words there)
+			if !cmd.HasParent() && cmd.Args == nil || !cmd.Runnable() {
+				report(line, KindCommand, "%s has no subcommand %q", cmd.CommandPath(), rest[0])
+				return nil
+			}
+			break
+		}
+		cmd, rest = sub, rest[1:]
+	}
+
+	for i, word := range rest {
+		if word == "--" {
+			break
+		}
+		for _, flag := range flagNames(word) {
+			if builtinFlags[flag] {
+				continue
+			}
+			if lookupFlag(cmd, flag) == nil {
+				report(line, KindFlag, "%s has no flag %s", cmd.CommandPath(), flag)
+				continue
+			}
+			if flag == "--set" && c.IsSetting != nil {
+				c.checkSetOverride(word, rest[i+1:], line, report)
+			}
+		}
+	}
+	return cmd
+}
+
+// checkSetOverride checks the key of a --set key=value override.
+func (c *Checker) checkSetOverride(word string, next []string, line int, report reportFunc) {
+	value, ok := strings.CutPrefix(word, "--set=")
+	if !ok {
+		if len(next) == 0 {
+			return
+		}
+		value = next[0]
+	}
+	key, _, ok := strings.Cut(value, "=")
+	if ok && settingKeyPattern.MatchString(key) && !c.IsSetting(key) {
+		report(line, KindSetting, "--set %s: %q is not a config.json setting", value, key)
+	}
+}
+
+func findSubcommand(cmd *cobra.Command, name string) *cobra.Command {
+	for _, sub := range cmd.Commands() {
+		if sub.Name() == name || sub.HasAlias(name) {
+			return sub
+		}
+	}
+	return nil
+}
+
+// lookupFlag finds a flag ("--name" or "-n") of cmd or its parents.
+func lookupFlag(cmd *cobra.Command, flag string) *pflag.Flag {
+	for c := cmd; c != nil; c = c.Parent() {
+		for _, set := range []*pflag.FlagSet{c.Flags(), c.PersistentFlags()} {
+			if c != cmd && set == c.Flags() {
+				continue // only persistent flags are inherited
+			}
+			if name, ok := strings.CutPrefix(flag, "--"); ok {
+				if f := set.Lookup(name); f != nil {
+					return f
+				}
+			} else if f := set.ShorthandLookup(strings.TrimPrefix(flag, "-")); f != nil {
+				return f
+			}
+		}
+	}
+	return nil
+}
+
+// hasFlagInTree reports whether cmd, a command it inherits from, or one of
+// its subcommands has the flag.
+func hasFlagInTree(cmd *cobra.Command, flag string) bool {
+	if builtinFlags[flag] || lookupFlag(cmd, flag) != nil {
+		return true
+	}
+	for _, sub := range cmd.Commands() {
+		if hasFlagInTree(sub, flag) {
+			return true
+		}
+	}
+	return false
+}
+
+// flagNames returns the flags a word of a command line or usage line names,
+// e.g. "--model=x" → --model, "[--draft|--ready]" → --draft, --ready.
+func flagNames(word string) []string {
+	if !strings.HasPrefix(strings.TrimLeft(word, "[("), "-") {
+		return nil
+	}
+	var names []string
+	for _, m := range longFlagPattern.FindAllStringSubmatch(word, -1) {
+		names = append(names, m[1])
+	}
+	for _, m := range shortFlagPattern.FindAllStringSubmatch(word, -1) {
+		names = append(names, m[1])
+	}
+	return names
+}
+
+// shellCommands returns the invocations of program in a line of a shell
+// snippet, split into words. Commands are found at the start of the line
+// and after pipes, && and ;.
+func shellCommands(line, program string) [][]string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "$ ")
+	if strings.HasPrefix(line, "#") {
+		return nil
+	}
+	var commands [][]string
+	var current []string
+	flush := func() {
+		if len(current) > 0 && current[0] == program {
+			commands = append(commands, current)
+		}
+		current = nil
+	}
+	for _, word := range shellWords(line) {
+		switch {
+		case word == "|" || word == "&&" || word == "||" || word == ";":
+			flush()
+		case strings.HasPrefix(word, "#") && len(current) > 0:
+			flush()
+			return commands
+		default:
+			current = append(current, word)
+		}
+	}
+	flush()
+	return commands
+}
+
+// shellWords splits a command line into words. Quoted words become a
+// placeholder so their content is never taken for a flag or subcommand.
+func shellWords(line string) []string {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	for i := 0; i < len(line); i++ {
+		ch := line[i]
+		switch {
+		case ch == '"' || ch == '\'':
+			end := strings.IndexByte(line[i+1:], ch)
+			if end < 0 {
+				end = len(line) - i - 1
+			}
+			word.WriteString("<quoted>")
+			inWord = true
+			i += end + 1
+		case ch == ' ' || ch == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		case ch == ';' && !inWord:
+			words = append(words, ";")
+		default:
+			word.WriteByte(ch)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words
+}
@@ -0,0 +1,265 @@
+// Package docsync detects documentation drift: README and docs/ pages that
+// describe commands, flags, settings, files or Go APIs the code no longer
+// has. It reads Markdown, cross-references it against the CLI's command
+// tree, the config.json settings and the source, and reports each mismatch
+// with its file and line.
+package docsync
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Kinds of drift.
+const (
+	KindCommand = "command" // a documented subcommand does not exist
+	KindFlag    = "flag"    // a documented flag does not exist on its command
+	KindSetting = "setting" // a documented config.json setting does not exist
+	KindPath    = "path"    // a referenced file or link target does not exist
+	KindSymbol  = "symbol"  // a Go snippet uses an identifier its package lacks
+)
+
+// Finding is a place where the documentation and the code disagree.
+type Finding struct {
+	File    string `json:"file"` // relative to the checked root
+	Line    int    `json:"line"`
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s:%d: %s: %s", f.File, f.Line, f.Kind, f.Message)
+}
+
+// Checker cross-references documentation against the code of the project
+// at Root.
+type Checker struct {
+	Root string
+	// CLI is the root command documented command lines start with, e.g.
+	// "ledit". Commands and flags are not checked when it is nil.
+	CLI *cobra.Command
+	// IsSetting reports whether a config.json key exists. Settings are not
+	// checked when it is nil.
+	IsSetting func(key string) bool
+
+	module  string                     // Go module path, from go.mod
+	symbols map[string]map[string]bool // exported identifiers by package directory
+}
+
+// DefaultFiles returns README.md and the Markdown files under docs/.
+func DefaultFiles(root string) ([]string, error) {
+	var files []string
+	if _, err := os.Stat(filepath.Join(root, "README.md")); err == nil {
+		files = append(files, "README.md")
+	}
+	err := filepath.WalkDir(filepath.Join(root, "docs"), func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if !d.IsDir() && strings.HasSuffix(strings.ToLower(d.Name()), ".md") {
+			rel, _ := filepath.Rel(root, path)
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	return files, err
+}
+
+var (
+	fencePattern      = regexp.MustCompile("^\\s*(```+|~~~+)\\s*([\\w+-]*)")
+	headingPattern    = regexp.MustCompile(`^(#{1,6})\s+(.+?)\s*#*\s*$`)
+	inlineCodePattern = regexp.MustCompile("`([^`]+)`")
+	linkPattern       = regexp.MustCompile(`\]\(([^)\s]+)\)`)
+	cliHeadingPattern = regexp.MustCompile("^`([\\w-]+(?: [\\w-]+)*)`$")
+)
+
+// shellLanguages are the code fence languages whose lines are commands.
+var shellLanguages = map[string]bool{"": true, "bash": true, "sh": true, "shell": true, "console": true, "zsh": true}
+
+// Check reads the Markdown files (relative to Root) and returns the drift
+// found, ordered by file and line.
+func (c *Checker) Check(files []string) ([]Finding, error) {
+	var findings []Finding
+	for _, file := range files {
+		found, err := c.checkFile(file)
+		if err != nil {
+			return nil, err
+		}
+		findings = append(findings, found...)
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].File != findings[j].File {
+			return findings[i].File < findings[j].File
+		}
+		return findings[i].Line < findings[j].Line
+	})
+	return findings, nil
+}
+
+// fileState tracks where in a Markdown file the scan is.
+type fileState struct {
+	file       string
+	section    string         // text of the current "##" heading
+	command    *cobra.Command // command of the current "### `ledit x`" heading
+	fence      string         // open fence marker, "" outside code blocks
+	language   string
+	block      []string
+	blockStart int
+}
+
+func (c *Checker) checkFile(file string) ([]Finding, error) {
+	f, err := os.Open(filepath.Join(c.Root, file))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", file, err)
+	}
+	defer f.Close()
+
+	var findings []Finding
+	report := func(line int, kind, format string, args ...interface{}) {
+		findings = append(findings, Finding{File: file, Line: line, Kind: kind, Message: fmt.Sprintf(format, args...)})
+	}
+	state := &fileState{file: file}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+
+		if m := fencePattern.FindStringSubmatch(line); m != nil && (state.fence == "" || strings.HasPrefix(strings.TrimSpace(line), state.fence)) {
+			if state.fence == "" {
+				state.fence, state.language, state.block, state.blockStart = m[1], strings.ToLower(m[2]), nil, n+1
+			} else {
+				c.checkBlock(state, report)
+				state.fence = ""
+			}
+			continue
+		}
+		if state.fence != "" {
+			state.block = append(state.block, line)
+			continue
+		}
+
+		if m := headingPattern.FindStringSubmatch(line); m != nil {
+			c.checkHeading(state, len(m[1]), m[2], n, report)
+			continue
+		}
+		for _, m := range inlineCodePattern.FindAllStringSubmatch(line, -1) {
+			c.checkInlineCode(state, m[1], n, report)
+		}
+		for _, m := range linkPattern.FindAllStringSubmatch(line, -1) {
+			c.checkLink(file, m[1], n, report)
+		}
+	}
+	return findings, scanner.Err()
+}
+
+type reportFunc func(line int, kind, format string, args ...interface{})
+
+func (c *Checker) checkHeading(state *fileState, level int, text string, line int, report reportFunc) {
+	switch level {
+	case 1, 2:
+		state.section = text
+		state.command = nil
+	case 3:
+		state.command = nil
+		if m := cliHeadingPattern.FindStringSubmatch(text); m != nil && c.CLI != nil {
+			words := strings.Fields(m[1])
+			if words[0] == c.CLI.Name() {
+				state.command = c.checkCommandLine(words, line, report)
+			}
+		}
+	case 4:
+		// Setting headings such as "#### `subagent_provider` and `subagent_model`"
+		if c.IsSetting == nil || !isConfigSection(state.section) {
+			return
+		}
+		for _, m := range inlineCodePattern.FindAllStringSubmatch(text, -1) {
+			if key := m[1]; settingKeyPattern.MatchString(key) && !c.IsSetting(key) {
+				report(line, KindSetting, "%q is not a config.json setting", key)
+			}
+		}
+	}
+}
+
+func (c *Checker) checkInlineCode(state *fileState, code string, line int, report reportFunc) {
+	code = strings.TrimSpace(code)
+	switch {
+	case c.CLI != nil && strings.HasPrefix(code, c.CLI.Name()+" "):
+		c.checkCommandLine(shellWords(code), line, report)
+	case state.command != nil && strings.HasPrefix(code, "-"):
+		// A flag mentioned in the section of a command: it may belong to
+		// the command or one of its subcommands
+		for _, flag := range flagNames(code) {
+			if !hasFlagInTree(state.command, flag) {
+				report(line, KindFlag, "%s has no flag %s", state.command.CommandPath(), flag)
+			}
+		}
+	case pathPattern.MatchString(code):
+		c.checkPath(code, line, report)
+	}
+}
+
+func (c *Checker) checkBlock(state *fileState, report reportFunc) {
+	switch {
+	case shellLanguages[state.language] && c.CLI != nil:
+		for i, text := range state.block {
+			for _, command := range shellCommands(text, c.CLI.Name()) {
+				c.checkCommandLine(command, state.blockStart+i, report)
+			}
+		}
+	case state.language == "json" && c.IsSetting != nil && isConfigSection(state.section):
+		c.checkSettingsBlock(state.block, state.blockStart, report)
+	case state.language == "go":
+		c.checkGoBlock(state.block, state.blockStart, report)
+	}
+}
+
+// isConfigSection reports whether a "##" section documents config.json.
+func isConfigSection(section string) bool {
+	return strings.Contains(strings.ToLower(section), "config.json")
+}
+
+// pathPattern matches inline code that names a file in the repository, such
+// as pkg/agent/agent.go or docs/CONFIGURATION.md.
+var pathPattern = regexp.MustCompile(`^(?:\./)?[A-Za-z0-9_-]+(?:/[\w.-]+)*/[\w-]+\.[A-Za-z0-9]+$`)
+
+// checkPath reports a repository path that does not exist. Paths whose
+// first directory is not in the repository (runtime files such as
+// .ledit/config.json, or paths in other projects) are not checked.
+func (c *Checker) checkPath(path string, line int, report reportFunc) {
+	path = strings.TrimPrefix(path, "./")
+	first, _, _ := strings.Cut(path, "/")
+	if info, err := os.Stat(filepath.Join(c.Root, first)); err != nil || !info.IsDir() {
+		return
+	}
+	if _, err := os.Stat(filepath.Join(c.Root, filepath.FromSlash(path))); err != nil {
+		report(line, KindPath, "%s does not exist", path)
+	}
+}
+
+// checkLink reports a relative Markdown link whose target does not exist.
+func (c *Checker) checkLink(file, target string, line int, report reportFunc) {
+	if strings.Contains(target, "://") || strings.HasPrefix(target, "#") || strings.HasPrefix(target, "mailto:") {
+		return
+	}
+	target, _, _ = strings.Cut(target, "#")
+	if target == "" {
+		return
+	}
+	path := filepath.Join(c.Root, filepath.Dir(file), filepath.FromSlash(target))
+	if strings.HasPrefix(target, "/") {
+		path = filepath.Join(c.Root, filepath.FromSlash(target))
+	}
+	if _, err := os.Stat(path); err != nil {
+		report(line, KindPath, "link target %s does not exist", target)
+	}
+}
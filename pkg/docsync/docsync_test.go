@@ -0,0 +1,126 @@
+package docsync
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func testCLI() *cobra.Command {
+	root := &cobra.Command{Use: "tool"}
+	root.PersistentFlags().String("set", "", "")
+	run := func(cmd *cobra.Command, args []string) {}
+	agent := &cobra.Command{Use: "agent [intent]", Run: run}
+	agent.Flags().StringP("model", "m", "", "")
+	agent.Flags().Bool("dry-run", false, "")
+	config := &cobra.Command{Use: "config"}
+	show := &cobra.Command{Use: "show", Run: run}
+	show.Flags().Bool("json", false, "")
+	config.AddCommand(show)
+	root.AddCommand(agent, config)
+	return root
+}
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestCheck(t *testing.T) {
+	readme := "# Tool\n" +
+		"\n" +
+		"```bash\n" +
+		"tool agent \"fix the --broken tests\" -m gpt --dry-run\n" +
+		"tool agent --verbose | tee out.log\n" +
+		"tool deploy now   # removed command\n" +
+		"tool config show --json && tool help\n" +
+		"tool --set api_timeouts.bogus=3 agent\n" +
+		"```\n" +
+		"\n" +
+		"### `tool config`\n" +
+		"\n" +
+		"Use `--json` for scripts, or `--yaml`. See `pkg/app/app.go` and `pkg/app/gone.go`,\n" +
+		"[the guide](docs/GUIDE.md) and [the FAQ](docs/FAQ.md#top).\n" +
+		"\n" +
+		"## Settings (config.json)\n" +
+		"\n" +
+		"#### `skip_prompt` and `old_setting`\n" +
+		"\n" +
+		"```json\n" +
+		"\"api_timeouts\": {\n" +
+		"  \"chunk_timeout_sec\": 30,\n" +
+		"  \"nope\": 1\n" +
+		"}\n" +
+		"```\n" +
+		"\n" +
+		"```go\n" +
+		"import app \"example.com/tool/pkg/app\"\n" +
+		"\n" +
+		"app.Run(app.Options{}) // app.Gone in a comment\n" +
+		"app.Stop()\n" +
+		"```\n"
+	dir := writeFiles(t, map[string]string{
+		"go.mod":        "module example.com/tool\n",
+		"README.md":     readme,
+		"docs/GUIDE.md": "# Guide\n",
+		"pkg/app/app.go": "package app\n\ntype Options struct{}\n\nfunc Run(Options) {}\n\n" +
+			"type server struct{}\n\nfunc (server) Stop() {}\n",
+	})
+	settings := map[string]bool{"skip_prompt": true, "api_timeouts": true, "api_timeouts.chunk_timeout_sec": true}
+	checker := &Checker{Root: dir, CLI: testCLI(), IsSetting: func(key string) bool { return settings[key] }}
+
+	files, err := DefaultFiles(dir)
+	if err != nil || len(files) != 2 {
+		t.Fatalf("DefaultFiles = %v, %v", files, err)
+	}
+	findings, err := checker.Check([]string{"README.md"})
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+
+	want := []string{
+		"README.md:5: flag: tool agent has no flag --verbose",
+		`README.md:6: command: tool has no subcommand "deploy"`,
+		`README.md:8: setting: --set api_timeouts.bogus=3: "api_timeouts.bogus" is not a config.json setting`,
+		"README.md:13: flag: tool config has no flag --yaml",
+		"README.md:13: path: pkg/app/gone.go does not exist",
+		"README.md:14: path: link target docs/FAQ.md does not exist",
+		`README.md:18: setting: "old_setting" is not a config.json setting`,
+		`README.md:23: setting: "api_timeouts.nope" is not a config.json setting`,
+		"README.md:31: symbol: app.Stop is not declared in pkg/app",
+	}
+	var got []string
+	for _, finding := range findings {
+		got = append(got, finding.String())
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("findings:\n%s\n\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestShellCommands(t *testing.T) {
+	commands := shellCommands(`$ echo 'tool agent' | tool agent -m "a b" ; tool config show # tool x`, "tool")
+	if len(commands) != 2 || strings.Join(commands[0], " ") != "tool agent -m <quoted>" ||
+		strings.Join(commands[1], " ") != "tool config show" {
+		t.Fatalf("shellCommands = %q", commands)
+	}
+	if got := flagNames("[--draft|--ready]"); len(got) != 2 || got[0] != "--draft" || got[1] != "--ready" {
+		t.Fatalf("flagNames = %q", got)
+	}
+	if got := flagNames("-m=x"); len(got) != 1 || got[0] != "-m" {
+		t.Fatalf("flagNames = %q", got)
+	}
+}
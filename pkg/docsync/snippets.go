@@ -0,0 +1,180 @@
+package docsync
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// settingKeyPattern matches a config.json key path such as
+// "api_timeouts.chunk_timeout_sec".
+var settingKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]*(?:\.[A-Za-z0-9_-]+)*$`)
+
+// checkSettingsBlock checks the keys of a JSON example of config.json. A
+// fragment of keys without the surrounding braces is accepted too; blocks
+// that are not JSON are skipped.
+func (c *Checker) checkSettingsBlock(block []string, start int, report reportFunc) {
+	text := strings.Join(block, "\n")
+	var settings map[string]interface{}
+	if json.Unmarshal([]byte(text), &settings) != nil &&
+		json.Unmarshal([]byte("{"+strings.TrimSuffix(strings.TrimSpace(text), ",")+"}"), &settings) != nil {
+		return
+	}
+	var walk func(prefix string, value map[string]interface{})
+	walk = func(prefix string, value map[string]interface{}) {
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			path := prefix + key
+			if !c.IsSetting(path) {
+				report(start+keyLine(block, key), KindSetting, "%q is not a config.json setting", path)
+				continue
+			}
+			if nested, ok := value[key].(map[string]interface{}); ok {
+				walk(path+".", nested)
+			}
+		}
+	}
+	walk("", settings)
+}
+
+// keyLine returns the line of the block on which key first appears.
+func keyLine(block []string, key string) int {
+	quoted := `"` + key + `"`
+	for i, line := range block {
+		if strings.Contains(line, quoted) {
+			return i
+		}
+	}
+	return 0
+}
+
+var (
+	goImportPattern    = regexp.MustCompile(`^\s*(?:import\s+)?(?:([A-Za-z_]\w*|\.)\s+)?"([^"]+)"`)
+	goQualifiedPattern = regexp.MustCompile(`\b([A-Za-z_]\w*)\.([A-Z]\w*)`)
+)
+
+// checkGoBlock checks a Go example against the project's packages: each
+// exported identifier it uses from an imported package of this module must
+// be declared by that package. Snippets need not compile; imports and
+// qualified identifiers are found line by line.
+func (c *Checker) checkGoBlock(block []string, start int, report reportFunc) {
+	module := c.modulePath()
+	if module == "" {
+		return
+	}
+	imports := map[string]string{} // package name → directory relative to Root
+	for _, line := range block {
+		m := goImportPattern.FindStringSubmatch(line)
+		if m == nil || (m[2] != module && !strings.HasPrefix(m[2], module+"/")) {
+			continue
+		}
+		dir := strings.TrimPrefix(strings.TrimPrefix(m[2], module), "/")
+		name := m[1]
+		if name == "" {
+			name = filepath.Base(m[2])
+		}
+		if name != "_" && name != "." {
+			imports[name] = dir
+		}
+	}
+	if len(imports) == 0 {
+		return
+	}
+
+	for i, line := range block {
+		if goImportPattern.MatchString(line) {
+			continue
+		}
+		code, _, _ := strings.Cut(line, "//")
+		for _, m := range goQualifiedPattern.FindAllStringSubmatch(code, -1) {
+			dir, ok := imports[m[1]]
+			if !ok {
+				continue
+			}
+			symbols := c.packageSymbols(dir)
+			if symbols == nil {
+				report(start+i, KindSymbol, "package %s does not exist", filepath.ToSlash(filepath.Join(module, dir)))
+				delete(imports, m[1])
+				continue
+			}
+			if !symbols[m[2]] {
+				report(start+i, KindSymbol, "%s.%s is not declared in %s", m[1], m[2], dir)
+			}
+		}
+	}
+}
+
+// modulePath reads the module path from go.mod at Root.
+func (c *Checker) modulePath() string {
+	if c.module != "" {
+		return c.module
+	}
+	data, err := os.ReadFile(filepath.Join(c.Root, "go.mod"))
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if rest, ok := strings.CutPrefix(strings.TrimSpace(line), "module "); ok {
+			c.module = strings.Trim(strings.TrimSpace(rest), `"`)
+			break
+		}
+	}
+	return c.module
+}
+
+// packageSymbols returns the exported top-level identifiers declared in the
+// package at dir, or nil when it has no Go files.
+func (c *Checker) packageSymbols(dir string) map[string]bool {
+	if symbols, ok := c.symbols[dir]; ok {
+		return symbols
+	}
+	if c.symbols == nil {
+		c.symbols = make(map[string]map[string]bool)
+	}
+	var symbols map[string]bool
+	matches, _ := filepath.Glob(filepath.Join(c.Root, filepath.FromSlash(dir), "*.go"))
+	fset := token.NewFileSet()
+	for _, path := range matches {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			continue
+		}
+		if symbols == nil {
+			symbols = make(map[string]bool)
+		}
+		for _, decl := range file.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				if decl.Recv == nil {
+					symbols[decl.Name.Name] = true
+				}
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					switch spec := spec.(type) {
+					case *ast.TypeSpec:
+						symbols[spec.Name.Name] = true
+					case *ast.ValueSpec:
+						for _, name := range spec.Names {
+							symbols[name.Name] = true
+						}
+					}
+				}
+			}
+		}
+	}
+	c.symbols[dir] = symbols
+	return symbols
+}
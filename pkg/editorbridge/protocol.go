@@ -0,0 +1,118 @@
+package editorbridge
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// maxMessageSize bounds the body of one message.
+const maxMessageSize = 32 << 20
+
+// JSON-RPC error codes.
+const (
+	CodeParseError       = -32700
+	CodeInvalidRequest   = -32600
+	CodeMethodNotFound   = -32601
+	CodeInvalidParams    = -32602
+	CodeInternalError    = -32603
+	CodeRequestCancelled = -32800
+)
+
+// Message is a JSON-RPC 2.0 request, notification or response. Requests
+// carry an ID and a method, notifications only a method, and responses an
+// ID with a result or an error.
+type Message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  any             `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// IsNotification reports whether the message is a request that expects no
+// response.
+func (m *Message) IsNotification() bool {
+	return len(m.ID) == 0 || string(m.ID) == "null"
+}
+
+// Error is a JSON-RPC error object.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+// Reader reads messages framed as in the Language Server Protocol: headers
+// with a Content-Length, a blank line, then the JSON body.
+type Reader struct {
+	r *textproto.Reader
+}
+
+// NewReader returns a Reader reading framed messages from r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: textproto.NewReader(bufio.NewReader(r))}
+}
+
+// Read returns the next message's body. It returns io.EOF when the stream
+// ends between messages.
+func (r *Reader) Read() ([]byte, error) {
+	header, err := r.r.ReadMIMEHeader()
+	if err != nil {
+		if errors.Is(err, io.EOF) && len(header) == 0 {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	value := strings.TrimSpace(header.Get("Content-Length"))
+	if value == "" {
+		return nil, errors.New("missing Content-Length header")
+	}
+	length, err := strconv.Atoi(value)
+	if err != nil || length < 0 || length > maxMessageSize {
+		return nil, fmt.Errorf("invalid Content-Length %q", value)
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r.r.R, body); err != nil {
+		return nil, fmt.Errorf("read body: %w", err)
+	}
+	return body, nil
+}
+
+// Writer writes framed messages. It is safe for concurrent use, so
+// notifications can be written while a response is being prepared.
+type Writer struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriter returns a Writer writing framed messages to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// Write frames and writes msg.
+func (w *Writer) Write(msg *Message) error {
+	msg.JSONRPC = "2.0"
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, err := fmt.Fprintf(w.w, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+		return err
+	}
+	_, err = w.w.Write(body)
+	return err
+}
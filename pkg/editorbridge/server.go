@@ -0,0 +1,671 @@
+// Package editorbridge serves a running agent to editor plugins such as VS
+// Code or Neovim extensions. Plugins speak JSON-RPC 2.0 with Language Server
+// Protocol framing, over stdio or a loopback TCP port, to explain or refactor
+// a selection, streaming the agent's answer, and to apply workspace edits
+// through the agent's change tracking.
+package editorbridge
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	tools "github.com/alantheprice/ledit/pkg/agent_tools"
+	"github.com/alantheprice/ledit/pkg/events"
+)
+
+// ProtocolVersion is the version of the bridge's methods and notifications,
+// reported by initialize and `ledit capabilities`.
+const ProtocolVersion = "1"
+
+// Methods a plugin can call.
+const (
+	MethodInitialize = "initialize"
+	MethodShutdown   = "shutdown"
+	MethodExit       = "exit"
+	MethodCancel     = "$/cancelRequest"
+	MethodExplain    = "ledit/explainSelection"
+	MethodRefactor   = "ledit/refactorSelection"
+	MethodApplyEdit  = "ledit/applyEdit"
+)
+
+// Notifications the server sends.
+const (
+	// NotifyStream carries a chunk of the answer to a running request.
+	NotifyStream = "ledit/stream"
+	// NotifyFileChanged reports a file the agent wrote, so the editor can
+	// reload it.
+	NotifyFileChanged = "ledit/fileChanged"
+)
+
+// CodeRequestFailed is returned when the agent fails a valid request.
+const CodeRequestFailed = -32803
+
+// CodeUnauthorized is returned, before the connection is closed, when a TCP
+// connection's first request is not an initialize with the server's token.
+const CodeUnauthorized = -32001
+
+// TokenFile is where ListenAndServe writes the token TCP connections must
+// present, relative to the workspace root. Only the owner can read it.
+const TokenFile = ".ledit/editor-bridge.token"
+
+// InitializeParams is the params of initialize. Token is required on TCP
+// connections and ignored on stdio, whose plugin spawned the server.
+type InitializeParams struct {
+	Token string `json:"token,omitempty"`
+}
+
+// Agent is the part of the agent the bridge drives.
+type Agent interface {
+	ProcessQuery(query string) (string, error)
+	EnableStreaming(callback func(string))
+	TriggerInterrupt()
+	ClearInterrupt()
+	ApplyEdits(ctx context.Context, label string, edits []tools.TransactionEdit) (string, error)
+}
+
+// Selection is the params of explainSelection and refactorSelection.
+type Selection struct {
+	File string `json:"file"`
+	// StartLine and EndLine are 1-based and inclusive.
+	StartLine int `json:"startLine,omitempty"`
+	EndLine   int `json:"endLine,omitempty"`
+	// Text is the selected text. When empty, the lines are read from File.
+	Text     string `json:"text,omitempty"`
+	Language string `json:"language,omitempty"`
+	// Instruction is a question about the code when explaining, and the
+	// change to make when refactoring.
+	Instruction string `json:"instruction,omitempty"`
+}
+
+// QueryResult is the result of explainSelection and refactorSelection: the
+// agent's whole answer, also streamed in NotifyStream chunks.
+type QueryResult struct {
+	Answer string `json:"answer"`
+}
+
+// Edit is one change of applyEdit: OldText replaced by NewText, or, when
+// Content is set, the file's whole new content.
+type Edit struct {
+	File    string  `json:"file"`
+	OldText string  `json:"oldText,omitempty"`
+	NewText string  `json:"newText,omitempty"`
+	Content *string `json:"content,omitempty"`
+}
+
+// ApplyEditParams is the params of applyEdit. The edits are applied
+// together: if one fails, no file is changed.
+type ApplyEditParams struct {
+	// Label describes the change in the change history.
+	Label string `json:"label,omitempty"`
+	Edits []Edit `json:"edits"`
+}
+
+// defaultEditLabel describes an applyEdit without a label.
+const defaultEditLabel = "Edit applied from the editor"
+
+// ApplyEditResult is the result of applyEdit, with a diff of each file.
+type ApplyEditResult struct {
+	Summary string `json:"summary"`
+}
+
+// StreamParams is the params of NotifyStream.
+type StreamParams struct {
+	ID   json.RawMessage `json:"id"`
+	Text string          `json:"text"`
+}
+
+// FileChangedParams is the params of NotifyFileChanged.
+type FileChangedParams struct {
+	File   string `json:"file"`
+	Action string `json:"action"`
+}
+
+// Server serves one agent to any number of editor connections. Agent work
+// runs one request at a time; others wait their turn.
+type Server struct {
+	agent  Agent
+	root   string
+	events *events.EventBus
+
+	// work serializes requests that use the agent.
+	work sync.Mutex
+
+	mu        sync.Mutex
+	running   string          // ID of the query the agent is running
+	pending   map[string]bool // IDs of agent requests not yet answered
+	cancelled map[string]bool
+
+	conns atomic.Int64
+}
+
+// NewServer returns a server for agent working in root. File changes
+// published on bus, which may be nil, are forwarded to every connection.
+func NewServer(agent Agent, root string, bus *events.EventBus) *Server {
+	return &Server{
+		agent:     agent,
+		root:      root,
+		events:    bus,
+		pending:   make(map[string]bool),
+		cancelled: make(map[string]bool),
+	}
+}
+
+// conn is one editor connection.
+type conn struct {
+	id  int64
+	out *Writer
+	// ids are the connection's agent requests, to cancel when it closes.
+	mu  sync.Mutex
+	ids map[string]bool
+}
+
+func (c *conn) notify(method string, params any) {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return
+	}
+	if err := c.out.Write(&Message{Method: method, Params: body}); err != nil {
+		fmt.Fprintf(os.Stderr, "[WARN] editor bridge: failed to send %s: %v\n", method, err)
+	}
+}
+
+func (c *conn) respond(id json.RawMessage, result any, err error) {
+	msg := &Message{ID: id}
+	if err != nil {
+		var rpcErr *Error
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &Error{Code: CodeRequestFailed, Message: err.Error()}
+		}
+		msg.Error = rpcErr
+	} else if result == nil {
+		msg.Result = json.RawMessage("null")
+	} else {
+		msg.Result = result
+	}
+	if writeErr := c.out.Write(msg); writeErr != nil {
+		fmt.Fprintf(os.Stderr, "[WARN] editor bridge: failed to send response: %v\n", writeErr)
+	}
+}
+
+// Serve reads requests from r and writes responses and notifications to w
+// until the stream ends or the plugin sends exit. Requests are handled
+// concurrently, so a cancel can arrive while a query runs.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	return s.serve(ctx, r, w, "")
+}
+
+// serve is Serve for a connection that must first present token, unless
+// token is empty.
+func (s *Server) serve(ctx context.Context, r io.Reader, w io.Writer, token string) error {
+	c := &conn{id: s.conns.Add(1), out: NewWriter(w), ids: make(map[string]bool)}
+	in := NewReader(r)
+	if token != "" {
+		if err := s.authenticate(ctx, c, in, token); err != nil {
+			return err
+		}
+	}
+	if s.events != nil {
+		name := fmt.Sprintf("editorbridge-%d", c.id)
+		go forwardFileChanges(c, s.events.Subscribe(name))
+		defer s.events.Unsubscribe(name)
+	}
+
+	var handlers sync.WaitGroup
+	defer func() {
+		s.cancelConn(c)
+		handlers.Wait()
+	}()
+
+	for {
+		body, err := in.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		msg := &Message{}
+		if err := json.Unmarshal(body, msg); err != nil {
+			c.respond(json.RawMessage("null"), nil, &Error{Code: CodeParseError, Message: err.Error()})
+			continue
+		}
+		switch msg.Method {
+		case "":
+			// Responses to the server are not expected; ignore them.
+			continue
+		case MethodExit:
+			return nil
+		case MethodCancel:
+			s.cancelParams(msg.Params)
+			continue
+		}
+		if s.usesAgent(msg.Method) && !msg.IsNotification() {
+			s.track(c, msg.ID)
+		}
+		handlers.Add(1)
+		go func() {
+			defer handlers.Done()
+			result, err := s.call(ctx, c, msg)
+			if !msg.IsNotification() {
+				c.respond(msg.ID, result, err)
+			}
+		}()
+	}
+}
+
+// authenticate answers the connection's first request, which must be an
+// initialize presenting token; anything else is refused.
+func (s *Server) authenticate(ctx context.Context, c *conn, in *Reader, token string) error {
+	body, err := in.Read()
+	if err != nil {
+		return err
+	}
+	msg := &Message{}
+	var params InitializeParams
+	if json.Unmarshal(body, msg) != nil || msg.Method != MethodInitialize || msg.IsNotification() ||
+		json.Unmarshal(msg.Params, &params) != nil ||
+		subtle.ConstantTimeCompare([]byte(params.Token), []byte(token)) != 1 {
+		id := msg.ID
+		if len(id) == 0 {
+			id = json.RawMessage("null")
+		}
+		c.respond(id, nil, &Error{Code: CodeUnauthorized, Message: "the first request must be initialize with the token from " + TokenFile})
+		return errors.New("refused a connection without the token")
+	}
+	result, err := s.call(ctx, c, msg)
+	c.respond(msg.ID, result, err)
+	return nil
+}
+
+// ListenAndServe accepts editor connections on addr, a loopback host:port,
+// until ctx is done. Each connection must open with an initialize request
+// presenting a token generated for this server and written to TokenFile
+// under the workspace, so other local users and programs cannot drive the
+// agent. ready, if not nil, is called with the address and the token file
+// once the server is listening, which with port 0 is how the chosen port is
+// learned.
+func (s *Server) ListenAndServe(ctx context.Context, addr string, ready func(addr net.Addr, tokenPath string)) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid listen address %q: %w", addr, err)
+	}
+	if !isLoopback(host) {
+		return fmt.Errorf("the editor bridge only listens on loopback addresses, not %q", host)
+	}
+	token, tokenPath, err := s.writeToken()
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tokenPath)
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	if ready != nil {
+		ready(listener.Addr(), tokenPath)
+	}
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	for {
+		netConn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		go func() {
+			defer netConn.Close()
+			if err := s.serve(ctx, netConn, netConn, token); err != nil {
+				fmt.Fprintf(os.Stderr, "[WARN] editor bridge: connection from %s: %v\n", netConn.RemoteAddr(), err)
+			}
+		}()
+	}
+}
+
+// writeToken generates a random token and writes it to TokenFile, readable
+// only by the owner.
+func (s *Server) writeToken() (token, path string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", fmt.Errorf("generate token: %w", err)
+	}
+	token = hex.EncodeToString(buf)
+	path = filepath.Join(s.root, TokenFile)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", "", fmt.Errorf("write token: %w", err)
+	}
+	// Remove a token left by an earlier server, whose mode may be looser.
+	_ = os.Remove(path)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return "", "", fmt.Errorf("write token: %w", err)
+	}
+	if _, err := f.WriteString(token + "\n"); err != nil {
+		f.Close()
+		return "", "", fmt.Errorf("write token: %w", err)
+	}
+	return token, path, f.Close()
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func forwardFileChanges(c *conn, ch <-chan events.UIEvent) {
+	for event := range ch {
+		if event.Type != events.EventTypeFileChanged {
+			continue
+		}
+		data, ok := event.Data.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		file, _ := data["file_path"].(string)
+		action, _ := data["action"].(string)
+		if file != "" {
+			c.notify(NotifyFileChanged, FileChangedParams{File: file, Action: action})
+		}
+	}
+}
+
+func (s *Server) usesAgent(method string) bool {
+	return method == MethodExplain || method == MethodRefactor || method == MethodApplyEdit
+}
+
+// call runs one request and returns its result.
+func (s *Server) call(ctx context.Context, c *conn, msg *Message) (any, error) {
+	switch msg.Method {
+	case MethodInitialize:
+		return map[string]any{
+			"name":            "ledit",
+			"protocolVersion": ProtocolVersion,
+			"workspace":       s.root,
+			"methods":         []string{MethodExplain, MethodRefactor, MethodApplyEdit},
+		}, nil
+	case MethodShutdown:
+		s.cancelConn(c)
+		return nil, nil
+	case MethodExplain, MethodRefactor:
+		defer s.untrack(c, msg.ID)
+		var sel Selection
+		if err := decodeParams(msg.Params, &sel); err != nil {
+			return nil, err
+		}
+		prompt, err := s.selectionPrompt(msg.Method, sel)
+		if err != nil {
+			return nil, &Error{Code: CodeInvalidParams, Message: err.Error()}
+		}
+		answer, err := s.query(c, msg.ID, prompt)
+		if err != nil {
+			return nil, err
+		}
+		return QueryResult{Answer: answer}, nil
+	case MethodApplyEdit:
+		defer s.untrack(c, msg.ID)
+		var params ApplyEditParams
+		if err := decodeParams(msg.Params, &params); err != nil {
+			return nil, err
+		}
+		edits, err := s.transactionEdits(params.Edits)
+		if err != nil {
+			return nil, &Error{Code: CodeInvalidParams, Message: err.Error()}
+		}
+		s.work.Lock()
+		defer s.work.Unlock()
+		if s.isCancelled(msg.ID) {
+			return nil, errCancelled
+		}
+		label := strings.TrimSpace(params.Label)
+		if label == "" {
+			label = defaultEditLabel
+		}
+		summary, err := s.agent.ApplyEdits(ctx, label, edits)
+		if err != nil {
+			return nil, err
+		}
+		return ApplyEditResult{Summary: summary}, nil
+	}
+	return nil, &Error{Code: CodeMethodNotFound, Message: fmt.Sprintf("unknown method %q", msg.Method)}
+}
+
+var errCancelled = &Error{Code: CodeRequestCancelled, Message: "request cancelled"}
+
+func decodeParams(raw json.RawMessage, v any) error {
+	if len(raw) == 0 {
+		return &Error{Code: CodeInvalidParams, Message: "missing params"}
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return &Error{Code: CodeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+// query runs prompt on the agent, streaming its answer to c.
+func (s *Server) query(c *conn, id json.RawMessage, prompt string) (string, error) {
+	s.work.Lock()
+	defer s.work.Unlock()
+
+	// Clear an interrupt left from an earlier request before this one can
+	// be cancelled.
+	s.agent.ClearInterrupt()
+	s.mu.Lock()
+	if s.cancelled[string(id)] {
+		s.mu.Unlock()
+		return "", errCancelled
+	}
+	s.running = string(id)
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.running = ""
+		s.mu.Unlock()
+	}()
+
+	s.agent.EnableStreaming(func(chunk string) {
+		c.notify(NotifyStream, StreamParams{ID: id, Text: chunk})
+	})
+	defer s.agent.EnableStreaming(func(string) {})
+
+	answer, err := s.agent.ProcessQuery(prompt)
+	if s.isCancelled(id) {
+		return "", errCancelled
+	}
+	return answer, err
+}
+
+// track records an agent request of c as pending, so it can be cancelled.
+func (s *Server) track(c *conn, id json.RawMessage) {
+	s.mu.Lock()
+	s.pending[string(id)] = true
+	s.mu.Unlock()
+	c.mu.Lock()
+	c.ids[string(id)] = true
+	c.mu.Unlock()
+}
+
+func (s *Server) untrack(c *conn, id json.RawMessage) {
+	s.mu.Lock()
+	delete(s.pending, string(id))
+	delete(s.cancelled, string(id))
+	s.mu.Unlock()
+	c.mu.Lock()
+	delete(c.ids, string(id))
+	c.mu.Unlock()
+}
+
+func (s *Server) isCancelled(id json.RawMessage) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cancelled[string(id)]
+}
+
+// cancel cancels a pending request, interrupting the agent if it is running.
+func (s *Server) cancel(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.pending[id] {
+		return
+	}
+	s.cancelled[id] = true
+	if s.running == id {
+		s.agent.TriggerInterrupt()
+	}
+}
+
+func (s *Server) cancelParams(raw json.RawMessage) {
+	var params struct {
+		ID json.RawMessage `json:"id"`
+	}
+	if json.Unmarshal(raw, &params) == nil && len(params.ID) > 0 {
+		s.cancel(string(params.ID))
+	}
+}
+
+// cancelConn cancels every pending request of c.
+func (s *Server) cancelConn(c *conn) {
+	c.mu.Lock()
+	ids := make([]string, 0, len(c.ids))
+	for id := range c.ids {
+		ids = append(ids, id)
+	}
+	c.mu.Unlock()
+	for _, id := range ids {
+		s.cancel(id)
+	}
+}
+
+// resolve makes a plugin's path absolute in the workspace, refusing paths
+// outside it so a plugin cannot read or write other files on the machine.
+func (s *Server) resolve(path string) (string, error) {
+	abs := filepath.Clean(path)
+	if !filepath.IsAbs(abs) {
+		abs = filepath.Join(s.root, abs)
+	}
+	rel, err := filepath.Rel(filepath.Clean(s.root), abs)
+	if s.root == "" || err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside the workspace", path)
+	}
+	return abs, nil
+}
+
+func (s *Server) transactionEdits(edits []Edit) ([]tools.TransactionEdit, error) {
+	if len(edits) == 0 {
+		return nil, errors.New("edits must be a non-empty array")
+	}
+	out := make([]tools.TransactionEdit, 0, len(edits))
+	for i, edit := range edits {
+		if edit.File == "" {
+			return nil, fmt.Errorf("edit %d: missing file", i+1)
+		}
+		if edit.Content == nil && edit.OldText == "" {
+			return nil, fmt.Errorf("edit %d (%s) needs content, or oldText and newText", i+1, edit.File)
+		}
+		path, err := s.resolve(edit.File)
+		if err != nil {
+			return nil, fmt.Errorf("edit %d: %w", i+1, err)
+		}
+		out = append(out, tools.TransactionEdit{
+			Path:    path,
+			OldStr:  edit.OldText,
+			NewStr:  edit.NewText,
+			Content: edit.Content,
+		})
+	}
+	return out, nil
+}
+
+// selectionPrompt frames a selection as the agent's query.
+func (s *Server) selectionPrompt(method string, sel Selection) (string, error) {
+	text := sel.Text
+	if text == "" {
+		var err error
+		if text, err = s.readLines(sel); err != nil {
+			return "", err
+		}
+	}
+	if strings.TrimSpace(text) == "" {
+		return "", errors.New("the selection is empty")
+	}
+
+	where := "the editor"
+	if sel.File != "" {
+		where = sel.File
+		if sel.StartLine > 0 {
+			end := sel.EndLine
+			if end < sel.StartLine {
+				end = sel.StartLine
+			}
+			if end == sel.StartLine {
+				where += fmt.Sprintf(" (line %d)", end)
+			} else {
+				where += fmt.Sprintf(" (lines %d-%d)", sel.StartLine, end)
+			}
+		}
+	}
+	code := fmt.Sprintf("```%s\n%s\n```", sel.Language, strings.TrimRight(text, "\n"))
+	instruction := strings.TrimSpace(sel.Instruction)
+
+	if method == MethodExplain {
+		prompt := fmt.Sprintf("Explain this code from %s. Do not modify any files.", where)
+		if instruction != "" {
+			prompt += "\nQuestion: " + instruction
+		}
+		return prompt + "\n\n" + code, nil
+	}
+	if sel.File == "" {
+		return "", errors.New("refactoring needs the selection's file")
+	}
+	if instruction == "" {
+		return "", errors.New("refactoring needs an instruction")
+	}
+	return fmt.Sprintf("Refactor this code from %s: %s\n"+
+		"Edit the file in place with your file editing tools, changing only what the instruction needs, then summarize the change.\n\n%s",
+		where, instruction, code), nil
+}
+
+// readLines reads the selected lines from the selection's file.
+func (s *Server) readLines(sel Selection) (string, error) {
+	if sel.File == "" || sel.StartLine <= 0 {
+		return "", errors.New("the selection needs text, or a file and start line")
+	}
+	path, err := s.resolve(sel.File)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	lines := strings.Split(string(data), "\n")
+	end := sel.EndLine
+	if end < sel.StartLine {
+		end = sel.StartLine
+	}
+	if sel.StartLine > len(lines) {
+		return "", fmt.Errorf("%s has %d lines, the selection starts at %d", sel.File, len(lines), sel.StartLine)
+	}
+	if end > len(lines) {
+		end = len(lines)
+	}
+	return strings.Join(lines[sel.StartLine-1:end], "\n"), nil
+}
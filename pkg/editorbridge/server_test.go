@@ -0,0 +1,323 @@
+package editorbridge
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	tools "github.com/alantheprice/ledit/pkg/agent_tools"
+	"github.com/alantheprice/ledit/pkg/events"
+)
+
+type fakeAgent struct {
+	mu        sync.Mutex
+	stream    func(string)
+	prompts   []string
+	label     string
+	edits     []tools.TransactionEdit
+	bus       *events.EventBus
+	block     bool
+	interrupt chan struct{}
+}
+
+func newFakeAgent() *fakeAgent {
+	return &fakeAgent{interrupt: make(chan struct{}, 1)}
+}
+
+func (f *fakeAgent) ProcessQuery(query string) (string, error) {
+	f.mu.Lock()
+	f.prompts = append(f.prompts, query)
+	stream, block := f.stream, f.block
+	f.mu.Unlock()
+	if block {
+		<-f.interrupt
+		return "", nil
+	}
+	stream("It ")
+	stream("adds.")
+	return "It adds.", nil
+}
+
+func (f *fakeAgent) EnableStreaming(callback func(string)) {
+	f.mu.Lock()
+	f.stream = callback
+	f.mu.Unlock()
+}
+
+func (f *fakeAgent) TriggerInterrupt() { f.interrupt <- struct{}{} }
+
+func (f *fakeAgent) ClearInterrupt() {}
+
+func (f *fakeAgent) ApplyEdits(ctx context.Context, label string, edits []tools.TransactionEdit) (string, error) {
+	f.label, f.edits = label, edits
+	f.bus.Publish(events.EventTypeFileChanged, events.FileChangedEvent(edits[0].Path, "edit", ""))
+	return "Committed 1 edit(s) to 1 file(s)", nil
+}
+
+// client drives a Server over pipes.
+type client struct {
+	t   *testing.T
+	in  *Reader
+	out *Writer
+}
+
+func startServer(t *testing.T, agent Agent, root string, bus *events.EventBus) *client {
+	t.Helper()
+	toServer, clientOut := io.Pipe()
+	clientIn, fromServer := io.Pipe()
+	server := NewServer(agent, root, bus)
+	done := make(chan error, 1)
+	go func() {
+		done <- server.Serve(context.Background(), toServer, fromServer)
+		fromServer.Close()
+	}()
+	t.Cleanup(func() {
+		clientOut.Close()
+		if err := <-done; err != nil {
+			t.Errorf("Serve: %v", err)
+		}
+	})
+	return &client{t: t, in: NewReader(clientIn), out: NewWriter(clientOut)}
+}
+
+func (c *client) send(id int, method string, params any) {
+	c.t.Helper()
+	msg := &Message{Method: method}
+	if id > 0 {
+		msg.ID = mustJSON(c.t, id)
+	}
+	if params != nil {
+		msg.Params = mustJSON(c.t, params)
+	}
+	if err := c.out.Write(msg); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// next reads the next message from the server.
+func (c *client) next() map[string]any {
+	c.t.Helper()
+	body, err := c.in.Read()
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	var msg map[string]any
+	if err := json.Unmarshal(body, &msg); err != nil {
+		c.t.Fatal(err)
+	}
+	return msg
+}
+
+func mustJSON(t *testing.T, v any) json.RawMessage {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestExplainSelectionStreamsAnswer(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "sum.go"), []byte("package sum\n\nfunc Add(a, b int) int {\n\treturn a + b\n}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	agent := newFakeAgent()
+	c := startServer(t, agent, root, nil)
+
+	c.send(1, MethodInitialize, map[string]any{})
+	if msg := c.next(); msg["result"].(map[string]any)["workspace"] != root {
+		t.Fatalf("unexpected initialize result %v", msg)
+	}
+
+	c.send(2, MethodExplain, Selection{File: "sum.go", StartLine: 3, EndLine: 5, Language: "go", Instruction: "why int?"})
+	var streamed strings.Builder
+	for {
+		msg := c.next()
+		if msg["method"] == NotifyStream {
+			params := msg["params"].(map[string]any)
+			if params["id"] != float64(2) {
+				t.Fatalf("expected chunks tagged with the request ID, got %v", params)
+			}
+			streamed.WriteString(params["text"].(string))
+			continue
+		}
+		if answer := msg["result"].(map[string]any)["answer"]; answer != "It adds." {
+			t.Fatalf("unexpected response %v", msg)
+		}
+		break
+	}
+	if streamed.String() != "It adds." {
+		t.Fatalf("expected the answer to be streamed, got %q", streamed.String())
+	}
+	prompt := agent.prompts[0]
+	if !strings.Contains(prompt, "sum.go (lines 3-5)") || !strings.Contains(prompt, "```go\nfunc Add(a, b int) int {\n\treturn a + b\n}\n```") || !strings.Contains(prompt, "why int?") {
+		t.Fatalf("expected the selected lines and question in the prompt, got %q", prompt)
+	}
+
+	c.send(3, MethodRefactor, Selection{Text: "x := 1"})
+	if msg := c.next(); msg["error"].(map[string]any)["code"] != float64(CodeInvalidParams) {
+		t.Fatalf("expected a refactor without a file to be rejected, got %v", msg)
+	}
+	c.send(4, "ledit/unknown", nil)
+	if msg := c.next(); msg["error"].(map[string]any)["code"] != float64(CodeMethodNotFound) {
+		t.Fatalf("expected an unknown method error, got %v", msg)
+	}
+}
+
+func TestApplyEditForwardsFileChanges(t *testing.T) {
+	root := t.TempDir()
+	bus := events.NewEventBus()
+	agent := newFakeAgent()
+	agent.bus = bus
+	c := startServer(t, agent, root, bus)
+
+	c.send(1, MethodApplyEdit, ApplyEditParams{Edits: []Edit{{File: "main.go", OldText: "old", NewText: "new"}}})
+	var changed, result map[string]any
+	for changed == nil || result == nil {
+		msg := c.next()
+		if msg["method"] == NotifyFileChanged {
+			changed = msg["params"].(map[string]any)
+		} else {
+			result = msg
+		}
+	}
+	path := filepath.Join(root, "main.go")
+	if len(agent.edits) != 1 || agent.edits[0] != (tools.TransactionEdit{Path: path, OldStr: "old", NewStr: "new"}) {
+		t.Fatalf("expected the edit resolved in the workspace, got %+v", agent.edits)
+	}
+	if agent.label != defaultEditLabel {
+		t.Fatalf("expected the default label, got %q", agent.label)
+	}
+	if changed["file"] != path || changed["action"] != "edit" {
+		t.Fatalf("unexpected file change %v", changed)
+	}
+	if !strings.HasPrefix(result["result"].(map[string]any)["summary"].(string), "Committed") {
+		t.Fatalf("unexpected result %v", result)
+	}
+}
+
+func TestPathsOutsideWorkspaceAreRefused(t *testing.T) {
+	parent := t.TempDir()
+	root := filepath.Join(parent, "workspace")
+	if err := os.Mkdir(root, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	secret := filepath.Join(parent, "secret.txt")
+	if err := os.WriteFile(secret, []byte("hunter2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	agent := newFakeAgent()
+	c := startServer(t, agent, root, nil)
+
+	id := 0
+	for _, file := range []string{secret, "../secret.txt", "sub/../../secret.txt", "/etc/passwd"} {
+		id++
+		c.send(id, MethodApplyEdit, ApplyEditParams{Edits: []Edit{{File: file, OldText: "hunter2", NewText: "x"}}})
+		if msg := c.next(); msg["error"] == nil || msg["error"].(map[string]any)["code"] != float64(CodeInvalidParams) {
+			t.Errorf("expected an edit of %s to be refused, got %v", file, msg)
+		}
+		id++
+		c.send(id, MethodExplain, Selection{File: file, StartLine: 1})
+		if msg := c.next(); msg["error"] == nil || msg["error"].(map[string]any)["code"] != float64(CodeInvalidParams) {
+			t.Errorf("expected reading %s to be refused, got %v", file, msg)
+		}
+	}
+	if len(agent.edits) != 0 || len(agent.prompts) != 0 {
+		t.Fatalf("expected the agent not to be called, got %+v %q", agent.edits, agent.prompts)
+	}
+}
+
+func TestCancelRequestInterruptsQuery(t *testing.T) {
+	agent := newFakeAgent()
+	agent.block = true
+	c := startServer(t, agent, t.TempDir(), nil)
+
+	c.send(7, MethodExplain, Selection{Text: "x := 1"})
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		agent.mu.Lock()
+		started := len(agent.prompts) > 0
+		agent.mu.Unlock()
+		if started || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	c.send(0, MethodCancel, map[string]any{"id": 7})
+	if msg := c.next(); msg["error"].(map[string]any)["code"] != float64(CodeRequestCancelled) {
+		t.Fatalf("expected the request to be cancelled, got %v", msg)
+	}
+}
+
+func TestReaderRequiresContentLength(t *testing.T) {
+	r := NewReader(strings.NewReader("Content-Type: application/json\r\n\r\n{}"))
+	if _, err := r.Read(); err == nil || !strings.Contains(err.Error(), "Content-Length") {
+		t.Fatalf("expected a missing Content-Length error, got %v", err)
+	}
+	if err := NewServer(newFakeAgent(), "", nil).ListenAndServe(context.Background(), "0.0.0.0:0", nil); err == nil {
+		t.Fatal("expected a non-loopback address to be refused")
+	}
+}
+
+func TestListenAndServeRequiresToken(t *testing.T) {
+	root := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	ready := make(chan [2]string, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- NewServer(newFakeAgent(), root, nil).ListenAndServe(ctx, "127.0.0.1:0", func(addr net.Addr, tokenPath string) {
+			ready <- [2]string{addr.String(), tokenPath}
+		})
+	}()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("ListenAndServe: %v", err)
+		}
+	})
+	listening := <-ready
+	addr, tokenPath := listening[0], listening[1]
+	info, err := os.Stat(tokenPath)
+	if err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("expected a token file only the owner can read, got %v, %v", info, err)
+	}
+	token, _ := os.ReadFile(tokenPath)
+
+	dial := func() *client {
+		netConn, err := net.Dial("tcp", addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { netConn.Close() })
+		return &client{t: t, in: NewReader(netConn), out: NewWriter(netConn)}
+	}
+
+	c := dial()
+	c.send(1, MethodExplain, Selection{Text: "x := 1"})
+	if msg := c.next(); msg["error"].(map[string]any)["code"] != float64(CodeUnauthorized) {
+		t.Fatalf("expected a request without the token to be refused, got %v", msg)
+	}
+	if _, err := c.in.Read(); err != io.EOF {
+		t.Fatalf("expected the connection to be closed, got %v", err)
+	}
+
+	c = dial()
+	c.send(1, MethodInitialize, InitializeParams{Token: "wrong"})
+	if msg := c.next(); msg["error"] == nil {
+		t.Fatalf("expected a wrong token to be refused, got %v", msg)
+	}
+
+	c = dial()
+	c.send(1, MethodInitialize, InitializeParams{Token: strings.TrimSpace(string(token))})
+	if msg := c.next(); msg["result"].(map[string]any)["workspace"] != root {
+		t.Fatalf("expected the token to be accepted, got %v", msg)
+	}
+}
@@ -0,0 +1,216 @@
+// Package execplan implements editable execution plans for the /plan command:
+// the agent drafts ordered steps with dependencies, the user reorders or
+// removes them, and the approved plan is executed one step at a time.
+package execplan
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// StepStatus mirrors the todo statuses so plan progress renders like todos.
+type StepStatus string
+
+const (
+	StepPending    StepStatus = "pending"
+	StepInProgress StepStatus = "in_progress"
+	StepCompleted  StepStatus = "completed"
+	StepFailed     StepStatus = "failed"
+	StepCancelled  StepStatus = "cancelled" // skipped because a dependency did not complete
+)
+
+// Step is one unit of work in a plan.
+type Step struct {
+	ID          string     `json:"id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description,omitempty"`
+	DependsOn   []string   `json:"depends_on,omitempty"`
+	Status      StepStatus `json:"status,omitempty"`
+	Error       string     `json:"error,omitempty"`
+}
+
+// ExecutionPlan is an ordered list of steps toward a goal. Steps run in list
+// order, so every dependency must appear before the step that needs it.
+type ExecutionPlan struct {
+	Goal  string `json:"goal"`
+	Steps []Step `json:"steps"`
+}
+
+// ParsePlan extracts a plan from a model reply. The reply may wrap the JSON
+// object in prose or a code fence. Steps without an ID are numbered.
+func ParsePlan(goal, reply string) (*ExecutionPlan, error) {
+	start := strings.Index(reply, "{")
+	end := strings.LastIndex(reply, "}")
+	if start < 0 || end <= start {
+		return nil, errors.New("plan reply does not contain a JSON object")
+	}
+
+	var plan ExecutionPlan
+	if err := json.Unmarshal([]byte(reply[start:end+1]), &plan); err != nil {
+		return nil, fmt.Errorf("failed to parse plan: %w", err)
+	}
+	if strings.TrimSpace(plan.Goal) == "" {
+		plan.Goal = goal
+	}
+
+	steps := plan.Steps[:0]
+	for _, step := range plan.Steps {
+		step.Title = strings.TrimSpace(step.Title)
+		if step.Title == "" {
+			continue
+		}
+		step.ID = strings.TrimSpace(step.ID)
+		step.Status = StepPending
+		step.Error = ""
+		steps = append(steps, step)
+	}
+	plan.Steps = steps
+	for i := range plan.Steps {
+		if plan.Steps[i].ID == "" {
+			plan.Steps[i].ID = strconv.Itoa(i + 1)
+		}
+	}
+
+	if len(plan.Steps) == 0 {
+		return nil, errors.New("plan has no steps")
+	}
+	if err := plan.Validate(); err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// Validate checks that step IDs are unique and that every dependency names an
+// earlier step.
+func (p *ExecutionPlan) Validate() error {
+	position := make(map[string]int, len(p.Steps))
+	for i, step := range p.Steps {
+		if _, dup := position[step.ID]; dup {
+			return fmt.Errorf("duplicate step id %q", step.ID)
+		}
+		position[step.ID] = i
+	}
+	for i, step := range p.Steps {
+		for _, dep := range step.DependsOn {
+			at, ok := position[dep]
+			switch {
+			case !ok:
+				return fmt.Errorf("step %d (%s) depends on unknown step %q", i+1, step.Title, dep)
+			case at >= i:
+				return fmt.Errorf("step %d (%s) must come after step %d (%s) it depends on", i+1, step.Title, at+1, p.Steps[at].Title)
+			}
+		}
+	}
+	return nil
+}
+
+// Move relocates the step at position from to position to (both 1-based).
+// The move is rejected if it would place a step before one of its
+// dependencies.
+func (p *ExecutionPlan) Move(from, to int) error {
+	if err := p.checkPosition(from); err != nil {
+		return err
+	}
+	if err := p.checkPosition(to); err != nil {
+		return err
+	}
+	original := append([]Step(nil), p.Steps...)
+	step := p.Steps[from-1]
+	rest := append(append([]Step(nil), p.Steps[:from-1]...), p.Steps[from:]...)
+	p.Steps = append(append(append([]Step(nil), rest[:to-1]...), step), rest[to-1:]...)
+	if err := p.Validate(); err != nil {
+		p.Steps = original
+		return err
+	}
+	return nil
+}
+
+// Remove deletes the step at position n (1-based) and drops it from the
+// dependencies of the remaining steps.
+func (p *ExecutionPlan) Remove(n int) error {
+	if err := p.checkPosition(n); err != nil {
+		return err
+	}
+	removed := p.Steps[n-1].ID
+	p.Steps = append(p.Steps[:n-1], p.Steps[n:]...)
+	for i := range p.Steps {
+		deps := p.Steps[i].DependsOn[:0]
+		for _, dep := range p.Steps[i].DependsOn {
+			if dep != removed {
+				deps = append(deps, dep)
+			}
+		}
+		p.Steps[i].DependsOn = deps
+	}
+	return nil
+}
+
+func (p *ExecutionPlan) checkPosition(n int) error {
+	if n < 1 || n > len(p.Steps) {
+		return fmt.Errorf("step %d does not exist (plan has %d steps)", n, len(p.Steps))
+	}
+	return nil
+}
+
+// Counts returns how many steps completed, failed and were cancelled.
+func (p *ExecutionPlan) Counts() (completed, failed, cancelled int) {
+	for _, step := range p.Steps {
+		switch step.Status {
+		case StepCompleted:
+			completed++
+		case StepFailed:
+			failed++
+		case StepCancelled:
+			cancelled++
+		}
+	}
+	return completed, failed, cancelled
+}
+
+// Render formats the plan for the console, one numbered line per step with
+// its status and dependencies.
+func Render(p *ExecutionPlan) string {
+	position := make(map[string]int, len(p.Steps))
+	for i, step := range p.Steps {
+		position[step.ID] = i + 1
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Plan: %s\n", p.Goal)
+	for i, step := range p.Steps {
+		fmt.Fprintf(&sb, "  %2d. %s %s", i+1, statusSymbol(step.Status), step.Title)
+		if len(step.DependsOn) > 0 {
+			deps := make([]string, 0, len(step.DependsOn))
+			for _, dep := range step.DependsOn {
+				deps = append(deps, strconv.Itoa(position[dep]))
+			}
+			fmt.Fprintf(&sb, "  (after %s)", strings.Join(deps, ", "))
+		}
+		sb.WriteString("\n")
+		if desc := strings.TrimSpace(step.Description); desc != "" {
+			fmt.Fprintf(&sb, "      %s\n", desc)
+		}
+		if step.Error != "" {
+			fmt.Fprintf(&sb, "      error: %s\n", step.Error)
+		}
+	}
+	return sb.String()
+}
+
+func statusSymbol(status StepStatus) string {
+	switch status {
+	case StepInProgress:
+		return "[~]"
+	case StepCompleted:
+		return "[x]"
+	case StepFailed:
+		return "[!]"
+	case StepCancelled:
+		return "[-]"
+	default:
+		return "[ ]"
+	}
+}
@@ -0,0 +1,184 @@
+package execplan
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const samplePlanReply = "Here is the plan:\n```json\n" + `{"steps": [
+  {"id": "1", "title": "Add config field"},
+  {"id": "2", "title": "Wire handler", "depends_on": ["1"]},
+  {"title": "Write docs"},
+  {"id": "4", "title": "Add tests", "depends_on": ["2"]}
+]}` + "\n```"
+
+func mustParse(t *testing.T) *ExecutionPlan {
+	t.Helper()
+	plan, err := ParsePlan("ship the feature", samplePlanReply)
+	if err != nil {
+		t.Fatalf("ParsePlan: %v", err)
+	}
+	return plan
+}
+
+func titles(plan *ExecutionPlan) string {
+	var out []string
+	for _, step := range plan.Steps {
+		out = append(out, step.Title)
+	}
+	return strings.Join(out, "|")
+}
+
+func TestParsePlan(t *testing.T) {
+	plan := mustParse(t)
+	if plan.Goal != "ship the feature" || len(plan.Steps) != 4 {
+		t.Fatalf("unexpected plan: %+v", plan)
+	}
+	if plan.Steps[2].ID != "3" {
+		t.Fatalf("expected missing id to be numbered, got %q", plan.Steps[2].ID)
+	}
+	for _, step := range plan.Steps {
+		if step.Status != StepPending {
+			t.Fatalf("expected pending status, got %q", step.Status)
+		}
+	}
+
+	if _, err := ParsePlan("g", `{"steps": [{"id": "1", "title": "a", "depends_on": ["9"]}]}`); err == nil {
+		t.Fatal("expected unknown dependency to be rejected")
+	}
+	if _, err := ParsePlan("g", "no json here"); err == nil {
+		t.Fatal("expected error for reply without JSON")
+	}
+	if _, err := ParsePlan("g", `{"steps": []}`); err == nil {
+		t.Fatal("expected error for empty plan")
+	}
+}
+
+func TestMoveKeepsDependenciesOrdered(t *testing.T) {
+	plan := mustParse(t)
+
+	if err := plan.Move(3, 1); err != nil {
+		t.Fatalf("Move: %v", err)
+	}
+	if got := titles(plan); got != "Write docs|Add config field|Wire handler|Add tests" {
+		t.Fatalf("unexpected order after move: %s", got)
+	}
+
+	// "Wire handler" depends on "Add config field", so it cannot go first.
+	if err := plan.Move(3, 1); err == nil {
+		t.Fatal("expected move before a dependency to be rejected")
+	}
+	if got := titles(plan); got != "Write docs|Add config field|Wire handler|Add tests" {
+		t.Fatalf("rejected move changed the plan: %s", got)
+	}
+
+	if err := plan.Move(9, 1); err == nil {
+		t.Fatal("expected out-of-range move to be rejected")
+	}
+}
+
+func TestRemoveDropsDependencies(t *testing.T) {
+	plan := mustParse(t)
+	if err := plan.Remove(1); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if len(plan.Steps) != 3 || len(plan.Steps[0].DependsOn) != 0 {
+		t.Fatalf("expected dependency on removed step to be dropped: %+v", plan.Steps)
+	}
+	if err := plan.Validate(); err != nil {
+		t.Fatalf("plan invalid after remove: %v", err)
+	}
+}
+
+func TestRenderShowsDependencyPositions(t *testing.T) {
+	plan := mustParse(t)
+	if err := plan.Move(3, 1); err != nil {
+		t.Fatal(err)
+	}
+	out := Render(plan)
+	if !strings.Contains(out, " 3. [ ] Wire handler  (after 2)") {
+		t.Fatalf("expected dependency rendered by position, got:\n%s", out)
+	}
+	if !strings.Contains(out, " 4. [ ] Add tests  (after 3)") {
+		t.Fatalf("expected dependency rendered by position, got:\n%s", out)
+	}
+}
+
+func TestExecutePlanCancelsDependentsOfFailedSteps(t *testing.T) {
+	plan := mustParse(t)
+	var prompts []string
+	var progress []string
+	runner := &Runner{
+		Execute: func(prompt string) error {
+			prompts = append(prompts, prompt)
+			if strings.Contains(prompt, "Carry out step 2 only") {
+				return errors.New("build failed")
+			}
+			return nil
+		},
+		OnProgress: func(p Progress) {
+			progress = append(progress, p.Step.Title+":"+string(p.Step.Status))
+		},
+	}
+
+	if err := runner.ExecutePlan(context.Background(), plan); err != nil {
+		t.Fatalf("ExecutePlan: %v", err)
+	}
+
+	want := []StepStatus{StepCompleted, StepFailed, StepCompleted, StepCancelled}
+	for i, status := range want {
+		if plan.Steps[i].Status != status {
+			t.Fatalf("step %d: expected %s, got %s", i+1, status, plan.Steps[i].Status)
+		}
+	}
+	if len(prompts) != 3 {
+		t.Fatalf("expected cancelled step not to run, got %d executions", len(prompts))
+	}
+	if !strings.Contains(prompts[0], "Plan: ship the feature") {
+		t.Fatalf("expected step prompt to include the plan, got:\n%s", prompts[0])
+	}
+	if progress[0] != "Add config field:in_progress" || progress[len(progress)-1] != "Add tests:cancelled" {
+		t.Fatalf("unexpected progress sequence: %v", progress)
+	}
+	if completed, failed, cancelled := plan.Counts(); completed != 2 || failed != 1 || cancelled != 1 {
+		t.Fatalf("unexpected counts: %d %d %d", completed, failed, cancelled)
+	}
+}
+
+func TestExecutePlanStopsWhenCancelled(t *testing.T) {
+	plan := mustParse(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	runs := 0
+	runner := &Runner{Execute: func(string) error {
+		runs++
+		cancel()
+		return nil
+	}}
+
+	if err := runner.ExecutePlan(ctx, plan); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if runs != 1 {
+		t.Fatalf("expected execution to stop after cancellation, got %d runs", runs)
+	}
+}
+
+func TestCreatePlanUsesGenerator(t *testing.T) {
+	var prompt string
+	runner := &Runner{Generate: func(p string) (string, error) {
+		prompt = p
+		return samplePlanReply, nil
+	}}
+	plan, err := runner.CreatePlan("  ship the feature ")
+	if err != nil {
+		t.Fatalf("CreatePlan: %v", err)
+	}
+	if !strings.Contains(prompt, "ship the feature") || len(plan.Steps) != 4 {
+		t.Fatalf("unexpected plan %+v for prompt %q", plan, prompt)
+	}
+	if _, err := runner.CreatePlan(" "); err == nil {
+		t.Fatal("expected empty goal to be rejected")
+	}
+}
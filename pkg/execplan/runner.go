@@ -0,0 +1,215 @@
+package execplan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/alantheprice/ledit/pkg/agent"
+	api "github.com/alantheprice/ledit/pkg/agent_api"
+	"github.com/alantheprice/ledit/pkg/factory"
+)
+
+const planSystemPrompt = "You are a software planning assistant. You break a goal into a short ordered list of concrete implementation steps. Reply with JSON only."
+
+// planResponseFormat is the reply BuildPlanPrompt asks for, enforced by
+// providers that support structured outputs.
+var planResponseFormat = api.ResponseFormat{
+	Name: "execution_plan",
+	Schema: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"steps": map[string]interface{}{
+				"type": "array",
+				"items": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"id":          map[string]interface{}{"type": "string"},
+						"title":       map[string]interface{}{"type": "string"},
+						"description": map[string]interface{}{"type": "string"},
+						"depends_on": map[string]interface{}{
+							"type":  "array",
+							"items": map[string]interface{}{"type": "string"},
+						},
+					},
+					"required": []string{"id", "title", "description", "depends_on"},
+				},
+			},
+		},
+		"required": []string{"steps"},
+	},
+}
+
+// Progress reports the step about to run (or just finished) during execution.
+type Progress struct {
+	Index int // 1-based position of Step in the plan
+	Total int
+	Step  Step
+	Plan  *ExecutionPlan
+}
+
+// Runner creates and executes plans. The hooks make it independent of the
+// agent so it can be exercised directly in tests.
+type Runner struct {
+	// Generate sends a planning prompt to the model without tools and
+	// returns its reply.
+	Generate func(prompt string) (string, error)
+	// Execute asks the agent to carry out one step.
+	Execute func(prompt string) error
+	// OnProgress, when set, is called when a step starts and when it ends.
+	OnProgress func(Progress)
+	// Budget, when set, is checked before each step. Once it returns an
+	// error, that step and every later step are cancelled.
+	Budget func() error
+}
+
+// NewAgentRunner wires a Runner to chatAgent: plans are drafted with a
+// tool-less request to the agent's provider and model, and steps run as
+// regular agent queries.
+func NewAgentRunner(chatAgent *agent.Agent) (*Runner, error) {
+	if chatAgent == nil {
+		return nil, errors.New("plan requires an active agent")
+	}
+	return &Runner{
+		Generate: func(prompt string) (string, error) {
+			client, err := planningClient(chatAgent)
+			if err != nil {
+				return "", err
+			}
+			messages := []api.Message{
+				{Role: "system", Content: planSystemPrompt},
+				{Role: "user", Content: prompt},
+			}
+			var resp *api.ChatResponse
+			if structured, ok := client.(interface {
+				SendStructuredRequest([]api.Message, api.ResponseFormat) (*api.ChatResponse, error)
+			}); ok {
+				resp, err = structured.SendStructuredRequest(messages, planResponseFormat)
+			} else {
+				resp, err = client.SendChatRequest(messages, nil, "", false)
+			}
+			if err != nil {
+				return "", err
+			}
+			if len(resp.Choices) == 0 {
+				return "", errors.New("model returned no plan")
+			}
+			return resp.Choices[0].Message.Content, nil
+		},
+		Execute: func(prompt string) error {
+			_, err := chatAgent.ProcessQuery(prompt)
+			return err
+		},
+	}, nil
+}
+
+func planningClient(chatAgent *agent.Agent) (api.ClientInterface, error) {
+	configManager := chatAgent.GetConfigManager()
+	if configManager == nil {
+		return nil, errors.New("agent configuration is not available")
+	}
+	clientType, err := configManager.MapStringToClientType(chatAgent.GetProvider())
+	if err != nil {
+		return nil, err
+	}
+	return factory.CreateProviderClient(clientType, chatAgent.GetModel())
+}
+
+// CreatePlan drafts a plan for goal.
+func (r *Runner) CreatePlan(goal string) (*ExecutionPlan, error) {
+	goal = strings.TrimSpace(goal)
+	if goal == "" {
+		return nil, errors.New("plan goal is empty")
+	}
+	reply, err := r.Generate(BuildPlanPrompt(goal))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate plan: %w", err)
+	}
+	return ParsePlan(goal, reply)
+}
+
+// ExecutePlan runs the steps in order. A step whose dependency did not
+// complete is cancelled instead of run; a failed step does not stop
+// independent steps. A step is also cancelled once the Budget hook reports
+// the budget is spent. It returns early only when ctx is cancelled.
+func (r *Runner) ExecutePlan(ctx context.Context, plan *ExecutionPlan) error {
+	if err := plan.Validate(); err != nil {
+		return err
+	}
+	status := make(map[string]StepStatus, len(plan.Steps))
+	var budgetErr error
+	for i := range plan.Steps {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		step := &plan.Steps[i]
+		if budgetErr == nil && r.Budget != nil {
+			budgetErr = r.Budget()
+		}
+
+		if budgetErr != nil {
+			step.Status = StepCancelled
+			step.Error = budgetErr.Error()
+		} else if blocker := firstIncompleteDependency(step, status); blocker != "" {
+			step.Status = StepCancelled
+			step.Error = fmt.Sprintf("dependency %q did not complete", blocker)
+		} else {
+			step.Status = StepInProgress
+			r.report(plan, i)
+			if err := r.Execute(BuildStepPrompt(plan, i)); err != nil {
+				step.Status = StepFailed
+				step.Error = err.Error()
+			} else {
+				step.Status = StepCompleted
+			}
+		}
+		status[step.ID] = step.Status
+		r.report(plan, i)
+	}
+	return nil
+}
+
+func firstIncompleteDependency(step *Step, status map[string]StepStatus) string {
+	for _, dep := range step.DependsOn {
+		if status[dep] != StepCompleted {
+			return dep
+		}
+	}
+	return ""
+}
+
+func (r *Runner) report(plan *ExecutionPlan, i int) {
+	if r.OnProgress != nil {
+		r.OnProgress(Progress{Index: i + 1, Total: len(plan.Steps), Step: plan.Steps[i], Plan: plan})
+	}
+}
+
+// BuildPlanPrompt asks for a plan as a JSON object.
+func BuildPlanPrompt(goal string) string {
+	return fmt.Sprintf(`Create an execution plan for this goal:
+
+%s
+
+Respond with a single JSON object and nothing else:
+{"steps": [{"id": "1", "title": "short imperative title", "description": "what to change and how to verify it", "depends_on": []}]}
+
+Rules:
+- Use 3 to 10 steps, each small enough for one focused agent turn
+- List steps in execution order; depends_on may only name earlier step ids
+- Only add a dependency when a step truly needs another step's result`, goal)
+}
+
+// BuildStepPrompt asks the agent to carry out step i with the whole plan as
+// context.
+func BuildStepPrompt(plan *ExecutionPlan, i int) string {
+	step := plan.Steps[i]
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "We are executing an approved plan step by step.\n\n%s\n", Render(plan))
+	fmt.Fprintf(&sb, "Carry out step %d only: %s\n", i+1, step.Title)
+	if desc := strings.TrimSpace(step.Description); desc != "" {
+		fmt.Fprintf(&sb, "\n%s\n", desc)
+	}
+	sb.WriteString("\nDo not start later steps. Verify your change, then briefly report what you did.")
+	return sb.String()
+}
@@ -0,0 +1,248 @@
+package execplan
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TemplateDir is where `ledit run-template <name>` looks for templates that
+// are not given as a path.
+var TemplateDir = filepath.Join(".ledit", "templates")
+
+// Template is a reusable task description, typically committed with the code
+// it applies to (e.g. .ledit/templates/add-endpoint.yaml):
+//
+//	name: add-endpoint
+//	goal: Add a {{method}} {{route}} endpoint backed by {{handler}}
+//	parameters:
+//	  - name: method
+//	    default: GET
+//	  - name: route
+//	    required: true
+//	  - name: handler
+//	    required: true
+//	constraints:
+//	  - Follow the existing handler registration in internal/api/routes.go
+//	paths: [internal/api/]
+//	validation: go test ./internal/api/...
+//	budget:
+//	  max_steps: 6
+//	  max_iterations: 40
+//	  max_cost: 0.50
+//
+// Goal, constraints, paths and validation may reference parameters as
+// {{name}}. The agent expands the template into an ExecutionPlan.
+type Template struct {
+	Name        string              `yaml:"name"`
+	Description string              `yaml:"description"`
+	Goal        string              `yaml:"goal"`
+	Parameters  []TemplateParameter `yaml:"parameters"`
+	Constraints []string            `yaml:"constraints"`
+	Paths       []string            `yaml:"paths"`
+	Validation  string              `yaml:"validation"`
+	Budget      TemplateBudget      `yaml:"budget"`
+}
+
+// TemplateParameter is a value supplied when the template is run.
+type TemplateParameter struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	Default     string `yaml:"default"`
+	Required    bool   `yaml:"required"`
+}
+
+// TemplateBudget limits a template run. Zero values mean no limit.
+type TemplateBudget struct {
+	MaxSteps      int     `yaml:"max_steps"`      // steps the drafted plan may have
+	MaxIterations int     `yaml:"max_iterations"` // agent iterations per step
+	MaxCost       float64 `yaml:"max_cost"`       // USD; remaining steps are cancelled once exceeded
+}
+
+var (
+	parameterNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+	placeholderPattern   = regexp.MustCompile(`\{\{\s*([^{}]*?)\s*\}\}`)
+)
+
+// ResolveTemplatePath returns nameOrPath if it is an existing file, otherwise
+// the matching .yaml or .yml file in the workspace's TemplateDir.
+func ResolveTemplatePath(workspaceRoot, nameOrPath string) (string, error) {
+	if info, err := os.Stat(nameOrPath); err == nil && info.Mode().IsRegular() {
+		return nameOrPath, nil
+	}
+	base := filepath.Join(workspaceRoot, TemplateDir, nameOrPath)
+	for _, candidate := range []string{base, base + ".yaml", base + ".yml"} {
+		if info, err := os.Stat(candidate); err == nil && info.Mode().IsRegular() {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("template %q not found (looked for a file and in %s)", nameOrPath, TemplateDir)
+}
+
+// LoadTemplate reads and parses the template at path.
+func LoadTemplate(path string) (*Template, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	tmpl, err := ParseTemplate(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if tmpl.Name == "" {
+		tmpl.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return tmpl, nil
+}
+
+// ParseTemplate parses template YAML and checks that it has a goal, that
+// parameter names are valid and unique, and that the budget is not negative.
+func ParseTemplate(data []byte) (*Template, error) {
+	var tmpl Template
+	if err := yaml.Unmarshal(data, &tmpl); err != nil {
+		return nil, err
+	}
+	tmpl.Name = strings.TrimSpace(tmpl.Name)
+	if strings.TrimSpace(tmpl.Goal) == "" {
+		return nil, errors.New("template has no goal")
+	}
+
+	seen := make(map[string]bool, len(tmpl.Parameters))
+	for i, param := range tmpl.Parameters {
+		if !parameterNamePattern.MatchString(param.Name) {
+			return nil, fmt.Errorf("parameter %d: invalid name %q", i+1, param.Name)
+		}
+		if seen[param.Name] {
+			return nil, fmt.Errorf("duplicate parameter %q", param.Name)
+		}
+		seen[param.Name] = true
+	}
+
+	if tmpl.Budget.MaxSteps < 0 || tmpl.Budget.MaxIterations < 0 || tmpl.Budget.MaxCost < 0 {
+		return nil, errors.New("budget values must not be negative")
+	}
+	return &tmpl, nil
+}
+
+// ParseParameterArgs parses key=value arguments.
+func ParseParameterArgs(args []string) (map[string]string, error) {
+	values := make(map[string]string, len(args))
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid parameter %q: expected key=value", arg)
+		}
+		values[key] = value
+	}
+	return values, nil
+}
+
+// Expand returns a copy of the template with {{name}} placeholders replaced
+// by values, falling back to parameter defaults. It fails on unknown values,
+// missing required parameters and placeholders naming undeclared parameters.
+func (t *Template) Expand(values map[string]string) (*Template, error) {
+	resolved := make(map[string]string, len(t.Parameters))
+	declared := make(map[string]bool, len(t.Parameters))
+	var missing []string
+	for _, param := range t.Parameters {
+		declared[param.Name] = true
+		value, ok := values[param.Name]
+		if !ok || value == "" {
+			value = param.Default
+		}
+		if value == "" && param.Required {
+			missing = append(missing, param.Name)
+		}
+		resolved[param.Name] = value
+	}
+
+	var unknown []string
+	for name := range values {
+		if !declared[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unknown parameter(s): %s", strings.Join(unknown, ", "))
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing required parameter(s): %s", strings.Join(missing, ", "))
+	}
+
+	var undeclared []string
+	substitute := func(text string) string {
+		return placeholderPattern.ReplaceAllStringFunc(text, func(match string) string {
+			name := placeholderPattern.FindStringSubmatch(match)[1]
+			if !declared[name] {
+				undeclared = append(undeclared, name)
+				return match
+			}
+			return resolved[name]
+		})
+	}
+
+	expanded := *t
+	expanded.Goal = strings.TrimSpace(substitute(t.Goal))
+	expanded.Validation = strings.TrimSpace(substitute(t.Validation))
+	expanded.Constraints = make([]string, 0, len(t.Constraints))
+	for _, constraint := range t.Constraints {
+		if constraint = strings.TrimSpace(substitute(constraint)); constraint != "" {
+			expanded.Constraints = append(expanded.Constraints, constraint)
+		}
+	}
+	expanded.Paths = make([]string, 0, len(t.Paths))
+	for _, path := range t.Paths {
+		if path = strings.TrimSpace(substitute(path)); path != "" {
+			expanded.Paths = append(expanded.Paths, path)
+		}
+	}
+	if len(undeclared) > 0 {
+		return nil, fmt.Errorf("template references undeclared parameter(s): %s", strings.Join(undeclared, ", "))
+	}
+	return &expanded, nil
+}
+
+// PlanGoal is the goal given to the planner: the template goal followed by
+// its constraints, target paths, validation command and step budget. It
+// becomes the plan's goal, so every step prompt carries it too.
+func (t *Template) PlanGoal() string {
+	var sb strings.Builder
+	sb.WriteString(strings.TrimSpace(t.Goal))
+	if len(t.Constraints) > 0 {
+		sb.WriteString("\n\nConstraints:")
+		for _, constraint := range t.Constraints {
+			fmt.Fprintf(&sb, "\n- %s", constraint)
+		}
+	}
+	if len(t.Paths) > 0 {
+		fmt.Fprintf(&sb, "\n\nOnly change files under: %s", strings.Join(t.Paths, ", "))
+	}
+	if t.Validation != "" {
+		fmt.Fprintf(&sb, "\n\nValidate changes with: %s", t.Validation)
+	}
+	if t.Budget.MaxSteps > 0 {
+		fmt.Fprintf(&sb, "\n\nUse at most %d steps.", t.Budget.MaxSteps)
+	}
+	return sb.String()
+}
+
+// CreateTemplatePlan drafts a plan for an expanded template and enforces its
+// step budget.
+func (r *Runner) CreateTemplatePlan(t *Template) (*ExecutionPlan, error) {
+	plan, err := r.CreatePlan(t.PlanGoal())
+	if err != nil {
+		return nil, err
+	}
+	if max := t.Budget.MaxSteps; max > 0 && len(plan.Steps) > max {
+		return nil, fmt.Errorf("drafted plan has %d steps, over the template budget of %d", len(plan.Steps), max)
+	}
+	return plan, nil
+}
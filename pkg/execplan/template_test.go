@@ -0,0 +1,164 @@
+package execplan
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const sampleTemplate = `name: add-endpoint
+goal: Add a {{method}} {{ route }} endpoint backed by {{handler}}
+parameters:
+  - name: method
+    default: GET
+  - name: route
+    required: true
+  - name: handler
+    required: true
+constraints:
+  - Register {{route}} next to the existing routes
+paths: [internal/api/]
+validation: go test ./internal/api/...
+budget:
+  max_steps: 3
+  max_iterations: 40
+  max_cost: 0.5
+`
+
+func mustParseTemplate(t *testing.T) *Template {
+	t.Helper()
+	tmpl, err := ParseTemplate([]byte(sampleTemplate))
+	if err != nil {
+		t.Fatalf("ParseTemplate: %v", err)
+	}
+	return tmpl
+}
+
+func TestTemplateExpand(t *testing.T) {
+	tmpl := mustParseTemplate(t)
+	expanded, err := tmpl.Expand(map[string]string{"route": "/users", "handler": "ListUsers"})
+	if err != nil {
+		t.Fatalf("Expand: %v", err)
+	}
+	if expanded.Goal != "Add a GET /users endpoint backed by ListUsers" {
+		t.Fatalf("unexpected goal %q", expanded.Goal)
+	}
+	if expanded.Constraints[0] != "Register /users next to the existing routes" {
+		t.Fatalf("unexpected constraints %v", expanded.Constraints)
+	}
+	if !strings.Contains(tmpl.Goal, "{{method}}") {
+		t.Fatal("expected Expand to leave the original template unchanged")
+	}
+
+	goal := expanded.PlanGoal()
+	for _, want := range []string{"Constraints:\n- Register /users", "Only change files under: internal/api/", "Validate changes with: go test ./internal/api/...", "at most 3 steps"} {
+		if !strings.Contains(goal, want) {
+			t.Fatalf("expected plan goal to contain %q, got:\n%s", want, goal)
+		}
+	}
+}
+
+func TestTemplateExpandRejectsBadParameters(t *testing.T) {
+	tmpl := mustParseTemplate(t)
+	cases := map[string]map[string]string{
+		"missing required parameter(s): handler": {"route": "/users"},
+		"unknown parameter(s): verb":             {"route": "/users", "handler": "h", "verb": "POST"},
+	}
+	for want, values := range cases {
+		if _, err := tmpl.Expand(values); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected error containing %q, got %v", want, err)
+		}
+	}
+
+	tmpl.Goal = "Rename {{old}}"
+	if _, err := tmpl.Expand(map[string]string{"route": "/users", "handler": "h"}); err == nil || !strings.Contains(err.Error(), "undeclared parameter(s): old") {
+		t.Fatalf("expected undeclared placeholder error, got %v", err)
+	}
+}
+
+func TestParseTemplateValidation(t *testing.T) {
+	for _, data := range []string{
+		"name: empty\n",
+		"goal: x\nparameters:\n  - name: a\n  - name: a\n",
+		"goal: x\nparameters:\n  - name: 'bad name'\n",
+		"goal: x\nbudget:\n  max_steps: -1\n",
+	} {
+		if _, err := ParseTemplate([]byte(data)); err == nil {
+			t.Fatalf("expected %q to be rejected", data)
+		}
+	}
+}
+
+func TestResolveTemplatePath(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, TemplateDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "add-endpoint.yaml")
+	if err := os.WriteFile(path, []byte(sampleTemplate), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	resolved, err := ResolveTemplatePath(root, "add-endpoint")
+	if err != nil || resolved != path {
+		t.Fatalf("expected %s, got %s (%v)", path, resolved, err)
+	}
+	if _, err := ResolveTemplatePath(root, "missing"); err == nil {
+		t.Fatal("expected missing template to be reported")
+	}
+	tmpl, err := LoadTemplate(resolved)
+	if err != nil || tmpl.Name != "add-endpoint" {
+		t.Fatalf("LoadTemplate: %+v, %v", tmpl, err)
+	}
+}
+
+func TestParseParameterArgs(t *testing.T) {
+	values, err := ParseParameterArgs([]string{"route=/users", "query=a=b"})
+	if err != nil || values["route"] != "/users" || values["query"] != "a=b" {
+		t.Fatalf("unexpected values %v (%v)", values, err)
+	}
+	if _, err := ParseParameterArgs([]string{"route"}); err == nil {
+		t.Fatal("expected argument without '=' to be rejected")
+	}
+}
+
+func TestCreateTemplatePlanEnforcesStepBudget(t *testing.T) {
+	tmpl := mustParseTemplate(t)
+	runner := &Runner{Generate: func(string) (string, error) { return samplePlanReply, nil }}
+	if _, err := runner.CreateTemplatePlan(tmpl); err == nil || !strings.Contains(err.Error(), "budget of 3") {
+		t.Fatalf("expected step budget error, got %v", err)
+	}
+	tmpl.Budget.MaxSteps = 4
+	if _, err := runner.CreateTemplatePlan(tmpl); err != nil {
+		t.Fatalf("CreateTemplatePlan: %v", err)
+	}
+}
+
+func TestExecutePlanCancelsStepsOverBudget(t *testing.T) {
+	plan := mustParse(t)
+	runs := 0
+	runner := &Runner{
+		Execute: func(string) error { runs++; return nil },
+		Budget: func() error {
+			if runs >= 2 {
+				return errors.New("cost budget reached")
+			}
+			return nil
+		},
+	}
+	if err := runner.ExecutePlan(context.Background(), plan); err != nil {
+		t.Fatalf("ExecutePlan: %v", err)
+	}
+	if runs != 2 {
+		t.Fatalf("expected 2 steps to run, got %d", runs)
+	}
+	for _, step := range plan.Steps[2:] {
+		if step.Status != StepCancelled || step.Error != "cost budget reached" {
+			t.Fatalf("expected step over budget to be cancelled, got %+v", step)
+		}
+	}
+}
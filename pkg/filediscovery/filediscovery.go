@@ -40,11 +40,12 @@ type FileResult struct {
 
 // WorkspaceInfo represents workspace information
 type WorkspaceInfo struct {
-	ProjectType string
-	AllFiles    []string
-	FilesByDir  map[string][]string
-	Error       error
-	RootDir     string
+	ProjectType   string
+	TestFramework string
+	AllFiles      []string
+	FilesByDir    map[string][]string
+	Error         error
+	RootDir       string
 }
 
 // DiscoverFilesRobust uses multiple strategies to find relevant files
@@ -549,11 +550,12 @@ func (fd *FileDiscovery) BuildWorkspaceStructure() *WorkspaceInfo {
 	}
 
 	return &WorkspaceInfo{
-		ProjectType: projectType,
-		AllFiles:    allFiles,
-		FilesByDir:  filesByDir,
-		Error:       err,
-		RootDir:     absRoot,
+		ProjectType:   projectType,
+		TestFramework: DetectTestFramework(absRoot),
+		AllFiles:      allFiles,
+		FilesByDir:    filesByDir,
+		Error:         err,
+		RootDir:       absRoot,
 	}
 }
 
@@ -584,6 +586,29 @@ func (fd *FileDiscovery) detectProjectType(files []string) string {
 	return "unknown"
 }
 
+// DetectProjectType reports the project type from the marker files at the
+// root of rootDir, without walking the tree. It uses the same names as
+// WorkspaceInfo.ProjectType and returns "unknown" when no marker is found.
+func DetectProjectType(rootDir string) string {
+	markers := []struct{ file, projectType string }{
+		{"go.mod", "go"},
+		{"Cargo.toml", "rust"},
+		{"package.json", "nodejs"},
+		{"pyproject.toml", "python"},
+		{"requirements.txt", "python"},
+		{"pom.xml", "java"},
+		{"build.gradle", "java"},
+		{"CMakeLists.txt", "c/c++"},
+		{"Makefile", "c/c++/make"},
+	}
+	for _, marker := range markers {
+		if _, err := os.Stat(filepath.Join(rootDir, marker.file)); err == nil {
+			return marker.projectType
+		}
+	}
+	return "unknown"
+}
+
 // GetFileStats returns statistics about files
 func (fd *FileDiscovery) GetFileStats(files []string) map[string]interface{} {
 	stats := map[string]interface{}{
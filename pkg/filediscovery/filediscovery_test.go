@@ -277,3 +277,48 @@ func TestDiscoverFilesRobust_DefaultOptions(t *testing.T) {
 		t.Fatal("expected non-nil result")
 	}
 }
+
+// --- DetectTestFramework tests ---
+
+func TestDetectTestFramework(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{"go module", map[string]string{"go.mod": "module x\n"}, TestFrameworkGo},
+		{"cargo", map[string]string{"Cargo.toml": "[package]\n"}, TestFrameworkCargo},
+		{"vitest dev dependency", map[string]string{"package.json": `{"devDependencies":{"vitest":"^1.0.0"}}`}, TestFrameworkVitest},
+		{"jest script", map[string]string{"package.json": `{"scripts":{"test":"jest --coverage"}}`}, TestFrameworkJest},
+		{"plain npm", map[string]string{"package.json": `{"scripts":{"test":"mocha"}}`}, TestFrameworkNPM},
+		{"pytest", map[string]string{"pyproject.toml": "[project]\n"}, TestFrameworkPytest},
+		{"maven", map[string]string{"pom.xml": "<project/>"}, TestFrameworkMaven},
+		{"unknown", map[string]string{"README.md": "# x"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := makeTree(t, tt.files)
+			if got := DetectTestFramework(root); got != tt.want {
+				t.Errorf("DetectTestFramework() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// --- DetectProjectType tests ---
+
+func TestDetectProjectType(t *testing.T) {
+	cases := []struct {
+		files map[string]string
+		want  string
+	}{
+		{map[string]string{"go.mod": "module x", "Makefile": "all:"}, "go"},
+		{map[string]string{"package.json": "{}", "README.md": "# x"}, "nodejs"},
+		{map[string]string{"README.md": "# x", "sub/go.mod": "module x"}, "unknown"},
+	}
+	for _, tc := range cases {
+		if got := DetectProjectType(makeTree(t, tc.files)); got != tc.want {
+			t.Errorf("DetectProjectType(%v) = %q, want %q", tc.files, got, tc.want)
+		}
+	}
+}
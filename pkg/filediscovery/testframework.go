@@ -0,0 +1,121 @@
+package filediscovery
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Test frameworks recognized by DetectTestFramework.
+const (
+	TestFrameworkGo     = "go"
+	TestFrameworkPytest = "pytest"
+	TestFrameworkJest   = "jest"
+	TestFrameworkVitest = "vitest"
+	TestFrameworkNPM    = "npm"
+	TestFrameworkCargo  = "cargo"
+	TestFrameworkMaven  = "maven"
+	TestFrameworkGradle = "gradle"
+)
+
+// DetectTestFramework inspects the project markers in rootDir and returns the
+// test framework the project uses, or "" when none is recognized.
+func DetectTestFramework(rootDir string) string {
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(rootDir, name))
+		return err == nil
+	}
+
+	switch {
+	case exists("go.mod"):
+		return TestFrameworkGo
+	case exists("Cargo.toml"):
+		return TestFrameworkCargo
+	case exists("package.json"):
+		return detectNodeTestFramework(filepath.Join(rootDir, "package.json"))
+	case exists("pytest.ini"), exists("conftest.py"), exists("tox.ini"),
+		exists("pyproject.toml"), exists("setup.py"), exists("requirements.txt"):
+		return TestFrameworkPytest
+	case exists("pom.xml"):
+		return TestFrameworkMaven
+	case exists("build.gradle"), exists("build.gradle.kts"):
+		return TestFrameworkGradle
+	}
+	return ""
+}
+
+// detectNodeTestFramework picks jest or vitest from package.json dependencies
+// and scripts, falling back to the generic npm test script.
+func detectNodeTestFramework(packageJSON string) string {
+	data, err := os.ReadFile(packageJSON)
+	if err != nil {
+		return TestFrameworkNPM
+	}
+	var pkg struct {
+		Scripts         map[string]string `json:"scripts"`
+		Dependencies    map[string]string `json:"dependencies"`
+		DevDependencies map[string]string `json:"devDependencies"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return TestFrameworkNPM
+	}
+
+	testScript := pkg.Scripts["test"]
+	for _, framework := range []string{TestFrameworkVitest, TestFrameworkJest} {
+		if _, ok := pkg.DevDependencies[framework]; ok {
+			return framework
+		}
+		if _, ok := pkg.Dependencies[framework]; ok {
+			return framework
+		}
+		if strings.Contains(testScript, framework) {
+			return framework
+		}
+	}
+	return TestFrameworkNPM
+}
+
+// TestCommandForFramework returns the shell command that runs a framework's
+// test suite, or "" for an unknown framework.
+func TestCommandForFramework(framework string) string {
+	switch framework {
+	case TestFrameworkGo:
+		return "go test ./..."
+	case TestFrameworkPytest:
+		return "python -m pytest -q"
+	case TestFrameworkJest:
+		return "npx jest --ci"
+	case TestFrameworkVitest:
+		return "npx vitest run"
+	case TestFrameworkNPM:
+		return "npm test"
+	case TestFrameworkCargo:
+		return "cargo test"
+	case TestFrameworkMaven:
+		return "mvn -q test"
+	case TestFrameworkGradle:
+		return "gradle test"
+	}
+	return ""
+}
+
+// BuildCommand returns the shell command that checks the project in rootDir
+// still builds, or "" when no build is recognized.
+func BuildCommand(rootDir string) string {
+	switch DetectTestFramework(rootDir) {
+	case TestFrameworkGo:
+		return "go build ./..."
+	case TestFrameworkCargo:
+		return "cargo check"
+	case TestFrameworkJest, TestFrameworkVitest, TestFrameworkNPM:
+		return "npm run build --if-present"
+	case TestFrameworkPytest:
+		return "python -m compileall -q ."
+	case TestFrameworkMaven:
+		return "mvn -q compile"
+	case TestFrameworkGradle:
+		return "gradle compileJava"
+	}
+	return ""
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"unicode/utf16"
 	"unicode/utf8"
@@ -228,13 +229,7 @@ func SafeResolvePathWithBypass(ctx context.Context, filePath string) (string, er
 	}
 
 	// Check if the resolved path is within the resolved working directory
-	relPath, err := filepath.Rel(resolvedCwd, resolvedAbs)
-	if err != nil {
-		return "", fmt.Errorf("failed to determine relative path: %w", err)
-	}
-
-	// If the relative path starts with "..", it's outside the working directory
-	if strings.HasPrefix(relPath, "..") {
+	if !isWithinDir(resolvedCwd, resolvedAbs) {
 		if SecurityBypassEnabled(ctx) {
 			// Security bypass enabled - allow access outside working directory
 			return resolvedAbs, nil
@@ -246,19 +241,26 @@ func SafeResolvePathWithBypass(ctx context.Context, filePath string) (string, er
 	return resolvedAbs, nil
 }
 
-// isInTmpPath checks if a path is within /tmp
+// isInTmpPath checks if a path is within /tmp, or on Windows the user's
+// temp directory. A directory merely named temp elsewhere doesn't count.
 func isInTmpPath(path string) bool {
 	// Check for /tmp paths
 	cleanPath := filepath.Clean(path)
 	if strings.HasPrefix(cleanPath, "/tmp/") || cleanPath == "/tmp" {
 		return true
 	}
-	// Also check for Windows-style temp paths
-	lowerPath := strings.ToLower(cleanPath)
-	if strings.Contains(lowerPath, "\\temp\\") || strings.Contains(lowerPath, "\\tmp\\") {
-		return true
+	return runtime.GOOS == "windows" && isWithinDir(os.TempDir(), cleanPath)
+}
+
+// isWithinDir reports whether path is dir or inside it. Both must be
+// absolute and cleaned. On Windows the comparison ignores case, and a path
+// on another drive is outside.
+func isWithinDir(dir, path string) bool {
+	relPath, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
 	}
-	return false
+	return relPath != ".." && !strings.HasPrefix(relPath, ".."+string(filepath.Separator))
 }
 
 // SafeResolvePathForWrite validates a file path for writing, checking that the
@@ -351,13 +353,7 @@ func SafeResolvePathForWriteWithBypass(ctx context.Context, filePath string) (st
 	}
 
 	// Check if the resolved parent directory is within the resolved working directory
-	relPath, err := filepath.Rel(resolvedCwd, resolvedParent)
-	if err != nil {
-		return "", fmt.Errorf("failed to determine relative path: %w", err)
-	}
-
-	// If the relative path starts with "..", it's outside the working directory
-	if strings.HasPrefix(relPath, "..") {
+	if !isWithinDir(resolvedCwd, resolvedParent) {
 		if SecurityBypassEnabled(ctx) {
 			// Security bypass enabled - allow writing outside working directory
 			return absPath, nil
@@ -0,0 +1,336 @@
+package filesystem
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+const (
+	// lockWaitTimeout is how long Lock waits for another writer of the same
+	// path. Writes hold the lock only while touching the file.
+	lockWaitTimeout = 30 * time.Second
+	// lockStaleAfter is the age after which a lock is assumed to belong to a
+	// process that died while holding it.
+	lockStaleAfter   = time.Minute
+	lockPollInterval = 20 * time.Millisecond
+
+	// maxSeenContentBytes caps the content kept per file for conflict diffs.
+	maxSeenContentBytes = 512 * 1024
+	// maxConflictDiffLines caps the diff shown in a write conflict.
+	maxConflictDiffLines = 80
+)
+
+// FileLocks is an advisory lock manager that serializes writes to the same
+// path across goroutines and ledit processes, such as parallel subagents. It
+// also remembers which version of each file this process last saw, so a
+// write based on a stale read is reported as a WriteConflictError instead of
+// overwriting changes made since by another agent or in an editor.
+type FileLocks struct {
+	dir   string
+	owner string
+
+	mu   sync.Mutex
+	seen map[string]seenVersion
+}
+
+// seenVersion is the content of a file as this process last saw it.
+type seenVersion struct {
+	path    string
+	hash    string
+	content string
+	// kept is false when the file was too large to keep its content.
+	kept bool
+	// modTime and size are the file's when seen; modTime is zero when the
+	// file on disk did not hold the seen content.
+	modTime time.Time
+	size    int64
+}
+
+// SeenSnapshot is the on-disk state of the seen files that were unchanged
+// when Snapshot was called.
+type SeenSnapshot map[string]fileStat
+
+type fileStat struct {
+	path    string
+	modTime time.Time
+	size    int64
+}
+
+// externalWriter describes the author of a change no agent stamped.
+const externalWriter = "an editor or another program outside ledit"
+
+// WriteConflictError is returned when a file was changed by another agent
+// after this process last read it.
+type WriteConflictError struct {
+	Path string
+	// Writer describes the agent that made the newer version.
+	Writer string
+	// Diff shows what the other agent changed, when both versions are known.
+	Diff string
+}
+
+func (e *WriteConflictError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "write conflict: %s was changed by %s after you last read it; your write was not applied so those changes are not lost.", e.Path, e.Writer)
+	if e.Diff != "" {
+		fmt.Fprintf(&sb, "\nTheir changes since your read:\n%s", e.Diff)
+	}
+	sb.WriteString("\nRe-read the file, merge your changes into its current content (edit_file keeps their changes intact), then retry.")
+	return sb.String()
+}
+
+// ErrLockTimeout is returned when a path stays locked by another writer.
+var ErrLockTimeout = errors.New("timed out waiting for file lock")
+
+var (
+	defaultLocksMu sync.Mutex
+	defaultLocks   *FileLocks
+)
+
+// Locks returns the process-wide lock manager. Its lock files live in a
+// directory under the system temp dir shared by every ledit process.
+func Locks() *FileLocks {
+	defaultLocksMu.Lock()
+	defer defaultLocksMu.Unlock()
+	if defaultLocks == nil {
+		defaultLocks = NewFileLocks(filepath.Join(os.TempDir(), "ledit-locks"), processOwner())
+	}
+	return defaultLocks
+}
+
+// NewFileLocks returns a lock manager keeping its lock files in dir. owner
+// names this process in conflict reports.
+func NewFileLocks(dir, owner string) *FileLocks {
+	return &FileLocks{dir: dir, owner: owner, seen: make(map[string]seenVersion)}
+}
+
+// processOwner describes this ledit process for other agents.
+func processOwner() string {
+	role := "the main agent"
+	if os.Getenv("LEDIT_SUBAGENT") == "1" {
+		role = "a subagent"
+		if persona := os.Getenv("LEDIT_PERSONA"); persona != "" {
+			role = "a " + persona + " subagent"
+		}
+	}
+	return fmt.Sprintf("%s (pid %d)", role, os.Getpid())
+}
+
+// key maps a path to the base name of its lock and stamp files.
+func (l *FileLocks) key(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	sum := sha256.Sum256([]byte(filepath.Clean(path)))
+	return hex.EncodeToString(sum[:16])
+}
+
+func hashContent(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// Lock acquires the write lock for path, waiting while another goroutine or
+// process holds it. The returned function releases the lock. When the lock
+// directory is unusable, locking is skipped rather than blocking writes.
+func (l *FileLocks) Lock(path string) (func(), error) {
+	if err := os.MkdirAll(l.dir, 0o755); err != nil {
+		return func() {}, nil
+	}
+	lockPath := filepath.Join(l.dir, l.key(path)+".lock")
+	deadline := time.Now().Add(lockWaitTimeout)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			fmt.Fprintf(f, "%s\n%s\n", l.owner, path)
+			f.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+		if !os.IsExist(err) {
+			return func() {}, nil
+		}
+		if info, statErr := os.Stat(lockPath); statErr == nil && time.Since(info.ModTime()) > lockStaleAfter {
+			os.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			holder, _ := os.ReadFile(lockPath)
+			owner, _, _ := strings.Cut(string(holder), "\n")
+			return nil, fmt.Errorf("%w on %s (held by %s)", ErrLockTimeout, path, strings.TrimSpace(owner))
+		}
+		time.Sleep(lockPollInterval)
+	}
+}
+
+// Observe records content as the version of path this process has seen.
+func (l *FileLocks) Observe(path string, content []byte) {
+	version := seenVersion{path: path, hash: hashContent(content)}
+	if len(content) <= maxSeenContentBytes {
+		version.content = string(content)
+		version.kept = true
+	}
+	if info, err := os.Stat(path); err == nil && info.Size() == int64(len(content)) {
+		version.modTime, version.size = info.ModTime(), info.Size()
+	}
+	l.mu.Lock()
+	l.seen[l.key(path)] = version
+	l.mu.Unlock()
+}
+
+// ObserveFile records the current content of path as seen.
+func (l *FileLocks) ObserveFile(path string) {
+	if content, err := os.ReadFile(path); err == nil {
+		l.Observe(path, content)
+	}
+}
+
+// Forget drops what this process has seen of path.
+func (l *FileLocks) Forget(path string) {
+	l.mu.Lock()
+	delete(l.seen, l.key(path))
+	l.mu.Unlock()
+}
+
+// Snapshot records the on-disk state of every seen file that has not changed
+// since this process saw it. Pass it to Adopt after running a tool that may
+// change files without recording its writes, such as a shell command.
+func (l *FileLocks) Snapshot() SeenSnapshot {
+	l.mu.Lock()
+	versions := make(map[string]seenVersion, len(l.seen))
+	for key, version := range l.seen {
+		versions[key] = version
+	}
+	l.mu.Unlock()
+
+	snapshot := make(SeenSnapshot)
+	for key, version := range versions {
+		if version.modTime.IsZero() {
+			continue
+		}
+		info, err := os.Stat(version.path)
+		if err == nil && info.ModTime().Equal(version.modTime) && info.Size() == version.size {
+			snapshot[key] = fileStat{path: version.path, modTime: version.modTime, size: version.size}
+		}
+	}
+	return snapshot
+}
+
+// Adopt marks as seen the current content of the files in snapshot that
+// changed since it was taken: this process's own tool changed them, so a
+// later write is not a conflict. Files that had already changed before the
+// snapshot are left alone and still conflict.
+func (l *FileLocks) Adopt(snapshot SeenSnapshot) {
+	for _, stat := range snapshot {
+		info, err := os.Stat(stat.path)
+		switch {
+		case os.IsNotExist(err):
+			l.Forget(stat.path)
+		case err == nil && (!info.ModTime().Equal(stat.modTime) || info.Size() != stat.size):
+			l.ObserveFile(stat.path)
+		}
+	}
+}
+
+// CheckConflict reports a WriteConflictError when path changed since this
+// process last saw it, whether another agent or an editor changed it; the
+// error asks the model to re-read the file and re-apply its change. Changes
+// this process made itself are recorded by RecordWrite or Adopt and do not
+// conflict. Call it while holding the path's lock.
+func (l *FileLocks) CheckConflict(path string) error {
+	l.mu.Lock()
+	seen, ok := l.seen[l.key(path)]
+	l.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	current, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	currentHash := hashContent(current)
+	if currentHash == seen.hash {
+		return nil
+	}
+	writer, hash, ok := l.readStamp(path)
+	if !ok || hash != currentHash {
+		writer = externalWriter
+	} else if writer == l.owner {
+		return nil
+	}
+	conflict := &WriteConflictError{Path: path, Writer: writer}
+	if seen.kept && len(current) <= maxSeenContentBytes {
+		conflict.Diff = lineDiff(seen.content, string(current), maxConflictDiffLines)
+	}
+	return conflict
+}
+
+// RecordWrite stamps content as this process's write of path and marks it
+// seen. Call it after writing, while holding the path's lock.
+func (l *FileLocks) RecordWrite(path string, content []byte) {
+	l.Observe(path, content)
+	if err := os.MkdirAll(l.dir, 0o755); err != nil {
+		return
+	}
+	stamp := l.owner + "\n" + hashContent(content) + "\n"
+	_ = os.WriteFile(filepath.Join(l.dir, l.key(path)+".stamp"), []byte(stamp), 0o644)
+}
+
+// readStamp returns the agent that last wrote path and the hash it wrote.
+func (l *FileLocks) readStamp(path string) (string, string, bool) {
+	data, err := os.ReadFile(filepath.Join(l.dir, l.key(path)+".stamp"))
+	if err != nil {
+		return "", "", false
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		return "", "", false
+	}
+	return lines[0], lines[1], true
+}
+
+// lineDiff renders the line changes from old to new as -/+ lines, showing at
+// most maxLines of them.
+func lineDiff(old, new string, maxLines int) string {
+	dmp := diffmatchpatch.New()
+	a, b, lines := dmp.DiffLinesToChars(old, new)
+	diffs := dmp.DiffCharsToLines(dmp.DiffMain(a, b, false), lines)
+
+	var sb strings.Builder
+	shown, omitted := 0, 0
+	for _, d := range diffs {
+		prefix := ""
+		switch d.Type {
+		case diffmatchpatch.DiffInsert:
+			prefix = "+"
+		case diffmatchpatch.DiffDelete:
+			prefix = "-"
+		default:
+			continue
+		}
+		for _, line := range strings.SplitAfter(d.Text, "\n") {
+			if line == "" {
+				continue
+			}
+			if shown >= maxLines {
+				omitted++
+				continue
+			}
+			sb.WriteString(prefix + strings.TrimSuffix(line, "\n") + "\n")
+			shown++
+		}
+	}
+	if omitted > 0 {
+		sb.WriteString("... " + strconv.Itoa(omitted) + " more changed lines\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,189 @@
+package filesystem
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFileLocksSerializesWriters(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "shared.go")
+	a := NewFileLocks(filepath.Join(dir, "locks"), "agent a")
+	b := NewFileLocks(filepath.Join(dir, "locks"), "agent b")
+
+	unlock, err := a.Lock(path)
+	if err != nil {
+		t.Fatalf("Lock: %v", err)
+	}
+
+	var (
+		mu    sync.Mutex
+		order []string
+		wg    sync.WaitGroup
+	)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		unlockB, err := b.Lock(path)
+		if err != nil {
+			t.Errorf("second Lock: %v", err)
+			return
+		}
+		mu.Lock()
+		order = append(order, "b")
+		mu.Unlock()
+		unlockB()
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	mu.Lock()
+	order = append(order, "a")
+	mu.Unlock()
+	unlock()
+	wg.Wait()
+
+	if strings.Join(order, ",") != "a,b" {
+		t.Errorf("lock not held until release, order = %v", order)
+	}
+}
+
+func TestFileLocksBreaksStaleLock(t *testing.T) {
+	dir := t.TempDir()
+	locks := NewFileLocks(filepath.Join(dir, "locks"), "agent")
+	path := filepath.Join(dir, "file.txt")
+
+	if err := os.MkdirAll(locks.dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	lockPath := filepath.Join(locks.dir, locks.key(path)+".lock")
+	if err := os.WriteFile(lockPath, []byte("dead process\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * lockStaleAfter)
+	if err := os.Chtimes(lockPath, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	unlock, err := locks.Lock(path)
+	if err != nil {
+		t.Fatalf("Lock over stale lock: %v", err)
+	}
+	unlock()
+	if _, err := os.Stat(lockPath); !os.IsNotExist(err) {
+		t.Error("lock file not removed on release")
+	}
+}
+
+func TestFileLocksCheckConflict(t *testing.T) {
+	dir := t.TempDir()
+	lockDir := filepath.Join(dir, "locks")
+	path := filepath.Join(dir, "main.go")
+	main := NewFileLocks(lockDir, "the main agent (pid 1)")
+	sub := NewFileLocks(lockDir, "a subagent (pid 2)")
+
+	write := func(l *FileLocks, content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		l.RecordWrite(path, []byte(content))
+	}
+
+	if err := main.CheckConflict(path); err != nil {
+		t.Fatalf("unseen file should not conflict: %v", err)
+	}
+
+	write(main, "package main\n\nfunc a() {}\n")
+	if err := main.CheckConflict(path); err != nil {
+		t.Fatalf("own write should not conflict: %v", err)
+	}
+
+	sub.ObserveFile(path)
+	write(sub, "package main\n\nfunc a() {}\n\nfunc b() {}\n")
+
+	err := main.CheckConflict(path)
+	var conflict *WriteConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("expected WriteConflictError, got %v", err)
+	}
+	if conflict.Writer != "a subagent (pid 2)" {
+		t.Errorf("writer = %q", conflict.Writer)
+	}
+	if !strings.Contains(conflict.Diff, "+func b() {}") {
+		t.Errorf("diff missing the subagent's change:\n%s", conflict.Diff)
+	}
+	if !strings.Contains(err.Error(), "Re-read the file") {
+		t.Errorf("error should ask for a merge: %v", err)
+	}
+
+	main.ObserveFile(path)
+	if err := main.CheckConflict(path); err != nil {
+		t.Errorf("no conflict expected after re-reading: %v", err)
+	}
+
+	// Changes no agent stamped, such as an editor save, conflict too.
+	if err := os.WriteFile(path, []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	err = main.CheckConflict(path)
+	if !errors.As(err, &conflict) || conflict.Writer != externalWriter {
+		t.Fatalf("expected an external WriteConflictError, got %v", err)
+	}
+	if !strings.Contains(conflict.Diff, "-func b() {}") {
+		t.Errorf("diff missing the editor's change:\n%s", conflict.Diff)
+	}
+}
+
+func TestFileLocksAdoptOwnChanges(t *testing.T) {
+	dir := t.TempDir()
+	locks := NewFileLocks(filepath.Join(dir, "locks"), "agent")
+	own := filepath.Join(dir, "own.go")
+	edited := filepath.Join(dir, "edited.go")
+	for _, path := range []string{own, edited} {
+		if err := os.WriteFile(path, []byte("package main\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		locks.ObserveFile(path)
+	}
+	later := time.Now().Add(time.Second)
+
+	// edited.go changes in an editor before the tool runs, own.go while it runs.
+	if err := os.WriteFile(edited, []byte("package main\n\n// editor\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	snapshot := locks.Snapshot()
+	if err := os.WriteFile(own, []byte("package main\n\n// gofmt\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(own, later, later); err != nil {
+		t.Fatal(err)
+	}
+	locks.Adopt(snapshot)
+
+	if err := locks.CheckConflict(own); err != nil {
+		t.Errorf("a change made by the tool itself should not conflict: %v", err)
+	}
+	if err := locks.CheckConflict(edited); err == nil {
+		t.Error("an editor change made before the tool ran should still conflict")
+	}
+}
+
+func TestLineDiffLimitsOutput(t *testing.T) {
+	var old, new strings.Builder
+	for i := 0; i < 10; i++ {
+		old.WriteString("old line\n")
+		new.WriteString("new line\n")
+	}
+	diff := lineDiff(old.String(), new.String(), 5)
+	if got := strings.Count(diff, "\n"); got != 6 {
+		t.Errorf("expected 5 lines plus a summary, got %d:\n%s", got, diff)
+	}
+	if !strings.Contains(diff, "15 more changed lines") {
+		t.Errorf("missing omitted count:\n%s", diff)
+	}
+}
@@ -0,0 +1,340 @@
+package fixtests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/scanner"
+	"go/token"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/alantheprice/ledit/pkg/agent"
+	"github.com/alantheprice/ledit/pkg/filediscovery"
+)
+
+// Selection is a test command narrowed to the tests a change set can affect.
+type Selection struct {
+	Command string   `json:"command"`
+	Targets []string `json:"targets"` // packages or test files, sorted
+}
+
+// changeKind classifies how much a package's change can ripple outwards.
+type changeKind int
+
+const (
+	changeCosmetic changeKind = iota // comments or formatting only
+	changeTests                      // test code only
+	changeCode                       // non-test declarations changed
+)
+
+// SelectAffected narrows the framework's default test command to the tests
+// affected by changes, plus the targets that failed in previous so repair
+// attempts elsewhere are re-checked too. ok is false when no safe narrowing
+// exists (unsupported framework, build files changed, nothing to select) and
+// the full suite should run.
+func SelectAffected(ctx context.Context, dir, framework string, changes []agent.TrackedFileChange, previous *RunResult) (Selection, bool, error) {
+	if len(changes) == 0 {
+		return Selection{}, false, nil
+	}
+	switch framework {
+	case filediscovery.TestFrameworkGo:
+		return selectGoPackages(ctx, dir, changes, previous)
+	case filediscovery.TestFrameworkJest:
+		return selectRelatedJS(dir, "npx jest --ci --findRelatedTests", changes, previous)
+	case filediscovery.TestFrameworkVitest:
+		return selectRelatedJS(dir, "npx vitest related --run", changes, previous)
+	}
+	return Selection{}, false, nil
+}
+
+// goListPackage is the subset of `go list -json` output the import graph needs.
+type goListPackage struct {
+	ImportPath   string
+	Dir          string
+	Imports      []string
+	TestImports  []string
+	XTestImports []string
+}
+
+// selectGoPackages picks the packages containing changed files and, when a
+// package's non-test declarations changed, every package that imports it
+// directly or transitively, plus packages whose tests import any of those.
+func selectGoPackages(ctx context.Context, dir string, changes []agent.TrackedFileChange, previous *RunResult) (Selection, bool, error) {
+	packages, err := listGoPackages(ctx, dir)
+	if err != nil {
+		return Selection{}, false, err
+	}
+	byDir := make(map[string]*goListPackage, len(packages))
+	importers := make(map[string][]string)
+	testImporters := make(map[string][]string)
+	for i := range packages {
+		pkg := &packages[i]
+		byDir[filepath.Clean(pkg.Dir)] = pkg
+		for _, imp := range pkg.Imports {
+			importers[imp] = append(importers[imp], pkg.ImportPath)
+		}
+		for _, imp := range append(append([]string(nil), pkg.TestImports...), pkg.XTestImports...) {
+			testImporters[imp] = append(testImporters[imp], pkg.ImportPath)
+		}
+	}
+
+	kinds := make(map[string]changeKind)
+	for _, change := range changes {
+		path := change.FilePath
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		switch filepath.Base(path) {
+		case "go.mod", "go.sum", "go.work", "go.work.sum":
+			// Dependency changes can affect any package.
+			return Selection{}, false, nil
+		}
+		pkg := owningPackage(byDir, dir, filepath.Dir(path))
+		if pkg == nil {
+			continue
+		}
+		kind := classifyGoChange(path, change.OriginalCode, change.NewCode)
+		if prev, seen := kinds[pkg.ImportPath]; !seen || kind > prev {
+			kinds[pkg.ImportPath] = kind
+		}
+	}
+
+	selected := make(map[string]bool)
+	var queue []string
+	for importPath, kind := range kinds {
+		selected[importPath] = true
+		if kind == changeCode {
+			queue = append(queue, importPath)
+		}
+	}
+	// Production imports propagate transitively; test-only imports add the
+	// importing package but go no further, since nothing imports its tests.
+	reached := make(map[string]bool)
+	for len(queue) > 0 {
+		importPath := queue[0]
+		queue = queue[1:]
+		if reached[importPath] {
+			continue
+		}
+		reached[importPath] = true
+		selected[importPath] = true
+		queue = append(queue, importers[importPath]...)
+		for _, tester := range testImporters[importPath] {
+			selected[tester] = true
+		}
+	}
+	if previous != nil {
+		known := make(map[string]bool, len(packages))
+		for _, pkg := range packages {
+			known[pkg.ImportPath] = true
+		}
+		for _, failure := range previous.Failures {
+			if known[failure.Package] {
+				selected[failure.Package] = true
+			}
+		}
+	}
+	if len(selected) == 0 {
+		return Selection{}, false, nil
+	}
+
+	targets := make([]string, 0, len(selected))
+	for importPath := range selected {
+		targets = append(targets, importPath)
+	}
+	sort.Strings(targets)
+	return Selection{Command: "go test " + strings.Join(targets, " "), Targets: targets}, true, nil
+}
+
+// listGoPackages runs `go list -json ./...` in dir.
+func listGoPackages(ctx context.Context, dir string) ([]goListPackage, error) {
+	cmd := exec.CommandContext(ctx, "go", "list", "-e", "-json=ImportPath,Dir,Imports,TestImports,XTestImports", "./...")
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go list failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var packages []goListPackage
+	decoder := json.NewDecoder(bytes.NewReader(out))
+	for {
+		var pkg goListPackage
+		if err := decoder.Decode(&pkg); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to parse go list output: %w", err)
+		}
+		packages = append(packages, pkg)
+	}
+	return packages, nil
+}
+
+// owningPackage finds the package for a directory, walking up so that files
+// under testdata/ or other non-package subdirectories map to their package.
+func owningPackage(byDir map[string]*goListPackage, root, dir string) *goListPackage {
+	root = filepath.Clean(root)
+	for dir = filepath.Clean(dir); ; dir = filepath.Dir(dir) {
+		if pkg, ok := byDir[dir]; ok {
+			return pkg
+		}
+		if dir == root || dir == filepath.Dir(dir) {
+			return nil
+		}
+	}
+}
+
+// classifyGoChange compares the top-level declarations of a file before and
+// after a change. Files that are not Go source, or that fail to parse, count
+// as code changes so their package's dependents are still tested.
+func classifyGoChange(path, before, after string) changeKind {
+	if filepath.Ext(path) != ".go" {
+		return changeCode
+	}
+	if strings.HasSuffix(path, "_test.go") {
+		return changeTests
+	}
+	beforeDecls, errBefore := declarationSources(before)
+	afterDecls, errAfter := declarationSources(after)
+	if errBefore != nil || errAfter != nil {
+		return changeCode
+	}
+	if len(beforeDecls) != len(afterDecls) {
+		return changeCode
+	}
+	for name, src := range afterDecls {
+		if beforeDecls[name] != src {
+			return changeCode
+		}
+	}
+	return changeCosmetic
+}
+
+// declarationSources maps each top-level declaration to its token stream,
+// so comments and formatting do not count as changes. An empty file has no
+// declarations.
+func declarationSources(src string) (map[string]string, error) {
+	decls := make(map[string]string)
+	if strings.TrimSpace(src) == "" {
+		return decls, nil
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+	decls["package"] = file.Name.Name
+	for i, decl := range file.Decls {
+		start, end := fset.Position(decl.Pos()).Offset, fset.Position(decl.End()).Offset
+		decls[declarationKey(decl, i)] = normalizedTokens(src[start:end])
+	}
+	return decls, nil
+}
+
+// normalizedTokens joins the tokens of src, dropping comments and the
+// semicolons the scanner inserts at line ends.
+func normalizedTokens(src string) string {
+	fset := token.NewFileSet()
+	var s scanner.Scanner
+	s.Init(fset.AddFile("", fset.Base(), len(src)), []byte(src), nil, 0)
+	var b strings.Builder
+	for {
+		_, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok == token.SEMICOLON && lit == "\n" {
+			continue
+		}
+		if lit == "" {
+			lit = tok.String()
+		}
+		b.WriteString(lit)
+		b.WriteByte(' ')
+	}
+	return b.String()
+}
+
+func declarationKey(decl ast.Decl, index int) string {
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		if d.Recv != nil && len(d.Recv.List) > 0 {
+			var buf bytes.Buffer
+			_ = printer.Fprint(&buf, token.NewFileSet(), d.Recv.List[0].Type)
+			return "func (" + buf.String() + ") " + d.Name.Name
+		}
+		return "func " + d.Name.Name
+	case *ast.GenDecl:
+		if len(d.Specs) > 0 {
+			switch spec := d.Specs[0].(type) {
+			case *ast.TypeSpec:
+				return "type " + spec.Name.Name
+			case *ast.ValueSpec:
+				if len(spec.Names) > 0 {
+					return d.Tok.String() + " " + spec.Names[0].Name
+				}
+			case *ast.ImportSpec:
+				return fmt.Sprintf("import %d", index)
+			}
+		}
+	}
+	return fmt.Sprintf("decl %d", index)
+}
+
+// selectRelatedJS lets jest or vitest find the tests related to the changed
+// source files, adding test files that failed in previous.
+func selectRelatedJS(dir, command string, changes []agent.TrackedFileChange, previous *RunResult) (Selection, bool, error) {
+	seen := make(map[string]bool)
+	var targets []string
+	add := func(path string) {
+		if path == "" {
+			return
+		}
+		if filepath.IsAbs(path) {
+			if rel, err := filepath.Rel(dir, path); err == nil {
+				path = rel
+			}
+		}
+		path = filepath.ToSlash(path)
+		if !seen[path] {
+			seen[path] = true
+			targets = append(targets, path)
+		}
+	}
+	for _, change := range changes {
+		switch filepath.Base(change.FilePath) {
+		case "package.json", "package-lock.json", "yarn.lock", "pnpm-lock.yaml":
+			return Selection{}, false, nil
+		}
+		add(change.FilePath)
+	}
+	if previous != nil {
+		for _, failure := range previous.Failures {
+			add(failure.File)
+		}
+	}
+	sort.Strings(targets)
+
+	quoted := make([]string, len(targets))
+	for i, target := range targets {
+		quoted[i] = shellQuote(target)
+	}
+	return Selection{Command: command + " " + strings.Join(quoted, " "), Targets: targets}, true, nil
+}
+
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-./@+") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
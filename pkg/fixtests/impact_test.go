@@ -0,0 +1,120 @@
+package fixtests
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/alantheprice/ledit/pkg/agent"
+	"github.com/alantheprice/ledit/pkg/filediscovery"
+)
+
+func TestClassifyGoChange(t *testing.T) {
+	before := "package calc\n\n// Add adds.\nfunc Add(a, b int) int { return a + b }\n"
+	cases := []struct {
+		name  string
+		path  string
+		after string
+		want  changeKind
+	}{
+		{"comment only", "calc.go", "package calc\n\n// Add returns the sum of a and b.\nfunc Add(a, b int) int {\n\treturn a + b\n}\n", changeCosmetic},
+		{"body change", "calc.go", "package calc\n\nfunc Add(a, b int) int { return a - b }\n", changeCode},
+		{"new declaration", "calc.go", before + "\nfunc Sub(a, b int) int { return a - b }\n", changeCode},
+		{"test file", "calc_test.go", "package calc\n", changeTests},
+		{"unparseable", "calc.go", "package calc\nfunc (", changeCode},
+		{"non-go file", "testdata/input.txt", "data", changeCode},
+	}
+	for _, tc := range cases {
+		if got := classifyGoChange(tc.path, before, tc.after); got != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+// writeModule lays out a module where app imports lib, and tool's tests
+// import lib.
+func writeModule(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go toolchain not available")
+	}
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":              "module example.com/m\n\ngo 1.21\n",
+		"lib/lib.go":          "package lib\n\nfunc Value() int { return 1 }\n",
+		"app/app.go":          "package app\n\nimport \"example.com/m/lib\"\n\nfunc Run() int { return lib.Value() }\n",
+		"tool/tool.go":        "package tool\n",
+		"tool/tool_test.go":   "package tool\n\nimport (\n\t\"testing\"\n\n\t\"example.com/m/lib\"\n)\n\nfunc TestValue(t *testing.T) { _ = lib.Value() }\n",
+		"other/other.go":      "package other\n",
+		"other/other_test.go": "package other\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestSelectAffectedGoFollowsImportGraph(t *testing.T) {
+	dir := writeModule(t)
+	ctx := context.Background()
+
+	changes := []agent.TrackedFileChange{{
+		FilePath:     "lib/lib.go",
+		OriginalCode: "package lib\n\nfunc Value() int { return 1 }\n",
+		NewCode:      "package lib\n\nfunc Value() int { return 2 }\n",
+	}}
+	selection, ok, err := SelectAffected(ctx, dir, filediscovery.TestFrameworkGo, changes, nil)
+	if err != nil || !ok {
+		t.Fatalf("SelectAffected: ok=%v err=%v", ok, err)
+	}
+	want := []string{"example.com/m/app", "example.com/m/lib", "example.com/m/tool"}
+	if !reflect.DeepEqual(selection.Targets, want) {
+		t.Fatalf("targets = %v, want %v", selection.Targets, want)
+	}
+
+	// A comment-only edit does not ripple to importers, but previously
+	// failing packages are re-checked.
+	changes[0].NewCode = "package lib\n\n// Value is one.\nfunc Value() int { return 1 }\n"
+	previous := &RunResult{Failures: []Failure{{Package: "example.com/m/other"}}}
+	selection, ok, err = SelectAffected(ctx, dir, filediscovery.TestFrameworkGo, changes, previous)
+	if err != nil || !ok {
+		t.Fatalf("SelectAffected: ok=%v err=%v", ok, err)
+	}
+	want = []string{"example.com/m/lib", "example.com/m/other"}
+	if !reflect.DeepEqual(selection.Targets, want) {
+		t.Fatalf("targets = %v, want %v", selection.Targets, want)
+	}
+	if selection.Command != "go test example.com/m/lib example.com/m/other" {
+		t.Fatalf("unexpected command %q", selection.Command)
+	}
+
+	// Dependency changes can affect everything.
+	if _, ok, _ := SelectAffected(ctx, dir, filediscovery.TestFrameworkGo, []agent.TrackedFileChange{{FilePath: "go.mod"}}, nil); ok {
+		t.Fatal("expected go.mod changes to require the full suite")
+	}
+}
+
+func TestSelectAffectedJS(t *testing.T) {
+	changes := []agent.TrackedFileChange{{FilePath: "src/sum.js"}, {FilePath: "src/my file.js"}}
+	previous := &RunResult{Failures: []Failure{{File: "src/other.test.js"}}}
+	selection, ok, err := SelectAffected(context.Background(), "/repo", filediscovery.TestFrameworkJest, changes, previous)
+	if err != nil || !ok {
+		t.Fatalf("SelectAffected: ok=%v err=%v", ok, err)
+	}
+	if want := "npx jest --ci --findRelatedTests 'src/my file.js' src/other.test.js src/sum.js"; selection.Command != want {
+		t.Fatalf("command = %q, want %q", selection.Command, want)
+	}
+
+	if _, ok, _ := SelectAffected(context.Background(), "/repo", filediscovery.TestFrameworkPytest, changes, nil); ok {
+		t.Fatal("expected unsupported frameworks to run the full suite")
+	}
+}
@@ -0,0 +1,371 @@
+// Package fixtests implements a test-driven repair loop: run the project's
+// tests, hand the structured failures to the agent for targeted edits, and
+// repeat until the suite passes or the iteration budget runs out.
+package fixtests
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alantheprice/ledit/pkg/agent"
+	"github.com/alantheprice/ledit/pkg/filediscovery"
+)
+
+const (
+	// DefaultMaxIterations is the default number of repair attempts.
+	DefaultMaxIterations = 5
+	// maxPromptFailures caps how many structured failures go into a prompt.
+	maxPromptFailures = 20
+	// maxPromptOutputTail caps how much raw test output goes into a prompt.
+	maxPromptOutputTail = 4000
+)
+
+// Options configures a repair loop.
+type Options struct {
+	Dir           string // working directory for the test command (default: cwd)
+	Command       string // test command (default: detected from the framework)
+	Framework     string // test framework (default: detected from Dir)
+	MaxIterations int    // repair attempts before giving up (default: DefaultMaxIterations)
+	FullSuite     bool   // always run the whole suite instead of only affected tests
+}
+
+// FileDelta summarizes how one file changed during an iteration.
+type FileDelta struct {
+	Path    string `json:"path"`
+	Added   int    `json:"added"`
+	Removed int    `json:"removed"`
+}
+
+// IterationReport records one repair attempt: the failing run that prompted
+// it and the edits the agent made in response.
+type IterationReport struct {
+	Iteration int         `json:"iteration"`
+	Before    *RunResult  `json:"before"`
+	Changes   []FileDelta `json:"changes"`
+	FixError  string      `json:"fix_error,omitempty"`
+	// Affected is the narrowed run that followed the attempt, when the
+	// change set allowed one.
+	Affected *RunResult `json:"affected,omitempty"`
+}
+
+// Report is the outcome of a repair loop.
+type Report struct {
+	Command         string            `json:"command"`
+	Framework       string            `json:"framework"`
+	Iterations      []IterationReport `json:"iterations"`
+	Final           *RunResult        `json:"final"`
+	Passed          bool              `json:"passed"`
+	BudgetExhausted bool              `json:"budget_exhausted"`
+}
+
+// Loop drives the repair cycle. The hooks make it independent of the agent so
+// it can be exercised directly in tests.
+type Loop struct {
+	// RunTests runs the test suite once.
+	RunTests func(ctx context.Context) (*RunResult, error)
+	// Fix asks the agent to repair the failures described by prompt.
+	Fix func(prompt string) error
+	// Changes returns every tracked file change so far, in order.
+	Changes func() []agent.TrackedFileChange
+	// RunAffected, when set, runs only the tests affected by changes (and
+	// those that failed in previous). ok is false when the change set cannot
+	// be narrowed. A passing affected run is always confirmed by the full
+	// suite before the loop reports success.
+	RunAffected func(ctx context.Context, changes []agent.TrackedFileChange, previous *RunResult) (result *RunResult, ok bool, err error)
+	// OnIteration, when set, is called after each repair attempt.
+	OnIteration func(IterationReport)
+
+	Command       string
+	Framework     string
+	MaxIterations int
+}
+
+// NewAgentLoop wires a Loop to chatAgent, resolving the test command from
+// opts or the detected test framework.
+func NewAgentLoop(chatAgent *agent.Agent, opts Options) (*Loop, error) {
+	if chatAgent == nil {
+		return nil, fmt.Errorf("fix-tests requires an active agent")
+	}
+	dir := opts.Dir
+	if dir == "" {
+		if root := chatAgent.GetWorkspaceRoot(); root != "" {
+			dir = root
+		} else if cwd, err := os.Getwd(); err == nil {
+			dir = cwd
+		}
+	}
+	framework := opts.Framework
+	if framework == "" {
+		framework = filediscovery.DetectTestFramework(dir)
+	}
+	command := strings.TrimSpace(opts.Command)
+	if command == "" {
+		command = filediscovery.TestCommandForFramework(framework)
+	}
+	if command == "" {
+		return nil, fmt.Errorf("could not detect a test command in %s; pass one explicitly", dir)
+	}
+
+	// Per-iteration diffs come from the change tracker.
+	if !chatAgent.IsChangeTrackingEnabled() {
+		chatAgent.EnableChangeTracking("fix-tests: " + command)
+	}
+
+	// Only the detected default command can be narrowed; a custom command
+	// may select tests or set flags we cannot reproduce.
+	var runAffected func(context.Context, []agent.TrackedFileChange, *RunResult) (*RunResult, bool, error)
+	if !opts.FullSuite && strings.TrimSpace(opts.Command) == "" {
+		runAffected = func(ctx context.Context, changes []agent.TrackedFileChange, previous *RunResult) (*RunResult, bool, error) {
+			selection, ok, err := SelectAffected(ctx, dir, framework, changes, previous)
+			if err != nil || !ok {
+				return nil, false, err
+			}
+			result, err := RunTests(ctx, dir, selection.Command, framework)
+			return result, err == nil, err
+		}
+	}
+
+	return &Loop{
+		RunTests: func(ctx context.Context) (*RunResult, error) {
+			return RunTests(ctx, dir, command, framework)
+		},
+		Fix: func(prompt string) error {
+			_, err := chatAgent.ProcessQuery(prompt)
+			return err
+		},
+		Changes: func() []agent.TrackedFileChange {
+			if tracker := chatAgent.GetChangeTracker(); tracker != nil {
+				return tracker.GetChanges()
+			}
+			return nil
+		},
+		RunAffected:   runAffected,
+		Command:       command,
+		Framework:     framework,
+		MaxIterations: opts.MaxIterations,
+	}, nil
+}
+
+// Run executes the loop until the tests pass, the budget is exhausted, or ctx
+// is cancelled.
+func (l *Loop) Run(ctx context.Context) (*Report, error) {
+	maxIterations := l.MaxIterations
+	if maxIterations <= 0 {
+		maxIterations = DefaultMaxIterations
+	}
+	report := &Report{Command: l.Command, Framework: l.Framework}
+
+	result, err := l.RunTests(ctx)
+	if err != nil {
+		return nil, err
+	}
+	changesAtStart := len(l.changes())
+	narrowed := false
+	for iteration := 1; !result.Passed; iteration++ {
+		if iteration > maxIterations {
+			report.BudgetExhausted = true
+			break
+		}
+		if err := ctx.Err(); err != nil {
+			report.Final = result
+			return report, err
+		}
+
+		changesBefore := len(l.changes())
+		iter := IterationReport{Iteration: iteration, Before: result}
+		command := l.Command
+		if result.Command != "" {
+			command = result.Command
+		}
+		if fixErr := l.Fix(BuildRepairPrompt(command, result, iteration, maxIterations)); fixErr != nil {
+			iter.FixError = fixErr.Error()
+		}
+		changes := l.changes()
+		if len(changes) > changesBefore {
+			iter.Changes = summarizeChanges(changes[changesBefore:])
+		}
+
+		// Re-check only the affected tests while repairing; fall through to
+		// the full suite once they pass.
+		var affected *RunResult
+		affected, narrowed, err = l.runAffected(ctx, changes[min(changesAtStart, len(changes)):], result)
+		if err != nil {
+			return report, err
+		}
+		if narrowed {
+			iter.Affected = affected
+		}
+		report.Iterations = append(report.Iterations, iter)
+		if l.OnIteration != nil {
+			l.OnIteration(iter)
+		}
+
+		if narrowed && !affected.Passed {
+			result = affected
+			continue
+		}
+		narrowed = false
+		if result, err = l.RunTests(ctx); err != nil {
+			return report, err
+		}
+	}
+
+	// The final verdict always comes from the full suite.
+	if narrowed {
+		if result, err = l.RunTests(ctx); err != nil {
+			return report, err
+		}
+	}
+
+	report.Final = result
+	report.Passed = result.Passed
+	return report, nil
+}
+
+func (l *Loop) runAffected(ctx context.Context, changes []agent.TrackedFileChange, previous *RunResult) (*RunResult, bool, error) {
+	if l.RunAffected == nil || len(changes) == 0 {
+		return nil, false, nil
+	}
+	return l.RunAffected(ctx, changes, previous)
+}
+
+func (l *Loop) changes() []agent.TrackedFileChange {
+	if l.Changes == nil {
+		return nil
+	}
+	return l.Changes()
+}
+
+// BuildRepairPrompt turns a failing test run into instructions for the agent.
+func BuildRepairPrompt(command string, result *RunResult, iteration, maxIterations int) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "The test command `%s` is failing (exit code %d). Repair attempt %d of %d.\n\n",
+		command, result.ExitCode, iteration, maxIterations)
+
+	if len(result.Failures) > 0 {
+		sb.WriteString("Failures:\n")
+		for i, failure := range result.Failures {
+			if i == maxPromptFailures {
+				fmt.Fprintf(&sb, "- ... and %d more\n", len(result.Failures)-maxPromptFailures)
+				break
+			}
+			sb.WriteString("- " + describeFailure(failure) + "\n")
+		}
+		sb.WriteString("\n")
+	}
+
+	output := result.Output
+	if len(output) > maxPromptOutputTail {
+		output = "..." + output[len(output)-maxPromptOutputTail:]
+	}
+	sb.WriteString("Test output (tail):\n```\n" + strings.TrimRight(output, "\n") + "\n```\n\n")
+
+	sb.WriteString("Make targeted edits to the code under test so these failures pass. " +
+		"Read the failing code before editing. Do not delete or weaken tests unless a test is clearly wrong, " +
+		"and say so if you change one. The tests will be re-run automatically after you finish.")
+	return sb.String()
+}
+
+func describeFailure(f Failure) string {
+	var parts []string
+	if f.Package != "" {
+		parts = append(parts, f.Package)
+	}
+	if f.Test != "" {
+		parts = append(parts, f.Test)
+	}
+	if loc := f.Location(); loc != "" {
+		parts = append(parts, "("+loc+")")
+	}
+	desc := strings.Join(parts, " ")
+	if f.Message != "" {
+		if desc != "" {
+			desc += ": "
+		}
+		desc += strings.ReplaceAll(f.Message, "\n", " | ")
+	}
+	return desc
+}
+
+// summarizeChanges collapses tracked changes into per-file line deltas.
+func summarizeChanges(changes []agent.TrackedFileChange) []FileDelta {
+	byPath := make(map[string]*FileDelta)
+	var order []string
+	for _, change := range changes {
+		delta, ok := byPath[change.FilePath]
+		if !ok {
+			delta = &FileDelta{Path: change.FilePath}
+			byPath[change.FilePath] = delta
+			order = append(order, change.FilePath)
+		}
+		added, removed := lineDelta(change.OriginalCode, change.NewCode)
+		delta.Added += added
+		delta.Removed += removed
+	}
+	sort.Strings(order)
+	deltas := make([]FileDelta, 0, len(order))
+	for _, path := range order {
+		deltas = append(deltas, *byPath[path])
+	}
+	return deltas
+}
+
+// lineDelta counts lines added and removed between two versions, treating the
+// files as multisets of lines.
+func lineDelta(before, after string) (added, removed int) {
+	counts := make(map[string]int)
+	for _, line := range splitLines(before) {
+		counts[line]++
+	}
+	for _, line := range splitLines(after) {
+		if counts[line] > 0 {
+			counts[line]--
+		} else {
+			added++
+		}
+	}
+	for _, n := range counts {
+		removed += n
+	}
+	return added, removed
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// FormatIteration renders an iteration as a short human-readable summary.
+func FormatIteration(iter IterationReport) string {
+	var sb strings.Builder
+	if n := len(iter.Before.Failures); n > 0 {
+		fmt.Fprintf(&sb, "Iteration %d: %d failure(s)", iter.Iteration, n)
+	} else {
+		fmt.Fprintf(&sb, "Iteration %d: tests failing (exit code %d)", iter.Iteration, iter.Before.ExitCode)
+	}
+	if iter.FixError != "" {
+		fmt.Fprintf(&sb, ", agent error: %s", iter.FixError)
+	}
+	if iter.Affected != nil {
+		status := "pass, confirming with the full suite"
+		if !iter.Affected.Passed {
+			status = "still failing"
+		}
+		fmt.Fprintf(&sb, ", affected tests %s (`%s`, %s)", status, iter.Affected.Command, iter.Affected.Duration.Round(time.Millisecond))
+	}
+	if len(iter.Changes) == 0 {
+		sb.WriteString(", no files changed")
+		return sb.String()
+	}
+	sb.WriteString("\n")
+	for _, delta := range iter.Changes {
+		fmt.Fprintf(&sb, "  %s (+%d/-%d)\n", delta.Path, delta.Added, delta.Removed)
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
@@ -0,0 +1,165 @@
+package fixtests
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/alantheprice/ledit/pkg/agent"
+)
+
+// scriptedLoop returns a Loop whose test runs follow results and whose fixes
+// append the given change for each attempt.
+func scriptedLoop(results []*RunResult, max int) (*Loop, *[]string) {
+	var prompts []string
+	var changes []agent.TrackedFileChange
+	run := 0
+	loop := &Loop{
+		RunTests: func(ctx context.Context) (*RunResult, error) {
+			result := results[run]
+			if run < len(results)-1 {
+				run++
+			}
+			return result, nil
+		},
+		Fix: func(prompt string) error {
+			prompts = append(prompts, prompt)
+			changes = append(changes, agent.TrackedFileChange{FilePath: "calc.go", OriginalCode: "a\nb\n", NewCode: "a\nc\nd\n"})
+			return nil
+		},
+		Changes:       func() []agent.TrackedFileChange { return changes },
+		Command:       "go test ./...",
+		MaxIterations: max,
+	}
+	return loop, &prompts
+}
+
+func failing(failures ...Failure) *RunResult {
+	return &RunResult{Command: "go test ./...", ExitCode: 1, Output: "FAIL", Failures: failures}
+}
+
+func TestLoopStopsWhenGreen(t *testing.T) {
+	loop, prompts := scriptedLoop([]*RunResult{
+		failing(Failure{Test: "TestAdd", File: "calc_test.go", Line: 3, Message: "want 4"}),
+		{Passed: true},
+	}, 5)
+
+	report, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !report.Passed || report.BudgetExhausted || len(report.Iterations) != 1 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if len(*prompts) != 1 || !strings.Contains((*prompts)[0], "TestAdd (calc_test.go:3): want 4") {
+		t.Fatalf("prompt should list structured failures, got %q", *prompts)
+	}
+	changes := report.Iterations[0].Changes
+	if len(changes) != 1 || changes[0] != (FileDelta{Path: "calc.go", Added: 2, Removed: 1}) {
+		t.Fatalf("unexpected iteration diff summary: %+v", changes)
+	}
+}
+
+func TestLoopExhaustsBudget(t *testing.T) {
+	loop, prompts := scriptedLoop([]*RunResult{failing()}, 2)
+
+	report, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if report.Passed || !report.BudgetExhausted || len(report.Iterations) != 2 || len(*prompts) != 2 {
+		t.Fatalf("expected 2 attempts and an exhausted budget, got %+v", report)
+	}
+	if !strings.Contains((*prompts)[1], "Repair attempt 2 of 2") {
+		t.Fatalf("prompt should state the attempt, got %q", (*prompts)[1])
+	}
+}
+
+func TestLoopAlreadyGreen(t *testing.T) {
+	loop, prompts := scriptedLoop([]*RunResult{{Passed: true}}, 3)
+
+	report, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !report.Passed || len(report.Iterations) != 0 || len(*prompts) != 0 {
+		t.Fatalf("expected no repair attempts, got %+v", report)
+	}
+}
+
+func TestLoopRecordsFixError(t *testing.T) {
+	loop, _ := scriptedLoop([]*RunResult{failing(), {Passed: true}}, 3)
+	loop.Fix = func(string) error { return errors.New("provider down") }
+
+	report, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if got := report.Iterations[0]; got.FixError != "provider down" || len(got.Changes) != 0 {
+		t.Fatalf("unexpected iteration: %+v", got)
+	}
+}
+
+func TestRunTestsReportsExitCode(t *testing.T) {
+	dir := t.TempDir()
+	result, err := RunTests(context.Background(), dir, "echo '--- FAIL: TestX (0.00s)'; exit 3", "go")
+	if err != nil {
+		t.Fatalf("RunTests: %v", err)
+	}
+	if result.Passed || result.ExitCode != 3 || len(result.Failures) != 1 || result.Failures[0].Test != "TestX" {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	result, err = RunTests(context.Background(), dir, "true", "go")
+	if err != nil || !result.Passed {
+		t.Fatalf("expected passing run, got %+v, %v", result, err)
+	}
+}
+
+func TestLoopConfirmsAffectedRunWithFullSuite(t *testing.T) {
+	loop, _ := scriptedLoop([]*RunResult{failing(), {Command: "go test ./...", Passed: true}}, 5)
+	affectedRuns := []*RunResult{
+		{Command: "go test example.com/calc", ExitCode: 1, Output: "FAIL"},
+		{Command: "go test example.com/calc", Passed: true},
+	}
+	var seen []int
+	loop.RunAffected = func(ctx context.Context, changes []agent.TrackedFileChange, previous *RunResult) (*RunResult, bool, error) {
+		seen = append(seen, len(changes))
+		result := affectedRuns[0]
+		affectedRuns = affectedRuns[1:]
+		return result, true, nil
+	}
+
+	report, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !report.Passed || len(report.Iterations) != 2 {
+		t.Fatalf("expected success after two attempts, got %+v", report)
+	}
+	if report.Final.Command != "go test ./..." {
+		t.Fatalf("final verdict should come from the full suite, got %q", report.Final.Command)
+	}
+	if first := report.Iterations[0]; first.Affected == nil || first.Affected.Passed {
+		t.Fatalf("first attempt should record the failing affected run, got %+v", first)
+	}
+	if len(seen) != 2 || seen[0] != 1 || seen[1] != 2 {
+		t.Fatalf("affected runs should see every change since the loop started, got %v", seen)
+	}
+}
+
+func TestLoopRunsFullSuiteWhenBudgetEndsOnAffectedRun(t *testing.T) {
+	loop, _ := scriptedLoop([]*RunResult{failing()}, 1)
+	loop.RunAffected = func(ctx context.Context, changes []agent.TrackedFileChange, previous *RunResult) (*RunResult, bool, error) {
+		return &RunResult{Command: "go test example.com/calc", ExitCode: 1}, true, nil
+	}
+
+	report, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !report.BudgetExhausted || report.Final.Command != "go test ./..." {
+		t.Fatalf("expected the full suite to decide the exhausted run, got %+v", report.Final)
+	}
+}
@@ -0,0 +1,204 @@
+package fixtests
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/alantheprice/ledit/pkg/filediscovery"
+)
+
+// Failure is one failing test (or build error) extracted from test output.
+type Failure struct {
+	Test    string `json:"test,omitempty"`
+	Package string `json:"package,omitempty"`
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// Location returns "file:line" (or just the file) when known.
+func (f Failure) Location() string {
+	if f.File == "" {
+		return ""
+	}
+	if f.Line > 0 {
+		return f.File + ":" + strconv.Itoa(f.Line)
+	}
+	return f.File
+}
+
+var (
+	goFailLine       = regexp.MustCompile(`^\s*--- FAIL: (\S+)`)
+	goPackageFail    = regexp.MustCompile(`^FAIL\s+(\S+)\s`)
+	goBuildHeader    = regexp.MustCompile(`^# (\S+)`)
+	goFileLine       = regexp.MustCompile(`^\s*([\w./\\-]+\.go):(\d+)(?::\d+)?: (.*)$`)
+	pytestFailed     = regexp.MustCompile(`^(?:FAILED|ERROR) ([^\s:]+)::(\S+)(?: - (.*))?$`)
+	pytestLocation   = regexp.MustCompile(`^([\w./\\-]+\.py):(\d+): (\w+)`)
+	jestBullet       = regexp.MustCompile(`^\s*● (.+)$`)
+	jestFileFail     = regexp.MustCompile(`^\s*FAIL\s+(\S+)`)
+	vitestCase       = regexp.MustCompile(`^\s*(?:×|✗|FAIL)\s+(\S+\.(?:[jt]sx?|mjs|cjs))\s+>\s+(.+?)(?:\s+\d+ms)?$`)
+	jsStackLocation  = regexp.MustCompile(`\(?([\w./\\-]+\.(?:[jt]sx?|mjs|cjs)):(\d+):\d+\)?`)
+	cargoTestFailed  = regexp.MustCompile(`^test (\S+) \.\.\. FAILED$`)
+	cargoPanicHeader = regexp.MustCompile(`^---- (\S+) stdout ----$`)
+	cargoPanicAt     = regexp.MustCompile(`panicked at ([\w./\\-]+\.rs):(\d+):\d+`)
+)
+
+// ParseFailures extracts structured failures from test output. Unknown
+// frameworks, or output none of the parsers understand, yield nil and callers
+// fall back to the raw output.
+func ParseFailures(framework, output string) []Failure {
+	lines := strings.Split(strings.ReplaceAll(output, "\r\n", "\n"), "\n")
+	switch framework {
+	case filediscovery.TestFrameworkGo:
+		return parseGoFailures(lines)
+	case filediscovery.TestFrameworkPytest:
+		return parsePytestFailures(lines)
+	case filediscovery.TestFrameworkJest, filediscovery.TestFrameworkVitest, filediscovery.TestFrameworkNPM:
+		return parseJSFailures(lines)
+	case filediscovery.TestFrameworkCargo:
+		return parseCargoFailures(lines)
+	}
+	return nil
+}
+
+func parseGoFailures(lines []string) []Failure {
+	var failures []Failure
+	current := -1 // index of the failure collecting messages
+	buildPkg := ""
+	pending := 0 // failures not yet assigned a package
+	for _, line := range lines {
+		if m := goFailLine.FindStringSubmatch(line); m != nil {
+			failures = append(failures, Failure{Test: m[1]})
+			current = len(failures) - 1
+			buildPkg = ""
+			continue
+		}
+		if m := goBuildHeader.FindStringSubmatch(line); m != nil {
+			buildPkg = m[1]
+			current = -1
+			continue
+		}
+		if m := goPackageFail.FindStringSubmatch(line); m != nil {
+			for i := pending; i < len(failures); i++ {
+				if failures[i].Package == "" {
+					failures[i].Package = m[1]
+				}
+			}
+			pending = len(failures)
+			current = -1
+			continue
+		}
+		m := goFileLine.FindStringSubmatch(line)
+		if m == nil {
+			if current >= 0 && strings.HasPrefix(line, "        ") && failures[current].Message != "" {
+				failures[current].Message += "\n" + strings.TrimSpace(line)
+			}
+			continue
+		}
+		lineNo, _ := strconv.Atoi(m[2])
+		switch {
+		case current >= 0 && failures[current].File == "":
+			failures[current].File, failures[current].Line, failures[current].Message = m[1], lineNo, strings.TrimSpace(m[3])
+		case current >= 0:
+			failures[current].Message += "\n" + strings.TrimSpace(m[3])
+		case buildPkg != "":
+			failures = append(failures, Failure{Package: buildPkg, File: m[1], Line: lineNo, Message: strings.TrimSpace(m[3])})
+		}
+	}
+	return failures
+}
+
+func parsePytestFailures(lines []string) []Failure {
+	var failures []Failure
+	locations := make(map[string]Failure)
+	for _, line := range lines {
+		if m := pytestLocation.FindStringSubmatch(line); m != nil {
+			lineNo, _ := strconv.Atoi(m[2])
+			locations[m[1]] = Failure{File: m[1], Line: lineNo}
+			continue
+		}
+		if m := pytestFailed.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			failure := Failure{File: m[1], Test: m[2], Message: m[3]}
+			if loc, ok := locations[m[1]]; ok {
+				failure.Line = loc.Line
+			}
+			failures = append(failures, failure)
+		}
+	}
+	return failures
+}
+
+func parseJSFailures(lines []string) []Failure {
+	var failures []Failure
+	file := ""
+	current := -1
+	for _, line := range lines {
+		if m := vitestCase.FindStringSubmatch(line); m != nil {
+			failures = append(failures, Failure{File: m[1], Test: strings.TrimSpace(m[2])})
+			current = len(failures) - 1
+			continue
+		}
+		if m := jestFileFail.FindStringSubmatch(line); m != nil {
+			file = m[1]
+			current = -1
+			continue
+		}
+		if m := jestBullet.FindStringSubmatch(line); m != nil {
+			failures = append(failures, Failure{File: file, Test: strings.TrimSpace(m[1])})
+			current = len(failures) - 1
+			continue
+		}
+		if current < 0 {
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+		if failures[current].Line == 0 {
+			if m := jsStackLocation.FindStringSubmatch(trimmed); m != nil && !strings.Contains(m[1], "node_modules") {
+				failures[current].File = m[1]
+				failures[current].Line, _ = strconv.Atoi(m[2])
+				continue
+			}
+		}
+		if failures[current].Message == "" && trimmed != "" && !strings.HasPrefix(trimmed, "at ") {
+			failures[current].Message = trimmed
+		}
+	}
+	return failures
+}
+
+func parseCargoFailures(lines []string) []Failure {
+	var failures []Failure
+	index := make(map[string]int)
+	current := ""
+	for _, line := range lines {
+		if m := cargoTestFailed.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			if _, ok := index[m[1]]; !ok {
+				index[m[1]] = len(failures)
+				failures = append(failures, Failure{Test: m[1]})
+			}
+			continue
+		}
+		if m := cargoPanicHeader.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			current = m[1]
+			if _, ok := index[current]; !ok {
+				index[current] = len(failures)
+				failures = append(failures, Failure{Test: current})
+			}
+			continue
+		}
+		if current == "" {
+			continue
+		}
+		f := &failures[index[current]]
+		if m := cargoPanicAt.FindStringSubmatch(line); m != nil {
+			f.File = m[1]
+			f.Line, _ = strconv.Atoi(m[2])
+			continue
+		}
+		if f.File != "" && f.Message == "" && strings.TrimSpace(line) != "" {
+			f.Message = strings.TrimSpace(line)
+		}
+	}
+	return failures
+}
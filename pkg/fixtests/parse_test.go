@@ -0,0 +1,96 @@
+package fixtests
+
+import (
+	"testing"
+
+	"github.com/alantheprice/ledit/pkg/filediscovery"
+)
+
+func TestParseGoFailures(t *testing.T) {
+	output := `--- FAIL: TestAdd (0.00s)
+    math_test.go:12: expected 4, got 5
+--- FAIL: TestSub (0.00s)
+    math_test.go:20: expected 1, got 0
+FAIL
+FAIL	example.com/calc	0.003s
+# example.com/calc/broken
+broken/broken.go:7:2: undefined: missing
+FAIL	example.com/calc/broken [build failed]
+`
+	failures := ParseFailures(filediscovery.TestFrameworkGo, output)
+	if len(failures) != 3 {
+		t.Fatalf("expected 3 failures, got %+v", failures)
+	}
+	want := Failure{Test: "TestAdd", Package: "example.com/calc", File: "math_test.go", Line: 12, Message: "expected 4, got 5"}
+	if failures[0] != want {
+		t.Fatalf("unexpected first failure: %+v", failures[0])
+	}
+	if failures[1].Test != "TestSub" || failures[1].Line != 20 {
+		t.Fatalf("unexpected second failure: %+v", failures[1])
+	}
+	build := failures[2]
+	if build.Package != "example.com/calc/broken" || build.File != "broken/broken.go" || build.Line != 7 || build.Message != "undefined: missing" {
+		t.Fatalf("unexpected build failure: %+v", build)
+	}
+}
+
+func TestParsePytestFailures(t *testing.T) {
+	output := `tests/test_math.py:8: AssertionError
+=========================== short test summary info ============================
+FAILED tests/test_math.py::test_add - assert 5 == 4
+1 failed, 3 passed in 0.02s
+`
+	failures := ParseFailures(filediscovery.TestFrameworkPytest, output)
+	if len(failures) != 1 {
+		t.Fatalf("expected 1 failure, got %+v", failures)
+	}
+	want := Failure{Test: "test_add", File: "tests/test_math.py", Line: 8, Message: "assert 5 == 4"}
+	if failures[0] != want {
+		t.Fatalf("unexpected failure: %+v", failures[0])
+	}
+}
+
+func TestParseJestFailures(t *testing.T) {
+	output := ` FAIL  src/math.test.js
+  ● math › adds numbers
+
+    expect(received).toBe(expected) // Object.is equality
+
+      at Object.<anonymous> (src/math.test.js:5:17)
+`
+	failures := ParseFailures(filediscovery.TestFrameworkJest, output)
+	if len(failures) != 1 {
+		t.Fatalf("expected 1 failure, got %+v", failures)
+	}
+	want := Failure{Test: "math › adds numbers", File: "src/math.test.js", Line: 5, Message: "expect(received).toBe(expected) // Object.is equality"}
+	if failures[0] != want {
+		t.Fatalf("unexpected failure: %+v", failures[0])
+	}
+}
+
+func TestParseCargoFailures(t *testing.T) {
+	output := `running 2 tests
+test tests::adds ... FAILED
+test tests::subs ... ok
+
+failures:
+
+---- tests::adds stdout ----
+thread 'tests::adds' panicked at src/lib.rs:10:9:
+assertion failed: 2 + 2 == 5
+`
+	failures := ParseFailures(filediscovery.TestFrameworkCargo, output)
+	if len(failures) != 1 {
+		t.Fatalf("expected 1 failure, got %+v", failures)
+	}
+	want := Failure{Test: "tests::adds", File: "src/lib.rs", Line: 10, Message: "assertion failed: 2 + 2 == 5"}
+	if failures[0] != want {
+		t.Fatalf("unexpected failure: %+v", failures[0])
+	}
+}
+
+func TestParseFailuresUnknownFramework(t *testing.T) {
+	if failures := ParseFailures("", "something failed"); failures != nil {
+		t.Fatalf("expected nil for unknown framework, got %+v", failures)
+	}
+}
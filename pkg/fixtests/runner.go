@@ -0,0 +1,54 @@
+package fixtests
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+// RunResult is the outcome of one test command run.
+type RunResult struct {
+	Command  string        `json:"command"`
+	Passed   bool          `json:"passed"`
+	ExitCode int           `json:"exit_code"`
+	Output   string        `json:"output"`
+	Failures []Failure     `json:"failures,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// RunTests runs command through the shell in dir and parses its failures.
+// A non-zero exit code is a test failure, not an error; errors are reserved
+// for commands that could not be run at all.
+func RunTests(ctx context.Context, dir, command, framework string) (*RunResult, error) {
+	start := time.Now()
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Dir = dir
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	err := cmd.Run()
+	result := &RunResult{
+		Command:  command,
+		Output:   output.String(),
+		Duration: time.Since(start),
+	}
+	if err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("failed to run test command %q: %w", command, err)
+		}
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("test command %q: %w", command, ctx.Err())
+		}
+		result.ExitCode = exitErr.ExitCode()
+	}
+	result.Passed = result.ExitCode == 0
+	if !result.Passed {
+		result.Failures = ParseFailures(framework, result.Output)
+	}
+	return result, nil
+}
@@ -0,0 +1,129 @@
+package forge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const perPage = 100
+
+// apiClient sends JSON requests to a forge REST API.
+type apiClient struct {
+	baseURL string
+	headers map[string]string
+	http    *http.Client
+}
+
+func newAPIClient(baseURL string, headers map[string]string) *apiClient {
+	return &apiClient{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		headers: headers,
+		http:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// do sends a request with body encoded as JSON and decodes the response
+// into out when it is not nil.
+func (c *apiClient) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for key, value := range c.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("%s %s: read response: %w", method, path, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s: %s%s", method, path, resp.Status, apiErrorMessage(data))
+	}
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("%s %s: decode response: %w", method, path, err)
+	}
+	return nil
+}
+
+// apiErrorMessage extracts the message of a GitHub or GitLab error body.
+func apiErrorMessage(data []byte) string {
+	var body struct {
+		Message interface{} `json:"message"`
+		Error   string      `json:"error"`
+		Errors  []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return ""
+	}
+	var parts []string
+	switch msg := body.Message.(type) {
+	case string:
+		parts = append(parts, msg)
+	case nil:
+	default:
+		encoded, _ := json.Marshal(msg)
+		parts = append(parts, string(encoded))
+	}
+	if body.Error != "" {
+		parts = append(parts, body.Error)
+	}
+	for _, e := range body.Errors {
+		if e.Message != "" {
+			parts = append(parts, e.Message)
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return ": " + strings.Join(parts, "; ")
+}
+
+// getAllPages fetches every page of a list endpoint.
+func getAllPages[T any](ctx context.Context, c *apiClient, path string) ([]T, error) {
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	var all []T
+	for page := 1; ; page++ {
+		var items []T
+		pagePath := path + sep + "per_page=" + strconv.Itoa(perPage) + "&page=" + strconv.Itoa(page)
+		if err := c.do(ctx, http.MethodGet, pagePath, nil, &items); err != nil {
+			return nil, err
+		}
+		all = append(all, items...)
+		if len(items) < perPage {
+			return all, nil
+		}
+	}
+}
@@ -0,0 +1,236 @@
+// Package forge talks to the code hosting service ("forge") behind the
+// repository's origin remote: GitHub or GitLab, hosted or self-managed. It
+// opens pull requests (merge requests on GitLab), lists their review comments,
+// replies to them and posts reviews. It also reads and comments on issues, on
+// those forges and on Jira.
+package forge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/alantheprice/ledit/pkg/credentials"
+)
+
+// Supported forge kinds. They double as the credential names tokens are
+// stored under (GITHUB_TOKEN and GITLAB_TOKEN in the environment).
+const (
+	KindGitHub = "github"
+	KindGitLab = "gitlab"
+)
+
+// Repo identifies a repository on a forge.
+type Repo struct {
+	Kind  string // KindGitHub or KindGitLab
+	Host  string // e.g. github.com or gitlab.example.com
+	Owner string // user, organization or (nested) GitLab group
+	Name  string
+}
+
+// FullName returns owner/name.
+func (r Repo) FullName() string {
+	return r.Owner + "/" + r.Name
+}
+
+// PullRequest is a GitHub pull request or GitLab merge request.
+type PullRequest struct {
+	Number     int
+	Title      string
+	Body       string
+	URL        string
+	HeadBranch string
+	BaseBranch string
+	HeadSHA    string
+	State      string
+	Draft      bool
+}
+
+// Comment is a review or conversation comment on a pull request. Comments
+// with a DiscussionID belong to a thread, which replies are added to.
+type Comment struct {
+	ID           string
+	DiscussionID string
+	Reply        bool // a reply within a thread rather than its first comment
+	Author       string
+	Body         string
+	Path         string // file the comment is attached to, empty for general comments
+	Line         int
+	URL          string
+	CreatedAt    time.Time
+}
+
+// Location describes where a comment is attached, e.g. "main.go:12".
+func (c Comment) Location() string {
+	if c.Path == "" {
+		return ""
+	}
+	if c.Line > 0 {
+		return fmt.Sprintf("%s:%d", c.Path, c.Line)
+	}
+	return c.Path
+}
+
+// CreateRequest describes a pull request to open.
+type CreateRequest struct {
+	Title string
+	Body  string
+	Head  string
+	Base  string
+	Draft bool
+}
+
+// Forge is the API of a code hosting service.
+type Forge interface {
+	Repo() Repo
+	// CreatePullRequest opens a pull request.
+	CreatePullRequest(ctx context.Context, req CreateRequest) (*PullRequest, error)
+	// FindPullRequest returns the open pull request for branch, or nil.
+	FindPullRequest(ctx context.Context, branch string) (*PullRequest, error)
+	// GetPullRequest returns a pull request by number.
+	GetPullRequest(ctx context.Context, number int) (*PullRequest, error)
+	// ListComments returns the review and conversation comments of a pull
+	// request, oldest first.
+	ListComments(ctx context.Context, number int) ([]Comment, error)
+	// Reply answers a comment, in its thread where the forge supports it.
+	Reply(ctx context.Context, number int, comment Comment, body string) (*Comment, error)
+	// PostReview posts inline comments on a pull request's diff.
+	PostReview(ctx context.Context, number int, review Review) error
+}
+
+// ErrNoToken is returned when no API token is configured for a forge.
+var ErrNoToken = errors.New("no API token configured")
+
+// New returns the client for repo, authenticated with token.
+func New(repo Repo, token string) (Forge, error) {
+	switch repo.Kind {
+	case KindGitHub:
+		return NewGitHub(repo, token, ""), nil
+	case KindGitLab:
+		return NewGitLab(repo, token, ""), nil
+	default:
+		return nil, fmt.Errorf("unsupported forge %q for host %s", repo.Kind, repo.Host)
+	}
+}
+
+// ResolveToken returns the API token for a forge kind: GITHUB_TOKEN (or
+// GH_TOKEN) / GITLAB_TOKEN from the environment, then the stored credential.
+func ResolveToken(kind string) (string, error) {
+	resolved, err := credentials.ResolveProvider(kind)
+	if err == nil && strings.TrimSpace(resolved.Value) != "" {
+		return strings.TrimSpace(resolved.Value), nil
+	}
+	if kind == KindGitHub {
+		if token := strings.TrimSpace(os.Getenv("GH_TOKEN")); token != "" {
+			return token, nil
+		}
+	}
+	return "", fmt.Errorf("%w for %s: set %s or run /pr token %s", ErrNoToken, kind, credentials.ProviderEnvVar(kind), kind)
+}
+
+// ParseRemoteURL parses a git remote URL (https, ssh:// or scp-style
+// git@host:owner/name.git). hosts maps self-managed hosts to their kind;
+// otherwise the kind is guessed from the host name.
+func ParseRemoteURL(remote string, hosts map[string]string) (Repo, error) {
+	remote = strings.TrimSpace(remote)
+	var host, path string
+	if strings.Contains(remote, "://") {
+		u, err := url.Parse(remote)
+		if err != nil {
+			return Repo{}, fmt.Errorf("parse remote %q: %w", remote, err)
+		}
+		host, path = u.Host, u.Path
+		if u.Scheme == "ssh" || u.Scheme == "git" {
+			host = u.Hostname()
+		}
+	} else if at := strings.Index(remote, "@"); at >= 0 && strings.Contains(remote[at:], ":") {
+		rest := remote[at+1:]
+		colon := strings.Index(rest, ":")
+		host, path = rest[:colon], rest[colon+1:]
+	} else {
+		return Repo{}, fmt.Errorf("unrecognized remote URL %q", remote)
+	}
+
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	slash := strings.LastIndex(path, "/")
+	if host == "" || slash <= 0 || slash == len(path)-1 {
+		return Repo{}, fmt.Errorf("remote %q does not name an owner/repository", remote)
+	}
+	repo := Repo{Host: strings.ToLower(host), Owner: path[:slash], Name: path[slash+1:]}
+
+	if kind, ok := hosts[repo.Host]; ok {
+		repo.Kind = strings.ToLower(kind)
+	} else if strings.Contains(repo.Host, "github") {
+		repo.Kind = KindGitHub
+	} else if strings.Contains(repo.Host, "gitlab") {
+		repo.Kind = KindGitLab
+	} else {
+		return Repo{}, fmt.Errorf("cannot tell whether %s is GitHub or GitLab; add it to forge_hosts in the config", repo.Host)
+	}
+	return repo, nil
+}
+
+// Workspace is the git state a pull request is opened from.
+type Workspace struct {
+	Repo   Repo
+	Remote string // remote name, "origin"
+	Branch string // current branch
+	Base   string // default branch of the remote
+}
+
+// Detect reads the origin remote, current branch and default branch of the
+// repository in dir.
+func Detect(dir string, hosts map[string]string) (*Workspace, error) {
+	remoteURL, err := gitOutput(dir, "remote", "get-url", "origin")
+	if err != nil {
+		return nil, fmt.Errorf("no origin remote: %w", err)
+	}
+	repo, err := ParseRemoteURL(remoteURL, hosts)
+	if err != nil {
+		return nil, err
+	}
+	branch, err := gitOutput(dir, "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil || branch == "HEAD" {
+		return nil, errors.New("not on a branch (detached HEAD)")
+	}
+	ws := &Workspace{Repo: repo, Remote: "origin", Branch: branch, Base: "main"}
+	if head, err := gitOutput(dir, "symbolic-ref", "--short", "refs/remotes/origin/HEAD"); err == nil {
+		ws.Base = strings.TrimPrefix(head, "origin/")
+	}
+	return ws, nil
+}
+
+// IsPushed reports whether the remote branch exists and contains HEAD.
+func (w *Workspace) IsPushed(dir string) bool {
+	remoteRef := "refs/remotes/" + w.Remote + "/" + w.Branch
+	if _, err := gitOutput(dir, "rev-parse", "--verify", "--quiet", remoteRef); err != nil {
+		return false
+	}
+	_, err := gitOutput(dir, "merge-base", "--is-ancestor", "HEAD", remoteRef)
+	return err == nil
+}
+
+// Push pushes the current branch and sets its upstream.
+func (w *Workspace) Push(ctx context.Context, dir string) error {
+	cmd := exec.CommandContext(ctx, "git", "push", "--set-upstream", w.Remote, w.Branch)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git push %s %s: %s", w.Remote, w.Branch, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func gitOutput(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
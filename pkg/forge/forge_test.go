@@ -0,0 +1,216 @@
+package forge
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseRemoteURL(t *testing.T) {
+	tests := []struct {
+		remote string
+		want   Repo
+	}{
+		{"https://github.com/alantheprice/ledit.git", Repo{KindGitHub, "github.com", "alantheprice", "ledit"}},
+		{"git@github.com:alantheprice/ledit.git", Repo{KindGitHub, "github.com", "alantheprice", "ledit"}},
+		{"ssh://git@gitlab.com:2222/group/sub/project.git", Repo{KindGitLab, "gitlab.com", "group/sub", "project"}},
+		{"https://token@gitlab.example.com/team/app", Repo{KindGitLab, "gitlab.example.com", "team", "app"}},
+		{"git@git.example.com:team/app.git", Repo{KindGitLab, "git.example.com", "team", "app"}},
+	}
+	hosts := map[string]string{"git.example.com": "gitlab"}
+	for _, tt := range tests {
+		got, err := ParseRemoteURL(tt.remote, hosts)
+		if err != nil {
+			t.Fatalf("ParseRemoteURL(%q): %v", tt.remote, err)
+		}
+		if got != tt.want {
+			t.Errorf("ParseRemoteURL(%q) = %+v, want %+v", tt.remote, got, tt.want)
+		}
+	}
+
+	for _, remote := range []string{"/srv/git/app.git", "https://github.com/ledit", "git@bitbucket.org:team/app.git"} {
+		if _, err := ParseRemoteURL(remote, nil); err == nil {
+			t.Errorf("expected %q to be rejected", remote)
+		}
+	}
+}
+
+// fakeForge serves canned JSON responses keyed by "METHOD escaped-path" and
+// records request bodies.
+type fakeForge struct {
+	t         *testing.T
+	responses map[string]string
+	bodies    map[string]map[string]interface{}
+	headers   http.Header
+}
+
+func newFakeForge(t *testing.T, responses map[string]string) (*fakeForge, *httptest.Server) {
+	f := &fakeForge{t: t, responses: responses, bodies: map[string]map[string]interface{}{}}
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+	return f, server
+}
+
+func (f *fakeForge) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.headers = r.Header.Clone()
+	key := r.Method + " " + r.URL.EscapedPath()
+	if r.Method == http.MethodGet && r.URL.Query().Get("page") != "" && r.URL.Query().Get("page") != "1" {
+		io.WriteString(w, "[]")
+		return
+	}
+	if r.Body != nil {
+		var body map[string]interface{}
+		if data, _ := io.ReadAll(r.Body); len(data) > 0 {
+			if err := json.Unmarshal(data, &body); err != nil {
+				f.t.Errorf("%s: invalid JSON body: %v", key, err)
+			}
+			f.bodies[key] = body
+		}
+	}
+	response, ok := f.responses[key]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		io.WriteString(w, `{"message": "Not Found"}`)
+		return
+	}
+	io.WriteString(w, response)
+}
+
+func TestGitHubPullRequestFlow(t *testing.T) {
+	fake, server := newFakeForge(t, map[string]string{
+		"POST /repos/acme/app/pulls": `{"number": 7, "title": "Add login", "html_url": "https://github.com/acme/app/pull/7", "state": "open", "draft": true, "head": {"ref": "login"}, "base": {"ref": "main"}}`,
+		"GET /repos/acme/app/pulls":  `[{"number": 7, "title": "Add login", "head": {"ref": "login"}, "base": {"ref": "main"}}]`,
+		"GET /repos/acme/app/pulls/7/comments": `[
+			{"id": 11, "body": "Rename this", "path": "login.go", "line": 12, "created_at": "2026-01-02T10:00:00Z", "user": {"login": "rev"}},
+			{"id": 13, "in_reply_to_id": 11, "body": "Agreed", "path": "login.go", "original_line": 12, "created_at": "2026-01-02T12:00:00Z", "user": {"login": "other"}}
+		]`,
+		"GET /repos/acme/app/issues/7/comments":            `[{"id": 12, "body": "Needs a test", "created_at": "2026-01-02T11:00:00Z", "user": {"login": "rev"}}]`,
+		"POST /repos/acme/app/pulls/7/comments/11/replies": `{"id": 14, "in_reply_to_id": 11, "body": "Done"}`,
+		"POST /repos/acme/app/issues/7/comments":           `{"id": 15, "body": "@rev Added"}`,
+	})
+	client := NewGitHub(Repo{KindGitHub, "github.com", "acme", "app"}, "secret", server.URL)
+	ctx := context.Background()
+
+	pr, err := client.CreatePullRequest(ctx, CreateRequest{Title: "Add login", Head: "login", Base: "main", Draft: true})
+	if err != nil {
+		t.Fatalf("CreatePullRequest: %v", err)
+	}
+	if pr.Number != 7 || !pr.Draft || pr.HeadBranch != "login" {
+		t.Fatalf("unexpected pull request %+v", pr)
+	}
+	if got := fake.headers.Get("Authorization"); got != "Bearer secret" {
+		t.Fatalf("Authorization = %q", got)
+	}
+	if body := fake.bodies["POST /repos/acme/app/pulls"]; body["draft"] != true || body["base"] != "main" {
+		t.Fatalf("unexpected create body %v", body)
+	}
+
+	if found, err := client.FindPullRequest(ctx, "login"); err != nil || found == nil || found.Number != 7 {
+		t.Fatalf("FindPullRequest = %+v, %v", found, err)
+	}
+
+	comments, err := client.ListComments(ctx, 7)
+	if err != nil {
+		t.Fatalf("ListComments: %v", err)
+	}
+	var ids []string
+	for _, c := range comments {
+		ids = append(ids, c.ID)
+	}
+	if strings.Join(ids, ",") != "11,12,13" {
+		t.Fatalf("expected comments ordered by time, got %v", ids)
+	}
+	if comments[0].Location() != "login.go:12" || comments[0].Reply || comments[0].DiscussionID != "11" {
+		t.Fatalf("unexpected review comment %+v", comments[0])
+	}
+	if !comments[2].Reply || comments[2].DiscussionID != "11" || comments[2].Line != 12 {
+		t.Fatalf("unexpected reply %+v", comments[2])
+	}
+
+	if _, err := client.Reply(ctx, 7, comments[2], "Done"); err != nil {
+		t.Fatalf("Reply to review thread: %v", err)
+	}
+	if _, err := client.Reply(ctx, 7, comments[1], "Added"); err != nil {
+		t.Fatalf("Reply to conversation comment: %v", err)
+	}
+	if body := fake.bodies["POST /repos/acme/app/issues/7/comments"]; body["body"] != "@rev Added" {
+		t.Fatalf("expected conversation reply to mention the author, got %v", body)
+	}
+}
+
+func TestGitLabMergeRequestFlow(t *testing.T) {
+	fake, server := newFakeForge(t, map[string]string{
+		"POST /projects/group%2Fsub%2Fapp/merge_requests":  `{"iid": 3, "title": "Draft: Add login", "web_url": "https://gitlab.com/group/sub/app/-/merge_requests/3", "state": "opened", "source_branch": "login", "target_branch": "main"}`,
+		"GET /projects/group%2Fsub%2Fapp/merge_requests":   `[]`,
+		"GET /projects/group%2Fsub%2Fapp/merge_requests/3": `{"iid": 3, "web_url": "https://gitlab.com/group/sub/app/-/merge_requests/3"}`,
+		"GET /projects/group%2Fsub%2Fapp/merge_requests/3/discussions": `[
+			{"id": "abc", "notes": [
+				{"id": 21, "body": "Rename this", "created_at": "2026-01-02T10:00:00Z", "author": {"username": "rev"}, "position": {"new_path": "login.go", "new_line": 12}},
+				{"id": 22, "body": "Agreed", "created_at": "2026-01-02T11:00:00Z", "author": {"username": "other"}}
+			]},
+			{"id": "def", "notes": [{"id": 23, "body": "added 1 commit", "system": true, "created_at": "2026-01-02T12:00:00Z"}]}
+		]`,
+		"POST /projects/group%2Fsub%2Fapp/merge_requests/3/discussions/abc/notes": `{"id": 24, "body": "Done"}`,
+	})
+	client := NewGitLab(Repo{KindGitLab, "gitlab.com", "group/sub", "app"}, "secret", server.URL)
+	ctx := context.Background()
+
+	pr, err := client.CreatePullRequest(ctx, CreateRequest{Title: "Add login", Head: "login", Base: "main", Draft: true})
+	if err != nil {
+		t.Fatalf("CreatePullRequest: %v", err)
+	}
+	if pr.Number != 3 || !pr.Draft {
+		t.Fatalf("unexpected merge request %+v", pr)
+	}
+	if got := fake.headers.Get("PRIVATE-TOKEN"); got != "secret" {
+		t.Fatalf("PRIVATE-TOKEN = %q", got)
+	}
+	if body := fake.bodies["POST /projects/group%2Fsub%2Fapp/merge_requests"]; body["title"] != "Draft: Add login" || body["source_branch"] != "login" {
+		t.Fatalf("unexpected create body %v", body)
+	}
+
+	if found, err := client.FindPullRequest(ctx, "login"); err != nil || found != nil {
+		t.Fatalf("expected no open merge request, got %+v, %v", found, err)
+	}
+
+	comments, err := client.ListComments(ctx, 3)
+	if err != nil {
+		t.Fatalf("ListComments: %v", err)
+	}
+	if len(comments) != 2 {
+		t.Fatalf("expected system notes to be skipped, got %+v", comments)
+	}
+	first := comments[0]
+	if first.DiscussionID != "abc" || first.Reply || first.Location() != "login.go:12" || !strings.HasSuffix(first.URL, "#note_21") {
+		t.Fatalf("unexpected first note %+v", first)
+	}
+	if !comments[1].Reply {
+		t.Fatalf("expected second note to be a reply: %+v", comments[1])
+	}
+
+	reply, err := client.Reply(ctx, 3, comments[1], "Done")
+	if err != nil {
+		t.Fatalf("Reply: %v", err)
+	}
+	if reply.ID != "24" || reply.DiscussionID != "abc" {
+		t.Fatalf("unexpected reply %+v", reply)
+	}
+}
+
+func TestAPIErrorsIncludeMessage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		io.WriteString(w, `{"message": "Validation Failed", "errors": [{"message": "A pull request already exists for acme:login."}]}`)
+	}))
+	defer server.Close()
+
+	client := NewGitHub(Repo{KindGitHub, "github.com", "acme", "app"}, "secret", server.URL)
+	_, err := client.CreatePullRequest(context.Background(), CreateRequest{Title: "x", Head: "login", Base: "main"})
+	if err == nil || !strings.Contains(err.Error(), "422") || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("expected API error details, got %v", err)
+	}
+}
@@ -0,0 +1,191 @@
+package forge
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// GitHub is a client for the GitHub REST API, including GitHub Enterprise
+// Server.
+type GitHub struct {
+	repo Repo
+	api  *apiClient
+}
+
+// NewGitHub returns a GitHub client. baseURL defaults to api.github.com, or
+// https://<host>/api/v3 for GitHub Enterprise hosts.
+func NewGitHub(repo Repo, token, baseURL string) *GitHub {
+	if baseURL == "" {
+		baseURL = "https://api.github.com"
+		if repo.Host != "github.com" {
+			baseURL = "https://" + repo.Host + "/api/v3"
+		}
+	}
+	return &GitHub{repo: repo, api: newAPIClient(baseURL, map[string]string{
+		"Authorization":        "Bearer " + token,
+		"Accept":               "application/vnd.github+json",
+		"X-GitHub-Api-Version": "2022-11-28",
+	})}
+}
+
+type githubPull struct {
+	Number  int    `json:"number"`
+	Title   string `json:"title"`
+	Body    string `json:"body"`
+	HTMLURL string `json:"html_url"`
+	State   string `json:"state"`
+	Draft   bool   `json:"draft"`
+	Head    struct {
+		Ref string `json:"ref"`
+		SHA string `json:"sha"`
+	} `json:"head"`
+	Base struct {
+		Ref string `json:"ref"`
+	} `json:"base"`
+}
+
+func (p githubPull) pullRequest() *PullRequest {
+	return &PullRequest{
+		Number:     p.Number,
+		Title:      p.Title,
+		Body:       p.Body,
+		URL:        p.HTMLURL,
+		HeadBranch: p.Head.Ref,
+		BaseBranch: p.Base.Ref,
+		HeadSHA:    p.Head.SHA,
+		State:      p.State,
+		Draft:      p.Draft,
+	}
+}
+
+type githubComment struct {
+	ID           int64     `json:"id"`
+	InReplyToID  int64     `json:"in_reply_to_id"`
+	Body         string    `json:"body"`
+	Path         string    `json:"path"`
+	Line         int       `json:"line"`
+	OriginalLine int       `json:"original_line"`
+	HTMLURL      string    `json:"html_url"`
+	CreatedAt    time.Time `json:"created_at"`
+	User         struct {
+		Login string `json:"login"`
+	} `json:"user"`
+}
+
+// comment converts a GitHub comment. Review comments get the ID of the
+// first comment of their thread as DiscussionID, which is what replies go to.
+func (c githubComment) comment(review bool) Comment {
+	comment := Comment{
+		ID:        strconv.FormatInt(c.ID, 10),
+		Author:    c.User.Login,
+		Body:      c.Body,
+		Path:      c.Path,
+		Line:      c.Line,
+		URL:       c.HTMLURL,
+		CreatedAt: c.CreatedAt,
+	}
+	if comment.Line == 0 {
+		comment.Line = c.OriginalLine
+	}
+	if review {
+		thread := c.ID
+		if c.InReplyToID != 0 {
+			thread = c.InReplyToID
+			comment.Reply = true
+		}
+		comment.DiscussionID = strconv.FormatInt(thread, 10)
+	}
+	return comment
+}
+
+// Repo returns the repository the client acts on.
+func (g *GitHub) Repo() Repo {
+	return g.repo
+}
+
+func (g *GitHub) repoPath() string {
+	return "/repos/" + url.PathEscape(g.repo.Owner) + "/" + url.PathEscape(g.repo.Name)
+}
+
+// CreatePullRequest opens a pull request.
+func (g *GitHub) CreatePullRequest(ctx context.Context, req CreateRequest) (*PullRequest, error) {
+	payload := map[string]interface{}{
+		"title": req.Title,
+		"body":  req.Body,
+		"head":  req.Head,
+		"base":  req.Base,
+		"draft": req.Draft,
+	}
+	var pull githubPull
+	if err := g.api.do(ctx, http.MethodPost, g.repoPath()+"/pulls", payload, &pull); err != nil {
+		return nil, err
+	}
+	return pull.pullRequest(), nil
+}
+
+// FindPullRequest returns the open pull request for branch, or nil.
+func (g *GitHub) FindPullRequest(ctx context.Context, branch string) (*PullRequest, error) {
+	query := url.Values{"state": {"open"}, "head": {g.repo.Owner + ":" + branch}}
+	var pulls []githubPull
+	if err := g.api.do(ctx, http.MethodGet, g.repoPath()+"/pulls?"+query.Encode(), nil, &pulls); err != nil {
+		return nil, err
+	}
+	if len(pulls) == 0 {
+		return nil, nil
+	}
+	return pulls[0].pullRequest(), nil
+}
+
+// ListComments returns the review comments and the conversation comments of
+// a pull request, oldest first.
+func (g *GitHub) ListComments(ctx context.Context, number int) ([]Comment, error) {
+	review, err := getAllPages[githubComment](ctx, g.api, fmt.Sprintf("%s/pulls/%d/comments", g.repoPath(), number))
+	if err != nil {
+		return nil, err
+	}
+	conversation, err := getAllPages[githubComment](ctx, g.api, fmt.Sprintf("%s/issues/%d/comments", g.repoPath(), number))
+	if err != nil {
+		return nil, err
+	}
+
+	comments := make([]Comment, 0, len(review)+len(conversation))
+	for _, c := range review {
+		comments = append(comments, c.comment(true))
+	}
+	for _, c := range conversation {
+		comments = append(comments, c.comment(false))
+	}
+	sort.SliceStable(comments, func(i, j int) bool {
+		return comments[i].CreatedAt.Before(comments[j].CreatedAt)
+	})
+	return comments, nil
+}
+
+// Reply answers a review comment in its thread. Conversation comments have
+// no threads on GitHub, so the reply is a new conversation comment.
+func (g *GitHub) Reply(ctx context.Context, number int, comment Comment, body string) (*Comment, error) {
+	var reply githubComment
+	if comment.DiscussionID != "" {
+		path := fmt.Sprintf("%s/pulls/%d/comments/%s/replies", g.repoPath(), number, url.PathEscape(comment.DiscussionID))
+		if err := g.api.do(ctx, http.MethodPost, path, map[string]string{"body": body}, &reply); err != nil {
+			return nil, err
+		}
+		c := reply.comment(true)
+		return &c, nil
+	}
+
+	if comment.Author != "" {
+		body = "@" + comment.Author + " " + body
+	}
+	path := fmt.Sprintf("%s/issues/%d/comments", g.repoPath(), number)
+	if err := g.api.do(ctx, http.MethodPost, path, map[string]string{"body": body}, &reply); err != nil {
+		return nil, err
+	}
+	c := reply.comment(false)
+	return &c, nil
+}
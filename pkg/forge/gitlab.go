@@ -0,0 +1,196 @@
+package forge
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// draftPrefix marks a GitLab merge request as a draft.
+const draftPrefix = "Draft: "
+
+// GitLab is a client for the GitLab REST API (gitlab.com or self-managed).
+// Pull requests are merge requests; their number is the merge request IID.
+type GitLab struct {
+	repo Repo
+	api  *apiClient
+}
+
+// NewGitLab returns a GitLab client. baseURL defaults to
+// https://<host>/api/v4.
+func NewGitLab(repo Repo, token, baseURL string) *GitLab {
+	if baseURL == "" {
+		baseURL = "https://" + repo.Host + "/api/v4"
+	}
+	return &GitLab{repo: repo, api: newAPIClient(baseURL, map[string]string{
+		"PRIVATE-TOKEN": token,
+	})}
+}
+
+type gitlabMergeRequest struct {
+	IID          int    `json:"iid"`
+	Title        string `json:"title"`
+	Description  string `json:"description"`
+	WebURL       string `json:"web_url"`
+	State        string `json:"state"`
+	Draft        bool   `json:"draft"`
+	SourceBranch string `json:"source_branch"`
+	TargetBranch string `json:"target_branch"`
+	SHA          string `json:"sha"`
+	DiffRefs     struct {
+		BaseSHA  string `json:"base_sha"`
+		StartSHA string `json:"start_sha"`
+		HeadSHA  string `json:"head_sha"`
+	} `json:"diff_refs"`
+}
+
+func (mr gitlabMergeRequest) pullRequest() *PullRequest {
+	return &PullRequest{
+		Number:     mr.IID,
+		Title:      mr.Title,
+		Body:       mr.Description,
+		URL:        mr.WebURL,
+		HeadBranch: mr.SourceBranch,
+		BaseBranch: mr.TargetBranch,
+		HeadSHA:    mr.SHA,
+		State:      mr.State,
+		Draft:      mr.Draft || strings.HasPrefix(mr.Title, draftPrefix),
+	}
+}
+
+type gitlabNote struct {
+	ID        int64     `json:"id"`
+	Body      string    `json:"body"`
+	System    bool      `json:"system"`
+	CreatedAt time.Time `json:"created_at"`
+	Author    struct {
+		Username string `json:"username"`
+	} `json:"author"`
+	Position *struct {
+		NewPath string `json:"new_path"`
+		NewLine int    `json:"new_line"`
+		OldPath string `json:"old_path"`
+		OldLine int    `json:"old_line"`
+	} `json:"position"`
+}
+
+func (n gitlabNote) comment(discussionID, mrURL string) Comment {
+	comment := Comment{
+		ID:           strconv.FormatInt(n.ID, 10),
+		DiscussionID: discussionID,
+		Author:       n.Author.Username,
+		Body:         n.Body,
+		CreatedAt:    n.CreatedAt,
+	}
+	if mrURL != "" {
+		comment.URL = fmt.Sprintf("%s#note_%d", mrURL, n.ID)
+	}
+	if p := n.Position; p != nil {
+		comment.Path, comment.Line = p.NewPath, p.NewLine
+		if comment.Path == "" {
+			comment.Path = p.OldPath
+		}
+		if comment.Line == 0 {
+			comment.Line = p.OldLine
+		}
+	}
+	return comment
+}
+
+type gitlabDiscussion struct {
+	ID    string       `json:"id"`
+	Notes []gitlabNote `json:"notes"`
+}
+
+// Repo returns the repository the client acts on.
+func (g *GitLab) Repo() Repo {
+	return g.repo
+}
+
+func (g *GitLab) projectPath() string {
+	return "/projects/" + url.PathEscape(g.repo.FullName())
+}
+
+// CreatePullRequest opens a merge request.
+func (g *GitLab) CreatePullRequest(ctx context.Context, req CreateRequest) (*PullRequest, error) {
+	title := req.Title
+	if req.Draft && !strings.HasPrefix(title, draftPrefix) {
+		title = draftPrefix + title
+	}
+	payload := map[string]interface{}{
+		"title":                title,
+		"description":          req.Body,
+		"source_branch":        req.Head,
+		"target_branch":        req.Base,
+		"remove_source_branch": true,
+	}
+	var mr gitlabMergeRequest
+	if err := g.api.do(ctx, http.MethodPost, g.projectPath()+"/merge_requests", payload, &mr); err != nil {
+		return nil, err
+	}
+	return mr.pullRequest(), nil
+}
+
+// FindPullRequest returns the open merge request for branch, or nil.
+func (g *GitLab) FindPullRequest(ctx context.Context, branch string) (*PullRequest, error) {
+	query := url.Values{"state": {"opened"}, "source_branch": {branch}}
+	var mrs []gitlabMergeRequest
+	if err := g.api.do(ctx, http.MethodGet, g.projectPath()+"/merge_requests?"+query.Encode(), nil, &mrs); err != nil {
+		return nil, err
+	}
+	if len(mrs) == 0 {
+		return nil, nil
+	}
+	return mrs[0].pullRequest(), nil
+}
+
+// ListComments returns the notes of every merge request discussion, oldest
+// first. System notes (pushes, label changes, ...) are skipped.
+func (g *GitLab) ListComments(ctx context.Context, number int) ([]Comment, error) {
+	mrPath := fmt.Sprintf("%s/merge_requests/%d", g.projectPath(), number)
+	var mr gitlabMergeRequest
+	if err := g.api.do(ctx, http.MethodGet, mrPath, nil, &mr); err != nil {
+		return nil, err
+	}
+	discussions, err := getAllPages[gitlabDiscussion](ctx, g.api, mrPath+"/discussions")
+	if err != nil {
+		return nil, err
+	}
+
+	var comments []Comment
+	for _, d := range discussions {
+		for i, note := range d.Notes {
+			if note.System {
+				continue
+			}
+			comment := note.comment(d.ID, mr.WebURL)
+			comment.Reply = i > 0
+			comments = append(comments, comment)
+		}
+	}
+	sort.SliceStable(comments, func(i, j int) bool {
+		return comments[i].CreatedAt.Before(comments[j].CreatedAt)
+	})
+	return comments, nil
+}
+
+// Reply adds a note to the discussion of comment, or a new merge request
+// note when the comment has no discussion.
+func (g *GitLab) Reply(ctx context.Context, number int, comment Comment, body string) (*Comment, error) {
+	path := fmt.Sprintf("%s/merge_requests/%d/notes", g.projectPath(), number)
+	if comment.DiscussionID != "" {
+		path = fmt.Sprintf("%s/merge_requests/%d/discussions/%s/notes", g.projectPath(), number, url.PathEscape(comment.DiscussionID))
+	}
+	var note gitlabNote
+	if err := g.api.do(ctx, http.MethodPost, path, map[string]string{"body": body}, &note); err != nil {
+		return nil, err
+	}
+	c := note.comment(comment.DiscussionID, "")
+	c.Reply = comment.DiscussionID != ""
+	return &c, nil
+}
@@ -0,0 +1,226 @@
+package forge
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// KindJira is the issue tracker kind of Jira (Cloud or Data Center). Like the
+// forge kinds it is the credential name its token is stored under
+// (JIRA_API_TOKEN in the environment).
+const KindJira = "jira"
+
+// Issue is a GitHub or GitLab issue or a Jira ticket.
+type Issue struct {
+	Kind     string // KindGitHub, KindGitLab or KindJira
+	Key      string // issue number, or the Jira key such as PROJ-12
+	Title    string
+	Body     string
+	URL      string
+	State    string
+	Author   string
+	Labels   []string
+	Comments []Comment // oldest first
+}
+
+// IssueTracker is the API of a service issues are tracked on.
+type IssueTracker interface {
+	// GetIssue returns an issue and its comments.
+	GetIssue(ctx context.Context, key string) (*Issue, error)
+	// CommentOnIssue adds a comment to an issue.
+	CommentOnIssue(ctx context.Context, key, body string) error
+}
+
+// IssueRef identifies an issue: a number in a forge repository or a key on
+// a Jira site.
+type IssueRef struct {
+	Kind    string
+	Repo    Repo   // repository of GitHub and GitLab issues
+	JiraURL string // site of Jira issues, e.g. https://acme.atlassian.net
+	Key     string
+}
+
+// String returns the conventional name of the issue, e.g. acme/app#12.
+func (r IssueRef) String() string {
+	if r.Kind == KindJira {
+		return r.Key
+	}
+	return r.Repo.FullName() + "#" + r.Key
+}
+
+var (
+	jiraKeyPattern     = regexp.MustCompile(`^[A-Z][A-Z0-9_]+-[0-9]+$`)
+	issueNumberPattern = regexp.MustCompile(`^#?[0-9]+$`)
+)
+
+// ParseIssueRef parses an issue URL, a number (12 or #12) of an issue in
+// the origin repository, or a Jira key (PROJ-12) on the site at jiraURL.
+// hosts maps self-managed forge hosts to their kind, as in ParseRemoteURL.
+func ParseIssueRef(ref string, origin *Repo, hosts map[string]string, jiraURL string) (IssueRef, error) {
+	ref = strings.TrimSpace(ref)
+	switch {
+	case issueNumberPattern.MatchString(ref):
+		if origin == nil {
+			return IssueRef{}, fmt.Errorf("issue %s needs a GitHub or GitLab origin remote; pass the issue URL instead", ref)
+		}
+		return IssueRef{Kind: origin.Kind, Repo: *origin, Key: strings.TrimPrefix(ref, "#")}, nil
+	case jiraKeyPattern.MatchString(ref):
+		if jiraURL == "" {
+			return IssueRef{}, fmt.Errorf("Jira issue %s needs the site URL: set jira.url in the config or pass the issue URL", ref)
+		}
+		return IssueRef{Kind: KindJira, JiraURL: strings.TrimSuffix(jiraURL, "/"), Key: ref}, nil
+	}
+
+	u, err := url.Parse(ref)
+	if err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+		return IssueRef{}, fmt.Errorf("unrecognized issue %q: use an issue URL, a number or a Jira key", ref)
+	}
+	path := strings.Trim(u.Path, "/")
+	if before, key, ok := strings.Cut(path, "browse/"); ok && jiraKeyPattern.MatchString(key) {
+		site := u.Scheme + "://" + u.Host
+		if before != "" {
+			// Jira Data Center may live under a context path
+			site += "/" + strings.Trim(before, "/")
+		}
+		return IssueRef{Kind: KindJira, JiraURL: site, Key: key}, nil
+	}
+
+	// GitLab issue URLs have a "/-/" separator; GitHub's do not
+	project, number, kind := path, "", ""
+	if i := strings.LastIndex(path, "/-/issues/"); i > 0 {
+		project, number, kind = path[:i], path[i+len("/-/issues/"):], KindGitLab
+	} else if i := strings.LastIndex(path, "/issues/"); i > 0 {
+		project, number, kind = path[:i], path[i+len("/issues/"):], KindGitHub
+	}
+	if _, err := strconv.Atoi(number); err != nil {
+		return IssueRef{}, fmt.Errorf("%q is not a GitHub, GitLab or Jira issue URL", ref)
+	}
+	host := strings.ToLower(u.Host)
+	if _, ok := hosts[host]; !ok {
+		hosts = map[string]string{host: kind}
+	}
+	repo, err := ParseRemoteURL(u.Scheme+"://"+host+"/"+project, hosts)
+	if err != nil {
+		return IssueRef{}, err
+	}
+	return IssueRef{Kind: repo.Kind, Repo: repo, Key: number}, nil
+}
+
+// NewIssueTracker returns the client for the tracker of ref, authenticated
+// with token. email is the account Jira Cloud API tokens belong to; without
+// it the token is sent as a Jira Data Center personal access token.
+func NewIssueTracker(ref IssueRef, token, email string) (IssueTracker, error) {
+	switch ref.Kind {
+	case KindGitHub:
+		return NewGitHub(ref.Repo, token, ""), nil
+	case KindGitLab:
+		return NewGitLab(ref.Repo, token, ""), nil
+	case KindJira:
+		return NewJira(ref.JiraURL, email, token), nil
+	default:
+		return nil, fmt.Errorf("unsupported issue tracker %q", ref.Kind)
+	}
+}
+
+type githubIssue struct {
+	Number  int    `json:"number"`
+	Title   string `json:"title"`
+	Body    string `json:"body"`
+	HTMLURL string `json:"html_url"`
+	State   string `json:"state"`
+	User    struct {
+		Login string `json:"login"`
+	} `json:"user"`
+	Labels []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+	PullRequest *struct{} `json:"pull_request"`
+}
+
+// GetIssue returns an issue and its comments.
+func (g *GitHub) GetIssue(ctx context.Context, key string) (*Issue, error) {
+	var raw githubIssue
+	if err := g.api.do(ctx, http.MethodGet, g.repoPath()+"/issues/"+url.PathEscape(key), nil, &raw); err != nil {
+		return nil, err
+	}
+	if raw.PullRequest != nil {
+		return nil, fmt.Errorf("%s#%s is a pull request, not an issue", g.repo.FullName(), key)
+	}
+	comments, err := getAllPages[githubComment](ctx, g.api, g.repoPath()+"/issues/"+url.PathEscape(key)+"/comments")
+	if err != nil {
+		return nil, err
+	}
+	issue := &Issue{
+		Kind:   KindGitHub,
+		Key:    strconv.Itoa(raw.Number),
+		Title:  raw.Title,
+		Body:   raw.Body,
+		URL:    raw.HTMLURL,
+		State:  raw.State,
+		Author: raw.User.Login,
+	}
+	for _, label := range raw.Labels {
+		issue.Labels = append(issue.Labels, label.Name)
+	}
+	for _, c := range comments {
+		issue.Comments = append(issue.Comments, c.comment(false))
+	}
+	return issue, nil
+}
+
+// CommentOnIssue adds a comment to an issue.
+func (g *GitHub) CommentOnIssue(ctx context.Context, key, body string) error {
+	return g.api.do(ctx, http.MethodPost, g.repoPath()+"/issues/"+url.PathEscape(key)+"/comments", map[string]string{"body": body}, nil)
+}
+
+type gitlabIssue struct {
+	IID         int      `json:"iid"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	WebURL      string   `json:"web_url"`
+	State       string   `json:"state"`
+	Labels      []string `json:"labels"`
+	Author      struct {
+		Username string `json:"username"`
+	} `json:"author"`
+}
+
+// GetIssue returns an issue and its comments, leaving out system notes
+// such as label changes.
+func (g *GitLab) GetIssue(ctx context.Context, key string) (*Issue, error) {
+	var raw gitlabIssue
+	issuePath := g.projectPath() + "/issues/" + url.PathEscape(key)
+	if err := g.api.do(ctx, http.MethodGet, issuePath, nil, &raw); err != nil {
+		return nil, err
+	}
+	notes, err := getAllPages[gitlabNote](ctx, g.api, issuePath+"/notes?sort=asc&order_by=created_at")
+	if err != nil {
+		return nil, err
+	}
+	issue := &Issue{
+		Kind:   KindGitLab,
+		Key:    strconv.Itoa(raw.IID),
+		Title:  raw.Title,
+		Body:   raw.Description,
+		URL:    raw.WebURL,
+		State:  raw.State,
+		Author: raw.Author.Username,
+		Labels: raw.Labels,
+	}
+	for _, note := range notes {
+		if !note.System {
+			issue.Comments = append(issue.Comments, note.comment("", raw.WebURL))
+		}
+	}
+	return issue, nil
+}
+
+// CommentOnIssue adds a note to an issue.
+func (g *GitLab) CommentOnIssue(ctx context.Context, key, body string) error {
+	return g.api.do(ctx, http.MethodPost, g.projectPath()+"/issues/"+url.PathEscape(key)+"/notes", map[string]string{"body": body}, nil)
+}
@@ -0,0 +1,154 @@
+package forge
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestParseIssueRef(t *testing.T) {
+	origin := &Repo{KindGitHub, "github.com", "acme", "app"}
+	hosts := map[string]string{"git.example.com": "gitlab"}
+	tests := []struct {
+		ref  string
+		want IssueRef
+	}{
+		{"42", IssueRef{Kind: KindGitHub, Repo: *origin, Key: "42"}},
+		{"#42", IssueRef{Kind: KindGitHub, Repo: *origin, Key: "42"}},
+		{"https://github.com/other/lib/issues/7", IssueRef{Kind: KindGitHub, Repo: Repo{KindGitHub, "github.com", "other", "lib"}, Key: "7"}},
+		{"https://gitlab.com/group/sub/app/-/issues/3", IssueRef{Kind: KindGitLab, Repo: Repo{KindGitLab, "gitlab.com", "group/sub", "app"}, Key: "3"}},
+		{"https://git.example.com/team/app/-/issues/9", IssueRef{Kind: KindGitLab, Repo: Repo{KindGitLab, "git.example.com", "team", "app"}, Key: "9"}},
+		{"https://ghe.corp.net/team/app/issues/5", IssueRef{Kind: KindGitHub, Repo: Repo{KindGitHub, "ghe.corp.net", "team", "app"}, Key: "5"}},
+		{"PROJ-12", IssueRef{Kind: KindJira, JiraURL: "https://acme.atlassian.net", Key: "PROJ-12"}},
+		{"https://jira.corp.net/jira/browse/OPS-4", IssueRef{Kind: KindJira, JiraURL: "https://jira.corp.net/jira", Key: "OPS-4"}},
+	}
+	for _, tt := range tests {
+		got, err := ParseIssueRef(tt.ref, origin, hosts, "https://acme.atlassian.net/")
+		if err != nil {
+			t.Fatalf("ParseIssueRef(%q): %v", tt.ref, err)
+		}
+		if got != tt.want {
+			t.Errorf("ParseIssueRef(%q) = %+v, want %+v", tt.ref, got, tt.want)
+		}
+	}
+
+	if got := (IssueRef{Kind: KindGitHub, Repo: *origin, Key: "42"}).String(); got != "acme/app#42" {
+		t.Errorf("String() = %q", got)
+	}
+	if _, err := ParseIssueRef("42", nil, nil, ""); err == nil {
+		t.Error("expected a bare number to need an origin repository")
+	}
+	if _, err := ParseIssueRef("PROJ-12", origin, nil, ""); err == nil || !strings.Contains(err.Error(), "jira.url") {
+		t.Errorf("expected a Jira key to need jira.url, got %v", err)
+	}
+	for _, ref := range []string{"fix the login", "https://github.com/acme/app/pull/3", "ftp://github.com/acme/app/issues/1"} {
+		if _, err := ParseIssueRef(ref, origin, nil, ""); err == nil {
+			t.Errorf("expected %q to be rejected", ref)
+		}
+	}
+}
+
+func TestGitHubIssueFlow(t *testing.T) {
+	fake, server := newFakeForge(t, map[string]string{
+		"GET /repos/acme/app/issues/42":           `{"number": 42, "title": "Login fails", "body": "Steps:\n- [ ] reproduce", "html_url": "https://github.com/acme/app/issues/42", "state": "open", "user": {"login": "reporter"}, "labels": [{"name": "bug"}]}`,
+		"GET /repos/acme/app/issues/42/comments":  `[{"id": 5, "body": "Also on mobile", "created_at": "2026-01-02T10:00:00Z", "user": {"login": "other"}}]`,
+		"POST /repos/acme/app/issues/42/comments": `{"id": 6, "body": "Fixed"}`,
+		"GET /repos/acme/app/issues/7":            `{"number": 7, "title": "Add login", "pull_request": {"url": "x"}}`,
+	})
+	client := NewGitHub(Repo{KindGitHub, "github.com", "acme", "app"}, "secret", server.URL)
+	ctx := context.Background()
+
+	issue, err := client.GetIssue(ctx, "42")
+	if err != nil {
+		t.Fatalf("GetIssue: %v", err)
+	}
+	if issue.Key != "42" || issue.Title != "Login fails" || issue.Author != "reporter" || len(issue.Labels) != 1 || issue.Labels[0] != "bug" {
+		t.Fatalf("unexpected issue %+v", issue)
+	}
+	if len(issue.Comments) != 1 || issue.Comments[0].Author != "other" {
+		t.Fatalf("unexpected comments %+v", issue.Comments)
+	}
+
+	if err := client.CommentOnIssue(ctx, "42", "Fixed"); err != nil {
+		t.Fatalf("CommentOnIssue: %v", err)
+	}
+	if body := fake.bodies["POST /repos/acme/app/issues/42/comments"]; body["body"] != "Fixed" {
+		t.Fatalf("unexpected comment body %v", body)
+	}
+
+	if _, err := client.GetIssue(ctx, "7"); err == nil || !strings.Contains(err.Error(), "pull request") {
+		t.Fatalf("expected pull requests to be rejected, got %v", err)
+	}
+}
+
+func TestGitLabIssueFlow(t *testing.T) {
+	fake, server := newFakeForge(t, map[string]string{
+		"GET /projects/group%2Fapp/issues/3": `{"iid": 3, "title": "Slow search", "description": "It is slow", "web_url": "https://gitlab.com/group/app/-/issues/3", "state": "opened", "labels": ["perf"], "author": {"username": "reporter"}}`,
+		"GET /projects/group%2Fapp/issues/3/notes": `[
+			{"id": 31, "body": "changed the description", "system": true, "created_at": "2026-01-02T09:00:00Z"},
+			{"id": 32, "body": "Only with filters", "created_at": "2026-01-02T10:00:00Z", "author": {"username": "other"}}
+		]`,
+		"POST /projects/group%2Fapp/issues/3/notes": `{"id": 33, "body": "Fixed"}`,
+	})
+	client := NewGitLab(Repo{KindGitLab, "gitlab.com", "group", "app"}, "secret", server.URL)
+	ctx := context.Background()
+
+	issue, err := client.GetIssue(ctx, "3")
+	if err != nil {
+		t.Fatalf("GetIssue: %v", err)
+	}
+	if issue.Body != "It is slow" || issue.State != "opened" || issue.Author != "reporter" {
+		t.Fatalf("unexpected issue %+v", issue)
+	}
+	if len(issue.Comments) != 1 || issue.Comments[0].Body != "Only with filters" || !strings.HasSuffix(issue.Comments[0].URL, "#note_32") {
+		t.Fatalf("expected system notes to be skipped, got %+v", issue.Comments)
+	}
+
+	if err := client.CommentOnIssue(ctx, "3", "Fixed"); err != nil {
+		t.Fatalf("CommentOnIssue: %v", err)
+	}
+	if body := fake.bodies["POST /projects/group%2Fapp/issues/3/notes"]; body["body"] != "Fixed" {
+		t.Fatalf("unexpected note body %v", body)
+	}
+}
+
+func TestJiraIssueFlow(t *testing.T) {
+	fake, server := newFakeForge(t, map[string]string{
+		"GET /rest/api/2/issue/PROJ-7": `{"key": "PROJ-7", "fields": {
+			"summary": "Export to CSV", "description": "Add an export button", "labels": ["ui"],
+			"status": {"name": "To Do"}, "reporter": {"displayName": "Pat"},
+			"comment": {"comments": [{"id": "100", "body": "Include headers", "created": "2026-01-02T10:00:00.000+0100", "author": {"displayName": "Sam"}}]}
+		}}`,
+		"POST /rest/api/2/issue/PROJ-7/comment": `{"id": "101"}`,
+	})
+	client := NewJira(server.URL+"/", "pat@example.com", "secret")
+	ctx := context.Background()
+
+	issue, err := client.GetIssue(ctx, "PROJ-7")
+	if err != nil {
+		t.Fatalf("GetIssue: %v", err)
+	}
+	if issue.Title != "Export to CSV" || issue.State != "To Do" || issue.URL != server.URL+"/browse/PROJ-7" {
+		t.Fatalf("unexpected issue %+v", issue)
+	}
+	if len(issue.Comments) != 1 || issue.Comments[0].Author != "Sam" || issue.Comments[0].CreatedAt.IsZero() {
+		t.Fatalf("unexpected comments %+v", issue.Comments)
+	}
+	want := "Basic " + base64.StdEncoding.EncodeToString([]byte("pat@example.com:secret"))
+	if got := fake.headers.Get("Authorization"); got != want {
+		t.Fatalf("Authorization = %q, want %q", got, want)
+	}
+
+	if err := client.CommentOnIssue(ctx, "PROJ-7", "Done"); err != nil {
+		t.Fatalf("CommentOnIssue: %v", err)
+	}
+	if body := fake.bodies["POST /rest/api/2/issue/PROJ-7/comment"]; body["body"] != "Done" {
+		t.Fatalf("unexpected comment body %v", body)
+	}
+
+	NewJira(server.URL, "", "pat-token").CommentOnIssue(ctx, "PROJ-7", "Done")
+	if got := fake.headers.Get("Authorization"); got != "Bearer pat-token" {
+		t.Fatalf("expected a personal access token without email, got %q", got)
+	}
+}
@@ -0,0 +1,100 @@
+package forge
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Jira is a client for the Jira REST API (version 2, which takes and returns
+// plain-text descriptions and comments on both Cloud and Data Center).
+type Jira struct {
+	site string
+	api  *apiClient
+}
+
+// NewJira returns a Jira client for the site at siteURL. With an email the
+// token is a Jira Cloud API token, sent with basic authentication; without
+// one it is a Data Center personal access token.
+func NewJira(siteURL, email, token string) *Jira {
+	siteURL = strings.TrimSuffix(siteURL, "/")
+	auth := "Bearer " + token
+	if email != "" {
+		auth = "Basic " + basicAuth(email, token)
+	}
+	return &Jira{site: siteURL, api: newAPIClient(siteURL+"/rest/api/2", map[string]string{
+		"Authorization": auth,
+	})}
+}
+
+func basicAuth(user, password string) string {
+	return base64.StdEncoding.EncodeToString([]byte(user + ":" + password))
+}
+
+// jiraTimeLayout is the timestamp format of the Jira REST API.
+const jiraTimeLayout = "2006-01-02T15:04:05.000-0700"
+
+type jiraComment struct {
+	ID      string `json:"id"`
+	Body    string `json:"body"`
+	Created string `json:"created"`
+	Author  struct {
+		DisplayName string `json:"displayName"`
+	} `json:"author"`
+}
+
+type jiraIssue struct {
+	Key    string `json:"key"`
+	Fields struct {
+		Summary     string   `json:"summary"`
+		Description string   `json:"description"`
+		Labels      []string `json:"labels"`
+		Status      struct {
+			Name string `json:"name"`
+		} `json:"status"`
+		Reporter struct {
+			DisplayName string `json:"displayName"`
+		} `json:"reporter"`
+		Comment struct {
+			Comments []jiraComment `json:"comments"`
+		} `json:"comment"`
+	} `json:"fields"`
+}
+
+// GetIssue returns an issue and its comments.
+func (j *Jira) GetIssue(ctx context.Context, key string) (*Issue, error) {
+	var raw jiraIssue
+	path := "/issue/" + url.PathEscape(key) + "?fields=summary,description,status,labels,reporter,comment"
+	if err := j.api.do(ctx, http.MethodGet, path, nil, &raw); err != nil {
+		return nil, err
+	}
+	issue := &Issue{
+		Kind:   KindJira,
+		Key:    raw.Key,
+		Title:  raw.Fields.Summary,
+		Body:   raw.Fields.Description,
+		URL:    j.site + "/browse/" + raw.Key,
+		State:  raw.Fields.Status.Name,
+		Author: raw.Fields.Reporter.DisplayName,
+		Labels: raw.Fields.Labels,
+	}
+	for _, c := range raw.Fields.Comment.Comments {
+		created, _ := time.Parse(jiraTimeLayout, c.Created)
+		issue.Comments = append(issue.Comments, Comment{
+			ID:        c.ID,
+			Author:    c.Author.DisplayName,
+			Body:      c.Body,
+			URL:       issue.URL + "?focusedCommentId=" + url.QueryEscape(c.ID),
+			CreatedAt: created,
+		})
+	}
+	return issue, nil
+}
+
+// CommentOnIssue adds a comment to an issue.
+func (j *Jira) CommentOnIssue(ctx context.Context, key, body string) error {
+	return j.api.do(ctx, http.MethodPost, "/issue/"+url.PathEscape(key)+"/comment", map[string]string{"body": body}, nil)
+}
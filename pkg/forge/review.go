@@ -0,0 +1,151 @@
+package forge
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ReviewComment is an inline comment on a line of the new version of a file
+// in a pull request's diff.
+type ReviewComment struct {
+	Path string `json:"path"`
+	Line int    `json:"line"`
+	Body string `json:"body"`
+}
+
+// Review is a set of comments posted on a pull request at once.
+type Review struct {
+	Body      string          // general comment, may be empty
+	CommitSHA string          // head commit the line numbers refer to
+	Comments  []ReviewComment // inline comments
+}
+
+// ParsePullRequestRef parses a pull request or merge request URL, or a
+// number (12 or #12) of one in the origin repository. hosts maps
+// self-managed hosts to their kind, as in ParseRemoteURL.
+func ParsePullRequestRef(ref string, origin *Repo, hosts map[string]string) (Repo, int, error) {
+	ref = strings.TrimSpace(ref)
+	if issueNumberPattern.MatchString(ref) {
+		if origin == nil {
+			return Repo{}, 0, fmt.Errorf("pull request %s needs a GitHub or GitLab origin remote; pass its URL instead", ref)
+		}
+		number, _ := strconv.Atoi(strings.TrimPrefix(ref, "#"))
+		return *origin, number, nil
+	}
+
+	u, err := url.Parse(ref)
+	if err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+		return Repo{}, 0, fmt.Errorf("unrecognized pull request %q: use its URL or number", ref)
+	}
+	path := strings.Trim(u.Path, "/")
+	project, number, kind := path, "", ""
+	if i := strings.LastIndex(path, "/-/merge_requests/"); i > 0 {
+		project, number, kind = path[:i], path[i+len("/-/merge_requests/"):], KindGitLab
+	} else if i := strings.LastIndex(path, "/pull/"); i > 0 {
+		project, number, kind = path[:i], path[i+len("/pull/"):], KindGitHub
+	}
+	// Drop trailing pages such as /files or /diffs
+	number, _, _ = strings.Cut(number, "/")
+	n, err := strconv.Atoi(number)
+	if err != nil {
+		return Repo{}, 0, fmt.Errorf("%q is not a GitHub pull request or GitLab merge request URL", ref)
+	}
+	host := strings.ToLower(u.Host)
+	if _, ok := hosts[host]; !ok {
+		hosts = map[string]string{host: kind}
+	}
+	repo, err := ParseRemoteURL(u.Scheme+"://"+host+"/"+project, hosts)
+	if err != nil {
+		return Repo{}, 0, err
+	}
+	return repo, n, nil
+}
+
+// PullRequestRef returns the ref a pull request's head commit can be fetched
+// from, e.g. refs/pull/12/head.
+func PullRequestRef(kind string, number int) string {
+	if kind == KindGitLab {
+		return fmt.Sprintf("refs/merge-requests/%d/head", number)
+	}
+	return fmt.Sprintf("refs/pull/%d/head", number)
+}
+
+// GetPullRequest returns a pull request by number.
+func (g *GitHub) GetPullRequest(ctx context.Context, number int) (*PullRequest, error) {
+	var pull githubPull
+	if err := g.api.do(ctx, http.MethodGet, fmt.Sprintf("%s/pulls/%d", g.repoPath(), number), nil, &pull); err != nil {
+		return nil, err
+	}
+	return pull.pullRequest(), nil
+}
+
+// PostReview posts the comments as one review. GitHub rejects the whole
+// review when a comment is not on a line of the diff.
+func (g *GitHub) PostReview(ctx context.Context, number int, review Review) error {
+	comments := make([]map[string]interface{}, 0, len(review.Comments))
+	for _, c := range review.Comments {
+		comments = append(comments, map[string]interface{}{
+			"path": c.Path,
+			"line": c.Line,
+			"side": "RIGHT",
+			"body": c.Body,
+		})
+	}
+	payload := map[string]interface{}{
+		"event":    "COMMENT",
+		"body":     review.Body,
+		"comments": comments,
+	}
+	if review.CommitSHA != "" {
+		payload["commit_id"] = review.CommitSHA
+	}
+	return g.api.do(ctx, http.MethodPost, fmt.Sprintf("%s/pulls/%d/reviews", g.repoPath(), number), payload, nil)
+}
+
+// GetPullRequest returns a merge request by IID.
+func (g *GitLab) GetPullRequest(ctx context.Context, number int) (*PullRequest, error) {
+	var mr gitlabMergeRequest
+	if err := g.api.do(ctx, http.MethodGet, fmt.Sprintf("%s/merge_requests/%d", g.projectPath(), number), nil, &mr); err != nil {
+		return nil, err
+	}
+	return mr.pullRequest(), nil
+}
+
+// PostReview starts a discussion on the diff for each comment and adds the
+// body as a merge request note. A comment GitLab cannot place on the diff
+// becomes a general discussion that names its location.
+func (g *GitLab) PostReview(ctx context.Context, number int, review Review) error {
+	mrPath := fmt.Sprintf("%s/merge_requests/%d", g.projectPath(), number)
+	var mr gitlabMergeRequest
+	if err := g.api.do(ctx, http.MethodGet, mrPath, nil, &mr); err != nil {
+		return err
+	}
+	for _, c := range review.Comments {
+		payload := map[string]interface{}{
+			"body": c.Body,
+			"position": map[string]interface{}{
+				"position_type": "text",
+				"base_sha":      mr.DiffRefs.BaseSHA,
+				"start_sha":     mr.DiffRefs.StartSHA,
+				"head_sha":      mr.DiffRefs.HeadSHA,
+				"old_path":      c.Path,
+				"new_path":      c.Path,
+				"new_line":      c.Line,
+			},
+		}
+		if err := g.api.do(ctx, http.MethodPost, mrPath+"/discussions", payload, nil); err != nil {
+			body := fmt.Sprintf("`%s:%d`\n\n%s", c.Path, c.Line, c.Body)
+			if err := g.api.do(ctx, http.MethodPost, mrPath+"/discussions", map[string]string{"body": body}, nil); err != nil {
+				return err
+			}
+		}
+	}
+	if strings.TrimSpace(review.Body) == "" {
+		return nil
+	}
+	return g.api.do(ctx, http.MethodPost, mrPath+"/notes", map[string]string{"body": review.Body}, nil)
+}
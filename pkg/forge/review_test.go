@@ -0,0 +1,89 @@
+package forge
+
+import (
+	"context"
+	"testing"
+)
+
+func TestParsePullRequestRef(t *testing.T) {
+	origin := &Repo{KindGitHub, "github.com", "acme", "app"}
+	tests := []struct {
+		ref    string
+		repo   Repo
+		number int
+	}{
+		{"12", *origin, 12},
+		{"#7", *origin, 7},
+		{"https://github.com/acme/app/pull/12/files", *origin, 12},
+		{"https://gitlab.com/group/sub/app/-/merge_requests/3/diffs", Repo{KindGitLab, "gitlab.com", "group/sub", "app"}, 3},
+		{"https://git.example.com/team/app/pull/5", Repo{KindGitLab, "git.example.com", "team", "app"}, 5},
+	}
+	hosts := map[string]string{"git.example.com": "gitlab"}
+	for _, tt := range tests {
+		repo, number, err := ParsePullRequestRef(tt.ref, origin, hosts)
+		if err != nil {
+			t.Fatalf("ParsePullRequestRef(%q): %v", tt.ref, err)
+		}
+		if repo != tt.repo || number != tt.number {
+			t.Errorf("ParsePullRequestRef(%q) = %+v, %d; want %+v, %d", tt.ref, repo, number, tt.repo, tt.number)
+		}
+	}
+
+	if _, _, err := ParsePullRequestRef("12", nil, nil); err == nil {
+		t.Error("expected a number without an origin to be rejected")
+	}
+	for _, ref := range []string{"main..HEAD", "https://github.com/acme/app/issues/12"} {
+		if _, _, err := ParsePullRequestRef(ref, origin, nil); err == nil {
+			t.Errorf("expected %q to be rejected", ref)
+		}
+	}
+}
+
+func TestGitHubPostReview(t *testing.T) {
+	fake, server := newFakeForge(t, map[string]string{
+		"GET /repos/acme/app/pulls/7":          `{"number": 7, "title": "Add login", "head": {"ref": "login", "sha": "abc123"}, "base": {"ref": "main"}}`,
+		"POST /repos/acme/app/pulls/7/reviews": `{"id": 1}`,
+	})
+	client := NewGitHub(Repo{KindGitHub, "github.com", "acme", "app"}, "secret", server.URL)
+	ctx := context.Background()
+
+	pr, err := client.GetPullRequest(ctx, 7)
+	if err != nil || pr.HeadSHA != "abc123" || pr.BaseBranch != "main" {
+		t.Fatalf("GetPullRequest = %+v, %v", pr, err)
+	}
+	err = client.PostReview(ctx, 7, Review{Body: "Found 1 issue", CommitSHA: pr.HeadSHA, Comments: []ReviewComment{{Path: "login.go", Line: 12, Body: "Check the error"}}})
+	if err != nil {
+		t.Fatalf("PostReview: %v", err)
+	}
+	body := fake.bodies["POST /repos/acme/app/pulls/7/reviews"]
+	comments, _ := body["comments"].([]interface{})
+	if body["event"] != "COMMENT" || body["commit_id"] != "abc123" || len(comments) != 1 {
+		t.Fatalf("unexpected review body %v", body)
+	}
+	if c := comments[0].(map[string]interface{}); c["path"] != "login.go" || c["line"] != float64(12) || c["side"] != "RIGHT" {
+		t.Fatalf("unexpected comment %v", c)
+	}
+}
+
+func TestGitLabPostReview(t *testing.T) {
+	fake, server := newFakeForge(t, map[string]string{
+		"GET /projects/acme%2Fapp/merge_requests/3":              `{"iid": 3, "sha": "head1", "diff_refs": {"base_sha": "base1", "start_sha": "start1", "head_sha": "head1"}}`,
+		"POST /projects/acme%2Fapp/merge_requests/3/discussions": `{"id": "d1"}`,
+		"POST /projects/acme%2Fapp/merge_requests/3/notes":       `{"id": 5}`,
+	})
+	client := NewGitLab(Repo{KindGitLab, "gitlab.com", "acme", "app"}, "secret", server.URL)
+
+	err := client.PostReview(context.Background(), 3, Review{Body: "Found 1 issue", Comments: []ReviewComment{{Path: "login.go", Line: 12, Body: "Check the error"}}})
+	if err != nil {
+		t.Fatalf("PostReview: %v", err)
+	}
+	discussion := fake.bodies["POST /projects/acme%2Fapp/merge_requests/3/discussions"]
+	position, _ := discussion["position"].(map[string]interface{})
+	if discussion["body"] != "Check the error" || position["base_sha"] != "base1" || position["start_sha"] != "start1" ||
+		position["new_path"] != "login.go" || position["new_line"] != float64(12) {
+		t.Fatalf("unexpected discussion %v", discussion)
+	}
+	if note := fake.bodies["POST /projects/acme%2Fapp/merge_requests/3/notes"]; note["body"] != "Found 1 issue" {
+		t.Fatalf("unexpected note %v", note)
+	}
+}
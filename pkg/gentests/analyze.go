@@ -0,0 +1,200 @@
+// Package gentests generates tests for the untested exported functions of a
+// Go source file. Generated tests are compiled and run, and are only kept
+// once they pass.
+package gentests
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strings"
+
+	"github.com/alantheprice/ledit/pkg/coverage"
+)
+
+// Function is an exported function or method of the analyzed file.
+type Function struct {
+	Name      string `json:"name"`
+	Receiver  string `json:"receiver,omitempty"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+	// Coverage is the fraction of the function's statements covered by the
+	// package's tests, or -1 when coverage was not measured.
+	Coverage float64 `json:"coverage"`
+}
+
+// String returns the function's name, qualified with its receiver type.
+func (f Function) String() string {
+	if f.Receiver != "" {
+		return f.Receiver + "." + f.Name
+	}
+	return f.Name
+}
+
+// Analysis lists the exported functions of a file and which of them lack
+// tests.
+type Analysis struct {
+	File     string     `json:"file"`
+	Dir      string     `json:"dir"`
+	Package  string     `json:"package"`
+	Exported []Function `json:"exported"`
+	Untested []Function `json:"untested"`
+	// UsedCoverage reports whether Untested comes from coverage data rather
+	// than from which names the package's tests mention.
+	UsedCoverage bool `json:"used_coverage"`
+}
+
+// TestFile is the test file generated tests are written to.
+func (a *Analysis) TestFile() string {
+	return strings.TrimSuffix(a.File, ".go") + "_test.go"
+}
+
+// Analyze parses the Go file at path and finds its untested exported
+// functions. With useCoverage set, the package's tests are run with a cover
+// profile and functions with no covered statements are untested; otherwise a
+// function is untested when no test file in the package mentions it.
+func Analyze(ctx context.Context, path string, useCoverage bool) (*Analysis, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", path, err)
+	}
+	if filepath.Ext(absPath) != ".go" || strings.HasSuffix(absPath, "_test.go") {
+		return nil, fmt.Errorf("gen-tests needs a Go source file, got %s", path)
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, absPath, nil, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	analysis := &Analysis{File: absPath, Dir: filepath.Dir(absPath), Package: file.Name.Name}
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil || !fn.Name.IsExported() {
+			continue
+		}
+		receiver := receiverType(fn)
+		if receiver != "" && !ast.IsExported(receiver) {
+			continue
+		}
+		analysis.Exported = append(analysis.Exported, Function{
+			Name:      fn.Name.Name,
+			Receiver:  receiver,
+			StartLine: fset.Position(fn.Pos()).Line,
+			EndLine:   fset.Position(fn.End()).Line,
+			Coverage:  -1,
+		})
+	}
+
+	if useCoverage {
+		// The profile names files by import path; the package's own
+		// directory holds only one file with this name.
+		profile, _, err := coverage.Profile(ctx, analysis.Dir, []string{"."})
+		if err == nil {
+			blocks := fileBlocks(profile, filepath.Base(absPath))
+			analysis.UsedCoverage = true
+			for i := range analysis.Exported {
+				fn := &analysis.Exported[i]
+				fn.Coverage = blocks.fraction(fn.StartLine, fn.EndLine)
+				if fn.Coverage == 0 {
+					analysis.Untested = append(analysis.Untested, *fn)
+				}
+			}
+			return analysis, nil
+		}
+		// Fall through to the name search when the package's tests cannot
+		// produce a profile, for example because they do not compile.
+	}
+
+	mentioned, err := testIdentifiers(analysis.Dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, fn := range analysis.Exported {
+		if !mentioned[fn.Name] {
+			analysis.Untested = append(analysis.Untested, fn)
+		}
+	}
+	return analysis, nil
+}
+
+func receiverType(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return ""
+	}
+	expr := fn.Recv.List[0].Type
+	for {
+		switch t := expr.(type) {
+		case *ast.StarExpr:
+			expr = t.X
+		case *ast.IndexExpr:
+			expr = t.X
+		case *ast.IndexListExpr:
+			expr = t.X
+		case *ast.Ident:
+			return t.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// testIdentifiers returns every identifier used in the package directory's
+// test files.
+func testIdentifiers(dir string) (map[string]bool, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*_test.go"))
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool)
+	fset := token.NewFileSet()
+	for _, path := range matches {
+		file, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			continue
+		}
+		ast.Inspect(file, func(n ast.Node) bool {
+			if ident, ok := n.(*ast.Ident); ok {
+				names[ident.Name] = true
+			}
+			return true
+		})
+	}
+	return names, nil
+}
+
+// coverBlocks are the cover profile blocks of one file.
+type coverBlocks []coverage.Block
+
+// fileBlocks returns the blocks of the file named base.
+func fileBlocks(blocks []coverage.Block, base string) coverBlocks {
+	var matched coverBlocks
+	for _, b := range blocks {
+		if filepath.Base(b.File) == base {
+			matched = append(matched, b)
+		}
+	}
+	return matched
+}
+
+// fraction returns the share of statements covered among the blocks within
+// the line range, or 0 when there are none.
+func (blocks coverBlocks) fraction(startLine, endLine int) float64 {
+	total, covered := 0, 0
+	for _, b := range blocks {
+		if b.StartLine < startLine || b.EndLine > endLine {
+			continue
+		}
+		total += b.Statements
+		if b.Count > 0 {
+			covered += b.Statements
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(covered) / float64(total)
+}
@@ -0,0 +1,273 @@
+package gentests
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// DefaultMaxAttempts is the default number of generate-and-verify rounds.
+	DefaultMaxAttempts = 3
+	// maxPromptOutputTail caps how much verification output goes into a prompt.
+	maxPromptOutputTail = 4000
+)
+
+// VerifyResult is the outcome of compiling and running the generated tests.
+type VerifyResult struct {
+	Command string `json:"command"`
+	Passed  bool   `json:"passed"`
+	Output  string `json:"output"`
+}
+
+// Attempt records one round of generation.
+type Attempt struct {
+	Attempt  int           `json:"attempt"`
+	Tests    []string      `json:"tests"`
+	Verify   *VerifyResult `json:"verify,omitempty"`
+	Problem  string        `json:"problem,omitempty"`
+	AgentErr string        `json:"agent_error,omitempty"`
+}
+
+// Report is the outcome of a generation run.
+type Report struct {
+	TestFile string    `json:"test_file"`
+	Attempts []Attempt `json:"attempts"`
+	// Tests are the generated test functions, set once they were kept.
+	Tests []string `json:"tests,omitempty"`
+	Kept  bool     `json:"kept"`
+}
+
+// Generator drives generation for one analyzed file. The hooks make it
+// independent of the agent so it can be exercised directly in tests.
+type Generator struct {
+	// Generate asks the agent to write or fix tests as described by prompt.
+	Generate func(prompt string) error
+	// Verify compiles and runs the named tests in the package directory.
+	Verify func(ctx context.Context, dir string, tests []string) (*VerifyResult, error)
+	// OnAttempt, when set, is called after each round.
+	OnAttempt   func(Attempt)
+	MaxAttempts int
+}
+
+// Run asks for tests covering analysis.Untested, verifies them and feeds
+// failures back until they pass or the attempts run out. The source file must
+// stay unchanged; edits to it are reverted. Tests that never pass are
+// removed by restoring the test file to its original state.
+func (g *Generator) Run(ctx context.Context, analysis *Analysis) (*Report, error) {
+	if len(analysis.Untested) == 0 {
+		return nil, errors.New("no untested exported functions to generate tests for")
+	}
+	maxAttempts := g.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+
+	testFile := analysis.TestFile()
+	report := &Report{TestFile: testFile}
+	source, err := os.ReadFile(analysis.File)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", analysis.File, err)
+	}
+	original, err := snapshotFile(testFile)
+	if err != nil {
+		return nil, err
+	}
+	existing := testFunctions(original.content)
+
+	prompt := BuildGeneratePrompt(analysis, testFile)
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return report, errors.Join(err, original.restore(testFile))
+		}
+		round := Attempt{Attempt: attempt}
+		if genErr := g.Generate(prompt); genErr != nil {
+			round.AgentErr = genErr.Error()
+		}
+
+		if current, err := os.ReadFile(analysis.File); err == nil && !bytes.Equal(current, source) {
+			if err := os.WriteFile(analysis.File, source, 0o644); err != nil {
+				return report, fmt.Errorf("failed to restore %s: %w", analysis.File, err)
+			}
+			round.Problem = fmt.Sprintf("%s was modified; the change was reverted. Only edit %s.", analysis.File, testFile)
+		}
+
+		current, err := os.ReadFile(testFile)
+		if err == nil {
+			round.Tests = newTests(testFunctions(current), existing)
+		}
+		if round.Problem == "" && len(round.Tests) == 0 {
+			round.Problem = fmt.Sprintf("No new Test functions were found in %s.", testFile)
+		}
+		if round.Problem == "" {
+			result, err := g.Verify(ctx, analysis.Dir, round.Tests)
+			if err != nil {
+				return report, errors.Join(err, original.restore(testFile))
+			}
+			round.Verify = result
+		}
+
+		report.Attempts = append(report.Attempts, round)
+		if g.OnAttempt != nil {
+			g.OnAttempt(round)
+		}
+		if round.Verify != nil && round.Verify.Passed {
+			report.Tests = round.Tests
+			report.Kept = true
+			return report, nil
+		}
+		prompt = BuildFixPrompt(testFile, round, attempt, maxAttempts)
+	}
+
+	if err := original.restore(testFile); err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+// fileSnapshot is a file's content before generation, for restoring it.
+type fileSnapshot struct {
+	exists  bool
+	content []byte
+}
+
+func snapshotFile(path string) (fileSnapshot, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return fileSnapshot{}, nil
+	}
+	if err != nil {
+		return fileSnapshot{}, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return fileSnapshot{exists: true, content: content}, nil
+}
+
+func (s fileSnapshot) restore(path string) error {
+	if !s.exists {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+		return nil
+	}
+	if err := os.WriteFile(path, s.content, 0o644); err != nil {
+		return fmt.Errorf("failed to restore %s: %w", path, err)
+	}
+	return nil
+}
+
+// testFunctions returns the names of the Test functions declared in src. A
+// file that does not parse yields the names found before the error.
+func testFunctions(src []byte) map[string]bool {
+	names := make(map[string]bool)
+	if len(src) == 0 {
+		return names
+	}
+	file, _ := parser.ParseFile(token.NewFileSet(), "", src, parser.SkipObjectResolution)
+	if file == nil {
+		return names
+	}
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil && strings.HasPrefix(fn.Name.Name, "Test") {
+			names[fn.Name.Name] = true
+		}
+	}
+	return names
+}
+
+func newTests(current, existing map[string]bool) []string {
+	var added []string
+	for name := range current {
+		if !existing[name] {
+			added = append(added, name)
+		}
+	}
+	sort.Strings(added)
+	return added
+}
+
+// VerifyGoTests compiles the package's tests and runs only the named ones.
+func VerifyGoTests(ctx context.Context, dir string, tests []string) (*VerifyResult, error) {
+	quoted := make([]string, len(tests))
+	for i, name := range tests {
+		quoted[i] = regexp.QuoteMeta(name)
+	}
+	pattern := "^(" + strings.Join(quoted, "|") + ")$"
+	command := fmt.Sprintf("go test -count=1 -run '%s' .", pattern)
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Dir = dir
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return nil, fmt.Errorf("failed to run %q: %w", command, err)
+	}
+	return &VerifyResult{Command: command, Passed: err == nil, Output: output.String()}, nil
+}
+
+// BuildGeneratePrompt asks the agent for table-driven tests of the untested
+// functions.
+func BuildGeneratePrompt(analysis *Analysis, testFile string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Write Go tests for the untested exported functions of %s (package %s):\n\n", analysis.File, analysis.Package)
+	for _, fn := range analysis.Untested {
+		fmt.Fprintf(&sb, "- %s (lines %d-%d)\n", fn, fn.StartLine, fn.EndLine)
+	}
+	fmt.Fprintf(&sb, "\nPut the tests in %s, creating it if needed and keeping any tests already there. ", testFile)
+	sb.WriteString("Read the source first and test its current behavior. Use table-driven tests " +
+		"(a slice of cases run with t.Run) with meaningful edge cases, and only the standard library " +
+		"and packages the module already uses. Do not modify the source file. " +
+		"The new tests will be compiled and run automatically after you finish, and only kept if they pass.")
+	return sb.String()
+}
+
+// BuildFixPrompt turns a failed attempt into instructions for the next one.
+func BuildFixPrompt(testFile string, round Attempt, attempt, maxAttempts int) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "The generated tests in %s were not accepted (attempt %d of %d).\n\n", testFile, attempt, maxAttempts)
+	if round.Problem != "" {
+		sb.WriteString(round.Problem + "\n\n")
+	}
+	if round.Verify != nil {
+		output := round.Verify.Output
+		if len(output) > maxPromptOutputTail {
+			output = "..." + output[len(output)-maxPromptOutputTail:]
+		}
+		fmt.Fprintf(&sb, "`%s` failed:\n```\n%s\n```\n\n", round.Verify.Command, strings.TrimRight(output, "\n"))
+	}
+	sb.WriteString("Fix the tests so they compile and pass against the current source. " +
+		"If a case fails because the expectation was wrong, correct the expectation; do not modify the source file.")
+	return sb.String()
+}
+
+// FormatAttempt renders an attempt as a short human-readable summary.
+func FormatAttempt(round Attempt) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Attempt %d: ", round.Attempt)
+	switch {
+	case round.Verify != nil && round.Verify.Passed:
+		fmt.Fprintf(&sb, "%d test(s) pass: %s", len(round.Tests), strings.Join(round.Tests, ", "))
+	case round.Verify != nil:
+		fmt.Fprintf(&sb, "%d test(s) failed verification", len(round.Tests))
+	default:
+		sb.WriteString(round.Problem)
+	}
+	if round.AgentErr != "" {
+		fmt.Fprintf(&sb, " (agent error: %s)", round.AgentErr)
+	}
+	return sb.String()
+}
@@ -0,0 +1,177 @@
+package gentests
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alantheprice/ledit/pkg/coverage"
+)
+
+const mathSource = `package mathx
+
+// Add adds.
+func Add(a, b int) int { return a + b }
+
+// Sub subtracts.
+func Sub(a, b int) int {
+	return a - b
+}
+
+func helper() {}
+
+type Counter struct{ n int }
+
+// Inc increments.
+func (c *Counter) Inc() { c.n++ }
+
+type hidden struct{}
+
+func (hidden) Visible() {}
+`
+
+func writeFixture(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func functionNames(fns []Function) []string {
+	names := make([]string, len(fns))
+	for i, fn := range fns {
+		names[i] = fn.String()
+	}
+	return names
+}
+
+func TestAnalyzeFindsUntestedExportedFunctions(t *testing.T) {
+	dir := writeFixture(t, map[string]string{
+		"math.go":      mathSource,
+		"math_test.go": "package mathx\n\nimport \"testing\"\n\nfunc TestAdd(t *testing.T) { _ = Add(1, 2) }\n",
+	})
+
+	analysis, err := Analyze(context.Background(), filepath.Join(dir, "math.go"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(functionNames(analysis.Exported), ","); got != "Add,Sub,Counter.Inc" {
+		t.Errorf("exported = %s", got)
+	}
+	if got := strings.Join(functionNames(analysis.Untested), ","); got != "Sub,Counter.Inc" {
+		t.Errorf("untested = %s", got)
+	}
+	if sub := analysis.Untested[0]; sub.StartLine != 7 || sub.EndLine != 9 {
+		t.Errorf("Sub spans lines %d-%d, want 7-9", sub.StartLine, sub.EndLine)
+	}
+	if analysis.TestFile() != filepath.Join(dir, "math_test.go") {
+		t.Errorf("test file = %s", analysis.TestFile())
+	}
+
+	if _, err := Analyze(context.Background(), filepath.Join(dir, "math_test.go"), false); err == nil {
+		t.Error("expected test files to be rejected")
+	}
+}
+
+func TestFileBlocksFraction(t *testing.T) {
+	profile := "mode: set\n" +
+		"example.com/mathx/math.go:4.28,4.41 1 1\n" +
+		"example.com/mathx/math.go:7.26,9.2 1 0\n" +
+		"example.com/mathx/other.go:7.26,9.2 1 1\n"
+	blocks := fileBlocks(coverage.ParseProfile([]byte(profile)), "math.go")
+	if len(blocks) != 2 {
+		t.Fatalf("expected the two math.go blocks, got %+v", blocks)
+	}
+	if got := blocks.fraction(4, 4); got != 1 {
+		t.Errorf("Add coverage = %v, want 1", got)
+	}
+	if got := blocks.fraction(7, 9); got != 0 {
+		t.Errorf("Sub coverage = %v, want 0", got)
+	}
+}
+
+func TestGeneratorKeepsPassingTests(t *testing.T) {
+	dir := writeFixture(t, map[string]string{"math.go": mathSource})
+	analysis, err := Analyze(context.Background(), filepath.Join(dir, "math.go"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var prompts []string
+	generator := &Generator{
+		Generate: func(prompt string) error {
+			prompts = append(prompts, prompt)
+			content := "package mathx\n\nimport \"testing\"\n\nfunc TestSub(t *testing.T) {}\n"
+			if len(prompts) == 2 {
+				content += "\nfunc TestCounterInc(t *testing.T) {}\n"
+			}
+			return os.WriteFile(analysis.TestFile(), []byte(content), 0o644)
+		},
+		Verify: func(_ context.Context, _ string, tests []string) (*VerifyResult, error) {
+			passed := len(tests) == 2
+			return &VerifyResult{Command: "go test", Passed: passed, Output: "--- FAIL: TestSub"}, nil
+		},
+	}
+	report, err := generator.Run(context.Background(), analysis)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Kept || len(report.Attempts) != 2 || strings.Join(report.Tests, ",") != "TestCounterInc,TestSub" {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if !strings.Contains(prompts[0], "- Sub (lines 7-9)") || !strings.Contains(prompts[0], "table-driven") {
+		t.Errorf("unexpected first prompt: %s", prompts[0])
+	}
+	if !strings.Contains(prompts[1], "--- FAIL: TestSub") {
+		t.Errorf("expected the failure output in the fix prompt: %s", prompts[1])
+	}
+	if _, err := os.Stat(analysis.TestFile()); err != nil {
+		t.Errorf("kept tests should stay on disk: %v", err)
+	}
+}
+
+func TestGeneratorDiscardsFailingTestsAndRevertsSource(t *testing.T) {
+	original := "package mathx\n\nimport \"testing\"\n\nfunc TestExisting(t *testing.T) {}\n"
+	dir := writeFixture(t, map[string]string{"math.go": mathSource, "math_test.go": original})
+	analysis, err := Analyze(context.Background(), filepath.Join(dir, "math.go"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verified := 0
+	generator := &Generator{
+		MaxAttempts: 2,
+		Generate: func(string) error {
+			if err := os.WriteFile(analysis.File, []byte(mathSource+"\n// changed\n"), 0o644); err != nil {
+				return err
+			}
+			return os.WriteFile(analysis.TestFile(), []byte(original+"\nfunc TestSub(t *testing.T) {}\n"), 0o644)
+		},
+		Verify: func(context.Context, string, []string) (*VerifyResult, error) {
+			verified++
+			return &VerifyResult{Passed: true}, nil
+		},
+	}
+	report, err := generator.Run(context.Background(), analysis)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Kept || verified != 0 {
+		t.Fatalf("tests written alongside source edits must not be kept: %+v", report)
+	}
+	if !strings.Contains(report.Attempts[0].Problem, "was modified") {
+		t.Errorf("unexpected problem: %q", report.Attempts[0].Problem)
+	}
+	if source, _ := os.ReadFile(analysis.File); string(source) != mathSource {
+		t.Error("source edits should be reverted")
+	}
+	if tests, _ := os.ReadFile(analysis.TestFile()); string(tests) != original {
+		t.Errorf("test file should be restored, got:\n%s", tests)
+	}
+}
@@ -24,6 +24,13 @@ type CommitMessageOptions struct {
 	Branch           string
 	FileChanges      []CommitFileChange
 	UserInstructions string
+	// Style selects the message convention: CommitStyleDefault (the
+	// default when empty) or CommitStyleConventional.
+	Style string
+	// ProjectType is the workspace's project type (see
+	// filediscovery.DetectProjectType), used as a hint for conventional
+	// commits.
+	ProjectType string
 }
 
 // CommitMessageResult contains generated message and diagnostics.
@@ -44,12 +51,9 @@ func GenerateCommitMessageFromStagedDiff(client api.ClientInterface, opts Commit
 		return nil, fmt.Errorf("staged diff is empty")
 	}
 
-	// Get timeout from agent config, default to 5 minutes
-	timeoutSec := 300 // Default 5 minutes
-	if agent, ok := client.(interface{ GetConfig() *configuration.Config }); ok {
-		if cfg := agent.GetConfig(); cfg != nil && cfg.APITimeouts != nil && cfg.APITimeouts.CommitMessageTimeoutSec > 0 {
-			timeoutSec = cfg.APITimeouts.CommitMessageTimeoutSec
-		}
+	timeoutSec := commitMessageTimeout(client)
+	if opts.Style == CommitStyleConventional {
+		return generateConventionalCommitMessage(client, opts, timeoutSec)
 	}
 
 	primaryAction := "Updates"
@@ -227,6 +231,49 @@ Generate a Git commit message summary. The message should follow these rules:
 	}, nil
 }
 
+// commitMessageTimeout returns the commit message timeout from the client's
+// config, defaulting to 5 minutes.
+func commitMessageTimeout(client api.ClientInterface) int {
+	timeoutSec := 300
+	if agent, ok := client.(interface{ GetConfig() *configuration.Config }); ok {
+		if cfg := agent.GetConfig(); cfg != nil && cfg.APITimeouts != nil && cfg.APITimeouts.CommitMessageTimeoutSec > 0 {
+			timeoutSec = cfg.APITimeouts.CommitMessageTimeoutSec
+		}
+	}
+	return timeoutSec
+}
+
+// sendCommitPrompt sends a single tool-less request and waits at most
+// timeoutSec for a reply with at least one choice.
+func sendCommitPrompt(client api.ClientInterface, system, prompt string, timeoutSec int) (*api.ChatResponse, error) {
+	messages := []api.Message{
+		{Role: "system", Content: system},
+		{Role: "user", Content: prompt},
+	}
+	type callResult struct {
+		resp *api.ChatResponse
+		err  error
+	}
+	done := make(chan callResult, 1)
+	go func() {
+		r, e := client.SendChatRequest(messages, nil, "", false)
+		done <- callResult{r, e}
+	}()
+
+	select {
+	case result := <-done:
+		if result.err != nil {
+			return nil, result.err
+		}
+		if result.resp == nil || len(result.resp.Choices) == 0 {
+			return nil, fmt.Errorf("no response from model")
+		}
+		return result.resp, nil
+	case <-time.After(time.Duration(timeoutSec) * time.Second):
+		return nil, fmt.Errorf("LLM request timed out after %ds", timeoutSec)
+	}
+}
+
 func actionFromStatus(status string) string {
 	switch strings.TrimSpace(status) {
 	case "A":
@@ -0,0 +1,285 @@
+package git
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	api "github.com/alantheprice/ledit/pkg/agent_api"
+)
+
+// conflictContextLines is how many lines around a conflict are shown to the
+// model when it proposes a resolution.
+const conflictContextLines = 20
+
+// ConflictHunk is one conflict region of a file, between the <<<<<<< and
+// >>>>>>> markers. Base is only set for diff3/zdiff3 conflict styles.
+type ConflictHunk struct {
+	StartLine   int // 1-based line of the <<<<<<< marker
+	EndLine     int // 1-based line of the >>>>>>> marker
+	OursLabel   string
+	TheirsLabel string
+	Ours        string
+	Base        string
+	HasBase     bool
+	Theirs      string
+}
+
+// Operation is a git operation that can stop with conflicts.
+type Operation string
+
+const (
+	OperationNone       Operation = ""
+	OperationMerge      Operation = "merge"
+	OperationRebase     Operation = "rebase"
+	OperationCherryPick Operation = "cherry-pick"
+	OperationRevert     Operation = "revert"
+	OperationAm         Operation = "am"
+)
+
+// ContinueCommand is the command that finishes the operation once every
+// conflict is resolved and staged.
+func (o Operation) ContinueCommand() string {
+	switch o {
+	case OperationMerge:
+		return "git commit --no-edit"
+	case OperationNone:
+		return ""
+	default:
+		return "git " + string(o) + " --continue"
+	}
+}
+
+// ConflictedFiles returns the unmerged paths of the repository in dir,
+// relative to the repository root.
+func ConflictedFiles(dir string) ([]string, error) {
+	cmd := exec.Command("git", "diff", "--name-only", "--diff-filter=U")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list conflicted files: %w", err)
+	}
+	var files []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			files = append(files, line)
+		}
+	}
+	return files, nil
+}
+
+// InProgressOperation reports which operation stopped the repository in dir,
+// or OperationNone.
+func InProgressOperation(dir string) Operation {
+	checks := []struct {
+		path string
+		op   Operation
+	}{
+		{"rebase-merge", OperationRebase},
+		{"rebase-apply/applying", OperationAm},
+		{"rebase-apply", OperationRebase},
+		{"MERGE_HEAD", OperationMerge},
+		{"CHERRY_PICK_HEAD", OperationCherryPick},
+		{"REVERT_HEAD", OperationRevert},
+	}
+	for _, check := range checks {
+		cmd := exec.Command("git", "rev-parse", "--git-path", check.path)
+		cmd.Dir = dir
+		out, err := cmd.Output()
+		if err != nil {
+			return OperationNone
+		}
+		path := strings.TrimSpace(string(out))
+		if !filepath.IsAbs(path) && dir != "" {
+			path = filepath.Join(dir, path)
+		}
+		if _, err := os.Stat(path); err == nil {
+			return check.op
+		}
+	}
+	return OperationNone
+}
+
+// conflictMarker returns which conflict marker line is, if any: '<', '|',
+// '=' or '>', and the label that follows it.
+func conflictMarker(line string) (byte, string) {
+	line = strings.TrimRight(line, "\r\n")
+	if len(line) < 7 {
+		return 0, ""
+	}
+	for _, c := range []byte{'<', '|', '=', '>'} {
+		if line[:7] != strings.Repeat(string(c), 7) {
+			continue
+		}
+		rest := line[7:]
+		if c == '=' {
+			if rest == "" {
+				return c, ""
+			}
+			return 0, ""
+		}
+		if rest == "" || rest[0] == ' ' {
+			return c, strings.TrimSpace(rest)
+		}
+	}
+	return 0, ""
+}
+
+// ParseConflicts finds the conflict hunks in content. It fails on conflicts
+// that are not terminated or contain misplaced markers.
+func ParseConflicts(content string) ([]ConflictHunk, error) {
+	hunks, _, err := scanConflicts(content, nil)
+	return hunks, err
+}
+
+// ApplyConflictResolutions replaces the hunks with the given indexes (in
+// ParseConflicts order) by their resolutions and leaves the other hunks in
+// place.
+func ApplyConflictResolutions(content string, resolutions map[int]string) (string, error) {
+	_, resolved, err := scanConflicts(content, resolutions)
+	return resolved, err
+}
+
+// scanConflicts parses content and rebuilds it with resolutions applied.
+func scanConflicts(content string, resolutions map[int]string) ([]ConflictHunk, string, error) {
+	const (
+		outside = iota
+		inOurs
+		inBase
+		inTheirs
+	)
+	var (
+		hunks   []ConflictHunk
+		out     strings.Builder
+		raw     strings.Builder // the current hunk as written
+		section strings.Builder
+		current ConflictHunk
+		state   = outside
+	)
+	lines := strings.SplitAfter(content, "\n")
+	for i, line := range lines {
+		if line == "" {
+			continue
+		}
+		marker, label := conflictMarker(line)
+		switch {
+		case state == outside && marker == '<':
+			current = ConflictHunk{StartLine: i + 1, OursLabel: label}
+			raw.Reset()
+			section.Reset()
+			state = inOurs
+		case state == outside:
+			// Lines like a lone ======= (e.g. a Markdown heading underline)
+			// are content outside a conflict.
+			out.WriteString(line)
+			continue
+		case state == inOurs && marker == '|':
+			current.Ours = section.String()
+			current.HasBase = true
+			section.Reset()
+			state = inBase
+		case (state == inOurs || state == inBase) && marker == '=':
+			if state == inOurs {
+				current.Ours = section.String()
+			} else {
+				current.Base = section.String()
+			}
+			section.Reset()
+			state = inTheirs
+		case state == inTheirs && marker == '>':
+			current.Theirs = section.String()
+			current.TheirsLabel = label
+			current.EndLine = i + 1
+			raw.WriteString(line)
+			if resolution, ok := resolutions[len(hunks)]; ok {
+				out.WriteString(resolution)
+				if resolution != "" && !strings.HasSuffix(resolution, "\n") {
+					out.WriteString("\n")
+				}
+			} else {
+				out.WriteString(raw.String())
+			}
+			hunks = append(hunks, current)
+			state = outside
+			continue
+		case marker != 0:
+			return nil, "", fmt.Errorf("line %d: unexpected conflict marker %q", i+1, strings.TrimSpace(line))
+		default:
+			section.WriteString(line)
+		}
+		raw.WriteString(line)
+	}
+	if state != outside {
+		return nil, "", fmt.Errorf("conflict starting at line %d is not terminated", current.StartLine)
+	}
+	return hunks, out.String(), nil
+}
+
+// ConflictProposal is a model's suggested resolution of one hunk.
+type ConflictProposal struct {
+	Resolution  string `json:"resolution"`
+	Explanation string `json:"explanation"`
+}
+
+// ProposeConflictResolution asks the model to resolve hunk, one of the
+// conflicts in path, showing it the surrounding lines of content.
+func ProposeConflictResolution(client api.ClientInterface, path, content string, hunk ConflictHunk, op Operation) (*ConflictProposal, error) {
+	if client == nil {
+		return nil, fmt.Errorf("no LLM client available")
+	}
+	system := "You resolve git merge conflicts. Combine the intent of both sides: keep every change that still applies, drop only what the other side deliberately removed or replaced, and produce code that compiles. " +
+		"Reply with JSON only: {\"resolution\": \"<the exact lines that replace the whole conflict, without markers>\", \"explanation\": \"<one or two sentences>\"}."
+	resp, err := sendCommitPrompt(client, system, buildConflictPrompt(path, content, hunk, op), commitMessageTimeout(client))
+	if err != nil {
+		return nil, err
+	}
+	return parseConflictProposal(resp.Choices[0].Message.Content)
+}
+
+func buildConflictPrompt(path, content string, hunk ConflictHunk, op Operation) string {
+	var sb strings.Builder
+	if op != OperationNone {
+		fmt.Fprintf(&sb, "A git %s stopped with a conflict in %s (lines %d-%d).\n", op, path, hunk.StartLine, hunk.EndLine)
+	} else {
+		fmt.Fprintf(&sb, "Resolve the conflict in %s (lines %d-%d).\n", path, hunk.StartLine, hunk.EndLine)
+	}
+
+	lines := strings.SplitAfter(content, "\n")
+	from := max(hunk.StartLine-1-conflictContextLines, 0)
+	to := min(hunk.EndLine+conflictContextLines, len(lines))
+	fmt.Fprintf(&sb, "\nSurrounding code (lines %d-%d):\n```\n%s```\n", from+1, to, strings.Join(lines[from:to], ""))
+
+	fmt.Fprintf(&sb, "\nOurs (%s):\n```\n%s```\n", labelOr(hunk.OursLabel, "current"), hunk.Ours)
+	if hunk.HasBase {
+		fmt.Fprintf(&sb, "\nCommon ancestor:\n```\n%s```\n", hunk.Base)
+	}
+	fmt.Fprintf(&sb, "\nTheirs (%s):\n```\n%s```\n", labelOr(hunk.TheirsLabel, "incoming"), hunk.Theirs)
+	return sb.String()
+}
+
+func labelOr(label, fallback string) string {
+	if label == "" {
+		return fallback
+	}
+	return label
+}
+
+func parseConflictProposal(reply string) (*ConflictProposal, error) {
+	start := strings.Index(reply, "{")
+	end := strings.LastIndex(reply, "}")
+	if start < 0 || end <= start {
+		return nil, fmt.Errorf("model did not return a JSON resolution")
+	}
+	var proposal ConflictProposal
+	if err := json.Unmarshal([]byte(reply[start:end+1]), &proposal); err != nil {
+		return nil, fmt.Errorf("could not parse proposed resolution: %w", err)
+	}
+	if hunks, err := ParseConflicts(proposal.Resolution); err != nil || len(hunks) > 0 {
+		return nil, fmt.Errorf("proposed resolution still contains conflict markers")
+	}
+	proposal.Explanation = strings.TrimSpace(proposal.Explanation)
+	return &proposal, nil
+}
@@ -0,0 +1,139 @@
+package git
+
+import (
+	"strings"
+	"testing"
+)
+
+const twoConflicts = `package main
+
+<<<<<<< HEAD
+func a() int { return 1 }
+=======
+func a() int { return 2 }
+>>>>>>> feature
+func b() {}
+<<<<<<< HEAD
+var x = 1
+||||||| base
+var x = 0
+=======
+var x = 2
+>>>>>>> feature
+`
+
+func TestParseConflicts(t *testing.T) {
+	hunks, err := ParseConflicts(twoConflicts)
+	if err != nil {
+		t.Fatalf("ParseConflicts: %v", err)
+	}
+	if len(hunks) != 2 {
+		t.Fatalf("expected 2 hunks, got %d", len(hunks))
+	}
+
+	first := hunks[0]
+	if first.StartLine != 3 || first.EndLine != 7 {
+		t.Errorf("first hunk lines = %d-%d, want 3-7", first.StartLine, first.EndLine)
+	}
+	if first.OursLabel != "HEAD" || first.TheirsLabel != "feature" {
+		t.Errorf("labels = %q/%q", first.OursLabel, first.TheirsLabel)
+	}
+	if first.Ours != "func a() int { return 1 }\n" || first.Theirs != "func a() int { return 2 }\n" {
+		t.Errorf("unexpected sides: %q / %q", first.Ours, first.Theirs)
+	}
+	if first.HasBase {
+		t.Error("first hunk should not have a base")
+	}
+
+	second := hunks[1]
+	if !second.HasBase || second.Base != "var x = 0\n" {
+		t.Errorf("second hunk base = %q (HasBase %v)", second.Base, second.HasBase)
+	}
+	if second.Ours != "var x = 1\n" || second.Theirs != "var x = 2\n" {
+		t.Errorf("unexpected sides: %q / %q", second.Ours, second.Theirs)
+	}
+}
+
+func TestParseConflicts_MarkersOutsideConflict(t *testing.T) {
+	content := "Title\n=======\n\ntext\n"
+	hunks, err := ParseConflicts(content)
+	if err != nil {
+		t.Fatalf("ParseConflicts: %v", err)
+	}
+	if len(hunks) != 0 {
+		t.Fatalf("expected no hunks, got %d", len(hunks))
+	}
+}
+
+func TestParseConflicts_Malformed(t *testing.T) {
+	tests := map[string]string{
+		"unterminated":   "<<<<<<< HEAD\na\n=======\nb\n",
+		"nested start":   "<<<<<<< HEAD\n<<<<<<< HEAD\n=======\n>>>>>>> x\n",
+		"end before sep": "<<<<<<< HEAD\na\n>>>>>>> x\n",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseConflicts(content); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestApplyConflictResolutions(t *testing.T) {
+	resolved, err := ApplyConflictResolutions(twoConflicts, map[int]string{1: "var x = 3"})
+	if err != nil {
+		t.Fatalf("ApplyConflictResolutions: %v", err)
+	}
+	if !strings.Contains(resolved, "func b() {}\nvar x = 3\n") {
+		t.Errorf("second hunk not replaced:\n%s", resolved)
+	}
+	hunks, err := ParseConflicts(resolved)
+	if err != nil {
+		t.Fatalf("ParseConflicts after apply: %v", err)
+	}
+	if len(hunks) != 1 || hunks[0].Ours != "func a() int { return 1 }\n" {
+		t.Fatalf("first hunk should be left in place, got %+v", hunks)
+	}
+
+	resolved, err = ApplyConflictResolutions(resolved, map[int]string{0: ""})
+	if err != nil {
+		t.Fatalf("ApplyConflictResolutions: %v", err)
+	}
+	want := "package main\n\nfunc b() {}\nvar x = 3\n"
+	if resolved != want {
+		t.Errorf("resolved = %q, want %q", resolved, want)
+	}
+}
+
+func TestParseConflictProposal(t *testing.T) {
+	proposal, err := parseConflictProposal("Here you go:\n{\"resolution\": \"var x = 3\\n\", \"explanation\": \" keeps both \"}")
+	if err != nil {
+		t.Fatalf("parseConflictProposal: %v", err)
+	}
+	if proposal.Resolution != "var x = 3\n" || proposal.Explanation != "keeps both" {
+		t.Errorf("unexpected proposal %+v", proposal)
+	}
+
+	if _, err := parseConflictProposal("no json"); err == nil {
+		t.Error("expected an error without JSON")
+	}
+	withMarkers := `{"resolution": "<<<<<<< HEAD\na\n=======\nb\n>>>>>>> x\n"}`
+	if _, err := parseConflictProposal(withMarkers); err == nil {
+		t.Error("expected an error for a resolution with conflict markers")
+	}
+}
+
+func TestOperationContinueCommand(t *testing.T) {
+	tests := map[Operation]string{
+		OperationNone:       "",
+		OperationMerge:      "git commit --no-edit",
+		OperationRebase:     "git rebase --continue",
+		OperationCherryPick: "git cherry-pick --continue",
+	}
+	for op, want := range tests {
+		if got := op.ContinueCommand(); got != want {
+			t.Errorf("%q.ContinueCommand() = %q, want %q", op, got, want)
+		}
+	}
+}
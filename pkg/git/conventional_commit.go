@@ -0,0 +1,382 @@
+package git
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	api "github.com/alantheprice/ledit/pkg/agent_api"
+	"github.com/alantheprice/ledit/pkg/utils"
+)
+
+// Commit message styles, set with the commit_style config key.
+const (
+	CommitStyleDefault      = "default"
+	CommitStyleConventional = "conventional"
+)
+
+// ConventionalCommitTypes are the commit types accepted in Conventional
+// Commit headers.
+var ConventionalCommitTypes = []string{"feat", "fix", "docs", "style", "refactor", "perf", "test", "build", "ci", "chore", "revert"}
+
+// ConventionalCommit is a commit message in the Conventional Commits format:
+//
+//	type(scope)!: description
+//
+//	body
+//
+//	BREAKING CHANGE: breaking change
+type ConventionalCommit struct {
+	Type           string `json:"type"`
+	Scope          string `json:"scope"`
+	Description    string `json:"description"`
+	Body           string `json:"body"`
+	BreakingChange string `json:"breaking_change"`
+}
+
+var conventionalHeaderPattern = regexp.MustCompile(`^([a-z]+)(?:\(([^()]*)\))?(!)?: (.+)$`)
+
+// String formats the commit with a header of at most 72 characters and a
+// body wrapped at 72 columns.
+func (c ConventionalCommit) String() string {
+	header := c.Type
+	if c.Scope != "" {
+		header += "(" + c.Scope + ")"
+	}
+	if c.BreakingChange != "" {
+		header += "!"
+	}
+	header += ": "
+	header += TruncateRunes(c.Description, 72-len(header))
+
+	parts := []string{header}
+	if body := strings.TrimSpace(c.Body); body != "" {
+		parts = append(parts, WrapText(body, 72))
+	}
+	if c.BreakingChange != "" {
+		parts = append(parts, WrapText("BREAKING CHANGE: "+c.BreakingChange, 72))
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// ParseConventionalCommit parses a Conventional Commit message. It fails
+// when the first line is not a "type(scope): description" header.
+func ParseConventionalCommit(message string) (ConventionalCommit, error) {
+	message = strings.TrimSpace(message)
+	header, rest, _ := strings.Cut(message, "\n")
+	m := conventionalHeaderPattern.FindStringSubmatch(strings.TrimSpace(header))
+	if m == nil {
+		return ConventionalCommit{}, fmt.Errorf("not a conventional commit header: %q", header)
+	}
+	commit := ConventionalCommit{Type: m[1], Scope: m[2], Description: strings.TrimSpace(m[4])}
+
+	var body []string
+	for _, paragraph := range strings.Split(strings.TrimSpace(rest), "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		for _, prefix := range []string{"BREAKING CHANGE:", "BREAKING-CHANGE:"} {
+			if strings.HasPrefix(paragraph, prefix) {
+				commit.BreakingChange = strings.TrimSpace(strings.TrimPrefix(paragraph, prefix))
+				paragraph = ""
+			}
+		}
+		if paragraph != "" {
+			body = append(body, paragraph)
+		}
+	}
+	commit.Body = strings.Join(body, "\n\n")
+	if m[3] == "!" && commit.BreakingChange == "" {
+		commit.BreakingChange = commit.Description
+	}
+	return commit, nil
+}
+
+// containerDirs hold one module or package per subdirectory, so the scope
+// is the subdirectory name (pkg/git -> git, packages/web -> web).
+var containerDirs = map[string]bool{
+	"pkg": true, "internal": true, "cmd": true, "src": true, "lib": true,
+	"packages": true, "apps": true, "services": true, "modules": true, "crates": true,
+}
+
+var dependencyFiles = map[string]bool{
+	"go.mod": true, "go.sum": true, "package.json": true, "package-lock.json": true,
+	"yarn.lock": true, "pnpm-lock.yaml": true, "Cargo.toml": true, "Cargo.lock": true,
+	"requirements.txt": true, "pyproject.toml": true, "poetry.lock": true,
+}
+
+// scopeForPath returns the scope a single changed path suggests.
+func scopeForPath(p string) string {
+	p = path.Clean(strings.ReplaceAll(p, "\\", "/"))
+	dir, base := path.Split(p)
+	parts := strings.Split(strings.Trim(dir, "/"), "/")
+	switch {
+	case dir == "":
+		switch {
+		case dependencyFiles[base]:
+			return "deps"
+		case strings.HasSuffix(strings.ToLower(base), ".md"):
+			return "docs"
+		}
+		return ""
+	case parts[0] == ".github" || parts[0] == ".circleci":
+		return "ci"
+	case parts[0] == "docs" || parts[0] == "doc":
+		return "docs"
+	case containerDirs[parts[0]] && len(parts) > 1:
+		return strings.ToLower(parts[1])
+	}
+	return strings.ToLower(strings.TrimPrefix(parts[0], "."))
+}
+
+// InferCommitScope picks the scope most of the changed files share: the
+// package or module directory they live in. It returns "" when the changes
+// are spread across the workspace.
+func InferCommitScope(changes []CommitFileChange) string {
+	if len(changes) == 0 {
+		return ""
+	}
+	counts := make(map[string]int)
+	for _, change := range changes {
+		if scope := scopeForPath(change.Path); scope != "" {
+			counts[scope]++
+		}
+	}
+	best, bestCount, tied := "", 0, false
+	for scope, count := range counts {
+		switch {
+		case count > bestCount:
+			best, bestCount, tied = scope, count, false
+		case count == bestCount:
+			tied = true
+		}
+	}
+	// The scope must cover at least two thirds of the changed files.
+	if tied || bestCount*3 < len(changes)*2 {
+		return ""
+	}
+	return best
+}
+
+// InferCommitType returns the commit type implied by the kind of files
+// changed (only tests, only docs, ...), or "" when the diff has to decide.
+func InferCommitType(changes []CommitFileChange) string {
+	if len(changes) == 0 {
+		return ""
+	}
+	kinds := make(map[string]bool)
+	for _, change := range changes {
+		kinds[fileKind(change.Path)] = true
+	}
+	if len(kinds) != 1 {
+		return ""
+	}
+	for kind := range kinds {
+		return kind
+	}
+	return ""
+}
+
+func fileKind(p string) string {
+	p = strings.ReplaceAll(p, "\\", "/")
+	lower := strings.ToLower(p)
+	base := path.Base(p)
+	switch {
+	case strings.HasSuffix(lower, "_test.go"), strings.Contains(lower, ".test."), strings.Contains(lower, ".spec."),
+		strings.Contains(lower, "__tests__/"), strings.HasPrefix(lower, "test/"), strings.HasPrefix(lower, "tests/"):
+		return "test"
+	case strings.HasPrefix(lower, ".github/"), strings.HasPrefix(lower, ".circleci/"), base == ".gitlab-ci.yml":
+		return "ci"
+	case dependencyFiles[base], base == "Makefile", base == "Dockerfile":
+		return "build"
+	case strings.HasSuffix(lower, ".md"), strings.HasSuffix(lower, ".rst"), strings.HasPrefix(lower, "docs/"):
+		return "docs"
+	}
+	return ""
+}
+
+var exportedGoDeclPattern = regexp.MustCompile(`^func (?:\([^)]*\) )?([A-Z]\w*)|^type ([A-Z]\w*)`)
+
+// removedExportedSymbols lists exported Go functions and types the diff
+// removes without adding back, a hint that the change may be breaking.
+func removedExportedSymbols(diff string) []string {
+	removed := make(map[string]bool)
+	added := make(map[string]bool)
+	for _, line := range strings.Split(diff, "\n") {
+		if strings.HasPrefix(line, "---") || strings.HasPrefix(line, "+++") || len(line) < 2 {
+			continue
+		}
+		m := exportedGoDeclPattern.FindStringSubmatch(line[1:])
+		if m == nil {
+			continue
+		}
+		name := m[1] + m[2]
+		switch line[0] {
+		case '-':
+			removed[name] = true
+		case '+':
+			added[name] = true
+		}
+	}
+	var symbols []string
+	for name := range removed {
+		if !added[name] {
+			symbols = append(symbols, name)
+		}
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// generateConventionalCommitMessage asks the model for the parts of a
+// Conventional Commit and assembles the message. The scope inferred from
+// the changed paths takes precedence over the model's.
+func generateConventionalCommitMessage(client api.ClientInterface, opts CommitMessageOptions, timeoutSec int) (*CommitMessageResult, error) {
+	optimized := utils.NewDiffOptimizer().OptimizeDiff(strings.TrimSpace(opts.Diff))
+	inferredType := InferCommitType(opts.FileChanges)
+	inferredScope := InferCommitScope(opts.FileChanges)
+
+	var hints strings.Builder
+	if opts.ProjectType != "" && opts.ProjectType != "unknown" {
+		fmt.Fprintf(&hints, "Project type: %s\n", opts.ProjectType)
+	}
+	hints.WriteString("Changed files:\n")
+	for _, change := range opts.FileChanges {
+		fmt.Fprintf(&hints, "- %s %s\n", change.Status, change.Path)
+	}
+	if inferredType != "" {
+		fmt.Fprintf(&hints, "Suggested type: %s\n", inferredType)
+	}
+	if inferredScope != "" {
+		fmt.Fprintf(&hints, "Suggested scope: %s\n", inferredScope)
+	}
+	if removed := removedExportedSymbols(opts.Diff); len(removed) > 0 {
+		fmt.Fprintf(&hints, "Exported symbols removed: %s (check whether this breaks callers)\n", strings.Join(removed, ", "))
+	}
+	if instructions := strings.TrimSpace(opts.UserInstructions); instructions != "" {
+		fmt.Fprintf(&hints, "\nUSER INSTRUCTIONS:\n%s\n", instructions)
+	}
+
+	prompt := fmt.Sprintf(`%s
+CODE CHANGES:
+%s
+
+Describe these changes as a Conventional Commit. Respond with a single JSON object and nothing else:
+{"type": "%s", "scope": "short lowercase scope or empty", "description": "imperative summary, lowercase, no period", "body": "what changed and why, plain text", "breaking_change": "what breaks for users, or empty"}
+
+Rules:
+- description must be under 60 characters
+- body is one or two short paragraphs without markdown, lists or filenames
+- only set breaking_change when existing users or callers must change something`,
+		hints.String(), optimized.OptimizedContent, strings.Join(ConventionalCommitTypes, "|"))
+
+	resp, err := sendCommitPrompt(client, "You are a git commit message generator that follows the Conventional Commits specification.", prompt, timeoutSec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate commit message: %w", err)
+	}
+
+	commit, err := parseConventionalReply(resp.Choices[0].Message.Content)
+	if err != nil {
+		return nil, err
+	}
+	commit.Type = strings.ToLower(strings.TrimSpace(commit.Type))
+	if !isConventionalType(commit.Type) {
+		commit.Type = inferredType
+		if commit.Type == "" {
+			commit.Type = "chore"
+		}
+	}
+	if inferredScope != "" {
+		commit.Scope = inferredScope
+	} else {
+		commit.Scope = sanitizeScope(commit.Scope)
+	}
+	commit.Description = strings.TrimSuffix(NormalizeShortTitle(commit.Description), ".")
+	if commit.Description == "" {
+		return nil, fmt.Errorf("generated commit description was empty")
+	}
+	commit.BreakingChange = strings.TrimSpace(commit.BreakingChange)
+
+	return &CommitMessageResult{
+		Message:      commit.String(),
+		ApproxTokens: resp.Usage.TotalTokens,
+		Warnings:     append([]string(nil), optimized.Warnings...),
+	}, nil
+}
+
+// parseConventionalReply reads the model's JSON reply, falling back to a
+// plain Conventional Commit message.
+func parseConventionalReply(reply string) (ConventionalCommit, error) {
+	start := strings.Index(reply, "{")
+	end := strings.LastIndex(reply, "}")
+	if start >= 0 && end > start {
+		var commit ConventionalCommit
+		if err := json.Unmarshal([]byte(reply[start:end+1]), &commit); err == nil {
+			return commit, nil
+		}
+	}
+	commit, err := ParseConventionalCommit(strings.Trim(strings.TrimSpace(reply), "`"))
+	if err != nil {
+		return ConventionalCommit{}, fmt.Errorf("could not parse generated commit message: %w", err)
+	}
+	return commit, nil
+}
+
+func isConventionalType(commitType string) bool {
+	for _, t := range ConventionalCommitTypes {
+		if t == commitType {
+			return true
+		}
+	}
+	return false
+}
+
+func sanitizeScope(scope string) string {
+	scope = strings.ToLower(strings.TrimSpace(scope))
+	scope = strings.Trim(scope, "()")
+	return strings.Join(strings.Fields(scope), "-")
+}
+
+// GeneratePullRequestDescription drafts a Markdown pull request description
+// for the staged changes and the commit message generated for them.
+func GeneratePullRequestDescription(client api.ClientInterface, opts CommitMessageOptions, commitMessage string) (string, error) {
+	if client == nil {
+		return "", fmt.Errorf("client is required")
+	}
+	optimized := utils.NewDiffOptimizer().OptimizeDiff(strings.TrimSpace(opts.Diff))
+
+	var files strings.Builder
+	for _, change := range opts.FileChanges {
+		fmt.Fprintf(&files, "- %s %s\n", change.Status, change.Path)
+	}
+	prompt := fmt.Sprintf(`Write a pull request description for these changes.
+
+Commit message:
+%s
+
+Changed files:
+%s
+CODE CHANGES:
+%s
+
+Use Markdown with exactly these sections:
+## Summary
+One or two sentences on what the change does and why.
+## Changes
+A short bullet list of the notable changes.
+## Testing
+How the change can be verified.
+
+Return only the description.`, strings.TrimSpace(commitMessage), files.String(), optimized.OptimizedContent)
+
+	resp, err := sendCommitPrompt(client, "You write concise pull request descriptions for code reviewers.", prompt, commitMessageTimeout(client))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate PR description: %w", err)
+	}
+	description := strings.TrimSpace(resp.Choices[0].Message.Content)
+	if description == "" {
+		return "", fmt.Errorf("generated PR description was empty")
+	}
+	return description, nil
+}
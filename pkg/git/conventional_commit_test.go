@@ -0,0 +1,127 @@
+package git
+
+import (
+	"strings"
+	"testing"
+
+	api "github.com/alantheprice/ledit/pkg/agent_api"
+)
+
+func TestInferCommitScope(t *testing.T) {
+	tests := []struct {
+		name  string
+		paths []string
+		want  string
+	}{
+		{"single package", []string{"pkg/git/commit.go", "pkg/git/commit_test.go"}, "git"},
+		{"monorepo package", []string{"packages/web/src/app.ts"}, "web"},
+		{"top-level dir", []string{"webui/src/App.tsx", "webui/package.json"}, "webui"},
+		{"docs", []string{"docs/CLI_REFERENCE.md", "README.md"}, "docs"},
+		{"dependencies", []string{"go.mod", "go.sum"}, "deps"},
+		{"majority wins", []string{"pkg/git/a.go", "pkg/git/b.go", "pkg/agent/c.go"}, "git"},
+		{"spread out", []string{"pkg/git/a.go", "pkg/agent/b.go"}, ""},
+		{"root file", []string{"main.go"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var changes []CommitFileChange
+			for _, p := range tt.paths {
+				changes = append(changes, CommitFileChange{Status: "M", Path: p})
+			}
+			if got := InferCommitScope(changes); got != tt.want {
+				t.Errorf("InferCommitScope(%v) = %q, want %q", tt.paths, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInferCommitType(t *testing.T) {
+	tests := []struct {
+		paths []string
+		want  string
+	}{
+		{[]string{"pkg/git/commit_test.go", "webui/src/app.test.ts"}, "test"},
+		{[]string{"docs/guide.md", "README.md"}, "docs"},
+		{[]string{".github/workflows/ci.yml"}, "ci"},
+		{[]string{"go.mod", "Makefile"}, "build"},
+		{[]string{"pkg/git/commit.go", "pkg/git/commit_test.go"}, ""},
+	}
+	for _, tt := range tests {
+		var changes []CommitFileChange
+		for _, p := range tt.paths {
+			changes = append(changes, CommitFileChange{Status: "M", Path: p})
+		}
+		if got := InferCommitType(changes); got != tt.want {
+			t.Errorf("InferCommitType(%v) = %q, want %q", tt.paths, got, tt.want)
+		}
+	}
+}
+
+func TestConventionalCommitRoundTrip(t *testing.T) {
+	commit := ConventionalCommit{
+		Type:           "feat",
+		Scope:          "git",
+		Description:    "generate conventional commit messages",
+		Body:           "The commit flow can now follow the Conventional Commits format.",
+		BreakingChange: "commit_style defaults to default",
+	}
+	message := commit.String()
+	if !strings.HasPrefix(message, "feat(git)!: generate conventional commit messages\n\n") {
+		t.Fatalf("unexpected header:\n%s", message)
+	}
+	if !strings.HasSuffix(message, "\n\nBREAKING CHANGE: commit_style defaults to default") {
+		t.Fatalf("expected breaking change footer:\n%s", message)
+	}
+
+	parsed, err := ParseConventionalCommit(message)
+	if err != nil {
+		t.Fatalf("ParseConventionalCommit: %v", err)
+	}
+	if parsed != commit {
+		t.Fatalf("round trip mismatch:\n got %+v\nwant %+v", parsed, commit)
+	}
+
+	if _, err := ParseConventionalCommit("Adds a feature"); err == nil {
+		t.Fatal("expected non-conventional message to be rejected")
+	}
+	if c, err := ParseConventionalCommit("fix!: drop v1 API"); err != nil || c.BreakingChange != "drop v1 API" {
+		t.Fatalf("expected '!' to mark a breaking change, got %+v (%v)", c, err)
+	}
+}
+
+func TestRemovedExportedSymbols(t *testing.T) {
+	diff := `--- a/api.go
++++ b/api.go
+-func OldHandler(w http.ResponseWriter) {}
+-func (s *Server) Serve() error {
++func (s *Server) Serve() error {
+-type Options struct {
+-func helper() {}`
+	got := strings.Join(removedExportedSymbols(diff), ",")
+	if got != "OldHandler,Options" {
+		t.Fatalf("removedExportedSymbols = %q", got)
+	}
+}
+
+func TestGenerateCommitMessageConventionalStyle(t *testing.T) {
+	reply := "```json\n" + `{"type": "feature", "scope": "Commit Flow", "description": "Add scope inference.", "body": "Scopes come from the changed paths.", "breaking_change": ""}` + "\n```"
+	var choice api.Choice
+	choice.Message.Content = reply
+	mockClient := &mockAPIClient{descResponse: &api.ChatResponse{Choices: []api.Choice{choice}}}
+
+	result, err := GenerateCommitMessageFromStagedDiff(mockClient, CommitMessageOptions{
+		Diff:        "diff --git a/pkg/git/scope.go b/pkg/git/scope.go\n+func InferScope() {}",
+		Branch:      "feature/scope",
+		FileChanges: []CommitFileChange{{Status: "A", Path: "pkg/git/scope.go"}},
+		Style:       CommitStyleConventional,
+	})
+	if err != nil {
+		t.Fatalf("GenerateCommitMessageFromStagedDiff: %v", err)
+	}
+	// Unknown types fall back to chore, the inferred scope wins and the
+	// branch prefix of the default style is not added.
+	want := "chore(git): Add scope inference\n\nScopes come from the changed paths."
+	if result.Message != want {
+		t.Fatalf("unexpected message:\n%s\nwant:\n%s", result.Message, want)
+	}
+}
@@ -0,0 +1,148 @@
+package git
+
+import (
+	"bufio"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// uncommittedHash is the hash git blame reports for lines not yet committed.
+const uncommittedHash = "0000000000000000000000000000000000000000"
+
+// HistoryCommit is a commit that changed a file.
+type HistoryCommit struct {
+	Hash    string // first 12 characters of the hash
+	Author  string
+	Date    time.Time
+	Subject string
+}
+
+// BlameSegment is a run of consecutive lines last changed by one commit.
+type BlameSegment struct {
+	StartLine   int // 1-based, inclusive
+	EndLine     int // 1-based, inclusive
+	Commit      HistoryCommit
+	Uncommitted bool // the lines have changes not yet committed
+}
+
+// FileHistory is the recent history of a file, or of a line range of it.
+type FileHistory struct {
+	Path    string
+	Commits []HistoryCommit
+	Blame   []BlameSegment
+}
+
+// GetFileHistory returns the last limit commits that changed path in the
+// repository in dir, following renames, and who last changed each line from
+// startLine to endLine. A zero startLine blames from the first line and a
+// zero endLine to the last.
+func GetFileHistory(dir, path string, startLine, endLine, limit int) (*FileHistory, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+	history := &FileHistory{Path: path}
+
+	cmd := exec.Command("git", "log", "--follow", "-n", strconv.Itoa(limit), "--date=iso-strict",
+		"--format=%H%x1f%an%x1f%ad%x1f%s", "--", path)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the history of %s: %w", path, gitError(err))
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.Split(line, "\x1f")
+		if len(fields) != 4 || len(fields[0]) < 12 {
+			continue
+		}
+		date, _ := time.Parse(time.RFC3339, fields[2])
+		history.Commits = append(history.Commits, HistoryCommit{Hash: fields[0][:12], Author: fields[1], Date: date, Subject: fields[3]})
+	}
+
+	args := []string{"blame", "--porcelain"}
+	if startLine > 0 || endLine > 0 {
+		lineRange := strconv.Itoa(max(startLine, 1)) + ","
+		if endLine > 0 {
+			lineRange += strconv.Itoa(endLine)
+		}
+		args = append(args, "-L", lineRange)
+	}
+	cmd = exec.Command("git", append(args, "--", path)...)
+	cmd.Dir = dir
+	out, err = cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to blame %s: %w", path, gitError(err))
+	}
+	history.Blame = parseBlame(string(out))
+	return history, nil
+}
+
+// parseBlame turns git blame --porcelain output into segments of
+// consecutive lines from the same commit.
+func parseBlame(out string) []BlameSegment {
+	commits := map[string]*HistoryCommit{}
+	var segments []BlameSegment
+	var hash string
+	var line int
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		text := scanner.Text()
+		if strings.HasPrefix(text, "\t") {
+			// The line's content ends its entry
+			commit := commits[hash]
+			if n := len(segments); n > 0 && segments[n-1].EndLine == line-1 && segments[n-1].Commit.Hash == commit.Hash {
+				segments[n-1].EndLine = line
+			} else {
+				segments = append(segments, BlameSegment{StartLine: line, EndLine: line, Commit: *commit, Uncommitted: hash == uncommittedHash})
+			}
+			continue
+		}
+		key, value, _ := strings.Cut(text, " ")
+		if len(key) == 40 && isHex(key) {
+			hash = key
+			fields := strings.Fields(value)
+			if len(fields) >= 2 {
+				line, _ = strconv.Atoi(fields[1])
+			}
+			if commits[hash] == nil {
+				commits[hash] = &HistoryCommit{Hash: hash[:12]}
+			}
+			continue
+		}
+		commit := commits[hash]
+		if commit == nil {
+			continue
+		}
+		switch key {
+		case "author":
+			commit.Author = value
+		case "author-time":
+			if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+				commit.Date = time.Unix(seconds, 0)
+			}
+		case "summary":
+			commit.Subject = value
+		}
+	}
+	return segments
+}
+
+func isHex(s string) bool {
+	for _, r := range s {
+		if !strings.ContainsRune("0123456789abcdef", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// gitError adds git's message to a failed command's error.
+func gitError(err error) error {
+	if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return err
+}
@@ -0,0 +1,69 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestGetFileHistory(t *testing.T) {
+	dir := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=Ada", "GIT_AUTHOR_EMAIL=ada@example.com",
+			"GIT_COMMITTER_NAME=Ada", "GIT_COMMITTER_EMAIL=ada@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	run("init", "-q")
+	write("package main\n\nfunc main() {}\n")
+	run("add", "main.go")
+	run("commit", "-q", "-m", "Add main")
+	write("package main\n\n// main starts the server.\nfunc main() { serve() }\n")
+	run("commit", "-q", "-am", "Start the server")
+	write("package main\n\n// main starts the server.\nfunc main() { serve() }\n// TODO\n")
+
+	history, err := GetFileHistory(dir, "main.go", 0, 0, 10)
+	if err != nil {
+		t.Fatalf("GetFileHistory: %v", err)
+	}
+	if len(history.Commits) != 2 || history.Commits[0].Subject != "Start the server" || history.Commits[0].Author != "Ada" {
+		t.Fatalf("unexpected commits %+v", history.Commits)
+	}
+	want := []struct {
+		start, end  int
+		subject     string
+		uncommitted bool
+	}{
+		{1, 2, "Add main", false},
+		{3, 4, "Start the server", false},
+		{5, 5, "", true},
+	}
+	if len(history.Blame) != len(want) {
+		t.Fatalf("expected %d blame segments, got %+v", len(want), history.Blame)
+	}
+	for i, w := range want {
+		seg := history.Blame[i]
+		if seg.StartLine != w.start || seg.EndLine != w.end || seg.Uncommitted != w.uncommitted || (!w.uncommitted && seg.Commit.Subject != w.subject) {
+			t.Errorf("segment %d = %+v, want %+v", i, seg, w)
+		}
+	}
+
+	history, err = GetFileHistory(dir, "main.go", 3, 3, 1)
+	if err != nil {
+		t.Fatalf("GetFileHistory: %v", err)
+	}
+	if len(history.Commits) != 1 || len(history.Blame) != 1 || history.Blame[0].Commit.Subject != "Start the server" {
+		t.Fatalf("unexpected range history %+v", history)
+	}
+}
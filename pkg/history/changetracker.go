@@ -12,6 +12,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/alantheprice/ledit/pkg/console"
 	"github.com/alantheprice/ledit/pkg/filesystem"
 )
 
@@ -154,7 +155,7 @@ func PrintRevisionHistoryWithReader(inputReader *bufio.Reader) error {
 				fmt.Print("Already at the first revision.\n")
 			}
 		case "d":
-			fmt.Print("\n\033[1mAll File Diffs for this Revision:\033[0m\n")
+			fmt.Print("\n" + console.ColorizeBold("All File Diffs for this Revision:", "") + "\n")
 			for _, change := range revisionGroups[currentIndex].Changes {
 				fmt.Printf("\n--- Diff for %s ---\n", change.Filename)
 				diff := GetDiff(change.Filename, change.OriginalCode, change.NewCode)
@@ -175,14 +176,14 @@ func PrintRevisionHistoryWithReader(inputReader *bufio.Reader) error {
 			}
 		case "p": // Show original prompt
 			if revisionGroups[currentIndex].Instructions != "" {
-				fmt.Printf("\n\033[1mOriginal Prompt:\033[0m\n%s\n", revisionGroups[currentIndex].Instructions)
+				fmt.Printf("\n%s\n%s\n", console.ColorizeBold("Original Prompt:", ""), revisionGroups[currentIndex].Instructions)
 			} else {
 				fmt.Print("\nNo original prompt recorded.\n")
 			}
 		case "l": // Show LLM details
-			fmt.Printf("\n\033[1mEditing Model:\033[0m %s\n", revisionGroups[currentIndex].AgentModel)
+			fmt.Printf("\n%s %s\n", console.ColorizeBold("Editing Model:", ""), revisionGroups[currentIndex].AgentModel)
 			if revisionGroups[currentIndex].Response != "" {
-				fmt.Printf("\n\033[1mFull LLM Response:\033[0m\n%s\n", revisionGroups[currentIndex].Response)
+				fmt.Printf("\n%s\n%s\n", console.ColorizeBold("Full LLM Response:", ""), revisionGroups[currentIndex].Response)
 			} else {
 				fmt.Print("\nNo LLM response recorded.\n")
 			}
@@ -246,9 +247,9 @@ func PrintRevisionHistoryBuffer() (string, error) {
 }
 
 func displayRevision(group RevisionGroup) {
-	fmt.Printf("\r\n\033[1mEditing Model:\033[0m %s\r\n", group.AgentModel)
+	fmt.Printf("\r\n%s %s\r\n", console.ColorizeBold("Editing Model:", ""), group.AgentModel)
 	fmt.Print(strings.Repeat("=", 80) + "\r\n")
-	fmt.Printf("\033[36mRevision ID: %s\033[0m\r\n", group.RevisionID)
+	fmt.Printf("%s\r\n", console.Colorize("Revision ID: "+group.RevisionID, console.ActivePalette().Info))
 	fmt.Printf("Time: %s\r\n", group.Timestamp.Format(time.RFC1123))
 
 	// Display the editing model used for this revision
@@ -258,19 +259,20 @@ func displayRevision(group RevisionGroup) {
 		fmt.Print("Model: Not specified\r\n\r\n")
 	}
 
-	fmt.Printf("\033[1mFile Changes (%d):\033[0m\r\n", len(group.Changes))
+	fmt.Printf("%s\r\n", console.ColorizeBold(fmt.Sprintf("File Changes (%d):", len(group.Changes)), ""))
 	for _, change := range group.Changes {
 		fmt.Print(strings.Repeat("-", 40) + "\r\n")
-		fmt.Printf("\033[33m(%s)\033[0m", change.Filename)
-		fmt.Printf(" -- \033[1m%s\033[0m", change.FileRevisionHash)
+		fmt.Print(console.Colorize("("+change.Filename+")", console.ActivePalette().Warning))
+		fmt.Print(" -- " + console.ColorizeBold(change.FileRevisionHash, ""))
 		if change.Status != "active" {
-			fmt.Printf(" - %s%s%s\r\n", "\033[2m", change.Status, "\033[0m")
+			// Inactive changes are marked with a symbol, not just dimmed.
+			fmt.Printf(" - %s\r\n", console.Colorize(console.SymbolFailure+" "+change.Status, console.ColorDim))
 		} else {
-			fmt.Printf(" - \033[32m%s\033[0m\r\n", change.Status)
+			fmt.Printf(" - %s\r\n", console.StatusLabel(true, change.Status))
 		}
 
 		if change.Note.Valid {
-			fmt.Printf("    \033[1m%s\033[0m\r\n\r\n", change.Note.String)
+			fmt.Printf("    %s\r\n\r\n", console.ColorizeBold(change.Note.String, ""))
 		}
 
 		// Wrap the description at 72 characters and indent with 4 spaces
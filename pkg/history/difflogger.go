@@ -11,6 +11,7 @@ import (
 
 	"github.com/alantheprice/ledit/pkg/filesystem"
 	"github.com/alantheprice/ledit/pkg/pythonruntime"
+	"github.com/alantheprice/ledit/pkg/utils"
 	"github.com/sergi/go-diff/diffmatchpatch"
 )
 
@@ -219,6 +220,11 @@ func normalizeDiffText(text string) string {
 func GetDiff(filename, originalCode, newCode string) string {
 	checkPython()
 
+	// Compare lines, not line endings: a CR left at the end of a CRLF line
+	// would return the cursor and garble the colored output.
+	originalCode = utils.WithLineEnding(originalCode, "\n")
+	newCode = utils.WithLineEnding(newCode, "\n")
+
 	var result strings.Builder
 	var fullPrettyText string
 	var diffs []diffmatchpatch.Diff
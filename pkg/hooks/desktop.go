@@ -0,0 +1,35 @@
+package hooks
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"time"
+)
+
+// DesktopNotify shows a desktop notification, silently doing nothing when
+// no notifier is installed.
+func DesktopNotify(title, message string) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf("display notification %q with title %q", message, title)
+		cmd = exec.Command("osascript", "-e", script)
+	case "linux", "freebsd", "openbsd":
+		path, err := exec.LookPath("notify-send")
+		if err != nil {
+			return
+		}
+		cmd = exec.Command(path, title, message)
+	default:
+		return
+	}
+	if err := cmd.Start(); err != nil {
+		return
+	}
+	go func() {
+		timer := time.AfterFunc(5*time.Second, func() { _ = cmd.Process.Kill() })
+		_ = cmd.Wait()
+		timer.Stop()
+	}()
+}
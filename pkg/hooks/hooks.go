@@ -0,0 +1,339 @@
+// Package hooks reads a workspace's .ledit/hooks.yaml. Task hooks run when
+// an agent task starts, finishes or fails: desktop notifications, webhook
+// POSTs and Slack messages, whose titles, messages and webhook bodies are Go
+// templates over the task's Payload. Tool hooks are shell commands run
+// before or after matching tool calls.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the hooks file's path relative to the workspace root.
+var FileName = filepath.Join(".ledit", "hooks.yaml")
+
+// defaultTimeout bounds each webhook and Slack request.
+const defaultTimeout = 10 * time.Second
+
+// Event is a task lifecycle event hooks run on.
+type Event string
+
+const (
+	TaskStart  Event = "task_start"
+	TaskFinish Event = "task_finish"
+	TaskError  Event = "task_error"
+)
+
+// Events lists every event, in lifecycle order.
+var Events = []Event{TaskStart, TaskFinish, TaskError}
+
+// Hook types.
+const (
+	TypeDesktop = "desktop"
+	TypeWebhook = "webhook"
+	TypeSlack   = "slack"
+)
+
+// Payload describes the task an event is about. It is the data templates
+// are executed with and, as JSON, the default webhook body.
+type Payload struct {
+	Event     Event     `json:"event"`
+	Task      string    `json:"task"`
+	Workspace string    `json:"workspace"`
+	Status    string    `json:"status"` // "running" on task_start, then the result status
+	Error     string    `json:"error,omitempty"`
+	Time      time.Time `json:"time"`
+	// Result is the run's AgentResult, nil on task_start.
+	Result any `json:"result,omitempty"`
+}
+
+// Hook is one configured action.
+type Hook struct {
+	Name string  `yaml:"name"`
+	On   []Event `yaml:"on"`   // empty runs on every event
+	Type string  `yaml:"type"` // desktop, webhook or slack
+	// URL is the webhook or Slack incoming webhook URL. $VAR and ${VAR}
+	// are expanded from the environment, as are header values.
+	URL     string            `yaml:"url"`
+	Headers map[string]string `yaml:"headers"`
+	Title   string            `yaml:"title"`   // desktop notification title, and the first line of default Slack text
+	Message string            `yaml:"message"` // desktop notification or Slack text
+	Body    string            `yaml:"body"`    // webhook body; defaults to the payload JSON
+	// Timeout is a duration such as "5s" for webhook and Slack requests.
+	Timeout string `yaml:"timeout"`
+
+	title, message, body *template.Template
+	timeout              time.Duration
+}
+
+// Label names the hook in messages: its name, or its type.
+func (h *Hook) Label() string {
+	if h.Name != "" {
+		return h.Name
+	}
+	return h.Type + " hook"
+}
+
+// RunsOn reports whether the hook runs on event.
+func (h *Hook) RunsOn(event Event) bool {
+	if len(h.On) == 0 {
+		return true
+	}
+	for _, on := range h.On {
+		if on == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Config is a parsed hooks file. A nil Config runs nothing.
+type Config struct {
+	Path      string
+	Hooks     []*Hook
+	ToolHooks []*ToolHook
+}
+
+type hooksFile struct {
+	Hooks []*Hook     `yaml:"hooks"`
+	Tools []*ToolHook `yaml:"tools"`
+}
+
+// Load reads the hooks file for workspaceRoot. It returns (nil, nil) when
+// the workspace has no hooks file.
+func Load(workspaceRoot string) (*Config, error) {
+	path := filepath.Join(workspaceRoot, FileName)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	config, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	config.Path = path
+	return config, nil
+}
+
+// Parse parses hooks YAML and checks every task hook's type, events, URL
+// and templates, and every tool hook's match and command.
+func Parse(data []byte) (*Config, error) {
+	var file hooksFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+
+	config := &Config{}
+	for i, hook := range file.Hooks {
+		if hook == nil {
+			return nil, fmt.Errorf("hook %d: empty entry", i+1)
+		}
+		if err := hook.compile(); err != nil {
+			return nil, fmt.Errorf("hook %d (%s): %w", i+1, hook.Label(), err)
+		}
+		config.Hooks = append(config.Hooks, hook)
+	}
+	for i, hook := range file.Tools {
+		if hook == nil {
+			return nil, fmt.Errorf("tool hook %d: empty entry", i+1)
+		}
+		if err := hook.compile(); err != nil {
+			return nil, fmt.Errorf("tool hook %d (%s): %w", i+1, hook.Label(), err)
+		}
+		config.ToolHooks = append(config.ToolHooks, hook)
+	}
+	return config, nil
+}
+
+func (h *Hook) compile() error {
+	h.Type = strings.ToLower(strings.TrimSpace(h.Type))
+	switch h.Type {
+	case TypeDesktop:
+	case TypeWebhook, TypeSlack:
+		if strings.TrimSpace(h.URL) == "" {
+			return fmt.Errorf("%s hooks need a url", h.Type)
+		}
+	case "":
+		return fmt.Errorf("missing type (desktop, webhook or slack)")
+	default:
+		return fmt.Errorf("unknown type %q (desktop, webhook or slack)", h.Type)
+	}
+	for _, event := range h.On {
+		if !knownEvent(event) {
+			return fmt.Errorf("unknown event %q (task_start, task_finish or task_error)", event)
+		}
+	}
+
+	h.timeout = defaultTimeout
+	if h.Timeout != "" {
+		timeout, err := time.ParseDuration(h.Timeout)
+		if err != nil || timeout <= 0 {
+			return fmt.Errorf("invalid timeout %q", h.Timeout)
+		}
+		h.timeout = timeout
+	}
+
+	var err error
+	if h.title, err = parseTemplate("title", h.Title, defaultTitle); err != nil {
+		return err
+	}
+	if h.message, err = parseTemplate("message", h.Message, defaultMessage); err != nil {
+		return err
+	}
+	if h.Body != "" {
+		if h.body, err = parseTemplate("body", h.Body, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func knownEvent(event Event) bool {
+	for _, known := range Events {
+		if event == known {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	defaultTitle   = `ledit: task {{if eq .Event "task_start"}}started{{else if eq .Event "task_error"}}failed{{else}}{{.Status}}{{end}}`
+	defaultMessage = `{{.Task}}{{if .Error}} ({{.Error}}){{end}}`
+)
+
+func parseTemplate(name, text, fallback string) (*template.Template, error) {
+	if text == "" {
+		text = fallback
+	}
+	tmpl, err := template.New(name).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s template: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+// Run runs the hooks for the payload's event, one after another, and
+// returns the errors of those that failed. A nil Config runs nothing.
+func (c *Config) Run(ctx context.Context, payload Payload) []error {
+	if c == nil {
+		return nil
+	}
+	var errs []error
+	for _, hook := range c.Hooks {
+		if !hook.RunsOn(payload.Event) {
+			continue
+		}
+		if err := hook.Run(ctx, payload); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", hook.Label(), err))
+		}
+	}
+	return errs
+}
+
+// Run performs the hook's action for payload.
+func (h *Hook) Run(ctx context.Context, payload Payload) error {
+	switch h.Type {
+	case TypeDesktop:
+		title, err := execute(h.title, payload)
+		if err != nil {
+			return err
+		}
+		message, err := execute(h.message, payload)
+		if err != nil {
+			return err
+		}
+		DesktopNotify(title, truncate(message, 200))
+		return nil
+	case TypeSlack:
+		message, err := execute(h.message, payload)
+		if err != nil {
+			return err
+		}
+		if h.Message == "" {
+			title, err := execute(h.title, payload)
+			if err != nil {
+				return err
+			}
+			message = "*" + title + "*\n" + message
+		}
+		body, err := json.Marshal(map[string]string{"text": message})
+		if err != nil {
+			return err
+		}
+		return h.post(ctx, body)
+	case TypeWebhook:
+		var body []byte
+		if h.body != nil {
+			text, err := execute(h.body, payload)
+			if err != nil {
+				return err
+			}
+			body = []byte(text)
+		} else {
+			var err error
+			if body, err = json.Marshal(payload); err != nil {
+				return err
+			}
+		}
+		return h.post(ctx, body)
+	}
+	return fmt.Errorf("unknown type %q", h.Type)
+}
+
+func execute(tmpl *template.Template, payload Payload) (string, error) {
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, payload); err != nil {
+		return "", fmt.Errorf("%s template: %w", tmpl.Name(), err)
+	}
+	return sb.String(), nil
+}
+
+// post sends body to the hook's URL as JSON.
+func (h *Hook) post(ctx context.Context, body []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, os.ExpandEnv(h.URL), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ledit-hooks")
+	for name, value := range h.Headers {
+		req.Header.Set(name, os.ExpandEnv(value))
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("POST returned %s", resp.Status)
+	}
+	return nil
+}
+
+func truncate(s string, max int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	runes := []rune(s)
+	if len(runes) > max {
+		return string(runes[:max]) + "..."
+	}
+	return s
+}
@@ -0,0 +1,179 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseRejectsInvalidHooks(t *testing.T) {
+	for _, text := range []string{
+		"hooks:\n  - type: pager\n",
+		"hooks:\n  - on: [task_finish]\n",
+		"hooks:\n  - type: webhook\n",
+		"hooks:\n  - type: desktop\n    on: [task_done]\n",
+		"hooks:\n  - type: desktop\n    title: \"{{.Task\"\n",
+		"hooks:\n  - type: slack\n    url: https://example.com\n    timeout: soon\n",
+	} {
+		if _, err := Parse([]byte(text)); err == nil {
+			t.Errorf("expected %q to be rejected", text)
+		}
+	}
+}
+
+func TestLoadWithoutFile(t *testing.T) {
+	config, err := Load(t.TempDir())
+	if config != nil || err != nil {
+		t.Fatalf("expected no hooks, got %+v, %v", config, err)
+	}
+	if errs := config.Run(context.Background(), Payload{Event: TaskStart}); errs != nil {
+		t.Fatalf("expected a nil config to run nothing, got %v", errs)
+	}
+}
+
+func TestRunPostsWebhookAndSlackMessages(t *testing.T) {
+	type request struct {
+		path, auth string
+		body       []byte
+	}
+	requests := make(chan request, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- request{path: r.URL.Path, auth: r.Header.Get("Authorization"), body: body}
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+	t.Setenv("HOOK_SERVER", server.URL)
+	t.Setenv("HOOK_TOKEN", "secret")
+
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, ".ledit"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	yaml := `
+hooks:
+  - name: ci
+    type: webhook
+    on: [task_finish, task_error]
+    url: ${HOOK_SERVER}/ci
+    headers:
+      Authorization: Bearer $HOOK_TOKEN
+  - type: slack
+    on: [task_error]
+    url: ${HOOK_SERVER}/slack
+    message: "{{.Task}} failed after {{.Result.Iterations}} iterations: {{.Error}}"
+  - name: broken
+    type: webhook
+    on: [task_error]
+    url: ${HOOK_SERVER}/broken
+    body: '{"task": {{printf "%q" .Task}}}'
+`
+	if err := os.WriteFile(filepath.Join(root, FileName), []byte(yaml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	config, err := Load(root)
+	if err != nil || len(config.Hooks) != 3 {
+		t.Fatalf("expected three hooks, got %+v, %v", config, err)
+	}
+
+	if errs := config.Run(context.Background(), Payload{Event: TaskStart, Task: "fix it"}); len(errs) != 0 || len(requests) != 0 {
+		t.Fatalf("expected no hooks on task_start, got %v and %d requests", errs, len(requests))
+	}
+
+	type result struct {
+		Status     string `json:"status"`
+		Iterations int    `json:"iterations"`
+	}
+	payload := Payload{Event: TaskError, Task: "fix it", Status: "failure", Error: "boom", Result: result{Status: "failure", Iterations: 4}}
+	errs := config.Run(context.Background(), payload)
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "broken: POST returned 500") {
+		t.Fatalf("expected the broken hook to fail, got %v", errs)
+	}
+
+	ci := <-requests
+	var sent map[string]any
+	if err := json.Unmarshal(ci.body, &sent); err != nil {
+		t.Fatalf("webhook body is not JSON: %s", ci.body)
+	}
+	if ci.path != "/ci" || ci.auth != "Bearer secret" || sent["event"] != "task_error" || sent["result"].(map[string]any)["iterations"] != float64(4) {
+		t.Fatalf("unexpected webhook request %s %q %s", ci.path, ci.auth, ci.body)
+	}
+	slack := <-requests
+	if slack.path != "/slack" || string(slack.body) != `{"text":"fix it failed after 4 iterations: boom"}` {
+		t.Fatalf("unexpected Slack request %s %s", slack.path, slack.body)
+	}
+	if broken := <-requests; string(broken.body) != `{"task": "fix it"}` {
+		t.Fatalf("expected the body template, got %s", broken.body)
+	}
+}
+
+func TestDefaultTemplates(t *testing.T) {
+	config, err := Parse([]byte("hooks:\n  - type: desktop\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hook := config.Hooks[0]
+	for _, tt := range []struct {
+		payload        Payload
+		title, message string
+	}{
+		{Payload{Event: TaskStart, Task: "add tests", Status: "running"}, "ledit: task started", "add tests"},
+		{Payload{Event: TaskFinish, Task: "add tests", Status: "partial"}, "ledit: task partial", "add tests"},
+		{Payload{Event: TaskError, Task: "add tests", Status: "failure", Error: "rate limited"}, "ledit: task failed", "add tests (rate limited)"},
+	} {
+		title, _ := execute(hook.title, tt.payload)
+		message, _ := execute(hook.message, tt.payload)
+		if title != tt.title || message != tt.message {
+			t.Errorf("%s: got %q / %q", tt.payload.Event, title, message)
+		}
+	}
+}
+
+func TestToolHookCalls(t *testing.T) {
+	config, err := Parse([]byte(`
+tools:
+  - name: gofmt
+    when: after
+    match: "write: **/*.go"
+    run: gofmt -l "$LEDIT_TOOL_TARGET"
+    feedback: true
+  - when: before
+    match: "write: generated/**"
+    run: exit 1
+  - when: after
+    run: echo every call
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := map[string]interface{}{"path": "generated/api.go"}
+	after := config.ToolHookCalls(After, "write_file", args, "/src")
+	if len(after) != 2 || after[0].Hook.Label() != "gofmt" || after[0].Target != "generated/api.go" || after[1].Hook.Label() != "echo every call" {
+		t.Fatalf("unexpected after hooks %+v", after)
+	}
+	if before := config.ToolHookCalls(Before, "write_file", args, "/src"); len(before) != 1 {
+		t.Fatalf("expected the generated/ guard, got %+v", before)
+	}
+	if before := config.ToolHookCalls(Before, "read_file", args, "/src"); len(before) != 0 {
+		t.Fatalf("expected reads not to be guarded, got %+v", before)
+	}
+
+	for _, text := range []string{
+		"tools:\n  - run: echo hi\n",
+		"tools:\n  - when: during\n    run: echo hi\n",
+		"tools:\n  - when: after\n",
+		"tools:\n  - when: after\n    match: \"write: outside\"\n    run: echo hi\n",
+	} {
+		if _, err := Parse([]byte(text)); err == nil {
+			t.Errorf("expected %q to be rejected", text)
+		}
+	}
+}
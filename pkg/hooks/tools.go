@@ -0,0 +1,106 @@
+package hooks
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/alantheprice/ledit/pkg/toolpolicy"
+)
+
+// defaultToolHookTimeout bounds each tool hook command.
+const defaultToolHookTimeout = 30 * time.Second
+
+// When a tool hook runs.
+const (
+	Before = "before"
+	After  = "after"
+)
+
+// ToolHook is a shell command run before or after the agent's tool calls,
+// like a git hook for agent tools. A before hook that exits non-zero blocks
+// the call. An after hook runs once the call has succeeded, and with
+// Feedback its output is added to the tool result the model sees.
+type ToolHook struct {
+	Name string `yaml:"name"`
+	When string `yaml:"when"` // before or after
+	// Match selects calls with a tool policy target and optional pattern,
+	// such as "write_file", "write: **/*.go" or "shell: git push*". Empty
+	// matches every call.
+	Match    string `yaml:"match"`
+	Run      string `yaml:"run"`
+	Feedback bool   `yaml:"feedback"`
+	// Timeout is a duration such as "10s" (default: 30s).
+	Timeout string `yaml:"timeout"`
+
+	rule    toolpolicy.Rule
+	timeout time.Duration
+}
+
+// ToolHookCall is a tool hook matched to a call, with the path, command or
+// URL it matched. A hook matching several files of one call runs once for
+// each.
+type ToolHookCall struct {
+	Hook   *ToolHook
+	Target string
+}
+
+// Label names the hook in messages: its name, or its command.
+func (h *ToolHook) Label() string {
+	if h.Name != "" {
+		return h.Name
+	}
+	return h.Run
+}
+
+// TimeoutDuration is how long the hook's command may run.
+func (h *ToolHook) TimeoutDuration() time.Duration {
+	return h.timeout
+}
+
+func (h *ToolHook) compile() error {
+	h.When = strings.ToLower(strings.TrimSpace(h.When))
+	if h.When != Before && h.When != After {
+		return fmt.Errorf("when must be before or after, got %q", h.When)
+	}
+	if strings.TrimSpace(h.Run) == "" {
+		return fmt.Errorf("missing run command")
+	}
+	match := strings.TrimSpace(h.Match)
+	if match == "" {
+		match = "*"
+	}
+	rule, err := toolpolicy.ParseRule("allow " + match)
+	if err != nil {
+		return fmt.Errorf("invalid match %q: %w", h.Match, err)
+	}
+	h.rule = rule
+
+	h.timeout = defaultToolHookTimeout
+	if h.Timeout != "" {
+		timeout, err := time.ParseDuration(h.Timeout)
+		if err != nil || timeout <= 0 {
+			return fmt.Errorf("invalid timeout %q", h.Timeout)
+		}
+		h.timeout = timeout
+	}
+	return nil
+}
+
+// ToolHookCalls returns the hooks to run when a call to toolName with args
+// happens, in file order. A nil Config has none.
+func (c *Config) ToolHookCalls(when, toolName string, args map[string]interface{}, workspaceRoot string) []ToolHookCall {
+	if c == nil {
+		return nil
+	}
+	var calls []ToolHookCall
+	for _, hook := range c.ToolHooks {
+		if hook.When != when {
+			continue
+		}
+		for _, target := range hook.rule.MatchingSubjects(toolName, args, workspaceRoot) {
+			calls = append(calls, ToolHookCall{Hook: hook, Target: target})
+		}
+	}
+	return calls
+}
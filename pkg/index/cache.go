@@ -0,0 +1,126 @@
+package index
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// cacheVersion changes whenever extractSymbols would find different symbols
+// in the same content, so older caches are discarded.
+const cacheVersion = 2
+
+// CacheStats describes how a BuildSymbols run used the per-file cache.
+type CacheStats struct {
+	Files     int           // source files in the workspace
+	Unchanged int           // reused because size and modification time matched
+	Rehashed  int           // read again, but the content hash matched
+	Analyzed  int           // new or changed files whose symbols were extracted
+	Removed   int           // cached files no longer in the workspace
+	Duration  time.Duration // time the whole build took
+}
+
+func (s CacheStats) String() string {
+	return fmt.Sprintf("%d files: %d unchanged, %d rehashed, %d analyzed, %d removed in %s",
+		s.Files, s.Unchanged, s.Rehashed, s.Analyzed, s.Removed, s.Duration.Round(time.Millisecond))
+}
+
+// HitRate is the share of files whose symbols came from the cache.
+func (s CacheStats) HitRate() float64 {
+	if s.Files == 0 {
+		return 0
+	}
+	return float64(s.Unchanged+s.Rehashed) / float64(s.Files)
+}
+
+// symbolCache is .ledit/cache/symbols.json: the symbols of every source
+// file, keyed by path and content hash.
+type symbolCache struct {
+	Version int                   `json:"version"`
+	Files   map[string]cacheEntry `json:"files"`
+}
+
+type cacheEntry struct {
+	Hash    string   `json:"hash"`     // SHA-256 of the content
+	Size    int64    `json:"size"`     // size when last hashed
+	ModTime int64    `json:"mod_time"` // modification time (Unix nanoseconds) when last hashed
+	Symbols []Symbol `json:"symbols,omitempty"`
+}
+
+// cacheMu serializes builds in this process, e.g. the workspace watcher's
+// refresh and a file discovery run.
+var cacheMu sync.Mutex
+
+// CachePath returns the symbol cache of the workspace at root.
+func CachePath(root string) string {
+	return filepath.Join(root, ".ledit", "cache", "symbols.json")
+}
+
+// loadCache reads the symbol cache, returning an empty one when it is
+// missing, unreadable or from another version.
+func loadCache(root string) *symbolCache {
+	cache := &symbolCache{Version: cacheVersion, Files: map[string]cacheEntry{}}
+	data, err := os.ReadFile(CachePath(root))
+	if err != nil {
+		return cache
+	}
+	var stored symbolCache
+	if err := json.Unmarshal(data, &stored); err != nil || stored.Version != cacheVersion || stored.Files == nil {
+		return cache
+	}
+	return &stored
+}
+
+// saveCache writes the symbol cache. It writes then renames, so another
+// process never reads a partial file.
+func saveCache(root string, cache *symbolCache) error {
+	path := CachePath(root)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create symbol cache directory: %w", err)
+	}
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return fmt.Errorf("failed to encode symbol cache: %w", err)
+	}
+	tmp := path + fmt.Sprintf(".%d.tmp", os.Getpid())
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write symbol cache: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to write symbol cache: %w", err)
+	}
+	return nil
+}
+
+// symbolsFor returns the symbols of the file at path, from the cache when
+// the file is unchanged. It records the outcome in stats and the entry to
+// keep in next.
+func symbolsFor(path, rel, ext string, info os.FileInfo, cache, next *symbolCache, stats *CacheStats) ([]Symbol, bool) {
+	cached, ok := cache.Files[rel]
+	if ok && cached.Size == info.Size() && cached.ModTime == info.ModTime().UnixNano() {
+		next.Files[rel] = cached
+		stats.Unchanged++
+		return cached.Symbols, true
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	sum := sha256.Sum256(b)
+	entry := cacheEntry{Hash: hex.EncodeToString(sum[:]), Size: info.Size(), ModTime: info.ModTime().UnixNano()}
+	if ok && cached.Hash == entry.Hash {
+		entry.Symbols = cached.Symbols
+		stats.Rehashed++
+	} else {
+		entry.Symbols = extractSymbols(ext, string(b))
+		stats.Analyzed++
+	}
+	next.Files[rel] = entry
+	return entry.Symbols, true
+}
@@ -0,0 +1,61 @@
+package index
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBuildSymbolsReusesUnchangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, src string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a.go", "package m\n\nfunc Alpha() {}\n")
+	write("b.go", "package m\n\nfunc Beta() {}\n")
+	write("c.py", "def gamma():\n    pass\n")
+
+	idx, err := BuildSymbols(dir)
+	if err != nil {
+		t.Fatalf("BuildSymbols: %v", err)
+	}
+	if idx.Stats.Analyzed != 3 || idx.Stats.HitRate() != 0 {
+		t.Fatalf("expected every file analyzed on the first build, got %s", idx.Stats)
+	}
+	if _, err := os.Stat(CachePath(dir)); err != nil {
+		t.Fatalf("symbol cache missing: %v", err)
+	}
+
+	// Change one file, touch another without changing it, remove the third
+	write("a.go", "package m\n\nfunc AlphaRenamed() {}\n")
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(filepath.Join(dir, "b.go"), later, later); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(dir, "c.py")); err != nil {
+		t.Fatal(err)
+	}
+
+	idx, err = BuildSymbols(dir)
+	if err != nil {
+		t.Fatalf("BuildSymbols: %v", err)
+	}
+	if idx.Stats.Files != 2 || idx.Stats.Analyzed != 1 || idx.Stats.Rehashed != 1 || idx.Stats.Removed != 1 {
+		t.Fatalf("unexpected stats %s", idx.Stats)
+	}
+	if hits := SearchSymbols(idx, []string{"alpharenamed"}); len(hits) != 1 || hits[0] != "a.go" {
+		t.Fatalf("expected the changed file's new symbols, got %v", hits)
+	}
+
+	idx, err = BuildSymbols(dir)
+	if err != nil {
+		t.Fatalf("BuildSymbols: %v", err)
+	}
+	if idx.Stats.Unchanged != 2 || idx.Stats.HitRate() != 1 || len(idx.Files) != 2 {
+		t.Fatalf("expected everything from the cache, got %s", idx.Stats)
+	}
+}
@@ -0,0 +1,84 @@
+package index
+
+import "regexp"
+
+// symbolPattern finds one kind of symbol. The name is the pattern's first
+// non-empty group; when list is set, that group holds several names (an
+// import block or list) and each match of list within it is one symbol.
+type symbolPattern struct {
+	kind string
+	re   *regexp.Regexp
+	list *regexp.Regexp
+}
+
+func pattern(kind, expr string) symbolPattern {
+	return symbolPattern{kind: kind, re: regexp.MustCompile(expr)}
+}
+
+func listPattern(kind, expr, item string) symbolPattern {
+	return symbolPattern{kind: kind, re: regexp.MustCompile(expr), list: regexp.MustCompile(item)}
+}
+
+// Patterns shared by the JavaScript and TypeScript extensions.
+var jsPatterns = []symbolPattern{
+	pattern("import", `(?m)^\s*import\s+(?:[^'";]*?\s+from\s+)?['"]([^'"]+)['"]`),
+	pattern("import", `\brequire\(\s*['"]([^'"]+)['"]\s*\)`),
+	pattern("func", `\bfunction\*?\s+([A-Za-z_$][\w$]*)\s*[<(]`),
+	pattern("func", `(?m)^\s*(?:export\s+)?(?:const|let|var)\s+([A-Za-z_$][\w$]*)\s*(?::[^=]+)?=\s*(?:async\s+)?(?:function\b|\([^)]*\)\s*(?::[^=]+)?=>|[A-Za-z_$][\w$]*\s*=>)`),
+	pattern("class", `(?m)^\s*(?:export\s+)?(?:default\s+)?(?:abstract\s+)?class\s+([A-Za-z_$][\w$]*)`),
+	pattern("type", `(?m)^\s*(?:export\s+)?(?:declare\s+)?(?:interface|type|enum)\s+([A-Za-z_$][\w$]*)`),
+}
+
+// languagePatterns lists the symbol patterns for each indexed source
+// extension, so every language yields its functions, classes and types,
+// and imports.
+var languagePatterns = map[string][]symbolPattern{
+	".go": {
+		pattern("import", `(?m)^import\s+(?:[A-Za-z_.]\w*\s+)?"([^"]+)"`),
+		listPattern("import", `(?ms)^import\s*\((.*?)^\)`, `(?m)^\s*(?:[A-Za-z_.]\w*\s+)?"([^"]+)"`),
+		pattern("func", `(?m)^func\s+([A-Za-z_]\w*)\s*[\[(]`),
+		pattern("method", `(?m)^func\s+\([^)]*\)\s*([A-Za-z_]\w*)\s*[\[(]`),
+		pattern("type", `(?m)^\s*type\s+([A-Za-z_]\w*)\b`),
+	},
+	".py": {
+		listPattern("import", `(?m)^\s*import\s+([\w.]+(?:\s+as\s+\w+)?(?:\s*,\s*[\w.]+(?:\s+as\s+\w+)?)*)`, `([\w.]+)(?:\s+as\s+\w+)?`),
+		pattern("import", `(?m)^\s*from\s+([\w.]+)\s+import\b`),
+		pattern("func", `(?m)^\s*(?:async\s+)?def\s+([A-Za-z_]\w*)\s*\(`),
+		pattern("class", `(?m)^\s*class\s+([A-Za-z_]\w*)\b`),
+	},
+	".js":  jsPatterns,
+	".jsx": jsPatterns,
+	".mjs": jsPatterns,
+	".cjs": jsPatterns,
+	".ts":  jsPatterns,
+	".tsx": jsPatterns,
+	".rb": {
+		pattern("import", `(?m)^\s*require(?:_relative)?\s*\(?\s*['"]([^'"]+)['"]`),
+		pattern("func", `(?m)^\s*def\s+(?:self\.)?([A-Za-z_]\w*[!?=]?)`),
+		pattern("class", `(?m)^\s*(?:class|module)\s+([A-Za-z_][\w:]*)`),
+	},
+	".php": {
+		pattern("import", `(?m)^\s*use\s+([A-Za-z_\\][\w\\]*)`),
+		pattern("func", `(?m)^\s*(?:(?:public|private|protected|static|abstract|final)\s+)*function\s+&?([A-Za-z_]\w*)\s*\(`),
+		pattern("class", `(?m)^\s*(?:(?:abstract|final|readonly)\s+)*class\s+([A-Za-z_]\w*)`),
+		pattern("type", `(?m)^\s*(?:interface|trait|enum)\s+([A-Za-z_]\w*)`),
+	},
+	".rs": {
+		pattern("import", `(?m)^\s*(?:pub(?:\([^)]*\))?\s+)?use\s+([^;]+);`),
+		pattern("func", `(?m)^\s*(?:pub(?:\([^)]*\))?\s+)?(?:(?:const|async|unsafe|extern\s+"[^"]*")\s+)*fn\s+([A-Za-z_]\w*)`),
+		pattern("type", `(?m)^\s*(?:pub(?:\([^)]*\))?\s+)?(?:struct|enum|trait|type|union)\s+([A-Za-z_]\w*)`),
+	},
+	".java": {
+		pattern("import", `(?m)^\s*import\s+(?:static\s+)?([\w.]+(?:\.\*)?)\s*;`),
+		pattern("class", `(?m)^\s*(?:(?:public|protected|private|abstract|final|static|sealed|non-sealed)\s+)*class\s+([A-Za-z_]\w*)`),
+		pattern("type", `(?m)^\s*(?:(?:public|protected|private|abstract|static|sealed|non-sealed)\s+)*(?:interface|enum|record)\s+([A-Za-z_]\w*)`),
+		pattern("method", `(?m)^\s*(?:(?:public|protected|private|static|final|abstract|synchronized|native|default)\s+)*(?:<[^>]+>\s+)?[\w.<>\[\]?, ]*[\w>\]]\s+([A-Za-z_]\w*)\s*\([^;{)]*\)\s*(?:throws\s+[\w., ]+)?\{`),
+	},
+}
+
+// notSymbols are keywords a loose declaration pattern can take for a name,
+// such as the "if" in "} else if (x) {".
+var notSymbols = map[string]bool{
+	"if": true, "for": true, "while": true, "switch": true, "catch": true,
+	"return": true, "new": true, "else": true, "synchronized": true,
+}
@@ -5,13 +5,28 @@ import (
 	"log"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
+	"sync/atomic"
+	"time"
 )
 
+// buildDone and buildTotal count the source files of the symbol index build
+// in progress; buildTotal is zero when none is running.
+var buildDone, buildTotal atomic.Int64
+
+// BuildProgress reports how many of the workspace's source files the running
+// BuildSymbols has processed. ok is false when no build is running.
+func BuildProgress() (done, total int, ok bool) {
+	t := buildTotal.Load()
+	if t == 0 {
+		return 0, 0, false
+	}
+	return int(buildDone.Load()), int(t), true
+}
+
 type Symbol struct {
 	Name string `json:"name"`
-	Kind string `json:"kind"` // func, class, type, method
+	Kind string `json:"kind"` // func, class, type, method, import
 }
 
 type FileSymbols struct {
@@ -21,11 +36,20 @@ type FileSymbols struct {
 
 type SymbolIndex struct {
 	Files []FileSymbols `json:"files"`
+	Stats CacheStats    `json:"-"` // how the build used the per-file cache
 }
 
-// BuildSymbols scans the workspace root for source files and extracts simple symbols via regex
+// BuildSymbols scans the workspace root for source files and extracts symbols
+// (functions, classes and types, and imports) via per-language regexes.
+// Symbols of files unchanged since the last build come from the per-file
+// cache in .ledit/cache; idx.Stats reports how much was reused.
 func BuildSymbols(root string) (*SymbolIndex, error) {
-	var files []string
+	start := time.Now()
+	type sourceFile struct {
+		path string
+		info os.FileInfo
+	}
+	var files []sourceFile
 	if err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return nil
@@ -33,33 +57,48 @@ func BuildSymbols(root string) (*SymbolIndex, error) {
 		if info == nil || info.IsDir() {
 			return nil
 		}
-		ext := strings.ToLower(filepath.Ext(path))
-		switch ext {
-		case ".go", ".py", ".js", ".ts", ".rb", ".php", ".rs", ".java":
-			files = append(files, path)
+		if _, ok := languagePatterns[strings.ToLower(filepath.Ext(path))]; ok {
+			files = append(files, sourceFile{path: path, info: info})
 		}
 		return nil
 	}); err != nil {
 		log.Printf("[debug] filepath.Walk failed in BuildSymbols: %v", err)
 	}
 
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	cache := loadCache(root)
+	next := &symbolCache{Version: cacheVersion, Files: make(map[string]cacheEntry, len(files))}
+
 	idx := &SymbolIndex{}
+	idx.Stats.Files = len(files)
+	buildDone.Store(0)
+	buildTotal.Store(int64(len(files)))
+	defer buildTotal.Store(0)
 	for _, f := range files {
-		b, err := os.ReadFile(f)
-		if err != nil {
-			continue
+		rel := f.path
+		if r, err := filepath.Rel(root, f.path); err == nil {
+			rel = r
 		}
-		content := string(b)
-		ext := strings.ToLower(filepath.Ext(f))
-		symbols := extractSymbols(ext, content)
-		if len(symbols) > 0 {
-			rel := f
-			if r, err := filepath.Rel(root, f); err == nil {
-				rel = r
-			}
-			idx.Files = append(idx.Files, FileSymbols{File: filepath.ToSlash(rel), Symbols: symbols})
+		rel = filepath.ToSlash(rel)
+		symbols, ok := symbolsFor(f.path, rel, strings.ToLower(filepath.Ext(f.path)), f.info, cache, next, &idx.Stats)
+		buildDone.Add(1)
+		if ok && len(symbols) > 0 {
+			idx.Files = append(idx.Files, FileSymbols{File: rel, Symbols: symbols})
 		}
 	}
+	for rel := range cache.Files {
+		if _, ok := next.Files[rel]; !ok {
+			idx.Stats.Removed++
+		}
+	}
+	if idx.Stats.Unchanged < len(next.Files) || idx.Stats.Removed > 0 {
+		if err := saveCache(root, next); err != nil {
+			log.Printf("[debug] %v", err)
+		}
+	}
+	idx.Stats.Duration = time.Since(start)
+
 	// persist to .ledit/symbols.json
 	if err := os.MkdirAll(filepath.Join(root, ".ledit"), 0755); err != nil {
 		log.Printf("[debug] failed to create .ledit directory: %v", err)
@@ -76,82 +115,43 @@ func BuildSymbols(root string) (*SymbolIndex, error) {
 	return idx, nil
 }
 
+// extractSymbols returns the symbols the patterns for ext find in content,
+// ordered by kind as the patterns are listed and then by position.
 func extractSymbols(ext, content string) []Symbol {
 	var out []Symbol
 	add := func(kind, name string) {
-		if name != "" {
+		name = strings.TrimSpace(name)
+		if name != "" && !notSymbols[name] {
 			out = append(out, Symbol{Name: name, Kind: kind})
 		}
 	}
-	switch ext {
-	case ".go":
-		reFunc := regexp.MustCompile(`(?m)^\s*func\s+(?:\([^)]*\)\s*)?([A-Za-z_][A-Za-z0-9_]*)\s*\(`)
-		for _, m := range reFunc.FindAllStringSubmatch(content, -1) {
-			add("func", m[1])
-		}
-		reType := regexp.MustCompile(`(?m)^\s*type\s+([A-Za-z_][A-Za-z0-9_]*)\b`)
-		for _, m := range reType.FindAllStringSubmatch(content, -1) {
-			add("type", m[1])
-		}
-	case ".py":
-		reDef := regexp.MustCompile(`(?m)^\s*def\s+([A-Za-z_][A-Za-z0-9_]*)\s*\(`)
-		for _, m := range reDef.FindAllStringSubmatch(content, -1) {
-			add("func", m[1])
-		}
-		reCls := regexp.MustCompile(`(?m)^\s*class\s+([A-Za-z_][A-Za-z0-9_]*)\b`)
-		for _, m := range reCls.FindAllStringSubmatch(content, -1) {
-			add("class", m[1])
-		}
-	case ".js", ".ts":
-		reFunc := regexp.MustCompile(`(?m)\bfunction\s+([A-Za-z_][A-Za-z0-9_]*)\s*\(|\b([A-Za-z_][A-Za-z0-9_]*)\s*=\s*\(`)
-		for _, m := range reFunc.FindAllStringSubmatch(content, -1) {
-			name := m[1]
-			if name == "" {
-				name = m[2]
+	for _, p := range languagePatterns[ext] {
+		for _, m := range p.re.FindAllStringSubmatch(content, -1) {
+			name := firstGroup(m)
+			if p.list == nil {
+				add(p.kind, name)
+				continue
+			}
+			for _, item := range p.list.FindAllStringSubmatch(name, -1) {
+				add(p.kind, firstGroup(item))
 			}
-			add("func", name)
-		}
-		reCls := regexp.MustCompile(`(?m)\bclass\s+([A-Za-z_][A-Za-z0-9_]*)\b`)
-		for _, m := range reCls.FindAllStringSubmatch(content, -1) {
-			add("class", m[1])
-		}
-	case ".rb":
-		reDef := regexp.MustCompile(`(?m)^\s*def\s+([A-Za-z_][A-Za-z0-9_!?]*)`)
-		for _, m := range reDef.FindAllStringSubmatch(content, -1) {
-			add("func", m[1])
-		}
-		reCls := regexp.MustCompile(`(?m)^\s*class\s+([A-Za-z_][A-Za-z0-9_:]*)`)
-		for _, m := range reCls.FindAllStringSubmatch(content, -1) {
-			add("class", m[1])
-		}
-	case ".php":
-		reDef := regexp.MustCompile(`(?m)^\s*function\s+([A-Za-z_][A-Za-z0-9_]*)\s*\(`)
-		for _, m := range reDef.FindAllStringSubmatch(content, -1) {
-			add("func", m[1])
-		}
-		reCls := regexp.MustCompile(`(?m)^\s*class\s+([A-Za-z_][A-Za-z0-9_]*)`)
-		for _, m := range reCls.FindAllStringSubmatch(content, -1) {
-			add("class", m[1])
-		}
-	case ".rs":
-		reFn := regexp.MustCompile(`(?m)^\s*fn\s+([A-Za-z_][A-Za-z0-9_]*)\b`)
-		for _, m := range reFn.FindAllStringSubmatch(content, -1) {
-			add("func", m[1])
-		}
-	case ".java":
-		reCls := regexp.MustCompile(`(?m)\bclass\s+([A-Za-z_][A-Za-z0-9_]*)\b`)
-		for _, m := range reCls.FindAllStringSubmatch(content, -1) {
-			add("class", m[1])
-		}
-		reMeth := regexp.MustCompile(`(?m)\b([A-Za-z_][A-Za-z0-9_]*)\s+([A-Za-z_][A-Za-z0-9_]*)\s*\([^;]*\)\s*\{`)
-		for _, m := range reMeth.FindAllStringSubmatch(content, -1) {
-			add("method", m[2])
 		}
 	}
 	return out
 }
 
-// SearchSymbols returns files whose symbols match any of the provided tokens (case-insensitive)
+// firstGroup returns the first non-empty submatch of m.
+func firstGroup(m []string) string {
+	for _, g := range m[1:] {
+		if g != "" {
+			return g
+		}
+	}
+	return ""
+}
+
+// SearchSymbols returns files whose symbols match any of the provided tokens (case-insensitive).
+// Imports are not matched: a file using a package is rarely about it.
 func SearchSymbols(idx *SymbolIndex, tokens []string) []string {
 	var out []string
 	tokSet := map[string]bool{}
@@ -164,6 +164,9 @@ func SearchSymbols(idx *SymbolIndex, tokens []string) []string {
 	for _, fs := range idx.Files {
 		match := false
 		for _, s := range fs.Symbols {
+			if s.Kind == "import" {
+				continue
+			}
 			name := strings.ToLower(s.Name)
 			for t := range tokSet {
 				if strings.Contains(name, t) {
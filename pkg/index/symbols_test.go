@@ -3,6 +3,7 @@ package index
 import (
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 )
 
@@ -34,3 +35,51 @@ func TestBuildAndSearchSymbols_GoFile(t *testing.T) {
 		t.Fatalf("expected search hits for tokens")
 	}
 }
+
+func TestExtractSymbols_Languages(t *testing.T) {
+	tests := []struct {
+		ext  string
+		src  string
+		want []Symbol
+	}{
+		{".go", "package m\n\nimport (\n\t\"fmt\"\n\tstr \"strings\"\n)\n\nfunc Map[T any](xs []T) {}\n\nfunc (p *Person) Greet() {}\n\ntype Person struct{}\n", []Symbol{
+			{"fmt", "import"}, {"strings", "import"}, {"Map", "func"}, {"Greet", "method"}, {"Person", "type"},
+		}},
+		{".py", "import os, numpy as np\nfrom typing import List\n\nclass Shape:\n    async def area(self):\n        pass\n", []Symbol{
+			{"os", "import"}, {"numpy", "import"}, {"typing", "import"}, {"area", "func"}, {"Shape", "class"},
+		}},
+		{".ts", "import { useState } from 'react';\nconst fs = require(\"fs\");\nexport interface Props {}\nexport const render = async (p: Props): Promise<void> => {};\nconst total = (a + b);\nexport default class App {}\nfunction helper<T>(x: T) {}\n", []Symbol{
+			{"react", "import"}, {"fs", "import"}, {"helper", "func"}, {"render", "func"}, {"App", "class"}, {"Props", "type"},
+		}},
+		{".rs", "use std::collections::HashMap;\n\npub struct Cache {}\n\nimpl Cache {\n    pub async fn get(&self) {}\n}\n", []Symbol{
+			{"std::collections::HashMap", "import"}, {"get", "func"}, {"Cache", "type"},
+		}},
+		{".java", "import java.util.List;\n\npublic final class Repo {\n    public List<String> findAll(int limit) throws IOException {\n        if (limit > 0) {\n        } else if (limit < 0) {\n        }\n    }\n}\n", []Symbol{
+			{"java.util.List", "import"}, {"Repo", "class"}, {"findAll", "method"},
+		}},
+		{".rb", "require 'json'\n\nmodule Billing\n  def self.charge!\n  end\nend\n", []Symbol{
+			{"json", "import"}, {"charge!", "func"}, {"Billing", "class"},
+		}},
+		{".php", "<?php\nuse App\\Models\\User;\n\nfinal class UserController {\n    public static function show($id) {}\n}\n", []Symbol{
+			{"App\\Models\\User", "import"}, {"show", "func"}, {"UserController", "class"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ext, func(t *testing.T) {
+			got := extractSymbols(tt.ext, tt.src)
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("extractSymbols(%s)\n got %v\nwant %v", tt.ext, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchSymbols_SkipsImports(t *testing.T) {
+	idx := &SymbolIndex{Files: []FileSymbols{
+		{File: "a.go", Symbols: []Symbol{{Name: "encoding/json", Kind: "import"}}},
+		{File: "b.go", Symbols: []Symbol{{Name: "decodeJSON", Kind: "func"}}},
+	}}
+	if hits := SearchSymbols(idx, []string{"json"}); !slices.Equal(hits, []string{"b.go"}) {
+		t.Fatalf("expected only the file declaring a match, got %v", hits)
+	}
+}
@@ -0,0 +1,453 @@
+// Package jobs runs agent tasks in the background. Each job is a headless
+// `ledit agent --output json` process whose event stream is followed for
+// progress, so the console stays usable while long tasks work.
+package jobs
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/alantheprice/ledit/pkg/agent"
+)
+
+// Status is the state of a job.
+type Status string
+
+const (
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusPartial   Status = "partial"
+	StatusFailed    Status = "failed"
+	StatusCancelled Status = "cancelled"
+)
+
+// cancelGracePeriod is how long a cancelled job may take to write its result
+// before it is killed.
+const cancelGracePeriod = 10 * time.Second
+
+// Spec describes the task a job runs.
+type Spec struct {
+	Prompt   string
+	WorkDir  string
+	Provider string
+	Model    string
+}
+
+// Progress is what a running job last reported.
+type Progress struct {
+	Iteration   int
+	TotalTokens int
+	Cost        float64
+	ToolCalls   int
+	CurrentTool string
+	Message     string
+	UpdatedAt   time.Time
+}
+
+// Job is a snapshot of a background task.
+type Job struct {
+	ID           string
+	Spec         Spec
+	Status       Status
+	StartedAt    time.Time
+	FinishedAt   time.Time
+	LogPath      string
+	Progress     Progress
+	FilesChanged []string
+	Result       *agent.AgentResult
+	Err          string
+}
+
+// Done reports whether the job has finished.
+func (j Job) Done() bool {
+	return j.Status != StatusRunning
+}
+
+// Duration is how long the job ran, or has been running.
+func (j Job) Duration() time.Duration {
+	if j.FinishedAt.IsZero() {
+		return time.Since(j.StartedAt)
+	}
+	return j.FinishedAt.Sub(j.StartedAt)
+}
+
+// Summary is a one-line description of a finished job.
+func (j Job) Summary() string {
+	summary := fmt.Sprintf("job %s %s after %s", j.ID, j.Status, j.Duration().Round(time.Second))
+	if n := len(j.FilesChanged); n > 0 {
+		summary += fmt.Sprintf(", %d file(s) changed", n)
+	}
+	if j.Progress.TotalTokens > 0 {
+		summary += fmt.Sprintf(", %d tokens", j.Progress.TotalTokens)
+	}
+	if j.Progress.Cost > 0 {
+		summary += fmt.Sprintf(", $%.4f", j.Progress.Cost)
+	}
+	return summary
+}
+
+// entry is the manager's live state for one job.
+type entry struct {
+	job       Job
+	files     map[string]bool
+	cancel    context.CancelFunc
+	cancelled bool
+	done      chan struct{}
+}
+
+// Manager starts background jobs and tracks them until they finish.
+type Manager struct {
+	mu      sync.Mutex
+	entries []*entry
+	nextID  int
+
+	// command builds the process for a job; tests replace it.
+	command func(ctx context.Context, spec Spec) (*exec.Cmd, error)
+	// onFinish is called without the lock held once a job has finished.
+	onFinish func(Job)
+}
+
+// NewManager returns a manager that runs jobs with the current ledit binary
+// and calls onFinish, if set, when each job ends.
+func NewManager(onFinish func(Job)) *Manager {
+	return &Manager{command: agentCommand, onFinish: onFinish}
+}
+
+var (
+	defaultMu      sync.Mutex
+	defaultManager *Manager
+)
+
+// Default returns the process-wide manager, creating it on first use with
+// Notify as its finish callback.
+func Default() *Manager {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if defaultManager == nil {
+		defaultManager = NewManager(Notify)
+	}
+	return defaultManager
+}
+
+// agentCommand runs the task as a headless agent with the prompt on stdin.
+func agentCommand(ctx context.Context, spec Spec) (*exec.Cmd, error) {
+	leditPath, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current executable path: %w", err)
+	}
+	args := []string{"agent", "--output", "json", "--prompt-stdin"}
+	if spec.Provider != "" {
+		args = append(args, "--provider", spec.Provider)
+	}
+	if spec.Model != "" {
+		args = append(args, "--model", spec.Model)
+	}
+	cmd := exec.CommandContext(ctx, leditPath, args...)
+	cmd.Stdin = strings.NewReader(spec.Prompt)
+	cmd.Env = append(os.Environ(), "LEDIT_FROM_AGENT=1")
+	return cmd, nil
+}
+
+// Submit starts a job and returns its initial snapshot. The job's
+// human-readable output is written to a log under .ledit/jobs.
+func (m *Manager) Submit(spec Spec) (Job, error) {
+	if strings.TrimSpace(spec.Prompt) == "" {
+		return Job{}, fmt.Errorf("a background job needs a prompt")
+	}
+	if spec.WorkDir == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return Job{}, err
+		}
+		spec.WorkDir = wd
+	}
+
+	m.mu.Lock()
+	m.nextID++
+	id := strconv.Itoa(m.nextID)
+	m.mu.Unlock()
+
+	started := time.Now()
+	logDir := filepath.Join(spec.WorkDir, ".ledit", "jobs")
+	if err := os.MkdirAll(logDir, 0o755); err != nil {
+		return Job{}, fmt.Errorf("failed to create job log directory: %w", err)
+	}
+	logPath := filepath.Join(logDir, fmt.Sprintf("%s-job%s.log", started.Format("20060102-150405"), id))
+	logFile, err := os.Create(logPath)
+	if err != nil {
+		return Job{}, fmt.Errorf("failed to create job log: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cmd, err := m.command(ctx, spec)
+	if err != nil {
+		cancel()
+		logFile.Close()
+		return Job{}, err
+	}
+	cmd.Dir = spec.WorkDir
+	cmd.Stderr = logFile
+	// Let a cancelled job stop gracefully and still report its result.
+	cmd.Cancel = func() error { return cmd.Process.Signal(syscall.SIGTERM) }
+	cmd.WaitDelay = cancelGracePeriod
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		cancel()
+		logFile.Close()
+		return Job{}, err
+	}
+	if err := cmd.Start(); err != nil {
+		cancel()
+		logFile.Close()
+		return Job{}, fmt.Errorf("failed to start job: %w", err)
+	}
+
+	e := &entry{
+		job: Job{
+			ID:        id,
+			Spec:      spec,
+			Status:    StatusRunning,
+			StartedAt: started,
+			LogPath:   logPath,
+			Progress:  Progress{UpdatedAt: started},
+		},
+		files:  make(map[string]bool),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	m.mu.Lock()
+	m.entries = append(m.entries, e)
+	snapshot := e.job
+	m.mu.Unlock()
+
+	go m.run(e, cmd, stdout, logFile)
+	return snapshot, nil
+}
+
+// run follows the job's event stream until the process exits.
+func (m *Manager) run(e *entry, cmd *exec.Cmd, stdout io.Reader, logFile *os.File) {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		m.observe(e, scanner.Bytes())
+	}
+	waitErr := cmd.Wait()
+	logFile.Close()
+	e.cancel()
+
+	m.mu.Lock()
+	job := &e.job
+	job.FinishedAt = time.Now()
+	switch {
+	case e.cancelled:
+		job.Status = StatusCancelled
+	case job.Result != nil:
+		job.Status = statusForResult(job.Result.Status)
+		job.Err = job.Result.Error
+	case waitErr != nil:
+		job.Status = StatusFailed
+		job.Err = waitErr.Error()
+	default:
+		job.Status = StatusFailed
+		job.Err = "job exited without a result"
+	}
+	snapshot := m.snapshot(e)
+	m.mu.Unlock()
+
+	close(e.done)
+	if m.onFinish != nil {
+		m.onFinish(snapshot)
+	}
+}
+
+// streamRecord is one line of the headless agent's JSON stream.
+type streamRecord struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// observe updates the job from one stream record. Lines that are not
+// records are ignored.
+func (m *Manager) observe(e *entry, line []byte) {
+	var record streamRecord
+	if err := json.Unmarshal(line, &record); err != nil || record.Type == "" {
+		return
+	}
+	if record.Type == "result" {
+		var result agent.AgentResult
+		if err := json.Unmarshal(record.Data, &result); err != nil {
+			return
+		}
+		m.mu.Lock()
+		e.job.Result = &result
+		e.job.Progress.TotalTokens = result.Tokens.Total
+		e.job.Progress.Cost = result.Cost
+		e.job.Progress.Iteration = result.Iterations
+		e.job.Progress.CurrentTool = ""
+		for _, path := range result.FilesChanged {
+			e.files[path] = true
+		}
+		m.mu.Unlock()
+		return
+	}
+
+	var data map[string]interface{}
+	if err := json.Unmarshal(record.Data, &data); err != nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p := &e.job.Progress
+	switch record.Type {
+	case "tool_start":
+		p.ToolCalls++
+		p.CurrentTool = stringField(data, "display_name")
+		if p.CurrentTool == "" {
+			p.CurrentTool = stringField(data, "tool_name")
+		}
+	case "tool_end":
+		p.CurrentTool = ""
+	case "metrics_update":
+		p.TotalTokens = intField(data, "total_tokens")
+		p.Iteration = intField(data, "iteration")
+		if cost, ok := data["total_cost"].(float64); ok {
+			p.Cost = cost
+		}
+	case "query_progress":
+		p.Message = stringField(data, "message")
+		if iteration := intField(data, "iteration"); iteration > 0 {
+			p.Iteration = iteration
+		}
+	case "file_changed":
+		if path := stringField(data, "file_path"); path != "" {
+			e.files[path] = true
+		}
+	default:
+		return
+	}
+	p.UpdatedAt = time.Now()
+}
+
+func stringField(data map[string]interface{}, key string) string {
+	s, _ := data[key].(string)
+	return s
+}
+
+func intField(data map[string]interface{}, key string) int {
+	n, _ := data[key].(float64)
+	return int(n)
+}
+
+func statusForResult(status string) Status {
+	switch status {
+	case agent.ResultStatusSuccess:
+		return StatusSucceeded
+	case agent.ResultStatusPartial:
+		return StatusPartial
+	default:
+		return StatusFailed
+	}
+}
+
+// snapshot copies e's job. The caller holds m.mu.
+func (m *Manager) snapshot(e *entry) Job {
+	job := e.job
+	job.FilesChanged = make([]string, 0, len(e.files))
+	for path := range e.files {
+		job.FilesChanged = append(job.FilesChanged, path)
+	}
+	sort.Strings(job.FilesChanged)
+	return job
+}
+
+func (m *Manager) find(id string) (*entry, error) {
+	id = strings.TrimPrefix(strings.TrimSpace(id), "#")
+	for _, e := range m.entries {
+		if e.job.ID == id {
+			return e, nil
+		}
+	}
+	return nil, fmt.Errorf("no job %q", id)
+}
+
+// List returns every job of this session, oldest first.
+func (m *Manager) List() []Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	jobs := make([]Job, 0, len(m.entries))
+	for _, e := range m.entries {
+		jobs = append(jobs, m.snapshot(e))
+	}
+	return jobs
+}
+
+// MonitorProgress returns the current state of job id.
+func (m *Manager) MonitorProgress(id string) (Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, err := m.find(id)
+	if err != nil {
+		return Job{}, err
+	}
+	return m.snapshot(e), nil
+}
+
+// CancelTask stops job id. The job gets a grace period to finish its current
+// step and report a result before it is killed.
+func (m *Manager) CancelTask(id string) error {
+	m.mu.Lock()
+	e, err := m.find(id)
+	if err != nil {
+		m.mu.Unlock()
+		return err
+	}
+	if e.job.Done() {
+		m.mu.Unlock()
+		return fmt.Errorf("job %s already %s", e.job.ID, e.job.Status)
+	}
+	e.cancelled = true
+	m.mu.Unlock()
+	e.cancel()
+	return nil
+}
+
+// Wait blocks until job id finishes or ctx ends and returns its final state.
+func (m *Manager) Wait(ctx context.Context, id string) (Job, error) {
+	m.mu.Lock()
+	e, err := m.find(id)
+	m.mu.Unlock()
+	if err != nil {
+		return Job{}, err
+	}
+	select {
+	case <-e.done:
+	case <-ctx.Done():
+		return Job{}, ctx.Err()
+	}
+	return m.MonitorProgress(id)
+}
+
+// Running returns how many jobs are still running.
+func (m *Manager) Running() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	running := 0
+	for _, e := range m.entries {
+		if !e.job.Done() {
+			running++
+		}
+	}
+	return running
+}
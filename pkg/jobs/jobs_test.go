@@ -0,0 +1,133 @@
+package jobs
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+// shellManager returns a manager whose jobs run script with sh.
+func shellManager(t *testing.T, script string, finished chan<- Job) *Manager {
+	t.Helper()
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	m := NewManager(func(job Job) {
+		if finished != nil {
+			finished <- job
+		}
+	})
+	m.command = func(ctx context.Context, spec Spec) (*exec.Cmd, error) {
+		return exec.CommandContext(ctx, "sh", "-c", script), nil
+	}
+	return m
+}
+
+func waitJob(t *testing.T, m *Manager, id string) Job {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	job, err := m.Wait(ctx, id)
+	if err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	return job
+}
+
+func TestSubmitFollowsEventStream(t *testing.T) {
+	script := `echo 'human output' >&2
+echo '{"type":"tool_start","data":{"tool_name":"read_file","display_name":"Read main.go"}}'
+echo '{"type":"file_changed","data":{"file_path":"main.go","action":"modified"}}'
+echo '{"type":"metrics_update","data":{"total_tokens":1200,"iteration":3,"total_cost":0.02}}'
+echo 'not json'
+echo '{"type":"result","data":{"status":"success","response":"Done.","files_changed":["util.go"],"tokens":{"total":1500},"cost":0.03,"iterations":4}}'
+`
+	finished := make(chan Job, 1)
+	m := shellManager(t, script, finished)
+	dir := t.TempDir()
+
+	job, err := m.Submit(Spec{Prompt: "fix it", WorkDir: dir})
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	if job.ID != "1" || job.Status != StatusRunning {
+		t.Fatalf("unexpected initial job %+v", job)
+	}
+
+	done := waitJob(t, m, job.ID)
+	if done.Status != StatusSucceeded {
+		t.Fatalf("status = %s (err %q)", done.Status, done.Err)
+	}
+	if done.Progress.ToolCalls != 1 || done.Progress.TotalTokens != 1500 || done.Progress.Iteration != 4 {
+		t.Errorf("unexpected progress %+v", done.Progress)
+	}
+	if strings.Join(done.FilesChanged, ",") != "main.go,util.go" {
+		t.Errorf("files changed = %v", done.FilesChanged)
+	}
+	if done.Result == nil || done.Result.Response != "Done." {
+		t.Errorf("unexpected result %+v", done.Result)
+	}
+
+	logData, err := os.ReadFile(done.LogPath)
+	if err != nil {
+		t.Fatalf("read log: %v", err)
+	}
+	if !strings.Contains(string(logData), "human output") {
+		t.Errorf("log missing stderr output: %q", logData)
+	}
+
+	select {
+	case notified := <-finished:
+		if notified.ID != job.ID || notified.Status != StatusSucceeded {
+			t.Errorf("unexpected notification %+v", notified)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("finish callback not called")
+	}
+}
+
+func TestSubmitWithoutResultFails(t *testing.T) {
+	m := shellManager(t, "exit 3", nil)
+	job, err := m.Submit(Spec{Prompt: "x", WorkDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	done := waitJob(t, m, job.ID)
+	if done.Status != StatusFailed || done.Err == "" {
+		t.Errorf("expected a failed job with an error, got %s %q", done.Status, done.Err)
+	}
+}
+
+func TestCancelTask(t *testing.T) {
+	m := shellManager(t, "sleep 30", nil)
+	job, err := m.Submit(Spec{Prompt: "slow", WorkDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	if running := m.Running(); running != 1 {
+		t.Errorf("Running() = %d, want 1", running)
+	}
+	if err := m.CancelTask(job.ID); err != nil {
+		t.Fatalf("CancelTask: %v", err)
+	}
+	done := waitJob(t, m, job.ID)
+	if done.Status != StatusCancelled {
+		t.Errorf("status = %s, want cancelled", done.Status)
+	}
+	if err := m.CancelTask(job.ID); err == nil {
+		t.Error("cancelling a finished job should fail")
+	}
+	if _, err := m.MonitorProgress("42"); err == nil {
+		t.Error("expected an error for an unknown job")
+	}
+}
+
+func TestSubmitRequiresPrompt(t *testing.T) {
+	m := NewManager(nil)
+	if _, err := m.Submit(Spec{Prompt: "  ", WorkDir: t.TempDir()}); err == nil {
+		t.Error("expected an error for an empty prompt")
+	}
+}
@@ -0,0 +1,41 @@
+package jobs
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/alantheprice/ledit/pkg/hooks"
+)
+
+// Notify announces a finished job: a terminal bell with a summary line, and a
+// desktop notification where one is available. Set
+// LEDIT_NO_DESKTOP_NOTIFY=1 to keep it to the terminal.
+func Notify(job Job) {
+	fmt.Printf("\a\r\n[bg] %s. Details: /jobs %s\r\n", capitalize(job.Summary()), job.ID)
+	if job.Result != nil && strings.TrimSpace(job.Result.Response) != "" {
+		fmt.Printf("[bg] %s\r\n", truncate(job.Result.Response, 200))
+	} else if job.Err != "" {
+		fmt.Printf("[bg] %s\r\n", truncate(job.Err, 200))
+	}
+	if os.Getenv("LEDIT_NO_DESKTOP_NOTIFY") == "1" {
+		return
+	}
+	hooks.DesktopNotify("ledit: job "+job.ID+" "+string(job.Status), truncate(job.Spec.Prompt, 120))
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+func truncate(s string, max int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	runes := []rune(s)
+	if len(runes) > max {
+		return string(runes[:max]) + "..."
+	}
+	return s
+}
@@ -0,0 +1,153 @@
+// Package ledit is the public Go API for embedding the ledit agent in other
+// programs. It wraps pkg/agent behind a small surface: construct an Agent, run
+// tasks with optional streaming, register custom tools, and save or restore
+// sessions.
+//
+// This package follows semantic versioning independently of the CLI. Exported
+// identifiers are only added within a major APIVersion; they are never removed
+// or changed incompatibly. Everything under pkg/agent and other internal
+// packages may change without notice and should not be imported directly.
+package ledit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/alantheprice/ledit/pkg/agent"
+)
+
+// APIVersion is the semantic version of this package's API.
+const APIVersion = "1.0.0"
+
+// ErrBusy is returned by Run when another task is already running on the agent.
+var ErrBusy = errors.New("ledit: agent is already running a task")
+
+// Options configures a new Agent. Zero values use the user's ledit
+// configuration.
+type Options struct {
+	// Provider and Model select the LLM, e.g. "openai" and "gpt-5".
+	Provider string
+	Model    string
+	// WorkspaceRoot is the directory the agent's file and shell tools operate
+	// in. Defaults to the current directory.
+	WorkspaceRoot string
+	// SystemPrompt replaces the built-in system prompt.
+	SystemPrompt string
+	// MaxIterations bounds tool-calling rounds per task; 0 means unlimited.
+	MaxIterations int
+	// SessionID names the session used by SaveSession.
+	SessionID string
+}
+
+// Result describes a completed task.
+type Result struct {
+	Output            string
+	TerminationReason string
+	Iterations        int
+	TotalTokens       int
+	TotalCost         float64
+}
+
+// Agent is an embeddable ledit agent. Its methods are safe for concurrent use,
+// but only one task runs at a time.
+type Agent struct {
+	inner *agent.Agent
+	mu    sync.Mutex
+}
+
+// New creates an agent from opts.
+func New(opts Options) (*Agent, error) {
+	model := strings.TrimSpace(opts.Model)
+	if provider := strings.TrimSpace(opts.Provider); provider != "" {
+		model = provider + ":" + model
+	}
+	inner, err := agent.NewAgentWithModel(model)
+	if err != nil {
+		return nil, fmt.Errorf("ledit: failed to create agent: %w", err)
+	}
+	return newAgent(inner, opts), nil
+}
+
+func newAgent(inner *agent.Agent, opts Options) *Agent {
+	if opts.WorkspaceRoot != "" {
+		inner.SetWorkspaceRoot(opts.WorkspaceRoot)
+	}
+	if opts.SystemPrompt != "" {
+		inner.SetSystemPrompt(opts.SystemPrompt)
+	}
+	if opts.MaxIterations > 0 {
+		inner.SetMaxIterations(opts.MaxIterations)
+	}
+	if opts.SessionID != "" {
+		inner.SetSessionID(opts.SessionID)
+	}
+	return &Agent{inner: inner}
+}
+
+// RunOption customizes a single Run call.
+type RunOption func(*runConfig)
+
+type runConfig struct {
+	onStream func(string)
+}
+
+// WithStreamCallback streams response text to fn as it is generated.
+func WithStreamCallback(fn func(chunk string)) RunOption {
+	return func(c *runConfig) { c.onStream = fn }
+}
+
+// Run executes task and returns once the agent stops. The conversation carries
+// over to the next Run until ClearHistory is called. Cancelling ctx interrupts
+// the agent and Run returns ctx.Err().
+func (a *Agent) Run(ctx context.Context, task string, opts ...RunOption) (*Result, error) {
+	if !a.mu.TryLock() {
+		return nil, ErrBusy
+	}
+	defer a.mu.Unlock()
+
+	var cfg runConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.onStream != nil {
+		a.inner.EnableStreaming(cfg.onStream)
+		defer a.inner.DisableStreaming()
+	}
+
+	a.inner.ClearInterrupt()
+	type outcome struct {
+		output string
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		output, err := a.inner.ProcessQuery(task)
+		done <- outcome{output: output, err: err}
+	}()
+
+	select {
+	case res := <-done:
+		if res.err != nil {
+			return nil, res.err
+		}
+		return &Result{
+			Output:            res.output,
+			TerminationReason: a.inner.GetLastRunTerminationReason(),
+			Iterations:        a.inner.GetCurrentIteration(),
+			TotalTokens:       a.inner.GetTotalTokens(),
+			TotalCost:         a.inner.GetTotalCost(),
+		}, nil
+	case <-ctx.Done():
+		a.inner.TriggerInterrupt()
+		<-done
+		return nil, ctx.Err()
+	}
+}
+
+// Close releases resources held by the agent, such as MCP server processes.
+func (a *Agent) Close() {
+	a.inner.Shutdown()
+}
@@ -0,0 +1,152 @@
+package ledit
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alantheprice/ledit/pkg/agent"
+	api "github.com/alantheprice/ledit/pkg/agent_api"
+)
+
+func newScriptedAgent(t *testing.T, responses ...*agent.ScriptedResponse) (*Agent, *agent.ScriptedClient) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	client := agent.NewScriptedClient(responses...)
+	inner, err := agent.NewAgentWithClient(client)
+	if err != nil {
+		t.Fatalf("NewAgentWithClient: %v", err)
+	}
+	a := newAgent(inner, Options{WorkspaceRoot: t.TempDir(), MaxIterations: 5})
+	t.Cleanup(a.Close)
+	return a, client
+}
+
+func toolCall(id, name, arguments string) api.ToolCall {
+	var tc api.ToolCall
+	tc.ID = id
+	tc.Type = "function"
+	tc.Function.Name = name
+	tc.Function.Arguments = arguments
+	return tc
+}
+
+func TestRunCallsRegisteredTool(t *testing.T) {
+	a, client := newScriptedAgent(t,
+		agent.NewScriptedResponseBuilder().ToolCall(toolCall("call_1", "lookup_ticket", `{"id":"ABC-1"}`)).FinishReason("tool_calls").Build(),
+		agent.NewScriptedResponseBuilder().Content("Ticket ABC-1 is currently open and assigned to the platform team.").FinishReason("stop").Build(),
+	)
+
+	var gotID any
+	err := a.RegisterTool(Tool{
+		Name:        "lookup_ticket",
+		Description: "Look up a ticket by ID",
+		Parameters:  []Parameter{{Name: "id", Description: "Ticket ID", Required: true}},
+		Handler: func(ctx context.Context, args map[string]any) (string, error) {
+			gotID = args["id"]
+			return "status: open", nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("RegisterTool: %v", err)
+	}
+
+	result, err := a.Run(context.Background(), "What is the status of ABC-1?")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if gotID != "ABC-1" {
+		t.Fatalf("expected handler to receive id ABC-1, got %v", gotID)
+	}
+	if !strings.Contains(result.Output, "Ticket ABC-1 is currently open") {
+		t.Fatalf("unexpected output %q", result.Output)
+	}
+
+	requests := client.GetSentRequests()
+	if len(requests) < 2 {
+		t.Fatalf("expected a follow-up request after the tool call, got %d", len(requests))
+	}
+	last := requests[len(requests)-1]
+	if !strings.Contains(last[len(last)-1].Content, "status: open") {
+		t.Fatalf("expected tool result in the conversation, got %q", last[len(last)-1].Content)
+	}
+}
+
+func TestRegisterToolRejectsBuiltinNames(t *testing.T) {
+	a, _ := newScriptedAgent(t)
+	handler := func(ctx context.Context, args map[string]any) (string, error) { return "", nil }
+	if err := a.RegisterTool(Tool{Name: "read_file", Handler: handler}); err == nil {
+		t.Fatal("expected conflict with built-in read_file")
+	}
+	if err := a.RegisterTool(Tool{Name: "mcp_custom", Handler: handler}); err == nil {
+		t.Fatal("expected mcp_ prefix to be rejected")
+	}
+	if err := a.RegisterTool(Tool{Name: "no_handler"}); err == nil {
+		t.Fatal("expected missing handler to be rejected")
+	}
+}
+
+func TestRunStreamsResponse(t *testing.T) {
+	a, _ := newScriptedAgent(t,
+		agent.NewScriptedResponseBuilder().Content("Hello! The streamed answer is complete and nothing else needs to be done.").FinishReason("stop").Build(),
+	)
+
+	var chunks strings.Builder
+	if _, err := a.Run(context.Background(), "hello", WithStreamCallback(func(chunk string) {
+		chunks.WriteString(chunk)
+	})); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !strings.Contains(chunks.String(), "The streamed answer is complete") {
+		t.Fatalf("expected streamed content, got %q", chunks.String())
+	}
+}
+
+func TestRunCancelledByContext(t *testing.T) {
+	a, _ := newScriptedAgent(t,
+		agent.NewScriptedResponseBuilder().Content("This answer arrives long after the caller has given up waiting.").FinishReason("stop").Delay(5*time.Second).Build(),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := a.Run(ctx, "slow task")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 4*time.Second {
+		t.Fatalf("expected cancellation to stop the run early, took %s", elapsed)
+	}
+}
+
+func TestSaveAndLoadSession(t *testing.T) {
+	a, _ := newScriptedAgent(t,
+		agent.NewScriptedResponseBuilder().Content("I have noted that for later in this session.").FinishReason("stop").Build(),
+	)
+	if _, err := a.Run(context.Background(), "remember this"); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	id, err := a.SaveSession()
+	if err != nil {
+		t.Fatalf("SaveSession: %v", err)
+	}
+	if id == "" || a.SessionID() != id {
+		t.Fatalf("expected session ID to be set, got %q / %q", id, a.SessionID())
+	}
+
+	a.ClearHistory()
+	if err := a.LoadSession(id); err != nil {
+		t.Fatalf("LoadSession: %v", err)
+	}
+	found := false
+	for _, msg := range a.inner.GetMessages() {
+		if strings.Contains(msg.Content, "remember this") {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal("expected the loaded session to restore the earlier conversation")
+	}
+}
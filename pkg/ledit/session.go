@@ -0,0 +1,80 @@
+package ledit
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/alantheprice/ledit/pkg/agent"
+)
+
+// Session summarizes a saved conversation.
+type Session struct {
+	ID               string
+	Name             string
+	WorkingDirectory string
+	LastUpdated      time.Time
+}
+
+// ListSessions returns the sessions saved for the current directory, most
+// recent first.
+func ListSessions() ([]Session, error) {
+	infos, err := agent.ListSessionsWithTimestamps()
+	if err != nil {
+		return nil, fmt.Errorf("ledit: failed to list sessions: %w", err)
+	}
+	sessions := make([]Session, 0, len(infos))
+	for _, info := range infos {
+		sessions = append(sessions, Session{
+			ID:               info.SessionID,
+			Name:             info.Name,
+			WorkingDirectory: info.WorkingDirectory,
+			LastUpdated:      info.LastUpdated,
+		})
+	}
+	return sessions, nil
+}
+
+// SessionID returns the ID SaveSession writes to. It is empty until set via
+// Options, LoadSession or an earlier save.
+func (a *Agent) SessionID() string {
+	return a.inner.GetSessionID()
+}
+
+// SaveSession persists the conversation under SessionID, generating an ID if
+// none is set, and returns the ID used.
+func (a *Agent) SaveSession() (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	sessionID := a.inner.GetSessionID()
+	if sessionID == "" {
+		sessionID = fmt.Sprintf("session_%d", time.Now().Unix())
+		a.inner.SetSessionID(sessionID)
+	}
+	if err := a.inner.SaveState(sessionID); err != nil {
+		return "", fmt.Errorf("ledit: failed to save session: %w", err)
+	}
+	return sessionID, nil
+}
+
+// LoadSession replaces the conversation with a saved session so the next Run
+// continues it.
+func (a *Agent) LoadSession(sessionID string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	state, err := a.inner.LoadState(sessionID)
+	if err != nil {
+		return fmt.Errorf("ledit: failed to load session %q: %w", sessionID, err)
+	}
+	a.inner.ApplyState(state)
+	a.inner.SetSessionID(sessionID)
+	return nil
+}
+
+// ClearHistory starts a fresh conversation on the same agent.
+func (a *Agent) ClearHistory() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.inner.ClearConversationHistory()
+}
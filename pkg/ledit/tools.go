@@ -0,0 +1,80 @@
+package ledit
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alantheprice/ledit/pkg/agent"
+	api "github.com/alantheprice/ledit/pkg/agent_api"
+)
+
+// Parameter describes one argument of a Tool.
+type Parameter struct {
+	Name        string
+	Type        string // JSON schema type: "string", "integer", "number", "boolean", "array" or "object"
+	Description string
+	Required    bool
+}
+
+// Tool is a function the model may call during Run, alongside the built-in
+// tools.
+type Tool struct {
+	Name        string
+	Description string
+	Parameters  []Parameter
+	// Handler receives the decoded arguments and returns the text sent back to
+	// the model. A returned error is reported to the model as a failed call.
+	Handler func(ctx context.Context, args map[string]any) (string, error)
+}
+
+// RegisterTool makes tool available to the model on later runs. Names must not
+// collide with built-in tools or use the "mcp_" prefix. Registering a name
+// again replaces the earlier tool.
+func (a *Agent) RegisterTool(tool Tool) error {
+	if tool.Handler == nil {
+		return fmt.Errorf("ledit: tool %q has no handler", tool.Name)
+	}
+
+	properties := make(map[string]any, len(tool.Parameters))
+	required := []string{}
+	for _, param := range tool.Parameters {
+		if param.Name == "" {
+			return fmt.Errorf("ledit: tool %q has a parameter without a name", tool.Name)
+		}
+		paramType := param.Type
+		if paramType == "" {
+			paramType = "string"
+		}
+		properties[param.Name] = map[string]any{
+			"type":        paramType,
+			"description": param.Description,
+		}
+		if param.Required {
+			required = append(required, param.Name)
+		}
+	}
+
+	var definition api.Tool
+	definition.Type = "function"
+	definition.Function.Name = tool.Name
+	definition.Function.Description = tool.Description
+	definition.Function.Parameters = map[string]any{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+
+	handler := tool.Handler
+	err := a.inner.RegisterCustomTool(definition, func(ctx context.Context, _ *agent.Agent, args map[string]interface{}) (string, error) {
+		return handler(ctx, args)
+	})
+	if err != nil {
+		return fmt.Errorf("ledit: %w", err)
+	}
+	return nil
+}
+
+// UnregisterTool removes a tool added with RegisterTool.
+func (a *Agent) UnregisterTool(name string) {
+	a.inner.UnregisterCustomTool(name)
+}
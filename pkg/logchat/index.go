@@ -0,0 +1,366 @@
+// Package logchat indexes large log files so the agent can answer questions
+// about them with targeted retrieval (time-bounded searches, line slices and
+// histograms) instead of reading the whole log into context. Ingestion
+// records line offsets and timestamps and summarizes fixed-size windows of
+// lines, which gives the model a cheap overview to navigate from.
+package logchat
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultWindowLines is the number of lines summarized per window.
+	DefaultWindowLines = 1000
+	// maxSignatures is the number of distinct error signatures kept per window.
+	maxSignatures = 3
+	// maxSignatureLength caps the normalized message stored as a signature.
+	maxSignatureLength = 120
+)
+
+// Window summarizes a contiguous range of log lines.
+type Window struct {
+	StartLine int       // 1-based, inclusive
+	EndLine   int       // 1-based, inclusive
+	Start     time.Time // first timestamp seen in the window (zero if none)
+	End       time.Time // last timestamp seen in the window (zero if none)
+	Levels    map[string]int
+	Statuses  map[string]int // HTTP status classes such as "5xx"
+	// Signatures are the most frequent normalized error/warning messages.
+	Signatures []Signature
+}
+
+// Signature is a normalized log message with its occurrence count and the
+// first line it appeared on.
+type Signature struct {
+	Text      string
+	Count     int
+	FirstLine int
+}
+
+// Index is a line and timestamp index over one log file.
+type Index struct {
+	// Source describes where the log came from (a path or a command).
+	Source string
+	// Path is the file the index reads from. For command output it is a
+	// temporary spool file removed by Close.
+	Path    string
+	Windows []Window
+
+	offsets   []int64 // byte offset of each line start, plus the file size
+	times     []int64 // unix nanos per line; continuation lines inherit, 0 before any timestamp
+	firsts    map[string]int
+	spool     bool
+	levelSum  map[string]int
+	statusSum map[string]int
+}
+
+// IndexFile indexes the log at path.
+func IndexFile(path string, windowLines int) (*Index, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log: %w", err)
+	}
+	defer f.Close()
+
+	idx := newIndex(path, path)
+	if err := idx.ingest(f, windowLines); err != nil {
+		return nil, err
+	}
+	return idx, nil
+}
+
+// IndexCommand runs command through the shell, spools its combined output to
+// a temporary file and indexes it. The caller must Close the index.
+func IndexCommand(ctx context.Context, command string, windowLines int) (*Index, error) {
+	spool, err := os.CreateTemp("", "ledit-logs-*.log")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create log spool: %w", err)
+	}
+	spoolPath := spool.Name()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Stdout = spool
+	cmd.Stderr = spool
+	runErr := cmd.Run()
+	if closeErr := spool.Close(); closeErr != nil && runErr == nil {
+		runErr = closeErr
+	}
+	if runErr != nil {
+		info, statErr := os.Stat(spoolPath)
+		if statErr != nil || info.Size() == 0 {
+			_ = os.Remove(spoolPath)
+			return nil, fmt.Errorf("log command failed: %w", runErr)
+		}
+		// Commands like `kubectl logs` may exit non-zero after printing
+		// useful output; keep what was captured.
+	}
+
+	idx, err := IndexFile(spoolPath, windowLines)
+	if err != nil {
+		_ = os.Remove(spoolPath)
+		return nil, err
+	}
+	idx.Source = command
+	idx.spool = true
+	return idx, nil
+}
+
+// Close removes the spool file of a command-backed index.
+func (idx *Index) Close() error {
+	if !idx.spool {
+		return nil
+	}
+	return os.Remove(idx.Path)
+}
+
+// Lines returns the number of indexed lines.
+func (idx *Index) Lines() int {
+	return len(idx.offsets) - 1
+}
+
+func newIndex(source, path string) *Index {
+	return &Index{
+		Source:    source,
+		Path:      path,
+		firsts:    make(map[string]int),
+		levelSum:  make(map[string]int),
+		statusSum: make(map[string]int),
+	}
+}
+
+// ingest reads r line by line, recording offsets, timestamps and window
+// summaries.
+func (idx *Index) ingest(r io.Reader, windowLines int) error {
+	if windowLines <= 0 {
+		windowLines = DefaultWindowLines
+	}
+	reader := bufio.NewReaderSize(r, 256*1024)
+
+	var (
+		offset  int64
+		lastTS  int64
+		current *Window
+		sigs    map[string]*Signature
+	)
+	finishWindow := func() {
+		if current == nil {
+			return
+		}
+		current.Signatures = topSignatures(sigs)
+		idx.Windows = append(idx.Windows, *current)
+		current = nil
+	}
+
+	for {
+		line, err := reader.ReadString('\n')
+		if len(line) > 0 {
+			lineNo := len(idx.offsets) + 1
+			idx.offsets = append(idx.offsets, offset)
+			offset += int64(len(line))
+			text := strings.TrimRight(line, "\r\n")
+
+			if ts, ok := ParseTimestamp(text); ok {
+				lastTS = ts.UnixNano()
+			}
+			idx.times = append(idx.times, lastTS)
+
+			if current == nil {
+				current = &Window{StartLine: lineNo, Levels: make(map[string]int), Statuses: make(map[string]int)}
+				sigs = make(map[string]*Signature)
+			}
+			current.EndLine = lineNo
+			if lastTS != 0 {
+				ts := time.Unix(0, lastTS).UTC()
+				if current.Start.IsZero() {
+					current.Start = ts
+				}
+				current.End = ts
+			}
+
+			level := detectLevel(text)
+			if level != "" {
+				current.Levels[level]++
+				idx.levelSum[level]++
+				idx.noteFirst("level:"+level, lineNo)
+			}
+			if status := detectStatus(text); status != "" {
+				class := status[:1] + "xx"
+				current.Statuses[class]++
+				idx.statusSum[class]++
+				idx.noteFirst("status:"+class, lineNo)
+			}
+			if isProblemLevel(level) {
+				sig := normalizeSignature(text)
+				if s, ok := sigs[sig]; ok {
+					s.Count++
+				} else {
+					sigs[sig] = &Signature{Text: sig, Count: 1, FirstLine: lineNo}
+				}
+			}
+
+			if lineNo-current.StartLine+1 >= windowLines {
+				finishWindow()
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read log: %w", err)
+		}
+	}
+	finishWindow()
+	idx.offsets = append(idx.offsets, offset)
+	return nil
+}
+
+func (idx *Index) noteFirst(key string, line int) {
+	if _, ok := idx.firsts[key]; !ok {
+		idx.firsts[key] = line
+	}
+}
+
+func topSignatures(sigs map[string]*Signature) []Signature {
+	out := make([]Signature, 0, len(sigs))
+	for _, s := range sigs {
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].FirstLine < out[j].FirstLine
+	})
+	if len(out) > maxSignatures {
+		out = out[:maxSignatures]
+	}
+	return out
+}
+
+var (
+	isoTimestampRe = regexp.MustCompile(`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:[.,]\d+)?(?:Z|[+-]\d{2}:?\d{2})?`)
+	clfTimestampRe = regexp.MustCompile(`\d{2}/[A-Z][a-z]{2}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}`)
+	sysTimestampRe = regexp.MustCompile(`^(?:<\d+>)?([A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}:\d{2})`)
+	epochFieldRe   = regexp.MustCompile(`"(?:ts|time|timestamp)"\s*:\s*(\d{10}(?:\.\d+)?)`)
+
+	levelRe  = regexp.MustCompile(`(?i)\b(fatal|panic|crit(?:ical)?|error|err|warn(?:ing)?|info|debug|trace)\b`)
+	statusRe = regexp.MustCompile(`(?:" |\bstatus(?:_code|Code)?["']?\s*[=:]\s*"?)([1-5]\d\d)\b`)
+
+	uuidRe   = regexp.MustCompile(`(?i)[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}`)
+	hexRe    = regexp.MustCompile(`(?i)\b0x[0-9a-f]+\b|\b[0-9a-f]{12,}\b`)
+	numberRe = regexp.MustCompile(`\d+`)
+)
+
+// ParseTimestamp extracts the timestamp of a log line. It recognizes
+// ISO-8601/RFC 3339 timestamps, Apache/Nginx common log format, syslog
+// prefixes and epoch seconds in JSON "ts"/"time" fields. Timestamps without a
+// zone are treated as UTC, and syslog timestamps are placed in the current year.
+func ParseTimestamp(line string) (time.Time, bool) {
+	head := line
+	if len(head) > 256 {
+		head = head[:256]
+	}
+	if m := isoTimestampRe.FindString(head); m != "" {
+		return parseISO(m)
+	}
+	if m := clfTimestampRe.FindString(head); m != "" {
+		if ts, err := time.Parse("02/Jan/2006:15:04:05 -0700", m); err == nil {
+			return ts.UTC(), true
+		}
+	}
+	if m := sysTimestampRe.FindStringSubmatch(head); m != nil {
+		if ts, err := time.Parse("Jan _2 15:04:05", m[1]); err == nil {
+			return ts.AddDate(time.Now().Year(), 0, 0).UTC(), true
+		}
+	}
+	if m := epochFieldRe.FindStringSubmatch(head); m != nil {
+		var secs, frac int64
+		whole, fraction, _ := strings.Cut(m[1], ".")
+		fmt.Sscan(whole, &secs)
+		if fraction != "" {
+			fraction = (fraction + "000000000")[:9]
+			fmt.Sscan(fraction, &frac)
+		}
+		return time.Unix(secs, frac).UTC(), true
+	}
+	return time.Time{}, false
+}
+
+func parseISO(s string) (time.Time, bool) {
+	s = strings.Replace(s, ",", ".", 1)
+	s = strings.Replace(s, " ", "T", 1)
+	for _, layout := range []string{
+		time.RFC3339Nano,
+		"2006-01-02T15:04:05.999999999Z0700",
+		"2006-01-02T15:04:05.999999999",
+	} {
+		if ts, err := time.Parse(layout, s); err == nil {
+			return ts.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// detectLevel returns the canonical severity of a line, or "".
+func detectLevel(line string) string {
+	head := line
+	if len(head) > 200 {
+		head = head[:200]
+	}
+	m := levelRe.FindStringSubmatch(head)
+	if m == nil {
+		return ""
+	}
+	switch strings.ToLower(m[1]) {
+	case "fatal", "panic", "crit", "critical":
+		return "FATAL"
+	case "error", "err":
+		return "ERROR"
+	case "warn", "warning":
+		return "WARN"
+	case "info":
+		return "INFO"
+	default:
+		return "DEBUG"
+	}
+}
+
+func isProblemLevel(level string) bool {
+	return level == "FATAL" || level == "ERROR" || level == "WARN"
+}
+
+// detectStatus returns an HTTP status code found in an access-log or
+// structured status field, or "".
+func detectStatus(line string) string {
+	m := statusRe.FindStringSubmatch(line)
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+// normalizeSignature strips timestamps and variable tokens (ids, numbers) so
+// repeated occurrences of the same message group together.
+func normalizeSignature(line string) string {
+	s := isoTimestampRe.ReplaceAllString(line, "")
+	s = clfTimestampRe.ReplaceAllString(s, "")
+	s = uuidRe.ReplaceAllString(s, "<id>")
+	s = hexRe.ReplaceAllString(s, "<hex>")
+	s = numberRe.ReplaceAllString(s, "#")
+	s = strings.Join(strings.Fields(s), " ")
+	if len(s) > maxSignatureLength {
+		s = strings.ToValidUTF8(s[:maxSignatureLength], "") + "…"
+	}
+	return s
+}
@@ -0,0 +1,110 @@
+package logchat
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+const sampleLog = `2024-05-01T10:00:00Z INFO server started
+2024-05-01T10:00:30Z INFO 127.0.0.1 "GET /health HTTP/1.1" 200 12
+2024-05-01T10:01:05Z ERROR request 1234 failed: upstream timeout
+2024-05-01T10:01:06Z INFO 127.0.0.1 "GET /api HTTP/1.1" 500 87
+goroutine 1 [running]:
+2024-05-01T10:02:10Z ERROR request 5678 failed: upstream timeout
+2024-05-01T10:02:11Z INFO 127.0.0.1 "GET /api HTTP/1.1" 500 87
+2024-05-01T10:03:00Z FATAL runtime: out of memory
+`
+
+func writeSampleLog(t *testing.T) *Index {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(path, []byte(sampleLog), 0644); err != nil {
+		t.Fatal(err)
+	}
+	idx, err := IndexFile(path, 4)
+	if err != nil {
+		t.Fatalf("IndexFile: %v", err)
+	}
+	return idx
+}
+
+func TestIndexFileWindowsAndTimestamps(t *testing.T) {
+	idx := writeSampleLog(t)
+
+	if idx.Lines() != 8 {
+		t.Fatalf("Lines() = %d, want 8", idx.Lines())
+	}
+	if len(idx.Windows) != 2 {
+		t.Fatalf("got %d windows, want 2", len(idx.Windows))
+	}
+	first := idx.Windows[0]
+	if first.StartLine != 1 || first.EndLine != 4 || first.Statuses["5xx"] != 1 || first.Levels["ERROR"] != 1 {
+		t.Errorf("unexpected first window: %+v", first)
+	}
+	if got := idx.Windows[1].Signatures; len(got) == 0 || !strings.Contains(got[0].Text, "request # failed") {
+		t.Errorf("expected normalized error signature, got %+v", got)
+	}
+	// The stack trace line inherits the previous timestamp.
+	if got, want := idx.Time(5), time.Date(2024, 5, 1, 10, 1, 6, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Time(5) = %v, want %v", got, want)
+	}
+
+	overview := idx.Overview()
+	for _, want := range []string{"Lines: 8", "First 5xx: line 4", "First FATAL: line 8", "lines 5-8"} {
+		if !strings.Contains(overview, want) {
+			t.Errorf("overview missing %q:\n%s", want, overview)
+		}
+	}
+}
+
+func TestSearchAndHistogram(t *testing.T) {
+	idx := writeSampleLog(t)
+
+	res, err := idx.Search(Query{
+		Pattern: regexp.MustCompile(`" 500 `),
+		Since:   time.Date(2024, 5, 1, 10, 2, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 1 || res.Matches[0].Number != 7 {
+		t.Fatalf("unexpected search result: %+v", res)
+	}
+
+	buckets, err := idx.Histogram(Query{Pattern: regexp.MustCompile(`(?i)error`)}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(buckets) != 2 || buckets[0].FirstLine != 3 || buckets[1].FirstLine != 6 {
+		t.Fatalf("unexpected histogram: %+v", buckets)
+	}
+
+	lines, err := idx.Slice(7, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 2 || !strings.Contains(FormatLines(lines), "8 [2024-05-01T10:03:00.000Z]: 2024-05-01T10:03:00Z FATAL") {
+		t.Fatalf("unexpected slice: %q", FormatLines(lines))
+	}
+}
+
+func TestParseTimestampFormats(t *testing.T) {
+	cases := map[string]time.Time{
+		`127.0.0.1 - - [01/May/2024:10:00:00 +0200] "GET / HTTP/1.1" 200 1`: time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC),
+		`{"level":"error","ts":1714557600.5,"msg":"boom"}`:                  time.Unix(1714557600, 500000000).UTC(),
+		`2024-05-01 10:00:00,123 WARN slow query`:                           time.Date(2024, 5, 1, 10, 0, 0, 123000000, time.UTC),
+	}
+	for line, want := range cases {
+		got, ok := ParseTimestamp(line)
+		if !ok || !got.Equal(want) {
+			t.Errorf("ParseTimestamp(%q) = %v, %v; want %v", line, got, ok, want)
+		}
+	}
+	if _, ok := ParseTimestamp("no timestamp here"); ok {
+		t.Error("expected no timestamp")
+	}
+}
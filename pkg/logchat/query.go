@@ -0,0 +1,355 @@
+package logchat
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// maxLineChars truncates long lines in retrieval output.
+	maxLineChars = 400
+	// maxSliceLines caps the lines returned by one slice or context request.
+	maxSliceLines = 200
+	// defaultSearchResults is the number of matches returned when no limit is given.
+	defaultSearchResults = 50
+	// maxOverviewRows caps the window rows in an overview; adjacent windows
+	// are merged to fit.
+	maxOverviewRows = 40
+)
+
+// Line is one retrieved log line.
+type Line struct {
+	Number int
+	Time   time.Time // zero when no timestamp is known
+	Text   string
+}
+
+// Query bounds a search. Zero values leave a bound open.
+type Query struct {
+	Pattern   *regexp.Regexp
+	Since     time.Time
+	Until     time.Time
+	StartLine int
+	EndLine   int
+	Limit     int
+}
+
+// SearchResult holds matching lines and the total number of matches.
+type SearchResult struct {
+	Matches []Line
+	Total   int
+}
+
+// Time returns the timestamp of a 1-based line, or the zero time.
+func (idx *Index) Time(line int) time.Time {
+	if line < 1 || line > len(idx.times) || idx.times[line-1] == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, idx.times[line-1]).UTC()
+}
+
+// LineAt returns the first line whose timestamp is at or after t, or 0.
+func (idx *Index) LineAt(t time.Time) int {
+	target := t.UnixNano()
+	i := sort.Search(len(idx.times), func(i int) bool { return idx.times[i] >= target })
+	if i == len(idx.times) {
+		return 0
+	}
+	return i + 1
+}
+
+// Slice returns lines start..end (1-based, inclusive), capped at maxSliceLines.
+func (idx *Index) Slice(start, end int) ([]Line, error) {
+	if start < 1 {
+		start = 1
+	}
+	if end > idx.Lines() {
+		end = idx.Lines()
+	}
+	if end < start {
+		return nil, nil
+	}
+	if end-start+1 > maxSliceLines {
+		end = start + maxSliceLines - 1
+	}
+	var lines []Line
+	err := idx.scan(start, end, func(l Line) bool {
+		lines = append(lines, l)
+		return true
+	})
+	return lines, err
+}
+
+// Search scans the lines inside the query bounds for the pattern.
+func (idx *Index) Search(q Query) (SearchResult, error) {
+	start, end := idx.bounds(q)
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultSearchResults
+	}
+	var res SearchResult
+	err := idx.scan(start, end, func(l Line) bool {
+		if !idx.withinTime(l, q) {
+			return true
+		}
+		if q.Pattern != nil && !q.Pattern.MatchString(l.Text) {
+			return true
+		}
+		res.Total++
+		if len(res.Matches) < limit {
+			res.Matches = append(res.Matches, l)
+		}
+		return true
+	})
+	return res, err
+}
+
+// Bucket is one histogram bar.
+type Bucket struct {
+	Start time.Time
+	Count int
+	// FirstLine is the first matching line in the bucket.
+	FirstLine int
+}
+
+// Histogram counts matching lines per time bucket. Lines without a timestamp
+// are skipped.
+func (idx *Index) Histogram(q Query, bucket time.Duration) ([]Bucket, error) {
+	if bucket <= 0 {
+		return nil, fmt.Errorf("bucket must be positive")
+	}
+	start, end := idx.bounds(q)
+	counts := make(map[int64]*Bucket)
+	err := idx.scan(start, end, func(l Line) bool {
+		if l.Time.IsZero() || !idx.withinTime(l, q) {
+			return true
+		}
+		if q.Pattern != nil && !q.Pattern.MatchString(l.Text) {
+			return true
+		}
+		key := l.Time.Truncate(bucket).UnixNano()
+		if b, ok := counts[key]; ok {
+			b.Count++
+		} else {
+			counts[key] = &Bucket{Start: time.Unix(0, key).UTC(), Count: 1, FirstLine: l.Number}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	buckets := make([]Bucket, 0, len(counts))
+	for _, b := range counts {
+		buckets = append(buckets, *b)
+	}
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].Start.Before(buckets[j].Start) })
+	return buckets, nil
+}
+
+// Overview describes the whole log: size, time span, severity and status
+// totals, first occurrences, and per-window summaries merged to fit.
+func (idx *Index) Overview() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Source: %s\nLines: %d\n", idx.Source, idx.Lines())
+	if first, last := idx.span(); !first.IsZero() {
+		fmt.Fprintf(&sb, "Time span: %s → %s (%s)\n", formatTime(first), formatTime(last), last.Sub(first).Round(time.Second))
+	} else {
+		sb.WriteString("Time span: no timestamps recognized; use line numbers\n")
+	}
+	if len(idx.levelSum) > 0 {
+		fmt.Fprintf(&sb, "Levels: %s\n", formatCounts(idx.levelSum))
+	}
+	if len(idx.statusSum) > 0 {
+		fmt.Fprintf(&sb, "HTTP statuses: %s\n", formatCounts(idx.statusSum))
+	}
+	for _, key := range []string{"level:FATAL", "level:ERROR", "status:5xx"} {
+		if line, ok := idx.firsts[key]; ok {
+			fmt.Fprintf(&sb, "First %s: line %d%s\n", strings.SplitN(key, ":", 2)[1], line, timeSuffix(idx.Time(line)))
+		}
+	}
+
+	sb.WriteString("\nWindows:\n")
+	for _, w := range mergeWindows(idx.Windows, maxOverviewRows) {
+		fmt.Fprintf(&sb, "- lines %d-%d", w.StartLine, w.EndLine)
+		if !w.Start.IsZero() {
+			fmt.Fprintf(&sb, " [%s → %s]", formatTime(w.Start), formatTime(w.End))
+		}
+		if len(w.Levels) > 0 {
+			fmt.Fprintf(&sb, " levels{%s}", formatCounts(w.Levels))
+		}
+		if len(w.Statuses) > 0 {
+			fmt.Fprintf(&sb, " http{%s}", formatCounts(w.Statuses))
+		}
+		sb.WriteString("\n")
+		for _, sig := range w.Signatures {
+			fmt.Fprintf(&sb, "    %d× (first line %d) %s\n", sig.Count, sig.FirstLine, sig.Text)
+		}
+	}
+	return sb.String()
+}
+
+// FormatLines renders lines as "N [time] text".
+func FormatLines(lines []Line) string {
+	var sb strings.Builder
+	for _, l := range lines {
+		text := l.Text
+		if len(text) > maxLineChars {
+			text = strings.ToValidUTF8(text[:maxLineChars], "") + "…"
+		}
+		fmt.Fprintf(&sb, "%d%s: %s\n", l.Number, timeSuffix(l.Time), text)
+	}
+	return sb.String()
+}
+
+// bounds narrows a query to a line range, using the timestamp index to skip
+// lines before Since.
+func (idx *Index) bounds(q Query) (int, int) {
+	start, end := 1, idx.Lines()
+	if q.StartLine > start {
+		start = q.StartLine
+	}
+	if q.EndLine > 0 && q.EndLine < end {
+		end = q.EndLine
+	}
+	if !q.Since.IsZero() {
+		if line := idx.LineAt(q.Since); line == 0 {
+			return 1, 0
+		} else if line > start {
+			start = line
+		}
+	}
+	return start, end
+}
+
+func (idx *Index) withinTime(l Line, q Query) bool {
+	if q.Since.IsZero() && q.Until.IsZero() {
+		return true
+	}
+	if l.Time.IsZero() {
+		return false
+	}
+	if !q.Since.IsZero() && l.Time.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && l.Time.After(q.Until) {
+		return false
+	}
+	return true
+}
+
+// scan calls fn for lines start..end until fn returns false.
+func (idx *Index) scan(start, end int, fn func(Line) bool) error {
+	if start < 1 || end < start || end > idx.Lines() {
+		return nil
+	}
+	f, err := os.Open(idx.Path)
+	if err != nil {
+		return fmt.Errorf("failed to open log: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Seek(idx.offsets[start-1], io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek log: %w", err)
+	}
+	reader := bufio.NewReaderSize(f, 256*1024)
+	for n := start; n <= end; n++ {
+		text, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("failed to read log: %w", err)
+		}
+		if !fn(Line{Number: n, Time: idx.Time(n), Text: strings.TrimRight(text, "\r\n")}) {
+			return nil
+		}
+		if err == io.EOF {
+			return nil
+		}
+	}
+	return nil
+}
+
+func (idx *Index) span() (time.Time, time.Time) {
+	var first, last time.Time
+	for _, w := range idx.Windows {
+		if w.Start.IsZero() {
+			continue
+		}
+		if first.IsZero() {
+			first = w.Start
+		}
+		last = w.End
+	}
+	return first, last
+}
+
+// mergeWindows combines adjacent windows so at most maxRows remain.
+func mergeWindows(windows []Window, maxRows int) []Window {
+	if len(windows) <= maxRows {
+		return windows
+	}
+	per := (len(windows) + maxRows - 1) / maxRows
+	merged := make([]Window, 0, maxRows)
+	for i := 0; i < len(windows); i += per {
+		group := windows[i:min(i+per, len(windows))]
+		w := Window{
+			StartLine: group[0].StartLine,
+			EndLine:   group[len(group)-1].EndLine,
+			Levels:    make(map[string]int),
+			Statuses:  make(map[string]int),
+		}
+		sigs := make(map[string]*Signature)
+		for _, g := range group {
+			if !g.Start.IsZero() {
+				if w.Start.IsZero() {
+					w.Start = g.Start
+				}
+				w.End = g.End
+			}
+			for k, v := range g.Levels {
+				w.Levels[k] += v
+			}
+			for k, v := range g.Statuses {
+				w.Statuses[k] += v
+			}
+			for _, s := range g.Signatures {
+				if existing, ok := sigs[s.Text]; ok {
+					existing.Count += s.Count
+				} else {
+					copied := s
+					sigs[s.Text] = &copied
+				}
+			}
+		}
+		w.Signatures = topSignatures(sigs)
+		merged = append(merged, w)
+	}
+	return merged
+}
+
+func formatCounts(counts map[string]int) string {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%d", k, counts[k]))
+	}
+	return strings.Join(parts, " ")
+}
+
+func formatTime(t time.Time) string {
+	return t.Format("2006-01-02T15:04:05.000Z")
+}
+
+func timeSuffix(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return " [" + formatTime(t) + "]"
+}
@@ -0,0 +1,239 @@
+package logchat
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/alantheprice/ledit/pkg/agent"
+	api "github.com/alantheprice/ledit/pkg/agent_api"
+)
+
+// maxHistogramBuckets caps histogram output; larger spans should use a wider bucket.
+const maxHistogramBuckets = 120
+
+// SystemPrompt returns the instructions appended to the agent's system prompt
+// for a log triage session over idx.
+func SystemPrompt(idx *Index) string {
+	return fmt.Sprintf(`## Log triage session
+
+You are helping triage a production incident from a log with %d lines (source: %s).
+The log is NOT in your context. Use the log tools to retrieve only what you need:
+- log_overview: time span, severity and HTTP status totals, first errors, per-window summaries. Start here.
+- log_histogram: counts of matching lines per time bucket, to find when something started or spiked.
+- log_search: regex search bounded by time range and/or line range.
+- log_context: lines surrounding a line number, to explain a specific event.
+- log_slice: an explicit line range.
+Cite line numbers and timestamps in answers. Times are UTC; accept RFC 3339 ("2024-05-01T13:04:05Z") or "2024-05-01 13:04:05".`,
+		idx.Lines(), idx.Source)
+}
+
+// Register adds the log retrieval tools for idx to a.
+func Register(a *agent.Agent, idx *Index) error {
+	for _, tool := range tools(idx) {
+		if err := a.RegisterCustomTool(tool.definition, tool.handler); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type logTool struct {
+	definition api.Tool
+	handler    agent.ToolHandler
+}
+
+func tools(idx *Index) []logTool {
+	return []logTool{
+		{
+			definition: definition("log_overview",
+				"Summarize the indexed log: line count, time span, severity and HTTP status totals, first occurrences of errors, and windowed summaries with the most frequent error signatures.",
+				nil, nil),
+			handler: func(ctx context.Context, _ *agent.Agent, _ map[string]interface{}) (string, error) {
+				return idx.Overview(), nil
+			},
+		},
+		{
+			definition: definition("log_search",
+				"Search the log with a case-insensitive regular expression, optionally bounded by time and line range. Returns matching lines with line numbers and timestamps plus the total match count.",
+				map[string]interface{}{
+					"pattern":     param("string", "Regular expression (case-insensitive). Omit to list every line in the bounds."),
+					"since":       param("string", "Only lines at or after this UTC time"),
+					"until":       param("string", "Only lines at or before this UTC time"),
+					"start_line":  param("integer", "First line to scan (1-based)"),
+					"end_line":    param("integer", "Last line to scan (inclusive)"),
+					"max_results": param("integer", fmt.Sprintf("Maximum lines returned (default %d)", defaultSearchResults)),
+				}, nil),
+			handler: func(ctx context.Context, _ *agent.Agent, args map[string]interface{}) (string, error) {
+				q, err := queryFromArgs(args)
+				if err != nil {
+					return "", err
+				}
+				q.Limit = intArg(args, "max_results")
+				res, err := idx.Search(q)
+				if err != nil {
+					return "", err
+				}
+				if res.Total == 0 {
+					return "No matching lines.", nil
+				}
+				header := fmt.Sprintf("%d matching lines", res.Total)
+				if res.Total > len(res.Matches) {
+					header += fmt.Sprintf(" (showing first %d; narrow the bounds or raise max_results)", len(res.Matches))
+				}
+				return header + ":\n" + FormatLines(res.Matches), nil
+			},
+		},
+		{
+			definition: definition("log_context",
+				"Return the lines surrounding a line number, e.g. to explain the first occurrence of an error.",
+				map[string]interface{}{
+					"line":   param("integer", "Line number to center on"),
+					"before": param("integer", "Lines before (default 20)"),
+					"after":  param("integer", "Lines after (default 20)"),
+				}, []string{"line"}),
+			handler: func(ctx context.Context, _ *agent.Agent, args map[string]interface{}) (string, error) {
+				line := intArg(args, "line")
+				if line < 1 || line > idx.Lines() {
+					return "", fmt.Errorf("line must be between 1 and %d", idx.Lines())
+				}
+				before, after := 20, 20
+				if _, ok := args["before"]; ok {
+					before = intArg(args, "before")
+				}
+				if _, ok := args["after"]; ok {
+					after = intArg(args, "after")
+				}
+				lines, err := idx.Slice(line-before, line+after)
+				if err != nil {
+					return "", err
+				}
+				return FormatLines(lines), nil
+			},
+		},
+		{
+			definition: definition("log_slice",
+				fmt.Sprintf("Return an explicit range of log lines (at most %d per call).", maxSliceLines),
+				map[string]interface{}{
+					"start_line": param("integer", "First line (1-based)"),
+					"end_line":   param("integer", "Last line (inclusive)"),
+				}, []string{"start_line", "end_line"}),
+			handler: func(ctx context.Context, _ *agent.Agent, args map[string]interface{}) (string, error) {
+				lines, err := idx.Slice(intArg(args, "start_line"), intArg(args, "end_line"))
+				if err != nil {
+					return "", err
+				}
+				if len(lines) == 0 {
+					return "No lines in range.", nil
+				}
+				return FormatLines(lines), nil
+			},
+		},
+		{
+			definition: definition("log_histogram",
+				"Count lines matching a regular expression per time bucket, to see when errors started or spiked. Each bucket includes its first matching line.",
+				map[string]interface{}{
+					"pattern": param("string", "Regular expression (case-insensitive), e.g. \"\\\" 5\\\\d\\\\d \" or \"OOM|out of memory\""),
+					"bucket":  param("string", "Bucket width as a Go duration (default 1m), e.g. 10s, 5m, 1h"),
+					"since":   param("string", "Only lines at or after this UTC time"),
+					"until":   param("string", "Only lines at or before this UTC time"),
+				}, []string{"pattern"}),
+			handler: func(ctx context.Context, _ *agent.Agent, args map[string]interface{}) (string, error) {
+				q, err := queryFromArgs(args)
+				if err != nil {
+					return "", err
+				}
+				bucket := time.Minute
+				if raw := stringArg(args, "bucket"); raw != "" {
+					if bucket, err = time.ParseDuration(raw); err != nil {
+						return "", fmt.Errorf("invalid bucket %q: %w", raw, err)
+					}
+				}
+				buckets, err := idx.Histogram(q, bucket)
+				if err != nil {
+					return "", err
+				}
+				if len(buckets) == 0 {
+					return "No timestamped lines match.", nil
+				}
+				var sb strings.Builder
+				if len(buckets) > maxHistogramBuckets {
+					fmt.Fprintf(&sb, "%d buckets; showing the first %d. Use a wider bucket or narrower time range.\n", len(buckets), maxHistogramBuckets)
+					buckets = buckets[:maxHistogramBuckets]
+				}
+				for _, b := range buckets {
+					fmt.Fprintf(&sb, "%s  %6d  (first line %d)\n", formatTime(b.Start), b.Count, b.FirstLine)
+				}
+				return sb.String(), nil
+			},
+		},
+	}
+}
+
+func definition(name, description string, properties map[string]interface{}, required []string) api.Tool {
+	if properties == nil {
+		properties = map[string]interface{}{}
+	}
+	if required == nil {
+		required = []string{}
+	}
+	var tool api.Tool
+	tool.Type = "function"
+	tool.Function.Name = name
+	tool.Function.Description = description
+	tool.Function.Parameters = map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+	return tool
+}
+
+func param(typ, description string) map[string]interface{} {
+	return map[string]interface{}{"type": typ, "description": description}
+}
+
+// queryFromArgs builds the shared pattern/time/line bounds of a query.
+func queryFromArgs(args map[string]interface{}) (Query, error) {
+	q := Query{StartLine: intArg(args, "start_line"), EndLine: intArg(args, "end_line")}
+	if pattern := stringArg(args, "pattern"); pattern != "" {
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			return Query{}, fmt.Errorf("invalid pattern: %w", err)
+		}
+		q.Pattern = re
+	}
+	for key, dst := range map[string]*time.Time{"since": &q.Since, "until": &q.Until} {
+		raw := stringArg(args, key)
+		if raw == "" {
+			continue
+		}
+		ts, ok := ParseTimestamp(raw)
+		if !ok {
+			return Query{}, fmt.Errorf("invalid %s %q: use RFC 3339 or \"YYYY-MM-DD HH:MM:SS\"", key, raw)
+		}
+		*dst = ts
+	}
+	return q, nil
+}
+
+func stringArg(args map[string]interface{}, key string) string {
+	s, _ := args[key].(string)
+	return strings.TrimSpace(s)
+}
+
+func intArg(args map[string]interface{}, key string) int {
+	switch v := args[key].(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	case string:
+		var n int
+		fmt.Sscan(v, &n)
+		return n
+	}
+	return 0
+}
@@ -0,0 +1,101 @@
+package semantic
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// RenamedFile is one file changed by a rename.
+type RenamedFile struct {
+	Path   string `json:"path"`
+	Before string `json:"-"`
+	After  string `json:"-"`
+}
+
+// RenameGo renames the Go identifier at pos in filePath, and every reference
+// to it, to newName using gopls. gopls type-checks the rename and refuses
+// it on conflicts, so unlike a text replacement it neither misses references
+// nor touches unrelated identifiers with the same name. The files are
+// rewritten in place; if writing fails part way, they are restored.
+func RenameGo(ctx context.Context, workspaceRoot, filePath string, pos Position, newName string) ([]RenamedFile, error) {
+	goplsPath, err := exec.LookPath("gopls")
+	if err != nil {
+		return nil, fmt.Errorf("rename needs gopls on PATH (go install golang.org/x/tools/gopls@latest): %w", errGoplsNotAvailable)
+	}
+	posArg := fmt.Sprintf("%s:%d:%d", filePath, pos.Line, pos.Column)
+
+	// -l lists the files the rename would change without writing them, so
+	// their content can be kept for the change history.
+	listed, err := runGoplsRename(ctx, goplsPath, workspaceRoot, "-l", posArg, newName)
+	if err != nil {
+		return nil, err
+	}
+	var files []RenamedFile
+	for _, line := range strings.Split(listed, "\n") {
+		path := strings.TrimSpace(line)
+		if path == "" {
+			continue
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(workspaceRoot, path)
+		}
+		before, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		files = append(files, RenamedFile{Path: path, Before: string(before)})
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("gopls found nothing to rename at %s", posArg)
+	}
+
+	if _, err := runGoplsRename(ctx, goplsPath, workspaceRoot, "-w", posArg, newName); err != nil {
+		return nil, errors.Join(err, restoreRenamedFiles(files))
+	}
+	for i := range files {
+		after, err := os.ReadFile(files[i].Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", files[i].Path, err)
+		}
+		files[i].After = string(after)
+	}
+	return files, nil
+}
+
+func runGoplsRename(ctx context.Context, goplsPath, workspaceRoot, mode, posArg, newName string) (string, error) {
+	cmd := exec.CommandContext(ctx, goplsPath, "rename", mode, posArg, newName)
+	cmd.Dir = workspaceRoot
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("gopls rename: %s", msg)
+	}
+	return stdout.String(), nil
+}
+
+func restoreRenamedFiles(files []RenamedFile) error {
+	var errs []error
+	for _, f := range files {
+		if current, err := os.ReadFile(f.Path); err == nil && string(current) == f.Before {
+			continue
+		}
+		if err := os.WriteFile(f.Path, []byte(f.Before), 0o644); err != nil {
+			errs = append(errs, fmt.Errorf("failed to restore %s: %w", f.Path, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -197,7 +197,7 @@ func (c *MCPClient) Initialize(ctx context.Context) error {
 	c.mutex.RUnlock()
 
 	initParams := map[string]interface{}{
-		"protocolVersion": "2024-11-05",
+		"protocolVersion": StdioProtocolVersion,
 		"capabilities": map[string]interface{}{
 			"tools":     map[string]interface{}{},
 			"resources": map[string]interface{}{},
@@ -193,7 +193,7 @@ func (c *MCPHTTPClient) Initialize(ctx context.Context) error {
 	c.mu.Unlock()
 
 	params := map[string]interface{}{
-		"protocolVersion": "2025-06-18",
+		"protocolVersion": HTTPProtocolVersion,
 		"capabilities": map[string]interface{}{
 			"roots": map[string]interface{}{
 				"listChanged": false,
@@ -7,6 +7,12 @@ import (
 	"time"
 )
 
+// MCP protocol revisions negotiated during initialize.
+const (
+	StdioProtocolVersion = "2024-11-05"
+	HTTPProtocolVersion  = "2025-06-18"
+)
+
 // MCPServerConfig represents the configuration for an MCP server
 type MCPServerConfig struct {
 	Name        string            `json:"name"`
@@ -0,0 +1,186 @@
+package modelsettings
+
+import (
+	_ "embed"
+	"encoding/json"
+	"strings"
+	"sync"
+)
+
+//go:embed model_capabilities.json
+var modelCapabilitiesJSON []byte
+
+// CapabilityProfile describes what a model (or model family) supports. Unset
+// boolean fields leave the value from lower-precedence sources in place.
+type CapabilityProfile struct {
+	ID                 string   `json:"id"`
+	MatchPrefixes      []string `json:"match_prefixes,omitempty"`
+	MatchExact         []string `json:"match_exact,omitempty"`
+	ContextWindow      int      `json:"context_window,omitempty"`
+	NativeTools        *bool    `json:"native_tools,omitempty"`
+	Vision             *bool    `json:"vision,omitempty"`
+	Reasoning          *bool    `json:"reasoning,omitempty"`
+	StreamingToolCalls *bool    `json:"streaming_tool_calls,omitempty"`
+	StructuredOutputs  string   `json:"structured_outputs,omitempty"` // json_schema, grammar, or none
+	InputCostPerMTok   float64  `json:"input_cost_per_mtok,omitempty"`
+	OutputCostPerMTok  float64  `json:"output_cost_per_mtok,omitempty"`
+	Source             string   `json:"source,omitempty"`
+}
+
+// ModelCapabilities is the resolved capability set for one model.
+type ModelCapabilities struct {
+	Known              bool
+	ContextWindow      int  // 0 when unknown
+	NativeTools        bool // accepts a tools array and returns structured tool_calls
+	Vision             bool
+	Reasoning          bool
+	StreamingToolCalls bool   // streams tool_call deltas reliably
+	StructuredOutputs  string // how replies can be constrained; empty when they can't
+	InputCostPerMTok   float64
+	OutputCostPerMTok  float64
+	PriceSource        string // where the prices came from; empty when unpriced
+	Source             string
+}
+
+// EstimateCost returns the USD cost of a request from the model's cost table,
+// or 0 when the model has no pricing.
+func (c ModelCapabilities) EstimateCost(promptTokens, completionTokens int) float64 {
+	return (float64(promptTokens)*c.InputCostPerMTok + float64(completionTokens)*c.OutputCostPerMTok) / 1_000_000
+}
+
+// Structured output modes. With json_schema the provider enforces strict
+// tool schemas and response_format schemas (OpenAI structured outputs); with
+// grammar it compiles a response_format schema into a sampling grammar
+// (llama.cpp servers). None turns a mode from a lower-precedence source off.
+const (
+	StructuredOutputsJSONSchema = "json_schema"
+	StructuredOutputsGrammar    = "grammar"
+	StructuredOutputsNone       = "none"
+)
+
+const openRouterProvider = "openrouter"
+
+var (
+	capabilitiesOnce    sync.Once
+	capabilityProfiles  []CapabilityProfile
+	providerStructured  map[string]string
+	defaultCapabilities = ModelCapabilities{NativeTools: true, StreamingToolCalls: true}
+)
+
+func ensureCapabilitiesLoaded() {
+	capabilitiesOnce.Do(func() {
+		var catalog struct {
+			Profiles                  []CapabilityProfile `json:"profiles"`
+			ProviderStructuredOutputs map[string]string   `json:"provider_structured_outputs"`
+		}
+		_ = json.Unmarshal(modelCapabilitiesJSON, &catalog)
+		capabilityProfiles = catalog.Profiles
+		providerStructured = catalog.ProviderStructuredOutputs
+	})
+}
+
+// ResolveModelCapabilities applies precedence:
+// override profile > refreshed price table (prices only, not for local
+// providers) > embedded profile (exact, then longest prefix) >
+// OpenRouter snapshot (only when served by OpenRouter) > provider
+// structured output mode > defaults.
+// Overrides typically come from user configuration. Unknown models are
+// assumed to support native and streamed tool calls.
+func ResolveModelCapabilities(provider, model string, overrides ...CapabilityProfile) ModelCapabilities {
+	ensureLoaded()
+	ensureCapabilitiesLoaded()
+	key := normalizeModelKey(model)
+	caps := defaultCapabilities
+	caps.StructuredOutputs = providerStructured[strings.ToLower(strings.TrimSpace(provider))]
+
+	// The OpenRouter snapshot reflects OpenRouter's serving of a model; the
+	// same weights behind another provider may expose different features.
+	if strings.EqualFold(strings.TrimSpace(provider), openRouterProvider) {
+		if entry, ok := modelsByKey[key]; ok {
+			caps.Known = true
+			caps.NativeTools = entry.Supported["tools"]
+			caps.Reasoning = entry.Supported["reasoning"]
+			caps.Source = "https://openrouter.ai/api/v1/models"
+		}
+	}
+
+	if profile := matchCapabilityProfile(capabilityProfiles, key); profile != nil {
+		applyCapabilityProfile(&caps, profile)
+	}
+
+	if !localProviders[strings.ToLower(strings.TrimSpace(provider))] {
+		if table := CurrentPriceTable(); table != nil {
+			if price, ok := table.Lookup(model); ok {
+				caps.InputCostPerMTok = price.InputPerMTok
+				caps.OutputCostPerMTok = price.OutputPerMTok
+				caps.PriceSource = table.Source
+			}
+		}
+	}
+
+	if profile := matchCapabilityProfile(overrides, key); profile != nil {
+		applyCapabilityProfile(&caps, profile)
+	}
+
+	if caps.StructuredOutputs == StructuredOutputsNone {
+		caps.StructuredOutputs = ""
+	}
+	return caps
+}
+
+func applyCapabilityProfile(caps *ModelCapabilities, profile *CapabilityProfile) {
+	caps.Known = true
+	if profile.ContextWindow > 0 {
+		caps.ContextWindow = profile.ContextWindow
+	}
+	if profile.NativeTools != nil {
+		caps.NativeTools = *profile.NativeTools
+	}
+	if profile.Vision != nil {
+		caps.Vision = *profile.Vision
+	}
+	if profile.Reasoning != nil {
+		caps.Reasoning = *profile.Reasoning
+	}
+	if profile.StreamingToolCalls != nil {
+		caps.StreamingToolCalls = *profile.StreamingToolCalls
+	}
+	if profile.StructuredOutputs != "" {
+		caps.StructuredOutputs = profile.StructuredOutputs
+	}
+	if profile.InputCostPerMTok > 0 || profile.OutputCostPerMTok > 0 {
+		caps.InputCostPerMTok = profile.InputCostPerMTok
+		caps.OutputCostPerMTok = profile.OutputCostPerMTok
+		caps.PriceSource = profile.Source
+		if caps.PriceSource == "" {
+			caps.PriceSource = profile.ID
+		}
+	}
+	if profile.Source != "" {
+		caps.Source = profile.Source
+	}
+}
+
+// matchCapabilityProfile returns the exact match, else the profile with the
+// longest matching prefix.
+func matchCapabilityProfile(profiles []CapabilityProfile, modelKey string) *CapabilityProfile {
+	for i := range profiles {
+		for _, exact := range profiles[i].MatchExact {
+			if normalizeModelKey(exact) == modelKey {
+				return &profiles[i]
+			}
+		}
+	}
+
+	var best *CapabilityProfile
+	bestLen := 0
+	for i := range profiles {
+		for _, prefix := range profiles[i].MatchPrefixes {
+			p := strings.ToLower(strings.TrimSpace(prefix))
+			if p != "" && strings.HasPrefix(modelKey, p) && len(p) > bestLen {
+				best, bestLen = &profiles[i], len(p)
+			}
+		}
+	}
+	return best
+}
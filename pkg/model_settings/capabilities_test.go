@@ -0,0 +1,118 @@
+package modelsettings
+
+import (
+	"math"
+	"testing"
+)
+
+func boolPtr(v bool) *bool { return &v }
+
+func TestResolveModelCapabilitiesLongestPrefixWins(t *testing.T) {
+	mini := ResolveModelCapabilities("openai", "gpt-4o-mini")
+	full := ResolveModelCapabilities("openai", "gpt-4o-2024-08-06")
+
+	if !mini.Known || !full.Known {
+		t.Fatalf("expected both models to be known")
+	}
+	if mini.InputCostPerMTok != 0.15 {
+		t.Fatalf("expected gpt-4o-mini pricing, got %v", mini.InputCostPerMTok)
+	}
+	if full.InputCostPerMTok != 2.5 {
+		t.Fatalf("expected gpt-4o pricing, got %v", full.InputCostPerMTok)
+	}
+	if !mini.Vision || !mini.NativeTools || mini.ContextWindow != 128000 {
+		t.Fatalf("unexpected gpt-4o-mini capabilities: %+v", mini)
+	}
+}
+
+func TestResolveModelCapabilitiesNormalizesVariants(t *testing.T) {
+	caps := ResolveModelCapabilities("ollama-local", "gemma3:12b")
+	if !caps.Known {
+		t.Fatalf("expected gemma to be known")
+	}
+	if caps.NativeTools {
+		t.Fatalf("expected gemma without native tools")
+	}
+}
+
+func TestResolveModelCapabilitiesUnknownModelDefaults(t *testing.T) {
+	caps := ResolveModelCapabilities("custom", "my-finetune-v2")
+	if caps.Known {
+		t.Fatalf("expected unknown model")
+	}
+	if !caps.NativeTools || !caps.StreamingToolCalls {
+		t.Fatalf("expected unknown models to assume native streamed tool calls, got %+v", caps)
+	}
+	if caps.ContextWindow != 0 || caps.EstimateCost(1000, 1000) != 0 {
+		t.Fatalf("expected no context window or pricing for unknown model, got %+v", caps)
+	}
+}
+
+func TestResolveModelCapabilitiesOpenRouterSnapshotOnlyForOpenRouter(t *testing.T) {
+	// The OpenRouter snapshot lists qwen2.5-coder-7b-instruct without tools.
+	viaOpenRouter := ResolveModelCapabilities("openrouter", "qwen/qwen2.5-coder-7b-instruct")
+	if !viaOpenRouter.Known || viaOpenRouter.NativeTools {
+		t.Fatalf("expected OpenRouter snapshot to report no native tools, got %+v", viaOpenRouter)
+	}
+
+	local := ResolveModelCapabilities("ollama-local", "qwen2.5-coder-7b-instruct")
+	if local.Known || !local.NativeTools {
+		t.Fatalf("expected other providers to ignore the OpenRouter snapshot, got %+v", local)
+	}
+}
+
+func TestResolveModelCapabilitiesOverrides(t *testing.T) {
+	overrides := []CapabilityProfile{
+		{
+			ID:                 "local-coder",
+			MatchPrefixes:      []string{"my-coder"},
+			ContextWindow:      16384,
+			NativeTools:        boolPtr(false),
+			StreamingToolCalls: boolPtr(false),
+		},
+		{
+			ID:               "cheap-4o",
+			MatchExact:       []string{"gpt-4o"},
+			InputCostPerMTok: 1.0,
+		},
+	}
+
+	caps := ResolveModelCapabilities("custom", "my-coder-7b", overrides...)
+	if !caps.Known || caps.NativeTools || caps.StreamingToolCalls || caps.ContextWindow != 16384 {
+		t.Fatalf("expected override to apply, got %+v", caps)
+	}
+
+	// Overrides only replace the fields they set.
+	gpt := ResolveModelCapabilities("openai", "gpt-4o", overrides...)
+	if gpt.InputCostPerMTok != 1.0 || gpt.OutputCostPerMTok != 0 {
+		t.Fatalf("expected overridden pricing, got %+v", gpt)
+	}
+	if !gpt.Vision || gpt.ContextWindow != 128000 {
+		t.Fatalf("expected registry fields to be kept, got %+v", gpt)
+	}
+}
+
+func TestResolveModelCapabilitiesStructuredOutputs(t *testing.T) {
+	if got := ResolveModelCapabilities("openai", "gpt-4o-mini").StructuredOutputs; got != StructuredOutputsJSONSchema {
+		t.Fatalf("expected json_schema for gpt-4o-mini, got %q", got)
+	}
+	if got := ResolveModelCapabilities("lmstudio", "my-local-model").StructuredOutputs; got != StructuredOutputsGrammar {
+		t.Fatalf("expected lmstudio to default to grammar, got %q", got)
+	}
+	if got := ResolveModelCapabilities("deepseek", "deepseek-chat").StructuredOutputs; got != "" {
+		t.Fatalf("expected no structured outputs for deepseek-chat, got %q", got)
+	}
+
+	off := CapabilityProfile{ID: "user", MatchPrefixes: []string{"gpt-4o"}, StructuredOutputs: StructuredOutputsNone}
+	if got := ResolveModelCapabilities("openai", "gpt-4o", off).StructuredOutputs; got != "" {
+		t.Fatalf("expected an override of none to turn structured outputs off, got %q", got)
+	}
+}
+
+func TestModelCapabilitiesEstimateCost(t *testing.T) {
+	caps := ModelCapabilities{InputCostPerMTok: 2.5, OutputCostPerMTok: 10}
+	got := caps.EstimateCost(1_000_000, 500_000)
+	if math.Abs(got-7.5) > 1e-9 {
+		t.Fatalf("expected cost 7.5, got %v", got)
+	}
+}
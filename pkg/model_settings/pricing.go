@@ -0,0 +1,236 @@
+package modelsettings
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultPricingURL is the price source used when none is configured. Its
+// per-token prices are the providers' list prices.
+const DefaultPricingURL = "https://openrouter.ai/api/v1/models"
+
+const maxPricingBytes int64 = 16 << 20
+
+// ModelPrice is a model's price in USD per million tokens.
+type ModelPrice struct {
+	InputPerMTok  float64 `json:"input_per_mtok"`
+	OutputPerMTok float64 `json:"output_per_mtok"`
+}
+
+// PriceTable is a cached snapshot of a pricing source. ETag is sent back on
+// the next refresh so an unchanged source costs a 304 instead of a download.
+type PriceTable struct {
+	Source    string                `json:"source"`
+	ETag      string                `json:"etag,omitempty"`
+	FetchedAt time.Time             `json:"fetched_at"`
+	Models    map[string]ModelPrice `json:"models"` // by normalized model key
+}
+
+// localProviders serve models on the user's machine, so list prices don't
+// apply to them.
+var localProviders = map[string]bool{"ollama": true, "ollama-local": true, "lmstudio": true}
+
+var (
+	priceTableMu sync.RWMutex
+	priceTable   *PriceTable
+)
+
+// SetPriceTable makes table the refreshed prices ResolveModelCapabilities
+// applies on top of the built-in registry. nil removes them.
+func SetPriceTable(table *PriceTable) {
+	priceTableMu.Lock()
+	defer priceTableMu.Unlock()
+	priceTable = table
+}
+
+// CurrentPriceTable returns the table set by SetPriceTable, or nil.
+func CurrentPriceTable() *PriceTable {
+	priceTableMu.RLock()
+	defer priceTableMu.RUnlock()
+	return priceTable
+}
+
+// Lookup returns the price of model, ignoring provider prefixes and variant
+// suffixes the way the capability registry does. OpenRouter's :free
+// variants cost nothing.
+func (t *PriceTable) Lookup(model string) (ModelPrice, bool) {
+	if t == nil {
+		return ModelPrice{}, false
+	}
+	if strings.HasSuffix(strings.ToLower(strings.TrimSpace(model)), ":free") {
+		return ModelPrice{}, true
+	}
+	price, ok := t.Models[normalizeModelKey(model)]
+	return price, ok
+}
+
+// Stale reports whether the table is older than maxAge.
+func (t *PriceTable) Stale(maxAge time.Duration) bool {
+	return t == nil || time.Since(t.FetchedAt) > maxAge
+}
+
+// LookupPriceOverride finds model's entry in user price overrides. Keys are
+// "provider/model" or a model name; the provider-qualified key wins.
+func LookupPriceOverride(overrides map[string]ModelPrice, provider, model string) (ModelPrice, bool) {
+	qualified := strings.TrimSpace(provider) + "/" + strings.TrimSpace(model)
+	for name, price := range overrides {
+		if strings.EqualFold(strings.TrimSpace(name), qualified) {
+			return price, true
+		}
+	}
+	key := normalizeModelKey(model)
+	for name, price := range overrides {
+		if strings.EqualFold(strings.TrimSpace(name), strings.TrimSpace(model)) ||
+			(!strings.Contains(name, "/") && normalizeModelKey(name) == key) {
+			return price, true
+		}
+	}
+	return ModelPrice{}, false
+}
+
+// LoadPriceTable reads a table saved by RefreshPriceTable. A missing file
+// yields nil without an error.
+func LoadPriceTable(path string) (*PriceTable, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var table PriceTable
+	if err := json.Unmarshal(data, &table); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return &table, nil
+}
+
+func (t *PriceTable) save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create pricing directory: %w", err)
+	}
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// RefreshPriceTable fetches prices from url (DefaultPricingURL when empty)
+// into the table cached at path and returns it. The cached ETag makes the
+// request conditional; when the source is unchanged only the fetch time is
+// updated. The source may be OpenRouter's model list or a JSON object with a
+// "models" map of ModelPrice values, such as a team-hosted price sheet.
+func RefreshPriceTable(ctx context.Context, path, url string) (*PriceTable, error) {
+	if strings.TrimSpace(url) == "" {
+		url = DefaultPricingURL
+	}
+	cached, _ := LoadPriceTable(path)
+	if cached != nil && cached.Source != url {
+		cached = nil // prices from another source must not be kept
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create pricing request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "ledit-pricing/1.0")
+	if cached != nil && cached.ETag != "" {
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+
+	client := &http.Client{Timeout: 20 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch pricing: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var table *PriceTable
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		table = cached
+	case resp.StatusCode == http.StatusOK:
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxPricingBytes))
+		if err != nil {
+			return nil, fmt.Errorf("read pricing response body: %w", err)
+		}
+		models, err := parsePrices(body)
+		if err != nil {
+			return nil, fmt.Errorf("parse pricing from %s: %w", url, err)
+		}
+		table = &PriceTable{Source: url, ETag: resp.Header.Get("ETag"), Models: models}
+	default:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("pricing fetch failed (%d): %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	table.FetchedAt = time.Now()
+	if err := table.save(path); err != nil {
+		return nil, err
+	}
+	return table, nil
+}
+
+// perMillion converts a per-token price to a per-million-token one, rounded
+// to a millionth of a dollar so "0.0000004" becomes exactly 0.4.
+func perMillion(perToken float64) float64 {
+	return math.Round(perToken*1e12) / 1e6
+}
+
+// parsePrices reads OpenRouter's model list, whose prices are USD per token
+// as strings, or ledit's {"models": {"<model>": ModelPrice}} format.
+func parsePrices(body []byte) (map[string]ModelPrice, error) {
+	var doc struct {
+		Data []struct {
+			ID      string `json:"id"`
+			Pricing struct {
+				Prompt     string `json:"prompt"`
+				Completion string `json:"completion"`
+			} `json:"pricing"`
+		} `json:"data"`
+		Models map[string]ModelPrice `json:"models"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, err
+	}
+
+	models := make(map[string]ModelPrice)
+	for _, entry := range doc.Data {
+		input, inErr := strconv.ParseFloat(entry.Pricing.Prompt, 64)
+		output, outErr := strconv.ParseFloat(entry.Pricing.Completion, 64)
+		// Negative prices mark router models whose price depends on the pick.
+		if inErr != nil || outErr != nil || input < 0 || output < 0 {
+			continue
+		}
+		price := ModelPrice{InputPerMTok: perMillion(input), OutputPerMTok: perMillion(output)}
+		key := normalizeModelKey(entry.ID)
+		// Variants such as :free normalize onto the base model; keep the paid price.
+		if existing, ok := models[key]; ok && existing.InputPerMTok+existing.OutputPerMTok >= price.InputPerMTok+price.OutputPerMTok {
+			continue
+		}
+		models[key] = price
+	}
+	for name, price := range doc.Models {
+		models[normalizeModelKey(name)] = price
+	}
+	if len(models) == 0 {
+		return nil, errors.New("no model prices found")
+	}
+	return models, nil
+}
@@ -0,0 +1,91 @@
+package modelsettings
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+const openRouterPrices = `{"data": [
+	{"id": "openai/gpt-5", "pricing": {"prompt": "0.000002", "completion": "0.000012"}},
+	{"id": "acme/coder-9b:free", "pricing": {"prompt": "0", "completion": "0"}},
+	{"id": "acme/coder-9b", "pricing": {"prompt": "0.0000001", "completion": "0.0000004"}},
+	{"id": "openrouter/auto", "pricing": {"prompt": "-1", "completion": "-1"}}
+]}`
+
+func TestRefreshPriceTableUsesETag(t *testing.T) {
+	requests, conditional := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			conditional++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(openRouterPrices))
+	}))
+	defer server.Close()
+	path := filepath.Join(t.TempDir(), "pricing.json")
+
+	table, err := RefreshPriceTable(context.Background(), path, server.URL)
+	if err != nil {
+		t.Fatalf("RefreshPriceTable: %v", err)
+	}
+	if price, ok := table.Lookup("gpt-5"); !ok || price.InputPerMTok != 2 || price.OutputPerMTok != 12 {
+		t.Fatalf("gpt-5 price = %+v, %v", price, ok)
+	}
+	if price, _ := table.Lookup("acme/coder-9b"); price.OutputPerMTok != 0.4 {
+		t.Fatalf("expected the paid price to win over the :free variant, got %+v", price)
+	}
+	if price, ok := table.Lookup("coder-9b:free"); !ok || price.InputPerMTok != 0 {
+		t.Fatalf("expected :free variants to be free, got %+v", price)
+	}
+	if _, ok := table.Lookup("auto"); ok {
+		t.Fatal("expected variable-priced router models to be skipped")
+	}
+
+	first := table.FetchedAt
+	again, err := RefreshPriceTable(context.Background(), path, server.URL)
+	if err != nil || conditional != 1 || len(again.Models) != len(table.Models) || !again.FetchedAt.After(first) {
+		t.Fatalf("expected a 304 to keep the cached prices, got %+v, %v (conditional %d)", again, err, conditional)
+	}
+	saved, err := LoadPriceTable(path)
+	if err != nil || saved.ETag != `"v1"` || saved.Source != server.URL {
+		t.Fatalf("LoadPriceTable = %+v, %v", saved, err)
+	}
+}
+
+func TestPriceTableAndOverridePrecedence(t *testing.T) {
+	SetPriceTable(&PriceTable{Source: "test-source", Models: map[string]ModelPrice{"gpt-5": {InputPerMTok: 2, OutputPerMTok: 12}}})
+	defer SetPriceTable(nil)
+
+	caps := ResolveModelCapabilities("openai", "gpt-5")
+	if caps.InputCostPerMTok != 2 || caps.PriceSource != "test-source" {
+		t.Fatalf("expected refreshed prices over the registry, got %+v", caps)
+	}
+	if local := ResolveModelCapabilities("ollama", "gpt-5"); local.PriceSource == "test-source" {
+		t.Fatalf("expected refreshed list prices not to apply to local providers, got %+v", local)
+	}
+
+	overrides := map[string]ModelPrice{
+		"gpt-5":        {InputPerMTok: 1, OutputPerMTok: 8},
+		"azure/gpt-5":  {InputPerMTok: 0.5, OutputPerMTok: 4},
+		"other/model":  {InputPerMTok: 9},
+		"my-local-llm": {},
+	}
+	if price, ok := LookupPriceOverride(overrides, "azure", "gpt-5"); !ok || price.InputPerMTok != 0.5 {
+		t.Fatalf("expected the provider-qualified override, got %+v, %v", price, ok)
+	}
+	if price, ok := LookupPriceOverride(overrides, "openrouter", "openai/gpt-5"); !ok || price.InputPerMTok != 1 {
+		t.Fatalf("expected the model override, got %+v, %v", price, ok)
+	}
+	if price, ok := LookupPriceOverride(overrides, "ollama", "my-local-llm"); !ok || price.OutputPerMTok != 0 {
+		t.Fatalf("expected a zero override to be found, got %+v, %v", price, ok)
+	}
+	if _, ok := LookupPriceOverride(overrides, "openai", "model"); ok {
+		t.Fatal("expected a provider-qualified override not to match another provider")
+	}
+}
@@ -27,6 +27,7 @@ type Definition struct {
 	AllowedTools     []string `json:"allowed_tools,omitempty"`
 	Enabled          bool     `json:"enabled"`
 	Aliases          []string `json:"aliases,omitempty"`
+	MaxTokens        int      `json:"max_tokens,omitempty"`
 }
 
 var (
@@ -113,7 +114,7 @@ func fallbackDefinitions() map[string]Definition {
 			ID:           "orchestrator",
 			Name:         "Orchestrator",
 			Description:  "Primary orchestration persona",
-			AllowedTools: []string{"shell_command", "read_file", "write_file", "edit_file", "write_structured_file", "patch_structured_file", "search_files", "web_search", "fetch_url", "run_subagent", "run_parallel_subagents", "TodoWrite", "TodoRead", "add_memory", "read_memory", "list_memories", "delete_memory"},
+			AllowedTools: []string{"shell_command", "read_file", "fetch_more", "read_symbol", "impact_of_change", "file_history", "test_coverage", "run_linters", "write_file", "edit_file", "rename_symbol", "edit_transaction", "write_structured_file", "patch_structured_file", "search_files", "web_search", "fetch_url", "run_subagent", "run_parallel_subagents", "list_personas", "ask_user", "TodoWrite", "TodoRead", "add_memory", "read_memory", "list_memories", "delete_memory"},
 			Enabled:      true,
 		},
 		"general": {
@@ -121,7 +122,7 @@ func fallbackDefinitions() map[string]Definition {
 			Name:         "General",
 			Description:  "General-purpose persona",
 			SystemPrompt: "pkg/agent/prompts/subagent_prompts/general.md",
-			AllowedTools: []string{"shell_command", "read_file", "write_file", "edit_file", "write_structured_file", "patch_structured_file", "search_files", "TodoWrite", "TodoRead"},
+			AllowedTools: []string{"shell_command", "read_file", "fetch_more", "read_symbol", "impact_of_change", "file_history", "test_coverage", "run_linters", "write_file", "edit_file", "rename_symbol", "edit_transaction", "write_structured_file", "patch_structured_file", "search_files", "TodoWrite", "TodoRead"},
 			Enabled:      true,
 		},
 	}
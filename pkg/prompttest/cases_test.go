@@ -0,0 +1,122 @@
+package prompttest
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var (
+	updateGoldens = flag.Bool("update", false, "rewrite golden files and the success-rate baseline from the replayed runs")
+	recordCases   = flag.Bool("record", false, "re-record cases against the configured provider (makes live API calls)")
+)
+
+// casesDir returns the test case directory: LEDIT_PROMPT_TEST_CASES, or
+// test_cases/ at the repository root.
+func casesDir() string {
+	if dir := os.Getenv("LEDIT_PROMPT_TEST_CASES"); dir != "" {
+		return dir
+	}
+	return filepath.Join("..", "..", "test_cases")
+}
+
+// TestPromptCases replays every recorded case. A case fails when its replay
+// no longer matches golden.json; the suite fails when a case that succeeded
+// in baseline.json stops meeting its expectations or the success rate drops.
+func TestPromptCases(t *testing.T) {
+	dir, err := filepath.Abs(casesDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases, err := LoadCases(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cases) == 0 {
+		t.Skipf("no prompt test cases in %s", dir)
+	}
+	if *recordCases {
+		t.Setenv("LEDIT_ALLOW_REAL_PROVIDER", "1")
+	} else {
+		// Keep the user's config, memories and custom prompts out of replays.
+		t.Setenv("LEDIT_CONFIG", t.TempDir())
+	}
+	repoRoot := filepath.Dir(dir)
+
+	results := make(map[string]bool)
+	for _, c := range cases {
+		results[c.Name] = false
+		t.Run(c.Name, func(t *testing.T) {
+			prompt, err := c.PromptText(repoRoot)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *recordCases {
+				archive, _, err := c.Record(prompt, nil)
+				if err != nil {
+					t.Fatalf("record: %v", err)
+				}
+				if err := c.SaveRecording(archive); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			archive, err := c.LoadRecording()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := c.CheckRecording(archive, prompt); err != nil {
+				if errors.Is(err, ErrStaleRecording) {
+					t.Fatalf("%v; re-record with: go test ./pkg/prompttest -run 'TestPromptCases/%s$' -record", err, c.Name)
+				}
+				t.Fatal(err)
+			}
+
+			outcome, err := c.Replay(archive)
+			if err != nil {
+				t.Fatalf("replay: %v", err)
+			}
+			if outcome.UnusedResponses > 0 || len(outcome.DivergentRequests) > 0 {
+				t.Errorf("replay no longer follows the recording: %d unused responses, divergent requests %v",
+					outcome.UnusedResponses, outcome.DivergentRequests)
+			}
+
+			if *updateGoldens || *recordCases {
+				if err := c.UpdateGolden(outcome); err != nil {
+					t.Fatal(err)
+				}
+			} else if diff, err := c.CompareGolden(outcome); err != nil {
+				t.Fatal(err)
+			} else if diff != "" {
+				t.Errorf("outcome differs from %s (run with -update if the change is intended):\n%s", goldenFile, diff)
+			}
+
+			results[c.Name] = outcome.Passed()
+			if !outcome.Passed() {
+				t.Logf("expectations not met:\n  %s", strings.Join(outcome.Failures, "\n  "))
+			}
+		})
+	}
+
+	current := NewBaseline(results)
+	if *updateGoldens {
+		if err := current.Save(dir); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	baseline, err := LoadBaseline(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if regressed := baseline.Regressions(results); len(regressed) > 0 {
+		t.Errorf("prompt regression: cases that succeeded in %s now fail: %s", baselineFile, strings.Join(regressed, ", "))
+	}
+	if current.SuccessRate+1e-9 < baseline.SuccessRate {
+		t.Errorf("prompt regression: success rate %.0f%% is below the baseline %.0f%%", current.SuccessRate*100, baseline.SuccessRate*100)
+	}
+	t.Logf("prompt cases: %d/%d succeeded (%.0f%%)", len(current.Passing), len(results), current.SuccessRate*100)
+}
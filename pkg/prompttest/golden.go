@@ -0,0 +1,165 @@
+package prompttest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// Golden is the part of an outcome compared against golden.json. Tool results
+// are left out: they carry timings and sizes that vary between machines.
+type Golden struct {
+	Passed    bool              `json:"passed"`
+	Failures  []string          `json:"failures,omitempty"`
+	Response  string            `json:"response"`
+	Error     string            `json:"error,omitempty"`
+	ToolCalls []GoldenToolCall  `json:"tool_calls"`
+	Files     map[string]string `json:"files"`
+}
+
+// GoldenToolCall is a tool call in a golden file.
+type GoldenToolCall struct {
+	Tool      string `json:"tool"`
+	Arguments string `json:"arguments"`
+	Failed    bool   `json:"failed,omitempty"`
+}
+
+// Golden returns the comparable form of the outcome.
+func (o *Outcome) Golden() Golden {
+	golden := Golden{
+		Passed:    o.Passed(),
+		Failures:  o.Failures,
+		Response:  o.Response,
+		Error:     o.Error,
+		ToolCalls: []GoldenToolCall{},
+		Files:     o.Files,
+	}
+	for _, call := range o.ToolCalls {
+		golden.ToolCalls = append(golden.ToolCalls, GoldenToolCall{
+			Tool:      call.ToolName,
+			Arguments: call.Arguments,
+			Failed:    call.Error != "",
+		})
+	}
+	return golden
+}
+
+// CompareGolden compares outcome with the case's golden file and returns a
+// diff of the differences, or "" when they match.
+func (c *Case) CompareGolden(outcome *Outcome) (string, error) {
+	want, err := os.ReadFile(filepath.Join(c.Dir, goldenFile))
+	if err != nil {
+		return "", fmt.Errorf("failed to read golden file for %s: %w", c.Name, err)
+	}
+	got, err := json.MarshalIndent(outcome.Golden(), "", "  ")
+	if err != nil {
+		return "", err
+	}
+	got = append(got, '\n')
+	if string(want) == string(got) {
+		return "", nil
+	}
+	return diffLines(string(want), string(got)), nil
+}
+
+// UpdateGolden writes outcome as the case's golden file.
+func (c *Case) UpdateGolden(outcome *Outcome) error {
+	return writeJSON(filepath.Join(c.Dir, goldenFile), outcome.Golden())
+}
+
+// diffLines renders the changed lines from want to got as -/+ lines.
+func diffLines(want, got string) string {
+	dmp := diffmatchpatch.New()
+	a, b, lines := dmp.DiffLinesToChars(want, got)
+	diffs := dmp.DiffCharsToLines(dmp.DiffMain(a, b, false), lines)
+
+	var sb strings.Builder
+	for _, d := range diffs {
+		prefix := ""
+		switch d.Type {
+		case diffmatchpatch.DiffInsert:
+			prefix = "+"
+		case diffmatchpatch.DiffDelete:
+			prefix = "-"
+		default:
+			continue
+		}
+		for _, line := range strings.SplitAfter(d.Text, "\n") {
+			if line != "" {
+				sb.WriteString(prefix + strings.TrimSuffix(line, "\n") + "\n")
+			}
+		}
+	}
+	return sb.String()
+}
+
+// Baseline is the suite result prompt changes are measured against.
+type Baseline struct {
+	SuccessRate float64  `json:"success_rate"`
+	Passing     []string `json:"passing"`
+}
+
+// LoadBaseline loads the baseline in the cases directory. A missing baseline
+// is empty, so nothing can regress against it.
+func LoadBaseline(dir string) (*Baseline, error) {
+	data, err := os.ReadFile(filepath.Join(dir, baselineFile))
+	if os.IsNotExist(err) {
+		return &Baseline{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var baseline Baseline
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", baselineFile, err)
+	}
+	return &baseline, nil
+}
+
+// NewBaseline builds a baseline from per-case results.
+func NewBaseline(results map[string]bool) *Baseline {
+	baseline := &Baseline{SuccessRate: SuccessRate(results), Passing: []string{}}
+	for name, passed := range results {
+		if passed {
+			baseline.Passing = append(baseline.Passing, name)
+		}
+	}
+	sort.Strings(baseline.Passing)
+	return baseline
+}
+
+// Save writes the baseline to the cases directory.
+func (b *Baseline) Save(dir string) error {
+	return writeJSON(filepath.Join(dir, baselineFile), b)
+}
+
+// Regressions returns the baseline's passing cases that now fail. Cases that
+// no longer exist are ignored.
+func (b *Baseline) Regressions(results map[string]bool) []string {
+	var regressed []string
+	for _, name := range b.Passing {
+		if passed, ok := results[name]; ok && !passed {
+			regressed = append(regressed, name)
+		}
+	}
+	return regressed
+}
+
+// SuccessRate returns the fraction of passing results.
+func SuccessRate(results map[string]bool) float64 {
+	if len(results) == 0 {
+		return 0
+	}
+	passed := 0
+	for _, ok := range results {
+		if ok {
+			passed++
+		}
+	}
+	return float64(passed) / float64(len(results))
+}
@@ -0,0 +1,400 @@
+// Package prompttest runs prompt regression cases: agent runs recorded once
+// against a live provider and replayed offline, so a prompt change that makes
+// the agent do worse fails `go test` without any API calls.
+//
+// Each case is a directory under test_cases/ holding:
+//
+//	case.json       queries, fixture files and the expectations that define success
+//	recording.json  the run archive replayed instead of calling a provider
+//	golden.json     the normalized outcome of the replay
+//
+// test_cases/baseline.json records which cases succeeded and the suite success
+// rate; a drop below it is a regression.
+package prompttest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/alantheprice/ledit/pkg/agent"
+	api "github.com/alantheprice/ledit/pkg/agent_api"
+	"github.com/alantheprice/ledit/pkg/configuration"
+)
+
+const (
+	caseFile      = "case.json"
+	recordingFile = "recording.json"
+	goldenFile    = "golden.json"
+	baselineFile  = "baseline.json"
+
+	// workspacePlaceholder replaces the temporary workspace path in goldens.
+	workspacePlaceholder = "$WORKSPACE"
+)
+
+// ErrStaleRecording is returned when a case's recording was not made with the
+// current prompt or queries, so replaying it would not test them.
+var ErrStaleRecording = errors.New("stale recording")
+
+// Case is one prompt regression case.
+type Case struct {
+	Name string `json:"-"`
+	Dir  string `json:"-"`
+
+	Description string   `json:"description,omitempty"`
+	Queries     []string `json:"queries"`
+	// SystemPrompt is the prompt under test, as a path relative to the
+	// repository root. Empty means the embedded agent system prompt.
+	SystemPrompt string `json:"system_prompt,omitempty"`
+	// Files are the fixture files the run starts with, keyed by relative path.
+	Files  map[string]string `json:"files,omitempty"`
+	Expect Expectations      `json:"expect"`
+}
+
+// Expectations define what a successful run looks like.
+type Expectations struct {
+	ToolsCalled      []string            `json:"tools_called,omitempty"`
+	ToolsNotCalled   []string            `json:"tools_not_called,omitempty"`
+	MaxToolCalls     int                 `json:"max_tool_calls,omitempty"`
+	ResponseContains []string            `json:"response_contains,omitempty"`
+	FileContains     map[string][]string `json:"file_contains,omitempty"`
+	// NoError requires every query to complete without an error.
+	NoError bool `json:"no_error,omitempty"`
+}
+
+// Outcome is the result of running a case.
+type Outcome struct {
+	Response  string
+	Error     string
+	ToolCalls []agent.RecordedToolCall
+	// Files is the workspace content after the run, keyed by relative path.
+	Files map[string]string
+	// UnusedResponses and DivergentRequests are set by Replay and show that the
+	// replayed run no longer follows the recording.
+	UnusedResponses   int
+	DivergentRequests []int
+	// Failures lists the expectations the run did not meet.
+	Failures []string
+}
+
+// Passed reports whether the run met every expectation.
+func (o *Outcome) Passed() bool {
+	return len(o.Failures) == 0
+}
+
+// LoadCases loads every case directory in dir, sorted by name.
+func LoadCases(dir string) ([]*Case, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read test cases: %w", err)
+	}
+	var cases []*Case
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		caseDir := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(filepath.Join(caseDir, caseFile))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		c := &Case{Name: entry.Name(), Dir: caseDir}
+		if err := json.Unmarshal(data, c); err != nil {
+			return nil, fmt.Errorf("invalid %s in %s: %w", caseFile, entry.Name(), err)
+		}
+		if len(c.Queries) == 0 {
+			return nil, fmt.Errorf("case %s has no queries", c.Name)
+		}
+		cases = append(cases, c)
+	}
+	sort.Slice(cases, func(i, j int) bool { return cases[i].Name < cases[j].Name })
+	return cases, nil
+}
+
+// PromptText returns the prompt under test. repoRoot resolves SystemPrompt.
+func (c *Case) PromptText(repoRoot string) (string, error) {
+	if c.SystemPrompt == "" {
+		return agent.BaseSystemPrompt()
+	}
+	data, err := os.ReadFile(filepath.Join(repoRoot, filepath.FromSlash(c.SystemPrompt)))
+	if err != nil {
+		return "", fmt.Errorf("failed to read system prompt for %s: %w", c.Name, err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// LoadRecording loads the case's recorded run.
+func (c *Case) LoadRecording() (*agent.RunArchive, error) {
+	return agent.LoadRunArchive(filepath.Join(c.Dir, recordingFile))
+}
+
+// SaveRecording writes archive as the case's recording.
+func (c *Case) SaveRecording(archive *agent.RunArchive) error {
+	return writeJSON(filepath.Join(c.Dir, recordingFile), archive)
+}
+
+// CheckRecording returns ErrStaleRecording when archive was recorded for
+// different queries or without prompt in its system message.
+func (c *Case) CheckRecording(archive *agent.RunArchive, prompt string) error {
+	if strings.Join(archive.Queries, "\n") != strings.Join(c.Queries, "\n") {
+		return fmt.Errorf("%w: the case's queries changed since it was recorded", ErrStaleRecording)
+	}
+	if len(archive.Exchanges) == 0 {
+		return fmt.Errorf("%w: no LLM exchanges recorded", ErrStaleRecording)
+	}
+	for _, msg := range archive.Exchanges[0].Messages {
+		if msg.Role == "system" {
+			if strings.Contains(msg.Content, strings.TrimSpace(prompt)) {
+				return nil
+			}
+			break
+		}
+	}
+	return fmt.Errorf("%w: the system prompt changed since the case was recorded", ErrStaleRecording)
+}
+
+// Replay runs the case against its recording, without calling a provider.
+func (c *Case) Replay(archive *agent.RunArchive) (*Outcome, error) {
+	var outcome *Outcome
+	err := c.inWorkspace(func(workspace string) error {
+		chatAgent, replayClient, err := agent.NewReplayAgent(archive)
+		if err != nil {
+			return fmt.Errorf("failed to create replay agent: %w", err)
+		}
+		defer chatAgent.Shutdown()
+
+		outcome, err = c.run(chatAgent, workspace)
+		if err != nil {
+			return err
+		}
+		outcome.UnusedResponses = replayClient.Remaining()
+		outcome.DivergentRequests = replayClient.DivergentRequests()
+		return nil
+	})
+	return outcome, err
+}
+
+// Record runs the case with prompt as the system prompt and returns the new
+// recording. A nil client uses the configured live provider.
+func (c *Case) Record(prompt string, client api.ClientInterface) (*agent.RunArchive, *Outcome, error) {
+	var (
+		archive agent.RunArchive
+		outcome *Outcome
+	)
+	err := c.inWorkspace(func(workspace string) error {
+		var (
+			chatAgent *agent.Agent
+			err       error
+		)
+		if client != nil {
+			chatAgent, err = agent.NewAgentWithClient(client)
+		} else {
+			chatAgent, err = agent.NewAgent()
+		}
+		if err != nil {
+			return fmt.Errorf("failed to create agent: %w", err)
+		}
+		defer chatAgent.Shutdown()
+
+		if c.SystemPrompt != "" {
+			chatAgent.SetBaseSystemPrompt(prompt)
+			chatAgent.SetSystemPrompt(prompt)
+		}
+		recorder, err := agent.NewRunRecorder(os.TempDir(), chatAgent.GetProvider(), chatAgent.GetModel())
+		if err != nil {
+			return err
+		}
+		chatAgent.EnableRunRecording(recorder)
+		if outcome, err = c.run(chatAgent, workspace); err != nil {
+			return err
+		}
+		archive = recorder.Archive()
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &archive, outcome, nil
+}
+
+// run processes the case's queries with chatAgent, recording its tool calls.
+func (c *Case) run(chatAgent *agent.Agent, workspace string) (*Outcome, error) {
+	if err := chatAgent.GetConfigManager().UpdateConfigNoSave(func(cfg *configuration.Config) error {
+		cfg.SkipPrompt = true
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	recorder := chatAgent.GetRunRecorder()
+	if recorder == nil {
+		var err error
+		if recorder, err = agent.NewRunRecorder(os.TempDir(), chatAgent.GetProvider(), chatAgent.GetModel()); err != nil {
+			return nil, err
+		}
+		chatAgent.EnableRunRecording(recorder)
+	}
+
+	outcome := &Outcome{}
+	for i, query := range c.Queries {
+		response, err := chatAgent.ProcessQuery(query)
+		outcome.Response = response
+		if err != nil {
+			outcome.Error = fmt.Sprintf("query %d: %v", i+1, err)
+			break
+		}
+	}
+	outcome.ToolCalls = recorder.Archive().ToolCalls
+
+	files, err := snapshotFiles(workspace)
+	if err != nil {
+		return nil, err
+	}
+	outcome.Files = files
+	outcome.normalize(workspace)
+	outcome.Failures = c.Expect.Check(outcome)
+	return outcome, nil
+}
+
+// inWorkspace runs fn in a temporary workspace holding the case's fixtures.
+// The agent and its tools resolve paths against the current directory, so
+// cases must not run in parallel.
+func (c *Case) inWorkspace(fn func(workspace string) error) error {
+	workspace, err := os.MkdirTemp("", "ledit-prompttest-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(workspace)
+	if resolved, err := filepath.EvalSymlinks(workspace); err == nil {
+		workspace = resolved
+	}
+
+	for rel, content := range c.Files {
+		path := filepath.Join(workspace, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			return err
+		}
+	}
+
+	previous, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	if err := os.Chdir(workspace); err != nil {
+		return err
+	}
+	defer os.Chdir(previous)
+	return fn(workspace)
+}
+
+// snapshotFiles returns the content of every file in the workspace, skipping
+// hidden directories such as .ledit.
+func snapshotFiles(workspace string) (map[string]string, error) {
+	files := make(map[string]string)
+	err := filepath.WalkDir(workspace, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != workspace && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(workspace, path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = string(data)
+		return nil
+	})
+	return files, err
+}
+
+// normalize replaces the temporary workspace path so outcomes are comparable
+// across runs.
+func (o *Outcome) normalize(workspace string) {
+	replace := func(s string) string {
+		return strings.ReplaceAll(s, workspace, workspacePlaceholder)
+	}
+	o.Response = replace(o.Response)
+	o.Error = replace(o.Error)
+	for i := range o.ToolCalls {
+		o.ToolCalls[i].Arguments = replace(o.ToolCalls[i].Arguments)
+		o.ToolCalls[i].Result = replace(o.ToolCalls[i].Result)
+		o.ToolCalls[i].Error = replace(o.ToolCalls[i].Error)
+	}
+	for path, content := range o.Files {
+		o.Files[path] = replace(content)
+	}
+}
+
+// Check returns a description of each expectation outcome does not meet.
+func (e Expectations) Check(outcome *Outcome) []string {
+	var failures []string
+	called := make(map[string]bool)
+	for _, call := range outcome.ToolCalls {
+		called[call.ToolName] = true
+	}
+	for _, tool := range e.ToolsCalled {
+		if !called[tool] {
+			failures = append(failures, fmt.Sprintf("expected a %s call", tool))
+		}
+	}
+	for _, tool := range e.ToolsNotCalled {
+		if called[tool] {
+			failures = append(failures, fmt.Sprintf("unexpected %s call", tool))
+		}
+	}
+	if e.MaxToolCalls > 0 && len(outcome.ToolCalls) > e.MaxToolCalls {
+		failures = append(failures, fmt.Sprintf("made %d tool calls, expected at most %d", len(outcome.ToolCalls), e.MaxToolCalls))
+	}
+	response := strings.ToLower(outcome.Response)
+	for _, want := range e.ResponseContains {
+		if !strings.Contains(response, strings.ToLower(want)) {
+			failures = append(failures, fmt.Sprintf("response does not mention %q", want))
+		}
+	}
+	paths := make([]string, 0, len(e.FileContains))
+	for path := range e.FileContains {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		content, ok := outcome.Files[path]
+		if !ok {
+			failures = append(failures, fmt.Sprintf("%s does not exist", path))
+			continue
+		}
+		for _, want := range e.FileContains[path] {
+			if !strings.Contains(content, want) {
+				failures = append(failures, fmt.Sprintf("%s does not contain %q", path, want))
+			}
+		}
+	}
+	if e.NoError && outcome.Error != "" {
+		failures = append(failures, "run failed: "+outcome.Error)
+	}
+	return failures
+}
+
+func writeJSON(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
@@ -0,0 +1,103 @@
+package prompttest
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/alantheprice/ledit/pkg/agent"
+	api "github.com/alantheprice/ledit/pkg/agent_api"
+)
+
+func TestExpectationsCheck(t *testing.T) {
+	outcome := &Outcome{
+		Response: "Updated the Greeting in notes.txt.",
+		ToolCalls: []agent.RecordedToolCall{
+			{ToolName: "read_file"},
+			{ToolName: "write_file"},
+		},
+		Files: map[string]string{"notes.txt": "Hello, world!\n"},
+	}
+
+	pass := Expectations{
+		ToolsCalled:      []string{"read_file", "write_file"},
+		ToolsNotCalled:   []string{"shell_command"},
+		MaxToolCalls:     2,
+		ResponseContains: []string{"greeting"},
+		FileContains:     map[string][]string{"notes.txt": {"Hello"}},
+		NoError:          true,
+	}
+	if failures := pass.Check(outcome); len(failures) != 0 {
+		t.Fatalf("expected no failures, got %v", failures)
+	}
+
+	fail := Expectations{
+		ToolsCalled:      []string{"edit_file"},
+		ToolsNotCalled:   []string{"write_file"},
+		MaxToolCalls:     1,
+		ResponseContains: []string{"tests pass"},
+		FileContains:     map[string][]string{"notes.txt": {"Goodbye"}, "missing.txt": {"x"}},
+	}
+	failures := fail.Check(outcome)
+	want := []string{
+		"expected a edit_file call",
+		"unexpected write_file call",
+		"made 2 tool calls, expected at most 1",
+		`response does not mention "tests pass"`,
+		"missing.txt does not exist",
+		`notes.txt does not contain "Goodbye"`,
+	}
+	if strings.Join(failures, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected failures:\n%s", strings.Join(failures, "\n"))
+	}
+}
+
+func TestCheckRecordingDetectsStalePrompt(t *testing.T) {
+	c := &Case{Name: "example", Queries: []string{"Do the thing"}}
+	archive := &agent.RunArchive{
+		Queries: []string{"Do the thing"},
+		Exchanges: []agent.RecordedExchange{{
+			Messages: []api.Message{
+				{Role: "system", Content: "You are a careful agent.\n\n## Current Date and Time\n..."},
+				{Role: "user", Content: "Do the thing"},
+			},
+		}},
+	}
+
+	if err := c.CheckRecording(archive, "You are a careful agent."); err != nil {
+		t.Fatalf("expected a current recording, got %v", err)
+	}
+	if err := c.CheckRecording(archive, "You are a reckless agent."); !errors.Is(err, ErrStaleRecording) {
+		t.Fatalf("expected ErrStaleRecording for a changed prompt, got %v", err)
+	}
+	c.Queries = []string{"Do another thing"}
+	if err := c.CheckRecording(archive, "You are a careful agent."); !errors.Is(err, ErrStaleRecording) {
+		t.Fatalf("expected ErrStaleRecording for changed queries, got %v", err)
+	}
+}
+
+func TestBaselineRegressions(t *testing.T) {
+	baseline := NewBaseline(map[string]bool{"a": true, "b": true, "c": false})
+	if got := strings.Join(baseline.Passing, ","); got != "a,b" {
+		t.Fatalf("passing = %s", got)
+	}
+
+	results := map[string]bool{"a": true, "b": false, "c": true}
+	if regressed := baseline.Regressions(results); strings.Join(regressed, ",") != "b" {
+		t.Fatalf("regressions = %v", regressed)
+	}
+	if rate := SuccessRate(results); rate < 0.66 || rate > 0.67 {
+		t.Fatalf("success rate = %v", rate)
+	}
+	delete(results, "b")
+	if regressed := baseline.Regressions(results); len(regressed) != 0 {
+		t.Fatalf("removed cases should not regress, got %v", regressed)
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	diff := diffLines("a\nb\nc\n", "a\nB\nc\n")
+	if diff != "-b\n+B\n" {
+		t.Fatalf("unexpected diff:\n%s", diff)
+	}
+}
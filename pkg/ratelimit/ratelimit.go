@@ -0,0 +1,240 @@
+// Package ratelimit keeps ledit's LLM requests to a provider under its
+// requests-per-minute and tokens-per-minute limits. The limiter's state is a
+// file guarded by a file lock, so the main agent and the subagent processes
+// it starts share one budget per provider.
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofrs/flock"
+)
+
+// window is the span the limits apply to.
+const window = time.Minute
+
+// maxJitter bounds the random delay added to each wait, so processes
+// waiting on the same slot do not all retry at once.
+const maxJitter = 500 * time.Millisecond
+
+// Limits are a provider's per-minute limits. Zero means unlimited.
+type Limits struct {
+	RequestsPerMinute int
+	TokensPerMinute   int
+}
+
+// Enabled reports whether any limit is set.
+func (l Limits) Enabled() bool {
+	return l.RequestsPerMinute > 0 || l.TokensPerMinute > 0
+}
+
+// entry is one request in the window.
+type entry struct {
+	ID     string    `json:"id"`
+	At     time.Time `json:"at"`
+	Tokens int       `json:"tokens"`
+}
+
+// state is the shared limiter file.
+type state struct {
+	Entries []entry `json:"entries"`
+	// PausedUntil holds every request back after the provider rejected one
+	// for exceeding its rate limit.
+	PausedUntil time.Time `json:"paused_until,omitempty"`
+}
+
+// Limiter is the shared limiter for one provider.
+type Limiter struct {
+	provider string
+	limits   Limits
+	path     string
+	// mu serializes goroutines; lock serializes processes.
+	mu   sync.Mutex
+	lock *flock.Flock
+
+	now   func() time.Time
+	sleep func(context.Context, time.Duration) error
+}
+
+var reservationSeq atomic.Int64
+
+// New returns the limiter for provider, keeping its state in dir.
+func New(dir, provider string, limits Limits) *Limiter {
+	path := filepath.Join(dir, sanitizeProvider(provider)+".json")
+	return &Limiter{
+		provider: provider,
+		limits:   limits,
+		path:     path,
+		lock:     flock.New(path + ".lock"),
+		now:      time.Now,
+		sleep:    sleepContext,
+	}
+}
+
+// Reservation is a request admitted by the limiter.
+type Reservation struct {
+	limiter *Limiter
+	id      string
+}
+
+// Wait blocks until a request of about tokens tokens fits within the
+// limits, then records it. onWait, if set, is called with the delay and the
+// limit being waited on before each wait. It returns early with the
+// context's error.
+func (l *Limiter) Wait(ctx context.Context, tokens int, onWait func(time.Duration, string)) (*Reservation, error) {
+	id := fmt.Sprintf("%d-%d", os.Getpid(), reservationSeq.Add(1))
+	for {
+		delay, reason, err := l.reserve(id, tokens)
+		if err != nil {
+			return nil, err
+		}
+		if delay <= 0 {
+			return &Reservation{limiter: l, id: id}, nil
+		}
+		delay += time.Duration(rand.Int63n(int64(maxJitter)))
+		if onWait != nil {
+			onWait(delay, reason)
+		}
+		if err := l.sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Settle replaces the reservation's estimate with the tokens the request
+// actually used.
+func (r *Reservation) Settle(tokens int) error {
+	if r == nil {
+		return nil
+	}
+	return r.limiter.update(func(s *state) {
+		for i := range s.Entries {
+			if s.Entries[i].ID == r.id {
+				s.Entries[i].Tokens = tokens
+				return
+			}
+		}
+	})
+}
+
+// Pause holds back every request to the provider, from any process, for d.
+// It is used when the provider rejects a request for exceeding its limits.
+func (l *Limiter) Pause(d time.Duration) error {
+	until := l.now().Add(d)
+	return l.update(func(s *state) {
+		if until.After(s.PausedUntil) {
+			s.PausedUntil = until
+		}
+	})
+}
+
+// reserve records the request if it fits now. Otherwise it returns how long
+// to wait before trying again and which limit is full.
+func (l *Limiter) reserve(id string, tokens int) (time.Duration, string, error) {
+	var delay time.Duration
+	var reason string
+	err := l.update(func(s *state) {
+		now := l.now()
+		if now.Before(s.PausedUntil) {
+			delay, reason = s.PausedUntil.Sub(now), fmt.Sprintf("%s rate limited the last request", l.provider)
+			return
+		}
+		if rpm := l.limits.RequestsPerMinute; rpm > 0 && len(s.Entries) >= rpm {
+			// The oldest request that has to leave the window to make room.
+			oldest := s.Entries[len(s.Entries)-rpm]
+			delay, reason = oldest.At.Add(window).Sub(now), fmt.Sprintf("%d requests/min", rpm)
+			return
+		}
+		if tpm := l.limits.TokensPerMinute; tpm > 0 {
+			used := 0
+			for _, e := range s.Entries {
+				used += e.Tokens
+			}
+			// A request larger than the whole budget is let through once the
+			// window is empty rather than waiting forever.
+			for i := 0; used+tokens > tpm && used > 0; i++ {
+				used -= s.Entries[i].Tokens
+				delay = s.Entries[i].At.Add(window).Sub(now)
+			}
+			if delay > 0 {
+				reason = fmt.Sprintf("%d tokens/min", tpm)
+				return
+			}
+		}
+		s.Entries = append(s.Entries, entry{ID: id, At: now, Tokens: tokens})
+	})
+	return delay, reason, err
+}
+
+// update applies fn to the state under the file lock, after dropping
+// requests that have left the window.
+func (l *Limiter) update(fn func(*state)) error {
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create rate limit directory: %w", err)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.lock.Lock(); err != nil {
+		return fmt.Errorf("failed to lock %s: %w", l.path, err)
+	}
+	defer l.lock.Unlock()
+
+	var s state
+	data, err := os.ReadFile(l.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read %s: %w", l.path, err)
+	}
+	if len(data) > 0 && json.Unmarshal(data, &s) != nil {
+		// A damaged file only loses the last minute of history.
+		s = state{}
+	}
+
+	cutoff := l.now().Add(-window)
+	kept := s.Entries[:0]
+	for _, e := range s.Entries {
+		if e.At.After(cutoff) {
+			kept = append(kept, e)
+		}
+	}
+	s.Entries = kept
+	fn(&s)
+
+	data, err = json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(l.path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", l.path, err)
+	}
+	return nil
+}
+
+func sanitizeProvider(provider string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.' {
+			return r
+		}
+		return '_'
+	}, provider)
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,135 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeClock drives limiters whose waits advance time instead of sleeping.
+type fakeClock struct {
+	now   time.Time
+	waits []time.Duration
+}
+
+func (c *fakeClock) limiter(dir string, limits Limits) *Limiter {
+	l := New(dir, "openai", limits)
+	l.now = func() time.Time { return c.now }
+	l.sleep = func(_ context.Context, d time.Duration) error {
+		c.waits = append(c.waits, d)
+		c.now = c.now.Add(d)
+		return nil
+	}
+	return l
+}
+
+func TestWaitRequestsPerMinute(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	dir := t.TempDir()
+	// Two limiters on one directory stand in for two processes.
+	main := clock.limiter(dir, Limits{RequestsPerMinute: 2})
+	subagent := clock.limiter(dir, Limits{RequestsPerMinute: 2})
+
+	for _, l := range []*Limiter{main, subagent} {
+		if _, err := l.Wait(context.Background(), 100, nil); err != nil {
+			t.Fatal(err)
+		}
+		clock.now = clock.now.Add(10 * time.Second)
+	}
+	if len(clock.waits) != 0 {
+		t.Fatalf("expected no waits within the limit, got %v", clock.waits)
+	}
+
+	var reasons []string
+	if _, err := main.Wait(context.Background(), 100, func(_ time.Duration, reason string) {
+		reasons = append(reasons, reason)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	// The first request leaves the window 40s after now, plus jitter.
+	if len(clock.waits) != 1 || clock.waits[0] < 40*time.Second || clock.waits[0] >= 40*time.Second+maxJitter {
+		t.Errorf("expected one wait of about 40s, got %v", clock.waits)
+	}
+	if len(reasons) != 1 || reasons[0] != "2 requests/min" {
+		t.Errorf("unexpected wait reasons %v", reasons)
+	}
+}
+
+func TestWaitTokensPerMinute(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	l := clock.limiter(t.TempDir(), Limits{TokensPerMinute: 1000})
+
+	first, err := l.Wait(context.Background(), 600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock.now = clock.now.Add(5 * time.Second)
+	// The request used fewer tokens than estimated, which frees budget.
+	if err := first.Settle(300); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Wait(context.Background(), 600, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(clock.waits) != 0 {
+		t.Fatalf("expected the settled request to leave room, got waits %v", clock.waits)
+	}
+
+	clock.now = clock.now.Add(5 * time.Second)
+	if _, err := l.Wait(context.Background(), 400, nil); err != nil {
+		t.Fatal(err)
+	}
+	// 900 tokens are in the window; the first request expires 50s from now.
+	if len(clock.waits) != 1 || clock.waits[0] < 50*time.Second || clock.waits[0] >= 50*time.Second+maxJitter {
+		t.Errorf("expected one wait of about 50s, got %v", clock.waits)
+	}
+}
+
+func TestWaitOversizedRequestRunsAlone(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	l := clock.limiter(t.TempDir(), Limits{TokensPerMinute: 1000})
+	if _, err := l.Wait(context.Background(), 5000, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(clock.waits) != 0 {
+		t.Fatalf("an oversized request should not wait on an empty window, got %v", clock.waits)
+	}
+	if _, err := l.Wait(context.Background(), 10, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(clock.waits) != 1 {
+		t.Errorf("expected the next request to wait for the window, got %v", clock.waits)
+	}
+}
+
+func TestPauseAppliesToAllProcesses(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	dir := t.TempDir()
+	main := clock.limiter(dir, Limits{RequestsPerMinute: 100})
+	subagent := clock.limiter(dir, Limits{RequestsPerMinute: 100})
+
+	if err := main.Pause(20 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	var reason string
+	if _, err := subagent.Wait(context.Background(), 10, func(_ time.Duration, r string) { reason = r }); err != nil {
+		t.Fatal(err)
+	}
+	if len(clock.waits) != 1 || clock.waits[0] < 20*time.Second || !strings.Contains(reason, "openai") {
+		t.Errorf("expected a 20s pause for openai, got %v (%q)", clock.waits, reason)
+	}
+}
+
+func TestWaitCanceled(t *testing.T) {
+	l := New(t.TempDir(), "openai", Limits{RequestsPerMinute: 1})
+	if _, err := l.Wait(context.Background(), 0, nil); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := l.Wait(ctx, 0, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
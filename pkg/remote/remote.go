@@ -0,0 +1,269 @@
+// Package remote runs the agent's shell commands, builds and tests on another
+// host over SSH while the console and the file tools work on the local
+// workspace. The workspace maps to a directory on the host. Files changed
+// locally travel with each command in one tar stream, and files the command
+// changes on the host are brought back after it exits, so a command costs two
+// round trips over a shared SSH connection however many files changed.
+package remote
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultExclude are the directory names never synced when Config.Exclude is
+// empty. The host keeps its own .git, so git commands see its checkout.
+var DefaultExclude = []string{".git", ".ledit", "node_modules", ".venv", "__pycache__", ".cache"}
+
+// compressAbove is the round-trip time above which transfers are compressed:
+// on a slow link compression costs less than it saves.
+const compressAbove = 20 * time.Millisecond
+
+// Config describes the host and how local paths map onto it.
+type Config struct {
+	// Host is [user@]host or a Host alias from ~/.ssh/config.
+	Host         string
+	Port         int
+	IdentityFile string
+	// Paths maps local directories to directories on the host; the longest
+	// matching prefix maps the workspace. Remote paths may start with ~/.
+	// Empty maps the local home directory to the remote one.
+	Paths map[string]string
+	// Exclude lists directory names that are never synced.
+	Exclude []string
+	// ControlDir holds the sockets of shared SSH connections. Empty opens a
+	// connection per command.
+	ControlDir string
+}
+
+// ErrNotMapped is returned for a workspace outside every mapped directory.
+var ErrNotMapped = errors.New("workspace is not under a directory mapped to the remote host")
+
+// lookPath is replaced in tests.
+var lookPath = exec.LookPath
+
+// Host runs commands for one workspace on a remote host.
+type Host struct {
+	config     Config
+	ssh        string
+	localRoot  string
+	remoteRoot string // may start with ~/ until connected
+	exclude    map[string]bool
+
+	mu        sync.Mutex
+	connected bool
+	rtt       time.Duration
+	synced    map[string]fileState // by slash-separated relative path
+	markers   int
+}
+
+// New returns a host running commands for the workspace at localRoot. It
+// fails when ssh is not installed or the workspace is not mapped.
+func New(cfg Config, localRoot string) (*Host, error) {
+	cfg.Host = strings.TrimSpace(cfg.Host)
+	if cfg.Host == "" {
+		return nil, errors.New("remote host is not set")
+	}
+	ssh, err := lookPath("ssh")
+	if err != nil {
+		return nil, fmt.Errorf("remote execution needs the ssh client: %w", err)
+	}
+	localRoot, err = filepath.Abs(localRoot)
+	if err != nil {
+		return nil, err
+	}
+	remoteRoot, err := mapPath(cfg.Paths, localRoot)
+	if err != nil {
+		return nil, err
+	}
+	if len(cfg.Exclude) == 0 {
+		cfg.Exclude = DefaultExclude
+	}
+	exclude := make(map[string]bool, len(cfg.Exclude))
+	for _, name := range cfg.Exclude {
+		exclude[name] = true
+	}
+	return &Host{
+		config:     cfg,
+		ssh:        ssh,
+		localRoot:  localRoot,
+		remoteRoot: remoteRoot,
+		exclude:    exclude,
+		synced:     make(map[string]fileState),
+	}, nil
+}
+
+// mapPath returns the remote directory localRoot maps to.
+func mapPath(paths map[string]string, localRoot string) (string, error) {
+	home, _ := os.UserHomeDir()
+	if len(paths) == 0 {
+		paths = map[string]string{home: "~"}
+	}
+	prefixes := make([]string, 0, len(paths))
+	for prefix := range paths {
+		prefixes = append(prefixes, prefix)
+	}
+	// Longest first, so the most specific mapping wins.
+	sort.Slice(prefixes, func(i, j int) bool { return len(prefixes[i]) > len(prefixes[j]) })
+	for _, prefix := range prefixes {
+		local := prefix
+		if home != "" && (local == "~" || strings.HasPrefix(local, "~/")) {
+			local = filepath.Join(home, strings.TrimPrefix(local, "~"))
+		}
+		rel, err := filepath.Rel(filepath.Clean(local), localRoot)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		return path.Join(paths[prefix], filepath.ToSlash(rel)), nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrNotMapped, localRoot)
+}
+
+// Describe summarizes the host for status messages.
+func (h *Host) Describe() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return fmt.Sprintf("%s:%s", h.config.Host, h.remoteRoot)
+}
+
+// RemoteRoot returns the directory the workspace maps to on the host.
+func (h *Host) RemoteRoot() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.remoteRoot
+}
+
+// RemotePath returns the host path of local, a path in the workspace.
+func (h *Host) RemotePath(local string) (string, error) {
+	rel, err := filepath.Rel(h.localRoot, local)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside the workspace %s", local, h.localRoot)
+	}
+	return path.Join(h.RemoteRoot(), filepath.ToSlash(rel)), nil
+}
+
+// MapOutput rewrites host paths in command output to local ones, so compiler
+// errors and stack traces point at files the local tools can open.
+func (h *Host) MapOutput(output string) string {
+	root := h.RemoteRoot()
+	if strings.HasPrefix(root, "~") || root == h.localRoot {
+		return output
+	}
+	return strings.ReplaceAll(output, root, h.localRoot)
+}
+
+// sshArgs returns the arguments running remoteCommand on the host.
+func (h *Host) sshArgs(remoteCommand string) []string {
+	args := []string{"-T", "-o", "BatchMode=yes"}
+	if h.config.ControlDir != "" && runtime.GOOS != "windows" {
+		args = append(args,
+			"-o", "ControlMaster=auto",
+			"-o", "ControlPath="+filepath.Join(h.config.ControlDir, "%C"),
+			"-o", "ControlPersist=10m")
+	}
+	if h.config.Port > 0 {
+		args = append(args, "-p", fmt.Sprint(h.config.Port))
+	}
+	if h.config.IdentityFile != "" {
+		args = append(args, "-i", h.config.IdentityFile)
+	}
+	if h.rtt > compressAbove {
+		args = append(args, "-C")
+	}
+	return append(args, "--", h.config.Host, "sh -c "+shellQuote(remoteCommand))
+}
+
+func (h *Host) command(ctx context.Context, remoteCommand string) *exec.Cmd {
+	h.mu.Lock()
+	args := h.sshArgs(remoteCommand)
+	h.mu.Unlock()
+	cmd := exec.CommandContext(ctx, h.ssh, args...)
+	cmd.Dir = h.localRoot
+	return cmd
+}
+
+// Connect opens the shared connection, measures the round-trip time and
+// resolves the remote directory to an absolute path. Commands connect on
+// first use; calling it again returns the first measurement.
+func (h *Host) Connect(ctx context.Context) (time.Duration, error) {
+	h.mu.Lock()
+	if h.connected {
+		defer h.mu.Unlock()
+		return h.rtt, nil
+	}
+	h.mu.Unlock()
+	if h.config.ControlDir != "" {
+		if err := os.MkdirAll(h.config.ControlDir, 0700); err != nil {
+			return 0, err
+		}
+	}
+
+	// The first call pays for the SSH handshake; the second one rides the
+	// shared connection and is what each command will cost.
+	if _, err := h.run(ctx, "true"); err != nil {
+		return 0, fmt.Errorf("failed to connect to %s: %w", h.config.Host, err)
+	}
+	start := time.Now()
+	home, err := h.run(ctx, "pwd")
+	if err != nil {
+		return 0, fmt.Errorf("failed to connect to %s: %w", h.config.Host, err)
+	}
+	rtt := time.Since(start)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.rtt = rtt
+	if h.remoteRoot == "~" || strings.HasPrefix(h.remoteRoot, "~/") {
+		h.remoteRoot = path.Join(strings.TrimSpace(home), strings.TrimPrefix(h.remoteRoot, "~"))
+	}
+	h.connected = true
+	return rtt, nil
+}
+
+// run runs remoteCommand on the host and returns its stdout.
+func (h *Host) run(ctx context.Context, remoteCommand string) (string, error) {
+	cmd := h.command(ctx, remoteCommand)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil && stderr.Len() > 0 {
+		err = fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), err
+}
+
+// Compressed reports whether transfers are compressed, which Connect decides
+// from the round-trip time.
+func (h *Host) Compressed() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.rtt > compressAbove
+}
+
+// Close ends the shared connection.
+func (h *Host) Close() {
+	if h.config.ControlDir == "" || runtime.GOOS == "windows" {
+		return
+	}
+	args := []string{"-o", "ControlPath=" + filepath.Join(h.config.ControlDir, "%C"), "-O", "exit"}
+	if h.config.Port > 0 {
+		args = append(args, "-p", fmt.Sprint(h.config.Port))
+	}
+	exec.Command(h.ssh, append(args, h.config.Host)...).Run()
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,158 @@
+package remote
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// fakeSSH installs an ssh that runs the remote command locally, so the
+// "remote" directory is just another local one.
+func fakeSSH(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a POSIX shell")
+	}
+	script := filepath.Join(t.TempDir(), "ssh")
+	err := os.WriteFile(script, []byte(`#!/bin/sh
+while [ $# -gt 0 ]; do
+	case "$1" in
+		--) shift; break ;;
+		-o|-p|-i) shift 2 ;;
+		*) shift ;;
+	esac
+done
+shift
+cd "$HOME" && exec sh -c "$1"
+`), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := lookPath
+	t.Cleanup(func() { lookPath = previous })
+	lookPath = func(string) (string, error) { return script, nil }
+}
+
+func runOn(t *testing.T, h *Host, dir, command string) string {
+	t.Helper()
+	cmd, finish, err := h.Command(context.Background(), dir, command)
+	if err != nil {
+		t.Fatalf("Command: %v", err)
+	}
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("%s: %v\n%s", command, err, out)
+	}
+	output, err := finish(string(out))
+	if err != nil {
+		t.Fatalf("finish: %v", err)
+	}
+	return output
+}
+
+func TestMapPath(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+	paths := map[string]string{"/work": "/data/work", "/work/special": "~/special"}
+	for local, want := range map[string]string{
+		"/work/app":            "/data/work/app",
+		"/work":                "/data/work",
+		"/work/special/nested": "~/special/nested",
+	} {
+		if got, err := mapPath(paths, local); err != nil || got != want {
+			t.Errorf("mapPath(%s) = %q, %v; want %s", local, got, err, want)
+		}
+	}
+	if _, err := mapPath(paths, "/workshop"); err == nil {
+		t.Error("expected a sibling with a shared name prefix not to be mapped")
+	}
+	if got, err := mapPath(nil, filepath.Join(home, "src", "app")); err != nil || got != "~/src/app" {
+		t.Errorf("expected the home directory to map by default, got %q, %v", got, err)
+	}
+}
+
+func TestCommandSyncsWorkspace(t *testing.T) {
+	fakeSSH(t)
+	local, remoteHome := t.TempDir(), t.TempDir()
+	t.Setenv("HOME", remoteHome)
+	writeFile := func(root, rel, content string) {
+		t.Helper()
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile(local, "main.go", "package main\n")
+	writeFile(local, "pkg/util.go", "package pkg\n")
+	writeFile(local, "stale.txt", "remove me\n")
+	writeFile(local, ".git/HEAD", "ref: refs/heads/main\n")
+
+	h, err := New(Config{Host: "dev@box", Paths: map[string]string{local: "~/proj"}}, local)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	remoteRoot := filepath.Join(remoteHome, "proj")
+
+	out := runOn(t, h, filepath.Join(local, "pkg"), "pwd; cat util.go; echo generated > ../gen.txt; rm ../stale.txt")
+	if h.RemoteRoot() != remoteRoot {
+		t.Fatalf("expected ~ to resolve to the remote home, got %s", h.RemoteRoot())
+	}
+	if first, _, _ := strings.Cut(out, "\n"); first != filepath.Join(local, "pkg") {
+		t.Fatalf("expected the remote directory in the output to map to the local one, got %q", out)
+	}
+	if !strings.Contains(out, "package pkg") || !strings.Contains(out, "[remote] Ran on dev@box:"+remoteRoot+"; sent 3 changed file(s), brought back 1 file(s) it changed, deleted 1 file(s) it removed.") {
+		t.Fatalf("unexpected output %q", out)
+	}
+	if _, err := os.Stat(filepath.Join(remoteRoot, ".git")); !os.IsNotExist(err) {
+		t.Fatal("expected .git not to be synced")
+	}
+	if data, err := os.ReadFile(filepath.Join(local, "gen.txt")); err != nil || string(data) != "generated\n" {
+		t.Fatalf("expected the generated file to come back, got %q, %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(local, "stale.txt")); !os.IsNotExist(err) {
+		t.Fatal("expected the file deleted on the host to be deleted locally")
+	}
+
+	// Only local changes since the last command travel, and local deletions
+	// reach the host.
+	writeFile(local, "main.go", "package main\n\nfunc main() {}\n")
+	os.Remove(filepath.Join(local, "gen.txt"))
+	out = runOn(t, h, local, "cat main.go; ls")
+	if !strings.Contains(out, "func main() {}") || strings.Contains(out, "gen.txt\n") {
+		t.Fatalf("unexpected output %q", out)
+	}
+	if !strings.Contains(out, "sent 1 changed file(s), removed 1 file(s) deleted locally.") {
+		t.Fatalf("expected only the changes to be sent, got %q", out)
+	}
+	if out := runOn(t, h, local, "true"); strings.Contains(out, "[remote]") {
+		t.Fatalf("expected nothing to move, got %q", out)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(remoteRoot, ".ledit")); len(entries) != 0 {
+		t.Fatalf("expected the sync markers to be cleaned up, got %v", entries)
+	}
+}
+
+func TestNewRequiresSSHAndMapping(t *testing.T) {
+	previous := lookPath
+	t.Cleanup(func() { lookPath = previous })
+	lookPath = func(string) (string, error) { return "", exec.ErrNotFound }
+	if _, err := New(Config{Host: "box"}, t.TempDir()); err == nil || !strings.Contains(err.Error(), "ssh client") {
+		t.Fatalf("expected a missing ssh client error, got %v", err)
+	}
+	lookPath = func(string) (string, error) { return "/usr/bin/ssh", nil }
+	if _, err := New(Config{Host: "box", Paths: map[string]string{"/elsewhere": "/srv"}}, t.TempDir()); err == nil {
+		t.Fatal("expected an unmapped workspace to be rejected")
+	}
+	if _, err := New(Config{}, t.TempDir()); err == nil {
+		t.Fatal("expected a missing host to be rejected")
+	}
+}
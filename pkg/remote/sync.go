@@ -0,0 +1,456 @@
+package remote
+
+import (
+	"archive/tar"
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// fileState is what a synced file looked like locally when it was synced.
+type fileState struct {
+	size    int64
+	modTime time.Time
+	mode    fs.FileMode
+	link    string
+}
+
+func stateOf(info fs.FileInfo, link string) fileState {
+	return fileState{size: info.Size(), modTime: info.ModTime(), mode: info.Mode(), link: link}
+}
+
+// SyncStats counts the files moved by one command.
+type SyncStats struct {
+	Sent     int
+	Removed  int // deleted on the host because they were deleted locally
+	Received int
+	Deleted  int // deleted locally because the command deleted them
+}
+
+// run is a command on the host with the files it moves.
+type run struct {
+	cmd    *exec.Cmd
+	marker string
+	stats  SyncStats
+}
+
+// Command returns a command running shellCommand on the host in dir, a
+// directory of the local workspace. Files changed in the workspace since the
+// last command are sent ahead of it in the same round trip. The caller runs
+// the command and passes its output to finish, which brings back the files
+// it changed and returns the output with host paths mapped to local ones.
+func (h *Host) Command(ctx context.Context, dir, shellCommand string) (*exec.Cmd, func(output string) (string, error), error) {
+	r, err := h.start(ctx, dir, shellCommand)
+	if err != nil {
+		return nil, nil, err
+	}
+	finish := func(output string) (string, error) {
+		output = h.MapOutput(output)
+		err := h.finish(ctx, r)
+		if note := r.stats.describe(); note != "" {
+			output = strings.TrimRight(output, "\n") + fmt.Sprintf("\n[remote] Ran on %s; %s.", h.Describe(), note)
+		}
+		return output, err
+	}
+	return r.cmd, finish, nil
+}
+
+// describe lists the file transfers, or returns "" when there were none.
+func (s SyncStats) describe() string {
+	var parts []string
+	for _, part := range []struct {
+		n    int
+		what string
+	}{
+		{s.Sent, "sent %d changed file(s)"},
+		{s.Removed, "removed %d file(s) deleted locally"},
+		{s.Received, "brought back %d file(s) it changed"},
+		{s.Deleted, "deleted %d file(s) it removed"},
+	} {
+		if part.n > 0 {
+			parts = append(parts, fmt.Sprintf(part.what, part.n))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (h *Host) start(ctx context.Context, dir, shellCommand string) (*run, error) {
+	if _, err := h.Connect(ctx); err != nil {
+		return nil, err
+	}
+	remoteDir, err := h.RemotePath(dir)
+	if err != nil {
+		return nil, err
+	}
+	send, remove, err := h.changes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan the workspace: %w", err)
+	}
+
+	h.mu.Lock()
+	h.markers++
+	marker := fmt.Sprintf(".ledit/remote-sync-%d-%d", os.Getpid(), h.markers)
+	root := h.remoteRoot
+	h.mu.Unlock()
+
+	// Files the command writes are found by comparing them with a marker
+	// touched after the sent files are unpacked. The clock must tick past the
+	// marker's timestamp first, or files written right away would share it.
+	var script strings.Builder
+	fmt.Fprintf(&script, "mkdir -p %s && cd %s || exit 125\n", shellQuote(root), shellQuote(root))
+	if len(send) > 0 {
+		// Unpacked files get the host's current time, which stays behind the
+		// marker whatever the clock difference between the two machines.
+		script.WriteString("tar -xpmf - || { echo 'ledit: failed to unpack the workspace changes' >&2; exit 125; }\n")
+	}
+	if len(remove) > 0 {
+		script.WriteString("rm -f --")
+		for _, rel := range remove {
+			script.WriteString(" " + shellQuote(rel))
+		}
+		script.WriteString("\n")
+	}
+	fmt.Fprintf(&script, "mkdir -p .ledit && touch %[1]s %[1]s.tick || exit 125\n"+
+		"until [ -n \"$(find %[1]s.tick -newer %[1]s)\" ]; do touch %[1]s.tick; done\n"+
+		"rm -f %[1]s.tick\n", shellQuote(marker))
+	fmt.Fprintf(&script, "cd %s || exit 125\n", shellQuote(remoteDir))
+	fmt.Fprintf(&script, "sh -c %s </dev/null\n", shellQuote(shellCommand))
+
+	r := &run{cmd: h.command(ctx, script.String()), marker: marker}
+	r.stats.Sent, r.stats.Removed = len(send), len(remove)
+	if len(send) > 0 {
+		pr, pw := io.Pipe()
+		go func() {
+			pw.CloseWithError(h.writeTar(pw, send))
+		}()
+		r.cmd.Stdin = pr
+	}
+	return r, nil
+}
+
+// finish brings back the files the command changed or deleted on the host.
+// Files changed locally while it ran are left alone. If the sync can't be
+// trusted, everything is sent again with the next command.
+func (h *Host) finish(ctx context.Context, r *run) error {
+	received, deleted, err := h.pull(ctx, r.marker)
+	r.stats.Received, r.stats.Deleted = received, deleted
+	if err != nil {
+		h.mu.Lock()
+		clear(h.synced)
+		h.mu.Unlock()
+		return fmt.Errorf("failed to bring back the files changed on %s: %w", h.config.Host, err)
+	}
+	return nil
+}
+
+// changes returns the files to send to the host and the paths to delete
+// there, and records them as synced.
+func (h *Host) changes() (send, remove []string, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	seen := make(map[string]bool, len(h.synced))
+	err = h.walk(func(rel string, state fileState) {
+		seen[rel] = true
+		if previous, ok := h.synced[rel]; !ok || previous != state {
+			send = append(send, rel)
+			h.synced[rel] = state
+		}
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	for rel := range h.synced {
+		if !seen[rel] {
+			remove = append(remove, rel)
+			delete(h.synced, rel)
+		}
+	}
+	sort.Strings(remove)
+	return send, remove, nil
+}
+
+// walk calls fn for each regular file and symlink in the workspace outside
+// excluded directories.
+func (h *Host) walk(fn func(rel string, state fileState)) error {
+	return filepath.WalkDir(h.localRoot, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p != h.localRoot && errors.Is(err, fs.ErrPermission) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			if p != h.localRoot && h.exclude[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() && d.Type()&fs.ModeSymlink == 0 {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil // removed while walking
+		}
+		link := ""
+		if d.Type()&fs.ModeSymlink != 0 {
+			if link, err = os.Readlink(p); err != nil {
+				return nil
+			}
+		}
+		rel, err := filepath.Rel(h.localRoot, p)
+		if err != nil {
+			return err
+		}
+		fn(filepath.ToSlash(rel), stateOf(info, link))
+		return nil
+	})
+}
+
+// writeTar writes the workspace files at rels as a tar stream.
+func (h *Host) writeTar(w io.Writer, rels []string) error {
+	tw := tar.NewWriter(w)
+	for _, rel := range rels {
+		if err := addToTar(tw, filepath.Join(h.localRoot, filepath.FromSlash(rel)), rel); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+func addToTar(tw *tar.Writer, local, rel string) error {
+	info, err := os.Lstat(local)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil // deleted since the scan; the next command removes it
+	}
+	if err != nil {
+		return err
+	}
+	link := ""
+	if info.Mode()&fs.ModeSymlink != 0 {
+		if link, err = os.Readlink(local); err != nil {
+			return err
+		}
+	}
+	header, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return err
+	}
+	header.Name = rel
+	header.Uname, header.Gname = "", ""
+	if info.Mode()&fs.ModeSymlink != 0 {
+		return tw.WriteHeader(header)
+	}
+	f, err := os.Open(local)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	// A file that grew since Lstat is cut to the size in the header.
+	_, err = io.CopyN(tw, f, header.Size)
+	return err
+}
+
+// pull lists the files on the host, then receives the ones changed since
+// marker as a tar stream, in one round trip.
+func (h *Host) pull(ctx context.Context, marker string) (received, deleted int, err error) {
+	h.mu.Lock()
+	root := h.remoteRoot
+	excluded := make([]string, 0, len(h.exclude))
+	for name := range h.exclude {
+		excluded = append(excluded, "-name "+shellQuote(name))
+	}
+	h.mu.Unlock()
+	sort.Strings(excluded)
+	prune := ""
+	if len(excluded) > 0 {
+		prune = `-type d \( ` + strings.Join(excluded, " -o ") + ` \) -prune -o `
+	}
+
+	// Without the marker the files were never unpacked, and the listing
+	// would make every file sent look deleted.
+	script := fmt.Sprintf("cd %[1]s || exit 125\n"+
+		"[ -f %[3]s ] || { echo 'ledit: the workspace changes did not reach the host' >&2; exit 125; }\n"+
+		"find . %[2]s\\( -type f -o -type l \\) -print0 || exit 125\n"+
+		"printf '\\0'\n"+
+		"find . %[2]s\\( -type f -o -type l \\) -newer %[3]s -print0 | tar -cf - --null -T -\n"+
+		"status=$?\n"+
+		"rm -f %[3]s\n"+
+		"exit $status\n",
+		shellQuote(root), prune, shellQuote(marker))
+	cmd := h.command(ctx, script)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return 0, 0, err
+	}
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		return 0, 0, err
+	}
+	reader := bufio.NewReader(stdout)
+	present, err := readNulList(reader)
+	if err == nil {
+		received, err = h.unpack(reader)
+	}
+	// Drain what is left so the command can exit.
+	io.Copy(io.Discard, reader)
+	if waitErr := cmd.Wait(); err == nil && waitErr != nil {
+		err = fmt.Errorf("%w: %s", waitErr, strings.TrimSpace(stderr.String()))
+	}
+	if err != nil {
+		return received, 0, err
+	}
+	return received, h.removeDeleted(present), nil
+}
+
+// readNulList reads NUL-terminated paths up to an empty one.
+func readNulList(r *bufio.Reader) (map[string]bool, error) {
+	paths := make(map[string]bool)
+	for {
+		entry, err := r.ReadString(0)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected end of the file list: %w", err)
+		}
+		entry = strings.TrimSuffix(entry, "\x00")
+		if entry == "" {
+			return paths, nil
+		}
+		paths[strings.TrimPrefix(entry, "./")] = true
+	}
+}
+
+// unpack writes the files in a tar stream from the host into the workspace.
+func (h *Host) unpack(r io.Reader) (int, error) {
+	tr := tar.NewReader(r)
+	received := 0
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return received, nil
+		}
+		if err != nil {
+			return received, err
+		}
+		rel := path.Clean(strings.TrimPrefix(header.Name, "./"))
+		if rel == "." || path.IsAbs(rel) || rel == ".." || strings.HasPrefix(rel, "../") {
+			return received, fmt.Errorf("refusing to write %q outside the workspace", header.Name)
+		}
+		if header.Typeflag != tar.TypeReg && header.Typeflag != tar.TypeSymlink {
+			continue
+		}
+		if err := h.receive(header, tr, rel); err != nil {
+			return received, fmt.Errorf("failed to write %s: %w", rel, err)
+		}
+		received++
+	}
+}
+
+// receive writes one file from the host unless it was changed locally since
+// it was last synced.
+func (h *Host) receive(header *tar.Header, content io.Reader, rel string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	local := filepath.Join(h.localRoot, filepath.FromSlash(rel))
+	if info, err := os.Lstat(local); err == nil {
+		if previous, ok := h.synced[rel]; !ok || previous != stateOf(info, linkTarget(local, info)) {
+			return nil // changed locally meanwhile; the local change wins
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(local), 0755); err != nil {
+		return err
+	}
+
+	if header.Typeflag == tar.TypeSymlink {
+		os.Remove(local)
+		if err := os.Symlink(header.Linkname, local); err != nil {
+			return err
+		}
+	} else {
+		tmp, err := os.CreateTemp(filepath.Dir(local), ".ledit-remote-*")
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(tmp, content)
+		if closeErr := tmp.Close(); err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			err = os.Chmod(tmp.Name(), fs.FileMode(header.Mode).Perm())
+		}
+		if err == nil {
+			err = os.Chtimes(tmp.Name(), header.ModTime, header.ModTime)
+		}
+		if err == nil {
+			err = os.Rename(tmp.Name(), local)
+		}
+		if err != nil {
+			os.Remove(tmp.Name())
+			return err
+		}
+	}
+	info, err := os.Lstat(local)
+	if err != nil {
+		return err
+	}
+	h.synced[rel] = stateOf(info, header.Linkname)
+	return nil
+}
+
+// removeDeleted deletes synced files that are gone from the host, unless
+// they were changed locally since they were synced.
+func (h *Host) removeDeleted(present map[string]bool) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	deleted := 0
+	for rel, state := range h.synced {
+		if present[rel] {
+			continue
+		}
+		local := filepath.Join(h.localRoot, filepath.FromSlash(rel))
+		info, err := os.Lstat(local)
+		if err == nil && stateOf(info, linkTarget(local, info)) != state {
+			continue
+		}
+		if err == nil && os.Remove(local) != nil {
+			continue
+		}
+		delete(h.synced, rel)
+		deleted++
+	}
+	return deleted
+}
+
+func linkTarget(local string, info fs.FileInfo) string {
+	if info.Mode()&fs.ModeSymlink == 0 {
+		return ""
+	}
+	target, _ := os.Readlink(local)
+	return target
+}
+
+// Push sends every file changed since the last sync to the host, without
+// running a command.
+func (h *Host) Push(ctx context.Context) (SyncStats, error) {
+	r, err := h.start(ctx, h.localRoot, "true")
+	if err != nil {
+		return SyncStats{}, err
+	}
+	if out, err := r.cmd.CombinedOutput(); err != nil {
+		return r.stats, fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return r.stats, h.finish(ctx, r)
+}
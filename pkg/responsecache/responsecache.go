@@ -0,0 +1,150 @@
+// Package responsecache stores LLM responses on disk so identical requests
+// can be answered without calling the provider again.
+package responsecache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	api "github.com/alantheprice/ledit/pkg/agent_api"
+)
+
+// Cache is a directory of responses, one file per request key.
+type Cache struct {
+	dir      string
+	ttl      time.Duration
+	maxBytes int64
+	now      func() time.Time
+}
+
+// record is one cached response file.
+type record struct {
+	CreatedAt time.Time        `json:"created_at"`
+	Provider  string           `json:"provider"`
+	Model     string           `json:"model"`
+	Response  api.ChatResponse `json:"response"`
+}
+
+// New returns the cache in dir. Entries expire after ttl, and the oldest
+// are evicted once the directory grows past maxBytes.
+func New(dir string, ttl time.Duration, maxBytes int64) *Cache {
+	return &Cache{dir: dir, ttl: ttl, maxBytes: maxBytes, now: time.Now}
+}
+
+// Request is everything that determines a response.
+type Request struct {
+	Provider        string        `json:"provider"`
+	Model           string        `json:"model"`
+	Messages        []api.Message `json:"messages"`
+	Tools           []api.Tool    `json:"tools,omitempty"`
+	Reasoning       string        `json:"reasoning,omitempty"`
+	DisableThinking bool          `json:"disable_thinking,omitempty"`
+}
+
+// Key hashes a request into its cache key.
+func Key(req Request) (string, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode request for the response cache: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Get returns the response cached under key, if it has not expired.
+func (c *Cache) Get(key string) (*api.ChatResponse, bool) {
+	path := c.path(key)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	var rec record
+	if err := json.Unmarshal(data, &rec); err != nil || c.expired(rec.CreatedAt) {
+		_ = os.Remove(path)
+		return nil, false
+	}
+	return &rec.Response, true
+}
+
+// Put caches resp under key, then drops expired entries and evicts the
+// oldest ones until the cache fits its size cap.
+func (c *Cache) Put(key, provider, model string, resp *api.ChatResponse) error {
+	if resp == nil {
+		return nil
+	}
+	if err := os.MkdirAll(c.dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create response cache directory: %w", err)
+	}
+	data, err := json.Marshal(record{CreatedAt: c.now(), Provider: provider, Model: model, Response: *resp})
+	if err != nil {
+		return fmt.Errorf("failed to encode response for the cache: %w", err)
+	}
+	// Write then rename so concurrent readers never see a partial file.
+	tmp := c.path(key) + fmt.Sprintf(".%d.tmp", os.Getpid())
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write response cache: %w", err)
+	}
+	if err := os.Rename(tmp, c.path(key)); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to write response cache: %w", err)
+	}
+	return c.prune()
+}
+
+func (c *Cache) prune() error {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return err
+	}
+	type cached struct {
+		path    string
+		modTime time.Time
+		size    int64
+	}
+	var files []cached
+	var total int64
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		path := filepath.Join(c.dir, entry.Name())
+		if c.expired(info.ModTime()) {
+			_ = os.Remove(path)
+			continue
+		}
+		files = append(files, cached{path: path, modTime: info.ModTime(), size: info.Size()})
+		total += info.Size()
+	}
+	if c.maxBytes <= 0 || total <= c.maxBytes {
+		return nil
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+	for _, f := range files {
+		if total <= c.maxBytes {
+			break
+		}
+		if err := os.Remove(f.path); err == nil {
+			total -= f.size
+		}
+	}
+	return nil
+}
+
+func (c *Cache) expired(createdAt time.Time) bool {
+	return c.ttl > 0 && c.now().Sub(createdAt) > c.ttl
+}
+
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
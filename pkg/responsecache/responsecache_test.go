@@ -0,0 +1,89 @@
+package responsecache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	api "github.com/alantheprice/ledit/pkg/agent_api"
+)
+
+func testResponse(content string) *api.ChatResponse {
+	resp := &api.ChatResponse{}
+	resp.Choices = make([]api.Choice, 1)
+	resp.Choices[0].Message.Role = "assistant"
+	resp.Choices[0].Message.Content = content
+	resp.Usage.TotalTokens = 42
+	return resp
+}
+
+func TestKey(t *testing.T) {
+	req := Request{Provider: "openai", Model: "gpt-5", Messages: []api.Message{{Role: "user", Content: "Summarize README.md"}}}
+	a, err := Key(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := Key(req)
+	if a != b {
+		t.Error("identical requests must share a key")
+	}
+	for name, changed := range map[string]Request{
+		"model":    {Provider: "openai", Model: "gpt-5-mini", Messages: req.Messages},
+		"messages": {Provider: "openai", Model: "gpt-5", Messages: []api.Message{{Role: "user", Content: "Summarize main.go"}}},
+		"thinking": {Provider: "openai", Model: "gpt-5", Messages: req.Messages, DisableThinking: true},
+	} {
+		if key, _ := Key(changed); key == a {
+			t.Errorf("changing the %s must change the key", name)
+		}
+	}
+}
+
+func TestGetPut(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	c := New(t.TempDir(), time.Hour, 0)
+	c.now = func() time.Time { return now }
+
+	if _, ok := c.Get("missing"); ok {
+		t.Fatal("expected a miss")
+	}
+	if err := c.Put("k1", "openai", "gpt-5", testResponse("cached answer")); err != nil {
+		t.Fatal(err)
+	}
+	resp, ok := c.Get("k1")
+	if !ok || resp.Choices[0].Message.Content != "cached answer" || resp.Usage.TotalTokens != 42 {
+		t.Fatalf("expected the cached response, got %+v (%v)", resp, ok)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if _, ok := c.Get("k1"); ok {
+		t.Error("expected the entry to expire")
+	}
+	if _, err := os.Stat(c.path("k1")); !os.IsNotExist(err) {
+		t.Errorf("expected the expired entry to be removed, got %v", err)
+	}
+}
+
+func TestPutEvictsOldest(t *testing.T) {
+	dir := t.TempDir()
+	c := New(dir, 0, 0)
+	if err := c.Put("old", "openai", "gpt-5", testResponse("old")); err != nil {
+		t.Fatal(err)
+	}
+	past := time.Now().Add(-time.Minute)
+	if err := os.Chtimes(filepath.Join(dir, "old.json"), past, past); err != nil {
+		t.Fatal(err)
+	}
+	info, _ := os.Stat(filepath.Join(dir, "old.json"))
+	// Room for one entry only.
+	c.maxBytes = info.Size() + 10
+	if err := c.Put("new", "openai", "gpt-5", testResponse("new")); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.Get("old"); ok {
+		t.Error("expected the oldest entry to be evicted")
+	}
+	if _, ok := c.Get("new"); !ok {
+		t.Error("expected the newest entry to be kept")
+	}
+}
@@ -0,0 +1,262 @@
+package runstats
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// maxSeries is how many series the charts show; the rest are drawn as "other".
+const maxSeries = 5
+
+const otherSeries = "other"
+
+// textBars fill the stacked bars of the text chart, one per series, so the
+// series stay apart without color. The last is used for "other".
+var textBars = [maxSeries + 1]string{"█", "▓", "▒", "░", "#", "+"}
+
+// chartColors is the Okabe-Ito colorblind-safe palette, one per series.
+var chartColors = [maxSeries + 1]string{"#0072b2", "#e69f00", "#009e73", "#cc79a7", "#56b4e9", "#999999"}
+
+// chartSeries returns the series to draw, at most maxSeries of them plus
+// "other" when more exist.
+func (r Report) chartSeries() []string {
+	if len(r.Series) <= maxSeries {
+		return r.Series
+	}
+	return append(append([]string{}, r.Series[:maxSeries]...), otherSeries)
+}
+
+// seriesCosts returns a bucket's spend for each of the chart's series.
+func seriesCosts(b Bucket, series []string) []float64 {
+	costs := make([]float64, len(series))
+	shown := 0.0
+	for i, s := range series {
+		if s != otherSeries {
+			costs[i] = b.Series[s]
+			shown += costs[i]
+		}
+	}
+	if n := len(series); n > 0 && series[n-1] == otherSeries {
+		costs[n-1] = b.Cost - shown
+	}
+	return costs
+}
+
+func (r Report) maxBucketCost() float64 {
+	highest := 0.0
+	for _, b := range r.Timeline {
+		if b.Cost > highest {
+			highest = b.Cost
+		}
+	}
+	return highest
+}
+
+func (i Interval) label(t time.Time) string {
+	switch i {
+	case Month:
+		return t.Format("2006-01")
+	case Week:
+		return "wk " + t.Format("01-02")
+	}
+	return t.Format("01-02")
+}
+
+// DisplayProject shortens a project path under the home directory to ~/...
+func DisplayProject(path string) string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return path
+	}
+	if rel, err := filepath.Rel(home, path); err == nil && !strings.HasPrefix(rel, "..") {
+		if rel == "." {
+			return "~"
+		}
+		return filepath.Join("~", rel)
+	}
+	return path
+}
+
+func (r Report) seriesLabel(s string) string {
+	if r.By == "project" && s != otherSeries {
+		return DisplayProject(s)
+	}
+	return s
+}
+
+func formatCost(cost float64) string {
+	if cost < 1 {
+		return fmt.Sprintf("$%.4f", cost)
+	}
+	return fmt.Sprintf("$%.2f", cost)
+}
+
+func formatTokens(tokens int) string {
+	switch {
+	case tokens >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(tokens)/1_000_000)
+	case tokens >= 1_000:
+		return fmt.Sprintf("%.1fk", float64(tokens)/1_000)
+	}
+	return fmt.Sprintf("%d", tokens)
+}
+
+func successRate(g Group) string {
+	if g.Runs == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d%%", g.Succeeded*100/g.Runs)
+}
+
+func (r Report) summaryLine() string {
+	return fmt.Sprintf("%d runs (%d succeeded, %d failed), %s tokens, %s, avg %s per run",
+		r.Total.Runs, r.Total.Succeeded, r.Total.Failed, formatTokens(r.Total.Tokens), formatCost(r.Total.Cost),
+		r.Total.AverageDuration().Round(time.Second))
+}
+
+// RenderText renders the report for the terminal, with the timeline as a
+// stacked bar chart at most width cells wide.
+func RenderText(r Report, width int) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Runs %s to %s\n", r.From.Format("2006-01-02"), r.To.Format("2006-01-02"))
+	if r.Total.Runs == 0 {
+		sb.WriteString("No runs recorded in this period.\n")
+		return sb.String()
+	}
+	sb.WriteString("  " + r.summaryLine() + "\n")
+
+	writeTextGroups(&sb, "By model", r.Models, func(s string) string { return s })
+	writeTextGroups(&sb, "By project", r.Projects, DisplayProject)
+
+	fmt.Fprintf(&sb, "\nSpend per %s by %s\n", r.Interval, r.By)
+	series := r.chartSeries()
+	highest := r.maxBucketCost()
+	for _, b := range r.Timeline {
+		bar := ""
+		if highest > 0 {
+			for i, cost := range seriesCosts(b, series) {
+				if cost <= 0 {
+					continue
+				}
+				bar += strings.Repeat(textBars[barIndex(i, series)], int(cost/highest*float64(width)+0.5))
+			}
+		}
+		// Pad by cells: the bar characters are several bytes each.
+		if pad := width - len([]rune(bar)); pad > 0 {
+			bar += strings.Repeat(" ", pad)
+		}
+		fmt.Fprintf(&sb, "  %-8s %s %s\n", r.Interval.label(b.Start), bar, formatCost(b.Cost))
+	}
+	sb.WriteString("  ")
+	for i, s := range series {
+		fmt.Fprintf(&sb, " %s %s", textBars[barIndex(i, series)], r.seriesLabel(s))
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// barIndex keeps "other" on the last fill whatever the number of series.
+func barIndex(i int, series []string) int {
+	if series[i] == otherSeries {
+		return maxSeries
+	}
+	return i
+}
+
+func writeTextGroups(sb *strings.Builder, title string, groups []Group, label func(string) string) {
+	fmt.Fprintf(sb, "\n%s\n", title)
+	keyWidth := 10
+	for _, g := range groups {
+		if n := len([]rune(label(g.Key))); n > keyWidth {
+			keyWidth = n
+		}
+	}
+	fmt.Fprintf(sb, "  %-*s %6s %6s %9s %10s %9s\n", keyWidth, "", "RUNS", "OK", "TOKENS", "COST", "AVG TIME")
+	for _, g := range groups {
+		fmt.Fprintf(sb, "  %-*s %6d %6s %9s %10s %9s\n", keyWidth, label(g.Key), g.Runs, successRate(g),
+			formatTokens(g.Tokens), formatCost(g.Cost), g.AverageDuration().Round(time.Second))
+	}
+}
+
+const htmlStyle = `body{font-family:system-ui,-apple-system,sans-serif;max-width:960px;margin:2rem auto;padding:0 1rem;color:#1b1b1b;line-height:1.5}
+table{border-collapse:collapse;margin:1rem 0}
+th,td{padding:.25rem .75rem;text-align:right;border-bottom:1px solid #ddd}
+th:first-child,td:first-child{text-align:left}
+.meta{color:#555}
+.legend span{display:inline-block;margin-right:1rem}
+.swatch{display:inline-block;width:.8rem;height:.8rem;margin-right:.3rem;vertical-align:middle}`
+
+// RenderHTML renders the report as a standalone HTML page with the timeline
+// as an inline SVG stacked bar chart.
+func RenderHTML(r Report) string {
+	var sb strings.Builder
+	title := fmt.Sprintf("ledit stats %s to %s", r.From.Format("2006-01-02"), r.To.Format("2006-01-02"))
+	sb.WriteString("<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(&sb, "<title>%s</title>\n<style>%s</style>\n</head>\n<body>\n", html.EscapeString(title), htmlStyle)
+	fmt.Fprintf(&sb, "<h1>%s</h1>\n", html.EscapeString(title))
+	if r.Total.Runs == 0 {
+		sb.WriteString("<p class=\"meta\">No runs recorded in this period.</p>\n</body>\n</html>\n")
+		return sb.String()
+	}
+	fmt.Fprintf(&sb, "<p class=\"meta\">%s</p>\n", html.EscapeString(r.summaryLine()))
+
+	fmt.Fprintf(&sb, "<h2>Spend per %s by %s</h2>\n", r.Interval, r.By)
+	writeSVGChart(&sb, r)
+	writeHTMLGroups(&sb, "By model", r.Models, func(s string) string { return s })
+	writeHTMLGroups(&sb, "By project", r.Projects, DisplayProject)
+	sb.WriteString("</body>\n</html>\n")
+	return sb.String()
+}
+
+func writeSVGChart(sb *strings.Builder, r Report) {
+	const height, top, bottom, left = 240.0, 10.0, 40.0, 60.0
+	barWidth := 24.0
+	if len(r.Timeline) > 30 {
+		barWidth = 12
+	}
+	width := left + float64(len(r.Timeline))*(barWidth+4) + 10
+	plot := height - top - bottom
+	highest := r.maxBucketCost()
+	series := r.chartSeries()
+
+	fmt.Fprintf(sb, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%.0f\" height=\"%.0f\" role=\"img\" aria-label=\"Spend per %s\">\n", width, height, r.Interval)
+	fmt.Fprintf(sb, "<line x1=\"%.0f\" y1=\"%.0f\" x2=\"%.0f\" y2=\"%.0f\" stroke=\"#999\"/>\n", left, height-bottom, width, height-bottom)
+	fmt.Fprintf(sb, "<text x=\"%.0f\" y=\"%.0f\" font-size=\"11\" text-anchor=\"end\">%s</text>\n", left-6, top+10, formatCost(highest))
+	for i, b := range r.Timeline {
+		x := left + float64(i)*(barWidth+4) + 2
+		y := height - bottom
+		for j, cost := range seriesCosts(b, series) {
+			if cost <= 0 || highest <= 0 {
+				continue
+			}
+			h := cost / highest * plot
+			y -= h
+			fmt.Fprintf(sb, "<rect x=\"%.1f\" y=\"%.1f\" width=\"%.0f\" height=\"%.1f\" fill=\"%s\"><title>%s %s: %s</title></rect>\n",
+				x, y, barWidth, h, chartColors[barIndex(j, series)], html.EscapeString(r.Interval.label(b.Start)),
+				html.EscapeString(r.seriesLabel(series[j])), formatCost(cost))
+		}
+		if len(r.Timeline) <= 16 || i%(len(r.Timeline)/8+1) == 0 {
+			fmt.Fprintf(sb, "<text x=\"%.1f\" y=\"%.0f\" font-size=\"10\" text-anchor=\"middle\">%s</text>\n",
+				x+barWidth/2, height-bottom+14, html.EscapeString(r.Interval.label(b.Start)))
+		}
+	}
+	sb.WriteString("</svg>\n<p class=\"legend\">")
+	for i, s := range series {
+		fmt.Fprintf(sb, "<span><span class=\"swatch\" style=\"background:%s\"></span>%s</span>", chartColors[barIndex(i, series)], html.EscapeString(r.seriesLabel(s)))
+	}
+	sb.WriteString("</p>\n")
+}
+
+func writeHTMLGroups(sb *strings.Builder, title string, groups []Group, label func(string) string) {
+	fmt.Fprintf(sb, "<h2>%s</h2>\n<table>\n<tr><th></th><th>Runs</th><th>Succeeded</th><th>Tokens</th><th>Cost</th><th>Avg time</th></tr>\n", html.EscapeString(title))
+	for _, g := range groups {
+		fmt.Fprintf(sb, "<tr><td>%s</td><td>%d</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td></tr>\n",
+			html.EscapeString(label(g.Key)), g.Runs, successRate(g), formatTokens(g.Tokens), formatCost(g.Cost),
+			g.AverageDuration().Round(time.Second))
+	}
+	sb.WriteString("</table>\n")
+}
@@ -0,0 +1,204 @@
+package runstats
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Interval is the width of a timeline bucket.
+type Interval string
+
+const (
+	Day   Interval = "day"
+	Week  Interval = "week"
+	Month Interval = "month"
+)
+
+// ParseInterval accepts day, week or month.
+func ParseInterval(s string) (Interval, error) {
+	switch Interval(s) {
+	case Day, Week, Month:
+		return Interval(s), nil
+	}
+	return "", fmt.Errorf("unknown interval %q (want day, week or month)", s)
+}
+
+// start returns the beginning of the bucket that holds t: local midnight,
+// the Monday of its week or the first of its month.
+func (i Interval) start(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	switch i {
+	case Week:
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case Month:
+		return day.AddDate(0, 0, 1-day.Day())
+	}
+	return day
+}
+
+func (i Interval) next(t time.Time) time.Time {
+	switch i {
+	case Week:
+		return t.AddDate(0, 0, 7)
+	case Month:
+		return t.AddDate(0, 1, 0)
+	}
+	return t.AddDate(0, 0, 1)
+}
+
+// Group is the total of the runs that share a model or project.
+type Group struct {
+	Key       string        `json:"key"`
+	Runs      int           `json:"runs"`
+	Succeeded int           `json:"succeeded"`
+	Failed    int           `json:"failed"` // partial runs count as neither
+	Tokens    int           `json:"tokens"`
+	Cost      float64       `json:"cost"`
+	Duration  time.Duration `json:"duration_ns"`
+}
+
+func (g *Group) add(run Run) {
+	g.Runs++
+	switch run.Status {
+	case "success":
+		g.Succeeded++
+	case "failure":
+		g.Failed++
+	}
+	g.Tokens += run.TotalTokens
+	g.Cost += run.Cost
+	g.Duration += time.Duration(run.DurationMs) * time.Millisecond
+}
+
+// AverageDuration returns the mean run duration.
+func (g Group) AverageDuration() time.Duration {
+	if g.Runs == 0 {
+		return 0
+	}
+	return g.Duration / time.Duration(g.Runs)
+}
+
+// Bucket is the spend in one timeline interval, split by series (a model or
+// a project).
+type Bucket struct {
+	Start  time.Time          `json:"start"`
+	Runs   int                `json:"runs"`
+	Cost   float64            `json:"cost"`
+	Series map[string]float64 `json:"series"`
+}
+
+// Report aggregates runs for `ledit stats`.
+type Report struct {
+	From     time.Time `json:"from"`
+	To       time.Time `json:"to"`
+	Interval Interval  `json:"interval"`
+	By       string    `json:"by"` // model or project: what the timeline is split by
+	Total    Group     `json:"total"`
+	Models   []Group   `json:"models"`
+	Projects []Group   `json:"projects"`
+	// Series lists the timeline's series, most expensive first.
+	Series   []string `json:"series"`
+	Timeline []Bucket `json:"timeline"`
+}
+
+// Build aggregates runs between from and to into a report whose timeline
+// has one bucket per interval, empty ones included, split by model or by
+// project.
+func Build(runs []Run, from, to time.Time, interval Interval, by string) (Report, error) {
+	if by != "model" && by != "project" {
+		return Report{}, fmt.Errorf("unknown grouping %q (want model or project)", by)
+	}
+	report := Report{From: from, To: to, Interval: interval, By: by, Total: Group{Key: "total"}}
+	models := map[string]*Group{}
+	projects := map[string]*Group{}
+	buckets := map[time.Time]*Bucket{}
+	for start := interval.start(from); !start.After(to); start = interval.next(start) {
+		bucket := &Bucket{Start: start, Series: map[string]float64{}}
+		buckets[start] = bucket
+		report.Timeline = append(report.Timeline, *bucket)
+	}
+
+	for _, run := range runs {
+		if run.Time.Before(from) || run.Time.After(to) {
+			continue
+		}
+		report.Total.add(run)
+		groupFor(models, modelKey(run)).add(run)
+		groupFor(projects, projectKey(run)).add(run)
+		if bucket := buckets[interval.start(run.Time.In(from.Location()))]; bucket != nil {
+			bucket.Runs++
+			bucket.Cost += run.Cost
+			series := modelKey(run)
+			if by == "project" {
+				series = projectKey(run)
+			}
+			bucket.Series[series] += run.Cost
+		}
+	}
+
+	for i := range report.Timeline {
+		report.Timeline[i] = *buckets[report.Timeline[i].Start]
+	}
+	report.Models = sortedGroups(models)
+	report.Projects = sortedGroups(projects)
+	if by == "project" {
+		report.Series = keys(report.Projects)
+	} else {
+		report.Series = keys(report.Models)
+	}
+	return report, nil
+}
+
+func modelKey(run Run) string {
+	model := run.Model
+	if model == "" {
+		model = "unknown"
+	}
+	if run.Provider == "" {
+		return model
+	}
+	return run.Provider + "/" + model
+}
+
+func projectKey(run Run) string {
+	if run.Project == "" {
+		return "unknown"
+	}
+	return run.Project
+}
+
+func groupFor(groups map[string]*Group, key string) *Group {
+	g, ok := groups[key]
+	if !ok {
+		g = &Group{Key: key}
+		groups[key] = g
+	}
+	return g
+}
+
+// sortedGroups orders groups by cost, then by runs and key.
+func sortedGroups(groups map[string]*Group) []Group {
+	sorted := make([]Group, 0, len(groups))
+	for _, g := range groups {
+		sorted = append(sorted, *g)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Cost != sorted[j].Cost {
+			return sorted[i].Cost > sorted[j].Cost
+		}
+		if sorted[i].Runs != sorted[j].Runs {
+			return sorted[i].Runs > sorted[j].Runs
+		}
+		return sorted[i].Key < sorted[j].Key
+	})
+	return sorted
+}
+
+func keys(groups []Group) []string {
+	out := make([]string, len(groups))
+	for i, g := range groups {
+		out[i] = g.Key
+	}
+	return out
+}
@@ -0,0 +1,105 @@
+package runstats
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordAndLoad(t *testing.T) {
+	dir := t.TempDir()
+	if runs, err := Load(dir, time.Time{}); err != nil || len(runs) != 0 {
+		t.Fatalf("Load of a missing log = %v, %v", runs, err)
+	}
+	old := time.Date(2026, 9, 1, 12, 0, 0, 0, time.UTC)
+	recent := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+	for _, run := range []Run{{Time: old, Model: "a", Cost: 1}, {Time: recent, Model: "b", Cost: 2}} {
+		if err := Record(dir, run); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+	// A line cut short by a crash is skipped.
+	f, _ := os.OpenFile(Path(dir), os.O_APPEND|os.O_WRONLY, 0)
+	f.WriteString("{\"time\":\n")
+	f.Close()
+
+	runs, err := Load(dir, time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil || len(runs) != 1 || runs[0].Model != "b" || runs[0].Cost != 2 {
+		t.Fatalf("Load = %+v, %v", runs, err)
+	}
+}
+
+func TestBuildReport(t *testing.T) {
+	loc := time.UTC
+	from := time.Date(2026, 10, 12, 0, 0, 0, 0, loc) // Monday
+	to := time.Date(2026, 10, 25, 18, 0, 0, 0, loc)
+	runs := []Run{
+		{Time: from.Add(10 * time.Hour), Project: "/src/api", Provider: "openai", Model: "gpt", Status: "success", TotalTokens: 1000, Cost: 0.5, DurationMs: 60_000},
+		{Time: from.Add(30 * time.Hour), Project: "/src/api", Provider: "openai", Model: "gpt", Status: "failure", TotalTokens: 500, Cost: 0.25, DurationMs: 30_000},
+		{Time: from.AddDate(0, 0, 8), Project: "/src/web", Provider: "anthropic", Model: "claude", Status: "partial", TotalTokens: 3000, Cost: 1.5, DurationMs: 90_000},
+		{Time: from.AddDate(0, 0, -1), Model: "outside", Cost: 9},
+	}
+
+	if _, err := Build(runs, from, to, Day, "team"); err == nil {
+		t.Fatal("expected an unknown grouping to be rejected")
+	}
+	report, err := Build(runs, from, to, Week, "model")
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if report.Total.Runs != 3 || report.Total.Succeeded != 1 || report.Total.Failed != 1 || report.Total.Cost != 2.25 {
+		t.Fatalf("unexpected total %+v", report.Total)
+	}
+	if len(report.Models) != 2 || report.Models[0].Key != "anthropic/claude" || report.Models[1].Runs != 2 {
+		t.Fatalf("unexpected models %+v", report.Models)
+	}
+	if report.Models[1].AverageDuration() != 45*time.Second {
+		t.Fatalf("average duration = %s", report.Models[1].AverageDuration())
+	}
+	if len(report.Timeline) != 2 || report.Timeline[0].Cost != 0.75 || report.Timeline[1].Series["anthropic/claude"] != 1.5 {
+		t.Fatalf("unexpected timeline %+v", report.Timeline)
+	}
+
+	daily, _ := Build(runs, from, to, Day, "project")
+	if len(daily.Timeline) != 14 || daily.Timeline[1].Series["/src/api"] != 0.25 || daily.Series[0] != "/src/web" {
+		t.Fatalf("unexpected daily report %+v", daily)
+	}
+	monthly, _ := Build(runs, from, to, Month, "model")
+	if len(monthly.Timeline) != 1 || !monthly.Timeline[0].Start.Equal(time.Date(2026, 10, 1, 0, 0, 0, 0, loc)) {
+		t.Fatalf("unexpected monthly timeline %+v", monthly.Timeline)
+	}
+
+	text := RenderText(report, 20)
+	for _, want := range []string{"3 runs (1 succeeded, 1 failed)", "anthropic/claude", "Spend per week by model", "wk 10-12", "█", "$1.50"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("text report missing %q:\n%s", want, text)
+		}
+	}
+	page := RenderHTML(report)
+	for _, want := range []string{"<!DOCTYPE html>", "<svg", "<rect", "anthropic/claude", "<table>"} {
+		if !strings.Contains(page, want) {
+			t.Errorf("HTML report missing %q", want)
+		}
+	}
+	if empty := RenderText(Report{From: from, To: to}, 20); !strings.Contains(empty, "No runs recorded") {
+		t.Errorf("unexpected empty report %q", empty)
+	}
+}
+
+func TestChartGroupsExtraSeriesAsOther(t *testing.T) {
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	var runs []Run
+	for i, model := range []string{"a", "b", "c", "d", "e", "f", "g"} {
+		runs = append(runs, Run{Time: from.Add(time.Hour), Model: model, Cost: float64(10 - i)})
+	}
+	report, _ := Build(runs, from, from.Add(12*time.Hour), Day, "model")
+	series := report.chartSeries()
+	if len(series) != maxSeries+1 || series[maxSeries] != otherSeries {
+		t.Fatalf("unexpected chart series %v", series)
+	}
+	costs := seriesCosts(report.Timeline[0], series)
+	if costs[0] != 10 || costs[maxSeries] != 4+5 {
+		t.Fatalf("unexpected series costs %v", costs)
+	}
+}
@@ -0,0 +1,99 @@
+// Package runstats keeps a local record of agent runs (tokens, cost, model,
+// duration, outcome) for `ledit stats`. Nothing leaves the machine: runs are
+// appended to stats/runs.jsonl in the ledit config directory.
+package runstats
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gofrs/flock"
+)
+
+// Run is the metadata of one agent run.
+type Run struct {
+	Time             time.Time `json:"time"` // when the run finished
+	Project          string    `json:"project"`
+	Provider         string    `json:"provider"`
+	Model            string    `json:"model"`
+	Status           string    `json:"status"` // success, partial or failure
+	DurationMs       int64     `json:"duration_ms"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	CachedTokens     int       `json:"cached_tokens,omitempty"`
+	TotalTokens      int       `json:"total_tokens"`
+	Cost             float64   `json:"cost"`
+	Iterations       int       `json:"iterations,omitempty"`
+	FilesChanged     int       `json:"files_changed,omitempty"`
+}
+
+// Path returns the run log in the config directory dir.
+func Path(dir string) string {
+	return filepath.Join(dir, "stats", "runs.jsonl")
+}
+
+// Record appends run to the log in dir. The log is locked while writing so
+// concurrent ledit processes don't interleave lines.
+func Record(dir string, run Run) error {
+	path := Path(dir)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create stats directory: %w", err)
+	}
+	data, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+
+	lock := flock.New(path + ".lock")
+	if err := lock.Lock(); err != nil {
+		return fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+	defer lock.Unlock()
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// Load returns the runs in dir that finished at or after since, oldest
+// first. Lines that don't parse (such as one cut short by a crash) are
+// skipped.
+func Load(dir string, since time.Time) ([]Run, error) {
+	path := Path(dir)
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	var runs []Run
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var run Run
+		if err := json.Unmarshal(scanner.Bytes(), &run); err != nil {
+			continue
+		}
+		if !run.Time.Before(since) {
+			runs = append(runs, run)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return runs, nil
+}
@@ -0,0 +1,199 @@
+// Package sandbox runs shell commands in isolation: the workspace is mounted
+// read-write, system directories read-only, the rest of the filesystem is
+// hidden and the network is cut off. Commands run in a container (Docker or
+// Podman) or in unprivileged Linux namespaces via bubblewrap.
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Backend is the isolation mechanism.
+type Backend string
+
+const (
+	BackendAuto       Backend = "auto"
+	BackendBubblewrap Backend = "bubblewrap"
+	BackendPodman     Backend = "podman"
+	BackendDocker     Backend = "docker"
+)
+
+// DefaultImage is the container image used when none is configured.
+const DefaultImage = "docker.io/library/alpine:latest"
+
+// Backends lists the concrete backends in the order auto tries them.
+var Backends = []Backend{BackendBubblewrap, BackendPodman, BackendDocker}
+
+var binaries = map[Backend]string{
+	BackendBubblewrap: "bwrap",
+	BackendPodman:     "podman",
+	BackendDocker:     "docker",
+}
+
+// systemDirs are mounted read-only under bubblewrap so the usual tools and
+// their libraries and configuration are available.
+var systemDirs = []string{"/usr", "/bin", "/sbin", "/lib", "/lib32", "/lib64", "/etc", "/opt", "/nix"}
+
+// ErrUnavailable is returned when the configured backend is not installed.
+var ErrUnavailable = errors.New("sandbox backend not available")
+
+// Config selects and configures a backend.
+type Config struct {
+	Backend Backend
+	// Image is the container image for docker and podman.
+	Image string
+	// Network allows network access.
+	Network bool
+	// Mounts are extra host paths made available read-only, such as a
+	// toolchain under the home directory. A leading ~ is expanded.
+	Mounts []string
+}
+
+// Executor builds sandboxed commands.
+type Executor struct {
+	backend Backend
+	binary  string
+	config  Config
+}
+
+// lookPath is replaced in tests.
+var lookPath = exec.LookPath
+
+// New returns an executor for cfg. The auto backend picks the first
+// installed one of bubblewrap (Linux only), podman and docker.
+func New(cfg Config) (*Executor, error) {
+	backend := Backend(strings.ToLower(strings.TrimSpace(string(cfg.Backend))))
+	switch backend {
+	case "":
+		backend = BackendAuto
+	case "bwrap":
+		backend = BackendBubblewrap
+	}
+	if cfg.Image == "" {
+		cfg.Image = DefaultImage
+	}
+
+	candidates := []Backend{backend}
+	if backend == BackendAuto {
+		candidates = Backends
+	} else if _, ok := binaries[backend]; !ok {
+		return nil, fmt.Errorf("unknown sandbox backend %q (use auto, bubblewrap, podman or docker)", cfg.Backend)
+	}
+	for _, candidate := range candidates {
+		if candidate == BackendBubblewrap && runtime.GOOS != "linux" {
+			continue
+		}
+		if path, err := lookPath(binaries[candidate]); err == nil {
+			cfg.Backend = candidate
+			return &Executor{backend: candidate, binary: path, config: cfg}, nil
+		}
+	}
+	if backend == BackendAuto {
+		return nil, fmt.Errorf("%w: install bubblewrap, podman or docker", ErrUnavailable)
+	}
+	return nil, fmt.Errorf("%w: %s not found in PATH", ErrUnavailable, binaries[backend])
+}
+
+// Backend returns the backend in use.
+func (e *Executor) Backend() Backend {
+	return e.backend
+}
+
+// Describe summarizes the sandbox for status messages.
+func (e *Executor) Describe() string {
+	network := "no network"
+	if e.config.Network {
+		network = "network allowed"
+	}
+	if e.backend == BackendBubblewrap {
+		return fmt.Sprintf("%s, %s", e.backend, network)
+	}
+	return fmt.Sprintf("%s %s, %s", e.backend, e.config.Image, network)
+}
+
+// Command returns a command running shellCommand in the sandbox with
+// workspace mounted read-write as the working directory.
+func (e *Executor) Command(ctx context.Context, workspace, shellCommand string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, e.binary, e.Args(workspace, shellCommand)...)
+	cmd.Dir = workspace
+	return cmd
+}
+
+// Args returns the backend arguments for running shellCommand.
+func (e *Executor) Args(workspace, shellCommand string) []string {
+	switch e.backend {
+	case BackendBubblewrap:
+		return e.bubblewrapArgs(workspace, shellCommand)
+	default:
+		return e.containerArgs(workspace, shellCommand)
+	}
+}
+
+func (e *Executor) bubblewrapArgs(workspace, shellCommand string) []string {
+	args := []string{"--unshare-all", "--die-with-parent"}
+	if e.config.Network {
+		args = append(args, "--share-net")
+	}
+	for _, dir := range systemDirs {
+		args = append(args, "--ro-bind-try", dir, dir)
+	}
+	args = append(args, "--proc", "/proc", "--dev", "/dev", "--tmpfs", "/tmp")
+	for _, mount := range e.mounts() {
+		args = append(args, "--ro-bind-try", mount, mount)
+	}
+	args = append(args,
+		"--bind", workspace, workspace,
+		"--chdir", workspace,
+		"--setenv", "HOME", "/tmp",
+		"/bin/sh", "-c", shellCommand)
+	return args
+}
+
+func (e *Executor) containerArgs(workspace, shellCommand string) []string {
+	args := []string{"run", "--rm", "-i"}
+	if !e.config.Network {
+		args = append(args, "--network", "none")
+	}
+	switch {
+	case e.backend == BackendPodman:
+		// Rootless podman maps the caller's user into the container.
+		args = append(args, "--userns=keep-id")
+	case runtime.GOOS == "linux":
+		// Docker runs as root by default, which would leave root-owned files
+		// in the workspace.
+		args = append(args, "--user", fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid()))
+	}
+	args = append(args, "-v", workspace+":"+workspace, "-w", workspace, "-e", "HOME=/tmp")
+	for _, mount := range e.mounts() {
+		args = append(args, "-v", mount+":"+mount+":ro")
+	}
+	return append(args, e.config.Image, "/bin/sh", "-c", shellCommand)
+}
+
+// mounts returns the configured extra mounts as absolute paths.
+func (e *Executor) mounts() []string {
+	var mounts []string
+	for _, mount := range e.config.Mounts {
+		mount = strings.TrimSpace(mount)
+		if mount == "" {
+			continue
+		}
+		if mount == "~" || strings.HasPrefix(mount, "~/") {
+			if home, err := os.UserHomeDir(); err == nil {
+				mount = filepath.Join(home, strings.TrimPrefix(mount, "~"))
+			}
+		}
+		if abs, err := filepath.Abs(mount); err == nil {
+			mount = abs
+		}
+		mounts = append(mounts, mount)
+	}
+	return mounts
+}
@@ -0,0 +1,109 @@
+package sandbox
+
+import (
+	"errors"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func fakeLookPath(t *testing.T, installed ...string) {
+	t.Helper()
+	previous := lookPath
+	t.Cleanup(func() { lookPath = previous })
+	lookPath = func(name string) (string, error) {
+		for _, bin := range installed {
+			if bin == name {
+				return "/usr/bin/" + name, nil
+			}
+		}
+		return "", exec.ErrNotFound
+	}
+}
+
+func TestNewPicksInstalledBackend(t *testing.T) {
+	fakeLookPath(t, "docker", "podman")
+	executor, err := New(Config{})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if executor.Backend() != BackendPodman {
+		t.Errorf("auto picked %s, want podman before docker", executor.Backend())
+	}
+
+	executor, err = New(Config{Backend: "Docker"})
+	if err != nil || executor.Backend() != BackendDocker {
+		t.Fatalf("explicit docker: %v, %v", executor, err)
+	}
+
+	if _, err := New(Config{Backend: "bwrap"}); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("expected ErrUnavailable for a missing bubblewrap, got %v", err)
+	}
+	if _, err := New(Config{Backend: "chroot"}); err == nil || errors.Is(err, ErrUnavailable) {
+		t.Errorf("expected an unknown backend error, got %v", err)
+	}
+
+	fakeLookPath(t)
+	if _, err := New(Config{}); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("expected ErrUnavailable with nothing installed, got %v", err)
+	}
+}
+
+func TestBubblewrapArgsIsolateWorkspace(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("bubblewrap is Linux only")
+	}
+	fakeLookPath(t, "bwrap")
+	executor, err := New(Config{Mounts: []string{"/opt/toolchain"}})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	args := strings.Join(executor.Args("/work/repo", "go test ./..."), " ")
+	for _, want := range []string{
+		"--unshare-all",
+		"--ro-bind-try /usr /usr",
+		"--ro-bind-try /opt/toolchain /opt/toolchain",
+		"--bind /work/repo /work/repo",
+		"--chdir /work/repo",
+		"/bin/sh -c go test ./...",
+	} {
+		if !strings.Contains(args, want) {
+			t.Errorf("args missing %q: %s", want, args)
+		}
+	}
+	if strings.Contains(args, "--share-net") {
+		t.Errorf("network should be blocked by default: %s", args)
+	}
+
+	executor.config.Network = true
+	if args := strings.Join(executor.Args("/work/repo", "true"), " "); !strings.Contains(args, "--share-net") {
+		t.Errorf("network allowed but not shared: %s", args)
+	}
+}
+
+func TestContainerArgs(t *testing.T) {
+	fakeLookPath(t, "docker")
+	executor, err := New(Config{Backend: BackendDocker, Image: "golang:1.25", Mounts: []string{"/srv/cache"}})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	args := executor.Args("/work/repo", "make test")
+	joined := strings.Join(args, " ")
+	for _, want := range []string{
+		"run --rm -i --network none",
+		"-v /work/repo:/work/repo -w /work/repo",
+		"-v /srv/cache:/srv/cache:ro",
+		"golang:1.25 /bin/sh -c make test",
+	} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("args missing %q: %s", want, joined)
+		}
+	}
+	if args[len(args)-1] != "make test" {
+		t.Errorf("command must be a single argument, got %q", args[len(args)-1])
+	}
+	if got := executor.Describe(); got != "docker golang:1.25, no network" {
+		t.Errorf("Describe() = %q", got)
+	}
+}
@@ -0,0 +1,269 @@
+package schedule
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alantheprice/ledit/pkg/jobs"
+)
+
+// Record is one line of the daemon's JSON log.
+type Record struct {
+	Time         time.Time  `json:"time"`
+	Event        string     `json:"event"` // daemon_started, started, skipped, finished, error, daemon_stopped
+	TaskID       string     `json:"task_id,omitempty"`
+	JobID        string     `json:"job_id,omitempty"`
+	Prompt       string     `json:"prompt,omitempty"`
+	Status       string     `json:"status,omitempty"`
+	Log          string     `json:"log,omitempty"`
+	DurationSec  float64    `json:"duration_sec,omitempty"`
+	FilesChanged []string   `json:"files_changed,omitempty"`
+	Tokens       int        `json:"tokens,omitempty"`
+	Cost         float64    `json:"cost,omitempty"`
+	Response     string     `json:"response,omitempty"`
+	Error        string     `json:"error,omitempty"`
+	Tasks        int        `json:"tasks,omitempty"`
+	NextRun      *time.Time `json:"next_run,omitempty"`
+}
+
+// maxLoggedResponse caps the agent response copied into a finished record;
+// the job log has all of it.
+const maxLoggedResponse = 500
+
+// Daemon starts the project's due tasks as background jobs and logs what
+// happens to them as JSON lines.
+type Daemon struct {
+	root    string
+	manager *jobs.Manager
+
+	mu      sync.Mutex
+	out     *json.Encoder
+	running map[string]string // task ID → job ID
+	tasks   map[string]string // job ID → task ID
+	done    map[string]chan jobs.Job
+	pending sync.WaitGroup // running jobs
+
+	// submit starts a job; tests replace it.
+	submit func(jobs.Spec) (jobs.Job, error)
+	now    func() time.Time
+}
+
+// NewDaemon returns a daemon for the project at root that writes its log to
+// out.
+func NewDaemon(root string, out io.Writer) *Daemon {
+	d := &Daemon{
+		root:    root,
+		out:     json.NewEncoder(out),
+		running: make(map[string]string),
+		tasks:   make(map[string]string),
+		done:    make(map[string]chan jobs.Job),
+		now:     time.Now,
+	}
+	d.manager = jobs.NewManager(d.finished)
+	d.submit = d.manager.Submit
+	return d
+}
+
+// Run checks the schedule at the start of every minute until ctx ends. Runs
+// missed while the daemon was not running are not made up. On return,
+// running jobs have been cancelled and have finished.
+func (d *Daemon) Run(ctx context.Context) error {
+	tasks, err := Load(d.root)
+	if err != nil {
+		return err
+	}
+	started := Record{Event: "daemon_started", Tasks: len(tasks)}
+	if runs := NextRuns(tasks, d.now()); len(runs) > 0 {
+		started.NextRun = &runs[0].At
+	}
+	d.log(started)
+
+	last := d.now().Truncate(time.Minute)
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		timer.Reset(last.Add(time.Minute).Sub(d.now()))
+		select {
+		case <-ctx.Done():
+			d.shutdown()
+			d.log(Record{Event: "daemon_stopped"})
+			return nil
+		case <-timer.C:
+		}
+		minute := d.now().Truncate(time.Minute)
+		if !minute.After(last) {
+			continue
+		}
+		d.Tick(minute)
+		last = minute
+	}
+}
+
+// Tick starts the tasks due at minute.
+func (d *Daemon) Tick(minute time.Time) {
+	tasks, err := Load(d.root)
+	if err != nil {
+		d.log(Record{Event: "error", Error: err.Error()})
+		return
+	}
+	for _, task := range tasks {
+		if task.Disabled {
+			continue
+		}
+		cron, err := ParseCron(task.Cron)
+		if err != nil {
+			d.log(Record{Event: "error", TaskID: task.ID, Error: err.Error()})
+			continue
+		}
+		if cron.Matches(minute) && task.LastRun.Before(minute) {
+			d.start(task, minute)
+		}
+	}
+}
+
+// RunNow starts task id immediately and waits for its job to finish.
+func (d *Daemon) RunNow(ctx context.Context, id string) (jobs.Job, error) {
+	tasks, err := Load(d.root)
+	if err != nil {
+		return jobs.Job{}, err
+	}
+	for _, task := range tasks {
+		if task.ID != id {
+			continue
+		}
+		done := make(chan jobs.Job, 1)
+		d.mu.Lock()
+		d.done[id] = done
+		d.mu.Unlock()
+		if !d.start(task, d.now()) {
+			d.mu.Lock()
+			delete(d.done, id)
+			d.mu.Unlock()
+			return jobs.Job{}, fmt.Errorf("task %s did not start", id)
+		}
+		select {
+		case job := <-done:
+			return job, nil
+		case <-ctx.Done():
+			d.shutdown()
+			return <-done, ctx.Err()
+		}
+	}
+	return jobs.Job{}, fmt.Errorf("no scheduled task %q", id)
+}
+
+// start claims task's run at time at and submits its job. Another daemon
+// that already claimed the run, or a previous run still going, skips it.
+func (d *Daemon) start(task Task, at time.Time) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if jobID, busy := d.running[task.ID]; busy {
+		d.logLocked(Record{Event: "skipped", TaskID: task.ID, JobID: jobID, Error: "the previous run is still going"})
+		return false
+	}
+
+	claimed := false
+	if err := Update(d.root, task.ID, func(t *Task) {
+		if t.LastRun.Before(at) {
+			t.LastRun, t.LastStatus, t.LastLog = at, string(jobs.StatusRunning), ""
+			claimed = true
+		}
+	}); err != nil {
+		d.logLocked(Record{Event: "error", TaskID: task.ID, Error: err.Error()})
+		return false
+	}
+	if !claimed {
+		return false
+	}
+
+	job, err := d.submit(jobs.Spec{Prompt: task.Prompt, WorkDir: d.root, Provider: task.Provider, Model: task.Model})
+	if err != nil {
+		d.logLocked(Record{Event: "error", TaskID: task.ID, Error: err.Error()})
+		d.setOutcome(task.ID, string(jobs.StatusFailed), "")
+		return false
+	}
+	d.pending.Add(1)
+	d.running[task.ID] = job.ID
+	d.tasks[job.ID] = task.ID
+	d.setOutcome(task.ID, string(jobs.StatusRunning), job.LogPath)
+	d.logLocked(Record{Event: "started", TaskID: task.ID, JobID: job.ID, Prompt: task.Prompt, Log: job.LogPath})
+	return true
+}
+
+// finished is the job manager's callback for a finished job.
+func (d *Daemon) finished(job jobs.Job) {
+	defer d.pending.Done()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	taskID := d.tasks[job.ID]
+	delete(d.tasks, job.ID)
+	delete(d.running, taskID)
+	d.setOutcome(taskID, string(job.Status), job.LogPath)
+
+	record := Record{
+		Event:        "finished",
+		TaskID:       taskID,
+		JobID:        job.ID,
+		Status:       string(job.Status),
+		Log:          job.LogPath,
+		DurationSec:  job.Duration().Round(time.Second).Seconds(),
+		FilesChanged: job.FilesChanged,
+		Tokens:       job.Progress.TotalTokens,
+		Cost:         job.Progress.Cost,
+		Error:        job.Err,
+	}
+	if job.Result != nil {
+		record.Response = strings.TrimSpace(job.Result.Response)
+		if len(record.Response) > maxLoggedResponse {
+			record.Response = strings.ToValidUTF8(record.Response[:maxLoggedResponse], "") + "..."
+		}
+	}
+	d.logLocked(record)
+
+	if done, ok := d.done[taskID]; ok {
+		delete(d.done, taskID)
+		done <- job
+	}
+}
+
+// shutdown cancels the running jobs and waits until they have been logged.
+func (d *Daemon) shutdown() {
+	for _, job := range d.manager.List() {
+		if !job.Done() {
+			d.manager.CancelTask(job.ID)
+		}
+	}
+	d.pending.Wait()
+}
+
+// setOutcome records the status of a task's latest run. The caller holds
+// d.mu.
+func (d *Daemon) setOutcome(taskID, status, logPath string) {
+	if taskID == "" {
+		return
+	}
+	if err := Update(d.root, taskID, func(t *Task) {
+		t.LastStatus = status
+		if logPath != "" {
+			t.LastLog = logPath
+		}
+	}); err != nil {
+		d.logLocked(Record{Event: "error", TaskID: taskID, Error: err.Error()})
+	}
+}
+
+func (d *Daemon) log(record Record) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.logLocked(record)
+}
+
+func (d *Daemon) logLocked(record Record) {
+	record.Time = d.now()
+	d.out.Encode(record)
+}
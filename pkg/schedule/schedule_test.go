@@ -0,0 +1,131 @@
+package schedule
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alantheprice/ledit/pkg/jobs"
+)
+
+func TestParseCronAndNext(t *testing.T) {
+	// Thursday 2026-10-15 10:30
+	from := time.Date(2026, 10, 15, 10, 30, 0, 0, time.UTC)
+	cases := []struct {
+		expr string
+		want time.Time
+	}{
+		{"0 9 * * 1", time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * mon", time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 10, 15, 10, 45, 0, 0, time.UTC)},
+		{"30 10 * * *", time.Date(2026, 10, 16, 10, 30, 0, 0, time.UTC)},
+		{"0 0 1 jan *", time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 12 * * 7", time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)},
+		{"0 8-17/4 * * 1-5", time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)},
+		// Both day fields restricted: the 20th or any Saturday.
+		{"0 0 20 * 6", time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tc := range cases {
+		cron, err := ParseCron(tc.expr)
+		if err != nil {
+			t.Fatalf("ParseCron(%q): %v", tc.expr, err)
+		}
+		if got := cron.Next(from); !got.Equal(tc.want) {
+			t.Errorf("%q: next = %s, want %s", tc.expr, got, tc.want)
+		}
+		if !cron.Matches(tc.want) {
+			t.Errorf("%q does not match its own next run %s", tc.expr, tc.want)
+		}
+	}
+
+	for _, expr := range []string{"", "0 9 * *", "60 * * * *", "0 9 * * 8", "5-1 * * * *", "*/0 * * * *", "@fortnightly"} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q): expected an error", expr)
+		}
+	}
+	never, _ := ParseCron("0 0 30 2 *")
+	if next := never.Next(from); !next.IsZero() {
+		t.Errorf("expected no run for February 30th, got %s", next)
+	}
+}
+
+func TestStoreAddListRemove(t *testing.T) {
+	root := t.TempDir()
+	if _, err := Add(root, Task{Prompt: "x", Cron: "not cron"}); err == nil {
+		t.Fatal("expected an invalid cron expression to be rejected")
+	}
+	first, err := Add(root, Task{Prompt: " update CHANGELOG ", Cron: "0 9 * * 1"})
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	second, _ := Add(root, Task{Prompt: "tidy deps", Cron: "@daily", Model: "m"})
+	if first.ID != "1" || second.ID != "2" || first.Prompt != "update CHANGELOG" {
+		t.Fatalf("unexpected tasks %+v %+v", first, second)
+	}
+	if err := Remove(root, "1"); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	tasks, err := Load(root)
+	if err != nil || len(tasks) != 1 || tasks[0].ID != "2" || tasks[0].Model != "m" {
+		t.Fatalf("Load = %+v, %v", tasks, err)
+	}
+	third, _ := Add(root, Task{Prompt: "again", Cron: "@hourly"})
+	if third.ID != "3" {
+		t.Fatalf("expected IDs not to be reused, got %s", third.ID)
+	}
+	if err := Remove(root, "1"); err == nil {
+		t.Fatal("expected removing a missing task to fail")
+	}
+}
+
+func TestDaemonStartsDueTasksOnce(t *testing.T) {
+	root := t.TempDir()
+	weekly, _ := Add(root, Task{Prompt: "update CHANGELOG", Cron: "0 9 * * 1"})
+	Add(root, Task{Prompt: "not due", Cron: "0 10 * * 1"})
+
+	var out bytes.Buffer
+	d := NewDaemon(root, &out)
+	var submitted []jobs.Spec
+	d.submit = func(spec jobs.Spec) (jobs.Job, error) {
+		submitted = append(submitted, spec)
+		return jobs.Job{ID: "7", Spec: spec, Status: jobs.StatusRunning, LogPath: "job7.log"}, nil
+	}
+
+	monday9 := time.Date(2026, 10, 19, 9, 0, 0, 0, time.Local)
+	d.Tick(monday9)
+	d.Tick(monday9) // already claimed
+	if len(submitted) != 1 || submitted[0].Prompt != "update CHANGELOG" || submitted[0].WorkDir != root {
+		t.Fatalf("expected one job for the due task, got %+v", submitted)
+	}
+
+	// The next week's run is skipped while the first is still going.
+	d.Tick(monday9.AddDate(0, 0, 7))
+	if len(submitted) != 1 {
+		t.Fatalf("expected no second job while the first runs, got %d", len(submitted))
+	}
+
+	d.finished(jobs.Job{ID: "7", Status: jobs.StatusSucceeded, LogPath: "job7.log", FilesChanged: []string{"CHANGELOG.md"},
+		Progress: jobs.Progress{TotalTokens: 1500, Cost: 0.02}})
+	tasks, _ := Load(root)
+	if tasks[0].ID != weekly.ID || !tasks[0].LastRun.Equal(monday9) || tasks[0].LastStatus != "succeeded" || tasks[0].LastLog != "job7.log" {
+		t.Fatalf("expected the run's outcome to be stored, got %+v", tasks[0])
+	}
+
+	var events []string
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var record Record
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("log line is not JSON: %q", line)
+		}
+		events = append(events, record.Event)
+		if record.Event == "finished" && (record.TaskID != weekly.ID || record.Tokens != 1500 || record.FilesChanged[0] != "CHANGELOG.md") {
+			t.Fatalf("unexpected finished record %+v", record)
+		}
+	}
+	if got := strings.Join(events, ","); got != "started,skipped,finished" {
+		t.Fatalf("unexpected log events %s", got)
+	}
+}
@@ -0,0 +1,176 @@
+// Package schedule runs agent tasks on cron schedules. Tasks are stored per
+// project in .ledit/schedule.json; `ledit schedule run` checks them every
+// minute and starts the due ones as background jobs.
+package schedule
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofrs/flock"
+)
+
+// Task is one scheduled agent task.
+type Task struct {
+	ID        string    `json:"id"`
+	Prompt    string    `json:"prompt"`
+	Cron      string    `json:"cron"`
+	Provider  string    `json:"provider,omitempty"`
+	Model     string    `json:"model,omitempty"`
+	Disabled  bool      `json:"disabled,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// Outcome of the most recent run, kept up to date by the daemon.
+	LastRun    time.Time `json:"last_run,omitempty"`
+	LastStatus string    `json:"last_status,omitempty"`
+	LastLog    string    `json:"last_log,omitempty"`
+}
+
+// file is the layout of .ledit/schedule.json.
+type file struct {
+	Tasks []Task `json:"tasks"`
+}
+
+// StorePath returns the schedule file of the project at root.
+func StorePath(root string) string {
+	return filepath.Join(root, ".ledit", "schedule.json")
+}
+
+// Load returns the project's scheduled tasks in the order they were added.
+func Load(root string) ([]Task, error) {
+	var tasks []Task
+	err := update(root, false, func(f *file) error {
+		tasks = append(tasks, f.Tasks...)
+		return nil
+	})
+	return tasks, err
+}
+
+// Add validates task's prompt and cron expression, gives it an ID and
+// stores it.
+func Add(root string, task Task) (Task, error) {
+	task.Prompt = strings.TrimSpace(task.Prompt)
+	if task.Prompt == "" {
+		return Task{}, errors.New("a scheduled task needs a prompt")
+	}
+	cron, err := ParseCron(task.Cron)
+	if err != nil {
+		return Task{}, err
+	}
+	if cron.Next(time.Now()).IsZero() {
+		return Task{}, fmt.Errorf("cron expression %q never matches", task.Cron)
+	}
+	task.Cron = cron.String()
+	if task.CreatedAt.IsZero() {
+		task.CreatedAt = time.Now()
+	}
+
+	err = update(root, true, func(f *file) error {
+		highest := 0
+		for _, existing := range f.Tasks {
+			if n, err := strconv.Atoi(existing.ID); err == nil && n > highest {
+				highest = n
+			}
+		}
+		task.ID = strconv.Itoa(highest + 1)
+		f.Tasks = append(f.Tasks, task)
+		return nil
+	})
+	return task, err
+}
+
+// Remove deletes task id.
+func Remove(root, id string) error {
+	return update(root, true, func(f *file) error {
+		for i, task := range f.Tasks {
+			if task.ID == id {
+				f.Tasks = append(f.Tasks[:i], f.Tasks[i+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("no scheduled task %q", id)
+	})
+}
+
+// Update applies fn to task id.
+func Update(root, id string, fn func(*Task)) error {
+	return update(root, true, func(f *file) error {
+		for i := range f.Tasks {
+			if f.Tasks[i].ID == id {
+				fn(&f.Tasks[i])
+				return nil
+			}
+		}
+		return fmt.Errorf("no scheduled task %q", id)
+	})
+}
+
+// NextRuns returns each task's next run after t, ordered by time. Disabled
+// tasks and tasks whose expression no longer parses are left out.
+func NextRuns(tasks []Task, t time.Time) []Run {
+	var runs []Run
+	for _, task := range tasks {
+		if task.Disabled {
+			continue
+		}
+		cron, err := ParseCron(task.Cron)
+		if err != nil {
+			continue
+		}
+		if at := cron.Next(t); !at.IsZero() {
+			runs = append(runs, Run{Task: task, At: at})
+		}
+	}
+	sort.SliceStable(runs, func(i, j int) bool { return runs[i].At.Before(runs[j].At) })
+	return runs
+}
+
+// Run is a task due at a time.
+type Run struct {
+	Task Task
+	At   time.Time
+}
+
+// update reads the schedule file under a lock shared with other ledit
+// processes, applies fn and, when write is set, saves the result.
+func update(root string, write bool, fn func(*file) error) error {
+	path := StorePath(root)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create schedule directory: %w", err)
+	}
+	lock := flock.New(path + ".lock")
+	if err := lock.Lock(); err != nil {
+		return fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+	defer lock.Unlock()
+
+	var f file
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &f); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	}
+	if err := fn(&f); err != nil || !write {
+		return err
+	}
+
+	data, err = json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
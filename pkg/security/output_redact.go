@@ -1,6 +1,7 @@
 package security
 
 import (
+	"fmt"
 	"os"
 	"sort"
 	"strings"
@@ -22,9 +23,11 @@ type RedactionResult struct {
 	Secrets []DetectedSecret // What was found (if any)
 }
 
-// OutputRedactor scans tool output for secrets using two strategies:
+// OutputRedactor scans tool output for secrets using three strategies:
 //  1. Environment value matching — literal secret values from env vars
 //  2. Pattern matching — regex-based detection of common credential formats
+//  3. Secret scanning — known credential formats (AWS, GitHub, Slack, private
+//     keys, ...) plus a Shannon-entropy check for values of secret-like keys
 type OutputRedactor struct {
 	envSecretValues  map[string]string // var name -> value (sensitive env vars only)
 	envSecretSnippets map[string]string // value -> var name (reverse index for fast lookup)
@@ -102,8 +105,24 @@ func (r *OutputRedactor) RedactToolOutput(output string, toolName string, toolAr
 	patternSecrets := r.detectAndRedactPatterns(&content)
 	secrets = append(secrets, patternSecrets...)
 
+	// Pass 3 — secret scan: redacts what the log-line patterns leave behind
+	// (AWS keys, private keys, webhooks, high-entropy key values). Types already
+	// reported by pass 2 are not reported twice.
+	content, scannedSecrets := RedactSecrets(content)
+	reported := make(map[string]bool, len(patternSecrets))
+	for _, s := range patternSecrets {
+		reported[s.Type] = true
+	}
+	for _, s := range scannedSecrets {
+		if !reported[s.Type] {
+			secrets = append(secrets, s)
+		}
+	}
+
 	if len(secrets) == 0 {
 		secrets = nil
+	} else {
+		logger.Logf("security: WARNING redacted %d secret(s) from tool=%s: %s", len(secrets), toolName, DescribeSecrets(secrets))
 	}
 
 	return RedactionResult{
@@ -195,3 +214,17 @@ func (r *OutputRedactor) detectAndRedactPatterns(content *string) []DetectedSecr
 	*content = redacted
 	return secrets
 }
+
+// DescribeSecrets summarizes detections by type and line without including
+// the secret values, e.g. "AWS Access Key ID (line 3), GitHub Token".
+func DescribeSecrets(secrets []DetectedSecret) string {
+	parts := make([]string, 0, len(secrets))
+	for _, s := range secrets {
+		if s.Line > 0 {
+			parts = append(parts, fmt.Sprintf("%s (line %d)", s.Type, s.Line))
+		} else {
+			parts = append(parts, s.Type)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,223 @@
+package security
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// secretPattern is a known credential format. When group is non-zero only that
+// submatch is redacted, so the surrounding key name stays readable.
+type secretPattern struct {
+	name  string
+	re    *regexp.Regexp
+	group int
+}
+
+// knownSecretPatterns match credential formats with distinctive prefixes or
+// structure. They are checked before the entropy heuristic.
+var knownSecretPatterns = []secretPattern{
+	{name: "Private Key", re: regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z0-9 ]*PRIVATE KEY-----`)},
+	{name: "AWS Access Key ID", re: regexp.MustCompile(`\b(?:AKIA|ASIA|AROA|AIDA|AGPA|ANPA)[0-9A-Z]{16}\b`)},
+	{name: "AWS Secret Access Key", re: regexp.MustCompile(`(?i)aws_?secret_?access_?key["']?\s*[:=]\s*["']?([A-Za-z0-9/+=]{40})`), group: 1},
+	{name: "AWS Session Token", re: regexp.MustCompile(`(?i)aws_?session_?token["']?\s*[:=]\s*["']?([A-Za-z0-9/+=]{100,})`), group: 1},
+	{name: "GitHub PAT", re: regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})`)},
+	{name: "GitLab PAT", re: regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20,}`)},
+	{name: "Slack Token", re: regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`)},
+	{name: "Slack App Token", re: regexp.MustCompile(`\bxapp-\d-[A-Za-z0-9-]{10,}`)},
+	{name: "Slack Webhook", re: regexp.MustCompile(`https://hooks\.slack\.com/(?:services|workflows)/[A-Za-z0-9/_-]{20,}`)},
+	{name: "Google API Key", re: regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}`)},
+	{name: "Stripe API Key", re: regexp.MustCompile(`\b(?:sk|rk)_(?:live|test)_[A-Za-z0-9]{24,}`)},
+	{name: "LLM Provider Key", re: regexp.MustCompile(`\bsk-(?:ant-|proj-|or-v1-)?[A-Za-z0-9_-]{20,}`)},
+	{name: "JWT", re: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`)},
+}
+
+const (
+	// entropyMinLength and entropyMaxLength bound the tokens the entropy
+	// heuristic considers; long runs are usually encoded blobs, not keys.
+	entropyMinLength = 32
+	entropyMaxLength = 200
+	// entropyThreshold is the Shannon entropy (bits per character) above
+	// which a mixed-case alphanumeric token is treated as a secret. Random
+	// base64 scores around 5; long identifiers score around 4.
+	entropyThreshold = 4.5
+)
+
+// keyedValueRegex finds values assigned to secret-like keys (api_key=...,
+// "client_secret": "...", Authorization: Bearer ...). Only these values are
+// checked by the entropy heuristic: unkeyed random-looking tokens are far
+// more often checksums, hashes or encoded data than credentials.
+var keyedValueRegex = regexp.MustCompile(`(?i)(?:key|token|secret|passw(?:or)?d|pwd|credential|auth)[\w.-]*["']?\s*[:=]\s*["']?(?:bearer\s+)?([A-Za-z0-9+/_=-]{32,})`)
+
+// hashPrefixRegex matches the prefix of digests whose values look random but
+// are not secrets: go.sum h1: hashes and Subresource Integrity values as used
+// in package-lock.json, yarn.lock and pnpm-lock.yaml.
+var hashPrefixRegex = regexp.MustCompile(`(?i)(?:\bh1:|\bsha(?:1|224|256|384|512)[-:])$`)
+
+// hashValueRegex matches a digest whose algorithm prefix was captured as
+// part of the value, as in key: sha512-<base64>.
+var hashValueRegex = regexp.MustCompile(`(?i)^sha(?:1|224|256|384|512)-`)
+
+// redactionPlaceholderRegex matches the placeholders RedactSecrets and the
+// log-line redaction leave in tool output.
+var redactionPlaceholderRegex = regexp.MustCompile(`\[REDACTED(?::[^\]\n]*)?\]`)
+
+// secretMatch is one secret found in a piece of text.
+type secretMatch struct {
+	name       string
+	start, end int
+}
+
+// ScanSecrets finds secrets in content using known credential patterns and a
+// Shannon-entropy heuristic for high-randomness values of secret-like keys.
+func ScanSecrets(content string) []DetectedSecret {
+	matches := scanSecretMatches(content)
+	secrets := make([]DetectedSecret, 0, len(matches))
+	for _, m := range matches {
+		secrets = append(secrets, DetectedSecret{
+			Type:    m.name,
+			Snippet: secretSnippet(content[m.start:m.end]),
+			Line:    strings.Count(content[:m.start], "\n") + 1,
+		})
+	}
+	return secrets
+}
+
+// RedactSecrets replaces every secret ScanSecrets would report with a
+// [REDACTED:<type>] placeholder.
+func RedactSecrets(content string) (string, []DetectedSecret) {
+	matches := scanSecretMatches(content)
+	if len(matches) == 0 {
+		return content, nil
+	}
+
+	var sb strings.Builder
+	secrets := make([]DetectedSecret, 0, len(matches))
+	last := 0
+	for _, m := range matches {
+		secrets = append(secrets, DetectedSecret{
+			Type:    m.name,
+			Snippet: secretSnippet(content[m.start:m.end]),
+			Line:    strings.Count(content[:m.start], "\n") + 1,
+		})
+		sb.WriteString(content[last:m.start])
+		sb.WriteString("[REDACTED:" + m.name + "]")
+		last = m.end
+	}
+	sb.WriteString(content[last:])
+	return sb.String(), secrets
+}
+
+// CountRedactionPlaceholders returns how many redaction placeholders content
+// holds. Writing a placeholder back to a file would replace the real value,
+// so file tools refuse content that adds any.
+func CountRedactionPlaceholders(content string) int {
+	return len(redactionPlaceholderRegex.FindAllStringIndex(content, -1))
+}
+
+func scanSecretMatches(content string) []secretMatch {
+	var matches []secretMatch
+	for _, p := range knownSecretPatterns {
+		for _, loc := range p.re.FindAllStringSubmatchIndex(content, -1) {
+			start, end := loc[0], loc[1]
+			if p.group > 0 && len(loc) > 2*p.group+1 && loc[2*p.group] >= 0 {
+				start, end = loc[2*p.group], loc[2*p.group+1]
+			}
+			matches = append(matches, secretMatch{name: p.name, start: start, end: end})
+		}
+	}
+
+	for _, loc := range keyedValueRegex.FindAllStringSubmatchIndex(content, -1) {
+		start, end := loc[2], loc[3]
+		if isHighEntropySecret(content[start:end]) && !isHashDigest(content, start) && !overlapsAny(matches, start, end) {
+			matches = append(matches, secretMatch{name: "High Entropy String", start: start, end: end})
+		}
+	}
+
+	// Order by position and drop overlaps, keeping the earliest (then longest) match.
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].start != matches[j].start {
+			return matches[i].start < matches[j].start
+		}
+		return matches[i].end > matches[j].end
+	})
+	result := matches[:0]
+	end := -1
+	for _, m := range matches {
+		if m.start < end {
+			continue
+		}
+		result = append(result, m)
+		end = m.end
+	}
+	return result
+}
+
+// isHashDigest reports whether the token at start is the value of a digest,
+// such as h1:<base64> or sha512-<base64>.
+func isHashDigest(content string, start int) bool {
+	return hashValueRegex.MatchString(content[start:]) ||
+		hashPrefixRegex.MatchString(content[max(0, start-8):start])
+}
+
+func overlapsAny(matches []secretMatch, start, end int) bool {
+	for _, m := range matches {
+		if start < m.end && m.start < end {
+			return true
+		}
+	}
+	return false
+}
+
+// isHighEntropySecret reports whether token looks like a random credential:
+// mixed-case letters plus digits (which rules out hex digests, UUIDs and
+// ordinary words) with high Shannon entropy.
+func isHighEntropySecret(token string) bool {
+	if len(token) < entropyMinLength || len(token) > entropyMaxLength {
+		return false
+	}
+	if strings.HasPrefix(token, "REDACTED") {
+		return false
+	}
+	var hasUpper, hasLower, hasDigit bool
+	for _, r := range token {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			hasUpper = true
+		case r >= 'a' && r <= 'z':
+			hasLower = true
+		case r >= '0' && r <= '9':
+			hasDigit = true
+		}
+	}
+	if !hasUpper || !hasLower || !hasDigit {
+		return false
+	}
+	return shannonEntropy(token) >= entropyThreshold
+}
+
+// shannonEntropy returns the entropy of s in bits per character.
+func shannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	counts := make(map[rune]int)
+	for _, r := range s {
+		counts[r]++
+	}
+	n := float64(len(s))
+	entropy := 0.0
+	for _, c := range counts {
+		p := float64(c) / n
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+func secretSnippet(value string) string {
+	if len(value) > 40 {
+		return value[:37] + "..."
+	}
+	return value
+}
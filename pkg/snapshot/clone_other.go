@@ -0,0 +1,10 @@
+//go:build !linux && !darwin
+
+package snapshot
+
+import "errors"
+
+// cloneFile is unsupported here; files are copied instead.
+func cloneFile(src, dst string) error {
+	return errors.ErrUnsupported
+}
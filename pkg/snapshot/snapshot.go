@@ -0,0 +1,447 @@
+// Package snapshot takes whole-workspace snapshots independent of git, so
+// untracked and ignored files are covered too. Snapshots are stored per
+// project in .ledit/snapshots/<id>/: a manifest plus a copy of every file.
+// Files unchanged since the previous snapshot are hardlinked to its copy and
+// the rest are cloned copy-on-write where the filesystem supports it, so a
+// snapshot of a mostly unchanged workspace costs little time or space.
+package snapshot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alantheprice/ledit/pkg/utils"
+)
+
+// excludedDirs are never snapshotted or touched by a restore.
+var excludedDirs = map[string]bool{
+	".git": true, ".ledit": true, "node_modules": true, ".venv": true, "__pycache__": true, ".cache": true,
+}
+
+// Entry is one file or symlink in a snapshot, by slash-separated path
+// relative to the workspace root.
+type Entry struct {
+	Path    string      `json:"path"`
+	Mode    fs.FileMode `json:"mode"`
+	Size    int64       `json:"size"`
+	ModTime time.Time   `json:"mod_time"`
+	Link    string      `json:"link,omitempty"` // symlink target
+}
+
+// Snapshot describes a stored snapshot. Linked, Cloned and Copied count how
+// its files were stored.
+type Snapshot struct {
+	ID      string    `json:"id"`
+	Name    string    `json:"name,omitempty"`
+	Created time.Time `json:"created"`
+	Entries []Entry   `json:"entries"`
+	Dirs    []string  `json:"dirs"`
+
+	Files  int   `json:"files"`
+	Bytes  int64 `json:"bytes"`
+	Linked int   `json:"linked"`
+	Cloned int   `json:"cloned"`
+	Copied int   `json:"copied"`
+}
+
+// Label returns the snapshot's ID with its name, if it has one.
+func (s Snapshot) Label() string {
+	if s.Name == "" {
+		return s.ID
+	}
+	return fmt.Sprintf("%s (%s)", s.ID, s.Name)
+}
+
+// Summary describes the snapshot's size and how its files were stored.
+func (s Snapshot) Summary() string {
+	return fmt.Sprintf("%d files, %s (%d linked to the previous snapshot, %d cloned, %d copied)",
+		s.Files, utils.FormatFileSize(s.Bytes), s.Linked, s.Cloned, s.Copied)
+}
+
+// Dir returns the directory holding the project's snapshots.
+func Dir(root string) string {
+	return filepath.Join(root, ".ledit", "snapshots")
+}
+
+func filesDir(root, id string) string {
+	return filepath.Join(Dir(root), id, "files")
+}
+
+// List returns the project's snapshots, oldest first.
+func List(root string) ([]Snapshot, error) {
+	dirents, err := os.ReadDir(Dir(root))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var snapshots []Snapshot
+	for _, dirent := range dirents {
+		if _, err := strconv.Atoi(dirent.Name()); err != nil || !dirent.IsDir() {
+			continue
+		}
+		snap, err := load(root, dirent.Name())
+		if err != nil {
+			continue // interrupted while being created
+		}
+		snapshots = append(snapshots, snap)
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		a, _ := strconv.Atoi(snapshots[i].ID)
+		b, _ := strconv.Atoi(snapshots[j].ID)
+		return a < b
+	})
+	return snapshots, nil
+}
+
+func load(root, id string) (Snapshot, error) {
+	var snap Snapshot
+	data, err := os.ReadFile(filepath.Join(Dir(root), id, "manifest.json"))
+	if err != nil {
+		return snap, err
+	}
+	err = json.Unmarshal(data, &snap)
+	return snap, err
+}
+
+// Find returns the snapshot with the given ID or name. When several have
+// the name, the newest wins.
+func Find(root, ref string) (Snapshot, error) {
+	snapshots, err := List(root)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	for i := len(snapshots) - 1; i >= 0; i-- {
+		if snapshots[i].ID == ref || snapshots[i].Name == ref {
+			return snapshots[i], nil
+		}
+	}
+	return Snapshot{}, fmt.Errorf("no snapshot %q", ref)
+}
+
+// Create snapshots every file under root outside excludedDirs.
+func Create(root, name string) (Snapshot, error) {
+	snapshots, err := List(root)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	previous := map[string]Entry{}
+	previousFiles := ""
+	if len(snapshots) > 0 {
+		latest := snapshots[len(snapshots)-1]
+		for _, entry := range latest.Entries {
+			previous[entry.Path] = entry
+		}
+		previousFiles = filesDir(root, latest.ID)
+	}
+
+	snap := Snapshot{Name: strings.TrimSpace(name), Created: time.Now()}
+	dir, err := reserveID(root, snapshots, &snap)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	if err := snap.store(root, filepath.Join(dir, "files"), previous, previousFiles); err != nil {
+		os.RemoveAll(dir)
+		return Snapshot{}, err
+	}
+
+	// The manifest is written last: a snapshot without one is incomplete.
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err == nil {
+		err = os.WriteFile(filepath.Join(dir, "manifest.json"), data, 0644)
+	}
+	if err != nil {
+		os.RemoveAll(dir)
+		return Snapshot{}, fmt.Errorf("failed to write snapshot manifest: %w", err)
+	}
+	return snap, nil
+}
+
+// reserveID creates the directory of the next snapshot ID and sets snap.ID.
+func reserveID(root string, snapshots []Snapshot, snap *Snapshot) (string, error) {
+	if err := os.MkdirAll(Dir(root), 0755); err != nil {
+		return "", fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	highest := 0
+	if len(snapshots) > 0 {
+		highest, _ = strconv.Atoi(snapshots[len(snapshots)-1].ID)
+	}
+	for id := highest + 1; ; id++ {
+		dir := filepath.Join(Dir(root), strconv.Itoa(id))
+		err := os.Mkdir(dir, 0755)
+		if errors.Is(err, fs.ErrExist) {
+			continue // left by an interrupted snapshot or taken concurrently
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to create snapshot directory: %w", err)
+		}
+		snap.ID = strconv.Itoa(id)
+		return dir, nil
+	}
+}
+
+// store copies the workspace into files, hardlinking files unchanged since
+// the previous snapshot to its copies.
+func (snap *Snapshot) store(root, files string, previous map[string]Entry, previousFiles string) error {
+	if err := os.Mkdir(files, 0755); err != nil {
+		return err
+	}
+	return walk(root, func(rel string, d fs.DirEntry, info fs.FileInfo) error {
+		dst := filepath.Join(files, filepath.FromSlash(rel))
+		if d.IsDir() {
+			snap.Dirs = append(snap.Dirs, rel)
+			return os.MkdirAll(dst, 0755)
+		}
+		entry := Entry{Path: rel, Mode: info.Mode(), Size: info.Size(), ModTime: info.ModTime()}
+		if info.Mode()&fs.ModeSymlink != 0 {
+			target, err := os.Readlink(filepath.Join(root, filepath.FromSlash(rel)))
+			if err != nil {
+				return err
+			}
+			entry.Link = target
+			snap.Entries = append(snap.Entries, entry)
+			return nil
+		}
+
+		src := filepath.Join(root, filepath.FromSlash(rel))
+		switch prev, ok := previous[rel]; {
+		case ok && sameFile(prev, info) && os.Link(filepath.Join(previousFiles, filepath.FromSlash(rel)), dst) == nil:
+			snap.Linked++
+		case cloneFile(src, dst) == nil:
+			snap.Cloned++
+		default:
+			if err := copyFile(src, dst); err != nil {
+				return fmt.Errorf("failed to snapshot %s: %w", rel, err)
+			}
+			snap.Copied++
+		}
+		snap.Entries = append(snap.Entries, entry)
+		snap.Files++
+		snap.Bytes += info.Size()
+		return nil
+	})
+}
+
+// walk calls fn for every directory, regular file and symlink under root
+// outside excludedDirs, with its slash-separated relative path.
+func walk(root string, fn func(rel string, d fs.DirEntry, info fs.FileInfo) error) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == root {
+			return nil
+		}
+		if d.IsDir() && excludedDirs[d.Name()] {
+			return filepath.SkipDir
+		}
+		if !d.IsDir() && !d.Type().IsRegular() && d.Type()&fs.ModeSymlink == 0 {
+			return nil // sockets, devices and pipes
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		return fn(filepath.ToSlash(rel), d, info)
+	})
+}
+
+// sameFile reports whether info still looks like the file entry recorded.
+func sameFile(entry Entry, info fs.FileInfo) bool {
+	return entry.Link == "" && info.Mode().IsRegular() && info.Mode().Perm() == entry.Mode.Perm() &&
+		info.Size() == entry.Size && info.ModTime().Equal(entry.ModTime)
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	return out.Close()
+}
+
+// RestoreOptions controls Restore.
+type RestoreOptions struct {
+	DryRun   bool // report the changes without making them
+	NoBackup bool // skip the snapshot of the current state taken first
+}
+
+// RestoreResult lists what a restore changed, by relative path.
+type RestoreResult struct {
+	Backup    Snapshot // snapshot of the workspace before the restore
+	Restored  []string
+	Deleted   []string
+	Unchanged int
+}
+
+// Restore returns the workspace to snapshot ref: changed and missing files
+// are restored and files created since are deleted. Unless opts.NoBackup is
+// set, the current state is snapshotted first so the restore can be undone.
+func Restore(root, ref string, opts RestoreOptions) (RestoreResult, error) {
+	var result RestoreResult
+	snap, err := Find(root, ref)
+	if err != nil {
+		return result, err
+	}
+	if !opts.DryRun && !opts.NoBackup {
+		if result.Backup, err = Create(root, "before restore of "+snap.ID); err != nil {
+			return result, fmt.Errorf("failed to snapshot the workspace before restoring: %w", err)
+		}
+	}
+
+	want := make(map[string]Entry, len(snap.Entries))
+	for _, entry := range snap.Entries {
+		want[entry.Path] = entry
+	}
+	wantDirs := make(map[string]bool, len(snap.Dirs))
+	for _, dir := range snap.Dirs {
+		wantDirs[dir] = true
+	}
+
+	// Delete what the snapshot doesn't have, remembering directories to
+	// remove once they're empty.
+	var extraDirs []string
+	err = walk(root, func(rel string, d fs.DirEntry, info fs.FileInfo) error {
+		_, isFile := want[rel]
+		switch {
+		case d.IsDir() && wantDirs[rel]:
+			return nil
+		case d.IsDir() && !isFile:
+			extraDirs = append(extraDirs, rel)
+			return nil
+		case !d.IsDir() && isFile:
+			return nil
+		}
+		result.Deleted = append(result.Deleted, rel)
+		if opts.DryRun {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if err := os.RemoveAll(filepath.Join(root, filepath.FromSlash(rel))); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return result, fmt.Errorf("failed to restore snapshot %s: %w", snap.ID, err)
+	}
+
+	files := filesDir(root, snap.ID)
+	for _, dir := range snap.Dirs {
+		if opts.DryRun {
+			break
+		}
+		if err := os.MkdirAll(filepath.Join(root, filepath.FromSlash(dir)), 0755); err != nil {
+			return result, err
+		}
+	}
+	for _, entry := range snap.Entries {
+		path := filepath.Join(root, filepath.FromSlash(entry.Path))
+		if info, err := os.Lstat(path); err == nil && unchanged(entry, path, info) {
+			result.Unchanged++
+			continue
+		}
+		result.Restored = append(result.Restored, entry.Path)
+		if opts.DryRun {
+			continue
+		}
+		if err := restoreEntry(entry, path, filepath.Join(files, filepath.FromSlash(entry.Path))); err != nil {
+			return result, fmt.Errorf("failed to restore %s: %w", entry.Path, err)
+		}
+	}
+
+	// Deepest first, so parents empty out before they're tried.
+	if !opts.DryRun {
+		sort.Sort(sort.Reverse(sort.StringSlice(extraDirs)))
+		for _, dir := range extraDirs {
+			os.Remove(filepath.Join(root, filepath.FromSlash(dir)))
+		}
+	}
+	return result, nil
+}
+
+func unchanged(entry Entry, path string, info fs.FileInfo) bool {
+	if entry.Link != "" {
+		target, err := os.Readlink(path)
+		return err == nil && info.Mode()&fs.ModeSymlink != 0 && target == entry.Link
+	}
+	return sameFile(entry, info)
+}
+
+// restoreEntry puts entry back at path from its stored copy, replacing
+// whatever is there.
+func restoreEntry(entry Entry, path, stored string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if info, err := os.Lstat(path); err == nil && info.IsDir() {
+		if err := os.RemoveAll(path); err != nil {
+			return err
+		}
+	}
+	if entry.Link != "" {
+		os.Remove(path)
+		return os.Symlink(entry.Link, path)
+	}
+
+	// Written beside the file and renamed over it, so it is never left half
+	// restored.
+	tmp := filepath.Join(filepath.Dir(path), ".ledit-restore-"+filepath.Base(path))
+	os.Remove(tmp)
+	if err := cloneFile(stored, tmp); err != nil {
+		if err := copyFile(stored, tmp); err != nil {
+			return err
+		}
+	}
+	if err := os.Chmod(tmp, entry.Mode.Perm()); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Chtimes(tmp, entry.ModTime, entry.ModTime); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// Delete removes snapshot ref.
+func Delete(root, ref string) (Snapshot, error) {
+	snap, err := Find(root, ref)
+	if err != nil {
+		return snap, err
+	}
+	return snap, os.RemoveAll(filepath.Join(Dir(root), snap.ID))
+}
@@ -0,0 +1,152 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func writeFile(t *testing.T, root, rel, content string) {
+	t.Helper()
+	path := filepath.Join(root, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, root, rel string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(rel)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestCreateAndRestore(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "main.go", "package main\n")
+	writeFile(t, root, "docs/notes.txt", "untracked notes\n")
+	writeFile(t, root, "node_modules/dep/index.js", "ignored\n")
+	if err := os.MkdirAll(filepath.Join(root, "empty"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("main.go", filepath.Join(root, "link.go")); err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := Create(root, "before refactor")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if first.ID != "1" || first.Files != 2 || first.Linked != 0 || len(first.Entries) != 3 {
+		t.Fatalf("unexpected first snapshot %+v", first)
+	}
+
+	// Unchanged files are hardlinked to the previous snapshot's copy
+	writeFile(t, root, "main.go", "package main\n\nfunc main() {}\n")
+	second, err := Create(root, "")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if second.ID != "2" || second.Linked != 1 || second.Cloned+second.Copied != 1 {
+		t.Fatalf("expected docs/notes.txt to be linked, got %+v", second)
+	}
+
+	writeFile(t, root, "docs/notes.txt", "rewritten\n")
+	writeFile(t, root, "new/file.txt", "created after\n")
+	os.RemoveAll(filepath.Join(root, "empty"))
+	os.Remove(filepath.Join(root, "link.go"))
+
+	dry, err := Restore(root, "before refactor", RestoreOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if !slices.Equal(dry.Restored, []string{"docs/notes.txt", "link.go", "main.go"}) || !slices.Equal(dry.Deleted, []string{"new/file.txt"}) {
+		t.Fatalf("unexpected dry run %+v", dry)
+	}
+	if readFile(t, root, "docs/notes.txt") != "rewritten\n" {
+		t.Fatal("expected a dry run to change nothing")
+	}
+
+	result, err := Restore(root, "1", RestoreOptions{})
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if result.Backup.ID != "3" || len(result.Restored) != 3 || len(result.Deleted) != 1 {
+		t.Fatalf("unexpected restore %+v", result)
+	}
+	if readFile(t, root, "main.go") != "package main\n" || readFile(t, root, "docs/notes.txt") != "untracked notes\n" {
+		t.Fatal("expected file contents to be restored")
+	}
+	if target, err := os.Readlink(filepath.Join(root, "link.go")); err != nil || target != "main.go" {
+		t.Fatalf("expected the symlink to be restored, got %q, %v", target, err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "new")); !os.IsNotExist(err) {
+		t.Fatal("expected files and directories created after the snapshot to be deleted")
+	}
+	if info, err := os.Stat(filepath.Join(root, "empty")); err != nil || !info.IsDir() {
+		t.Fatal("expected the empty directory to be recreated")
+	}
+	if readFile(t, root, "node_modules/dep/index.js") != "ignored\n" {
+		t.Fatal("expected excluded directories to be left alone")
+	}
+
+	// Restoring the stored copy must not let later edits reach back into it
+	writeFile(t, root, "main.go", "edited again\n")
+	again, err := Restore(root, "1", RestoreOptions{NoBackup: true})
+	if err != nil || !slices.Equal(again.Restored, []string{"main.go"}) || again.Unchanged != 2 {
+		t.Fatalf("unexpected second restore %+v, %v", again, err)
+	}
+	if readFile(t, root, "main.go") != "package main\n" {
+		t.Fatal("expected the snapshot's copy to be unaffected by edits")
+	}
+
+	// The backup undoes the restore
+	if _, err := Restore(root, result.Backup.ID, RestoreOptions{NoBackup: true}); err != nil {
+		t.Fatalf("Restore backup: %v", err)
+	}
+	if readFile(t, root, "new/file.txt") != "created after\n" || readFile(t, root, "docs/notes.txt") != "rewritten\n" {
+		t.Fatal("expected the backup to bring back the pre-restore state")
+	}
+}
+
+func TestListFindAndDelete(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "a.txt", "a")
+	for _, name := range []string{"one", "two"} {
+		if _, err := Create(root, name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// An interrupted snapshot has no manifest and isn't listed
+	if err := os.MkdirAll(filepath.Join(Dir(root), "3", "files"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshots, err := List(root)
+	if err != nil || len(snapshots) != 2 || snapshots[1].Label() != "2 (two)" {
+		t.Fatalf("unexpected list %+v, %v", snapshots, err)
+	}
+	if snap, err := Find(root, "one"); err != nil || snap.ID != "1" {
+		t.Fatalf("expected to find a snapshot by name, got %+v, %v", snap, err)
+	}
+	if snap, err := Create(root, ""); err != nil || snap.ID != "4" {
+		t.Fatalf("expected the next ID to skip the leftover directory, got %+v, %v", snap, err)
+	}
+
+	if _, err := Delete(root, "1"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := Find(root, "1"); err == nil {
+		t.Fatal("expected the deleted snapshot to be gone")
+	}
+	// Hardlinked copies survive deleting the snapshot they were linked from
+	if _, err := Restore(root, "two", RestoreOptions{NoBackup: true}); err != nil || readFile(t, root, "a.txt") != "a" {
+		t.Fatalf("Restore after delete: %v", err)
+	}
+}
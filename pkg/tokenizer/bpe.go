@@ -0,0 +1,148 @@
+package tokenizer
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Pre-tokenizer patterns of OpenAI's encodings with \s spelled out as
+// Unicode whitespace. Go's regexp has no lookahead, so the patterns' final
+// `\s+(?!\S)|\s+` is matched as `\s+` and adjusted in split.
+const (
+	ws    = `\t\n\v\f\r \x{85}\p{Z}`
+	cl100 = `(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}| ?[^` + ws + `\p{L}\p{N}]+[\r\n]*|[` + ws + `]*[\r\n]+|[` + ws + `]+`
+	o200k = `[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]*[\p{Ll}\p{Lm}\p{Lo}\p{M}]+(?i:'s|'t|'re|'ve|'m|'ll|'d)?` +
+		`|[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]+[\p{Ll}\p{Lm}\p{Lo}\p{M}]*(?i:'s|'t|'re|'ve|'m|'ll|'d)?` +
+		`|\p{N}{1,3}| ?[^` + ws + `\p{L}\p{N}]+[\r\n/]*|[` + ws + `]*[\r\n]+|[` + ws + `]+`
+)
+
+var (
+	cl100kSplitter = regexp.MustCompile(`^(?:` + cl100 + `)`)
+	o200kSplitter  = regexp.MustCompile(`^(?:` + o200k + `)`)
+	whitespaceOnly = regexp.MustCompile(`^[` + ws + `]+$`)
+)
+
+// maxMergePiece bounds the quadratic merge loop; longer pieces are counted
+// in chunks of this many bytes.
+const maxMergePiece = 2048
+
+// BPE counts tokens the way tiktoken encodes them: the text is split with
+// the encoding's pre-tokenizer and each piece's bytes are merged by rank.
+type BPE struct {
+	name     string
+	ranks    map[string]int
+	splitter *regexp.Regexp
+}
+
+// LoadTiktoken reads a .tiktoken rank file (a base64 token and its rank per
+// line), such as cl100k_base.tiktoken. Files named o200k* use the o200k
+// pre-tokenizer; the rest use cl100k's.
+func LoadTiktoken(path string) (*BPE, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	b := &BPE{name: name, ranks: make(map[string]int, 200_000), splitter: cl100kSplitter}
+	if strings.HasPrefix(name, "o200k") {
+		b.splitter = o200kSplitter
+	}
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		token, rank, ok := strings.Cut(strings.TrimSpace(scanner.Text()), " ")
+		if !ok {
+			continue
+		}
+		raw, err := base64.StdEncoding.DecodeString(token)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid token: %w", path, line, err)
+		}
+		n, err := strconv.Atoi(rank)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid rank: %w", path, line, err)
+		}
+		b.ranks[string(raw)] = n
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(b.ranks) == 0 {
+		return nil, fmt.Errorf("%s has no tokens", path)
+	}
+	return b, nil
+}
+
+// Name returns the encoding name, such as "cl100k_base".
+func (b *BPE) Name() string { return b.name }
+
+// Count returns the number of tokens text encodes to.
+func (b *BPE) Count(text string) int {
+	total := 0
+	for _, piece := range b.split(text) {
+		for len(piece) > maxMergePiece {
+			total += b.countPiece(piece[:maxMergePiece])
+			piece = piece[maxMergePiece:]
+		}
+		total += b.countPiece(piece)
+	}
+	return total
+}
+
+// split applies the pre-tokenizer. A run of whitespace followed by other
+// text gives up its last character to the next piece, as `\s+(?!\S)` does.
+func (b *BPE) split(text string) []string {
+	var pieces []string
+	for len(text) > 0 {
+		end := 0
+		if loc := b.splitter.FindStringIndex(text); loc != nil {
+			end = loc[1]
+		}
+		if end == 0 {
+			_, end = utf8.DecodeRuneInString(text)
+		}
+		piece := text[:end]
+		if end < len(text) && whitespaceOnly.MatchString(piece) && !strings.HasSuffix(piece, "\n") &&
+			!strings.HasSuffix(piece, "\r") && utf8.RuneCountInString(piece) > 1 {
+			_, size := utf8.DecodeLastRuneInString(piece)
+			end -= size
+		}
+		pieces = append(pieces, text[:end])
+		text = text[end:]
+	}
+	return pieces
+}
+
+// countPiece merges piece's bytes by rank, lowest first, and returns the
+// number of parts left.
+func (b *BPE) countPiece(piece string) int {
+	if _, ok := b.ranks[piece]; ok {
+		return 1
+	}
+	// bounds[i] is where part i starts; the last entry is len(piece).
+	bounds := make([]int, len(piece)+1)
+	for i := range bounds {
+		bounds[i] = i
+	}
+	for len(bounds) > 2 {
+		best, bestRank := -1, 0
+		for i := 0; i+2 < len(bounds); i++ {
+			if rank, ok := b.ranks[piece[bounds[i]:bounds[i+2]]]; ok && (best < 0 || rank < bestRank) {
+				best, bestRank = i, rank
+			}
+		}
+		if best < 0 {
+			break
+		}
+		bounds = append(bounds[:best+1], bounds[best+2:]...)
+	}
+	return len(bounds) - 1
+}
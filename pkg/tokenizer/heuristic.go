@@ -0,0 +1,47 @@
+package tokenizer
+
+import "strings"
+
+// Heuristic estimates tokens from word and character counts. It is the
+// fallback when no tokenizer file is available for the model.
+type Heuristic struct{}
+
+// Name returns "heuristic".
+func (Heuristic) Name() string { return "heuristic" }
+
+// Count estimates the tokens in text: about 0.75 tokens per word for prose,
+// 1.2 for code, or a quarter of the characters when that is higher, plus
+// half a token per newline or tab.
+func (Heuristic) Count(text string) int {
+	if text == "" {
+		return 0
+	}
+
+	words := strings.Fields(text)
+	specialTokens := 0
+	for _, char := range text {
+		if char == '\n' || char == '\r' || char == '\t' {
+			specialTokens++
+		}
+	}
+
+	tokensPerWord := 0.75
+	if LooksLikeCode(text) {
+		tokensPerWord = 1.2
+	}
+	wordTokens := float64(len(words)) * tokensPerWord
+	charTokens := float64(len(text)) * 0.25
+	total := int(max(wordTokens, charTokens) + float64(specialTokens)*0.5)
+	return max(total, 1)
+}
+
+// LooksLikeCode reports whether text appears to be source code.
+func LooksLikeCode(text string) bool {
+	for _, marker := range []string{"func ", "import ", "package ", "if ", "for ", "return ", "var ", "const ", "struct ",
+		"interface ", "func(", "{\n", "}\n", "();", "= {", "=> {"} {
+		if strings.Contains(text, marker) {
+			return true
+		}
+	}
+	return false
+}
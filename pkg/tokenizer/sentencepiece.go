@@ -0,0 +1,198 @@
+package tokenizer
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// SentencePiece piece types from sentencepiece_model.proto.
+const (
+	spNormal      = 1
+	spUnknown     = 2
+	spControl     = 3
+	spUserDefined = 4
+	spByte        = 6
+)
+
+// maxPieceRunes caps the piece lengths tried at each position.
+const maxPieceRunes = 32
+
+// SentencePiece counts tokens with a sentencepiece .model file, as used by
+// Llama 2, Mistral and Gemma. Text is segmented into the pieces with the
+// best total score (exact for unigram models, a close match for BPE ones);
+// characters no piece covers count one token per UTF-8 byte when the model
+// has byte fallback, otherwise one unknown token.
+type SentencePiece struct {
+	name         string
+	scores       map[string]float32
+	maxRunes     int
+	byteFallback bool
+	dummyPrefix  bool
+}
+
+// LoadSentencePiece reads a sentencepiece ModelProto.
+func LoadSentencePiece(path string) (*SentencePiece, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	sp := &SentencePiece{
+		name:        strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)),
+		scores:      make(map[string]float32),
+		dummyPrefix: true,
+	}
+	err = readProto(data, func(field int, value []byte, varint uint64) error {
+		switch field {
+		case 1: // pieces
+			return sp.addPiece(value)
+		case 3: // normalizer_spec
+			return readProto(value, func(field int, _ []byte, varint uint64) error {
+				if field == 3 { // add_dummy_prefix
+					sp.dummyPrefix = varint != 0
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if len(sp.scores) == 0 {
+		return nil, fmt.Errorf("%s has no pieces", path)
+	}
+	return sp, nil
+}
+
+func (sp *SentencePiece) addPiece(message []byte) error {
+	var piece string
+	var score float32
+	kind := uint64(spNormal)
+	err := readProto(message, func(field int, value []byte, varint uint64) error {
+		switch field {
+		case 1:
+			piece = string(value)
+		case 2:
+			if len(value) == 4 {
+				score = math.Float32frombits(binary.LittleEndian.Uint32(value))
+			}
+		case 3:
+			kind = varint
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	switch kind {
+	case spByte:
+		sp.byteFallback = true
+	case spNormal, spUserDefined:
+		sp.scores[piece] = score
+		sp.maxRunes = max(sp.maxRunes, min(utf8.RuneCountInString(piece), maxPieceRunes))
+	case spUnknown, spControl:
+	}
+	return nil
+}
+
+// readProto calls fn for each field of a protobuf message: value holds the
+// bytes of length-delimited and fixed32 fields, varint the value of varint
+// fields.
+func readProto(data []byte, fn func(field int, value []byte, varint uint64) error) error {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return errors.New("invalid field key")
+		}
+		data = data[n:]
+		field, wire := int(key>>3), key&7
+		var value []byte
+		var varint uint64
+		switch wire {
+		case 0:
+			varint, n = binary.Uvarint(data)
+			if n <= 0 {
+				return errors.New("invalid varint")
+			}
+			data = data[n:]
+		case 1, 5:
+			size := 8
+			if wire == 5 {
+				size = 4
+			}
+			if len(data) < size {
+				return errors.New("truncated fixed-size field")
+			}
+			value, data = data[:size], data[size:]
+		case 2:
+			length, n := binary.Uvarint(data)
+			if n <= 0 || uint64(len(data)-n) < length {
+				return errors.New("truncated length-delimited field")
+			}
+			value, data = data[n:n+int(length)], data[n+int(length):]
+		default:
+			return fmt.Errorf("unsupported wire type %d", wire)
+		}
+		if err := fn(field, value, varint); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Name returns the model file's base name.
+func (sp *SentencePiece) Name() string { return sp.name }
+
+// Count returns the number of pieces text is segmented into.
+func (sp *SentencePiece) Count(text string) int {
+	if text == "" {
+		return 0
+	}
+	normalized := strings.ReplaceAll(strings.ToValidUTF8(text, "\uFFFD"), " ", "▁")
+	if sp.dummyPrefix {
+		normalized = "▁" + normalized
+	}
+	runes := []rune(normalized)
+	// offsets[i] is the byte offset of rune i.
+	offsets := make([]int, len(runes)+1)
+	for i, r := range runes {
+		offsets[i+1] = offsets[i] + utf8.RuneLen(r)
+	}
+
+	// best[i] is the highest score of a segmentation of the first i runes
+	// and count[i] its number of tokens.
+	best := make([]float64, len(runes)+1)
+	count := make([]int, len(runes)+1)
+	for i := 1; i <= len(runes); i++ {
+		best[i] = math.Inf(-1)
+	}
+	const unknownScore = -100.0
+	for start := 0; start < len(runes); start++ {
+		if math.IsInf(best[start], -1) {
+			continue
+		}
+		for length := 1; length <= sp.maxRunes && start+length <= len(runes); length++ {
+			score, ok := sp.scores[normalized[offsets[start]:offsets[start+length]]]
+			if ok && best[start]+float64(score) > best[start+length] {
+				best[start+length] = best[start] + float64(score)
+				count[start+length] = count[start] + 1
+			}
+		}
+		// A character without a piece of its own becomes byte or unknown tokens.
+		if _, ok := sp.scores[string(runes[start])]; !ok && best[start]+unknownScore > best[start+1] {
+			tokens := 1
+			if sp.byteFallback {
+				tokens = utf8.RuneLen(runes[start])
+			}
+			best[start+1] = best[start] + unknownScore
+			count[start+1] = count[start] + tokens
+		}
+	}
+	return count[len(runes)]
+}
@@ -0,0 +1,152 @@
+// Package tokenizer counts tokens the way model providers do: with
+// tiktoken-compatible BPE rank files for OpenAI-style models and
+// sentencepiece models for others, falling back to a heuristic estimate
+// when no tokenizer file is available.
+package tokenizer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Tokenizer counts the tokens in a piece of text.
+type Tokenizer interface {
+	// Name identifies the encoding, such as "cl100k_base" or "heuristic".
+	Name() string
+	Count(text string) int
+}
+
+// builtinEncodings maps model name prefixes to the tiktoken encoding they
+// use. The longest matching prefix wins.
+var builtinEncodings = map[string]string{
+	"gpt-5":                  "o200k_base",
+	"gpt-4.1":                "o200k_base",
+	"gpt-4o":                 "o200k_base",
+	"chatgpt-4o":             "o200k_base",
+	"o1":                     "o200k_base",
+	"o3":                     "o200k_base",
+	"o4":                     "o200k_base",
+	"gpt-oss":                "o200k_base",
+	"gpt-4":                  "cl100k_base",
+	"gpt-3.5":                "cl100k_base",
+	"text-embedding-3":       "cl100k_base",
+	"text-embedding-ada-002": "cl100k_base",
+}
+
+// Encodings lists the tiktoken encodings the built-in model mapping uses.
+var Encodings = []string{"cl100k_base", "o200k_base"}
+
+var (
+	mu     sync.RWMutex
+	dir    string
+	files  map[string]string
+	loaded           = make(map[string]Tokenizer)
+	active Tokenizer = Heuristic{}
+)
+
+// Configure sets the directory tokenizer files are looked up in and the
+// model-prefix to file mapping from the tokenizers config option. Relative
+// file paths are resolved against dir.
+func Configure(tokenizerDir string, modelFiles map[string]string) {
+	mu.Lock()
+	defer mu.Unlock()
+	dir = tokenizerDir
+	files = modelFiles
+}
+
+// Dir returns the configured tokenizer directory.
+func Dir() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return dir
+}
+
+// SetModel makes the tokenizer for model the one Count uses.
+func SetModel(model string) {
+	t := ForModel(model)
+	mu.Lock()
+	active = t
+	mu.Unlock()
+}
+
+// Active returns the tokenizer Count uses.
+func Active() Tokenizer {
+	mu.RLock()
+	defer mu.RUnlock()
+	return active
+}
+
+// Count counts the tokens in text with the active tokenizer.
+func Count(text string) int {
+	return Active().Count(text)
+}
+
+// ForModel returns the tokenizer for model: a configured file for the
+// longest matching model prefix, else the built-in encoding for OpenAI
+// models when its rank file is in the tokenizer directory, else the
+// heuristic. Provider prefixes such as "openai/" are ignored.
+func ForModel(model string) Tokenizer {
+	name := strings.ToLower(model)
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+
+	mu.RLock()
+	baseDir, configured := dir, files
+	mu.RUnlock()
+
+	if prefix := longestPrefix(name, configured); prefix != "" {
+		path := configured[prefix]
+		if !filepath.IsAbs(path) && baseDir != "" {
+			path = filepath.Join(baseDir, path)
+		}
+		if t := load(path); t != nil {
+			return t
+		}
+	}
+	if prefix := longestPrefix(name, builtinEncodings); prefix != "" && baseDir != "" {
+		if t := load(filepath.Join(baseDir, builtinEncodings[prefix]+".tiktoken")); t != nil {
+			return t
+		}
+	}
+	return Heuristic{}
+}
+
+func longestPrefix(name string, prefixes map[string]string) string {
+	best := ""
+	for prefix := range prefixes {
+		if strings.HasPrefix(name, strings.ToLower(prefix)) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	return best
+}
+
+// load returns the tokenizer in path, reading it on first use: .model files
+// are sentencepiece models, anything else a tiktoken rank file. It returns
+// nil when the file is missing or invalid.
+func load(path string) Tokenizer {
+	mu.RLock()
+	t, ok := loaded[path]
+	mu.RUnlock()
+	if ok {
+		return t
+	}
+	if _, err := os.Stat(path); err != nil {
+		return nil
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".model") {
+		if sp, err := LoadSentencePiece(path); err == nil {
+			t = sp
+		}
+	} else if bpe, err := LoadTiktoken(path); err == nil {
+		t = bpe
+	}
+	mu.Lock()
+	loaded[path] = t
+	mu.Unlock()
+	return t
+}
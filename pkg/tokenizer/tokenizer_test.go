@@ -0,0 +1,162 @@
+package tokenizer
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// writeTiktoken writes a rank file with every single byte plus merges.
+func writeTiktoken(t *testing.T, path string, merges ...string) {
+	t.Helper()
+	var b strings.Builder
+	for i := 0; i < 256; i++ {
+		fmt.Fprintf(&b, "%s %d\n", base64.StdEncoding.EncodeToString([]byte{byte(i)}), i)
+	}
+	for i, merge := range merges {
+		fmt.Fprintf(&b, "%s %d\n", base64.StdEncoding.EncodeToString([]byte(merge)), 256+i)
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func protoBytes(field int, value []byte) []byte {
+	out := binary.AppendUvarint(nil, uint64(field<<3|2))
+	out = binary.AppendUvarint(out, uint64(len(value)))
+	return append(out, value...)
+}
+
+func protoVarint(field int, value uint64) []byte {
+	return binary.AppendUvarint(binary.AppendUvarint(nil, uint64(field<<3)), value)
+}
+
+func spPiece(piece string, score float32, kind uint64) []byte {
+	msg := protoBytes(1, []byte(piece))
+	msg = append(msg, byte(2<<3|5))
+	msg = binary.LittleEndian.AppendUint32(msg, math.Float32bits(score))
+	return protoBytes(1, append(msg, protoVarint(3, kind)...))
+}
+
+// writeSentencePiece writes a small ModelProto with byte fallback.
+func writeSentencePiece(t *testing.T, path string, dummyPrefix bool) {
+	t.Helper()
+	var model []byte
+	model = append(model, spPiece("<unk>", 0, spUnknown)...)
+	model = append(model, spPiece("<0x74>", 0, spByte)...)
+	model = append(model, spPiece("▁hello", -1, spNormal)...)
+	model = append(model, spPiece("▁", -2, spNormal)...)
+	for _, c := range []string{"h", "e", "l", "o"} {
+		model = append(model, spPiece(c, -5, spNormal)...)
+	}
+	prefix := uint64(0)
+	if dummyPrefix {
+		prefix = 1
+	}
+	model = append(model, protoBytes(3, protoVarint(3, prefix))...)
+	if err := os.WriteFile(path, model, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBPESplitAndCount(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cl100k_base.tiktoken")
+	writeTiktoken(t, path, "ab", " a", " ab")
+	bpe, err := LoadTiktoken(path)
+	if err != nil {
+		t.Fatalf("LoadTiktoken: %v", err)
+	}
+
+	if got := bpe.split("a  b\n\nc"); !slices.Equal(got, []string{"a", " ", " b", "\n\n", "c"}) {
+		t.Fatalf("split = %q", got)
+	}
+	if got := bpe.Count("ab ab"); got != 2 {
+		t.Fatalf("Count(ab ab) = %d, want 2", got)
+	}
+	if got := bpe.Count("abc"); got != 2 {
+		t.Fatalf("Count(abc) = %d, want 2", got)
+	}
+	if got := bpe.Count(strings.Repeat("x", 5000)); got != 5000 {
+		t.Fatalf("expected unmerged bytes to count one token each, got %d", got)
+	}
+}
+
+func TestSentencePieceCount(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tiny.model")
+	writeSentencePiece(t, path, true)
+	sp, err := LoadSentencePiece(path)
+	if err != nil {
+		t.Fatalf("LoadSentencePiece: %v", err)
+	}
+	if sp.Name() != "tiny" || !sp.byteFallback {
+		t.Fatalf("unexpected model %+v", sp)
+	}
+	if got := sp.Count("hello"); got != 1 {
+		t.Fatalf("Count(hello) = %d, want 1", got)
+	}
+	// ▁hello ▁ t(byte) h e r(byte) e
+	if got := sp.Count("hello there"); got != 7 {
+		t.Fatalf("Count(hello there) = %d, want 7", got)
+	}
+	if got := sp.Count("é"); got != 3 {
+		t.Fatalf("expected byte fallback for uncovered characters, got %d", got)
+	}
+
+	writeSentencePiece(t, filepath.Join(dir, "plain.model"), false)
+	plain, err := LoadSentencePiece(filepath.Join(dir, "plain.model"))
+	if err != nil {
+		t.Fatalf("LoadSentencePiece: %v", err)
+	}
+	if got := plain.Count("hello"); got != 5 {
+		t.Fatalf("Count(hello) without a dummy prefix = %d, want 5", got)
+	}
+}
+
+func TestForModelResolution(t *testing.T) {
+	dir := t.TempDir()
+	writeTiktoken(t, filepath.Join(dir, "o200k_base.tiktoken"), "ab")
+	writeTiktoken(t, filepath.Join(dir, "custom.tiktoken"))
+	writeSentencePiece(t, filepath.Join(dir, "llama.model"), true)
+	if err := os.WriteFile(filepath.Join(dir, "broken.tiktoken"), []byte("not base64!! x\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	Configure(dir, map[string]string{
+		"gpt-4o-mini": "custom.tiktoken",
+		"llama":       "llama.model",
+		"mistral":     "broken.tiktoken",
+	})
+	defer func() {
+		Configure("", nil)
+		SetModel("")
+	}()
+
+	for model, want := range map[string]string{
+		"gpt-4o":            "o200k_base",
+		"openai/gpt-5-mini": "o200k_base",
+		"gpt-4o-mini":       "custom",
+		"meta/Llama-3-70b":  "llama",
+		"gpt-4":             "heuristic", // cl100k_base.tiktoken isn't downloaded
+		"mistral-large":     "heuristic", // invalid file
+		"claude-sonnet-4":   "heuristic",
+	} {
+		if got := ForModel(model).Name(); got != want {
+			t.Errorf("ForModel(%q) = %s, want %s", model, got, want)
+		}
+	}
+
+	SetModel("gpt-4o")
+	if Active().Name() != "o200k_base" || Count("abab") != 2 {
+		t.Fatalf("expected the active tokenizer to follow SetModel, got %s counting %d", Active().Name(), Count("abab"))
+	}
+	SetModel("unknown-model")
+	if Count("abab") != (Heuristic{}).Count("abab") {
+		t.Fatal("expected the heuristic for models without a tokenizer")
+	}
+}
@@ -0,0 +1,373 @@
+// Package toolpolicy evaluates the declarative tool permission rules in a
+// workspace's .ledit/policy.yaml. Each rule allows, denies or asks for
+// approval of a tool category (shell, read, write, ...) or a single tool,
+// optionally narrowed by a pattern over the command, path or URL.
+package toolpolicy
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the policy file's path relative to the workspace root.
+var FileName = filepath.Join(".ledit", "policy.yaml")
+
+// Action is what a rule does with a matching tool call.
+type Action string
+
+const (
+	Allow Action = "allow"
+	Ask   Action = "ask"
+	Deny  Action = "deny"
+)
+
+// precedence orders actions so the most restrictive matching rule wins.
+func (a Action) precedence() int {
+	switch a {
+	case Deny:
+		return 3
+	case Ask:
+		return 2
+	case Allow:
+		return 1
+	}
+	return 0
+}
+
+// Rule is one parsed policy rule, e.g. "ask write: outside ./src".
+type Rule struct {
+	Action   Action
+	Target   string // a category, a tool name, or "*"
+	Pattern  string // "" matches every call
+	Outside  bool   // pattern was "outside <dir>"
+	Position int    // 1-based index in the policy file
+	matcher  *regexp.Regexp
+}
+
+// String renders the rule in policy file syntax.
+func (r Rule) String() string {
+	s := fmt.Sprintf("%s %s", r.Action, r.Target)
+	switch {
+	case r.Outside:
+		s += ": outside " + r.Pattern
+	case r.Pattern != "":
+		s += ": " + r.Pattern
+	}
+	return s
+}
+
+// Decision is the outcome of evaluating a tool call.
+type Decision struct {
+	Action  Action // "" when no rule matched
+	Rule    *Rule
+	Subject string // the command, path or URL the rule was matched against
+}
+
+// Matched reports whether any rule applied to the call.
+func (d Decision) Matched() bool {
+	return d.Rule != nil
+}
+
+// Policy is a parsed policy file. A nil Policy matches nothing.
+type Policy struct {
+	Path  string
+	Rules []Rule
+	// Sandbox, when set, runs shell commands in an isolated executor.
+	Sandbox *Sandbox
+}
+
+// Sandbox is the policy's "sandbox:" section. Matching shell commands run
+// with only the workspace writable and, unless Network is set, no network.
+type Sandbox struct {
+	// Backend is auto, bubblewrap, podman or docker.
+	Backend string `yaml:"backend"`
+	// Image is the container image for podman and docker.
+	Image   string `yaml:"image"`
+	Network bool   `yaml:"network"`
+	// Mounts are extra host paths made available read-only.
+	Mounts []string `yaml:"mounts"`
+	// Commands are shell patterns selecting the commands to sandbox; empty
+	// sandboxes every command.
+	Commands []string `yaml:"commands"`
+
+	matchers []*regexp.Regexp
+}
+
+// Applies reports whether command must run in the sandbox. Like shell rules,
+// patterns match each part of a compound command. A nil Sandbox applies to
+// nothing.
+func (s *Sandbox) Applies(command string) bool {
+	if s == nil {
+		return false
+	}
+	if len(s.matchers) == 0 {
+		return true
+	}
+	for _, segment := range shellSegments(command) {
+		for _, matcher := range s.matchers {
+			if matcher.MatchString(segment) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type policyFile struct {
+	Rules   []yaml.Node `yaml:"rules"`
+	Sandbox *Sandbox    `yaml:"sandbox"`
+}
+
+// Load reads the policy file for workspaceRoot. It returns (nil, nil) when the
+// workspace has no policy file.
+func Load(workspaceRoot string) (*Policy, error) {
+	path := filepath.Join(workspaceRoot, FileName)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	policy, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	policy.Path = path
+	return policy, nil
+}
+
+// Parse parses policy YAML. Rules may be written as strings
+// ("deny shell: rm*") or, when left unquoted, as single-key maps, which is
+// how YAML reads `- deny shell: rm*`.
+func Parse(data []byte) (*Policy, error) {
+	var file policyFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+
+	policy := &Policy{}
+	for i, node := range file.Rules {
+		var text string
+		switch {
+		case node.Kind == yaml.ScalarNode:
+			text = node.Value
+		case node.Kind == yaml.MappingNode && len(node.Content) == 2 && node.Content[1].Kind == yaml.ScalarNode:
+			text = node.Content[0].Value + ": " + node.Content[1].Value
+		default:
+			return nil, fmt.Errorf("rule %d (line %d): expected \"<allow|ask|deny> <target>: <pattern>\"", i+1, node.Line)
+		}
+		rule, err := ParseRule(text)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d (line %d): %w", i+1, node.Line, err)
+		}
+		rule.Position = i + 1
+		policy.Rules = append(policy.Rules, rule)
+	}
+
+	if sandbox := file.Sandbox; sandbox != nil {
+		for _, pattern := range sandbox.Commands {
+			if pattern = strings.TrimSpace(pattern); pattern != "" {
+				sandbox.matchers = append(sandbox.matchers, compileGlob(pattern, false))
+			}
+		}
+		policy.Sandbox = sandbox
+	}
+	return policy, nil
+}
+
+// ParseRule parses a single "<action> <target>[: <pattern>]" rule.
+func ParseRule(text string) (Rule, error) {
+	head, pattern, _ := strings.Cut(strings.TrimSpace(text), ":")
+	fields := strings.Fields(head)
+	if len(fields) != 2 {
+		return Rule{}, fmt.Errorf("invalid rule %q: expected \"<allow|ask|deny> <target>: <pattern>\"", text)
+	}
+
+	rule := Rule{Action: Action(strings.ToLower(fields[0])), Target: fields[1]}
+	if rule.Action.precedence() == 0 {
+		return Rule{}, fmt.Errorf("invalid rule %q: action must be allow, ask or deny", text)
+	}
+
+	pattern = strings.Trim(strings.TrimSpace(pattern), `"'`)
+	if rest, ok := strings.CutPrefix(pattern, "outside"); ok && (rest == "" || rest[0] == ' ') {
+		rule.Outside = true
+		pattern = strings.TrimSpace(rest)
+		if pattern == "" {
+			return Rule{}, fmt.Errorf("invalid rule %q: outside needs a directory", text)
+		}
+	}
+	if pattern == "*" || pattern == "**" {
+		pattern = ""
+	}
+	rule.Pattern = pattern
+
+	if pattern != "" && !rule.Outside {
+		if isPathCategory(rule.Target) || isPathCategory(toolCategories[rule.Target]) {
+			rule.matcher = compileGlob(normalizeRulePath(pattern), true)
+		} else {
+			rule.matcher = compileGlob(pattern, false)
+		}
+	}
+	return rule, nil
+}
+
+// Evaluate returns the decision of the most restrictive rule matching the
+// call (deny over ask over allow); among equally restrictive rules the first
+// one wins. workspaceRoot resolves relative paths and "outside" rules.
+func (p *Policy) Evaluate(toolName string, args map[string]interface{}, workspaceRoot string) Decision {
+	if p == nil {
+		return Decision{}
+	}
+	category, subjects := Subjects(toolName, args)
+
+	var decision Decision
+	for i := range p.Rules {
+		rule := &p.Rules[i]
+		if !rule.appliesTo(toolName, category) {
+			continue
+		}
+		for _, subject := range subjects {
+			if !rule.matches(category, subject, workspaceRoot) {
+				continue
+			}
+			if decision.Rule == nil || rule.Action.precedence() > decision.Action.precedence() {
+				decision = Decision{Action: rule.Action, Rule: rule, Subject: subject}
+			}
+			break
+		}
+	}
+	return decision
+}
+
+// Summary groups the rules by action, each group in file order.
+func (p *Policy) Summary() map[Action][]Rule {
+	summary := make(map[Action][]Rule)
+	if p == nil {
+		return summary
+	}
+	for _, rule := range p.Rules {
+		summary[rule.Action] = append(summary[rule.Action], rule)
+	}
+	return summary
+}
+
+// MatchingSubjects returns the subjects of a call (see Subjects) that the
+// rule matches, or nil when it matches none. Unlike Evaluate it reports every
+// file of an edit_transaction the pattern covers.
+func (r *Rule) MatchingSubjects(toolName string, args map[string]interface{}, workspaceRoot string) []string {
+	category, subjects := Subjects(toolName, args)
+	if !r.appliesTo(toolName, category) {
+		return nil
+	}
+	var matched []string
+	for _, subject := range subjects {
+		if r.matches(category, subject, workspaceRoot) {
+			matched = append(matched, subject)
+		}
+	}
+	return matched
+}
+
+func (r *Rule) appliesTo(toolName, category string) bool {
+	return r.Target == "*" || r.Target == toolName || (category != "" && r.Target == category)
+}
+
+func (r *Rule) matches(category, subject, workspaceRoot string) bool {
+	if r.Pattern == "" {
+		return true
+	}
+	if subject == "" {
+		return false
+	}
+	if category == CategoryShell {
+		for _, segment := range shellSegments(subject) {
+			if r.matcher != nil && r.matcher.MatchString(segment) {
+				return true
+			}
+		}
+		return false
+	}
+	if !isPathCategory(category) {
+		return r.matcher != nil && r.matcher.MatchString(subject)
+	}
+
+	rel := relativePath(subject, workspaceRoot)
+	if r.Outside {
+		dir := normalizeRulePath(r.Pattern)
+		if dir == "." {
+			return rel == ".." || strings.HasPrefix(rel, "../")
+		}
+		return rel != dir && !strings.HasPrefix(rel, dir+"/")
+	}
+	return r.matcher != nil && r.matcher.MatchString(rel)
+}
+
+// relativePath expresses path relative to workspaceRoot with forward slashes.
+// Paths outside the workspace keep a leading "../".
+func relativePath(path, workspaceRoot string) string {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(workspaceRoot, path)
+	}
+	rel, err := filepath.Rel(workspaceRoot, filepath.Clean(path))
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+func normalizeRulePath(pattern string) string {
+	pattern = filepath.ToSlash(filepath.Clean(pattern))
+	return strings.TrimPrefix(pattern, "./")
+}
+
+// compileGlob converts a glob into an anchored regexp. In path globs "*"
+// stops at "/" and "**" crosses directories; in command globs "*" matches
+// anything.
+func compileGlob(pattern string, pathGlob bool) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case c == '*' && pathGlob && i+1 < len(pattern) && pattern[i+1] == '*':
+			i++
+			if i+1 < len(pattern) && pattern[i+1] == '/' {
+				i++
+				b.WriteString("(?:.*/)?")
+			} else {
+				b.WriteString(".*")
+			}
+		case c == '*' && pathGlob:
+			b.WriteString("[^/]*")
+		case c == '*':
+			b.WriteString(".*")
+		case c == '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+var shellSeparator = regexp.MustCompile(`&&|\|\||;|\||\n`)
+
+// shellSegments splits a compound command so "deny shell: rm*" also catches
+// "cd build && rm -rf out".
+func shellSegments(command string) []string {
+	segments := shellSeparator.Split(command, -1)
+	result := make([]string, 0, len(segments))
+	for _, segment := range segments {
+		if segment = strings.TrimSpace(segment); segment != "" {
+			result = append(result, segment)
+		}
+	}
+	return result
+}
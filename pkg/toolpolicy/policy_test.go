@@ -0,0 +1,209 @@
+package toolpolicy
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const examplePolicy = `
+rules:
+  - deny shell: rm*
+  - ask write: outside ./src
+  - "allow read: **"
+  - "allow shell: go test*"
+  - deny read: "**/.env"
+  - ask deploy
+`
+
+func TestParseAcceptsQuotedAndUnquotedRules(t *testing.T) {
+	policy, err := Parse([]byte(examplePolicy))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	want := []string{
+		"deny shell: rm*",
+		"ask write: outside ./src",
+		"allow read",
+		"allow shell: go test*",
+		"deny read: **/.env",
+		"ask deploy",
+	}
+	if len(policy.Rules) != len(want) {
+		t.Fatalf("expected %d rules, got %d", len(want), len(policy.Rules))
+	}
+	for i, rule := range policy.Rules {
+		if rule.String() != want[i] || rule.Position != i+1 {
+			t.Fatalf("rule %d: expected %q at position %d, got %q at %d", i, want[i], i+1, rule.String(), rule.Position)
+		}
+	}
+}
+
+func TestParseRejectsInvalidRules(t *testing.T) {
+	for _, text := range []string{
+		"rules:\n  - block shell: rm*\n",
+		"rules:\n  - deny: rm*\n",
+		"rules:\n  - ask write: outside\n",
+		"rules:\n  - [deny, shell]\n",
+	} {
+		if _, err := Parse([]byte(text)); err == nil {
+			t.Fatalf("expected %q to be rejected", text)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	policy, err := Parse([]byte(examplePolicy))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	root := t.TempDir()
+
+	tests := []struct {
+		name string
+		tool string
+		args map[string]interface{}
+		want Action
+	}{
+		{"denied command", "shell_command", map[string]interface{}{"command": "rm -rf build"}, Deny},
+		{"denied command in a chain", "shell_command", map[string]interface{}{"command": "cd build && rm -rf out"}, Deny},
+		{"allowed command", "shell_command", map[string]interface{}{"command": "go test ./..."}, Allow},
+		{"unmatched command", "shell_command", map[string]interface{}{"command": "ls -la"}, ""},
+		{"write inside src", "write_file", map[string]interface{}{"path": "src/main.go"}, ""},
+		{"write outside src", "edit_file", map[string]interface{}{"path": "docs/README.md"}, Ask},
+		{"absolute write inside src", "write_file", map[string]interface{}{"file_path": filepath.Join(root, "src", "a.go")}, ""},
+		{"write to a src lookalike", "write_file", map[string]interface{}{"path": "srcfoo/a.go"}, Ask},
+		{"read anything", "read_file", map[string]interface{}{"path": "docs/README.md"}, Allow},
+		{"deny overrides allow", "read_file", map[string]interface{}{"path": "config/.env"}, Deny},
+		{"search defaults to the workspace", "search_files", map[string]interface{}{"search_pattern": "TODO"}, Allow},
+		{"transaction inside src", "edit_transaction", map[string]interface{}{"edits": []interface{}{
+			map[string]interface{}{"path": "src/a.go"}, map[string]interface{}{"file_path": "src/b.go"},
+		}}, ""},
+		{"transaction touching a file outside src", "edit_transaction", map[string]interface{}{"edits": []interface{}{
+			map[string]interface{}{"path": "src/a.go"}, map[string]interface{}{"path": "docs/README.md"},
+		}}, Ask},
+		{"tool name target", "deploy", nil, Ask},
+		{"uncovered tool", "git", map[string]interface{}{"operation": "push"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := policy.Evaluate(tt.tool, tt.args, root)
+			if decision.Action != tt.want {
+				t.Fatalf("expected %q, got %q (rule %v)", tt.want, decision.Action, decision.Rule)
+			}
+			if decision.Matched() != (tt.want != "") {
+				t.Fatalf("Matched() = %v for action %q", decision.Matched(), decision.Action)
+			}
+		})
+	}
+}
+
+func TestOutsideWorkspace(t *testing.T) {
+	policy, err := Parse([]byte("rules:\n  - deny *: outside .\n"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	root := t.TempDir()
+	if d := policy.Evaluate("read_file", map[string]interface{}{"path": "../secrets.txt"}, root); d.Action != Deny {
+		t.Fatalf("expected a path outside the workspace to be denied, got %q", d.Action)
+	}
+	if d := policy.Evaluate("read_file", map[string]interface{}{"path": "notes.txt"}, root); d.Matched() {
+		t.Fatalf("expected a workspace path not to match, got %q", d.Action)
+	}
+	if d := policy.Evaluate("shell_command", map[string]interface{}{"command": "ls"}, root); d.Matched() {
+		t.Fatalf("expected an outside rule not to match commands, got %q", d.Action)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	root := t.TempDir()
+	if policy, err := Load(root); policy != nil || err != nil {
+		t.Fatalf("expected no policy without a file, got %v, %v", policy, err)
+	}
+
+	path := filepath.Join(root, FileName)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("rules:\n  - nonsense\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(root); err == nil || !strings.Contains(err.Error(), "rule 1") {
+		t.Fatalf("expected the invalid rule to be reported, got %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte(examplePolicy), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	policy, err := Load(root)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if policy.Path != path || len(policy.Summary()[Deny]) != 2 {
+		t.Fatalf("unexpected policy: %+v", policy)
+	}
+}
+
+func TestParseSandbox(t *testing.T) {
+	policy, err := Parse([]byte(`
+rules:
+  - deny shell: rm*
+sandbox:
+  backend: podman
+  image: golang:1.25
+  mounts: ["~/go"]
+  commands: ["go test*", "make*"]
+`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	sandbox := policy.Sandbox
+	if sandbox == nil || sandbox.Backend != "podman" || sandbox.Image != "golang:1.25" || sandbox.Network {
+		t.Fatalf("unexpected sandbox: %+v", sandbox)
+	}
+	for command, want := range map[string]bool{
+		"go test ./...":          true,
+		"cd pkg && make":         true,
+		"git status":             false,
+		"echo hi; go test -race": true,
+	} {
+		if got := sandbox.Applies(command); got != want {
+			t.Errorf("Applies(%q) = %v, want %v", command, got, want)
+		}
+	}
+
+	policy, err = Parse([]byte("sandbox:\n  network: true\n"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if !policy.Sandbox.Applies("curl example.com") {
+		t.Error("a sandbox without commands should apply to every command")
+	}
+
+	policy, err = Parse([]byte(examplePolicy))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if policy.Sandbox.Applies("go test ./...") {
+		t.Error("no sandbox section should sandbox nothing")
+	}
+}
+
+func TestRuleMatchingSubjects(t *testing.T) {
+	rule, err := ParseRule("allow write: **/*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := map[string]interface{}{"edits": []interface{}{
+		map[string]interface{}{"path": "pkg/a.go"},
+		map[string]interface{}{"path": "README.md"},
+		map[string]interface{}{"path": "main.go"},
+	}}
+	if got := rule.MatchingSubjects("edit_transaction", args, "/src"); strings.Join(got, ",") != "pkg/a.go,main.go" {
+		t.Fatalf("expected the Go files of the transaction, got %q", got)
+	}
+	if got := rule.MatchingSubjects("read_file", map[string]interface{}{"path": "a.go"}, "/src"); got != nil {
+		t.Fatalf("expected a write rule not to match reads, got %q", got)
+	}
+}
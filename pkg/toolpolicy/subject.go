@@ -0,0 +1,83 @@
+package toolpolicy
+
+// Categories group tools so one rule covers every tool with the same effect.
+const (
+	CategoryShell = "shell"
+	CategoryRead  = "read"
+	CategoryWrite = "write"
+	CategoryGit   = "git"
+	CategoryWeb   = "web"
+)
+
+// Categories lists the rule targets that name a category rather than a tool.
+var Categories = []string{CategoryShell, CategoryRead, CategoryWrite, CategoryGit, CategoryWeb}
+
+var toolCategories = map[string]string{
+	"shell_command":         CategoryShell,
+	"read_file":             CategoryRead,
+	"read_symbol":           CategoryRead,
+	"impact_of_change":      CategoryRead,
+	"file_history":          CategoryRead,
+	"search_files":          CategoryRead,
+	"write_file":            CategoryWrite,
+	"edit_file":             CategoryWrite,
+	"write_structured_file": CategoryWrite,
+	"patch_structured_file": CategoryWrite,
+	"rename_symbol":         CategoryWrite,
+	"edit_transaction":      CategoryWrite,
+	"git":                   CategoryGit,
+	"commit":                CategoryGit,
+	"pr":                    CategoryGit,
+	"fetch_url":             CategoryWeb,
+	"browse_url":            CategoryWeb,
+	"web_search":            CategoryWeb,
+}
+
+// subjectArgs are the argument names (canonical first, then aliases) holding
+// the value a category's patterns are matched against.
+var subjectArgs = map[string][]string{
+	CategoryShell: {"command", "cmd"},
+	CategoryRead:  {"path", "file_path", "directory", "root"},
+	CategoryWrite: {"path", "file_path"},
+	CategoryGit:   {"operation", "op", "action"},
+	CategoryWeb:   {"url", "query"},
+}
+
+// Subject returns the category of toolName and the command, path, git
+// operation or URL its arguments act on. Both are "" when unknown; a
+// search_files call without a directory searches ".".
+func Subject(toolName string, args map[string]interface{}) (category, subject string) {
+	category = toolCategories[toolName]
+	for _, name := range subjectArgs[category] {
+		if value, ok := args[name].(string); ok && value != "" {
+			return category, value
+		}
+	}
+	if toolName == "search_files" {
+		return category, "."
+	}
+	return category, ""
+}
+
+// Subjects is Subject for every value a call acts on. An edit_transaction
+// call writes each file in its edits, so rules are matched against each.
+func Subjects(toolName string, args map[string]interface{}) (category string, subjects []string) {
+	category, subject := Subject(toolName, args)
+	if edits, ok := args["edits"].([]interface{}); ok && toolName == "edit_transaction" {
+		for _, item := range edits {
+			if edit, ok := item.(map[string]interface{}); ok {
+				if _, path := Subject("edit_file", edit); path != "" {
+					subjects = append(subjects, path)
+				}
+			}
+		}
+		if len(subjects) > 0 {
+			return category, subjects
+		}
+	}
+	return category, []string{subject}
+}
+
+func isPathCategory(category string) bool {
+	return category == CategoryRead || category == CategoryWrite
+}
@@ -0,0 +1,125 @@
+package transcript
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// htmlStyle uses a colorblind-safe blue/orange scheme. Diff lines keep their
+// +/- prefixes and tool results carry a ✓/✗ label, so nothing relies on color
+// alone and the page stays readable when printed in grayscale.
+const htmlStyle = `body{font-family:system-ui,-apple-system,sans-serif;max-width:960px;margin:2rem auto;padding:0 1rem;color:#1b1b1b;line-height:1.5}
+pre{background:#f6f6f6;padding:.75rem;overflow-x:auto;border-radius:4px}
+.entry{margin:1.5rem 0}
+.meta{color:#555}
+.status{font-weight:bold;padding:0 .3rem;border-radius:3px}
+.status-ok{color:#0b5cad;border:1px solid #0b5cad}
+.status-fail{color:#b35900;border:1px solid #b35900}
+.diff .add{color:#0b5cad;background:#e8f1fb}
+.diff .del{color:#b35900;background:#fdf0e3;text-decoration:line-through}
+.diff .hunk{color:#6a3d9a}
+.noise{color:#555;font-style:italic}`
+
+// RenderHTML renders a transcript as a standalone HTML page.
+func RenderHTML(t *Transcript) string {
+	var sb strings.Builder
+
+	title := t.Name
+	if strings.TrimSpace(title) == "" {
+		title = t.SessionID
+	}
+	sb.WriteString("<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(&sb, "<title>Session transcript: %s</title>\n", html.EscapeString(title))
+	fmt.Fprintf(&sb, "<style>\n%s\n</style>\n</head>\n<body>\n", htmlStyle)
+	fmt.Fprintf(&sb, "<h1>Session transcript: %s</h1>\n<ul class=\"meta\">\n", html.EscapeString(title))
+	fmt.Fprintf(&sb, "<li>Session: <code>%s</code></li>\n", html.EscapeString(t.SessionID))
+	if t.WorkingDirectory != "" {
+		fmt.Fprintf(&sb, "<li>Working directory: <code>%s</code></li>\n", html.EscapeString(t.WorkingDirectory))
+	}
+	if !t.LastUpdated.IsZero() {
+		fmt.Fprintf(&sb, "<li>Last updated: %s</li>\n", t.LastUpdated.Format("2006-01-02 15:04:05"))
+	}
+	fmt.Fprintf(&sb, "<li>Mode: %s</li>\n</ul>\n", html.EscapeString(string(t.Mode)))
+
+	for _, entry := range t.Entries {
+		sb.WriteString("<section class=\"entry\">\n")
+		switch entry.Kind {
+		case KindSystem:
+			sb.WriteString("<h2>System</h2>\n")
+			writePre(&sb, entry.Content)
+		case KindPrompt:
+			sb.WriteString("<h2>User</h2>\n")
+			writePre(&sb, strings.TrimSpace(entry.Content))
+		case KindResponse:
+			sb.WriteString("<h2>Assistant</h2>\n")
+			writePre(&sb, strings.TrimSpace(entry.Content))
+		case KindToolCall:
+			fmt.Fprintf(&sb, "<h3>Tool call: <code>%s</code></h3>\n", html.EscapeString(entry.ToolName))
+			writePre(&sb, entry.Content)
+		case KindToolResult:
+			fmt.Fprintf(&sb, "<h3>Tool result: <code>%s</code> %s</h3>\n", html.EscapeString(entry.ToolName), toolResultStatus(entry.Content))
+			writePre(&sb, entry.Content)
+		case KindToolNoise:
+			fmt.Fprintf(&sb, "<p class=\"noise\">tools: %s</p>\n", html.EscapeString(entry.Content))
+		case KindDiff:
+			fmt.Fprintf(&sb, "<h3>Change: <code>%s</code> (%s)</h3>\n", html.EscapeString(entry.Path), html.EscapeString(entry.ToolName))
+			if entry.ToolName == "write_structured_file" || entry.ToolName == "patch_structured_file" {
+				writePre(&sb, entry.Content)
+			} else {
+				writeDiff(&sb, entry.Content)
+			}
+		}
+		sb.WriteString("</section>\n")
+	}
+
+	if u := t.Usage; u != nil {
+		sb.WriteString("<section class=\"entry\">\n<h2>Cost summary</h2>\n<ul class=\"meta\">\n")
+		fmt.Fprintf(&sb, "<li>Total cost: $%.4f</li>\n", u.TotalCost)
+		fmt.Fprintf(&sb, "<li>Tokens: %d (%d prompt, %d completion)</li>\n", u.TotalTokens, u.PromptTokens, u.CompletionTokens)
+		if u.CachedTokens > 0 {
+			fmt.Fprintf(&sb, "<li>Cached tokens: %d (saved $%.4f)</li>\n", u.CachedTokens, u.CachedCostSavings)
+		}
+		sb.WriteString("</ul>\n</section>\n")
+	}
+
+	sb.WriteString("</body>\n</html>\n")
+	return sb.String()
+}
+
+// toolResultStatus labels a tool result as succeeded or failed with a symbol
+// and text. Failed tool calls are recorded with an "Error" prefix.
+func toolResultStatus(content string) string {
+	trimmed := strings.TrimSpace(content)
+	if strings.HasPrefix(trimmed, "Error") || strings.HasPrefix(trimmed, "error:") {
+		return `<span class="status status-fail">✗ failed</span>`
+	}
+	return `<span class="status status-ok">✓ ok</span>`
+}
+
+func writePre(sb *strings.Builder, content string) {
+	sb.WriteString("<pre>" + html.EscapeString(strings.TrimRight(content, "\n")) + "</pre>\n")
+}
+
+// writeDiff renders a unified diff with one span per line, keeping the +/-
+// prefixes so additions and deletions stay distinguishable without color.
+func writeDiff(sb *strings.Builder, content string) {
+	sb.WriteString("<pre class=\"diff\">")
+	for _, line := range strings.Split(strings.TrimRight(content, "\n"), "\n") {
+		class := ""
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"), strings.HasPrefix(line, "@@"):
+			class = "hunk"
+		case strings.HasPrefix(line, "+"):
+			class = "add"
+		case strings.HasPrefix(line, "-"):
+			class = "del"
+		}
+		if class == "" {
+			sb.WriteString(html.EscapeString(line) + "\n")
+			continue
+		}
+		fmt.Fprintf(sb, "<span class=\"%s\">%s</span>\n", class, html.EscapeString(line))
+	}
+	sb.WriteString("</pre>\n")
+}
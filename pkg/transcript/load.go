@@ -0,0 +1,36 @@
+package transcript
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alantheprice/ledit/pkg/agent"
+)
+
+// LoadSession loads a persisted session by ID, or the most recent session for
+// workingDir when sessionID is empty.
+func LoadSession(sessionID, workingDir string) (*agent.ConversationState, error) {
+	sessions, err := agent.ListAllSessionsWithTimestamps()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	sessionID = strings.TrimSpace(sessionID)
+	for _, info := range sessions {
+		if sessionID != "" && info.SessionID != sessionID {
+			continue
+		}
+		if sessionID == "" && strings.TrimSpace(info.WorkingDirectory) != workingDir {
+			continue
+		}
+		if info.StoragePath != "" {
+			return agent.ImportStateFromJSONFile(info.StoragePath)
+		}
+		return agent.LoadStateWithoutAgentScoped(info.SessionID, info.WorkingDirectory)
+	}
+
+	if sessionID != "" {
+		return nil, fmt.Errorf("session %q not found", sessionID)
+	}
+	return nil, fmt.Errorf("no prior session found for current directory: %s", workingDir)
+}
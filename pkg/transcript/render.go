@@ -0,0 +1,78 @@
+package transcript
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RenderMarkdown renders a transcript as Markdown.
+func RenderMarkdown(t *Transcript) string {
+	var sb strings.Builder
+
+	title := t.Name
+	if strings.TrimSpace(title) == "" {
+		title = t.SessionID
+	}
+	fmt.Fprintf(&sb, "# Session transcript: %s\n\n", title)
+	fmt.Fprintf(&sb, "- Session: `%s`\n", t.SessionID)
+	if t.WorkingDirectory != "" {
+		fmt.Fprintf(&sb, "- Working directory: `%s`\n", t.WorkingDirectory)
+	}
+	if !t.LastUpdated.IsZero() {
+		fmt.Fprintf(&sb, "- Last updated: %s\n", t.LastUpdated.Format("2006-01-02 15:04:05"))
+	}
+	fmt.Fprintf(&sb, "- Mode: %s\n", t.Mode)
+
+	for _, entry := range t.Entries {
+		sb.WriteString("\n")
+		switch entry.Kind {
+		case KindSystem:
+			sb.WriteString("## System\n\n")
+			writeFenced(&sb, "", entry.Content)
+		case KindPrompt:
+			sb.WriteString("## User\n\n")
+			sb.WriteString(strings.TrimSpace(entry.Content) + "\n")
+		case KindResponse:
+			sb.WriteString("## Assistant\n\n")
+			sb.WriteString(strings.TrimSpace(entry.Content) + "\n")
+		case KindToolCall:
+			fmt.Fprintf(&sb, "### Tool call: `%s`\n\n", entry.ToolName)
+			writeFenced(&sb, "json", entry.Content)
+		case KindToolResult:
+			fmt.Fprintf(&sb, "### Tool result: `%s`\n\n", entry.ToolName)
+			writeFenced(&sb, "", entry.Content)
+		case KindToolNoise:
+			fmt.Fprintf(&sb, "> tools: %s\n", entry.Content)
+		case KindDiff:
+			fmt.Fprintf(&sb, "### Change: `%s` (%s)\n\n", entry.Path, entry.ToolName)
+			lang := "diff"
+			if entry.ToolName == "write_structured_file" || entry.ToolName == "patch_structured_file" {
+				lang = "json"
+			}
+			writeFenced(&sb, lang, entry.Content)
+		}
+	}
+
+	if u := t.Usage; u != nil {
+		sb.WriteString("\n## Cost summary\n\n")
+		fmt.Fprintf(&sb, "- Total cost: $%.4f\n", u.TotalCost)
+		fmt.Fprintf(&sb, "- Tokens: %d (%d prompt, %d completion)\n", u.TotalTokens, u.PromptTokens, u.CompletionTokens)
+		if u.CachedTokens > 0 {
+			fmt.Fprintf(&sb, "- Cached tokens: %d (saved $%.4f)\n", u.CachedTokens, u.CachedCostSavings)
+		}
+	}
+
+	return sb.String()
+}
+
+// writeFenced writes content inside a code fence long enough to contain any
+// backtick runs already present in the content.
+func writeFenced(sb *strings.Builder, lang, content string) {
+	fence := "```"
+	for strings.Contains(content, fence) {
+		fence += "`"
+	}
+	sb.WriteString(fence + lang + "\n")
+	sb.WriteString(strings.TrimRight(content, "\n") + "\n")
+	sb.WriteString(fence + "\n")
+}
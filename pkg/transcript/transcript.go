@@ -0,0 +1,296 @@
+// Package transcript builds human-readable transcripts from persisted agent
+// sessions. Transcripts are produced by applying a Mode transform over the
+// structured conversation state (messages and tool calls) rather
+// than by post-processing console output.
+package transcript
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alantheprice/ledit/pkg/agent"
+	api "github.com/alantheprice/ledit/pkg/agent_api"
+)
+
+// Mode selects how much of a session a transcript keeps.
+type Mode string
+
+const (
+	// ModeFull keeps every message, tool call and tool result verbatim.
+	ModeFull Mode = "full"
+	// ModeCondense keeps prompts, responses and diffs, and collapses tool
+	// noise (reads, searches, shell output) into one-line summaries.
+	ModeCondense Mode = "condense"
+	// ModeOnlyChanges keeps only user prompts and the diffs they produced.
+	ModeOnlyChanges Mode = "only-changes"
+)
+
+// Entry kinds.
+const (
+	KindSystem     = "system"
+	KindPrompt     = "prompt"
+	KindResponse   = "response"
+	KindToolCall   = "tool_call"
+	KindToolResult = "tool_result"
+	KindToolNoise  = "tool_summary"
+	KindDiff       = "diff"
+)
+
+// Entry is a single block of a transcript.
+type Entry struct {
+	Kind     string `json:"kind"`
+	Role     string `json:"role,omitempty"`
+	ToolName string `json:"tool_name,omitempty"`
+	Path     string `json:"path,omitempty"`
+	Content  string `json:"content"`
+}
+
+// Transcript is a rendered view of one session.
+type Transcript struct {
+	SessionID        string    `json:"session_id"`
+	Name             string    `json:"name,omitempty"`
+	WorkingDirectory string    `json:"working_directory,omitempty"`
+	LastUpdated      time.Time `json:"last_updated"`
+	Mode             Mode      `json:"mode"`
+	Entries          []Entry   `json:"entries"`
+	Usage            *Usage    `json:"usage,omitempty"`
+}
+
+// Usage is the session's token and cost summary.
+type Usage struct {
+	TotalCost         float64 `json:"total_cost"`
+	TotalTokens       int     `json:"total_tokens"`
+	PromptTokens      int     `json:"prompt_tokens"`
+	CompletionTokens  int     `json:"completion_tokens"`
+	CachedTokens      int     `json:"cached_tokens,omitempty"`
+	CachedCostSavings float64 `json:"cached_cost_savings,omitempty"`
+}
+
+// ParseMode validates a mode name.
+func ParseMode(value string) (Mode, error) {
+	switch Mode(strings.ToLower(strings.TrimSpace(value))) {
+	case ModeFull:
+		return ModeFull, nil
+	case ModeCondense:
+		return ModeCondense, nil
+	case ModeOnlyChanges, "only_changes", "changes":
+		return ModeOnlyChanges, nil
+	default:
+		return "", fmt.Errorf("unsupported transcript mode %q: must be one of full, condense, only-changes", value)
+	}
+}
+
+// ResolveFormat validates a format name ("markdown", "md" or "html"),
+// inferring html from an .html or .htm output path when format is empty.
+func ResolveFormat(format, output string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "":
+		switch strings.ToLower(filepath.Ext(output)) {
+		case ".html", ".htm":
+			return "html", nil
+		}
+		return "markdown", nil
+	case "markdown", "md":
+		return "markdown", nil
+	case "html":
+		return "html", nil
+	}
+	return "", fmt.Errorf("unsupported transcript format %q: must be markdown or html", format)
+}
+
+// Render renders t in a format returned by ResolveFormat.
+func Render(t *Transcript, format string) string {
+	if format == "html" {
+		return RenderHTML(t)
+	}
+	return RenderMarkdown(t)
+}
+
+// Build converts a persisted conversation into a transcript using the given mode.
+func Build(state agent.ConversationState, mode Mode) (*Transcript, error) {
+	if _, err := ParseMode(string(mode)); err != nil {
+		return nil, err
+	}
+
+	t := &Transcript{
+		SessionID:        state.SessionID,
+		Name:             state.Name,
+		WorkingDirectory: state.WorkingDirectory,
+		LastUpdated:      state.LastUpdated,
+		Mode:             mode,
+	}
+
+	var pendingNoise []string
+	flushNoise := func() {
+		if len(pendingNoise) == 0 {
+			return
+		}
+		t.Entries = append(t.Entries, Entry{Kind: KindToolNoise, Content: summarizeToolNoise(pendingNoise)})
+		pendingNoise = nil
+	}
+
+	toolNames := make(map[string]string)
+	for _, msg := range state.Messages {
+		switch strings.ToLower(strings.TrimSpace(msg.Role)) {
+		case "system":
+			if mode == ModeFull {
+				t.Entries = append(t.Entries, Entry{Kind: KindSystem, Role: "system", Content: msg.Content})
+			}
+
+		case "user":
+			flushNoise()
+			if strings.TrimSpace(msg.Content) == "" {
+				continue
+			}
+			t.Entries = append(t.Entries, Entry{Kind: KindPrompt, Role: "user", Content: msg.Content})
+
+		case "assistant":
+			if mode != ModeOnlyChanges && strings.TrimSpace(msg.Content) != "" {
+				flushNoise()
+				t.Entries = append(t.Entries, Entry{Kind: KindResponse, Role: "assistant", Content: msg.Content})
+			}
+			for _, tc := range msg.ToolCalls {
+				toolNames[tc.ID] = tc.Function.Name
+				if diff, ok := diffEntryForToolCall(tc); ok {
+					flushNoise()
+					t.Entries = append(t.Entries, diff)
+					continue
+				}
+				switch mode {
+				case ModeFull:
+					t.Entries = append(t.Entries, Entry{
+						Kind:     KindToolCall,
+						Role:     "assistant",
+						ToolName: tc.Function.Name,
+						Content:  tc.Function.Arguments,
+					})
+				case ModeCondense:
+					pendingNoise = append(pendingNoise, tc.Function.Name)
+				}
+			}
+
+		case "tool":
+			if mode != ModeFull {
+				continue
+			}
+			t.Entries = append(t.Entries, Entry{
+				Kind:     KindToolResult,
+				Role:     "tool",
+				ToolName: toolNames[msg.ToolCallId],
+				Content:  msg.Content,
+			})
+		}
+	}
+	flushNoise()
+
+	if state.TotalTokens > 0 || state.TotalCost > 0 {
+		t.Usage = &Usage{
+			TotalCost:         state.TotalCost,
+			TotalTokens:       state.TotalTokens,
+			PromptTokens:      state.PromptTokens,
+			CompletionTokens:  state.CompletionTokens,
+			CachedTokens:      state.CachedTokens,
+			CachedCostSavings: state.CachedCostSavings,
+		}
+	}
+
+	return t, nil
+}
+
+// diffEntryForToolCall turns a file-mutating tool call into a diff entry.
+func diffEntryForToolCall(tc api.ToolCall) (Entry, bool) {
+	name := tc.Function.Name
+	switch name {
+	case "write_file", "edit_file", "write_structured_file", "patch_structured_file":
+	default:
+		return Entry{}, false
+	}
+
+	var args map[string]interface{}
+	if err := json.Unmarshal([]byte(tc.Function.Arguments), &args); err != nil {
+		return Entry{Kind: KindDiff, ToolName: name, Content: tc.Function.Arguments}, true
+	}
+
+	path := firstString(args, "path", "file_path")
+	entry := Entry{Kind: KindDiff, ToolName: name, Path: path}
+	switch name {
+	case "edit_file":
+		entry.Content = unifiedReplacement(path,
+			firstString(args, "old_str", "old_string"),
+			firstString(args, "new_str", "new_string"))
+	case "write_file":
+		entry.Content = unifiedReplacement(path, "", firstString(args, "content"))
+	default:
+		payload := args["patch_ops"]
+		if payload == nil {
+			payload = args["data"]
+		}
+		formatted, err := json.MarshalIndent(payload, "", "  ")
+		if err != nil {
+			formatted = []byte(tc.Function.Arguments)
+		}
+		entry.Content = string(formatted)
+	}
+	return entry, true
+}
+
+// unifiedReplacement renders a string replacement as a single unified-diff hunk.
+func unifiedReplacement(path, oldText, newText string) string {
+	var sb strings.Builder
+	oldPath := "a/" + path
+	if oldText == "" {
+		oldPath = "/dev/null"
+	}
+	fmt.Fprintf(&sb, "--- %s\n+++ b/%s\n", oldPath, path)
+	for _, line := range splitLines(oldText) {
+		sb.WriteString("-" + line + "\n")
+	}
+	for _, line := range splitLines(newText) {
+		sb.WriteString("+" + line + "\n")
+	}
+	return sb.String()
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// summarizeToolNoise collapses a run of non-mutating tool calls into one line,
+// e.g. "read_file ×3, search_files".
+func summarizeToolNoise(names []string) string {
+	counts := make(map[string]int)
+	var order []string
+	for _, name := range names {
+		if counts[name] == 0 {
+			order = append(order, name)
+		}
+		counts[name]++
+	}
+	sort.SliceStable(order, func(i, j int) bool { return counts[order[i]] > counts[order[j]] })
+
+	parts := make([]string, 0, len(order))
+	for _, name := range order {
+		if counts[name] > 1 {
+			parts = append(parts, fmt.Sprintf("%s ×%d", name, counts[name]))
+		} else {
+			parts = append(parts, name)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func firstString(args map[string]interface{}, keys ...string) string {
+	for _, key := range keys {
+		if value, ok := args[key].(string); ok {
+			return value
+		}
+	}
+	return ""
+}
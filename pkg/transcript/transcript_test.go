@@ -0,0 +1,173 @@
+package transcript
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alantheprice/ledit/pkg/agent"
+	api "github.com/alantheprice/ledit/pkg/agent_api"
+)
+
+func toolCall(id, name, args string) api.ToolCall {
+	tc := api.ToolCall{ID: id, Type: "function"}
+	tc.Function.Name = name
+	tc.Function.Arguments = args
+	return tc
+}
+
+func sampleState() agent.ConversationState {
+	return agent.ConversationState{
+		SessionID: "abc",
+		Messages: []api.Message{
+			{Role: "system", Content: "You are helpful."},
+			{Role: "user", Content: "Rename foo to bar"},
+			{Role: "assistant", Content: "Looking around.", ToolCalls: []api.ToolCall{
+				toolCall("1", "read_file", `{"path":"main.go"}`),
+				toolCall("2", "read_file", `{"path":"util.go"}`),
+				toolCall("3", "search_files", `{"pattern":"foo"}`),
+			}},
+			{Role: "tool", ToolCallId: "1", Content: "package main"},
+			{Role: "tool", ToolCallId: "2", Content: "package util"},
+			{Role: "tool", ToolCallId: "3", Content: "main.go:3: foo()"},
+			{Role: "assistant", ToolCalls: []api.ToolCall{
+				toolCall("4", "edit_file", `{"path":"main.go","old_str":"foo()","new_str":"bar()"}`),
+			}},
+			{Role: "tool", ToolCallId: "4", Content: "ok"},
+			{Role: "assistant", Content: "Renamed."},
+		},
+	}
+}
+
+func kinds(entries []Entry) []string {
+	out := make([]string, 0, len(entries))
+	for _, e := range entries {
+		out = append(out, e.Kind)
+	}
+	return out
+}
+
+func TestBuildModes(t *testing.T) {
+	tests := []struct {
+		mode Mode
+		want []string
+	}{
+		{ModeFull, []string{KindSystem, KindPrompt, KindResponse, KindToolCall, KindToolCall, KindToolCall,
+			KindToolResult, KindToolResult, KindToolResult, KindDiff, KindToolResult, KindResponse}},
+		{ModeCondense, []string{KindPrompt, KindResponse, KindToolNoise, KindDiff, KindResponse}},
+		{ModeOnlyChanges, []string{KindPrompt, KindDiff}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			tr, err := Build(sampleState(), tt.mode)
+			if err != nil {
+				t.Fatalf("Build returned error: %v", err)
+			}
+			got := kinds(tr.Entries)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Fatalf("unexpected entries:\n got  %v\n want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildCondenseSummarizesToolNoise(t *testing.T) {
+	tr, err := Build(sampleState(), ModeCondense)
+	if err != nil {
+		t.Fatalf("Build returned error: %v", err)
+	}
+	if got := tr.Entries[2].Content; got != "read_file ×2, search_files" {
+		t.Fatalf("unexpected tool summary %q", got)
+	}
+	diff := tr.Entries[3]
+	if diff.Path != "main.go" || !strings.Contains(diff.Content, "-foo()\n+bar()") {
+		t.Fatalf("unexpected diff entry: %+v", diff)
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	if _, err := ParseMode("bogus"); err == nil {
+		t.Fatal("expected error for unknown mode")
+	}
+	if mode, err := ParseMode("only_changes"); err != nil || mode != ModeOnlyChanges {
+		t.Fatalf("ParseMode(only_changes) = %q, %v", mode, err)
+	}
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	tr, err := Build(sampleState(), ModeCondense)
+	if err != nil {
+		t.Fatalf("Build returned error: %v", err)
+	}
+	out := RenderMarkdown(tr)
+	for _, want := range []string{"# Session transcript: abc", "## User", "> tools: read_file ×2, search_files", "```diff", "+bar()"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("rendered markdown missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestRenderHTMLMarksStatusWithSymbols(t *testing.T) {
+	state := sampleState()
+	state.Messages = append(state.Messages,
+		api.Message{Role: "assistant", ToolCalls: []api.ToolCall{toolCall("5", "shell_command", `{"command":"go test"}`)}},
+		api.Message{Role: "tool", ToolCallId: "5", Content: "Error: exit status 1 <fail>"},
+	)
+	tr, err := Build(state, ModeFull)
+	if err != nil {
+		t.Fatalf("Build returned error: %v", err)
+	}
+	out := RenderHTML(tr)
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		"✓ ok",
+		"✗ failed",
+		`<span class="del">-foo()</span>`,
+		`<span class="add">+bar()</span>`,
+		"Error: exit status 1 &lt;fail&gt;",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("rendered html missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestRenderCostSummary(t *testing.T) {
+	state := sampleState()
+	state.TotalCost = 0.0123
+	state.TotalTokens = 1500
+	state.PromptTokens = 1200
+	state.CompletionTokens = 300
+	tr, err := Build(state, ModeCondense)
+	if err != nil {
+		t.Fatalf("Build returned error: %v", err)
+	}
+	if out := RenderMarkdown(tr); !strings.Contains(out, "## Cost summary\n\n- Total cost: $0.0123\n- Tokens: 1500 (1200 prompt, 300 completion)\n") {
+		t.Fatalf("rendered markdown missing cost summary:\n%s", out)
+	}
+	if out := RenderHTML(tr); !strings.Contains(out, "<li>Total cost: $0.0123</li>") {
+		t.Fatalf("rendered html missing cost summary:\n%s", out)
+	}
+
+	tr, _ = Build(sampleState(), ModeCondense)
+	if strings.Contains(RenderMarkdown(tr), "Cost summary") {
+		t.Fatal("expected no cost summary without usage")
+	}
+}
+
+func TestResolveFormat(t *testing.T) {
+	cases := map[[2]string]string{
+		{"", "out.html"}:   "html",
+		{"", "out.md"}:     "markdown",
+		{"md", "out.html"}: "markdown",
+		{"HTML", ""}:       "html",
+	}
+	for in, want := range cases {
+		got, err := ResolveFormat(in[0], in[1])
+		if err != nil || got != want {
+			t.Errorf("ResolveFormat(%q, %q) = %q, %v; want %q", in[0], in[1], got, err, want)
+		}
+	}
+	if _, err := ResolveFormat("pdf", ""); err == nil {
+		t.Error("expected an error for an unsupported format")
+	}
+}
@@ -0,0 +1,23 @@
+package utils
+
+import "strings"
+
+// LineEnding returns "\r\n" when most of content's lines end with CRLF, as in
+// files written on Windows, and "\n" otherwise.
+func LineEnding(content string) string {
+	crlf := strings.Count(content, "\r\n")
+	if crlf > 0 && crlf*2 > strings.Count(content, "\n") {
+		return "\r\n"
+	}
+	return "\n"
+}
+
+// WithLineEnding returns s with every line ending, CRLF or LF, replaced by
+// ending.
+func WithLineEnding(s, ending string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	if ending == "\n" {
+		return s
+	}
+	return strings.ReplaceAll(s, "\n", ending)
+}
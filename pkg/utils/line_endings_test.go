@@ -0,0 +1,23 @@
+package utils
+
+import "testing"
+
+func TestLineEndings(t *testing.T) {
+	for content, want := range map[string]string{
+		"a\r\nb\r\nc":   "\r\n",
+		"a\nb\nc":       "\n",
+		"a\r\nb\nc\r\n": "\r\n",
+		"a\r\nb\nc\nd":  "\n",
+		"single line":   "\n",
+	} {
+		if got := LineEnding(content); got != want {
+			t.Errorf("LineEnding(%q) = %q, want %q", content, got, want)
+		}
+	}
+	if got := WithLineEnding("a\nb\r\nc", "\r\n"); got != "a\r\nb\r\nc" {
+		t.Errorf("WithLineEnding to CRLF = %q", got)
+	}
+	if got := WithLineEnding("a\r\nb\nc", "\n"); got != "a\nb\nc" {
+		t.Errorf("WithLineEnding to LF = %q", got)
+	}
+}
@@ -3,6 +3,7 @@ package utils
 import (
 	"fmt"
 	"math"
+	"math/rand"
 	"net/http"
 	"strconv"
 	"strings"
@@ -137,9 +138,11 @@ func (rlb *RateLimitBackoff) parseRateLimitHeaders(resp *http.Response) time.Dur
 	return 0 // No parseable headers found
 }
 
-// exponentialBackoff calculates exponential backoff delay
+// exponentialBackoff calculates exponential backoff delay, with up to 25%
+// jitter so parallel agents that were limited together retry apart
 func (rlb *RateLimitBackoff) exponentialBackoff(attempt int) time.Duration {
 	delay := rlb.BaseDelay * time.Duration(math.Pow(2, float64(attempt)))
+	delay += time.Duration(rand.Int63n(int64(delay)/4 + 1))
 	return rlb.capDelay(delay)
 }
 
@@ -10,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/alantheprice/ledit/pkg/tokenizer"
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
 )
@@ -128,13 +129,10 @@ func StringSliceEqual(a, b []string) bool {
 	return true
 }
 
-// EstimateTokens provides a rough estimate of the number of tokens in a given text.
-// This is a simple character-based estimation (e.g., 4 chars per token) and may not be accurate
-// for all models or languages, but provides a general idea for prompt length management.
+// EstimateTokens returns the number of tokens in a given text, counted with the
+// current model's tokenizer when one is available and estimated otherwise.
 func EstimateTokens(text string) int {
-	// A common heuristic is 4 characters per token for English text.
-	// This is a rough estimate and can vary significantly by model and language.
-	return len(text) / 4
+	return tokenizer.Count(text)
 }
 
 // IsValidFileExtension checks if the given filename has one of the allowed extensions.
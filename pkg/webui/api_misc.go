@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/alantheprice/ledit/pkg/capabilities"
 	"github.com/alantheprice/ledit/pkg/console"
 )
 
@@ -40,6 +41,18 @@ func (ws *ReactWebServer) handleAPIConfig(w http.ResponseWriter, r *http.Request
 	json.NewEncoder(w).Encode(config)
 }
 
+// handleAPICapabilities serves the capabilities handshake document, the same
+// one printed by `ledit capabilities --json`.
+func (ws *ReactWebServer) handleAPICapabilities(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(capabilities.Collect())
+}
+
 // handleTerminalHistory handles API requests for terminal history
 func (ws *ReactWebServer) handleTerminalHistory(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
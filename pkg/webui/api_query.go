@@ -226,7 +226,9 @@ func (ws *ReactWebServer) handleAPIQuery(w http.ResponseWriter, r *http.Request)
 	})
 }
 
-// handleAPIQuerySteer injects user input into the currently running query loop.
+// handleAPIQuerySteer interrupts the currently running query loop with a
+// correction: the tool chain pauses after the current call and the model
+// revises its plan.
 func (ws *ReactWebServer) handleAPIQuerySteer(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -276,7 +278,7 @@ func (ws *ReactWebServer) handleAPIQuerySteer(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	if err := clientAgent.InjectInputContext(query.Query); err != nil {
+	if err := clientAgent.Steer(query.Query); err != nil {
 		http.Error(w, fmt.Sprintf("Failed to steer active query: %v", err), http.StatusConflict)
 		return
 	}
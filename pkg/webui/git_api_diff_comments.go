@@ -0,0 +1,140 @@
+package webui
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const maxDiffLineComments = 100
+
+// diffLineComment is a reviewer comment anchored to one line of a diff.
+type diffLineComment struct {
+	Path string `json:"path"`
+	Line int    `json:"line"`           // 1-based line number on Side
+	Side string `json:"side,omitempty"` // "new" (default) or "old" for removed lines
+	Code string `json:"code,omitempty"` // the diff line the comment is attached to
+	Body string `json:"body"`
+}
+
+// handleAPIGitRevisionRequest turns line comments on a proposed diff into a
+// structured revision request. The client sends the returned prompt to the
+// chat so the model revises exactly the lines that were commented on.
+func (ws *ReactWebServer) handleAPIGitRevisionRequest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxQueryBodyBytes)
+	var req struct {
+		Comments     []diffLineComment `json:"comments"`
+		Instructions string            `json:"instructions"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	workspaceRoot := ws.getWorkspaceRootForRequest(r)
+	comments, err := normalizeDiffLineComments(req.Comments, workspaceRoot)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":  "success",
+		"prompt":   formatRevisionRequest(comments, req.Instructions),
+		"comments": len(comments),
+	})
+}
+
+// normalizeDiffLineComments validates comments, makes paths workspace-relative
+// and fills in the commented code from disk when the client did not send it.
+func normalizeDiffLineComments(comments []diffLineComment, workspaceRoot string) ([]diffLineComment, error) {
+	if len(comments) == 0 {
+		return nil, fmt.Errorf("at least one comment is required")
+	}
+	if len(comments) > maxDiffLineComments {
+		return nil, fmt.Errorf("too many comments (max %d)", maxDiffLineComments)
+	}
+
+	out := make([]diffLineComment, 0, len(comments))
+	for i, c := range comments {
+		c.Path = filepath.ToSlash(makeGitRelativePath(normalizeGitPath(c.Path), workspaceRoot))
+		c.Body = strings.TrimSpace(c.Body)
+		c.Code = strings.TrimRight(c.Code, "\r\n")
+		switch {
+		case c.Path == "":
+			return nil, fmt.Errorf("comment %d: path is required", i+1)
+		case filepath.IsAbs(c.Path) || c.Path == ".." || strings.HasPrefix(c.Path, "../"):
+			return nil, fmt.Errorf("comment %d: path %q is outside the workspace", i+1, c.Path)
+		case c.Line <= 0:
+			return nil, fmt.Errorf("comment %d: line must be positive", i+1)
+		case c.Body == "":
+			return nil, fmt.Errorf("comment %d: comment text is required", i+1)
+		}
+		if c.Side != "old" {
+			c.Side = "new"
+			if c.Code == "" && workspaceRoot != "" {
+				c.Code = readFileLine(filepath.Join(workspaceRoot, filepath.FromSlash(c.Path)), c.Line)
+			}
+		}
+		out = append(out, c)
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Path != out[j].Path {
+			return out[i].Path < out[j].Path
+		}
+		return out[i].Line < out[j].Line
+	})
+	return out, nil
+}
+
+// formatRevisionRequest renders comments as a numbered list the model can
+// work through one by one, e.g. "foo.go line 40: use the existing helper".
+func formatRevisionRequest(comments []diffLineComment, instructions string) string {
+	var sb strings.Builder
+	sb.WriteString("Revise the proposed changes according to these line comments. Address every comment, change only what each one asks for, and keep the rest of the changes as they are.\n\n")
+	for i, c := range comments {
+		location := fmt.Sprintf("%s line %d", c.Path, c.Line)
+		if c.Side == "old" {
+			location += " (removed line)"
+		}
+		fmt.Fprintf(&sb, "%d. %s: %s\n", i+1, location, c.Body)
+		if c.Code != "" {
+			fmt.Fprintf(&sb, "   > %s\n", c.Code)
+		}
+	}
+	if extra := strings.TrimSpace(instructions); extra != "" {
+		fmt.Fprintf(&sb, "\nAdditional instructions:\n%s\n", extra)
+	}
+	sb.WriteString("\nWhen done, reply with one line per comment number describing how it was resolved.")
+	return sb.String()
+}
+
+// readFileLine returns line n (1-based) of path, or "" if it cannot be read.
+func readFileLine(path string, n int) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for i := 1; scanner.Scan(); i++ {
+		if i == n {
+			return strings.TrimRight(scanner.Text(), "\r")
+		}
+	}
+	return ""
+}
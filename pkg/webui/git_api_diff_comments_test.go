@@ -0,0 +1,81 @@
+package webui
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func postRevisionRequest(t *testing.T, server *ReactWebServer, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/api/git/revision-request", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	server.handleAPIGitRevisionRequest(w, req)
+	return w
+}
+
+func TestHandleAPIGitRevisionRequestFormatsComments(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "foo.go"), "package foo\n\nfunc join(a, b string) string { return a + b }\n")
+
+	server := &ReactWebServer{workspaceRoot: dir}
+	w := postRevisionRequest(t, server, `{
+		"comments": [
+			{"path": "foo.go", "line": 3, "body": "use the existing helper"},
+			{"path": "bar.go", "line": 12, "side": "old", "code": "-\tif x == nil {", "body": "keep this nil check"},
+			{"path": "`+filepath.ToSlash(filepath.Join(dir, "foo.go"))+`", "line": 1, "code": "+package foo", "body": "rename the package"}
+		],
+		"instructions": "Run go test afterwards."
+	}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		Prompt   string `json:"prompt"`
+		Comments int    `json:"comments"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Comments != 3 {
+		t.Fatalf("expected 3 comments, got %d", resp.Comments)
+	}
+
+	for _, want := range []string{
+		"1. bar.go line 12 (removed line): keep this nil check\n   > -\tif x == nil {",
+		"2. foo.go line 1: rename the package\n   > +package foo",
+		"3. foo.go line 3: use the existing helper\n   > func join(a, b string) string { return a + b }",
+		"Additional instructions:\nRun go test afterwards.",
+	} {
+		if !strings.Contains(resp.Prompt, want) {
+			t.Fatalf("expected prompt to contain %q, got:\n%s", want, resp.Prompt)
+		}
+	}
+}
+
+func TestHandleAPIGitRevisionRequestRejectsInvalidComments(t *testing.T) {
+	server := &ReactWebServer{workspaceRoot: t.TempDir()}
+	cases := map[string]string{
+		"no comments":       `{"comments": []}`,
+		"missing body":      `{"comments": [{"path": "a.go", "line": 1, "body": " "}]}`,
+		"bad line":          `{"comments": [{"path": "a.go", "line": 0, "body": "x"}]}`,
+		"outside workspace": `{"comments": [{"path": "../etc/passwd", "line": 1, "body": "x"}]}`,
+		"invalid json":      `{`,
+	}
+	for name, body := range cases {
+		if w := postRevisionRequest(t, server, body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", name, w.Code)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/git/revision-request", nil)
+	w := httptest.NewRecorder()
+	server.handleAPIGitRevisionRequest(w, req)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", w.Code)
+	}
+}
@@ -156,3 +156,32 @@ func TestHandleIndexDoesNotServeAPIPaths(t *testing.T) {
 		t.Fatal("expected API fallback to avoid serving index html")
 	}
 }
+
+func TestHandleAPICapabilities(t *testing.T) {
+	server := NewReactWebServer(nil, events.NewEventBus(), 0)
+
+	rec := httptest.NewRecorder()
+	server.handleAPICapabilities(rec, httptest.NewRequest(http.MethodGet, "/api/capabilities", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	var body struct {
+		SchemaVersion int `json:"schema_version"`
+		Tools         []struct {
+			Name string `json:"name"`
+		} `json:"tools"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if body.SchemaVersion == 0 || len(body.Tools) == 0 {
+		t.Fatalf("unexpected capabilities document: %s", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	server.handleAPICapabilities(rec, httptest.NewRequest(http.MethodPost, "/api/capabilities", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405 for POST, got %d", rec.Code)
+	}
+}
@@ -100,7 +100,9 @@ func NewReactWebServer(agent *agent.Agent, eventBus *events.EventBus, port int)
 		daemonRoot = workspaceRoot
 	}
 
-	providercatalog.RefreshFromRemoteAsync("")
+	if !configuration.IsOffline() {
+		providercatalog.RefreshFromRemoteAsync("")
+	}
 
 	securityPromptMgr := security.NewSecurityPromptManager()
 	security.SetGlobalPromptManager(securityPromptMgr)
@@ -174,6 +176,7 @@ func (ws *ReactWebServer) Start(ctx context.Context) error {
 	mux.HandleFunc("/api/query/stop", ws.handleAPIQueryStop)
 	mux.HandleFunc("/api/stats", ws.handleAPIStats)
 	mux.HandleFunc("/api/providers", ws.handleAPIProviders)
+	mux.HandleFunc("/api/capabilities", ws.handleAPICapabilities)
 	mux.HandleFunc("/api/onboarding/status", ws.handleAPIOnboardingStatus)
 	mux.HandleFunc("/api/onboarding/complete", ws.handleAPIOnboardingComplete)
 	mux.HandleFunc("/api/onboarding/skip", ws.handleAPIOnboardingSkip)
@@ -222,6 +225,7 @@ func (ws *ReactWebServer) Start(ctx context.Context) error {
 	mux.HandleFunc("/api/git/stage-all", ws.handleAPIGitStageAll)
 	mux.HandleFunc("/api/git/unstage-all", ws.handleAPIGitUnstageAll)
 	mux.HandleFunc("/api/git/diff", ws.handleAPIGitDiff)
+	mux.HandleFunc("/api/git/revision-request", ws.handleAPIGitRevisionRequest)
 	mux.HandleFunc("/api/git/branches", ws.handleAPIGitBranches)
 	mux.HandleFunc("/api/git/worktrees", ws.handleAPIGitWorktrees)
 	mux.HandleFunc("/api/git/worktree/create", ws.handleAPIGitWorktreeCreate)
@@ -1,18 +1,43 @@
+import React from 'react';
 import { classifyDiffLine } from '../utils/format';
 import './DiffSurface.css';
 
-function DiffSurface({ diffText }: { diffText: string }) {
+interface DiffSurfaceProps {
+  diffText: string;
+  /** When set, lines for which it returns true get a clickable line number. */
+  isLineCommentable?: (index: number) => boolean;
+  onLineNumberClick?: (index: number) => void;
+  /** Renders content (e.g. a comment thread) directly below a line. */
+  renderBelowLine?: (index: number) => React.ReactNode;
+}
+
+function DiffSurface({ diffText, isLineCommentable, onLineNumberClick, renderBelowLine }: DiffSurfaceProps) {
   if (!diffText) return null;
   const diffLines = diffText.split('\n');
   return (
     <div className="commit-detail-diff-surface">
       {diffLines.map((line, index) => {
         const lineClass = classifyDiffLine(line);
+        const commentable = !!onLineNumberClick && (isLineCommentable ? isLineCommentable(index) : true);
         return (
-          <div key={`${index}-${line}`} className={`commit-detail-diff-line ${lineClass}`}>
-            <span className="commit-detail-diff-line-number">{index + 1}</span>
-            <span className="commit-detail-diff-line-text">{line || ' '}</span>
-          </div>
+          <React.Fragment key={`${index}-${line}`}>
+            <div className={`commit-detail-diff-line ${lineClass}`}>
+              {commentable ? (
+                <button
+                  type="button"
+                  className="commit-detail-diff-line-number commentable"
+                  title="Comment on this line"
+                  onClick={() => onLineNumberClick?.(index)}
+                >
+                  {index + 1}
+                </button>
+              ) : (
+                <span className="commit-detail-diff-line-number">{index + 1}</span>
+              )}
+              <span className="commit-detail-diff-line-text">{line || ' '}</span>
+            </div>
+            {renderBelowLine ? renderBelowLine(index) : null}
+          </React.Fragment>
         );
       })}
     </div>
@@ -1,5 +1,8 @@
-import { GitCompareArrows } from 'lucide-react';
+import { useCallback, useEffect, useMemo, useState } from 'react';
+import { GitCompareArrows, MessageSquarePlus } from 'lucide-react';
 import DiffSurface from './DiffSurface';
+import { ApiService } from '../services/api';
+import { computeDiffAnchors, diffAnchorKey, DiffLineAnchor } from '../utils/diffAnchors';
 
 interface GitDiffResponse {
   message: string;
@@ -20,6 +23,13 @@ interface DiffWorkspaceTabProps {
   onDiffModeChange: (mode: 'combined' | 'staged' | 'unstaged') => void;
   title?: string;
   modeOptions?: Array<'combined' | 'staged' | 'unstaged'>;
+  /** Receives the structured revision request built from line comments. */
+  onSubmitRevision?: (prompt: string) => void;
+}
+
+interface LineComment {
+  anchor: DiffLineAnchor;
+  body: string;
 }
 
 const getDiffText = (diff: GitDiffResponse | null, diffMode: 'combined' | 'staged' | 'unstaged'): string => {
@@ -43,6 +53,7 @@ function DiffWorkspaceTab({
   onDiffModeChange,
   title = 'Git Diff',
   modeOptions,
+  onSubmitRevision,
 }: DiffWorkspaceTabProps): JSX.Element {
   const availableModes =
     modeOptions ||
@@ -53,6 +64,129 @@ function DiffWorkspaceTab({
     });
 
   const diffText = getDiffText(diff, diffMode);
+  const anchors = useMemo(() => computeDiffAnchors(diffText, path), [diffText, path]);
+
+  // Comments are keyed by file position, so they survive diff mode switches
+  // as long as the commented line is still part of the diff.
+  const [comments, setComments] = useState<Record<string, LineComment>>({});
+  const [draftKey, setDraftKey] = useState<string | null>(null);
+  const [draftText, setDraftText] = useState('');
+  const [isSubmitting, setIsSubmitting] = useState(false);
+  const [submitError, setSubmitError] = useState<string | null>(null);
+
+  useEffect(() => {
+    setComments({});
+    setDraftKey(null);
+    setDraftText('');
+    setSubmitError(null);
+  }, [path]);
+
+  const commentCount = Object.keys(comments).length;
+
+  const openDraft = useCallback(
+    (index: number) => {
+      const anchor = anchors[index];
+      if (!anchor) return;
+      const key = diffAnchorKey(anchor);
+      setDraftKey(key);
+      setDraftText(comments[key]?.body || '');
+    },
+    [anchors, comments]
+  );
+
+  const saveDraft = useCallback(
+    (anchor: DiffLineAnchor) => {
+      const body = draftText.trim();
+      const key = diffAnchorKey(anchor);
+      setComments((prev) => {
+        const next = { ...prev };
+        if (body) {
+          next[key] = { anchor, body };
+        } else {
+          delete next[key];
+        }
+        return next;
+      });
+      setDraftKey(null);
+      setDraftText('');
+    },
+    [draftText]
+  );
+
+  const deleteComment = useCallback((key: string) => {
+    setComments((prev) => {
+      const next = { ...prev };
+      delete next[key];
+      return next;
+    });
+  }, []);
+
+  const submitComments = useCallback(async () => {
+    if (!onSubmitRevision || commentCount === 0) return;
+    setIsSubmitting(true);
+    setSubmitError(null);
+    try {
+      const payload = Object.values(comments).map(({ anchor, body }) => ({
+        path: anchor.path,
+        line: anchor.line,
+        side: anchor.side,
+        code: anchor.code,
+        body,
+      }));
+      const { prompt } = await ApiService.getInstance().buildRevisionRequest(payload);
+      onSubmitRevision(prompt);
+      setComments({});
+    } catch (err) {
+      setSubmitError(err instanceof Error ? err.message : String(err));
+    } finally {
+      setIsSubmitting(false);
+    }
+  }, [comments, commentCount, onSubmitRevision]);
+
+  const renderBelowLine = (index: number) => {
+    const anchor = anchors[index];
+    if (!anchor) return null;
+    const key = diffAnchorKey(anchor);
+    if (draftKey === key) {
+      return (
+        <div className="diff-line-comment">
+          <textarea
+            autoFocus
+            value={draftText}
+            placeholder={`Comment on ${anchor.path} line ${anchor.line}`}
+            onChange={(e) => setDraftText(e.target.value)}
+            onKeyDown={(e) => {
+              if (e.key === 'Enter' && (e.metaKey || e.ctrlKey)) saveDraft(anchor);
+              if (e.key === 'Escape') setDraftKey(null);
+            }}
+          />
+          <div className="diff-line-comment-actions">
+            <button className="workspace-diff-mode-tab" onClick={() => setDraftKey(null)}>
+              Cancel
+            </button>
+            <button className="workspace-diff-mode-tab active" onClick={() => saveDraft(anchor)}>
+              Save
+            </button>
+          </div>
+        </div>
+      );
+    }
+    const comment = comments[key];
+    if (!comment) return null;
+    return (
+      <div className="diff-line-comment">
+        <div className="diff-line-comment-body">{comment.body}</div>
+        <div className="diff-line-comment-actions">
+          <button className="workspace-diff-mode-tab" onClick={() => openDraft(index)}>
+            Edit
+          </button>
+          <button className="workspace-diff-mode-tab" onClick={() => deleteComment(key)}>
+            Delete
+          </button>
+        </div>
+      </div>
+    );
+  };
 
   return (
     <div className="workspace-tab workspace-diff-tab">
@@ -76,6 +210,26 @@ function DiffWorkspaceTab({
         ) : null}
       </div>
 
+      {onSubmitRevision && (commentCount > 0 || submitError) ? (
+        <div className="workspace-diff-comment-bar">
+          <MessageSquarePlus size={14} />
+          <span>
+            {commentCount} line comment{commentCount === 1 ? '' : 's'}
+          </span>
+          {submitError ? <span className="workspace-tab-error">{submitError}</span> : null}
+          <button className="workspace-diff-mode-tab" onClick={() => setComments({})} disabled={isSubmitting}>
+            Discard
+          </button>
+          <button
+            className="workspace-diff-mode-tab active"
+            onClick={submitComments}
+            disabled={isSubmitting || commentCount === 0}
+          >
+            {isSubmitting ? 'Sending…' : 'Request revision'}
+          </button>
+        </div>
+      ) : null}
+
       {isLoading ? (
         <div className="workspace-tab-empty">
           <GitCompareArrows size={28} />
@@ -87,7 +241,12 @@ function DiffWorkspaceTab({
           <p>{error}</p>
         </div>
       ) : diffText ? (
-        <DiffSurface diffText={diffText} />
+        <DiffSurface
+          diffText={diffText}
+          isLineCommentable={(index) => !!anchors[index]}
+          onLineNumberClick={onSubmitRevision ? openDraft : undefined}
+          renderBelowLine={onSubmitRevision ? renderBelowLine : undefined}
+        />
       ) : (
         <div className="workspace-tab-empty">
           <p>(no diff available)</p>
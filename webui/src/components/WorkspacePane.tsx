@@ -104,6 +104,7 @@ const WorkspacePane: React.FC<WorkspacePaneProps> = ({ paneId, chatProps, review
           onDiffModeChange={diffState.onDiffModeChange}
           title={buffer.metadata?.title as string | undefined}
           modeOptions={buffer.metadata?.modeOptions as any}
+          onSubmitRevision={chatProps.isProcessing ? chatProps.onQueueMessage : chatProps.onSendMessage}
         />
       );
     }
@@ -1222,6 +1222,31 @@ class ApiService {
     }
   }
 
+  async buildRevisionRequest(
+    comments: Array<{ path: string; line: number; side: 'new' | 'old'; code?: string; body: string }>,
+    instructions?: string
+  ): Promise<{
+    message: string;
+    prompt: string;
+    comments: number;
+  }> {
+    try {
+      const response = await clientFetch('/api/git/revision-request', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ comments, instructions: instructions || '' }),
+      });
+      if (!response.ok) {
+        const text = await response.text();
+        throw new Error(text || `HTTP error! status: ${response.status}`);
+      }
+      return await response.json();
+    } catch (error) {
+      console.error('Failed to build revision request:', error);
+      throw error;
+    }
+  }
+
   async getGitDiff(path: string): Promise<{
     message: string;
     path: string;
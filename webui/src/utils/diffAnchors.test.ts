@@ -0,0 +1,51 @@
+// @ts-nocheck
+
+import { computeDiffAnchors, diffAnchorKey } from './diffAnchors';
+
+const diff = [
+  'diff --git a/pkg/foo.go b/pkg/foo.go',
+  '--- a/pkg/foo.go',
+  '+++ b/pkg/foo.go',
+  '@@ -10,3 +10,4 @@ func main() {',
+  ' \tctx := context.Background()',
+  '-\tresult := join(a, b)',
+  '+\tresult := strings.Join(parts, ",")',
+  '+\tlog.Println(result)',
+  ' \treturn result',
+  '\\ No newline at end of file',
+].join('\n');
+
+describe('computeDiffAnchors', () => {
+  it('maps hunk lines to old and new file positions', () => {
+    const anchors = computeDiffAnchors(diff, 'fallback.go');
+
+    expect(anchors.slice(0, 4)).toEqual([null, null, null, null]);
+    expect(anchors[4]).toMatchObject({ path: 'pkg/foo.go', line: 10, side: 'new' });
+    expect(anchors[5]).toMatchObject({ path: 'pkg/foo.go', line: 11, side: 'old', code: '-\tresult := join(a, b)' });
+    expect(anchors[6]).toMatchObject({ line: 11, side: 'new' });
+    expect(anchors[7]).toMatchObject({ line: 12, side: 'new' });
+    expect(anchors[8]).toMatchObject({ line: 13, side: 'new' });
+    expect(anchors[9]).toBeNull();
+  });
+
+  it('uses the fallback path when the diff has no file header', () => {
+    const anchors = computeDiffAnchors('@@ -1 +1 @@\n-old\n+new', 'notes.txt');
+    expect(anchors[1]).toEqual({ path: 'notes.txt', line: 1, side: 'old', code: '-old' });
+    expect(anchors[2]).toEqual({ path: 'notes.txt', line: 1, side: 'new', code: '+new' });
+  });
+
+  it('ends a hunk at non-diff lines such as section banners', () => {
+    const anchors = computeDiffAnchors('@@ -1 +1 @@\n+a\nUnstaged changes:\n+b', 'x.go');
+    expect(anchors[1]).not.toBeNull();
+    expect(anchors[2]).toBeNull();
+    expect(anchors[3]).toBeNull();
+  });
+});
+
+describe('diffAnchorKey', () => {
+  it('distinguishes sides of the same line number', () => {
+    const oldKey = diffAnchorKey({ path: 'a.go', line: 3, side: 'old', code: '-x' });
+    const newKey = diffAnchorKey({ path: 'a.go', line: 3, side: 'new', code: '+x' });
+    expect(oldKey).not.toEqual(newKey);
+  });
+});
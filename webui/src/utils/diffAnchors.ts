@@ -0,0 +1,82 @@
+/**
+ * Maps rendered diff lines to file positions so review comments can be
+ * anchored to "line 40 of foo.go" rather than to a line of the diff text.
+ */
+export interface DiffLineAnchor {
+  path: string;
+  line: number; // 1-based line number on `side`
+  side: 'new' | 'old'; // 'old' for removed lines
+  code: string; // the diff line, including its +/-/space prefix
+}
+
+export function diffAnchorKey(anchor: DiffLineAnchor): string {
+  return `${anchor.path}:${anchor.side}:${anchor.line}`;
+}
+
+/**
+ * Returns one entry per line of diffText: an anchor for added, removed and
+ * context lines inside a hunk, and null for headers and other lines.
+ * fallbackPath is used until a "+++ b/<path>" header names the file.
+ */
+export function computeDiffAnchors(diffText: string, fallbackPath: string): Array<DiffLineAnchor | null> {
+  const lines = diffText.split('\n');
+  const anchors: Array<DiffLineAnchor | null> = [];
+  let path = fallbackPath;
+  let inHunk = false;
+  let oldLine = 0;
+  let newLine = 0;
+
+  for (const line of lines) {
+    if (line.startsWith('diff ')) {
+      inHunk = false;
+      anchors.push(null);
+      continue;
+    }
+    if (line.startsWith('+++ ')) {
+      const target = line.slice(4).trim();
+      if (target !== '/dev/null') {
+        path = target.replace(/^b\//, '');
+      }
+      inHunk = false;
+      anchors.push(null);
+      continue;
+    }
+    if (line.startsWith('--- ')) {
+      inHunk = false;
+      anchors.push(null);
+      continue;
+    }
+
+    const hunk = line.match(/^@@ -(\d+)(?:,\d+)? \+(\d+)(?:,\d+)? @@/);
+    if (hunk) {
+      oldLine = parseInt(hunk[1], 10);
+      newLine = parseInt(hunk[2], 10);
+      inHunk = true;
+      anchors.push(null);
+      continue;
+    }
+
+    if (!inHunk || line.startsWith('\\')) {
+      anchors.push(null);
+      continue;
+    }
+
+    if (line.startsWith('+')) {
+      anchors.push({ path, line: newLine, side: 'new', code: line });
+      newLine++;
+    } else if (line.startsWith('-')) {
+      anchors.push({ path, line: oldLine, side: 'old', code: line });
+      oldLine++;
+    } else if (line.startsWith(' ') || line === '') {
+      anchors.push({ path, line: newLine, side: 'new', code: line });
+      oldLine++;
+      newLine++;
+    } else {
+      // Anything else (e.g. the "Unstaged changes" banner) ends the hunk.
+      inHunk = false;
+      anchors.push(null);
+    }
+  }
+
+  return anchors;
+}